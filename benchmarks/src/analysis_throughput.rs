@@ -0,0 +1,139 @@
+//! End-to-end analysis throughput, with profiles of a representative run.
+//!
+//! Measures files analyzed per second over a synthetic multi-language project,
+//! then repeats one run inside a [`ProfileSession`] (the API behind
+//! `analyze --cpuprofile/--memprofile`) and writes the profiles to
+//! `target/profiles/` so regressions can be inspected with `pprof`.
+//!
+//! ```bash
+//! cargo bench --features benchmarks,profiling --bench analysis_throughput
+//! go tool pprof -top target/profiles/analysis.cpu.pb
+//! ```
+
+use std::fs;
+use std::path::{Path, PathBuf};
+
+use criterion::{criterion_group, criterion_main, BenchmarkId, Criterion, Throughput};
+use tempfile::TempDir;
+use tokio::runtime::Runtime;
+use valknut_rs::api::config_types::AnalysisConfig;
+use valknut_rs::api::engine::ValknutEngine;
+use valknut_rs::core::profiling::{ProfileSession, ProfilingOptions, HEAP_PROFILING_AVAILABLE};
+
+/// Write `modules` Python and Go files with branchy, partially duplicated code.
+fn create_project(modules: usize) -> TempDir {
+    let project = TempDir::new().expect("create temp project");
+    for i in 0..modules {
+        let dir = project.path().join(format!("pkg{}", i % 8));
+        fs::create_dir_all(&dir).expect("create package dir");
+        fs::write(
+            dir.join(format!("module_{i}.py")),
+            format!(
+                r#"
+def process_{i}(items, limit):
+    total = 0
+    for item in items:
+        if item > limit:
+            total += item * 2
+        elif item % 2 == 0:
+            total -= item
+        else:
+            total += 1
+    return total
+
+class Worker{i}:
+    def __init__(self, queue):
+        self.queue = queue
+
+    def drain(self):
+        while self.queue:
+            value = self.queue.pop()
+            if value is None:
+                break
+"#
+            ),
+        )
+        .expect("write python module");
+        fs::write(
+            dir.join(format!("module_{i}.go")),
+            format!(
+                r#"package pkg
+
+func Scale{i}(values []int, factor int) int {{
+	sum := 0
+	for _, v := range values {{
+		switch {{
+		case v < 0:
+			sum -= v * factor
+		case v > 100:
+			sum += factor
+		default:
+			sum += v
+		}}
+	}}
+	return sum
+}}
+"#
+            ),
+        )
+        .expect("write go module");
+    }
+    project
+}
+
+/// Analysis configuration shared by every run.
+fn analysis_config() -> AnalysisConfig {
+    AnalysisConfig::new().languages(|mut languages| {
+        languages.enabled = vec!["python".to_string(), "go".to_string()];
+        languages
+    })
+}
+
+/// Run one full analysis of `root`.
+async fn analyze(root: &Path) -> usize {
+    let mut engine = ValknutEngine::new(analysis_config())
+        .await
+        .expect("create engine");
+    let results = engine.analyze_directory(root).await.expect("analysis");
+    results.files_analyzed()
+}
+
+fn bench_analysis_throughput(c: &mut Criterion) {
+    let runtime = Runtime::new().expect("tokio runtime");
+    let mut group = c.benchmark_group("analysis_throughput");
+    group.sample_size(10);
+
+    for modules in [25, 100] {
+        let project = create_project(modules);
+        group.throughput(Throughput::Elements((modules * 2) as u64));
+        group.bench_with_input(
+            BenchmarkId::from_parameter(modules * 2),
+            &project,
+            |b, p| b.iter(|| runtime.block_on(analyze(p.path()))),
+        );
+    }
+    group.finish();
+
+    profile_representative_run(&runtime);
+}
+
+/// Profile one analysis of the larger project and write pprof files.
+fn profile_representative_run(runtime: &Runtime) {
+    let out_dir = PathBuf::from("target/profiles");
+    let project = create_project(100);
+    let options = ProfilingOptions {
+        cpu_profile: Some(out_dir.join("analysis.cpu.pb")),
+        mem_profile: HEAP_PROFILING_AVAILABLE.then(|| out_dir.join("analysis.heap.pb")),
+        ..ProfilingOptions::default()
+    };
+
+    runtime.block_on(async {
+        let session = ProfileSession::start(options).expect("start profilers");
+        analyze(project.path()).await;
+        session.finish().await.expect("write profiles");
+    });
+    println!("Profiles written to {}", out_dir.display());
+}
+
+criterion_group!(benches, bench_analysis_throughput);
+criterion_main!(benches);
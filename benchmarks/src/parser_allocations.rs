@@ -0,0 +1,176 @@
+//! Allocations of the built-in parsers with and without buffer pooling.
+//!
+//! Parses a synthetic tree of Swift, Elixir, Terraform and Protocol Buffers
+//! files through [`PluginRegistry::parse_file`], first with the scratch
+//! buffer pools of `valknut_rs::lang::buffer_pool` enabled and then with
+//! them disabled, and prints the allocations and allocated bytes per file of
+//! each run before timing both with Criterion.
+//!
+//! ```bash
+//! cargo bench --features benchmarks --bench parser_allocations
+//! ```
+
+use std::alloc::{GlobalAlloc, Layout, System};
+use std::fs;
+use std::path::PathBuf;
+use std::sync::atomic::{AtomicUsize, Ordering};
+
+use criterion::{black_box, criterion_group, criterion_main, BenchmarkId, Criterion, Throughput};
+use tempfile::TempDir;
+use valknut_rs::lang::buffer_pool::{SOURCE_BYTES, SOURCE_CHARS};
+use valknut_rs::lang::{PluginConfig, PluginRegistry};
+
+/// System allocator that counts allocations and allocated bytes.
+struct CountingAllocator;
+
+static ALLOCATIONS: AtomicUsize = AtomicUsize::new(0);
+static ALLOCATED_BYTES: AtomicUsize = AtomicUsize::new(0);
+
+unsafe impl GlobalAlloc for CountingAllocator {
+    unsafe fn alloc(&self, layout: Layout) -> *mut u8 {
+        ALLOCATIONS.fetch_add(1, Ordering::Relaxed);
+        ALLOCATED_BYTES.fetch_add(layout.size(), Ordering::Relaxed);
+        System.alloc(layout)
+    }
+
+    unsafe fn dealloc(&self, ptr: *mut u8, layout: Layout) {
+        System.dealloc(ptr, layout)
+    }
+
+    unsafe fn realloc(&self, ptr: *mut u8, layout: Layout, new_size: usize) -> *mut u8 {
+        ALLOCATIONS.fetch_add(1, Ordering::Relaxed);
+        ALLOCATED_BYTES.fetch_add(new_size, Ordering::Relaxed);
+        System.realloc(ptr, layout, new_size)
+    }
+}
+
+#[global_allocator]
+static GLOBAL: CountingAllocator = CountingAllocator;
+
+/// Write `modules` files for each built-in parser.
+fn create_project(modules: usize) -> (TempDir, Vec<PathBuf>) {
+    let project = TempDir::new().expect("create temp project");
+    let mut files = Vec::new();
+    for i in 0..modules {
+        let sources = [
+            (
+                format!("Store{i}.swift"),
+                format!(
+                    r#"import Foundation
+
+public final class Store{i}: ObservableObject {{
+    @Published private(set) var items: [Int] = []
+
+    public func load(id: Int) async throws -> Int {{
+        let value = try await fetch(id)
+        items.append(value)
+        return value
+    }}
+}}
+
+extension Store{i}: Identifiable {{
+    var count: Int {{ items.count }}
+}}
+"#
+                ),
+            ),
+            (
+                format!("accounts_{i}.ex"),
+                format!(
+                    r#"defmodule Shop.Accounts{i} do
+  alias Shop.Repo
+
+  @spec fetch(integer()) :: {{:ok, map()}} | :error
+  def fetch(id) when is_integer(id), do: Repo.get(id)
+  def fetch(_), do: :error
+end
+"#
+                ),
+            ),
+            (
+                format!("bucket_{i}.tf"),
+                format!(
+                    r#"resource "aws_s3_bucket" "logs_{i}" {{
+  bucket = "logs-{i}-${{var.env}}"
+  depends_on = [aws_iam_role.writer]
+}}
+"#
+                ),
+            ),
+            (
+                format!("order_{i}.proto"),
+                format!(
+                    r#"syntax = "proto3";
+package shop.v{i};
+
+message Order {{
+  string id = 1;
+  repeated Item items = 2;
+  message Item {{ string sku = 1; int32 quantity = 2; }}
+}}
+"#
+                ),
+            ),
+        ];
+        for (name, source) in sources {
+            let path = project.path().join(name);
+            fs::write(&path, source).expect("write fixture file");
+            files.push(path);
+        }
+    }
+    (project, files)
+}
+
+/// Parse every file once.
+fn parse_all(registry: &PluginRegistry, files: &[PathBuf]) {
+    for file in files {
+        black_box(registry.parse_file(file).expect("parse fixture file"));
+    }
+}
+
+/// Allocations and bytes allocated per file while parsing `files` once.
+fn allocations_per_file(registry: &PluginRegistry, files: &[PathBuf]) -> (f64, f64) {
+    // Warm the pools (and any lazily initialized state) first.
+    parse_all(registry, files);
+
+    let allocations = ALLOCATIONS.load(Ordering::Relaxed);
+    let bytes = ALLOCATED_BYTES.load(Ordering::Relaxed);
+    parse_all(registry, files);
+    let allocations = ALLOCATIONS.load(Ordering::Relaxed) - allocations;
+    let bytes = ALLOCATED_BYTES.load(Ordering::Relaxed) - bytes;
+    (
+        allocations as f64 / files.len() as f64,
+        bytes as f64 / files.len() as f64,
+    )
+}
+
+fn set_pooling(enabled: bool) {
+    SOURCE_BYTES.set_enabled(enabled);
+    SOURCE_CHARS.set_enabled(enabled);
+}
+
+fn bench_parser_allocations(c: &mut Criterion) {
+    let config = PluginConfig::default();
+    let registry = PluginRegistry::with_builtins(&config);
+    let (_project, files) = create_project(50);
+
+    for (label, enabled) in [("pooled", true), ("unpooled", false)] {
+        set_pooling(enabled);
+        let (allocations, bytes) = allocations_per_file(&registry, &files);
+        println!("{label:>8}: {allocations:.1} allocations, {bytes:.0} bytes allocated per file");
+    }
+
+    let mut group = c.benchmark_group("parser_allocations");
+    group.throughput(Throughput::Elements(files.len() as u64));
+    for (label, enabled) in [("pooled", true), ("unpooled", false)] {
+        set_pooling(enabled);
+        group.bench_with_input(BenchmarkId::from_parameter(label), &files, |b, files| {
+            b.iter(|| parse_all(&registry, files))
+        });
+    }
+    group.finish();
+    set_pooling(true);
+}
+
+criterion_group!(benches, bench_parser_allocations);
+criterion_main!(benches);
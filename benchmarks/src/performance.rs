@@ -4,9 +4,14 @@
 //! including SIMD-accelerated computations, parallel processing, and memory optimization.
 
 use criterion::{black_box, criterion_group, criterion_main, BenchmarkId, Criterion};
+use std::fs;
 use std::hint::black_box as std_black_box;
+use std::path::PathBuf;
+use tempfile::TempDir;
 use valknut_rs::core::{
     bayesian::BayesianNormalizer,
+    concurrency::ConcurrentAnalyzer,
+    dependency::TypeGraph,
     featureset::FeatureVector,
     pipeline::{AnalysisConfig, AnalysisPipeline},
 };
@@ -69,6 +74,43 @@ class Class_{}:
         .collect()
 }
 
+/// Generate a Go project whose packages hold, call and implement types of their neighbours
+fn generate_go_project(packages: usize) -> (TempDir, Vec<PathBuf>) {
+    let project = TempDir::new().expect("create temp project");
+    let mut files = Vec::new();
+    for i in 0..packages {
+        let prev = (i + packages - 1) % packages;
+        let mut source = format!("package pkg{i}\n\nimport \"example.com/app/pkg{prev}\"\n");
+        for t in 0..20 {
+            source.push_str(&format!(
+                r#"
+type Store{t} interface {{
+	Get{t}(key string) string
+}}
+
+type Memory{t} struct {{
+	next *pkg{prev}.Memory{t}
+	store pkg{prev}.Store{t}
+}}
+
+func (m *Memory{t}) Get{t}(key string) string {{
+	return m.store.Get{t}(key)
+}}
+
+func NewMemory{t}() *Memory{t} {{
+	return &Memory{t}{{next: pkg{prev}.NewMemory{t}()}}
+}}
+"#
+            ));
+        }
+        let path = project.path().join(format!("pkg{i}/types.go"));
+        fs::create_dir_all(path.parent().unwrap()).expect("create package dir");
+        fs::write(&path, source).expect("write package");
+        files.push(path);
+    }
+    (project, files)
+}
+
 /// Benchmark Bayesian normalization performance
 fn benchmark_bayesian_normalization(c: &mut Criterion) {
     let mut group = c.benchmark_group("bayesian_normalization");
@@ -265,6 +307,60 @@ fn benchmark_pipeline_performance(c: &mut Criterion) {
     group.finish();
 }
 
+/// Benchmark how the bounded worker pool scales with worker count on CPU-bound work
+fn benchmark_worker_pool_scaling(c: &mut Criterion) {
+    let mut group = c.benchmark_group("worker_pool_scaling");
+    group.sample_size(10);
+
+    let runtime = tokio::runtime::Builder::new_multi_thread()
+        .worker_threads(8)
+        .enable_all()
+        .build()
+        .unwrap();
+    let sources = generate_test_code(64);
+
+    for workers in [1, 2, 4, 8] {
+        let pool = ConcurrentAnalyzer::with_workers(workers);
+        group.bench_with_input(BenchmarkId::new("workers", workers), &workers, |b, _| {
+            b.iter(|| {
+                let run = runtime.block_on(pool.run(sources.clone(), |source| async move {
+                    // Simulate per-file parsing cost with repeated hashing
+                    (0..200).fold(0u64, |acc, round| {
+                        source.bytes().fold(acc ^ round, |h, b| {
+                            h.wrapping_mul(31).wrapping_add(b as u64)
+                        })
+                    })
+                }));
+                std_black_box(run.results);
+            });
+        });
+    }
+
+    group.finish();
+}
+
+/// Benchmark type graph construction, whose reference resolution runs on the rayon pool
+fn benchmark_type_resolution(c: &mut Criterion) {
+    let mut group = c.benchmark_group("type_resolution");
+    group.sample_size(10);
+
+    let (project, files) = generate_go_project(100);
+    for threads in [1, 2, 4, 8] {
+        let pool = rayon::ThreadPoolBuilder::new()
+            .num_threads(threads)
+            .build()
+            .unwrap();
+        group.bench_with_input(BenchmarkId::new("threads", threads), &threads, |b, _| {
+            b.iter(|| {
+                let graph = pool.install(|| TypeGraph::from_files(project.path(), &files));
+                std_black_box(graph.unwrap().edges.len());
+            });
+        });
+    }
+
+    group.finish();
+}
+
 /// Benchmark memory allocation patterns
 fn benchmark_memory_optimization(c: &mut Criterion) {
     let mut group = c.benchmark_group("memory_optimization");
@@ -372,6 +468,8 @@ criterion_group!(
     benchmark_bayesian_normalization,
     benchmark_lsh_minhash,
     benchmark_pipeline_performance,
+    benchmark_worker_pool_scaling,
+    benchmark_type_resolution,
     benchmark_memory_optimization,
 );
 
@@ -0,0 +1,15 @@
+//! Build script: compiles the gRPC service definitions under `proto/`.
+//!
+//! The schema is parsed with `protox`, a pure-Rust protobuf compiler, so
+//! building valknut does not require `protoc` to be installed.
+
+fn main() -> Result<(), Box<dyn std::error::Error>> {
+    println!("cargo:rerun-if-changed=proto");
+
+    let descriptors = protox::compile(["valknut/v1/valknut.proto"], ["proto"])?;
+    tonic_build::configure()
+        .build_server(true)
+        .build_client(true)
+        .compile_fds(descriptors)?;
+    Ok(())
+}
@@ -77,7 +77,7 @@ pub struct FileSettings {
     /// Maximum number of files to analyze (None = unlimited)
     pub max_files: Option<usize>,
 
-    /// Maximum file size in bytes (None = unlimited, default = 500KB)
+    /// Maximum file size in bytes (None = unlimited, default = 1MB)
     /// Files larger than this are skipped during analysis
     pub max_file_size_bytes: Option<u64>,
 
@@ -185,7 +185,7 @@ impl Default for FileSettings {
                 "*.min.js".to_string(),
             ],
             max_files: None,
-            max_file_size_bytes: Some(500 * 1024), // 500KB default
+            max_file_size_bytes: Some(1024 * 1024), // 1MB default
             follow_symlinks: false,
         }
     }
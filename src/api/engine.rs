@@ -1,5 +1,6 @@
 //! Main analysis engine implementation.
 
+use std::collections::BTreeMap;
 use std::path::{Path, PathBuf};
 use std::sync::Arc;
 
@@ -11,6 +12,8 @@ use crate::core::errors::{Result, ValknutError};
 use crate::core::featureset::FeatureVector;
 use crate::core::pipeline::AnalysisResults;
 use crate::core::pipeline::{AnalysisConfig as PipelineAnalysisConfig, AnalysisPipeline};
+use crate::core::scoring::{ComplexityBaselines, FileComplexity};
+use crate::detectors::rules::{RuleEngine, RuleFinding};
 
 /// Compute the common root directory from a list of paths.
 /// Returns the longest common prefix that ends at a directory boundary.
@@ -125,11 +128,19 @@ impl ValknutEngine {
         }
 
         // Run the pipeline
-        let pipeline_results = self.pipeline.analyze_directory(path).await?;
+        let mut pipeline_results = self.pipeline.analyze_directory(path).await?;
+        let redactions = std::mem::take(&mut pipeline_results.results.redactions);
 
         // Convert to public API format with the directory as project root
         let project_root = path.canonicalize().unwrap_or_else(|_| path.to_path_buf());
-        let results = AnalysisResults::from_pipeline_results(pipeline_results, project_root);
+        let mut results = AnalysisResults::from_pipeline_results(pipeline_results, project_root);
+        results.file_complexity = self.file_complexity(&results);
+        if !self.config.analysis.include_tests {
+            results.exclude_test_context();
+        }
+        results.rule_findings = self.evaluate_rules(&results)?;
+        results.sort_deterministically();
+        let results = redactions.redact(results)?;
 
         info!(
             "Directory analysis completed: {} files processed, {} entities analyzed",
@@ -153,7 +164,7 @@ impl ValknutEngine {
             .map(|file| file.as_ref().to_path_buf())
             .collect();
 
-        let comprehensive = self
+        let mut comprehensive = self
             .pipeline
             .analyze_paths(&paths, None)
             .await
@@ -161,14 +172,39 @@ impl ValknutEngine {
                 ValknutError::pipeline("file_analysis", format!("File analysis failed: {}", err))
             })?;
 
+        let redactions = std::mem::take(&mut comprehensive.redactions);
         let pipeline_results = self.pipeline.wrap_results(comprehensive);
 
         // Compute project root from common prefix of file paths
         let project_root = compute_common_root(&paths);
-        Ok(AnalysisResults::from_pipeline_results(
-            pipeline_results,
-            project_root,
-        ))
+        let mut results = AnalysisResults::from_pipeline_results(pipeline_results, project_root);
+        results.file_complexity = self.file_complexity(&results);
+        if !self.config.analysis.include_tests {
+            results.exclude_test_context();
+        }
+        results.rule_findings = self.evaluate_rules(&results)?;
+        results.sort_deterministically();
+        redactions.redact(results)
+    }
+
+    /// Per-file raw and baseline-corrected complexity, keyed by project-relative path.
+    fn file_complexity(&self, results: &AnalysisResults) -> BTreeMap<String, FileComplexity> {
+        ComplexityBaselines::with_overrides(&self.config.scoring.complexity_baselines)
+            .per_file(&results.passes.complexity.detailed_results)
+            .into_iter()
+            .map(|(path, complexity)| {
+                let relative = Path::new(&path)
+                    .strip_prefix(&results.project_root)
+                    .map(|relative| relative.to_string_lossy().into_owned())
+                    .unwrap_or(path);
+                (relative, complexity)
+            })
+            .collect()
+    }
+
+    /// Evaluate the configured lint rules against converted results.
+    fn evaluate_rules(&self, results: &AnalysisResults) -> Result<Vec<RuleFinding>> {
+        Ok(RuleEngine::from_configs(&self.config.rules)?.evaluate(results))
     }
 
     /// Analyze pre-extracted feature vectors (for testing and advanced usage)
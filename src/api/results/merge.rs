@@ -26,7 +26,8 @@ impl AnalysisResults {
     /// Merges another result into self, modifying self in place.
     ///
     /// Combines all metrics using weighted averaging where appropriate,
-    /// and accumulates collections like candidates and warnings.
+    /// and accumulates collections like candidates and warnings, keeping
+    /// them in canonical order.
     pub fn merge_in_place(&mut self, other: AnalysisResults) {
         let base_files = self.summary.files_processed;
         let base_entities = self.summary.entities_analyzed;
@@ -38,6 +39,7 @@ impl AnalysisResults {
         self.summary.refactoring_needed += other.summary.refactoring_needed;
         self.summary.high_priority += other.summary.high_priority;
         self.summary.critical += other.summary.critical;
+        self.summary.timed_out |= other.summary.timed_out;
 
         self.summary.avg_refactoring_score = weighted_average(
             self.summary.avg_refactoring_score,
@@ -103,6 +105,24 @@ impl AnalysisResults {
 
         self.coverage_packs.extend(other.coverage_packs.into_iter());
         self.warnings.extend(other.warnings.into_iter());
+        self.skipped_files.extend(other.skipped_files.into_iter());
+        self.generated_code.merge(other.generated_code);
+        self.notebook_metadata.extend(other.notebook_metadata);
+        self.duplicates.merge(other.duplicates);
+        self.file_complexity.extend(other.file_complexity);
+        self.rule_findings.extend(other.rule_findings.into_iter());
+        self.dependency_report
+            .extend(other.dependency_report.into_iter());
+        // Health scores do not combine; keep ours and recompute after merging.
+        if self.project_health.is_none() {
+            self.project_health = other.project_health;
+        }
+        if self.interface_report.is_none() {
+            self.interface_report = other.interface_report;
+        }
+        self.context_statistics.merge(&other.context_statistics);
+        self.changed_files_only |= other.changed_files_only;
+        self.sort_deterministically();
     }
 }
 
@@ -281,6 +301,7 @@ mod tests {
             issue_count: 1,
             suggestion_count: 1,
             coverage_percentage: None,
+            context: Default::default(),
         }
     }
 
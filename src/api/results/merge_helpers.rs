@@ -65,6 +65,7 @@ pub fn merge_stage_results(
         } else {
             current.cohesion
         },
+        timed_out: current.timed_out || incoming.timed_out,
     }
 }
 
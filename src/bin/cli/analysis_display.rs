@@ -51,11 +51,87 @@ pub fn display_analysis_summary(result: &AnalysisResults, detailed: bool) {
         summary.avg_refactoring_score
     );
 
+    display_dependency_report(result);
+    display_interface_report(result);
+    display_project_health(result);
+
     if detailed {
         display_detailed_metrics(result);
     }
 }
 
+/// Display outdated and vulnerable Go dependencies from `--check-deps`.
+fn display_dependency_report(result: &AnalysisResults) {
+    let report = &result.dependency_report;
+    if report.is_empty() {
+        return;
+    }
+    let outdated = report.iter().filter(|dep| dep.is_outdated()).count();
+    let vulnerable: Vec<_> = report.iter().filter(|dep| dep.cve_count > 0).collect();
+    println!(
+        "  dependencies {} | outdated {} | vulnerable {}",
+        report.len(),
+        outdated,
+        vulnerable.len()
+    );
+    for dep in vulnerable {
+        println!(
+            "    - {} {} ({}){}",
+            dep.module,
+            dep.current_version,
+            dep.cve_ids.join(", ").red(),
+            dep.latest_version
+                .as_deref()
+                .map(|latest| format!(" latest {latest}"))
+                .unwrap_or_default()
+        );
+    }
+}
+
+/// Display broken and missing Go interface assertions from `--check-interfaces`.
+fn display_interface_report(result: &AnalysisResults) {
+    let Some(report) = result.interface_report.as_ref() else {
+        return;
+    };
+    let implementations: usize = report
+        .interfaces
+        .iter()
+        .map(|entry| entry.implementors.len())
+        .sum();
+    println!(
+        "  interfaces {} | implementations {} | broken assertions {} | suggestions {}",
+        report.interfaces.len(),
+        implementations,
+        report.errors().count(),
+        report.suggestions().count()
+    );
+    for finding in report.errors() {
+        let location = match finding.line {
+            Some(line) => format!("{}:{line}", finding.file),
+            None => finding.file.clone(),
+        };
+        println!("    - {} ({location})", finding.message.red());
+    }
+}
+
+/// Display the `--health-score` aggregate and each of its components.
+fn display_project_health(result: &AnalysisResults) {
+    let Some(health) = result.project_health.as_ref() else {
+        return;
+    };
+    println!("  project health {:.1}/100", health.score);
+    for component in &health.components {
+        let score = component
+            .score
+            .map(|score| format!("{score:.1}"))
+            .unwrap_or_else(|| "n/a".to_string());
+        println!(
+            "    - {} {} (weight {}): {}",
+            component.criterion, score, component.weight, component.detail
+        );
+    }
+}
+
 /// Display detailed metrics when verbose mode is enabled
 fn display_detailed_metrics(result: &AnalysisResults) {
     if let Some(metrics) = result.health_metrics.as_ref() {
@@ -78,10 +154,37 @@ fn display_detailed_metrics(result: &AnalysisResults) {
         }
     }
 
+    display_duplicates(result);
     display_hotspots(result);
     display_warnings(result);
 }
 
+/// Display clone pairs found by `--detect-duplicates`, largest classes first
+fn display_duplicates(result: &AnalysisResults) {
+    if result.duplicates.is_empty() {
+        return;
+    }
+    println!(
+        "  duplicates: {} clone classes, {} pairs",
+        result.duplicates.classes.len(),
+        result.duplicates.pair_count()
+    );
+    for class in result.duplicates.classes.iter().take(3) {
+        for (first, second) in class.pairs() {
+            println!(
+                "    - {} @ {}:{} == {} @ {}:{} ({} nodes)",
+                first.name,
+                first.path.display(),
+                first.start_line,
+                second.name,
+                second.path.display(),
+                second.start_line,
+                class.node_count
+            );
+        }
+    }
+}
+
 /// Display top hotspots from analysis
 fn display_hotspots(result: &AnalysisResults) {
     let mut hotspots: Vec<&RefactoringCandidate> = result
@@ -5,6 +5,13 @@
 
 use clap::{Args, Parser, Subcommand, ValueEnum};
 use std::path::PathBuf;
+use valknut_rs::core::concurrency::ConcurrencyLimit;
+use valknut_rs::core::config::byte_size::parse_byte_size;
+use valknut_rs::core::config::duration::parse_duration;
+use valknut_rs::core::symbol_filter::{SymbolFilter, DEFAULT_CHANGED_BASE};
+use valknut_rs::core::token_budget::TrimStrategy;
+
+use crate::cli::output::FieldFilter;
 
 const VERSION: &str = env!("CARGO_PKG_VERSION");
 
@@ -24,8 +31,15 @@ Common Usage:
   valknut analyze --profile thorough --quality-gate --fail-on-issues
   valknut analyze --coverage-file coverage/lcov.info
   valknut doc-audit --root . --strict            # audit READMEs and docs
+  valknut init                                   # scaffold valknut.toml for this project
   valknut init-config --output valknut.yml       # write a starter config
   valknut validate-config --config valknut.yml   # verify config before CI
+  valknut config validate                        # check valknut.toml / .valknut.yaml flags
+  valknut migrate --dry-run                      # preview upgrading valknut.toml to the current schema
+  valknut doctor                                 # preflight checks for common setup problems
+  valknut validate out/analysis.json             # check output against the current schema
+  valknut openapi --format yaml -o openapi.yaml  # partial spec from Go HTTP routes
+  valknut review --base main                     # review context for the current branch
   valknut list-languages                         # supported languages
   valknut mcp-stdio                              # run MCP server for editors
 
@@ -40,6 +54,14 @@ pub struct Cli {
     #[arg(short, long, global = true)]
     pub verbose: bool,
 
+    /// Minimum level of log messages written to stderr (default: info, or debug with --verbose)
+    #[arg(long, global = true, value_enum)]
+    pub log_level: Option<LogLevel>,
+
+    /// Format of log messages written to stderr
+    #[arg(long, global = true, value_enum, default_value = "text")]
+    pub log_format: LogFormat,
+
     /// Toggle usage analytics collection (off by default for privacy)
     #[arg(long, global = true)]
     pub survey: bool,
@@ -59,6 +81,9 @@ pub enum Commands {
     #[command(name = "print-default-config")]
     PrintDefaultConfig,
 
+    /// Detect languages in a directory and scaffold (or merge into) valknut.toml
+    Init(InitArgs),
+
     /// Initialize a configuration file with defaults
     #[command(name = "init-config")]
     InitConfig(InitConfigArgs),
@@ -67,6 +92,24 @@ pub enum Commands {
     #[command(name = "validate-config")]
     ValidateConfig(ValidateConfigArgs),
 
+    /// Check analysis output files against the current output schema
+    Validate(ValidateArgs),
+
+    /// Enforce the structural rules in valknut.toml, exiting non-zero on violations
+    Check(CheckArgs),
+
+    /// Report rule violations and fix the safe ones (trailing newlines, comment case, blank lines)
+    Lint(LintArgs),
+
+    /// Manage CLI flag files (valknut.toml / .valknut.yaml)
+    Config(ConfigArgs),
+
+    /// Upgrade valknut.toml / .valknut.yaml to the current config-version, keeping a backup
+    Migrate(MigrateArgs),
+
+    /// Check the repository, config, cache directory, Go toolchain and parsers for setup problems
+    Doctor(DoctorArgs),
+
     /// Run MCP server over stdio (for Claude Code integration)
     #[command(name = "mcp-stdio")]
     McpStdio(McpStdioArgs),
@@ -75,6 +118,9 @@ pub enum Commands {
     #[command(name = "mcp-manifest")]
     McpManifest(McpManifestArgs),
 
+    /// Register the MCP server with Claude Desktop
+    Mcp(McpArgs),
+
     /// List supported programming languages and their status
     #[command(name = "list-languages")]
     ListLanguages,
@@ -82,6 +128,57 @@ pub enum Commands {
     /// Audit documentation coverage and README freshness
     #[command(name = "doc-audit")]
     DocAudit(DocAuditArgs),
+
+    /// Re-analyze files as they change and stream updates over a Unix socket
+    Watch(WatchArgs),
+
+    /// Find every reference to a symbol by name
+    Xref(XrefArgs),
+
+    /// Report structural changes between two analysis snapshots
+    Diff(DiffArgs),
+
+    /// Compare the structure of two repositories: packages, signatures, types and dependencies
+    Compare(CompareArgs),
+
+    /// Save analysis snapshots under a tag, list them, or prune old ones
+    Archive(ArchiveArgs),
+
+    /// Summarise repository metrics from the incremental analysis cache
+    Stats(StatsArgs),
+
+    /// Export a shareable summary of a project (packages, symbols, dependencies)
+    Export(ExportArgs),
+
+    /// Plan which files fit an LLM context window, ranked by relevance, references and recency
+    #[command(name = "token-budget")]
+    TokenBudget(TokenBudgetArgs),
+
+    /// Extract a partial OpenAPI spec from the HTTP routes of a Go service
+    Openapi(OpenApiArgs),
+
+    /// Browse cached analysis results in an interactive terminal UI
+    Tui(TuiArgs),
+
+    /// Rewrite the doc comments valknut reads into their canonical form
+    Fmt(FmtArgs),
+
+    /// Show which developer last modified each symbol, from git history
+    Blame(BlameArgs),
+
+    /// Summarise the changes since a base branch for code review
+    Review(ReviewArgs),
+
+    /// Serve the valknut.v1 gRPC API and/or the JSON REST API
+    #[command(alias = "server")]
+    Serve(ServeArgs),
+
+    /// Interactive client for exercising a running gRPC server
+    #[command(name = "grpc-client")]
+    GrpcClient(GrpcClientArgs),
+
+    /// Inspect external language parser plugins
+    Plugins(PluginsArgs),
 }
 
 /// Quality gate configuration for CI/CD integration
@@ -99,6 +196,10 @@ pub struct QualityGateArgs {
     #[arg(long)]
     pub max_complexity: Option<f64>,
 
+    /// Fail if any function's cyclomatic complexity exceeds N (applies without --quality-gate)
+    #[arg(long, value_name = "N")]
+    pub max_function_complexity: Option<u32>,
+
     /// Minimum required health score (0-100, higher is better) [default: 60]
     #[arg(long)]
     pub min_health: Option<f64>,
@@ -162,6 +263,14 @@ pub struct CloneDetectionArgs {
     /// Dry-run mode - analyze but don't change behavior (for testing)
     #[arg(long)]
     pub denoise_dry_run: bool,
+
+    /// Group functions identical after normalizing names, literals and comments into clone classes
+    #[arg(long)]
+    pub detect_duplicates: bool,
+
+    /// Minimum function size in normalized AST nodes for --detect-duplicates (default: 50)
+    #[arg(long, value_name = "NODES")]
+    pub min_clone_nodes: Option<usize>,
 }
 
 /// Advanced clone detection tuning (rarely needed - use config file instead)
@@ -245,6 +354,516 @@ pub enum DocAuditFormat {
     Json,
 }
 
+/// Watch mode configuration
+#[derive(Args, Clone, Debug)]
+pub struct WatchArgs {
+    /// Directory to watch
+    #[arg(default_value = ".")]
+    pub path: PathBuf,
+
+    /// Configuration file
+    #[arg(short, long)]
+    pub config: Option<PathBuf>,
+
+    /// Quiet period after the last change before re-analysis starts, in milliseconds
+    #[arg(long, value_name = "MS", default_value_t = 200)]
+    pub debounce_ms: u64,
+
+    /// Unix socket to publish updates on (default: valknut/watch-<hash>.sock under the
+    /// runtime directory, or the cache directory where there is none)
+    #[arg(long, value_name = "PATH")]
+    pub socket: Option<PathBuf>,
+
+    /// Rescan the tree periodically instead of using filesystem notifications
+    #[arg(long)]
+    pub poll: bool,
+
+    /// Interval between rescans when polling, in milliseconds
+    #[arg(long, value_name = "MS", default_value_t = 1000)]
+    pub poll_interval_ms: u64,
+}
+
+/// gRPC and HTTP server configuration
+#[derive(Args, Clone, Debug)]
+#[group(id = "listeners", args = ["grpc", "http"], required = true, multiple = true)]
+pub struct ServeArgs {
+    /// Address for the gRPC API, e.g. `:50051` or `127.0.0.1:50051`
+    #[arg(long, value_name = "ADDR")]
+    pub grpc: Option<String>,
+
+    /// Address for the JSON REST API, e.g. `:8080` or `127.0.0.1:8080`
+    #[arg(long, value_name = "ADDR")]
+    pub http: Option<String>,
+
+    /// PEM certificate chain for the gRPC API; enables TLS together with --tls-key
+    #[arg(long, value_name = "PATH", requires = "tls_key")]
+    pub tls_cert: Option<PathBuf>,
+
+    /// PEM private key for --tls-cert
+    #[arg(long, value_name = "PATH", requires = "tls_cert")]
+    pub tls_key: Option<PathBuf>,
+
+    /// Configuration file used for analysis requests
+    #[arg(short, long)]
+    pub config: Option<PathBuf>,
+
+    /// Maximum requests processed at once across both APIs; extra requests wait (default: CPU count)
+    #[arg(long, value_name = "N")]
+    pub max_concurrent: Option<usize>,
+
+    /// Maximum HTTP requests per second; requests beyond it get 429 (`/health` is exempt)
+    #[arg(long, value_name = "N", requires = "http", value_parser = clap::value_parser!(u32).range(1..))]
+    pub rate_limit: Option<u32>,
+}
+
+/// gRPC test client options
+#[derive(Args, Clone, Debug)]
+pub struct GrpcClientArgs {
+    /// Server URI (use https:// for TLS)
+    #[arg(default_value = "http://127.0.0.1:50051")]
+    pub addr: String,
+
+    /// PEM CA certificate used to verify the server
+    #[arg(long, value_name = "PATH")]
+    pub ca_cert: Option<PathBuf>,
+
+    /// Expected server name in the TLS certificate (default: host of ADDR)
+    #[arg(long, value_name = "NAME")]
+    pub domain: Option<String>,
+}
+
+/// Cross-reference lookup options
+#[derive(Args, Clone, Debug)]
+pub struct XrefArgs {
+    /// Symbol name to look up (qualifiers such as `pkg.` or `module::` are ignored)
+    pub symbol: String,
+
+    /// Directory or file to search
+    #[arg(long, default_value = ".")]
+    pub path: PathBuf,
+
+    /// Output format for the references
+    #[arg(long, value_enum, default_value = "text")]
+    pub format: XrefFormat,
+}
+
+/// Output formats available for the xref command.
+#[derive(Clone, Copy, Debug, PartialEq, ValueEnum)]
+pub enum XrefFormat {
+    /// One `path:line:column` location per line
+    Text,
+    /// JSON array of references
+    Json,
+}
+
+/// Project export options
+#[derive(Args, Clone, Debug)]
+pub struct ExportArgs {
+    /// Project directory to summarise
+    #[arg(default_value = ".")]
+    pub path: PathBuf,
+
+    /// Document format to produce
+    #[arg(long, value_enum, default_value = "markdown")]
+    pub format: ExportFormat,
+
+    /// Write the document to this file instead of stdout
+    #[arg(short, long, value_name = "FILE")]
+    pub out: Option<PathBuf>,
+
+    /// File listing the paths (relative to PATH, one per line) for the context format; `-` reads stdin
+    #[arg(long, value_name = "FILE", default_value = "-")]
+    pub files_from: PathBuf,
+}
+
+/// Document formats available for the export command.
+#[derive(Clone, Copy, Debug, PartialEq, ValueEnum)]
+pub enum ExportFormat {
+    /// Markdown with a Mermaid dependency diagram and a complexity heatmap
+    Markdown,
+    /// Markdown with the contents of the files listed by --files-from, in order
+    Context,
+}
+
+/// Token budget planning options
+#[derive(Args, Clone, Debug)]
+pub struct TokenBudgetArgs {
+    /// Project directory to plan the context for
+    #[arg(default_value = ".")]
+    pub path: PathBuf,
+
+    /// Model whose tokenizer and context window apply (Claude, GPT/o-series or Gemini)
+    #[arg(long, default_value = "claude-3-5-sonnet")]
+    pub model: String,
+
+    /// Maximum number of tokens to include (default: the model's context window)
+    #[arg(long, value_name = "TOKENS")]
+    pub budget: Option<usize>,
+
+    /// Rank files by relevance to this free-text query first
+    #[arg(long)]
+    pub query: Option<String>,
+
+    /// Output format for the plan
+    #[arg(long, value_enum, default_value = "text")]
+    pub format: TokenBudgetFormat,
+}
+
+/// Output formats available for the token-budget command.
+#[derive(Clone, Copy, Debug, PartialEq, ValueEnum)]
+pub enum TokenBudgetFormat {
+    /// Prioritized table of files with token estimates and the total
+    Text,
+    /// JSON plan with every included and dropped file
+    Json,
+    /// Included paths only, one per line, for `valknut export --format context`
+    Paths,
+}
+
+/// OpenAPI extraction options
+#[derive(Args, Clone, Debug)]
+pub struct OpenApiArgs {
+    /// Go service directory (or single file) to scan for route registrations
+    #[arg(default_value = ".")]
+    pub path: PathBuf,
+
+    /// Document format to produce
+    #[arg(long, value_enum, default_value = "json")]
+    pub format: OpenApiFormat,
+
+    /// Write the document to this file instead of stdout
+    #[arg(short, long, value_name = "FILE")]
+    pub out: Option<PathBuf>,
+
+    /// API title in `info.title` (default: the directory name)
+    #[arg(long)]
+    pub title: Option<String>,
+}
+
+/// Document formats available for the openapi command.
+#[derive(Clone, Copy, Debug, PartialEq, ValueEnum)]
+pub enum OpenApiFormat {
+    /// OpenAPI JSON
+    Json,
+    /// OpenAPI YAML
+    Yaml,
+}
+
+/// Interactive explorer options
+#[derive(Args, Clone, Debug)]
+pub struct TuiArgs {
+    /// Directory to explore
+    #[arg(default_value = ".")]
+    pub path: PathBuf,
+
+    /// Incremental cache directory (default: ~/.cache/valknut)
+    #[arg(long, value_name = "DIR")]
+    pub cache_dir: Option<PathBuf>,
+}
+
+/// Preflight check options
+#[derive(Args, Clone, Debug)]
+pub struct DoctorArgs {
+    /// Project directory to check
+    #[arg(default_value = ".")]
+    pub path: PathBuf,
+
+    /// Configuration file (default: .valknut.yml or .valknut.yaml in the project directory)
+    #[arg(short, long)]
+    pub config: Option<PathBuf>,
+
+    /// Incremental cache directory (default: ~/.cache/valknut)
+    #[arg(long, value_name = "DIR")]
+    pub cache_dir: Option<PathBuf>,
+}
+
+/// Repository statistics options
+#[derive(Args, Clone, Debug)]
+pub struct StatsArgs {
+    /// Directories to summarise
+    #[arg(default_value = ".")]
+    pub paths: Vec<PathBuf>,
+
+    /// Incremental cache directory (default: ~/.cache/valknut)
+    #[arg(long, value_name = "DIR")]
+    pub cache_dir: Option<PathBuf>,
+
+    /// Print the statistics as JSON
+    #[arg(long)]
+    pub json: bool,
+
+    /// Number of entries in each ranking
+    #[arg(long, default_value_t = 10)]
+    pub top: usize,
+}
+
+/// Doc comment formatting options
+#[derive(Args, Clone, Debug)]
+pub struct FmtArgs {
+    /// Files or directories to format
+    #[arg(default_value = ".")]
+    pub paths: Vec<PathBuf>,
+
+    /// Configuration file
+    #[arg(short, long)]
+    pub config: Option<PathBuf>,
+
+    /// Report files that would change and exit non-zero instead of rewriting them
+    #[arg(long)]
+    pub check: bool,
+
+    /// Number of files formatted in parallel (default: one per core)
+    #[arg(long, value_name = "N")]
+    pub workers: Option<usize>,
+}
+
+/// Analysis output validation options
+#[derive(Args, Clone, Debug)]
+pub struct ValidateArgs {
+    /// Output files written by `analyze --format json` or `--format jsonl`
+    #[arg(required_unless_present = "print_schema")]
+    pub files: Vec<PathBuf>,
+
+    /// Print the embedded JSON Schema instead of validating
+    #[arg(long)]
+    pub print_schema: bool,
+}
+
+/// Symbol ownership options
+#[derive(Args, Clone, Debug)]
+pub struct BlameArgs {
+    /// Package directory or file to blame
+    #[arg(default_value = ".")]
+    pub path: PathBuf,
+
+    /// Also print a leaderboard of the N authors owning the most lines
+    #[arg(long, value_name = "N")]
+    pub top_owners: Option<usize>,
+
+    /// Output format for the ownership report
+    #[arg(long, value_enum, default_value = "text")]
+    pub format: BlameFormat,
+
+    /// Cache directory shared with `analyze` (default: ~/.cache/valknut)
+    #[arg(long, value_name = "DIR")]
+    pub cache_dir: Option<PathBuf>,
+
+    /// Blame every file from scratch without reading or writing the cache
+    #[arg(long)]
+    pub no_cache: bool,
+}
+
+/// Output formats available for the blame command.
+#[derive(Clone, Copy, Debug, PartialEq, ValueEnum)]
+pub enum BlameFormat {
+    /// One `path:line name owner date` row per symbol
+    Text,
+    /// JSON object with `symbols` and `owners`
+    Json,
+}
+
+/// Structural rule check options
+#[derive(Args, Clone, Debug)]
+pub struct CheckArgs {
+    /// Directory to check
+    #[arg(default_value = ".")]
+    pub path: PathBuf,
+
+    /// File holding the `[[check.rules]]` (default: valknut.toml or .valknut.yaml
+    /// in the repository root and the checked directory)
+    #[arg(long, value_name = "FILE")]
+    pub rules: Option<PathBuf>,
+
+    /// Output format for the violations
+    #[arg(long, value_enum, default_value = "text")]
+    pub format: CheckFormat,
+}
+
+/// Output formats available for the check command.
+#[derive(Clone, Copy, Debug, PartialEq, ValueEnum)]
+pub enum CheckFormat {
+    /// One `path:line: severity [rule] message` row per violation and a summary
+    Text,
+    /// JSON object with `violations` and per-severity counts
+    Json,
+}
+
+/// Lint options
+#[derive(Args, Clone, Debug)]
+pub struct LintArgs {
+    /// Directory to lint
+    #[arg(default_value = ".")]
+    pub path: PathBuf,
+
+    /// File holding the `[[check.rules]]` run alongside the fixable built-ins
+    /// (default: valknut.toml or .valknut.yaml in the repository root and the
+    /// linted directory)
+    #[arg(long, value_name = "FILE")]
+    pub rules: Option<PathBuf>,
+
+    /// Rewrite files to fix the violations of fixable rules
+    #[arg(long)]
+    pub fix: bool,
+
+    /// Print the fixes as unified diffs without modifying any file
+    #[arg(long, conflicts_with = "fix")]
+    pub fix_dry_run: bool,
+}
+
+/// Flag file migration options
+#[derive(Args, Clone, Debug)]
+pub struct MigrateArgs {
+    /// Flag file to migrate (default: valknut.toml or .valknut.yaml in the
+    /// repository root and the current directory)
+    pub file: Option<PathBuf>,
+
+    /// Print the steps and diff without writing the file or a backup
+    #[arg(long)]
+    pub dry_run: bool,
+}
+
+/// Diff review options
+#[derive(Args, Clone, Debug)]
+pub struct ReviewArgs {
+    /// Directory inside the repository; only changes under it are summarised
+    #[arg(default_value = ".")]
+    pub path: PathBuf,
+
+    /// Branch or revision the changes are reviewed against (`git diff <base>...HEAD`)
+    #[arg(long, default_value = "main")]
+    pub base: String,
+
+    /// Document format to produce
+    #[arg(long, value_enum, default_value = "markdown")]
+    pub format: ReviewFormat,
+
+    /// Write the document to this file instead of stdout
+    #[arg(short, long, value_name = "FILE")]
+    pub out: Option<PathBuf>,
+
+    /// Cache directory shared with `analyze` (default: ~/.cache/valknut)
+    #[arg(long, value_name = "DIR")]
+    pub cache_dir: Option<PathBuf>,
+
+    /// Parse every changed file from scratch without reading the cache
+    #[arg(long)]
+    pub no_cache: bool,
+}
+
+/// Document formats available for the review command.
+#[derive(Clone, Copy, Debug, PartialEq, ValueEnum)]
+pub enum ReviewFormat {
+    /// Markdown context document for reviewers and LLM prompts
+    Markdown,
+    /// JSON object with `files` and their changed symbols
+    Json,
+}
+
+/// Snapshot diff options
+#[derive(Args, Clone, Debug)]
+pub struct DiffArgs {
+    /// Older snapshot (JSON written by `analyze --format json`, or `archive:<tag>`)
+    pub before: PathBuf,
+
+    /// Newer snapshot to compare against
+    pub after: PathBuf,
+
+    /// Output format for the diff
+    #[arg(long, value_enum, default_value = "text")]
+    pub format: DiffFormat,
+
+    /// Directory `archive:<tag>` snapshots are read from (default: ~/.valknut/archives)
+    #[arg(long, value_name = "DIR")]
+    pub archive_dir: Option<PathBuf>,
+}
+
+/// Repository comparison options
+#[derive(Args, Clone, Debug)]
+pub struct CompareArgs {
+    /// First repository (labelled `a` in the output)
+    pub repo_a: PathBuf,
+
+    /// Second repository (labelled `b` in the output)
+    pub repo_b: PathBuf,
+
+    /// Output format for the comparison
+    #[arg(long, value_enum, default_value = "text")]
+    pub format: CompareFormat,
+}
+
+/// Snapshot archive options
+#[derive(Args, Clone, Debug)]
+#[command(subcommand_negates_reqs = true)]
+pub struct ArchiveArgs {
+    #[command(subcommand)]
+    pub command: Option<ArchiveCommand>,
+
+    /// Tag to archive the snapshot under, e.g. v1.2.3
+    #[arg(long, required = true)]
+    pub tag: Option<String>,
+
+    /// Snapshot to archive (JSON written by `analyze --format json`)
+    #[arg(
+        long,
+        value_name = "FILE",
+        default_value = ".valknut/analysis-results.json"
+    )]
+    pub from: PathBuf,
+
+    /// Replace an existing archive with the same tag
+    #[arg(long)]
+    pub force: bool,
+
+    /// Archive directory (default: ~/.valknut/archives)
+    #[arg(long, value_name = "DIR", global = true)]
+    pub archive_dir: Option<PathBuf>,
+}
+
+/// Subcommands of `valknut archive`
+#[derive(Subcommand, Clone, Debug)]
+pub enum ArchiveCommand {
+    /// List archived snapshots with their tag, date, size and file count
+    List {
+        /// Print the list as JSON
+        #[arg(long)]
+        json: bool,
+    },
+
+    /// Delete all but the most recently archived snapshots
+    Prune {
+        /// Number of snapshots to keep
+        #[arg(long, value_name = "N")]
+        keep_last: usize,
+    },
+}
+
+/// Output formats available for the diff command.
+#[derive(Clone, Copy, Debug, PartialEq, ValueEnum)]
+pub enum DiffFormat {
+    /// Human-readable change list grouped by file
+    Text,
+    /// JSON document with a summary and every change
+    Json,
+}
+
+/// Output formats available for the compare command.
+#[derive(Clone, Copy, Debug, PartialEq, ValueEnum)]
+pub enum CompareFormat {
+    /// Human-readable sections with `[a]`/`[b]` labels
+    Text,
+    /// JSON document with a summary and every item
+    Json,
+}
+
+/// Output formats available for the package dependency graph export.
+#[derive(Clone, Copy, Debug, PartialEq, ValueEnum)]
+pub enum DepGraphFormat {
+    /// Graphviz DOT graph
+    Dot,
+    /// JSON graph with cycles and condensed components
+    Json,
+}
+
 /// Documentation audit configuration options
 #[derive(Args, Clone, Debug)]
 pub struct DocAuditArgs {
@@ -331,6 +950,101 @@ pub struct AnalysisControlArgs {
     /// Enable semantic cohesion analysis (experimental - uses local embeddings)
     #[arg(long)]
     pub cohesion: bool,
+
+    /// Bypass the incremental analysis cache and re-parse every file
+    #[arg(long)]
+    pub no_cache: bool,
+
+    /// Evict least recently used files once the incremental cache exceeds SIZE,
+    /// e.g. 512mb, 1gb (default 1gb; 0 = no limit)
+    #[arg(long, value_name = "SIZE", value_parser = parse_byte_size_arg)]
+    pub cache_max_size: Option<u64>,
+
+    /// Exclude paths matching this glob (repeatable; wins over .valknutignore)
+    #[arg(long, value_name = "GLOB")]
+    pub exclude: Vec<String>,
+
+    /// Ignore all .valknutignore files (useful for debugging discovery)
+    #[arg(long)]
+    pub no_ignore_file: bool,
+
+    /// Directory levels listed in parallel when discovery walks the filesystem
+    /// instead of the git index (default 2; 0 = single-threaded)
+    #[arg(long, value_name = "N")]
+    pub discovery_depth: Option<usize>,
+
+    /// Cap the threads used for file I/O, parsing and analysis: a count (4) or
+    /// a share of the cores (50%); 1 analyzes on a single thread
+    #[arg(long, value_name = "N|PERCENT%", value_parser = parse_concurrency_limit_arg)]
+    pub concurrency_limit: Option<ConcurrencyLimit>,
+
+    /// Skip files larger than SIZE, e.g. 512kb, 1mb, 2gb (default 1mb; 0 = no limit)
+    #[arg(long, value_name = "SIZE", value_parser = parse_byte_size_arg)]
+    pub max_file_size: Option<u64>,
+
+    /// Stop the analysis after DURATION, e.g. 90s, 5m, 1h30m, and report the
+    /// files not reached as `not_analyzed` with `"timed_out": true` in the summary
+    #[arg(long, value_name = "DURATION", value_parser = parse_duration_arg)]
+    pub timeout: Option<std::time::Duration>,
+
+    /// Keep tests/, testdata/ and *_test.go files in the primary output (default)
+    #[arg(long, conflicts_with = "exclude_tests")]
+    pub include_tests: bool,
+
+    /// Drop tests/, testdata/ and *_test.go files from the primary output; they are still counted in context_statistics
+    #[arg(long)]
+    pub exclude_tests: bool,
+
+    /// Keep generated files (`// Code generated ... DO NOT EDIT.`) in complexity and coverage metrics
+    #[arg(long)]
+    pub include_generated: bool,
+
+    /// Analyze dependency and build-output directories skipped by default:
+    /// vendor, third_party, node_modules, bower_components, __pycache__, .venv,
+    /// venv, .tox, target, dist, build, .gradle and .next (.git, .hg and .svn
+    /// are always skipped)
+    #[arg(long)]
+    pub include_vendor: bool,
+
+    /// Only analyze files changed since this git revision (e.g. HEAD~1, origin/main)
+    #[arg(long, value_name = "GIT_REF")]
+    pub since: Option<String>,
+
+    /// With --since, skip uncommitted working-tree changes and compare against HEAD
+    #[arg(long, requires = "since")]
+    pub committed_only: bool,
+
+    /// Only analyze Go files built for this GOOS, like `GOOS=<os> go build` (default: host OS)
+    #[arg(long = "os", value_name = "GOOS")]
+    pub target_os: Option<String>,
+
+    /// Only analyze Go files built for this GOARCH, like `GOARCH=<arch> go build` (default: host architecture)
+    #[arg(long = "arch", value_name = "GOARCH")]
+    pub target_arch: Option<String>,
+
+    /// Comma-separated build tags that count as set, like `go build -tags` (implies platform filtering)
+    #[arg(long, value_name = "TAG,...", value_delimiter = ',')]
+    pub tags: Vec<String>,
+
+    /// Export the function-level call graph as an adjacency list in JSON output
+    #[arg(long)]
+    pub call_graph: bool,
+
+    /// Maximum call-graph traversal depth from entry points (implies --call-graph)
+    #[arg(long, value_name = "N")]
+    pub call_graph_depth: Option<usize>,
+
+    /// Only export the Go/Java package import graph (dot or json) instead of running analysis
+    #[arg(long, value_name = "FORMAT")]
+    pub dep_graph: Option<DepGraphFormat>,
+
+    /// Check go.mod dependencies for newer versions (GOPROXY) and known CVEs (osv.dev); needs network access
+    #[arg(long)]
+    pub check_deps: bool,
+
+    /// List implementations of exported Go interfaces; fail when a `var _ I = (*T)(nil)` assertion is broken
+    #[arg(long)]
+    pub check_interfaces: bool,
 }
 
 /// Semantic cohesion analysis configuration
@@ -377,13 +1091,88 @@ pub struct AIFeaturesArgs {
     pub oracle_dry_run: bool,
 }
 
+/// Options for analyzing remote Git repositories passed by URL
+#[derive(Args)]
+pub struct RemoteArgs {
+    /// Branch, tag or commit to analyze when a path is a Git URL (default: remote HEAD)
+    #[arg(long = "ref", value_name = "REF")]
+    pub git_ref: Option<String>,
+
+    /// History depth to fetch for Git URLs (0 = full history)
+    #[arg(long, value_name = "N", default_value_t = 1)]
+    pub depth: u32,
+}
+
+/// Profiling of the analyzer itself (requires a build with `--features profiling`)
+#[derive(Args, Default)]
+pub struct ProfilingArgs {
+    /// Write a pprof CPU profile of the analysis run to FILE
+    #[arg(long, value_name = "FILE")]
+    pub cpuprofile: Option<PathBuf>,
+
+    /// Write a pprof heap profile (live sampled allocations at the end of the run) to FILE (Linux only)
+    #[arg(long, value_name = "FILE")]
+    pub memprofile: Option<PathBuf>,
+
+    /// Write a Chrome/Perfetto execution trace of analysis stages to FILE
+    #[arg(long, value_name = "FILE")]
+    pub trace: Option<PathBuf>,
+}
+
+/// Project health score: a weighted 0-100 aggregate of coverage, complexity,
+/// documentation, dependency freshness and license presence
+#[derive(Args, Default)]
+pub struct HealthScoreArgs {
+    /// Compute the project health score and include it in the output
+    #[arg(long)]
+    pub health_score: bool,
+
+    /// Fail if the project health score is below N (0-100; implies --health-score)
+    #[arg(long, value_name = "N")]
+    pub min_health_score: Option<f64>,
+
+    /// Weight of test coverage in the health score [default: 30]
+    #[arg(long, value_name = "W")]
+    pub health_weight_coverage: Option<f64>,
+
+    /// Weight of average cyclomatic complexity in the health score [default: 25]
+    #[arg(long, value_name = "W")]
+    pub health_weight_complexity: Option<f64>,
+
+    /// Weight of exported-symbol documentation in the health score [default: 20]
+    #[arg(long, value_name = "W")]
+    pub health_weight_docs: Option<f64>,
+
+    /// Weight of dependency freshness (needs --check-deps) in the health score [default: 15]
+    #[arg(long, value_name = "W")]
+    pub health_weight_dependencies: Option<f64>,
+
+    /// Weight of license file presence in the health score [default: 10]
+    #[arg(long, value_name = "W")]
+    pub health_weight_license: Option<f64>,
+
+    /// Per-language complexity baseline, e.g. `go=1.3` (repeatable; overrides scoring.complexity_baselines)
+    #[arg(long, value_name = "LANG=FACTOR", value_parser = parse_language_factor)]
+    pub complexity_baseline: Vec<(String, f64)>,
+}
+
 /// Arguments for the primary `analyze` command
 #[derive(Args)]
 pub struct AnalyzeArgs {
-    /// One or more directories or files to analyze (defaults to current directory)
+    /// One or more directories, files, Git URLs or source archives (.tar.gz, .zip, .tar.bz2,
+    /// .tar.xz) to analyze (defaults to current directory)
     #[arg(default_value = ".")]
     pub paths: Vec<PathBuf>,
 
+    /// Read a single source file from stdin instead of analyzing paths
+    #[arg(long, conflicts_with_all = ["paths", "since"])]
+    pub stdin: bool,
+
+    /// Virtual path for --stdin source; sets the reported file path and language
+    /// (without it the language comes from a `#!` line, defaulting to Go)
+    #[arg(long, value_name = "PATH", requires = "stdin")]
+    pub stdin_path: Option<PathBuf>,
+
     /// Configuration file path (auto-discovers .valknut.yml/.yaml when omitted)
     #[arg(short, long)]
     pub config: Option<PathBuf>,
@@ -393,12 +1182,13 @@ pub struct AnalyzeArgs {
     pub out: PathBuf,
 
     /// Output format(s) - can be specified multiple times for multiple outputs
-    /// Available: jsonl, json, yaml, markdown, html, sonar, csv, ci-summary, pretty
-    #[arg(short, long, value_enum, action = clap::ArgAction::Append)]
+    /// Available: jsonl, json, yaml, markdown, html, sonar, sarif, junit, csv, ci-summary, pretty,
+    /// summary, dot, symbol-graph
+    #[arg(short, long, alias = "output-format", value_enum, action = clap::ArgAction::Append)]
     pub format: Vec<OutputFormat>,
 
     /// Output bundle preset - expands to multiple formats
-    /// ci: json, sonar, ci-summary | dev: html, json | full: all formats | review: html, markdown, json
+    /// ci: json, sonar, sarif, ci-summary | dev: html, json | full: all formats | review: html, markdown, json
     #[arg(long, value_enum)]
     pub output_bundle: Option<OutputBundle>,
 
@@ -406,6 +1196,33 @@ pub struct AnalyzeArgs {
     #[arg(short, long)]
     pub quiet: bool,
 
+    /// Omit the column header line from `--format summary` output (for piping)
+    #[arg(long)]
+    pub no_header: bool,
+
+    /// Limit `--format dot` to the N nodes with the most edges
+    #[arg(long, value_name = "N")]
+    pub dot_max_nodes: Option<usize>,
+
+    /// Keep only these fields in `--format json`/`jsonl` output, e.g.
+    /// `fields=summary,refactoring_candidates.name` (dot paths, `*` wildcards)
+    #[arg(long, value_name = "FILTER")]
+    pub output_filter: Option<FieldFilter>,
+
+    /// Replace string literal values in every output with `<redacted>`
+    #[arg(long, conflicts_with = "stream")]
+    pub redact_strings: bool,
+
+    /// Replace comment text in every output with `<redacted>`
+    #[arg(long, conflicts_with = "stream")]
+    pub redact_comments: bool,
+
+    /// Stream per-file complexity as NDJSON to stdout while analysis runs, in the
+    /// same schema as `--format ndjson` (skips whole-repository passes such as
+    /// clone detection and health scoring)
+    #[arg(long, conflicts_with_all = ["format", "output_bundle", "quality_gate", "since"])]
+    pub stream: bool,
+
     /// Performance optimization profile to balance speed vs thoroughness
     #[arg(long, value_enum, default_value = "fast")]
     pub profile: PerformanceProfile,
@@ -430,6 +1247,15 @@ pub struct AnalyzeArgs {
 
     #[command(flatten)]
     pub ai_features: AIFeaturesArgs,
+
+    #[command(flatten)]
+    pub remote: RemoteArgs,
+
+    #[command(flatten)]
+    pub profiling: ProfilingArgs,
+
+    #[command(flatten)]
+    pub health: HealthScoreArgs,
 }
 
 impl AnalyzeArgs {
@@ -466,6 +1292,26 @@ impl AnalyzeArgs {
     }
 }
 
+/// Options for scaffolding a `valknut.toml` flag file
+#[derive(Args, Clone, Debug)]
+pub struct InitArgs {
+    /// Project directory to inspect
+    #[arg(default_value = ".")]
+    pub dir: PathBuf,
+
+    /// Flag file to create or merge into, relative to the project directory
+    #[arg(short, long, default_value = "valknut.toml")]
+    pub output: PathBuf,
+
+    /// Add the project's .gitignore patterns to `exclude` without asking
+    #[arg(long, conflicts_with = "no_gitignore")]
+    pub gitignore: bool,
+
+    /// Do not inherit .gitignore patterns (and do not ask)
+    #[arg(long)]
+    pub no_gitignore: bool,
+}
+
 /// Initialize a configuration file with default values
 #[derive(Args)]
 pub struct InitConfigArgs {
@@ -490,12 +1336,109 @@ pub struct ValidateConfigArgs {
     pub verbose: bool,
 }
 
+/// Flag file management
+#[derive(Args)]
+pub struct ConfigArgs {
+    #[command(subcommand)]
+    pub command: ConfigCommand,
+}
+
+/// Subcommands of `valknut config`
+#[derive(Subcommand)]
+pub enum ConfigCommand {
+    /// Check flag files for unknown keys and invalid values
+    Validate(ConfigValidateArgs),
+}
+
+/// Validate CLI flag files
+#[derive(Args)]
+pub struct ConfigValidateArgs {
+    /// Flag file to validate (defaults to valknut.toml / .valknut.yaml in the repo root and current directory)
+    #[arg(long)]
+    pub file: Option<PathBuf>,
+}
+
+/// Language parser plugin management
+#[derive(Args)]
+pub struct PluginsArgs {
+    #[command(subcommand)]
+    pub command: PluginsCommand,
+}
+
+/// Subcommands of `valknut plugins`
+#[derive(Subcommand)]
+pub enum PluginsCommand {
+    /// List loaded plugins, their extensions, and plugins that failed to load
+    List(PluginDirArgs),
+
+    /// Parse one file with the plugin registered for its extension and print the result as JSON
+    Parse(PluginParseArgs),
+}
+
+/// Where to find plugins
+#[derive(Args)]
+pub struct PluginDirArgs {
+    /// Plugin directory (overrides `plugins.directory` from the config file)
+    #[arg(long, value_name = "DIR")]
+    pub dir: Option<PathBuf>,
+
+    /// Configuration file
+    #[arg(short, long)]
+    pub config: Option<PathBuf>,
+}
+
+/// Parse a file with a plugin
+#[derive(Args)]
+pub struct PluginParseArgs {
+    /// File to parse
+    pub file: PathBuf,
+
+    #[command(flatten)]
+    pub plugins: PluginDirArgs,
+}
+
 /// Start MCP server over stdio for IDE integrations
 #[derive(Args)]
 pub struct McpStdioArgs {
     /// Configuration file
     #[arg(short, long)]
     pub config: Option<PathBuf>,
+
+    /// Default token budget for the build_context tool (files are ranked and trimmed to fit)
+    #[arg(long, value_name = "N")]
+    pub context_budget: Option<usize>,
+
+    /// How build_context trims files that do not fit the budget
+    #[arg(long, value_name = "STRATEGY", default_value = "drop-files")]
+    pub context_strategy: ContextStrategy,
+
+    /// Only return symbols meeting every listed criterion: exported, documented, changed
+    #[arg(long, value_name = "FILTERS", value_parser = parse_symbol_filter_arg)]
+    pub symbol_filter: Option<SymbolFilter>,
+
+    /// Revision `--symbol-filter changed` compares the current branch against
+    #[arg(long, value_name = "REV", default_value = DEFAULT_CHANGED_BASE)]
+    pub symbol_filter_base: String,
+}
+
+/// Trimming strategies for fitting code context into a token budget.
+#[derive(Clone, Copy, Debug, PartialEq, ValueEnum)]
+pub enum ContextStrategy {
+    /// Leave out whole files that do not fit
+    DropFiles,
+    /// Replace function bodies with placeholders but keep signatures
+    TruncateBodies,
+}
+
+/// Conversion into the library trim strategy.
+impl From<ContextStrategy> for TrimStrategy {
+    /// Maps the CLI strategy onto the matching [`TrimStrategy`].
+    fn from(strategy: ContextStrategy) -> Self {
+        match strategy {
+            ContextStrategy::DropFiles => TrimStrategy::DropFiles,
+            ContextStrategy::TruncateBodies => TrimStrategy::TruncateBodies,
+        }
+    }
 }
 
 /// Generate an MCP manifest JSON file
@@ -506,12 +1449,52 @@ pub struct McpManifestArgs {
     pub output: Option<PathBuf>,
 }
 
+/// MCP server registration
+#[derive(Args)]
+pub struct McpArgs {
+    #[command(subcommand)]
+    pub command: McpCommand,
+}
+
+/// Subcommands of `valknut mcp`
+#[derive(Subcommand)]
+pub enum McpCommand {
+    /// Add valknut to the Claude Desktop MCP servers and restart Claude Desktop
+    Install(McpInstallArgs),
+
+    /// Remove valknut from the Claude Desktop MCP servers and restart Claude Desktop
+    Uninstall(McpInstallArgs),
+}
+
+/// Claude Desktop registration options
+#[derive(Args)]
+pub struct McpInstallArgs {
+    /// Claude Desktop config file (default: detected for the platform)
+    #[arg(long, value_name = "FILE")]
+    pub config_path: Option<PathBuf>,
+
+    /// Name of the server entry under `mcpServers`
+    #[arg(long, default_value = "valknut")]
+    pub name: String,
+
+    /// Print the resulting config instead of writing it
+    #[arg(long)]
+    pub dry_run: bool,
+
+    /// Do not restart Claude Desktop after changing the config
+    #[arg(long)]
+    pub no_restart: bool,
+}
+
 /// Available output formats for analysis reports
 /// Report serialization options for the `analyze` command.
 #[derive(Clone, Debug, PartialEq, ValueEnum)]
 pub enum OutputFormat {
     /// Line-delimited JSON format
     Jsonl,
+    /// Per-file complexity streamed to analysis-results.ndjson as each file finishes,
+    /// then a summary line (the `--stream` schema)
+    Ndjson,
     /// JSON format output
     Json,
     /// YAML format output
@@ -522,18 +1505,28 @@ pub enum OutputFormat {
     Html,
     /// SonarQube integration format
     Sonar,
+    /// SARIF 2.1.0 log for GitHub code scanning and IDEs
+    Sarif,
+    /// JUnit XML: one test suite per file, one failing test case per violated rule
+    Junit,
     /// CSV spreadsheet data
     Csv,
     /// CI/CD summary format (concise JSON for automated systems)
     CiSummary,
     /// Human-readable format
     Pretty,
+    /// One line per file on stdout: exports, max cyclomatic complexity, warnings
+    Summary,
+    /// Graphviz DOT type hierarchy: struct containment, interface satisfaction, calls
+    Dot,
+    /// JSON list of symbol-level edges: calls, embedding, interface assignments
+    SymbolGraph,
 }
 
 /// Preset bundles of output formats for common workflows
 #[derive(Clone, PartialEq, ValueEnum)]
 pub enum OutputBundle {
-    /// CI/CD pipeline outputs: json, sonar, ci-summary
+    /// CI/CD pipeline outputs: json, sonar, sarif, ci-summary
     Ci,
     /// Developer workflow: html, json
     Dev,
@@ -552,10 +1545,15 @@ impl OutputFormat {
             self,
             OutputFormat::Json
                 | OutputFormat::Jsonl
+                | OutputFormat::Ndjson
                 | OutputFormat::Yaml
                 | OutputFormat::Csv
                 | OutputFormat::Sonar
+                | OutputFormat::Sarif
+                | OutputFormat::Junit
                 | OutputFormat::CiSummary
+                | OutputFormat::Summary
+                | OutputFormat::SymbolGraph
         )
     }
 }
@@ -569,6 +1567,7 @@ impl OutputBundle {
             OutputBundle::Ci => vec![
                 OutputFormat::Json,
                 OutputFormat::Sonar,
+                OutputFormat::Sarif,
                 OutputFormat::CiSummary,
             ],
             OutputBundle::Dev => vec![OutputFormat::Html, OutputFormat::Json],
@@ -580,6 +1579,8 @@ impl OutputBundle {
                 OutputFormat::Yaml,
                 OutputFormat::Csv,
                 OutputFormat::Sonar,
+                OutputFormat::Sarif,
+                OutputFormat::Junit,
                 OutputFormat::CiSummary,
             ],
             OutputBundle::Review => vec![
@@ -591,6 +1592,30 @@ impl OutputBundle {
     }
 }
 
+/// Minimum severity of emitted log messages
+#[derive(Debug, Clone, Copy, PartialEq, Eq, ValueEnum)]
+pub enum LogLevel {
+    /// Everything, including per-stage tracing
+    Trace,
+    /// Per-file progress such as cache hits and processed files
+    Debug,
+    /// Run milestones
+    Info,
+    /// Recoverable problems, such as files that failed to analyze
+    Warn,
+    /// Failures only
+    Error,
+}
+
+/// Log record formats
+#[derive(Debug, Clone, Copy, PartialEq, Eq, ValueEnum)]
+pub enum LogFormat {
+    /// Human-readable lines
+    Text,
+    /// One JSON object per line, for log aggregators
+    Json,
+}
+
 /// Verbosity levels for optional survey prompts
 #[derive(Debug, Clone, ValueEnum)]
 pub enum SurveyVerbosity {
@@ -616,3 +1641,38 @@ pub enum PerformanceProfile {
     /// Extreme mode - maximum analysis depth, all optimizations enabled
     Extreme,
 }
+
+/// Parse a `--max-file-size` or `--cache-max-size` value such as `512kb` into bytes.
+fn parse_byte_size_arg(value: &str) -> Result<u64, String> {
+    parse_byte_size(value).map_err(|e| e.to_string())
+}
+
+/// Parse a `--timeout` value such as `5m` or `1h30m`.
+fn parse_duration_arg(value: &str) -> Result<std::time::Duration, String> {
+    parse_duration(value).map_err(|e| e.to_string())
+}
+
+/// Parse a `--concurrency-limit` value such as `4` or `50%`.
+fn parse_concurrency_limit_arg(value: &str) -> Result<ConcurrencyLimit, String> {
+    value.parse::<ConcurrencyLimit>().map_err(|e| e.to_string())
+}
+
+/// Parse a `--symbol-filter` value such as `exported,changed`.
+fn parse_symbol_filter_arg(value: &str) -> Result<SymbolFilter, String> {
+    value.parse::<SymbolFilter>().map_err(|e| e.to_string())
+}
+
+/// Parse a `--complexity-baseline` value such as `go=1.3`.
+fn parse_language_factor(value: &str) -> Result<(String, f64), String> {
+    let (language, factor) = value
+        .split_once('=')
+        .ok_or_else(|| format!("expected LANG=FACTOR, got '{value}'"))?;
+    let factor: f64 = factor
+        .trim()
+        .parse()
+        .map_err(|_| format!("invalid baseline factor '{factor}'"))?;
+    if !factor.is_finite() || factor <= 0.0 {
+        return Err(format!("baseline factor must be positive, got {factor}"));
+    }
+    Ok((language.trim().to_string(), factor))
+}
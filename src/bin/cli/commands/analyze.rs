@@ -9,8 +9,9 @@ use crate::cli::analysis_display::{
 };
 use crate::cli::args::{
     AIFeaturesArgs, AdvancedCloneArgs, AnalysisControlArgs, AnalyzeArgs, CloneDetectionArgs,
-    CohesionArgs, CoverageArgs, InitConfigArgs, OutputFormat, PerformanceProfile, QualityGateArgs,
-    SurveyVerbosity, ValidateConfigArgs,
+    CohesionArgs, CoverageArgs, DepGraphFormat, HealthScoreArgs, InitConfigArgs, OutputFormat,
+    PerformanceProfile, ProfilingArgs, QualityGateArgs, RemoteArgs, SurveyVerbosity,
+    ValidateConfigArgs,
 };
 use crate::cli::config_builder::{
     build_analysis_config, build_coverage_config, build_denoise_config, build_valknut_config,
@@ -27,7 +28,7 @@ pub use crate::cli::quality_gates::{
     evaluate_quality_gates, print_violation_group, severity_for_excess, severity_for_shortfall,
     top_issue_files,
 };
-use crate::cli::reports::is_quiet;
+use crate::cli::reports::{analysed_files, is_quiet};
 // Re-export report generation functions for tests (they use `super::*`)
 pub use crate::cli::reports::{
     format_file_info, format_to_string, generate_default_content, generate_html_file,
@@ -48,23 +49,41 @@ use std::env;
 use std::path::Path;
 use std::path::PathBuf;
 use tabled::{settings::Style as TableStyle, Table, Tabled};
+use tokio_util::sync::CancellationToken;
 use tracing::{info, warn};
 
 // Import comprehensive analysis pipeline
+use crate::cli::commands::watch::shutdown_signal;
 use valknut_rs::api::config_types::AnalysisConfig as ApiAnalysisConfig;
 use valknut_rs::api::engine::ValknutEngine;
 use valknut_rs::api::results::{AnalysisResults, RefactoringCandidate};
+use valknut_rs::core::concurrency::configure_global_thread_pool;
+use valknut_rs::core::config::duration::format_duration;
 use valknut_rs::core::config::ReportFormat;
 use valknut_rs::core::config::{CoverageConfig, ValknutConfig};
+use valknut_rs::core::dependency::{
+    DependencyChecker, GoModuleGraph, GradleGraph, InterfaceReport, JsModuleGraph, PackageGraph,
+    SwiftPackageGraph,
+};
 use valknut_rs::core::file_utils::CoverageDiscovery;
+use valknut_rs::core::pipeline::discovery::changed_files_since;
+use valknut_rs::core::pipeline::streaming::{NdjsonSink, StreamingPipeline};
 use valknut_rs::core::pipeline::{
     AnalysisConfig as PipelineAnalysisConfig, QualityGateConfig, QualityGateResult,
-    QualityGateViolation,
+    QualityGateViolation, SkipReason,
 };
-use valknut_rs::core::scoring::Priority;
+use valknut_rs::core::profiling::{ProfileSession, ProfilingOptions};
+use valknut_rs::core::project_health::{HealthWeights, ProjectHealth};
+use valknut_rs::core::scoring::{ComplexityBaselines, Priority};
 use valknut_rs::detectors::structure::StructureConfig;
+use valknut_rs::io::archive::{is_archive, ExtractedArchive};
+use valknut_rs::io::remote::{is_remote_url, RemoteCheckout};
 use valknut_rs::io::reports::ReportGenerator;
-use valknut_rs::lang::{extension_is_supported, registered_languages, LanguageStability};
+use valknut_rs::io::stdin::StdinSource;
+use valknut_rs::lang::{
+    extension_is_supported, registered_languages, BuildTarget, DockerGraph, LanguageStability,
+    ProtoGraph, TerraformGraph,
+};
 
 const VERSION: &str = env!("CARGO_PKG_VERSION");
 
@@ -82,12 +101,79 @@ pub async fn analyze_command(
         print_header();
     }
 
-    let valknut_config = build_valknut_config(&args).await?;
+    let mut valknut_config = build_valknut_config(&args).await?;
     warn_for_unsupported_languages(&valknut_config, quiet_mode);
+    if let Some(threads) = valknut_config.performance.max_threads {
+        configure_global_thread_pool(threads);
+    }
+
+    // Checkouts, extracted archives and staged stdin are held until the command
+    // returns so their temp directories outlive analysis.
+    let stdin_source = if args.stdin {
+        Some(StdinSource::read(
+            std::io::stdin().lock(),
+            args.stdin_path.as_deref(),
+        )?)
+    } else {
+        None
+    };
+    let input_paths = match &stdin_source {
+        Some(source) => vec![source.root().to_path_buf()],
+        None => args.paths.clone(),
+    };
+    let (local_paths, _checkouts) = fetch_remote_paths(&input_paths, &args.remote, quiet_mode)?;
+    let (local_paths, archives) = extract_archive_paths(&local_paths, quiet_mode)?;
+    let valid_paths = validate_input_paths(&local_paths)?;
+
+    if args.stream {
+        return stream_analysis(&valid_paths, valknut_config, &args, std::io::stdout()).await;
+    }
 
-    let valid_paths = validate_input_paths(&args.paths)?;
     tokio::fs::create_dir_all(&args.out).await?;
 
+    if let Some(format) = args.analysis_control.dep_graph {
+        export_terraform_graph(&valid_paths, format, &args.out, quiet_mode)?;
+        export_proto_graph(&valid_paths, format, &args.out, quiet_mode)?;
+        export_docker_graph(&valid_paths, format, &args.out, quiet_mode)?;
+        export_gradle_graph(&valid_paths, format, &args.out, quiet_mode)?;
+        export_swift_package_graph(&valid_paths, format, &args.out, quiet_mode)?;
+        export_js_module_graph(&valid_paths, format, &args.out, quiet_mode)?;
+        return export_package_graph(&valid_paths, format, &args.out, quiet_mode);
+    }
+
+    if let Some(since) = &args.analysis_control.since {
+        let changed =
+            collect_changed_files(&valid_paths, since, args.analysis_control.committed_only)?;
+        if !quiet_mode {
+            println!(
+                "Limiting analysis to {} file(s) changed since {}",
+                changed.len(),
+                since
+            );
+        }
+        valknut_config.analysis.only_files = Some(changed);
+    }
+
+    let control = &args.analysis_control;
+    if control.target_os.is_some() || control.target_arch.is_some() || !control.tags.is_empty() {
+        let target = BuildTarget::new(
+            control.target_os.as_deref(),
+            control.target_arch.as_deref(),
+            control.tags.clone(),
+        );
+        if !quiet_mode {
+            println!(
+                "Limiting Go analysis to files built for {}/{}",
+                target.os, target.arch
+            );
+        }
+        valknut_config.analysis.build_target = Some(target);
+    }
+
+    if args.format.contains(&OutputFormat::Ndjson) {
+        return stream_ndjson_report(&valid_paths, valknut_config, &args).await;
+    }
+
     display_pre_analysis_info(
         &valid_paths,
         &args,
@@ -97,8 +183,50 @@ pub async fn analyze_command(
     )
     .await?;
 
-    let analysis_result =
+    let complexity_baselines =
+        ComplexityBaselines::with_overrides(&valknut_config.scoring.complexity_baselines);
+    let profile_session = ProfileSession::start(profiling_options(&args.profiling))?;
+    if let Some(timeout) = args.analysis_control.timeout {
+        valknut_config.analysis.deadline = Some(std::time::Instant::now() + timeout);
+    }
+    let mut analysis_result =
         run_analysis_phase(&valid_paths, valknut_config, &args, quiet_mode, detail_mode).await?;
+    profile_session.finish().await?;
+    analysis_result.changed_files_only = args.analysis_control.since.is_some();
+    if analysis_result.summary.timed_out && !quiet_mode {
+        let not_analyzed = analysis_result
+            .skipped_files
+            .iter()
+            .filter(|file| file.reason == SkipReason::NotAnalyzed)
+            .count();
+        println!(
+            "Analysis timed out after {}; {} files were not analyzed",
+            format_duration(args.analysis_control.timeout.unwrap_or_default()),
+            not_analyzed
+        );
+    }
+    if args.analysis_control.check_deps {
+        check_dependencies(&valid_paths, &mut analysis_result, quiet_mode).await;
+    }
+    if args.analysis_control.check_interfaces {
+        check_interfaces(&mut analysis_result, quiet_mode)?;
+    }
+    if stdin_source.is_some() {
+        // Report the virtual path relative to where the caller ran the command.
+        analysis_result.project_root = std::env::current_dir()?;
+    }
+    if let ([archive], [_]) = (archives.as_slice(), input_paths.as_slice()) {
+        // Paths are already relative to the archive root; name the archive, not the temp dir.
+        analysis_result.project_root = archive.archive.clone();
+    }
+    if args.health.health_score || args.health.min_health_score.is_some() {
+        analysis_result.project_health = Some(ProjectHealth::assess(
+            &valid_paths,
+            &analysis_result,
+            &health_weights(&args.health),
+            &complexity_baselines,
+        ));
+    }
 
     let quality_gate_result =
         evaluate_quality_gates_if_enabled(&analysis_result, &args, quiet_mode)?;
@@ -114,6 +242,15 @@ pub async fn analyze_command(
 
     handle_quality_gate_result(quality_gate_result, quiet_mode, detail_mode)?;
 
+    if let Some(report) = &analysis_result.interface_report {
+        let broken = report.errors().count();
+        if broken > 0 {
+            return Err(anyhow::anyhow!(
+                "{broken} interface assertion(s) not satisfied"
+            ));
+        }
+    }
+
     if !quiet_mode {
         println!("Analysis completed.");
     }
@@ -121,6 +258,204 @@ pub async fn analyze_command(
     Ok(())
 }
 
+/// Attach the Go dependency report, turning a failed lookup into a warning.
+async fn check_dependencies(paths: &[PathBuf], result: &mut AnalysisResults, quiet_mode: bool) {
+    if !quiet_mode {
+        println!("Checking Go module dependencies...");
+    }
+    match DependencyChecker::from_env().check(paths).await {
+        Ok(report) => result.dependency_report = report,
+        Err(e) => result
+            .warnings
+            .push(format!("Dependency check failed: {e}")),
+    }
+}
+
+/// Attach the Go interface implementation report for the analysed files.
+fn check_interfaces(result: &mut AnalysisResults, quiet_mode: bool) -> anyhow::Result<()> {
+    if !quiet_mode {
+        println!("Checking Go interface implementations...");
+    }
+    let files: Vec<PathBuf> = analysed_files(result)
+        .into_iter()
+        .filter(|path| path.extension().is_some_and(|ext| ext == "go"))
+        .collect();
+    result.interface_report = Some(InterfaceReport::check(&result.project_root, &files)?);
+    Ok(())
+}
+
+/// Health score weights, with command-line (or `valknut.toml`) overrides applied.
+fn health_weights(args: &HealthScoreArgs) -> HealthWeights {
+    let defaults = HealthWeights::default();
+    HealthWeights {
+        coverage: args.health_weight_coverage.unwrap_or(defaults.coverage),
+        complexity: args.health_weight_complexity.unwrap_or(defaults.complexity),
+        documentation: args.health_weight_docs.unwrap_or(defaults.documentation),
+        dependencies: args
+            .health_weight_dependencies
+            .unwrap_or(defaults.dependencies),
+        license: args.health_weight_license.unwrap_or(defaults.license),
+    }
+}
+
+/// Write `--format ndjson` to `analysis-results.ndjson` through the streaming
+/// pipeline, so records land as each file finishes rather than after the run.
+async fn stream_ndjson_report(
+    paths: &[PathBuf],
+    valknut_config: ValknutConfig,
+    args: &AnalyzeArgs,
+) -> anyhow::Result<()> {
+    let conflict = if args.effective_formats().len() > 1 {
+        Some("other output formats")
+    } else if args.quality_gate.quality_gate || args.quality_gate.fail_on_issues {
+        Some("quality gates")
+    } else if args.redact_strings || args.redact_comments {
+        Some("redaction")
+    } else {
+        None
+    };
+    if let Some(conflict) = conflict {
+        return Err(anyhow::anyhow!(
+            "--format ndjson streams per-file records and cannot be combined with {}",
+            conflict
+        ));
+    }
+
+    let path = args.out.join("analysis-results.ndjson");
+    let file = std::fs::File::create(&path)?;
+    stream_analysis(paths, valknut_config, args, std::io::BufWriter::new(file)).await?;
+    info!("Streamed NDJSON report to {}", path.display());
+    Ok(())
+}
+
+/// Run the streaming pipeline, writing one NDJSON record per file to `output`.
+///
+/// Ctrl-C cancels the pipeline: every stage stops and the summary record is
+/// written with `"cancelled": true`. `--timeout` does the same and also sets
+/// `"timed_out": true`.
+async fn stream_analysis(
+    paths: &[PathBuf],
+    mut valknut_config: ValknutConfig,
+    args: &AnalyzeArgs,
+    output: impl std::io::Write + Send,
+) -> anyhow::Result<()> {
+    if let Some(timeout) = args.analysis_control.timeout {
+        valknut_config.analysis.deadline = Some(std::time::Instant::now() + timeout);
+    }
+    let pipeline_config = PipelineAnalysisConfig::from(valknut_config.clone());
+    let cancel = CancellationToken::new();
+    let pipeline = StreamingPipeline::new_with_config(pipeline_config, valknut_config)
+        .with_cancellation(cancel.clone());
+    let mut sink = NdjsonSink::new(output);
+    let interrupt = tokio::spawn(async move {
+        shutdown_signal().await;
+        cancel.cancel();
+    });
+
+    let profile_session = ProfileSession::start(profiling_options(&args.profiling))?;
+    let summary = pipeline.run_to_sink(paths, &mut sink).await;
+    interrupt.abort();
+    let summary = summary?;
+    profile_session.finish().await?;
+
+    if summary.timed_out {
+        warn!(
+            "Streaming analysis timed out after {} of {} file(s)",
+            summary.files_analyzed + summary.files_failed,
+            summary.files_discovered
+        );
+    } else if summary.cancelled {
+        warn!(
+            "Streaming analysis interrupted after {} of {} file(s)",
+            summary.files_analyzed + summary.files_failed,
+            summary.files_discovered
+        );
+    }
+
+    info!(
+        "Streamed {} file(s): {} analyzed, {} failed",
+        summary.files_discovered, summary.files_analyzed, summary.files_failed
+    );
+    Ok(())
+}
+
+/// Profiles requested on the command line.
+fn profiling_options(args: &ProfilingArgs) -> ProfilingOptions {
+    ProfilingOptions {
+        cpu_profile: args.cpuprofile.clone(),
+        mem_profile: args.memprofile.clone(),
+        ..ProfilingOptions::default()
+    }
+}
+
+/// Replace Git URLs among `paths` with temporary checkouts of the requested revision.
+fn fetch_remote_paths(
+    paths: &[PathBuf],
+    remote: &RemoteArgs,
+    quiet_mode: bool,
+) -> anyhow::Result<(Vec<PathBuf>, Vec<RemoteCheckout>)> {
+    let mut local_paths = Vec::with_capacity(paths.len());
+    let mut checkouts = Vec::new();
+    for path in paths {
+        let target = path.to_string_lossy();
+        if !is_remote_url(&target) {
+            local_paths.push(path.clone());
+            continue;
+        }
+        if !quiet_mode {
+            println!(
+                "Fetching {} ({})...",
+                target,
+                remote.git_ref.as_deref().unwrap_or("HEAD")
+            );
+        }
+        let checkout = RemoteCheckout::fetch(&target, remote.git_ref.as_deref(), remote.depth)?;
+        info!(
+            "Checked out {} at {} into {}",
+            target,
+            checkout.commit,
+            checkout.path().display()
+        );
+        local_paths.push(checkout.path().to_path_buf());
+        checkouts.push(checkout);
+    }
+
+    if remote.git_ref.is_some() && checkouts.is_empty() {
+        return Err(anyhow::anyhow!(
+            "--ref can only be used when analyzing a Git URL"
+        ));
+    }
+    Ok((local_paths, checkouts))
+}
+
+/// Replace archives among `paths` with temporary extractions of their contents.
+fn extract_archive_paths(
+    paths: &[PathBuf],
+    quiet_mode: bool,
+) -> anyhow::Result<(Vec<PathBuf>, Vec<ExtractedArchive>)> {
+    let mut local_paths = Vec::with_capacity(paths.len());
+    let mut archives = Vec::new();
+    for path in paths {
+        if !is_archive(path) {
+            local_paths.push(path.clone());
+            continue;
+        }
+        if !quiet_mode {
+            println!("Extracting {}...", path.display());
+        }
+        let archive = ExtractedArchive::extract(path)?;
+        info!(
+            "Extracted {} file(s) from {} into {}",
+            archive.files,
+            path.display(),
+            archive.root().display()
+        );
+        local_paths.push(archive.root().to_path_buf());
+        archives.push(archive);
+    }
+    Ok((local_paths, archives))
+}
+
 /// Validate that all input paths exist and return them.
 fn validate_input_paths(paths: &[PathBuf]) -> anyhow::Result<Vec<PathBuf>> {
     let mut valid_paths = Vec::new();
@@ -137,6 +472,321 @@ fn validate_input_paths(paths: &[PathBuf]) -> anyhow::Result<Vec<PathBuf>> {
     Ok(valid_paths)
 }
 
+/// Files changed since `since` across the repositories containing `paths`.
+///
+/// Uncommitted working-tree changes are included unless `committed_only` is set.
+fn collect_changed_files(
+    paths: &[PathBuf],
+    since: &str,
+    committed_only: bool,
+) -> anyhow::Result<Vec<PathBuf>> {
+    let mut changed = Vec::new();
+    for path in paths {
+        changed.extend(changed_files_since(path, since, committed_only)?);
+    }
+    changed.sort();
+    changed.dedup();
+    Ok(changed)
+}
+
+/// Write the `depends_on` graph of any Terraform blocks under `paths`.
+///
+/// Nothing is written when the paths hold no `.tf` files.
+fn export_terraform_graph(
+    paths: &[PathBuf],
+    format: DepGraphFormat,
+    out_dir: &Path,
+    quiet_mode: bool,
+) -> anyhow::Result<()> {
+    let graph = TerraformGraph::from_paths(paths)?;
+    if graph.is_empty() {
+        return Ok(());
+    }
+
+    let (file_name, content) = match format {
+        DepGraphFormat::Dot => ("terraform-graph.dot", graph.to_dot()),
+        DepGraphFormat::Json => ("terraform-graph.json", graph.to_json()?),
+    };
+    let output_path = out_dir.join(file_name);
+    std::fs::write(&output_path, content)?;
+
+    if !quiet_mode {
+        println!(
+            "Terraform graph: {} blocks, {} references",
+            graph.blocks.len(),
+            graph.edge_count()
+        );
+        println!("Report: {}", output_path.display());
+    }
+
+    Ok(())
+}
+
+/// Write the project graph of any Gradle multi-project builds under `paths`.
+///
+/// Nothing is written when the paths hold no `settings.gradle(.kts)` file.
+fn export_gradle_graph(
+    paths: &[PathBuf],
+    format: DepGraphFormat,
+    out_dir: &Path,
+    quiet_mode: bool,
+) -> anyhow::Result<()> {
+    let graph = GradleGraph::from_paths(paths)?;
+    if graph.is_empty() {
+        return Ok(());
+    }
+
+    let (file_name, content) = match format {
+        DepGraphFormat::Dot => ("gradle-graph.dot", graph.to_dot()),
+        DepGraphFormat::Json => ("gradle-graph.json", graph.to_json()?),
+    };
+    let output_path = out_dir.join(file_name);
+    std::fs::write(&output_path, content)?;
+
+    if !quiet_mode {
+        println!(
+            "Gradle graph: {} builds, {} projects, {} project dependencies",
+            graph.builds.len(),
+            graph.project_count(),
+            graph.edge_count()
+        );
+        for error in graph.builds.iter().flat_map(|build| &build.errors) {
+            eprintln!("  {}", error.yellow());
+        }
+        println!("Report: {}", output_path.display());
+    }
+
+    Ok(())
+}
+
+/// Write the target graph of any Swift packages under `paths`.
+///
+/// Nothing is written when the paths hold no `Package.swift` file.
+fn export_swift_package_graph(
+    paths: &[PathBuf],
+    format: DepGraphFormat,
+    out_dir: &Path,
+    quiet_mode: bool,
+) -> anyhow::Result<()> {
+    let graph = SwiftPackageGraph::from_paths(paths)?;
+    if graph.is_empty() {
+        return Ok(());
+    }
+
+    let (file_name, content) = match format {
+        DepGraphFormat::Dot => ("swift-package-graph.dot", graph.to_dot()),
+        DepGraphFormat::Json => ("swift-package-graph.json", graph.to_json()?),
+    };
+    let output_path = out_dir.join(file_name);
+    std::fs::write(&output_path, content)?;
+
+    if !quiet_mode {
+        println!(
+            "Swift package graph: {} packages, {} targets, {} dependencies",
+            graph.packages.len(),
+            graph.target_count(),
+            graph.edge_count()
+        );
+        for error in graph.packages.iter().flat_map(|package| &package.errors) {
+            eprintln!("  {}", error.yellow());
+        }
+        println!("Report: {}", output_path.display());
+    }
+
+    Ok(())
+}
+
+/// Write the import graph of any `.proto` files under `paths`.
+///
+/// Nothing is written when the paths hold no `.proto` files.
+fn export_proto_graph(
+    paths: &[PathBuf],
+    format: DepGraphFormat,
+    out_dir: &Path,
+    quiet_mode: bool,
+) -> anyhow::Result<()> {
+    let graph = ProtoGraph::from_paths(paths)?;
+    if graph.is_empty() {
+        return Ok(());
+    }
+
+    let (file_name, content) = match format {
+        DepGraphFormat::Dot => ("proto-graph.dot", graph.to_dot()),
+        DepGraphFormat::Json => ("proto-graph.json", graph.to_json()?),
+    };
+    let output_path = out_dir.join(file_name);
+    std::fs::write(&output_path, content)?;
+
+    if !quiet_mode {
+        println!(
+            "Proto graph: {} files, {} imports",
+            graph.files.len(),
+            graph.edge_count()
+        );
+        println!("Report: {}", output_path.display());
+    }
+
+    Ok(())
+}
+
+/// Write the build-stage graph of any Dockerfiles under `paths`.
+///
+/// Nothing is written when the paths hold no Dockerfiles.
+fn export_docker_graph(
+    paths: &[PathBuf],
+    format: DepGraphFormat,
+    out_dir: &Path,
+    quiet_mode: bool,
+) -> anyhow::Result<()> {
+    let graph = DockerGraph::from_paths(paths)?;
+    if graph.is_empty() {
+        return Ok(());
+    }
+
+    let (file_name, content) = match format {
+        DepGraphFormat::Dot => ("docker-graph.dot", graph.to_dot()),
+        DepGraphFormat::Json => ("docker-graph.json", graph.to_json()?),
+    };
+    let output_path = out_dir.join(file_name);
+    std::fs::write(&output_path, content)?;
+
+    if !quiet_mode {
+        println!(
+            "Docker graph: {} Dockerfiles, {} stages, {} stage dependencies",
+            graph.files.len(),
+            graph.stage_count(),
+            graph.edge_count()
+        );
+        println!("Report: {}", output_path.display());
+    }
+
+    Ok(())
+}
+
+/// Write the module graph of any JavaScript files under `paths`.
+///
+/// Nothing is written when the paths hold no JavaScript files.
+fn export_js_module_graph(
+    paths: &[PathBuf],
+    format: DepGraphFormat,
+    out_dir: &Path,
+    quiet_mode: bool,
+) -> anyhow::Result<()> {
+    let graph = JsModuleGraph::from_paths(paths)?;
+    if graph.is_empty() {
+        return Ok(());
+    }
+
+    let (file_name, content) = match format {
+        DepGraphFormat::Dot => ("js-module-graph.dot", graph.to_dot()),
+        DepGraphFormat::Json => ("js-module-graph.json", graph.to_json()?),
+    };
+    let output_path = out_dir.join(file_name);
+    std::fs::write(&output_path, content)?;
+
+    if !quiet_mode {
+        println!(
+            "JavaScript module graph: {} modules, {} imports, {} packages",
+            graph.modules.len(),
+            graph.edge_count(),
+            graph.packages.len()
+        );
+        for unresolved in &graph.unresolved {
+            println!(
+                "  unresolved: {}:{} imports '{}'",
+                unresolved.from, unresolved.line, unresolved.specifier
+            );
+        }
+        println!("Report: {}", output_path.display());
+    }
+
+    Ok(())
+}
+
+/// Build the Go and Java package import graph and write it to the output directory.
+///
+/// Trees holding more than one `go.mod` are analyzed module by module and
+/// exported as a stitched module graph instead.
+fn export_package_graph(
+    paths: &[PathBuf],
+    format: DepGraphFormat,
+    out_dir: &Path,
+    quiet_mode: bool,
+) -> anyhow::Result<()> {
+    if paths
+        .iter()
+        .map(|path| GoModuleGraph::count_modules(path))
+        .sum::<usize>()
+        > 1
+    {
+        return export_module_graph(paths, format, out_dir, quiet_mode);
+    }
+
+    let graph = PackageGraph::from_projects(paths)?;
+    for cycle in &graph.cycles {
+        warn!("Import cycle between packages: {}", cycle.join(", "));
+    }
+
+    let (file_name, content) = match format {
+        DepGraphFormat::Dot => ("package-graph.dot", graph.to_dot()),
+        DepGraphFormat::Json => ("package-graph.json", graph.to_json()?),
+    };
+    let output_path = out_dir.join(file_name);
+    std::fs::write(&output_path, content)?;
+
+    if !quiet_mode {
+        println!(
+            "Package graph: {} packages, {} imports, {} cycle(s)",
+            graph.packages.len(),
+            graph.edge_count(),
+            graph.cycles.len()
+        );
+        println!("Report: {}", output_path.display());
+    }
+
+    Ok(())
+}
+
+/// Analyze every Go module under `paths` and write the stitched module graph.
+///
+/// The report is always written; circular module dependencies then fail the command.
+fn export_module_graph(
+    paths: &[PathBuf],
+    format: DepGraphFormat,
+    out_dir: &Path,
+    quiet_mode: bool,
+) -> anyhow::Result<()> {
+    let graph = GoModuleGraph::discover(paths)?;
+
+    let (file_name, content) = match format {
+        DepGraphFormat::Dot => ("module-graph.dot", graph.to_dot()),
+        DepGraphFormat::Json => ("module-graph.json", graph.to_json()?),
+    };
+    let output_path = out_dir.join(file_name);
+    std::fs::write(&output_path, content)?;
+
+    if !quiet_mode {
+        println!(
+            "Module graph: {} modules, {} cross-module imports, {} cycle(s)",
+            graph.modules.len(),
+            graph.cross_module_imports.len(),
+            graph.cycles.len()
+        );
+        println!("Report: {}", output_path.display());
+    }
+
+    for error in &graph.errors {
+        eprintln!("error: {error}");
+    }
+    if graph.has_errors() {
+        return Err(anyhow::anyhow!(
+            "Go module graph has {} error(s)",
+            graph.errors.len()
+        ));
+    }
+    Ok(())
+}
+
 /// Display pre-analysis information including run overview and coverage preview.
 async fn display_pre_analysis_info(
     valid_paths: &[PathBuf],
@@ -1,5 +1,7 @@
 use super::*;
-use crate::cli::args::{DocAuditArgs, DocAuditFormat, McpManifestArgs, McpStdioArgs};
+use crate::cli::args::{
+    ContextStrategy, DocAuditArgs, DocAuditFormat, McpManifestArgs, McpStdioArgs,
+};
 use crate::cli::config_builder::apply_performance_profile;
 use anyhow::Result;
 use gag::BufferRedirect;
@@ -227,6 +229,7 @@ fn sample_candidate(path: &str, priority: Priority, score: f64) -> RefactoringCa
         issue_count: 1,
         suggestion_count: 1,
         coverage_percentage: None,
+        context: Default::default(),
     }
 }
 
@@ -234,16 +237,25 @@ fn sample_candidate(path: &str, priority: Priority, score: f64) -> RefactoringCa
 fn create_default_analyze_args() -> AnalyzeArgs {
     AnalyzeArgs {
         paths: vec![PathBuf::from("test")],
+        stdin: false,
+        stdin_path: None,
         out: PathBuf::from("output"),
         format: vec![OutputFormat::Json],
         output_bundle: None,
         config: None,
         quiet: false,
+        no_header: false,
+        dot_max_nodes: None,
+        output_filter: None,
+        redact_strings: false,
+        redact_comments: false,
+        stream: false,
         profile: PerformanceProfile::Balanced,
         quality_gate: QualityGateArgs {
             quality_gate: false,
             fail_on_issues: false,
             max_complexity: None,
+            max_function_complexity: None,
             min_health: None,
             min_doc_health: None,
             max_debt: None,
@@ -261,6 +273,8 @@ fn create_default_analyze_args() -> AnalyzeArgs {
             require_blocks: None,
             similarity: None,
             denoise_dry_run: false,
+            detect_duplicates: false,
+            min_clone_nodes: None,
         },
         advanced_clone: AdvancedCloneArgs {
             no_auto: false,
@@ -294,6 +308,27 @@ fn create_default_analyze_args() -> AnalyzeArgs {
             no_impact: false,
             no_lsh: false,
             cohesion: false,
+            no_cache: false,
+            cache_max_size: None,
+            exclude: Vec::new(),
+            no_ignore_file: false,
+            discovery_depth: None,
+            max_file_size: None,
+            timeout: None,
+            include_tests: false,
+            exclude_tests: false,
+            include_generated: false,
+            include_vendor: false,
+            since: None,
+            committed_only: false,
+            target_os: None,
+            target_arch: None,
+            tags: Vec::new(),
+            call_graph: false,
+            call_graph_depth: None,
+            dep_graph: None,
+            check_deps: false,
+            check_interfaces: false,
         },
         cohesion: CohesionArgs {
             cohesion_min_score: None,
@@ -308,6 +343,12 @@ fn create_default_analyze_args() -> AnalyzeArgs {
             oracle_slicing_threshold: None,
             oracle_dry_run: false,
         },
+        remote: RemoteArgs {
+            git_ref: None,
+            depth: 1,
+        },
+        profiling: ProfilingArgs::default(),
+        health: HealthScoreArgs::default(),
     }
 }
 
@@ -422,6 +463,8 @@ fn sample_analysis_results() -> AnalysisResults {
             critical_issues: 0,
             doc_health_score: 1.0,
             doc_issue_count: 0,
+            complexity: Default::default(),
+            timed_out: false,
         },
         normalized: None,
         passes: valknut_rs::api::results::StageResultsBundle::disabled(),
@@ -451,11 +494,22 @@ fn sample_analysis_results() -> AnalysisResults {
         coverage_packs: Vec::new(),
         warnings: Vec::new(),
         code_dictionary: CodeDictionary::default(),
+        rule_findings: Vec::new(),
+        changed_files_only: false,
+        dependency_report: Vec::new(),
+        interface_report: None,
+        project_health: None,
+        context_statistics: Default::default(),
         documentation: None,
         directory_health: HashMap::new(),
         file_health: HashMap::new(),
         entity_health: HashMap::new(),
         directory_health_tree: None,
+        skipped_files: Vec::new(),
+        generated_code: Default::default(),
+        notebook_metadata: Vec::new(),
+        duplicates: Default::default(),
+        file_complexity: Default::default(),
     }
 }
 
@@ -498,6 +552,7 @@ fn output_format_machine_readable_detection() {
     assert!(OutputFormat::Csv.is_machine_readable());
     assert!(OutputFormat::Sonar.is_machine_readable());
     assert!(OutputFormat::CiSummary.is_machine_readable());
+    assert!(OutputFormat::Summary.is_machine_readable());
     assert!(!OutputFormat::Markdown.is_machine_readable());
     assert!(!OutputFormat::Html.is_machine_readable());
     assert!(!OutputFormat::Pretty.is_machine_readable());
@@ -674,12 +729,34 @@ async fn generate_reports_with_output_bundle_ci() {
         .await
         .expect("bundle report generation should succeed");
 
-    // CI bundle should generate: json, sonar, ci-summary
+    // CI bundle should generate: json, sonar, sarif, ci-summary
     assert!(temp.path().join("analysis-results.json").exists());
     assert!(temp.path().join("sonarqube-issues.json").exists());
+    assert!(temp.path().join("valknut.sarif").exists());
     assert!(temp.path().join("ci-summary.json").exists());
 }
 
+#[test]
+fn fetch_remote_paths_passes_local_paths_through() {
+    let args = create_default_analyze_args();
+    let paths = vec![PathBuf::from("src"), PathBuf::from("./tests")];
+
+    let (local, checkouts) = fetch_remote_paths(&paths, &args.remote, true).unwrap();
+
+    assert_eq!(local, paths);
+    assert!(checkouts.is_empty());
+}
+
+#[test]
+fn fetch_remote_paths_rejects_ref_without_url() {
+    let mut args = create_default_analyze_args();
+    args.remote.git_ref = Some("main".to_string());
+
+    let err = fetch_remote_paths(&[PathBuf::from("src")], &args.remote, true).unwrap_err();
+
+    assert!(err.to_string().contains("--ref"));
+}
+
 #[test]
 fn effective_formats_combines_explicit_and_bundle() {
     use crate::cli::args::OutputBundle;
@@ -823,6 +900,115 @@ fn evaluate_quality_gates_reports_violations() {
     );
 }
 
+#[test]
+fn max_function_complexity_fails_without_quality_gate_mode() {
+    use valknut_rs::detectors::complexity::{
+        ComplexityAnalysisResult, ComplexityMetrics, ComplexitySeverity, HalsteadMetrics,
+    };
+
+    let mut result = sample_analysis_results();
+    result
+        .passes
+        .complexity
+        .detailed_results
+        .push(ComplexityAnalysisResult {
+            entity_id: "src/lib.rs:tangled".to_string(),
+            file_path: "src/lib.rs".to_string(),
+            line_number: 12,
+            start_line: 12,
+            entity_name: "tangled".to_string(),
+            entity_type: "function".to_string(),
+            metrics: ComplexityMetrics {
+                cyclomatic_complexity: 14.0,
+                cognitive_complexity: 20.0,
+                max_nesting_depth: 4.0,
+                parameter_count: 2.0,
+                lines_of_code: 60.0,
+                statement_count: 40.0,
+                expression_complexity: 0.0,
+                halstead: HalsteadMetrics::default(),
+                technical_debt_score: 40.0,
+                maintainability_index: 50.0,
+                decision_points: Vec::new(),
+            },
+            issues: Vec::new(),
+            severity: ComplexitySeverity::High,
+            recommendations: Vec::new(),
+        });
+
+    let mut args = create_default_analyze_args();
+    args.quality_gate.max_function_complexity = Some(10);
+    let gate = evaluate_quality_gates_if_enabled(&result, &args, true)
+        .unwrap()
+        .expect("gate runs when --max-function-complexity is set");
+    assert!(!gate.passed);
+    assert_eq!(gate.violations.len(), 1);
+    assert_eq!(gate.violations[0].current_value, 14.0);
+    assert_eq!(
+        gate.violations[0].affected_files,
+        vec![PathBuf::from("src/lib.rs")]
+    );
+
+    args.quality_gate.max_function_complexity = Some(14);
+    let gate = evaluate_quality_gates_if_enabled(&result, &args, true)
+        .unwrap()
+        .unwrap();
+    assert!(gate.passed);
+}
+
+#[test]
+fn min_health_score_fails_without_quality_gate_mode() {
+    use valknut_rs::core::project_health::{HealthComponent, HealthCriterion, ProjectHealth};
+
+    let mut result = sample_analysis_results();
+    result.project_health = Some(ProjectHealth::from_components(vec![
+        HealthComponent::scored(
+            HealthCriterion::Coverage,
+            30.0,
+            50.0,
+            "50.0% of lines covered",
+        ),
+        HealthComponent::scored(
+            HealthCriterion::License,
+            10.0,
+            100.0,
+            "license file present",
+        ),
+    ]));
+
+    let mut args = create_default_analyze_args();
+    args.health.min_health_score = Some(70.0);
+    let gate = evaluate_quality_gates_if_enabled(&result, &args, true)
+        .unwrap()
+        .expect("gate runs when --min-health-score is set");
+    assert!(!gate.passed);
+    assert_eq!(gate.violations.len(), 1);
+    assert_eq!(gate.violations[0].rule_name, "Project Health Score");
+    assert_eq!(gate.violations[0].current_value, 62.5);
+    assert!(gate.violations[0]
+        .description
+        .contains("weakest: coverage 50.0"));
+
+    args.health.min_health_score = Some(60.0);
+    let gate = evaluate_quality_gates_if_enabled(&result, &args, true)
+        .unwrap()
+        .unwrap();
+    assert!(gate.passed);
+}
+
+#[test]
+fn health_weights_apply_overrides() {
+    let mut args = HealthScoreArgs::default();
+    assert_eq!(health_weights(&args), HealthWeights::default());
+
+    args.health_weight_coverage = Some(0.0);
+    args.health_weight_license = Some(40.0);
+    let weights = health_weights(&args);
+    assert_eq!(weights.coverage, 0.0);
+    assert_eq!(weights.license, 40.0);
+    assert_eq!(weights.complexity, HealthWeights::default().complexity);
+}
+
 #[test]
 fn evaluate_quality_gates_handles_missing_metrics_when_verbose() {
     let mut result = sample_analysis_results();
@@ -1111,7 +1297,13 @@ async fn test_validate_config_verbose() {
 
 #[tokio::test]
 async fn test_mcp_stdio_command() {
-    let args = McpStdioArgs { config: None };
+    let args = McpStdioArgs {
+        config: None,
+        context_budget: None,
+        context_strategy: ContextStrategy::DropFiles,
+        symbol_filter: None,
+        symbol_filter_base: "main".to_string(),
+    };
 
     let result = mcp_stdio_command(args, false, SurveyVerbosity::Low).await;
     assert!(result.is_ok());
@@ -1126,6 +1318,10 @@ async fn test_mcp_stdio_command_with_config() {
 
     let args = McpStdioArgs {
         config: Some(temp_file.path().to_path_buf()),
+        context_budget: Some(8_000),
+        context_strategy: ContextStrategy::TruncateBodies,
+        symbol_filter: Some("exported,documented".parse().unwrap()),
+        symbol_filter_base: "main".to_string(),
     };
 
     let result = mcp_stdio_command(args, true, SurveyVerbosity::High).await;
@@ -1565,6 +1761,57 @@ async fn test_analyze_command_quiet_mode_on_minimal_project() {
     );
 }
 
+#[tokio::test]
+async fn analyze_command_streams_ndjson_format_in_stream_schema() {
+    let project = TempDir::new().expect("temp project");
+    for name in ["a.py", "b.py"] {
+        fs::write(
+            project.path().join(name),
+            "def f(x):\n    if x:\n        return 1\n    return 0\n",
+        )
+        .expect("write sample file");
+    }
+    let output = TempDir::new().expect("output dir");
+
+    let mut args = create_default_analyze_args();
+    args.paths = vec![project.path().to_path_buf()];
+    args.out = output.path().join("reports");
+    args.format = vec![OutputFormat::Ndjson];
+
+    analyze_command(args, false, SurveyVerbosity::Low, false)
+        .await
+        .expect("ndjson analysis succeeds");
+
+    let content = fs::read_to_string(output.path().join("reports/analysis-results.ndjson"))
+        .expect("ndjson report written");
+    let records: Vec<serde_json::Value> = content
+        .lines()
+        .map(|line| serde_json::from_str(line).expect("valid NDJSON line"))
+        .collect();
+    assert_eq!(records.len(), 3);
+    assert!(records[..2].iter().all(|r| r["type"] == "file"));
+    let summary = &records[2];
+    assert_eq!(summary["type"], "summary");
+    assert_eq!(summary["files_discovered"], 2);
+}
+
+#[tokio::test]
+async fn analyze_command_rejects_ndjson_format_with_other_formats() {
+    let project = TempDir::new().expect("temp project");
+    fs::write(project.path().join("a.py"), "def f():\n    return 1\n").expect("write sample file");
+    let output = TempDir::new().expect("output dir");
+
+    let mut args = create_default_analyze_args();
+    args.paths = vec![project.path().to_path_buf()];
+    args.out = output.path().join("reports");
+    args.format = vec![OutputFormat::Ndjson, OutputFormat::Json];
+
+    let err = analyze_command(args, false, SurveyVerbosity::Low, false)
+        .await
+        .expect_err("ndjson cannot share a run with batch formats");
+    assert!(err.to_string().contains("--format ndjson"));
+}
+
 #[tokio::test]
 #[serial]
 async fn run_analysis_with_progress_handles_denoise_configuration() -> Result<()> {
@@ -0,0 +1,138 @@
+//! Snapshot archive command implementation.
+//!
+//! `valknut archive --tag <tag>` compresses the JSON written by
+//! `analyze --format json` into the archive directory; `archive list` and
+//! `archive prune --keep-last N` inspect and trim it. Archived snapshots are
+//! compared with `valknut diff archive:<old> archive:<new>`.
+
+use std::path::PathBuf;
+
+use anyhow::Context;
+use owo_colors::OwoColorize;
+use tabled::{settings::Style as TableStyle, Table, Tabled};
+
+use crate::cli::args::{ArchiveArgs, ArchiveCommand};
+use valknut_rs::core::snapshot_diff::Snapshot;
+use valknut_rs::io::snapshot_archive::{ArchiveEntry, ArchiveStore};
+
+/// Run the archive command: save a snapshot, or list or prune the archive.
+pub fn archive_command(args: ArchiveArgs) -> anyhow::Result<()> {
+    let store = ArchiveStore::open(archive_dir(args.archive_dir.clone())?);
+
+    match args.command {
+        Some(ArchiveCommand::List { json }) => {
+            let entries = store.list()?;
+            if json {
+                println!("{}", serde_json::to_string_pretty(&entries)?);
+            } else if entries.is_empty() {
+                println!(
+                    "{} No archived snapshots; run `valknut archive --tag <tag>` first.",
+                    "ℹ️".bright_blue()
+                );
+            } else {
+                print!("{}", render_list(&entries));
+            }
+        }
+        Some(ArchiveCommand::Prune { keep_last }) => {
+            let removed = store.prune(keep_last)?;
+            for entry in &removed {
+                println!("Removed {}", entry.tag);
+            }
+            println!(
+                "{} Pruned {} archive(s), kept the {} most recent",
+                "✅".green(),
+                removed.len(),
+                keep_last
+            );
+        }
+        None => {
+            let tag = args.tag.context("--tag is required")?;
+            let content = std::fs::read_to_string(&args.from).with_context(|| {
+                format!(
+                    "Failed to read {}; run `valknut analyze --format json` first",
+                    args.from.display()
+                )
+            })?;
+            let snapshot: serde_json::Value = serde_json::from_str(&content)
+                .with_context(|| format!("{} is not valid JSON", args.from.display()))?;
+            // Only archive snapshots `valknut diff` can read back.
+            Snapshot::from_value(&snapshot)?;
+
+            let entry = store.save(&tag, &snapshot, args.force)?;
+            println!(
+                "{} Archived {} ({} files, {}) to {}",
+                "✅".green(),
+                entry.tag.bold(),
+                entry.file_count,
+                format_size(entry.size_bytes),
+                store.path_for(&entry.tag).display()
+            );
+        }
+    }
+    Ok(())
+}
+
+/// The archive directory from `--archive-dir`, or the default.
+pub fn archive_dir(explicit: Option<PathBuf>) -> anyhow::Result<PathBuf> {
+    explicit
+        .or_else(ArchiveStore::default_dir)
+        .context("no archive directory: pass --archive-dir")
+}
+
+/// Render the archive as a table, oldest first.
+fn render_list(entries: &[ArchiveEntry]) -> String {
+    #[derive(Tabled)]
+    struct ArchiveRow {
+        tag: String,
+        date: String,
+        size: String,
+        files: usize,
+    }
+
+    let rows = entries.iter().map(|entry| ArchiveRow {
+        tag: entry.tag.clone(),
+        date: entry.archived_at.format("%Y-%m-%d %H:%M").to_string(),
+        size: format_size(entry.size_bytes),
+        files: entry.file_count,
+    });
+    format!("{}\n", Table::new(rows).with(TableStyle::rounded()))
+}
+
+/// Human-readable byte count.
+fn format_size(bytes: u64) -> String {
+    const UNITS: [&str; 4] = ["B", "KB", "MB", "GB"];
+    let mut size = bytes as f64;
+    let mut unit = 0;
+    while size >= 1024.0 && unit < UNITS.len() - 1 {
+        size /= 1024.0;
+        unit += 1;
+    }
+    if unit == 0 {
+        format!("{bytes} B")
+    } else {
+        format!("{size:.1} {}", UNITS[unit])
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use chrono::{TimeZone, Utc};
+
+    #[test]
+    fn list_shows_tag_date_size_and_file_count() {
+        let entries = vec![ArchiveEntry {
+            tag: "v1.2.3".to_string(),
+            archived_at: Utc.with_ymd_and_hms(2026, 3, 1, 9, 30, 0).unwrap(),
+            size_bytes: 2048,
+            file_count: 42,
+        }];
+
+        let table = render_list(&entries);
+        for expected in ["v1.2.3", "2026-03-01 09:30", "2.0 KB", "42"] {
+            assert!(table.contains(expected), "missing {expected} in\n{table}");
+        }
+        assert_eq!(format_size(512), "512 B");
+        assert_eq!(format_size(3 << 20), "3.0 MB");
+    }
+}
@@ -0,0 +1,122 @@
+//! Symbol ownership command implementation.
+//!
+//! `valknut blame <package>` attributes each function and type to the author
+//! of its most recent change, so reviewers know whom to ping. `--top-owners N`
+//! adds a leaderboard of authors by lines owned and symbol count.
+
+use tabled::{settings::Style as TableStyle, Table, Tabled};
+
+use crate::cli::args::{BlameArgs, BlameFormat};
+use valknut_rs::core::blame::{blame_symbols, BlameReport, OwnerSummary};
+use valknut_rs::io::cache::IncrementalCache;
+
+/// Run the blame command and print symbol owners.
+pub fn blame_command(args: BlameArgs) -> anyhow::Result<()> {
+    let cache_dir = if args.no_cache {
+        None
+    } else {
+        args.cache_dir
+            .clone()
+            .or_else(IncrementalCache::default_dir)
+    };
+    let report = blame_symbols(&args.path, cache_dir.as_deref())?;
+
+    match args.format {
+        BlameFormat::Json => {
+            let owners = match args.top_owners {
+                Some(limit) => report.top_owners(limit),
+                None => &report.owners[..],
+            };
+            let output = serde_json::json!({
+                "symbols": report.symbols,
+                "owners": owners,
+            });
+            println!("{}", serde_json::to_string_pretty(&output)?);
+        }
+        BlameFormat::Text => {
+            print!("{}", render_symbols(&report));
+            if let Some(limit) = args.top_owners {
+                println!();
+                println!("{}", render_leaderboard(report.top_owners(limit)));
+            }
+        }
+    }
+    Ok(())
+}
+
+/// Render one `path:start-end name owner date` row per symbol.
+fn render_symbols(report: &BlameReport) -> String {
+    let mut output = String::new();
+    for symbol in &report.symbols {
+        output.push_str(&format!(
+            "{}:{}-{} {} ({}) {} <{}> {}\n",
+            symbol.file_path.display(),
+            symbol.start_line,
+            symbol.end_line,
+            symbol.name,
+            symbol.kind,
+            symbol.owner_name,
+            symbol.owner_email,
+            symbol.last_modified_date.format("%Y-%m-%d"),
+        ));
+    }
+    output
+}
+
+/// Render the owner leaderboard as a table.
+fn render_leaderboard(owners: &[OwnerSummary]) -> String {
+    #[derive(Tabled)]
+    struct OwnerRow {
+        rank: usize,
+        owner: String,
+        lines: usize,
+        symbols: usize,
+    }
+
+    let rows = owners.iter().enumerate().map(|(index, owner)| OwnerRow {
+        rank: index + 1,
+        owner: format!("{} <{}>", owner.name, owner.email),
+        lines: owner.lines_owned,
+        symbols: owner.symbols,
+    });
+    Table::new(rows).with(TableStyle::rounded()).to_string()
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use chrono::{TimeZone, Utc};
+    use std::path::PathBuf;
+    use valknut_rs::core::blame::SymbolOwnership;
+
+    #[test]
+    fn renders_symbols_and_leaderboard() {
+        let report = BlameReport {
+            symbols: vec![SymbolOwnership {
+                name: "Area".to_string(),
+                kind: "Function".to_string(),
+                file_path: PathBuf::from("shapes.go"),
+                start_line: 3,
+                end_line: 5,
+                owner_name: "Ada".to_string(),
+                owner_email: "ada@example.com".to_string(),
+                last_modified_date: Utc.with_ymd_and_hms(2024, 5, 1, 12, 0, 0).unwrap(),
+                commit: "abc123".to_string(),
+            }],
+            owners: vec![OwnerSummary {
+                email: "ada@example.com".to_string(),
+                name: "Ada".to_string(),
+                lines_owned: 42,
+                symbols: 1,
+            }],
+        };
+
+        assert_eq!(
+            render_symbols(&report),
+            "shapes.go:3-5 Area (Function) Ada <ada@example.com> 2024-05-01\n"
+        );
+        let table = render_leaderboard(report.top_owners(5));
+        assert!(table.contains("Ada <ada@example.com>"));
+        assert!(table.contains("42"));
+    }
+}
@@ -0,0 +1,166 @@
+//! Structural rule check command implementation.
+//!
+//! `valknut check` analyses a directory against the `[[check.rules]]` declared
+//! in valknut.toml (or `.valknut.yaml`), prints every violation as
+//! `path:line: severity [rule] message` followed by a summary, and exits
+//! non-zero when a warning or error rule is violated, so it can gate CI.
+//! Rules are the configured rules of the engine: built-ins such as
+//! `no-circular-imports`, `expr` predicates over functions and types, and
+//! jq-style `query` filters over the JSON report.
+
+use std::collections::{BTreeMap, BTreeSet};
+use std::path::Path;
+
+use anyhow::Context;
+use owo_colors::OwoColorize;
+use serde_json::json;
+
+use crate::cli::args::{CheckArgs, CheckFormat};
+use crate::cli::flag_config::{discover_flag_files, load_check_rules};
+use valknut_rs::api::engine::ValknutEngine;
+use valknut_rs::core::config::ValknutConfig;
+use valknut_rs::detectors::rules::{RuleFinding, RuleSeverity};
+
+/// Run the check command, failing when a warning or error rule is violated.
+pub async fn check_command(args: CheckArgs) -> anyhow::Result<()> {
+    let rule_files = match &args.rules {
+        Some(path) => vec![path.clone()],
+        None => discover_flag_files(&args.path),
+    };
+    let rules = load_check_rules(&rule_files)?;
+    if rules.is_empty() {
+        anyhow::bail!(
+            "no rules to check: declare [[check.rules]] in valknut.toml (searched {})",
+            describe_files(&rule_files)
+        );
+    }
+
+    let mut config = ValknutConfig::default();
+    config.rules = rules;
+    let mut engine = ValknutEngine::new_from_valknut_config(config).await?;
+    let results = engine
+        .analyze_directory(&args.path)
+        .await
+        .with_context(|| format!("failed to analyse {}", args.path.display()))?;
+    let findings = results.rule_findings;
+
+    match args.format {
+        CheckFormat::Text => print!("{}", render_text(&findings)),
+        CheckFormat::Json => println!("{}", serde_json::to_string_pretty(&render_json(&findings))?),
+    }
+
+    let failing = findings
+        .iter()
+        .filter(|finding| finding.severity >= RuleSeverity::Warning)
+        .count();
+    if failing > 0 {
+        anyhow::bail!("{failing} rule violation(s)");
+    }
+    Ok(())
+}
+
+/// One `path:line: severity [rule] message` row per finding and a summary,
+/// uncoloured so editors and CI logs can link the locations.
+pub(crate) fn render_text(findings: &[RuleFinding]) -> String {
+    let mut out = String::new();
+    for finding in findings {
+        let location = match finding.line_range {
+            Some((start, _)) => format!("{}:{start}", finding.file_path),
+            None => finding.file_path.clone(),
+        };
+        out.push_str(&format!(
+            "{location}: {} [{}] {}\n",
+            finding.severity, finding.rule, finding.message
+        ));
+    }
+
+    if findings.is_empty() {
+        out.push_str(&format!("{} All rules passed\n", "✅".green()));
+        return out;
+    }
+    let counts = severity_counts(findings);
+    let files = findings
+        .iter()
+        .map(|finding| finding.file_path.as_str())
+        .collect::<BTreeSet<_>>()
+        .len();
+    let breakdown: Vec<String> = counts
+        .iter()
+        .rev()
+        .map(|(severity, count)| format!("{severity}: {count}"))
+        .collect();
+    out.push_str(&format!(
+        "\n{} violation(s) in {files} location(s) ({})\n",
+        findings.len(),
+        breakdown.join(", ")
+    ));
+    out
+}
+
+/// JSON document with every violation and per-severity counts.
+fn render_json(findings: &[RuleFinding]) -> serde_json::Value {
+    let counts: BTreeMap<String, usize> = severity_counts(findings)
+        .into_iter()
+        .map(|(severity, count)| (severity.to_string(), count))
+        .collect();
+    json!({
+        "violations": findings,
+        "counts": counts,
+    })
+}
+
+/// Number of findings per severity, lowest severity first.
+fn severity_counts(findings: &[RuleFinding]) -> BTreeMap<RuleSeverity, usize> {
+    let mut counts = BTreeMap::new();
+    for finding in findings {
+        *counts.entry(finding.severity).or_default() += 1;
+    }
+    counts
+}
+
+/// Comma-separated file list for error messages.
+fn describe_files(paths: &[impl AsRef<Path>]) -> String {
+    if paths.is_empty() {
+        return "no flag files found".to_string();
+    }
+    paths
+        .iter()
+        .map(|path| path.as_ref().display().to_string())
+        .collect::<Vec<_>>()
+        .join(", ")
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn finding(rule: &str, severity: RuleSeverity, path: &str, line: Option<usize>) -> RuleFinding {
+        RuleFinding {
+            rule: rule.to_string(),
+            severity,
+            message: format!("{rule} violated"),
+            file_path: path.to_string(),
+            entity: None,
+            line_range: line.map(|line| (line, line + 4)),
+        }
+    }
+
+    #[test]
+    fn text_lists_locations_and_summarises_by_severity() {
+        let findings = vec![
+            finding("acyclic", RuleSeverity::Error, ".", None),
+            finding("complexity", RuleSeverity::Warning, "pkg/a.go", Some(12)),
+            finding("docs", RuleSeverity::Warning, "pkg/a.go", Some(40)),
+        ];
+
+        let text = render_text(&findings);
+        assert!(text.contains(".: error [acyclic] acyclic violated\n"));
+        assert!(text.contains("pkg/a.go:12: warning [complexity] complexity violated\n"));
+        assert!(text.ends_with("3 violation(s) in 2 location(s) (error: 1, warning: 2)\n"));
+        assert!(render_text(&[]).contains("All rules passed"));
+
+        let document = render_json(&findings);
+        assert_eq!(document["counts"], json!({ "error": 1, "warning": 2 }));
+        assert_eq!(document["violations"][1]["line_range"], json!([12, 16]));
+    }
+}
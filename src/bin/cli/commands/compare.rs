@@ -0,0 +1,147 @@
+//! Cross-repository comparison command implementation.
+//!
+//! `valknut compare <repo-a> <repo-b>` reports where two repositories built
+//! from a common template have drifted apart: packages only one of them has,
+//! same-named functions with different signatures, shared types, and module
+//! requirements or package dependencies that differ. Every item says which
+//! repository it comes from.
+
+use crate::cli::args::{CompareArgs, CompareFormat};
+use valknut_rs::core::repo_compare::{compare_repos, Repo, RepoComparison, RepoSnapshot};
+
+/// Run the compare command and print the comparison.
+pub fn compare_command(args: CompareArgs) -> anyhow::Result<()> {
+    let a = RepoSnapshot::collect(&args.repo_a)?;
+    let b = RepoSnapshot::collect(&args.repo_b)?;
+    let comparison = compare_repos(&a, &b);
+
+    match args.format {
+        CompareFormat::Json => println!("{}", serde_json::to_string_pretty(&comparison)?),
+        CompareFormat::Text => print!("{}", render_text(&comparison)),
+    }
+    Ok(())
+}
+
+/// Render the comparison as sections of `[a]`/`[b]`-labelled items.
+fn render_text(comparison: &RepoComparison) -> String {
+    let summary = &comparison.summary;
+    let label = |repo: Repo| match repo {
+        Repo::A => "a",
+        Repo::B => "b",
+    };
+    let mut output = format!(
+        "a = {}\nb = {}\nPackages: {} in a, {} in b, {} shared\nFunctions: {} matching, {} with different signatures\nTypes: {} shared\nModules: {} shared, {} diverged\n",
+        comparison.repo_a,
+        comparison.repo_b,
+        summary.packages_a,
+        summary.packages_b,
+        summary.shared_packages,
+        summary.matching_functions,
+        summary.signature_mismatches,
+        summary.shared_types,
+        summary.shared_modules,
+        summary.diverged_modules
+    );
+    if comparison.is_identical() {
+        output.push_str("\nNo structural differences.\n");
+    }
+
+    if !comparison.packages_only_in.is_empty() {
+        output.push_str("\nPackages in one repository only\n");
+        for package in &comparison.packages_only_in {
+            output.push_str(&format!(
+                "  [{}] {} ({} files, {} exported symbols)\n",
+                label(package.repo),
+                package.package,
+                package.files,
+                package.symbols
+            ));
+        }
+    }
+
+    if !comparison.signature_mismatches.is_empty() {
+        output.push_str("\nSignature differences\n");
+        for mismatch in &comparison.signature_mismatches {
+            output.push_str(&format!("  {}.{}\n", mismatch.package, mismatch.name));
+            for (repo, side) in [(Repo::A, &mismatch.a), (Repo::B, &mismatch.b)] {
+                output.push_str(&format!(
+                    "    [{}] {} ({}:{})\n",
+                    label(repo),
+                    side.signature.as_deref().unwrap_or("?"),
+                    side.file_path,
+                    side.line
+                ));
+            }
+        }
+    }
+
+    if !comparison.shared_types.is_empty() {
+        output.push_str("\nShared types\n");
+        for shared in &comparison.shared_types {
+            let kind = if shared.a.kind == shared.b.kind {
+                shared.a.kind.to_lowercase()
+            } else {
+                format!("{} in a, {} in b", shared.a.kind, shared.b.kind).to_lowercase()
+            };
+            output.push_str(&format!("  {}.{} ({kind})\n", shared.package, shared.name));
+        }
+    }
+
+    if !comparison.module_divergence.is_empty() {
+        output.push_str("\nModule requirements\n");
+        for module in &comparison.module_divergence {
+            output.push_str(&format!(
+                "  {}: a {}, b {}\n",
+                module.module,
+                module.version_a.as_deref().unwrap_or("-"),
+                module.version_b.as_deref().unwrap_or("-")
+            ));
+        }
+    }
+
+    if !comparison.dependencies_only_in.is_empty() {
+        output.push_str("\nPackage dependencies in one repository only\n");
+        for dep in &comparison.dependencies_only_in {
+            output.push_str(&format!(
+                "  [{}] {} -> {} ({} calls)\n",
+                label(dep.repo),
+                dep.from,
+                dep.to,
+                dep.calls
+            ));
+        }
+    }
+    output
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use valknut_rs::core::repo_compare::{ModuleDivergence, PackageOnlyIn};
+
+    #[test]
+    fn render_text_labels_items_by_repository() {
+        let comparison = RepoComparison {
+            repo_a: "billing".to_string(),
+            repo_b: "shipping".to_string(),
+            packages_only_in: vec![PackageOnlyIn {
+                package: "tracking".to_string(),
+                repo: Repo::B,
+                files: 2,
+                symbols: 5,
+            }],
+            module_divergence: vec![ModuleDivergence {
+                module: "go.uber.org/zap".to_string(),
+                version_a: Some("v1.26.0".to_string()),
+                version_b: None,
+            }],
+            ..RepoComparison::default()
+        };
+
+        let text = render_text(&comparison);
+        assert!(text.starts_with("a = billing\nb = shipping\n"));
+        assert!(text.contains("\n  [b] tracking (2 files, 5 exported symbols)\n"));
+        assert!(text.contains("\n  go.uber.org/zap: a v1.26.0, b -\n"));
+        assert!(render_text(&RepoComparison::default()).contains("No structural differences."));
+    }
+}
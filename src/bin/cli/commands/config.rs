@@ -11,8 +11,11 @@ use serde_yaml;
 use tabled::{settings::Style as TableStyle, Table, Tabled};
 
 use crate::cli::analysis_display::display_config_summary;
-use crate::cli::args::{InitConfigArgs, ValidateConfigArgs};
+use crate::cli::args::{
+    ConfigArgs, ConfigCommand, ConfigValidateArgs, InitConfigArgs, ValidateConfigArgs,
+};
 use crate::cli::config_builder::load_configuration;
+use crate::cli::flag_config::{discover_flag_files, validate_flag_file};
 use valknut_rs::detectors::structure::StructureConfig;
 
 /// Print default configuration in YAML format
@@ -183,3 +186,69 @@ pub async fn validate_config(args: ValidateConfigArgs) -> anyhow::Result<()> {
 
     Ok(())
 }
+
+/// Run a `valknut config` subcommand
+pub fn config_command(args: ConfigArgs) -> anyhow::Result<()> {
+    match args.command {
+        ConfigCommand::Validate(args) => validate_flag_files(args),
+    }
+}
+
+/// Validate CLI flag files for unknown keys and invalid values
+fn validate_flag_files(args: ConfigValidateArgs) -> anyhow::Result<()> {
+    let files = match args.file {
+        Some(file) => vec![file],
+        None => discover_flag_files(&std::env::current_dir()?),
+    };
+    if files.is_empty() {
+        anyhow::bail!(
+            "No valknut.toml or .valknut.yaml found in the repository root or current directory"
+        );
+    }
+
+    let mut invalid = 0;
+    for file in &files {
+        println!(
+            "{} {}",
+            "🔍 Validating flag file:".bright_blue().bold(),
+            file.display().to_string().cyan()
+        );
+
+        let issues = match validate_flag_file(file) {
+            Ok(issues) => issues,
+            Err(e) => {
+                eprintln!("   {} {:#}", "❌".red(), e);
+                invalid += 1;
+                continue;
+            }
+        };
+
+        if issues.is_empty() {
+            println!("   {}", "✅ All keys are valid".bright_green());
+            continue;
+        }
+        invalid += 1;
+        for issue in issues {
+            eprintln!(
+                "   {} {}: {}",
+                "❌".red(),
+                issue.key.yellow(),
+                issue.message
+            );
+        }
+    }
+
+    if invalid > 0 {
+        println!();
+        println!(
+            "{}",
+            "💡 Tip: Keys are long-form CLI flags, e.g. max-complexity = 60".dimmed()
+        );
+        anyhow::bail!(
+            "{} of {} flag file(s) failed validation",
+            invalid,
+            files.len()
+        );
+    }
+    Ok(())
+}
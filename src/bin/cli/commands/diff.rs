@@ -0,0 +1,185 @@
+//! Snapshot diff command implementation.
+//!
+//! `valknut diff <before.json> <after.json>` compares two analysis snapshots by
+//! symbol identity and reports what changed structurally: symbols added,
+//! removed, renamed, moved or modified, and files that appeared or disappeared.
+//! Either side may be `archive:<tag>` to read a snapshot saved by
+//! `valknut archive`.
+
+use std::collections::BTreeMap;
+use std::path::Path;
+
+use super::archive::archive_dir;
+use crate::cli::args::{DiffArgs, DiffFormat};
+use valknut_rs::core::snapshot_diff::{
+    diff_snapshots, load_snapshot, Snapshot, SnapshotDiff, SymbolChange, SymbolChangeKind,
+};
+use valknut_rs::io::snapshot_archive::{parse_archive_ref, ArchiveStore};
+
+/// Run the diff command and print the changes between two snapshots.
+pub fn diff_command(args: DiffArgs) -> anyhow::Result<()> {
+    let before = load(&args.before, &args)?;
+    let after = load(&args.after, &args)?;
+    let diff = diff_snapshots(&before, &after);
+
+    match args.format {
+        DiffFormat::Json => println!("{}", serde_json::to_string_pretty(&diff)?),
+        DiffFormat::Text => print!("{}", render_text(&diff)),
+    }
+    Ok(())
+}
+
+/// Load a snapshot file, or the archived snapshot an `archive:<tag>` names.
+fn load(path: &Path, args: &DiffArgs) -> anyhow::Result<Snapshot> {
+    match path.to_str().and_then(parse_archive_ref) {
+        Some(tag) => {
+            let store = ArchiveStore::open(archive_dir(args.archive_dir.clone())?);
+            Ok(Snapshot::from_value(&store.load(tag)?)?)
+        }
+        None => Ok(load_snapshot(path)?),
+    }
+}
+
+/// Render the diff as a change list grouped by file.
+fn render_text(diff: &SnapshotDiff) -> String {
+    if diff.is_empty() {
+        return "No structural changes.\n".to_string();
+    }
+
+    let summary = &diff.summary;
+    let mut output = format!(
+        "Files: +{} -{}\nSymbols: {} added, {} removed, {} renamed, {} moved, {} kind changed, {} modified\n",
+        summary.files_added,
+        summary.files_removed,
+        summary.added,
+        summary.removed,
+        summary.renamed,
+        summary.moved,
+        summary.kind_changed,
+        summary.modified
+    );
+    for file in &diff.files_added {
+        output.push_str(&format!("  + {file}\n"));
+    }
+    for file in &diff.files_removed {
+        output.push_str(&format!("  - {file}\n"));
+    }
+
+    let mut by_file: BTreeMap<&str, Vec<&SymbolChange>> = BTreeMap::new();
+    for change in &diff.changes {
+        by_file.entry(&change.file_path).or_default().push(change);
+    }
+    for (file, changes) in by_file {
+        output.push_str(&format!("\n{file}\n"));
+        for change in changes {
+            output.push_str(&format!("  {}\n", describe(change)));
+        }
+    }
+    output
+}
+
+/// One-line description of a symbol change.
+fn describe(change: &SymbolChange) -> String {
+    let marker = match change.change {
+        SymbolChangeKind::Added => "+",
+        SymbolChangeKind::Removed => "-",
+        SymbolChangeKind::Renamed | SymbolChangeKind::Moved => ">",
+        SymbolChangeKind::KindChanged => "!",
+        SymbolChangeKind::Modified => "~",
+    };
+    let mut line = format!(
+        "{marker} {} {} (line {})",
+        change.kind, change.name, change.line
+    );
+
+    let mut details = Vec::new();
+    match change.change {
+        SymbolChangeKind::Renamed => {
+            let from = change.previous_name.as_deref().unwrap_or("?");
+            match &change.previous_file {
+                Some(file) => details.push(format!("renamed from {from} in {file}")),
+                None => details.push(format!("renamed from {from}")),
+            }
+        }
+        SymbolChangeKind::Moved => {
+            let from = change.previous_file.as_deref().unwrap_or("?");
+            details.push(format!("moved from {from}"));
+        }
+        SymbolChangeKind::KindChanged => {
+            let from = change.previous_kind.as_deref().unwrap_or("?");
+            details.push(format!("was {from}"));
+        }
+        _ => {}
+    }
+    details.extend(change.metric_deltas.iter().map(|delta| {
+        format!(
+            "{} {} -> {}",
+            delta.metric.replace('_', " "),
+            delta.before,
+            delta.after
+        )
+    }));
+
+    if !details.is_empty() {
+        line.push_str(": ");
+        line.push_str(&details.join(", "));
+    }
+    line
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use valknut_rs::core::snapshot_diff::{DiffSummary, MetricDelta};
+
+    fn change(kind: SymbolChangeKind, name: &str) -> SymbolChange {
+        SymbolChange {
+            change: kind,
+            name: name.to_string(),
+            kind: "function".to_string(),
+            file_path: "src/lib.py".to_string(),
+            line: 3,
+            previous_name: None,
+            previous_file: None,
+            previous_kind: None,
+            metric_deltas: Vec::new(),
+        }
+    }
+
+    #[test]
+    fn render_text_groups_changes_by_file() {
+        let diff = SnapshotDiff {
+            summary: DiffSummary {
+                renamed: 1,
+                modified: 1,
+                ..DiffSummary::default()
+            },
+            files_added: Vec::new(),
+            files_removed: Vec::new(),
+            changes: vec![
+                SymbolChange {
+                    previous_name: Some("load".to_string()),
+                    ..change(SymbolChangeKind::Renamed, "read_config")
+                },
+                SymbolChange {
+                    metric_deltas: vec![MetricDelta {
+                        metric: "lines_of_code".to_string(),
+                        before: 10.0,
+                        after: 14.0,
+                    }],
+                    ..change(SymbolChangeKind::Modified, "run")
+                },
+            ],
+        };
+
+        let text = render_text(&diff);
+        assert!(text.contains("0 moved, 0 kind changed, 1 modified"));
+        assert!(text.contains(
+            "\nsrc/lib.py\n  > function read_config (line 3): renamed from load\n  ~ function run (line 3): lines of code 10 -> 14\n"
+        ));
+        assert_eq!(
+            render_text(&SnapshotDiff::default()),
+            "No structural changes.\n"
+        );
+    }
+}
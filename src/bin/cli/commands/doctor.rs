@@ -0,0 +1,471 @@
+//! Doctor Command Implementation
+//!
+//! `valknut doctor [PATH]` runs preflight checks for the setups that most
+//! often break an analysis: a path outside any repository, a configuration
+//! file that does not load, an unwritable cache directory, a Go toolchain
+//! older than the project's modules require, and parser executables that
+//! cannot be run. Each check prints `[OK]`, `[WARN]` or `[FAIL]` with a short
+//! explanation and, unless it passed, a suggested fix. Warnings keep the exit
+//! status at 0; any failure makes it non-zero.
+
+use std::fs;
+use std::path::{Path, PathBuf};
+use std::process::Command;
+
+use owo_colors::OwoColorize;
+
+use crate::cli::args::DoctorArgs;
+use crate::cli::config_layer::is_engine_config_file;
+use valknut_rs::core::config::ValknutConfig;
+use valknut_rs::core::dependency::go_modules::find_module_dirs;
+use valknut_rs::io::cache::IncrementalCache;
+use valknut_rs::lang::plugins::{PluginConfig, PluginRegistry};
+
+/// Configuration files looked up in the project directory when `--config` is omitted.
+const CONFIG_FILE_NAMES: &[&str] = &[".valknut.yml", ".valknut.yaml"];
+
+/// Outcome of a preflight check.
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub enum CheckStatus {
+    /// Nothing to fix.
+    Ok,
+    /// Some features will be unavailable, but analysis can run.
+    Warn,
+    /// Analysis will fail or silently miss files until this is fixed.
+    Fail,
+}
+
+/// Result of one preflight check.
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct Check {
+    /// What was checked.
+    pub name: &'static str,
+    /// Outcome.
+    pub status: CheckStatus,
+    /// What was found.
+    pub message: String,
+    /// How to resolve a warning or failure.
+    pub fix: Option<String>,
+}
+
+/// Constructors for [`Check`].
+impl Check {
+    /// A passing check.
+    fn ok(name: &'static str, message: impl Into<String>) -> Self {
+        Self {
+            name,
+            status: CheckStatus::Ok,
+            message: message.into(),
+            fix: None,
+        }
+    }
+
+    /// A check that found a problem; `status` says how serious it is.
+    fn problem(
+        name: &'static str,
+        status: CheckStatus,
+        message: impl Into<String>,
+        fix: impl Into<String>,
+    ) -> Self {
+        Self {
+            name,
+            status,
+            message: message.into(),
+            fix: Some(fix.into()),
+        }
+    }
+}
+
+/// Run every check, print the results, and fail if any check failed.
+pub fn doctor_command(args: DoctorArgs) -> anyhow::Result<()> {
+    let checks = run_checks(&args);
+    print!("{}", render_text(&checks));
+
+    let failed = checks
+        .iter()
+        .filter(|check| check.status == CheckStatus::Fail)
+        .count();
+    if failed > 0 {
+        anyhow::bail!("{failed} of {} check(s) failed", checks.len());
+    }
+    Ok(())
+}
+
+/// Run the checks in order; project checks are skipped when the path is unusable.
+fn run_checks(args: &DoctorArgs) -> Vec<Check> {
+    let root = check_root(&args.path);
+    let project_usable = root.status != CheckStatus::Fail;
+    let mut checks = vec![root];
+
+    let mut plugins = PluginConfig::default();
+    if project_usable {
+        let (config, loaded) = check_config(&args.path, args.config.as_deref());
+        checks.push(config);
+        if let Some(loaded) = loaded {
+            plugins = loaded.plugins;
+        }
+    }
+    checks.push(check_cache_dir(
+        args.cache_dir
+            .clone()
+            .or_else(IncrementalCache::default_dir),
+    ));
+    if project_usable {
+        checks.push(check_go(&args.path, installed_go_version().as_deref()));
+    }
+    checks.push(check_ruby(ruby_version()));
+    checks.push(check_plugins(&plugins));
+    checks
+}
+
+/// The project path exists and, ideally, lies inside a git repository.
+fn check_root(path: &Path) -> Check {
+    const NAME: &str = "repository";
+    if !path.is_dir() {
+        let problem = if path.exists() {
+            "is not a directory"
+        } else {
+            "does not exist"
+        };
+        return Check::problem(
+            NAME,
+            CheckStatus::Fail,
+            format!("{} {problem}", path.display()),
+            "Pass the project directory: valknut doctor <PATH>",
+        );
+    }
+    match git2::Repository::discover(path) {
+        Ok(repo) => match repo.workdir() {
+            Some(workdir) => Check::ok(NAME, format!("git repository at {}", workdir.display())),
+            None => Check::problem(
+                NAME,
+                CheckStatus::Warn,
+                format!("{} is inside a bare repository", path.display()),
+                "Run valknut on a checkout with a working tree",
+            ),
+        },
+        Err(_) => Check::problem(
+            NAME,
+            CheckStatus::Warn,
+            format!(
+                "{} is not inside a git repository; `review`, `blame` and change queries need one",
+                path.display()
+            ),
+            "Run valknut from a git checkout, or `git init` the project",
+        ),
+    }
+}
+
+/// The configuration file, if any, loads; returns the loaded configuration.
+fn check_config(root: &Path, explicit: Option<&Path>) -> (Check, Option<ValknutConfig>) {
+    const NAME: &str = "config";
+    let discovered = || {
+        CONFIG_FILE_NAMES
+            .iter()
+            .map(|name| root.join(name))
+            .find(|path| path.is_file() && is_engine_config_file(path))
+    };
+    let Some(path) = explicit.map(Path::to_path_buf).or_else(discovered) else {
+        return (
+            Check::ok(NAME, "no .valknut.yml; using the default configuration"),
+            None,
+        );
+    };
+
+    match ValknutConfig::from_yaml_file(&path) {
+        Ok(config) => (
+            Check::ok(NAME, format!("{} loads", path.display())),
+            Some(config),
+        ),
+        Err(err) => (
+            Check::problem(
+                NAME,
+                CheckStatus::Fail,
+                format!("{}: {err}", path.display()),
+                format!(
+                    "Compare it with `valknut print-default-config`, then re-run \
+                     `valknut validate-config --config {}`",
+                    path.display()
+                ),
+            ),
+            None,
+        ),
+    }
+}
+
+/// The incremental cache directory can be created and written to.
+fn check_cache_dir(dir: Option<PathBuf>) -> Check {
+    const NAME: &str = "cache";
+    let Some(dir) = dir else {
+        return Check::problem(
+            NAME,
+            CheckStatus::Warn,
+            "no user cache directory could be determined",
+            "Pass --cache-dir <DIR> to commands that use the incremental cache",
+        );
+    };
+    let probe = dir.join(format!(".doctor-{}", uuid::Uuid::new_v4()));
+    let written = fs::create_dir_all(&dir)
+        .and_then(|_| fs::write(&probe, b"ok"))
+        .and_then(|_| fs::remove_file(&probe));
+    match written {
+        Ok(()) => Check::ok(NAME, format!("{} is writable", dir.display())),
+        Err(err) => Check::problem(
+            NAME,
+            CheckStatus::Fail,
+            format!("{} is not writable: {err}", dir.display()),
+            "Fix the directory's permissions, or pass --cache-dir <DIR>",
+        ),
+    }
+}
+
+/// The installed Go toolchain is at least the newest `go` directive under `root`.
+fn check_go(root: &Path, installed: Option<&str>) -> Check {
+    const NAME: &str = "go";
+    let required = find_module_dirs(root)
+        .into_iter()
+        .filter_map(|dir| {
+            let directive = go_directive(&fs::read_to_string(dir.join("go.mod")).ok()?)?;
+            Some((version_parts(&directive)?, directive, dir))
+        })
+        .max_by(|a, b| a.0.cmp(&b.0));
+    let Some((required_parts, required, module)) = required else {
+        return Check::ok(NAME, "no go.mod with a go directive; no toolchain needed");
+    };
+    let module = module.join("go.mod");
+
+    let Some(installed) = installed else {
+        return Check::problem(
+            NAME,
+            CheckStatus::Warn,
+            format!(
+                "`go` is not on PATH, but {} requires go {required}",
+                module.display()
+            ),
+            format!(
+                "Install Go {required} or newer (https://go.dev/dl/); valknut parses Go \
+                 without it, but the modules cannot be built or vetted"
+            ),
+        );
+    };
+    match version_parts(installed) {
+        Some(parts) if parts >= required_parts => {
+            Check::ok(NAME, format!("go {installed} satisfies go {required}"))
+        }
+        _ => Check::problem(
+            NAME,
+            CheckStatus::Warn,
+            format!(
+                "go {installed} is older than go {required} required by {}",
+                module.display()
+            ),
+            format!("Upgrade Go to {required} or newer"),
+        ),
+    }
+}
+
+/// The Ruby interpreter behind the built-in Ruby parser runs.
+fn check_ruby(version: Option<String>) -> Check {
+    const NAME: &str = "ruby parser";
+    match version {
+        Some(version) => Check::ok(NAME, format!("{version} runs the built-in Ruby parser")),
+        None => Check::problem(
+            NAME,
+            CheckStatus::Warn,
+            "`ruby` is not on PATH; .rb and .rake files will be skipped",
+            "Install Ruby to analyze Ruby code",
+        ),
+    }
+}
+
+/// Every executable in the plugin directory loads.
+fn check_plugins(config: &PluginConfig) -> Check {
+    const NAME: &str = "plugins";
+    if !config.directory.is_dir() {
+        return Check::ok(
+            NAME,
+            format!("no plugin directory at {}", config.directory.display()),
+        );
+    }
+    let registry = PluginRegistry::load(config);
+    if registry.errors().is_empty() {
+        return Check::ok(
+            NAME,
+            format!(
+                "{} plugin(s) loaded from {}",
+                registry.parsers().count(),
+                config.directory.display()
+            ),
+        );
+    }
+    let failures: Vec<String> = registry
+        .errors()
+        .iter()
+        .map(|error| format!("{}: {}", error.path.display(), error.message))
+        .collect();
+    Check::problem(
+        NAME,
+        CheckStatus::Fail,
+        failures.join("; "),
+        "Make each plugin executable and check that `<plugin> describe` prints its \
+         manifest; `valknut plugins list` shows what loads",
+    )
+}
+
+/// Version of the `go` on `PATH`, e.g. `1.22.3`.
+fn installed_go_version() -> Option<String> {
+    let output = Command::new("go").arg("version").output().ok()?;
+    if !output.status.success() {
+        return None;
+    }
+    // `go version go1.22.3 linux/amd64`
+    String::from_utf8_lossy(&output.stdout)
+        .split_whitespace()
+        .find_map(|token| {
+            token
+                .strip_prefix("go")
+                .filter(|v| v.starts_with(char::is_numeric))
+        })
+        .map(str::to_string)
+}
+
+/// `ruby <version>` of the `ruby` on `PATH`.
+fn ruby_version() -> Option<String> {
+    let output = Command::new("ruby").arg("--version").output().ok()?;
+    if !output.status.success() {
+        return None;
+    }
+    // `ruby 3.2.2 (2023-03-30 revision e51014f9c0) [x86_64-linux]`
+    let text = String::from_utf8_lossy(&output.stdout);
+    let words: Vec<&str> = text.split_whitespace().take(2).collect();
+    (!words.is_empty()).then(|| words.join(" "))
+}
+
+/// Version in the `go` directive of a `go.mod`.
+fn go_directive(go_mod: &str) -> Option<String> {
+    go_mod.lines().find_map(|line| {
+        let version = line.trim().strip_prefix("go ")?.trim();
+        (!version.is_empty()).then(|| version.to_string())
+    })
+}
+
+/// Major, minor and patch of a Go version such as `1.22`, `1.22.3` or `1.23rc1`.
+fn version_parts(version: &str) -> Option<[u32; 3]> {
+    let numeric: String = version
+        .chars()
+        .take_while(|ch| ch.is_ascii_digit() || *ch == '.')
+        .collect();
+    let mut parts = [0; 3];
+    for (slot, part) in parts.iter_mut().zip(numeric.split('.')) {
+        *slot = part.parse().ok()?;
+    }
+    Some(parts)
+}
+
+/// One line per check, with the fix beneath it, and a summary line.
+fn render_text(checks: &[Check]) -> String {
+    let width = checks
+        .iter()
+        .map(|check| check.name.len())
+        .max()
+        .unwrap_or(0);
+    let mut out = String::new();
+    for check in checks {
+        let label = match check.status {
+            CheckStatus::Ok => format!("{:<6}", "[OK]").green().to_string(),
+            CheckStatus::Warn => format!("{:<6}", "[WARN]").yellow().to_string(),
+            CheckStatus::Fail => format!("{:<6}", "[FAIL]").red().to_string(),
+        };
+        out.push_str(&format!(
+            "{label} {:<width$}  {}\n",
+            check.name, check.message
+        ));
+        if let Some(fix) = &check.fix {
+            out.push_str(&format!("       {:<width$}  fix: {fix}\n", ""));
+        }
+    }
+
+    let count = |status| checks.iter().filter(|check| check.status == status).count();
+    out.push_str(&format!(
+        "\n{} ok, {} warning(s), {} failed\n",
+        count(CheckStatus::Ok),
+        count(CheckStatus::Warn),
+        count(CheckStatus::Fail)
+    ));
+    out
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use tempfile::tempdir;
+
+    #[test]
+    fn go_check_compares_the_toolchain_with_the_newest_directive() {
+        let tmp = tempdir().unwrap();
+        assert_eq!(check_go(tmp.path(), None).status, CheckStatus::Ok);
+
+        fs::write(
+            tmp.path().join("go.mod"),
+            "module example.com/app\n\ngo 1.21\n",
+        )
+        .unwrap();
+        fs::create_dir(tmp.path().join("tools")).unwrap();
+        fs::write(
+            tmp.path().join("tools/go.mod"),
+            "module example.com/app/tools\n\ngo 1.22.1\n",
+        )
+        .unwrap();
+
+        assert_eq!(check_go(tmp.path(), Some("1.22.3")).status, CheckStatus::Ok);
+        assert_eq!(
+            check_go(tmp.path(), Some("1.23rc1")).status,
+            CheckStatus::Ok
+        );
+        let old = check_go(tmp.path(), Some("1.22"));
+        assert_eq!(old.status, CheckStatus::Warn);
+        assert!(old.message.contains("older than go 1.22.1"));
+        let missing = check_go(tmp.path(), None);
+        assert_eq!(missing.status, CheckStatus::Warn);
+        assert!(missing.fix.unwrap().starts_with("Install Go 1.22.1"));
+    }
+
+    #[test]
+    fn unusable_paths_fail_with_a_fix() {
+        let tmp = tempdir().unwrap();
+        let file = tmp.path().join("notes.txt");
+        fs::write(&file, "not a directory").unwrap();
+
+        assert_eq!(check_root(&file).status, CheckStatus::Fail);
+        assert_eq!(
+            check_root(&tmp.path().join("missing")).status,
+            CheckStatus::Fail
+        );
+        assert_eq!(
+            check_cache_dir(Some(tmp.path().join("cache"))).status,
+            CheckStatus::Ok
+        );
+        let cache = check_cache_dir(Some(file.join("cache")));
+        assert_eq!(cache.status, CheckStatus::Fail);
+
+        let text = render_text(&[cache, Check::ok("config", "defaults")]);
+        assert!(text.contains("[FAIL]"));
+        assert!(text.contains("fix: Fix the directory's permissions"));
+        assert!(text.ends_with("1 ok, 0 warning(s), 1 failed\n"));
+    }
+
+    #[test]
+    fn broken_config_files_fail() {
+        let tmp = tempdir().unwrap();
+        let (check, config) = check_config(tmp.path(), None);
+        assert_eq!(check.status, CheckStatus::Ok);
+        assert!(config.is_none());
+
+        let path = tmp.path().join(".valknut.yml");
+        fs::write(&path, "analysis: [not, a, mapping]\n").unwrap();
+        let (check, config) = check_config(tmp.path(), None);
+        assert_eq!(check.status, CheckStatus::Fail);
+        assert!(config.is_none());
+        assert!(check.fix.unwrap().contains("validate-config"));
+    }
+}
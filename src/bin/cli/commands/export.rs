@@ -0,0 +1,96 @@
+//! Project export command implementation.
+//!
+//! `valknut export --format markdown <path>` writes a Markdown summary of a
+//! project, ready to paste into a document or pull request: a Mermaid
+//! dependency diagram, a complexity heatmap and a symbol table per package.
+//!
+//! `valknut export --format context <path>` instead writes the full contents
+//! of the files listed by `--files-from` (stdin by default), in order, as an
+//! LLM context document; `valknut token-budget --format paths` produces such
+//! a list.
+
+use std::io::Read;
+use std::path::Path;
+
+use anyhow::Context;
+
+use crate::cli::args::{ExportArgs, ExportFormat};
+use valknut_rs::io::reports::ProjectSummary;
+
+/// Run the export command, writing the document to `--out` or stdout.
+pub fn export_command(args: ExportArgs) -> anyhow::Result<()> {
+    let document = match args.format {
+        ExportFormat::Markdown => ProjectSummary::collect(&args.path)?.to_markdown(),
+        ExportFormat::Context => {
+            let list = if args.files_from == Path::new("-") {
+                let mut list = String::new();
+                std::io::stdin()
+                    .read_to_string(&mut list)
+                    .context("failed to read the file list from stdin")?;
+                list
+            } else {
+                std::fs::read_to_string(&args.files_from)
+                    .with_context(|| format!("failed to read {}", args.files_from.display()))?
+            };
+            let paths: Vec<&str> = list
+                .lines()
+                .map(str::trim)
+                .filter(|line| !line.is_empty())
+                .collect();
+            render_context(&args.path, &paths)?
+        }
+    };
+
+    match &args.out {
+        Some(path) => std::fs::write(path, document)
+            .with_context(|| format!("failed to write {}", path.display()))?,
+        None => print!("{document}"),
+    }
+    Ok(())
+}
+
+/// Markdown document with one fenced section per file of `paths` (relative
+/// to `root`), in the order given.
+fn render_context(root: &Path, paths: &[&str]) -> anyhow::Result<String> {
+    let mut document = format!("# Context: {}\n", root.display());
+    for path in paths {
+        let full_path = root.join(path);
+        let content = std::fs::read_to_string(&full_path)
+            .with_context(|| format!("failed to read {}", full_path.display()))?;
+        let language = Path::new(path)
+            .extension()
+            .and_then(|ext| ext.to_str())
+            .unwrap_or_default();
+        // A fence longer than any backtick run in the file cannot be closed early.
+        let longest_run = content.split(|c| c != '`').map(str::len).max().unwrap_or(0);
+        let fence = "`".repeat(longest_run.max(2) + 1);
+        let newline = if content.ends_with('\n') { "" } else { "\n" };
+        document.push_str(&format!(
+            "\n## {path}\n\n{fence}{language}\n{content}{newline}{fence}\n"
+        ));
+    }
+    Ok(document)
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use tempfile::tempdir;
+
+    #[test]
+    fn render_context_fences_files_in_the_given_order() {
+        let tmp = tempdir().unwrap();
+        let root = tmp.path();
+        std::fs::create_dir_all(root.join("pkg")).unwrap();
+        std::fs::write(root.join("pkg/store.go"), "package pkg\n").unwrap();
+        std::fs::write(root.join("README.md"), "Run:\n```\nmake\n```").unwrap();
+
+        let document = render_context(root, &["pkg/store.go", "README.md"]).unwrap();
+        let body = document.split_once('\n').unwrap().1;
+        assert_eq!(
+            body,
+            "\n## pkg/store.go\n\n```go\npackage pkg\n```\n\n## README.md\n\n````md\nRun:\n```\nmake\n```\n````\n"
+        );
+        assert!(render_context(root, &["missing.go"]).is_err());
+    }
+}
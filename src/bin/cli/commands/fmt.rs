@@ -0,0 +1,128 @@
+//! Doc comment formatting command implementation.
+//!
+//! `valknut fmt` rewrites the doc comments valknut reads into their canonical
+//! form (see [`valknut_rs::lang::doc_comments`]). It is not a general-purpose
+//! formatter: code, string literals and unrelated comments are left alone.
+//! With `--check` nothing is written and the command fails when any file
+//! would change, for use in CI.
+
+use std::path::PathBuf;
+use std::sync::{Arc, Mutex};
+
+use anyhow::Context;
+use owo_colors::OwoColorize;
+
+use crate::cli::args::FmtArgs;
+use valknut_rs::core::concurrency::WorkerPool;
+use valknut_rs::core::config::ValknutConfig;
+use valknut_rs::core::errors::ValknutError;
+use valknut_rs::core::pipeline::{discover_files, AnalysisConfig};
+use valknut_rs::lang::format_doc_comments;
+
+/// Run the fmt command over every discovered source file.
+pub async fn fmt_command(args: FmtArgs) -> anyhow::Result<()> {
+    let config = match &args.config {
+        Some(path) => ValknutConfig::from_yaml_file(path)?,
+        None => ValknutConfig::default(),
+    };
+    let pipeline_config = AnalysisConfig::from(config.clone());
+    let files = discover_files(&args.paths, &pipeline_config, Some(&config))?;
+
+    let changed = format_files(files, args.check, args.workers).await?;
+    let verb = if args.check {
+        "Would reformat"
+    } else {
+        "Reformatted"
+    };
+    for path in &changed {
+        println!("{} {}", verb.yellow(), path.display());
+    }
+
+    if args.check && !changed.is_empty() {
+        anyhow::bail!("{} file(s) need `valknut fmt`", changed.len());
+    }
+    if changed.is_empty() {
+        println!("{} Doc comments are already canonical", "✅".green());
+    }
+    Ok(())
+}
+
+/// Format `files` in parallel and return the ones that changed (or would
+/// change, when `check` is set), sorted.
+async fn format_files(
+    files: Vec<PathBuf>,
+    check: bool,
+    workers: Option<usize>,
+) -> anyhow::Result<Vec<PathBuf>> {
+    let changed = Arc::new(Mutex::new(Vec::new()));
+    let sink = Arc::clone(&changed);
+    let handler = move |path: PathBuf| {
+        let sink = Arc::clone(&sink);
+        async move {
+            let source = tokio::fs::read_to_string(&path)
+                .await
+                .map_err(|e| ValknutError::io(format!("Failed to read {}", path.display()), e))?;
+            let Some(formatted) = format_doc_comments(&path, &source)? else {
+                return Ok(());
+            };
+            if !check {
+                tokio::fs::write(&path, formatted).await.map_err(|e| {
+                    ValknutError::io(format!("Failed to write {}", path.display()), e)
+                })?;
+            }
+            sink.lock()
+                .unwrap_or_else(|poisoned| poisoned.into_inner())
+                .push(path);
+            Ok::<(), ValknutError>(())
+        }
+    };
+    let pool = match workers {
+        Some(workers) => WorkerPool::new(workers, handler),
+        None => WorkerPool::with_default_workers(handler),
+    };
+
+    for path in files {
+        if pool.submit(path).await.is_err() {
+            break;
+        }
+    }
+    pool.wait().await.context("valknut fmt failed")?;
+
+    let mut changed = std::mem::take(
+        &mut *changed
+            .lock()
+            .unwrap_or_else(|poisoned| poisoned.into_inner()),
+    );
+    changed.sort();
+    Ok(changed)
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[tokio::test]
+    async fn check_mode_reports_without_writing() {
+        let dir = tempfile::tempdir().unwrap();
+        let messy = dir.path().join("messy.rs");
+        let clean = dir.path().join("clean.rs");
+        std::fs::write(&messy, "///Runs.\npub fn run() {}\n").unwrap();
+        std::fs::write(&clean, "/// Runs.\npub fn run() {}\n").unwrap();
+        let files = vec![clean.clone(), messy.clone()];
+
+        let changed = format_files(files.clone(), true, Some(2)).await.unwrap();
+        assert_eq!(changed, vec![messy.clone()]);
+        assert_eq!(
+            std::fs::read_to_string(&messy).unwrap(),
+            "///Runs.\npub fn run() {}\n"
+        );
+
+        let changed = format_files(files.clone(), false, Some(2)).await.unwrap();
+        assert_eq!(changed, vec![messy.clone()]);
+        assert_eq!(
+            std::fs::read_to_string(&messy).unwrap(),
+            "/// Runs.\npub fn run() {}\n"
+        );
+        assert!(format_files(files, true, None).await.unwrap().is_empty());
+    }
+}
@@ -0,0 +1,224 @@
+//! gRPC Client Command Implementation
+//!
+//! `valknut grpc-client [ADDR]` connects to a running `valknut serve --grpc`
+//! instance and reads commands from stdin, one per line, printing each
+//! response. It is a test harness for the server, not a stable scripting
+//! interface; integrations should use the generated `valknut.v1` stubs.
+
+use tokio::io::{AsyncBufReadExt, AsyncWriteExt, BufReader};
+use tonic::transport::{Certificate, Channel, ClientTlsConfig, Endpoint};
+
+use crate::cli::args::GrpcClientArgs;
+use crate::grpc::proto;
+use crate::grpc::proto::valknut_service_client::ValknutServiceClient;
+
+/// Commands understood by the interactive prompt.
+const HELP: &str = "\
+Commands:
+  analyze <file>                  AnalyzeFile
+  search <path> <pattern> [-i]    SearchSymbols (-i: case-insensitive)
+  next                            Next page of the last search
+  graph <path>                    GetDependencyGraph
+  help                            Show this help
+  quit                            Exit";
+
+/// One parsed prompt line.
+#[derive(Debug, Clone, PartialEq)]
+enum ClientCommand {
+    /// Analyze a single file.
+    Analyze { path: String },
+    /// Start a new symbol search.
+    Search {
+        path: String,
+        pattern: String,
+        case_insensitive: bool,
+    },
+    /// Continue the previous search.
+    Next,
+    /// Fetch the package dependency graph.
+    Graph { path: String },
+    /// Print the command list.
+    Help,
+    /// Leave the prompt.
+    Quit,
+}
+
+/// Connect to the server and run the prompt until `quit` or end of input.
+pub async fn grpc_client_command(args: GrpcClientArgs) -> anyhow::Result<()> {
+    let channel = connect(&args).await?;
+    let mut client = ValknutServiceClient::new(channel);
+    println!("Connected to {}. Type `help` for commands.", args.addr);
+
+    let mut lines = BufReader::new(tokio::io::stdin()).lines();
+    let mut last_search: Option<proto::SearchSymbolsRequest> = None;
+    loop {
+        print!("valknut> ");
+        tokio::io::stdout().flush().await?;
+        let Some(line) = lines.next_line().await? else {
+            break;
+        };
+
+        let command = match parse_command(&line) {
+            Ok(Some(command)) => command,
+            Ok(None) => continue,
+            Err(message) => {
+                eprintln!("{message}");
+                continue;
+            }
+        };
+
+        let outcome = match command {
+            ClientCommand::Quit => break,
+            ClientCommand::Help => {
+                println!("{HELP}");
+                Ok(())
+            }
+            ClientCommand::Analyze { path } => client
+                .analyze_file(proto::AnalyzeFileRequest { path })
+                .await
+                .map(|response| println!("{:#?}", response.into_inner())),
+            ClientCommand::Graph { path } => client
+                .get_dependency_graph(proto::GetDependencyGraphRequest { path })
+                .await
+                .map(|response| println!("{:#?}", response.into_inner())),
+            ClientCommand::Search {
+                path,
+                pattern,
+                case_insensitive,
+            } => {
+                let request = proto::SearchSymbolsRequest {
+                    path,
+                    pattern,
+                    case_insensitive,
+                    ..Default::default()
+                };
+                search(&mut client, request, &mut last_search).await
+            }
+            ClientCommand::Next => match last_search.take() {
+                Some(request) => search(&mut client, request, &mut last_search).await,
+                None => {
+                    eprintln!("No more results; start a new search first");
+                    Ok(())
+                }
+            },
+        };
+
+        if let Err(status) = outcome {
+            eprintln!("{:?}: {}", status.code(), status.message());
+        }
+    }
+    Ok(())
+}
+
+/// Run a search and remember the request for `next` if more pages remain.
+async fn search(
+    client: &mut ValknutServiceClient<Channel>,
+    request: proto::SearchSymbolsRequest,
+    last_search: &mut Option<proto::SearchSymbolsRequest>,
+) -> Result<(), tonic::Status> {
+    let response = client.search_symbols(request.clone()).await?.into_inner();
+    *last_search = (!response.next_cursor.is_empty()).then(|| proto::SearchSymbolsRequest {
+        cursor: response.next_cursor.clone(),
+        ..request
+    });
+    println!("{:#?}", response);
+    Ok(())
+}
+
+/// Open a channel to the server, configuring TLS for `https://` addresses.
+async fn connect(args: &GrpcClientArgs) -> anyhow::Result<Channel> {
+    let mut endpoint = Endpoint::from_shared(args.addr.clone())
+        .map_err(|e| anyhow::anyhow!("Invalid server address '{}': {}", args.addr, e))?;
+
+    if args.addr.starts_with("https://") || args.ca_cert.is_some() {
+        let mut tls = ClientTlsConfig::new();
+        if let Some(path) = &args.ca_cert {
+            let pem = std::fs::read(path)
+                .map_err(|e| anyhow::anyhow!("Cannot read {}: {}", path.display(), e))?;
+            tls = tls.ca_certificate(Certificate::from_pem(pem));
+        }
+        if let Some(domain) = &args.domain {
+            tls = tls.domain_name(domain.clone());
+        }
+        endpoint = endpoint.tls_config(tls)?;
+    }
+
+    endpoint
+        .connect()
+        .await
+        .map_err(|e| anyhow::anyhow!("Cannot connect to {}: {}", args.addr, e))
+}
+
+/// Parse a prompt line; blank lines yield `None`.
+fn parse_command(line: &str) -> Result<Option<ClientCommand>, String> {
+    let words: Vec<&str> = line.split_whitespace().collect();
+    let Some((&name, rest)) = words.split_first() else {
+        return Ok(None);
+    };
+
+    let command = match (name, rest) {
+        ("analyze", [path]) => ClientCommand::Analyze {
+            path: path.to_string(),
+        },
+        ("graph", [path]) => ClientCommand::Graph {
+            path: path.to_string(),
+        },
+        ("search", [path, pattern]) => ClientCommand::Search {
+            path: path.to_string(),
+            pattern: pattern.to_string(),
+            case_insensitive: false,
+        },
+        ("search", [path, pattern, "-i"]) => ClientCommand::Search {
+            path: path.to_string(),
+            pattern: pattern.to_string(),
+            case_insensitive: true,
+        },
+        ("next", []) => ClientCommand::Next,
+        ("help", []) => ClientCommand::Help,
+        ("quit" | "exit", []) => ClientCommand::Quit,
+        ("analyze" | "graph" | "search" | "next" | "help" | "quit" | "exit", _) => {
+            return Err(format!("Wrong arguments for `{name}`\n{HELP}"));
+        }
+        _ => return Err(format!("Unknown command `{name}`\n{HELP}")),
+    };
+    Ok(Some(command))
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn parse_command_recognizes_each_command() {
+        assert_eq!(
+            parse_command("analyze src/lib.rs"),
+            Ok(Some(ClientCommand::Analyze {
+                path: "src/lib.rs".to_string()
+            }))
+        );
+        assert_eq!(
+            parse_command("  search . ^handle -i "),
+            Ok(Some(ClientCommand::Search {
+                path: ".".to_string(),
+                pattern: "^handle".to_string(),
+                case_insensitive: true,
+            }))
+        );
+        assert_eq!(
+            parse_command("graph ."),
+            Ok(Some(ClientCommand::Graph {
+                path: ".".to_string()
+            }))
+        );
+        assert_eq!(parse_command("next"), Ok(Some(ClientCommand::Next)));
+        assert_eq!(parse_command("exit"), Ok(Some(ClientCommand::Quit)));
+        assert_eq!(parse_command("   "), Ok(None));
+    }
+
+    #[test]
+    fn parse_command_rejects_bad_input() {
+        assert!(parse_command("analyze").is_err());
+        assert!(parse_command("search . x --bogus").is_err());
+        assert!(parse_command("frobnicate").is_err());
+    }
+}
@@ -0,0 +1,468 @@
+//! `valknut init`: scaffold a `valknut.toml` flag file for a project.
+//!
+//! The project is walked once to count source files per registered language.
+//! From that survey the command derives `paths` (the directories to analyze)
+//! and `exclude` globs for each detected language's build and dependency
+//! output, optionally adding the patterns from `.gitignore`. An existing flag
+//! file is merged rather than overwritten: keys already set are kept, missing
+//! `exclude` patterns are appended, and the file is left untouched when there
+//! is nothing to add.
+
+use std::collections::BTreeMap;
+use std::io::IsTerminal;
+use std::path::Path;
+
+use anyhow::Context;
+use owo_colors::OwoColorize;
+use toml::{Table, Value};
+
+use crate::cli::args::InitArgs;
+use crate::cli::flag_migration::{CONFIG_VERSION_KEY, CURRENT_CONFIG_VERSION};
+use valknut_rs::core::pipeline::{VCS_DIRECTORIES, VENDOR_DIRECTORIES};
+use valknut_rs::lang::registry::{detect_language_from_path, registered_languages};
+
+/// Header comment noting that dependency directories need no `exclude` entry.
+const VENDOR_NOTE: &str =
+    "# vendor/, node_modules/, target/ and other dependency or build-output\n\
+     # directories are skipped by default; set `include-vendor = true` to analyze them.\n";
+
+/// Source files found in a project, grouped by language.
+#[derive(Debug, Default, PartialEq)]
+pub struct ProjectSurvey {
+    /// File counts keyed by registry language key.
+    pub languages: BTreeMap<&'static str, usize>,
+    /// Top-level directories that contain source files.
+    pub source_dirs: Vec<String>,
+    /// True when source files sit directly in the project root.
+    pub root_sources: bool,
+}
+
+/// Summary methods for [`ProjectSurvey`].
+impl ProjectSurvey {
+    /// Walk `root` and count source files per language.
+    pub fn scan(root: &Path) -> Self {
+        let mut survey = Self::default();
+        let walker = ignore::WalkBuilder::new(root)
+            .filter_entry(|entry| {
+                entry.depth() == 0
+                    || !entry.file_type().is_some_and(|t| t.is_dir())
+                    || !is_skipped_dir(&entry.file_name().to_string_lossy())
+            })
+            .build();
+
+        for entry in walker.flatten() {
+            if !entry.file_type().is_some_and(|t| t.is_file()) {
+                continue;
+            }
+            let path = entry.path();
+            let Some(key) = language_key(path) else {
+                continue;
+            };
+            *survey.languages.entry(key).or_default() += 1;
+
+            let relative = path.strip_prefix(root).unwrap_or(path);
+            let mut components = relative.components();
+            match (components.next(), components.next()) {
+                (Some(top), Some(_)) => {
+                    let dir = top.as_os_str().to_string_lossy().into_owned();
+                    if !survey.source_dirs.contains(&dir) {
+                        survey.source_dirs.push(dir);
+                    }
+                }
+                _ => survey.root_sources = true,
+            }
+        }
+        survey.source_dirs.sort();
+        survey
+    }
+
+    /// Directories to analyze: the root when it holds sources itself, otherwise
+    /// each top-level source directory.
+    pub fn paths(&self) -> Vec<String> {
+        if self.root_sources || self.source_dirs.is_empty() {
+            vec![".".to_string()]
+        } else {
+            self.source_dirs.clone()
+        }
+    }
+
+    /// Exclude globs for the detected languages, without duplicates.
+    pub fn excludes(&self) -> Vec<String> {
+        let mut excludes: Vec<String> = Vec::new();
+        for key in self.languages.keys() {
+            for pattern in language_excludes(key) {
+                if !excludes.iter().any(|existing| existing == pattern) {
+                    excludes.push((*pattern).to_string());
+                }
+            }
+        }
+        excludes
+    }
+
+    /// Human-readable language list, e.g. `Go (12), Python (3)`.
+    pub fn describe(&self) -> String {
+        self.languages
+            .iter()
+            .map(|(key, count)| format!("{} ({count})", language_name(key)))
+            .collect::<Vec<_>>()
+            .join(", ")
+    }
+}
+
+/// What [`scaffold_flag_file`] changed.
+#[derive(Debug, Default, PartialEq)]
+pub struct InitOutcome {
+    /// True when the flag file did not exist before.
+    pub created: bool,
+    /// Top-level keys that were set.
+    pub keys_added: Vec<String>,
+    /// Exclude patterns appended to the file.
+    pub excludes_added: Vec<String>,
+}
+
+/// Run `valknut init`.
+pub fn init_command(args: InitArgs) -> anyhow::Result<()> {
+    if !args.dir.is_dir() {
+        anyhow::bail!("{} is not a directory", args.dir.display());
+    }
+    if git2::Repository::discover(&args.dir).is_err() {
+        eprintln!(
+            "{} {} is not inside a git repository; discovery will not honour VCS ignores",
+            "warning:".yellow().bold(),
+            args.dir.display()
+        );
+    }
+
+    let survey = ProjectSurvey::scan(&args.dir);
+    if survey.languages.is_empty() {
+        eprintln!(
+            "{} no supported source files found under {}",
+            "warning:".yellow().bold(),
+            args.dir.display()
+        );
+    } else {
+        println!("Detected languages: {}", survey.describe());
+    }
+
+    let gitignore = args.dir.join(".gitignore");
+    let gitignore_excludes = if gitignore.is_file() {
+        let content = std::fs::read_to_string(&gitignore)
+            .with_context(|| format!("Failed to read {}", gitignore.display()))?;
+        let patterns = gitignore_globs(&content);
+        if !patterns.is_empty() && inherit_gitignore(&args, patterns.len())? {
+            patterns
+        } else {
+            Vec::new()
+        }
+    } else {
+        Vec::new()
+    };
+
+    let output = args.dir.join(&args.output);
+    let outcome = scaffold_flag_file(&output, &survey, &gitignore_excludes)?;
+
+    if outcome.created {
+        println!(
+            "{} {}",
+            "✅ Created".bright_green().bold(),
+            output.display().to_string().cyan()
+        );
+    } else if outcome.keys_added.is_empty() && outcome.excludes_added.is_empty() {
+        println!("{} is already up to date", output.display());
+        return Ok(());
+    } else {
+        println!(
+            "{} {}",
+            "✅ Updated".bright_green().bold(),
+            output.display().to_string().cyan()
+        );
+    }
+    for key in &outcome.keys_added {
+        println!("   + {key}");
+    }
+    for pattern in &outcome.excludes_added {
+        println!("   + exclude {pattern}");
+    }
+    println!(
+        "Check it with {}",
+        "valknut config validate".to_string().cyan()
+    );
+    Ok(())
+}
+
+/// Decide whether `.gitignore` patterns are inherited, asking when interactive.
+fn inherit_gitignore(args: &InitArgs, count: usize) -> anyhow::Result<bool> {
+    if args.gitignore {
+        return Ok(true);
+    }
+    if args.no_gitignore {
+        return Ok(false);
+    }
+    if !std::io::stdin().is_terminal() {
+        println!("Found .gitignore; pass --gitignore to add its {count} pattern(s) to `exclude`");
+        return Ok(false);
+    }
+    dialoguer::Confirm::new()
+        .with_prompt(format!(
+            "Add the {count} pattern(s) from .gitignore to `exclude`?"
+        ))
+        .default(true)
+        .interact()
+        .context("Failed to read answer")
+}
+
+/// Create `path`, or merge the survey's defaults into it.
+pub fn scaffold_flag_file(
+    path: &Path,
+    survey: &ProjectSurvey,
+    extra_excludes: &[String],
+) -> anyhow::Result<InitOutcome> {
+    let created = !path.exists();
+    let mut table = if created {
+        let mut table = Table::new();
+        table.insert(
+            CONFIG_VERSION_KEY.to_string(),
+            Value::Integer(CURRENT_CONFIG_VERSION.into()),
+        );
+        table
+    } else {
+        let content = std::fs::read_to_string(path)
+            .with_context(|| format!("Failed to read {}", path.display()))?;
+        toml::from_str(&content).with_context(|| format!("Failed to parse {}", path.display()))?
+    };
+
+    let mut excludes = survey.excludes();
+    excludes.extend(extra_excludes.iter().cloned());
+    let mut outcome = merge_defaults(&mut table, survey.paths(), excludes)?;
+    outcome.created = created;
+    if !created && outcome.keys_added.is_empty() && outcome.excludes_added.is_empty() {
+        return Ok(outcome);
+    }
+
+    let mut content =
+        String::from("# valknut CLI flag defaults; any long-form flag may be set here.\n");
+    if !survey.languages.is_empty() {
+        content.push_str(&format!("# Detected languages: {}\n", survey.describe()));
+    }
+    content.push_str(VENDOR_NOTE);
+    content.push('\n');
+    content.push_str(&toml::to_string(&table)?);
+    std::fs::write(path, content).with_context(|| format!("Failed to write {}", path.display()))?;
+    Ok(outcome)
+}
+
+/// Set `paths` when absent and append missing `exclude` patterns.
+fn merge_defaults(
+    table: &mut Table,
+    paths: Vec<String>,
+    excludes: Vec<String>,
+) -> anyhow::Result<InitOutcome> {
+    let mut outcome = InitOutcome::default();
+
+    if !table.contains_key("paths") {
+        table.insert("paths".to_string(), string_array(paths));
+        outcome.keys_added.push("paths".to_string());
+    }
+
+    let mut current = match table.remove("exclude") {
+        None => Vec::new(),
+        Some(Value::String(pattern)) => vec![pattern],
+        Some(Value::Array(values)) => values
+            .into_iter()
+            .map(|value| match value {
+                Value::String(pattern) => Ok(pattern),
+                other => anyhow::bail!("`exclude` entries must be strings, found {other}"),
+            })
+            .collect::<anyhow::Result<Vec<_>>>()?,
+        Some(other) => anyhow::bail!("`exclude` must be a list of globs, found {other}"),
+    };
+    let had_excludes = !current.is_empty();
+    for pattern in excludes {
+        if !current.contains(&pattern) {
+            outcome.excludes_added.push(pattern.clone());
+            current.push(pattern);
+        }
+    }
+    if !had_excludes && !current.is_empty() {
+        outcome.keys_added.push("exclude".to_string());
+    }
+    if !current.is_empty() {
+        table.insert("exclude".to_string(), string_array(current));
+    }
+    Ok(outcome)
+}
+
+/// TOML array of strings.
+fn string_array(values: Vec<String>) -> Value {
+    Value::Array(values.into_iter().map(Value::String).collect())
+}
+
+/// Convert `.gitignore` lines into exclude globs.
+///
+/// Negations (`!pattern`) cannot be expressed as excludes and are skipped.
+pub fn gitignore_globs(content: &str) -> Vec<String> {
+    let mut globs = Vec::new();
+    for line in content.lines() {
+        let line = line.trim();
+        if line.is_empty() || line.starts_with('#') || line.starts_with('!') {
+            continue;
+        }
+        let dir_only = line.ends_with('/');
+        let pattern = line.trim_matches('/');
+        if pattern.is_empty() {
+            continue;
+        }
+        let anchored = line.starts_with('/') || pattern.contains('/');
+        let base = if anchored || pattern.starts_with("**/") {
+            pattern.to_string()
+        } else {
+            format!("**/{pattern}")
+        };
+
+        let mut push = |glob: String| {
+            if !globs.contains(&glob) {
+                globs.push(glob);
+            }
+        };
+        if !dir_only {
+            push(base.clone());
+        }
+        let wildcard_name = base
+            .rsplit('/')
+            .next()
+            .is_some_and(|name| name.contains('*'));
+        if dir_only || !wildcard_name {
+            push(format!("{base}/**"));
+        }
+    }
+    globs
+}
+
+/// Directories the survey never descends into, whatever `.gitignore` says:
+/// the ones discovery skips by default.
+fn is_skipped_dir(name: &str) -> bool {
+    VCS_DIRECTORIES.contains(&name) || VENDOR_DIRECTORIES.contains(&name)
+}
+
+/// Exclude globs for build output and generated code of a language.
+///
+/// Directories discovery skips by default (`vendor`, `node_modules`, `.venv`,
+/// `target`, ...) are not repeated, so `include-vendor` can still reach them.
+fn language_excludes(key: &str) -> &'static [&'static str] {
+    match key {
+        "go" => &["**/*.pb.go"],
+        "js" | "ts" => &["**/*.min.js", "**/coverage/**"],
+        "java" => &["**/generated-sources/**"],
+        "cs" => &["**/bin/**", "**/obj/**"],
+        "kt" => &["**/generated/**"],
+        _ => &[],
+    }
+}
+
+/// Registry key for a supported source file.
+fn language_key(path: &Path) -> Option<&'static str> {
+    let detected = detect_language_from_path(&path.to_string_lossy());
+    registered_languages()
+        .iter()
+        .find(|info| info.key == detected)
+        .map(|info| info.key)
+}
+
+/// Display name for a registry key.
+fn language_name(key: &str) -> &'static str {
+    registered_languages()
+        .iter()
+        .find(|info| info.key == key)
+        .map_or("Unknown", |info| info.name)
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::cli::flag_config::validate_flag_file;
+    use std::fs;
+    use tempfile::tempdir;
+
+    fn write(root: &Path, relative: &str, content: &str) {
+        let path = root.join(relative);
+        fs::create_dir_all(path.parent().unwrap()).unwrap();
+        fs::write(path, content).unwrap();
+    }
+
+    #[test]
+    fn survey_counts_languages_and_source_dirs() {
+        let dir = tempdir().unwrap();
+        write(dir.path(), "cmd/app/main.go", "package main\n");
+        write(dir.path(), "internal/store/store.go", "package store\n");
+        write(dir.path(), "scripts/gen.py", "print(1)\n");
+        write(dir.path(), "vendor/lib/lib.go", "package lib\n");
+        write(dir.path(), "README.md", "# readme\n");
+
+        let survey = ProjectSurvey::scan(dir.path());
+        assert_eq!(survey.languages.get("go"), Some(&2));
+        assert_eq!(survey.languages.get("py"), Some(&1));
+        assert_eq!(survey.paths(), vec!["cmd", "internal", "scripts"]);
+        assert_eq!(survey.excludes(), vec!["**/*.pb.go"]);
+        assert_eq!(survey.describe(), "Go (2), Python (1)");
+    }
+
+    #[test]
+    fn gitignore_patterns_become_globs() {
+        let globs = gitignore_globs("# comment\n/bin\nlogs/\n*.log\n!keep.log\ndocs/generated\n");
+        assert_eq!(
+            globs,
+            vec![
+                "bin",
+                "bin/**",
+                "**/logs/**",
+                "**/*.log",
+                "docs/generated",
+                "docs/generated/**",
+            ]
+        );
+    }
+
+    #[test]
+    fn rerunning_merges_instead_of_overwriting() {
+        let dir = tempdir().unwrap();
+        write(dir.path(), "main.go", "package main\n");
+        let path = dir.path().join("valknut.toml");
+        fs::write(
+            &path,
+            "max-complexity = 60\nexclude = [\"**/testdata/**\"]\n",
+        )
+        .unwrap();
+
+        let survey = ProjectSurvey::scan(dir.path());
+        let first = scaffold_flag_file(&path, &survey, &[]).unwrap();
+        assert!(!first.created);
+        assert_eq!(first.keys_added, vec!["paths"]);
+        assert_eq!(first.excludes_added, vec!["**/*.pb.go"]);
+
+        let table: Table = toml::from_str(&fs::read_to_string(&path).unwrap()).unwrap();
+        assert_eq!(table["max-complexity"].as_integer(), Some(60));
+        assert_eq!(table["exclude"].as_array().unwrap().len(), 2);
+
+        let written = fs::read_to_string(&path).unwrap();
+        assert!(written.contains("`include-vendor = true`"));
+        let second = scaffold_flag_file(&path, &survey, &[]).unwrap();
+        assert_eq!(second, InitOutcome::default());
+        assert_eq!(fs::read_to_string(&path).unwrap(), written);
+    }
+
+    #[test]
+    fn scaffolded_file_passes_flag_validation() {
+        let dir = tempdir().unwrap();
+        write(dir.path(), "src/app.ts", "export const x = 1;\n");
+        let path = dir.path().join("valknut.toml");
+
+        let outcome = scaffold_flag_file(
+            &path,
+            &ProjectSurvey::scan(dir.path()),
+            &gitignore_globs("coverage/\n"),
+        )
+        .unwrap();
+        assert!(outcome.created);
+        assert_eq!(validate_flag_file(&path).unwrap(), Vec::new());
+    }
+}
@@ -0,0 +1,192 @@
+//! Lint command implementation.
+//!
+//! `valknut lint` runs the fixable built-in rules (`trailing-newline`,
+//! `comment-capitalization`, `no-blank-lines-before-closing-brace`) and the
+//! Go `lock-ordering` check together with the `[[check.rules]]` of
+//! valknut.toml and reports violations the way `valknut check` does. With `--fix` the violations of fixable rules are
+//! rewritten in place, each file replaced through a temporary file so an
+//! interrupted run never leaves it half written; `--fix-dry-run` prints the
+//! same changes as unified diffs instead. When fixing, violations of the other
+//! rules are still listed but do not fail the command.
+
+use std::collections::BTreeSet;
+use std::fs;
+use std::path::Path;
+
+use anyhow::Context;
+use owo_colors::OwoColorize;
+
+use crate::cli::args::LintArgs;
+use crate::cli::commands::check::render_text;
+use crate::cli::flag_config::{discover_flag_files, load_check_rules};
+use crate::cli::text_diff::unified_diff;
+use valknut_rs::api::engine::ValknutEngine;
+use valknut_rs::core::config::ValknutConfig;
+use valknut_rs::detectors::rules::{
+    RuleConfig, RuleEngine, RuleFinding, RuleSeverity, BUILTIN_RULES,
+};
+
+/// Run the lint command.
+pub async fn lint_command(args: LintArgs) -> anyhow::Result<()> {
+    let rule_files = match &args.rules {
+        Some(path) => vec![path.clone()],
+        None => discover_flag_files(&args.path),
+    };
+    let rules = with_default_builtins(load_check_rules(&rule_files)?);
+    let rule_engine = RuleEngine::from_configs(&rules)?;
+
+    let mut config = ValknutConfig::default();
+    config.rules = rules;
+    let mut engine = ValknutEngine::new_from_valknut_config(config).await?;
+    let results = engine
+        .analyze_directory(&args.path)
+        .await
+        .with_context(|| format!("failed to analyse {}", args.path.display()))?;
+    let findings = results.rule_findings;
+
+    if !args.fix && !args.fix_dry_run {
+        print!("{}", render_text(&findings));
+        let fixable = findings
+            .iter()
+            .filter(|finding| rule_engine.is_fixable(&finding.rule))
+            .count();
+        if fixable > 0 {
+            println!("{fixable} of them can be fixed with `valknut lint --fix`");
+        }
+        let failing = findings
+            .iter()
+            .filter(|finding| finding.severity >= RuleSeverity::Warning)
+            .count();
+        if failing > 0 {
+            anyhow::bail!("{failing} rule violation(s)");
+        }
+        return Ok(());
+    }
+
+    let (fixable, remaining): (Vec<RuleFinding>, Vec<RuleFinding>) = findings
+        .into_iter()
+        .partition(|finding| rule_engine.is_fixable(&finding.rule));
+    let files: BTreeSet<&str> = fixable
+        .iter()
+        .map(|finding| finding.file_path.as_str())
+        .collect();
+    let mut fixed_files = 0;
+    for path in &files {
+        let full_path = results.project_root.join(path);
+        let source = fs::read_to_string(&full_path)
+            .with_context(|| format!("failed to read {}", full_path.display()))?;
+        let Some(fix) = rule_engine.fix(path, &source) else {
+            continue;
+        };
+        if args.fix_dry_run {
+            print!(
+                "{}",
+                unified_diff(
+                    &format!("a/{path}"),
+                    &format!("b/{path}"),
+                    &source,
+                    &fix.source
+                )
+            );
+        } else {
+            write_atomically(&full_path, &fix.source)?;
+            println!("{} {path} ({})", "Fixed".green(), fix.rules.join(", "));
+        }
+        fixed_files += 1;
+    }
+
+    let verb = if args.fix_dry_run {
+        "Would fix"
+    } else {
+        "Fixed"
+    };
+    println!(
+        "{verb} {} violation(s) in {fixed_files} file(s)",
+        fixable.len()
+    );
+    if !remaining.is_empty() {
+        println!("\nViolations without an automatic fix:");
+        print!("{}", render_text(&remaining));
+    }
+    Ok(())
+}
+
+/// Built-in rules linted by default besides the fixable ones.
+const DEFAULT_LINT_RULES: &[&str] = &["lock-ordering", "go-constructor-bypass"];
+
+/// `rules` plus every fixable or default built-in not configured already, as
+/// a warning named after its identifier.
+fn with_default_builtins(mut rules: Vec<RuleConfig>) -> Vec<RuleConfig> {
+    let defaults = BUILTIN_RULES
+        .iter()
+        .filter(|rule| rule.fixable || DEFAULT_LINT_RULES.contains(&rule.id));
+    for builtin in defaults {
+        let configured = rules
+            .iter()
+            .any(|rule| rule.name == builtin.id || rule.builtin.as_deref() == Some(builtin.id));
+        if !configured {
+            rules.push(RuleConfig {
+                name: builtin.id.to_string(),
+                severity: RuleSeverity::Warning,
+                builtin: Some(builtin.id.to_string()),
+                expr: None,
+                query: None,
+                message: None,
+                params: Default::default(),
+            });
+        }
+    }
+    rules
+}
+
+/// Replace `path` with `contents` through a temporary file in the same
+/// directory, so the file is never left truncated.
+fn write_atomically(path: &Path, contents: &str) -> anyhow::Result<()> {
+    let temp_path = path.with_extension(format!("{}.tmp", uuid::Uuid::new_v4()));
+    fs::write(&temp_path, contents)
+        .with_context(|| format!("failed to write {}", temp_path.display()))?;
+    fs::rename(&temp_path, path).with_context(|| format!("failed to replace {}", path.display()))
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn default_builtins_are_added_unless_configured() {
+        let configured = RuleConfig {
+            name: "final-newline".to_string(),
+            severity: RuleSeverity::Error,
+            builtin: Some("trailing-newline".to_string()),
+            expr: None,
+            query: None,
+            message: None,
+            params: Default::default(),
+        };
+
+        let rules = with_default_builtins(vec![configured]);
+        let names: Vec<&str> = rules.iter().map(|rule| rule.name.as_str()).collect();
+        assert_eq!(
+            names,
+            [
+                "final-newline",
+                "lock-ordering",
+                "go-constructor-bypass",
+                "comment-capitalization",
+                "no-blank-lines-before-closing-brace"
+            ]
+        );
+        assert!(RuleEngine::from_configs(&rules).is_ok());
+    }
+
+    #[test]
+    fn atomic_write_replaces_the_file() {
+        let dir = tempfile::tempdir().unwrap();
+        let path = dir.path().join("main.rs");
+        fs::write(&path, "fn main() {}").unwrap();
+
+        write_atomically(&path, "fn main() {}\n").unwrap();
+        assert_eq!(fs::read_to_string(&path).unwrap(), "fn main() {}\n");
+        assert_eq!(fs::read_dir(dir.path()).unwrap().count(), 1);
+    }
+}
@@ -1,14 +1,30 @@
 //! MCP (Model Context Protocol) server commands.
 //!
-//! This module provides commands for starting the MCP stdio server
-//! and generating MCP manifest files for IDE integration.
+//! This module provides commands for starting the MCP stdio server,
+//! generating MCP manifest files for IDE integration, and registering the
+//! server with Claude Desktop (`valknut mcp install` / `uninstall`).
 
-use crate::cli::args::{McpManifestArgs, McpStdioArgs, SurveyVerbosity};
+use std::path::{Path, PathBuf};
+use std::process::{Command, Stdio};
+
+use anyhow::Context;
+use serde_json::Value;
+
+use crate::cli::args::{
+    McpArgs, McpCommand, McpInstallArgs, McpManifestArgs, McpStdioArgs, SurveyVerbosity,
+};
 use crate::cli::commands::load_configuration;
+use valknut_rs::core::token_budget::ContextBudget;
 use valknut_rs::detectors::structure::StructureConfig;
 
 const VERSION: &str = env!("CARGO_PKG_VERSION");
 
+/// Claude Desktop configuration file, relative to the platform config directory.
+const CLAUDE_DESKTOP_CONFIG: &str = "Claude/claude_desktop_config.json";
+
+/// Key holding the MCP server registrations in the Claude Desktop config.
+const MCP_SERVERS_KEY: &str = "mcpServers";
+
 /// Start the MCP stdio server for IDE integration.
 ///
 /// This command starts a JSON-RPC 2.0 server that communicates via stdio,
@@ -17,6 +33,7 @@ const VERSION: &str = env!("CARGO_PKG_VERSION");
 /// Available tools exposed by the server:
 /// - analyze_code: Analyze code for refactoring opportunities and quality metrics
 /// - get_refactoring_suggestions: Get specific refactoring suggestions for a code entity
+/// - build_context: Rank and trim files to fit a token budget (`--context-budget`)
 ///
 /// The server follows the MCP specification and can be used with Claude Code
 /// and other MCP-compatible clients.
@@ -45,7 +62,18 @@ pub async fn mcp_stdio_command(
     // Initialize and run MCP server
     eprintln!("MCP JSON-RPC 2.0 server ready for requests");
 
-    if let Err(e) = run_mcp_server(VERSION).await {
+    let context_budget = args
+        .context_budget
+        .map(|max_tokens| ContextBudget::new(max_tokens, args.context_strategy.into()));
+
+    let symbol_filter = args
+        .symbol_filter
+        .map(|filter| filter.with_base(args.symbol_filter_base));
+    if let Some(filter) = &symbol_filter {
+        eprintln!("Symbol filter: {filter}");
+    }
+
+    if let Err(e) = run_mcp_server(VERSION, context_budget, symbol_filter).await {
         eprintln!("MCP server error: {}", e);
         return Err(anyhow::anyhow!("MCP server failed: {}", e));
     }
@@ -137,3 +165,313 @@ pub async fn mcp_manifest_command(args: McpManifestArgs) -> anyhow::Result<()> {
 
     Ok(())
 }
+
+/// Run `valknut mcp <subcommand>`.
+pub fn mcp_command(args: McpArgs) -> anyhow::Result<()> {
+    match args.command {
+        McpCommand::Install(args) => mcp_install_command(args),
+        McpCommand::Uninstall(args) => mcp_uninstall_command(args),
+    }
+}
+
+/// Register the valknut MCP server in the Claude Desktop configuration.
+///
+/// A missing configuration file is created; other servers and settings in an
+/// existing one are kept. An existing registration under the same name is
+/// replaced.
+fn mcp_install_command(args: McpInstallArgs) -> anyhow::Result<()> {
+    let config_path = claude_desktop_config_path(&args)?;
+    let mut config = read_desktop_config(&config_path)?;
+    let command = std::env::current_exe()
+        .context("failed to locate the valknut executable")?
+        .to_string_lossy()
+        .into_owned();
+
+    if !register_server(&mut config, &args.name, server_entry(&command))? {
+        println!(
+            "valknut is already registered as `{}` in {}",
+            args.name,
+            config_path.display()
+        );
+        return Ok(());
+    }
+    apply_config_change(&config_path, &config, &args)?;
+    if !args.dry_run {
+        println!(
+            "Registered valknut as MCP server `{}` in {}",
+            args.name,
+            config_path.display()
+        );
+    }
+    Ok(())
+}
+
+/// Remove the valknut MCP server from the Claude Desktop configuration.
+fn mcp_uninstall_command(args: McpInstallArgs) -> anyhow::Result<()> {
+    let config_path = claude_desktop_config_path(&args)?;
+    let mut config = read_desktop_config(&config_path)?;
+
+    if !unregister_server(&mut config, &args.name) {
+        println!(
+            "No MCP server named `{}` in {}",
+            args.name,
+            config_path.display()
+        );
+        return Ok(());
+    }
+    apply_config_change(&config_path, &config, &args)?;
+    if !args.dry_run {
+        println!(
+            "Removed MCP server `{}` from {}",
+            args.name,
+            config_path.display()
+        );
+    }
+    Ok(())
+}
+
+/// Write the changed configuration and restart Claude Desktop, or describe
+/// both with `--dry-run`.
+fn apply_config_change(
+    config_path: &Path,
+    config: &Value,
+    args: &McpInstallArgs,
+) -> anyhow::Result<()> {
+    let contents = format!("{}\n", serde_json::to_string_pretty(config)?);
+    if args.dry_run {
+        println!("Would write {}:\n{}", config_path.display(), contents);
+        if !args.no_restart && claude_desktop_running() {
+            println!("Would restart Claude Desktop");
+        }
+        return Ok(());
+    }
+
+    write_desktop_config(config_path, &contents)?;
+    if !args.no_restart && claude_desktop_running() {
+        match restart_claude_desktop() {
+            Ok(()) => println!("Restarted Claude Desktop to load the change"),
+            Err(e) => eprintln!("Restart Claude Desktop to load the change ({e})"),
+        }
+    }
+    Ok(())
+}
+
+/// The Claude Desktop config file: `--config-path`, or the platform default
+/// (`~/Library/Application Support/Claude` on macOS, `~/.config/Claude` on Linux).
+fn claude_desktop_config_path(args: &McpInstallArgs) -> anyhow::Result<PathBuf> {
+    if let Some(path) = &args.config_path {
+        return Ok(path.clone());
+    }
+    dirs::config_dir()
+        .map(|dir| dir.join(CLAUDE_DESKTOP_CONFIG))
+        .context("cannot locate the Claude Desktop config directory: pass --config-path")
+}
+
+/// Read the config file, treating a missing or empty file as an empty object.
+fn read_desktop_config(path: &Path) -> anyhow::Result<Value> {
+    let contents = match std::fs::read_to_string(path) {
+        Ok(contents) => contents,
+        Err(e) if e.kind() == std::io::ErrorKind::NotFound => {
+            return Ok(Value::Object(Default::default()))
+        }
+        Err(e) => return Err(e).with_context(|| format!("failed to read {}", path.display())),
+    };
+    if contents.trim().is_empty() {
+        return Ok(Value::Object(Default::default()));
+    }
+    let config: Value = serde_json::from_str(&contents)
+        .with_context(|| format!("{} is not valid JSON", path.display()))?;
+    anyhow::ensure!(
+        config.is_object(),
+        "{} does not contain a JSON object",
+        path.display()
+    );
+    Ok(config)
+}
+
+/// Write the config through a temporary file so a crash never truncates it.
+fn write_desktop_config(path: &Path, contents: &str) -> anyhow::Result<()> {
+    if let Some(parent) = path.parent() {
+        std::fs::create_dir_all(parent)
+            .with_context(|| format!("failed to create {}", parent.display()))?;
+    }
+    let temp_path = path.with_extension("json.tmp");
+    std::fs::write(&temp_path, contents)
+        .with_context(|| format!("failed to write {}", temp_path.display()))?;
+    std::fs::rename(&temp_path, path)
+        .with_context(|| format!("failed to replace {}", path.display()))
+}
+
+/// Server block launching `valknut mcp-stdio`.
+fn server_entry(command: &str) -> Value {
+    serde_json::json!({
+        "command": command,
+        "args": ["mcp-stdio"],
+    })
+}
+
+/// Add or replace the server `name`; returns whether the config changed.
+fn register_server(config: &mut Value, name: &str, entry: Value) -> anyhow::Result<bool> {
+    let servers = config
+        .as_object_mut()
+        .context("Claude Desktop config is not a JSON object")?
+        .entry(MCP_SERVERS_KEY)
+        .or_insert_with(|| Value::Object(Default::default()))
+        .as_object_mut()
+        .with_context(|| format!("`{MCP_SERVERS_KEY}` is not a JSON object"))?;
+    if servers.get(name) == Some(&entry) {
+        return Ok(false);
+    }
+    servers.insert(name.to_string(), entry);
+    Ok(true)
+}
+
+/// Remove the server `name`, dropping `mcpServers` once it is empty; returns
+/// whether the config changed.
+fn unregister_server(config: &mut Value, name: &str) -> bool {
+    let Some(root) = config.as_object_mut() else {
+        return false;
+    };
+    let Some(servers) = root.get_mut(MCP_SERVERS_KEY).and_then(Value::as_object_mut) else {
+        return false;
+    };
+    if servers.remove(name).is_none() {
+        return false;
+    }
+    if servers.is_empty() {
+        root.remove(MCP_SERVERS_KEY);
+    }
+    true
+}
+
+/// Process name of the Claude Desktop app on this platform.
+fn claude_desktop_process() -> &'static str {
+    if cfg!(target_os = "macos") {
+        "Claude"
+    } else {
+        "claude-desktop"
+    }
+}
+
+/// Whether Claude Desktop is running (never on platforms without `pgrep`).
+fn claude_desktop_running() -> bool {
+    Command::new("pgrep")
+        .args(["-x", claude_desktop_process()])
+        .stdout(Stdio::null())
+        .stderr(Stdio::null())
+        .status()
+        .is_ok_and(|status| status.success())
+}
+
+/// Quit Claude Desktop and launch it again so it reloads its MCP servers.
+fn restart_claude_desktop() -> anyhow::Result<()> {
+    if cfg!(target_os = "macos") {
+        run_quietly(Command::new("osascript").args(["-e", "quit app \"Claude\""]))?;
+        wait_for_exit();
+        run_quietly(Command::new("open").args(["-a", "Claude"]))
+    } else {
+        run_quietly(Command::new("pkill").args(["-x", claude_desktop_process()]))?;
+        wait_for_exit();
+        Command::new(claude_desktop_process())
+            .stdin(Stdio::null())
+            .stdout(Stdio::null())
+            .stderr(Stdio::null())
+            .spawn()
+            .with_context(|| format!("failed to launch {}", claude_desktop_process()))?;
+        Ok(())
+    }
+}
+
+/// Wait up to five seconds for Claude Desktop to exit.
+fn wait_for_exit() {
+    for _ in 0..50 {
+        if !claude_desktop_running() {
+            return;
+        }
+        std::thread::sleep(std::time::Duration::from_millis(100));
+    }
+}
+
+/// Run a helper command, failing when it cannot start or exits unsuccessfully.
+fn run_quietly(command: &mut Command) -> anyhow::Result<()> {
+    let program = command.get_program().to_string_lossy().into_owned();
+    let status = command
+        .stdout(Stdio::null())
+        .stderr(Stdio::null())
+        .status()
+        .with_context(|| format!("failed to run {program}"))?;
+    anyhow::ensure!(status.success(), "{program} exited with {status}");
+    Ok(())
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn install_args(config_path: &Path) -> McpInstallArgs {
+        McpInstallArgs {
+            config_path: Some(config_path.to_path_buf()),
+            name: "valknut".to_string(),
+            dry_run: false,
+            no_restart: true,
+        }
+    }
+
+    #[test]
+    fn register_keeps_other_servers_and_settings() {
+        let mut config = serde_json::json!({
+            "theme": "dark",
+            "mcpServers": {"files": {"command": "mcp-files"}},
+        });
+        let entry = server_entry("/usr/local/bin/valknut");
+
+        assert!(register_server(&mut config, "valknut", entry.clone()).unwrap());
+        assert!(!register_server(&mut config, "valknut", entry.clone()).unwrap());
+        assert_eq!(config["theme"], "dark");
+        assert_eq!(config["mcpServers"]["files"]["command"], "mcp-files");
+        assert_eq!(config["mcpServers"]["valknut"], entry);
+        assert_eq!(config["mcpServers"]["valknut"]["args"][0], "mcp-stdio");
+
+        assert!(unregister_server(&mut config, "valknut"));
+        assert!(!unregister_server(&mut config, "valknut"));
+        assert!(config["mcpServers"].get("valknut").is_none());
+        assert_eq!(config["mcpServers"]["files"]["command"], "mcp-files");
+    }
+
+    #[test]
+    fn unregister_drops_empty_server_map() {
+        let mut config = serde_json::json!({"mcpServers": {}});
+        register_server(&mut config, "valknut", server_entry("valknut")).unwrap();
+        assert!(unregister_server(&mut config, "valknut"));
+        assert_eq!(config, serde_json::json!({}));
+    }
+
+    #[test]
+    fn install_creates_missing_config_and_uninstall_removes_it() {
+        let temp = tempfile::tempdir().unwrap();
+        let config_path = temp.path().join("Claude/claude_desktop_config.json");
+
+        let mut dry_run = install_args(&config_path);
+        dry_run.dry_run = true;
+        mcp_install_command(dry_run).unwrap();
+        assert!(!config_path.exists(), "--dry-run must not write");
+
+        mcp_install_command(install_args(&config_path)).unwrap();
+        let config = read_desktop_config(&config_path).unwrap();
+        assert_eq!(config["mcpServers"]["valknut"]["args"][0], "mcp-stdio");
+
+        mcp_uninstall_command(install_args(&config_path)).unwrap();
+        assert_eq!(
+            read_desktop_config(&config_path).unwrap(),
+            serde_json::json!({})
+        );
+    }
+
+    #[test]
+    fn rejects_non_object_config() {
+        let temp = tempfile::tempdir().unwrap();
+        let config_path = temp.path().join("claude_desktop_config.json");
+        std::fs::write(&config_path, "[1, 2]").unwrap();
+        assert!(read_desktop_config(&config_path).is_err());
+    }
+}
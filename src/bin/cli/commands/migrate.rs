@@ -0,0 +1,91 @@
+//! Flag file migration command implementation.
+//!
+//! `valknut migrate` upgrades `valknut.toml` / `.valknut.yaml` to the current
+//! `config-version` through the chain of steps in
+//! [`crate::cli::flag_migration`], prints the steps and a diff of each file,
+//! and writes the result after saving the original as `<file>.v<N>.bak`.
+//! With `--dry-run` nothing is written.
+
+use owo_colors::OwoColorize;
+
+use crate::cli::args::MigrateArgs;
+use crate::cli::flag_config::discover_flag_files;
+use crate::cli::flag_migration::{migrate_flag_file, MigratedFile};
+use crate::cli::text_diff::{line_diff, DiffLine};
+
+/// Run the migrate command over the given or discovered flag files.
+pub fn migrate_command(args: MigrateArgs) -> anyhow::Result<()> {
+    let files = match args.file {
+        Some(path) => vec![path],
+        None => {
+            let cwd = std::env::current_dir()?;
+            discover_flag_files(&cwd)
+        }
+    };
+    if files.is_empty() {
+        anyhow::bail!(
+            "No valknut.toml or .valknut.yaml found in the repository root or current directory"
+        );
+    }
+
+    for path in &files {
+        let migrated = migrate_flag_file(path)?;
+        if migrated.is_unchanged() {
+            println!(
+                "{} {} is already at version {}",
+                "✅".green(),
+                path.display(),
+                migrated.report.to
+            );
+            continue;
+        }
+
+        print!("{}", render_migration(&migrated));
+        if args.dry_run {
+            println!("{} nothing written (--dry-run)", "ℹ️".blue());
+        } else {
+            let backup = migrated.write()?;
+            println!(
+                "{} Migrated {} (original saved as {})",
+                "✅".green(),
+                path.display(),
+                backup.display()
+            );
+        }
+    }
+    Ok(())
+}
+
+/// Steps applied to a file followed by a diff of its contents.
+fn render_migration(migrated: &MigratedFile) -> String {
+    let report = &migrated.report;
+    let mut out = format!(
+        "{} {} from version {} to {}\n",
+        "Migrating".bold(),
+        migrated.path.display(),
+        report.from,
+        report.to
+    );
+    for (from, step) in (report.from..).zip(&report.steps) {
+        out.push_str(&format!("  v{from} -> v{}: {step}\n", from + 1));
+    }
+
+    out.push_str(&format!(
+        "--- {} (version {})\n",
+        migrated.path.display(),
+        report.from
+    ));
+    out.push_str(&format!(
+        "+++ {} (version {})\n",
+        migrated.path.display(),
+        report.to
+    ));
+    for line in line_diff(&migrated.original, &migrated.migrated) {
+        match line {
+            DiffLine::Same(text) => out.push_str(&format!(" {text}\n")),
+            DiffLine::Removed(text) => out.push_str(&format!("{}\n", format!("-{text}").red())),
+            DiffLine::Added(text) => out.push_str(&format!("{}\n", format!("+{text}").green())),
+        }
+    }
+    out
+}
@@ -2,29 +2,139 @@
 //!
 //! This module contains all command implementations for the Valknut CLI:
 //! - analyze: Main code analysis command
+//! - archive: Tagged, compressed analysis snapshots
+//! - blame: Symbol ownership from git history
+//! - check: Structural rule enforcement for CI
+//! - compare: Structural comparison of two repositories
 //! - config: Configuration management commands
+//! - diff: Structural diff between analysis snapshots
 //! - doc_audit: Documentation audit command
-//! - mcp: MCP server commands
+//! - doctor: Preflight checks for common setup problems
+//! - export: Markdown project summary and LLM context documents
+//! - fmt: Canonical formatting of doc comments
+//! - grpc_client: Interactive test client for the gRPC server
+//! - init: Project-aware valknut.toml scaffolding
+//! - lint: Rule violations with in-place fixes for the safe ones
+//! - mcp: MCP server commands and Claude Desktop registration
+//! - migrate: Flag file upgrades to the current config-version
+//! - openapi: OpenAPI extraction from Go HTTP routes
 //! - oracle: AI refactoring oracle commands
+//! - plugins: External language parser plugins
+//! - review: Review context for the changes since a base branch
+//! - serve: gRPC server mode
+//! - stats: Aggregate repository metrics from the incremental cache
+//! - token_budget: LLM context window planning
+//! - tui: Interactive explorer over the incremental cache
+//! - validate: Analysis output schema validation
+//! - watch: Continuous re-analysis on filesystem changes
+//! - xref: Symbol cross-reference lookup
 
 pub mod analyze;
+pub mod archive;
+pub mod blame;
+pub mod check;
+pub mod compare;
 pub mod config;
+pub mod diff;
 pub mod doc_audit;
+pub mod doctor;
+pub mod export;
+pub mod fmt;
+pub mod grpc_client;
+pub mod init;
+pub mod lint;
 pub mod mcp;
+pub mod migrate;
+pub mod openapi;
 pub mod oracle;
+pub mod plugins;
+pub mod review;
+pub mod serve;
+pub mod stats;
+pub mod token_budget;
+pub mod tui;
+pub mod validate;
+pub mod watch;
+pub mod xref;
 
 // Re-export analyze command items (previously at cli::commands level)
 pub use analyze::*;
 
+// Re-export archive command
+pub use archive::archive_command;
+
+// Re-export blame command
+pub use blame::blame_command;
+
+// Re-export check command
+pub use check::check_command;
+
+// Re-export compare command
+pub use compare::compare_command;
+
 // Re-export config command items
 pub use super::config_builder::load_configuration;
-pub use config::{init_config, print_default_config, validate_config};
+pub use config::{config_command, init_config, print_default_config, validate_config};
+
+// Re-export diff command
+pub use diff::diff_command;
 
 // Re-export doc_audit command
 pub use doc_audit::doc_audit_command;
 
+// Re-export doctor command
+pub use doctor::doctor_command;
+
+// Re-export export command
+pub use export::export_command;
+
+// Re-export fmt command
+pub use fmt::fmt_command;
+
+// Re-export grpc_client command
+pub use grpc_client::grpc_client_command;
+
+// Re-export init command
+pub use init::init_command;
+
+// Re-export lint command
+pub use lint::lint_command;
+
+// Re-export openapi command
+pub use openapi::openapi_command;
+
 // Re-export mcp commands
-pub use mcp::{mcp_manifest_command, mcp_stdio_command};
+pub use mcp::{mcp_command, mcp_manifest_command, mcp_stdio_command};
+
+// Re-export migrate command
+pub use migrate::migrate_command;
 
 // Re-export oracle commands
 pub use oracle::{run_oracle_analysis, run_oracle_dry_run};
+
+// Re-export plugins command
+pub use plugins::plugins_command;
+
+// Re-export review command
+pub use review::review_command;
+
+// Re-export serve command
+pub use serve::serve_command;
+
+// Re-export stats command
+pub use stats::stats_command;
+
+// Re-export token_budget command
+pub use token_budget::token_budget_command;
+
+// Re-export tui command
+pub use tui::tui_command;
+
+// Re-export validate command
+pub use validate::validate_command;
+
+// Re-export watch command
+pub use watch::watch_command;
+
+// Re-export xref command
+pub use xref::xref_command;
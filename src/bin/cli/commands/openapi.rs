@@ -0,0 +1,41 @@
+//! OpenAPI extraction command implementation.
+//!
+//! `valknut openapi <path>` finds the HTTP routes a Go service registers with
+//! net/http, chi, gin, echo or gorilla/mux and writes them as a partial
+//! OpenAPI 3.0 document in JSON or YAML.
+
+use anyhow::Context;
+
+use crate::cli::args::{OpenApiArgs, OpenApiFormat};
+use valknut_rs::core::http_routes::{extract_routes, openapi_document};
+
+/// Title used when the scanned path has no usable directory name.
+const DEFAULT_TITLE: &str = "API";
+
+/// Run the openapi command, writing the document to `--out` or stdout.
+pub fn openapi_command(args: OpenApiArgs) -> anyhow::Result<()> {
+    let routes = extract_routes(&args.path)?;
+    let title = args.title.clone().unwrap_or_else(|| {
+        args.path
+            .canonicalize()
+            .ok()
+            .and_then(|path| {
+                path.file_stem()
+                    .map(|name| name.to_string_lossy().into_owned())
+            })
+            .unwrap_or_else(|| DEFAULT_TITLE.to_string())
+    });
+
+    let document = openapi_document(&routes, &title);
+    let rendered = match args.format {
+        OpenApiFormat::Json => document.to_json()? + "\n",
+        OpenApiFormat::Yaml => document.to_yaml()?,
+    };
+
+    match &args.out {
+        Some(path) => std::fs::write(path, rendered)
+            .with_context(|| format!("failed to write {}", path.display()))?,
+        None => print!("{rendered}"),
+    }
+    Ok(())
+}
@@ -0,0 +1,87 @@
+//! Plugins Command Implementation
+//!
+//! `valknut plugins list` shows the built-in parsers and the external language
+//! parsers found in the plugin directory, and `valknut plugins parse <file>` runs one of them so
+//! plugin authors can check their output. See `valknut_rs::lang::plugins` for
+//! the plugin protocol.
+
+use owo_colors::OwoColorize;
+use tabled::{settings::Style as TableStyle, Table, Tabled};
+
+use crate::cli::args::{PluginDirArgs, PluginsArgs, PluginsCommand};
+use valknut_rs::core::config::ValknutConfig;
+use valknut_rs::lang::plugins::{LanguageParser, PluginConfig, PluginRegistry};
+
+/// Run a `valknut plugins` subcommand
+pub fn plugins_command(args: PluginsArgs) -> anyhow::Result<()> {
+    match args.command {
+        PluginsCommand::List(args) => list_plugins(&args),
+        PluginsCommand::Parse(args) => {
+            let registry = PluginRegistry::with_builtins(&plugin_config(&args.plugins)?);
+            let analysis = registry.parse_file(&args.file)?;
+            println!("{}", serde_json::to_string_pretty(&analysis)?);
+            Ok(())
+        }
+    }
+}
+
+/// Print loaded plugins and load failures
+fn list_plugins(args: &PluginDirArgs) -> anyhow::Result<()> {
+    let config = plugin_config(args)?;
+    let registry = PluginRegistry::with_builtins(&config);
+    println!(
+        "{} {}",
+        "🔌 Language plugins in".bright_blue().bold(),
+        config.directory.display().to_string().cyan()
+    );
+
+    /// Table row for plugin listing output.
+    #[derive(Tabled)]
+    struct PluginRow {
+        name: String,
+        extensions: String,
+    }
+
+    let rows: Vec<PluginRow> = registry
+        .parsers()
+        .map(|parser| PluginRow {
+            name: parser.name().to_string(),
+            extensions: parser
+                .extensions()
+                .iter()
+                .map(|ext| format!(".{}", ext))
+                .collect::<Vec<_>>()
+                .join(", "),
+        })
+        .collect();
+
+    if rows.is_empty() {
+        println!("   No plugins loaded");
+    } else {
+        let mut table = Table::new(rows);
+        table.with(TableStyle::rounded());
+        println!("{}", table);
+    }
+
+    for error in registry.errors() {
+        eprintln!(
+            "   {} {}: {}",
+            "❌".red(),
+            error.path.display(),
+            error.message
+        );
+    }
+    Ok(())
+}
+
+/// Resolve plugin settings from the config file and `--dir`
+fn plugin_config(args: &PluginDirArgs) -> anyhow::Result<PluginConfig> {
+    let mut config = match &args.config {
+        Some(path) => ValknutConfig::from_yaml_file(path)?.plugins,
+        None => PluginConfig::default(),
+    };
+    if let Some(dir) = &args.dir {
+        config.directory = dir.clone();
+    }
+    Ok(config)
+}
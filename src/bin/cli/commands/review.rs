@@ -0,0 +1,35 @@
+//! Diff review command implementation.
+//!
+//! `valknut review --base main` summarises `git diff main...HEAD` for a
+//! reviewer or an LLM prompt: the changed files and their packages, the
+//! signatures of added and modified functions, the types they touch and the
+//! tests that cover them, as Markdown or JSON.
+
+use anyhow::Context;
+
+use crate::cli::args::{ReviewArgs, ReviewFormat};
+use valknut_rs::core::review::review_context;
+use valknut_rs::io::cache::IncrementalCache;
+
+/// Run the review command, writing the document to `--out` or stdout.
+pub fn review_command(args: ReviewArgs) -> anyhow::Result<()> {
+    let cache_dir = if args.no_cache {
+        None
+    } else {
+        args.cache_dir
+            .clone()
+            .or_else(IncrementalCache::default_dir)
+    };
+    let context = review_context(&args.path, &args.base, cache_dir.as_deref())?;
+    let document = match args.format {
+        ReviewFormat::Markdown => context.to_markdown(),
+        ReviewFormat::Json => serde_json::to_string_pretty(&context)? + "\n",
+    };
+
+    match &args.out {
+        Some(path) => std::fs::write(path, document)
+            .with_context(|| format!("failed to write {}", path.display()))?,
+        None => print!("{document}"),
+    }
+    Ok(())
+}
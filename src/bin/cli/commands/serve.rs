@@ -0,0 +1,80 @@
+//! Serve Command Implementation
+//!
+//! `valknut serve --grpc <addr>` exposes analysis over the `valknut.v1` gRPC
+//! API (see `proto/valknut/v1/valknut.proto`) and `--http <addr>` over the
+//! JSON REST API of [`crate::http::server`]; both may run at once, sharing one
+//! worker pool, until SIGINT/SIGTERM.
+
+use futures::FutureExt;
+use tracing::info;
+
+use crate::cli::args::ServeArgs;
+use crate::cli::commands::watch::shutdown_signal;
+use crate::grpc::server::{parse_listen_addr, serve, GrpcService, TlsFiles};
+use crate::http::server::{self as http_server, HttpService};
+use crate::worker_pool::WorkerPool;
+use valknut_rs::core::config::ValknutConfig;
+
+/// Run the requested servers until shutdown is requested.
+pub async fn serve_command(args: ServeArgs) -> anyhow::Result<()> {
+    let grpc_addr = args.grpc.as_deref().map(parse_listen_addr).transpose()?;
+    let http_addr = args.http.as_deref().map(parse_listen_addr).transpose()?;
+    let config = match &args.config {
+        Some(path) => ValknutConfig::from_yaml_file(path)?,
+        None => ValknutConfig::default(),
+    };
+    let max_concurrent = args.max_concurrent.unwrap_or_else(WorkerPool::default_size);
+    let pool = WorkerPool::new(max_concurrent);
+    let rate_limit = args.rate_limit;
+    let tls = match (args.tls_cert, args.tls_key) {
+        (Some(cert), Some(key)) => Some(TlsFiles { cert, key }),
+        _ => None,
+    };
+    let shutdown = shutdown_signal().boxed().shared();
+
+    let grpc = async {
+        let Some(addr) = grpc_addr else {
+            return Ok(());
+        };
+        println!(
+            "Serving valknut.v1 gRPC on {} ({}, up to {} concurrent requests)",
+            addr,
+            if tls.is_some() { "TLS" } else { "plaintext" },
+            max_concurrent
+        );
+        serve(
+            addr,
+            GrpcService::new(config.clone(), pool.clone()),
+            tls.as_ref(),
+            shutdown.clone(),
+        )
+        .await?;
+        info!("gRPC server stopped");
+        anyhow::Ok(())
+    };
+
+    let http = async {
+        let Some(addr) = http_addr else {
+            return Ok(());
+        };
+        let rate = match rate_limit {
+            Some(limit) => format!(", at most {limit} requests/s"),
+            None => String::new(),
+        };
+        println!(
+            "Serving the REST API on http://{} (up to {} concurrent requests{})",
+            addr, max_concurrent, rate
+        );
+        http_server::serve(
+            addr,
+            HttpService::new(config.clone(), pool.clone(), rate_limit),
+            shutdown.clone(),
+        )
+        .await?;
+        info!("HTTP server stopped");
+        anyhow::Ok(())
+    };
+
+    tokio::try_join!(grpc, http)?;
+    Ok(())
+}
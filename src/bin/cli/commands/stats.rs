@@ -0,0 +1,166 @@
+//! Repository statistics command implementation.
+//!
+//! `valknut stats` summarises the incremental cache written by `analyze`
+//! (sizes, languages, exported symbols, complexity and import hot spots)
+//! without re-parsing any file.
+
+use anyhow::Context;
+use owo_colors::OwoColorize;
+use tabled::{settings::Style as TableStyle, Table, Tabled};
+
+use crate::cli::args::StatsArgs;
+use valknut_rs::io::cache::stats::FileStats;
+use valknut_rs::io::cache::{IncrementalCache, RepositoryStats};
+
+/// Run the stats command and print the summary.
+pub fn stats_command(args: StatsArgs) -> anyhow::Result<()> {
+    let cache_dir = args
+        .cache_dir
+        .clone()
+        .or_else(IncrementalCache::default_dir)
+        .context("no cache directory: pass --cache-dir")?;
+    let cache = IncrementalCache::open(&cache_dir);
+    let stats = RepositoryStats::collect(&cache, &args.paths, args.top)?;
+
+    if args.json {
+        println!("{}", serde_json::to_string_pretty(&stats)?);
+        return Ok(());
+    }
+    if stats.total_files == 0 {
+        println!(
+            "{} No cached analysis under the given paths; run `valknut analyze` first.",
+            "ℹ️".bright_blue()
+        );
+    }
+    print!("{}", render_text(&stats));
+    Ok(())
+}
+
+/// Render the summary as a set of tables.
+fn render_text(stats: &RepositoryStats) -> String {
+    #[derive(Tabled)]
+    struct MetricRow {
+        metric: String,
+        value: String,
+    }
+
+    #[derive(Tabled)]
+    struct LanguageRow {
+        language: String,
+        files: usize,
+        lines: usize,
+        symbols: usize,
+    }
+
+    #[derive(Tabled)]
+    struct FileRow {
+        file: String,
+        lines: usize,
+        symbols: usize,
+    }
+
+    #[derive(Tabled)]
+    struct ImportRow {
+        module: String,
+        importers: usize,
+    }
+
+    let cache = &stats.cache;
+    let metrics = [
+        ("Files", stats.total_files.to_string()),
+        ("Lines of code", stats.total_lines.to_string()),
+        ("Symbols", stats.total_symbols.to_string()),
+        ("Exported symbols", stats.exported_symbols.to_string()),
+        (
+            "Avg. cyclomatic complexity",
+            format!("{:.2}", stats.average_cyclomatic_complexity),
+        ),
+        (
+            "Cache hit rate",
+            format!(
+                "{:.1}% ({} fresh, {} stale, {} uncached)",
+                cache.hit_rate * 100.0,
+                cache.hits,
+                cache.stale,
+                cache.uncached
+            ),
+        ),
+        (
+            "Oldest cache entry",
+            cache
+                .oldest_entry_age_secs
+                .map_or_else(|| "unknown".to_string(), format_age),
+        ),
+    ]
+    .into_iter()
+    .map(|(metric, value)| MetricRow {
+        metric: metric.to_string(),
+        value,
+    });
+
+    let files = |files: &[FileStats]| {
+        files
+            .iter()
+            .map(|file| FileRow {
+                file: file.path.clone(),
+                lines: file.lines,
+                symbols: file.symbols,
+            })
+            .collect::<Vec<_>>()
+    };
+
+    let mut output = String::new();
+    let mut section = |title: &str, mut table: Table| {
+        table.with(TableStyle::rounded());
+        output.push_str(&format!("{}\n{table}\n\n", title.bold()));
+    };
+    section("Summary", Table::new(metrics));
+    section(
+        "Languages",
+        Table::new(stats.languages.iter().map(|language| LanguageRow {
+            language: language.language.clone(),
+            files: language.files,
+            lines: language.lines,
+            symbols: language.symbols,
+        })),
+    );
+    section(
+        "Largest files by lines",
+        Table::new(files(&stats.largest_by_lines)),
+    );
+    section(
+        "Largest files by symbols",
+        Table::new(files(&stats.largest_by_symbols)),
+    );
+    section(
+        "Most imported modules",
+        Table::new(stats.most_imported.iter().map(|module| ImportRow {
+            module: module.module.clone(),
+            importers: module.importers,
+        })),
+    );
+    output
+}
+
+/// Render an age in seconds using its largest whole unit.
+fn format_age(secs: u64) -> String {
+    match secs {
+        0..=59 => format!("{secs}s ago"),
+        60..=3_599 => format!("{}m ago", secs / 60),
+        3_600..=86_399 => format!("{}h ago", secs / 3_600),
+        _ => format!("{}d ago", secs / 86_400),
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn format_age_uses_largest_unit() {
+        assert_eq!(format_age(42), "42s ago");
+        assert_eq!(format_age(600), "10m ago");
+        assert_eq!(format_age(7_200), "2h ago");
+        assert_eq!(format_age(3 * 86_400 + 5), "3d ago");
+    }
+}
@@ -0,0 +1,96 @@
+//! Token budget planning command implementation.
+//!
+//! `valknut token-budget --model <model> --budget <tokens> <path>` ranks the
+//! source files of a project by query relevance, references to their exported
+//! symbols and recency, then keeps as many whole files as fit in the budget,
+//! counting tokens the way the model's tokenizer does. `--format paths` prints
+//! just the selected paths, ready to pipe into
+//! `valknut export --format context <path>`.
+
+use crate::cli::args::{TokenBudgetArgs, TokenBudgetFormat};
+use valknut_rs::core::token_budget::{plan_token_budget, ModelTokenizer, TokenBudgetPlan};
+
+/// Run the token-budget command and print the plan.
+pub fn token_budget_command(args: TokenBudgetArgs) -> anyhow::Result<()> {
+    let tokenizer = ModelTokenizer::for_model(&args.model)?;
+    let budget = args.budget.unwrap_or(tokenizer.context_window);
+    let query = args.query.as_deref().unwrap_or_default();
+    let plan = plan_token_budget(&args.path, &tokenizer, budget, query)?;
+
+    match args.format {
+        TokenBudgetFormat::Json => println!("{}", serde_json::to_string_pretty(&plan)?),
+        TokenBudgetFormat::Paths => {
+            for file in &plan.files {
+                println!("{}", file.path);
+            }
+        }
+        TokenBudgetFormat::Text => print!("{}", render_text(&plan)),
+    }
+    Ok(())
+}
+
+/// Render the plan as a ranked table followed by the total.
+fn render_text(plan: &TokenBudgetPlan) -> String {
+    let mut output = format!(
+        "Model: {} ({}), budget {} tokens\n",
+        plan.model, plan.family, plan.budget
+    );
+    if !plan.files.is_empty() {
+        output.push_str(&format!(
+            "{:>4}  {:>8}  {:>4}  path\n",
+            "#", "tokens", "refs"
+        ));
+    }
+    for (rank, file) in plan.files.iter().enumerate() {
+        output.push_str(&format!(
+            "{:>4}  {:>8}  {:>4}  {}\n",
+            rank + 1,
+            file.tokens,
+            file.references,
+            file.path
+        ));
+    }
+    output.push_str(&format!(
+        "Total: {} of {} tokens in {} files, {} dropped\n",
+        plan.total_tokens,
+        plan.budget,
+        plan.files.len(),
+        plan.dropped.len()
+    ));
+    output
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use valknut_rs::core::token_budget::{ModelFamily, PlannedFile};
+
+    fn planned(path: &str, tokens: usize, references: usize) -> PlannedFile {
+        PlannedFile {
+            path: path.to_string(),
+            tokens,
+            relevance: 0.0,
+            references,
+            modified: None,
+        }
+    }
+
+    #[test]
+    fn render_text_lists_files_in_priority_order_with_total() {
+        let plan = TokenBudgetPlan {
+            model: "gpt-4o".to_string(),
+            family: ModelFamily::OpenAi,
+            budget: 1000,
+            total_tokens: 700,
+            files: vec![planned("lib/util.go", 400, 12), planned("main.go", 300, 0)],
+            dropped: vec![planned("gen/big.go", 90_000, 0)],
+        };
+
+        let text = render_text(&plan);
+        assert!(text.starts_with("Model: gpt-4o (OpenAI), budget 1000 tokens\n"));
+        assert!(
+            text.contains("\n   1       400    12  lib/util.go\n   2       300     0  main.go\n")
+        );
+        assert!(text.ends_with("Total: 700 of 1000 tokens in 2 files, 1 dropped\n"));
+    }
+}
@@ -0,0 +1,881 @@
+//! Interactive repository explorer.
+//!
+//! `valknut tui [PATH]` browses the incremental cache written by `analyze`:
+//! a file tree on the left, the symbols of the selected file in the middle
+//! and details of the selected symbol on the right. Nothing is parsed, so the
+//! explorer opens instantly even on large repositories.
+//!
+//! Keys: arrows move, Enter expands a directory or opens a file's symbols,
+//! Tab switches panes, `/` fuzzy-searches paths and symbol names, `c` copies
+//! the selected symbol's fully-qualified name, `q` or Esc quits.
+
+use std::collections::{BTreeSet, HashSet};
+use std::path::{Path, PathBuf};
+
+use anyhow::Context;
+use ratatui::crossterm::event::{self, Event, KeyCode, KeyEvent, KeyEventKind, KeyModifiers};
+use ratatui::layout::{Constraint, Layout, Rect};
+use ratatui::style::{Color, Modifier, Style};
+use ratatui::text::Line;
+use ratatui::widgets::{Block, Borders, List, ListItem, ListState, Paragraph, Wrap};
+use ratatui::{DefaultTerminal, Frame};
+use serde_json::Value;
+
+use crate::cli::args::TuiArgs;
+use valknut_rs::core::featureset::CodeEntity;
+use valknut_rs::io::cache::IncrementalCache;
+
+/// Source lines shown under a symbol's details.
+const SOURCE_PREVIEW_LINES: usize = 40;
+
+/// Key hints shown in the status bar when no message is pending.
+const KEY_HINTS: &str =
+    "↑↓ move  Enter expand/open  ←→/Tab switch pane  / search  c copy name  q quit";
+
+/// Run the explorer until the user quits.
+pub fn tui_command(args: TuiArgs) -> anyhow::Result<()> {
+    let cache_dir = args
+        .cache_dir
+        .clone()
+        .or_else(IncrementalCache::default_dir)
+        .context("no cache directory: pass --cache-dir")?;
+    let root = args
+        .path
+        .canonicalize()
+        .with_context(|| format!("failed to resolve {}", args.path.display()))?;
+    let cache = IncrementalCache::open(&cache_dir);
+    let files = cached_files(&cache, &root);
+    if files.is_empty() {
+        anyhow::bail!(
+            "no cached analysis under {}; run `valknut analyze` first",
+            args.path.display()
+        );
+    }
+
+    let mut explorer = Explorer::new(files);
+    let mut terminal = ratatui::init();
+    let result = run(&mut terminal, &mut explorer);
+    ratatui::restore();
+    result
+}
+
+/// Draw frames and dispatch key presses until the explorer asks to quit.
+fn run(terminal: &mut DefaultTerminal, explorer: &mut Explorer) -> anyhow::Result<()> {
+    let mut clipboard: Option<arboard::Clipboard> = None;
+    while !explorer.quit {
+        terminal.draw(|frame| draw(frame, explorer))?;
+        let Event::Key(key) = event::read()? else {
+            continue;
+        };
+        if key.kind != KeyEventKind::Press {
+            continue;
+        }
+        if let Some(name) = explorer.handle_key(key) {
+            explorer.status = Some(copy_to_clipboard(&mut clipboard, &name));
+        }
+    }
+    Ok(())
+}
+
+/// Copy `text`, keeping the clipboard handle alive so X11 selections persist.
+fn copy_to_clipboard(clipboard: &mut Option<arboard::Clipboard>, text: &str) -> String {
+    if clipboard.is_none() {
+        match arboard::Clipboard::new() {
+            Ok(handle) => *clipboard = Some(handle),
+            Err(e) => return format!("Clipboard unavailable: {e}"),
+        }
+    }
+    match clipboard.as_mut().map(|handle| handle.set_text(text)) {
+        Some(Ok(())) => format!("Copied {text}"),
+        Some(Err(e)) => format!("Failed to copy {text}: {e}"),
+        None => "Clipboard unavailable".to_string(),
+    }
+}
+
+/// Cached files under `root` that still exist, sorted by relative path.
+fn cached_files(cache: &IncrementalCache, root: &Path) -> Vec<ExplorerFile> {
+    let mut files: Vec<ExplorerFile> = cache
+        .entries()
+        .into_iter()
+        .filter(|(path, _)| path.starts_with(root) && path.exists())
+        .map(|(path, entry)| {
+            let mut symbols = entry.entities;
+            symbols.sort_by_key(|symbol| symbol.line_range.map_or(0, |(start, _)| start));
+            ExplorerFile {
+                path: path.strip_prefix(root).unwrap_or(&path).to_path_buf(),
+                absolute_path: path,
+                lines_of_code: entry.lines_of_code,
+                imports: entry.imports,
+                symbols,
+            }
+        })
+        .collect();
+    files.sort_by(|a, b| a.path.cmp(&b.path));
+    files
+}
+
+/// A cached file shown in the explorer.
+#[derive(Debug, Clone)]
+struct ExplorerFile {
+    /// Path relative to the explored directory.
+    path: PathBuf,
+    /// Path on disk, used for the source preview.
+    absolute_path: PathBuf,
+    /// Lines of code recorded by the last analysis.
+    lines_of_code: usize,
+    /// Module specifiers the file imports.
+    imports: Vec<String>,
+    /// Symbols in source order.
+    symbols: Vec<CodeEntity>,
+}
+
+/// What a row of the left pane refers to.
+#[derive(Debug, Clone, PartialEq, Eq)]
+enum TreeEntry {
+    /// A directory, by relative path.
+    Dir(PathBuf),
+    /// A file, by index into [`Explorer::files`].
+    File(usize),
+}
+
+/// A visible row of the left pane.
+#[derive(Debug, Clone, PartialEq, Eq)]
+struct TreeRow {
+    /// Directory or file the row shows.
+    entry: TreeEntry,
+    /// Indentation level.
+    depth: usize,
+    /// Text shown for the row.
+    label: String,
+}
+
+/// Pane receiving navigation keys.
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+enum Focus {
+    /// The file tree.
+    Tree,
+    /// The symbol list.
+    Symbols,
+}
+
+/// Explorer state, independent of the terminal.
+#[derive(Debug)]
+struct Explorer {
+    /// Cached files sorted by path.
+    files: Vec<ExplorerFile>,
+    /// Directories currently expanded.
+    expanded: BTreeSet<PathBuf>,
+    /// Rows of the left pane.
+    rows: Vec<TreeRow>,
+    /// Selected row of the left pane.
+    tree_cursor: usize,
+    /// Selected symbol of the middle pane.
+    symbol_cursor: usize,
+    /// Pane receiving navigation keys.
+    focus: Focus,
+    /// Current search query; empty when not searching.
+    query: String,
+    /// Whether keys are being typed into the query.
+    editing_query: bool,
+    /// One-off message replacing the key hints.
+    status: Option<String>,
+    /// Set when the user asked to quit.
+    quit: bool,
+}
+
+/// Navigation and search methods for [`Explorer`].
+impl Explorer {
+    /// Start with every directory collapsed.
+    fn new(files: Vec<ExplorerFile>) -> Self {
+        let mut explorer = Self {
+            files,
+            expanded: BTreeSet::new(),
+            rows: Vec::new(),
+            tree_cursor: 0,
+            symbol_cursor: 0,
+            focus: Focus::Tree,
+            query: String::new(),
+            editing_query: false,
+            status: None,
+            quit: false,
+        };
+        explorer.refresh_rows();
+        explorer
+    }
+
+    /// Apply a key press, returning a name to copy to the clipboard.
+    fn handle_key(&mut self, key: KeyEvent) -> Option<String> {
+        self.status = None;
+        if key.modifiers.contains(KeyModifiers::CONTROL) && key.code == KeyCode::Char('c') {
+            self.quit = true;
+            return None;
+        }
+        if self.editing_query {
+            self.edit_query(key.code);
+            return None;
+        }
+
+        match key.code {
+            KeyCode::Char('q') => self.quit = true,
+            KeyCode::Esc if !self.query.is_empty() => self.set_query(String::new()),
+            KeyCode::Esc => self.quit = true,
+            KeyCode::Char('/') => {
+                self.editing_query = true;
+                self.focus = Focus::Tree;
+            }
+            KeyCode::Char('c') => {
+                let name = self.selected_qualified_name();
+                if name.is_none() {
+                    self.status = Some("Select a symbol to copy its name".to_string());
+                }
+                return name;
+            }
+            KeyCode::Up => self.move_cursor(-1),
+            KeyCode::Down => self.move_cursor(1),
+            KeyCode::PageUp => self.move_cursor(-10),
+            KeyCode::PageDown => self.move_cursor(10),
+            KeyCode::Enter => self.activate(),
+            KeyCode::Right | KeyCode::Tab if self.focus == Focus::Tree => {
+                if !self.visible_symbols().is_empty() {
+                    self.focus = Focus::Symbols;
+                }
+            }
+            KeyCode::Left | KeyCode::BackTab | KeyCode::Tab => self.leave_symbols_or_collapse(),
+            _ => {}
+        }
+        None
+    }
+
+    /// Handle a key while the search prompt is open.
+    fn edit_query(&mut self, code: KeyCode) {
+        match code {
+            KeyCode::Enter => self.editing_query = false,
+            KeyCode::Esc => {
+                self.editing_query = false;
+                self.set_query(String::new());
+            }
+            KeyCode::Backspace => {
+                let mut query = self.query.clone();
+                query.pop();
+                self.set_query(query);
+            }
+            KeyCode::Char(c) => {
+                let mut query = self.query.clone();
+                query.push(c);
+                self.set_query(query);
+            }
+            KeyCode::Up => self.move_cursor(-1),
+            KeyCode::Down => self.move_cursor(1),
+            _ => {}
+        }
+    }
+
+    /// Replace the search query and rebuild the left pane.
+    fn set_query(&mut self, query: String) {
+        self.query = query;
+        self.tree_cursor = 0;
+        self.symbol_cursor = 0;
+        self.refresh_rows();
+    }
+
+    /// Move the cursor of the focused pane by `delta` rows.
+    fn move_cursor(&mut self, delta: isize) {
+        let (cursor, len) = match self.focus {
+            Focus::Tree => (&mut self.tree_cursor, self.rows.len()),
+            Focus::Symbols => {
+                let len = self.visible_symbols().len();
+                (&mut self.symbol_cursor, len)
+            }
+        };
+        if len == 0 {
+            return;
+        }
+        *cursor = cursor.saturating_add_signed(delta).min(len - 1);
+        if self.focus == Focus::Tree {
+            self.symbol_cursor = 0;
+        }
+    }
+
+    /// Enter: toggle the selected directory or open the selected file.
+    fn activate(&mut self) {
+        if self.focus == Focus::Symbols {
+            return;
+        }
+        match self.rows.get(self.tree_cursor).map(|row| row.entry.clone()) {
+            Some(TreeEntry::Dir(dir)) => {
+                if !self.expanded.remove(&dir) {
+                    self.expanded.insert(dir);
+                }
+                self.refresh_rows();
+            }
+            Some(TreeEntry::File(_)) if !self.visible_symbols().is_empty() => {
+                self.focus = Focus::Symbols;
+            }
+            _ => {}
+        }
+    }
+
+    /// Left: return to the tree, or collapse the selected directory (or its parent).
+    fn leave_symbols_or_collapse(&mut self) {
+        if self.focus == Focus::Symbols {
+            self.focus = Focus::Tree;
+            return;
+        }
+        let Some(row) = self.rows.get(self.tree_cursor) else {
+            return;
+        };
+        let dir = match &row.entry {
+            TreeEntry::Dir(dir) if self.expanded.contains(dir) => dir.clone(),
+            TreeEntry::Dir(dir) => match dir.parent() {
+                Some(parent) if !parent.as_os_str().is_empty() => parent.to_path_buf(),
+                _ => return,
+            },
+            TreeEntry::File(index) => match self.files[*index].path.parent() {
+                Some(parent) if !parent.as_os_str().is_empty() => parent.to_path_buf(),
+                _ => return,
+            },
+        };
+        self.expanded.remove(&dir);
+        self.refresh_rows();
+        if let Some(position) = self
+            .rows
+            .iter()
+            .position(|row| row.entry == TreeEntry::Dir(dir.clone()))
+        {
+            self.tree_cursor = position;
+        }
+    }
+
+    /// Rebuild the left pane: the tree, or ranked matches while searching.
+    fn refresh_rows(&mut self) {
+        self.rows = if self.query.is_empty() {
+            tree_rows(&self.files, &self.expanded)
+        } else {
+            search_rows(&self.files, &self.query)
+        };
+        self.tree_cursor = self.tree_cursor.min(self.rows.len().saturating_sub(1));
+    }
+
+    /// The file under the tree cursor, if the cursor is on a file.
+    fn selected_file(&self) -> Option<&ExplorerFile> {
+        match self.rows.get(self.tree_cursor)?.entry {
+            TreeEntry::File(index) => self.files.get(index),
+            TreeEntry::Dir(_) => None,
+        }
+    }
+
+    /// Symbols of the selected file; while searching, the matching ones ranked
+    /// best first (all of them when only the path matched).
+    fn visible_symbols(&self) -> Vec<&CodeEntity> {
+        let Some(file) = self.selected_file() else {
+            return Vec::new();
+        };
+        if self.query.is_empty() {
+            return file.symbols.iter().collect();
+        }
+        let mut matches: Vec<(u32, &CodeEntity)> = file
+            .symbols
+            .iter()
+            .filter_map(|symbol| fuzzy_score(&self.query, &symbol.name).map(|s| (s, symbol)))
+            .collect();
+        if matches.is_empty() {
+            return file.symbols.iter().collect();
+        }
+        matches.sort_by(|a, b| b.0.cmp(&a.0));
+        matches.into_iter().map(|(_, symbol)| symbol).collect()
+    }
+
+    /// The symbol under the symbol cursor.
+    fn selected_symbol(&self) -> Option<&CodeEntity> {
+        self.visible_symbols().get(self.symbol_cursor).copied()
+    }
+
+    /// Fully-qualified name of the selected symbol.
+    fn selected_qualified_name(&self) -> Option<String> {
+        let file = self.selected_file()?;
+        let symbol = self.selected_symbol()?;
+        Some(qualified_name(file, symbol))
+    }
+}
+
+/// Rows of the collapsible file tree.
+///
+/// Directories appear where their first file sorts; the files and
+/// subdirectories of a collapsed directory are hidden.
+fn tree_rows(files: &[ExplorerFile], expanded: &BTreeSet<PathBuf>) -> Vec<TreeRow> {
+    let mut rows = Vec::new();
+    let mut listed_dirs: HashSet<PathBuf> = HashSet::new();
+    for (index, file) in files.iter().enumerate() {
+        let components: Vec<String> = file
+            .path
+            .components()
+            .map(|component| component.as_os_str().to_string_lossy().into_owned())
+            .collect();
+        let Some((name, dirs)) = components.split_last() else {
+            continue;
+        };
+
+        let mut dir = PathBuf::new();
+        let mut visible = true;
+        for (depth, component) in dirs.iter().enumerate() {
+            dir.push(component);
+            if listed_dirs.insert(dir.clone()) {
+                rows.push(TreeRow {
+                    entry: TreeEntry::Dir(dir.clone()),
+                    depth,
+                    label: format!("{component}/"),
+                });
+            }
+            if !expanded.contains(&dir) {
+                visible = false;
+                break;
+            }
+        }
+        if visible {
+            rows.push(TreeRow {
+                entry: TreeEntry::File(index),
+                depth: dirs.len(),
+                label: name.clone(),
+            });
+        }
+    }
+    rows
+}
+
+/// Files matching `query` by path or by a symbol name, best match first.
+fn search_rows(files: &[ExplorerFile], query: &str) -> Vec<TreeRow> {
+    let mut matches: Vec<(u32, usize)> = files
+        .iter()
+        .enumerate()
+        .filter_map(|(index, file)| {
+            let path_score = fuzzy_score(query, &file.path.to_string_lossy());
+            let symbol_score = file
+                .symbols
+                .iter()
+                .filter_map(|symbol| fuzzy_score(query, &symbol.name))
+                .max();
+            path_score.max(symbol_score).map(|score| (score, index))
+        })
+        .collect();
+    matches.sort_by(|a, b| b.0.cmp(&a.0).then(a.1.cmp(&b.1)));
+    matches
+        .into_iter()
+        .map(|(_, index)| TreeRow {
+            entry: TreeEntry::File(index),
+            depth: 0,
+            label: files[index].path.display().to_string(),
+        })
+        .collect()
+}
+
+/// Score `candidate` against a fuzzy `query`, or `None` when it does not match.
+///
+/// Every query character must appear in order (case-insensitively).
+/// Consecutive matches and matches at word starts (after a separator or at a
+/// camelCase hump) score higher.
+fn fuzzy_score(query: &str, candidate: &str) -> Option<u32> {
+    let chars: Vec<char> = candidate.chars().collect();
+    let mut score = 0;
+    let mut next = 0;
+    let mut previous: Option<usize> = None;
+    for wanted in query.chars().filter(|c| !c.is_whitespace()) {
+        let offset = chars[next..]
+            .iter()
+            .position(|c| c.to_lowercase().eq(wanted.to_lowercase()))?;
+        let index = next + offset;
+        score += 1;
+        if previous.is_some_and(|previous| previous + 1 == index) {
+            score += 4;
+        }
+        let word_start = index == 0
+            || !chars[index - 1].is_alphanumeric()
+            || (chars[index].is_uppercase() && chars[index - 1].is_lowercase());
+        if word_start {
+            score += 3;
+        }
+        previous = Some(index);
+        next = index + 1;
+    }
+    Some(score)
+}
+
+/// Fully-qualified name of a symbol.
+///
+/// Adapters that know the qualified name record it; otherwise the name is
+/// built from the declared package (or the file's module path), the method
+/// receiver, if any, and the symbol name.
+fn qualified_name(file: &ExplorerFile, symbol: &CodeEntity) -> String {
+    let property = |key: &str| symbol.properties.get(key).and_then(Value::as_str);
+    if let Some(name) = property("qualified_name") {
+        return name.to_string();
+    }
+
+    let module = property("package").map(str::to_string).unwrap_or_else(|| {
+        file.path
+            .with_extension("")
+            .components()
+            .map(|component| component.as_os_str().to_string_lossy().into_owned())
+            .collect::<Vec<_>>()
+            .join(".")
+    });
+    match property("receiver_base_type") {
+        Some(receiver) => format!("{module}.{receiver}.{}", symbol.name),
+        None => format!("{module}.{}", symbol.name),
+    }
+}
+
+/// Draw the three panes and the status bar.
+fn draw(frame: &mut Frame, explorer: &Explorer) {
+    let [body, status] =
+        Layout::vertical([Constraint::Min(1), Constraint::Length(1)]).areas(frame.area());
+    let [tree, symbols, details] = Layout::horizontal([
+        Constraint::Percentage(30),
+        Constraint::Percentage(30),
+        Constraint::Percentage(40),
+    ])
+    .areas(body);
+
+    draw_tree(frame, explorer, tree);
+    draw_symbols(frame, explorer, symbols);
+    draw_details(frame, explorer, details);
+
+    let status_line = if explorer.editing_query {
+        format!("/{}▏", explorer.query)
+    } else if let Some(message) = &explorer.status {
+        message.clone()
+    } else if !explorer.query.is_empty() {
+        format!("search: {}  (Esc clears)  {KEY_HINTS}", explorer.query)
+    } else {
+        KEY_HINTS.to_string()
+    };
+    frame.render_widget(
+        Paragraph::new(status_line).style(Style::default().fg(Color::DarkGray)),
+        status,
+    );
+}
+
+/// Border block for a pane, highlighted when it has focus.
+fn pane(title: String, focused: bool) -> Block<'static> {
+    let style = if focused {
+        Style::default().fg(Color::Cyan)
+    } else {
+        Style::default()
+    };
+    Block::default()
+        .borders(Borders::ALL)
+        .border_style(style)
+        .title(title)
+}
+
+/// Style of the selected row in a list.
+fn highlight() -> Style {
+    Style::default()
+        .add_modifier(Modifier::REVERSED)
+        .add_modifier(Modifier::BOLD)
+}
+
+/// Draw the file tree (or search results).
+fn draw_tree(frame: &mut Frame, explorer: &Explorer, area: Rect) {
+    let items: Vec<ListItem> = explorer
+        .rows
+        .iter()
+        .map(|row| {
+            let marker = match &row.entry {
+                TreeEntry::Dir(dir) if explorer.expanded.contains(dir) => "▾ ",
+                TreeEntry::Dir(_) => "▸ ",
+                TreeEntry::File(_) => "  ",
+            };
+            ListItem::new(format!("{}{marker}{}", "  ".repeat(row.depth), row.label))
+        })
+        .collect();
+    let title = if explorer.query.is_empty() {
+        format!(" Files ({}) ", explorer.files.len())
+    } else {
+        format!(" Matches ({}) ", explorer.rows.len())
+    };
+    let mut state = ListState::default().with_selected(Some(explorer.tree_cursor));
+    frame.render_stateful_widget(
+        List::new(items)
+            .block(pane(title, explorer.focus == Focus::Tree))
+            .highlight_style(highlight()),
+        area,
+        &mut state,
+    );
+}
+
+/// Draw the symbols of the selected file.
+fn draw_symbols(frame: &mut Frame, explorer: &Explorer, area: Rect) {
+    let symbols = explorer.visible_symbols();
+    let items: Vec<ListItem> = symbols
+        .iter()
+        .map(|symbol| {
+            ListItem::new(format!(
+                "{} {}",
+                kind_badge(&symbol.entity_type),
+                symbol.name
+            ))
+        })
+        .collect();
+    let mut state = ListState::default();
+    if explorer.focus == Focus::Symbols {
+        state.select(Some(explorer.symbol_cursor));
+    }
+    frame.render_stateful_widget(
+        List::new(items)
+            .block(pane(
+                format!(" Symbols ({}) ", symbols.len()),
+                explorer.focus == Focus::Symbols,
+            ))
+            .highlight_style(highlight()),
+        area,
+        &mut state,
+    );
+}
+
+/// Draw details of the selected symbol, or of the selected file.
+fn draw_details(frame: &mut Frame, explorer: &Explorer, area: Rect) {
+    let lines = match (explorer.selected_file(), explorer.focus) {
+        (Some(file), Focus::Symbols) => match explorer.selected_symbol() {
+            Some(symbol) => symbol_details(file, symbol),
+            None => file_details(file),
+        },
+        (Some(file), Focus::Tree) => file_details(file),
+        (None, _) => vec![Line::from("Select a file to list its symbols.")],
+    };
+    frame.render_widget(
+        Paragraph::new(lines)
+            .block(pane(" Details ".to_string(), false))
+            .wrap(Wrap { trim: false }),
+        area,
+    );
+}
+
+/// Summary of a file: size, symbol count and imports.
+fn file_details(file: &ExplorerFile) -> Vec<Line<'static>> {
+    let mut lines = vec![
+        Line::styled(
+            file.path.display().to_string(),
+            Style::default().add_modifier(Modifier::BOLD),
+        ),
+        Line::from(format!("{} lines of code", file.lines_of_code)),
+        Line::from(format!("{} symbols", file.symbols.len())),
+    ];
+    if !file.imports.is_empty() {
+        lines.push(Line::from(""));
+        lines.push(Line::from("Imports:"));
+        lines.extend(
+            file.imports
+                .iter()
+                .map(|import| Line::from(format!("  {import}"))),
+        );
+    }
+    lines
+}
+
+/// Name, location, properties and source preview of a symbol.
+fn symbol_details(file: &ExplorerFile, symbol: &CodeEntity) -> Vec<Line<'static>> {
+    let location = match symbol.line_range {
+        Some((start, end)) => format!("{}:{start}-{end}", file.path.display()),
+        None => file.path.display().to_string(),
+    };
+    let mut lines = vec![
+        Line::styled(
+            qualified_name(file, symbol),
+            Style::default().add_modifier(Modifier::BOLD),
+        ),
+        Line::from(format!("{} at {location}", symbol.entity_type)),
+        Line::from(""),
+    ];
+
+    let mut properties: Vec<(&String, &Value)> = symbol
+        .properties
+        .iter()
+        .filter(|(key, _)| !matches!(key.as_str(), "node_kind" | "start_byte" | "end_byte"))
+        .collect();
+    properties.sort_by(|a, b| a.0.cmp(b.0));
+    for (key, value) in properties {
+        let value = match value {
+            Value::String(text) => text.clone(),
+            other => other.to_string(),
+        };
+        lines.push(Line::from(format!("{key}: {value}")));
+    }
+
+    let source = if symbol.source_code.is_empty() {
+        source_excerpt(&file.absolute_path, symbol.line_range)
+    } else {
+        symbol.source_code.clone()
+    };
+    if !source.is_empty() {
+        lines.push(Line::from(""));
+        lines.extend(
+            source
+                .lines()
+                .take(SOURCE_PREVIEW_LINES)
+                .map(|line| Line::styled(line.to_string(), Style::default().fg(Color::Gray))),
+        );
+    }
+    lines
+}
+
+/// Lines `start..=end` of a file, or nothing when unavailable.
+fn source_excerpt(path: &Path, line_range: Option<(usize, usize)>) -> String {
+    let (Some((start, end)), Ok(source)) = (line_range, std::fs::read_to_string(path)) else {
+        return String::new();
+    };
+    source
+        .lines()
+        .skip(start.saturating_sub(1))
+        .take(end.saturating_sub(start) + 1)
+        .collect::<Vec<_>>()
+        .join("\n")
+}
+
+/// Short marker for an entity type.
+fn kind_badge(entity_type: &str) -> &'static str {
+    match entity_type.to_ascii_lowercase().as_str() {
+        "function" => "ƒ",
+        "method" => "m",
+        "class" | "struct" => "C",
+        "interface" | "trait" => "I",
+        "enum" => "E",
+        "constant" => "K",
+        "variable" => "v",
+        "module" => "M",
+        _ => "·",
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn file(path: &str, symbols: &[&str]) -> ExplorerFile {
+        ExplorerFile {
+            path: PathBuf::from(path),
+            absolute_path: PathBuf::from("/repo").join(path),
+            lines_of_code: 10,
+            imports: Vec::new(),
+            symbols: symbols
+                .iter()
+                .map(|name| CodeEntity::new(format!("{path}:{name}"), "Function", *name, path))
+                .collect(),
+        }
+    }
+
+    fn labels(explorer: &Explorer) -> Vec<String> {
+        explorer.rows.iter().map(|row| row.label.clone()).collect()
+    }
+
+    fn press(explorer: &mut Explorer, code: KeyCode) -> Option<String> {
+        explorer.handle_key(KeyEvent::new(code, KeyModifiers::NONE))
+    }
+
+    #[test]
+    fn tree_expands_and_collapses_directories() {
+        let mut explorer = Explorer::new(vec![
+            file("main.go", &["main"]),
+            file("pkg/api/server.go", &["Serve"]),
+            file("pkg/util.go", &["Clamp"]),
+        ]);
+        assert_eq!(labels(&explorer), vec!["main.go", "pkg/"]);
+
+        press(&mut explorer, KeyCode::Down);
+        press(&mut explorer, KeyCode::Enter);
+        assert_eq!(
+            labels(&explorer),
+            vec!["main.go", "pkg/", "api/", "util.go"]
+        );
+
+        press(&mut explorer, KeyCode::Down);
+        press(&mut explorer, KeyCode::Enter);
+        assert_eq!(
+            labels(&explorer),
+            vec!["main.go", "pkg/", "api/", "server.go", "util.go"]
+        );
+
+        // Left on a file collapses its directory and selects it.
+        press(&mut explorer, KeyCode::Down);
+        press(&mut explorer, KeyCode::Left);
+        assert_eq!(
+            labels(&explorer),
+            vec!["main.go", "pkg/", "api/", "util.go"]
+        );
+        assert_eq!(explorer.tree_cursor, 2);
+    }
+
+    #[test]
+    fn search_ranks_paths_and_symbols() {
+        let mut explorer = Explorer::new(vec![
+            file("cmd/root.go", &["Execute"]),
+            file("pkg/parser.go", &["ParseConfig", "tokenize"]),
+            file("pkg/printer.go", &["PrintReport"]),
+        ]);
+        press(&mut explorer, KeyCode::Char('/'));
+        for c in "pcfg".chars() {
+            press(&mut explorer, KeyCode::Char(c));
+        }
+        press(&mut explorer, KeyCode::Enter);
+        assert!(!explorer.editing_query);
+        assert_eq!(labels(&explorer), vec!["pkg/parser.go"]);
+
+        let symbols: Vec<&str> = explorer
+            .visible_symbols()
+            .iter()
+            .map(|symbol| symbol.name.as_str())
+            .collect();
+        assert_eq!(symbols, vec!["ParseConfig"]);
+
+        // Esc clears the search before it quits.
+        press(&mut explorer, KeyCode::Esc);
+        assert!(explorer.query.is_empty());
+        assert!(!explorer.quit);
+        press(&mut explorer, KeyCode::Esc);
+        assert!(explorer.quit);
+    }
+
+    #[test]
+    fn fuzzy_score_prefers_word_starts_and_runs() {
+        assert_eq!(fuzzy_score("xyz", "ParseConfig"), None);
+        assert!(fuzzy_score("pc", "ParseConfig") > fuzzy_score("pc", "specific"));
+        assert!(fuzzy_score("parse", "parse_args") > fuzzy_score("parse", "pxaxrxsxe"));
+        assert_eq!(fuzzy_score("", "anything"), Some(0));
+    }
+
+    #[test]
+    fn copy_yields_qualified_symbol_name() {
+        let mut server = file("pkg/api/server.go", &["Serve", "Handle"]);
+        server.symbols[1]
+            .properties
+            .insert("receiver_base_type".to_string(), Value::from("Server"));
+        let mut explorer = Explorer::new(vec![server]);
+        assert_eq!(press(&mut explorer, KeyCode::Char('c')), None);
+        assert!(explorer.status.is_some());
+
+        press(&mut explorer, KeyCode::Enter);
+        press(&mut explorer, KeyCode::Down);
+        press(&mut explorer, KeyCode::Enter);
+        press(&mut explorer, KeyCode::Down);
+        press(&mut explorer, KeyCode::Enter);
+        assert_eq!(explorer.focus, Focus::Symbols);
+        assert_eq!(
+            press(&mut explorer, KeyCode::Char('c')).as_deref(),
+            Some("pkg.api.server.Serve")
+        );
+        press(&mut explorer, KeyCode::Down);
+        assert_eq!(
+            press(&mut explorer, KeyCode::Char('c')).as_deref(),
+            Some("pkg.api.server.Server.Handle")
+        );
+
+        let mut kotlin = file("Order.kt", &["Order"]);
+        kotlin.symbols[0].properties.insert(
+            "qualified_name".to_string(),
+            Value::from("com.acme.orders.Order"),
+        );
+        assert_eq!(
+            qualified_name(&kotlin, &kotlin.symbols[0]),
+            "com.acme.orders.Order"
+        );
+    }
+}
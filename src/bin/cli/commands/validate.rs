@@ -0,0 +1,94 @@
+//! Analysis output validation command implementation.
+//!
+//! `valknut validate <output.json>` checks previously generated analysis
+//! output against the JSON Schema embedded in this binary and lists every
+//! missing or type-changed field, so a CI job can catch schema drift between
+//! valknut versions before it reaches downstream consumers.
+
+use std::path::Path;
+
+use anyhow::Context;
+use owo_colors::OwoColorize;
+
+use crate::cli::args::ValidateArgs;
+use valknut_rs::io::reports::{
+    validate_analysis_results, SchemaViolation, ANALYSIS_RESULTS_SCHEMA,
+};
+
+/// Run the validate command over every given output file.
+pub fn validate_command(args: ValidateArgs) -> anyhow::Result<()> {
+    if args.print_schema {
+        println!("{ANALYSIS_RESULTS_SCHEMA}");
+        return Ok(());
+    }
+
+    let mut invalid = 0;
+    for file in &args.files {
+        let violations = validate_file(file)?;
+        if violations.is_empty() {
+            println!("{} {}", "✅".green(), file.display());
+            continue;
+        }
+        invalid += 1;
+        println!(
+            "{} {}: {} schema violation(s)",
+            "❌".red(),
+            file.display(),
+            violations.len()
+        );
+        for violation in &violations {
+            println!("  {violation}");
+        }
+    }
+
+    if invalid > 0 {
+        anyhow::bail!(
+            "{invalid} of {} file(s) do not match the analysis output schema",
+            args.files.len()
+        );
+    }
+    Ok(())
+}
+
+/// Parse one JSON (or single-record JSONL) output file and validate it.
+fn validate_file(path: &Path) -> anyhow::Result<Vec<SchemaViolation>> {
+    let content = std::fs::read_to_string(path)
+        .with_context(|| format!("Failed to read {}", path.display()))?;
+    let document: serde_json::Value = serde_json::from_str(&content)
+        .with_context(|| format!("{} is not a JSON document", path.display()))?;
+    Ok(validate_analysis_results(&document))
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use std::path::PathBuf;
+
+    fn args(files: Vec<PathBuf>) -> ValidateArgs {
+        ValidateArgs {
+            files,
+            print_schema: false,
+        }
+    }
+
+    #[test]
+    fn fails_on_files_that_do_not_match_the_schema() {
+        let dir = tempfile::tempdir().unwrap();
+        let valid = dir.path().join("valid.json");
+        let invalid = dir.path().join("invalid.json");
+        let results = valknut_rs::core::pipeline::AnalysisResults::empty();
+        std::fs::write(&valid, serde_json::to_string(&results).unwrap()).unwrap();
+        std::fs::write(&invalid, r#"{"summary": []}"#).unwrap();
+
+        assert!(validate_command(args(vec![valid.clone()])).is_ok());
+        assert!(validate_command(args(vec![valid, invalid])).is_err());
+    }
+
+    #[test]
+    fn rejects_files_that_are_not_json() {
+        let dir = tempfile::tempdir().unwrap();
+        let report = dir.path().join("report.html");
+        std::fs::write(&report, "<html></html>").unwrap();
+        assert!(validate_file(&report).is_err());
+    }
+}
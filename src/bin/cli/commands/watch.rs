@@ -0,0 +1,383 @@
+//! Watch Command Implementation
+//!
+//! `valknut watch <dir>` keeps running, re-analyzes files as they change, and
+//! streams the results to subscribers over a Unix socket. Changes come from
+//! filesystem notifications; `--poll` (or a platform without them) rescans the
+//! tree every `--poll-interval-ms` instead. Every line written to
+//! a subscriber is one NDJSON record in the same shape as `--format ndjson`,
+//! tagged with an `event` field (`"update"` or `"delete"`).
+
+use std::path::{Path, PathBuf};
+use std::time::{Duration, Instant};
+
+use tokio::sync::broadcast;
+use tracing::{info, warn};
+
+use crate::cli::args::WatchArgs;
+use crate::cli::reports::{ndjson_file_records, ndjson_skipped_record};
+use valknut_rs::api::engine::ValknutEngine;
+use valknut_rs::core::config::ValknutConfig;
+use valknut_rs::core::pipeline::{SkipReason, SkippedFile};
+use valknut_rs::io::cache::IncrementalCache;
+use valknut_rs::io::watch::{Debouncer, FileChange, FileChangeKind, FileWatcher};
+
+/// Upper bound on how often delivered notifications are drained; draining is cheap.
+const MAX_EVENT_CHECK_INTERVAL: Duration = Duration::from_millis(100);
+
+/// Lower bound on the interval between full rescans when polling.
+const MIN_POLL_INTERVAL: Duration = Duration::from_millis(250);
+
+/// Number of undelivered records buffered per subscriber before it starts lagging.
+const SUBSCRIBER_BUFFER: usize = 256;
+
+/// First delay before accepting again after a failed accept; doubles per failure.
+const MIN_ACCEPT_BACKOFF: Duration = Duration::from_millis(50);
+
+/// Longest delay between accept attempts while accepting keeps failing.
+const MAX_ACCEPT_BACKOFF: Duration = Duration::from_secs(5);
+
+/// Run the watch loop until SIGINT/SIGTERM, draining in-flight work before exiting.
+pub async fn watch_command(args: WatchArgs) -> anyhow::Result<()> {
+    let root = args
+        .path
+        .canonicalize()
+        .map_err(|e| anyhow::anyhow!("Cannot watch {}: {}", args.path.display(), e))?;
+    if !root.is_dir() {
+        return Err(anyhow::anyhow!("Not a directory: {}", root.display()));
+    }
+
+    let config = match &args.config {
+        Some(path) => ValknutConfig::from_yaml_file(path)?,
+        None => ValknutConfig::default(),
+    };
+    let socket_path = args
+        .socket
+        .clone()
+        .unwrap_or_else(|| default_socket_path(&root));
+
+    let (events, _) = broadcast::channel::<String>(SUBSCRIBER_BUFFER);
+    let server = serve_subscribers(&socket_path, events.clone())?;
+
+    let mut watcher = FileWatcher::new(&root, args.poll);
+    let window = Duration::from_millis(args.debounce_ms);
+    let mut debouncer = Debouncer::new(window);
+    let (tick, mode) = if watcher.is_polling() {
+        let interval = Duration::from_millis(args.poll_interval_ms).max(MIN_POLL_INTERVAL);
+        (
+            interval,
+            format!("polling every {} ms", interval.as_millis()),
+        )
+    } else {
+        (
+            window.clamp(Duration::from_millis(10), MAX_EVENT_CHECK_INTERVAL),
+            "filesystem events".to_string(),
+        )
+    };
+    let mut ticker = tokio::time::interval(tick);
+    ticker.set_missed_tick_behavior(tokio::time::MissedTickBehavior::Delay);
+
+    println!(
+        "Watching {} ({} files, {}, debounce {} ms)",
+        root.display(),
+        watcher.tracked_files(),
+        mode,
+        args.debounce_ms
+    );
+    println!("Subscribe: {}", socket_path.display());
+
+    let shutdown = shutdown_signal();
+    tokio::pin!(shutdown);
+
+    loop {
+        tokio::select! {
+            _ = &mut shutdown => break,
+            _ = ticker.tick() => {
+                debouncer.record(watcher.poll(), Instant::now());
+                if let Some(batch) = debouncer.take_ready(Instant::now()) {
+                    // Awaited inline: a shutdown signal is only observed once the batch finishes.
+                    publish_batch(&config, &root, batch, &events).await;
+                }
+            }
+        }
+    }
+
+    info!("Shutdown requested; draining pending changes");
+    debouncer.record(watcher.poll(), Instant::now());
+    if debouncer.has_pending() {
+        publish_batch(&config, &root, debouncer.take_all(), &events).await;
+    }
+
+    server.abort();
+    let _ = std::fs::remove_file(&socket_path);
+    println!("Watch stopped.");
+    Ok(())
+}
+
+/// Default subscriber socket: under the runtime directory (or the cache directory
+/// where there is none) rather than inside the watched tree, named after the
+/// watched root so watchers of different trees never collide.
+fn default_socket_path(root: &Path) -> PathBuf {
+    let dir = dirs::runtime_dir()
+        .or_else(dirs::cache_dir)
+        .unwrap_or_else(std::env::temp_dir)
+        .join("valknut");
+    let hash = IncrementalCache::content_hash(&root.to_string_lossy());
+    dir.join(format!("watch-{}.sock", &hash[..16]))
+}
+
+/// Re-analyze the updated files in a batch and broadcast one record per change.
+async fn publish_batch(
+    config: &ValknutConfig,
+    root: &Path,
+    batch: Vec<FileChange>,
+    events: &broadcast::Sender<String>,
+) {
+    for record in analyze_batch(config, root, &batch).await {
+        // Sending only fails when nobody is subscribed, which is fine.
+        let _ = events.send(record.to_string());
+    }
+}
+
+/// Build the NDJSON records describing a batch of changes.
+async fn analyze_batch(
+    config: &ValknutConfig,
+    root: &Path,
+    batch: &[FileChange],
+) -> Vec<serde_json::Value> {
+    let relative = |path: &Path| -> String {
+        path.strip_prefix(root)
+            .unwrap_or(path)
+            .to_string_lossy()
+            .into_owned()
+    };
+
+    let mut records: Vec<serde_json::Value> = batch
+        .iter()
+        .filter(|change| change.kind == FileChangeKind::Delete)
+        .map(|change| {
+            serde_json::json!({
+                "type": "file",
+                "event": FileChangeKind::Delete,
+                "path": relative(&change.path),
+            })
+        })
+        .collect();
+
+    let updated: Vec<PathBuf> = batch
+        .iter()
+        .filter(|change| change.kind == FileChangeKind::Update)
+        .map(|change| change.path.clone())
+        .collect();
+    if updated.is_empty() {
+        return records;
+    }
+
+    info!("Re-analyzing {} changed file(s)", updated.len());
+    let results = match analyze_files(config, &updated).await {
+        Ok(results) => results,
+        Err(e) => {
+            warn!("Re-analysis failed: {}", e);
+            return records;
+        }
+    };
+
+    // Result paths are relative to the batch's common root; rebase them onto the watched root.
+    let base = results
+        .project_root
+        .strip_prefix(root)
+        .map(Path::to_path_buf)
+        .unwrap_or_default();
+    let mut file_records = ndjson_file_records(&results);
+    for record in &mut file_records {
+        if let Some(path) = record["path"].as_str().map(str::to_string) {
+            record["path"] = serde_json::json!(base.join(path).to_string_lossy());
+        }
+    }
+    // Updated files the analysis left out are reported as not analyzed.
+    for path in &updated {
+        let relative_path = relative(path);
+        if !file_records
+            .iter()
+            .any(|record| record["path"] == relative_path)
+        {
+            let skipped = SkippedFile {
+                path: path.clone(),
+                reason: SkipReason::NotAnalyzed,
+                size_bytes: std::fs::metadata(path).map_or(0, |meta| meta.len()),
+                limit_bytes: 0,
+                language: None,
+            };
+            file_records.push(ndjson_skipped_record(&skipped, &relative_path));
+        }
+    }
+    for mut record in file_records {
+        record["event"] = serde_json::json!(FileChangeKind::Update);
+        records.push(record);
+    }
+
+    records.push(serde_json::json!({
+        "type": "summary",
+        "event": FileChangeKind::Update,
+        "project_root": root,
+        "summary": results.summary,
+        "statistics": results.statistics,
+        "warnings": results.warnings,
+    }));
+    records
+}
+
+/// Run the analysis engine over a set of files.
+async fn analyze_files(
+    config: &ValknutConfig,
+    files: &[PathBuf],
+) -> valknut_rs::core::errors::Result<valknut_rs::api::results::AnalysisResults> {
+    let mut engine = ValknutEngine::new_from_valknut_config(config.clone()).await?;
+    engine.analyze_files(files).await
+}
+
+/// Accept subscribers on a Unix socket and forward every broadcast record to them.
+#[cfg(unix)]
+fn serve_subscribers(
+    socket_path: &Path,
+    events: broadcast::Sender<String>,
+) -> anyhow::Result<tokio::task::JoinHandle<()>> {
+    use tokio::io::AsyncWriteExt;
+    use tokio::net::UnixListener;
+
+    if let Some(parent) = socket_path.parent() {
+        std::fs::create_dir_all(parent)?;
+    }
+    // A socket left behind by a crashed watcher would make bind fail.
+    if socket_path.exists() {
+        std::fs::remove_file(socket_path)?;
+    }
+    let listener = UnixListener::bind(socket_path)
+        .map_err(|e| anyhow::anyhow!("Failed to bind {}: {}", socket_path.display(), e))?;
+
+    Ok(tokio::spawn(async move {
+        let mut backoff = MIN_ACCEPT_BACKOFF;
+        loop {
+            let mut stream = match listener.accept().await {
+                Ok((stream, _)) => {
+                    backoff = MIN_ACCEPT_BACKOFF;
+                    stream
+                }
+                Err(e) => {
+                    // Errors such as EMFILE persist until a descriptor is freed.
+                    warn!("Failed to accept watch subscriber: {}", e);
+                    tokio::time::sleep(backoff).await;
+                    backoff = (backoff * 2).min(MAX_ACCEPT_BACKOFF);
+                    continue;
+                }
+            };
+            let mut receiver = events.subscribe();
+            tokio::spawn(async move {
+                loop {
+                    let line = match receiver.recv().await {
+                        Ok(line) => line,
+                        Err(broadcast::error::RecvError::Lagged(skipped)) => {
+                            warn!("Watch subscriber lagged; dropped {} record(s)", skipped);
+                            continue;
+                        }
+                        Err(broadcast::error::RecvError::Closed) => break,
+                    };
+                    if stream.write_all(line.as_bytes()).await.is_err()
+                        || stream.write_all(b"\n").await.is_err()
+                    {
+                        break;
+                    }
+                }
+            });
+        }
+    }))
+}
+
+/// Unix sockets are unavailable on this platform.
+#[cfg(not(unix))]
+fn serve_subscribers(
+    _socket_path: &Path,
+    _events: broadcast::Sender<String>,
+) -> anyhow::Result<tokio::task::JoinHandle<()>> {
+    Err(anyhow::anyhow!(
+        "valknut watch requires Unix domain sockets, which this platform does not support"
+    ))
+}
+
+/// Resolve when the process receives SIGINT or SIGTERM.
+pub(crate) async fn shutdown_signal() {
+    let ctrl_c = async {
+        if let Err(e) = tokio::signal::ctrl_c().await {
+            warn!("Failed to listen for Ctrl-C: {}", e);
+            std::future::pending::<()>().await;
+        }
+    };
+
+    #[cfg(unix)]
+    let terminate = async {
+        use tokio::signal::unix::{signal, SignalKind};
+        match signal(SignalKind::terminate()) {
+            Ok(mut stream) => {
+                stream.recv().await;
+            }
+            Err(e) => {
+                warn!("Failed to listen for SIGTERM: {}", e);
+                std::future::pending::<()>().await;
+            }
+        }
+    };
+    #[cfg(not(unix))]
+    let terminate = std::future::pending::<()>();
+
+    tokio::select! {
+        _ = ctrl_c => {},
+        _ = terminate => {},
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use std::fs;
+    use tempfile::tempdir;
+
+    #[test]
+    fn default_socket_lives_outside_the_watched_tree() {
+        let root = Path::new("/srv/repo");
+        let socket = default_socket_path(root);
+        assert!(!socket.starts_with(root));
+        assert_eq!(socket, default_socket_path(root));
+        assert_ne!(socket, default_socket_path(Path::new("/srv/other")));
+    }
+
+    #[tokio::test]
+    async fn analyze_batch_tags_records_with_events() {
+        let tmp = tempdir().unwrap();
+        let root = tmp.path();
+        fs::write(root.join("app.py"), "def run():\n    return 1\n").unwrap();
+
+        let batch = vec![
+            FileChange {
+                path: root.join("app.py"),
+                kind: FileChangeKind::Update,
+            },
+            FileChange {
+                path: root.join("old.py"),
+                kind: FileChangeKind::Delete,
+            },
+        ];
+        let records = analyze_batch(&ValknutConfig::default(), root, &batch).await;
+
+        assert_eq!(records[0]["event"], "delete");
+        assert_eq!(records[0]["path"], "old.py");
+
+        let update = records
+            .iter()
+            .find(|record| record["type"] == "file" && record["path"] == "app.py")
+            .expect("updated file record");
+        assert_eq!(update["event"], "update");
+
+        let summary = records.last().unwrap();
+        assert_eq!(summary["type"], "summary");
+        assert_eq!(summary["event"], "update");
+    }
+}
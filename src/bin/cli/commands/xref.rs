@@ -0,0 +1,79 @@
+//! Cross-reference command implementation.
+//!
+//! `valknut xref <symbol>` lists every location that mentions a symbol by name,
+//! with the enclosing function or type, without needing a language server.
+
+use std::path::Path;
+
+use crate::cli::args::{XrefArgs, XrefFormat};
+use valknut_rs::core::xref::{find_references, SymbolReference};
+
+/// Run the xref command and print the references it finds.
+pub fn xref_command(args: XrefArgs) -> anyhow::Result<()> {
+    let references = find_references(&args.path, &args.symbol)?;
+    match args.format {
+        XrefFormat::Json => println!("{}", serde_json::to_string_pretty(&references)?),
+        XrefFormat::Text => print!("{}", render_text(&args.path, &references)),
+    }
+    Ok(())
+}
+
+/// Render references as `path:line:column` lines, grep-style.
+fn render_text(root: &Path, references: &[SymbolReference]) -> String {
+    let mut output = String::new();
+    for reference in references {
+        let path = if reference.file_path.is_absolute() {
+            reference.file_path.clone()
+        } else {
+            root.join(&reference.file_path)
+        };
+        output.push_str(&format!(
+            "{}:{}:{}",
+            path.display(),
+            reference.line,
+            reference.column
+        ));
+        if let Some(enclosing) = &reference.enclosing {
+            output.push_str(&format!(" in {enclosing}"));
+        }
+        if reference.is_definition {
+            output.push_str(" (definition)");
+        }
+        output.push('\n');
+    }
+    output
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use std::path::PathBuf;
+    use valknut_rs::core::xref::MatchKind;
+
+    #[test]
+    fn render_text_marks_definitions_and_scopes() {
+        let references = vec![
+            SymbolReference {
+                file_path: PathBuf::from("main.go"),
+                line: 3,
+                column: 6,
+                enclosing: None,
+                is_definition: true,
+                matched_by: MatchKind::Syntax,
+            },
+            SymbolReference {
+                file_path: PathBuf::from("main.go"),
+                line: 9,
+                column: 6,
+                enclosing: Some("run".to_string()),
+                is_definition: false,
+                matched_by: MatchKind::Syntax,
+            },
+        ];
+
+        assert_eq!(
+            render_text(Path::new("src"), &references),
+            "src/main.go:3:6 (definition)\nsrc/main.go:9:6 in run\n"
+        );
+    }
+}
@@ -269,6 +269,62 @@ pub fn apply_analysis_control_flags(config: &mut ValknutConfig, args: &AnalyzeAr
     if args.analysis_control.no_lsh {
         config.analysis.enable_lsh_analysis = false;
     }
+    if args.analysis_control.no_cache {
+        config.io.enable_caching = false;
+    }
+    if args.analysis_control.no_ignore_file {
+        config.analysis.use_ignore_files = false;
+    }
+    if args.analysis_control.exclude_tests {
+        config.analysis.include_tests = false;
+    } else if args.analysis_control.include_tests {
+        config.analysis.include_tests = true;
+    }
+    if args.analysis_control.include_generated {
+        config.analysis.include_generated = true;
+    }
+    if args.analysis_control.include_vendor {
+        config.analysis.include_vendor = true;
+    }
+    if args.clone_detection.detect_duplicates {
+        config.analysis.detect_duplicates = true;
+    }
+    if let Some(min_clone_nodes) = args.clone_detection.min_clone_nodes {
+        config.analysis.min_clone_nodes = min_clone_nodes;
+    }
+    if args.redact_strings {
+        config.analysis.redact_strings = true;
+    }
+    if args.redact_comments {
+        config.analysis.redact_comments = true;
+    }
+    for (language, factor) in &args.health.complexity_baseline {
+        config
+            .scoring
+            .complexity_baselines
+            .insert(language.clone(), *factor);
+    }
+    if let Some(max_file_size) = args.analysis_control.max_file_size {
+        config.analysis.max_file_size_bytes = max_file_size;
+    }
+    if let Some(cache_max_size) = args.analysis_control.cache_max_size {
+        config.io.cache_max_size_bytes = cache_max_size;
+    }
+    if let Some(depth) = args.analysis_control.discovery_depth {
+        config.analysis.discovery_fanout_depth = depth;
+    }
+    if let Some(limit) = args.analysis_control.concurrency_limit {
+        config.performance.max_threads = Some(limit.resolve());
+    }
+    for pattern in &args.analysis_control.exclude {
+        if !config.analysis.exclude_patterns.contains(pattern) {
+            config.analysis.exclude_patterns.push(pattern.clone());
+        }
+    }
+    if args.analysis_control.call_graph || args.analysis_control.call_graph_depth.is_some() {
+        config.graph.enable_call_graph = true;
+        config.graph.call_graph_depth = args.analysis_control.call_graph_depth;
+    }
     if args.analysis_control.cohesion {
         config.analysis.enable_cohesion_analysis = true;
         config.cohesion.enabled = true;
@@ -4,11 +4,11 @@
 //! seamless merging of default configurations, configuration files, and CLI overrides.
 
 use anyhow;
-use std::path::PathBuf;
+use std::path::{Path, PathBuf};
 
 use crate::cli::args::AnalyzeArgs;
 use valknut_rs::api::config_types as api_config;
-use valknut_rs::core::config::{CoverageConfig, DenoiseConfig, LshConfig, ValknutConfig};
+use valknut_rs::core::config::{CoverageConfig, DenoiseConfig, IoConfig, LshConfig, ValknutConfig};
 
 /// Trait for merging configuration layers
 pub trait ConfigMerge<T> {
@@ -69,7 +69,21 @@ fn apply_advanced_sections_from_file(target: &mut ValknutConfig, source: &Valknu
     target.performance = source.performance.clone();
     target.structure = source.structure.clone();
     target.live_reach = source.live_reach.clone();
+    target.rules = source.rules.clone();
     target.analysis.enable_names_analysis = source.analysis.enable_names_analysis;
+    target.analysis.use_ignore_files = source.analysis.use_ignore_files;
+    target.analysis.discovery_fanout_depth = source.analysis.discovery_fanout_depth;
+    target.analysis.include_tests = source.analysis.include_tests;
+    target.analysis.include_generated = source.analysis.include_generated;
+    target.analysis.include_vendor = source.analysis.include_vendor;
+    target.analysis.detect_duplicates = source.analysis.detect_duplicates;
+    target.analysis.min_clone_nodes = source.analysis.min_clone_nodes;
+    target.analysis.expression_complexity_threshold =
+        source.analysis.expression_complexity_threshold;
+    target.analysis.redact_strings = source.analysis.redact_strings;
+    target.analysis.redact_comments = source.analysis.redact_comments;
+    target.analysis.max_ast_depth = source.analysis.max_ast_depth;
+    target.analysis.language_mappings = source.analysis.language_mappings.clone();
     // Preserve file-level include/exclude/ignore patterns
     if !source.analysis.exclude_patterns.is_empty() {
         target.analysis.exclude_patterns = source.analysis.exclude_patterns.clone();
@@ -82,17 +96,27 @@ fn apply_advanced_sections_from_file(target: &mut ValknutConfig, source: &Valknu
     }
 }
 
+/// Returns true when a YAML file is an engine configuration (has an `analysis` section)
+/// rather than a file of CLI flag defaults.
+pub(crate) fn is_engine_config_file(path: &Path) -> bool {
+    std::fs::read_to_string(path)
+        .ok()
+        .and_then(|content| serde_yaml::from_str::<serde_yaml::Value>(&content).ok())
+        .is_some_and(|value| value.get("analysis").is_some())
+}
+
 /// Enhanced configuration loading with layered approach
 pub fn build_layered_valknut_config(args: &AnalyzeArgs) -> anyhow::Result<ValknutConfig> {
     let mut api_config = api_config::AnalysisConfig::default();
     let mut file_config: Option<ValknutConfig> = None;
 
-    // Prefer an explicit --config, otherwise look for local defaults (.valknut.yml/.yaml)
+    // Prefer an explicit --config, otherwise look for local defaults (.valknut.yml/.yaml).
+    // A `.valknut.yaml` holding only CLI flag defaults is left to the flag layer.
     let implicit_config_path = if args.config.is_none() {
         [".valknut.yml", ".valknut.yaml"]
             .iter()
             .map(PathBuf::from)
-            .find(|p| p.exists())
+            .find(|p| p.exists() && is_engine_config_file(p))
     } else {
         None
     };
@@ -126,6 +150,52 @@ pub fn build_layered_valknut_config(args: &AnalyzeArgs) -> anyhow::Result<Valknu
     let cli_overrides = ValknutConfig::from_cli_args(args);
     config.merge_with(cli_overrides);
 
+    if let Some(max_file_size) = args.analysis_control.max_file_size {
+        config.analysis.max_file_size_bytes = max_file_size;
+    }
+    if let Some(cache_max_size) = args.analysis_control.cache_max_size {
+        config.io.cache_max_size_bytes = cache_max_size;
+    }
+    if let Some(depth) = args.analysis_control.discovery_depth {
+        config.analysis.discovery_fanout_depth = depth;
+    }
+    if let Some(limit) = args.analysis_control.concurrency_limit {
+        config.performance.max_threads = Some(limit.resolve());
+    }
+    if args.analysis_control.include_tests {
+        config.analysis.include_tests = true;
+    }
+    if args.analysis_control.include_generated {
+        config.analysis.include_generated = true;
+    }
+    if args.analysis_control.include_vendor {
+        config.analysis.include_vendor = true;
+    }
+    if args.clone_detection.detect_duplicates {
+        config.analysis.detect_duplicates = true;
+    }
+    if let Some(min_clone_nodes) = args.clone_detection.min_clone_nodes {
+        config.analysis.min_clone_nodes = min_clone_nodes;
+    }
+    if args.redact_strings {
+        config.analysis.redact_strings = true;
+    }
+    if args.redact_comments {
+        config.analysis.redact_comments = true;
+    }
+    for (language, factor) in &args.health.complexity_baseline {
+        config
+            .scoring
+            .complexity_baselines
+            .insert(language.clone(), *factor);
+    }
+    // CLI --exclude globs are layered on top of every other pattern source.
+    for pattern in &args.analysis_control.exclude {
+        if !config.analysis.exclude_patterns.contains(pattern) {
+            config.analysis.exclude_patterns.push(pattern.clone());
+        }
+    }
+
     // Respect merged coverage setting; only force-disable when CLI requests it.
     if args.coverage.no_coverage {
         config.analysis.enable_coverage_analysis = false;
@@ -182,6 +252,13 @@ impl ConfigMerge<ValknutConfig> for ValknutConfig {
         if other.analysis.max_file_size_bytes != default_analysis.max_file_size_bytes {
             self.analysis.max_file_size_bytes = other.analysis.max_file_size_bytes;
         }
+        if other.analysis.max_ast_depth != default_analysis.max_ast_depth {
+            self.analysis.max_ast_depth = other.analysis.max_ast_depth;
+        }
+
+        self.analysis
+            .language_mappings
+            .extend(other.analysis.language_mappings.clone());
 
         // Replace include/exclude/ignore patterns only when explicitly changed from defaults
         if other.analysis.include_patterns != default_analysis.include_patterns {
@@ -193,6 +270,33 @@ impl ConfigMerge<ValknutConfig> for ValknutConfig {
         if other.analysis.ignore_patterns != default_analysis.ignore_patterns {
             self.analysis.ignore_patterns = other.analysis.ignore_patterns.clone();
         }
+        if other.analysis.use_ignore_files != default_analysis.use_ignore_files {
+            self.analysis.use_ignore_files = other.analysis.use_ignore_files;
+        }
+        if other.analysis.discovery_fanout_depth != default_analysis.discovery_fanout_depth {
+            self.analysis.discovery_fanout_depth = other.analysis.discovery_fanout_depth;
+        }
+        if other.analysis.include_tests != default_analysis.include_tests {
+            self.analysis.include_tests = other.analysis.include_tests;
+        }
+        if other.analysis.include_generated != default_analysis.include_generated {
+            self.analysis.include_generated = other.analysis.include_generated;
+        }
+        if other.analysis.include_vendor != default_analysis.include_vendor {
+            self.analysis.include_vendor = other.analysis.include_vendor;
+        }
+        if other.analysis.detect_duplicates != default_analysis.detect_duplicates {
+            self.analysis.detect_duplicates = other.analysis.detect_duplicates;
+        }
+        if other.analysis.min_clone_nodes != default_analysis.min_clone_nodes {
+            self.analysis.min_clone_nodes = other.analysis.min_clone_nodes;
+        }
+        if other.analysis.redact_strings != default_analysis.redact_strings {
+            self.analysis.redact_strings = other.analysis.redact_strings;
+        }
+        if other.analysis.redact_comments != default_analysis.redact_comments {
+            self.analysis.redact_comments = other.analysis.redact_comments;
+        }
 
         if other.io.cache_dir.is_some() {
             self.io.cache_dir = other.io.cache_dir;
@@ -213,9 +317,18 @@ impl ConfigMerge<ValknutConfig> for ValknutConfig {
         if other.lsh.apted_max_pairs_per_entity != default_lsh.apted_max_pairs_per_entity {
             self.lsh.apted_max_pairs_per_entity = other.lsh.apted_max_pairs_per_entity;
         }
-        if other.io.enable_caching != self.io.enable_caching {
+        if other.io.enable_caching != IoConfig::default().enable_caching {
             self.io.enable_caching = other.io.enable_caching;
         }
+        if other.io.cache_max_size_bytes != IoConfig::default().cache_max_size_bytes {
+            self.io.cache_max_size_bytes = other.io.cache_max_size_bytes;
+        }
+        if other.graph.enable_call_graph {
+            self.graph.enable_call_graph = true;
+        }
+        if other.graph.call_graph_depth.is_some() {
+            self.graph.call_graph_depth = other.graph.call_graph_depth;
+        }
 
         // Merge cohesion config (only if explicitly enabled)
         if other.cohesion.enabled {
@@ -376,6 +489,68 @@ mod tests {
             Some("file")
         );
     }
+
+    #[test]
+    fn no_cache_flag_disables_incremental_cache() {
+        let cli = Cli::parse_from(["valknut", "analyze", "--no-cache", "."]);
+        let Commands::Analyze(args_box) = cli.command else {
+            panic!("expected analyze command");
+        };
+
+        let config = build_layered_valknut_config(&args_box).expect("build config");
+        assert!(!config.io.enable_caching);
+    }
+
+    #[test]
+    fn cache_max_size_flag_bounds_incremental_cache() {
+        let cli = Cli::parse_from(["valknut", "analyze", "."]);
+        let Commands::Analyze(args_box) = cli.command else {
+            panic!("expected analyze command");
+        };
+        let config = build_layered_valknut_config(&args_box).expect("build config");
+        assert_eq!(config.io.cache_max_size_bytes, 1 << 30);
+
+        let cli = Cli::parse_from(["valknut", "analyze", "--cache-max-size", "512mb", "."]);
+        let Commands::Analyze(args_box) = cli.command else {
+            panic!("expected analyze command");
+        };
+        let config = build_layered_valknut_config(&args_box).expect("build config");
+        assert_eq!(config.io.cache_max_size_bytes, 512 << 20);
+    }
+
+    #[test]
+    fn ignore_file_and_exclude_flags_apply() {
+        let cli = Cli::parse_from([
+            "valknut",
+            "analyze",
+            "--no-ignore-file",
+            "--exclude",
+            "**/generated/**",
+            ".",
+        ]);
+        let Commands::Analyze(args_box) = cli.command else {
+            panic!("expected analyze command");
+        };
+
+        let config = build_layered_valknut_config(&args_box).expect("build config");
+        assert!(!config.analysis.use_ignore_files);
+        assert!(config
+            .analysis
+            .exclude_patterns
+            .contains(&"**/generated/**".to_string()));
+    }
+
+    #[test]
+    fn call_graph_depth_flag_enables_call_graph_export() {
+        let cli = Cli::parse_from(["valknut", "analyze", "--call-graph-depth", "3", "."]);
+        let Commands::Analyze(args_box) = cli.command else {
+            panic!("expected analyze command");
+        };
+
+        let config = build_layered_valknut_config(&args_box).expect("build config");
+        assert!(config.graph.enable_call_graph);
+        assert_eq!(config.graph.call_graph_depth, Some(3));
+    }
 }
 
 /// Merge API-layer analysis configuration, giving precedence to the incoming config.
@@ -629,6 +804,19 @@ impl FromCliArgs<AnalyzeArgs> for ValknutConfig {
         if let Some(max_pairs) = args.advanced_clone.apted_max_pairs {
             config.lsh.apted_max_pairs_per_entity = max_pairs;
         }
+        if args.analysis_control.no_cache {
+            config.io.enable_caching = false;
+        }
+        if args.analysis_control.no_ignore_file {
+            config.analysis.use_ignore_files = false;
+        }
+        if args.analysis_control.exclude_tests {
+            config.analysis.include_tests = false;
+        }
+        if args.analysis_control.call_graph || args.analysis_control.call_graph_depth.is_some() {
+            config.graph.enable_call_graph = true;
+            config.graph.call_graph_depth = args.analysis_control.call_graph_depth;
+        }
 
         // Cohesion analysis configuration
         if args.analysis_control.cohesion {
@@ -0,0 +1,965 @@
+//! CLI flag defaults from `valknut.toml` and `.valknut.yaml`.
+//!
+//! A flag file sets any long-form CLI flag by name (`max-complexity = 60`;
+//! `max_complexity` works too) and may define named `profiles` tables that are
+//! activated with `--profile <name>`. The file in the repository root is read
+//! first and the one in the current directory overrides it; flags given on the
+//! command line always win. Values are turned into arguments and spliced into
+//! the command line before clap parses it, so every flag is supported without a
+//! parallel settings struct. Top-level engine sections (`analysis`, `lsh`, ...)
+//! in a YAML file belong to the layered engine configuration and are ignored
+//! here, as is the `[check]` table read by `valknut check` and the
+//! `config-version` key upgraded by `valknut migrate`.
+//!
+//! Every flag can also be set from a `VALKNUT_` environment variable named
+//! after it (`VALKNUT_MAX_FILE_SIZE=2mb`), with `__` separating nested keys
+//! (`VALKNUT_PROFILES__CI__MAX_COMPLEXITY=5`). Variables override the flag
+//! files and are overridden by the command line, so the lookup order is CLI
+//! flag, environment, flag file, default. Variables that name no flag, such as
+//! `VALKNUT_TEMPLATE_ROOT`, are left to the code that reads them.
+
+use std::collections::{BTreeMap, BTreeSet};
+use std::ffi::OsString;
+use std::path::{Path, PathBuf};
+
+use anyhow::Context;
+use clap::parser::ValueSource;
+use clap::{Arg, ArgAction, Command, CommandFactory};
+use serde_json::Value;
+
+use crate::cli::args::Cli;
+use crate::cli::flag_migration::{config_version, parse_version, CONFIG_VERSION_KEY};
+use valknut_rs::core::config::ValknutConfig;
+use valknut_rs::detectors::rules::{RuleConfig, RuleEngine};
+
+/// Flag file names searched in each directory, in priority order.
+pub const FLAG_FILE_NAMES: &[&str] = &["valknut.toml", ".valknut.yaml"];
+
+/// Key holding the named profiles table.
+pub const PROFILES_KEY: &str = "profiles";
+
+/// Prefix of the environment variables that set flags.
+pub const ENV_PREFIX: &str = "VALKNUT_";
+
+/// Separator between the levels of a nested key in an environment variable.
+pub const ENV_NESTING_SEPARATOR: &str = "__";
+
+/// Key holding the `valknut check` table. `check` is also a boolean flag of
+/// `valknut fmt`, so only a table counts.
+pub const CHECK_KEY: &str = "check";
+
+/// Flag values merged from one or more flag files.
+#[derive(Debug, Clone, Default, PartialEq)]
+pub struct FlagConfig {
+    /// Flag values keyed by long flag name.
+    pub values: BTreeMap<String, Value>,
+    /// Named profiles, each a set of flag values.
+    pub profiles: BTreeMap<String, BTreeMap<String, Value>>,
+}
+
+/// A problem found while validating a flag file.
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct FlagFileIssue {
+    /// Dotted key path, e.g. `profiles.ci.max-complexity`.
+    pub key: String,
+    /// What is wrong with the key or its value.
+    pub message: String,
+}
+
+/// Loading and application methods for [`FlagConfig`].
+impl FlagConfig {
+    /// Read and merge `paths`; later files override earlier ones key by key.
+    pub fn load(paths: &[PathBuf]) -> anyhow::Result<Self> {
+        let mut config = Self::default();
+        for path in paths {
+            let document = read_flag_file(path)?;
+            config_version(&document).with_context(|| format!("in {}", path.display()))?;
+            let Value::Object(entries) = document else {
+                anyhow::bail!("{} must contain a table of flags", path.display());
+            };
+
+            for (key, value) in entries {
+                if key == PROFILES_KEY {
+                    let Value::Object(profiles) = value else {
+                        anyhow::bail!("`profiles` in {} must be a table", path.display());
+                    };
+                    for (name, profile) in profiles {
+                        let Value::Object(profile) = profile else {
+                            anyhow::bail!(
+                                "profile `{}` in {} must be a table",
+                                name,
+                                path.display()
+                            );
+                        };
+                        config
+                            .profiles
+                            .entry(name)
+                            .or_default()
+                            .extend(profile.into_iter().map(|(k, v)| (normalize_key(&k), v)));
+                    }
+                } else if key != CONFIG_VERSION_KEY
+                    && !is_engine_section(&key, &value)
+                    && !is_check_table(&key, &value)
+                {
+                    config.values.insert(normalize_key(&key), value);
+                }
+            }
+        }
+        Ok(config)
+    }
+
+    /// Read flag values from `VALKNUT_*` variables in `vars`.
+    ///
+    /// A value is converted to the type its flag expects (booleans accept
+    /// `true`/`false`, `1`/`0`, `yes`/`no` and `on`/`off`; list flags take
+    /// comma-separated items) and checked like a flag file value. Errors name
+    /// the offending variable.
+    pub fn from_env(vars: impl IntoIterator<Item = (OsString, OsString)>) -> anyhow::Result<Self> {
+        let cli = Cli::command();
+        let mut config = Self::default();
+        for (name, raw) in vars {
+            let Some(name) = name.to_str() else {
+                continue;
+            };
+            let Some(key) = name.strip_prefix(ENV_PREFIX) else {
+                continue;
+            };
+            let Some((profile, key)) = env_key(&cli, name, key)? else {
+                continue;
+            };
+            let raw = raw
+                .into_string()
+                .map_err(|_| anyhow::anyhow!("{name} is not valid UTF-8"))?;
+            let value = env_value(&cli, &key, &raw)
+                .and_then(|value| validate_entry(&cli, &key, &value).map(|()| value))
+                .map_err(|message| {
+                    anyhow::anyhow!("invalid value `{raw}` for {name}: {message}")
+                })?;
+            match profile {
+                Some(profile) => {
+                    config
+                        .profiles
+                        .entry(profile)
+                        .or_default()
+                        .insert(key, value);
+                }
+                None => {
+                    config.values.insert(key, value);
+                }
+            }
+        }
+        Ok(config)
+    }
+
+    /// Layer `other` over this configuration, key by key.
+    pub fn merge(&mut self, other: Self) {
+        self.values.extend(other.values);
+        for (name, profile) in other.profiles {
+            self.profiles.entry(name).or_default().extend(profile);
+        }
+    }
+
+    /// Splice flag values into `args` for the invoked subcommand.
+    ///
+    /// `--profile <name>` naming a profile from the file is consumed here and its
+    /// values layered over the top-level ones; any other `--profile` value is left
+    /// for clap (the built-in performance profiles). Flags already present on the
+    /// command line and values the subcommand does not accept are skipped.
+    pub fn apply(&self, args: Vec<OsString>) -> Vec<OsString> {
+        let (args, profile) = take_profile(args, &self.profiles);
+        let mut values = self.values.clone();
+        if let Some(profile) = profile.and_then(|name| self.profiles.get(&name)) {
+            values.extend(profile.clone());
+        }
+        if values.is_empty() {
+            return args;
+        }
+
+        let cli = Cli::command();
+        let Ok(matches) = cli.clone().try_get_matches_from(&args) else {
+            return args;
+        };
+        let Some((name, sub_matches)) = matches.subcommand() else {
+            return args;
+        };
+        let Some(subcommand) = cli.find_subcommand(name) else {
+            return args;
+        };
+
+        let mut flags = Vec::new();
+        let mut positionals = Vec::new();
+        for (key, value) in &values {
+            let (owner, arg, source) = if let Some(arg) = find_arg(subcommand, key) {
+                (
+                    subcommand,
+                    arg,
+                    sub_matches.value_source(arg.get_id().as_str()),
+                )
+            } else if let Some(arg) = find_arg(&cli, key).filter(|arg| arg.is_global_set()) {
+                (&cli, arg, matches.value_source(arg.get_id().as_str()))
+            } else {
+                continue;
+            };
+            if source == Some(ValueSource::CommandLine) {
+                continue;
+            }
+
+            let Ok(rendered) = render_arg(arg, value) else {
+                continue;
+            };
+            if rendered.is_empty() || check_accepts(owner, &rendered).is_err() {
+                continue;
+            }
+            if arg.is_positional() {
+                positionals.extend(rendered);
+            } else {
+                flags.extend(rendered);
+            }
+        }
+
+        let insert_at = args
+            .iter()
+            .skip(1)
+            .position(|arg| arg.to_str() == Some(name))
+            .map_or(args.len(), |index| index + 2);
+        let mut spliced = args[..insert_at].to_vec();
+        spliced.extend(flags.into_iter().map(OsString::from));
+        spliced.extend_from_slice(&args[insert_at..]);
+        spliced.extend(positionals.into_iter().map(OsString::from));
+        spliced
+    }
+}
+
+/// Apply flag files found for the current directory, and `VALKNUT_*`
+/// environment variables over them, to raw process arguments.
+///
+/// `valknut config ...` and `valknut migrate` are passed through untouched so
+/// a broken or outdated file can still be validated and upgraded.
+pub fn apply_flag_files(args: Vec<OsString>) -> anyhow::Result<Vec<OsString>> {
+    if matches!(
+        invoked_subcommand(&args).as_deref(),
+        Some("config" | "migrate")
+    ) {
+        return Ok(args);
+    }
+
+    let cwd = std::env::current_dir().context("Failed to determine current directory")?;
+    let files = discover_flag_files(&cwd);
+    let mut config = FlagConfig::load(&files)
+        .context("Invalid flag file; run `valknut config validate` for details")?;
+    config
+        .merge(FlagConfig::from_env(std::env::vars_os()).context("Invalid environment variable")?);
+    Ok(config.apply(args))
+}
+
+/// Flag files for `cwd`: the repository root's first, then the directory's own.
+pub fn discover_flag_files(cwd: &Path) -> Vec<PathBuf> {
+    let mut dirs = Vec::new();
+    if let Some(root) = repo_root(cwd) {
+        if !same_dir(&root, cwd) {
+            dirs.push(root);
+        }
+    }
+    dirs.push(cwd.to_path_buf());
+
+    dirs.iter()
+        .filter_map(|dir| {
+            FLAG_FILE_NAMES
+                .iter()
+                .map(|name| dir.join(name))
+                .find(|path| path.is_file())
+        })
+        .collect()
+}
+
+/// Rules declared as `[[check.rules]]` in `paths`; a rule in a later file
+/// replaces an earlier rule with the same name.
+pub fn load_check_rules(paths: &[PathBuf]) -> anyhow::Result<Vec<RuleConfig>> {
+    let mut rules: Vec<RuleConfig> = Vec::new();
+    for path in paths {
+        let document = read_flag_file(path)?;
+        let Some(check) = document.get(CHECK_KEY).filter(|value| value.is_object()) else {
+            continue;
+        };
+        for rule in check_rules(check).with_context(|| format!("in {}", path.display()))? {
+            match rules.iter_mut().find(|existing| existing.name == rule.name) {
+                Some(existing) => *existing = rule,
+                None => rules.push(rule),
+            }
+        }
+    }
+    Ok(rules)
+}
+
+/// Check every key of a flag file against the CLI definition.
+///
+/// Returns an error only when the file cannot be read or parsed.
+pub fn validate_flag_file(path: &Path) -> anyhow::Result<Vec<FlagFileIssue>> {
+    let document = read_flag_file(path)?;
+    let Value::Object(entries) = document else {
+        return Ok(vec![FlagFileIssue {
+            key: String::new(),
+            message: "file must contain a table of flags".to_string(),
+        }]);
+    };
+
+    let cli = Cli::command();
+    let allows_engine_sections = !is_toml(path);
+    let mut issues = Vec::new();
+    for (key, value) in &entries {
+        if key == PROFILES_KEY {
+            let Value::Object(profiles) = value else {
+                issues.push(issue(key, "must be a table of profiles"));
+                continue;
+            };
+            for (name, profile) in profiles {
+                let prefix = format!("{PROFILES_KEY}.{name}");
+                let Value::Object(profile) = profile else {
+                    issues.push(issue(&prefix, "must be a table of flags"));
+                    continue;
+                };
+                for (key, value) in profile {
+                    if let Err(message) = validate_entry(&cli, key, value) {
+                        issues.push(issue(&format!("{prefix}.{key}"), &message));
+                    }
+                }
+            }
+        } else if key == CONFIG_VERSION_KEY {
+            if let Err(e) = parse_version(value) {
+                issues.push(issue(key, &format!("{e:#}")));
+            }
+        } else if is_check_table(key, value) {
+            let valid = check_rules(value).and_then(|rules| Ok(RuleEngine::from_configs(&rules)?));
+            if let Err(e) = valid {
+                issues.push(issue(key, &format!("{e:#}")));
+            }
+        } else if is_engine_section(key, value) {
+            if !allows_engine_sections {
+                issues.push(issue(
+                    key,
+                    "engine settings are only read from YAML configuration files",
+                ));
+            }
+        } else if let Err(message) = validate_entry(&cli, key, value) {
+            issues.push(issue(key, &message));
+        }
+    }
+    Ok(issues)
+}
+
+/// Build a [`FlagFileIssue`].
+fn issue(key: &str, message: &str) -> FlagFileIssue {
+    FlagFileIssue {
+        key: key.to_string(),
+        message: message.to_string(),
+    }
+}
+
+/// Every subcommand (or the top-level command, for a global flag) defining
+/// the flag `key`, with its argument.
+fn flag_owners<'a>(cli: &'a Command, key: &str) -> Vec<(&'a Command, &'a Arg)> {
+    let mut owners: Vec<(&Command, &Arg)> = cli
+        .get_subcommands()
+        .filter_map(|sub| find_arg(sub, key).map(|arg| (sub, arg)))
+        .collect();
+    if let Some(arg) = find_arg(cli, key).filter(|arg| arg.is_global_set()) {
+        owners.push((cli, arg));
+    }
+    owners
+}
+
+/// Validate one flag against every subcommand (or global flag) that defines it.
+fn validate_entry(cli: &Command, key: &str, value: &Value) -> Result<(), String> {
+    let key = normalize_key(key);
+    let owners = flag_owners(cli, &key);
+    if owners.is_empty() {
+        return Err("unknown key (not a long-form CLI flag)".to_string());
+    }
+
+    let mut first_error = None;
+    for (owner, arg) in owners {
+        let outcome = render_arg(arg, value).and_then(|rendered| check_accepts(owner, &rendered));
+        match outcome {
+            Ok(()) => return Ok(()),
+            Err(message) => {
+                first_error.get_or_insert(message);
+            }
+        }
+    }
+    Err(first_error.unwrap_or_default())
+}
+
+/// Profile and flag named by the part of variable `name` after the prefix,
+/// or `None` when it names no flag.
+///
+/// `PROFILES__CI__MAX_COMPLEXITY` sets `max-complexity` in profile `ci`.
+/// `PROFILES__CI_MAX_COMPLEXITY` is accepted too when exactly one split of
+/// `CI_MAX_COMPLEXITY` into profile and flag names a known flag.
+fn env_key(
+    cli: &Command,
+    name: &str,
+    key: &str,
+) -> anyhow::Result<Option<(Option<String>, String)>> {
+    let flag = |key: &str| {
+        let key = normalize_key(&key.to_ascii_lowercase());
+        (!flag_owners(cli, &key).is_empty()).then_some(key)
+    };
+    let parts: Vec<&str> = key.split(ENV_NESTING_SEPARATOR).collect();
+    match parts.as_slice() {
+        [key] => Ok(flag(key).map(|key| (None, key))),
+        [section, rest @ ..] if section.eq_ignore_ascii_case(PROFILES_KEY) => {
+            let (profile, key) = match rest {
+                [profile, key] => match flag(key) {
+                    Some(key) => (profile.to_string(), key),
+                    None => {
+                        anyhow::bail!("{name} names no flag: `{key}` is not a long-form CLI flag")
+                    }
+                },
+                [profile_and_key] => {
+                    let splits: Vec<(&str, String)> = profile_and_key
+                        .match_indices('_')
+                        .filter_map(|(at, _)| {
+                            let (profile, key) = profile_and_key.split_at(at);
+                            flag(&key[1..]).map(|key| (profile, key))
+                        })
+                        .collect();
+                    match splits.as_slice() {
+                        [(profile, key)] => (profile.to_string(), key.clone()),
+                        _ => anyhow::bail!(
+                            "{name} is ambiguous; name the profile and flag as \
+                             {ENV_PREFIX}PROFILES__<PROFILE>__<FLAG>"
+                        ),
+                    }
+                }
+                _ => anyhow::bail!(
+                    "{name} must have the form {ENV_PREFIX}PROFILES__<PROFILE>__<FLAG>"
+                ),
+            };
+            if profile.is_empty() {
+                anyhow::bail!("{name} has an empty profile name");
+            }
+            Ok(Some((Some(profile.to_ascii_lowercase()), key)))
+        }
+        _ => Ok(None),
+    }
+}
+
+/// Convert the text of an environment variable to the value type of flag `key`.
+fn env_value(cli: &Command, key: &str, raw: &str) -> Result<Value, String> {
+    let Some((_, arg)) = flag_owners(cli, key).into_iter().next() else {
+        return Err("unknown key (not a long-form CLI flag)".to_string());
+    };
+    match arg.get_action() {
+        ArgAction::SetTrue | ArgAction::SetFalse => {
+            match raw.trim().to_ascii_lowercase().as_str() {
+                "true" | "1" | "yes" | "on" => Ok(Value::Bool(true)),
+                "false" | "0" | "no" | "off" => Ok(Value::Bool(false)),
+                _ => Err("expects true or false".to_string()),
+            }
+        }
+        ArgAction::Append => Ok(env_list(raw)),
+        _ if arg
+            .get_num_args()
+            .is_some_and(|range| range.max_values() > 1) =>
+        {
+            Ok(env_list(raw))
+        }
+        _ => Ok(Value::String(raw.to_string())),
+    }
+}
+
+/// Comma-separated items of a list-valued variable.
+fn env_list(raw: &str) -> Value {
+    Value::Array(
+        raw.split(',')
+            .map(str::trim)
+            .filter(|item| !item.is_empty())
+            .map(|item| Value::String(item.to_string()))
+            .collect(),
+    )
+}
+
+/// Parse a flag file into a JSON value, choosing TOML or YAML by extension.
+pub fn read_flag_file(path: &Path) -> anyhow::Result<Value> {
+    let content = std::fs::read_to_string(path)
+        .with_context(|| format!("Failed to read {}", path.display()))?;
+    if is_toml(path) {
+        let table: toml::Table = toml::from_str(&content)
+            .with_context(|| format!("Failed to parse {}", path.display()))?;
+        Ok(serde_json::to_value(table)?)
+    } else {
+        let value: Value = serde_yaml::from_str(&content)
+            .with_context(|| format!("Failed to parse {}", path.display()))?;
+        Ok(if value.is_null() {
+            Value::Object(Default::default())
+        } else {
+            value
+        })
+    }
+}
+
+/// Returns true for the `[check]` table.
+pub fn is_check_table(key: &str, value: &Value) -> bool {
+    key == CHECK_KEY && value.is_object()
+}
+
+/// Deserialize the `rules` list of a `[check]` table.
+fn check_rules(check: &Value) -> anyhow::Result<Vec<RuleConfig>> {
+    match check.get("rules") {
+        Some(rules) => serde_json::from_value(rules.clone()).context("invalid `check.rules`"),
+        None => Ok(Vec::new()),
+    }
+}
+
+/// Returns true for `.toml` files.
+pub fn is_toml(path: &Path) -> bool {
+    path.extension().and_then(|ext| ext.to_str()) == Some("toml")
+}
+
+/// Returns true for a table (or the `rules` list) under an engine configuration
+/// key. Some section names double as boolean flags (`denoise`, `cohesion`), so
+/// scalars never count.
+pub fn is_engine_section(key: &str, value: &Value) -> bool {
+    (value.is_object() || value.is_array()) && engine_sections().contains(key)
+}
+
+/// Top-level keys of the engine configuration.
+fn engine_sections() -> BTreeSet<String> {
+    let mut sections: BTreeSet<String> = serde_json::to_value(ValknutConfig::default())
+        .ok()
+        .and_then(|value| value.as_object().map(|map| map.keys().cloned().collect()))
+        .unwrap_or_default();
+    sections.insert("live_reach".to_string());
+    sections
+}
+
+/// Long flag spelling of a key: `max_complexity` and `--max-complexity` become `max-complexity`.
+pub fn normalize_key(key: &str) -> String {
+    key.trim_start_matches("--").replace('_', "-")
+}
+
+/// Find the argument a key names: a long flag, or a positional by id.
+fn find_arg<'a>(command: &'a Command, key: &str) -> Option<&'a Arg> {
+    command.get_arguments().find(|arg| {
+        arg.get_long() == Some(key)
+            || (arg.is_positional() && arg.get_id().as_str().replace('_', "-") == key)
+    })
+}
+
+/// Render a flag value as command-line arguments.
+fn render_arg(arg: &Arg, value: &Value) -> Result<Vec<String>, String> {
+    let flag = |long: &str| format!("--{long}");
+    let long = arg.get_long();
+    match arg.get_action() {
+        ArgAction::SetTrue | ArgAction::SetFalse => {
+            let Value::Bool(enabled) = value else {
+                return Err("expects true or false".to_string());
+            };
+            let set = matches!(arg.get_action(), ArgAction::SetTrue) == *enabled;
+            Ok(long.filter(|_| set).map(flag).into_iter().collect())
+        }
+        ArgAction::Set | ArgAction::Append => {
+            let items = match value {
+                Value::Array(items) => {
+                    let multiple = matches!(arg.get_action(), ArgAction::Append)
+                        || arg
+                            .get_num_args()
+                            .is_some_and(|range| range.max_values() > 1);
+                    if !multiple {
+                        return Err("expects a single value, not a list".to_string());
+                    }
+                    items.iter().map(scalar).collect::<Result<Vec<_>, _>>()?
+                }
+                other => vec![scalar(other)?],
+            };
+            if arg.is_positional() {
+                return Ok(items);
+            }
+            let long = long.ok_or_else(|| "cannot be set from a config file".to_string())?;
+            Ok(items
+                .into_iter()
+                .map(|item| format!("{}={}", flag(long), item))
+                .collect())
+        }
+        _ => Err("cannot be set from a config file".to_string()),
+    }
+}
+
+/// String form of a scalar value.
+fn scalar(value: &Value) -> Result<String, String> {
+    match value {
+        Value::String(text) => Ok(text.clone()),
+        Value::Number(number) => Ok(number.to_string()),
+        Value::Bool(flag) => Ok(flag.to_string()),
+        _ => Err("expects a string, number or boolean".to_string()),
+    }
+}
+
+/// Check that `command` accepts `rendered` arguments, ignoring required arguments.
+fn check_accepts(command: &Command, rendered: &[String]) -> Result<(), String> {
+    let name = command.get_name().to_string();
+    command
+        .clone()
+        .subcommand_required(false)
+        .arg_required_else_help(false)
+        .mut_args(|arg| arg.required(false))
+        .try_get_matches_from(std::iter::once(name).chain(rendered.iter().cloned()))
+        .map(|_| ())
+        .map_err(|err| {
+            let rendered = err.to_string();
+            let first_line = rendered.lines().next().unwrap_or_default();
+            first_line.trim_start_matches("error: ").to_string()
+        })
+}
+
+/// Remove `--profile <name>` when `name` is a profile defined in a flag file.
+fn take_profile(
+    args: Vec<OsString>,
+    profiles: &BTreeMap<String, BTreeMap<String, Value>>,
+) -> (Vec<OsString>, Option<String>) {
+    let mut kept = Vec::with_capacity(args.len());
+    let mut selected = None;
+    let mut iter = args.into_iter().peekable();
+    while let Some(arg) = iter.next() {
+        let text = arg.to_str().unwrap_or_default();
+        if selected.is_none() {
+            if let Some(name) = text.strip_prefix("--profile=") {
+                if profiles.contains_key(name) {
+                    selected = Some(name.to_string());
+                    continue;
+                }
+            } else if text == "--profile" {
+                let next = iter
+                    .peek()
+                    .and_then(|next| next.to_str())
+                    .unwrap_or_default();
+                if profiles.contains_key(next) {
+                    selected = Some(next.to_string());
+                    iter.next();
+                    continue;
+                }
+            }
+        }
+        kept.push(arg);
+    }
+    (kept, selected)
+}
+
+/// Name of the subcommand in `args`: the first token that names one.
+fn invoked_subcommand(args: &[OsString]) -> Option<String> {
+    let cli = Cli::command();
+    args.iter()
+        .skip(1)
+        .filter_map(|arg| arg.to_str())
+        .find(|arg| cli.find_subcommand(arg).is_some())
+        .map(str::to_string)
+}
+
+/// Working directory of the Git repository containing `dir`.
+fn repo_root(dir: &Path) -> Option<PathBuf> {
+    let repo = git2::Repository::discover(dir).ok()?;
+    repo.workdir().map(Path::to_path_buf)
+}
+
+/// Returns true when both paths resolve to the same directory.
+fn same_dir(a: &Path, b: &Path) -> bool {
+    match (a.canonicalize(), b.canonicalize()) {
+        (Ok(a), Ok(b)) => a == b,
+        _ => a == b,
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use clap::Parser;
+    use serde_json::json;
+    use std::fs;
+    use tempfile::tempdir;
+
+    use crate::cli::args::{Commands, OutputFormat, PerformanceProfile};
+
+    fn args(list: &[&str]) -> Vec<OsString> {
+        list.iter().map(OsString::from).collect()
+    }
+
+    fn analyze(parsed: Vec<OsString>) -> Box<crate::cli::args::AnalyzeArgs> {
+        match Cli::parse_from(parsed).command {
+            Commands::Analyze(args) => args,
+            _ => panic!("expected analyze command"),
+        }
+    }
+
+    fn write(dir: &Path, name: &str, contents: &str) -> PathBuf {
+        let path = dir.join(name);
+        fs::write(&path, contents).unwrap();
+        path
+    }
+
+    #[test]
+    fn toml_values_apply_and_cli_flags_win() {
+        let tmp = tempdir().unwrap();
+        let path = write(
+            tmp.path(),
+            "valknut.toml",
+            r#"
+max_complexity = 60
+quiet = true
+format = ["json", "sarif"]
+paths = ["src"]
+
+[profiles.ci]
+max-complexity = 40
+fail-on-issues = true
+profile = "thorough"
+"#,
+        );
+        let config = FlagConfig::load(&[path]).unwrap();
+
+        let parsed = analyze(config.apply(args(&["valknut", "analyze"])));
+        assert_eq!(parsed.quality_gate.max_complexity, Some(60.0));
+        assert!(parsed.quiet);
+        assert!(matches!(
+            parsed.format.as_slice(),
+            [OutputFormat::Json, OutputFormat::Sarif]
+        ));
+        assert_eq!(parsed.paths, vec![PathBuf::from("src")]);
+
+        let parsed = analyze(config.apply(args(&[
+            "valknut",
+            "analyze",
+            "--max-complexity",
+            "90",
+            "--format",
+            "html",
+            "lib",
+        ])));
+        assert_eq!(parsed.quality_gate.max_complexity, Some(90.0));
+        assert!(matches!(parsed.format.as_slice(), [OutputFormat::Html]));
+        assert_eq!(parsed.paths, vec![PathBuf::from("lib")]);
+
+        let parsed = analyze(config.apply(args(&["valknut", "analyze", "--profile", "ci"])));
+        assert_eq!(parsed.quality_gate.max_complexity, Some(40.0));
+        assert!(parsed.quality_gate.fail_on_issues);
+        assert!(matches!(parsed.profile, PerformanceProfile::Thorough));
+
+        // Built-in performance profiles still reach clap untouched.
+        let parsed = analyze(config.apply(args(&["valknut", "analyze", "--profile=balanced"])));
+        assert!(matches!(parsed.profile, PerformanceProfile::Balanced));
+    }
+
+    fn env(vars: &[(&str, &str)]) -> anyhow::Result<FlagConfig> {
+        FlagConfig::from_env(
+            vars.iter()
+                .map(|(name, value)| (OsString::from(name), OsString::from(value))),
+        )
+    }
+
+    #[test]
+    fn environment_overrides_files_and_cli_flags_win() {
+        let tmp = tempdir().unwrap();
+        let path = write(
+            tmp.path(),
+            "valknut.toml",
+            "max-complexity = 60\nquiet = true\n\n[profiles.ci]\nmax-complexity = 40\n",
+        );
+        let mut config = FlagConfig::load(&[path]).unwrap();
+        config.merge(
+            env(&[
+                ("VALKNUT_MAX_FILE_SIZE", "2mb"),
+                ("VALKNUT_QUIET", "off"),
+                ("VALKNUT_FORMAT", "json, sarif"),
+                ("VALKNUT_PROFILES__CI__MAX_COMPLEXITY", "5"),
+                ("VALKNUT_PROFILES__NIGHTLY_FAIL_ON_ISSUES", "yes"),
+                ("VALKNUT_TEMPLATE_ROOT", "/srv/templates"),
+                ("HOME", "/root"),
+            ])
+            .unwrap(),
+        );
+        assert_eq!(config.values["quiet"], json!(false));
+        assert_eq!(config.profiles["nightly"]["fail-on-issues"], json!(true));
+
+        let parsed = analyze(config.apply(args(&["valknut", "analyze"])));
+        assert_eq!(parsed.analysis_control.max_file_size, Some(2 * 1024 * 1024));
+        assert_eq!(parsed.quality_gate.max_complexity, Some(60.0));
+        assert!(!parsed.quiet);
+        assert!(matches!(
+            parsed.format.as_slice(),
+            [OutputFormat::Json, OutputFormat::Sarif]
+        ));
+
+        let parsed = analyze(config.apply(args(&["valknut", "analyze", "--profile", "ci"])));
+        assert_eq!(parsed.quality_gate.max_complexity, Some(5.0));
+
+        let parsed = analyze(config.apply(args(&["valknut", "analyze", "--max-file-size", "0"])));
+        assert_eq!(parsed.analysis_control.max_file_size, Some(0));
+    }
+
+    #[test]
+    fn invalid_environment_values_name_the_variable() {
+        let cases = [
+            ("VALKNUT_MAX_COMPLEXITY", "high", "VALKNUT_MAX_COMPLEXITY"),
+            ("VALKNUT_QUIET", "maybe", "expects true or false"),
+            (
+                "VALKNUT_PROFILES__CI__NO_SUCH_FLAG",
+                "1",
+                "VALKNUT_PROFILES__CI__NO_SUCH_FLAG",
+            ),
+            ("VALKNUT_PROFILES__MAX_COMPLEXITY", "5", "ambiguous"),
+        ];
+        for (name, value, expected) in cases {
+            let err = env(&[(name, value)]).unwrap_err();
+            assert!(
+                format!("{err:#}").contains(expected),
+                "{name}={value}: {err:#}"
+            );
+        }
+        assert_eq!(
+            env(&[("VALKNUT_TEMPLATE_ROOT", "/srv/templates")]).unwrap(),
+            FlagConfig::default()
+        );
+    }
+
+    #[test]
+    fn values_other_subcommands_reject_are_skipped() {
+        let tmp = tempdir().unwrap();
+        let path = write(tmp.path(), ".valknut.yaml", "format: html\nverbose: true\n");
+        let config = FlagConfig::load(&[path]).unwrap();
+
+        let applied = config.apply(args(&["valknut", "xref", "main"]));
+        let cli = Cli::parse_from(applied);
+        assert!(cli.verbose);
+        assert!(matches!(cli.command, Commands::Xref(_)));
+    }
+
+    #[test]
+    fn yaml_engine_sections_are_ignored_and_later_files_override() {
+        let tmp = tempdir().unwrap();
+        let root = write(
+            tmp.path(),
+            "root.yaml",
+            "max-issues: 10\nmin-health: 50\ndenoise: true\nlsh:\n  num_hashes: 64\n",
+        );
+        let local = write(tmp.path(), "local.yaml", "max-issues: 3\n");
+
+        let config = FlagConfig::load(&[root, local]).unwrap();
+        assert_eq!(config.values.len(), 3);
+        assert_eq!(config.values["denoise"], true);
+        assert_eq!(config.values["max-issues"], 3);
+        assert_eq!(config.values["min-health"], 50);
+    }
+
+    #[test]
+    fn validation_reports_unknown_keys_and_type_errors() {
+        let tmp = tempdir().unwrap();
+        let path = write(
+            tmp.path(),
+            "valknut.toml",
+            r#"
+max-complexity = "high"
+colour = true
+quiet = "yes"
+out = "reports"
+
+[lsh]
+num_hashes = 64
+
+[profiles.ci]
+max-issues = -1
+fail-on-issues = true
+"#,
+        );
+
+        let issues = validate_flag_file(&path).unwrap();
+        let keys: Vec<&str> = issues.iter().map(|issue| issue.key.as_str()).collect();
+        assert_eq!(
+            keys,
+            vec![
+                "colour",
+                "lsh",
+                "max-complexity",
+                "profiles.ci.max-issues",
+                "quiet"
+            ]
+        );
+        assert!(issues[0].message.starts_with("unknown key"));
+        assert!(issues[2].message.contains("invalid value"));
+        assert_eq!(issues[4].message, "expects true or false");
+
+        let valid = write(
+            tmp.path(),
+            ".valknut.yaml",
+            "profiles:\n  ci:\n    quiet: true\n",
+        );
+        assert!(validate_flag_file(&valid).unwrap().is_empty());
+    }
+
+    #[test]
+    fn check_rules_are_read_separately_and_validated() {
+        let tmp = tempdir().unwrap();
+        let root = write(
+            tmp.path(),
+            "valknut.toml",
+            r#"
+quiet = true
+
+[[check.rules]]
+name = "complexity"
+builtin = "max-cyclomatic-complexity"
+params = { max = 15 }
+
+[[check.rules]]
+name = "no-cycles"
+severity = "error"
+builtin = "no-circular-imports"
+"#,
+        );
+        let local = write(
+            tmp.path(),
+            ".valknut.yaml",
+            "check:
+  rules:
+    - name: complexity
+      builtin: max-cyclomatic-complexity
+      params: { max: 8 }
+",
+        );
+
+        let config = FlagConfig::load(&[root.clone(), local.clone()]).unwrap();
+        assert_eq!(config.values.keys().collect::<Vec<_>>(), vec!["quiet"]);
+        let rules = load_check_rules(&[root.clone(), local]).unwrap();
+        let names: Vec<&str> = rules.iter().map(|rule| rule.name.as_str()).collect();
+        assert_eq!(names, vec!["complexity", "no-cycles"]);
+        assert_eq!(rules[0].params["max"], 8);
+        assert!(validate_flag_file(&root).unwrap().is_empty());
+
+        let invalid = write(
+            tmp.path(),
+            "invalid.toml",
+            "[[check.rules]]
+name = \"docs\"
+builtin = \"no-such-rule\"
+",
+        );
+        let issues = validate_flag_file(&invalid).unwrap();
+        assert_eq!(issues.len(), 1);
+        assert_eq!(issues[0].key, "check");
+        assert!(issues[0].message.contains("no-such-rule"));
+    }
+
+    #[test]
+    fn discovery_prefers_toml_and_reads_repo_root_first() {
+        let tmp = tempdir().unwrap();
+        git2::Repository::init(tmp.path()).unwrap();
+        let nested = tmp.path().join("service");
+        fs::create_dir_all(&nested).unwrap();
+        write(tmp.path(), ".valknut.yaml", "quiet: true\n");
+        write(&nested, ".valknut.yaml", "quiet: false\n");
+        write(&nested, "valknut.toml", "quiet = false\n");
+
+        let files = discover_flag_files(&nested);
+        assert_eq!(files.len(), 2);
+        assert!(files[0].ends_with(".valknut.yaml"));
+        assert!(files[1].ends_with("service/valknut.toml"));
+    }
+}
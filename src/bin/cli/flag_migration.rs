@@ -0,0 +1,360 @@
+//! Versioned upgrades of `valknut.toml` and `.valknut.yaml` flag files.
+//!
+//! A flag file records the schema it was written for in `config-version`;
+//! files without the key predate versioning and are version 1. Each
+//! [`Migration`] upgrades a document by exactly one version, so a file is
+//! brought up to [`CURRENT_CONFIG_VERSION`] by chaining every step from its
+//! own version onwards. Migrations work on the parsed document, which means
+//! comments other than the file's leading comment block are not carried over;
+//! `valknut migrate` keeps a backup of the original for that reason.
+
+use std::path::{Path, PathBuf};
+
+use anyhow::Context;
+use serde_json::{Map, Value};
+
+use crate::cli::flag_config::{
+    is_check_table, is_engine_section, is_toml, normalize_key, read_flag_file, CHECK_KEY,
+    PROFILES_KEY,
+};
+
+/// Key recording the schema version of a flag file.
+pub const CONFIG_VERSION_KEY: &str = "config-version";
+
+/// Schema version written by this build.
+pub const CURRENT_CONFIG_VERSION: u32 = 3;
+
+/// Key of the rule list, at the top level before version 3 and inside the
+/// `[check]` table since.
+const RULES_KEY: &str = "rules";
+
+/// One upgrade step, from version `from` to `from + 1`.
+struct Migration {
+    /// Version the step upgrades from
+    from: u32,
+    /// What the step changes, for the migration summary
+    summary: &'static str,
+    /// Rewrite the top-level table; the flag is true for TOML files
+    apply: fn(&mut Map<String, Value>, bool) -> anyhow::Result<()>,
+}
+
+/// Every upgrade step, oldest first.
+const MIGRATIONS: &[Migration] = &[
+    Migration {
+        from: 1,
+        summary: "spell flag keys as long flags (`max_complexity` becomes `max-complexity`)",
+        apply: canonical_flag_keys,
+    },
+    Migration {
+        from: 2,
+        summary: "move top-level `[[rules]]` in valknut.toml to `[[check.rules]]`",
+        apply: rules_under_check,
+    },
+];
+
+/// Result of migrating one document.
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct MigrationReport {
+    /// Version the document was at
+    pub from: u32,
+    /// Version the document is at now
+    pub to: u32,
+    /// Summaries of the steps applied, in order
+    pub steps: Vec<&'static str>,
+}
+
+/// A flag file rewritten for the current schema.
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct MigratedFile {
+    /// The flag file
+    pub path: PathBuf,
+    /// Contents before migration
+    pub original: String,
+    /// Contents after migration
+    pub migrated: String,
+    /// Steps that produced `migrated`
+    pub report: MigrationReport,
+}
+
+/// Reporting and persistence methods for [`MigratedFile`].
+impl MigratedFile {
+    /// Returns true when migration changed nothing.
+    pub fn is_unchanged(&self) -> bool {
+        self.report.steps.is_empty()
+    }
+
+    /// Where the original is kept: `valknut.toml` at version 1 is backed up as
+    /// `valknut.toml.v1.bak`.
+    pub fn backup_path(&self) -> PathBuf {
+        let name = self
+            .path
+            .file_name()
+            .map(|name| name.to_string_lossy().into_owned())
+            .unwrap_or_default();
+        self.path
+            .with_file_name(format!("{name}.v{}.bak", self.report.from))
+    }
+
+    /// Copy the original to [`Self::backup_path`] and write the migrated file.
+    pub fn write(&self) -> anyhow::Result<PathBuf> {
+        let backup = self.backup_path();
+        std::fs::write(&backup, &self.original)
+            .with_context(|| format!("Failed to write {}", backup.display()))?;
+        std::fs::write(&self.path, &self.migrated)
+            .with_context(|| format!("Failed to write {}", self.path.display()))?;
+        Ok(backup)
+    }
+}
+
+/// Schema version of a parsed flag file; files without `config-version` are
+/// version 1.
+pub fn config_version(document: &Value) -> anyhow::Result<u32> {
+    match document.get(CONFIG_VERSION_KEY) {
+        Some(value) => parse_version(value),
+        None => Ok(1),
+    }
+}
+
+/// Validate a `config-version` value, rejecting versions newer than this build.
+pub fn parse_version(value: &Value) -> anyhow::Result<u32> {
+    let version = value
+        .as_u64()
+        .filter(|version| *version >= 1)
+        .and_then(|version| u32::try_from(version).ok())
+        .with_context(|| {
+            format!("`{CONFIG_VERSION_KEY}` must be a positive integer, found {value}")
+        })?;
+    if version > CURRENT_CONFIG_VERSION {
+        anyhow::bail!(
+            "`{CONFIG_VERSION_KEY}` {version} is newer than this valknut supports ({CURRENT_CONFIG_VERSION}); upgrade valknut to read it"
+        );
+    }
+    Ok(version)
+}
+
+/// Upgrade `document` to [`CURRENT_CONFIG_VERSION`] in place.
+pub fn migrate_document(document: &mut Value, toml: bool) -> anyhow::Result<MigrationReport> {
+    let from = config_version(document)?;
+    let Value::Object(table) = document else {
+        anyhow::bail!("file must contain a table of flags");
+    };
+
+    let mut steps = Vec::new();
+    for migration in MIGRATIONS.iter().filter(|migration| migration.from >= from) {
+        (migration.apply)(table, toml).with_context(|| {
+            format!(
+                "migration from version {} to {} failed",
+                migration.from,
+                migration.from + 1
+            )
+        })?;
+        steps.push(migration.summary);
+    }
+    if from < CURRENT_CONFIG_VERSION {
+        table.insert(
+            CONFIG_VERSION_KEY.to_string(),
+            Value::from(CURRENT_CONFIG_VERSION),
+        );
+    }
+    Ok(MigrationReport {
+        from,
+        to: CURRENT_CONFIG_VERSION,
+        steps,
+    })
+}
+
+/// Read the flag file at `path` and migrate it, without writing anything.
+pub fn migrate_flag_file(path: &Path) -> anyhow::Result<MigratedFile> {
+    let original = std::fs::read_to_string(path)
+        .with_context(|| format!("Failed to read {}", path.display()))?;
+    let mut document = read_flag_file(path)?;
+    let toml = is_toml(path);
+    let report =
+        migrate_document(&mut document, toml).with_context(|| format!("in {}", path.display()))?;
+
+    let migrated = if report.steps.is_empty() {
+        original.clone()
+    } else {
+        let mut content = leading_comments(&original);
+        content.push_str(&render_document(&document, toml)?);
+        content
+    };
+    Ok(MigratedFile {
+        path: path.to_path_buf(),
+        original,
+        migrated,
+        report,
+    })
+}
+
+/// Version 1 to 2: rename flag keys to their long flag spelling at the top
+/// level and in profiles. When two spellings of a flag are present the one
+/// that sorts last wins, as it did when the file was loaded.
+fn canonical_flag_keys(table: &mut Map<String, Value>, _toml: bool) -> anyhow::Result<()> {
+    let entries = std::mem::take(table);
+    for (key, mut value) in entries {
+        if key == PROFILES_KEY {
+            if let Value::Object(profiles) = &mut value {
+                for profile in profiles.values_mut() {
+                    if let Value::Object(flags) = profile {
+                        *flags = std::mem::take(flags)
+                            .into_iter()
+                            .map(|(key, value)| (normalize_key(&key), value))
+                            .collect();
+                    }
+                }
+            }
+            table.insert(key, value);
+        } else if key == CONFIG_VERSION_KEY
+            || is_check_table(&key, &value)
+            || is_engine_section(&key, &value)
+        {
+            table.insert(key, value);
+        } else {
+            table.insert(normalize_key(&key), value);
+        }
+    }
+    Ok(())
+}
+
+/// Version 2 to 3: `valknut check` reads rules from the `[check]` table, so a
+/// top-level `rules` list in valknut.toml (which was never read) moves there.
+/// In YAML files `rules` belongs to the engine configuration and is kept.
+fn rules_under_check(table: &mut Map<String, Value>, toml: bool) -> anyhow::Result<()> {
+    if !toml {
+        return Ok(());
+    }
+    let rules = match table.remove(RULES_KEY) {
+        Some(Value::Array(rules)) => rules,
+        Some(other) => {
+            table.insert(RULES_KEY.to_string(), other);
+            return Ok(());
+        }
+        None => return Ok(()),
+    };
+
+    let check = table
+        .entry(CHECK_KEY)
+        .or_insert_with(|| Value::Object(Map::new()));
+    let Value::Object(check) = check else {
+        anyhow::bail!(
+            "cannot move `{RULES_KEY}` under `{CHECK_KEY}`: `{CHECK_KEY}` is set as a flag"
+        );
+    };
+    match check
+        .entry(RULES_KEY)
+        .or_insert_with(|| Value::Array(Vec::new()))
+    {
+        Value::Array(existing) => existing.extend(rules),
+        _ => anyhow::bail!("`{CHECK_KEY}.{RULES_KEY}` must be a list of rules"),
+    }
+    Ok(())
+}
+
+/// Serialize a migrated document in the file's own format.
+fn render_document(document: &Value, toml: bool) -> anyhow::Result<String> {
+    if toml {
+        let table: toml::Table = serde_json::from_value(document.clone())
+            .context("migrated flags cannot be written as TOML")?;
+        Ok(toml::to_string(&table)?)
+    } else {
+        Ok(serde_yaml::to_string(document)?)
+    }
+}
+
+/// The comment block (and blank lines) at the top of a flag file.
+fn leading_comments(content: &str) -> String {
+    let mut header = String::new();
+    for line in content.lines() {
+        let trimmed = line.trim();
+        if !trimmed.is_empty() && !trimmed.starts_with('#') {
+            break;
+        }
+        header.push_str(line);
+        header.push('\n');
+    }
+    header
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::cli::flag_config::{load_check_rules, validate_flag_file, FlagConfig};
+    use crate::cli::text_diff::{line_diff, DiffLine};
+    use serde_json::json;
+    use std::fs;
+    use tempfile::tempdir;
+
+    #[test]
+    fn version_one_chains_through_every_step() {
+        let mut document = json!({
+            "max_complexity": 60,
+            "--quiet": true,
+            "profiles": { "ci": { "fail_on_issues": true } },
+            "rules": [{ "name": "short", "builtin": "max-function-length" }],
+        });
+
+        let report = migrate_document(&mut document, true).unwrap();
+        assert_eq!((report.from, report.to), (1, CURRENT_CONFIG_VERSION));
+        assert_eq!(report.steps.len(), MIGRATIONS.len());
+        assert_eq!(
+            document,
+            json!({
+                "config-version": CURRENT_CONFIG_VERSION,
+                "max-complexity": 60,
+                "quiet": true,
+                "profiles": { "ci": { "fail-on-issues": true } },
+                "check": { "rules": [{ "name": "short", "builtin": "max-function-length" }] },
+            })
+        );
+
+        let again = migrate_document(&mut document, true).unwrap();
+        assert_eq!(again.from, CURRENT_CONFIG_VERSION);
+        assert!(again.steps.is_empty());
+    }
+
+    #[test]
+    fn later_versions_skip_earlier_steps() {
+        let mut document = json!({ "config-version": 2, "max_complexity": 60 });
+        let report = migrate_document(&mut document, true).unwrap();
+        assert_eq!(report.steps, vec![MIGRATIONS[1].summary]);
+        assert_eq!(document["max_complexity"], json!(60));
+
+        let mut yaml = json!({ "rules": [{ "name": "short" }] });
+        migrate_document(&mut yaml, false).unwrap();
+        assert!(yaml.get("check").is_none());
+        assert!(yaml.get("rules").is_some());
+
+        let mut newer = json!({ "config-version": CURRENT_CONFIG_VERSION + 1 });
+        assert!(migrate_document(&mut newer, true).is_err());
+        let mut conflicting = json!({ "check": true, "rules": [] });
+        assert!(migrate_document(&mut conflicting, true).is_err());
+    }
+
+    #[test]
+    fn migrated_file_keeps_its_header_and_backs_up_the_original() {
+        let tmp = tempdir().unwrap();
+        let path = tmp.path().join("valknut.toml");
+        let original = "# team defaults\n\nmax_complexity = 60\n\n[[rules]]\nname = \"short\"\nbuiltin = \"max-function-length\"\n";
+        fs::write(&path, original).unwrap();
+
+        let migrated = migrate_flag_file(&path).unwrap();
+        assert!(!migrated.is_unchanged());
+        assert!(migrated.migrated.starts_with("# team defaults\n\n"));
+        let diff = line_diff(&migrated.original, &migrated.migrated);
+        assert!(diff.contains(&DiffLine::Same("# team defaults")));
+        assert!(diff.contains(&DiffLine::Removed("max_complexity = 60")));
+        assert!(diff.contains(&DiffLine::Added("max-complexity = 60")));
+        assert_eq!(fs::read_to_string(&path).unwrap(), original);
+
+        let backup = migrated.write().unwrap();
+        assert_eq!(backup, tmp.path().join("valknut.toml.v1.bak"));
+        assert_eq!(fs::read_to_string(&backup).unwrap(), original);
+        assert_eq!(validate_flag_file(&path).unwrap(), Vec::new());
+        assert_eq!(load_check_rules(&[path.clone()]).unwrap().len(), 1);
+        let config = FlagConfig::load(&[path.clone()]).unwrap();
+        assert!(!config.values.contains_key(CONFIG_VERSION_KEY));
+
+        assert!(migrate_flag_file(&path).unwrap().is_unchanged());
+    }
+}
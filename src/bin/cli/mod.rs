@@ -6,18 +6,24 @@
 //! - commands: Command implementations (analyze, config, doc_audit, mcp, oracle)
 //! - config_builder: Configuration building from CLI arguments
 //! - config_layer: Configuration layer management and merging
+//! - flag_config: CLI flag defaults from valknut.toml / .valknut.yaml files
+//! - flag_migration: Versioned upgrades of flag files to the current schema
 //! - output: Output formatting, report generation, and display functions
 //! - quality_gates: Quality gate evaluation and violation handling
 //! - reports: Report generation for various output formats
+//! - text_diff: Line and unified diffs of file contents
 
 pub mod analysis_display;
 pub mod args;
 pub mod commands;
 pub mod config_builder;
 pub mod config_layer;
+pub mod flag_config;
+pub mod flag_migration;
 pub mod output;
 pub mod quality_gates;
 pub mod reports;
+pub mod text_diff;
 
 // Re-export commonly used items for convenience
 pub use args::*;
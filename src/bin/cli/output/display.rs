@@ -207,6 +207,14 @@ pub fn display_completion_summary(
             println!("   1. Import the SonarQube JSON into your SonarQube instance");
             println!("   2. Set up quality gates based on the technical debt metrics");
         }
+        OutputFormat::Sarif => {
+            println!("   1. Upload valknut.sarif with github/codeql-action/upload-sarif");
+            println!("   2. Open the SARIF log in your IDE's SARIF viewer to jump to findings");
+        }
+        OutputFormat::Junit => {
+            println!("   1. Publish valknut-junit.xml as a test report in your CI system");
+            println!("   2. Each failing test case names a rule; its file is the test suite");
+        }
         OutputFormat::Csv => {
             println!("   1. Import the CSV data into your project tracking system");
             println!("   2. Prioritize refactoring tasks based on effort estimates");
@@ -0,0 +1,485 @@
+//! Field selection for JSON reports (`--output-filter`).
+//!
+//! A [`FieldFilter`] is parsed from `fields=summary,refactoring_candidates.name`:
+//! a comma-separated list of dot-separated paths. A path keeps the named field
+//! and everything below it; its ancestors are kept only as containers for the
+//! selected fields. Arrays are transparent, so `refactoring_candidates.name`
+//! keeps the `name` of every candidate. A `*` matches any run of characters
+//! within one segment: `passes.*.enabled` keeps the `enabled` flag of every
+//! pass, and `summary.code_*` every summary field starting with `code_`.
+//!
+//! [`FieldFilterWriter`] applies a filter to JSON as it is written, token by
+//! token, so the unfiltered document is never held in memory: only the key
+//! being read and the path to the current container are buffered. The
+//! filtered document is written compactly.
+
+use std::io::{self, Write};
+use std::str::FromStr;
+
+/// Fields to keep in JSON output.
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct FieldFilter {
+    patterns: Vec<Vec<String>>,
+}
+
+/// What to do with a field, given its path.
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub enum Selection {
+    /// The field is selected: keep it whole.
+    Keep,
+    /// A selected field may lie below: keep the container, filter its children.
+    Descend,
+    /// Nothing below is selected.
+    Drop,
+}
+
+/// Parsing for [`FieldFilter`].
+impl FromStr for FieldFilter {
+    type Err = String;
+
+    fn from_str(value: &str) -> Result<Self, Self::Err> {
+        let fields = value
+            .strip_prefix("fields=")
+            .ok_or_else(|| format!("expected fields=<field>[,<field>...], got {value:?}"))?;
+        let mut patterns = Vec::new();
+        for field in fields.split(',').map(str::trim).filter(|f| !f.is_empty()) {
+            let segments: Vec<String> = field.split('.').map(str::to_string).collect();
+            if segments.iter().any(String::is_empty) {
+                return Err(format!("invalid field {field:?}: empty path segment"));
+            }
+            patterns.push(segments);
+        }
+        if patterns.is_empty() {
+            return Err("fields= needs at least one field".to_string());
+        }
+        Ok(Self { patterns })
+    }
+}
+
+/// Matching methods for [`FieldFilter`].
+impl FieldFilter {
+    /// Decide what to do with the field at `path` (object keys from the root).
+    pub fn select(&self, path: &[String]) -> Selection {
+        let mut selection = Selection::Drop;
+        for pattern in &self.patterns {
+            let shared = pattern.len().min(path.len());
+            let matches = pattern[..shared]
+                .iter()
+                .zip(&path[..shared])
+                .all(|(pattern, key)| segment_matches(pattern, key));
+            if !matches {
+                continue;
+            }
+            if pattern.len() <= path.len() {
+                return Selection::Keep;
+            }
+            selection = Selection::Descend;
+        }
+        selection
+    }
+}
+
+/// Whether `key` matches a path segment in which `*` matches any characters.
+fn segment_matches(pattern: &str, key: &str) -> bool {
+    let mut parts = pattern.split('*');
+    let first = parts.next().unwrap_or_default();
+    let Some(mut rest) = key.strip_prefix(first) else {
+        return false;
+    };
+    let parts: Vec<&str> = parts.collect();
+    let Some((last, middle)) = parts.split_last() else {
+        return rest.is_empty();
+    };
+    for part in middle {
+        match rest.find(part) {
+            Some(index) => rest = &rest[index + part.len()..],
+            None => return false,
+        }
+    }
+    rest.len() >= last.len() && rest.ends_with(last)
+}
+
+/// Writer that forwards only the fields selected by a [`FieldFilter`] of the
+/// JSON document written to it.
+pub struct FieldFilterWriter<W: Write> {
+    inner: W,
+    filter: FieldFilter,
+    /// Open containers that are being filtered (never kept or dropped whole).
+    frames: Vec<Frame>,
+    /// Keys leading to the innermost open object.
+    path: Vec<String>,
+    state: State,
+}
+
+/// A container whose children are being filtered.
+#[derive(Debug)]
+struct Frame {
+    array: bool,
+    /// No child has been written yet (so no comma is due).
+    empty: bool,
+    /// The container is the value of an object key, pushed onto `path`.
+    keyed: bool,
+}
+
+/// Position of the filter in the JSON grammar.
+#[derive(Debug)]
+enum State {
+    /// Expecting a value; `key` is its raw key when it belongs to an object.
+    Value {
+        key: Option<Vec<u8>>,
+        selection: Selection,
+    },
+    /// After `[`: expecting an element or `]`.
+    ArrayStart,
+    /// After `{` or `,` in an object: expecting a key or `}`.
+    KeyOrEnd,
+    /// Inside a key string.
+    Key { raw: Vec<u8>, escape: bool },
+    /// After a key: expecting `:`.
+    Colon { raw: Vec<u8> },
+    /// Inside a value that is copied (`copy`) or skipped whole.
+    Scan { scan: Scan, copy: bool },
+    /// After a value: expecting `,` or the end of the container.
+    AfterValue,
+    /// After the root value.
+    Done,
+}
+
+/// Tracks where a raw JSON value ends.
+#[derive(Debug, Default)]
+struct Scan {
+    depth: usize,
+    in_string: bool,
+    escape: bool,
+    scalar: bool,
+}
+
+/// How a byte relates to the end of a scanned value.
+#[derive(Debug, PartialEq, Eq)]
+enum ScanStep {
+    /// The value continues after this byte.
+    Continue,
+    /// This byte is the last one of the value.
+    Last,
+    /// The value ended before this byte, which belongs to the enclosing container.
+    Ended,
+}
+
+/// Step methods for [`Scan`].
+impl Scan {
+    /// Start scanning a value whose first byte is `first`.
+    fn start(first: u8) -> Self {
+        match first {
+            b'"' => Self {
+                in_string: true,
+                ..Self::default()
+            },
+            b'{' | b'[' => Self {
+                depth: 1,
+                ..Self::default()
+            },
+            _ => Self {
+                scalar: true,
+                ..Self::default()
+            },
+        }
+    }
+
+    /// Advance over `byte`.
+    fn step(&mut self, byte: u8) -> ScanStep {
+        if self.scalar {
+            return if matches!(byte, b',' | b'}' | b']') || byte.is_ascii_whitespace() {
+                ScanStep::Ended
+            } else {
+                ScanStep::Continue
+            };
+        }
+        if self.in_string {
+            if self.escape {
+                self.escape = false;
+            } else if byte == b'\\' {
+                self.escape = true;
+            } else if byte == b'"' {
+                self.in_string = false;
+                if self.depth == 0 {
+                    return ScanStep::Last;
+                }
+            }
+            return ScanStep::Continue;
+        }
+        match byte {
+            b'"' => self.in_string = true,
+            b'{' | b'[' => self.depth += 1,
+            b'}' | b']' => {
+                self.depth -= 1;
+                if self.depth == 0 {
+                    return ScanStep::Last;
+                }
+            }
+            _ => {}
+        }
+        ScanStep::Continue
+    }
+}
+
+/// Construction and streaming methods for [`FieldFilterWriter`].
+impl<W: Write> FieldFilterWriter<W> {
+    /// Filter the JSON document written to the returned writer into `inner`.
+    pub fn new(inner: W, filter: FieldFilter) -> Self {
+        Self {
+            inner,
+            filter,
+            frames: Vec::new(),
+            path: Vec::new(),
+            state: State::Value {
+                key: None,
+                selection: Selection::Descend,
+            },
+        }
+    }
+
+    /// Check that a complete document was written and return the inner writer.
+    pub fn finish(mut self) -> io::Result<W> {
+        // A scalar root only ends at end of input.
+        if let State::Scan { scan, .. } = &self.state {
+            if scan.scalar && self.frames.is_empty() {
+                self.state = State::Done;
+            }
+        }
+        if !matches!(self.state, State::Done) {
+            return Err(io::Error::new(
+                io::ErrorKind::UnexpectedEof,
+                "incomplete JSON document",
+            ));
+        }
+        self.inner.flush()?;
+        Ok(self.inner)
+    }
+
+    /// Feed one byte of input.
+    fn feed(&mut self, byte: u8) -> io::Result<()> {
+        match std::mem::replace(&mut self.state, State::Done) {
+            State::Value { key, selection } => {
+                if byte.is_ascii_whitespace() {
+                    self.state = State::Value { key, selection };
+                    return Ok(());
+                }
+                self.start_value(byte, key, selection)
+            }
+            State::ArrayStart => {
+                if byte.is_ascii_whitespace() {
+                    self.state = State::ArrayStart;
+                    Ok(())
+                } else if byte == b']' {
+                    self.close(byte)
+                } else {
+                    self.start_value(byte, None, Selection::Descend)
+                }
+            }
+            State::KeyOrEnd => match byte {
+                b'"' => {
+                    self.state = State::Key {
+                        raw: Vec::new(),
+                        escape: false,
+                    };
+                    Ok(())
+                }
+                b'}' => self.close(byte),
+                _ if byte.is_ascii_whitespace() => {
+                    self.state = State::KeyOrEnd;
+                    Ok(())
+                }
+                _ => Err(invalid(byte)),
+            },
+            State::Key { mut raw, escape } => {
+                if !escape && byte == b'"' {
+                    self.state = State::Colon { raw };
+                } else {
+                    raw.push(byte);
+                    self.state = State::Key {
+                        raw,
+                        escape: !escape && byte == b'\\',
+                    };
+                }
+                Ok(())
+            }
+            State::Colon { raw } => match byte {
+                b':' => {
+                    self.path.push(decode_key(&raw));
+                    let selection = self.filter.select(&self.path);
+                    self.path.pop();
+                    self.state = State::Value {
+                        key: Some(raw),
+                        selection,
+                    };
+                    Ok(())
+                }
+                _ if byte.is_ascii_whitespace() => {
+                    self.state = State::Colon { raw };
+                    Ok(())
+                }
+                _ => Err(invalid(byte)),
+            },
+            State::Scan { mut scan, copy } => match scan.step(byte) {
+                ScanStep::Continue => {
+                    if copy && (scan.in_string || !byte.is_ascii_whitespace()) {
+                        self.inner.write_all(&[byte])?;
+                    }
+                    self.state = State::Scan { scan, copy };
+                    Ok(())
+                }
+                ScanStep::Last => {
+                    if copy {
+                        self.inner.write_all(&[byte])?;
+                    }
+                    self.end_value();
+                    Ok(())
+                }
+                ScanStep::Ended => {
+                    self.end_value();
+                    self.feed(byte)
+                }
+            },
+            State::AfterValue => match byte {
+                b',' => {
+                    let array = self.frames.last().is_some_and(|frame| frame.array);
+                    self.state = if array {
+                        State::Value {
+                            key: None,
+                            selection: Selection::Descend,
+                        }
+                    } else {
+                        State::KeyOrEnd
+                    };
+                    Ok(())
+                }
+                b'}' | b']' => self.close(byte),
+                _ if byte.is_ascii_whitespace() => {
+                    self.state = State::AfterValue;
+                    Ok(())
+                }
+                _ => Err(invalid(byte)),
+            },
+            State::Done => {
+                if byte.is_ascii_whitespace() {
+                    Ok(())
+                } else {
+                    Err(invalid(byte))
+                }
+            }
+        }
+    }
+
+    /// Handle the first byte of a value according to its selection.
+    fn start_value(
+        &mut self,
+        byte: u8,
+        key: Option<Vec<u8>>,
+        selection: Selection,
+    ) -> io::Result<()> {
+        let container = matches!(byte, b'{' | b'[');
+        let root = self.frames.is_empty();
+        let copy = match selection {
+            Selection::Keep => true,
+            Selection::Descend if container => {
+                self.write_prefix(key.as_deref())?;
+                self.inner.write_all(&[byte])?;
+                if let Some(raw) = &key {
+                    self.path.push(decode_key(raw));
+                }
+                self.frames.push(Frame {
+                    array: byte == b'[',
+                    empty: true,
+                    keyed: key.is_some(),
+                });
+                self.state = if byte == b'[' {
+                    State::ArrayStart
+                } else {
+                    State::KeyOrEnd
+                };
+                return Ok(());
+            }
+            Selection::Descend => root,
+            Selection::Drop => false,
+        };
+        if copy {
+            self.write_prefix(key.as_deref())?;
+            self.inner.write_all(&[byte])?;
+        }
+        self.state = State::Scan {
+            scan: Scan::start(byte),
+            copy,
+        };
+        Ok(())
+    }
+
+    /// Write the comma and key due before a kept value.
+    fn write_prefix(&mut self, key: Option<&[u8]>) -> io::Result<()> {
+        if let Some(frame) = self.frames.last_mut() {
+            if !frame.empty {
+                self.inner.write_all(b",")?;
+            }
+            frame.empty = false;
+        }
+        if let Some(raw) = key {
+            self.inner.write_all(b"\"")?;
+            self.inner.write_all(raw)?;
+            self.inner.write_all(b"\":")?;
+        }
+        Ok(())
+    }
+
+    /// Close the innermost filtered container with `byte`.
+    fn close(&mut self, byte: u8) -> io::Result<()> {
+        let frame = self.frames.pop().ok_or_else(|| invalid(byte))?;
+        if frame.array != (byte == b']') {
+            return Err(invalid(byte));
+        }
+        self.inner.write_all(&[byte])?;
+        if frame.keyed {
+            self.path.pop();
+        }
+        self.end_value();
+        Ok(())
+    }
+
+    /// Move past a finished value.
+    fn end_value(&mut self) {
+        self.state = if self.frames.is_empty() {
+            State::Done
+        } else {
+            State::AfterValue
+        };
+    }
+}
+
+/// [`Write`] implementation for [`FieldFilterWriter`].
+impl<W: Write> Write for FieldFilterWriter<W> {
+    fn write(&mut self, buf: &[u8]) -> io::Result<usize> {
+        for &byte in buf {
+            self.feed(byte)?;
+        }
+        Ok(buf.len())
+    }
+
+    fn flush(&mut self) -> io::Result<()> {
+        self.inner.flush()
+    }
+}
+
+/// Key text with JSON escapes resolved, for matching against the filter.
+fn decode_key(raw: &[u8]) -> String {
+    let text = String::from_utf8_lossy(raw);
+    if !raw.contains(&b'\\') {
+        return text.into_owned();
+    }
+    serde_json::from_str(&format!("\"{text}\"")).unwrap_or_else(|_| text.into_owned())
+}
+
+/// Error for a byte that cannot appear at this point of a JSON document.
+fn invalid(byte: u8) -> io::Error {
+    io::Error::new(
+        io::ErrorKind::InvalidData,
+        format!("unexpected {:?} in JSON output", byte as char),
+    )
+}
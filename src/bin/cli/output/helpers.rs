@@ -47,13 +47,19 @@ pub fn format_location(rec: &serde_json::Value) -> String {
 pub fn format_to_string(format: &OutputFormat) -> &str {
     match format {
         OutputFormat::Jsonl => "jsonl",
+        OutputFormat::Ndjson => "ndjson",
         OutputFormat::Json => "json",
         OutputFormat::Yaml => "yaml",
         OutputFormat::Markdown => "markdown",
         OutputFormat::Html => "html",
         OutputFormat::Sonar => "sonar",
+        OutputFormat::Sarif => "sarif",
+        OutputFormat::Junit => "junit",
         OutputFormat::Csv => "csv",
         OutputFormat::CiSummary => "ci-summary",
         OutputFormat::Pretty => "pretty",
+        OutputFormat::Summary => "summary",
+        OutputFormat::Dot => "dot",
+        OutputFormat::SymbolGraph => "symbol-graph",
     }
 }
@@ -1,16 +1,19 @@
 //! Output Formatting, Report Generation, and Display Functions
 //!
 //! This module contains all output formatting functions, report generation for
-//! various formats (HTML, Markdown, CSV, Sonar), and display utilities.
+//! various formats (HTML, Markdown, CSV, Sonar, SARIF, per-file summary), and display
+//! utilities.
 
 mod csv_export;
 mod display;
+mod field_filter;
 mod helpers;
 mod html_report;
 mod markdown_report;
 mod report_helpers;
 mod reports;
 mod sonar;
+mod summary;
 mod writers;
 
 use std::path::Path;
@@ -28,13 +31,18 @@ pub use display::{
     display_file_complexity_recommendations, display_refactoring_suggestions,
     display_top_structure_issues, print_comprehensive_results_pretty, print_human_readable_results,
 };
+pub use field_filter::{FieldFilter, FieldFilterWriter};
 pub use helpers::format_to_string;
 pub use html_report::generate_html_report;
 pub use markdown_report::generate_markdown_report;
 pub use sonar::generate_sonar_report;
+pub use summary::{
+    build_file_summary, print_file_summary, render_file_summary, FileSummaryRow, SummaryOptions,
+    SummaryStatus,
+};
 pub use writers::{
     build_report_generator, write_ci_summary, write_csv, write_html, write_json, write_jsonl,
-    write_markdown, write_sonar, write_yaml,
+    write_junit, write_markdown, write_ndjson, write_sarif, write_sonar, write_yaml,
 };
 
 /// Generate outputs with progress feedback
@@ -77,19 +85,44 @@ pub async fn generate_outputs(
     tokio::fs::create_dir_all(out_path).await?;
 
     match output_format {
-        OutputFormat::Json | OutputFormat::Jsonl | OutputFormat::Yaml => {
+        OutputFormat::Json | OutputFormat::Jsonl | OutputFormat::Ndjson | OutputFormat::Yaml => {
             write_data_format(result, out_path, output_format).await
         }
         OutputFormat::Markdown | OutputFormat::Html => {
             write_rich_report(result, out_path, output_format).await
         }
-        OutputFormat::Sonar | OutputFormat::Csv | OutputFormat::CiSummary => {
+        OutputFormat::Sonar
+        | OutputFormat::Sarif
+        | OutputFormat::Junit
+        | OutputFormat::Csv
+        | OutputFormat::CiSummary => {
             write_integration_format(result, out_path, output_format).await
         }
         OutputFormat::Pretty => {
             print_comprehensive_results_pretty(result);
             Ok(())
         }
+        OutputFormat::Summary => {
+            if let Ok(analysis_results) = serde_json::from_value::<AnalysisResults>(result.clone())
+            {
+                print_file_summary(&analysis_results, &SummaryOptions::for_stdout(true, None));
+            }
+            Ok(())
+        }
+        OutputFormat::Dot => {
+            if let Ok(analysis_results) = serde_json::from_value::<AnalysisResults>(result.clone())
+            {
+                crate::cli::reports::write_type_graph(&analysis_results, None, out_path)?;
+            }
+            Ok(())
+        }
+        OutputFormat::SymbolGraph => {
+            if let Ok(analysis_results) = serde_json::from_value::<AnalysisResults>(result.clone())
+            {
+                crate::cli::reports::write_symbol_graph(&analysis_results, out_path)?;
+            }
+            Ok(())
+        }
     }
 }
 
@@ -104,6 +137,7 @@ async fn write_data_format(
 
     match format {
         OutputFormat::Jsonl => write_jsonl(result, out_path).await,
+        OutputFormat::Ndjson => write_ndjson(analysis_results.as_ref(), result, out_path).await,
         OutputFormat::Json => {
             write_json(&generator, analysis_results.as_ref(), result, out_path).await
         }
@@ -134,7 +168,7 @@ async fn write_rich_report(
     }
 }
 
-/// Write CI/integration formats (Sonar, CSV, CI Summary).
+/// Write CI/integration formats (Sonar, SARIF, JUnit, CSV, CI Summary).
 async fn write_integration_format(
     result: &serde_json::Value,
     out_path: &Path,
@@ -147,6 +181,12 @@ async fn write_integration_format(
         OutputFormat::Sonar => {
             write_sonar(&generator, analysis_results.as_ref(), result, out_path).await
         }
+        OutputFormat::Sarif => {
+            write_sarif(&generator, analysis_results.as_ref(), result, out_path).await
+        }
+        OutputFormat::Junit => {
+            write_junit(&generator, analysis_results.as_ref(), result, out_path).await
+        }
         OutputFormat::Csv => {
             write_csv(&generator, analysis_results.as_ref(), result, out_path).await
         }
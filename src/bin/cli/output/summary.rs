@@ -0,0 +1,254 @@
+//! One-line-per-file digest for the `summary` output format.
+//!
+//! Each row lists a file's path, how many symbols it exports, the highest
+//! cyclomatic complexity among its functions, and its complexity warnings.
+//! Rows are coloured (red for violations, yellow for warnings) only when
+//! stdout is a terminal, so the table pipes cleanly into other tools.
+
+use std::collections::BTreeMap;
+use std::io::IsTerminal;
+use std::path::Path;
+
+use owo_colors::OwoColorize;
+
+use valknut_rs::core::pipeline::AnalysisResults;
+use valknut_rs::detectors::complexity::{ComplexityAnalysisResult, ComplexityReport};
+use valknut_rs::detectors::rules::relative_path;
+use valknut_rs::detectors::structure::file::FileAnalyzer;
+use valknut_rs::detectors::structure::StructureConfig;
+use valknut_rs::lang::registry::adapter_for_file;
+
+/// Column headers of the summary table.
+const HEADERS: [&str; 4] = ["FILE", "EXPORTS", "MAX CC", "WARNINGS"];
+
+/// Issue severities that mark a file as a violation rather than a warning.
+const VIOLATION_SEVERITIES: &[&str] = &["critical", "veryhigh"];
+
+/// Rendering options for the summary table.
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub struct SummaryOptions {
+    /// Print the column header line.
+    pub header: bool,
+    /// Colour rows by status.
+    pub color: bool,
+    /// Per-function cyclomatic limit from `--max-function-complexity`.
+    pub max_function_complexity: Option<u32>,
+}
+
+/// Overall state of a file in the summary.
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub enum SummaryStatus {
+    /// No complexity issues.
+    Clean,
+    /// Complexity issues below the violation level.
+    Warning,
+    /// Critical issues or a function over the complexity gate.
+    Violation,
+}
+
+/// One row of the summary table.
+#[derive(Debug, Clone, PartialEq)]
+pub struct FileSummaryRow {
+    /// Path relative to the project root.
+    pub path: String,
+    /// Exported symbols, or `None` when the file could not be parsed.
+    pub exported_symbols: Option<usize>,
+    /// Highest cyclomatic complexity among the file's functions.
+    pub max_complexity: Option<f64>,
+    /// Issue kinds found in the file, with repeat counts.
+    pub warnings: Vec<String>,
+    /// Row status used for colouring.
+    pub status: SummaryStatus,
+}
+
+/// Construction methods for [`SummaryOptions`].
+impl SummaryOptions {
+    /// Options for printing to stdout; colour is enabled only on a terminal.
+    pub fn for_stdout(header: bool, max_function_complexity: Option<u32>) -> Self {
+        Self {
+            header,
+            color: std::io::stdout().is_terminal(),
+            max_function_complexity,
+        }
+    }
+}
+
+/// Print the summary table for `result` to stdout.
+pub fn print_file_summary(result: &AnalysisResults, options: &SummaryOptions) {
+    let rows = build_file_summary(result, options.max_function_complexity);
+    print!("{}", render_file_summary(&rows, options));
+}
+
+/// Build one summary row per analysed file, sorted by path.
+pub fn build_file_summary(
+    result: &AnalysisResults,
+    max_function_complexity: Option<u32>,
+) -> Vec<FileSummaryRow> {
+    let root = result.project_root.as_path();
+    let mut by_file: BTreeMap<String, Vec<ComplexityAnalysisResult>> = BTreeMap::new();
+    for entry in &result.passes.complexity.detailed_results {
+        by_file
+            .entry(relative_path(root, &entry.file_path))
+            .or_default()
+            .push(entry.clone());
+    }
+    for path in result.file_health.keys() {
+        by_file.entry(relative_path(root, path)).or_default();
+    }
+
+    let analyzer = FileAnalyzer::new(StructureConfig::default());
+    by_file
+        .into_iter()
+        .map(|(path, entries)| {
+            let report = ComplexityReport::from_results(&entries);
+            let max_complexity = (report.function_count > 0).then_some(report.max_cyclomatic);
+
+            let mut warnings = issue_counts(&entries);
+            let mut status = if warnings.is_empty() {
+                SummaryStatus::Clean
+            } else {
+                SummaryStatus::Warning
+            };
+            let violates_severity = entries.iter().flat_map(|e| &e.issues).any(|issue| {
+                VIOLATION_SEVERITIES.contains(&issue.severity.to_lowercase().as_str())
+            });
+            if violates_severity {
+                status = SummaryStatus::Violation;
+            }
+            if let (Some(limit), Some(max)) = (max_function_complexity, max_complexity) {
+                if max > f64::from(limit) {
+                    warnings.push(format!("max-function-complexity>{limit}"));
+                    status = SummaryStatus::Violation;
+                }
+            }
+
+            FileSummaryRow {
+                exported_symbols: count_exported_symbols(&analyzer, &root.join(&path)),
+                path,
+                max_complexity,
+                warnings,
+                status,
+            }
+        })
+        .collect()
+}
+
+/// Render rows as an aligned table, ending with a newline.
+pub fn render_file_summary(rows: &[FileSummaryRow], options: &SummaryOptions) -> String {
+    let cells: Vec<[String; 4]> = rows
+        .iter()
+        .map(|row| {
+            [
+                row.path.clone(),
+                row.exported_symbols
+                    .map_or_else(|| "-".to_string(), |count| count.to_string()),
+                row.max_complexity
+                    .map_or_else(|| "-".to_string(), |max| format!("{max:.0}")),
+                if row.warnings.is_empty() {
+                    "-".to_string()
+                } else {
+                    row.warnings.join(", ")
+                },
+            ]
+        })
+        .collect();
+
+    let mut widths = HEADERS.map(str::len);
+    for row in &cells {
+        for (width, cell) in widths.iter_mut().zip(row) {
+            *width = (*width).max(cell.len());
+        }
+    }
+
+    let mut output = String::new();
+    if options.header {
+        let header = format_line(&HEADERS.map(str::to_string), &widths);
+        if options.color {
+            output.push_str(&header.bold().to_string());
+        } else {
+            output.push_str(&header);
+        }
+        output.push('\n');
+    }
+    for (row, cells) in rows.iter().zip(&cells) {
+        let line = format_line(cells, &widths);
+        let line = match (options.color, row.status) {
+            (true, SummaryStatus::Violation) => line.red().to_string(),
+            (true, SummaryStatus::Warning) => line.yellow().to_string(),
+            _ => line,
+        };
+        output.push_str(&line);
+        output.push('\n');
+    }
+    output
+}
+
+/// Pad every column but the last to its width.
+fn format_line(cells: &[String; 4], widths: &[usize; 4]) -> String {
+    format!(
+        "{:<w0$}  {:>w1$}  {:>w2$}  {}",
+        cells[0],
+        cells[1],
+        cells[2],
+        cells[3],
+        w0 = widths[0],
+        w1 = widths[1],
+        w2 = widths[2],
+    )
+}
+
+/// Issue kinds across `entries` in first-seen order, e.g. `long-function x2`.
+fn issue_counts(entries: &[ComplexityAnalysisResult]) -> Vec<String> {
+    let mut counts: Vec<(String, usize)> = Vec::new();
+    for issue in entries.iter().flat_map(|entry| &entry.issues) {
+        let kind = kebab_case(&issue.issue_type);
+        match counts.iter_mut().find(|(existing, _)| *existing == kind) {
+            Some((_, count)) => *count += 1,
+            None => counts.push((kind, 1)),
+        }
+    }
+    counts
+        .into_iter()
+        .map(|(kind, count)| {
+            if count > 1 {
+                format!("{kind} x{count}")
+            } else {
+                kind
+            }
+        })
+        .collect()
+}
+
+/// Convert `HighCyclomaticComplexity` or `large_file` to `high-cyclomatic-complexity` / `large-file`.
+fn kebab_case(name: &str) -> String {
+    let mut kebab = String::with_capacity(name.len() + 4);
+    for (index, ch) in name.chars().enumerate() {
+        if ch == '_' {
+            kebab.push('-');
+        } else if ch.is_ascii_uppercase() {
+            if index > 0 && !kebab.ends_with('-') {
+                kebab.push('-');
+            }
+            kebab.push(ch.to_ascii_lowercase());
+        } else {
+            kebab.push(ch);
+        }
+    }
+    kebab
+}
+
+/// Count the symbols a file exports, or `None` if it cannot be read or parsed.
+fn count_exported_symbols(analyzer: &FileAnalyzer, path: &Path) -> Option<usize> {
+    let source = std::fs::read_to_string(path).ok()?;
+    let mut adapter = adapter_for_file(path).ok()?;
+    let index = adapter
+        .parse_source(&source, &path.to_string_lossy())
+        .ok()?;
+    Some(
+        index
+            .entities
+            .values()
+            .filter(|entity| analyzer.is_entity_exported(entity, path, &source))
+            .count(),
+    )
+}
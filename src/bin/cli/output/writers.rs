@@ -32,6 +32,27 @@ pub async fn write_jsonl(result: &serde_json::Value, out_path: &Path) -> anyhow:
     Ok(())
 }
 
+/// Write streaming NDJSON output (one object per file plus a trailing summary).
+pub async fn write_ndjson(
+    analysis_results: Option<&AnalysisResults>,
+    result: &serde_json::Value,
+    out_path: &Path,
+) -> anyhow::Result<()> {
+    let report_file = out_path.join("report.ndjson");
+    match analysis_results {
+        Some(results) => crate::cli::reports::write_ndjson_report(&report_file, results)?,
+        None => {
+            tokio::fs::write(
+                &report_file,
+                format!("{}\n", serde_json::to_string(result)?),
+            )
+            .await?
+        }
+    }
+    println!("📄 NDJSON report: {}", report_file.display());
+    Ok(())
+}
+
 /// Write JSON format output.
 pub async fn write_json(
     generator: &ReportGenerator,
@@ -125,6 +146,52 @@ pub async fn write_sonar(
     Ok(())
 }
 
+/// Write SARIF 2.1.0 output.
+pub async fn write_sarif(
+    generator: &ReportGenerator,
+    analysis_results: Option<&AnalysisResults>,
+    result: &serde_json::Value,
+    out_path: &Path,
+) -> anyhow::Result<()> {
+    let report_file = out_path.join("valknut.sarif");
+    let owned;
+    let results = match analysis_results {
+        Some(results) => results,
+        None => {
+            owned = serde_json::from_value::<AnalysisResults>(result.clone()).map_err(|e| {
+                anyhow::anyhow!("SARIF output requires full analysis results: {}", e)
+            })?;
+            &owned
+        }
+    };
+    generator.generate_sarif_report(results, &report_file)?;
+    println!("📊 SARIF report: {}", report_file.display());
+    Ok(())
+}
+
+/// Write JUnit XML output.
+pub async fn write_junit(
+    generator: &ReportGenerator,
+    analysis_results: Option<&AnalysisResults>,
+    result: &serde_json::Value,
+    out_path: &Path,
+) -> anyhow::Result<()> {
+    let report_file = out_path.join("valknut-junit.xml");
+    let owned;
+    let results = match analysis_results {
+        Some(results) => results,
+        None => {
+            owned = serde_json::from_value::<AnalysisResults>(result.clone()).map_err(|e| {
+                anyhow::anyhow!("JUnit output requires full analysis results: {}", e)
+            })?;
+            &owned
+        }
+    };
+    generator.generate_junit_report(results, &report_file)?;
+    println!("📊 JUnit report: {}", report_file.display());
+    Ok(())
+}
+
 /// Write CSV format output.
 pub async fn write_csv(
     generator: &ReportGenerator,
@@ -205,6 +205,7 @@ fn build_sample_analysis_results() -> AnalysisResults {
         issue_count: 1,
         suggestion_count: 1,
         coverage_percentage: None,
+        context: Default::default(),
     };
 
     AnalysisResults {
@@ -226,6 +227,8 @@ fn build_sample_analysis_results() -> AnalysisResults {
             critical_issues: 0,
             doc_health_score: 1.0,
             doc_issue_count: 0,
+            complexity: Default::default(),
+            timed_out: false,
         },
         normalized: None,
         passes: valknut_rs::api::results::StageResultsBundle::disabled(),
@@ -248,11 +251,22 @@ fn build_sample_analysis_results() -> AnalysisResults {
         coverage_packs: Vec::new(),
         warnings: vec!["Sample warning".to_string()],
         code_dictionary: CodeDictionary::default(),
+        rule_findings: Vec::new(),
+        changed_files_only: false,
+        dependency_report: Vec::new(),
+        interface_report: None,
+        project_health: None,
+        context_statistics: Default::default(),
         documentation: None,
         directory_health: HashMap::new(),
         file_health: HashMap::new(),
         entity_health: HashMap::new(),
         directory_health_tree: None,
+        skipped_files: Vec::new(),
+        generated_code: Default::default(),
+        notebook_metadata: Vec::new(),
+        duplicates: Default::default(),
+        file_complexity: Default::default(),
     }
 }
 
@@ -272,6 +286,10 @@ fn test_format_to_string() {
     assert_eq!(format_to_string(&OutputFormat::Csv), "csv");
     assert_eq!(format_to_string(&OutputFormat::CiSummary), "ci-summary");
     assert_eq!(format_to_string(&OutputFormat::Pretty), "pretty");
+    assert_eq!(format_to_string(&OutputFormat::Summary), "summary");
+    assert_eq!(format_to_string(&OutputFormat::Dot), "dot");
+    assert_eq!(format_to_string(&OutputFormat::Junit), "junit");
+    assert_eq!(format_to_string(&OutputFormat::SymbolGraph), "symbol-graph");
 }
 
 #[test]
@@ -1323,3 +1341,347 @@ async fn test_generate_outputs_with_analysis_results_across_formats() {
         "sonar output should contain issues array"
     );
 }
+
+#[tokio::test]
+async fn test_generate_outputs_ndjson_writes_file_records_then_summary() {
+    let result = typed_analysis_results_json();
+    let temp_dir = tempdir().unwrap();
+    generate_outputs(&result, temp_dir.path(), &OutputFormat::Ndjson)
+        .await
+        .unwrap();
+
+    let content = fs::read_to_string(temp_dir.path().join("report.ndjson")).unwrap();
+    let lines: Vec<serde_json::Value> = content
+        .lines()
+        .map(|line| serde_json::from_str(line).expect("each line is a JSON object"))
+        .collect();
+
+    assert_eq!(lines.len(), 2);
+    assert_eq!(lines[0]["type"], "file");
+    assert_eq!(lines[0]["path"], "src/lib.rs");
+    assert_eq!(
+        lines[0]["refactoring_candidates"][0]["name"],
+        "analyze_module"
+    );
+    assert_eq!(lines[1]["type"], "summary");
+    assert_eq!(lines[1]["summary"]["files_processed"], 1);
+}
+
+#[test]
+fn test_ndjson_file_records_list_skipped_files() {
+    let mut result = build_sample_analysis_results();
+    result
+        .skipped_files
+        .push(valknut_rs::core::pipeline::SkippedFile {
+            path: std::path::PathBuf::from("gen/api.pb.go"),
+            reason: valknut_rs::core::pipeline::SkipReason::LargeFile,
+            size_bytes: 3 << 20,
+            limit_bytes: 1 << 20,
+            language: None,
+        });
+
+    let records = crate::cli::reports::ndjson_file_records(&result);
+    let skipped = records
+        .iter()
+        .find(|record| record["path"] == "gen/api.pb.go")
+        .expect("skipped file should be listed");
+    assert_eq!(skipped["status"], "skipped");
+    assert_eq!(skipped["reason"], "skipped_large_file");
+    assert_eq!(skipped["size_bytes"], 3 << 20);
+    assert!(records
+        .iter()
+        .filter(|record| record["path"] != "gen/api.pb.go")
+        .all(|record| record["status"] == "analyzed"));
+}
+
+#[test]
+fn test_ndjson_file_records_mark_generated_files() {
+    let mut result = build_sample_analysis_results();
+    result
+        .generated_code
+        .files
+        .push(valknut_rs::detectors::generated::GeneratedFile {
+            path: std::path::PathBuf::from("pb/api.pb.go"),
+            generator: Some("protoc-gen-go".to_string()),
+        });
+
+    let records = crate::cli::reports::ndjson_file_records(&result);
+    let generated = records
+        .iter()
+        .find(|record| record["path"] == "pb/api.pb.go")
+        .expect("generated file should be listed");
+    assert_eq!(generated["status"], "generated");
+    assert_eq!(generated["generated"], true);
+    assert_eq!(generated["generator"], "protoc-gen-go");
+}
+
+#[test]
+fn test_ndjson_file_records_include_normalized_complexity() {
+    let mut result = build_sample_analysis_results();
+    result.file_health.insert("cmd/main.go".to_string(), 80.0);
+    result.file_complexity.insert(
+        "cmd/main.go".to_string(),
+        valknut_rs::core::scoring::FileComplexity {
+            language: "go".to_string(),
+            functions: 2,
+            mean_cyclomatic: 13.0,
+            normalized_mean_cyclomatic: 10.0,
+            baseline: 1.3,
+        },
+    );
+
+    let records = crate::cli::reports::ndjson_file_records(&result);
+    let record = records
+        .iter()
+        .find(|record| record["path"] == "cmd/main.go")
+        .expect("scored file should be listed");
+    assert_eq!(record["complexity"]["mean_cyclomatic"], 13.0);
+    assert_eq!(record["complexity"]["normalized_mean_cyclomatic"], 10.0);
+}
+
+fn complexity_entry(
+    file_path: &str,
+    name: &str,
+    cyclomatic: f64,
+    issues: Vec<(&str, &str)>,
+) -> valknut_rs::detectors::complexity::ComplexityAnalysisResult {
+    use valknut_rs::detectors::complexity::{
+        ComplexityAnalysisResult, ComplexityIssue, ComplexityMetrics, ComplexitySeverity,
+        HalsteadMetrics,
+    };
+
+    ComplexityAnalysisResult {
+        entity_id: format!("{file_path}:{name}"),
+        file_path: file_path.to_string(),
+        line_number: 1,
+        start_line: 1,
+        entity_name: name.to_string(),
+        entity_type: "function".to_string(),
+        metrics: ComplexityMetrics {
+            cyclomatic_complexity: cyclomatic,
+            cognitive_complexity: 0.0,
+            max_nesting_depth: 0.0,
+            parameter_count: 0.0,
+            lines_of_code: 1.0,
+            statement_count: 1.0,
+            expression_complexity: 0.0,
+            halstead: HalsteadMetrics::default(),
+            technical_debt_score: 0.0,
+            maintainability_index: 100.0,
+            decision_points: Vec::new(),
+        },
+        issues: issues
+            .into_iter()
+            .map(|(issue_type, severity)| ComplexityIssue {
+                entity_id: format!("{file_path}:{name}"),
+                issue_type: issue_type.to_string(),
+                severity: severity.to_string(),
+                description: String::new(),
+                recommendation: String::new(),
+                location: file_path.to_string(),
+                metric_value: cyclomatic,
+                threshold: 10.0,
+            })
+            .collect(),
+        severity: ComplexitySeverity::Low,
+        recommendations: Vec::new(),
+    }
+}
+
+fn summary_analysis_results(root: &std::path::Path) -> AnalysisResults {
+    fs::create_dir_all(root.join("pkg")).unwrap();
+    fs::write(
+        root.join("pkg/server.go"),
+        "package pkg\n\nfunc Handle() {}\n\nfunc helper() {}\n\ntype Server struct{}\n",
+    )
+    .unwrap();
+
+    let mut results = build_sample_analysis_results();
+    results.project_root = root.to_path_buf();
+    results.passes.complexity.detailed_results = vec![
+        complexity_entry(
+            &root.join("pkg/server.go").to_string_lossy(),
+            "Handle",
+            14.0,
+            vec![
+                ("HighCyclomaticComplexity", "High"),
+                ("LongFunction", "High"),
+                ("LongFunction", "High"),
+            ],
+        ),
+        complexity_entry("pkg/server.go", "helper", 3.0, Vec::new()),
+        complexity_entry(
+            "pkg/legacy.go",
+            "Run",
+            40.0,
+            vec![("DeepNesting", "Critical")],
+        ),
+    ];
+    results
+        .file_health
+        .insert("pkg/clean.go".to_string(), 100.0);
+    results
+}
+
+#[test]
+fn test_build_file_summary_groups_rows_by_file() {
+    let temp_dir = tempdir().unwrap();
+    let results = summary_analysis_results(temp_dir.path());
+
+    let rows = build_file_summary(&results, None);
+    let paths: Vec<&str> = rows.iter().map(|row| row.path.as_str()).collect();
+    assert_eq!(
+        paths,
+        vec!["pkg/clean.go", "pkg/legacy.go", "pkg/server.go"]
+    );
+
+    let clean = &rows[0];
+    assert_eq!(clean.exported_symbols, None);
+    assert_eq!(clean.max_complexity, None);
+    assert_eq!(clean.status, SummaryStatus::Clean);
+
+    let legacy = &rows[1];
+    assert_eq!(legacy.warnings, vec!["deep-nesting"]);
+    assert_eq!(legacy.status, SummaryStatus::Violation);
+
+    let server = &rows[2];
+    assert_eq!(server.exported_symbols, Some(2));
+    assert_eq!(server.max_complexity, Some(14.0));
+    assert_eq!(
+        server.warnings,
+        vec!["high-cyclomatic-complexity", "long-function x2"]
+    );
+    assert_eq!(server.status, SummaryStatus::Warning);
+
+    let gated = build_file_summary(&results, Some(10));
+    assert_eq!(gated[2].status, SummaryStatus::Violation);
+    assert_eq!(
+        gated[2].warnings.last().map(String::as_str),
+        Some("max-function-complexity>10")
+    );
+}
+
+#[test]
+fn test_render_file_summary_aligns_columns_and_omits_header() {
+    let temp_dir = tempdir().unwrap();
+    let rows = build_file_summary(&summary_analysis_results(temp_dir.path()), None);
+    let mut options = SummaryOptions {
+        header: true,
+        color: false,
+        max_function_complexity: None,
+    };
+
+    let table = render_file_summary(&rows, &options);
+    let lines: Vec<&str> = table.lines().collect();
+    assert_eq!(lines.len(), 4);
+    assert!(lines[0].starts_with("FILE"));
+    assert_eq!(
+        lines[0],
+        format!(
+            "{:<13}  {:>7}  {:>6}  WARNINGS",
+            "FILE", "EXPORTS", "MAX CC"
+        )
+    );
+    assert_eq!(
+        lines[3],
+        format!(
+            "{:<13}  {:>7}  {:>6}  high-cyclomatic-complexity, long-function x2",
+            "pkg/server.go", 2, 14
+        )
+    );
+    assert!(
+        !table.contains('\u{1b}'),
+        "plain output must not contain ANSI escapes"
+    );
+
+    options.header = false;
+    let body = render_file_summary(&rows, &options);
+    assert_eq!(body.lines().count(), 3);
+    assert!(body.starts_with("pkg/clean.go"));
+
+    options.color = true;
+    assert!(render_file_summary(&rows, &options).contains('\u{1b}'));
+}
+
+const FILTER_DOC: &str = r#"{
+  "summary": {"files": 3, "code_health": 0.8, "code_x": "a,}\"b", "avg": 1.5},
+  "refactoring_candidates": [
+    {"name": "a", "score": 1, "issues": [{"code": "X", "n": 1}]},
+    {"name": "b", "score": 2, "issues": []},
+    7
+  ],
+  "passes": {"complexity": {"enabled": true, "n": [1, 2]}, "lsh": {"enabled": false}},
+  "warnings": ["w1", "w2"],
+  "empty": {}
+}"#;
+
+fn filter_json(filter: &str, json: &str) -> String {
+    use std::io::Write;
+
+    let mut writer = FieldFilterWriter::new(Vec::new(), filter.parse().unwrap());
+    // Small chunks exercise keys and strings split across writes
+    for chunk in json.as_bytes().chunks(3) {
+        writer.write_all(chunk).unwrap();
+    }
+    String::from_utf8(writer.finish().unwrap()).unwrap()
+}
+
+#[test]
+fn field_filter_keeps_selected_paths() {
+    assert_eq!(
+        filter_json("fields=summary.files", FILTER_DOC),
+        r#"{"summary":{"files":3}}"#
+    );
+    assert_eq!(
+        filter_json("fields=refactoring_candidates.name", FILTER_DOC),
+        r#"{"refactoring_candidates":[{"name":"a"},{"name":"b"}]}"#
+    );
+    assert_eq!(
+        filter_json("fields=refactoring_candidates.issues.code", FILTER_DOC),
+        r#"{"refactoring_candidates":[{"issues":[{"code":"X"}]},{"issues":[]}]}"#
+    );
+    assert_eq!(filter_json("fields=empty", FILTER_DOC), r#"{"empty":{}}"#);
+    assert_eq!(filter_json("fields=nothing", FILTER_DOC), "{}");
+    assert_eq!(
+        filter_json("fields=summary.files.deep", FILTER_DOC),
+        r#"{"summary":{}}"#
+    );
+}
+
+#[test]
+fn field_filter_supports_wildcards() {
+    assert_eq!(
+        filter_json("fields=summary.code_*", FILTER_DOC),
+        r#"{"summary":{"code_health":0.8,"code_x":"a,}\"b"}}"#
+    );
+    assert_eq!(
+        filter_json("fields=passes.*.enabled,warnings", FILTER_DOC),
+        r#"{"passes":{"complexity":{"enabled":true},"lsh":{"enabled":false}},"warnings":["w1","w2"]}"#
+    );
+    assert_eq!(
+        filter_json("fields=passes.*", FILTER_DOC),
+        r#"{"passes":{"complexity":{"enabled":true,"n":[1,2]},"lsh":{"enabled":false}}}"#
+    );
+}
+
+#[test]
+fn field_filter_handles_non_object_roots() {
+    assert_eq!(
+        filter_json("fields=name", r#"[{"name":1,"x":2}, 3]"#),
+        r#"[{"name":1}]"#
+    );
+    assert_eq!(filter_json("fields=x", "42"), "42");
+}
+
+#[test]
+fn field_filter_rejects_malformed_input() {
+    use std::io::Write;
+
+    assert!("summary".parse::<FieldFilter>().is_err());
+    assert!("fields=a..b".parse::<FieldFilter>().is_err());
+    assert!("fields=".parse::<FieldFilter>().is_err());
+
+    let mut writer = FieldFilterWriter::new(Vec::new(), "fields=a".parse().unwrap());
+    writer.write_all(br#"{"a": [1"#).unwrap();
+    assert!(writer.finish().is_err());
+}
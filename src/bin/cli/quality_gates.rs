@@ -8,6 +8,7 @@ use owo_colors::OwoColorize;
 use valknut_rs::api::results::{AnalysisResults, RefactoringCandidate};
 use valknut_rs::core::pipeline::{QualityGateConfig, QualityGateResult, QualityGateViolation};
 use valknut_rs::core::scoring::Priority;
+use valknut_rs::detectors::complexity::ComplexityReport;
 
 use crate::cli::args::{AnalyzeArgs, QualityGateArgs};
 
@@ -416,14 +417,91 @@ pub fn evaluate_quality_gates_if_enabled(
     args: &AnalyzeArgs,
     quiet_mode: bool,
 ) -> anyhow::Result<Option<QualityGateResult>> {
-    if !args.quality_gate.quality_gate && !args.quality_gate.fail_on_issues {
+    let max_function_complexity = args.quality_gate.max_function_complexity;
+    let min_health_score = args.health.min_health_score;
+    if !args.quality_gate.quality_gate
+        && !args.quality_gate.fail_on_issues
+        && max_function_complexity.is_none()
+        && min_health_score.is_none()
+    {
         return Ok(None);
     }
     let quality_config = build_quality_gate_config(args);
-    let gate_result = evaluate_quality_gates(result, &quality_config, !quiet_mode)?;
+    let mut gate_result = evaluate_quality_gates(result, &quality_config, !quiet_mode)?;
+    if let Some(max) = max_function_complexity {
+        check_function_complexity_violations(&mut gate_result.violations, result, max);
+        gate_result.passed = gate_result.violations.is_empty();
+    }
+    if let Some(min) = min_health_score {
+        check_project_health_violation(&mut gate_result.violations, result, min);
+        gate_result.passed = gate_result.violations.is_empty();
+    }
     Ok(Some(gate_result))
 }
 
+/// Add a violation when the project health score is below `min`.
+pub fn check_project_health_violation(
+    violations: &mut Vec<QualityGateViolation>,
+    result: &AnalysisResults,
+    min: f64,
+) {
+    let Some(health) = result.project_health.as_ref() else {
+        return;
+    };
+    if health.score >= min {
+        return;
+    }
+    let weakest = health
+        .components
+        .iter()
+        .filter(|component| component.weight > 0.0)
+        .filter_map(|component| component.score.map(|score| (component, score)))
+        .min_by(|(_, a), (_, b)| a.total_cmp(b))
+        .map(|(component, score)| format!("; weakest: {} {:.1}", component.criterion, score))
+        .unwrap_or_default();
+    violations.push(build_violation(
+        "Project Health Score",
+        format!(
+            "Project health score {:.1} is below the minimum of {:.1}{}",
+            health.score, min, weakest
+        ),
+        health.score,
+        min,
+        severity_for_shortfall(health.score, min),
+        Vec::new(),
+        vec!["Improve the lowest-scoring components listed under project health"],
+    ));
+}
+
+/// Add a violation for every function whose cyclomatic complexity exceeds `max`.
+pub fn check_function_complexity_violations(
+    violations: &mut Vec<QualityGateViolation>,
+    result: &AnalysisResults,
+    max: u32,
+) {
+    let threshold = f64::from(max);
+    for function in
+        ComplexityReport::functions_exceeding(&result.passes.complexity.detailed_results, threshold)
+    {
+        violations.push(build_violation(
+            "Function Cyclomatic Complexity",
+            format!(
+                "{} ({}:{}) has cyclomatic complexity {:.0}, above the maximum of {}",
+                function.name,
+                function.file_path,
+                function.start_line,
+                function.cyclomatic_complexity,
+                max
+            ),
+            function.cyclomatic_complexity,
+            threshold,
+            severity_for_excess(function.cyclomatic_complexity, threshold),
+            vec![PathBuf::from(&function.file_path)],
+            vec!["Split the function or replace conditionals with polymorphism or lookups"],
+        ));
+    }
+}
+
 /// Handle quality gate result and return error if failed.
 pub fn handle_quality_gate_result(
     result: Option<QualityGateResult>,
@@ -1,14 +1,18 @@
 //! Report generation logic for various output formats.
 
+use std::collections::{BTreeMap, HashMap};
 use std::fs::File;
-use std::io::BufWriter;
-use std::path::Path;
+use std::io::{BufWriter, Write};
+use std::path::{Path, PathBuf};
 
-use valknut_rs::api::results::AnalysisResults;
+use valknut_rs::api::results::{AnalysisResults, RefactoringCandidate};
 use valknut_rs::core::config::ReportFormat;
+use valknut_rs::core::dependency::{SymbolGraph, TypeGraph};
+use valknut_rs::core::pipeline::SkippedFile;
 use valknut_rs::io::reports::ReportGenerator;
 
 use crate::cli::args::{AnalyzeArgs, OutputFormat};
+use crate::cli::output::{print_file_summary, FieldFilter, FieldFilterWriter, SummaryOptions};
 
 /// Helper to write content to a file with consistent error handling.
 pub async fn write_report(path: &Path, content: &str, format_name: &str) -> anyhow::Result<()> {
@@ -18,15 +22,33 @@ pub async fn write_report(path: &Path, content: &str, format_name: &str) -> anyh
 }
 
 /// Write JSON report directly to file (streaming, avoids building string in memory).
+///
+/// When an `--output-filter` is given the document is serialized compactly through a
+/// [`FieldFilterWriter`], so unselected fields are dropped as they stream past.
 pub fn write_json_streaming(
     path: &Path,
     result: &AnalysisResults,
     oracle_response: &Option<valknut_rs::oracle::RefactoringOracleResponse>,
+    filter: Option<&FieldFilter>,
 ) -> anyhow::Result<()> {
     let file =
         File::create(path).map_err(|e| anyhow::anyhow!("Failed to create JSON file: {}", e))?;
     let writer = BufWriter::new(file);
 
+    if let Some(filter) = filter {
+        return match oracle_response {
+            Some(oracle) => write_filtered_json(
+                writer,
+                &serde_json::json!({
+                    "oracle_refactoring_plan": oracle,
+                    "analysis_results": result
+                }),
+                filter,
+            ),
+            None => write_filtered_json(writer, result, filter),
+        };
+    }
+
     let combined = match oracle_response {
         Some(oracle) => serde_json::json!({
             "oracle_refactoring_plan": oracle,
@@ -40,6 +62,155 @@ pub fn write_json_streaming(
         .map_err(|e| anyhow::anyhow!("Failed to write JSON: {}", e))
 }
 
+/// Serialize `value` compactly through a field filter into `writer`.
+pub fn write_filtered_json<W: Write, T: serde::Serialize + ?Sized>(
+    writer: W,
+    value: &T,
+    filter: &FieldFilter,
+) -> anyhow::Result<()> {
+    let mut filtered = FieldFilterWriter::new(writer, filter.clone());
+    serde_json::to_writer(&mut filtered, value)
+        .map_err(|e| anyhow::anyhow!("Failed to write filtered JSON: {}", e))?;
+    filtered
+        .finish()
+        .and_then(|mut inner| inner.flush())
+        .map_err(|e| anyhow::anyhow!("Failed to write filtered JSON: {}", e))
+}
+
+/// Write a finished analysis as NDJSON: one `file` record per analyzed file, then a `summary`.
+///
+/// Only for callers that already hold a full [`AnalysisResults`]; each line goes straight
+/// into the buffered writer rather than through one large string. `analyze --format ndjson`
+/// does not come through here: like `--stream` it runs the `StreamingPipeline` into an
+/// `NdjsonSink`, so records are written as each file completes.
+pub fn write_ndjson_report(path: &Path, result: &AnalysisResults) -> anyhow::Result<()> {
+    let file =
+        File::create(path).map_err(|e| anyhow::anyhow!("Failed to create NDJSON file: {}", e))?;
+    let mut writer = BufWriter::new(file);
+
+    for record in ndjson_file_records(result) {
+        write_ndjson_line(&mut writer, &record)?;
+    }
+
+    let summary = serde_json::json!({
+        "type": "summary",
+        "project_root": result.project_root,
+        "summary": result.summary,
+        "statistics": result.statistics,
+        "warnings": result.warnings,
+    });
+    write_ndjson_line(&mut writer, &summary)?;
+
+    writer
+        .flush()
+        .map_err(|e| anyhow::anyhow!("Failed to flush NDJSON file: {}", e))
+}
+
+/// Serialize a single NDJSON record followed by a newline.
+fn write_ndjson_line(writer: &mut impl Write, record: &serde_json::Value) -> anyhow::Result<()> {
+    serde_json::to_writer(&mut *writer, record)
+        .map_err(|e| anyhow::anyhow!("Failed to write NDJSON record: {}", e))?;
+    writer
+        .write_all(b"\n")
+        .map_err(|e| anyhow::anyhow!("Failed to write NDJSON record: {}", e))
+}
+
+/// Build one self-contained record per file, keyed by its project-relative path.
+pub fn ndjson_file_records(result: &AnalysisResults) -> Vec<serde_json::Value> {
+    let relative = |path: &str| -> String {
+        Path::new(path)
+            .strip_prefix(&result.project_root)
+            .map(|p| p.to_string_lossy().into_owned())
+            .unwrap_or_else(|_| path.to_string())
+    };
+
+    let mut candidates_by_file: BTreeMap<String, Vec<&RefactoringCandidate>> = BTreeMap::new();
+    for candidate in &result.refactoring_candidates {
+        candidates_by_file
+            .entry(relative(&candidate.file_path))
+            .or_default()
+            .push(candidate);
+    }
+    for path in result.file_health.keys() {
+        candidates_by_file.entry(relative(path)).or_default();
+    }
+
+    let health: HashMap<String, f64> = result
+        .file_health
+        .iter()
+        .map(|(path, score)| (relative(path), *score))
+        .collect();
+    let doc_health: HashMap<String, f64> = result
+        .documentation
+        .iter()
+        .flat_map(|docs| docs.file_doc_health.iter())
+        .map(|(path, score)| (relative(path), *score))
+        .collect();
+
+    let mut records: Vec<(String, serde_json::Value)> = candidates_by_file
+        .into_iter()
+        .map(|(path, candidates)| {
+            let mut record = serde_json::json!({
+                "type": "file",
+                "status": "analyzed",
+                "health_score": health.get(&path),
+                "doc_health_score": doc_health.get(&path),
+                "refactoring_candidates": candidates,
+                "path": path,
+            });
+            if let Some(complexity) = result.file_complexity.get(&path) {
+                record["complexity"] = serde_json::json!(complexity);
+            }
+            // Generated files are only analyzed when `--include-generated` is set.
+            if let Some(generated) = result.generated_code.file(Path::new(&path)) {
+                record["generated"] = serde_json::Value::Bool(true);
+                record["generator"] = serde_json::json!(generated.generator);
+            }
+            (path, record)
+        })
+        .collect();
+
+    // Generated files excluded from the metrics are listed with their generator.
+    for generated in &result.generated_code.files {
+        let path = relative(&generated.path.to_string_lossy());
+        if records.iter().any(|(existing, _)| *existing == path) {
+            continue;
+        }
+        let record = serde_json::json!({
+            "type": "file",
+            "status": "generated",
+            "generated": true,
+            "generator": generated.generator,
+            "path": path,
+        });
+        records.push((path, record));
+    }
+
+    // Skipped files are listed too so consumers can tell the report is incomplete.
+    for skipped in &result.skipped_files {
+        let path = relative(&skipped.path.to_string_lossy());
+        let record = ndjson_skipped_record(skipped, &path);
+        records.push((path, record));
+    }
+
+    records.sort_by(|a, b| a.0.cmp(&b.0));
+    records.into_iter().map(|(_, record)| record).collect()
+}
+
+/// NDJSON record for a file that matched discovery but was not analyzed,
+/// reported under `path`.
+pub fn ndjson_skipped_record(skipped: &SkippedFile, path: &str) -> serde_json::Value {
+    serde_json::json!({
+        "type": "file",
+        "status": "skipped",
+        "reason": skipped.reason,
+        "size_bytes": skipped.size_bytes,
+        "limit_bytes": skipped.limit_bytes,
+        "language": skipped.language,
+        "path": path,
+    })
+}
+
 /// Generate JSON report content.
 pub fn generate_json_content(result: &AnalysisResults) -> anyhow::Result<String> {
     serde_json::to_string_pretty(result)
@@ -91,6 +262,12 @@ pub async fn generate_sonar_content(result: &AnalysisResults) -> anyhow::Result<
         .map_err(|e| anyhow::anyhow!("Failed to generate SonarQube report: {}", e))
 }
 
+/// Generate SARIF 2.1.0 report content.
+pub fn generate_sarif_content(result: &AnalysisResults) -> anyhow::Result<String> {
+    serde_json::to_string_pretty(&valknut_rs::io::reports::build_sarif_log(result))
+        .map_err(|e| anyhow::anyhow!("Failed to serialize SARIF report: {}", e))
+}
+
 /// Generate CSV report content.
 pub async fn generate_csv_content(result: &AnalysisResults) -> anyhow::Result<String> {
     let result_json = serde_json::to_value(result)?;
@@ -121,10 +298,15 @@ pub fn format_file_info(format: &OutputFormat) -> (&'static str, &'static str) {
     match format {
         OutputFormat::Json => ("analysis-results.json", "JSON"),
         OutputFormat::Jsonl => ("analysis-results.jsonl", "JSONL"),
+        OutputFormat::Ndjson => ("analysis-results.ndjson", "NDJSON"),
         OutputFormat::Yaml => ("analysis-results.yaml", "YAML"),
         OutputFormat::Markdown => ("team-report.md", "markdown"),
         OutputFormat::Sonar => ("sonarqube-issues.json", "SonarQube"),
+        OutputFormat::Sarif => ("valknut.sarif", "SARIF"),
+        OutputFormat::Junit => ("valknut-junit.xml", "JUnit"),
         OutputFormat::Csv => ("analysis-data.csv", "CSV"),
+        OutputFormat::Dot => ("type-graph.dot", "DOT"),
+        OutputFormat::SymbolGraph => ("symbol-graph.json", "symbol graph"),
         _ => ("analysis-results.json", "JSON"),
     }
 }
@@ -141,6 +323,8 @@ pub async fn generate_format_content(
         OutputFormat::Yaml => generate_yaml_content(result),
         OutputFormat::Markdown => generate_markdown_content(result).await,
         OutputFormat::Sonar => generate_sonar_content(result).await,
+        OutputFormat::Sarif => generate_sarif_content(result),
+        OutputFormat::Junit => Ok(valknut_rs::io::reports::build_junit_report(result)),
         OutputFormat::Csv => generate_csv_content(result).await,
         _ => generate_default_content(result, oracle_response),
     }
@@ -148,7 +332,7 @@ pub async fn generate_format_content(
 
 /// Determines whether CLI output should be suppressed for the given args.
 pub fn is_quiet(args: &AnalyzeArgs) -> bool {
-    args.quiet || args.has_machine_readable_format()
+    args.quiet || args.stream || args.has_machine_readable_format()
 }
 
 /// Generate a single report for a specific format.
@@ -157,6 +341,7 @@ async fn generate_single_report(
     result: &AnalysisResults,
     oracle_response: &Option<valknut_rs::oracle::RefactoringOracleResponse>,
     out_dir: &std::path::Path,
+    filter: Option<&FieldFilter>,
 ) -> anyhow::Result<std::path::PathBuf> {
     let path = match format {
         OutputFormat::Html => {
@@ -168,7 +353,21 @@ async fn generate_single_report(
         OutputFormat::Json => {
             let (filename, _) = format_file_info(format);
             let path = out_dir.join(filename);
-            write_json_streaming(&path, result, oracle_response)?;
+            write_json_streaming(&path, result, oracle_response, filter)?;
+            path
+        }
+        OutputFormat::Jsonl if filter.is_some() => {
+            let (filename, _) = format_file_info(format);
+            let path = out_dir.join(filename);
+            let file = File::create(path.as_path())
+                .map_err(|e| anyhow::anyhow!("Failed to create JSONL file: {}", e))?;
+            write_filtered_json(BufWriter::new(file), result, filter.unwrap())?;
+            path
+        }
+        OutputFormat::Ndjson => {
+            let (filename, _) = format_file_info(format);
+            let path = out_dir.join(filename);
+            write_ndjson_report(&path, result)?;
             path
         }
         OutputFormat::CiSummary => {
@@ -193,6 +392,62 @@ async fn generate_single_report(
     Ok(path)
 }
 
+/// Write the Graphviz type hierarchy of the analysed files.
+pub fn write_type_graph(
+    result: &AnalysisResults,
+    max_nodes: Option<usize>,
+    out_dir: &Path,
+) -> anyhow::Result<PathBuf> {
+    let root = result.project_root.as_path();
+    let mut graph = TypeGraph::from_files(root, &analysed_files(result))?;
+    if let Some(max_nodes) = max_nodes {
+        graph.prune_to_most_connected(max_nodes);
+    }
+
+    let (filename, _) = format_file_info(&OutputFormat::Dot);
+    let path = out_dir.join(filename);
+    std::fs::write(&path, graph.to_dot())
+        .map_err(|e| anyhow::anyhow!("Failed to write DOT report: {}", e))?;
+    Ok(path)
+}
+
+/// Write the symbol-level call, embedding and assignment edges of the analysed files.
+pub fn write_symbol_graph(result: &AnalysisResults, out_dir: &Path) -> anyhow::Result<PathBuf> {
+    let root = result.project_root.as_path();
+    let graph = SymbolGraph::from_files(root, &analysed_files(result))?;
+
+    let (filename, _) = format_file_info(&OutputFormat::SymbolGraph);
+    let path = out_dir.join(filename);
+    std::fs::write(&path, graph.to_json()?)
+        .map_err(|e| anyhow::anyhow!("Failed to write symbol graph: {}", e))?;
+    Ok(path)
+}
+
+/// Absolute paths of every file the analysis covered.
+pub fn analysed_files(result: &AnalysisResults) -> Vec<PathBuf> {
+    let root = result.project_root.as_path();
+    result
+        .file_health
+        .keys()
+        .map(PathBuf::from)
+        .chain(
+            result
+                .passes
+                .complexity
+                .detailed_results
+                .iter()
+                .map(|entry| PathBuf::from(&entry.file_path)),
+        )
+        .map(|path| {
+            if path.is_absolute() {
+                path
+            } else {
+                root.join(path)
+            }
+        })
+        .collect()
+}
+
 /// Generate CI summary content (concise JSON for automated systems).
 fn generate_ci_summary_content(
     result: &AnalysisResults,
@@ -238,7 +493,34 @@ pub async fn generate_reports_with_oracle(
     let mut output_files = Vec::new();
 
     for format in &formats {
-        let path = generate_single_report(format, result, oracle_response, &args.out).await?;
+        if *format == OutputFormat::Summary {
+            // Summary goes to stdout for scanning by eye or piping; no file is written
+            let options = SummaryOptions::for_stdout(
+                !args.no_header,
+                args.quality_gate.max_function_complexity,
+            );
+            print_file_summary(result, &options);
+            continue;
+        }
+        if *format == OutputFormat::Dot {
+            // Built from the analysed sources rather than the results, so it needs the args
+            let path = write_type_graph(result, args.dot_max_nodes, &args.out)?;
+            output_files.push((format.clone(), path));
+            continue;
+        }
+        if *format == OutputFormat::SymbolGraph {
+            let path = write_symbol_graph(result, &args.out)?;
+            output_files.push((format.clone(), path));
+            continue;
+        }
+        let path = generate_single_report(
+            format,
+            result,
+            oracle_response,
+            &args.out,
+            args.output_filter.as_ref(),
+        )
+        .await?;
         output_files.push((format.clone(), path));
     }
 
@@ -0,0 +1,209 @@
+//! Line diffs for showing what a command changed, or would change, in a file.
+//!
+//! [`line_diff`] compares two texts line by line with Myers' O(ND) algorithm,
+//! so small edits to large source files stay cheap. [`unified_diff`] renders
+//! the same comparison in the `diff -u` format, keeping line endings
+//! significant so that a missing final newline shows up as a change.
+
+/// Unchanged lines shown around each change of a [`unified_diff`].
+const CONTEXT_LINES: usize = 3;
+
+/// One line of a [`line_diff`].
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub enum DiffLine<'a> {
+    /// Present in both versions
+    Same(&'a str),
+    /// Only in the original
+    Removed(&'a str),
+    /// Only in the new version
+    Added(&'a str),
+}
+
+/// Line-by-line difference between `old` and `new`, in file order.
+pub fn line_diff<'a>(old: &'a str, new: &'a str) -> Vec<DiffLine<'a>> {
+    let old: Vec<&str> = old.lines().collect();
+    let new: Vec<&str> = new.lines().collect();
+    diff_lines(&old, &new)
+}
+
+/// `old` and `new` as a unified diff with the given file labels, or an empty
+/// string when they are identical.
+pub fn unified_diff(old_label: &str, new_label: &str, old: &str, new: &str) -> String {
+    let old_lines: Vec<&str> = old.split_inclusive('\n').collect();
+    let new_lines: Vec<&str> = new.split_inclusive('\n').collect();
+    let lines = diff_lines(&old_lines, &new_lines);
+    let changes: Vec<usize> = lines
+        .iter()
+        .enumerate()
+        .filter(|(_, line)| !matches!(line, DiffLine::Same(_)))
+        .map(|(index, _)| index)
+        .collect();
+    if changes.is_empty() {
+        return String::new();
+    }
+
+    // Changes closer together than twice the context share a hunk.
+    let mut hunks: Vec<(usize, usize)> = Vec::new();
+    for &index in &changes {
+        match hunks.last_mut() {
+            Some((_, last)) if index - *last <= 2 * CONTEXT_LINES => *last = index,
+            _ => hunks.push((index, index)),
+        }
+    }
+
+    let mut out = format!("--- {old_label}\n+++ {new_label}\n");
+    for (first, last) in hunks {
+        let start = first.saturating_sub(CONTEXT_LINES);
+        let end = (last + CONTEXT_LINES + 1).min(lines.len());
+        let hunk = &lines[start..end];
+        let old_before = lines[..start]
+            .iter()
+            .filter(|line| !matches!(line, DiffLine::Added(_)))
+            .count();
+        let new_before = lines[..start]
+            .iter()
+            .filter(|line| !matches!(line, DiffLine::Removed(_)))
+            .count();
+        let old_count = hunk
+            .iter()
+            .filter(|line| !matches!(line, DiffLine::Added(_)))
+            .count();
+        let new_count = hunk
+            .iter()
+            .filter(|line| !matches!(line, DiffLine::Removed(_)))
+            .count();
+        out.push_str(&format!(
+            "@@ -{} +{} @@\n",
+            hunk_range(old_before, old_count),
+            hunk_range(new_before, new_count)
+        ));
+        for line in hunk {
+            let (prefix, text) = match line {
+                DiffLine::Same(text) => (' ', text),
+                DiffLine::Removed(text) => ('-', text),
+                DiffLine::Added(text) => ('+', text),
+            };
+            out.push(prefix);
+            out.push_str(text);
+            if !text.ends_with('\n') {
+                out.push_str("\n\\ No newline at end of file\n");
+            }
+        }
+    }
+    out
+}
+
+/// `start,count` of a hunk header, where `before` lines precede the hunk.
+/// An empty hunk is positioned after the line it follows, as `diff -u` does.
+fn hunk_range(before: usize, count: usize) -> String {
+    let start = if count == 0 { before } else { before + 1 };
+    format!("{start},{count}")
+}
+
+/// Shortest edit script turning `old` into `new` (Myers, 1986).
+fn diff_lines<'a>(old: &[&'a str], new: &[&'a str]) -> Vec<DiffLine<'a>> {
+    let (n, m) = (old.len() as isize, new.len() as isize);
+    let max = (n + m) as usize;
+    let offset = max as isize + 1;
+    let mut v = vec![0isize; 2 * max + 3];
+    // trace[d] holds diagonals -(d + 1)..=(d + 1) of `v` before step `d`.
+    let mut trace: Vec<Vec<isize>> = Vec::new();
+
+    'search: for d in 0..=max as isize {
+        let low = (offset - d - 1) as usize;
+        let high = (offset + d + 1) as usize;
+        trace.push(v[low..=high].to_vec());
+        for k in (-d..=d).step_by(2) {
+            let at = |k: isize| (offset + k) as usize;
+            let mut x = if k == -d || (k != d && v[at(k - 1)] < v[at(k + 1)]) {
+                v[at(k + 1)]
+            } else {
+                v[at(k - 1)] + 1
+            };
+            let mut y = x - k;
+            while x < n && y < m && old[x as usize] == new[y as usize] {
+                x += 1;
+                y += 1;
+            }
+            v[at(k)] = x;
+            if x >= n && y >= m {
+                break 'search;
+            }
+        }
+    }
+
+    let mut lines = Vec::with_capacity(old.len().max(new.len()));
+    let (mut x, mut y) = (n, m);
+    for (d, v) in trace.iter().enumerate().rev() {
+        let d = d as isize;
+        let at = |k: isize| v[(k + d + 1) as usize];
+        let k = x - y;
+        let prev_k = if k == -d || (k != d && at(k - 1) < at(k + 1)) {
+            k + 1
+        } else {
+            k - 1
+        };
+        let prev_x = at(prev_k);
+        let prev_y = prev_x - prev_k;
+        while x > prev_x && y > prev_y {
+            lines.push(DiffLine::Same(old[(x - 1) as usize]));
+            x -= 1;
+            y -= 1;
+        }
+        if d > 0 {
+            if x == prev_x {
+                lines.push(DiffLine::Added(new[(y - 1) as usize]));
+            } else {
+                lines.push(DiffLine::Removed(old[(x - 1) as usize]));
+            }
+        }
+        x = prev_x;
+        y = prev_y;
+    }
+    lines.reverse();
+    lines
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn diff_marks_removed_and_added_lines_in_order() {
+        assert_eq!(
+            line_diff("a\nb\nc\n", "a\nc\nd\n"),
+            vec![
+                DiffLine::Same("a"),
+                DiffLine::Removed("b"),
+                DiffLine::Same("c"),
+                DiffLine::Added("d"),
+            ]
+        );
+        assert_eq!(line_diff("", "x\n"), vec![DiffLine::Added("x")]);
+        assert_eq!(line_diff("x\n", ""), vec![DiffLine::Removed("x")]);
+        assert!(line_diff("", "").is_empty());
+    }
+
+    #[test]
+    fn unified_diff_groups_changes_into_hunks_with_context() {
+        let old: String = (1..=20).map(|i| format!("line {i}\n")).collect();
+        let new = old
+            .replace("line 2\n", "line two\n")
+            .replace("line 18\n", "");
+        assert_eq!(
+            unified_diff("a/x.rs", "b/x.rs", &old, &new),
+            "--- a/x.rs\n+++ b/x.rs\n\
+             @@ -1,5 +1,5 @@\n line 1\n-line 2\n+line two\n line 3\n line 4\n line 5\n\
+             @@ -15,6 +15,5 @@\n line 15\n line 16\n line 17\n-line 18\n line 19\n line 20\n"
+        );
+        assert_eq!(unified_diff("a", "b", &old, &old), "");
+    }
+
+    #[test]
+    fn unified_diff_shows_a_missing_final_newline() {
+        assert_eq!(
+            unified_diff("a", "b", "x\ny", "x\ny\n"),
+            "--- a\n+++ b\n@@ -1,2 +1,2 @@\n x\n-y\n\\ No newline at end of file\n+y\n"
+        );
+    }
+}
@@ -0,0 +1,13 @@
+//! gRPC transport for valknut analysis.
+//!
+//! The `valknut.v1` service is defined in `proto/valknut/v1/valknut.proto` and
+//! compiled by the build script. [`server`] implements it on top of the
+//! library; `valknut grpc-client` drives it from the command line.
+
+pub mod server;
+
+/// Generated `valknut.v1` messages, server and client.
+pub mod proto {
+    #![allow(clippy::all, missing_docs)]
+    tonic::include_proto!("valknut.v1");
+}
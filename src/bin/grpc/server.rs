@@ -0,0 +1,318 @@
+//! `valknut.v1.ValknutService` implementation.
+//!
+//! CPU-bound work (parsing, analysis, graph construction) runs on the shared
+//! [`WorkerPool`] so slow requests never stall the HTTP/2 connection tasks.
+//! Callers beyond the pool's cap wait for a slot, which together with HTTP/2
+//! flow control pushes back on busy clients instead of queueing unbounded work
+//! inside the server.
+
+use std::future::Future;
+use std::net::{SocketAddr, ToSocketAddrs};
+use std::path::PathBuf;
+
+use tokio::runtime::Handle;
+use tonic::transport::{Identity, Server, ServerTlsConfig};
+use tonic::{Request, Response, Status};
+use tracing::info;
+
+use valknut_rs::api::engine::ValknutEngine;
+use valknut_rs::api::results::{AnalysisResults, RefactoringCandidate};
+use valknut_rs::core::config::ValknutConfig;
+use valknut_rs::core::dependency::{PackageGraph, PackageOrigin};
+use valknut_rs::core::errors::ValknutError;
+use valknut_rs::core::scoring::Priority;
+use valknut_rs::core::symbol_search::{
+    search_symbols, SymbolMatch, SymbolQuery, DEFAULT_PAGE_SIZE,
+};
+
+use super::proto;
+use super::proto::valknut_service_server::{ValknutService, ValknutServiceServer};
+use crate::worker_pool::{WorkerError, WorkerPool};
+
+/// PEM-encoded certificate chain and private key for serving over TLS.
+#[derive(Debug, Clone)]
+pub struct TlsFiles {
+    /// Certificate chain file.
+    pub cert: PathBuf,
+    /// Private key file.
+    pub key: PathBuf,
+}
+
+/// Handler for the `valknut.v1.ValknutService` RPCs.
+pub struct GrpcService {
+    /// Configuration used for every `AnalyzeFile` request.
+    config: ValknutConfig,
+    /// Limits how many requests are processed concurrently.
+    pool: WorkerPool,
+}
+
+/// Construction and request helpers for [`GrpcService`].
+impl GrpcService {
+    /// Create a service running its requests on `pool`.
+    pub fn new(config: ValknutConfig, pool: WorkerPool) -> Self {
+        Self { config, pool }
+    }
+
+    /// Wait for a processing slot, then run `work` on the blocking pool.
+    async fn run_blocking<T, F>(&self, work: F) -> Result<T, Status>
+    where
+        T: Send + 'static,
+        F: FnOnce() -> Result<T, ValknutError> + Send + 'static,
+    {
+        match self.pool.run(work).await {
+            Ok(result) => result.map_err(to_status),
+            Err(e @ WorkerError::ShuttingDown) => Err(Status::unavailable(e.to_string())),
+            Err(e) => Err(Status::internal(e.to_string())),
+        }
+    }
+}
+
+/// [`ValknutService`] implementation for [`GrpcService`].
+#[tonic::async_trait]
+impl ValknutService for GrpcService {
+    /// Analyze one file with the server's configuration.
+    async fn analyze_file(
+        &self,
+        request: Request<proto::AnalyzeFileRequest>,
+    ) -> Result<Response<proto::AnalyzeFileResponse>, Status> {
+        let path = request.into_inner().path;
+        let file = existing_path(&path)?;
+        if !file.is_file() {
+            return Err(Status::invalid_argument(format!("Not a file: {path}")));
+        }
+
+        info!("gRPC AnalyzeFile {}", file.display());
+        let config = self.config.clone();
+        let handle = Handle::current();
+        let results = self
+            .run_blocking(move || handle.block_on(analyze_file(config, file)))
+            .await?;
+        Ok(Response::new(analyze_file_response(path, &results)))
+    }
+
+    /// Regex search over symbol names.
+    async fn search_symbols(
+        &self,
+        request: Request<proto::SearchSymbolsRequest>,
+    ) -> Result<Response<proto::SearchSymbolsResponse>, Status> {
+        let request = request.into_inner();
+        let root = existing_path(&request.path)?;
+        let query = SymbolQuery {
+            pattern: request.pattern,
+            case_insensitive: request.case_insensitive,
+        };
+        let cursor = (!request.cursor.is_empty()).then_some(request.cursor);
+        let limit = match request.limit {
+            0 => DEFAULT_PAGE_SIZE,
+            limit => limit as usize,
+        };
+
+        info!(
+            "gRPC SearchSymbols /{}/ in {}",
+            query.pattern,
+            root.display()
+        );
+        let page = self
+            .run_blocking(move || search_symbols(&root, &query, cursor.as_deref(), limit))
+            .await?;
+        Ok(Response::new(proto::SearchSymbolsResponse {
+            matches: page.matches.into_iter().map(symbol).collect(),
+            total: page.total as u64,
+            next_cursor: page.next_cursor.unwrap_or_default(),
+        }))
+    }
+
+    /// Package import graph of a Go or Java project.
+    async fn get_dependency_graph(
+        &self,
+        request: Request<proto::GetDependencyGraphRequest>,
+    ) -> Result<Response<proto::GetDependencyGraphResponse>, Status> {
+        let root = existing_path(&request.into_inner().path)?;
+
+        info!("gRPC GetDependencyGraph {}", root.display());
+        let graph = self
+            .run_blocking(move || PackageGraph::from_projects(&[root]))
+            .await?;
+        Ok(Response::new(dependency_graph_response(&graph)))
+    }
+}
+
+/// Serve `service` on `addr` until `shutdown` resolves.
+pub async fn serve(
+    addr: SocketAddr,
+    service: GrpcService,
+    tls: Option<&TlsFiles>,
+    shutdown: impl Future<Output = ()>,
+) -> anyhow::Result<()> {
+    let mut builder = Server::builder();
+    if let Some(tls) = tls {
+        let read = |path: &PathBuf| {
+            std::fs::read(path)
+                .map_err(|e| anyhow::anyhow!("Cannot read {}: {}", path.display(), e))
+        };
+        let identity = Identity::from_pem(read(&tls.cert)?, read(&tls.key)?);
+        builder = builder.tls_config(ServerTlsConfig::new().identity(identity))?;
+    }
+
+    builder
+        .add_service(ValknutServiceServer::new(service))
+        .serve_with_shutdown(addr, shutdown)
+        .await?;
+    Ok(())
+}
+
+/// Parse a listen address; a bare `:port` listens on all interfaces.
+pub fn parse_listen_addr(value: &str) -> anyhow::Result<SocketAddr> {
+    let value = value.trim();
+    let address = match value.strip_prefix(':') {
+        Some(port) => format!("0.0.0.0:{port}"),
+        None => value.to_string(),
+    };
+    address
+        .to_socket_addrs()
+        .map_err(|e| anyhow::anyhow!("Invalid listen address '{}': {}", value, e))?
+        .next()
+        .ok_or_else(|| anyhow::anyhow!("Invalid listen address '{}'", value))
+}
+
+/// Run the analysis engine over a single file.
+async fn analyze_file(
+    config: ValknutConfig,
+    file: PathBuf,
+) -> Result<AnalysisResults, ValknutError> {
+    let mut engine = ValknutEngine::new_from_valknut_config(config).await?;
+    engine.analyze_files(&[file]).await
+}
+
+/// Resolve a request path, rejecting empty and missing paths.
+fn existing_path(path: &str) -> Result<PathBuf, Status> {
+    if path.is_empty() {
+        return Err(Status::invalid_argument("path is required"));
+    }
+    let path = PathBuf::from(path);
+    if !path.exists() {
+        return Err(Status::not_found(format!(
+            "Path does not exist: {}",
+            path.display()
+        )));
+    }
+    Ok(path)
+}
+
+/// Map library errors onto gRPC status codes.
+fn to_status(error: ValknutError) -> Status {
+    match error {
+        ValknutError::Validation { .. } => Status::invalid_argument(error.to_string()),
+        ValknutError::Unsupported { .. } | ValknutError::UnsupportedLanguage { .. } => {
+            Status::unimplemented(error.to_string())
+        }
+        _ => Status::internal(error.to_string()),
+    }
+}
+
+/// Build the `AnalyzeFile` response from single-file analysis results.
+fn analyze_file_response(path: String, results: &AnalysisResults) -> proto::AnalyzeFileResponse {
+    let mut candidates: Vec<&RefactoringCandidate> =
+        results.refactoring_candidates.iter().collect();
+    candidates.sort_by(|a, b| b.score.total_cmp(&a.score));
+
+    proto::AnalyzeFileResponse {
+        path,
+        // A single-file run scores at most that one file.
+        health_score: results.file_health.values().next().copied(),
+        candidates: candidates.into_iter().map(refactoring_candidate).collect(),
+        warnings: results.warnings.clone(),
+    }
+}
+
+/// Convert a refactoring candidate to its wire form.
+fn refactoring_candidate(candidate: &RefactoringCandidate) -> proto::RefactoringCandidate {
+    let (start_line, end_line) = candidate.line_range.unwrap_or((0, 0));
+    proto::RefactoringCandidate {
+        name: candidate.name.clone(),
+        start_line: start_line as u32,
+        end_line: end_line as u32,
+        priority: priority_name(candidate.priority).to_string(),
+        score: candidate.score,
+        confidence: candidate.confidence,
+        issues: candidate
+            .issues
+            .iter()
+            .map(|issue| proto::Issue {
+                code: issue.code.clone(),
+                category: issue.category.clone(),
+                severity: issue.severity,
+            })
+            .collect(),
+    }
+}
+
+/// Lower-case name of a priority level.
+fn priority_name(priority: Priority) -> &'static str {
+    match priority {
+        Priority::None => "none",
+        Priority::Low => "low",
+        Priority::Medium => "medium",
+        Priority::High => "high",
+        Priority::Critical => "critical",
+    }
+}
+
+/// Convert a symbol search match to its wire form.
+fn symbol(found: SymbolMatch) -> proto::Symbol {
+    proto::Symbol {
+        name: found.name,
+        kind: found.kind,
+        file_path: found.file_path.to_string_lossy().into_owned(),
+        start_line: found.start_line as u32,
+        end_line: found.end_line as u32,
+        signature: found.signature,
+    }
+}
+
+/// Build the `GetDependencyGraph` response.
+fn dependency_graph_response(graph: &PackageGraph) -> proto::GetDependencyGraphResponse {
+    proto::GetDependencyGraphResponse {
+        module_path: graph.module_path.clone(),
+        packages: graph
+            .packages
+            .values()
+            .map(|package| proto::Package {
+                import_path: package.import_path.clone(),
+                origin: package_origin(package.origin) as i32,
+                files: package.files as u32,
+                module: package.module.clone(),
+            })
+            .collect(),
+        imports: graph
+            .imports
+            .iter()
+            .flat_map(|(importer, imported)| {
+                imported.iter().map(move |imported| proto::PackageImport {
+                    importer: importer.clone(),
+                    imported: imported.clone(),
+                })
+            })
+            .collect(),
+        cycles: graph
+            .cycles
+            .iter()
+            .map(|cycle| proto::PackageCycle {
+                packages: cycle.clone(),
+            })
+            .collect(),
+    }
+}
+
+/// Convert a package origin to its wire enum.
+fn package_origin(origin: PackageOrigin) -> proto::PackageOrigin {
+    match origin {
+        PackageOrigin::Internal => proto::PackageOrigin::Internal,
+        PackageOrigin::Stdlib => proto::PackageOrigin::Stdlib,
+        PackageOrigin::ThirdParty => proto::PackageOrigin::ThirdParty,
+    }
+}
+
+#[cfg(test)]
+#[path = "server_tests.rs"]
+mod tests;
@@ -0,0 +1,156 @@
+use super::*;
+use std::fs;
+use tempfile::tempdir;
+use tonic::Code;
+
+fn service() -> GrpcService {
+    GrpcService::new(ValknutConfig::default(), WorkerPool::new(2))
+}
+
+#[test]
+fn parse_listen_addr_accepts_bare_port_and_host_port() {
+    assert_eq!(
+        parse_listen_addr(":50051").unwrap(),
+        "0.0.0.0:50051".parse().unwrap()
+    );
+    assert_eq!(
+        parse_listen_addr("127.0.0.1:9000").unwrap(),
+        "127.0.0.1:9000".parse().unwrap()
+    );
+    assert!(parse_listen_addr("not an address").is_err());
+    assert!(parse_listen_addr(":not-a-port").is_err());
+}
+
+#[tokio::test]
+async fn search_symbols_pages_with_cursor() {
+    let tmp = tempdir().unwrap();
+    fs::write(
+        tmp.path().join("handlers.py"),
+        "def login_handler():\n    pass\n\ndef logout_handler():\n    pass\n\ndef helper():\n    pass\n",
+    )
+    .unwrap();
+
+    let request = |cursor: String| {
+        Request::new(proto::SearchSymbolsRequest {
+            path: tmp.path().to_string_lossy().into_owned(),
+            pattern: "_handler$".to_string(),
+            case_insensitive: false,
+            cursor,
+            limit: 1,
+        })
+    };
+    let first = service()
+        .search_symbols(request(String::new()))
+        .await
+        .expect("first page")
+        .into_inner();
+    assert_eq!(first.total, 2);
+    assert_eq!(first.matches[0].name, "login_handler");
+    assert_eq!(first.matches[0].file_path, "handlers.py");
+    assert!(!first.next_cursor.is_empty());
+
+    let second = service()
+        .search_symbols(request(first.next_cursor))
+        .await
+        .expect("second page")
+        .into_inner();
+    assert_eq!(second.matches[0].name, "logout_handler");
+    assert!(second.next_cursor.is_empty());
+}
+
+#[tokio::test]
+async fn search_symbols_rejects_invalid_pattern() {
+    let tmp = tempdir().unwrap();
+    let status = service()
+        .search_symbols(Request::new(proto::SearchSymbolsRequest {
+            path: tmp.path().to_string_lossy().into_owned(),
+            pattern: "(".to_string(),
+            ..Default::default()
+        }))
+        .await
+        .expect_err("invalid pattern should fail");
+    assert_eq!(status.code(), Code::InvalidArgument);
+}
+
+#[tokio::test]
+async fn get_dependency_graph_reports_packages_and_imports() {
+    let tmp = tempdir().unwrap();
+    let root = tmp.path();
+    fs::write(root.join("go.mod"), "module example.com/app\n").unwrap();
+    fs::write(
+        root.join("main.go"),
+        "package main\n\nimport \"example.com/app/api\"\n",
+    )
+    .unwrap();
+    fs::create_dir_all(root.join("api")).unwrap();
+    fs::write(root.join("api/api.go"), "package api\n\nimport \"fmt\"\n").unwrap();
+
+    let graph = service()
+        .get_dependency_graph(Request::new(proto::GetDependencyGraphRequest {
+            path: root.to_string_lossy().into_owned(),
+        }))
+        .await
+        .expect("dependency graph")
+        .into_inner();
+
+    assert_eq!(graph.module_path.as_deref(), Some("example.com/app"));
+    let origin = |import_path: &str| {
+        graph
+            .packages
+            .iter()
+            .find(|package| package.import_path == import_path)
+            .map(|package| package.origin())
+    };
+    assert_eq!(
+        origin("example.com/app/api"),
+        Some(proto::PackageOrigin::Internal)
+    );
+    assert_eq!(origin("fmt"), Some(proto::PackageOrigin::Stdlib));
+    assert!(graph.imports.iter().any(|edge| {
+        edge.importer == "example.com/app" && edge.imported == "example.com/app/api"
+    }));
+    assert!(graph.cycles.is_empty());
+}
+
+#[tokio::test]
+async fn analyze_file_rejects_missing_and_directory_paths() {
+    let status = service()
+        .analyze_file(Request::new(proto::AnalyzeFileRequest {
+            path: "/definitely/missing/file.rs".to_string(),
+        }))
+        .await
+        .expect_err("missing file should fail");
+    assert_eq!(status.code(), Code::NotFound);
+
+    let tmp = tempdir().unwrap();
+    let status = service()
+        .analyze_file(Request::new(proto::AnalyzeFileRequest {
+            path: tmp.path().to_string_lossy().into_owned(),
+        }))
+        .await
+        .expect_err("directories should fail");
+    assert_eq!(status.code(), Code::InvalidArgument);
+}
+
+#[test]
+fn refactoring_candidate_maps_priority_and_missing_lines() {
+    let candidate = RefactoringCandidate {
+        entity_id: "src/lib.rs:function:parse".to_string(),
+        name: "parse".to_string(),
+        file_path: "src/lib.rs".to_string(),
+        line_range: None,
+        priority: Priority::High,
+        score: 0.8,
+        confidence: 0.9,
+        issues: Vec::new(),
+        suggestions: Vec::new(),
+        issue_count: 0,
+        suggestion_count: 0,
+        coverage_percentage: None,
+        context: Default::default(),
+    };
+
+    let wire = refactoring_candidate(&candidate);
+    assert_eq!(wire.priority, "high");
+    assert_eq!((wire.start_line, wire.end_line), (0, 0));
+}
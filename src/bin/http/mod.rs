@@ -0,0 +1,6 @@
+//! HTTP transport for valknut analysis.
+//!
+//! [`server`] exposes the same operations as the gRPC service as a JSON REST
+//! API for clients without a gRPC stack; `valknut serve --http <addr>` runs it.
+
+pub mod server;
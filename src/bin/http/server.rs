@@ -0,0 +1,404 @@
+//! JSON REST API over the analysis library.
+//!
+//! | Method | Path | Purpose |
+//! |--------|------|---------|
+//! | `POST` | `/analyze` | Analyze a file on disk (`{"path": ...}`) or posted source (`{"content": ..., "path": ...}`) |
+//! | `GET` | `/symbols` | Symbols of a package directory (`?package=`, optional `path`, `pattern`, `cursor`, `limit`) |
+//! | `GET` | `/graph` | Package import graph of a Go or Java project (`?path=`) |
+//! | `GET` | `/health` | Liveness probe returning the server version |
+//!
+//! Requests run on the shared [`WorkerPool`], so the HTTP and gRPC servers of
+//! one process never analyze more than its cap of requests at once. An
+//! optional [`RateLimiter`] rejects requests beyond a global rate with
+//! `429 Too Many Requests`; `/health` is exempt so probes keep working under
+//! load, and never touches the pool. Errors are returned as `{"error": ...}`.
+
+use std::future::Future;
+use std::net::SocketAddr;
+use std::path::{Path, PathBuf};
+use std::sync::{Arc, Mutex, PoisonError};
+use std::time::Instant;
+
+use axum::extract::rejection::JsonRejection;
+use axum::extract::{Query, Request, State};
+use axum::http::{header, StatusCode};
+use axum::middleware::{self, Next};
+use axum::response::{IntoResponse, Response};
+use axum::routing::{get, post};
+use axum::{Json, Router};
+use serde::Deserialize;
+use serde_json::{json, Value};
+use tokio::net::TcpListener;
+use tokio::runtime::Handle;
+use tracing::info;
+
+use valknut_rs::api::engine::ValknutEngine;
+use valknut_rs::api::results::{AnalysisResults, RefactoringCandidate};
+use valknut_rs::core::config::ValknutConfig;
+use valknut_rs::core::dependency::PackageGraph;
+use valknut_rs::core::errors::ValknutError;
+use valknut_rs::core::symbol_search::{search_symbols, SymbolPage, SymbolQuery, DEFAULT_PAGE_SIZE};
+use valknut_rs::io::stdin::StdinSource;
+
+use crate::worker_pool::{WorkerError, WorkerPool};
+
+/// Pattern matching every symbol name, used when `/symbols` gets none.
+const ALL_SYMBOLS: &str = ".";
+
+/// Handler state for the REST API.
+pub struct HttpService {
+    /// Configuration used for every `/analyze` request.
+    config: ValknutConfig,
+    /// Runs the CPU-bound part of each request.
+    pool: WorkerPool,
+    /// Global request rate limit, if any.
+    limiter: Option<RateLimiter>,
+}
+
+/// Construction and request helpers for [`HttpService`].
+impl HttpService {
+    /// Create a service running its requests on `pool`, admitting at most
+    /// `rate_limit` requests per second when set.
+    pub fn new(config: ValknutConfig, pool: WorkerPool, rate_limit: Option<u32>) -> Self {
+        Self {
+            config,
+            pool,
+            limiter: rate_limit.map(RateLimiter::new),
+        }
+    }
+
+    /// Wait for a processing slot, then run `work` on the blocking pool.
+    async fn run_blocking<T, F>(&self, work: F) -> Result<T, ApiError>
+    where
+        T: Send + 'static,
+        F: FnOnce() -> Result<T, ValknutError> + Send + 'static,
+    {
+        Ok(self.pool.run(work).await??)
+    }
+}
+
+/// Token bucket admitting a steady number of requests per second, with bursts
+/// of up to one second's worth.
+#[derive(Debug)]
+pub struct RateLimiter {
+    per_second: f64,
+    bucket: Mutex<Bucket>,
+}
+
+/// Tokens left and when they were last topped up.
+#[derive(Debug)]
+struct Bucket {
+    tokens: f64,
+    updated: Instant,
+}
+
+/// Admission methods for [`RateLimiter`].
+impl RateLimiter {
+    /// Create a limiter admitting `per_second` requests per second (at least one).
+    pub fn new(per_second: u32) -> Self {
+        let per_second = f64::from(per_second.max(1));
+        Self {
+            per_second,
+            bucket: Mutex::new(Bucket {
+                tokens: per_second,
+                updated: Instant::now(),
+            }),
+        }
+    }
+
+    /// Take a token if one is available.
+    pub fn try_acquire(&self) -> bool {
+        self.try_acquire_at(Instant::now())
+    }
+
+    /// [`try_acquire`](Self::try_acquire) at a given instant.
+    fn try_acquire_at(&self, now: Instant) -> bool {
+        let mut bucket = self.bucket.lock().unwrap_or_else(PoisonError::into_inner);
+        let elapsed = now.saturating_duration_since(bucket.updated).as_secs_f64();
+        bucket.tokens = (bucket.tokens + elapsed * self.per_second).min(self.per_second);
+        bucket.updated = now;
+        if bucket.tokens >= 1.0 {
+            bucket.tokens -= 1.0;
+            true
+        } else {
+            false
+        }
+    }
+}
+
+/// An error response: a status code and a `{"error": ...}` body.
+#[derive(Debug)]
+pub struct ApiError {
+    status: StatusCode,
+    message: String,
+}
+
+/// Constructors for [`ApiError`].
+impl ApiError {
+    /// Build an error with `status`.
+    fn new(status: StatusCode, message: impl Into<String>) -> Self {
+        Self {
+            status,
+            message: message.into(),
+        }
+    }
+}
+
+/// Renders the error as JSON.
+impl IntoResponse for ApiError {
+    fn into_response(self) -> Response {
+        (self.status, Json(json!({ "error": self.message }))).into_response()
+    }
+}
+
+/// Maps library errors onto HTTP status codes.
+impl From<ValknutError> for ApiError {
+    fn from(error: ValknutError) -> Self {
+        let status = match error {
+            ValknutError::Validation { .. } => StatusCode::BAD_REQUEST,
+            ValknutError::Unsupported { .. } | ValknutError::UnsupportedLanguage { .. } => {
+                StatusCode::UNPROCESSABLE_ENTITY
+            }
+            _ => StatusCode::INTERNAL_SERVER_ERROR,
+        };
+        Self::new(status, error.to_string())
+    }
+}
+
+/// Maps worker pool failures onto HTTP status codes.
+impl From<WorkerError> for ApiError {
+    fn from(error: WorkerError) -> Self {
+        let status = match error {
+            WorkerError::ShuttingDown => StatusCode::SERVICE_UNAVAILABLE,
+            WorkerError::Failed(_) => StatusCode::INTERNAL_SERVER_ERROR,
+        };
+        Self::new(status, error.to_string())
+    }
+}
+
+/// Body of `POST /analyze`.
+#[derive(Debug, Deserialize)]
+pub struct AnalyzeRequest {
+    /// File to analyze, or the virtual path (which picks the language) of `content`.
+    #[serde(default)]
+    pub path: Option<String>,
+    /// Source to analyze instead of a file on disk.
+    #[serde(default)]
+    pub content: Option<String>,
+}
+
+/// Query of `GET /symbols`.
+#[derive(Debug, Deserialize)]
+pub struct SymbolsParams {
+    /// Project root (default: the server's working directory).
+    pub path: Option<String>,
+    /// Package directory relative to the root; the whole project when absent.
+    pub package: Option<String>,
+    /// RE2 pattern over symbol names (default: every symbol).
+    pub pattern: Option<String>,
+    /// Match the pattern regardless of letter case.
+    #[serde(default)]
+    pub case_insensitive: bool,
+    /// Cursor from a previous page of the same query.
+    pub cursor: Option<String>,
+    /// Page size.
+    pub limit: Option<usize>,
+}
+
+/// Query of `GET /graph`.
+#[derive(Debug, Deserialize)]
+pub struct GraphParams {
+    /// Project root (default: the server's working directory).
+    pub path: Option<String>,
+}
+
+/// Routes of the REST API.
+pub fn router(service: HttpService) -> Router {
+    let service = Arc::new(service);
+    Router::new()
+        .route("/analyze", post(analyze))
+        .route("/symbols", get(symbols))
+        .route("/graph", get(graph))
+        .layer(middleware::from_fn_with_state(service.clone(), rate_limit))
+        .route("/health", get(health))
+        .with_state(service)
+}
+
+/// Serve the REST API on `addr` until `shutdown` resolves.
+pub async fn serve(
+    addr: SocketAddr,
+    service: HttpService,
+    shutdown: impl Future<Output = ()> + Send + 'static,
+) -> anyhow::Result<()> {
+    let listener = TcpListener::bind(addr)
+        .await
+        .map_err(|e| anyhow::anyhow!("Cannot listen on {}: {}", addr, e))?;
+    serve_listener(listener, service, shutdown).await
+}
+
+/// Serve the REST API on an already bound listener until `shutdown` resolves.
+pub async fn serve_listener(
+    listener: TcpListener,
+    service: HttpService,
+    shutdown: impl Future<Output = ()> + Send + 'static,
+) -> anyhow::Result<()> {
+    axum::serve(listener, router(service))
+        .with_graceful_shutdown(shutdown)
+        .await?;
+    Ok(())
+}
+
+/// Reject the request with `429` when the rate limit is exhausted.
+async fn rate_limit(
+    State(service): State<Arc<HttpService>>,
+    request: Request,
+    next: Next,
+) -> Response {
+    if let Some(limiter) = &service.limiter {
+        if !limiter.try_acquire() {
+            let mut response =
+                ApiError::new(StatusCode::TOO_MANY_REQUESTS, "Rate limit exceeded").into_response();
+            response
+                .headers_mut()
+                .insert(header::RETRY_AFTER, header::HeaderValue::from_static("1"));
+            return response;
+        }
+    }
+    next.run(request).await
+}
+
+/// `GET /health`: report liveness without doing any work.
+async fn health() -> Json<Value> {
+    Json(json!({
+        "status": "ok",
+        "version": env!("CARGO_PKG_VERSION"),
+    }))
+}
+
+/// `POST /analyze`: analyze one file with the server's configuration.
+async fn analyze(
+    State(service): State<Arc<HttpService>>,
+    payload: Result<Json<AnalyzeRequest>, JsonRejection>,
+) -> Result<Json<Value>, ApiError> {
+    let Json(request) = payload
+        .map_err(|rejection| ApiError::new(StatusCode::BAD_REQUEST, rejection.body_text()))?;
+    let config = service.config.clone();
+    let handle = Handle::current();
+
+    let (path, results) = match request.content {
+        Some(content) => {
+            let virtual_path = request.path.map(PathBuf::from);
+            info!("HTTP POST /analyze ({} bytes of source)", content.len());
+            service
+                .run_blocking(move || {
+                    let staged = StdinSource::from_source(&content, virtual_path.as_deref())?;
+                    let path = staged
+                        .file()
+                        .strip_prefix(staged.root())
+                        .map(|path| path.to_string_lossy().into_owned())
+                        .unwrap_or_default();
+                    let results = handle.block_on(async {
+                        let mut engine = ValknutEngine::new_from_valknut_config(config).await?;
+                        engine.analyze_directory(staged.root()).await
+                    })?;
+                    Ok((path, results))
+                })
+                .await?
+        }
+        None => {
+            let path = request.path.unwrap_or_default();
+            let file = existing_path(&path)?;
+            if !file.is_file() {
+                return Err(ApiError::new(
+                    StatusCode::BAD_REQUEST,
+                    format!("Not a file: {path}"),
+                ));
+            }
+            info!("HTTP POST /analyze {}", file.display());
+            let results = service
+                .run_blocking(move || {
+                    handle.block_on(async {
+                        let mut engine = ValknutEngine::new_from_valknut_config(config).await?;
+                        engine.analyze_files(&[file]).await
+                    })
+                })
+                .await?;
+            (path, results)
+        }
+    };
+    Ok(Json(analyze_response(path, &results)))
+}
+
+/// `GET /symbols`: list (or search) the symbols of a package directory.
+async fn symbols(
+    State(service): State<Arc<HttpService>>,
+    Query(params): Query<SymbolsParams>,
+) -> Result<Json<SymbolPage>, ApiError> {
+    let root = existing_path(params.path.as_deref().unwrap_or("."))?;
+    let package = params.package.unwrap_or_default();
+    let dir = existing_path(&root.join(&package).to_string_lossy())?;
+    let query = SymbolQuery {
+        pattern: params.pattern.unwrap_or_else(|| ALL_SYMBOLS.to_string()),
+        case_insensitive: params.case_insensitive,
+    };
+    let cursor = params.cursor.filter(|cursor| !cursor.is_empty());
+    let limit = params.limit.unwrap_or(DEFAULT_PAGE_SIZE);
+
+    info!("HTTP GET /symbols /{}/ in {}", query.pattern, dir.display());
+    let mut page = service
+        .run_blocking(move || search_symbols(&dir, &query, cursor.as_deref(), limit))
+        .await?;
+    // Report paths relative to the project root rather than the package.
+    for found in &mut page.matches {
+        found.file_path = Path::new(&package).join(&found.file_path);
+    }
+    Ok(Json(page))
+}
+
+/// `GET /graph`: package import graph of a Go or Java project.
+async fn graph(
+    State(service): State<Arc<HttpService>>,
+    Query(params): Query<GraphParams>,
+) -> Result<Json<PackageGraph>, ApiError> {
+    let root = existing_path(params.path.as_deref().unwrap_or("."))?;
+
+    info!("HTTP GET /graph {}", root.display());
+    let graph = service
+        .run_blocking(move || PackageGraph::from_projects(&[root]))
+        .await?;
+    Ok(Json(graph))
+}
+
+/// Resolve a request path, rejecting empty and missing paths.
+fn existing_path(path: &str) -> Result<PathBuf, ApiError> {
+    if path.is_empty() {
+        return Err(ApiError::new(StatusCode::BAD_REQUEST, "path is required"));
+    }
+    let path = PathBuf::from(path);
+    if !path.exists() {
+        return Err(ApiError::new(
+            StatusCode::NOT_FOUND,
+            format!("Path does not exist: {}", path.display()),
+        ));
+    }
+    Ok(path)
+}
+
+/// Build the `/analyze` response from single-file analysis results.
+fn analyze_response(path: String, results: &AnalysisResults) -> Value {
+    let mut candidates: Vec<&RefactoringCandidate> =
+        results.refactoring_candidates.iter().collect();
+    candidates.sort_by(|a, b| b.score.total_cmp(&a.score));
+
+    json!({
+        "path": path,
+        // A single-file run scores at most that one file.
+        "health_score": results.file_health.values().next().copied(),
+        "candidates": candidates,
+        "warnings": results.warnings,
+    })
+}
+
+#[cfg(test)]
+#[path = "server_tests.rs"]
+mod tests;
@@ -0,0 +1,125 @@
+use super::*;
+use std::fs;
+use std::time::Duration;
+use tempfile::tempdir;
+use tokio::sync::oneshot;
+
+/// Serve on an ephemeral port; the server stops when the sender is dropped.
+async fn start(rate_limit: Option<u32>) -> (String, oneshot::Sender<()>) {
+    let listener = TcpListener::bind("127.0.0.1:0").await.unwrap();
+    let base = format!("http://{}", listener.local_addr().unwrap());
+    let (stop, stopped) = oneshot::channel::<()>();
+    let service = HttpService::new(ValknutConfig::default(), WorkerPool::new(2), rate_limit);
+    tokio::spawn(serve_listener(listener, service, async {
+        let _ = stopped.await;
+    }));
+    (base, stop)
+}
+
+#[test]
+fn rate_limiter_refills_at_the_configured_rate() {
+    let limiter = RateLimiter::new(2);
+    let start = Instant::now();
+    assert!(limiter.try_acquire_at(start));
+    assert!(limiter.try_acquire_at(start));
+    assert!(!limiter.try_acquire_at(start));
+
+    assert!(limiter.try_acquire_at(start + Duration::from_millis(500)));
+    assert!(!limiter.try_acquire_at(start + Duration::from_millis(600)));
+    // Idle time never banks more than one second of requests.
+    let later = start + Duration::from_secs(10);
+    assert!(limiter.try_acquire_at(later));
+    assert!(limiter.try_acquire_at(later));
+    assert!(!limiter.try_acquire_at(later));
+}
+
+#[tokio::test]
+async fn health_is_exempt_from_the_rate_limit() {
+    let (base, _stop) = start(Some(1)).await;
+    let tmp = tempdir().unwrap();
+    let client = reqwest::Client::new();
+
+    for _ in 0..3 {
+        let response = client.get(format!("{base}/health")).send().await.unwrap();
+        assert_eq!(response.status(), StatusCode::OK);
+        let body: Value = response.json().await.unwrap();
+        assert_eq!(
+            body,
+            json!({ "status": "ok", "version": env!("CARGO_PKG_VERSION") })
+        );
+    }
+
+    let graph = format!("{base}/graph?path={}", tmp.path().display());
+    let first = client.get(&graph).send().await.unwrap();
+    assert_eq!(first.status(), StatusCode::OK);
+    let second = client.get(&graph).send().await.unwrap();
+    assert_eq!(second.status(), StatusCode::TOO_MANY_REQUESTS);
+    assert_eq!(second.headers()[header::RETRY_AFTER], "1");
+}
+
+#[tokio::test]
+async fn symbols_are_listed_per_package() {
+    let (base, _stop) = start(None).await;
+    let tmp = tempdir().unwrap();
+    fs::create_dir_all(tmp.path().join("api")).unwrap();
+    fs::write(
+        tmp.path().join("api/handlers.py"),
+        "def login_handler():\n    pass\n\ndef helper():\n    pass\n",
+    )
+    .unwrap();
+    fs::write(tmp.path().join("main.py"), "def main():\n    pass\n").unwrap();
+
+    let page: SymbolPage = reqwest::get(format!(
+        "{base}/symbols?path={}&package=api",
+        tmp.path().display()
+    ))
+    .await
+    .unwrap()
+    .json()
+    .await
+    .unwrap();
+    let names: Vec<&str> = page
+        .matches
+        .iter()
+        .map(|found| found.name.as_str())
+        .collect();
+    assert_eq!(names, ["login_handler", "helper"]);
+    assert_eq!(page.matches[0].file_path, PathBuf::from("api/handlers.py"));
+
+    let missing = reqwest::get(format!(
+        "{base}/symbols?path={}&package=web",
+        tmp.path().display()
+    ))
+    .await
+    .unwrap();
+    assert_eq!(missing.status(), StatusCode::NOT_FOUND);
+}
+
+#[tokio::test]
+async fn analyze_rejects_missing_paths_and_malformed_bodies() {
+    let (base, _stop) = start(None).await;
+    let client = reqwest::Client::new();
+    let analyze = |body: &'static str| {
+        client
+            .post(format!("{base}/analyze"))
+            .header(header::CONTENT_TYPE, "application/json")
+            .body(body)
+            .send()
+    };
+
+    let missing = analyze(r#"{"path": "/definitely/missing/file.rs"}"#)
+        .await
+        .unwrap();
+    assert_eq!(missing.status(), StatusCode::NOT_FOUND);
+    let body: Value = missing.json().await.unwrap();
+    assert!(body["error"].as_str().unwrap().contains("does not exist"));
+
+    let empty = analyze("{}").await.unwrap();
+    assert_eq!(empty.status(), StatusCode::BAD_REQUEST);
+    let malformed = analyze("{not json").await.unwrap();
+    assert_eq!(malformed.status(), StatusCode::BAD_REQUEST);
+    let unsupported = analyze(r#"{"path": "notes.txt", "content": "hello"}"#)
+        .await
+        .unwrap();
+    assert_eq!(unsupported.status(), StatusCode::UNPROCESSABLE_ENTITY);
+}
@@ -11,11 +11,11 @@ use valknut_rs::core::pipeline::{CodeDictionary, RefactoringCandidate};
 use valknut_rs::core::scoring::Priority;
 use valknut_rs::io::reports::ReportGenerator;
 
-use crate::mcp::protocol::error_codes;
+use crate::mcp::protocol::{error_codes, ToolError};
 
 // Type aliases
 type DynError = Box<dyn std::error::Error>;
-pub(crate) type ParseResult = Result<(String, Option<String>), (i32, String)>;
+pub(crate) type ParseResult = Result<(String, Option<String>), ToolError>;
 
 /// Format analysis results according to requested format
 pub(crate) fn format_analysis_results(
@@ -57,7 +57,7 @@ pub fn format_analysis_results_with_temp_path(
 /// Parse entity ID to extract file path and entity name
 pub(crate) fn parse_entity_id(entity_id: &str) -> ParseResult {
     if entity_id.is_empty() {
-        return Err((
+        return Err(ToolError::new(
             error_codes::INVALID_PARAMS,
             "Entity ID cannot be empty".to_string(),
         ));
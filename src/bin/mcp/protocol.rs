@@ -1,6 +1,7 @@
 //! MCP protocol types and message handling for JSON-RPC 2.0 communication.
 
 use serde::{Deserialize, Serialize};
+use valknut_rs::core::errors::ValknutError;
 
 /// JSON-RPC 2.0 request structure
 #[derive(Debug, Deserialize)]
@@ -28,6 +29,33 @@ pub struct JsonRpcError {
     pub data: Option<serde_json::Value>,
 }
 
+/// Error returned by a tool call, sent as the JSON-RPC error object
+#[derive(Debug, Clone, PartialEq)]
+pub struct ToolError {
+    pub code: i32,
+    pub message: String,
+    /// Structured error details (`kind`, `file_path`, `line`, ...)
+    pub data: Option<serde_json::Value>,
+}
+
+/// Construction methods for [`ToolError`].
+impl ToolError {
+    /// Create an error with a code and message only
+    pub fn new(code: i32, message: impl Into<String>) -> Self {
+        Self {
+            code,
+            message: message.into(),
+            data: None,
+        }
+    }
+
+    /// Attach the structured details of the library error that caused this one
+    pub fn with_details(mut self, error: &ValknutError) -> Self {
+        self.data = serde_json::to_value(error.details()).ok();
+        self
+    }
+}
+
 /// MCP tool definition for tool discovery
 #[derive(Debug, Serialize)]
 pub struct McpTool {
@@ -103,6 +131,20 @@ impl JsonRpcResponse {
             id,
         }
     }
+
+    /// Create an error response from a failed tool call
+    pub fn tool_error(id: Option<serde_json::Value>, error: ToolError) -> Self {
+        Self {
+            jsonrpc: "2.0".to_string(),
+            result: None,
+            error: Some(JsonRpcError {
+                code: error.code,
+                message: error.message,
+                data: error.data,
+            }),
+            id,
+        }
+    }
 }
 
 /// MCP error codes
@@ -217,6 +259,162 @@ pub fn create_analyze_file_quality_schema() -> serde_json::Value {
     })
 }
 
+/// Create tool schema for find_package_importers
+pub fn create_package_importers_schema() -> serde_json::Value {
+    serde_json::json!({
+        "type": "object",
+        "properties": {
+            "path": {
+                "type": "string",
+                "description": "Root directory of the Go or Java project to scan"
+            },
+            "package": {
+                "type": "string",
+                "description": "Import path (Go) or package name (Java) whose importers should be listed"
+            }
+        },
+        "required": ["path", "package"]
+    })
+}
+
+/// Create tool schema for find_references
+pub fn create_find_references_schema() -> serde_json::Value {
+    serde_json::json!({
+        "type": "object",
+        "properties": {
+            "path": {
+                "type": "string",
+                "description": "Directory or file to search"
+            },
+            "symbol": {
+                "type": "string",
+                "description": "Symbol name to look up; qualifiers such as `pkg.` are ignored"
+            }
+        },
+        "required": ["path", "symbol"]
+    })
+}
+
+/// Create tool schema for get_top_level_symbols
+pub fn create_top_level_symbols_schema() -> serde_json::Value {
+    serde_json::json!({
+        "type": "object",
+        "properties": {
+            "path": {
+                "type": "string",
+                "description": "Package directory (subdirectories are not included) or single file"
+            }
+        },
+        "required": ["path"]
+    })
+}
+
+/// Create tool schema for get_hot_symbols
+pub fn create_hot_symbols_schema() -> serde_json::Value {
+    serde_json::json!({
+        "type": "object",
+        "properties": {
+            "path": {
+                "type": "string",
+                "description": "Repository root; every directory under it is treated as a package"
+            },
+            "limit": {
+                "type": "integer",
+                "description": "Number of symbols to return",
+                "default": 20,
+                "minimum": 1
+            }
+        },
+        "required": ["path"]
+    })
+}
+
+/// Create tool schema for get_changed_symbols
+pub fn create_changed_symbols_schema() -> serde_json::Value {
+    serde_json::json!({
+        "type": "object",
+        "properties": {
+            "path": {
+                "type": "string",
+                "description": "Directory inside a git repository; only changes under it are listed"
+            },
+            "since": {
+                "type": "string",
+                "description": "Git revision to compare HEAD against",
+                "default": "HEAD~1"
+            }
+        },
+        "required": ["path"]
+    })
+}
+
+/// Create tool schema for search_symbols
+pub fn create_search_symbols_schema() -> serde_json::Value {
+    serde_json::json!({
+        "type": "object",
+        "properties": {
+            "path": {
+                "type": "string",
+                "description": "Directory or file to search"
+            },
+            "pattern": {
+                "type": "string",
+                "description": "RE2 (Go regexp) pattern matched anywhere in symbol names, e.g. `.*Handler`"
+            },
+            "case_insensitive": {
+                "type": "boolean",
+                "default": false,
+                "description": "Match names regardless of letter case"
+            },
+            "cursor": {
+                "type": "string",
+                "description": "Cursor from a previous page's `next_cursor` to continue the same search"
+            },
+            "limit": {
+                "type": "integer",
+                "minimum": 1,
+                "maximum": 1000,
+                "default": 100,
+                "description": "Maximum number of matches per page"
+            }
+        },
+        "required": ["path", "pattern"]
+    })
+}
+
+/// Create tool schema for build_context
+pub fn create_build_context_schema() -> serde_json::Value {
+    serde_json::json!({
+        "type": "object",
+        "properties": {
+            "path": {
+                "type": "string",
+                "description": "Directory or file to gather context from"
+            },
+            "query": {
+                "type": "string",
+                "description": "Free-text query used to rank files by relevance (optional)"
+            },
+            "max_tokens": {
+                "type": "integer",
+                "minimum": 1,
+                "description": "Token budget; overrides the server's --context-budget and the 70000-token default (optional)"
+            },
+            "strategy": {
+                "type": "string",
+                "enum": ["drop_files", "truncate_bodies"],
+                "description": "How to handle files that do not fit the budget (optional)"
+            },
+            "include_content": {
+                "type": "boolean",
+                "default": false,
+                "description": "Whether to include the (possibly trimmed) file contents; requires max_tokens unless the server sets --context-budget"
+            }
+        },
+        "required": ["path"]
+    })
+}
+
 #[cfg(test)]
 mod tests {
     use super::*;
@@ -248,6 +446,50 @@ mod tests {
         assert!(error.data.is_none());
     }
 
+    #[test]
+    fn tool_errors_carry_structured_details() {
+        let cause = ValknutError::parse_with_location(
+            "python",
+            "Failed to parse Python source code",
+            "app/main.py",
+            Some(12),
+            None,
+        );
+        let error = ToolError::new(
+            error_codes::ANALYSIS_ERROR,
+            format!("Analysis failed: {cause}"),
+        )
+        .with_details(&cause);
+        let response = JsonRpcResponse::tool_error(Some(serde_json::json!(7)), error);
+
+        let json = serde_json::to_value(&response).unwrap();
+        assert_eq!(json["error"]["code"], error_codes::ANALYSIS_ERROR);
+        assert_eq!(json["error"]["data"]["kind"], "parse");
+        assert_eq!(json["error"]["data"]["file_path"], "app/main.py");
+        assert_eq!(json["error"]["data"]["line"], 12);
+        assert!(json["error"]["data"].get("column").is_none());
+    }
+
+    #[test]
+    fn tool_errors_carry_syntax_error_positions() {
+        let mut adapter = valknut_rs::lang::python::PythonAdapter::new().unwrap();
+        let cause = adapter
+            .parse_source("def ok():\n    pass\n)\n", "app/broken.py")
+            .expect_err("unbalanced parenthesis is a syntax error");
+        let error = ToolError::new(
+            error_codes::ANALYSIS_ERROR,
+            format!("Analysis failed: {cause}"),
+        )
+        .with_details(&cause);
+        let response = JsonRpcResponse::tool_error(Some(serde_json::json!(8)), error);
+
+        let json = serde_json::to_value(&response).unwrap();
+        assert_eq!(json["error"]["data"]["kind"], "parse");
+        assert_eq!(json["error"]["data"]["file_path"], "app/broken.py");
+        assert_eq!(json["error"]["data"]["line"], 3);
+        assert_eq!(json["error"]["data"]["column"], 1);
+    }
+
     #[test]
     fn analyze_code_schema_declares_required_fields_and_enum() {
         let schema = create_analyze_code_schema();
@@ -358,4 +600,56 @@ mod tests {
         assert_eq!(include_suggestions.get("type"), Some(&json!("boolean")));
         assert_eq!(include_suggestions.get("default"), Some(&json!(true)));
     }
+
+    #[test]
+    fn package_importers_schema_requires_path_and_package() {
+        let schema = create_package_importers_schema();
+
+        let required = schema["required"].as_array().expect("required entries");
+        assert_eq!(required, &vec![json!("path"), json!("package")]);
+
+        let properties = schema["properties"].as_object().expect("properties object");
+        assert_eq!(properties["package"]["type"], json!("string"));
+    }
+
+    #[test]
+    fn find_references_schema_requires_path_and_symbol() {
+        let schema = create_find_references_schema();
+
+        let required = schema["required"].as_array().expect("required entries");
+        assert_eq!(required, &vec![json!("path"), json!("symbol")]);
+    }
+
+    #[test]
+    fn search_symbols_schema_requires_path_and_pattern() {
+        let schema = create_search_symbols_schema();
+
+        let required = schema["required"].as_array().expect("required entries");
+        assert_eq!(required, &vec![json!("path"), json!("pattern")]);
+        assert_eq!(
+            schema["properties"]["case_insensitive"]["default"],
+            json!(false)
+        );
+    }
+
+    #[test]
+    fn changed_symbols_schema_defaults_to_the_last_commit() {
+        let schema = create_changed_symbols_schema();
+
+        let required = schema["required"].as_array().expect("required entries");
+        assert_eq!(required, &vec![json!("path")]);
+        assert_eq!(schema["properties"]["since"]["default"], json!("HEAD~1"));
+    }
+
+    #[test]
+    fn build_context_schema_enumerates_strategies() {
+        let schema = create_build_context_schema();
+
+        let required = schema["required"].as_array().expect("required entries");
+        assert_eq!(required, &vec![json!("path")]);
+        assert_eq!(
+            schema["properties"]["strategy"]["enum"],
+            json!(["drop_files", "truncate_bodies"])
+        );
+    }
 }
@@ -9,17 +9,26 @@ use tokio::sync::Mutex;
 use tracing::{debug, error, info};
 
 use crate::mcp::protocol::{
-    create_analyze_code_schema, create_analyze_file_quality_schema,
-    create_refactoring_suggestions_schema, create_validate_quality_gates_schema, error_codes,
-    ContentItem, JsonRpcRequest, JsonRpcResponse, McpCapabilities, McpInitResult, McpServerInfo,
-    McpTool, ToolCallParams, ToolResult,
+    create_analyze_code_schema, create_analyze_file_quality_schema, create_build_context_schema,
+    create_changed_symbols_schema, create_find_references_schema, create_hot_symbols_schema,
+    create_package_importers_schema, create_refactoring_suggestions_schema,
+    create_search_symbols_schema, create_top_level_symbols_schema,
+    create_validate_quality_gates_schema, error_codes, ContentItem, JsonRpcRequest,
+    JsonRpcResponse, McpCapabilities, McpInitResult, McpServerInfo, McpTool, ToolCallParams,
+    ToolError, ToolResult,
 };
 use crate::mcp::tools::{
-    execute_analyze_code, execute_analyze_file_quality, execute_refactoring_suggestions,
-    execute_validate_quality_gates, AnalyzeCodeParams, AnalyzeFileQualityParams,
-    RefactoringSuggestionsParams, ValidateQualityGatesParams,
+    execute_analyze_code, execute_analyze_file_quality, execute_build_context,
+    execute_changed_symbols, execute_find_references, execute_hot_symbols,
+    execute_package_importers, execute_refactoring_suggestions, execute_search_symbols,
+    execute_top_level_symbols, execute_validate_quality_gates, AnalyzeCodeParams,
+    AnalyzeFileQualityParams, BuildContextParams, ChangedSymbolsParams, FindReferencesParams,
+    HotSymbolsParams, PackageImportersParams, RefactoringSuggestionsParams, SearchSymbolsParams,
+    TopLevelSymbolsParams, ValidateQualityGatesParams,
 };
 use valknut_rs::api::results::AnalysisResults;
+use valknut_rs::core::symbol_filter::SymbolFilter;
+use valknut_rs::core::token_budget::ContextBudget;
 
 /// Session-level analysis cache for avoiding redundant work
 #[derive(Debug, Clone)]
@@ -35,6 +44,10 @@ pub struct McpServer {
     server_info: McpServerInfo,
     /// Session-level cache to avoid re-running analysis for recently analyzed paths
     analysis_cache: Arc<Mutex<HashMap<PathBuf, AnalysisCache>>>,
+    /// Default token budget applied by the build_context tool
+    context_budget: Option<ContextBudget>,
+    /// Filter applied to the symbols every tool returns
+    symbol_filter: Option<SymbolFilter>,
 }
 
 /// Factory, caching, and request handling methods for [`McpServer`].
@@ -47,9 +60,24 @@ impl McpServer {
                 version: version.to_string(),
             },
             analysis_cache: Arc::new(Mutex::new(HashMap::new())),
+            context_budget: None,
+            symbol_filter: None,
         }
     }
 
+    /// Set the default token budget used by the build_context tool.
+    pub fn with_context_budget(mut self, budget: Option<ContextBudget>) -> Self {
+        self.context_budget = budget;
+        self
+    }
+
+    /// Restrict the symbols returned by search_symbols, get_top_level_symbols,
+    /// get_hot_symbols and build_context.
+    pub fn with_symbol_filter(mut self, filter: Option<SymbolFilter>) -> Self {
+        self.symbol_filter = filter;
+        self
+    }
+
     /// Get cached analysis results if available and still valid (within 5 minutes)
     async fn get_cached_analysis(&self, path: &PathBuf) -> Option<Arc<AnalysisResults>> {
         let cache = self.analysis_cache.lock().await;
@@ -97,7 +125,7 @@ impl McpServer {
     async fn execute_analyze_code_cached(
         &self,
         params: AnalyzeCodeParams,
-    ) -> Result<ToolResult, (i32, String)> {
+    ) -> Result<ToolResult, ToolError> {
         info!(
             "Executing analyze_code tool with caching for path: {}",
             params.path
@@ -121,10 +149,10 @@ impl McpServer {
     }
 
     /// Validate path exists and return both the path and its canonical form.
-    fn validate_and_canonicalize_path(path_str: &str) -> Result<(PathBuf, PathBuf), (i32, String)> {
+    fn validate_and_canonicalize_path(path_str: &str) -> Result<(PathBuf, PathBuf), ToolError> {
         let path = PathBuf::from(path_str);
         if !path.exists() {
-            return Err((
+            return Err(ToolError::new(
                 error_codes::INVALID_PARAMS,
                 format!("Path does not exist: {}", path_str),
             ));
@@ -134,7 +162,7 @@ impl McpServer {
     }
 
     /// Run a fresh analysis on the given path.
-    async fn run_fresh_analysis(path: &PathBuf) -> Result<AnalysisResults, (i32, String)> {
+    async fn run_fresh_analysis(path: &PathBuf) -> Result<AnalysisResults, ToolError> {
         let analysis_config = valknut_rs::api::config_types::AnalysisConfig::default()
             .with_confidence_threshold(0.75)
             .with_max_files(5000)
@@ -149,18 +177,20 @@ impl McpServer {
             .await
             .map_err(|e| {
                 error!("Failed to create analysis engine: {}", e);
-                (
+                ToolError::new(
                     error_codes::ANALYSIS_ERROR,
                     format!("Failed to create analysis engine: {}", e),
                 )
+                .with_details(&e)
             })?;
 
         engine.analyze_directory(path).await.map_err(|e| {
             error!("Analysis failed: {}", e);
-            (
+            ToolError::new(
                 error_codes::ANALYSIS_ERROR,
                 format!("Analysis failed: {}", e),
             )
+            .with_details(&e)
         })
     }
 
@@ -169,10 +199,10 @@ impl McpServer {
         &self,
         results: &AnalysisResults,
         format: &str,
-    ) -> Result<ToolResult, (i32, String)> {
+    ) -> Result<ToolResult, ToolError> {
         let formatted_output = self.format_analysis_results(results, format).map_err(|e| {
             error!("Failed to format results: {}", e);
-            (
+            ToolError::new(
                 error_codes::INTERNAL_ERROR,
                 format!("Failed to format results: {}", e),
             )
@@ -361,6 +391,58 @@ impl McpServer {
                 description: "Analyze quality metrics and issues for a specific file".to_string(),
                 input_schema: create_analyze_file_quality_schema(),
             },
+            McpTool {
+                name: "build_context".to_string(),
+                description: "Rank and trim files to fit an LLM token budget, with per-file and \
+                              per-symbol token estimates and the tables each SQL file references"
+                    .to_string(),
+                input_schema: create_build_context_schema(),
+            },
+            McpTool {
+                name: "find_package_importers".to_string(),
+                description:
+                    "List all Go or Java packages that transitively import a given package"
+                        .to_string(),
+                input_schema: create_package_importers_schema(),
+            },
+            McpTool {
+                name: "find_references".to_string(),
+                description: "Find every location that references a symbol by name, with its \
+                              enclosing function or type"
+                    .to_string(),
+                input_schema: create_find_references_schema(),
+            },
+            McpTool {
+                name: "search_symbols".to_string(),
+                description: "Find functions, methods and types whose name matches a regex, with \
+                              file locations and signatures; paginated with a cursor"
+                    .to_string(),
+                input_schema: create_search_symbols_schema(),
+            },
+            McpTool {
+                name: "get_top_level_symbols".to_string(),
+                description: "List a package's exported functions, types, constants and \
+                              variables with doc comments, signatures, locations, the \
+                              semver tag that introduced them and how many other files \
+                              reference them"
+                    .to_string(),
+                input_schema: create_top_level_symbols_schema(),
+            },
+            McpTool {
+                name: "get_hot_symbols".to_string(),
+                description: "List the exported symbols referenced by the most other files in a \
+                              repository, with separate counts for test files"
+                    .to_string(),
+                input_schema: create_hot_symbols_schema(),
+            },
+            McpTool {
+                name: "get_changed_symbols".to_string(),
+                description: "List the functions and types whose implementation changed since a \
+                              git revision (default: the last commit), leaving out unchanged \
+                              and merely moved symbols"
+                    .to_string(),
+                input_schema: create_changed_symbols_schema(),
+            },
         ]
     }
 
@@ -381,7 +463,7 @@ impl McpServer {
 
         match tool_result {
             Ok(result) => JsonRpcResponse::success(id, serde_json::to_value(result).unwrap()),
-            Err((code, message)) => JsonRpcResponse::error(id, code, message),
+            Err(error) => JsonRpcResponse::tool_error(id, error),
         }
     }
 
@@ -411,7 +493,7 @@ impl McpServer {
         &self,
         name: &str,
         arguments: serde_json::Value,
-    ) -> Result<ToolResult, (i32, String)> {
+    ) -> Result<ToolResult, ToolError> {
         match name {
             "analyze_code" => self.dispatch_analyze_code(arguments).await,
             "get_refactoring_suggestions" => {
@@ -419,7 +501,14 @@ impl McpServer {
             }
             "validate_quality_gates" => Self::dispatch_validate_quality_gates(arguments).await,
             "analyze_file_quality" => Self::dispatch_analyze_file_quality(arguments).await,
-            _ => Err((
+            "find_package_importers" => Self::dispatch_package_importers(arguments).await,
+            "find_references" => Self::dispatch_find_references(arguments).await,
+            "search_symbols" => self.dispatch_search_symbols(arguments).await,
+            "get_top_level_symbols" => self.dispatch_top_level_symbols(arguments).await,
+            "get_hot_symbols" => self.dispatch_hot_symbols(arguments).await,
+            "get_changed_symbols" => Self::dispatch_changed_symbols(arguments).await,
+            "build_context" => self.dispatch_build_context(arguments).await,
+            _ => Err(ToolError::new(
                 error_codes::TOOL_NOT_FOUND,
                 format!("Unknown tool: {}", name),
             )),
@@ -430,9 +519,9 @@ impl McpServer {
     async fn dispatch_analyze_code(
         &self,
         arguments: serde_json::Value,
-    ) -> Result<ToolResult, (i32, String)> {
+    ) -> Result<ToolResult, ToolError> {
         let params = serde_json::from_value::<AnalyzeCodeParams>(arguments).map_err(|e| {
-            (
+            ToolError::new(
                 error_codes::INVALID_PARAMS,
                 format!("Invalid analyze_code parameters: {}", e),
             )
@@ -443,10 +532,10 @@ impl McpServer {
     /// Dispatch get_refactoring_suggestions tool.
     async fn dispatch_refactoring_suggestions(
         arguments: serde_json::Value,
-    ) -> Result<ToolResult, (i32, String)> {
+    ) -> Result<ToolResult, ToolError> {
         let params =
             serde_json::from_value::<RefactoringSuggestionsParams>(arguments).map_err(|e| {
-                (
+                ToolError::new(
                     error_codes::INVALID_PARAMS,
                     format!("Invalid get_refactoring_suggestions parameters: {}", e),
                 )
@@ -457,10 +546,10 @@ impl McpServer {
     /// Dispatch validate_quality_gates tool.
     async fn dispatch_validate_quality_gates(
         arguments: serde_json::Value,
-    ) -> Result<ToolResult, (i32, String)> {
+    ) -> Result<ToolResult, ToolError> {
         let params =
             serde_json::from_value::<ValidateQualityGatesParams>(arguments).map_err(|e| {
-                (
+                ToolError::new(
                     error_codes::INVALID_PARAMS,
                     format!("Invalid validate_quality_gates parameters: {}", e),
                 )
@@ -471,16 +560,111 @@ impl McpServer {
     /// Dispatch analyze_file_quality tool.
     async fn dispatch_analyze_file_quality(
         arguments: serde_json::Value,
-    ) -> Result<ToolResult, (i32, String)> {
+    ) -> Result<ToolResult, ToolError> {
         let params =
             serde_json::from_value::<AnalyzeFileQualityParams>(arguments).map_err(|e| {
-                (
+                ToolError::new(
                     error_codes::INVALID_PARAMS,
                     format!("Invalid analyze_file_quality parameters: {}", e),
                 )
             })?;
         execute_analyze_file_quality(params).await
     }
+
+    /// Dispatch build_context tool.
+    async fn dispatch_build_context(
+        &self,
+        arguments: serde_json::Value,
+    ) -> Result<ToolResult, ToolError> {
+        let params = serde_json::from_value::<BuildContextParams>(arguments).map_err(|e| {
+            ToolError::new(
+                error_codes::INVALID_PARAMS,
+                format!("Invalid build_context parameters: {}", e),
+            )
+        })?;
+        execute_build_context(params, self.context_budget, self.symbol_filter.as_ref()).await
+    }
+
+    /// Dispatch find_references tool.
+    async fn dispatch_find_references(
+        arguments: serde_json::Value,
+    ) -> Result<ToolResult, ToolError> {
+        let params = serde_json::from_value::<FindReferencesParams>(arguments).map_err(|e| {
+            ToolError::new(
+                error_codes::INVALID_PARAMS,
+                format!("Invalid find_references parameters: {}", e),
+            )
+        })?;
+        execute_find_references(params).await
+    }
+
+    /// Dispatch search_symbols tool.
+    async fn dispatch_search_symbols(
+        &self,
+        arguments: serde_json::Value,
+    ) -> Result<ToolResult, ToolError> {
+        let params = serde_json::from_value::<SearchSymbolsParams>(arguments).map_err(|e| {
+            ToolError::new(
+                error_codes::INVALID_PARAMS,
+                format!("Invalid search_symbols parameters: {}", e),
+            )
+        })?;
+        execute_search_symbols(params, self.symbol_filter.as_ref()).await
+    }
+
+    /// Dispatch get_top_level_symbols tool.
+    async fn dispatch_top_level_symbols(
+        &self,
+        arguments: serde_json::Value,
+    ) -> Result<ToolResult, ToolError> {
+        let params = serde_json::from_value::<TopLevelSymbolsParams>(arguments).map_err(|e| {
+            ToolError::new(
+                error_codes::INVALID_PARAMS,
+                format!("Invalid get_top_level_symbols parameters: {}", e),
+            )
+        })?;
+        execute_top_level_symbols(params, self.symbol_filter.as_ref()).await
+    }
+
+    /// Dispatch get_hot_symbols tool.
+    async fn dispatch_hot_symbols(
+        &self,
+        arguments: serde_json::Value,
+    ) -> Result<ToolResult, ToolError> {
+        let params = serde_json::from_value::<HotSymbolsParams>(arguments).map_err(|e| {
+            ToolError::new(
+                error_codes::INVALID_PARAMS,
+                format!("Invalid get_hot_symbols parameters: {}", e),
+            )
+        })?;
+        execute_hot_symbols(params, self.symbol_filter.as_ref()).await
+    }
+
+    /// Dispatch find_package_importers tool.
+    async fn dispatch_package_importers(
+        arguments: serde_json::Value,
+    ) -> Result<ToolResult, ToolError> {
+        let params = serde_json::from_value::<PackageImportersParams>(arguments).map_err(|e| {
+            ToolError::new(
+                error_codes::INVALID_PARAMS,
+                format!("Invalid find_package_importers parameters: {}", e),
+            )
+        })?;
+        execute_package_importers(params).await
+    }
+
+    /// Dispatch get_changed_symbols tool.
+    async fn dispatch_changed_symbols(
+        arguments: serde_json::Value,
+    ) -> Result<ToolResult, ToolError> {
+        let params = serde_json::from_value::<ChangedSymbolsParams>(arguments).map_err(|e| {
+            ToolError::new(
+                error_codes::INVALID_PARAMS,
+                format!("Invalid get_changed_symbols parameters: {}", e),
+            )
+        })?;
+        execute_changed_symbols(params).await
+    }
 }
 
 /// Extension trait for JsonRpcResponse to set id.
@@ -498,9 +682,15 @@ impl JsonRpcResponseExt for JsonRpcResponse {
     }
 }
 
-/// Run the MCP server with the given version
-pub async fn run_mcp_server(version: &str) -> Result<(), Box<dyn std::error::Error>> {
-    let server = McpServer::new(version);
+/// Run the MCP server with the given version, default context budget and symbol filter
+pub async fn run_mcp_server(
+    version: &str,
+    context_budget: Option<ContextBudget>,
+    symbol_filter: Option<SymbolFilter>,
+) -> Result<(), Box<dyn std::error::Error>> {
+    let server = McpServer::new(version)
+        .with_context_budget(context_budget)
+        .with_symbol_filter(symbol_filter);
     server.run().await
 }
 
@@ -532,6 +722,8 @@ mod tests {
             critical_issues: 0,
             doc_health_score: 1.0,
             doc_issue_count: 0,
+            complexity: Default::default(),
+            timed_out: false,
         };
 
         let candidate = valknut_rs::api::results::RefactoringCandidate {
@@ -563,6 +755,7 @@ mod tests {
             issue_count: 1,
             suggestion_count: 1,
             coverage_percentage: None,
+            context: Default::default(),
         };
 
         let mut code_dictionary = CodeDictionary::default();
@@ -600,11 +793,22 @@ mod tests {
             coverage_packs: Vec::new(),
             warnings: vec!["Minor warning".to_string()],
             code_dictionary,
+            rule_findings: Vec::new(),
+            changed_files_only: false,
+            dependency_report: Vec::new(),
+            interface_report: None,
+            project_health: None,
+            context_statistics: Default::default(),
             documentation: None,
             directory_health: HashMap::new(),
             file_health: HashMap::new(),
             entity_health: HashMap::new(),
             directory_health_tree: None,
+            skipped_files: Vec::new(),
+            generated_code: Default::default(),
+            notebook_metadata: Vec::new(),
+            duplicates: Default::default(),
+            file_complexity: Default::default(),
         }
     }
 
@@ -617,6 +821,13 @@ mod tests {
         assert!(names.contains(&"get_refactoring_suggestions"));
         assert!(names.contains(&"validate_quality_gates"));
         assert!(names.contains(&"analyze_file_quality"));
+        assert!(names.contains(&"find_package_importers"));
+        assert!(names.contains(&"build_context"));
+        assert!(names.contains(&"find_references"));
+        assert!(names.contains(&"search_symbols"));
+        assert!(names.contains(&"get_top_level_symbols"));
+        assert!(names.contains(&"get_hot_symbols"));
+        assert!(names.contains(&"get_changed_symbols"));
     }
 
     #[test]
@@ -824,11 +1035,11 @@ mod tests {
             .execute_analyze_code_cached(params)
             .await
             .unwrap_err();
-        assert_eq!(err.0, error_codes::INVALID_PARAMS);
+        assert_eq!(err.code, error_codes::INVALID_PARAMS);
         assert!(
-            err.1.contains("Path does not exist"),
+            err.message.contains("Path does not exist"),
             "unexpected error text: {}",
-            err.1
+            err.message
         );
     }
 
@@ -26,9 +26,24 @@ pub type AnalysisCacheRef = Arc<Mutex<HashMap<PathBuf, AnalysisCache>>>;
 use valknut_rs::api::{
     config_types::AnalysisConfig, engine::ValknutEngine, results::AnalysisResults,
 };
+use valknut_rs::core::changed_symbols::{changed_symbols_since, DEFAULT_SINCE};
+use valknut_rs::core::dependency::PackageGraph;
 use valknut_rs::core::errors::ValknutError;
+use valknut_rs::core::file_utils::FileReader;
+use valknut_rs::core::pipeline::discovery::IGNORE_FILE_NAME;
+use valknut_rs::core::public_api::{hot_symbols, top_level_symbols, DEFAULT_HOT_SYMBOLS};
+use valknut_rs::core::symbol_filter::{SymbolFilter, SymbolScope};
+use valknut_rs::core::symbol_search::{
+    search_symbols_where, SymbolMatch, SymbolQuery, DEFAULT_PAGE_SIZE,
+};
+use valknut_rs::core::token_budget::{
+    relevance_score, symbol_token_counts, ContextBudget, ContextFile, TrimStrategy,
+};
+use valknut_rs::core::xref::find_references;
+use valknut_rs::lang::sql::{is_sql_file, parse_sql, GoEmbeds};
+use valknut_rs::oracle::VALKNUT_OUTPUT_TOKEN_BUDGET;
 
-use crate::mcp::protocol::{error_codes, ContentItem, ToolResult};
+use crate::mcp::protocol::{error_codes, ContentItem, ToolError, ToolResult};
 
 /// Parameters for analyze_code tool
 #[derive(serde::Deserialize)]
@@ -68,6 +83,69 @@ pub struct AnalyzeFileQualityParams {
     pub include_suggestions: bool,
 }
 
+/// Parameters for build_context tool
+#[derive(serde::Deserialize)]
+pub struct BuildContextParams {
+    pub path: String,
+    #[serde(default)]
+    pub query: Option<String>,
+    #[serde(default)]
+    pub max_tokens: Option<usize>,
+    #[serde(default)]
+    pub strategy: Option<TrimStrategy>,
+    #[serde(default)]
+    pub include_content: bool,
+}
+
+/// Parameters for find_package_importers tool
+#[derive(serde::Deserialize)]
+pub struct PackageImportersParams {
+    pub path: String,
+    pub package: String,
+}
+
+/// Parameters for find_references tool
+#[derive(serde::Deserialize)]
+pub struct FindReferencesParams {
+    pub path: String,
+    pub symbol: String,
+}
+
+/// Parameters for search_symbols tool
+#[derive(serde::Deserialize)]
+pub struct SearchSymbolsParams {
+    pub path: String,
+    pub pattern: String,
+    #[serde(default)]
+    pub case_insensitive: bool,
+    #[serde(default)]
+    pub cursor: Option<String>,
+    #[serde(default = "default_search_limit")]
+    pub limit: usize,
+}
+
+/// Parameters for get_top_level_symbols tool
+#[derive(serde::Deserialize)]
+pub struct TopLevelSymbolsParams {
+    pub path: String,
+}
+
+/// Parameters for get_hot_symbols tool
+#[derive(serde::Deserialize)]
+pub struct HotSymbolsParams {
+    pub path: String,
+    #[serde(default = "default_hot_symbols_limit")]
+    pub limit: usize,
+}
+
+/// Parameters for get_changed_symbols tool
+#[derive(serde::Deserialize)]
+pub struct ChangedSymbolsParams {
+    pub path: String,
+    #[serde(default = "default_since")]
+    pub since: String,
+}
+
 /// Default value for including suggestions in file quality analysis.
 fn default_include_suggestions() -> bool {
     true
@@ -83,14 +161,29 @@ fn default_max_suggestions() -> usize {
     10
 }
 
+/// Default page size for search_symbols results.
+fn default_search_limit() -> usize {
+    DEFAULT_PAGE_SIZE
+}
+
+/// Default number of symbols get_hot_symbols returns.
+fn default_hot_symbols_limit() -> usize {
+    DEFAULT_HOT_SYMBOLS
+}
+
+/// Default revision get_changed_symbols compares HEAD against: the last commit.
+fn default_since() -> String {
+    DEFAULT_SINCE.to_string()
+}
+
 /// Execute the analyze_code tool
-pub async fn execute_analyze_code(params: AnalyzeCodeParams) -> Result<ToolResult, (i32, String)> {
+pub async fn execute_analyze_code(params: AnalyzeCodeParams) -> Result<ToolResult, ToolError> {
     info!("Executing analyze_code tool for path: {}", params.path);
 
     // Validate path exists
     let path = Path::new(&params.path);
     if !path.exists() {
-        return Err((
+        return Err(ToolError::new(
             error_codes::INVALID_PARAMS,
             format!("Path does not exist: {}", params.path),
         ));
@@ -112,10 +205,11 @@ pub async fn execute_analyze_code(params: AnalyzeCodeParams) -> Result<ToolResul
         Ok(results) => results,
         Err(e) => {
             error!("Analysis failed: {}", e);
-            return Err((
+            return Err(ToolError::new(
                 error_codes::ANALYSIS_ERROR,
                 format!("Analysis failed: {}", e),
-            ));
+            )
+            .with_details(&e));
         }
     };
 
@@ -124,7 +218,7 @@ pub async fn execute_analyze_code(params: AnalyzeCodeParams) -> Result<ToolResul
         Ok(output) => output,
         Err(e) => {
             error!("Failed to format results: {}", e);
-            return Err((
+            return Err(ToolError::new(
                 error_codes::INTERNAL_ERROR,
                 format!("Failed to format results: {}", e),
             ));
@@ -142,7 +236,7 @@ pub async fn execute_analyze_code(params: AnalyzeCodeParams) -> Result<ToolResul
 /// Execute the get_refactoring_suggestions tool
 pub async fn execute_refactoring_suggestions(
     params: RefactoringSuggestionsParams,
-) -> Result<ToolResult, (i32, String)> {
+) -> Result<ToolResult, ToolError> {
     info!(
         "Executing get_refactoring_suggestions tool for entity: {}",
         params.entity_id
@@ -167,10 +261,11 @@ pub async fn execute_refactoring_suggestions(
         Ok(results) => results,
         Err(e) => {
             error!("Analysis failed: {}", e);
-            return Err((
+            return Err(ToolError::new(
                 error_codes::ANALYSIS_ERROR,
                 format!("Analysis failed: {}", e),
-            ));
+            )
+            .with_details(&e));
         }
     };
 
@@ -182,7 +277,7 @@ pub async fn execute_refactoring_suggestions(
         Ok(json) => json,
         Err(e) => {
             error!("Failed to serialize suggestions: {}", e);
-            return Err((
+            return Err(ToolError::new(
                 error_codes::INTERNAL_ERROR,
                 format!("Failed to serialize suggestions: {}", e),
             ));
@@ -288,7 +383,7 @@ fn cache_entry_is_fresh(entry: &AnalysisCache) -> bool {
 /// Execute the validate_quality_gates tool
 pub async fn execute_validate_quality_gates(
     params: ValidateQualityGatesParams,
-) -> Result<ToolResult, (i32, String)> {
+) -> Result<ToolResult, ToolError> {
     info!(
         "Executing validate_quality_gates tool for path: {}",
         params.path
@@ -297,7 +392,7 @@ pub async fn execute_validate_quality_gates(
     // Validate path exists
     let path = Path::new(&params.path);
     if !path.exists() {
-        return Err((
+        return Err(ToolError::new(
             error_codes::INVALID_PARAMS,
             format!("Path does not exist: {}", params.path),
         ));
@@ -313,10 +408,11 @@ pub async fn execute_validate_quality_gates(
         Ok(engine) => engine,
         Err(e) => {
             error!("Failed to initialize analysis engine: {}", e);
-            return Err((
+            return Err(ToolError::new(
                 error_codes::ANALYSIS_ERROR,
                 format!("Failed to initialize analysis engine: {}", e),
-            ));
+            )
+            .with_details(&e));
         }
     };
 
@@ -325,10 +421,11 @@ pub async fn execute_validate_quality_gates(
         Ok(results) => results,
         Err(e) => {
             error!("Analysis failed: {}", e);
-            return Err((
+            return Err(ToolError::new(
                 error_codes::ANALYSIS_ERROR,
                 format!("Analysis failed: {}", e),
-            ));
+            )
+            .with_details(&e));
         }
     };
 
@@ -338,7 +435,7 @@ pub async fn execute_validate_quality_gates(
         Ok(json) => json,
         Err(e) => {
             error!("Failed to serialize quality gate results: {}", e);
-            return Err((
+            return Err(ToolError::new(
                 error_codes::INTERNAL_ERROR,
                 format!("Failed to serialize quality gate results: {}", e),
             ));
@@ -356,7 +453,7 @@ pub async fn execute_validate_quality_gates(
 /// Execute the analyze_file_quality tool
 pub async fn execute_analyze_file_quality(
     params: AnalyzeFileQualityParams,
-) -> Result<ToolResult, (i32, String)> {
+) -> Result<ToolResult, ToolError> {
     info!(
         "Executing analyze_file_quality tool for file: {}",
         params.file_path
@@ -365,14 +462,14 @@ pub async fn execute_analyze_file_quality(
     // Validate file exists
     let file_path = Path::new(&params.file_path);
     if !file_path.exists() {
-        return Err((
+        return Err(ToolError::new(
             error_codes::INVALID_PARAMS,
             format!("File does not exist: {}", params.file_path),
         ));
     }
 
     if !file_path.is_file() {
-        return Err((
+        return Err(ToolError::new(
             error_codes::INVALID_PARAMS,
             format!("Path is not a file: {}", params.file_path),
         ));
@@ -388,10 +485,11 @@ pub async fn execute_analyze_file_quality(
         Ok(engine) => engine,
         Err(e) => {
             error!("Failed to initialize analysis engine: {}", e);
-            return Err((
+            return Err(ToolError::new(
                 error_codes::ANALYSIS_ERROR,
                 format!("Failed to initialize analysis engine: {}", e),
-            ));
+            )
+            .with_details(&e));
         }
     };
 
@@ -401,10 +499,11 @@ pub async fn execute_analyze_file_quality(
         Ok(results) => results,
         Err(e) => {
             error!("Analysis failed: {}", e);
-            return Err((
+            return Err(ToolError::new(
                 error_codes::ANALYSIS_ERROR,
                 format!("Analysis failed: {}", e),
-            ));
+            )
+            .with_details(&e));
         }
     };
 
@@ -415,7 +514,7 @@ pub async fn execute_analyze_file_quality(
         Ok(json) => json,
         Err(e) => {
             error!("Failed to serialize file quality report: {}", e);
-            return Err((
+            return Err(ToolError::new(
                 error_codes::INTERNAL_ERROR,
                 format!("Failed to serialize file quality report: {}", e),
             ));
@@ -430,6 +529,484 @@ pub async fn execute_analyze_file_quality(
     })
 }
 
+/// Execute the find_package_importers tool
+pub async fn execute_package_importers(
+    params: PackageImportersParams,
+) -> Result<ToolResult, ToolError> {
+    info!(
+        "Executing find_package_importers tool for package {} in {}",
+        params.package, params.path
+    );
+
+    let path = PathBuf::from(&params.path);
+    if !path.exists() {
+        return Err(ToolError::new(
+            error_codes::INVALID_PARAMS,
+            format!("Path does not exist: {}", params.path),
+        ));
+    }
+
+    let graph = match PackageGraph::from_projects(&[path]) {
+        Ok(graph) => graph,
+        Err(e) => {
+            error!("Package graph construction failed: {}", e);
+            return Err(ToolError::new(
+                error_codes::ANALYSIS_ERROR,
+                format!("Package graph construction failed: {}", e),
+            )
+            .with_details(&e));
+        }
+    };
+
+    let importers = graph.transitive_importers(&params.package);
+    let cycles: Vec<_> = graph
+        .cycles
+        .iter()
+        .filter(|cycle| cycle.contains(&params.package))
+        .collect();
+    let report = serde_json::json!({
+        "package": params.package,
+        "origin": graph.origin(&params.package),
+        "importer_count": importers.len(),
+        "importers": importers,
+        "cycles": cycles,
+    });
+
+    let formatted = serde_json::to_string_pretty(&report).map_err(|e| {
+        ToolError::new(
+            error_codes::INTERNAL_ERROR,
+            format!("Failed to serialize package importers: {}", e),
+        )
+    })?;
+
+    Ok(ToolResult {
+        content: vec![ContentItem {
+            content_type: "text".to_string(),
+            text: formatted,
+        }],
+    })
+}
+
+/// Execute the find_references tool
+pub async fn execute_find_references(
+    params: FindReferencesParams,
+) -> Result<ToolResult, ToolError> {
+    info!(
+        "Executing find_references tool for symbol {} in {}",
+        params.symbol, params.path
+    );
+
+    let path = PathBuf::from(&params.path);
+    if !path.exists() {
+        return Err(ToolError::new(
+            error_codes::INVALID_PARAMS,
+            format!("Path does not exist: {}", params.path),
+        ));
+    }
+
+    let references = find_references(&path, &params.symbol).map_err(|e| {
+        error!("Reference search failed: {}", e);
+        ToolError::new(
+            error_codes::ANALYSIS_ERROR,
+            format!("Reference search failed: {}", e),
+        )
+        .with_details(&e)
+    })?;
+
+    let report = serde_json::json!({
+        "symbol": params.symbol,
+        "reference_count": references.len(),
+        "references": references,
+    });
+
+    let formatted = serde_json::to_string_pretty(&report).map_err(|e| {
+        ToolError::new(
+            error_codes::INTERNAL_ERROR,
+            format!("Failed to serialize references: {}", e),
+        )
+    })?;
+
+    Ok(ToolResult {
+        content: vec![ContentItem {
+            content_type: "text".to_string(),
+            text: formatted,
+        }],
+    })
+}
+
+/// Execute the search_symbols tool, keeping only the matches `filter` accepts
+pub async fn execute_search_symbols(
+    params: SearchSymbolsParams,
+    filter: Option<&SymbolFilter>,
+) -> Result<ToolResult, ToolError> {
+    info!(
+        "Executing search_symbols tool for pattern {} in {}",
+        params.pattern, params.path
+    );
+
+    let path = PathBuf::from(&params.path);
+    if !path.exists() {
+        return Err(ToolError::new(
+            error_codes::INVALID_PARAMS,
+            format!("Path does not exist: {}", params.path),
+        ));
+    }
+
+    let query = SymbolQuery {
+        pattern: params.pattern,
+        case_insensitive: params.case_insensitive,
+    };
+    let mut scope = scope_symbol_filter(filter, &path)?;
+    let keep = |symbol: &SymbolMatch| match &mut scope {
+        Some(scope) => scope.keeps(
+            &symbol_file(&path, &symbol.file_path),
+            &symbol.name,
+            symbol.start_line,
+            symbol.end_line,
+        ),
+        None => true,
+    };
+    let page = search_symbols_where(&path, &query, params.cursor.as_deref(), params.limit, keep)
+        .map_err(|e| match e {
+            ValknutError::Validation { .. } => {
+                ToolError::new(error_codes::INVALID_PARAMS, e.to_string()).with_details(&e)
+            }
+            _ => {
+                error!("Symbol search failed: {}", e);
+                ToolError::new(
+                    error_codes::ANALYSIS_ERROR,
+                    format!("Symbol search failed: {}", e),
+                )
+                .with_details(&e)
+            }
+        })?;
+
+    let formatted = serde_json::to_string_pretty(&page).map_err(|e| {
+        ToolError::new(
+            error_codes::INTERNAL_ERROR,
+            format!("Failed to serialize symbol matches: {}", e),
+        )
+    })?;
+
+    Ok(ToolResult {
+        content: vec![ContentItem {
+            content_type: "text".to_string(),
+            text: formatted,
+        }],
+    })
+}
+
+/// Execute the get_top_level_symbols tool, keeping only the symbols `filter` accepts
+pub async fn execute_top_level_symbols(
+    params: TopLevelSymbolsParams,
+    filter: Option<&SymbolFilter>,
+) -> Result<ToolResult, ToolError> {
+    info!("Executing get_top_level_symbols tool for {}", params.path);
+
+    let path = PathBuf::from(&params.path);
+    if !path.exists() {
+        return Err(ToolError::new(
+            error_codes::INVALID_PARAMS,
+            format!("Path does not exist: {}", params.path),
+        ));
+    }
+
+    let mut symbols = top_level_symbols(&path).map_err(|e| {
+        error!("Listing top-level symbols failed: {}", e);
+        ToolError::new(
+            error_codes::ANALYSIS_ERROR,
+            format!("Listing top-level symbols failed: {}", e),
+        )
+        .with_details(&e)
+    })?;
+    if let Some(mut scope) = scope_symbol_filter(filter, &path)? {
+        symbols.retain(|symbol| {
+            scope.keeps(
+                &symbol_file(&path, &symbol.file_path),
+                &symbol.name,
+                symbol.start_line,
+                symbol.end_line,
+            )
+        });
+    }
+
+    let report = serde_json::json!({
+        "package": params.path,
+        "symbol_count": symbols.len(),
+        "symbols": symbols,
+    });
+
+    let formatted = serde_json::to_string_pretty(&report).map_err(|e| {
+        ToolError::new(
+            error_codes::INTERNAL_ERROR,
+            format!("Failed to serialize symbols: {}", e),
+        )
+    })?;
+
+    Ok(ToolResult {
+        content: vec![ContentItem {
+            content_type: "text".to_string(),
+            text: formatted,
+        }],
+    })
+}
+
+/// Execute the get_hot_symbols tool, ranking only the symbols `filter` accepts
+pub async fn execute_hot_symbols(
+    params: HotSymbolsParams,
+    filter: Option<&SymbolFilter>,
+) -> Result<ToolResult, ToolError> {
+    info!("Executing get_hot_symbols tool for {}", params.path);
+
+    let path = PathBuf::from(&params.path);
+    if !path.is_dir() {
+        return Err(ToolError::new(
+            error_codes::INVALID_PARAMS,
+            format!("Path is not a directory: {}", params.path),
+        ));
+    }
+
+    let mut symbols = hot_symbols(&path, usize::MAX).map_err(|e| {
+        error!("Ranking hot symbols failed: {}", e);
+        ToolError::new(
+            error_codes::ANALYSIS_ERROR,
+            format!("Ranking hot symbols failed: {}", e),
+        )
+        .with_details(&e)
+    })?;
+    if let Some(mut scope) = scope_symbol_filter(filter, &path)? {
+        symbols.retain(|symbol| {
+            scope.keeps(
+                &path.join(&symbol.file_path),
+                &symbol.name,
+                symbol.start_line,
+                symbol.end_line,
+            )
+        });
+    }
+    symbols.truncate(params.limit);
+
+    let report = serde_json::json!({
+        "root": params.path,
+        "symbol_count": symbols.len(),
+        "symbols": symbols,
+    });
+
+    let formatted = serde_json::to_string_pretty(&report).map_err(|e| {
+        ToolError::new(
+            error_codes::INTERNAL_ERROR,
+            format!("Failed to serialize symbols: {}", e),
+        )
+    })?;
+
+    Ok(ToolResult {
+        content: vec![ContentItem {
+            content_type: "text".to_string(),
+            text: formatted,
+        }],
+    })
+}
+
+/// Execute the get_changed_symbols tool
+pub async fn execute_changed_symbols(
+    params: ChangedSymbolsParams,
+) -> Result<ToolResult, ToolError> {
+    info!(
+        "Executing get_changed_symbols tool for {} since {}",
+        params.path, params.since
+    );
+
+    let path = PathBuf::from(&params.path);
+    if !path.exists() {
+        return Err(ToolError::new(
+            error_codes::INVALID_PARAMS,
+            format!("Path does not exist: {}", params.path),
+        ));
+    }
+
+    let changed = changed_symbols_since(&path, &params.since).map_err(|e| match e {
+        ValknutError::Validation { .. } => {
+            ToolError::new(error_codes::INVALID_PARAMS, e.to_string()).with_details(&e)
+        }
+        _ => {
+            error!("Diffing changed symbols failed: {}", e);
+            ToolError::new(
+                error_codes::ANALYSIS_ERROR,
+                format!("Diffing changed symbols failed: {}", e),
+            )
+            .with_details(&e)
+        }
+    })?;
+
+    let formatted = serde_json::to_string_pretty(&changed).map_err(|e| {
+        ToolError::new(
+            error_codes::INTERNAL_ERROR,
+            format!("Failed to serialize changed symbols: {}", e),
+        )
+    })?;
+
+    Ok(ToolResult {
+        content: vec![ContentItem {
+            content_type: "text".to_string(),
+            text: formatted,
+        }],
+    })
+}
+
+/// Execute the build_context tool
+///
+/// Every candidate file and symbol is annotated with an estimated token count. Files are
+/// ranked by query relevance and recency and trimmed to fit the call's budget, the
+/// server's `--context-budget`, or [`VALKNUT_OUTPUT_TOKEN_BUDGET`] when neither is set.
+/// File contents are only returned under an explicit budget. `.sql` files also list the
+/// tables they reference and the Go files that `//go:embed` them. Symbol lists
+/// only include the symbols `filter` accepts.
+pub async fn execute_build_context(
+    params: BuildContextParams,
+    default_budget: Option<ContextBudget>,
+    filter: Option<&SymbolFilter>,
+) -> Result<ToolResult, ToolError> {
+    info!("Executing build_context tool for path: {}", params.path);
+
+    let root = Path::new(&params.path);
+    if !root.exists() {
+        return Err(ToolError::new(
+            error_codes::INVALID_PARAMS,
+            format!("Path does not exist: {}", params.path),
+        ));
+    }
+
+    let max_tokens = params.max_tokens.or(default_budget.map(|b| b.max_tokens));
+    if params.include_content && max_tokens.is_none() {
+        return Err(ToolError::new(
+            error_codes::INVALID_PARAMS,
+            "include_content requires max_tokens when the server has no --context-budget"
+                .to_string(),
+        ));
+    }
+
+    let query = params.query.as_deref().unwrap_or_default();
+    let mut candidates = Vec::new();
+    let mut walker = ignore::WalkBuilder::new(root);
+    walker.add_custom_ignore_filename(IGNORE_FILE_NAME);
+    for entry in walker.build() {
+        let entry = match entry {
+            Ok(entry) => entry,
+            Err(err) => {
+                warn!("Failed to walk directory: {err}");
+                continue;
+            }
+        };
+        let path = entry.path();
+        if !(FileReader::is_code_file(path) || is_sql_file(path)) || !path.is_file() {
+            continue;
+        }
+        let Ok(content) = FileReader::read_to_string(path) else {
+            warn!("Skipping unreadable file: {}", path.display());
+            continue;
+        };
+        let display_path = path.to_string_lossy().into_owned();
+        candidates.push(ContextFile {
+            relevance: relevance_score(query, &display_path, &content),
+            references: 0,
+            modified: entry.metadata().ok().and_then(|m| m.modified().ok()),
+            path: display_path,
+            content,
+        });
+    }
+
+    let budget = ContextBudget::new(
+        max_tokens.unwrap_or(VALKNUT_OUTPUT_TOKEN_BUDGET),
+        params
+            .strategy
+            .or(default_budget.map(|b| b.strategy))
+            .unwrap_or_default(),
+    );
+    let context = budget.fit(candidates);
+    let embeds = GoEmbeds::scan(root);
+    let mut scope = scope_symbol_filter(filter, root)?;
+
+    let files: Vec<serde_json::Value> = context
+        .included
+        .iter()
+        .map(|file| {
+            let mut symbols = symbol_token_counts(&file.path, &file.content).unwrap_or_default();
+            if let Some(scope) = &mut scope {
+                symbols.retain(|symbol| {
+                    let (start_line, end_line) = symbol.line_range.unwrap_or_default();
+                    scope.keeps(Path::new(&file.path), &symbol.name, start_line, end_line)
+                });
+            }
+            let mut entry = serde_json::json!({
+                "path": file.path,
+                "tokens": file.tokens,
+                "original_tokens": file.original_tokens,
+                "truncated": file.truncated,
+                "symbols": symbols,
+            });
+            if is_sql_file(Path::new(&file.path)) {
+                entry["tables_referenced"] =
+                    serde_json::json!(parse_sql(&file.content).tables_referenced);
+                entry["embedded_by"] = serde_json::json!(embeds.embedders(Path::new(&file.path)));
+            }
+            if params.include_content {
+                entry["content"] = serde_json::json!(file.content);
+            }
+            entry
+        })
+        .collect();
+
+    let report = serde_json::json!({
+        "max_tokens": budget.max_tokens,
+        "strategy": budget.strategy,
+        "total_tokens": context.total_tokens,
+        "files": files,
+        "dropped": context.dropped,
+    });
+
+    let formatted = serde_json::to_string_pretty(&report).map_err(|e| {
+        ToolError::new(
+            error_codes::INTERNAL_ERROR,
+            format!("Failed to serialize context bundle: {}", e),
+        )
+    })?;
+
+    Ok(ToolResult {
+        content: vec![ContentItem {
+            content_type: "text".to_string(),
+            text: formatted,
+        }],
+    })
+}
+
+/// Bind the server's `--symbol-filter` to `root`.
+fn scope_symbol_filter<'f>(
+    filter: Option<&'f SymbolFilter>,
+    root: &Path,
+) -> Result<Option<SymbolScope<'f>>, ToolError> {
+    filter
+        .map(|filter| {
+            filter.scope(root).map_err(|e| {
+                ToolError::new(
+                    error_codes::ANALYSIS_ERROR,
+                    format!("Symbol filter '{}' failed: {}", filter, e),
+                )
+                .with_details(&e)
+            })
+        })
+        .transpose()
+}
+
+/// File declaring a symbol listed under `root`: listings report paths relative
+/// to a directory, and the path itself when `root` is a single file.
+fn symbol_file(root: &Path, file_path: &Path) -> PathBuf {
+    if root.is_file() {
+        root.to_path_buf()
+    } else {
+        root.join(file_path)
+    }
+}
+
 /// Evaluate quality gates against analysis results
 fn evaluate_quality_gates(
     results: &AnalysisResults,
@@ -26,6 +26,8 @@ fn sample_results() -> AnalysisResults {
         critical_issues: 1,
         doc_health_score: 1.0,
         doc_issue_count: 0,
+        complexity: Default::default(),
+        timed_out: false,
     };
 
     let candidate = valknut_rs::api::results::RefactoringCandidate {
@@ -70,6 +72,7 @@ fn sample_results() -> AnalysisResults {
         issue_count: 2,
         suggestion_count: 1,
         coverage_percentage: None,
+        context: Default::default(),
     };
 
     let mut code_dictionary = CodeDictionary::default();
@@ -116,11 +119,22 @@ fn sample_results() -> AnalysisResults {
         coverage_packs: Vec::new(),
         warnings: Vec::new(),
         code_dictionary,
+        rule_findings: Vec::new(),
+        changed_files_only: false,
+        dependency_report: Vec::new(),
+        interface_report: None,
+        project_health: None,
+        context_statistics: Default::default(),
         documentation: None,
         directory_health: HashMap::new(),
         file_health: HashMap::new(),
         entity_health: HashMap::new(),
         directory_health_tree: None,
+        skipped_files: Vec::new(),
+        generated_code: Default::default(),
+        notebook_metadata: Vec::new(),
+        duplicates: Default::default(),
+        file_complexity: Default::default(),
     }
 }
 
@@ -585,11 +599,11 @@ async fn execute_analyze_code_returns_invalid_params_for_missing_path() {
         .await
         .expect_err("non-existent paths should be rejected early");
 
-    assert_eq!(err.0, error_codes::INVALID_PARAMS);
+    assert_eq!(err.code, error_codes::INVALID_PARAMS);
     assert!(
-        err.1.contains("does not exist"),
+        err.message.contains("does not exist"),
         "unexpected error message: {}",
-        err.1
+        err.message
     );
 }
 
@@ -604,11 +618,11 @@ async fn execute_refactoring_suggestions_rejects_empty_entity_id() {
         .await
         .expect_err("empty entity ids should fail validation");
 
-    assert_eq!(err.0, error_codes::INVALID_PARAMS);
+    assert_eq!(err.code, error_codes::INVALID_PARAMS);
     assert!(
-        err.1.to_lowercase().contains("entity id"),
+        err.message.to_lowercase().contains("entity id"),
         "unexpected error message: {}",
-        err.1
+        err.message
     );
 }
 
@@ -632,11 +646,11 @@ async fn execute_validate_quality_gates_requires_existing_path() {
         .await
         .expect_err("missing directories should yield validation errors");
 
-    assert_eq!(err.0, error_codes::INVALID_PARAMS);
+    assert_eq!(err.code, error_codes::INVALID_PARAMS);
     assert!(
-        err.1.contains("does not exist"),
+        err.message.contains("does not exist"),
         "unexpected error message: {}",
-        err.1
+        err.message
     );
 }
 
@@ -657,11 +671,11 @@ async fn execute_analyze_file_quality_requires_real_files() {
         .await
         .expect_err("missing files should be rejected");
 
-    assert_eq!(err.0, error_codes::INVALID_PARAMS);
+    assert_eq!(err.code, error_codes::INVALID_PARAMS);
     assert!(
-        err.1.contains("does not exist"),
+        err.message.contains("does not exist"),
         "unexpected error message: {}",
-        err.1
+        err.message
     );
 }
 
@@ -677,10 +691,391 @@ async fn execute_analyze_file_quality_rejects_directory_paths() {
         .await
         .expect_err("directories should not be accepted as file inputs");
 
-    assert_eq!(err.0, error_codes::INVALID_PARAMS);
+    assert_eq!(err.code, error_codes::INVALID_PARAMS);
     assert!(
-        err.1.contains("not a file"),
+        err.message.contains("not a file"),
         "unexpected error message: {}",
-        err.1
+        err.message
     );
 }
+
+#[tokio::test]
+async fn execute_package_importers_lists_transitive_importers() {
+    let project = tempdir().expect("temp dir");
+    let root = project.path();
+    fs::write(root.join("go.mod"), "module example.com/app\n").unwrap();
+    fs::write(
+        root.join("main.go"),
+        "package main\n\nimport \"example.com/app/api\"\n",
+    )
+    .unwrap();
+    fs::create_dir_all(root.join("api")).unwrap();
+    fs::write(
+        root.join("api/api.go"),
+        "package api\n\nimport \"github.com/go-chi/chi/v5\"\n",
+    )
+    .unwrap();
+
+    let params = PackageImportersParams {
+        path: root.to_string_lossy().into_owned(),
+        package: "github.com/go-chi/chi/v5".to_string(),
+    };
+    let result = execute_package_importers(params)
+        .await
+        .expect("package importers should succeed");
+
+    let payload: serde_json::Value =
+        serde_json::from_str(&result.content[0].text).expect("valid json payload");
+    assert_eq!(payload["origin"], "third_party");
+    assert_eq!(
+        payload["importers"],
+        serde_json::json!(["example.com/app", "example.com/app/api"])
+    );
+}
+
+#[tokio::test]
+async fn execute_package_importers_rejects_missing_path() {
+    let params = PackageImportersParams {
+        path: "/definitely/missing/project".to_string(),
+        package: "fmt".to_string(),
+    };
+
+    let err = execute_package_importers(params)
+        .await
+        .expect_err("missing paths should be rejected");
+
+    assert_eq!(err.code, error_codes::INVALID_PARAMS);
+}
+
+#[tokio::test]
+async fn execute_build_context_ranks_by_query_and_respects_budget() {
+    let tmp = tempdir().unwrap();
+    let root = tmp.path();
+    fs::write(
+        root.join("billing.py"),
+        "def charge(invoice):\n    return invoice.total\n",
+    )
+    .unwrap();
+    fs::write(
+        root.join("unrelated.py"),
+        "def helper(values):\n    return [value * 2 for value in values]\n",
+    )
+    .unwrap();
+
+    let params = BuildContextParams {
+        path: root.to_string_lossy().into_owned(),
+        query: Some("invoice".to_string()),
+        max_tokens: Some(20),
+        strategy: None,
+        include_content: false,
+    };
+    let result = execute_build_context(params, None, None)
+        .await
+        .expect("build_context should succeed");
+
+    let payload: serde_json::Value =
+        serde_json::from_str(&result.content[0].text).expect("valid json payload");
+    let files = payload["files"].as_array().expect("files array");
+    assert_eq!(files.len(), 1);
+    assert!(files[0]["path"].as_str().unwrap().ends_with("billing.py"));
+    assert_eq!(files[0]["symbols"][0]["name"], "charge");
+    assert!(files[0].get("content").is_none());
+    assert_eq!(payload["dropped"].as_array().unwrap().len(), 1);
+    assert!(payload["total_tokens"].as_u64().unwrap() <= 20);
+}
+
+#[tokio::test]
+async fn execute_build_context_requires_budget_for_content() {
+    let tmp = tempdir().unwrap();
+    fs::write(tmp.path().join("main.py"), "def main():\n    pass\n").unwrap();
+
+    let params = BuildContextParams {
+        path: tmp.path().to_string_lossy().into_owned(),
+        query: None,
+        max_tokens: None,
+        strategy: None,
+        include_content: true,
+    };
+    let err = execute_build_context(params, None, None)
+        .await
+        .expect_err("contents without a budget should be rejected");
+
+    assert_eq!(err.code, error_codes::INVALID_PARAMS);
+}
+
+#[tokio::test]
+async fn execute_build_context_reports_sql_tables_and_embedders() {
+    let tmp = tempdir().unwrap();
+    let root = tmp.path();
+    fs::write(
+        root.join("queries.sql"),
+        "-- name: GetUser :one\nSELECT id FROM users JOIN orgs ON orgs.id = users.org_id;\n",
+    )
+    .unwrap();
+    fs::write(
+        root.join("db.go"),
+        "package db\n\n//go:embed queries.sql\nvar queries string\n",
+    )
+    .unwrap();
+
+    let params = BuildContextParams {
+        path: root.to_string_lossy().into_owned(),
+        query: None,
+        max_tokens: None,
+        strategy: None,
+        include_content: false,
+    };
+    let result = execute_build_context(params, None, None)
+        .await
+        .expect("build_context should succeed");
+
+    let payload: serde_json::Value =
+        serde_json::from_str(&result.content[0].text).expect("valid json payload");
+    assert_eq!(payload["max_tokens"], VALKNUT_OUTPUT_TOKEN_BUDGET);
+    let files = payload["files"].as_array().expect("files array");
+    let sql = files
+        .iter()
+        .find(|file| file["path"].as_str().unwrap().ends_with("queries.sql"))
+        .expect("sql file included");
+    assert_eq!(
+        sql["tables_referenced"],
+        serde_json::json!(["orgs", "users"])
+    );
+    let embedders = sql["embedded_by"].as_array().unwrap();
+    assert_eq!(embedders.len(), 1);
+    assert!(embedders[0].as_str().unwrap().ends_with("db.go"));
+
+    let go = files
+        .iter()
+        .find(|file| file["path"].as_str().unwrap().ends_with("db.go"))
+        .expect("go file included");
+    assert!(go.get("tables_referenced").is_none());
+}
+
+#[tokio::test]
+async fn execute_find_references_reports_enclosing_scopes() {
+    let tmp = tempdir().unwrap();
+    let root = tmp.path();
+    fs::write(
+        root.join("app.py"),
+        "def load():\n    return 1\n\ndef main():\n    return load()\n",
+    )
+    .unwrap();
+
+    let params = FindReferencesParams {
+        path: root.to_string_lossy().into_owned(),
+        symbol: "load".to_string(),
+    };
+    let result = execute_find_references(params)
+        .await
+        .expect("find_references should succeed");
+
+    let payload: serde_json::Value =
+        serde_json::from_str(&result.content[0].text).expect("valid json payload");
+    assert_eq!(payload["reference_count"], 2);
+    assert_eq!(payload["references"][0]["is_definition"], true);
+    assert_eq!(payload["references"][1]["line"], 5);
+    assert_eq!(payload["references"][1]["enclosing"], "main");
+}
+
+#[tokio::test]
+async fn execute_search_symbols_pages_with_cursor() {
+    let tmp = tempdir().unwrap();
+    let root = tmp.path();
+    fs::write(
+        root.join("handlers.py"),
+        "def login_handler():\n    pass\n\ndef logout_handler():\n    pass\n\ndef helper():\n    pass\n",
+    )
+    .unwrap();
+
+    let params = |cursor: Option<String>| SearchSymbolsParams {
+        path: root.to_string_lossy().into_owned(),
+        pattern: "_HANDLER$".to_string(),
+        case_insensitive: true,
+        cursor,
+        limit: 1,
+    };
+    let first = execute_search_symbols(params(None), None)
+        .await
+        .expect("search_symbols should succeed");
+    let first: serde_json::Value =
+        serde_json::from_str(&first.content[0].text).expect("valid json payload");
+    assert_eq!(first["total"], 2);
+    assert_eq!(first["matches"][0]["name"], "login_handler");
+    assert_eq!(first["matches"][0]["start_line"], 1);
+    assert_eq!(first["matches"][0]["signature"], "def login_handler()");
+
+    let cursor = first["next_cursor"].as_str().map(str::to_string);
+    let second = execute_search_symbols(params(cursor), None)
+        .await
+        .expect("second page should succeed");
+    let second: serde_json::Value =
+        serde_json::from_str(&second.content[0].text).expect("valid json payload");
+    assert_eq!(second["matches"][0]["name"], "logout_handler");
+    assert!(second["next_cursor"].is_null());
+}
+
+#[tokio::test]
+async fn execute_search_symbols_applies_the_symbol_filter() {
+    let tmp = tempdir().unwrap();
+    fs::write(
+        tmp.path().join("client.go"),
+        "package client\n\n// Dial opens a connection.\nfunc Dial(addr string) error {\n\treturn nil\n}\n\nfunc DialTimeout(addr string) error {\n\treturn nil\n}\n\nfunc dialRaw() {}\n",
+    )
+    .unwrap();
+    let params = || SearchSymbolsParams {
+        path: tmp.path().to_string_lossy().into_owned(),
+        pattern: "(?i)^dial".to_string(),
+        case_insensitive: false,
+        cursor: None,
+        limit: 10,
+    };
+    let names = |result: ToolResult| {
+        let payload: serde_json::Value =
+            serde_json::from_str(&result.content[0].text).expect("valid json payload");
+        payload["matches"]
+            .as_array()
+            .unwrap()
+            .iter()
+            .map(|symbol| symbol["name"].as_str().unwrap().to_string())
+            .collect::<Vec<_>>()
+    };
+
+    let exported: SymbolFilter = "exported".parse().unwrap();
+    let result = execute_search_symbols(params(), Some(&exported))
+        .await
+        .expect("filtered search should succeed");
+    assert_eq!(names(result), vec!["Dial", "DialTimeout"]);
+
+    let documented: SymbolFilter = "exported,documented".parse().unwrap();
+    let result = execute_search_symbols(params(), Some(&documented))
+        .await
+        .expect("filtered search should succeed");
+    assert_eq!(names(result), vec!["Dial"]);
+
+    let changed: SymbolFilter = "changed".parse().unwrap();
+    let ToolError { code, .. } = execute_search_symbols(params(), Some(&changed))
+        .await
+        .expect_err("changed needs a git repository");
+    assert_eq!(code, error_codes::ANALYSIS_ERROR);
+}
+
+#[tokio::test]
+async fn execute_search_symbols_rejects_invalid_regex() {
+    let tmp = tempdir().unwrap();
+    let params = SearchSymbolsParams {
+        path: tmp.path().to_string_lossy().into_owned(),
+        pattern: "(".to_string(),
+        case_insensitive: false,
+        cursor: None,
+        limit: 10,
+    };
+    let ToolError { code, .. } = execute_search_symbols(params, None)
+        .await
+        .expect_err("invalid pattern should fail");
+    assert_eq!(code, error_codes::INVALID_PARAMS);
+}
+
+#[tokio::test]
+async fn execute_top_level_symbols_lists_exported_api() {
+    let tmp = tempdir().unwrap();
+    fs::write(
+        tmp.path().join("client.go"),
+        "package client\n\n// Dial opens a connection.\nfunc Dial(addr string) error {\n\treturn nil\n}\n\nfunc retry() {}\n",
+    )
+    .unwrap();
+
+    let result = execute_top_level_symbols(
+        TopLevelSymbolsParams {
+            path: tmp.path().to_string_lossy().into_owned(),
+        },
+        None,
+    )
+    .await
+    .expect("get_top_level_symbols should succeed");
+    let payload: serde_json::Value =
+        serde_json::from_str(&result.content[0].text).expect("valid json payload");
+    assert_eq!(payload["symbol_count"], 1);
+    let dial = &payload["symbols"][0];
+    assert_eq!(dial["name"], "Dial");
+    assert_eq!(dial["doc"], "Dial opens a connection.");
+    assert_eq!(dial["signature"], "func Dial(addr string) error");
+    assert!(dial["since_version"].is_null());
+}
+
+#[tokio::test]
+async fn execute_top_level_symbols_rejects_missing_path() {
+    let ToolError { code, .. } = execute_top_level_symbols(
+        TopLevelSymbolsParams {
+            path: "/definitely/not/here".to_string(),
+        },
+        None,
+    )
+    .await
+    .expect_err("missing path should fail");
+    assert_eq!(code, error_codes::INVALID_PARAMS);
+}
+
+#[tokio::test]
+async fn execute_changed_symbols_requires_a_git_repository() {
+    let tmp = tempdir().unwrap();
+    let params: ChangedSymbolsParams = serde_json::from_value(serde_json::json!({
+        "path": tmp.path().to_string_lossy(),
+    }))
+    .unwrap();
+    assert_eq!(params.since, "HEAD~1");
+
+    let err = execute_changed_symbols(params)
+        .await
+        .expect_err("directories outside a repository should be rejected");
+
+    assert_eq!(err.code, error_codes::INVALID_PARAMS);
+    assert!(err.message.contains("is not in a git repository"));
+}
+
+#[tokio::test]
+async fn execute_hot_symbols_ranks_by_reference_count() {
+    let tmp = tempdir().unwrap();
+    fs::create_dir(tmp.path().join("client")).unwrap();
+    fs::write(
+        tmp.path().join("client/client.go"),
+        "package client\n\nfunc Dial(addr string) error {\n\treturn nil\n}\n\nfunc Close() {}\n",
+    )
+    .unwrap();
+    fs::write(
+        tmp.path().join("main.go"),
+        "package main\n\nfunc main() {\n\t_ = client.Dial(\"db\")\n}\n",
+    )
+    .unwrap();
+    fs::write(
+        tmp.path().join("client/client_test.go"),
+        "package client\n\nfunc TestClose(t *testing.T) {\n\tClose()\n}\n",
+    )
+    .unwrap();
+
+    let params: HotSymbolsParams = serde_json::from_value(serde_json::json!({
+        "path": tmp.path().to_string_lossy(),
+    }))
+    .unwrap();
+    let result = execute_hot_symbols(params, None)
+        .await
+        .expect("get_hot_symbols should succeed");
+    let payload: serde_json::Value =
+        serde_json::from_str(&result.content[0].text).expect("valid json payload");
+    assert_eq!(payload["symbol_count"], 2);
+    assert_eq!(payload["symbols"][0]["name"], "Dial");
+    assert_eq!(payload["symbols"][0]["reference_count"], 1);
+    assert_eq!(payload["symbols"][1]["name"], "Close");
+    assert_eq!(payload["symbols"][1]["test_reference_count"], 1);
+
+    let ToolError { code, .. } = execute_hot_symbols(
+        HotSymbolsParams {
+            path: tmp.path().join("main.go").to_string_lossy().into_owned(),
+            limit: 5,
+        },
+        None,
+    )
+    .await
+    .expect_err("files should be rejected");
+    assert_eq!(code, error_codes::INVALID_PARAMS);
+}
@@ -8,39 +8,129 @@
 use clap::Parser;
 
 mod cli;
+mod grpc;
+mod http;
 mod mcp;
+mod worker_pool;
 
+use cli::args::{LogFormat, LogLevel};
 use cli::{Cli, Commands};
 
 /// Entry point for the valknut CLI.
-#[tokio::main]
-async fn main() -> anyhow::Result<()> {
-    let cli = Cli::parse();
+fn main() -> anyhow::Result<()> {
+    let args = cli::flag_config::apply_flag_files(std::env::args_os().collect())?;
+    let cli = Cli::parse_from(args);
 
-    run_cli(cli).await
+    build_runtime(&cli)?.block_on(run_cli(cli))
 }
 
-/// Initialize tracing/logging based on verbosity setting.
-fn init_logging(verbose: bool) {
-    let log_level = if verbose {
-        tracing::Level::DEBUG
-    } else {
-        tracing::Level::INFO
+/// Build the async runtime, sized by `analyze --concurrency-limit` when given.
+///
+/// A limit of 1 runs every task on the main thread, with blocking parses on a
+/// single helper thread, so at most one parse runs alongside the main thread.
+/// Larger limits cap the worker threads and, separately, the blocking-parse
+/// threads, so up to twice the limit may be busy at once. The discovery walk
+/// and the rayon pool are capped to the limit by the analyze command.
+fn build_runtime(cli: &Cli) -> std::io::Result<tokio::runtime::Runtime> {
+    let limit = match &cli.command {
+        Commands::Analyze(args) => args
+            .analysis_control
+            .concurrency_limit
+            .map(|limit| limit.resolve()),
+        _ => None,
     };
-    let _ = tracing_subscriber::fmt()
-        .with_max_level(log_level)
+    let mut builder = match limit {
+        Some(1) => tokio::runtime::Builder::new_current_thread(),
+        Some(threads) => {
+            let mut builder = tokio::runtime::Builder::new_multi_thread();
+            builder.worker_threads(threads);
+            builder
+        }
+        None => tokio::runtime::Builder::new_multi_thread(),
+    };
+    if let Some(threads) = limit {
+        builder.max_blocking_threads(threads);
+    }
+    builder.enable_all().build()
+}
+
+/// Initialize tracing/logging on stderr so stdout only carries command output.
+fn init_logging(level: tracing::Level, format: LogFormat) {
+    let builder = tracing_subscriber::fmt()
+        .with_max_level(level)
+        .with_target(false)
+        .with_writer(std::io::stderr);
+    let _ = match format {
+        LogFormat::Text => builder.try_init(),
+        LogFormat::Json => builder.json().try_init(),
+    };
+}
+
+/// Maximum log level for `--log-level`, falling back to the `--verbose` setting.
+fn log_level(level: Option<LogLevel>, verbose: bool) -> tracing::Level {
+    match level {
+        Some(LogLevel::Trace) => tracing::Level::TRACE,
+        Some(LogLevel::Debug) => tracing::Level::DEBUG,
+        Some(LogLevel::Info) => tracing::Level::INFO,
+        Some(LogLevel::Warn) => tracing::Level::WARN,
+        Some(LogLevel::Error) => tracing::Level::ERROR,
+        None if verbose => tracing::Level::DEBUG,
+        None => tracing::Level::INFO,
+    }
+}
+
+/// Initializes logging plus a Chrome trace writer for `analyze --trace`.
+///
+/// The returned guard flushes the trace file when dropped.
+#[cfg(feature = "profiling")]
+fn init_logging_with_trace(
+    level: tracing::Level,
+    format: LogFormat,
+    trace: &std::path::Path,
+) -> tracing_chrome::FlushGuard {
+    use tracing_subscriber::filter::LevelFilter;
+    use tracing_subscriber::prelude::*;
+
+    let (chrome, guard) = tracing_chrome::ChromeLayerBuilder::new()
+        .file(trace)
+        .include_args(true)
+        .build();
+    let fmt_layer = tracing_subscriber::fmt::layer()
         .with_target(false)
+        .with_writer(std::io::stderr);
+    let fmt_layer = match format {
+        LogFormat::Text => fmt_layer.boxed(),
+        LogFormat::Json => fmt_layer.json().boxed(),
+    };
+    let _ = tracing_subscriber::registry()
+        .with(chrome)
+        .with(fmt_layer.with_filter(LevelFilter::from_level(level)))
         .try_init();
+    guard
 }
 
 /// Runs the CLI with the parsed command and options.
 async fn run_cli(cli: Cli) -> anyhow::Result<()> {
-    init_logging(cli.verbose);
+    let trace = match &cli.command {
+        Commands::Analyze(args) => args.profiling.trace.clone(),
+        _ => None,
+    };
+    let level = log_level(cli.log_level, cli.verbose);
+    #[cfg(feature = "profiling")]
+    let _trace_guard = trace
+        .as_deref()
+        .map(|path| init_logging_with_trace(level, cli.log_format, path));
+    #[cfg(not(feature = "profiling"))]
+    if trace.is_some() {
+        anyhow::bail!("--trace requires a build with the `profiling` feature (cargo build --release --features profiling)");
+    }
+    init_logging(level, cli.log_format);
     let Cli {
         command,
         survey,
         survey_verbosity,
         verbose,
+        ..
     } = cli;
 
     match command {
@@ -49,18 +139,44 @@ async fn run_cli(cli: Cli) -> anyhow::Result<()> {
             cli::analyze_command(*args, survey, survey_verbosity, verbose).await
         }
         Commands::DocAudit(args) => cli::doc_audit_command(args),
+        Commands::Xref(args) => cli::xref_command(args),
+        Commands::Diff(args) => cli::diff_command(args),
+        Commands::Compare(args) => cli::compare_command(args),
+        Commands::Archive(args) => cli::archive_command(args),
+        Commands::Stats(args) => cli::stats_command(args),
+        Commands::Export(args) => cli::export_command(args),
+        Commands::TokenBudget(args) => cli::token_budget_command(args),
+        Commands::Openapi(args) => cli::openapi_command(args),
+        Commands::Tui(args) => cli::tui_command(args),
+        Commands::Fmt(args) => cli::fmt_command(args).await,
+        Commands::Blame(args) => cli::blame_command(args),
+        Commands::Review(args) => cli::review_command(args),
 
         // Configuration commands
         Commands::PrintDefaultConfig => cli::print_default_config().await,
+        Commands::Init(args) => cli::init_command(args),
         Commands::InitConfig(args) => cli::init_config(args).await,
         Commands::ValidateConfig(args) => cli::validate_config(args).await,
+        Commands::Validate(args) => cli::validate_command(args),
+        Commands::Check(args) => cli::check_command(args).await,
+        Commands::Lint(args) => cli::lint_command(args).await,
+        Commands::Config(args) => cli::config_command(args),
+        Commands::Migrate(args) => cli::migrate_command(args),
+        Commands::Doctor(args) => cli::doctor_command(args),
 
         // MCP commands
         Commands::McpStdio(args) => cli::mcp_stdio_command(args, survey, survey_verbosity).await,
         Commands::McpManifest(args) => cli::mcp_manifest_command(args).await,
+        Commands::Mcp(args) => cli::mcp_command(args),
 
         // Info commands
         Commands::ListLanguages => cli::list_languages().await,
+        Commands::Plugins(args) => cli::plugins_command(args),
+
+        // Long-running commands
+        Commands::Watch(args) => cli::watch_command(args).await,
+        Commands::Serve(args) => cli::serve_command(args).await,
+        Commands::GrpcClient(args) => cli::grpc_client_command(args).await,
     }
 }
 
@@ -69,8 +185,10 @@ mod tests {
     use super::*;
     use clap::Parser;
     use cli::args::{
-        DocAuditFormat, InitConfigArgs, McpManifestArgs, OutputFormat, SurveyVerbosity,
-        ValidateConfigArgs,
+        ArchiveCommand, BlameFormat, CheckFormat, CompareFormat, DiffFormat, DocAuditFormat, ExportFormat, InitConfigArgs,
+        McpManifestArgs,
+        OpenApiFormat, OutputFormat, ReviewFormat, SurveyVerbosity, TokenBudgetFormat, ValidateConfigArgs,
+        XrefFormat,
     };
     use std::path::PathBuf;
     use tempfile::tempdir;
@@ -186,11 +304,707 @@ mod tests {
         }
     }
 
+    #[tokio::test]
+    async fn test_cli_parsing_config_validate() {
+        let cli = Cli::parse_from(["valknut", "config", "validate", "--file", "valknut.toml"]);
+        match cli.command {
+            Commands::Config(args) => match args.command {
+                cli::args::ConfigCommand::Validate(args) => {
+                    assert_eq!(args.file, Some(PathBuf::from("valknut.toml")));
+                }
+            },
+            _ => panic!("Expected Config command"),
+        }
+    }
+
+    #[test]
+    fn test_cli_parsing_mcp_install() {
+        let cli = Cli::parse_from(["valknut", "mcp", "install", "--dry-run", "--no-restart"]);
+        match cli.command {
+            Commands::Mcp(args) => match args.command {
+                cli::args::McpCommand::Install(args) => {
+                    assert!(args.dry_run);
+                    assert!(args.no_restart);
+                    assert_eq!(args.name, "valknut");
+                    assert_eq!(args.config_path, None);
+                }
+                _ => panic!("Expected mcp install"),
+            },
+            _ => panic!("Expected Mcp command"),
+        }
+    }
+
+    #[tokio::test]
+    async fn test_cli_parsing_profiling_flags() {
+        let cli = Cli::parse_from([
+            "valknut",
+            "analyze",
+            "--cpuprofile",
+            "cpu.pb",
+            "--memprofile",
+            "heap.pb",
+            "--trace",
+            "trace.json",
+            "--format",
+            "json",
+        ]);
+        match cli.command {
+            Commands::Analyze(args) => {
+                assert_eq!(args.profiling.cpuprofile, Some(PathBuf::from("cpu.pb")));
+                assert_eq!(args.profiling.memprofile, Some(PathBuf::from("heap.pb")));
+                assert_eq!(args.profiling.trace, Some(PathBuf::from("trace.json")));
+                assert_eq!(args.format, vec![OutputFormat::Json]);
+            }
+            _ => panic!("Expected Analyze command"),
+        }
+    }
+
+    #[tokio::test]
+    async fn test_cli_parsing_stdin() {
+        let cli = Cli::parse_from(["valknut", "analyze", "--stdin", "--stdin-path=main.go"]);
+        match cli.command {
+            Commands::Analyze(args) => {
+                assert!(args.stdin);
+                assert_eq!(args.stdin_path, Some(PathBuf::from("main.go")));
+            }
+            _ => panic!("Expected Analyze command"),
+        }
+
+        assert!(Cli::try_parse_from(["valknut", "analyze", "--stdin-path", "main.go"]).is_err());
+        assert!(Cli::try_parse_from(["valknut", "analyze", "--stdin", "src"]).is_err());
+    }
+
+    #[tokio::test]
+    async fn test_cli_parsing_stream() {
+        let cli = Cli::parse_from(["valknut", "analyze", "--stream", "src"]);
+        match cli.command {
+            Commands::Analyze(args) => {
+                assert!(args.stream);
+                assert_eq!(args.paths, vec![PathBuf::from("src")]);
+            }
+            _ => panic!("Expected Analyze command"),
+        }
+
+        assert!(
+            Cli::try_parse_from(["valknut", "analyze", "--stream", "--format", "json"]).is_err()
+        );
+        assert!(Cli::try_parse_from(["valknut", "analyze", "--stream", "--quality-gate"]).is_err());
+    }
+
+    #[tokio::test]
+    async fn test_cli_parsing_init() {
+        let cli = Cli::parse_from(["valknut", "init", "services/api", "--gitignore"]);
+        match cli.command {
+            Commands::Init(args) => {
+                assert_eq!(args.dir, PathBuf::from("services/api"));
+                assert_eq!(args.output, PathBuf::from("valknut.toml"));
+                assert!(args.gitignore);
+                assert!(!args.no_gitignore);
+            }
+            _ => panic!("Expected Init command"),
+        }
+
+        assert!(Cli::try_parse_from(["valknut", "init", "--gitignore", "--no-gitignore"]).is_err());
+    }
+
+    #[tokio::test]
+    async fn test_cli_parsing_max_file_size() {
+        let cli = Cli::parse_from(["valknut", "analyze", "--max-file-size", "512kb"]);
+        match cli.command {
+            Commands::Analyze(args) => {
+                assert_eq!(args.analysis_control.max_file_size, Some(512 * 1024));
+            }
+            _ => panic!("Expected Analyze command"),
+        }
+
+        assert!(Cli::try_parse_from(["valknut", "analyze", "--max-file-size", "1tb"]).is_err());
+    }
+
+    #[tokio::test]
+    async fn test_cli_parsing_timeout() {
+        let cli = Cli::parse_from(["valknut", "analyze", "--timeout", "5m"]);
+        match cli.command {
+            Commands::Analyze(args) => {
+                assert_eq!(
+                    args.analysis_control.timeout,
+                    Some(std::time::Duration::from_secs(300))
+                );
+            }
+            _ => panic!("Expected Analyze command"),
+        }
+
+        assert!(Cli::try_parse_from(["valknut", "analyze", "--timeout", "soon"]).is_err());
+    }
+
+    #[tokio::test]
+    async fn test_cli_parsing_test_context_flags() {
+        let cli = Cli::parse_from(["valknut", "analyze", "--exclude-tests"]);
+        match cli.command {
+            Commands::Analyze(args) => {
+                assert!(args.analysis_control.exclude_tests);
+                assert!(!args.analysis_control.include_tests);
+            }
+            _ => panic!("Expected Analyze command"),
+        }
+
+        assert!(
+            Cli::try_parse_from(["valknut", "analyze", "--include-tests", "--exclude-tests"])
+                .is_err()
+        );
+    }
+
+    #[tokio::test]
+    async fn test_cli_parsing_include_vendor() {
+        let cli = Cli::parse_from(["valknut", "analyze", "--include-vendor"]);
+        match cli.command {
+            Commands::Analyze(args) => assert!(args.analysis_control.include_vendor),
+            _ => panic!("Expected Analyze command"),
+        }
+
+        let cli = Cli::parse_from(["valknut", "analyze"]);
+        match cli.command {
+            Commands::Analyze(args) => assert!(!args.analysis_control.include_vendor),
+            _ => panic!("Expected Analyze command"),
+        }
+
+        // The help text lists every directory discovery skips.
+        let command = <Cli as clap::CommandFactory>::command();
+        let help = command
+            .find_subcommand("analyze")
+            .and_then(|analyze| {
+                analyze
+                    .get_arguments()
+                    .find(|arg| arg.get_long() == Some("include-vendor"))
+            })
+            .and_then(|arg| arg.get_help())
+            .expect("--include-vendor has help text")
+            .to_string();
+        for dir in valknut_rs::core::pipeline::VENDOR_DIRECTORIES
+            .iter()
+            .chain(valknut_rs::core::pipeline::VCS_DIRECTORIES)
+        {
+            assert!(help.contains(dir), "help should mention {dir}");
+        }
+    }
+
+    #[tokio::test]
+    async fn test_cli_parsing_plugins() {
+        let cli = Cli::parse_from(["valknut", "plugins", "list", "--dir", "tools/plugins"]);
+        match cli.command {
+            Commands::Plugins(args) => match args.command {
+                cli::args::PluginsCommand::List(args) => {
+                    assert_eq!(args.dir, Some(PathBuf::from("tools/plugins")));
+                }
+                _ => panic!("Expected plugins list"),
+            },
+            _ => panic!("Expected Plugins command"),
+        }
+
+        let cli = Cli::parse_from(["valknut", "plugins", "parse", "policy.rules"]);
+        match cli.command {
+            Commands::Plugins(args) => match args.command {
+                cli::args::PluginsCommand::Parse(args) => {
+                    assert_eq!(args.file, PathBuf::from("policy.rules"));
+                    assert!(args.plugins.dir.is_none());
+                }
+                _ => panic!("Expected plugins parse"),
+            },
+            _ => panic!("Expected Plugins command"),
+        }
+    }
+
+    #[tokio::test]
+    async fn test_cli_parsing_watch() {
+        let cli = Cli::parse_from(["valknut", "watch", "src", "--debounce-ms", "50"]);
+        match cli.command {
+            Commands::Watch(args) => {
+                assert_eq!(args.path, PathBuf::from("src"));
+                assert_eq!(args.debounce_ms, 50);
+                assert!(args.socket.is_none());
+                assert!(!args.poll);
+            }
+            _ => panic!("Expected Watch command"),
+        }
+
+        let cli = Cli::parse_from(["valknut", "watch"]);
+        match cli.command {
+            Commands::Watch(args) => {
+                assert_eq!(args.debounce_ms, 200);
+                assert_eq!(args.poll_interval_ms, 1000);
+            }
+            _ => panic!("Expected Watch command"),
+        }
+
+        let cli = Cli::parse_from(["valknut", "watch", "--poll", "--poll-interval-ms", "5000"]);
+        match cli.command {
+            Commands::Watch(args) => {
+                assert!(args.poll);
+                assert_eq!(args.poll_interval_ms, 5000);
+            }
+            _ => panic!("Expected Watch command"),
+        }
+    }
+
+    #[test]
+    fn test_cli_parsing_archive() {
+        let cli = Cli::parse_from(["valknut", "archive", "--tag", "v1.2.3"]);
+        match cli.command {
+            Commands::Archive(args) => {
+                assert_eq!(args.tag.as_deref(), Some("v1.2.3"));
+                assert!(args.command.is_none());
+                assert!(args.from.ends_with("analysis-results.json"));
+            }
+            _ => panic!("Expected Archive command"),
+        }
+
+        let cli = Cli::parse_from([
+            "valknut",
+            "archive",
+            "prune",
+            "--keep-last",
+            "3",
+            "--archive-dir",
+            "snapshots",
+        ]);
+        match cli.command {
+            Commands::Archive(args) => {
+                assert!(matches!(
+                    args.command,
+                    Some(ArchiveCommand::Prune { keep_last: 3 })
+                ));
+                assert_eq!(args.archive_dir, Some(PathBuf::from("snapshots")));
+            }
+            _ => panic!("Expected Archive command"),
+        }
+
+        assert!(Cli::try_parse_from(["valknut", "archive"]).is_err());
+    }
+
+    #[test]
+    fn test_cli_parsing_dot_output() {
+        let cli = Cli::parse_from([
+            "valknut",
+            "analyze",
+            "--output-format",
+            "dot",
+            "--dot-max-nodes",
+            "40",
+        ]);
+        match cli.command {
+            Commands::Analyze(args) => {
+                assert!(matches!(args.format.as_slice(), [OutputFormat::Dot]));
+                assert_eq!(args.dot_max_nodes, Some(40));
+            }
+            _ => panic!("Expected Analyze command"),
+        }
+    }
+
+    #[test]
+    fn test_cli_parsing_serve() {
+        let cli = Cli::parse_from([
+            "valknut",
+            "serve",
+            "--grpc",
+            ":50051",
+            "--tls-cert",
+            "server.pem",
+            "--tls-key",
+            "server.key",
+        ]);
+        match cli.command {
+            Commands::Serve(args) => {
+                assert_eq!(args.grpc.as_deref(), Some(":50051"));
+                assert!(args.http.is_none());
+                assert_eq!(args.tls_cert, Some(PathBuf::from("server.pem")));
+                assert_eq!(args.tls_key, Some(PathBuf::from("server.key")));
+                assert!(args.max_concurrent.is_none());
+            }
+            _ => panic!("Expected Serve command"),
+        }
+
+        assert!(
+            Cli::try_parse_from(["valknut", "serve", "--grpc", ":1", "--tls-cert", "a.pem"])
+                .is_err(),
+            "--tls-cert without --tls-key should be rejected"
+        );
+
+        let cli = Cli::parse_from(["valknut", "server", "--http", ":8080", "--rate-limit", "20"]);
+        match cli.command {
+            Commands::Serve(args) => {
+                assert!(args.grpc.is_none());
+                assert_eq!(args.http.as_deref(), Some(":8080"));
+                assert_eq!(args.rate_limit, Some(20));
+            }
+            _ => panic!("Expected Serve command"),
+        }
+        assert!(Cli::try_parse_from(["valknut", "serve"]).is_err());
+        assert!(Cli::try_parse_from(["valknut", "serve", "--grpc", ":1", "--rate-limit", "5"]).is_err());
+
+        let cli = Cli::parse_from(["valknut", "grpc-client"]);
+        match cli.command {
+            Commands::GrpcClient(args) => assert_eq!(args.addr, "http://127.0.0.1:50051"),
+            _ => panic!("Expected GrpcClient command"),
+        }
+    }
+
+    #[test]
+    fn test_cli_parsing_analyze_remote_ref() {
+        let cli = Cli::parse_from([
+            "valknut",
+            "analyze",
+            "https://github.com/org/repo",
+            "--ref",
+            "v1.2.0",
+        ]);
+        match cli.command {
+            Commands::Analyze(args) => {
+                assert_eq!(
+                    args.paths,
+                    vec![PathBuf::from("https://github.com/org/repo")]
+                );
+                assert_eq!(args.remote.git_ref.as_deref(), Some("v1.2.0"));
+                assert_eq!(args.remote.depth, 1);
+            }
+            _ => panic!("Expected Analyze command"),
+        }
+    }
+
+    #[test]
+    fn test_cli_parsing_analyze_build_target() {
+        let cli = Cli::parse_from([
+            "valknut",
+            "analyze",
+            "--os",
+            "linux",
+            "--arch",
+            "amd64",
+            "--tags",
+            "integration,netgo",
+        ]);
+        match cli.command {
+            Commands::Analyze(args) => {
+                let control = args.analysis_control;
+                assert_eq!(control.target_os.as_deref(), Some("linux"));
+                assert_eq!(control.target_arch.as_deref(), Some("amd64"));
+                assert_eq!(control.tags, vec!["integration", "netgo"]);
+            }
+            _ => panic!("Expected Analyze command"),
+        }
+    }
+
+    #[test]
+    fn test_cli_parsing_xref() {
+        let cli = Cli::parse_from(["valknut", "xref", "pkg.Load", "--format", "json"]);
+        match cli.command {
+            Commands::Xref(args) => {
+                assert_eq!(args.symbol, "pkg.Load");
+                assert_eq!(args.path, PathBuf::from("."));
+                assert_eq!(args.format, XrefFormat::Json);
+            }
+            _ => panic!("Expected Xref command"),
+        }
+    }
+
+    #[test]
+    fn test_cli_parsing_stats() {
+        let cli = Cli::parse_from(["valknut", "stats", "src", "lib", "--json", "--top", "5"]);
+        match cli.command {
+            Commands::Stats(args) => {
+                assert_eq!(args.paths, vec![PathBuf::from("src"), PathBuf::from("lib")]);
+                assert!(args.json);
+                assert_eq!(args.top, 5);
+                assert!(args.cache_dir.is_none());
+            }
+            _ => panic!("Expected Stats command"),
+        }
+    }
+
+    #[test]
+    fn test_cli_parsing_export() {
+        let cli = Cli::parse_from([
+            "valknut",
+            "export",
+            "--format",
+            "markdown",
+            "-o",
+            "SUMMARY.md",
+        ]);
+        match cli.command {
+            Commands::Export(args) => {
+                assert_eq!(args.path, PathBuf::from("."));
+                assert_eq!(args.format, ExportFormat::Markdown);
+                assert_eq!(args.out, Some(PathBuf::from("SUMMARY.md")));
+            }
+            _ => panic!("Expected Export command"),
+        }
+    }
+
+    #[test]
+    fn test_cli_parsing_token_budget() {
+        let cli = Cli::parse_from([
+            "valknut",
+            "token-budget",
+            "--model",
+            "claude-3-5-sonnet",
+            "--budget",
+            "100000",
+            "--format",
+            "paths",
+            "services/api",
+        ]);
+        match cli.command {
+            Commands::TokenBudget(args) => {
+                assert_eq!(args.path, PathBuf::from("services/api"));
+                assert_eq!(args.model, "claude-3-5-sonnet");
+                assert_eq!(args.budget, Some(100_000));
+                assert_eq!(args.query, None);
+                assert_eq!(args.format, TokenBudgetFormat::Paths);
+            }
+            _ => panic!("Expected TokenBudget command"),
+        }
+
+        let cli = Cli::parse_from(["valknut", "export", "--format", "context", "."]);
+        match cli.command {
+            Commands::Export(args) => {
+                assert_eq!(args.format, ExportFormat::Context);
+                assert_eq!(args.files_from, PathBuf::from("-"));
+            }
+            _ => panic!("Expected Export command"),
+        }
+    }
+
+    #[test]
+    fn test_cli_parsing_openapi() {
+        let cli = Cli::parse_from([
+            "valknut",
+            "openapi",
+            "services/api",
+            "--format",
+            "yaml",
+            "--title",
+            "Orders API",
+            "-o",
+            "openapi.yaml",
+        ]);
+        match cli.command {
+            Commands::Openapi(args) => {
+                assert_eq!(args.path, PathBuf::from("services/api"));
+                assert_eq!(args.format, OpenApiFormat::Yaml);
+                assert_eq!(args.title.as_deref(), Some("Orders API"));
+                assert_eq!(args.out, Some(PathBuf::from("openapi.yaml")));
+            }
+            _ => panic!("Expected Openapi command"),
+        }
+    }
+
+    #[test]
+    fn test_cli_parsing_concurrency_limit() {
+        let cli = Cli::parse_from(["valknut", "analyze", "--concurrency-limit", "50%", "src"]);
+        match cli.command {
+            Commands::Analyze(args) => {
+                let limit = args.analysis_control.concurrency_limit.unwrap();
+                assert_eq!(limit.to_string(), "50%");
+                assert_eq!(limit.resolve_for(8), 4);
+            }
+            _ => panic!("Expected Analyze command"),
+        }
+
+        let cli = Cli::parse_from(["valknut", "analyze", "--concurrency-limit", "1"]);
+        match &cli.command {
+            Commands::Analyze(args) => {
+                assert_eq!(
+                    args.analysis_control.concurrency_limit.unwrap().resolve(),
+                    1
+                );
+            }
+            _ => panic!("Expected Analyze command"),
+        }
+        let runtime = build_runtime(&cli).unwrap();
+        assert_eq!(
+            runtime.handle().runtime_flavor(),
+            tokio::runtime::RuntimeFlavor::CurrentThread
+        );
+        assert!(Cli::try_parse_from(["valknut", "analyze", "--concurrency-limit", "0"]).is_err());
+    }
+
+    #[test]
+    fn test_cli_parsing_review() {
+        let cli = Cli::parse_from(["valknut", "review", "--base", "develop", "--format", "json"]);
+        match cli.command {
+            Commands::Review(args) => {
+                assert_eq!(args.path, PathBuf::from("."));
+                assert_eq!(args.base, "develop");
+                assert_eq!(args.format, ReviewFormat::Json);
+                assert!(args.out.is_none());
+            }
+            _ => panic!("Expected Review command"),
+        }
+    }
+
+    #[test]
+    fn test_cli_parsing_migrate() {
+        let cli = Cli::parse_from(["valknut", "migrate", "--dry-run"]);
+        match cli.command {
+            Commands::Migrate(args) => {
+                assert!(args.dry_run);
+                assert!(args.file.is_none());
+            }
+            _ => panic!("Expected Migrate command"),
+        }
+
+        let cli = Cli::parse_from(["valknut", "migrate", "service/valknut.toml"]);
+        match cli.command {
+            Commands::Migrate(args) => {
+                assert!(!args.dry_run);
+                assert_eq!(args.file, Some(PathBuf::from("service/valknut.toml")));
+            }
+            _ => panic!("Expected Migrate command"),
+        }
+    }
+
+    #[test]
+    fn test_cli_parsing_check() {
+        let cli = Cli::parse_from(["valknut", "check", "services", "--format", "json"]);
+        match cli.command {
+            Commands::Check(args) => {
+                assert_eq!(args.path, PathBuf::from("services"));
+                assert_eq!(args.format, CheckFormat::Json);
+                assert!(args.rules.is_none());
+            }
+            _ => panic!("Expected Check command"),
+        }
+    }
+
+    #[test]
+    fn test_cli_parsing_lint() {
+        let cli = Cli::parse_from(["valknut", "lint", "src", "--fix-dry-run"]);
+        match cli.command {
+            Commands::Lint(args) => {
+                assert_eq!(args.path, PathBuf::from("src"));
+                assert!(args.fix_dry_run);
+                assert!(!args.fix);
+            }
+            _ => panic!("Expected Lint command"),
+        }
+
+        assert!(Cli::try_parse_from(["valknut", "lint", "--fix", "--fix-dry-run"]).is_err());
+    }
+
+    #[test]
+    fn test_cli_parsing_doctor() {
+        let cli = Cli::parse_from(["valknut", "doctor"]);
+        match cli.command {
+            Commands::Doctor(args) => {
+                assert_eq!(args.path, PathBuf::from("."));
+                assert!(args.config.is_none());
+                assert!(args.cache_dir.is_none());
+            }
+            _ => panic!("Expected Doctor command"),
+        }
+    }
+
+    #[test]
+    fn test_cli_parsing_tui() {
+        let cli = Cli::parse_from(["valknut", "tui", "src", "--cache-dir", "/tmp/valknut"]);
+        match cli.command {
+            Commands::Tui(args) => {
+                assert_eq!(args.path, PathBuf::from("src"));
+                assert_eq!(args.cache_dir, Some(PathBuf::from("/tmp/valknut")));
+            }
+            _ => panic!("Expected Tui command"),
+        }
+    }
+
+    #[test]
+    fn test_cli_parsing_validate() {
+        let cli = Cli::parse_from(["valknut", "validate", "a.json", "b.jsonl"]);
+        match cli.command {
+            Commands::Validate(args) => {
+                assert_eq!(
+                    args.files,
+                    vec![PathBuf::from("a.json"), PathBuf::from("b.jsonl")]
+                );
+                assert!(!args.print_schema);
+            }
+            _ => panic!("Expected Validate command"),
+        }
+        assert!(Cli::try_parse_from(["valknut", "validate"]).is_err());
+        assert!(Cli::try_parse_from(["valknut", "validate", "--print-schema"]).is_ok());
+    }
+
+    #[test]
+    fn test_cli_parsing_blame() {
+        let cli = Cli::parse_from([
+            "valknut",
+            "blame",
+            "pkg/server",
+            "--top-owners",
+            "3",
+            "--format",
+            "json",
+        ]);
+        match cli.command {
+            Commands::Blame(args) => {
+                assert_eq!(args.path, PathBuf::from("pkg/server"));
+                assert_eq!(args.top_owners, Some(3));
+                assert_eq!(args.format, BlameFormat::Json);
+                assert!(!args.no_cache);
+            }
+            _ => panic!("Expected Blame command"),
+        }
+    }
+
+    #[test]
+    fn test_cli_parsing_fmt() {
+        let cli = Cli::parse_from(["valknut", "fmt", "src", "--check", "--workers", "2"]);
+        match cli.command {
+            Commands::Fmt(args) => {
+                assert_eq!(args.paths, vec![PathBuf::from("src")]);
+                assert!(args.check);
+                assert_eq!(args.workers, Some(2));
+                assert!(args.config.is_none());
+            }
+            _ => panic!("Expected Fmt command"),
+        }
+    }
+
+    #[test]
+    fn test_cli_parsing_diff() {
+        let cli = Cli::parse_from([
+            "valknut", "diff", "old.json", "new.json", "--format", "json",
+        ]);
+        match cli.command {
+            Commands::Diff(args) => {
+                assert_eq!(args.before, PathBuf::from("old.json"));
+                assert_eq!(args.after, PathBuf::from("new.json"));
+                assert_eq!(args.format, DiffFormat::Json);
+            }
+            _ => panic!("Expected Diff command"),
+        }
+    }
+
+    #[test]
+    fn test_cli_parsing_compare() {
+        let cli = Cli::parse_from([
+            "valknut", "compare", "svc-a", "svc-b", "--format", "json",
+        ]);
+        match cli.command {
+            Commands::Compare(args) => {
+                assert_eq!(args.repo_a, PathBuf::from("svc-a"));
+                assert_eq!(args.repo_b, PathBuf::from("svc-b"));
+                assert_eq!(args.format, CompareFormat::Json);
+            }
+            _ => panic!("Expected Compare command"),
+        }
+    }
+
     #[tokio::test]
     async fn test_run_cli_print_default_config_executes() {
         let cli = Cli {
             command: Commands::PrintDefaultConfig,
             verbose: false,
+            log_level: None,
+            log_format: LogFormat::Text,
             survey: false,
             survey_verbosity: SurveyVerbosity::Maximum,
         };
@@ -209,6 +1023,8 @@ mod tests {
                 force: true,
             }),
             verbose: false,
+            log_level: None,
+            log_format: LogFormat::Text,
             survey: false,
             survey_verbosity: SurveyVerbosity::Maximum,
         };
@@ -223,6 +1039,8 @@ mod tests {
                 verbose: true,
             }),
             verbose: false,
+            log_level: None,
+            log_format: LogFormat::Text,
             survey: false,
             survey_verbosity: SurveyVerbosity::Maximum,
         };
@@ -243,6 +1061,8 @@ mod tests {
                 output: Some(manifest_path.clone()),
             }),
             verbose: false,
+            log_level: None,
+            log_format: LogFormat::Text,
             survey: false,
             survey_verbosity: SurveyVerbosity::Maximum,
         };
@@ -258,6 +1078,8 @@ mod tests {
         let cli = Cli {
             command: Commands::ListLanguages,
             verbose: false,
+            log_level: None,
+            log_format: LogFormat::Text,
             survey: false,
             survey_verbosity: SurveyVerbosity::Maximum,
         };
@@ -267,6 +1089,28 @@ mod tests {
             .expect("list-languages command should succeed");
     }
 
+    #[test]
+    fn test_cli_parsing_log_flags() {
+        let cli = Cli::parse_from(["valknut", "analyze"]);
+        assert_eq!(cli.log_level, None);
+        assert_eq!(cli.log_format, LogFormat::Text);
+        assert_eq!(log_level(cli.log_level, cli.verbose), tracing::Level::INFO);
+
+        let cli = Cli::parse_from([
+            "valknut",
+            "analyze",
+            "--verbose",
+            "--log-level",
+            "warn",
+            "--log-format",
+            "json",
+        ]);
+        assert_eq!(cli.log_level, Some(LogLevel::Warn));
+        assert_eq!(cli.log_format, LogFormat::Json);
+        assert_eq!(log_level(cli.log_level, cli.verbose), tracing::Level::WARN);
+        assert_eq!(log_level(None, true), tracing::Level::DEBUG);
+    }
+
     #[test]
     fn test_cli_parsing_doc_audit_defaults() {
         let cli = Cli::parse_from(["valknut", "doc-audit"]);
@@ -340,6 +1184,27 @@ mod tests {
         }
     }
 
+    #[test]
+    fn test_cli_parsing_mcp_stdio_symbol_filter() {
+        let cli = Cli::parse_from([
+            "valknut",
+            "mcp-stdio",
+            "--symbol-filter",
+            "exported,changed",
+            "--symbol-filter-base",
+            "develop",
+        ]);
+        match cli.command {
+            Commands::McpStdio(args) => {
+                let filter = args.symbol_filter.expect("filter parsed");
+                assert_eq!(filter.to_string(), "exported,changed");
+                assert_eq!(args.symbol_filter_base, "develop");
+            }
+            _ => panic!("Expected McpStdio command"),
+        }
+        assert!(Cli::try_parse_from(["valknut", "mcp-stdio", "--symbol-filter", "public"]).is_err());
+    }
+
     #[tokio::test]
     async fn test_cli_parsing_mcp_manifest() {
         let cli = Cli::parse_from(["valknut", "mcp-manifest", "--output", "manifest.json"]);
@@ -385,11 +1250,14 @@ mod tests {
     async fn test_cli_parsing_output_format_variants() {
         let formats = [
             ("jsonl", OutputFormat::Jsonl),
+            ("ndjson", OutputFormat::Ndjson),
             ("json", OutputFormat::Json),
             ("yaml", OutputFormat::Yaml),
             ("markdown", OutputFormat::Markdown),
             ("html", OutputFormat::Html),
             ("sonar", OutputFormat::Sonar),
+            ("sarif", OutputFormat::Sarif),
+            ("junit", OutputFormat::Junit),
             ("csv", OutputFormat::Csv),
             ("ci-summary", OutputFormat::CiSummary),
             ("pretty", OutputFormat::Pretty),
@@ -0,0 +1,98 @@
+//! Bounded pool for the CPU-bound work of the network servers.
+//!
+//! Parsing, analysis and graph construction run on tokio's blocking pool so
+//! slow requests never stall the connection tasks. A semaphore caps how many
+//! requests are processed at once; callers beyond the cap wait for a permit.
+//! The gRPC and HTTP servers share one [`WorkerPool`] when both are running,
+//! so the cap applies to the process as a whole.
+
+use std::sync::Arc;
+
+use tokio::sync::{OwnedSemaphorePermit, Semaphore};
+
+/// Why a job could not run to completion.
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub enum WorkerError {
+    /// The pool was closed while the job waited for a slot.
+    ShuttingDown,
+    /// The job panicked or was cancelled.
+    Failed(String),
+}
+
+/// Display implementation for [`WorkerError`].
+impl std::fmt::Display for WorkerError {
+    fn fmt(&self, f: &mut std::fmt::Formatter<'_>) -> std::fmt::Result {
+        match self {
+            WorkerError::ShuttingDown => write!(f, "Server is shutting down"),
+            WorkerError::Failed(reason) => write!(f, "Request handler failed: {reason}"),
+        }
+    }
+}
+
+/// Semaphore-bounded access to the blocking pool; clones share the bound.
+#[derive(Debug, Clone)]
+pub struct WorkerPool {
+    permits: Arc<Semaphore>,
+}
+
+/// Construction and scheduling methods for [`WorkerPool`].
+impl WorkerPool {
+    /// Create a pool running at most `max_concurrent` jobs at once.
+    pub fn new(max_concurrent: usize) -> Self {
+        Self {
+            permits: Arc::new(Semaphore::new(max_concurrent.max(1))),
+        }
+    }
+
+    /// Pool size used when none is configured: the number of CPUs.
+    pub fn default_size() -> usize {
+        std::thread::available_parallelism()
+            .map(|n| n.get())
+            .unwrap_or(4)
+    }
+
+    /// Wait for a slot, then run `work` on the blocking pool.
+    pub async fn run<T, F>(&self, work: F) -> Result<T, WorkerError>
+    where
+        T: Send + 'static,
+        F: FnOnce() -> T + Send + 'static,
+    {
+        let permit = self.acquire().await?;
+        tokio::task::spawn_blocking(move || {
+            let _permit = permit;
+            work()
+        })
+        .await
+        .map_err(|e| WorkerError::Failed(e.to_string()))
+    }
+
+    /// Wait for a processing slot.
+    async fn acquire(&self) -> Result<OwnedSemaphorePermit, WorkerError> {
+        self.permits
+            .clone()
+            .acquire_owned()
+            .await
+            .map_err(|_| WorkerError::ShuttingDown)
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[tokio::test]
+    async fn jobs_hold_a_slot_while_running() {
+        let pool = WorkerPool::new(2);
+        let observed = pool.clone();
+        let available = pool
+            .run(move || observed.permits.available_permits())
+            .await
+            .unwrap();
+        assert_eq!(available, 1);
+        assert_eq!(pool.permits.available_permits(), 2);
+
+        let err = pool.run(|| -> usize { panic!("boom") }).await.unwrap_err();
+        assert!(matches!(err, WorkerError::Failed(_)));
+        assert_eq!(WorkerPool::new(0).permits.available_permits(), 1);
+    }
+}
@@ -51,33 +51,53 @@
 use bumpalo::Bump;
 use std::path::Path;
 use std::sync::Arc;
-use tracing::{debug, info};
+use tracing::{debug, info, warn};
 
 use crate::core::ast_service::AstService;
 use crate::core::errors::{Result, ValknutError};
 use crate::core::featureset::{CodeEntity, ExtractionContext};
 use crate::core::interned_entities::{InternedCodeEntity, InternedParseIndex};
 use crate::core::interning::{intern, resolve, InternedString, StringInterner};
+use crate::lang::common::DEFAULT_MAX_RECURSION_DEPTH;
 use crate::lang::{adapter_for_file, LanguageAdapter};
 
 /// Arena-based file analyzer that eliminates allocation churn during analysis
 pub struct ArenaFileAnalyzer {
     /// Shared AST service for parsing and caching
     ast_service: Arc<AstService>,
+    /// Deepest AST node entity extraction visits
+    max_ast_depth: usize,
+    /// Instant after which parses are abandoned (`--timeout`)
+    deadline: Option<std::time::Instant>,
 }
 
 /// Factory, configuration, and analysis methods for [`ArenaFileAnalyzer`].
 impl ArenaFileAnalyzer {
     /// Create a new arena-based file analyzer
     pub fn new() -> Self {
-        Self {
-            ast_service: Arc::new(AstService::new()),
-        }
+        Self::with_ast_service(Arc::new(AstService::new()))
     }
 
     /// Create analyzer with shared AST service
     pub fn with_ast_service(ast_service: Arc<AstService>) -> Self {
-        Self { ast_service }
+        Self {
+            ast_service,
+            max_ast_depth: DEFAULT_MAX_RECURSION_DEPTH,
+            deadline: None,
+        }
+    }
+
+    /// Fail files whose AST nests deeper than `depth` (`analysis.max_ast_depth`).
+    pub fn with_max_ast_depth(mut self, depth: usize) -> Self {
+        self.max_ast_depth = depth;
+        self
+    }
+
+    /// Abandon a parse still running once `deadline` has passed; the file
+    /// then fails with a parse error.
+    pub fn with_deadline(mut self, deadline: Option<std::time::Instant>) -> Self {
+        self.deadline = deadline;
+        self
     }
 
     /// Analyze a file using arena allocation for maximum performance
@@ -108,6 +128,8 @@ impl ArenaFileAnalyzer {
 
         // Get language adapter for this file
         let mut adapter = adapter_for_file(file_path)?;
+        adapter.set_max_ast_depth(self.max_ast_depth);
+        adapter.set_parse_deadline(self.deadline);
 
         // Perform arena-based entity extraction
         let analysis_result = self
@@ -373,6 +395,7 @@ fn count_lines_of_code(source: &str) -> usize {
 /// Arena-based batch analysis for multiple files
 pub struct ArenaBatchAnalyzer {
     file_analyzer: ArenaFileAnalyzer,
+    deadline: Option<std::time::Instant>,
 }
 
 /// Factory and batch analysis methods for [`ArenaBatchAnalyzer`].
@@ -381,12 +404,34 @@ impl ArenaBatchAnalyzer {
     pub fn new() -> Self {
         Self {
             file_analyzer: ArenaFileAnalyzer::new(),
+            deadline: None,
         }
     }
 
+    /// Fail files whose AST nests deeper than `depth` (`analysis.max_ast_depth`).
+    pub fn with_max_ast_depth(mut self, depth: usize) -> Self {
+        self.file_analyzer = self.file_analyzer.with_max_ast_depth(depth);
+        self
+    }
+
+    /// Stop once `deadline` has passed, abandoning the file being parsed;
+    /// files not reached are left out of the batch result.
+    pub fn with_deadline(mut self, deadline: Option<std::time::Instant>) -> Self {
+        self.file_analyzer = self.file_analyzer.with_deadline(deadline);
+        self.deadline = deadline;
+        self
+    }
+
+    /// Whether the deadline has passed.
+    fn past_deadline(&self) -> bool {
+        self.deadline
+            .is_some_and(|deadline| std::time::Instant::now() >= deadline)
+    }
+
     /// Analyze a batch of files with optimal arena usage
     ///
-    /// Each file gets its own arena for perfect isolation and cleanup.
+    /// Each file gets its own arena for perfect isolation and cleanup. Files
+    /// that fail to parse are logged and left out of the result.
     pub async fn analyze_batch(
         &self,
         files_and_sources: Vec<(&Path, &str)>,
@@ -404,10 +449,34 @@ impl ArenaBatchAnalyzer {
         );
 
         for (file_path, source_code) in files_and_sources {
-            let file_result = self
+            if self.past_deadline() {
+                info!(
+                    "Analysis deadline reached after {} of {} files",
+                    results.len(),
+                    file_count
+                );
+                break;
+            }
+            let file_result = match self
                 .file_analyzer
                 .analyze_file_in_arena(file_path, source_code)
-                .await?;
+                .await
+            {
+                Ok(file_result) => file_result,
+                // The parse was abandoned at the deadline: the file was not reached.
+                Err(ValknutError::Parse { .. }) if self.past_deadline() => {
+                    info!(
+                        "Analysis deadline reached while parsing {}",
+                        file_path.display()
+                    );
+                    break;
+                }
+                Err(e @ ValknutError::Parse { .. }) => {
+                    warn!("Skipping {}: {}", file_path.display(), e);
+                    continue;
+                }
+                Err(e) => return Err(e),
+            };
 
             total_entities += file_result.entity_count;
             total_arena_bytes += file_result.arena_bytes_used;
@@ -416,6 +485,7 @@ impl ArenaBatchAnalyzer {
         }
 
         let total_time = start_time.elapsed();
+        let file_count = results.len();
 
         let batch_result = ArenaBatchResult {
             file_results: results,
@@ -558,6 +628,59 @@ class TestClass:
         assert!(batch_result.arena_efficiency_score > 0.0);
     }
 
+    #[tokio::test]
+    async fn test_arena_batch_analysis_stops_at_deadline() {
+        let analyzer = ArenaBatchAnalyzer::new().with_deadline(Some(std::time::Instant::now()));
+
+        let test_file = PathBuf::from("test1.py");
+        let batch = analyzer
+            .analyze_batch(vec![(test_file.as_path(), "def func1(): pass")])
+            .await
+            .expect("expired deadline should still produce a result");
+
+        assert_eq!(batch.total_files, 0);
+        assert!(batch.file_results.is_empty());
+    }
+
+    #[tokio::test]
+    async fn test_arena_batch_analysis_abandons_a_parse_at_the_deadline() {
+        let deadline = std::time::Instant::now() + std::time::Duration::from_millis(50);
+        let analyzer = ArenaBatchAnalyzer::new().with_deadline(Some(deadline));
+
+        // Parsing this takes far longer than the deadline allows.
+        let endless = "value = [1, 2, 3]\n".repeat(2_000_000);
+        let endless_file = PathBuf::from("endless.py");
+        let later_file = PathBuf::from("later.py");
+        let batch = analyzer
+            .analyze_batch(vec![
+                (endless_file.as_path(), endless.as_str()),
+                (later_file.as_path(), "def func(): pass"),
+            ])
+            .await
+            .expect("an abandoned parse should not fail the batch");
+
+        assert_eq!(batch.total_files, 0);
+        assert!(batch.file_results.is_empty());
+    }
+
+    #[tokio::test]
+    async fn test_arena_batch_analysis_skips_files_with_syntax_errors() {
+        let analyzer = ArenaBatchAnalyzer::new();
+
+        let broken = PathBuf::from("broken.py");
+        let valid = PathBuf::from("valid.py");
+        let batch = analyzer
+            .analyze_batch(vec![
+                (broken.as_path(), "def broken(:\n    pass\n"),
+                (valid.as_path(), "def func(): pass"),
+            ])
+            .await
+            .expect("a syntax error should not fail the batch");
+
+        assert_eq!(batch.total_files, 1);
+        assert_eq!(batch.file_results[0].file_path_str(), "valid.py");
+    }
+
     #[tokio::test]
     async fn test_arena_batch_analysis_handles_empty_input() {
         let analyzer = ArenaBatchAnalyzer::new();
@@ -5,7 +5,9 @@
 
 use crate::core::errors::{Result, ValknutError};
 use crate::lang::common::{ParsedEntity, SourceLocation};
-use crate::lang::registry::{detect_language_from_path, get_tree_sitter_language};
+use crate::lang::registry::{
+    detect_language_from_path, get_tree_sitter_language, grammar_key_for_path,
+};
 use dashmap::DashMap;
 use std::collections::hash_map::DefaultHasher;
 use std::hash::{Hash, Hasher};
@@ -106,12 +108,13 @@ impl AstService {
 
         // Parse new tree using spawn_blocking for CPU-bound work
         let language_clone = language.clone();
+        let grammar_key = grammar_key_for_path(file_path);
         let source_clone = source.to_string();
         let file_path_clone = file_path.to_string();
 
         let tree = tokio::task::spawn_blocking(move || -> Result<Tree> {
             let mut parser = Parser::new();
-            let tree_sitter_language = get_tree_sitter_language(&language_clone)?;
+            let tree_sitter_language = get_tree_sitter_language(&grammar_key)?;
             parser.set_language(&tree_sitter_language).map_err(|e| {
                 ValknutError::parse(
                     &language_clone,
@@ -304,12 +307,19 @@ impl<'a> ComplexityCalculator<'a> {
     fn classify_node(&self, node: &Node) -> Option<DecisionKind> {
         match node.kind() {
             "if_statement" => Some(DecisionKind::If),
+            // Kotlin's `if` is an expression; other grammars' `if_expression` is not counted here
+            "if_expression" if self.context.language == "kt" => Some(DecisionKind::If),
             "else_if_clause" => Some(DecisionKind::ElseIf),
-            "while_statement" | "while_expression" => Some(DecisionKind::While),
+            "while_statement" | "while_expression" | "do_while_statement" => {
+                Some(DecisionKind::While)
+            }
             "for_statement" | "for_expression" => Some(DecisionKind::For),
-            "match_statement" | "match_expression" => Some(DecisionKind::Match),
+            "match_statement" | "match_expression" | "when_expression" => Some(DecisionKind::Match),
             "try_statement" | "try_expression" => Some(DecisionKind::Try),
-            "catch_clause" => Some(DecisionKind::Catch),
+            "catch_clause" | "catch_block" => Some(DecisionKind::Catch),
+            "conjunction_expression" => Some(DecisionKind::LogicalAnd),
+            "disjunction_expression" => Some(DecisionKind::LogicalOr),
+            "elvis_expression" => Some(DecisionKind::ConditionalExpression),
             "binary_expression" => {
                 // Check for logical operators
                 node.child_by_field_name("operator")
@@ -523,6 +533,33 @@ func complexFunction(x int) int {
         assert!(metrics.decision_points.len() > 0);
     }
 
+    #[tokio::test]
+    async fn test_kotlin_complexity() {
+        let service = AstService::new();
+        let source = r#"
+fun classify(x: Int?, flag: Boolean): String {
+    val n = x ?: 0
+    if (n > 0 && flag) {
+        return when (n) {
+            1 -> "one"
+            else -> "many"
+        }
+    }
+    return "none"
+}
+"#;
+
+        let cached_tree = service.get_ast("classify.kt", source).await.unwrap();
+        let context = service.create_context(&cached_tree, "classify.kt");
+        let metrics = service.calculate_complexity(&context).unwrap();
+
+        let kinds: Vec<_> = metrics.decision_points.iter().map(|p| &p.kind).collect();
+        assert!(kinds.contains(&&DecisionKind::If));
+        assert!(kinds.contains(&&DecisionKind::Match));
+        assert!(kinds.contains(&&DecisionKind::LogicalAnd));
+        assert!(kinds.contains(&&DecisionKind::ConditionalExpression));
+    }
+
     #[tokio::test]
     async fn test_typescript_complexity() {
         let service = AstService::new();
@@ -0,0 +1,472 @@
+//! Symbol ownership from git history.
+//!
+//! [`blame_symbols`] attributes every function, method and type under a path
+//! to the author of its most recent modification: the newest commit among the
+//! blame hunks covering the symbol's lines. Blame follows lines moved between
+//! files in the same commit, like `git log --follow`.
+//!
+//! Blame is computed lazily, one file at a time and only for files that
+//! declare symbols. Results are stored in a [`BlameCache`] next to the
+//! incremental index, and symbols are taken from that index when it is fresh
+//! so unchanged files are neither re-parsed nor re-blamed.
+
+use std::collections::HashMap;
+use std::path::{Path, PathBuf};
+
+use chrono::{DateTime, TimeZone, Utc};
+use git2::{BlameOptions, Repository};
+use ignore::WalkBuilder;
+use serde::{Deserialize, Serialize};
+use tracing::warn;
+
+use crate::core::errors::{Result, ValknutError};
+use crate::core::featureset::CodeEntity;
+use crate::core::file_utils::FileReader;
+use crate::core::pipeline::discovery::IGNORE_FILE_NAME;
+use crate::io::cache::blame::{BlameCache, BlameHunkRecord, CachedBlame};
+use crate::io::cache::IncrementalCache;
+use crate::lang::registry::adapter_for_file;
+
+/// Author reported for lines that are not committed yet, as `git blame` does.
+const NOT_COMMITTED_NAME: &str = "Not Committed Yet";
+
+/// Email reported for lines that are not committed yet.
+const NOT_COMMITTED_EMAIL: &str = "not.committed.yet";
+
+/// A symbol with the author of its most recent modification.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct SymbolOwnership {
+    /// Symbol name as declared
+    pub name: String,
+    /// Entity kind reported by the language adapter (`Function`, `Struct`, ...)
+    pub kind: String,
+    /// File declaring the symbol, relative to the blamed path
+    pub file_path: PathBuf,
+    /// 1-based first line of the declaration
+    pub start_line: usize,
+    /// 1-based last line of the declaration
+    pub end_line: usize,
+    /// Author name of the newest commit touching the symbol
+    pub owner_name: String,
+    /// Author email of the newest commit touching the symbol
+    pub owner_email: String,
+    /// Author date of that commit
+    pub last_modified_date: DateTime<Utc>,
+    /// Id of that commit (empty when the symbol has uncommitted changes)
+    pub commit: String,
+}
+
+/// Ownership totals for one author.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct OwnerSummary {
+    /// Author email, used to group commits
+    pub email: String,
+    /// Author name from the most recent commit
+    pub name: String,
+    /// Lines of blamed files last modified by this author
+    pub lines_owned: usize,
+    /// Symbols whose newest change is by this author
+    pub symbols: usize,
+}
+
+/// Ownership of every symbol under a path.
+#[derive(Debug, Clone, Default, PartialEq, Eq, Serialize, Deserialize)]
+pub struct BlameReport {
+    /// Symbols ordered by file and line
+    pub symbols: Vec<SymbolOwnership>,
+    /// Authors ordered by lines owned, then symbol count
+    pub owners: Vec<OwnerSummary>,
+}
+
+/// Ranking methods for [`BlameReport`].
+impl BlameReport {
+    /// The `limit` authors owning the most lines.
+    pub fn top_owners(&self, limit: usize) -> &[OwnerSummary] {
+        &self.owners[..limit.min(self.owners.len())]
+    }
+}
+
+/// Attribute every symbol under `root` (a directory or a single file) to its owner.
+///
+/// `cache_dir` holds the incremental index and the blame cache; without it
+/// every file is parsed and blamed from scratch.
+pub fn blame_symbols(root: &Path, cache_dir: Option<&Path>) -> Result<BlameReport> {
+    if !root.exists() {
+        return Err(ValknutError::validation(format!(
+            "Path does not exist: {}",
+            root.display()
+        )));
+    }
+    let repo = Repository::discover(root).map_err(|e| {
+        ValknutError::validation(format!(
+            "{} is not in a git repository: {e}",
+            root.display()
+        ))
+    })?;
+    let workdir = repo
+        .workdir()
+        .ok_or_else(|| ValknutError::validation("Cannot blame a bare repository"))?
+        .canonicalize()
+        .map_err(|e| ValknutError::io("Failed to resolve repository root", e))?;
+
+    let structure = cache_dir.map(IncrementalCache::open);
+    let mut blame_cache = cache_dir.map(BlameCache::open);
+    let mut symbols = Vec::new();
+    let mut lines_by_owner: HashMap<String, (String, i64, usize)> = HashMap::new();
+
+    for path in source_files(root) {
+        let source = match FileReader::read_to_string(&path) {
+            Ok(source) => source,
+            Err(err) => {
+                warn!("Skipping {}: {}", path.display(), err);
+                continue;
+            }
+        };
+        let entities = match entities_for(&path, &source, structure.as_ref()) {
+            Some(entities) if !entities.is_empty() => entities,
+            _ => continue,
+        };
+        let hunks = match file_blame(&repo, &workdir, &path, &source, blame_cache.as_mut()) {
+            Ok(hunks) => hunks,
+            Err(err) => {
+                warn!("Failed to blame {}: {}", path.display(), err);
+                continue;
+            }
+        };
+
+        for hunk in &hunks {
+            let entry = lines_by_owner
+                .entry(hunk.author_email.clone())
+                .or_insert_with(|| (hunk.author_name.clone(), hunk.time_secs, 0));
+            if hunk.time_secs >= entry.1 {
+                entry.0 = hunk.author_name.clone();
+                entry.1 = hunk.time_secs;
+            }
+            entry.2 += hunk.lines;
+        }
+
+        let display_path = match path.strip_prefix(root) {
+            Ok(relative) if !relative.as_os_str().is_empty() => relative.to_path_buf(),
+            _ => path.clone(),
+        };
+        symbols.extend(
+            entities
+                .iter()
+                .filter_map(|entity| attribute(entity, &hunks, &display_path)),
+        );
+    }
+
+    if let Some(cache) = blame_cache.as_mut() {
+        if let Err(err) = cache.save() {
+            warn!("Failed to save blame cache: {}", err);
+        }
+    }
+
+    symbols.sort_by(|a, b| {
+        (&a.file_path, a.start_line, &a.name).cmp(&(&b.file_path, b.start_line, &b.name))
+    });
+    let owners = summarize_owners(&symbols, lines_by_owner);
+    Ok(BlameReport { symbols, owners })
+}
+
+/// Supported source files under `root`, honoring ignore files.
+pub(crate) fn source_files(root: &Path) -> Vec<PathBuf> {
+    let mut builder = WalkBuilder::new(root);
+    builder.add_custom_ignore_filename(IGNORE_FILE_NAME);
+
+    let mut files: Vec<PathBuf> = builder
+        .build()
+        .filter_map(|entry| match entry {
+            Ok(entry) => Some(entry.into_path()),
+            Err(err) => {
+                warn!("Failed to walk directory: {err}");
+                None
+            }
+        })
+        .filter(|path| path.is_file() && FileReader::is_code_file(path))
+        .collect();
+    files.sort();
+    files
+}
+
+/// Entities declared in `path`, from the incremental index when it is fresh.
+pub(crate) fn entities_for(
+    path: &Path,
+    source: &str,
+    structure: Option<&IncrementalCache>,
+) -> Option<Vec<CodeEntity>> {
+    if let Some(entry) = structure
+        .filter(|cache| cache.is_fresh(path, source))
+        .and_then(|cache| cache.get(path))
+    {
+        return Some(entry.entities);
+    }
+    let mut adapter = adapter_for_file(path).ok()?;
+    match adapter.extract_code_entities(source, &path.to_string_lossy()) {
+        Ok(entities) => Some(entities),
+        Err(err) => {
+            warn!("Failed to parse {}: {}", path.display(), err);
+            None
+        }
+    }
+}
+
+/// Blame hunks of the working-tree version of `path`, cached when possible.
+fn file_blame(
+    repo: &Repository,
+    workdir: &Path,
+    path: &Path,
+    source: &str,
+    cache: Option<&mut BlameCache>,
+) -> Result<Vec<BlameHunkRecord>> {
+    let git_error = |e: git2::Error| ValknutError::internal(format!("git error: {e}"));
+    let absolute = path
+        .canonicalize()
+        .map_err(|e| ValknutError::io(format!("Failed to resolve {}", path.display()), e))?;
+    let relative = absolute.strip_prefix(workdir).map_err(|_| {
+        ValknutError::validation(format!("{} is outside the repository", path.display()))
+    })?;
+
+    let head_blob = repo
+        .head()
+        .and_then(|head| head.peel_to_tree())
+        .and_then(|tree| tree.get_path(relative))
+        .map(|entry| entry.id().to_string())
+        .unwrap_or_default();
+    let sha256 = IncrementalCache::content_hash(source);
+    if let Some(hunks) = cache
+        .as_deref()
+        .and_then(|cache| cache.get(path, &head_blob, &sha256))
+    {
+        return Ok(hunks.to_vec());
+    }
+
+    let hunks = if head_blob.is_empty() {
+        // Untracked files have no history: every line is uncommitted.
+        vec![uncommitted_hunk(1, source.lines().count())]
+    } else {
+        let mut options = BlameOptions::new();
+        options.track_copies_same_commit_moves(true);
+        let committed = repo
+            .blame_file(relative, Some(&mut options))
+            .map_err(git_error)?;
+        let blame = committed
+            .blame_buffer(source.as_bytes())
+            .map_err(git_error)?;
+        blame
+            .iter()
+            .map(|hunk| {
+                let start_line = hunk.final_start_line();
+                let lines = hunk.lines_in_hunk();
+                if hunk.final_commit_id().is_zero() {
+                    return uncommitted_hunk(start_line, lines);
+                }
+                let signature = hunk.final_signature();
+                BlameHunkRecord {
+                    start_line,
+                    lines,
+                    commit: hunk.final_commit_id().to_string(),
+                    author_name: signature.name().unwrap_or_default().to_string(),
+                    author_email: signature.email().unwrap_or_default().to_string(),
+                    time_secs: signature.when().seconds(),
+                }
+            })
+            .collect()
+    };
+
+    if let Some(cache) = cache {
+        cache.store(
+            path,
+            CachedBlame {
+                head_blob,
+                sha256,
+                hunks: hunks.clone(),
+            },
+        );
+    }
+    Ok(hunks)
+}
+
+/// A hunk of lines that only exist in the working tree.
+fn uncommitted_hunk(start_line: usize, lines: usize) -> BlameHunkRecord {
+    BlameHunkRecord {
+        start_line,
+        lines,
+        commit: String::new(),
+        author_name: NOT_COMMITTED_NAME.to_string(),
+        author_email: NOT_COMMITTED_EMAIL.to_string(),
+        time_secs: Utc::now().timestamp(),
+    }
+}
+
+/// Owner of `entity`: the newest hunk overlapping its line range.
+fn attribute(
+    entity: &CodeEntity,
+    hunks: &[BlameHunkRecord],
+    file_path: &Path,
+) -> Option<SymbolOwnership> {
+    let (start_line, end_line) = entity.line_range?;
+    let newest = hunks
+        .iter()
+        .filter(|hunk| hunk.start_line <= end_line && hunk.start_line + hunk.lines > start_line)
+        .max_by_key(|hunk| hunk.time_secs)?;
+    Some(SymbolOwnership {
+        name: entity.name.clone(),
+        kind: entity.entity_type.clone(),
+        file_path: file_path.to_path_buf(),
+        start_line,
+        end_line,
+        owner_name: newest.author_name.clone(),
+        owner_email: newest.author_email.clone(),
+        last_modified_date: Utc
+            .timestamp_opt(newest.time_secs, 0)
+            .single()
+            .unwrap_or_default(),
+        commit: newest.commit.clone(),
+    })
+}
+
+/// Combine per-author line counts with symbol ownership into a leaderboard.
+fn summarize_owners(
+    symbols: &[SymbolOwnership],
+    lines_by_owner: HashMap<String, (String, i64, usize)>,
+) -> Vec<OwnerSummary> {
+    let mut owners: HashMap<String, OwnerSummary> = lines_by_owner
+        .into_iter()
+        .map(|(email, (name, _, lines_owned))| {
+            let summary = OwnerSummary {
+                email: email.clone(),
+                name,
+                lines_owned,
+                symbols: 0,
+            };
+            (email, summary)
+        })
+        .collect();
+    for symbol in symbols {
+        owners
+            .entry(symbol.owner_email.clone())
+            .or_insert_with(|| OwnerSummary {
+                email: symbol.owner_email.clone(),
+                name: symbol.owner_name.clone(),
+                lines_owned: 0,
+                symbols: 0,
+            })
+            .symbols += 1;
+    }
+
+    let mut owners: Vec<OwnerSummary> = owners.into_values().collect();
+    owners.sort_by(|a, b| {
+        b.lines_owned
+            .cmp(&a.lines_owned)
+            .then(b.symbols.cmp(&a.symbols))
+            .then_with(|| a.email.cmp(&b.email))
+    });
+    owners
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use std::fs;
+    use tempfile::tempdir;
+
+    fn commit_as(repo: &Repository, name: &str, email: &str, time: i64, message: &str) {
+        let mut index = repo.index().unwrap();
+        index
+            .add_all(["*"].iter(), git2::IndexAddOption::DEFAULT, None)
+            .unwrap();
+        index.write().unwrap();
+        let tree = repo.find_tree(index.write_tree().unwrap()).unwrap();
+        let signature = git2::Signature::new(name, email, &git2::Time::new(time, 0)).unwrap();
+        let parent = repo.head().ok().and_then(|head| head.peel_to_commit().ok());
+        let parents: Vec<&git2::Commit> = parent.iter().collect();
+        repo.commit(
+            Some("HEAD"),
+            &signature,
+            &signature,
+            message,
+            &tree,
+            &parents,
+        )
+        .unwrap();
+    }
+
+    #[test]
+    fn attributes_symbols_to_their_latest_author() {
+        let tmp = tempdir().unwrap();
+        let repo = Repository::init(tmp.path()).unwrap();
+        let file = tmp.path().join("shapes.go");
+        fs::write(
+            &file,
+            "package shapes\n\nfunc Area() int {\n\treturn 1\n}\n\nfunc Perimeter() int {\n\treturn 2\n}\n",
+        )
+        .unwrap();
+        commit_as(&repo, "Ada", "ada@example.com", 1_600_000_000, "add shapes");
+        fs::write(
+            &file,
+            "package shapes\n\nfunc Area() int {\n\treturn 1\n}\n\nfunc Perimeter() int {\n\treturn 4\n}\n",
+        )
+        .unwrap();
+        commit_as(
+            &repo,
+            "Bo",
+            "bo@example.com",
+            1_700_000_000,
+            "fix perimeter",
+        );
+
+        let cache = tempdir().unwrap();
+        let report = blame_symbols(tmp.path(), Some(cache.path())).unwrap();
+        let owners: Vec<_> = report
+            .symbols
+            .iter()
+            .map(|symbol| (symbol.name.as_str(), symbol.owner_email.as_str()))
+            .collect();
+        assert_eq!(
+            owners,
+            vec![("Area", "ada@example.com"), ("Perimeter", "bo@example.com")]
+        );
+        assert_eq!(
+            report.symbols[1].last_modified_date.timestamp(),
+            1_700_000_000
+        );
+        assert_eq!(report.owners[0].email, "ada@example.com");
+        assert_eq!(report.owners[0].lines_owned, 8);
+        assert_eq!(report.owners[1].symbols, 1);
+        assert_eq!(report.top_owners(1).len(), 1);
+
+        // The second run is served from the blame cache.
+        assert!(cache.path().join("blame.v1.json").exists());
+        assert_eq!(
+            blame_symbols(tmp.path(), Some(cache.path())).unwrap(),
+            report
+        );
+    }
+
+    #[test]
+    fn uncommitted_edits_are_attributed_to_the_working_tree() {
+        let tmp = tempdir().unwrap();
+        let repo = Repository::init(tmp.path()).unwrap();
+        let file = tmp.path().join("main.py");
+        fs::write(&file, "def run():\n    return 1\n").unwrap();
+        commit_as(&repo, "Ada", "ada@example.com", 1_600_000_000, "add run");
+        fs::write(
+            &file,
+            "def run():\n    return 2\n\n\ndef stop():\n    pass\n",
+        )
+        .unwrap();
+
+        let report = blame_symbols(tmp.path(), None).unwrap();
+        assert!(report
+            .symbols
+            .iter()
+            .all(|symbol| symbol.owner_email == NOT_COMMITTED_EMAIL && symbol.commit.is_empty()));
+    }
+
+    #[test]
+    fn rejects_missing_paths() {
+        let tmp = tempdir().unwrap();
+        assert!(blame_symbols(&tmp.path().join("missing"), None).is_err());
+    }
+}
@@ -0,0 +1,227 @@
+//! Functions and types whose implementation changed since a revision.
+//!
+//! [`changed_symbols_since`] diffs the tree of a revision against `HEAD`, like
+//! `git diff <since> HEAD` (with the default `HEAD~1`, the files of
+//! `git show --stat HEAD`), parses both versions of every changed source file
+//! and runs [`FileAnalysis::diff`] on them. Only the differences that touch an
+//! implementation are kept: additions, removals, renames, and signature or
+//! body changes. Symbols that merely moved, and the unchanged symbols of a
+//! changed file, are left out, which keeps the answer to "what changed in the
+//! last commit?" much smaller than the patch itself.
+
+use std::path::Path;
+
+use git2::Delta;
+use serde::{Deserialize, Serialize};
+
+use crate::core::errors::{Result, ValknutError};
+use crate::core::file_utils::FileReader;
+use crate::core::review::{blob_text, git_error, open_repository};
+use crate::detectors::rules::{FileAnalysis, FileDiff, SymbolDiffKind};
+
+/// Revision compared against `HEAD` when none is given: the last commit.
+pub const DEFAULT_SINCE: &str = "HEAD~1";
+
+/// Entity kinds reported, as named by [`FileAnalysis`].
+const SYMBOL_KINDS: &[&str] = &["function", "method", "class", "struct", "interface", "enum"];
+
+/// Functions and types that changed between a revision and `HEAD`.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct ChangedSymbols {
+    /// Revision the changes are measured from, as given.
+    pub since: String,
+    /// Commit `since` resolved to.
+    pub base: String,
+    /// Commit of `HEAD`.
+    pub head: String,
+    /// Number of files that differ between the two commits.
+    pub files_changed: usize,
+    /// Number of changed symbols across all files.
+    pub symbol_count: usize,
+    /// Changed symbols of every file that has any, sorted by path.
+    pub files: Vec<FileDiff>,
+}
+
+/// Query methods for [`ChangedSymbols`].
+impl ChangedSymbols {
+    /// True when no function or type changed.
+    pub fn is_empty(&self) -> bool {
+        self.symbol_count == 0
+    }
+}
+
+/// Functions and types under `root` whose implementation differs between
+/// `since` and `HEAD`.
+pub fn changed_symbols_since(root: &Path, since: &str) -> Result<ChangedSymbols> {
+    let (repo, _, scope) = open_repository(root)?;
+    let head = repo
+        .head()
+        .and_then(|head| head.peel_to_commit())
+        .map_err(|e| git_error("resolve HEAD", e))?;
+    let base = repo
+        .revparse_single(since)
+        .and_then(|object| object.peel_to_commit())
+        .map_err(|e| {
+            ValknutError::validation(format!("Unknown git revision '{since}': {}", e.message()))
+        })?;
+    let base_tree = base
+        .tree()
+        .map_err(|e| git_error(&format!("read '{since}'"), e))?;
+    let head_tree = head.tree().map_err(|e| git_error("read HEAD", e))?;
+    let mut diff = repo
+        .diff_tree_to_tree(Some(&base_tree), Some(&head_tree), None)
+        .map_err(|e| git_error(&format!("diff '{since}' against HEAD"), e))?;
+    diff.find_similar(None)
+        .map_err(|e| git_error("detect renames", e))?;
+
+    let mut files_changed = 0;
+    let mut files = Vec::new();
+    for delta in diff.deltas() {
+        let old_path = delta.old_file().path();
+        let new_path = delta.new_file().path();
+        let Some(path) = new_path.or(old_path) else {
+            continue;
+        };
+        if !path.starts_with(&scope) {
+            continue;
+        }
+        files_changed += 1;
+        if !FileReader::is_code_file(path) {
+            continue;
+        }
+
+        let before_path = old_path.unwrap_or(path);
+        let after_path = new_path.unwrap_or(path);
+        let before_source = match delta.status() {
+            Delta::Added | Delta::Copied | Delta::Untracked => None,
+            _ => blob_text(&repo, &base_tree, before_path),
+        };
+        let after_source = match delta.status() {
+            Delta::Deleted => None,
+            _ => blob_text(&repo, &head_tree, after_path),
+        };
+        let before = analysis(before_path, before_source.as_deref());
+        let after = analysis(after_path, after_source.as_deref());
+
+        let mut file = before.diff(&after);
+        file.changes.retain(|change| {
+            change.change != SymbolDiffKind::Moved && SYMBOL_KINDS.contains(&change.kind.as_str())
+        });
+        if !file.is_empty() {
+            files.push(file);
+        }
+    }
+    files.sort_by(|a, b| a.after_path.cmp(&b.after_path));
+
+    Ok(ChangedSymbols {
+        since: since.to_string(),
+        base: base.id().to_string(),
+        head: head.id().to_string(),
+        files_changed,
+        symbol_count: files.iter().map(|file| file.changes.len()).sum(),
+        files,
+    })
+}
+
+/// Facts for one version of a file; a missing version has no entities.
+fn analysis(path: &Path, source: Option<&str>) -> FileAnalysis {
+    let path = path
+        .components()
+        .map(|c| c.as_os_str().to_string_lossy())
+        .collect::<Vec<_>>()
+        .join("/");
+    FileAnalysis::from_source(&path, source.unwrap_or(""), &[])
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use git2::Repository;
+    use std::fs;
+    use tempfile::tempdir;
+
+    /// Stage everything, including removals, and commit it on top of `HEAD`.
+    fn commit(repo: &Repository, message: &str) {
+        let mut index = repo.index().unwrap();
+        index
+            .add_all(["*"].iter(), git2::IndexAddOption::DEFAULT, None)
+            .unwrap();
+        index.update_all(["*"].iter(), None).unwrap();
+        index.write().unwrap();
+        let tree = repo.find_tree(index.write_tree().unwrap()).unwrap();
+        let signature = git2::Signature::now("Test", "test@example.com").unwrap();
+        let parent = repo.head().ok().and_then(|head| head.peel_to_commit().ok());
+        let parents: Vec<&git2::Commit> = parent.iter().collect();
+        repo.commit(
+            Some("HEAD"),
+            &signature,
+            &signature,
+            message,
+            &tree,
+            &parents,
+        )
+        .unwrap();
+    }
+
+    #[test]
+    fn lists_only_symbols_whose_implementation_changed() {
+        let tmp = tempdir().unwrap();
+        let repo = Repository::init(tmp.path()).unwrap();
+        fs::create_dir(tmp.path().join("shapes")).unwrap();
+        fs::write(
+            tmp.path().join("shapes/shapes.go"),
+            "package shapes\n\nfunc Area(side int) int {\n\treturn side * side\n}\n\nfunc Volume(side int) int {\n\treturn side * side * side\n}\n",
+        )
+        .unwrap();
+        fs::write(
+            tmp.path().join("shapes/old.go"),
+            "package shapes\n\nfunc Legacy() {}\n",
+        )
+        .unwrap();
+        fs::write(tmp.path().join("README.md"), "# Shapes\n").unwrap();
+        commit(&repo, "initial");
+
+        // Volume moves down a line without changing; Area's body changes.
+        fs::write(
+            tmp.path().join("shapes/shapes.go"),
+            "package shapes\n\n\nfunc Area(side int) int {\n\treturn side * side * 1\n}\n\nfunc Volume(side int) int {\n\treturn side * side * side\n}\n\nfunc Diagonal(side int) int {\n\treturn side\n}\n",
+        )
+        .unwrap();
+        fs::remove_file(tmp.path().join("shapes/old.go")).unwrap();
+        fs::write(
+            tmp.path().join("README.md"),
+            "# Shapes\n\nGeometry helpers.\n",
+        )
+        .unwrap();
+        commit(&repo, "edit");
+
+        let changed = changed_symbols_since(tmp.path(), DEFAULT_SINCE).unwrap();
+        assert_eq!(changed.files_changed, 3);
+        assert_eq!(changed.symbol_count, 3);
+        let symbols: Vec<_> = changed
+            .files
+            .iter()
+            .flat_map(|file| &file.changes)
+            .map(|change| {
+                (
+                    change.change,
+                    change.name.as_str(),
+                    change.file_path.as_str(),
+                )
+            })
+            .collect();
+        assert_eq!(
+            symbols,
+            vec![
+                (SymbolDiffKind::Removed, "Legacy", "shapes/old.go"),
+                (SymbolDiffKind::BodyChanged, "Area", "shapes/shapes.go"),
+                (SymbolDiffKind::Added, "Diagonal", "shapes/shapes.go"),
+            ]
+        );
+
+        let err = changed_symbols_since(tmp.path(), "no-such-ref").unwrap_err();
+        assert!(err
+            .to_string()
+            .contains("Unknown git revision 'no-such-ref'"));
+    }
+}
@@ -0,0 +1,437 @@
+//! Bounded worker pool for per-file analysis.
+//!
+//! Spawning one task per file keeps every pending file, and the future that
+//! will hold its source, alive at once; on repositories with tens of thousands
+//! of files that exhausts memory. [`ConcurrentAnalyzer`] instead runs a fixed
+//! number of workers that pull items from a bounded channel, so no more than
+//! [`ConcurrentAnalyzer::max_live_items`] items are in memory at any moment
+//! regardless of input size. Every run reports [`ConcurrencyStats`] with the
+//! observed peak so the bound can be checked.
+//!
+//! The workers themselves are a [`WorkerPool`], which plugins and
+//! integrations can also use directly. A [`ConcurrencyLimit`] caps the
+//! threads of every stage of an analysis at once.
+
+use std::fmt;
+use std::future::Future;
+use std::num::NonZeroUsize;
+use std::panic::AssertUnwindSafe;
+use std::sync::atomic::{AtomicUsize, Ordering};
+use std::sync::{Arc, Mutex, MutexGuard};
+use std::time::{Duration, Instant};
+
+use futures::FutureExt;
+use serde::{Deserialize, Serialize};
+use tokio_util::sync::CancellationToken;
+use tracing::{debug, warn};
+
+use crate::core::config::PerformanceConfig;
+use crate::core::errors::{Result, ValknutError};
+
+pub mod worker_pool;
+
+pub use worker_pool::WorkerPool;
+
+/// Queue slots allocated per worker when no capacity is given.
+const QUEUE_SLOTS_PER_WORKER: usize = 2;
+
+/// Number of workers used when none is configured: one per available core.
+pub fn default_worker_count() -> usize {
+    std::thread::available_parallelism()
+        .map(NonZeroUsize::get)
+        .unwrap_or(1)
+}
+
+/// Upper bound on the threads an analysis may use, e.g. `4` or `50%` of the cores.
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub enum ConcurrencyLimit {
+    /// A fixed number of threads.
+    Threads(NonZeroUsize),
+    /// A percentage of the available cores, rounded down but at least one thread.
+    PercentOfCores(NonZeroUsize),
+}
+
+/// Resolution methods for [`ConcurrencyLimit`].
+impl ConcurrencyLimit {
+    /// Number of threads allowed on this machine.
+    pub fn resolve(&self) -> usize {
+        self.resolve_for(default_worker_count())
+    }
+
+    /// Number of threads allowed on a machine with `cores` cores.
+    pub fn resolve_for(&self, cores: usize) -> usize {
+        match self {
+            Self::Threads(threads) => threads.get(),
+            Self::PercentOfCores(percent) => (cores * percent.get() / 100).max(1),
+        }
+    }
+}
+
+/// Parsing for [`ConcurrencyLimit`].
+impl std::str::FromStr for ConcurrencyLimit {
+    type Err = ValknutError;
+
+    /// Parse a positive thread count, or a positive percentage with a `%` suffix.
+    fn from_str(input: &str) -> Result<Self> {
+        let trimmed = input.trim();
+        let (number, percent) = match trimmed.strip_suffix('%') {
+            Some(number) => (number.trim_end(), true),
+            None => (trimmed, false),
+        };
+        let value = number
+            .parse::<usize>()
+            .ok()
+            .and_then(NonZeroUsize::new)
+            .ok_or_else(|| {
+                ValknutError::validation(format!(
+                    "invalid concurrency limit '{input}': expected a positive number of threads or a percentage such as 50%"
+                ))
+            })?;
+        Ok(if percent {
+            Self::PercentOfCores(value)
+        } else {
+            Self::Threads(value)
+        })
+    }
+}
+
+/// Display implementation for [`ConcurrencyLimit`].
+impl fmt::Display for ConcurrencyLimit {
+    /// Formats the limit as it is written on the command line.
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        match self {
+            Self::Threads(threads) => write!(f, "{threads}"),
+            Self::PercentOfCores(percent) => write!(f, "{percent}%"),
+        }
+    }
+}
+
+/// Size rayon's global pool, which runs the CPU-bound detectors, to `threads`.
+///
+/// The pool can only be sized before its first use; a later call keeps the
+/// existing pool and logs a warning.
+pub fn configure_global_thread_pool(threads: usize) {
+    if let Err(e) = rayon::ThreadPoolBuilder::new()
+        .num_threads(threads.max(1))
+        .build_global()
+    {
+        warn!("Could not limit the global thread pool to {threads} threads: {e}");
+    }
+}
+
+/// Fixed-size worker pool fed through a bounded channel.
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub struct ConcurrentAnalyzer {
+    workers: usize,
+    queue_capacity: usize,
+}
+
+/// Observations collected during a [`ConcurrentAnalyzer::run`].
+#[derive(Debug, Clone, Default, PartialEq, Serialize, Deserialize)]
+pub struct ConcurrencyStats {
+    /// Number of workers in the pool.
+    pub workers: usize,
+    /// Capacity of the work queue.
+    pub queue_capacity: usize,
+    /// Items handed to the pool.
+    pub items: usize,
+    /// Items whose analysis panicked and produced no result.
+    pub panicked: usize,
+    /// Highest number of items queued or being analysed at the same time.
+    pub peak_live_items: usize,
+    /// Highest number of items being analysed at the same time.
+    pub peak_in_flight: usize,
+    /// Wall-clock duration of the run.
+    pub elapsed: Duration,
+}
+
+/// Results of a pool run, in input order, with its statistics.
+#[derive(Debug, Clone)]
+pub struct ConcurrentRun<R> {
+    /// One result per item that completed, ordered like the input.
+    pub results: Vec<R>,
+    /// Statistics for the run.
+    pub stats: ConcurrencyStats,
+}
+
+/// Live and peak item counters shared between the producer and the workers.
+#[derive(Debug, Default)]
+struct LoadGauge {
+    live: AtomicUsize,
+    peak_live: AtomicUsize,
+    in_flight: AtomicUsize,
+    peak_in_flight: AtomicUsize,
+}
+
+/// Counter updates for [`LoadGauge`].
+impl LoadGauge {
+    /// An item was pulled from the input and is waiting for a worker.
+    fn enqueued(&self) {
+        let live = self.live.fetch_add(1, Ordering::SeqCst) + 1;
+        self.peak_live.fetch_max(live, Ordering::SeqCst);
+    }
+
+    /// A worker started analysing an item.
+    fn started(&self) {
+        let in_flight = self.in_flight.fetch_add(1, Ordering::SeqCst) + 1;
+        self.peak_in_flight.fetch_max(in_flight, Ordering::SeqCst);
+    }
+
+    /// A worker finished an item and released it.
+    fn finished(&self) {
+        self.in_flight.fetch_sub(1, Ordering::SeqCst);
+        self.live.fetch_sub(1, Ordering::SeqCst);
+    }
+}
+
+/// Lock a mutex, recovering the data if a holder panicked.
+fn lock<T>(mutex: &Mutex<T>) -> MutexGuard<'_, T> {
+    mutex
+        .lock()
+        .unwrap_or_else(|poisoned| poisoned.into_inner())
+}
+
+/// Default implementation for [`ConcurrentAnalyzer`].
+impl Default for ConcurrentAnalyzer {
+    /// Returns a pool with one worker per available core.
+    fn default() -> Self {
+        Self::new()
+    }
+}
+
+/// Construction and execution methods for [`ConcurrentAnalyzer`].
+impl ConcurrentAnalyzer {
+    /// Create a pool with one worker per available core.
+    pub fn new() -> Self {
+        Self::with_workers(default_worker_count())
+    }
+
+    /// Create a pool with `workers` workers (at least one).
+    pub fn with_workers(workers: usize) -> Self {
+        let workers = workers.max(1);
+        Self {
+            workers,
+            queue_capacity: workers * QUEUE_SLOTS_PER_WORKER,
+        }
+    }
+
+    /// Create a pool sized by `performance.max_threads`, defaulting to the core count.
+    pub fn from_performance_config(config: &PerformanceConfig) -> Self {
+        config
+            .max_threads
+            .map(Self::with_workers)
+            .unwrap_or_default()
+    }
+
+    /// Set the number of items that may wait in the queue (at least one).
+    pub fn with_queue_capacity(mut self, capacity: usize) -> Self {
+        self.queue_capacity = capacity.max(1);
+        self
+    }
+
+    /// Number of workers in the pool.
+    pub fn workers(&self) -> usize {
+        self.workers
+    }
+
+    /// Capacity of the work queue.
+    pub fn queue_capacity(&self) -> usize {
+        self.queue_capacity
+    }
+
+    /// Upper bound on items held in memory at once: one per worker, a full
+    /// queue, and the item the producer is waiting to enqueue.
+    pub fn max_live_items(&self) -> usize {
+        self.workers + self.queue_capacity + 1
+    }
+
+    /// Analyse every item with `analyze`, at most `workers` at a time.
+    ///
+    /// Items are pulled lazily from `items` as queue slots free up, so an
+    /// iterator over paths never materialises more than
+    /// [`max_live_items`](Self::max_live_items) of them. A panic while
+    /// analysing one item is logged and counted in
+    /// [`ConcurrencyStats::panicked`]; the remaining items still run.
+    pub async fn run<I, T, R, F, Fut>(&self, items: I, analyze: F) -> ConcurrentRun<R>
+    where
+        I: IntoIterator<Item = T>,
+        T: Send + 'static,
+        R: Send + 'static,
+        F: Fn(T) -> Fut + Send + Sync + 'static,
+        Fut: Future<Output = R> + Send + 'static,
+    {
+        let started = Instant::now();
+        let analyze = Arc::new(analyze);
+        let gauge = Arc::new(LoadGauge::default());
+        let completed = Arc::new(Mutex::new(Vec::new()));
+        let panicked = Arc::new(AtomicUsize::new(0));
+
+        let pool = {
+            let gauge = Arc::clone(&gauge);
+            let completed = Arc::clone(&completed);
+            let panicked = Arc::clone(&panicked);
+            WorkerPool::spawn(
+                self.workers,
+                self.queue_capacity,
+                CancellationToken::new(),
+                move |(index, item): (usize, T)| {
+                    let analyze = Arc::clone(&analyze);
+                    let gauge = Arc::clone(&gauge);
+                    let completed = Arc::clone(&completed);
+                    let panicked = Arc::clone(&panicked);
+                    async move {
+                        gauge.started();
+                        let outcome = AssertUnwindSafe(async { analyze(item).await })
+                            .catch_unwind()
+                            .await;
+                        gauge.finished();
+
+                        match outcome {
+                            Ok(result) => lock(&completed).push((index, result)),
+                            Err(_) => {
+                                warn!("Analysis of item {} panicked", index);
+                                panicked.fetch_add(1, Ordering::SeqCst);
+                            }
+                        }
+                        Ok(())
+                    }
+                },
+            )
+        };
+
+        let mut item_count = 0;
+        for item in items {
+            gauge.enqueued();
+            if pool.submit((item_count, item)).await.is_err() {
+                warn!("Worker pool stopped accepting work; remaining items skipped");
+                break;
+            }
+            item_count += 1;
+        }
+        if let Err(e) = pool.wait().await {
+            warn!("Worker pool failed: {}", e);
+        }
+
+        let mut indexed = std::mem::take(&mut *lock(&completed));
+        indexed.sort_by_key(|(index, _)| *index);
+
+        let stats = ConcurrencyStats {
+            workers: self.workers,
+            queue_capacity: self.queue_capacity,
+            items: item_count,
+            panicked: panicked.load(Ordering::SeqCst),
+            peak_live_items: gauge.peak_live.load(Ordering::SeqCst),
+            peak_in_flight: gauge.peak_in_flight.load(Ordering::SeqCst),
+            elapsed: started.elapsed(),
+        };
+        debug!(
+            "Worker pool processed {} items with {} workers in {:?} (peak live items: {})",
+            stats.items, stats.workers, stats.elapsed, stats.peak_live_items
+        );
+
+        ConcurrentRun {
+            results: indexed.into_iter().map(|(_, result)| result).collect(),
+            stats,
+        }
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[tokio::test(flavor = "multi_thread", worker_threads = 4)]
+    async fn preserves_input_order_and_bounds_live_items() {
+        let pool = ConcurrentAnalyzer::with_workers(3).with_queue_capacity(2);
+        let run = pool
+            .run(0..200u64, |n| async move {
+                tokio::task::yield_now().await;
+                n * 2
+            })
+            .await;
+
+        assert_eq!(run.results, (0..200u64).map(|n| n * 2).collect::<Vec<_>>());
+        assert_eq!(run.stats.items, 200);
+        assert!(run.stats.peak_in_flight <= 3);
+        assert!(run.stats.peak_live_items <= pool.max_live_items());
+    }
+
+    #[tokio::test(flavor = "multi_thread", worker_threads = 2)]
+    async fn panicking_items_are_counted_and_skipped() {
+        let run = ConcurrentAnalyzer::with_workers(2)
+            .run(0..10u32, |n| async move {
+                if n == 4 {
+                    panic!("boom");
+                }
+                n
+            })
+            .await;
+
+        assert_eq!(run.stats.panicked, 1);
+        assert_eq!(run.results, vec![0, 1, 2, 3, 5, 6, 7, 8, 9]);
+    }
+
+    #[tokio::test(flavor = "multi_thread", worker_threads = 2)]
+    async fn wall_clock_time_scales_with_workers() {
+        // Each item waits 20ms, so the run time is governed by how many items
+        // proceed concurrently rather than by the host's core count.
+        async fn timed(workers: usize) -> Duration {
+            ConcurrentAnalyzer::with_workers(workers)
+                .run(0..32, |_| tokio::time::sleep(Duration::from_millis(20)))
+                .await
+                .stats
+                .elapsed
+        }
+
+        let one = timed(1).await;
+        let mut previous = one;
+        for workers in [2, 4, 8] {
+            let elapsed = timed(workers).await;
+            assert!(
+                elapsed < previous,
+                "{workers} workers took {elapsed:?}, not faster than {previous:?}"
+            );
+            previous = elapsed;
+        }
+        assert!(
+            previous * 4 < one,
+            "8 workers ({previous:?}) should be at least 4x faster than 1 ({one:?})"
+        );
+    }
+
+    #[test]
+    fn concurrency_limits_parse_counts_and_percentages() {
+        let limit: ConcurrencyLimit = "6".parse().unwrap();
+        assert_eq!(limit.resolve_for(16), 6);
+        assert_eq!(limit.to_string(), "6");
+
+        let half: ConcurrencyLimit = "50%".parse().unwrap();
+        assert_eq!(half.resolve_for(16), 8);
+        assert_eq!(half.resolve_for(3), 1);
+        assert_eq!(half.resolve_for(1), 1);
+        assert_eq!(half.to_string(), "50%");
+        assert_eq!(
+            "150%".parse::<ConcurrencyLimit>().unwrap().resolve_for(4),
+            6
+        );
+
+        for invalid in ["0", "0%", "-2", "half", "%", ""] {
+            assert!(invalid.parse::<ConcurrencyLimit>().is_err(), "{invalid}");
+        }
+    }
+
+    #[test]
+    fn worker_count_defaults_to_available_cores() {
+        let pool = ConcurrentAnalyzer::from_performance_config(&PerformanceConfig::default());
+        assert_eq!(pool.workers(), default_worker_count());
+
+        let config = PerformanceConfig {
+            max_threads: Some(6),
+            ..PerformanceConfig::default()
+        };
+        let pool = ConcurrentAnalyzer::from_performance_config(&config);
+        assert_eq!(pool.workers(), 6);
+        assert_eq!(pool.queue_capacity(), 12);
+        assert_eq!(ConcurrentAnalyzer::with_workers(0).workers(), 1);
+    }
+}
@@ -0,0 +1,330 @@
+//! General-purpose worker pool for plugins and integrations.
+//!
+//! [`WorkerPool`] runs a fixed number of tokio workers over a bounded queue.
+//! Items are pushed with [`WorkerPool::submit`] as they become available, and
+//! [`WorkerPool::wait`] reports the first error any worker returned. The
+//! first failure cancels the pool: items being processed are abandoned at
+//! their next await point, queued items are dropped and further submissions
+//! are refused, so a failing run stops promptly instead of draining its
+//! input. [`ConcurrentAnalyzer`](super::ConcurrentAnalyzer) is
+//! built on this pool.
+
+use std::any::Any;
+use std::future::Future;
+use std::panic::AssertUnwindSafe;
+use std::sync::{Arc, Mutex as StdMutex};
+
+use futures::FutureExt;
+use tokio::sync::{mpsc, Mutex};
+use tokio::task::JoinHandle;
+use tokio_util::sync::CancellationToken;
+use tracing::warn;
+
+use super::{default_worker_count, lock, QUEUE_SLOTS_PER_WORKER};
+use crate::core::errors::{Result, ValknutError};
+
+/// Fixed-size pool of workers that process submitted items with one handler.
+///
+/// ```no_run
+/// # async fn example() -> valknut_rs::Result<()> {
+/// use valknut_rs::core::concurrency::WorkerPool;
+///
+/// let pool = WorkerPool::new(4, |path: String| async move {
+///     println!("processing {path}");
+///     Ok(())
+/// });
+/// for path in ["a.rs", "b.rs"] {
+///     if pool.submit(path.to_string()).await.is_err() {
+///         break; // a worker failed; `wait` reports why
+///     }
+/// }
+/// pool.wait().await
+/// # }
+/// ```
+#[derive(Debug)]
+pub struct WorkerPool<T> {
+    sender: Option<mpsc::Sender<T>>,
+    handles: Vec<JoinHandle<()>>,
+    cancel: CancellationToken,
+    first_error: Arc<StdMutex<Option<ValknutError>>>,
+    workers: usize,
+}
+
+/// Construction, submission and shutdown methods for [`WorkerPool`].
+impl<T: Send + 'static> WorkerPool<T> {
+    /// Start `workers` workers (at least one) that run `handler` on each item.
+    ///
+    /// Must be called from within a tokio runtime.
+    pub fn new<F, Fut>(workers: usize, handler: F) -> Self
+    where
+        F: Fn(T) -> Fut + Send + Sync + 'static,
+        Fut: Future<Output = Result<()>> + Send + 'static,
+    {
+        Self::with_cancellation(workers, CancellationToken::new(), handler)
+    }
+
+    /// Start a pool with one worker per available core.
+    pub fn with_default_workers<F, Fut>(handler: F) -> Self
+    where
+        F: Fn(T) -> Fut + Send + Sync + 'static,
+        Fut: Future<Output = Result<()>> + Send + 'static,
+    {
+        Self::new(default_worker_count(), handler)
+    }
+
+    /// Start a pool that also stops when `parent` is cancelled.
+    ///
+    /// The pool cancels a child of `parent`, so a worker failure never
+    /// cancels the caller's token.
+    pub fn with_cancellation<F, Fut>(workers: usize, parent: CancellationToken, handler: F) -> Self
+    where
+        F: Fn(T) -> Fut + Send + Sync + 'static,
+        Fut: Future<Output = Result<()>> + Send + 'static,
+    {
+        let workers = workers.max(1);
+        Self::spawn(workers, workers * QUEUE_SLOTS_PER_WORKER, parent, handler)
+    }
+
+    /// Start the workers with an explicit queue capacity (at least one).
+    pub(super) fn spawn<F, Fut>(
+        workers: usize,
+        queue_capacity: usize,
+        parent: CancellationToken,
+        handler: F,
+    ) -> Self
+    where
+        F: Fn(T) -> Fut + Send + Sync + 'static,
+        Fut: Future<Output = Result<()>> + Send + 'static,
+    {
+        let workers = workers.max(1);
+        let (sender, receiver) = mpsc::channel::<T>(queue_capacity.max(1));
+        let receiver = Arc::new(Mutex::new(receiver));
+        let handler = Arc::new(handler);
+        let cancel = parent.child_token();
+        let first_error = Arc::new(StdMutex::new(None));
+
+        let handles = (0..workers)
+            .map(|_| {
+                let receiver = Arc::clone(&receiver);
+                let handler = Arc::clone(&handler);
+                let cancel = cancel.clone();
+                let first_error = Arc::clone(&first_error);
+                tokio::spawn(async move {
+                    loop {
+                        let next = tokio::select! {
+                            biased;
+                            _ = cancel.cancelled() => None,
+                            item = async { receiver.lock().await.recv().await } => item,
+                        };
+                        let Some(item) = next else {
+                            break;
+                        };
+
+                        let outcome = tokio::select! {
+                            biased;
+                            _ = cancel.cancelled() => break,
+                            outcome = AssertUnwindSafe(handler(item)).catch_unwind() => outcome,
+                        };
+                        let error = match outcome {
+                            Ok(Ok(())) => continue,
+                            Ok(Err(error)) => error,
+                            Err(panic) => ValknutError::internal(format!(
+                                "worker panicked: {}",
+                                panic_message(panic.as_ref())
+                            )),
+                        };
+                        record_first_error(&first_error, error);
+                        cancel.cancel();
+                        break;
+                    }
+                })
+            })
+            .collect();
+
+        Self {
+            sender: Some(sender),
+            handles,
+            cancel,
+            first_error,
+            workers,
+        }
+    }
+
+    /// Number of workers in the pool.
+    pub fn workers(&self) -> usize {
+        self.workers
+    }
+
+    /// Queue `item`, waiting for a free slot when the queue is full.
+    ///
+    /// Returns the item back when the pool is closed or cancelled, which
+    /// happens as soon as any worker fails.
+    pub async fn submit(&self, item: T) -> std::result::Result<(), T> {
+        let Some(sender) = &self.sender else {
+            return Err(item);
+        };
+        let permit = tokio::select! {
+            biased;
+            _ = self.cancel.cancelled() => None,
+            permit = sender.reserve() => permit.ok(),
+        };
+        match permit {
+            Some(permit) => {
+                permit.send(item);
+                Ok(())
+            }
+            None => Err(item),
+        }
+    }
+
+    /// Stop accepting items; queued items are still processed.
+    pub fn close(&mut self) {
+        self.sender = None;
+    }
+
+    /// Stop the workers, abandoning in-flight items and dropping queued ones.
+    pub fn cancel(&self) {
+        self.cancel.cancel();
+    }
+
+    /// Whether the pool was cancelled, by a failure or explicitly.
+    pub fn is_cancelled(&self) -> bool {
+        self.cancel.is_cancelled()
+    }
+
+    /// Close the pool, wait for every worker to exit and return the first error.
+    pub async fn wait(mut self) -> Result<()> {
+        self.close();
+        for handle in std::mem::take(&mut self.handles) {
+            if let Err(error) = handle.await {
+                warn!("Worker task failed: {}", error);
+                record_first_error(
+                    &self.first_error,
+                    ValknutError::internal(format!("worker task failed: {error}")),
+                );
+            }
+        }
+        let first_error = lock(&self.first_error).take();
+        first_error.map_or(Ok(()), Err)
+    }
+}
+
+/// Keep `error` only if no earlier error was recorded.
+fn record_first_error(slot: &StdMutex<Option<ValknutError>>, error: ValknutError) {
+    let mut slot = lock(slot);
+    if slot.is_none() {
+        *slot = Some(error);
+    }
+}
+
+/// Best-effort text of a panic payload.
+fn panic_message(payload: &(dyn Any + Send)) -> &str {
+    payload
+        .downcast_ref::<&str>()
+        .copied()
+        .or_else(|| payload.downcast_ref::<String>().map(String::as_str))
+        .unwrap_or("non-string panic payload")
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use std::sync::atomic::{AtomicUsize, Ordering};
+
+    #[tokio::test(flavor = "multi_thread", worker_threads = 4)]
+    async fn processes_every_submitted_item() {
+        let total = Arc::new(AtomicUsize::new(0));
+        let sum = Arc::clone(&total);
+        let pool = WorkerPool::new(3, move |n: usize| {
+            let sum = Arc::clone(&sum);
+            async move {
+                sum.fetch_add(n, Ordering::SeqCst);
+                Ok(())
+            }
+        });
+        assert_eq!(pool.workers(), 3);
+
+        for n in 1..=100 {
+            pool.submit(n).await.unwrap();
+        }
+        pool.wait().await.unwrap();
+        assert_eq!(total.load(Ordering::SeqCst), 5050);
+    }
+
+    #[tokio::test(flavor = "multi_thread", worker_threads = 2)]
+    async fn first_error_cancels_remaining_work() {
+        let parent = CancellationToken::new();
+        let processed = Arc::new(AtomicUsize::new(0));
+        let counter = Arc::clone(&processed);
+        let pool = WorkerPool::with_cancellation(1, parent.clone(), move |n: usize| {
+            let counter = Arc::clone(&counter);
+            async move {
+                counter.fetch_add(1, Ordering::SeqCst);
+                if n == 3 {
+                    return Err(ValknutError::validation(format!("bad item {n}")));
+                }
+                Ok(())
+            }
+        });
+
+        let mut rejected = None;
+        for n in 0..1_000 {
+            if let Err(item) = pool.submit(n).await {
+                rejected = Some(item);
+                break;
+            }
+        }
+
+        assert!(
+            rejected.is_some(),
+            "submissions should stop after the failure"
+        );
+        assert!(pool.is_cancelled());
+        let error = pool.wait().await.unwrap_err();
+        assert!(error.to_string().contains("bad item 3"), "{error}");
+        assert!(processed.load(Ordering::SeqCst) < 1_000);
+        assert!(
+            !parent.is_cancelled(),
+            "failures must not cancel the parent"
+        );
+    }
+
+    #[tokio::test(flavor = "multi_thread", worker_threads = 2)]
+    async fn panics_are_reported_as_errors() {
+        let pool = WorkerPool::new(2, |n: u32| async move {
+            if n == 1 {
+                panic!("boom");
+            }
+            Ok(())
+        });
+        for n in 0..2 {
+            let _ = pool.submit(n).await;
+        }
+        let error = pool.wait().await.unwrap_err();
+        assert!(error.to_string().contains("boom"), "{error}");
+    }
+
+    #[tokio::test]
+    async fn parent_cancellation_stops_the_pool_without_error() {
+        let parent = CancellationToken::new();
+        let mut pool = WorkerPool::with_cancellation(2, parent.clone(), |_: u8| async {
+            std::future::pending::<()>().await;
+            Ok(())
+        });
+        pool.submit(1).await.unwrap();
+        parent.cancel();
+
+        assert!(pool.is_cancelled());
+        assert_eq!(pool.submit(2).await, Err(2));
+        pool.close();
+        assert!(pool.wait().await.is_ok());
+    }
+
+    #[tokio::test]
+    async fn closed_pool_rejects_items() {
+        let mut pool = WorkerPool::new(1, |_: u8| async { Ok(()) });
+        pool.close();
+        assert_eq!(pool.submit(7).await, Err(7));
+        assert!(pool.wait().await.is_ok());
+    }
+}
@@ -0,0 +1,104 @@
+//! Human-friendly byte sizes such as `512kb` or `1.5mb`.
+
+use crate::core::errors::{Result, ValknutError};
+
+/// Unit suffixes and their multipliers. Units are binary (`1kb` = 1024 bytes).
+const UNITS: &[(&str, u64)] = &[
+    ("", 1),
+    ("b", 1),
+    ("k", 1 << 10),
+    ("kb", 1 << 10),
+    ("kib", 1 << 10),
+    ("m", 1 << 20),
+    ("mb", 1 << 20),
+    ("mib", 1 << 20),
+    ("g", 1 << 30),
+    ("gb", 1 << 30),
+    ("gib", 1 << 30),
+];
+
+/// Parse a byte count with an optional, case-insensitive unit suffix.
+///
+/// Accepts plain integers (`1048576`), suffixed values (`512kb`, `2GB`) and
+/// fractional values (`1.5mb`); fractions are rounded down to whole bytes.
+pub fn parse_byte_size(input: &str) -> Result<u64> {
+    let trimmed = input.trim();
+    let split = trimmed
+        .find(|c: char| !(c.is_ascii_digit() || c == '.'))
+        .unwrap_or(trimmed.len());
+    let (number, unit) = trimmed.split_at(split);
+    let unit = unit.trim().to_ascii_lowercase();
+
+    let invalid = || {
+        ValknutError::validation(format!(
+            "invalid size '{input}': expected a number with an optional b/kb/mb/gb suffix"
+        ))
+    };
+    let multiplier = UNITS
+        .iter()
+        .find(|(suffix, _)| *suffix == unit)
+        .map(|(_, multiplier)| *multiplier)
+        .ok_or_else(invalid)?;
+
+    if let Ok(whole) = number.parse::<u64>() {
+        return whole.checked_mul(multiplier).ok_or_else(|| {
+            ValknutError::validation(format!("size '{input}' does not fit in 64 bits"))
+        });
+    }
+    let value: f64 = number.parse().map_err(|_| invalid())?;
+    let bytes = value * multiplier as f64;
+    if !bytes.is_finite() || bytes >= u64::MAX as f64 {
+        return Err(ValknutError::validation(format!(
+            "size '{input}' does not fit in 64 bits"
+        )));
+    }
+    Ok(bytes as u64)
+}
+
+/// Render a byte count with the largest unit that keeps it at least 1.
+pub fn format_byte_size(bytes: u64) -> String {
+    const STEPS: &[(&str, u64)] = &[("GB", 1 << 30), ("MB", 1 << 20), ("KB", 1 << 10)];
+    for (unit, size) in STEPS {
+        if bytes >= *size {
+            let value = bytes as f64 / *size as f64;
+            return if bytes % size == 0 {
+                format!("{}{unit}", bytes / size)
+            } else {
+                format!("{value:.1}{unit}")
+            };
+        }
+    }
+    format!("{bytes}B")
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn parses_plain_and_suffixed_sizes() {
+        assert_eq!(parse_byte_size("1048576").unwrap(), 1 << 20);
+        assert_eq!(parse_byte_size("512kb").unwrap(), 512 * 1024);
+        assert_eq!(parse_byte_size("1mb").unwrap(), 1 << 20);
+        assert_eq!(parse_byte_size("2GB").unwrap(), 2 << 30);
+        assert_eq!(parse_byte_size(" 1.5 MB ").unwrap(), 3 << 19);
+        assert_eq!(parse_byte_size("0").unwrap(), 0);
+    }
+
+    #[test]
+    fn rejects_malformed_sizes() {
+        for input in ["", "mb", "1tb", "1.2.3kb", "-1kb", "99999999999gb"] {
+            assert!(
+                parse_byte_size(input).is_err(),
+                "{input} should be rejected"
+            );
+        }
+    }
+
+    #[test]
+    fn formats_with_largest_unit() {
+        assert_eq!(format_byte_size(1 << 20), "1MB");
+        assert_eq!(format_byte_size(3 << 19), "1.5MB");
+        assert_eq!(format_byte_size(900), "900B");
+    }
+}
@@ -0,0 +1,113 @@
+//! Human-friendly durations such as `90s`, `5m` or `1h30m`.
+
+use std::time::Duration;
+
+use crate::core::errors::{Result, ValknutError};
+
+/// Unit suffixes and their length in milliseconds.
+const UNITS: &[(&str, u64)] = &[("ms", 1), ("s", 1_000), ("m", 60_000), ("h", 3_600_000)];
+
+/// Parse a duration made of one or more `<number><unit>` parts.
+///
+/// Units are `ms`, `s`, `m` and `h` (`5m`, `1h30m`, `1.5s`); a plain number
+/// (`300`) is a number of seconds. Zero is rejected because it could never
+/// let any work finish.
+pub fn parse_duration(input: &str) -> Result<Duration> {
+    let trimmed = input.trim().to_ascii_lowercase();
+    let invalid = || {
+        ValknutError::validation(format!(
+            "invalid duration '{input}': expected a number with an ms/s/m/h suffix, e.g. 90s or 5m"
+        ))
+    };
+    if trimmed.is_empty() {
+        return Err(invalid());
+    }
+    if let Ok(seconds) = trimmed.parse::<u64>() {
+        return match seconds {
+            0 => Err(invalid()),
+            seconds => Ok(Duration::from_secs(seconds)),
+        };
+    }
+
+    let mut millis = 0f64;
+    let mut rest = trimmed.as_str();
+    while !rest.is_empty() {
+        let split = rest
+            .find(|c: char| !(c.is_ascii_digit() || c == '.'))
+            .ok_or_else(invalid)?;
+        let (number, tail) = rest.split_at(split);
+        let unit_len = tail
+            .find(|c: char| c.is_ascii_digit() || c == '.')
+            .unwrap_or(tail.len());
+        let (unit, tail) = tail.split_at(unit_len);
+        let value: f64 = number.parse().map_err(|_| invalid())?;
+        let scale = UNITS
+            .iter()
+            .find(|(suffix, _)| *suffix == unit)
+            .map(|(_, scale)| *scale)
+            .ok_or_else(invalid)?;
+        millis += value * scale as f64;
+        rest = tail;
+    }
+    if !millis.is_finite() || millis < 1.0 || millis >= u64::MAX as f64 {
+        return Err(invalid());
+    }
+    Ok(Duration::from_millis(millis as u64))
+}
+
+/// Render a duration with the largest units that keep it exact, e.g. `5m` or
+/// `1h30m`; sub-second durations are shown in milliseconds.
+pub fn format_duration(duration: Duration) -> String {
+    let millis = duration.as_millis();
+    if millis % 1_000 != 0 {
+        return format!("{millis}ms");
+    }
+    let mut seconds = duration.as_secs();
+    let mut out = String::new();
+    for (unit, size) in [("h", 3_600), ("m", 60), ("s", 1)] {
+        if seconds >= size {
+            out.push_str(&format!("{}{unit}", seconds / size));
+            seconds %= size;
+        }
+    }
+    if out.is_empty() {
+        out.push_str("0s");
+    }
+    out
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn parses_plain_and_compound_durations() {
+        assert_eq!(parse_duration("300").unwrap(), Duration::from_secs(300));
+        assert_eq!(parse_duration("90s").unwrap(), Duration::from_secs(90));
+        assert_eq!(parse_duration("5m").unwrap(), Duration::from_secs(300));
+        assert_eq!(
+            parse_duration(" 1H30m ").unwrap(),
+            Duration::from_secs(5_400)
+        );
+        assert_eq!(
+            parse_duration("1.5s").unwrap(),
+            Duration::from_millis(1_500)
+        );
+        assert_eq!(parse_duration("250ms").unwrap(), Duration::from_millis(250));
+    }
+
+    #[test]
+    fn rejects_malformed_durations() {
+        for input in ["", "0", "0s", "m", "5x", "5m3", "1.2.3s", "-5m"] {
+            assert!(parse_duration(input).is_err(), "{input} should be rejected");
+        }
+    }
+
+    #[test]
+    fn formats_with_exact_units() {
+        assert_eq!(format_duration(Duration::from_secs(300)), "5m");
+        assert_eq!(format_duration(Duration::from_secs(5_400)), "1h30m");
+        assert_eq!(format_duration(Duration::from_secs(61)), "1m1s");
+        assert_eq!(format_duration(Duration::from_millis(250)), "250ms");
+    }
+}
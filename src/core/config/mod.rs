@@ -4,12 +4,14 @@
 //! the Python implementation while adding Rust-specific optimizations and
 //! type safety guarantees.
 
+pub mod byte_size;
 pub mod dedupe;
+pub mod duration;
 pub mod live_reach;
 pub mod scoring;
 pub mod validation;
 
-use std::collections::HashMap;
+use std::collections::{BTreeMap, HashMap};
 use std::path::PathBuf;
 
 use serde::{Deserialize, Serialize};
@@ -18,6 +20,7 @@ use crate::core::errors::{Result, ValknutError};
 use crate::detectors::bundled::BundledDetectionConfig;
 use crate::detectors::cohesion::CohesionConfig;
 use crate::detectors::structure::StructureConfig;
+use crate::lang::registry::{language_key_for_extension, normalize_language_key};
 
 // Re-export types from submodules
 pub use dedupe::{
@@ -130,6 +133,14 @@ pub struct ValknutConfig {
     #[serde(skip_serializing_if = "Option::is_none")]
     pub live_reach: Option<LiveReachConfig>,
 
+    /// Lint rules evaluated after analysis
+    #[serde(default)]
+    pub rules: Vec<crate::detectors::rules::RuleConfig>,
+
+    /// External language parser plugins
+    #[serde(default)]
+    pub plugins: crate::lang::plugins::PluginConfig,
+
     /// Code quality analysis configuration (simple pattern-based analysis)
     // pub names: NamesConfig,
     /// Placeholder to maintain serialization compatibility
@@ -167,6 +178,8 @@ impl ValknutConfig {
             cohesion: CohesionConfig::default(),
             bundled: BundledDetectionConfig::default(),
             live_reach: None,
+            rules: Vec::new(),
+            plugins: crate::lang::plugins::PluginConfig::default(),
             _names_placeholder: None,
         }
     }
@@ -201,7 +214,7 @@ impl ValknutConfig {
             "python".to_string(),
             LanguageConfig {
                 enabled: true,
-                file_extensions: vec![".py".to_string(), ".pyi".to_string()],
+                file_extensions: vec![".py".to_string(), ".pyi".to_string(), ".ipynb".to_string()],
                 tree_sitter_language: "python".to_string(),
                 max_file_size_mb: 10.0,
                 complexity_threshold: 10.0,
@@ -279,6 +292,54 @@ impl ValknutConfig {
             },
         );
 
+        languages.insert(
+            "java".to_string(),
+            LanguageConfig {
+                enabled: true,
+                file_extensions: vec![".java".to_string()],
+                tree_sitter_language: "java".to_string(),
+                max_file_size_mb: 10.0,
+                complexity_threshold: 15.0,
+                additional_settings: HashMap::new(),
+            },
+        );
+
+        languages.insert(
+            "csharp".to_string(),
+            LanguageConfig {
+                enabled: true,
+                file_extensions: vec![".cs".to_string()],
+                tree_sitter_language: "cs".to_string(),
+                max_file_size_mb: 10.0,
+                complexity_threshold: 15.0,
+                additional_settings: HashMap::new(),
+            },
+        );
+
+        languages.insert(
+            "kotlin".to_string(),
+            LanguageConfig {
+                enabled: true,
+                file_extensions: vec![".kt".to_string(), ".kts".to_string()],
+                tree_sitter_language: "kt".to_string(),
+                max_file_size_mb: 10.0,
+                complexity_threshold: 15.0,
+                additional_settings: HashMap::new(),
+            },
+        );
+
+        languages.insert(
+            "c".to_string(),
+            LanguageConfig {
+                enabled: true,
+                file_extensions: vec![".c".to_string()],
+                tree_sitter_language: "c".to_string(),
+                max_file_size_mb: 10.0,
+                complexity_threshold: 15.0,
+                additional_settings: HashMap::new(),
+            },
+        );
+
         languages
     }
 
@@ -293,6 +354,8 @@ impl ValknutConfig {
         self.dedupe.validate()?;
         self.denoise.validate()?;
         self.coverage.validate()?;
+        self.plugins.validate()?;
+        crate::detectors::rules::RuleEngine::from_configs(&self.rules)?;
         Ok(())
     }
 
@@ -365,10 +428,82 @@ pub struct AnalysisConfig {
     #[serde(default)]
     pub ignore_patterns: Vec<String>,
 
-    /// Maximum file size in bytes to analyze (0 = unlimited, default = 500KB)
-    /// Files larger than this are skipped during file discovery
+    /// Honor `.valknutignore` files found in the analyzed tree
+    #[serde(default = "AnalysisConfig::default_use_ignore_files")]
+    pub use_ignore_files: bool,
+
+    /// Directory levels listed on their own thread when discovery falls back to
+    /// walking the filesystem (0 = walk on a single thread)
+    #[serde(default = "AnalysisConfig::default_discovery_fanout_depth")]
+    pub discovery_fanout_depth: usize,
+
+    /// Maximum file size in bytes to analyze (0 = unlimited, default = 1MB)
+    /// Larger files are skipped during discovery and reported as skipped
     #[serde(default = "AnalysisConfig::default_max_file_size_bytes")]
     pub max_file_size_bytes: u64,
+
+    /// Keep test-context files (`tests/`, `testdata/`, `*_test.go`) in the
+    /// primary output. They are labeled and counted separately either way
+    #[serde(default = "AnalysisConfig::default_include_tests")]
+    pub include_tests: bool,
+
+    /// Keep files marked `Code generated ... DO NOT EDIT` in complexity and
+    /// coverage metrics. They are always part of the dependency graph
+    #[serde(default = "AnalysisConfig::default_include_generated")]
+    pub include_generated: bool,
+
+    /// Analyze dependency and build-output directories (`vendor/`,
+    /// `node_modules/`, `target/`, ...) that discovery skips by default
+    #[serde(default)]
+    pub include_vendor: bool,
+
+    /// Group functions that are identical after normalizing away names,
+    /// literal values and comments into clone classes
+    #[serde(default)]
+    pub detect_duplicates: bool,
+
+    /// Smallest function, in normalized AST nodes, considered by duplicate detection
+    #[serde(default = "AnalysisConfig::default_min_clone_nodes")]
+    pub min_clone_nodes: usize,
+
+    /// Expression complexity (nested calls, boolean chains, type assertions)
+    /// above which a function is reported
+    #[serde(default = "AnalysisConfig::default_expression_complexity_threshold")]
+    pub expression_complexity_threshold: f64,
+
+    /// Replace string literal values in the results with `<redacted>`
+    #[serde(default)]
+    pub redact_strings: bool,
+
+    /// Replace comment text in the results with `<redacted>`
+    #[serde(default)]
+    pub redact_comments: bool,
+
+    /// Deepest AST node entity extraction visits; files nested deeper fail to
+    /// parse instead of exhausting the stack
+    #[serde(default = "AnalysisConfig::default_max_ast_depth")]
+    pub max_ast_depth: usize,
+
+    /// Extra extension-to-language mappings, e.g. `hcl = "terraform"`.
+    /// Mapped files are discovered and, when the language has no parser,
+    /// reported as `language_unknown` instead of being analyzed
+    #[serde(default, skip_serializing_if = "BTreeMap::is_empty")]
+    pub language_mappings: BTreeMap<String, String>,
+
+    /// Restrict discovery to these files (set at runtime, e.g. by `--since`).
+    /// Other filters and `.valknutignore` still apply; `None` keeps every file
+    #[serde(skip)]
+    pub only_files: Option<Vec<PathBuf>>,
+
+    /// Only discover Go files whose build constraints hold for this target
+    /// (set at runtime by `--os`/`--arch`/`--tags`); `None` keeps every file
+    #[serde(skip)]
+    pub build_target: Option<crate::lang::go_build::BuildTarget>,
+
+    /// Stop starting new files once this instant has passed (set at runtime
+    /// by `--timeout`); files not reached are reported as not analyzed
+    #[serde(skip)]
+    pub deadline: Option<std::time::Instant>,
 }
 
 /// Default implementation for [`AnalysisConfig`].
@@ -386,30 +521,107 @@ impl Default for AnalysisConfig {
             enable_cohesion_analysis: false, // Disabled by default - experimental
             confidence_threshold: 0.7,
             max_files: 0,
-            exclude_patterns: vec![
-                "*/node_modules/*".to_string(),
-                "*/venv/*".to_string(),
-                "*/target/*".to_string(),
-                "*/__pycache__/*".to_string(),
-                "*.min.js".to_string(),
-            ],
+            // Dependency directories are skipped by discovery (see `include_vendor`)
+            exclude_patterns: vec!["*.min.js".to_string()],
             include_patterns: vec!["**/*".to_string()],
             ignore_patterns: Vec::new(),
+            use_ignore_files: Self::default_use_ignore_files(),
+            discovery_fanout_depth: Self::default_discovery_fanout_depth(),
             max_file_size_bytes: Self::default_max_file_size_bytes(),
+            include_tests: Self::default_include_tests(),
+            include_generated: Self::default_include_generated(),
+            include_vendor: false,
+            detect_duplicates: false,
+            min_clone_nodes: Self::default_min_clone_nodes(),
+            expression_complexity_threshold: Self::default_expression_complexity_threshold(),
+            redact_strings: false,
+            redact_comments: false,
+            max_ast_depth: Self::default_max_ast_depth(),
+            language_mappings: BTreeMap::new(),
+            only_files: None,
+            build_target: None,
+            deadline: None,
         }
     }
 }
 
 /// Default values and validation for [`AnalysisConfig`].
 impl AnalysisConfig {
-    /// Default maximum file size: 500KB
+    /// Default maximum file size: 1MB
     pub const fn default_max_file_size_bytes() -> u64 {
-        500 * 1024
+        1024 * 1024
+    }
+
+    /// `.valknutignore` files are honored unless explicitly disabled
+    pub const fn default_use_ignore_files() -> bool {
+        true
+    }
+
+    /// Discovery lists the first two directory levels in parallel
+    pub const fn default_discovery_fanout_depth() -> usize {
+        2
+    }
+
+    /// Test code is reported alongside source code unless excluded
+    pub const fn default_include_tests() -> bool {
+        true
+    }
+
+    /// Generated code is left out of complexity and coverage metrics
+    pub const fn default_include_generated() -> bool {
+        false
+    }
+
+    /// Duplicate detection ignores functions under 50 normalized AST nodes
+    pub const fn default_min_clone_nodes() -> usize {
+        crate::detectors::duplicates::DEFAULT_MIN_CLONE_NODES
+    }
+
+    /// Functions are reported once their expression complexity exceeds 20
+    pub const fn default_expression_complexity_threshold() -> f64 {
+        crate::detectors::complexity::DEFAULT_EXPRESSION_COMPLEXITY_THRESHOLD
+    }
+
+    /// Entity extraction descends at most 256 AST levels
+    pub const fn default_max_ast_depth() -> usize {
+        crate::lang::common::DEFAULT_MAX_RECURSION_DEPTH
+    }
+
+    /// What `redact_strings` and `redact_comments` remove from the results
+    pub fn redaction(&self) -> crate::core::redaction::RedactionOptions {
+        crate::core::redaction::RedactionOptions {
+            strings: self.redact_strings,
+            comments: self.redact_comments,
+        }
     }
 
     /// Validate analysis configuration
     pub fn validate(&self) -> Result<()> {
         validate_unit_range(self.confidence_threshold, "confidence_threshold")?;
+        validate_positive_usize(self.min_clone_nodes, "min_clone_nodes")?;
+        validate_positive_usize(self.max_ast_depth, "max_ast_depth")?;
+        validate_positive_f64(
+            self.expression_complexity_threshold,
+            "expression_complexity_threshold",
+        )?;
+        for (extension, language) in &self.language_mappings {
+            if extension.trim_start_matches('.').trim().is_empty() || language.trim().is_empty() {
+                return Err(ValknutError::config_field(
+                    format!("Invalid language mapping '{extension}' = '{language}': extension and language must be non-empty"),
+                    "analysis.language_mappings",
+                ));
+            }
+            // Adapters are picked by registered extension, so a mapping can only
+            // name a parsed language for an extension already dispatched to it.
+            if let Some(key) = normalize_language_key(language) {
+                if language_key_for_extension(extension.trim()) != Some(key) {
+                    return Err(ValknutError::config_field(
+                        format!("Invalid language mapping '{extension}' = '{language}': {language} files are only parsed by their registered extensions"),
+                        "analysis.language_mappings",
+                    ));
+                }
+            }
+        }
         Ok(())
     }
 }
@@ -440,6 +652,14 @@ pub struct GraphConfig {
     /// Sampling rate for approximation algorithms
     #[serde(default)]
     pub approximation_sample_rate: f64,
+
+    /// Export the function-level call graph as an adjacency list
+    #[serde(default)]
+    pub enable_call_graph: bool,
+
+    /// Maximum call-graph traversal depth from entry points (None = unbounded)
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub call_graph_depth: Option<usize>,
 }
 
 /// Default implementation for [`GraphConfig`].
@@ -453,6 +673,8 @@ impl Default for GraphConfig {
             max_exact_size: 10000,
             use_approximation: true,
             approximation_sample_rate: 0.1,
+            enable_call_graph: false,
+            call_graph_depth: None,
         }
     }
 }
@@ -620,6 +842,11 @@ pub struct IoConfig {
     #[serde(default)]
     pub cache_ttl_seconds: u64,
 
+    /// Maximum serialized size of the incremental analysis cache in bytes;
+    /// least recently used files are evicted past it (0 = unlimited, default = 1GB)
+    #[serde(default = "IoConfig::default_cache_max_size_bytes")]
+    pub cache_max_size_bytes: u64,
+
     /// Report output directory
     pub report_dir: Option<PathBuf>,
 
@@ -645,6 +872,7 @@ impl Default for IoConfig {
             cache_dir: None,
             enable_caching: true,
             cache_ttl_seconds: 3600, // 1 hour
+            cache_max_size_bytes: Self::default_cache_max_size_bytes(),
             report_dir: None,
             report_format: ReportFormat::Json,
             #[cfg(feature = "database")]
@@ -655,6 +883,14 @@ impl Default for IoConfig {
     }
 }
 
+/// Default values for [`IoConfig`].
+impl IoConfig {
+    /// Default incremental cache size: 1GB
+    pub const fn default_cache_max_size_bytes() -> u64 {
+        crate::io::cache::IncrementalCache::default_max_size_bytes()
+    }
+}
+
 /// Available report formats
 #[derive(Debug, Clone, Serialize, Deserialize, Default)]
 #[serde(rename_all = "snake_case")]
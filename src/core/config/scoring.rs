@@ -3,6 +3,8 @@
 //! This module contains configuration for scoring, normalization schemes,
 //! feature weights, and statistical parameters.
 
+use std::collections::BTreeMap;
+
 use serde::{Deserialize, Serialize};
 
 use crate::core::errors::{Result, ValknutError};
@@ -29,6 +31,11 @@ pub struct ScoringConfig {
     /// Statistical parameters
     #[serde(default)]
     pub statistical_params: StatisticalParams,
+
+    /// Per-language complexity baseline factors (e.g. `go: 1.3`), applied on
+    /// top of the built-in ones
+    #[serde(default, skip_serializing_if = "BTreeMap::is_empty")]
+    pub complexity_baselines: BTreeMap<String, f64>,
 }
 
 /// Default implementation for [`ScoringConfig`].
@@ -41,6 +48,7 @@ impl Default for ScoringConfig {
             confidence_reporting: false,
             weights: WeightsConfig::default(),
             statistical_params: StatisticalParams::default(),
+            complexity_baselines: BTreeMap::new(),
         }
     }
 }
@@ -51,6 +59,14 @@ impl ScoringConfig {
     pub fn validate(&self) -> Result<()> {
         self.weights.validate()?;
         self.statistical_params.validate()?;
+        for (language, &factor) in &self.complexity_baselines {
+            if !(factor > 0.0 && factor.is_finite()) {
+                return Err(ValknutError::validation(format!(
+                    "Complexity baseline for '{}' must be a positive number, got {}",
+                    language, factor
+                )));
+            }
+        }
         Ok(())
     }
 }
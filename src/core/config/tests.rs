@@ -37,6 +37,28 @@ fn analysis_config_confidence_threshold_bounds() {
     assert!(matches!(err, ValknutError::Validation { .. }));
 }
 
+#[test]
+fn analysis_config_language_mappings_only_name_parsed_languages_by_extension() {
+    let mut config = AnalysisConfig::default();
+    for (extension, language) in [(".hcl", "terraform"), ("pyi", "python"), ("h", "cpp")] {
+        config
+            .language_mappings
+            .insert(extension.to_string(), language.to_string());
+    }
+    config
+        .validate()
+        .expect("mappings consistent with the registry");
+
+    config
+        .language_mappings
+        .insert("pyx".to_string(), "python".to_string());
+    let err = expect_validation_error(config.validate());
+    assert!(matches!(
+        err,
+        ValknutError::Config { field: Some(ref field), .. } if field == "analysis.language_mappings"
+    ));
+}
+
 #[test]
 fn coverage_config_requires_patterns_when_auto_discovering() {
     let mut config = CoverageConfig::default();
@@ -0,0 +1,470 @@
+//! Files embedded into Go packages with `//go:embed`.
+//!
+//! Embedded SQL migrations, templates and static assets are logically part of
+//! the package that embeds them even though no Go code references them by
+//! import. This module resolves `//go:embed` patterns the way the Go toolchain
+//! does (relative to the package directory, directories expanded recursively,
+//! hidden `.`/`_` entries skipped unless the pattern carries `all:`), infers
+//! each file's language from its extension, and summarises SQL files into the
+//! tables they touch and the statement kinds they contain.
+
+use std::collections::{BTreeMap, BTreeSet};
+use std::path::{Path, PathBuf};
+
+use globset::GlobBuilder;
+use serde::{Deserialize, Serialize};
+use tracing::warn;
+use walkdir::WalkDir;
+
+use crate::lang::registry::detect_language_from_path;
+
+/// A file embedded into a package by a `//go:embed` directive.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct EmbeddedAsset {
+    /// Path of the file relative to the project root, `/`-separated.
+    pub path: String,
+    /// The `//go:embed` pattern that matched the file.
+    pub pattern: String,
+    /// Language inferred from the extension: a registered language key, or the
+    /// lowercase extension (`sql`, `html`, ...) for other files.
+    pub language: String,
+    /// Size of the file in bytes.
+    pub bytes: u64,
+    /// Number of lines in the file (0 for binary content).
+    pub lines: usize,
+    /// Tables and statement kinds, for SQL files.
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub sql: Option<SqlSummary>,
+}
+
+/// Tables and query patterns found in a SQL file.
+#[derive(Debug, Clone, Default, PartialEq, Eq, Serialize, Deserialize)]
+pub struct SqlSummary {
+    /// Tables read, written, created or referenced, sorted and deduplicated.
+    pub tables: Vec<String>,
+    /// Number of statements of each kind (`SELECT`, `CREATE TABLE`, ...).
+    pub statements: BTreeMap<String, usize>,
+}
+
+/// Resolve the embed `patterns` of the package in `package_dir` into assets.
+///
+/// Paths are reported relative to `root`. Patterns that match nothing are
+/// logged and skipped rather than failing the whole graph, since the Go
+/// compiler is the authority on invalid directives.
+pub fn collect_embedded_assets(
+    root: &Path,
+    package_dir: &Path,
+    patterns: &[String],
+) -> Vec<EmbeddedAsset> {
+    let mut assets = Vec::new();
+    let mut seen = BTreeSet::new();
+    for pattern in patterns {
+        let files = resolve_embed_pattern(package_dir, pattern);
+        if files.is_empty() {
+            warn!(
+                "//go:embed pattern '{}' in {} matched no files",
+                pattern,
+                package_dir.display()
+            );
+        }
+        for file in files {
+            if seen.insert(file.clone()) {
+                assets.push(embedded_asset(root, &file, pattern));
+            }
+        }
+    }
+    assets
+}
+
+/// Files matched by a single `//go:embed` pattern, sorted.
+///
+/// A pattern naming a directory embeds every file beneath it except those
+/// whose names start with `.` or `_`; the `all:` prefix includes them too.
+/// Files named directly by the pattern are always included.
+pub fn resolve_embed_pattern(package_dir: &Path, pattern: &str) -> Vec<PathBuf> {
+    let (include_hidden, pattern) = match pattern.strip_prefix("all:") {
+        Some(rest) => (true, rest),
+        None => (false, pattern),
+    };
+    let matcher = match GlobBuilder::new(pattern).literal_separator(true).build() {
+        Ok(glob) => glob.compile_matcher(),
+        Err(err) => {
+            warn!("Invalid //go:embed pattern '{}': {}", pattern, err);
+            return Vec::new();
+        }
+    };
+
+    let mut files = BTreeSet::new();
+    let mut walker = WalkDir::new(package_dir).min_depth(1).into_iter();
+    while let Some(entry) = walker.next() {
+        let Ok(entry) = entry else {
+            continue;
+        };
+        let Ok(relative) = entry.path().strip_prefix(package_dir) else {
+            continue;
+        };
+        if !matcher.is_match(relative) {
+            continue;
+        }
+        if entry.file_type().is_dir() {
+            files.extend(files_beneath(entry.path(), include_hidden));
+            walker.skip_current_dir();
+        } else if entry.file_type().is_file() {
+            files.insert(entry.path().to_path_buf());
+        }
+    }
+    files.into_iter().collect()
+}
+
+/// Every file beneath `dir`, skipping `.`/`_` entries unless `include_hidden`.
+fn files_beneath(dir: &Path, include_hidden: bool) -> Vec<PathBuf> {
+    WalkDir::new(dir)
+        .min_depth(1)
+        .into_iter()
+        .filter_entry(|entry| {
+            include_hidden
+                || !entry
+                    .file_name()
+                    .to_str()
+                    .is_some_and(|name| name.starts_with('.') || name.starts_with('_'))
+        })
+        .filter_map(|entry| entry.ok())
+        .filter(|entry| entry.file_type().is_file())
+        .map(|entry| entry.into_path())
+        .collect()
+}
+
+/// Build the asset record for one embedded file.
+fn embedded_asset(root: &Path, file: &Path, pattern: &str) -> EmbeddedAsset {
+    let relative = file.strip_prefix(root).unwrap_or(file);
+    let path = relative
+        .components()
+        .map(|c| c.as_os_str().to_string_lossy())
+        .collect::<Vec<_>>()
+        .join("/");
+    let language = asset_language(file);
+    let bytes = std::fs::metadata(file).map(|meta| meta.len()).unwrap_or(0);
+    let contents = std::fs::read_to_string(file).ok();
+    let lines = contents.as_deref().map_or(0, |text| text.lines().count());
+    let sql = (language == "sql")
+        .then_some(contents.as_deref())
+        .flatten()
+        .map(summarize_sql);
+
+    EmbeddedAsset {
+        path,
+        pattern: pattern.to_string(),
+        language,
+        bytes,
+        lines,
+        sql,
+    }
+}
+
+/// Registered language key for `file`, falling back to its lowercase extension.
+fn asset_language(file: &Path) -> String {
+    let detected = detect_language_from_path(&file.to_string_lossy());
+    if detected != "txt" {
+        return detected;
+    }
+    file.extension()
+        .map(|ext| ext.to_string_lossy().to_ascii_lowercase())
+        .unwrap_or_else(|| "txt".to_string())
+}
+
+/// Keywords after which the next identifier names a table.
+const TABLE_KEYWORDS: &[&str] = &["FROM", "JOIN", "INTO", "UPDATE", "TABLE", "REFERENCES"];
+
+/// Words skipped between a table keyword and the table name.
+const TABLE_NAME_PREFIXES: &[&str] = &["IF", "NOT", "EXISTS", "ONLY", "LATERAL"];
+
+/// Statement keywords that name the kind of a `WITH ...` query.
+const DML_KEYWORDS: &[&str] = &["SELECT", "INSERT", "UPDATE", "DELETE", "MERGE"];
+
+/// Extract the tables and statement kinds from SQL source.
+///
+/// This is a lexical scan rather than a full SQL parser: comments and string
+/// literals are skipped, statements are split on `;`, and the identifier
+/// following `FROM`, `JOIN`, `INTO`, `UPDATE`, `TABLE` or `REFERENCES` (and
+/// `ON` in `CREATE INDEX`) is taken as a table name.
+pub fn summarize_sql(source: &str) -> SqlSummary {
+    let mut tables = BTreeSet::new();
+    let mut statements = BTreeMap::new();
+
+    for statement in split_statements(source) {
+        let tokens = tokenize(&statement);
+        let Some(kind) = statement_kind(&tokens) else {
+            continue;
+        };
+        let is_index = kind.ends_with("INDEX");
+        for (position, token) in tokens.iter().enumerate() {
+            let keyword = token.to_ascii_uppercase();
+            let introduces_table =
+                TABLE_KEYWORDS.contains(&keyword.as_str()) || (is_index && keyword == "ON");
+            if !introduces_table {
+                continue;
+            }
+            // A keyword followed by `SET` (`ON CONFLICT DO UPDATE SET`) or a
+            // subquery names no table.
+            let name = tokens[position + 1..]
+                .iter()
+                .find(|candidate| {
+                    !TABLE_NAME_PREFIXES.contains(&candidate.to_ascii_uppercase().as_str())
+                })
+                .filter(|candidate| is_table_name(candidate));
+            if let Some(name) = name {
+                tables.insert(unquote_identifier(name));
+            }
+        }
+        *statements.entry(kind).or_insert(0) += 1;
+    }
+
+    SqlSummary {
+        tables: tables.into_iter().collect(),
+        statements,
+    }
+}
+
+/// Split SQL into statements, dropping comments and string literal contents.
+fn split_statements(source: &str) -> Vec<String> {
+    let mut statements = Vec::new();
+    let mut current = String::new();
+    let mut chars = source.chars().peekable();
+    while let Some(c) = chars.next() {
+        match c {
+            '-' if chars.peek() == Some(&'-') => {
+                for next in chars.by_ref() {
+                    if next == '\n' {
+                        break;
+                    }
+                }
+                current.push(' ');
+            }
+            '/' if chars.peek() == Some(&'*') => {
+                chars.next();
+                let mut previous = ' ';
+                for next in chars.by_ref() {
+                    if previous == '*' && next == '/' {
+                        break;
+                    }
+                    previous = next;
+                }
+                current.push(' ');
+            }
+            '\'' => {
+                for next in chars.by_ref() {
+                    if next == '\'' {
+                        break;
+                    }
+                }
+                current.push_str(" '' ");
+            }
+            ';' => statements.push(std::mem::take(&mut current)),
+            _ => current.push(c),
+        }
+    }
+    statements.push(current);
+    statements
+        .into_iter()
+        .filter(|statement| !statement.trim().is_empty())
+        .collect()
+}
+
+/// Words, quoted identifiers and single punctuation characters of a statement.
+fn tokenize(statement: &str) -> Vec<String> {
+    let mut tokens = Vec::new();
+    let mut current = String::new();
+    let mut quote: Option<char> = None;
+    for c in statement.chars() {
+        match quote {
+            Some(close) => {
+                current.push(c);
+                if c == close {
+                    quote = None;
+                }
+            }
+            None if c == '"' || c == '`' || c == '[' => {
+                current.push(c);
+                quote = Some(if c == '[' { ']' } else { c });
+            }
+            None if c.is_alphanumeric() || matches!(c, '_' | '.' | '$') => current.push(c),
+            None => {
+                if !current.is_empty() {
+                    tokens.push(std::mem::take(&mut current));
+                }
+                if !c.is_whitespace() {
+                    tokens.push(c.to_string());
+                }
+            }
+        }
+    }
+    if !current.is_empty() {
+        tokens.push(current);
+    }
+    tokens
+}
+
+/// Kind of a statement (`SELECT`, `CREATE TABLE`, ...) from its leading keywords.
+fn statement_kind(tokens: &[String]) -> Option<String> {
+    let words: Vec<String> = tokens
+        .iter()
+        .filter(|token| is_word(token))
+        .map(|token| token.to_ascii_uppercase())
+        .collect();
+    let first = words.first()?;
+    let kind = match first.as_str() {
+        "CREATE" | "ALTER" | "DROP" => {
+            let object = words[1..]
+                .iter()
+                .find(|word| {
+                    !matches!(
+                        word.as_str(),
+                        "OR" | "REPLACE" | "UNIQUE" | "TEMP" | "TEMPORARY"
+                    )
+                })
+                .cloned()
+                .unwrap_or_default();
+            format!("{first} {object}").trim_end().to_string()
+        }
+        "WITH" => main_statement_after_ctes(tokens),
+        _ => first.clone(),
+    };
+    Some(kind)
+}
+
+/// The DML keyword of a `WITH` query: the first one outside the CTE parentheses.
+fn main_statement_after_ctes(tokens: &[String]) -> String {
+    let mut depth = 0usize;
+    for token in tokens {
+        match token.as_str() {
+            "(" => depth += 1,
+            ")" => depth = depth.saturating_sub(1),
+            word if depth == 0 => {
+                let upper = word.to_ascii_uppercase();
+                if DML_KEYWORDS.contains(&upper.as_str()) {
+                    return upper;
+                }
+            }
+            _ => {}
+        }
+    }
+    "SELECT".to_string()
+}
+
+/// Returns true for bare words and quoted identifiers, not punctuation or literals.
+fn is_word(token: &str) -> bool {
+    token
+        .chars()
+        .next()
+        .is_some_and(|c| c.is_alphabetic() || matches!(c, '_' | '"' | '`' | '['))
+}
+
+/// Returns true for words that can name a table (not a clause keyword).
+fn is_table_name(token: &str) -> bool {
+    is_word(token)
+        && !matches!(
+            token.to_ascii_uppercase().as_str(),
+            "SET" | "SELECT" | "VALUES" | "WHERE" | "AS" | "ON" | "DEFAULT"
+        )
+}
+
+/// Strip identifier quoting from each segment of a possibly qualified name.
+fn unquote_identifier(name: &str) -> String {
+    name.chars()
+        .filter(|c| !matches!(c, '"' | '`' | '[' | ']'))
+        .collect()
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use std::fs;
+    use tempfile::tempdir;
+
+    fn write(root: &Path, relative: &str, contents: &str) {
+        let path = root.join(relative);
+        fs::create_dir_all(path.parent().unwrap()).unwrap();
+        fs::write(path, contents).unwrap();
+    }
+
+    #[test]
+    fn summarizes_tables_and_statement_kinds() {
+        let sql = r#"
+-- create the schema
+CREATE TABLE IF NOT EXISTS users (id SERIAL PRIMARY KEY, name TEXT);
+CREATE UNIQUE INDEX users_name ON users (name);
+CREATE TABLE "orders" (id INT, user_id INT REFERENCES users(id));
+/* seed; with a semicolon */
+INSERT INTO orders (id, user_id) VALUES (1, 'a;b');
+WITH recent AS (SELECT * FROM orders o JOIN app.users u ON u.id = o.user_id)
+UPDATE audit SET seen = true;
+"#;
+
+        let summary = summarize_sql(sql);
+        assert_eq!(
+            summary.tables,
+            vec!["app.users", "audit", "orders", "users"]
+        );
+        assert_eq!(summary.statements["CREATE TABLE"], 2);
+        assert_eq!(summary.statements["CREATE INDEX"], 1);
+        assert_eq!(summary.statements["INSERT"], 1);
+        assert_eq!(summary.statements["UPDATE"], 1);
+        assert_eq!(summary.statements.values().sum::<usize>(), 5);
+    }
+
+    #[test]
+    fn resolves_patterns_like_the_go_toolchain() {
+        let tmp = tempdir().unwrap();
+        let pkg = tmp.path().join("db");
+        write(&pkg, "migrations/001_init.sql", "CREATE TABLE t (id INT);");
+        write(&pkg, "migrations/_draft.sql", "");
+        write(&pkg, "migrations/.keep", "");
+        write(&pkg, "templates/page.html", "<p>hi</p>\n<p>there</p>\n");
+        write(&pkg, "db.go", "package db");
+
+        let visible = resolve_embed_pattern(&pkg, "migrations");
+        assert_eq!(visible, vec![pkg.join("migrations/001_init.sql")]);
+        assert_eq!(resolve_embed_pattern(&pkg, "all:migrations").len(), 3);
+        assert_eq!(
+            resolve_embed_pattern(&pkg, "*/*.html"),
+            vec![pkg.join("templates/page.html")]
+        );
+        assert!(resolve_embed_pattern(&pkg, "missing/*").is_empty());
+    }
+
+    #[test]
+    fn collects_assets_with_inferred_languages() {
+        let tmp = tempdir().unwrap();
+        let root = tmp.path();
+        let pkg = root.join("db");
+        write(&pkg, "schema.sql", "SELECT * FROM users;\n");
+        write(&pkg, "templates/page.html", "<p>hi</p>\n");
+        write(&pkg, "templates/app.js", "export {};\n");
+
+        let patterns = vec![
+            "schema.sql".to_string(),
+            "templates".to_string(),
+            "*.sql".to_string(),
+        ];
+        let assets = collect_embedded_assets(root, &pkg, &patterns);
+
+        let paths: Vec<&str> = assets.iter().map(|asset| asset.path.as_str()).collect();
+        assert_eq!(
+            paths,
+            vec![
+                "db/schema.sql",
+                "db/templates/app.js",
+                "db/templates/page.html"
+            ]
+        );
+        assert_eq!(assets[0].language, "sql");
+        assert_eq!(assets[0].lines, 1);
+        assert_eq!(
+            assets[0].sql.as_ref().map(|sql| sql.tables.clone()),
+            Some(vec!["users".to_string()])
+        );
+        assert_eq!(assets[1].language, "js");
+        assert_eq!(assets[2].language, "html");
+        assert!(assets[2].sql.is_none());
+    }
+}
@@ -0,0 +1,697 @@
+//! Dependency hygiene for Go modules.
+//!
+//! [`DependencyChecker::check`] reads the `require` directives of every
+//! `go.mod` beneath the roots, asks the module proxy (`GOPROXY`, defaulting to
+//! `proxy.golang.org`) for each module's latest version and queries the OSV
+//! database for known vulnerabilities in the required version. Modules matched
+//! by `GOPRIVATE`/`GONOPROXY` are never sent to the proxy, and those matched by
+//! `GOPRIVATE`/`GONOSUMDB` are never sent to OSV, mirroring what the `go` tool
+//! would disclose. Both lookups need network access, so the check only runs
+//! when requested.
+
+use std::cmp::Ordering;
+use std::collections::HashSet;
+use std::path::{Path, PathBuf};
+
+use futures::stream::{self, StreamExt};
+use globset::Glob;
+use serde::{Deserialize, Serialize};
+use tracing::warn;
+
+use super::go_modules::find_module_dirs;
+use crate::core::errors::{Result, ValknutError};
+
+/// Module proxy used when `GOPROXY` is unset.
+const DEFAULT_PROXY: &str = "https://proxy.golang.org";
+
+/// OSV batch query endpoint.
+const DEFAULT_OSV_URL: &str = "https://api.osv.dev/v1/querybatch";
+
+/// Largest number of queries OSV accepts in one batch.
+const OSV_BATCH_SIZE: usize = 1000;
+
+/// Concurrent requests to the module proxy.
+const PROXY_CONCURRENCY: usize = 8;
+
+/// One `require` directive from a `go.mod`.
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct GoRequirement {
+    /// Module path.
+    pub module: String,
+    /// Required version, e.g. `v1.4.2`.
+    pub version: String,
+    /// Marked `// indirect`.
+    pub indirect: bool,
+}
+
+/// Kind of semantic-version change between the current and latest version.
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Serialize, Deserialize)]
+#[serde(rename_all = "lowercase")]
+pub enum VersionBump {
+    /// Incompatible API change (`v1.x.y` to `v2.0.0`, or any change below `v1`'s minor).
+    Major,
+    /// New functionality.
+    Minor,
+    /// Bug fixes only.
+    Patch,
+    /// Same release, newer pre-release or pseudo-version.
+    Prerelease,
+}
+
+/// Hygiene report for one required module.
+#[derive(Debug, Clone, PartialEq, Serialize, Deserialize)]
+pub struct DependencyReport {
+    /// Module path.
+    pub module: String,
+    /// Version required by `go.mod`.
+    pub current_version: String,
+    /// Latest version known to the module proxy, when it could be looked up.
+    pub latest_version: Option<String>,
+    /// Change needed to reach `latest_version`; absent when up to date.
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub update: Option<VersionBump>,
+    /// Number of known vulnerabilities affecting `current_version`.
+    pub cve_count: usize,
+    /// OSV identifiers of those vulnerabilities (`GO-...`, `GHSA-...`).
+    pub cve_ids: Vec<String>,
+    /// Required only indirectly.
+    #[serde(default, skip_serializing_if = "std::ops::Not::not")]
+    pub indirect: bool,
+    /// Whether `go.sum` records a checksum for this module version.
+    pub in_go_sum: bool,
+    /// The `go.mod` that requires the module.
+    pub go_mod: PathBuf,
+}
+
+/// Methods for [`DependencyReport`].
+impl DependencyReport {
+    /// Whether a newer version is available.
+    pub fn is_outdated(&self) -> bool {
+        self.update.is_some()
+    }
+}
+
+/// Parse the `require` directives of a `go.mod`, in file order.
+///
+/// Both the single-line form and `require ( ... )` blocks are understood;
+/// other directives are ignored.
+pub fn parse_go_mod(content: &str) -> Vec<GoRequirement> {
+    let mut requirements = Vec::new();
+    let mut in_block = false;
+    for line in content.lines() {
+        let (code, comment) = match line.split_once("//") {
+            Some((code, comment)) => (code.trim(), comment.trim()),
+            None => (line.trim(), ""),
+        };
+        let spec = if in_block {
+            if code == ")" {
+                in_block = false;
+                continue;
+            }
+            code
+        } else if let Some(rest) = directive(code, "require") {
+            if rest == "(" {
+                in_block = true;
+                continue;
+            }
+            rest
+        } else {
+            continue;
+        };
+
+        let mut fields = spec.split_whitespace();
+        if let (Some(module), Some(version)) = (fields.next(), fields.next()) {
+            requirements.push(GoRequirement {
+                module: module.trim_matches('"').to_string(),
+                version: version.to_string(),
+                indirect: comment == "indirect",
+            });
+        }
+    }
+    requirements
+}
+
+/// The arguments of `keyword` when `line` is that directive.
+fn directive<'a>(line: &'a str, keyword: &str) -> Option<&'a str> {
+    let rest = line.strip_prefix(keyword)?;
+    (rest.is_empty() || rest.starts_with(char::is_whitespace)).then(|| rest.trim())
+}
+
+/// Module versions with a content checksum in a `go.sum`.
+///
+/// Lines that only hash a dependency's `go.mod` file are skipped.
+pub fn parse_go_sum(content: &str) -> HashSet<(String, String)> {
+    content
+        .lines()
+        .filter_map(|line| {
+            let mut fields = line.split_whitespace();
+            let module = fields.next()?;
+            let version = fields.next()?;
+            (!version.ends_with("/go.mod")).then(|| (module.to_string(), version.to_string()))
+        })
+        .collect()
+}
+
+/// A parsed Go module version (`vMAJOR.MINOR.PATCH[-PRERELEASE][+BUILD]`).
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct GoVersion {
+    /// Major version.
+    pub major: u64,
+    /// Minor version.
+    pub minor: u64,
+    /// Patch version.
+    pub patch: u64,
+    /// Pre-release identifiers, empty for releases. Pseudo-versions land here.
+    pub prerelease: Vec<String>,
+}
+
+/// Parsing and comparison methods for [`GoVersion`].
+impl GoVersion {
+    /// Parse a version; build metadata such as `+incompatible` is ignored.
+    pub fn parse(version: &str) -> Option<Self> {
+        let version = version.strip_prefix('v')?;
+        let version = version.split_once('+').map_or(version, |(core, _)| core);
+        let (core, prerelease) = match version.split_once('-') {
+            Some((core, pre)) => (core, pre.split('.').map(str::to_string).collect()),
+            None => (version, Vec::new()),
+        };
+        let mut numbers = core.split('.').map(|n| n.parse::<u64>().ok());
+        let version = Self {
+            major: numbers.next()??,
+            minor: numbers.next()??,
+            patch: numbers.next()??,
+            prerelease,
+        };
+        numbers.next().is_none().then_some(version)
+    }
+
+    /// The change needed to go from `self` to `newer`, or `None` if `newer` is not newer.
+    pub fn bump_to(&self, newer: &GoVersion) -> Option<VersionBump> {
+        if newer <= self {
+            return None;
+        }
+        Some(if newer.major != self.major {
+            VersionBump::Major
+        } else if newer.minor != self.minor {
+            // Below v1 every minor release may break the API.
+            if self.major == 0 {
+                VersionBump::Major
+            } else {
+                VersionBump::Minor
+            }
+        } else if newer.patch != self.patch {
+            VersionBump::Patch
+        } else {
+            VersionBump::Prerelease
+        })
+    }
+}
+
+/// Semantic-version precedence for [`GoVersion`].
+impl Ord for GoVersion {
+    /// Orders by release numbers, then pre-releases before the release,
+    /// then pre-release identifiers as semver specifies.
+    fn cmp(&self, other: &Self) -> Ordering {
+        (self.major, self.minor, self.patch)
+            .cmp(&(other.major, other.minor, other.patch))
+            .then_with(
+                || match (self.prerelease.is_empty(), other.prerelease.is_empty()) {
+                    (true, true) => Ordering::Equal,
+                    (true, false) => Ordering::Greater,
+                    (false, true) => Ordering::Less,
+                    (false, false) => compare_prerelease(&self.prerelease, &other.prerelease),
+                },
+            )
+    }
+}
+
+/// Partial ordering consistent with [`Ord`] for [`GoVersion`].
+impl PartialOrd for GoVersion {
+    /// Delegates to [`Ord::cmp`].
+    fn partial_cmp(&self, other: &Self) -> Option<Ordering> {
+        Some(self.cmp(other))
+    }
+}
+
+/// Compare pre-release identifier lists: numeric identifiers numerically and
+/// below alphanumeric ones, a shorter list first when one is a prefix.
+fn compare_prerelease(left: &[String], right: &[String]) -> Ordering {
+    for (a, b) in left.iter().zip(right) {
+        let ordering = match (a.parse::<u64>(), b.parse::<u64>()) {
+            (Ok(a), Ok(b)) => a.cmp(&b),
+            (Ok(_), Err(_)) => Ordering::Less,
+            (Err(_), Ok(_)) => Ordering::Greater,
+            (Err(_), Err(_)) => a.cmp(b),
+        };
+        if ordering != Ordering::Equal {
+            return ordering;
+        }
+    }
+    left.len().cmp(&right.len())
+}
+
+/// The change needed to go from `current` to `latest`, if both parse and `latest` is newer.
+pub fn version_bump(current: &str, latest: &str) -> Option<VersionBump> {
+    GoVersion::parse(current)?.bump_to(&GoVersion::parse(latest)?)
+}
+
+/// Escape a module path or version for a proxy URL: each upper-case letter
+/// becomes `!` followed by its lower-case form.
+pub fn escape_module_path(path: &str) -> String {
+    let mut escaped = String::with_capacity(path.len());
+    for c in path.chars() {
+        if c.is_ascii_uppercase() {
+            escaped.push('!');
+            escaped.push(c.to_ascii_lowercase());
+        } else {
+            escaped.push(c);
+        }
+    }
+    escaped
+}
+
+/// The first proxy URL in a `GOPROXY` value, or `None` when lookups are disabled.
+///
+/// `direct` entries are skipped since they require fetching the repository
+/// itself; `off` ends the list.
+pub fn proxy_from_goproxy(value: &str) -> Option<String> {
+    for entry in value.split([',', '|']).map(str::trim) {
+        match entry {
+            "" | "direct" => continue,
+            "off" => return None,
+            url => return Some(url.trim_end_matches('/').to_string()),
+        }
+    }
+    None
+}
+
+/// Whether `module` matches one of the comma-separated `GOPRIVATE`-style globs.
+///
+/// As in the `go` tool, a pattern matches any module whose leading path
+/// elements match it, so `example.com/internal` covers
+/// `example.com/internal/tools`.
+pub fn matches_module_patterns(module: &str, patterns: &str) -> bool {
+    patterns
+        .split(',')
+        .map(str::trim)
+        .filter(|pattern| !pattern.is_empty())
+        .any(|pattern| {
+            let Ok(glob) = Glob::new(pattern) else {
+                return false;
+            };
+            let matcher = glob.compile_matcher();
+            let depth = pattern.split('/').count();
+            let elements: Vec<&str> = module.split('/').collect();
+            elements.len() >= depth && matcher.is_match(elements[..depth].join("/"))
+        })
+}
+
+/// Response of the proxy's `@latest` endpoint.
+#[derive(Debug, Deserialize)]
+struct LatestInfo {
+    #[serde(rename = "Version")]
+    version: String,
+}
+
+/// Response of OSV's batch query endpoint.
+#[derive(Debug, Default, Deserialize)]
+struct OsvBatchResponse {
+    #[serde(default)]
+    results: Vec<OsvResult>,
+}
+
+/// Vulnerabilities for one OSV query.
+#[derive(Debug, Default, Deserialize)]
+struct OsvResult {
+    #[serde(default)]
+    vulns: Vec<OsvVuln>,
+}
+
+/// One OSV vulnerability reference.
+#[derive(Debug, Deserialize)]
+struct OsvVuln {
+    id: String,
+}
+
+/// Vulnerability identifiers per query, in query order.
+fn parse_osv_batch(body: &str, queries: usize) -> Result<Vec<Vec<String>>> {
+    let response: OsvBatchResponse = serde_json::from_str(body)
+        .map_err(|e| ValknutError::parse("json", format!("Invalid OSV response: {e}")))?;
+    let mut ids: Vec<Vec<String>> = response
+        .results
+        .into_iter()
+        .map(|result| {
+            let mut ids: Vec<String> = result.vulns.into_iter().map(|vuln| vuln.id).collect();
+            ids.sort();
+            ids.dedup();
+            ids
+        })
+        .collect();
+    ids.resize(queries, Vec::new());
+    Ok(ids)
+}
+
+/// Looks up latest versions and known vulnerabilities for Go dependencies.
+#[derive(Debug, Clone)]
+pub struct DependencyChecker {
+    client: reqwest::Client,
+    proxy: Option<String>,
+    osv_url: String,
+    no_proxy_patterns: String,
+    no_osv_patterns: String,
+}
+
+/// Construction and lookup methods for [`DependencyChecker`].
+impl DependencyChecker {
+    /// Configure the checker from `GOPROXY`, `GOPRIVATE`, `GONOPROXY` and `GONOSUMDB`.
+    pub fn from_env() -> Self {
+        let var = |name: &str| std::env::var(name).unwrap_or_default();
+        let goproxy = std::env::var("GOPROXY").unwrap_or_else(|_| DEFAULT_PROXY.to_string());
+        let private = var("GOPRIVATE");
+        Self {
+            proxy: proxy_from_goproxy(&goproxy),
+            osv_url: DEFAULT_OSV_URL.to_string(),
+            no_proxy_patterns: join_patterns(&private, &var("GONOPROXY")),
+            no_osv_patterns: join_patterns(&private, &var("GONOSUMDB")),
+            client: reqwest::Client::new(),
+        }
+    }
+
+    /// Use explicit endpoints; `proxy: None` disables latest-version lookups.
+    pub fn with_endpoints(proxy: Option<String>, osv_url: impl Into<String>) -> Self {
+        Self {
+            client: reqwest::Client::new(),
+            proxy,
+            osv_url: osv_url.into(),
+            no_proxy_patterns: String::new(),
+            no_osv_patterns: String::new(),
+        }
+    }
+
+    /// Report every dependency required by a `go.mod` beneath `roots`.
+    ///
+    /// A failed proxy lookup leaves that module's `latest_version` empty and is
+    /// logged; a failed OSV query fails the whole check, since reporting zero
+    /// vulnerabilities would be misleading.
+    pub async fn check(&self, roots: &[PathBuf]) -> Result<Vec<DependencyReport>> {
+        let mut reports = Vec::new();
+        for root in roots {
+            for dir in find_module_dirs(root) {
+                reports.extend(read_requirements(&dir)?);
+            }
+        }
+
+        let latest: Vec<Option<String>> = stream::iter(reports.iter())
+            .map(|report| self.latest_version(&report.module))
+            .buffered(PROXY_CONCURRENCY)
+            .collect()
+            .await;
+        for (report, latest) in reports.iter_mut().zip(latest) {
+            report.update = latest
+                .as_deref()
+                .and_then(|latest| version_bump(&report.current_version, latest));
+            report.latest_version = latest;
+        }
+
+        let public: Vec<usize> = (0..reports.len())
+            .filter(|&i| !matches_module_patterns(&reports[i].module, &self.no_osv_patterns))
+            .collect();
+        for batch in public.chunks(OSV_BATCH_SIZE) {
+            let queries: Vec<(&str, &str)> = batch
+                .iter()
+                .map(|&i| {
+                    (
+                        reports[i].module.as_str(),
+                        reports[i].current_version.as_str(),
+                    )
+                })
+                .collect();
+            let ids = self.query_osv(&queries).await?;
+            for (&i, ids) in batch.iter().zip(ids) {
+                reports[i].cve_count = ids.len();
+                reports[i].cve_ids = ids;
+            }
+        }
+        Ok(reports)
+    }
+
+    /// Latest version of `module` from the proxy, or `None` when unavailable.
+    async fn latest_version(&self, module: &str) -> Option<String> {
+        let proxy = self.proxy.as_ref()?;
+        if matches_module_patterns(module, &self.no_proxy_patterns) {
+            return None;
+        }
+        let url = format!("{proxy}/{}/@latest", escape_module_path(module));
+        let response = match self.client.get(&url).send().await {
+            Ok(response) if response.status().is_success() => response,
+            Ok(response) => {
+                warn!("Module proxy returned {} for {}", response.status(), module);
+                return None;
+            }
+            Err(e) => {
+                warn!("Module proxy lookup failed for {}: {}", module, e);
+                return None;
+            }
+        };
+        match response.json::<LatestInfo>().await {
+            Ok(info) => Some(info.version),
+            Err(e) => {
+                warn!("Invalid module proxy response for {}: {}", module, e);
+                None
+            }
+        }
+    }
+
+    /// Vulnerability identifiers for each `(module, version)` pair.
+    async fn query_osv(&self, queries: &[(&str, &str)]) -> Result<Vec<Vec<String>>> {
+        let body = serde_json::json!({
+            "queries": queries
+                .iter()
+                .map(|(module, version)| serde_json::json!({
+                    "package": { "name": module, "ecosystem": "Go" },
+                    // OSV records Go versions without the leading `v`.
+                    "version": version.trim_start_matches('v'),
+                }))
+                .collect::<Vec<_>>(),
+        });
+        let network = |e: reqwest::Error| ValknutError::internal(format!("OSV query failed: {e}"));
+        let response = self
+            .client
+            .post(&self.osv_url)
+            .json(&body)
+            .send()
+            .await
+            .and_then(reqwest::Response::error_for_status)
+            .map_err(network)?;
+        let text = response.text().await.map_err(network)?;
+        parse_osv_batch(&text, queries.len())
+    }
+}
+
+/// Requirements of the module in `dir`, checked against its `go.sum`.
+fn read_requirements(dir: &Path) -> Result<Vec<DependencyReport>> {
+    let go_mod = dir.join("go.mod");
+    let content = std::fs::read_to_string(&go_mod)
+        .map_err(|e| ValknutError::io(format!("Failed to read {}", go_mod.display()), e))?;
+    let sums = std::fs::read_to_string(dir.join("go.sum"))
+        .map(|content| parse_go_sum(&content))
+        .unwrap_or_default();
+
+    Ok(parse_go_mod(&content)
+        .into_iter()
+        .map(|requirement| DependencyReport {
+            in_go_sum: sums.contains(&(requirement.module.clone(), requirement.version.clone())),
+            module: requirement.module,
+            current_version: requirement.version,
+            latest_version: None,
+            update: None,
+            cve_count: 0,
+            cve_ids: Vec::new(),
+            indirect: requirement.indirect,
+            go_mod: go_mod.clone(),
+        })
+        .collect())
+}
+
+/// Combine two comma-separated pattern lists.
+fn join_patterns(first: &str, second: &str) -> String {
+    [first, second]
+        .into_iter()
+        .filter(|patterns| !patterns.trim().is_empty())
+        .collect::<Vec<_>>()
+        .join(",")
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn parses_single_line_and_block_requirements() {
+        let go_mod = r#"
+module example.com/app
+
+go 1.21
+
+require github.com/pkg/errors v0.9.1
+
+require (
+	golang.org/x/text v0.14.0 // indirect
+	github.com/BurntSushi/toml v1.3.2
+	// github.com/old/dep v1.0.0
+)
+
+replace golang.org/x/text => ../text
+"#;
+
+        let requirements = parse_go_mod(go_mod);
+        assert_eq!(
+            requirements,
+            vec![
+                GoRequirement {
+                    module: "github.com/pkg/errors".to_string(),
+                    version: "v0.9.1".to_string(),
+                    indirect: false,
+                },
+                GoRequirement {
+                    module: "golang.org/x/text".to_string(),
+                    version: "v0.14.0".to_string(),
+                    indirect: true,
+                },
+                GoRequirement {
+                    module: "github.com/BurntSushi/toml".to_string(),
+                    version: "v1.3.2".to_string(),
+                    indirect: false,
+                },
+            ]
+        );
+    }
+
+    #[test]
+    fn go_sum_skips_go_mod_only_hashes() {
+        let sums = parse_go_sum(
+            "github.com/pkg/errors v0.9.1 h1:abc=\n\
+             github.com/pkg/errors v0.9.1/go.mod h1:def=\n\
+             golang.org/x/text v0.13.0/go.mod h1:ghi=\n",
+        );
+        assert!(sums.contains(&("github.com/pkg/errors".to_string(), "v0.9.1".to_string())));
+        assert_eq!(sums.len(), 1);
+    }
+
+    #[test]
+    fn orders_versions_by_semver_precedence() {
+        let ordered = [
+            "v0.0.0-20230101120000-abcdef123456",
+            "v0.9.1",
+            "v1.0.0-alpha",
+            "v1.0.0-alpha.1",
+            "v1.0.0-beta.2",
+            "v1.0.0-beta.11",
+            "v1.0.0",
+            "v2.0.0+incompatible",
+        ];
+        for pair in ordered.windows(2) {
+            let (a, b) = (
+                GoVersion::parse(pair[0]).unwrap(),
+                GoVersion::parse(pair[1]).unwrap(),
+            );
+            assert!(a < b, "{} should sort before {}", pair[0], pair[1]);
+        }
+        assert!(GoVersion::parse("1.2.3").is_none());
+        assert!(GoVersion::parse("v1.2").is_none());
+    }
+
+    #[test]
+    fn classifies_version_bumps() {
+        assert_eq!(version_bump("v1.2.3", "v1.2.4"), Some(VersionBump::Patch));
+        assert_eq!(version_bump("v1.2.3", "v1.5.0"), Some(VersionBump::Minor));
+        assert_eq!(version_bump("v1.2.3", "v2.0.0"), Some(VersionBump::Major));
+        assert_eq!(version_bump("v0.9.1", "v0.10.0"), Some(VersionBump::Major));
+        assert_eq!(
+            version_bump("v1.3.0-rc.1", "v1.3.0-rc.2"),
+            Some(VersionBump::Prerelease)
+        );
+        assert_eq!(version_bump("v1.2.3", "v1.2.3"), None);
+        assert_eq!(version_bump("v1.2.3", "v1.0.0"), None);
+    }
+
+    #[test]
+    fn escapes_upper_case_module_paths() {
+        assert_eq!(
+            escape_module_path("github.com/BurntSushi/toml"),
+            "github.com/!burnt!sushi/toml"
+        );
+    }
+
+    #[test]
+    fn reads_goproxy_lists() {
+        assert_eq!(
+            proxy_from_goproxy("https://goproxy.io/,direct").as_deref(),
+            Some("https://goproxy.io")
+        );
+        assert_eq!(
+            proxy_from_goproxy("direct|https://corp.example/proxy").as_deref(),
+            Some("https://corp.example/proxy")
+        );
+        assert_eq!(proxy_from_goproxy("off"), None);
+        assert_eq!(proxy_from_goproxy("direct"), None);
+    }
+
+    #[test]
+    fn private_patterns_match_module_prefixes() {
+        let patterns = "*.corp.example,github.com/acme/internal";
+        assert!(matches_module_patterns(
+            "git.corp.example/tools/cli",
+            patterns
+        ));
+        assert!(matches_module_patterns(
+            "github.com/acme/internal/auth",
+            patterns
+        ));
+        assert!(!matches_module_patterns("github.com/acme/public", patterns));
+        assert!(!matches_module_patterns("github.com/acme/public", ""));
+    }
+
+    #[test]
+    fn osv_results_align_with_queries() {
+        let body = r#"{"results":[
+            {"vulns":[{"id":"GO-2022-0001","modified":"2023-01-01T00:00:00Z"},{"id":"GHSA-aaaa-bbbb-cccc"}]},
+            {}
+        ]}"#;
+        let ids = parse_osv_batch(body, 3).unwrap();
+        assert_eq!(ids[0], vec!["GHSA-aaaa-bbbb-cccc", "GO-2022-0001"]);
+        assert!(ids[1].is_empty());
+        assert!(ids[2].is_empty());
+        assert!(parse_osv_batch("not json", 1).is_err());
+    }
+
+    #[tokio::test]
+    async fn offline_check_reports_requirements_with_go_sum_status() {
+        let dir = tempfile::tempdir().unwrap();
+        std::fs::write(
+            dir.path().join("go.mod"),
+            "module example.com/app\n\nrequire (\n\tgithub.com/pkg/errors v0.9.1\n\tgolang.org/x/text v0.14.0 // indirect\n)\n",
+        )
+        .unwrap();
+        std::fs::write(
+            dir.path().join("go.sum"),
+            "github.com/pkg/errors v0.9.1 h1:abc=\n",
+        )
+        .unwrap();
+
+        let mut checker = DependencyChecker::with_endpoints(None, "http://127.0.0.1:9/unused");
+        // Everything is private, so nothing is sent over the network.
+        checker.no_osv_patterns = "*".to_string();
+        let reports = checker.check(&[dir.path().to_path_buf()]).await.unwrap();
+
+        assert_eq!(reports.len(), 2);
+        assert_eq!(reports[0].module, "github.com/pkg/errors");
+        assert!(reports[0].in_go_sum);
+        assert!(!reports[1].in_go_sum);
+        assert!(reports[1].indirect);
+        assert!(reports
+            .iter()
+            .all(|r| r.latest_version.is_none() && !r.is_outdated()));
+        assert_eq!(reports[0].go_mod, dir.path().join("go.mod"));
+    }
+}
@@ -0,0 +1,415 @@
+//! Multi-module Go workspaces.
+//!
+//! Monorepos often hold several Go modules, each with its own `go.mod`, in
+//! subdirectories of one tree. [`GoModuleGraph::discover`] finds every module
+//! beneath the roots, builds a separate [`PackageGraph`] for each (nested
+//! modules are excluded from their parents, as the `go` tool does), and stitches
+//! them together through the imports that cross module boundaries. Modules that
+//! end up depending on each other in a circle are reported as errors.
+
+use std::collections::{BTreeMap, BTreeSet, HashMap};
+use std::fmt::Write as _;
+use std::path::{Path, PathBuf};
+
+use ignore::WalkBuilder;
+use petgraph::algo::kosaraju_scc;
+use petgraph::graph::{Graph, NodeIndex};
+use serde::{Deserialize, Serialize};
+use tracing::warn;
+
+use super::package_graph::{is_go_module, is_within_module, read_module_path, PackageGraph};
+use super::{escape_dot, relative_dir};
+use crate::core::errors::{Result, ValknutError};
+use crate::core::pipeline::discovery::IGNORE_FILE_NAME;
+
+/// One Go module and its package graph.
+#[derive(Debug, Clone, Serialize, Deserialize)]
+pub struct GoModule {
+    /// Directory holding the module's `go.mod`, relative to the scanned root (`.` for the root).
+    pub root: String,
+    /// Package graph of the module's own packages.
+    #[serde(flatten)]
+    pub graph: PackageGraph,
+}
+
+/// An import from a package in one module of a package in another.
+#[derive(Debug, Clone, PartialEq, Eq, PartialOrd, Ord, Serialize, Deserialize)]
+pub struct CrossModuleImport {
+    /// Module containing the importing package.
+    pub from_module: String,
+    /// Importing package.
+    pub from_package: String,
+    /// Module containing the imported package.
+    pub to_module: String,
+    /// Imported package.
+    pub to_package: String,
+}
+
+/// Package graphs for every Go module under a tree, linked by cross-module imports.
+#[derive(Debug, Clone, Default, Serialize, Deserialize)]
+pub struct GoModuleGraph {
+    /// Every module found, sorted by module path.
+    pub modules: Vec<GoModule>,
+    /// Module-level dependencies: importing module -> imported modules.
+    pub module_imports: BTreeMap<String, BTreeSet<String>>,
+    /// Package imports that cross module boundaries.
+    pub cross_module_imports: Vec<CrossModuleImport>,
+    /// Sets of modules that depend on each other circularly (each sorted).
+    pub cycles: Vec<Vec<String>>,
+    /// Problems that make the workspace invalid, such as circular module dependencies.
+    pub errors: Vec<String>,
+}
+
+/// Discovery and query methods for [`GoModuleGraph`].
+impl GoModuleGraph {
+    /// Find every `go.mod` beneath the roots and build the stitched module graph.
+    ///
+    /// `vendor/` and `testdata/` trees are skipped, and `.gitignore` and
+    /// `.valknutignore` files are honoured during the walk.
+    pub fn discover(roots: &[PathBuf]) -> Result<Self> {
+        let mut found: Vec<(String, PathBuf, String)> = Vec::new();
+        for root in roots {
+            for dir in find_module_dirs(root) {
+                match read_module_path(&dir) {
+                    Some(module_path) => {
+                        found.push((module_path, dir.clone(), relative_dir(root, &dir)))
+                    }
+                    None => warn!("{} has no module directive", dir.join("go.mod").display()),
+                }
+            }
+        }
+        found.sort();
+
+        let mut errors = Vec::new();
+        for pair in found.windows(2) {
+            if pair[0].0 == pair[1].0 {
+                errors.push(format!(
+                    "Module {} is declared by both {} and {}",
+                    pair[0].0,
+                    pair[0].1.join("go.mod").display(),
+                    pair[1].1.join("go.mod").display()
+                ));
+            }
+        }
+
+        let module_paths: Vec<String> = found.iter().map(|(path, _, _)| path.clone()).collect();
+        let modules = found
+            .iter()
+            .map(|(_, dir, relative)| {
+                Ok(GoModule {
+                    root: relative.clone(),
+                    graph: PackageGraph::from_go_module(dir, &module_paths)?,
+                })
+            })
+            .collect::<Result<Vec<_>>>()?;
+
+        let mut graph = Self {
+            modules,
+            errors,
+            ..Self::default()
+        };
+        graph.link_modules(&module_paths);
+        Ok(graph)
+    }
+
+    /// Number of `go.mod` files beneath `root`.
+    pub fn count_modules(root: &Path) -> usize {
+        find_module_dirs(root).len()
+    }
+
+    /// The module with the given module path, if it was found.
+    pub fn module(&self, module_path: &str) -> Option<&GoModule> {
+        self.modules
+            .iter()
+            .find(|module| module.graph.module_path.as_deref() == Some(module_path))
+    }
+
+    /// Returns true when any module-level cycle or other error was found.
+    pub fn has_errors(&self) -> bool {
+        !self.errors.is_empty()
+    }
+
+    /// Render the module-level dependency graph in Graphviz DOT format.
+    ///
+    /// Edges between modules that form a cycle are red.
+    pub fn to_dot(&self) -> String {
+        let cycle_of: HashMap<&str, usize> = self
+            .cycles
+            .iter()
+            .enumerate()
+            .flat_map(|(idx, cycle)| cycle.iter().map(move |module| (module.as_str(), idx)))
+            .collect();
+
+        let mut dot = String::from("digraph modules {\n");
+        dot.push_str("  rankdir=LR;\n");
+        dot.push_str("  node [shape=box, style=filled, fillcolor=\"#cfe8ff\"];\n");
+        for module in &self.modules {
+            if let Some(path) = &module.graph.module_path {
+                let _ = writeln!(
+                    dot,
+                    "  \"{}\" [label=\"{}\\n{}\"];",
+                    escape_dot(path),
+                    escape_dot(path),
+                    escape_dot(&module.root)
+                );
+            }
+        }
+        for (from, targets) in &self.module_imports {
+            for to in targets {
+                let in_cycle = matches!(
+                    (cycle_of.get(from.as_str()), cycle_of.get(to.as_str())),
+                    (Some(a), Some(b)) if a == b
+                );
+                let style = if in_cycle { " [color=red]" } else { "" };
+                let _ = writeln!(
+                    dot,
+                    "  \"{}\" -> \"{}\"{};",
+                    escape_dot(from),
+                    escape_dot(to),
+                    style
+                );
+            }
+        }
+        dot.push_str("}\n");
+        dot
+    }
+
+    /// Serialize the graph as pretty-printed JSON.
+    pub fn to_json(&self) -> Result<String> {
+        serde_json::to_string_pretty(self).map_err(|err| {
+            ValknutError::internal(format!("Failed to serialize module graph: {}", err))
+        })
+    }
+
+    /// Derive cross-module imports, module dependencies, and module cycles.
+    fn link_modules(&mut self, module_paths: &[String]) {
+        let mut cross = BTreeSet::new();
+        for module in &self.modules {
+            let Some(from_module) = module.graph.module_path.as_deref() else {
+                continue;
+            };
+            for (from_package, targets) in &module.graph.imports {
+                for to_package in targets {
+                    match owning_module(to_package, module_paths) {
+                        Some(to_module) if to_module != from_module => {
+                            cross.insert(CrossModuleImport {
+                                from_module: from_module.to_string(),
+                                from_package: from_package.clone(),
+                                to_module: to_module.to_string(),
+                                to_package: to_package.clone(),
+                            });
+                        }
+                        _ => {}
+                    }
+                }
+            }
+        }
+
+        for import in &cross {
+            self.module_imports
+                .entry(import.from_module.clone())
+                .or_default()
+                .insert(import.to_module.clone());
+        }
+        self.cross_module_imports = cross.into_iter().collect();
+        self.cycles = module_cycles(module_paths, &self.module_imports);
+        for cycle in &self.cycles {
+            self.errors.push(format!(
+                "Circular dependency between modules: {}",
+                cycle.join(", ")
+            ));
+        }
+    }
+}
+
+/// Directories beneath `root` (including `root`) that contain a `go.mod`, sorted.
+pub fn find_module_dirs(root: &Path) -> Vec<PathBuf> {
+    let mut walker = WalkBuilder::new(root);
+    walker
+        .add_custom_ignore_filename(IGNORE_FILE_NAME)
+        .filter_entry(|entry| !matches!(entry.file_name().to_str(), Some("vendor" | "testdata")));
+
+    let mut dirs: Vec<PathBuf> = walker
+        .build()
+        .filter_map(|entry| match entry {
+            Ok(entry) => Some(entry),
+            Err(err) => {
+                warn!("Failed to walk directory: {err}");
+                None
+            }
+        })
+        .filter(|entry| entry.file_type().is_some_and(|kind| kind.is_dir()))
+        .map(|entry| entry.into_path())
+        .filter(|dir| is_go_module(dir))
+        .collect();
+    dirs.sort();
+    dirs
+}
+
+/// The most specific module in `module_paths` that contains `import_path`.
+fn owning_module<'a>(import_path: &str, module_paths: &'a [String]) -> Option<&'a str> {
+    module_paths
+        .iter()
+        .filter(|module| is_within_module(import_path, module))
+        .max_by_key(|module| module.len())
+        .map(String::as_str)
+}
+
+/// Strongly connected sets of more than one module, sorted.
+fn module_cycles(
+    module_paths: &[String],
+    module_imports: &BTreeMap<String, BTreeSet<String>>,
+) -> Vec<Vec<String>> {
+    let mut graph: Graph<&str, ()> = Graph::new();
+    let mut index: HashMap<&str, NodeIndex> = HashMap::new();
+    for module in module_paths {
+        index
+            .entry(module.as_str())
+            .or_insert_with(|| graph.add_node(module.as_str()));
+    }
+    for (from, targets) in module_imports {
+        for to in targets {
+            if let (Some(&a), Some(&b)) = (index.get(from.as_str()), index.get(to.as_str())) {
+                graph.add_edge(a, b, ());
+            }
+        }
+    }
+
+    let mut cycles: Vec<Vec<String>> = kosaraju_scc(&graph)
+        .into_iter()
+        .filter(|scc| scc.len() > 1)
+        .map(|scc| {
+            let mut members: Vec<String> = scc.iter().map(|idx| graph[*idx].to_string()).collect();
+            members.sort();
+            members
+        })
+        .collect();
+    cycles.sort();
+    cycles
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::core::dependency::PackageOrigin;
+    use std::fs;
+    use tempfile::tempdir;
+
+    fn write(root: &Path, relative: &str, contents: &str) {
+        let path = root.join(relative);
+        fs::create_dir_all(path.parent().unwrap()).unwrap();
+        fs::write(path, contents).unwrap();
+    }
+
+    /// Root module `example.com/app` with a nested `example.com/app/lib` module
+    /// and a sibling `example.com/tools` module.
+    fn monorepo() -> tempfile::TempDir {
+        let tmp = tempdir().unwrap();
+        let root = tmp.path();
+        write(root, "go.mod", "module example.com/app\n");
+        write(
+            root,
+            "main.go",
+            "package main\n\nimport (\n\t\"fmt\"\n\t\"example.com/app/lib/strings\"\n)\n",
+        );
+        write(root, "lib/go.mod", "module example.com/app/lib\n");
+        write(
+            root,
+            "lib/strings/strings.go",
+            "package strings\n\nimport \"unicode\"\n",
+        );
+        write(root, "tools/go.mod", "module example.com/tools\n");
+        write(
+            root,
+            "tools/gen/gen.go",
+            "package gen\n\nimport \"example.com/app/lib/strings\"\n",
+        );
+        tmp
+    }
+
+    #[test]
+    fn analyzes_each_module_and_links_cross_module_imports() {
+        let tmp = monorepo();
+        let graph = GoModuleGraph::discover(&[tmp.path().to_path_buf()]).unwrap();
+
+        let paths: Vec<_> = graph
+            .modules
+            .iter()
+            .map(|module| {
+                (
+                    module.graph.module_path.clone().unwrap(),
+                    module.root.clone(),
+                )
+            })
+            .collect();
+        assert_eq!(
+            paths,
+            vec![
+                ("example.com/app".to_string(), ".".to_string()),
+                ("example.com/app/lib".to_string(), "lib".to_string()),
+                ("example.com/tools".to_string(), "tools".to_string()),
+            ]
+        );
+
+        let app = graph.module("example.com/app").unwrap();
+        assert!(
+            !app.graph.packages.contains_key("example.com/app/lib"),
+            "nested module files must not be attributed to the parent"
+        );
+        assert_eq!(
+            app.graph.origin("example.com/app/lib/strings"),
+            Some(PackageOrigin::ThirdParty)
+        );
+
+        assert_eq!(
+            graph.module_imports["example.com/tools"],
+            BTreeSet::from(["example.com/app/lib".to_string()])
+        );
+        assert!(graph.cross_module_imports.contains(&CrossModuleImport {
+            from_module: "example.com/app".to_string(),
+            from_package: "example.com/app".to_string(),
+            to_module: "example.com/app/lib".to_string(),
+            to_package: "example.com/app/lib/strings".to_string(),
+        }));
+        assert!(graph.cycles.is_empty());
+        assert!(!graph.has_errors());
+        assert_eq!(GoModuleGraph::count_modules(tmp.path()), 3);
+    }
+
+    #[test]
+    fn reports_circular_module_dependencies_as_errors() {
+        let tmp = monorepo();
+        write(
+            tmp.path(),
+            "lib/strings/extra.go",
+            "package strings\n\nimport \"example.com/tools/gen\"\n",
+        );
+        let graph = GoModuleGraph::discover(&[tmp.path().to_path_buf()]).unwrap();
+
+        assert_eq!(
+            graph.cycles,
+            vec![vec![
+                "example.com/app/lib".to_string(),
+                "example.com/tools".to_string()
+            ]]
+        );
+        assert!(graph.has_errors());
+        assert!(graph.errors[0].contains("example.com/app/lib, example.com/tools"));
+        assert!(graph
+            .to_dot()
+            .contains("\"example.com/tools\" -> \"example.com/app/lib\" [color=red];"));
+    }
+
+    #[test]
+    fn json_output_has_top_level_modules_array() {
+        let tmp = monorepo();
+        let graph = GoModuleGraph::discover(&[tmp.path().to_path_buf()]).unwrap();
+        let json: serde_json::Value = serde_json::from_str(&graph.to_json().unwrap()).unwrap();
+
+        let modules = json["modules"].as_array().unwrap();
+        assert_eq!(modules.len(), 3);
+        assert_eq!(modules[1]["module_path"], "example.com/app/lib");
+        assert_eq!(modules[1]["root"], "lib");
+        assert!(modules[1]["packages"]["example.com/app/lib/strings"].is_object());
+    }
+}
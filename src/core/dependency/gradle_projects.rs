@@ -0,0 +1,590 @@
+//! Gradle multi-project builds.
+//!
+//! A Gradle build is rooted at a directory holding `settings.gradle` or
+//! `settings.gradle.kts`. The settings file `include`s the build's projects by
+//! path (`:app`, `:core:data`); each project lives in the matching directory
+//! (`core/data`) unless the settings file moves it with
+//! `project(":x").projectDir = file("...")`. Projects depend on each other
+//! through `project(":x")` references or type-safe `projects.core.data`
+//! accessors in their build scripts. [`GradleGraph::from_paths`] finds every
+//! build beneath the scanned paths and records that project graph.
+
+use std::collections::{BTreeMap, BTreeSet};
+use std::fs;
+use std::path::{Path, PathBuf};
+
+use ignore::WalkBuilder;
+use serde::{Deserialize, Serialize};
+use tracing::warn;
+
+use super::relative_dir;
+use crate::core::errors::{Result, ValknutError};
+use crate::core::pipeline::discovery::IGNORE_FILE_NAME;
+
+/// Settings file names, Kotlin DSL first.
+const SETTINGS_FILES: &[&str] = &["settings.gradle.kts", "settings.gradle"];
+
+/// Build script names, Kotlin DSL first.
+const BUILD_FILES: &[&str] = &["build.gradle.kts", "build.gradle"];
+
+/// One project of a Gradle build.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct GradleProject {
+    /// Project path, e.g. `:core:data` (`:` for the root project).
+    pub path: String,
+    /// Project directory relative to the build root (`.` for the root project).
+    pub dir: String,
+    /// Build script relative to the build root, when the project has one.
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub build_file: Option<String>,
+    /// Paths of the projects this one depends on.
+    #[serde(default, skip_serializing_if = "BTreeSet::is_empty")]
+    pub depends_on: BTreeSet<String>,
+}
+
+/// A Gradle build: one settings file and the projects it includes.
+#[derive(Debug, Clone, Default, PartialEq, Eq, Serialize, Deserialize)]
+pub struct GradleBuild {
+    /// Directory holding the settings file, relative to the scanned root (`.` for the root).
+    pub root: String,
+    /// `rootProject.name`, when the settings file sets it.
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub name: Option<String>,
+    /// Projects sorted by path, starting with the root project.
+    pub projects: Vec<GradleProject>,
+    /// Problems such as dependencies on projects the settings file does not include.
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub errors: Vec<String>,
+}
+
+/// What a settings file declares.
+#[derive(Debug, Clone, Default, PartialEq, Eq)]
+pub struct GradleSettings {
+    /// `rootProject.name`, when set.
+    pub name: Option<String>,
+    /// Included project paths, normalized to start with `:`.
+    pub includes: Vec<String>,
+    /// Project directories moved with `project(":x").projectDir = ...`.
+    pub project_dirs: BTreeMap<String, String>,
+}
+
+/// Parsing methods for [`GradleSettings`].
+impl GradleSettings {
+    /// Parse a Groovy or Kotlin DSL settings script.
+    pub fn parse(source: &str) -> Self {
+        let source = strip_comments(source);
+        let mut settings = Self::default();
+
+        for (index, _) in source.match_indices("include") {
+            if !is_call_start(&source, index, "include") {
+                continue;
+            }
+            let mut rest = source[index + "include".len()..].trim_start();
+            rest = rest.strip_prefix('(').unwrap_or(rest);
+            while let Some((literal, after)) = string_literal(rest.trim_start()) {
+                settings.includes.push(normalize_project_path(&literal));
+                match after.trim_start().strip_prefix(',') {
+                    Some(next) => rest = next,
+                    None => break,
+                }
+            }
+        }
+
+        for (index, _) in source.match_indices("project(") {
+            let after = &source[index + "project(".len()..];
+            let Some((path, after)) = string_literal(after.trim_start()) else {
+                continue;
+            };
+            let Some(assignment) = after
+                .trim_start()
+                .strip_prefix(')')
+                .map(str::trim_start)
+                .and_then(|rest| rest.strip_prefix(".projectDir"))
+            else {
+                continue;
+            };
+            let line = assignment.lines().next().unwrap_or_default();
+            if let Some(dir) = last_string_literal(line) {
+                settings
+                    .project_dirs
+                    .insert(normalize_project_path(&path), dir);
+            }
+        }
+
+        if let Some(index) = source.find("rootProject.name") {
+            let rest = source[index + "rootProject.name".len()..].trim_start();
+            if let Some((name, _)) = rest
+                .strip_prefix('=')
+                .and_then(|rest| string_literal(rest.trim_start()))
+            {
+                settings.name = Some(name);
+            }
+        }
+
+        settings.includes.sort();
+        settings.includes.dedup();
+        settings
+    }
+
+    /// Directory of an included project relative to the build root.
+    pub fn project_dir(&self, path: &str) -> String {
+        if let Some(dir) = self.project_dirs.get(path) {
+            return dir
+                .trim_start_matches("./")
+                .trim_end_matches('/')
+                .to_string();
+        }
+        match path.trim_start_matches(':') {
+            "" => ".".to_string(),
+            relative => relative.replace(':', "/"),
+        }
+    }
+}
+
+/// Loading and query methods for [`GradleBuild`].
+impl GradleBuild {
+    /// Load the build whose settings file is in `dir`; `root` is reported relative to `scan_root`.
+    pub fn load(scan_root: &Path, dir: &Path) -> Result<Self> {
+        let settings_path = SETTINGS_FILES
+            .iter()
+            .map(|name| dir.join(name))
+            .find(|path| path.is_file())
+            .ok_or_else(|| {
+                ValknutError::validation(format!("{} has no Gradle settings file", dir.display()))
+            })?;
+        let source = fs::read_to_string(&settings_path).map_err(|err| {
+            ValknutError::io(format!("Failed to read {}", settings_path.display()), err)
+        })?;
+        let settings = GradleSettings::parse(&source);
+
+        let mut known = vec![":".to_string()];
+        known.extend(settings.includes.iter().cloned());
+        let settings_name = settings_path
+            .file_name()
+            .map(|name| name.to_string_lossy().into_owned())
+            .unwrap_or_default();
+
+        let mut build = Self {
+            root: relative_dir(scan_root, dir),
+            name: settings.name.clone(),
+            ..Self::default()
+        };
+        for path in &known {
+            let project_dir = settings.project_dir(path);
+            let build_file = BUILD_FILES
+                .iter()
+                .map(|name| Path::new(&project_dir).join(name))
+                .find(|script| dir.join(script).is_file());
+            let mut depends_on = build_file
+                .as_ref()
+                .and_then(|script| fs::read_to_string(dir.join(script)).ok())
+                .map(|source| project_dependencies(&source, &known))
+                .unwrap_or_default();
+            depends_on.remove(path);
+
+            for dependency in depends_on.iter().filter(|dep| !known.contains(dep)) {
+                build.errors.push(format!(
+                    "Project {path} depends on {dependency}, which {settings_name} does not include"
+                ));
+            }
+            build.projects.push(GradleProject {
+                path: path.clone(),
+                dir: project_dir,
+                build_file: build_file.map(|script| to_slash(&script)),
+                depends_on,
+            });
+        }
+        build.projects.sort_by(|a, b| a.path.cmp(&b.path));
+        Ok(build)
+    }
+
+    /// The project whose directory most closely contains `relative_path`.
+    pub fn project_for(&self, relative_path: &str) -> Option<&GradleProject> {
+        self.projects
+            .iter()
+            .filter(|project| {
+                project.dir == "."
+                    || relative_path == project.dir
+                    || relative_path.starts_with(&format!("{}/", project.dir))
+            })
+            .max_by_key(|project| {
+                if project.dir == "." {
+                    0
+                } else {
+                    project.dir.len()
+                }
+            })
+    }
+}
+
+/// Every Gradle build found beneath a set of paths.
+#[derive(Debug, Clone, Default, PartialEq, Eq, Serialize, Deserialize)]
+pub struct GradleGraph {
+    /// Builds sorted by root directory.
+    pub builds: Vec<GradleBuild>,
+}
+
+/// Discovery and rendering methods for [`GradleGraph`].
+impl GradleGraph {
+    /// Find every settings file beneath the paths and load its build.
+    ///
+    /// `build/` and `.gradle/` directories are skipped, and `.gitignore` and
+    /// `.valknutignore` files are honoured during the walk.
+    pub fn from_paths(paths: &[PathBuf]) -> Result<Self> {
+        let mut builds = Vec::new();
+        for root in paths.iter().filter(|path| path.is_dir()) {
+            for dir in find_build_dirs(root) {
+                builds.push(GradleBuild::load(root, &dir)?);
+            }
+        }
+        builds.sort_by(|a, b| a.root.cmp(&b.root));
+        Ok(Self { builds })
+    }
+
+    /// Whether no Gradle build was found.
+    pub fn is_empty(&self) -> bool {
+        self.builds.is_empty()
+    }
+
+    /// Total number of projects across all builds.
+    pub fn project_count(&self) -> usize {
+        self.builds.iter().map(|build| build.projects.len()).sum()
+    }
+
+    /// Total number of project dependencies across all builds.
+    pub fn edge_count(&self) -> usize {
+        self.builds
+            .iter()
+            .flat_map(|build| &build.projects)
+            .map(|project| project.depends_on.len())
+            .sum()
+    }
+
+    /// Render the project graph in Graphviz DOT format, one cluster per build.
+    pub fn to_dot(&self) -> String {
+        let mut dot = String::from("digraph gradle {\n    rankdir=LR;\n");
+        for (index, build) in self.builds.iter().enumerate() {
+            let label = build.name.as_deref().unwrap_or(&build.root);
+            dot.push_str(&format!(
+                "    subgraph cluster_{index} {{\n        label=\"{label}\";\n"
+            ));
+            for project in &build.projects {
+                dot.push_str(&format!(
+                    "        \"{}{}\" [label=\"{}\"];\n",
+                    build.root, project.path, project.path
+                ));
+            }
+            dot.push_str("    }\n");
+            for project in &build.projects {
+                for dependency in &project.depends_on {
+                    dot.push_str(&format!(
+                        "    \"{}{}\" -> \"{}{}\";\n",
+                        build.root, project.path, build.root, dependency
+                    ));
+                }
+            }
+        }
+        dot.push_str("}\n");
+        dot
+    }
+
+    /// Render the graph as pretty-printed JSON.
+    pub fn to_json(&self) -> Result<String> {
+        serde_json::to_string_pretty(self)
+            .map_err(|e| ValknutError::internal(format!("Failed to serialize Gradle graph: {e}")))
+    }
+}
+
+/// Project paths referenced by a build script through `project(":x")`,
+/// `project(path: ":x")` or a type-safe `projects.x.y` accessor for one of `known`.
+pub fn project_dependencies(source: &str, known: &[String]) -> BTreeSet<String> {
+    let source = strip_comments(source);
+    let mut dependencies = BTreeSet::new();
+
+    for (index, _) in source.match_indices("project(") {
+        if !is_call_start(&source, index, "project") {
+            continue;
+        }
+        let mut rest = source[index + "project(".len()..].trim_start();
+        for prefix in ["path:", "path ="] {
+            rest = rest.strip_prefix(prefix).unwrap_or(rest).trim_start();
+        }
+        if let Some((path, _)) = string_literal(rest) {
+            dependencies.insert(normalize_project_path(&path));
+        }
+    }
+
+    let accessors: BTreeMap<String, &String> = known
+        .iter()
+        .filter(|path| path.as_str() != ":")
+        .map(|path| (type_safe_accessor(path), path))
+        .collect();
+    for (index, _) in source.match_indices("projects.") {
+        if !is_call_start(&source, index, "projects") {
+            continue;
+        }
+        let accessor: String = source[index + "projects.".len()..]
+            .chars()
+            .take_while(|c| c.is_ascii_alphanumeric() || *c == '_' || *c == '.')
+            .collect();
+        let accessor = accessor.trim_end_matches('.');
+        if let Some(path) = accessors.get(accessor) {
+            dependencies.insert((*path).clone());
+        }
+    }
+
+    dependencies
+}
+
+/// Type-safe accessor Gradle generates for a project path (`:core:data-api` -> `core.dataApi`).
+fn type_safe_accessor(path: &str) -> String {
+    path.trim_start_matches(':')
+        .split(':')
+        .map(|segment| {
+            let mut accessor = String::new();
+            let mut upper = false;
+            for c in segment.chars() {
+                if c == '-' || c == '_' {
+                    upper = true;
+                } else if upper {
+                    accessor.extend(c.to_uppercase());
+                    upper = false;
+                } else {
+                    accessor.push(c);
+                }
+            }
+            accessor
+        })
+        .collect::<Vec<_>>()
+        .join(".")
+}
+
+/// Normalize a project path to start with `:` (`app` -> `:app`).
+fn normalize_project_path(path: &str) -> String {
+    let path = path.trim();
+    if path.starts_with(':') {
+        path.to_string()
+    } else {
+        format!(":{path}")
+    }
+}
+
+/// True when `word` at `index` is a whole identifier rather than part of a longer one.
+fn is_call_start(source: &str, index: usize, word: &str) -> bool {
+    let before = source[..index].chars().next_back();
+    let after = source[index + word.len()..].chars().next();
+    !before.is_some_and(|c| c.is_ascii_alphanumeric() || c == '_' || c == '.')
+        && !after.is_some_and(|c| c.is_ascii_alphanumeric() || c == '_')
+}
+
+/// A leading single- or double-quoted string literal and the text after it.
+fn string_literal(text: &str) -> Option<(String, &str)> {
+    let quote = text.chars().next().filter(|c| *c == '"' || *c == '\'')?;
+    let end = text[1..].find(quote)? + 1;
+    Some((text[1..end].to_string(), &text[end + 1..]))
+}
+
+/// The last quoted string literal on a line.
+fn last_string_literal(line: &str) -> Option<String> {
+    let mut last = None;
+    let mut rest = line;
+    while let Some(start) = rest.find(['"', '\'']) {
+        match string_literal(&rest[start..]) {
+            Some((literal, after)) => {
+                last = Some(literal);
+                rest = after;
+            }
+            None => break,
+        }
+    }
+    last
+}
+
+/// Remove `//` and `/* */` comments outside string literals.
+fn strip_comments(source: &str) -> String {
+    let mut out = String::with_capacity(source.len());
+    let mut chars = source.chars().peekable();
+    let mut quote: Option<char> = None;
+    while let Some(c) = chars.next() {
+        if let Some(open) = quote {
+            out.push(c);
+            if c == '\\' {
+                out.extend(chars.next());
+            } else if c == open {
+                quote = None;
+            }
+            continue;
+        }
+        match (c, chars.peek()) {
+            ('/', Some('/')) => {
+                for next in chars.by_ref() {
+                    if next == '\n' {
+                        out.push('\n');
+                        break;
+                    }
+                }
+            }
+            ('/', Some('*')) => {
+                chars.next();
+                let mut previous = ' ';
+                for next in chars.by_ref() {
+                    if previous == '*' && next == '/' {
+                        break;
+                    }
+                    if next == '\n' {
+                        out.push('\n');
+                    }
+                    previous = next;
+                }
+            }
+            ('"' | '\'', _) => {
+                quote = Some(c);
+                out.push(c);
+            }
+            _ => out.push(c),
+        }
+    }
+    out
+}
+
+/// Directories beneath `root` holding a Gradle settings file.
+fn find_build_dirs(root: &Path) -> Vec<PathBuf> {
+    let mut walker = WalkBuilder::new(root);
+    walker
+        .add_custom_ignore_filename(IGNORE_FILE_NAME)
+        .filter_entry(|entry| !matches!(entry.file_name().to_str(), Some("build" | ".gradle")));
+
+    let mut dirs: Vec<PathBuf> = walker
+        .build()
+        .filter_map(|entry| match entry {
+            Ok(entry) => Some(entry),
+            Err(err) => {
+                warn!("Failed to walk directory: {err}");
+                None
+            }
+        })
+        .filter(|entry| entry.file_type().is_some_and(|kind| kind.is_dir()))
+        .map(|entry| entry.into_path())
+        .filter(|dir| SETTINGS_FILES.iter().any(|name| dir.join(name).is_file()))
+        .collect();
+    dirs.sort();
+    dirs
+}
+
+/// `path` with `/` separators.
+fn to_slash(path: &Path) -> String {
+    path.components()
+        .filter(|component| !matches!(component, std::path::Component::CurDir))
+        .map(|component| component.as_os_str().to_string_lossy())
+        .collect::<Vec<_>>()
+        .join("/")
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn settings_parse_includes_in_both_dsls() {
+        let kotlin = GradleSettings::parse(
+            r#"
+rootProject.name = "shop"
+// include(":disabled")
+include(":app", ":core:data")
+include("feature-cart")
+project(":core:data").projectDir = file("libs/data")
+"#,
+        );
+        assert_eq!(kotlin.name.as_deref(), Some("shop"));
+        assert_eq!(kotlin.includes, vec![":app", ":core:data", ":feature-cart"]);
+        assert_eq!(kotlin.project_dir(":core:data"), "libs/data");
+        assert_eq!(kotlin.project_dir(":feature-cart"), "feature-cart");
+        assert_eq!(kotlin.project_dir(":"), ".");
+
+        let groovy = GradleSettings::parse(
+            "rootProject.name = 'shop'\ninclude ':app',\n        ':core:ui'\nincludeBuild 'tools'\n",
+        );
+        assert_eq!(groovy.includes, vec![":app", ":core:ui"]);
+        assert_eq!(groovy.project_dir(":core:ui"), "core/ui");
+    }
+
+    #[test]
+    fn build_scripts_reference_projects() {
+        let known: Vec<String> = [":", ":app", ":core:data", ":feature-cart"]
+            .iter()
+            .map(|path| path.to_string())
+            .collect();
+        let script = r#"
+dependencies {
+    implementation(project(":core:data"))
+    implementation(projects.featureCart)
+    testImplementation project(path: ':missing')
+    // implementation(project(":app"))
+}
+"#;
+        let dependencies = project_dependencies(script, &known);
+        assert_eq!(
+            dependencies.into_iter().collect::<Vec<_>>(),
+            vec![":core:data", ":feature-cart", ":missing"]
+        );
+        assert_eq!(type_safe_accessor(":core:data-api"), "core.dataApi");
+    }
+
+    #[test]
+    fn builds_are_discovered_with_project_graph() {
+        let tmp = tempfile::tempdir().unwrap();
+        let root = tmp.path();
+        fs::create_dir_all(root.join("app/src/main/kotlin")).unwrap();
+        fs::create_dir_all(root.join("libs/data")).unwrap();
+        fs::write(
+            root.join("settings.gradle.kts"),
+            "rootProject.name = \"shop\"\ninclude(\":app\", \":core:data\", \":ghost\")\nproject(\":core:data\").projectDir = file(\"libs/data\")\n",
+        )
+        .unwrap();
+        fs::write(
+            root.join("app/build.gradle.kts"),
+            "dependencies { implementation(project(\":core:data\")); implementation(project(\":gone\")) }\n",
+        )
+        .unwrap();
+        fs::write(
+            root.join("libs/data/build.gradle"),
+            "apply plugin: 'kotlin'\n",
+        )
+        .unwrap();
+
+        let graph = GradleGraph::from_paths(&[root.to_path_buf()]).unwrap();
+        assert_eq!(graph.builds.len(), 1);
+        let build = &graph.builds[0];
+        assert_eq!(build.root, ".");
+        assert_eq!(build.name.as_deref(), Some("shop"));
+        let paths: Vec<_> = build.projects.iter().map(|p| p.path.as_str()).collect();
+        assert_eq!(paths, vec![":", ":app", ":core:data", ":ghost"]);
+
+        let app = &build.projects[1];
+        assert_eq!(app.build_file.as_deref(), Some("app/build.gradle.kts"));
+        assert_eq!(
+            app.depends_on.iter().collect::<Vec<_>>(),
+            vec![":core:data", ":gone"]
+        );
+        assert_eq!(build.projects[2].dir, "libs/data");
+        assert_eq!(
+            build.projects[2].build_file.as_deref(),
+            Some("libs/data/build.gradle")
+        );
+        assert_eq!(build.errors.len(), 1);
+        assert!(build.errors[0].contains(":gone"));
+
+        assert_eq!(
+            build
+                .project_for("libs/data/src/Repo.kt")
+                .map(|p| p.path.as_str()),
+            Some(":core:data")
+        );
+        assert_eq!(
+            build.project_for("buildSrc/x.kt").map(|p| p.path.as_str()),
+            Some(":")
+        );
+        assert_eq!(graph.edge_count(), 2);
+        assert!(graph.to_dot().contains("\".:app\" -> \".:core:data\";"));
+    }
+}
@@ -0,0 +1,289 @@
+//! Go interface satisfaction checks (`--check-interfaces`).
+//!
+//! [`InterfaceReport::check`] reuses the declarations the [`TypeGraph`]
+//! builder gathers and lists, for every exported interface, the concrete
+//! types in any package that declare all of its methods (matched by name,
+//! including methods of embedded interfaces). Compile-time assertions of the
+//! form `var _ Store = (*Memory)(nil)` (or `= &Memory{}`) are then compared
+//! against that list:
+//!
+//! - an asserted type that is missing methods is an **error**, so interface
+//!   additions that leave a plugin incomplete fail the run;
+//! - a type that satisfies an exported interface without an assertion is a
+//!   **suggestion** to add one.
+//!
+//! [`TypeGraph`]: super::TypeGraph
+
+use std::collections::{BTreeMap, BTreeSet};
+use std::path::{Path, PathBuf};
+
+use serde::{Deserialize, Serialize};
+
+use super::type_graph::{TypeGraphBuilder, TypeNodeKind};
+use crate::core::errors::Result;
+use crate::lang::go::is_exported;
+
+/// How serious an [`InterfaceFinding`] is.
+#[derive(Debug, Clone, Copy, PartialEq, Eq, PartialOrd, Ord, Serialize, Deserialize)]
+#[serde(rename_all = "snake_case")]
+pub enum InterfaceFindingSeverity {
+    /// An asserted implementation does not satisfy the interface.
+    Error,
+    /// An implementation has no compile-time assertion.
+    Suggestion,
+}
+
+/// A broken or missing interface assertion.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct InterfaceFinding {
+    /// Error or suggestion.
+    pub severity: InterfaceFindingSeverity,
+    /// Fully-qualified interface name (`<package>.<Name>`).
+    pub interface: String,
+    /// Fully-qualified concrete type name.
+    pub concrete: String,
+    /// Methods the concrete type does not declare (errors only).
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub missing_methods: Vec<String>,
+    /// File of the assertion (errors) or of the concrete type (suggestions).
+    pub file: String,
+    /// Line of the assertion, for errors.
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub line: Option<usize>,
+    /// Human-readable description.
+    pub message: String,
+}
+
+/// The concrete types implementing one exported interface.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct InterfaceImplementations {
+    /// Fully-qualified interface name.
+    pub interface: String,
+    /// Declaring file relative to the project root.
+    pub file: String,
+    /// Fully-qualified names of the implementing types, sorted.
+    pub implementors: Vec<String>,
+}
+
+/// Result of `--check-interfaces`.
+#[derive(Debug, Clone, Default, PartialEq, Eq, Serialize, Deserialize)]
+pub struct InterfaceReport {
+    /// Exported interfaces with at least one method, in sorted order.
+    pub interfaces: Vec<InterfaceImplementations>,
+    /// Errors first, then suggestions, each sorted by interface and type.
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub findings: Vec<InterfaceFinding>,
+}
+
+/// Construction and query methods for [`InterfaceReport`].
+impl InterfaceReport {
+    /// Check every interface declared in `files`, naming symbols by their
+    /// package relative to `root`.
+    ///
+    /// Files without a language adapter are skipped; files that fail to parse
+    /// are skipped with a warning.
+    pub fn check(root: &Path, files: &[PathBuf]) -> Result<Self> {
+        let builder = TypeGraphBuilder::collect(root, files);
+
+        // (concrete, interface) -> index of the `var _ I = ...` declaration
+        let mut assertions = BTreeMap::new();
+        for (index, variable) in builder.variables.iter().enumerate() {
+            if !variable.id.ends_with("._") {
+                continue;
+            }
+            let Some(interface) = variable
+                .var_type
+                .as_deref()
+                .and_then(|name| builder.resolve_type(&variable.package, name))
+                .filter(|id| builder.nodes[id].kind == TypeNodeKind::Interface)
+            else {
+                continue;
+            };
+            if let Some(concrete) = builder.concrete_type(variable) {
+                assertions.entry((concrete, interface)).or_insert(index);
+            }
+        }
+
+        let no_methods = BTreeSet::new();
+        let mut report = Self::default();
+        for interface in builder.nodes.values() {
+            if interface.kind != TypeNodeKind::Interface {
+                continue;
+            }
+            let required = builder.required_methods(&interface.id, &mut BTreeSet::new());
+            if required.is_empty() {
+                continue;
+            }
+            let exported = is_exported(&interface.name);
+            let mut implementors = Vec::new();
+
+            for concrete in builder.nodes.values() {
+                if concrete.kind != TypeNodeKind::Struct {
+                    continue;
+                }
+                let methods = builder.method_sets.get(&concrete.id).unwrap_or(&no_methods);
+                let missing: Vec<String> = required.difference(methods).cloned().collect();
+                let assertion = assertions
+                    .get(&(concrete.id.clone(), interface.id.clone()))
+                    .map(|&index| &builder.variables[index]);
+
+                match assertion {
+                    Some(variable) if !missing.is_empty() => {
+                        report.findings.push(InterfaceFinding {
+                            severity: InterfaceFindingSeverity::Error,
+                            interface: interface.id.clone(),
+                            concrete: concrete.id.clone(),
+                            message: format!(
+                                "{} is asserted to implement {} but is missing {}",
+                                concrete.id,
+                                interface.id,
+                                missing.join(", ")
+                            ),
+                            missing_methods: missing,
+                            file: variable.file.clone(),
+                            line: Some(variable.line),
+                        });
+                    }
+                    _ if !missing.is_empty() || !exported => {}
+                    Some(_) => implementors.push(concrete.id.clone()),
+                    None => {
+                        implementors.push(concrete.id.clone());
+                        let interface_name = if interface.package == concrete.package {
+                            interface.name.clone()
+                        } else {
+                            let qualifier =
+                                interface.package.rsplit('/').next().unwrap_or_default();
+                            format!("{qualifier}.{}", interface.name)
+                        };
+                        report.findings.push(InterfaceFinding {
+                            severity: InterfaceFindingSeverity::Suggestion,
+                            interface: interface.id.clone(),
+                            concrete: concrete.id.clone(),
+                            missing_methods: Vec::new(),
+                            file: concrete.file.clone(),
+                            line: None,
+                            message: format!(
+                                "{} implements {}; add `var _ {} = (*{})(nil)` to keep it that way",
+                                concrete.id, interface.id, interface_name, concrete.name
+                            ),
+                        });
+                    }
+                }
+            }
+
+            if exported {
+                report.interfaces.push(InterfaceImplementations {
+                    interface: interface.id.clone(),
+                    file: interface.file.clone(),
+                    implementors,
+                });
+            }
+        }
+
+        report.findings.sort_by(|a, b| {
+            (a.severity, &a.interface, &a.concrete).cmp(&(b.severity, &b.interface, &b.concrete))
+        });
+        Ok(report)
+    }
+
+    /// Whether no exported interfaces were found.
+    pub fn is_empty(&self) -> bool {
+        self.interfaces.is_empty() && self.findings.is_empty()
+    }
+
+    /// Asserted implementations that do not satisfy their interface.
+    pub fn errors(&self) -> impl Iterator<Item = &InterfaceFinding> {
+        self.findings
+            .iter()
+            .filter(|finding| finding.severity == InterfaceFindingSeverity::Error)
+    }
+
+    /// Implementations without a compile-time assertion.
+    pub fn suggestions(&self) -> impl Iterator<Item = &InterfaceFinding> {
+        self.findings
+            .iter()
+            .filter(|finding| finding.severity == InterfaceFindingSeverity::Suggestion)
+    }
+
+    /// Whether any assertion is broken.
+    pub fn has_errors(&self) -> bool {
+        self.errors().next().is_some()
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use std::fs;
+    use tempfile::tempdir;
+
+    fn write(root: &Path, relative: &str, contents: &str) -> PathBuf {
+        let path = root.join(relative);
+        fs::create_dir_all(path.parent().unwrap()).unwrap();
+        fs::write(&path, contents).unwrap();
+        path
+    }
+
+    fn sample_report() -> InterfaceReport {
+        let tmp = tempdir().unwrap();
+        let root = tmp.path();
+        let files = vec![
+            write(
+                root,
+                "plugin/plugin.go",
+                "package plugin\n\ntype Named interface {\n\tName() string\n}\n\ntype Plugin interface {\n\tNamed\n\tRun() error\n}\n\ntype runner interface {\n\tRun() error\n}\n",
+            ),
+            write(
+                root,
+                "plugins/echo.go",
+                "package plugins\n\nimport \"example.com/app/plugin\"\n\ntype Echo struct{}\n\nfunc (e *Echo) Name() string { return \"echo\" }\n\nfunc (e *Echo) Run() error { return nil }\n\nvar _ plugin.Plugin = (*Echo)(nil)\n\ntype Stale struct{}\n\nfunc (s *Stale) Name() string { return \"stale\" }\n\nvar _ plugin.Plugin = &Stale{}\n\ntype Quiet struct{}\n\nfunc (q Quiet) Name() string { return \"quiet\" }\n\nfunc (q Quiet) Run() error { return nil }\n",
+            ),
+        ];
+        InterfaceReport::check(root, &files).unwrap()
+    }
+
+    #[test]
+    fn lists_implementations_across_packages() {
+        let report = sample_report();
+
+        let plugin = report
+            .interfaces
+            .iter()
+            .find(|entry| entry.interface == "plugin.Plugin")
+            .expect("plugin.Plugin listed");
+        assert_eq!(plugin.implementors, vec!["plugins.Echo", "plugins.Quiet"]);
+        assert!(report
+            .interfaces
+            .iter()
+            .all(|entry| entry.interface != "plugin.runner"));
+    }
+
+    #[test]
+    fn broken_assertions_are_errors() {
+        let report = sample_report();
+
+        let errors: Vec<_> = report.errors().collect();
+        assert_eq!(errors.len(), 1);
+        assert_eq!(errors[0].concrete, "plugins.Stale");
+        assert_eq!(errors[0].interface, "plugin.Plugin");
+        assert_eq!(errors[0].missing_methods, vec!["Run"]);
+        assert_eq!(errors[0].file, "plugins/echo.go");
+        assert!(errors[0].line.is_some());
+        assert!(report.has_errors());
+    }
+
+    #[test]
+    fn unasserted_implementations_are_suggestions() {
+        let report = sample_report();
+
+        let suggested: Vec<_> = report
+            .suggestions()
+            .map(|finding| (finding.concrete.as_str(), finding.interface.as_str()))
+            .collect();
+        assert!(suggested.contains(&("plugins.Quiet", "plugin.Plugin")));
+        assert!(!suggested.contains(&("plugins.Echo", "plugin.Plugin")));
+        assert!(!suggested
+            .iter()
+            .any(|(_, interface)| *interface == "plugin.runner"));
+    }
+}
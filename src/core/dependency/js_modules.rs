@@ -0,0 +1,828 @@
+//! JavaScript module dependency graph.
+//!
+//! [`JsModuleGraph::from_paths`] reads every `.js`, `.mjs`, `.cjs` and `.jsx`
+//! file beneath the scanned paths (skipping `node_modules`) together with the
+//! `package.json` manifests next to them, and resolves each static import,
+//! re-export, `require()` and dynamic `import()` the way Node.js does:
+//!
+//! - `node:` specifiers and core module names (`fs`, `fs/promises`) are
+//!   built-ins;
+//! - relative specifiers resolve to the exact file, then the file with a
+//!   JavaScript extension added, then a directory's `package.json` `main` or
+//!   its `index` file;
+//! - bare specifiers naming a package in the tree resolve through that
+//!   package's `exports` map (subpaths, `*` patterns and the `import`,
+//!   `require`, `node` and `default` conditions, in manifest order) or, when it
+//!   has none, its `main` field; any other bare specifier is a third-party
+//!   package.
+//!
+//! Every module also keeps its exported names and declarations, so the graph
+//! doubles as a symbol index ([`JsModuleGraph::find_symbol`]).
+
+use std::collections::{BTreeMap, BTreeSet};
+use std::fmt;
+use std::path::{Path, PathBuf};
+
+use ignore::WalkBuilder;
+use serde::de::{self, Deserializer, MapAccess, SeqAccess, Visitor};
+use serde::{Deserialize, Serialize};
+use tracing::warn;
+
+use crate::core::errors::{Result, ValknutError};
+use crate::core::pipeline::discovery::IGNORE_FILE_NAME;
+use crate::lang::javascript::{
+    JavaScriptAdapter, JsDependencyKind, JsModuleReference, JsModuleSyntax, JsSymbol,
+};
+
+/// Extensions of the files read as JavaScript modules, in resolution order.
+const JS_EXTENSIONS: &[&str] = &["js", "mjs", "cjs", "jsx"];
+
+/// File name of a package manifest.
+const MANIFEST_FILE_NAME: &str = "package.json";
+
+/// Directory of installed dependencies, never scanned.
+const NODE_MODULES_DIR: &str = "node_modules";
+
+/// Node.js core modules, matched on the first segment of a bare specifier.
+const NODE_BUILTINS: &[&str] = &[
+    "assert",
+    "async_hooks",
+    "buffer",
+    "child_process",
+    "cluster",
+    "console",
+    "constants",
+    "crypto",
+    "dgram",
+    "diagnostics_channel",
+    "dns",
+    "domain",
+    "events",
+    "fs",
+    "http",
+    "http2",
+    "https",
+    "inspector",
+    "module",
+    "net",
+    "os",
+    "path",
+    "perf_hooks",
+    "process",
+    "punycode",
+    "querystring",
+    "readline",
+    "repl",
+    "stream",
+    "string_decoder",
+    "sys",
+    "timers",
+    "tls",
+    "trace_events",
+    "tty",
+    "url",
+    "util",
+    "v8",
+    "vm",
+    "wasi",
+    "worker_threads",
+    "zlib",
+];
+
+/// The `exports` field of a `package.json`.
+///
+/// Condition objects are matched in the order they are written, so maps keep
+/// their entries as a list rather than a sorted map.
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub enum ExportsField {
+    /// A target path such as `./dist/index.js`
+    Target(String),
+    /// Targets tried in order until one resolves
+    Fallbacks(Vec<ExportsField>),
+    /// Subpaths (keys starting with `.`) or conditions, in manifest order
+    Map(Vec<(String, ExportsField)>),
+    /// `null`: the subpath or condition is not exported
+    Blocked,
+}
+
+/// Deserializes any JSON value an `exports` field may hold.
+impl<'de> Deserialize<'de> for ExportsField {
+    fn deserialize<D: Deserializer<'de>>(deserializer: D) -> std::result::Result<Self, D::Error> {
+        deserializer.deserialize_any(ExportsVisitor)
+    }
+}
+
+/// Visitor building an [`ExportsField`] while preserving key order.
+struct ExportsVisitor;
+
+/// Maps strings, arrays, objects and `null` onto [`ExportsField`].
+impl<'de> Visitor<'de> for ExportsVisitor {
+    type Value = ExportsField;
+
+    fn expecting(&self, formatter: &mut fmt::Formatter) -> fmt::Result {
+        formatter.write_str("a package.json exports target, array or object")
+    }
+
+    fn visit_str<E: de::Error>(self, value: &str) -> std::result::Result<Self::Value, E> {
+        Ok(ExportsField::Target(value.to_string()))
+    }
+
+    fn visit_unit<E: de::Error>(self) -> std::result::Result<Self::Value, E> {
+        Ok(ExportsField::Blocked)
+    }
+
+    fn visit_seq<A: SeqAccess<'de>>(
+        self,
+        mut seq: A,
+    ) -> std::result::Result<Self::Value, A::Error> {
+        let mut targets = Vec::new();
+        while let Some(target) = seq.next_element()? {
+            targets.push(target);
+        }
+        Ok(ExportsField::Fallbacks(targets))
+    }
+
+    fn visit_map<A: MapAccess<'de>>(
+        self,
+        mut map: A,
+    ) -> std::result::Result<Self::Value, A::Error> {
+        let mut entries = Vec::new();
+        while let Some(entry) = map.next_entry()? {
+            entries.push(entry);
+        }
+        Ok(ExportsField::Map(entries))
+    }
+}
+
+/// Resolution methods for [`ExportsField`].
+impl ExportsField {
+    /// The package-relative target (`./dist/util.js`) that `subpath` (`.` or
+    /// `./util`) maps to under `conditions`, or `None` when the package does
+    /// not export it.
+    pub fn resolve(&self, subpath: &str, conditions: &[&str]) -> Option<String> {
+        let subpaths = match self {
+            Self::Map(entries) if entries.iter().all(|(key, _)| key.starts_with('.')) => entries,
+            // Sugar for `{ ".": <field> }`
+            _ => {
+                return (subpath == ".")
+                    .then(|| self.target(None, conditions))
+                    .flatten()
+            }
+        };
+
+        if let Some((_, field)) = subpaths
+            .iter()
+            .find(|(key, _)| key == subpath && !key.contains('*'))
+        {
+            return field.target(None, conditions);
+        }
+        // Of the matching `*` patterns, the one with the longest prefix wins.
+        subpaths
+            .iter()
+            .filter_map(|(key, field)| {
+                let (prefix, suffix) = key.split_once('*')?;
+                let capture = subpath
+                    .strip_prefix(prefix)?
+                    .strip_suffix(suffix)
+                    .filter(|capture| !capture.is_empty())?;
+                Some((prefix.len(), capture, field))
+            })
+            .max_by_key(|(prefix_len, _, _)| *prefix_len)
+            .and_then(|(_, capture, field)| field.target(Some(capture), conditions))
+    }
+
+    /// Resolve a target, substituting `capture` for `*` in pattern targets.
+    fn target(&self, capture: Option<&str>, conditions: &[&str]) -> Option<String> {
+        match self {
+            Self::Target(target) => Some(match capture {
+                Some(capture) => target.replace('*', capture),
+                None => target.clone(),
+            }),
+            Self::Fallbacks(targets) => targets
+                .iter()
+                .find_map(|target| target.target(capture, conditions)),
+            Self::Map(entries) => entries
+                .iter()
+                .filter(|(condition, _)| {
+                    condition == "default" || conditions.contains(&condition.as_str())
+                })
+                .find_map(|(_, field)| field.target(capture, conditions)),
+            Self::Blocked => None,
+        }
+    }
+}
+
+/// The fields of a `package.json` that affect resolution.
+#[derive(Debug, Clone, Default, PartialEq, Eq, Deserialize)]
+pub struct PackageManifest {
+    /// Package name, e.g. `@acme/util`
+    #[serde(default)]
+    pub name: Option<String>,
+    /// Entry point used when there is no `exports` field
+    #[serde(default)]
+    pub main: Option<String>,
+    /// Export map; when present it is the only way into the package
+    #[serde(default)]
+    pub exports: Option<ExportsField>,
+}
+
+/// Parsing methods for [`PackageManifest`].
+impl PackageManifest {
+    /// Parse the content of a `package.json`.
+    pub fn parse(content: &str) -> Result<Self> {
+        serde_json::from_str(content)
+            .map_err(|e| ValknutError::parse("json", format!("Invalid package.json: {e}")))
+    }
+}
+
+/// A JavaScript file in the graph.
+#[derive(Debug, Clone, Default, PartialEq, Eq, Serialize, Deserialize)]
+pub struct JsModule {
+    /// Name of the nearest enclosing package with a name
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub package: Option<String>,
+    /// Names the module exports
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub exports: Vec<String>,
+    /// Top-level declarations and class methods
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub symbols: Vec<JsSymbol>,
+}
+
+/// A resolved dependency between two modules of the graph.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct JsModuleEdge {
+    /// Importing module
+    pub from: String,
+    /// Imported module
+    pub to: String,
+    /// How the module is loaded
+    pub kind: JsDependencyKind,
+    /// Names taken from the module
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub names: Vec<String>,
+    /// 1-based line of the reference in `from`
+    pub line: usize,
+    /// Whether the module is only loaded on some paths
+    #[serde(default)]
+    pub conditional: bool,
+}
+
+/// A relative or workspace specifier that matched no file.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct UnresolvedJsImport {
+    /// Importing module
+    pub from: String,
+    /// Specifier as written
+    pub specifier: String,
+    /// 1-based line of the reference
+    pub line: usize,
+}
+
+/// Module dependency graph and symbol index of the JavaScript files in a tree.
+#[derive(Debug, Clone, Default, PartialEq, Eq, Serialize, Deserialize)]
+pub struct JsModuleGraph {
+    /// Modules keyed by root-relative path
+    pub modules: BTreeMap<String, JsModule>,
+    /// Dependencies between modules in the graph
+    pub edges: Vec<JsModuleEdge>,
+    /// Third-party packages, with the modules that import each
+    pub packages: BTreeMap<String, BTreeSet<String>>,
+    /// Node.js built-in modules used anywhere in the tree
+    pub builtins: BTreeSet<String>,
+    /// Specifiers that should have resolved but did not
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub unresolved: Vec<UnresolvedJsImport>,
+}
+
+/// Where a specifier leads.
+enum Resolution {
+    Module(String),
+    Package(String),
+    Builtin(String),
+    Unresolved,
+    /// Non-JavaScript assets such as `./data.json`
+    Ignored,
+}
+
+/// Construction, lookup and export methods for [`JsModuleGraph`].
+impl JsModuleGraph {
+    /// Read and resolve every JavaScript module beneath `roots`.
+    pub fn from_paths(roots: &[PathBuf]) -> Result<Self> {
+        let mut adapter = JavaScriptAdapter::new()?;
+        let mut modules = Vec::new();
+        let mut manifests = Vec::new();
+        for root in roots {
+            let mut builder = WalkBuilder::new(root);
+            builder
+                .add_custom_ignore_filename(IGNORE_FILE_NAME)
+                .filter_entry(|entry| entry.file_name() != NODE_MODULES_DIR);
+            for entry in builder.build().filter_map(|entry| entry.ok()) {
+                let path = entry.path();
+                let is_manifest = path
+                    .file_name()
+                    .is_some_and(|name| name == MANIFEST_FILE_NAME);
+                let is_module = path
+                    .extension()
+                    .and_then(|ext| ext.to_str())
+                    .is_some_and(|ext| JS_EXTENSIONS.contains(&ext));
+                if !path.is_file() || !(is_manifest || is_module) {
+                    continue;
+                }
+                let source = std::fs::read_to_string(path).map_err(|e| {
+                    ValknutError::io(format!("Failed to read {}", path.display()), e)
+                })?;
+                let relative = path.strip_prefix(root).unwrap_or(path).to_path_buf();
+
+                if is_manifest {
+                    match PackageManifest::parse(&source) {
+                        Ok(manifest) => manifests.push((relative, manifest)),
+                        Err(err) => warn!("Skipping {}: {}", path.display(), err),
+                    }
+                    continue;
+                }
+                match adapter.extract_module_syntax(&source) {
+                    Ok(syntax) => modules.push((relative, syntax)),
+                    Err(err) => warn!("Skipping {}: {}", path.display(), err),
+                }
+            }
+        }
+        Ok(Self::from_modules(&modules, &manifests))
+    }
+
+    /// Build the graph from already extracted modules and parsed manifests,
+    /// both keyed by root-relative path.
+    pub fn from_modules(
+        modules: &[(PathBuf, JsModuleSyntax)],
+        manifests: &[(PathBuf, PackageManifest)],
+    ) -> Self {
+        // Manifests keyed by their directory ("" for the root).
+        let manifests: BTreeMap<String, &PackageManifest> = manifests
+            .iter()
+            .map(|(path, manifest)| {
+                let dir = path.parent().map(path_key).unwrap_or_default();
+                (dir, manifest)
+            })
+            .collect();
+        let resolver = Resolver {
+            modules: modules.iter().map(|(path, _)| path_key(path)).collect(),
+            workspace: manifests
+                .iter()
+                .filter_map(|(dir, manifest)| Some((manifest.name.clone()?, dir.clone())))
+                .collect(),
+            manifests,
+        };
+
+        let mut graph = Self::default();
+        for (path, syntax) in modules {
+            let key = path_key(path);
+            graph.modules.insert(
+                key.clone(),
+                JsModule {
+                    package: resolver.package_of(&key),
+                    exports: syntax.exports.clone(),
+                    symbols: syntax.symbols.clone(),
+                },
+            );
+            for reference in &syntax.references {
+                graph.add_reference(&resolver, &key, reference);
+            }
+        }
+        graph
+    }
+
+    /// Record where one reference of module `from` leads.
+    fn add_reference(&mut self, resolver: &Resolver, from: &str, reference: &JsModuleReference) {
+        match resolver.resolve(from, &reference.specifier, reference.kind) {
+            Resolution::Module(to) => self.edges.push(JsModuleEdge {
+                from: from.to_string(),
+                to,
+                kind: reference.kind,
+                names: reference.names.clone(),
+                line: reference.line,
+                conditional: reference.conditional,
+            }),
+            Resolution::Package(name) => {
+                self.packages
+                    .entry(name)
+                    .or_default()
+                    .insert(from.to_string());
+            }
+            Resolution::Builtin(name) => {
+                self.builtins.insert(name);
+            }
+            Resolution::Unresolved => self.unresolved.push(UnresolvedJsImport {
+                from: from.to_string(),
+                specifier: reference.specifier.clone(),
+                line: reference.line,
+            }),
+            Resolution::Ignored => {}
+        }
+    }
+
+    /// Whether the tree holds no JavaScript modules.
+    pub fn is_empty(&self) -> bool {
+        self.modules.is_empty()
+    }
+
+    /// Number of dependencies between modules of the graph.
+    pub fn edge_count(&self) -> usize {
+        self.edges.len()
+    }
+
+    /// Every declaration named `name`, with the module declaring it.
+    pub fn find_symbol(&self, name: &str) -> Vec<(&str, &JsSymbol)> {
+        self.modules
+            .iter()
+            .flat_map(|(path, module)| {
+                module
+                    .symbols
+                    .iter()
+                    .filter(move |symbol| symbol.name == name)
+                    .map(move |symbol| (path.as_str(), symbol))
+            })
+            .collect()
+    }
+
+    /// Render the graph in Graphviz DOT format; conditional dependencies are
+    /// dashed and third-party packages are boxes.
+    pub fn to_dot(&self) -> String {
+        let mut dot = String::from("digraph javascript {\n    rankdir=LR;\n");
+        for module in self.modules.keys() {
+            dot.push_str(&format!("    \"{module}\";\n"));
+        }
+        for package in self.packages.keys() {
+            dot.push_str(&format!("    \"{package}\" [shape=box];\n"));
+        }
+        let mut edges = BTreeSet::new();
+        for edge in &self.edges {
+            edges.insert((edge.from.as_str(), edge.to.as_str(), edge.conditional));
+        }
+        for (from, to, conditional) in edges {
+            let style = if conditional { " [style=dashed]" } else { "" };
+            dot.push_str(&format!("    \"{from}\" -> \"{to}\"{style};\n"));
+        }
+        for (package, importers) in &self.packages {
+            for from in importers {
+                dot.push_str(&format!("    \"{from}\" -> \"{package}\";\n"));
+            }
+        }
+        dot.push_str("}\n");
+        dot
+    }
+
+    /// Render the graph as pretty-printed JSON.
+    pub fn to_json(&self) -> Result<String> {
+        serde_json::to_string_pretty(self).map_err(|e| {
+            ValknutError::internal(format!("Failed to serialize JavaScript module graph: {e}"))
+        })
+    }
+}
+
+/// Node.js-style specifier resolution over the scanned tree.
+struct Resolver<'a> {
+    /// Keys of every module in the tree
+    modules: BTreeSet<String>,
+    /// Manifests keyed by directory
+    manifests: BTreeMap<String, &'a PackageManifest>,
+    /// Package directories keyed by package name
+    workspace: BTreeMap<String, String>,
+}
+
+/// Resolution methods for [`Resolver`].
+impl Resolver<'_> {
+    /// Where `specifier`, loaded from module `from` as `kind`, leads.
+    fn resolve(&self, from: &str, specifier: &str, kind: JsDependencyKind) -> Resolution {
+        if let Some(builtin) = builtin_name(specifier) {
+            return Resolution::Builtin(builtin);
+        }
+        if specifier.starts_with("./") || specifier.starts_with("../") || specifier.starts_with('/')
+        {
+            let target = join(parent_dir(from), specifier);
+            return match self.resolve_path(&target) {
+                Some(module) => Resolution::Module(module),
+                None if is_asset(specifier) => Resolution::Ignored,
+                None => Resolution::Unresolved,
+            };
+        }
+
+        let (name, subpath) = split_package_specifier(specifier);
+        let Some(dir) = self.workspace.get(name) else {
+            return if specifier.starts_with('#') {
+                Resolution::Unresolved
+            } else {
+                Resolution::Package(name.to_string())
+            };
+        };
+        let manifest = self.manifests[dir];
+        let target = match &manifest.exports {
+            Some(exports) => {
+                let conditions: &[&str] = match kind {
+                    JsDependencyKind::Require => &["require", "node"],
+                    _ => &["import", "node"],
+                };
+                exports
+                    .resolve(&subpath, conditions)
+                    .and_then(|target| self.resolve_file(&join(dir, &target)))
+            }
+            None => self.resolve_path(&join(dir, &subpath)),
+        };
+        match target {
+            Some(module) => Resolution::Module(module),
+            None if is_asset(specifier) => Resolution::Ignored,
+            None => Resolution::Unresolved,
+        }
+    }
+
+    /// Resolve a path as a file, then as a directory.
+    fn resolve_path(&self, path: &str) -> Option<String> {
+        self.resolve_file(path).or_else(|| self.resolve_dir(path))
+    }
+
+    /// The module at `path` exactly or with a JavaScript extension added.
+    fn resolve_file(&self, path: &str) -> Option<String> {
+        if self.modules.contains(path) {
+            return Some(path.to_string());
+        }
+        JS_EXTENSIONS
+            .iter()
+            .map(|ext| format!("{path}.{ext}"))
+            .find(|candidate| self.modules.contains(candidate))
+    }
+
+    /// The module a directory stands for: its manifest's `main`, then its
+    /// `index` file.
+    fn resolve_dir(&self, dir: &str) -> Option<String> {
+        self.manifests
+            .get(dir)
+            .and_then(|manifest| manifest.main.as_deref())
+            .and_then(|main| self.resolve_file(&join(dir, main)))
+            .or_else(|| self.resolve_file(&join(dir, "index")))
+    }
+
+    /// Name of the nearest package enclosing `module`.
+    fn package_of(&self, module: &str) -> Option<String> {
+        let mut dir = parent_dir(module);
+        loop {
+            if let Some(name) = self.manifests.get(dir).and_then(|m| m.name.clone()) {
+                return Some(name);
+            }
+            if dir.is_empty() {
+                return None;
+            }
+            dir = parent_dir(dir);
+        }
+    }
+}
+
+/// Canonical name of a Node.js built-in, or `None` for other specifiers.
+fn builtin_name(specifier: &str) -> Option<String> {
+    if let Some(name) = specifier.strip_prefix("node:") {
+        return Some(name.to_string());
+    }
+    let first = specifier.split('/').next().unwrap_or(specifier);
+    NODE_BUILTINS
+        .contains(&first)
+        .then(|| specifier.to_string())
+}
+
+/// Split a bare specifier into its package name and `.`-relative subpath:
+/// `@acme/util/strings` becomes (`@acme/util`, `./strings`).
+fn split_package_specifier(specifier: &str) -> (&str, String) {
+    let segments = if specifier.starts_with('@') { 2 } else { 1 };
+    match specifier.match_indices('/').nth(segments - 1) {
+        Some((index, _)) => (&specifier[..index], format!(".{}", &specifier[index..])),
+        None => (specifier, ".".to_string()),
+    }
+}
+
+/// Whether a specifier names a file that is not a JavaScript module.
+fn is_asset(specifier: &str) -> bool {
+    Path::new(specifier)
+        .extension()
+        .and_then(|ext| ext.to_str())
+        .is_some_and(|ext| !JS_EXTENSIONS.contains(&ext))
+}
+
+/// Graph key of a path: `/` separators, no leading `./`.
+fn path_key(path: &Path) -> String {
+    let key = path.to_string_lossy().replace('\\', "/");
+    key.trim_start_matches("./").to_string()
+}
+
+/// Directory part of a key (`""` at the root).
+fn parent_dir(key: &str) -> &str {
+    key.rsplit_once('/').map_or("", |(dir, _)| dir)
+}
+
+/// Join a relative path onto a directory key, folding `.` and `..`.
+fn join(dir: &str, relative: &str) -> String {
+    let mut parts: Vec<&str> = if relative.starts_with('/') {
+        Vec::new()
+    } else {
+        dir.split('/').filter(|part| !part.is_empty()).collect()
+    };
+    for part in relative.split('/') {
+        match part {
+            "" | "." => {}
+            ".." => {
+                parts.pop();
+            }
+            part => parts.push(part),
+        }
+    }
+    parts.join("/")
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::lang::javascript::JsSymbolKind;
+
+    fn reference(specifier: &str, kind: JsDependencyKind, line: usize) -> JsModuleReference {
+        JsModuleReference {
+            specifier: specifier.to_string(),
+            kind,
+            names: vec!["default".to_string()],
+            line,
+            conditional: kind == JsDependencyKind::DynamicImport,
+        }
+    }
+
+    fn module(references: Vec<JsModuleReference>) -> JsModuleSyntax {
+        JsModuleSyntax {
+            references,
+            ..JsModuleSyntax::default()
+        }
+    }
+
+    fn manifest(content: &str) -> PackageManifest {
+        PackageManifest::parse(content).unwrap()
+    }
+
+    #[test]
+    fn exports_keep_condition_order_and_match_patterns() {
+        let exports = manifest(
+            r#"{
+                "name": "@acme/util",
+                "exports": {
+                    ".": { "require": "./dist/index.cjs", "import": "./dist/index.mjs" },
+                    "./features/*": { "node": "./src/features/*.js", "default": null },
+                    "./features/private/*": null,
+                    "./package.json": "./package.json"
+                }
+            }"#,
+        )
+        .exports
+        .unwrap();
+
+        assert_eq!(
+            exports.resolve(".", &["import", "node"]).as_deref(),
+            Some("./dist/index.mjs")
+        );
+        assert_eq!(
+            exports.resolve(".", &["require", "node"]).as_deref(),
+            Some("./dist/index.cjs")
+        );
+        assert_eq!(
+            exports
+                .resolve("./features/auth", &["import", "node"])
+                .as_deref(),
+            Some("./src/features/auth.js")
+        );
+        assert_eq!(exports.resolve("./features/private/x", &["node"]), None);
+        assert_eq!(exports.resolve("./internal", &["node"]), None);
+
+        let sugar = manifest(r#"{ "exports": ["./missing.js", "./index.js"] }"#)
+            .exports
+            .unwrap();
+        assert_eq!(sugar.resolve(".", &[]).as_deref(), Some("./missing.js"));
+        assert_eq!(sugar.resolve("./other", &[]), None);
+    }
+
+    #[test]
+    fn resolves_relative_workspace_builtin_and_package_specifiers() {
+        use JsDependencyKind::*;
+        let app = module(vec![
+            reference("./lib/math", Import, 1),
+            reference("./lib", Require, 2),
+            reference("@acme/util", Import, 3),
+            reference("@acme/util/strings", Import, 4),
+            reference("@acme/util/internal", Import, 5),
+            reference("node:fs", Import, 6),
+            reference("path", Require, 7),
+            reference("react-dom/client", Import, 8),
+            reference("./config.json", Import, 9),
+            reference("./missing", DynamicImport, 10),
+            reference("./lazy.mjs", DynamicImport, 11),
+        ]);
+        let mut util = module(vec![reference("../../../app/lib/math.js", ReExport, 1)]);
+        util.exports = vec!["capitalize".to_string()];
+        util.symbols = vec![JsSymbol {
+            name: "capitalize".to_string(),
+            kind: JsSymbolKind::Function,
+            line: 3,
+            exported: true,
+            class: None,
+            jsdoc: None,
+        }];
+        let modules = vec![
+            (PathBuf::from("app/main.js"), app),
+            (PathBuf::from("app/lib/math.js"), module(Vec::new())),
+            (PathBuf::from("app/lib/index.cjs"), module(Vec::new())),
+            (PathBuf::from("app/lazy.mjs"), module(Vec::new())),
+            (
+                PathBuf::from("packages/util/src/index.mjs"),
+                module(Vec::new()),
+            ),
+            (PathBuf::from("packages/util/src/strings.js"), util),
+        ];
+        let manifests = vec![
+            (
+                PathBuf::from("app/package.json"),
+                manifest(r#"{ "name": "app" }"#),
+            ),
+            (
+                PathBuf::from("packages/util/package.json"),
+                manifest(
+                    r#"{ "name": "@acme/util", "exports": {
+                        ".": { "import": "./src/index.mjs" },
+                        "./*": "./src/*.js"
+                    } }"#,
+                ),
+            ),
+        ];
+
+        let graph = JsModuleGraph::from_modules(&modules, &manifests);
+        let targets: Vec<_> = graph
+            .edges
+            .iter()
+            .filter(|edge| edge.from == "app/main.js")
+            .map(|edge| (edge.to.as_str(), edge.line, edge.conditional))
+            .collect();
+        assert_eq!(
+            targets,
+            vec![
+                ("app/lib/math.js", 1, false),
+                ("app/lib/index.cjs", 2, false),
+                ("packages/util/src/index.mjs", 3, false),
+                ("packages/util/src/strings.js", 4, false),
+                ("app/lazy.mjs", 11, true),
+            ]
+        );
+        assert_eq!(graph.edge_count(), 6);
+        assert_eq!(
+            graph.builtins.iter().collect::<Vec<_>>(),
+            vec!["fs", "path"]
+        );
+        assert!(graph.packages["react-dom"].contains("app/main.js"));
+        let unresolved: Vec<_> = graph
+            .unresolved
+            .iter()
+            .map(|u| u.specifier.as_str())
+            .collect();
+        assert_eq!(unresolved, vec!["@acme/util/internal", "./missing"]);
+
+        assert_eq!(graph.modules["app/main.js"].package.as_deref(), Some("app"));
+        let found = graph.find_symbol("capitalize");
+        assert_eq!(found.len(), 1);
+        assert_eq!(found[0].0, "packages/util/src/strings.js");
+
+        let dot = graph.to_dot();
+        assert!(dot.contains("\"app/main.js\" -> \"app/lazy.mjs\" [style=dashed];"));
+        assert!(dot.contains("\"react-dom\" [shape=box];"));
+        assert!(graph
+            .to_json()
+            .unwrap()
+            .contains("\"kind\": \"dynamic_import\""));
+    }
+
+    #[test]
+    fn directories_resolve_through_main_then_index() {
+        let modules = vec![
+            (
+                PathBuf::from("index.js"),
+                module(vec![
+                    reference("./vendored", JsDependencyKind::Require, 1),
+                    reference("./plain/", JsDependencyKind::Require, 2),
+                ]),
+            ),
+            (PathBuf::from("vendored/lib/entry.js"), module(Vec::new())),
+            (PathBuf::from("plain/index.js"), module(Vec::new())),
+        ];
+        let manifests = vec![(
+            PathBuf::from("vendored/package.json"),
+            manifest(r#"{ "main": "lib/entry" }"#),
+        )];
+
+        let graph = JsModuleGraph::from_modules(&modules, &manifests);
+        let targets: Vec<_> = graph.edges.iter().map(|edge| edge.to.as_str()).collect();
+        assert_eq!(targets, vec!["vendored/lib/entry.js", "plain/index.js"]);
+        assert!(graph.unresolved.is_empty());
+        assert_eq!(
+            split_package_specifier("lodash"),
+            ("lodash", ".".to_string())
+        );
+        assert_eq!(join("a/b", "../c/./d.js"), "a/c/d.js");
+    }
+}
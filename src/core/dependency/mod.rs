@@ -27,6 +27,16 @@
 //! ```
 
 mod call_resolution;
+pub mod embedded_assets;
+pub mod go_dependencies;
+pub mod go_modules;
+pub mod gradle_projects;
+pub mod interface_check;
+pub mod js_modules;
+pub mod package_graph;
+pub mod swift_packages;
+pub mod symbol_graph;
+pub mod type_graph;
 pub mod types;
 
 use std::collections::{HashMap, HashSet, VecDeque};
@@ -42,9 +52,23 @@ use crate::core::file_utils::FileReader;
 use crate::lang::{adapter_for_file, EntityKind, ParseIndex, ParsedEntity};
 
 use call_resolution::{select_target, CallIdentifier};
+pub use go_dependencies::{DependencyChecker, DependencyReport, VersionBump};
+pub use go_modules::{CrossModuleImport, GoModule, GoModuleGraph};
+pub use gradle_projects::{GradleBuild, GradleGraph, GradleProject, GradleSettings};
+pub use interface_check::{
+    InterfaceFinding, InterfaceFindingSeverity, InterfaceImplementations, InterfaceReport,
+};
+pub use js_modules::{JsModule, JsModuleEdge, JsModuleGraph, PackageManifest};
+pub use package_graph::{PackageComponent, PackageGraph, PackageNode, PackageOrigin};
+pub use swift_packages::{
+    SwiftManifest, SwiftPackage, SwiftPackageDependency, SwiftPackageGraph, SwiftProductDependency,
+    SwiftTarget,
+};
+pub use symbol_graph::{SymbolEdge, SymbolEdgeKind, SymbolGraph};
+pub use type_graph::{TypeEdge, TypeEdgeKind, TypeGraph, TypeNode, TypeNodeKind};
 pub use types::{
-    Chokepoint, DependencyMetrics, EntityKey, FunctionNode, ModuleGraph, ModuleGraphEdge,
-    ModuleGraphNode,
+    CallGraph, CallGraphNode, Chokepoint, DependencyMetrics, EntityKey, FunctionNode, ModuleGraph,
+    ModuleGraphEdge, ModuleGraphNode,
 };
 
 /// Results of dependency analysis for a project.
@@ -63,6 +87,8 @@ pub struct ProjectDependencyAnalysis {
     chokepoints: Vec<Chokepoint>,
     /// Module-level aggregation of the dependency graph.
     module_graph: ModuleGraph,
+    /// Resolved call edges from each caller to its callees.
+    calls: HashMap<EntityKey, Vec<EntityKey>>,
 }
 
 /// Analysis and query methods for [`ProjectDependencyAnalysis`].
@@ -75,6 +101,7 @@ impl ProjectDependencyAnalysis {
             cycles: Vec::new(),
             chokepoints: Vec::new(),
             module_graph: ModuleGraph::default(),
+            calls: HashMap::new(),
         }
     }
 
@@ -109,6 +136,7 @@ impl ProjectDependencyAnalysis {
         mark_cycle_members(&mut metrics, &cycle_members);
         let chokepoints = compute_chokepoints(&metrics, &nodes, 10);
         let module_graph = build_module_graph(&graph, &nodes, &metrics);
+        let calls = collect_call_edges(&graph);
 
         Ok(Self {
             nodes,
@@ -116,6 +144,7 @@ impl ProjectDependencyAnalysis {
             cycles,
             chokepoints,
             module_graph,
+            calls,
         })
     }
 
@@ -175,6 +204,107 @@ impl ProjectDependencyAnalysis {
     pub fn metrics_iter(&self) -> impl Iterator<Item = (&EntityKey, &DependencyMetrics)> {
         self.metrics.iter()
     }
+
+    /// Exports the function-level call graph as an adjacency list.
+    ///
+    /// With `max_depth`, traversal starts at entry points (functions nobody
+    /// calls, or every function when the graph is fully cyclic) and stops
+    /// expanding after `max_depth` hops, keeping output bounded on large programs.
+    pub fn call_graph(&self, max_depth: Option<usize>) -> CallGraph {
+        let mut graph = CallGraph {
+            max_depth,
+            ..CallGraph::default()
+        };
+
+        let Some(limit) = max_depth else {
+            for key in self.nodes.keys() {
+                self.add_call_graph_node(&mut graph, key);
+            }
+            for (caller, callees) in &self.calls {
+                self.add_call_graph_edges(&mut graph, caller, callees);
+            }
+            return graph;
+        };
+
+        let called: HashSet<&EntityKey> = self.calls.values().flatten().collect();
+        let mut roots: Vec<&EntityKey> = self
+            .nodes
+            .keys()
+            .filter(|key| !called.contains(key))
+            .collect();
+        if roots.is_empty() {
+            roots = self.nodes.keys().collect();
+        }
+        roots.sort_by(|a, b| self.node_id(a).cmp(&self.node_id(b)));
+
+        let mut depths: HashMap<&EntityKey, usize> = HashMap::with_capacity(self.nodes.len());
+        let mut queue: VecDeque<&EntityKey> = VecDeque::with_capacity(roots.len());
+        for root in roots {
+            depths.insert(root, 0);
+            queue.push_back(root);
+        }
+
+        while let Some(key) = queue.pop_front() {
+            self.add_call_graph_node(&mut graph, key);
+            let callees = self.calls.get(key).map(Vec::as_slice).unwrap_or_default();
+            if callees.is_empty() {
+                continue;
+            }
+
+            let depth = depths[key];
+            if depth >= limit {
+                graph.truncated = true;
+                continue;
+            }
+
+            self.add_call_graph_edges(&mut graph, key, callees);
+            for callee in callees {
+                if !depths.contains_key(callee) {
+                    depths.insert(callee, depth + 1);
+                    queue.push_back(callee);
+                }
+            }
+        }
+
+        graph
+    }
+
+    /// Returns the stable identifier used for `key` in exported call graphs.
+    fn node_id(&self, key: &EntityKey) -> String {
+        self.nodes
+            .get(key)
+            .map(|node| node.unique_id.clone())
+            .unwrap_or_else(|| format!("{}::{}", key.file_path.display(), key.qualified_name))
+    }
+
+    /// Inserts a node into an exported call graph.
+    fn add_call_graph_node(&self, graph: &mut CallGraph, key: &EntityKey) {
+        let Some(node) = self.nodes.get(key) else {
+            return;
+        };
+        graph
+            .nodes
+            .entry(node.unique_id.clone())
+            .or_insert_with(|| CallGraphNode {
+                name: node.name.clone(),
+                qualified_name: node.qualified_name.clone(),
+                file: normalize_path_string(&node.file_path),
+                start_line: node.start_line,
+            });
+    }
+
+    /// Records the outgoing edges of `caller` in an exported call graph.
+    fn add_call_graph_edges(
+        &self,
+        graph: &mut CallGraph,
+        caller: &EntityKey,
+        callees: &[EntityKey],
+    ) {
+        let mut targets: Vec<String> = callees.iter().map(|callee| self.node_id(callee)).collect();
+        targets.sort();
+        targets.dedup();
+        graph.adjacency.insert(self.node_id(caller), targets);
+    }
 }
 
 /// Parses a file and extracts function nodes with their call information.
@@ -196,7 +326,17 @@ fn collect_function_nodes(path: &Path) -> Result<Vec<FunctionNode>> {
         let start_line = Some(entity.location.start_line);
         let end_line = Some(entity.location.end_line);
 
-        let namespace = build_namespace(entity, &parse_index);
+        let mut namespace = build_namespace(entity, &parse_index);
+        if namespace.is_empty() {
+            // Go methods are declared at top level; their receiver type is the namespace.
+            if let Some(receiver) = entity
+                .metadata
+                .get("receiver_base_type")
+                .and_then(|value| value.as_str())
+            {
+                namespace.push(receiver.to_string());
+            }
+        }
         let qualified_name = if namespace.is_empty() {
             entity.name.clone()
         } else {
@@ -326,6 +466,21 @@ fn build_graph(nodes: &HashMap<EntityKey, FunctionNode>) -> (DependencyGraph, In
             let Some(call_id) = CallIdentifier::parse(raw_call) else {
                 continue;
             };
+            if is_go_source(node) {
+                let dispatch = go_dispatch_targets(&call_id, &name_lookup, nodes);
+                if !dispatch.is_empty() {
+                    for target_key in dispatch {
+                        try_add_edge(
+                            &mut graph,
+                            &index_map,
+                            &mut seen_targets,
+                            from_index,
+                            target_key,
+                        );
+                    }
+                    continue;
+                }
+            }
             if let Some(target_key) = find_target_for_call(&call_id, &name_lookup, node, nodes) {
                 try_add_edge(
                     &mut graph,
@@ -341,6 +496,82 @@ fn build_graph(nodes: &HashMap<EntityKey, FunctionNode>) -> (DependencyGraph, In
     (graph, index_map)
 }
 
+/// Returns true when the function was declared in a Go source file.
+fn is_go_source(node: &FunctionNode) -> bool {
+    node.file_path
+        .extension()
+        .map(|ext| ext.eq_ignore_ascii_case("go"))
+        .unwrap_or(false)
+}
+
+/// Resolves a Go method call that may be dispatched through an interface.
+///
+/// A call such as `store.Get()` can land on any type with a `Get` method, so
+/// when several receiver types define the method every one becomes a target
+/// (class-hierarchy analysis). Returns an empty list when ordinary resolution
+/// should be used instead.
+fn go_dispatch_targets<'a>(
+    call_id: &CallIdentifier,
+    name_lookup: &'a HashMap<String, Vec<&'a EntityKey>>,
+    nodes: &HashMap<EntityKey, FunctionNode>,
+) -> Vec<&'a EntityKey> {
+    if call_id.namespace().is_empty() {
+        return Vec::new();
+    }
+    let Some(candidates) = name_lookup.get(call_id.base()) else {
+        return Vec::new();
+    };
+
+    let methods: Vec<&EntityKey> = candidates
+        .iter()
+        .copied()
+        .filter(|key| {
+            nodes.get(*key).is_some_and(|node| {
+                !node.namespace.is_empty()
+                    && node.name.eq_ignore_ascii_case(call_id.base())
+                    && !namespace_is_receiver(call_id, node)
+            })
+        })
+        .collect();
+
+    let receiver_matches = candidates.iter().any(|key| {
+        nodes
+            .get(*key)
+            .is_some_and(|node| namespace_is_receiver(call_id, node))
+    });
+    if methods.len() > 1 && !receiver_matches {
+        methods
+    } else {
+        Vec::new()
+    }
+}
+
+/// Checks whether the call explicitly names the candidate's receiver type (`Type.Method`).
+fn namespace_is_receiver(call_id: &CallIdentifier, node: &FunctionNode) -> bool {
+    match (call_id.namespace().last(), node.namespace.last()) {
+        (Some(call_ns), Some(receiver)) => call_ns.eq_ignore_ascii_case(receiver),
+        _ => false,
+    }
+}
+
+/// Collects resolved call edges from the dependency graph.
+fn collect_call_edges(graph: &DependencyGraph) -> HashMap<EntityKey, Vec<EntityKey>> {
+    let mut calls: HashMap<EntityKey, Vec<EntityKey>> = HashMap::new();
+    for edge in graph.edge_references() {
+        let (Some(source), Some(target)) = (
+            graph.node_weight(edge.source()),
+            graph.node_weight(edge.target()),
+        ) else {
+            continue;
+        };
+        calls
+            .entry(source.clone())
+            .or_default()
+            .push(target.clone());
+    }
+    calls
+}
+
 /// Builds a lookup table from function names to their entity keys.
 fn build_name_lookup<'a>(
     nodes: &'a HashMap<EntityKey, FunctionNode>,
@@ -661,6 +892,30 @@ fn normalize_path_string(path: &Path) -> String {
     path.to_string_lossy().replace('\\', "/")
 }
 
+/// Escape a string for use inside a quoted DOT identifier.
+pub(crate) fn escape_dot(value: &str) -> String {
+    value.replace('\\', "\\\\").replace('"', "\\\"")
+}
+
+/// `dir` relative to `root`, with `/` separators (`.` for the root itself,
+/// `dir` unchanged when it is outside `root`).
+pub(crate) fn relative_dir(root: &Path, dir: &Path) -> String {
+    let Ok(relative) = dir.strip_prefix(root) else {
+        return dir.to_string_lossy().replace('\\', "/");
+    };
+    let relative = relative
+        .components()
+        .filter(|component| !matches!(component, std::path::Component::CurDir))
+        .map(|component| component.as_os_str().to_string_lossy())
+        .collect::<Vec<_>>()
+        .join("/");
+    if relative.is_empty() {
+        ".".to_string()
+    } else {
+        relative
+    }
+}
+
 /// Normalizes a path for consistent dependency tracking.
 ///
 /// Preserves relative paths when the file exists to avoid absolute path
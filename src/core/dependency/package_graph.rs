@@ -0,0 +1,824 @@
+//! Package-level import graphs for Go and Java projects.
+//!
+//! Every Go source file contributes the `import` paths it declares to the
+//! package (directory) it lives in; every Java source file contributes the
+//! packages of the types it imports to the package it declares. The resulting
+//! directed graph classifies each package as internal, standard library, or
+//! third-party, flags import cycles between internal packages, and condenses
+//! strongly connected components so cycles can be reasoned about as single
+//! units. Java packages additionally record the Maven or Gradle module that
+//! contains them; JDK packages are left out of the graph. Files embedded into
+//! Go packages with `//go:embed` are listed on their package as
+//! [`EmbeddedAsset`]s.
+
+use std::collections::{BTreeMap, BTreeSet, HashMap, VecDeque};
+use std::fmt::Write as _;
+use std::path::{Path, PathBuf};
+
+use ignore::WalkBuilder;
+use petgraph::algo::kosaraju_scc;
+use petgraph::graph::{Graph, NodeIndex};
+use serde::{Deserialize, Serialize};
+use tracing::warn;
+
+use super::embedded_assets::{collect_embedded_assets, EmbeddedAsset};
+use super::escape_dot;
+use crate::core::errors::{Result, ValknutError};
+use crate::core::file_utils::FileReader;
+use crate::core::pipeline::discovery::IGNORE_FILE_NAME;
+use crate::detectors::structure::config::ImportStatement;
+use crate::lang::go::{embed_patterns, GoAdapter};
+use crate::lang::java::{is_jdk_package, package_of_qualified_name, JavaAdapter};
+use crate::lang::LanguageAdapter;
+
+/// Where an imported package comes from.
+#[derive(Debug, Clone, Copy, PartialEq, Eq, PartialOrd, Ord, Serialize, Deserialize)]
+#[serde(rename_all = "snake_case")]
+pub enum PackageOrigin {
+    /// A package defined inside the analysed module.
+    Internal,
+    /// A standard library package: Go packages without a dot in the first
+    /// path segment, or JDK packages.
+    Stdlib,
+    /// A package fetched from another module.
+    ThirdParty,
+}
+
+/// A package node in the import graph.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct PackageNode {
+    /// Full import path of the package.
+    pub import_path: String,
+    /// Classification of the package.
+    pub origin: PackageOrigin,
+    /// Number of analysed source files belonging to the package (internal only).
+    pub files: usize,
+    /// Maven or Gradle module that contains the package (internal Java packages only).
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub module: Option<String>,
+    /// Files embedded with `//go:embed`, sorted by path (internal Go packages only).
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub embedded_assets: Vec<EmbeddedAsset>,
+}
+
+/// A strongly connected component of internal packages.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct PackageComponent {
+    /// Sorted import paths of the packages in the component.
+    pub packages: Vec<String>,
+    /// Indices of the components this component imports.
+    pub imports: Vec<usize>,
+}
+
+/// Directed graph of package-level import relationships.
+#[derive(Debug, Clone, Default, Serialize, Deserialize)]
+pub struct PackageGraph {
+    /// Module path declared in `go.mod`, when one was found.
+    pub module_path: Option<String>,
+    /// Every package seen, keyed by import path.
+    pub packages: BTreeMap<String, PackageNode>,
+    /// Import edges: importing package -> imported packages.
+    pub imports: BTreeMap<String, BTreeSet<String>>,
+    /// Import cycles among internal packages (each sorted, largest first).
+    pub cycles: Vec<Vec<String>>,
+    /// Condensation of the internal packages into strongly connected components.
+    pub components: Vec<PackageComponent>,
+}
+
+/// Construction and query methods for [`PackageGraph`].
+impl PackageGraph {
+    /// Build the package graph for every Go file beneath the given roots.
+    ///
+    /// `vendor/` and `testdata/` trees are skipped, and `.gitignore` and
+    /// `.valknutignore` files are honoured during the walk.
+    pub fn from_go_projects(roots: &[PathBuf]) -> Result<Self> {
+        PackageGraphBuilder::for_languages(true, false).build(roots)
+    }
+
+    /// Build the package graph for every Java file beneath the given roots.
+    ///
+    /// Packages are identified by their `package` declaration and attributed to
+    /// the nearest enclosing Maven (`pom.xml`) or Gradle (`build.gradle`,
+    /// `build.gradle.kts`) module. Imports of JDK packages are not recorded, and
+    /// Maven `target/` and Gradle `build/` output directories are skipped.
+    pub fn from_java_projects(roots: &[PathBuf]) -> Result<Self> {
+        PackageGraphBuilder::for_languages(false, true).build(roots)
+    }
+
+    /// Build a single package graph from both the Go and Java files beneath the roots.
+    pub fn from_projects(roots: &[PathBuf]) -> Result<Self> {
+        PackageGraphBuilder::for_languages(true, true).build(roots)
+    }
+
+    /// Build the package graph of the single Go module rooted at `root`.
+    ///
+    /// Subdirectories with their own `go.mod` belong to other modules and are
+    /// skipped. Imports of packages in `sibling_modules` are classified as
+    /// third-party even when they share this module's path prefix.
+    pub(super) fn from_go_module(root: &Path, sibling_modules: &[String]) -> Result<Self> {
+        let mut builder = PackageGraphBuilder::for_languages(true, false);
+        builder.skip_nested_modules = true;
+        builder.sibling_modules = sibling_modules.to_vec();
+        builder.build(&[root.to_path_buf()])
+    }
+
+    /// Packages that belong to the given Maven or Gradle module, sorted.
+    pub fn module_packages(&self, module: &str) -> Vec<String> {
+        self.packages
+            .values()
+            .filter(|node| node.module.as_deref() == Some(module))
+            .map(|node| node.import_path.clone())
+            .collect()
+    }
+
+    /// Classification of a package, if it appears in the graph.
+    pub fn origin(&self, import_path: &str) -> Option<PackageOrigin> {
+        self.packages.get(import_path).map(|node| node.origin)
+    }
+
+    /// Returns true when any internal import cycle was found.
+    pub fn has_cycles(&self) -> bool {
+        !self.cycles.is_empty()
+    }
+
+    /// Total number of import edges.
+    pub fn edge_count(&self) -> usize {
+        self.imports.values().map(BTreeSet::len).sum()
+    }
+
+    /// Every package that imports `import_path` directly or transitively, sorted.
+    pub fn transitive_importers(&self, import_path: &str) -> Vec<String> {
+        let mut importers_of: HashMap<&str, Vec<&str>> = HashMap::new();
+        for (from, targets) in &self.imports {
+            for to in targets {
+                importers_of
+                    .entry(to.as_str())
+                    .or_default()
+                    .push(from.as_str());
+            }
+        }
+
+        let mut seen = BTreeSet::new();
+        let mut queue = VecDeque::from([import_path]);
+        while let Some(current) = queue.pop_front() {
+            for importer in importers_of.get(current).into_iter().flatten() {
+                if seen.insert(importer.to_string()) {
+                    queue.push_back(importer);
+                }
+            }
+        }
+
+        seen.remove(import_path);
+        seen.into_iter().collect()
+    }
+
+    /// Render the graph in Graphviz DOT format.
+    ///
+    /// Nodes are coloured by origin and edges that participate in a cycle are red.
+    pub fn to_dot(&self) -> String {
+        let cycle_of: HashMap<&str, usize> = self
+            .cycles
+            .iter()
+            .enumerate()
+            .flat_map(|(idx, cycle)| cycle.iter().map(move |pkg| (pkg.as_str(), idx)))
+            .collect();
+
+        let mut dot = String::from("digraph packages {\n");
+        dot.push_str("  rankdir=LR;\n");
+        dot.push_str("  node [shape=box, style=filled];\n");
+
+        for node in self.packages.values() {
+            let color = match node.origin {
+                PackageOrigin::Internal => "#cfe8ff",
+                PackageOrigin::Stdlib => "#e0e0e0",
+                PackageOrigin::ThirdParty => "#ffe5b4",
+            };
+            let _ = writeln!(
+                dot,
+                "  \"{}\" [fillcolor=\"{}\"];",
+                escape_dot(&node.import_path),
+                color
+            );
+        }
+
+        for (from, targets) in &self.imports {
+            for to in targets {
+                let in_cycle = matches!(
+                    (cycle_of.get(from.as_str()), cycle_of.get(to.as_str())),
+                    (Some(a), Some(b)) if a == b
+                );
+                let style = if in_cycle { " [color=red]" } else { "" };
+                let _ = writeln!(
+                    dot,
+                    "  \"{}\" -> \"{}\"{};",
+                    escape_dot(from),
+                    escape_dot(to),
+                    style
+                );
+            }
+        }
+
+        dot.push_str("}\n");
+        dot
+    }
+
+    /// Serialize the graph as pretty-printed JSON.
+    pub fn to_json(&self) -> Result<String> {
+        serde_json::to_string_pretty(self).map_err(|err| {
+            ValknutError::internal(format!("Failed to serialize package graph: {}", err))
+        })
+    }
+}
+
+/// Accumulates imports per package before cycles and components are computed.
+#[derive(Debug, Default)]
+struct PackageGraphBuilder {
+    include_go: bool,
+    include_java: bool,
+    module_path: Option<String>,
+    files_per_package: BTreeMap<String, usize>,
+    imports: BTreeMap<String, BTreeSet<String>>,
+    java_modules: BTreeMap<String, String>,
+    java_targets: BTreeSet<String>,
+    embedded_assets: BTreeMap<String, Vec<EmbeddedAsset>>,
+    build_modules: HashMap<PathBuf, Option<String>>,
+    skip_nested_modules: bool,
+    sibling_modules: Vec<String>,
+}
+
+/// Incremental construction methods for [`PackageGraphBuilder`].
+impl PackageGraphBuilder {
+    /// Create a builder that analyses the selected languages.
+    fn for_languages(include_go: bool, include_java: bool) -> Self {
+        Self {
+            include_go,
+            include_java,
+            ..Self::default()
+        }
+    }
+
+    /// Add every root and assemble the graph.
+    fn build(mut self, roots: &[PathBuf]) -> Result<PackageGraph> {
+        for root in roots {
+            self.add_root(root)?;
+        }
+        Ok(self.finish())
+    }
+
+    /// Walk one project root and record the imports of its Go and Java files.
+    fn add_root(&mut self, root: &Path) -> Result<()> {
+        let module_path = read_module_path(root);
+        if self.include_go && self.module_path.is_none() {
+            self.module_path = module_path.clone();
+        }
+
+        let mut go_adapter = GoAdapter::new()?;
+        let mut java_adapter = JavaAdapter::new()?;
+        let skip_nested_modules = self.skip_nested_modules;
+        let mut walker = WalkBuilder::new(root);
+        walker
+            .add_custom_ignore_filename(IGNORE_FILE_NAME)
+            .filter_entry(move |entry| {
+                !matches!(entry.file_name().to_str(), Some("vendor" | "testdata"))
+                    && !is_build_output(entry.path())
+                    && !(skip_nested_modules && entry.depth() > 0 && is_go_module(entry.path()))
+            });
+
+        for entry in walker.build() {
+            let entry = match entry {
+                Ok(entry) => entry,
+                Err(err) => {
+                    warn!("Failed to walk directory: {err}");
+                    continue;
+                }
+            };
+            let path = entry.path();
+            match path.extension().and_then(|ext| ext.to_str()) {
+                Some("go") if self.include_go => {
+                    let source = FileReader::read_to_string(path)?;
+                    let package = package_import_path(root, path, module_path.as_deref());
+                    self.add_embedded_assets(root, path, &package, &source);
+                    self.add_file(&mut go_adapter, package, &source)?;
+                }
+                Some("java") if self.include_java => {
+                    let source = FileReader::read_to_string(path)?;
+                    self.add_java_file(&mut java_adapter, root, path, &source)?;
+                }
+                _ => {}
+            }
+        }
+
+        Ok(())
+    }
+
+    /// Record the imports declared by a single source file.
+    fn add_file(&mut self, adapter: &mut GoAdapter, package: String, source: &str) -> Result<()> {
+        let targets = self.imports.entry(package.clone()).or_default();
+        for import in adapter.extract_imports(source)? {
+            if import.module != package {
+                targets.insert(import.module);
+            }
+        }
+        *self.files_per_package.entry(package).or_default() += 1;
+        Ok(())
+    }
+
+    /// Record the files a Go source file embeds with `//go:embed`.
+    fn add_embedded_assets(&mut self, root: &Path, path: &Path, package: &str, source: &str) {
+        let patterns = embed_patterns(source);
+        let Some(package_dir) = path.parent().filter(|_| !patterns.is_empty()) else {
+            return;
+        };
+        let assets = collect_embedded_assets(root, package_dir, &patterns);
+        if !assets.is_empty() {
+            self.embedded_assets
+                .entry(package.to_string())
+                .or_default()
+                .extend(assets);
+        }
+    }
+
+    /// Record the packages imported by a single Java compilation unit.
+    ///
+    /// Files without a `package` declaration belong to the default package `.`.
+    fn add_java_file(
+        &mut self,
+        adapter: &mut JavaAdapter,
+        root: &Path,
+        path: &Path,
+        source: &str,
+    ) -> Result<()> {
+        let package = adapter
+            .package_name(source)?
+            .unwrap_or_else(|| ".".to_string());
+        if let Some(module) = self.build_module_for(root, path) {
+            self.java_modules.entry(package.clone()).or_insert(module);
+        }
+
+        let targets = self.imports.entry(package.clone()).or_default();
+        for import in adapter.extract_imports(source)? {
+            let target = java_import_package(&import);
+            if !target.is_empty() && target != package {
+                self.java_targets.insert(target.clone());
+                targets.insert(target);
+            }
+        }
+        *self.files_per_package.entry(package).or_default() += 1;
+        Ok(())
+    }
+
+    /// Name of the nearest Maven or Gradle module containing `file`, searching up to `root`.
+    fn build_module_for(&mut self, root: &Path, file: &Path) -> Option<String> {
+        let mut visited = Vec::new();
+        let mut module = None;
+        let mut dir = file.parent();
+        while let Some(current) = dir {
+            if let Some(cached) = self.build_modules.get(current) {
+                module = cached.clone();
+                break;
+            }
+            visited.push(current.to_path_buf());
+            if let Some(name) = build_module_name(current) {
+                module = Some(name);
+                break;
+            }
+            if current == root {
+                break;
+            }
+            dir = current.parent();
+        }
+
+        for dir in visited {
+            self.build_modules.insert(dir, module.clone());
+        }
+        module
+    }
+
+    /// Classify packages, detect cycles, and condense components.
+    fn finish(mut self) -> PackageGraph {
+        for assets in self.embedded_assets.values_mut() {
+            assets.sort_by(|a, b| a.path.cmp(&b.path));
+            assets.dedup_by(|a, b| a.path == b.path);
+        }
+
+        let module_path = self.module_path;
+        let classify = |import_path: &str| {
+            let in_module = module_path.as_deref().is_some_and(|module| {
+                is_within_module(import_path, module)
+                    && !self.sibling_modules.iter().any(|sibling| {
+                        sibling.len() > module.len() && is_within_module(import_path, sibling)
+                    })
+            });
+            if self.files_per_package.contains_key(import_path) || in_module {
+                PackageOrigin::Internal
+            } else if self.java_targets.contains(import_path) {
+                if is_jdk_package(import_path) {
+                    PackageOrigin::Stdlib
+                } else {
+                    PackageOrigin::ThirdParty
+                }
+            } else if import_path
+                .split('/')
+                .next()
+                .is_some_and(|seg| !seg.contains('.'))
+            {
+                PackageOrigin::Stdlib
+            } else {
+                PackageOrigin::ThirdParty
+            }
+        };
+
+        let mut packages = BTreeMap::new();
+        for (from, targets) in &self.imports {
+            for import_path in std::iter::once(from).chain(targets) {
+                packages
+                    .entry(import_path.clone())
+                    .or_insert_with(|| PackageNode {
+                        import_path: import_path.clone(),
+                        origin: classify(import_path),
+                        files: self
+                            .files_per_package
+                            .get(import_path)
+                            .copied()
+                            .unwrap_or(0),
+                        module: self.java_modules.get(import_path).cloned(),
+                        embedded_assets: self
+                            .embedded_assets
+                            .get(import_path)
+                            .cloned()
+                            .unwrap_or_default(),
+                    });
+            }
+        }
+
+        let (cycles, components) = condense_internal(&packages, &self.imports);
+
+        PackageGraph {
+            module_path,
+            packages,
+            imports: self.imports,
+            cycles,
+            components,
+        }
+    }
+}
+
+/// Compute cycles and the SCC condensation over internal packages.
+fn condense_internal(
+    packages: &BTreeMap<String, PackageNode>,
+    imports: &BTreeMap<String, BTreeSet<String>>,
+) -> (Vec<Vec<String>>, Vec<PackageComponent>) {
+    let mut graph: Graph<&str, ()> = Graph::new();
+    let mut index: HashMap<&str, NodeIndex> = HashMap::new();
+    for node in packages.values() {
+        if node.origin == PackageOrigin::Internal {
+            index.insert(&node.import_path, graph.add_node(&node.import_path));
+        }
+    }
+    for (from, targets) in imports {
+        let Some(&from_idx) = index.get(from.as_str()) else {
+            continue;
+        };
+        for to in targets {
+            if let Some(&to_idx) = index.get(to.as_str()) {
+                graph.add_edge(from_idx, to_idx, ());
+            }
+        }
+    }
+
+    let mut sccs: Vec<Vec<String>> = kosaraju_scc(&graph)
+        .into_iter()
+        .map(|scc| {
+            let mut members: Vec<String> = scc.iter().map(|idx| graph[*idx].to_string()).collect();
+            members.sort();
+            members
+        })
+        .collect();
+    sccs.sort();
+
+    let component_of: HashMap<&str, usize> = sccs
+        .iter()
+        .enumerate()
+        .flat_map(|(idx, members)| members.iter().map(move |pkg| (pkg.as_str(), idx)))
+        .collect();
+
+    let components = sccs
+        .iter()
+        .enumerate()
+        .map(|(idx, members)| {
+            let imports: BTreeSet<usize> = members
+                .iter()
+                .filter_map(|pkg| imports.get(pkg))
+                .flatten()
+                .filter_map(|target| component_of.get(target.as_str()).copied())
+                .filter(|target| *target != idx)
+                .collect();
+            PackageComponent {
+                packages: members.clone(),
+                imports: imports.into_iter().collect(),
+            }
+        })
+        .collect();
+
+    let mut cycles: Vec<Vec<String>> = sccs.into_iter().filter(|scc| scc.len() > 1).collect();
+    cycles.sort_by(|a, b| b.len().cmp(&a.len()).then_with(|| a.cmp(b)));
+
+    (cycles, components)
+}
+
+/// Read the `module` directive from `go.mod` in `root`, if present.
+pub(super) fn read_module_path(root: &Path) -> Option<String> {
+    let contents = std::fs::read_to_string(root.join("go.mod")).ok()?;
+    contents.lines().find_map(|line| {
+        let path = line.trim().strip_prefix("module")?.trim();
+        let path = path.trim_matches('"');
+        (!path.is_empty()).then(|| path.to_string())
+    })
+}
+
+/// Returns true when `import_path` is `module` itself or a package inside it.
+pub(super) fn is_within_module(import_path: &str, module: &str) -> bool {
+    import_path
+        .strip_prefix(module)
+        .is_some_and(|rest| rest.is_empty() || rest.starts_with('/'))
+}
+
+/// Returns true for directories that hold a `go.mod` file.
+pub(super) fn is_go_module(dir: &Path) -> bool {
+    dir.join("go.mod").is_file()
+}
+
+/// Import path of the package containing `file`.
+///
+/// Without a `go.mod`, the directory relative to the root is used (`.` for the root itself).
+fn package_import_path(root: &Path, file: &Path, module_path: Option<&str>) -> String {
+    let relative = file
+        .parent()
+        .and_then(|dir| dir.strip_prefix(root).ok())
+        .map(|dir| {
+            dir.components()
+                .map(|c| c.as_os_str().to_string_lossy())
+                .collect::<Vec<_>>()
+                .join("/")
+        })
+        .unwrap_or_default();
+
+    match (module_path, relative.is_empty()) {
+        (Some(module), true) => module.to_string(),
+        (Some(module), false) => format!("{}/{}", module, relative),
+        (None, true) => ".".to_string(),
+        (None, false) => relative,
+    }
+}
+
+/// Package referenced by a Java import (`com.acme.Outer.Inner` -> `com.acme`).
+fn java_import_package(import: &ImportStatement) -> String {
+    match (import.import_type.as_str(), &import.imports) {
+        ("star", _) if !import.module.contains(|c: char| c.is_ascii_uppercase()) => {
+            import.module.clone()
+        }
+        ("static", _) | ("star", _) => package_of_qualified_name(&import.module),
+        (_, Some(members)) => members
+            .first()
+            .map(|member| package_of_qualified_name(&format!("{}.{}", import.module, member)))
+            .unwrap_or_else(|| import.module.clone()),
+        (_, None) => package_of_qualified_name(&import.module),
+    }
+}
+
+/// Module name declared by a Maven or Gradle build file in `dir`, if there is one.
+///
+/// Maven modules use the project's `artifactId`; Gradle modules use the directory name.
+fn build_module_name(dir: &Path) -> Option<String> {
+    let dir_name = || {
+        dir.file_name()
+            .map(|name| name.to_string_lossy().into_owned())
+    };
+    if let Ok(pom) = std::fs::read_to_string(dir.join("pom.xml")) {
+        return pom_artifact_id(&pom).or_else(dir_name);
+    }
+    if dir.join("build.gradle").is_file() || dir.join("build.gradle.kts").is_file() {
+        return dir_name();
+    }
+    None
+}
+
+/// The project's own `artifactId` from a `pom.xml`, ignoring the `<parent>` block.
+fn pom_artifact_id(pom: &str) -> Option<String> {
+    let pom = match (pom.find("<parent>"), pom.find("</parent>")) {
+        (Some(start), Some(end)) if start < end => format!("{}{}", &pom[..start], &pom[end..]),
+        _ => pom.to_string(),
+    };
+    let start = pom.find("<artifactId>")? + "<artifactId>".len();
+    let end = start + pom[start..].find("</artifactId>")?;
+    let artifact = pom[start..end].trim();
+    (!artifact.is_empty()).then(|| artifact.to_string())
+}
+
+/// Returns true for Maven `target/` and Gradle `build/` output directories.
+fn is_build_output(path: &Path) -> bool {
+    let Some(parent) = path.parent() else {
+        return false;
+    };
+    match path.file_name().and_then(|name| name.to_str()) {
+        Some("target") => parent.join("pom.xml").is_file(),
+        Some("build") => {
+            parent.join("build.gradle").is_file() || parent.join("build.gradle.kts").is_file()
+        }
+        _ => false,
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use std::fs;
+    use tempfile::tempdir;
+
+    fn write(root: &Path, relative: &str, contents: &str) {
+        let path = root.join(relative);
+        fs::create_dir_all(path.parent().unwrap()).unwrap();
+        fs::write(path, contents).unwrap();
+    }
+
+    fn sample_project() -> tempfile::TempDir {
+        let tmp = tempdir().unwrap();
+        let root = tmp.path();
+        write(root, "go.mod", "module example.com/app\n\ngo 1.22\n");
+        write(
+            root,
+            "main.go",
+            "package main\n\nimport (\n\t\"fmt\"\n\t\"example.com/app/api\"\n)\n",
+        );
+        write(
+            root,
+            "api/api.go",
+            "package api\n\nimport (\n\t\"net/http\"\n\t\"github.com/go-chi/chi/v5\"\n\t\"example.com/app/store\"\n)\n",
+        );
+        write(
+            root,
+            "store/store.go",
+            "package store\n\nimport \"example.com/app/api\"\n",
+        );
+        write(
+            root,
+            "vendor/github.com/go-chi/chi/v5/chi.go",
+            "package chi\n\nimport \"example.com/app\"\n",
+        );
+        tmp
+    }
+
+    #[test]
+    fn classifies_packages_and_flags_cycles() {
+        let tmp = sample_project();
+        let graph = PackageGraph::from_go_projects(&[tmp.path().to_path_buf()]).unwrap();
+
+        assert_eq!(graph.module_path.as_deref(), Some("example.com/app"));
+        assert_eq!(
+            graph.origin("example.com/app"),
+            Some(PackageOrigin::Internal)
+        );
+        assert_eq!(graph.origin("net/http"), Some(PackageOrigin::Stdlib));
+        assert_eq!(
+            graph.origin("github.com/go-chi/chi/v5"),
+            Some(PackageOrigin::ThirdParty)
+        );
+        assert_eq!(graph.edge_count(), 6, "vendor/ must not contribute edges");
+
+        assert_eq!(
+            graph.cycles,
+            vec![vec![
+                "example.com/app/api".to_string(),
+                "example.com/app/store".to_string()
+            ]]
+        );
+
+        let cycle = graph
+            .components
+            .iter()
+            .position(|c| c.packages.len() == 2)
+            .unwrap();
+        let root = graph
+            .components
+            .iter()
+            .find(|c| c.packages == vec!["example.com/app".to_string()])
+            .unwrap();
+        assert_eq!(root.imports, vec![cycle]);
+    }
+
+    #[test]
+    fn transitive_importers_walk_reverse_edges() {
+        let tmp = sample_project();
+        let graph = PackageGraph::from_go_projects(&[tmp.path().to_path_buf()]).unwrap();
+
+        assert_eq!(
+            graph.transitive_importers("github.com/go-chi/chi/v5"),
+            vec![
+                "example.com/app".to_string(),
+                "example.com/app/api".to_string(),
+                "example.com/app/store".to_string(),
+            ]
+        );
+        assert!(graph.transitive_importers("example.com/app").is_empty());
+    }
+
+    #[test]
+    fn dot_output_highlights_cycle_edges() {
+        let tmp = sample_project();
+        let graph = PackageGraph::from_go_projects(&[tmp.path().to_path_buf()]).unwrap();
+        let dot = graph.to_dot();
+
+        assert!(dot.starts_with("digraph packages {"));
+        assert!(dot.contains("\"example.com/app/api\" -> \"example.com/app/store\" [color=red];"));
+        assert!(dot.contains("\"example.com/app\" -> \"fmt\";"));
+        assert!(dot.contains("\"net/http\" [fillcolor=\"#e0e0e0\"];"));
+    }
+
+    #[test]
+    fn embedded_files_attach_to_their_package() {
+        let tmp = sample_project();
+        let root = tmp.path();
+        write(
+            root,
+            "store/migrations.go",
+            "package store\n\nimport \"embed\"\n\n//go:embed migrations/*.sql\nvar migrations embed.FS\n",
+        );
+        write(
+            root,
+            "store/migrations/001_init.sql",
+            "CREATE TABLE accounts (id INT);\nSELECT * FROM accounts;\n",
+        );
+
+        let graph = PackageGraph::from_go_projects(&[root.to_path_buf()]).unwrap();
+        let store = &graph.packages["example.com/app/store"];
+        assert_eq!(store.embedded_assets.len(), 1);
+
+        let asset = &store.embedded_assets[0];
+        assert_eq!(asset.path, "store/migrations/001_init.sql");
+        assert_eq!(asset.pattern, "migrations/*.sql");
+        assert_eq!(asset.language, "sql");
+        let sql = asset.sql.as_ref().unwrap();
+        assert_eq!(sql.tables, vec!["accounts"]);
+        assert_eq!(sql.statements["CREATE TABLE"], 1);
+        assert_eq!(sql.statements["SELECT"], 1);
+
+        assert!(graph.packages["example.com/app/api"]
+            .embedded_assets
+            .is_empty());
+        assert!(graph.to_json().unwrap().contains("\"embedded_assets\""));
+    }
+
+    #[test]
+    fn java_packages_map_to_build_modules() {
+        let tmp = tempdir().unwrap();
+        let root = tmp.path();
+        write(
+            root,
+            "pom.xml",
+            "<project><artifactId>shop</artifactId><packaging>pom</packaging></project>",
+        );
+        write(
+            root,
+            "core/pom.xml",
+            "<project><parent><artifactId>shop</artifactId></parent><artifactId>shop-core</artifactId></project>",
+        );
+        write(
+            root,
+            "core/src/main/java/com/acme/core/Order.java",
+            "package com.acme.core;\n\nimport java.util.List;\nimport com.acme.api.OrderService;\n\npublic class Order {}\n",
+        );
+        write(root, "api/build.gradle.kts", "plugins { java }\n");
+        write(
+            root,
+            "api/src/main/java/com/acme/api/OrderService.java",
+            "package com.acme.api;\n\nimport com.acme.core.Order;\nimport com.google.common.collect.*;\nimport static org.junit.Assert.assertEquals;\n\npublic interface OrderService {}\n",
+        );
+        write(
+            root,
+            "core/target/generated/com/acme/gen/Stub.java",
+            "package com.acme.gen;\n\nimport com.acme.core.Order;\n",
+        );
+
+        let graph = PackageGraph::from_java_projects(&[root.to_path_buf()]).unwrap();
+
+        assert_eq!(graph.module_path, None);
+        assert_eq!(graph.origin("com.acme.core"), Some(PackageOrigin::Internal));
+        assert_eq!(
+            graph.origin("com.google.common.collect"),
+            Some(PackageOrigin::ThirdParty)
+        );
+        assert_eq!(graph.origin("org.junit"), Some(PackageOrigin::ThirdParty));
+        assert_eq!(graph.origin("java.util"), None, "JDK imports are excluded");
+        assert_eq!(graph.origin("com.acme.gen"), None, "target/ is skipped");
+
+        assert_eq!(graph.module_packages("shop-core"), vec!["com.acme.core"]);
+        assert_eq!(graph.module_packages("api"), vec!["com.acme.api"]);
+        assert_eq!(
+            graph.cycles,
+            vec![vec![
+                "com.acme.api".to_string(),
+                "com.acme.core".to_string()
+            ]]
+        );
+    }
+}
@@ -0,0 +1,680 @@
+//! Swift packages.
+//!
+//! A Swift package is rooted at a directory holding `Package.swift`. The
+//! manifest names the package's remote or local package dependencies
+//! (`.package(url: ..., from: ...)`) and its targets (`.target`,
+//! `.executableTarget`, `.testTarget`, ...). Targets depend on other targets
+//! of the same package by name and on products of dependency packages through
+//! `.product(name:package:)`. [`SwiftPackageGraph::from_paths`] finds every
+//! package beneath the scanned paths and records that target graph.
+
+use std::collections::BTreeSet;
+use std::fs;
+use std::path::{Path, PathBuf};
+
+use ignore::WalkBuilder;
+use serde::{Deserialize, Serialize};
+use tracing::warn;
+
+use super::relative_dir;
+use crate::core::errors::{Result, ValknutError};
+use crate::core::pipeline::discovery::IGNORE_FILE_NAME;
+use crate::lang::buffer_pool::source_chars;
+use crate::lang::swift::{tokenize, Spanned, Token};
+
+/// Manifest file name.
+const MANIFEST_FILE: &str = "Package.swift";
+
+/// Target declaration functions of `PackageDescription`.
+const TARGET_KINDS: &[&str] = &[
+    "target",
+    "executableTarget",
+    "testTarget",
+    "macro",
+    "plugin",
+    "systemLibrary",
+    "binaryTarget",
+];
+
+/// A package dependency declared with `.package(...)`.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct SwiftPackageDependency {
+    /// Package identity: the last URL or path component, lowercased, without `.git`.
+    pub identity: String,
+    /// `url:` or `path:` argument.
+    pub location: String,
+    /// Version requirement as written (`from: "1.2.0"`, `branch: "main"`).
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub requirement: Option<String>,
+}
+
+/// A product of a dependency package used by a target.
+#[derive(Debug, Clone, PartialEq, Eq, PartialOrd, Ord, Serialize, Deserialize)]
+pub struct SwiftProductDependency {
+    /// Product name.
+    pub name: String,
+    /// Identity of the package providing it, when the manifest names one.
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub package: Option<String>,
+}
+
+/// One target of a Swift package.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct SwiftTarget {
+    /// Target name.
+    pub name: String,
+    /// Declaration function, e.g. `target`, `executableTarget`, `testTarget`.
+    pub kind: String,
+    /// Source directory relative to the package root (`Sources/<name>` or
+    /// `Tests/<name>` unless the manifest sets `path:`).
+    pub dir: String,
+    /// Targets of the same package this one depends on.
+    #[serde(default, skip_serializing_if = "BTreeSet::is_empty")]
+    pub depends_on: BTreeSet<String>,
+    /// Products of dependency packages this one uses.
+    #[serde(default, skip_serializing_if = "BTreeSet::is_empty")]
+    pub products: BTreeSet<SwiftProductDependency>,
+}
+
+/// What a `Package.swift` manifest declares.
+#[derive(Debug, Clone, Default, PartialEq, Eq)]
+pub struct SwiftManifest {
+    /// `Package(name:)`.
+    pub name: Option<String>,
+    /// Package dependencies in declaration order.
+    pub dependencies: Vec<SwiftPackageDependency>,
+    /// Targets in declaration order. Names that are not targets of this
+    /// manifest are kept in [`SwiftTarget::depends_on`] for [`SwiftPackage::load`]
+    /// to sort out.
+    pub targets: Vec<SwiftTarget>,
+}
+
+/// A Swift package: one manifest and its targets.
+#[derive(Debug, Clone, Default, PartialEq, Eq, Serialize, Deserialize)]
+pub struct SwiftPackage {
+    /// Directory holding `Package.swift`, relative to the scanned root (`.` for the root).
+    pub root: String,
+    /// `Package(name:)`, when set.
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub name: Option<String>,
+    /// Package dependencies in declaration order.
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub dependencies: Vec<SwiftPackageDependency>,
+    /// Targets sorted by name.
+    pub targets: Vec<SwiftTarget>,
+    /// Problems such as dependencies on targets or packages the manifest does not declare.
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub errors: Vec<String>,
+}
+
+/// Parsing methods for [`SwiftManifest`].
+impl SwiftManifest {
+    /// Parse a `Package.swift` manifest.
+    pub fn parse(source: &str) -> Self {
+        let chars = source_chars(source);
+        let tokens = tokenize(&chars);
+        let mut manifest = Self::default();
+
+        let mut index = 0;
+        while index < tokens.len() {
+            if ident(&tokens, index) == Some("Package") && punct(&tokens, index + 1) == Some("(") {
+                let (arguments, _) = call_arguments(&tokens, index + 1);
+                manifest.name = arguments
+                    .iter()
+                    .find(|argument| argument.label.as_deref() == Some("name"))
+                    .and_then(|argument| argument.string(&tokens));
+                // Keep scanning inside the call for dependencies and targets.
+                index += 2;
+                continue;
+            }
+
+            let Some(function) = member_call(&tokens, index) else {
+                index += 1;
+                continue;
+            };
+            let (arguments, end) = call_arguments(&tokens, index + 2);
+            if function == "package" {
+                if let Some(dependency) = package_dependency(&chars, &tokens, &arguments) {
+                    manifest.dependencies.push(dependency);
+                }
+                index = end;
+            } else if TARGET_KINDS.contains(&function) {
+                if let Some(target) = target(&tokens, function, &arguments) {
+                    manifest.targets.push(target);
+                }
+                index = end;
+            } else {
+                index += 2;
+            }
+        }
+        manifest
+    }
+}
+
+/// Loading methods for [`SwiftPackage`].
+impl SwiftPackage {
+    /// Load the package whose manifest is in `dir`; `root` is reported relative to `scan_root`.
+    pub fn load(scan_root: &Path, dir: &Path) -> Result<Self> {
+        let manifest_path = dir.join(MANIFEST_FILE);
+        let source = fs::read_to_string(&manifest_path).map_err(|err| {
+            ValknutError::io(format!("Failed to read {}", manifest_path.display()), err)
+        })?;
+        let manifest = SwiftManifest::parse(&source);
+
+        let known: BTreeSet<String> = manifest
+            .targets
+            .iter()
+            .map(|target| target.name.clone())
+            .collect();
+        let identities: BTreeSet<String> = manifest
+            .dependencies
+            .iter()
+            .map(|dependency| dependency.identity.clone())
+            .collect();
+
+        let mut package = Self {
+            root: relative_dir(scan_root, dir),
+            name: manifest.name,
+            dependencies: manifest.dependencies,
+            ..Self::default()
+        };
+        for mut target in manifest.targets {
+            // A plain name is a target of this package or a product named after its package.
+            let (local, by_name): (BTreeSet<String>, BTreeSet<String>) = target
+                .depends_on
+                .iter()
+                .cloned()
+                .partition(|name| known.contains(name));
+            for name in by_name {
+                let identity = name.to_lowercase();
+                if identities.contains(&identity) {
+                    target.products.insert(SwiftProductDependency {
+                        name,
+                        package: Some(identity),
+                    });
+                } else {
+                    package.errors.push(format!(
+                        "Target {} depends on {name}, which {MANIFEST_FILE} does not declare",
+                        target.name
+                    ));
+                }
+            }
+            target.depends_on = local;
+            target.depends_on.remove(&target.name);
+
+            for product in &target.products {
+                if let Some(identity) = &product.package {
+                    if !identities.contains(identity) {
+                        package.errors.push(format!(
+                            "Target {} uses product {} of package {identity}, which {MANIFEST_FILE} does not depend on",
+                            target.name, product.name
+                        ));
+                    }
+                }
+            }
+            package.targets.push(target);
+        }
+        package.targets.sort_by(|a, b| a.name.cmp(&b.name));
+        Ok(package)
+    }
+}
+
+/// Every Swift package found beneath a set of paths.
+#[derive(Debug, Clone, Default, PartialEq, Eq, Serialize, Deserialize)]
+pub struct SwiftPackageGraph {
+    /// Packages sorted by root directory.
+    pub packages: Vec<SwiftPackage>,
+}
+
+/// Discovery and rendering methods for [`SwiftPackageGraph`].
+impl SwiftPackageGraph {
+    /// Find every `Package.swift` beneath the paths and load its package.
+    ///
+    /// `.build/` and `.swiftpm/` directories are skipped, and `.gitignore` and
+    /// `.valknutignore` files are honoured during the walk.
+    pub fn from_paths(paths: &[PathBuf]) -> Result<Self> {
+        let mut packages = Vec::new();
+        for root in paths.iter().filter(|path| path.is_dir()) {
+            for dir in find_package_dirs(root) {
+                packages.push(SwiftPackage::load(root, &dir)?);
+            }
+        }
+        packages.sort_by(|a, b| a.root.cmp(&b.root));
+        Ok(Self { packages })
+    }
+
+    /// Whether no Swift package was found.
+    pub fn is_empty(&self) -> bool {
+        self.packages.is_empty()
+    }
+
+    /// Total number of targets across all packages.
+    pub fn target_count(&self) -> usize {
+        self.packages
+            .iter()
+            .map(|package| package.targets.len())
+            .sum()
+    }
+
+    /// Total number of target and product dependencies across all packages.
+    pub fn edge_count(&self) -> usize {
+        self.packages
+            .iter()
+            .flat_map(|package| &package.targets)
+            .map(|target| target.depends_on.len() + target.products.len())
+            .sum()
+    }
+
+    /// Render the target graph in Graphviz DOT format, one cluster per
+    /// package. Products point at a box for the package providing them.
+    pub fn to_dot(&self) -> String {
+        let mut dot = String::from("digraph swift {\n    rankdir=LR;\n");
+        for (index, package) in self.packages.iter().enumerate() {
+            let label = package.name.as_deref().unwrap_or(&package.root);
+            dot.push_str(&format!(
+                "    subgraph cluster_{index} {{\n        label=\"{label}\";\n"
+            ));
+            for target in &package.targets {
+                dot.push_str(&format!(
+                    "        \"{}:{}\" [label=\"{}\"];\n",
+                    package.root, target.name, target.name
+                ));
+            }
+            dot.push_str("    }\n");
+            for dependency in &package.dependencies {
+                dot.push_str(&format!(
+                    "    \"pkg:{}\" [label=\"{}\", shape=box];\n",
+                    dependency.identity, dependency.identity
+                ));
+            }
+            for target in &package.targets {
+                for dependency in &target.depends_on {
+                    dot.push_str(&format!(
+                        "    \"{}:{}\" -> \"{}:{}\";\n",
+                        package.root, target.name, package.root, dependency
+                    ));
+                }
+                for product in &target.products {
+                    let provider = product.package.as_deref().unwrap_or(&product.name);
+                    dot.push_str(&format!(
+                        "    \"{}:{}\" -> \"pkg:{provider}\" [label=\"{}\"];\n",
+                        package.root, target.name, product.name
+                    ));
+                }
+            }
+        }
+        dot.push_str("}\n");
+        dot
+    }
+
+    /// Render the graph as pretty-printed JSON.
+    pub fn to_json(&self) -> Result<String> {
+        serde_json::to_string_pretty(self).map_err(|e| {
+            ValknutError::internal(format!("Failed to serialize Swift package graph: {e}"))
+        })
+    }
+}
+
+/// One argument of a call: its label and the token range of its value.
+struct Argument {
+    label: Option<String>,
+    start: usize,
+    end: usize,
+}
+
+/// Value accessors for [`Argument`].
+impl Argument {
+    /// The value when it is a single string literal.
+    fn string(&self, tokens: &[Spanned]) -> Option<String> {
+        match &tokens[self.start..self.end] {
+            [Spanned {
+                kind: Token::Str(text),
+                ..
+            }] => Some(text.clone()),
+            _ => None,
+        }
+    }
+}
+
+/// Build a dependency from the arguments of `.package(...)`.
+fn package_dependency(
+    chars: &[char],
+    tokens: &[Spanned],
+    arguments: &[Argument],
+) -> Option<SwiftPackageDependency> {
+    let location = arguments.iter().find_map(|argument| {
+        matches!(argument.label.as_deref(), Some("url" | "path"))
+            .then(|| argument.string(tokens))
+            .flatten()
+    })?;
+    let requirement: Vec<String> = arguments
+        .iter()
+        .filter(|argument| !matches!(argument.label.as_deref(), Some("url" | "path" | "name")))
+        .filter(|argument| argument.start < argument.end)
+        .map(|argument| {
+            let start = match argument.label {
+                // Include the label: `from: "1.0.0"`
+                Some(_) => tokens[argument.start - 2].start,
+                None => tokens[argument.start].start,
+            };
+            let text: String = chars[start..tokens[argument.end - 1].end].iter().collect();
+            text.split_whitespace().collect::<Vec<_>>().join(" ")
+        })
+        .collect();
+
+    let last = location
+        .trim_end_matches('/')
+        .rsplit(['/', ':'])
+        .next()
+        .unwrap_or(&location);
+    Some(SwiftPackageDependency {
+        identity: last.trim_end_matches(".git").to_lowercase(),
+        location: location.clone(),
+        requirement: (!requirement.is_empty()).then(|| requirement.join(", ")),
+    })
+}
+
+/// Build a target from the arguments of `.target(...)` and friends.
+fn target(tokens: &[Spanned], kind: &str, arguments: &[Argument]) -> Option<SwiftTarget> {
+    let labelled = |label: &str| {
+        arguments
+            .iter()
+            .find(|argument| argument.label.as_deref() == Some(label))
+    };
+    let name = labelled("name")?.string(tokens)?;
+    let dir = labelled("path")
+        .and_then(|argument| argument.string(tokens))
+        .map(|path| path.trim_end_matches('/').to_string())
+        .unwrap_or_else(|| {
+            let parent = if kind == "testTarget" {
+                "Tests"
+            } else if kind == "plugin" {
+                "Plugins"
+            } else {
+                "Sources"
+            };
+            format!("{parent}/{name}")
+        });
+
+    let mut target = SwiftTarget {
+        name,
+        kind: kind.to_string(),
+        dir,
+        depends_on: BTreeSet::new(),
+        products: BTreeSet::new(),
+    };
+    if let Some(dependencies) = labelled("dependencies") {
+        target_dependencies(tokens, dependencies, &mut target);
+    }
+    Some(target)
+}
+
+/// Read a target's `dependencies: [...]` array into `target`.
+fn target_dependencies(tokens: &[Spanned], dependencies: &Argument, target: &mut SwiftTarget) {
+    let mut index = dependencies.start;
+    while index < dependencies.end {
+        if let Token::Str(name) = &tokens[index].kind {
+            target.depends_on.insert(name.clone());
+            index += 1;
+            continue;
+        }
+        let Some(function) = member_call(tokens, index) else {
+            index += 1;
+            continue;
+        };
+        let (arguments, end) = call_arguments(tokens, index + 2);
+        let labelled = |label: &str| {
+            arguments
+                .iter()
+                .find(|argument| argument.label.as_deref() == Some(label))
+                .and_then(|argument| argument.string(tokens))
+        };
+        match (function, labelled("name")) {
+            ("target" | "byName", Some(name)) => {
+                target.depends_on.insert(name);
+            }
+            ("product", Some(name)) => {
+                target.products.insert(SwiftProductDependency {
+                    name,
+                    package: labelled("package").map(|package| package.to_lowercase()),
+                });
+            }
+            _ => {}
+        }
+        index = end;
+    }
+}
+
+/// The function name when `.name(` starts at `index`.
+fn member_call(tokens: &[Spanned], index: usize) -> Option<&str> {
+    if punct(tokens, index) != Some(".") || punct(tokens, index + 2) != Some("(") {
+        return None;
+    }
+    ident(tokens, index + 1)
+}
+
+/// Split the call whose `(` is at `open` into arguments. Returns them with the
+/// index just past the closing `)`.
+fn call_arguments(tokens: &[Spanned], open: usize) -> (Vec<Argument>, usize) {
+    let mut arguments = Vec::new();
+    let mut depth = 0usize;
+    let mut start = open + 1;
+    let mut index = open;
+    while index < tokens.len() {
+        match &tokens[index].kind {
+            Token::Punct(op) if matches!(op.as_str(), "(" | "[" | "{") => depth += 1,
+            Token::Punct(op) if matches!(op.as_str(), ")" | "]" | "}") => {
+                depth -= 1;
+                if depth == 0 {
+                    if start < index {
+                        arguments.push(argument(tokens, start, index));
+                    }
+                    return (arguments, index + 1);
+                }
+            }
+            Token::Punct(op) if op == "," && depth == 1 => {
+                arguments.push(argument(tokens, start, index));
+                start = index + 1;
+            }
+            _ => {}
+        }
+        index += 1;
+    }
+    (arguments, tokens.len())
+}
+
+/// The argument spanning `start..end`, split into its label and value.
+fn argument(tokens: &[Spanned], start: usize, end: usize) -> Argument {
+    match (ident(tokens, start), punct(tokens, start + 1)) {
+        (Some(label), Some(":")) if start + 1 < end => Argument {
+            label: Some(label.to_string()),
+            start: start + 2,
+            end,
+        },
+        _ => Argument {
+            label: None,
+            start,
+            end,
+        },
+    }
+}
+
+fn ident(tokens: &[Spanned], index: usize) -> Option<&str> {
+    match tokens.get(index).map(|token| &token.kind) {
+        Some(Token::Ident(word)) => Some(word),
+        _ => None,
+    }
+}
+
+fn punct(tokens: &[Spanned], index: usize) -> Option<&str> {
+    match tokens.get(index).map(|token| &token.kind) {
+        Some(Token::Punct(op)) => Some(op),
+        _ => None,
+    }
+}
+
+/// Directories beneath `root` holding a `Package.swift`.
+fn find_package_dirs(root: &Path) -> Vec<PathBuf> {
+    let mut walker = WalkBuilder::new(root);
+    walker
+        .add_custom_ignore_filename(IGNORE_FILE_NAME)
+        .filter_entry(|entry| !matches!(entry.file_name().to_str(), Some(".build" | ".swiftpm")));
+
+    let mut dirs: Vec<PathBuf> = walker
+        .build()
+        .filter_map(|entry| match entry {
+            Ok(entry) => Some(entry),
+            Err(err) => {
+                warn!("Failed to walk directory: {err}");
+                None
+            }
+        })
+        .filter(|entry| entry.file_type().is_some_and(|kind| kind.is_dir()))
+        .map(|entry| entry.into_path())
+        .filter(|dir| dir.join(MANIFEST_FILE).is_file())
+        .collect();
+    dirs.sort();
+    dirs
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    const MANIFEST: &str = r#"// swift-tools-version:5.9
+import PackageDescription
+
+let package = Package(
+    name: "Shop",
+    platforms: [.iOS(.v16), .macOS(.v13)],
+    products: [
+        .library(name: "ShopKit", targets: ["ShopKit"]),
+    ],
+    dependencies: [
+        .package(url: "https://github.com/apple/swift-collections.git", from: "1.0.0"),
+        .package(url: "https://github.com/pointfreeco/swift-snapshot-testing", .upToNextMinor(from: "1.15.0")),
+        .package(path: "../Analytics"),
+    ],
+    targets: [
+        .target(
+            name: "ShopKit",
+            dependencies: [
+                "Models",
+                .product(name: "Collections", package: "swift-collections"),
+                .product(name: "Analytics", package: "Analytics"),
+            ]
+        ),
+        .target(name: "Models", path: "Sources/Core/Models/"),
+        .executableTarget(name: "shop", dependencies: [.target(name: "ShopKit"), "Ghost"]),
+        .testTarget(
+            name: "ShopKitTests",
+            dependencies: [
+                .byName(name: "ShopKit"),
+                .product(name: "SnapshotTesting", package: "swift-snapshot-testing"),
+                .product(name: "Nimble", package: "Nimble"),
+            ]
+        ),
+    ]
+)
+"#;
+
+    #[test]
+    fn manifest_parses_dependencies_and_targets() {
+        let manifest = SwiftManifest::parse(MANIFEST);
+        assert_eq!(manifest.name.as_deref(), Some("Shop"));
+
+        let identities: Vec<_> = manifest
+            .dependencies
+            .iter()
+            .map(|dependency| dependency.identity.as_str())
+            .collect();
+        assert_eq!(
+            identities,
+            vec!["swift-collections", "swift-snapshot-testing", "analytics"]
+        );
+        assert_eq!(
+            manifest.dependencies[0].requirement.as_deref(),
+            Some("from: \"1.0.0\"")
+        );
+        assert_eq!(
+            manifest.dependencies[1].requirement.as_deref(),
+            Some(".upToNextMinor(from: \"1.15.0\")")
+        );
+        assert_eq!(manifest.dependencies[2].location, "../Analytics");
+        assert_eq!(manifest.dependencies[2].requirement, None);
+
+        let names: Vec<_> = manifest
+            .targets
+            .iter()
+            .map(|target| {
+                (
+                    target.name.as_str(),
+                    target.kind.as_str(),
+                    target.dir.as_str(),
+                )
+            })
+            .collect();
+        assert_eq!(
+            names,
+            vec![
+                ("ShopKit", "target", "Sources/ShopKit"),
+                ("Models", "target", "Sources/Core/Models"),
+                ("shop", "executableTarget", "Sources/shop"),
+                ("ShopKitTests", "testTarget", "Tests/ShopKitTests"),
+            ]
+        );
+        assert_eq!(
+            manifest.targets[0].products.iter().collect::<Vec<_>>(),
+            vec![
+                &SwiftProductDependency {
+                    name: "Analytics".to_string(),
+                    package: Some("analytics".to_string()),
+                },
+                &SwiftProductDependency {
+                    name: "Collections".to_string(),
+                    package: Some("swift-collections".to_string()),
+                },
+            ]
+        );
+    }
+
+    #[test]
+    fn packages_are_discovered_with_target_graph() {
+        let tmp = tempfile::tempdir().unwrap();
+        let root = tmp.path();
+        fs::create_dir_all(root.join("Shop/.build/checkouts/dep")).unwrap();
+        fs::write(root.join("Shop/Package.swift"), MANIFEST).unwrap();
+        fs::write(
+            root.join("Shop/.build/checkouts/dep/Package.swift"),
+            "let package = Package(name: \"Ignored\")\n",
+        )
+        .unwrap();
+
+        let graph = SwiftPackageGraph::from_paths(&[root.to_path_buf()]).unwrap();
+        assert_eq!(graph.packages.len(), 1);
+        let package = &graph.packages[0];
+        assert_eq!(package.root, "Shop");
+        assert_eq!(graph.target_count(), 4);
+
+        let shop = package.targets.iter().find(|t| t.name == "shop").unwrap();
+        assert_eq!(shop.depends_on.iter().collect::<Vec<_>>(), vec!["ShopKit"]);
+        let kit = package
+            .targets
+            .iter()
+            .find(|t| t.name == "ShopKit")
+            .unwrap();
+        assert_eq!(kit.depends_on.iter().collect::<Vec<_>>(), vec!["Models"]);
+
+        assert_eq!(package.errors.len(), 2);
+        assert!(package.errors[0].contains("Ghost"));
+        assert!(package.errors[1].contains("nimble"));
+
+        // ShopKit: Models + 2 products; shop: ShopKit; tests: ShopKit + 2 products
+        assert_eq!(graph.edge_count(), 7);
+        let dot = graph.to_dot();
+        assert!(dot.contains("\"Shop:shop\" -> \"Shop:ShopKit\";"));
+        assert!(
+            dot.contains("\"Shop:ShopKit\" -> \"pkg:swift-collections\" [label=\"Collections\"];")
+        );
+    }
+}
@@ -0,0 +1,258 @@
+//! Symbol-level dependency edges for impact analysis.
+//!
+//! [`SymbolGraph::from_files`] reuses the declarations the [`TypeGraph`]
+//! builder gathers from each file's AST and reports three kinds of directed
+//! edges between fully-qualified symbols (`<package>.<name>`, with methods as
+//! `<package>.<Type>.<method>` and variables declared in a function body as
+//! `<package>.<function>.<name>`):
+//!
+//! - **calls**: a function or method calls another declared function or method;
+//! - **embeds**: a struct embeds another type, or an interface embeds another
+//!   interface;
+//! - **assigns**: a variable declared with an interface type is initialised
+//!   with a declared concrete type that satisfies it. The concrete type comes
+//!   from the initial value (`&Memory{}`, `(*Memory)(nil)`) or from the first
+//!   result type of the constructor it calls (`NewMemory()`), so no type
+//!   checker is needed.
+//!
+//! Edges are kept sorted, so the serialized list is stable across runs.
+//!
+//! [`TypeGraph`]: super::TypeGraph
+
+use std::collections::BTreeSet;
+use std::path::{Path, PathBuf};
+
+use serde::{Deserialize, Serialize};
+
+use super::type_graph::{TypeEdgeKind, TypeGraphBuilder, TypeNodeKind};
+use crate::core::errors::{Result, ValknutError};
+
+/// Relationship represented by a [`SymbolEdge`].
+#[derive(Debug, Clone, Copy, PartialEq, Eq, PartialOrd, Ord, Serialize, Deserialize)]
+#[serde(rename_all = "snake_case")]
+pub enum SymbolEdgeKind {
+    /// The source function or method calls the target.
+    Calls,
+    /// The source type embeds the target type.
+    Embeds,
+    /// The source variable holds a value of the target concrete type.
+    Assigns,
+}
+
+/// A directed edge between two fully-qualified symbols.
+#[derive(Debug, Clone, PartialEq, Eq, PartialOrd, Ord, Serialize, Deserialize)]
+pub struct SymbolEdge {
+    /// Fully-qualified name of the source symbol.
+    pub source: String,
+    /// Fully-qualified name of the target symbol.
+    pub target: String,
+    /// Relationship kind.
+    pub kind: SymbolEdgeKind,
+    /// Interface the variable is declared as, for `assigns` edges.
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub interface: Option<String>,
+}
+
+/// Directed edges between the symbols of a set of files.
+#[derive(Debug, Clone, Default, PartialEq, Eq, Serialize, Deserialize)]
+pub struct SymbolGraph {
+    /// Edges in sorted order.
+    pub edges: BTreeSet<SymbolEdge>,
+}
+
+/// Construction, query and export methods for [`SymbolGraph`].
+impl SymbolGraph {
+    /// Build the graph for `files`, naming symbols by their package relative to `root`.
+    ///
+    /// Files without a language adapter are skipped; files that fail to parse
+    /// are skipped with a warning.
+    pub fn from_files(root: &Path, files: &[PathBuf]) -> Result<Self> {
+        let builder = TypeGraphBuilder::collect(root, files);
+        let type_edges = builder.edges();
+        let mut edges = BTreeSet::new();
+
+        for edge in type_edges.iter().filter(|e| e.kind == TypeEdgeKind::Calls) {
+            edges.insert(SymbolEdge {
+                source: edge.from.clone(),
+                target: edge.to.clone(),
+                kind: SymbolEdgeKind::Calls,
+                interface: None,
+            });
+        }
+
+        for (from, names) in &builder.embedded {
+            let package = &builder.nodes[from].package;
+            for name in names {
+                let name = name.trim_start_matches('*');
+                if let Some(to) = builder.resolve_type(package, name) {
+                    if to != *from {
+                        edges.insert(SymbolEdge {
+                            source: from.clone(),
+                            target: to,
+                            kind: SymbolEdgeKind::Embeds,
+                            interface: None,
+                        });
+                    }
+                }
+            }
+        }
+
+        let satisfies: BTreeSet<(&str, &str)> = type_edges
+            .iter()
+            .filter(|e| e.kind == TypeEdgeKind::Satisfies)
+            .map(|e| (e.from.as_str(), e.to.as_str()))
+            .collect();
+        for variable in &builder.variables {
+            let Some(interface) = variable
+                .var_type
+                .as_deref()
+                .and_then(|name| builder.resolve_type(&variable.package, name))
+                .filter(|id| builder.nodes[id].kind == TypeNodeKind::Interface)
+            else {
+                continue;
+            };
+            let Some(concrete) = builder.concrete_type(variable) else {
+                continue;
+            };
+            if satisfies.contains(&(concrete.as_str(), interface.as_str())) {
+                edges.insert(SymbolEdge {
+                    source: variable.id.clone(),
+                    target: concrete,
+                    kind: SymbolEdgeKind::Assigns,
+                    interface: Some(interface),
+                });
+            }
+        }
+
+        Ok(Self { edges })
+    }
+
+    /// Whether no edges were found.
+    pub fn is_empty(&self) -> bool {
+        self.edges.is_empty()
+    }
+
+    /// Edges leaving `symbol`.
+    pub fn outgoing<'a>(&'a self, symbol: &'a str) -> impl Iterator<Item = &'a SymbolEdge> {
+        self.edges.iter().filter(move |edge| edge.source == symbol)
+    }
+
+    /// Edges arriving at `symbol`.
+    pub fn incoming<'a>(&'a self, symbol: &'a str) -> impl Iterator<Item = &'a SymbolEdge> {
+        self.edges.iter().filter(move |edge| edge.target == symbol)
+    }
+
+    /// Render the edges as a pretty-printed JSON array.
+    pub fn to_json(&self) -> Result<String> {
+        serde_json::to_string_pretty(&self.edges)
+            .map_err(|e| ValknutError::internal(format!("Failed to serialize symbol graph: {e}")))
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use std::fs;
+    use tempfile::tempdir;
+
+    fn write(root: &Path, relative: &str, contents: &str) -> PathBuf {
+        let path = root.join(relative);
+        fs::create_dir_all(path.parent().unwrap()).unwrap();
+        fs::write(&path, contents).unwrap();
+        path
+    }
+
+    fn sample_graph() -> SymbolGraph {
+        let tmp = tempdir().unwrap();
+        let root = tmp.path();
+        let files = vec![
+            write(
+                root,
+                "store/store.go",
+                "package store\n\ntype Getter interface {\n\tGet(key string) string\n}\n\ntype Store interface {\n\tGetter\n\tPut(key, value string)\n}\n\ntype Base struct {\n\tname string\n}\n\ntype Memory struct {\n\t*Base\n\titems map[string]string\n}\n\nfunc (m *Memory) Get(key string) string {\n\treturn m.items[key]\n}\n\nfunc (m *Memory) Put(key, value string) {\n\tm.items[key] = value\n}\n\nfunc NewMemory() *Memory {\n\treturn &Memory{}\n}\n\nvar _ Store = (*Memory)(nil)\n",
+            ),
+            write(
+                root,
+                "api/server.go",
+                "package api\n\nimport \"example.com/app/store\"\n\nvar defaultStore store.Store = store.NewMemory()\n\nvar cache store.Getter = &store.Memory{}\n\nvar name string = \"api\"\n\nfunc Lookup(key string) string {\n\tvar local store.Store = store.NewMemory()\n\treturn local.Get(key)\n}\n",
+            ),
+        ];
+        SymbolGraph::from_files(root, &files).unwrap()
+    }
+
+    fn has_edge(graph: &SymbolGraph, source: &str, target: &str, kind: SymbolEdgeKind) -> bool {
+        graph
+            .edges
+            .iter()
+            .any(|edge| edge.source == source && edge.target == target && edge.kind == kind)
+    }
+
+    #[test]
+    fn records_calls_and_embedding() {
+        let graph = sample_graph();
+
+        assert!(has_edge(
+            &graph,
+            "api.Lookup",
+            "store.NewMemory",
+            SymbolEdgeKind::Calls
+        ));
+        assert!(has_edge(
+            &graph,
+            "store.Memory",
+            "store.Base",
+            SymbolEdgeKind::Embeds
+        ));
+        assert!(has_edge(
+            &graph,
+            "store.Store",
+            "store.Getter",
+            SymbolEdgeKind::Embeds
+        ));
+    }
+
+    #[test]
+    fn records_interface_variables_assigned_concrete_types() {
+        let graph = sample_graph();
+
+        for variable in [
+            "store._",
+            "api.defaultStore",
+            "api.cache",
+            "api.Lookup.local",
+        ] {
+            assert!(
+                has_edge(&graph, variable, "store.Memory", SymbolEdgeKind::Assigns),
+                "missing assigns edge from {variable}"
+            );
+        }
+        let interfaces: Vec<_> = graph
+            .outgoing("api.cache")
+            .filter_map(|edge| edge.interface.as_deref())
+            .collect();
+        assert_eq!(interfaces, vec!["store.Getter"]);
+        assert_eq!(graph.outgoing("api.name").count(), 0);
+        assert_eq!(
+            graph
+                .incoming("store.Memory")
+                .filter(|edge| edge.kind == SymbolEdgeKind::Assigns)
+                .count(),
+            4
+        );
+    }
+
+    #[test]
+    fn serializes_as_a_sorted_edge_list() {
+        let json: serde_json::Value =
+            serde_json::from_str(&sample_graph().to_json().unwrap()).unwrap();
+        let edges = json.as_array().expect("edge list");
+        assert!(!edges.is_empty());
+        assert!(edges.iter().all(|edge| edge["source"].is_string()
+            && edge["target"].is_string()
+            && edge["kind"].is_string()));
+        assert_eq!(
+            sample_graph().to_json().unwrap(),
+            sample_graph().to_json().unwrap()
+        );
+    }
+}
@@ -0,0 +1,780 @@
+//! Type hierarchy graphs for Graphviz export.
+//!
+//! [`TypeGraph::from_files`] parses each file with its language adapter and
+//! records three kinds of relationships between the declared types and
+//! functions:
+//!
+//! - **contains**: a struct or class has a field of another declared type
+//!   (taken from the `field_types` entity metadata);
+//! - **satisfies**: a concrete type declares every method an interface
+//!   requires (Go-style structural satisfaction, matched by method name);
+//! - **calls**: a function or method calls another declared function or method.
+//!
+//! Every node belongs to a package, the directory of its file relative to the
+//! project root, and the DOT rendering clusters nodes by package. Nodes and
+//! edges are kept in sorted collections so the output is byte-for-byte stable
+//! across runs and can be diffed in version control.
+
+use std::collections::{BTreeMap, BTreeSet, HashMap};
+use std::fmt::Write as _;
+use std::path::{Path, PathBuf};
+
+use rayon::prelude::*;
+use serde::{Deserialize, Serialize};
+use tracing::warn;
+
+use super::escape_dot;
+use crate::core::errors::Result;
+use crate::core::file_utils::FileReader;
+use crate::lang::registry::adapter_for_file;
+use crate::lang::{EntityKind, ParseIndex, ParsedEntity};
+
+/// What a [`TypeNode`] declares.
+#[derive(Debug, Clone, Copy, PartialEq, Eq, PartialOrd, Ord, Serialize, Deserialize)]
+#[serde(rename_all = "snake_case")]
+pub enum TypeNodeKind {
+    /// A struct or class.
+    Struct,
+    /// An interface or trait-like contract.
+    Interface,
+    /// A free function.
+    Function,
+    /// A method attached to a type.
+    Method,
+}
+
+/// A declared type or function.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct TypeNode {
+    /// Stable identifier: `<package>.<name>`, or `<package>.<Type>.<method>` for methods.
+    pub id: String,
+    /// Declared name.
+    pub name: String,
+    /// What the node declares.
+    pub kind: TypeNodeKind,
+    /// Directory of the declaring file relative to the project root (`.` for the root).
+    pub package: String,
+    /// Declaring file relative to the project root.
+    pub file: String,
+}
+
+/// Relationship represented by a [`TypeEdge`].
+#[derive(Debug, Clone, Copy, PartialEq, Eq, PartialOrd, Ord, Serialize, Deserialize)]
+#[serde(rename_all = "snake_case")]
+pub enum TypeEdgeKind {
+    /// The source type has a field of the target type.
+    Contains,
+    /// The source type implements every method of the target interface.
+    Satisfies,
+    /// The source function or method calls the target.
+    Calls,
+}
+
+/// A directed relationship between two nodes.
+#[derive(Debug, Clone, PartialEq, Eq, PartialOrd, Ord, Serialize, Deserialize)]
+pub struct TypeEdge {
+    /// Identifier of the source node.
+    pub from: String,
+    /// Identifier of the target node.
+    pub to: String,
+    /// Relationship kind.
+    pub kind: TypeEdgeKind,
+}
+
+/// Types, functions, and the relationships between them.
+#[derive(Debug, Clone, Default, PartialEq, Eq, Serialize, Deserialize)]
+pub struct TypeGraph {
+    /// Nodes keyed by identifier.
+    pub nodes: BTreeMap<String, TypeNode>,
+    /// Edges in sorted order.
+    pub edges: BTreeSet<TypeEdge>,
+}
+
+/// Construction, pruning, and rendering methods for [`TypeGraph`].
+impl TypeGraph {
+    /// Build the graph for `files`, reporting paths relative to `root`.
+    ///
+    /// Files without a language adapter are skipped; files that fail to parse
+    /// are skipped with a warning.
+    pub fn from_files(root: &Path, files: &[PathBuf]) -> Result<Self> {
+        Ok(TypeGraphBuilder::collect(root, files).finish())
+    }
+
+    /// Keep only the `max_nodes` nodes with the most edges, and the edges between them.
+    ///
+    /// Ties are broken by node identifier so pruning is deterministic.
+    pub fn prune_to_most_connected(&mut self, max_nodes: usize) {
+        if self.nodes.len() <= max_nodes {
+            return;
+        }
+
+        let mut degree: HashMap<&str, usize> = HashMap::new();
+        for edge in &self.edges {
+            *degree.entry(edge.from.as_str()).or_default() += 1;
+            *degree.entry(edge.to.as_str()).or_default() += 1;
+        }
+        let mut ranked: Vec<&str> = self.nodes.keys().map(String::as_str).collect();
+        ranked.sort_by(|a, b| {
+            let degree_of = |id: &str| degree.get(id).copied().unwrap_or(0);
+            degree_of(b).cmp(&degree_of(a)).then_with(|| a.cmp(b))
+        });
+        let keep: BTreeSet<String> = ranked
+            .into_iter()
+            .take(max_nodes)
+            .map(str::to_string)
+            .collect();
+
+        self.nodes.retain(|id, _| keep.contains(id));
+        self.edges
+            .retain(|edge| keep.contains(&edge.from) && keep.contains(&edge.to));
+    }
+
+    /// Render the graph in Graphviz DOT format, clustering nodes by package.
+    ///
+    /// Structs are boxes, interfaces dashed rounded boxes, and functions
+    /// ellipses. `contains` edges end in a diamond, `satisfies` edges are
+    /// dashed with a hollow arrow, and `calls` edges are grey.
+    pub fn to_dot(&self) -> String {
+        let mut packages: BTreeMap<&str, Vec<&TypeNode>> = BTreeMap::new();
+        for node in self.nodes.values() {
+            packages
+                .entry(node.package.as_str())
+                .or_default()
+                .push(node);
+        }
+
+        let mut dot = String::from("digraph types {\n");
+        dot.push_str("  rankdir=LR;\n");
+        dot.push_str("  compound=true;\n");
+        for (index, (package, nodes)) in packages.iter().enumerate() {
+            let _ = writeln!(dot, "  subgraph \"cluster_{index}\" {{");
+            let _ = writeln!(dot, "    label=\"{}\";", escape_dot(package));
+            for node in nodes {
+                let shape = match node.kind {
+                    TypeNodeKind::Struct => "shape=box",
+                    TypeNodeKind::Interface => "shape=box, style=\"rounded,dashed\"",
+                    TypeNodeKind::Function | TypeNodeKind::Method => "shape=ellipse",
+                };
+                let label = match node.kind {
+                    TypeNodeKind::Method => node
+                        .id
+                        .strip_prefix(&format!("{}.", node.package))
+                        .unwrap_or(&node.name),
+                    _ => &node.name,
+                };
+                let _ = writeln!(
+                    dot,
+                    "    \"{}\" [label=\"{}\", {}];",
+                    escape_dot(&node.id),
+                    escape_dot(label),
+                    shape
+                );
+            }
+            dot.push_str("  }\n");
+        }
+
+        for edge in &self.edges {
+            let style = match edge.kind {
+                TypeEdgeKind::Contains => "arrowhead=diamond",
+                TypeEdgeKind::Satisfies => "style=dashed, arrowhead=empty",
+                TypeEdgeKind::Calls => "color=gray40",
+            };
+            let _ = writeln!(
+                dot,
+                "  \"{}\" -> \"{}\" [{}];",
+                escape_dot(&edge.from),
+                escape_dot(&edge.to),
+                style
+            );
+        }
+
+        dot.push_str("}\n");
+        dot
+    }
+}
+
+/// Raw declarations gathered from parsed files before edges are resolved.
+///
+/// Shared with [`SymbolGraph`](super::SymbolGraph), which resolves the
+/// embedding and variable declarations the type graph does not draw.
+#[derive(Debug, Default)]
+pub(super) struct TypeGraphBuilder {
+    pub(super) nodes: BTreeMap<String, TypeNode>,
+    /// Struct id -> type names used by its fields.
+    field_types: BTreeMap<String, Vec<String>>,
+    /// Interface id -> required method names and embedded interface names.
+    interfaces: BTreeMap<String, (BTreeSet<String>, Vec<String>)>,
+    /// Concrete type id -> names of its methods.
+    pub(super) method_sets: BTreeMap<String, BTreeSet<String>>,
+    /// Function or method id -> raw call expressions.
+    calls: BTreeMap<String, Vec<String>>,
+    /// Struct or interface id -> names of the types it embeds.
+    pub(super) embedded: BTreeMap<String, Vec<String>>,
+    /// Function or method id -> declared result types.
+    pub(super) return_types: BTreeMap<String, Vec<String>>,
+    /// `var` declarations, in file and source order.
+    pub(super) variables: Vec<VariableDecl>,
+    /// `(last package segment, name)` -> node ids in sorted order, for
+    /// qualified references such as `store.Memory`.
+    symbols: HashMap<(String, String), Vec<String>>,
+}
+
+/// A `var` declaration and what its initial value says about its type.
+#[derive(Debug, Clone)]
+pub(super) struct VariableDecl {
+    /// `<package>.<name>`, or `<function id>.<name>` inside a function body.
+    pub(super) id: String,
+    /// Package of the declaring file.
+    pub(super) package: String,
+    /// Declaring file relative to the project root.
+    pub(super) file: String,
+    /// Line of the declaration (1-based).
+    pub(super) line: usize,
+    /// Declared type, as written.
+    pub(super) var_type: Option<String>,
+    /// Concrete type of a literal or conversion value, as written.
+    pub(super) value_type: Option<String>,
+    /// Callee of a call value, as written.
+    pub(super) value_call: Option<String>,
+}
+
+/// Incremental construction methods for [`TypeGraphBuilder`].
+impl TypeGraphBuilder {
+    /// Parse `files` (sorted and deduplicated) and record their declarations.
+    pub(super) fn collect(root: &Path, files: &[PathBuf]) -> Self {
+        let mut builder = Self::default();
+        let mut files = files.to_vec();
+        files.sort();
+        files.dedup();
+        for file in &files {
+            builder.add_file(root, file);
+        }
+        builder.index_symbols();
+        builder
+    }
+
+    /// Index every node under its package's last path segment and its name.
+    fn index_symbols(&mut self) {
+        for node in self.nodes.values() {
+            let segment = node.package.rsplit('/').next().unwrap_or(&node.package);
+            self.symbols
+                .entry((segment.to_string(), node.name.clone()))
+                .or_default()
+                .push(node.id.clone());
+        }
+    }
+
+    /// Parse one file and record its declarations.
+    fn add_file(&mut self, root: &Path, path: &Path) {
+        let Ok(mut adapter) = adapter_for_file(path) else {
+            return;
+        };
+        let source = match FileReader::read_to_string(path) {
+            Ok(source) => source,
+            Err(err) => {
+                warn!("Skipping {}: {}", path.display(), err);
+                return;
+            }
+        };
+        let index = match adapter.parse_source(&source, &path.to_string_lossy()) {
+            Ok(index) => index,
+            Err(err) => {
+                warn!("Failed to parse {}: {}", path.display(), err);
+                return;
+            }
+        };
+
+        let file = relative_path(root, path);
+        let package = match file.rsplit_once('/') {
+            Some((dir, _)) => dir.to_string(),
+            None => ".".to_string(),
+        };
+
+        let mut entities: Vec<&ParsedEntity> = index.entities.values().collect();
+        entities.sort_by(|a, b| a.id.cmp(&b.id));
+        for entity in entities {
+            if entity.kind == EntityKind::Variable {
+                let scope = enclosing_function(&index, entity);
+                self.add_entity(entity, scope, &package, &file);
+                continue;
+            }
+            let owner = entity
+                .metadata
+                .get("receiver_base_type")
+                .and_then(|value| value.as_str())
+                .map(str::to_string)
+                .or_else(|| {
+                    let parent = index.entities.get(entity.parent.as_deref()?)?;
+                    matches!(parent.kind, EntityKind::Class | EntityKind::Struct)
+                        .then(|| parent.name.clone())
+                });
+            self.add_entity(entity, owner, &package, &file);
+        }
+    }
+
+    /// Record a single entity; `owner` is the receiver or enclosing type of
+    /// methods, or the enclosing function of variables.
+    fn add_entity(
+        &mut self,
+        entity: &ParsedEntity,
+        owner: Option<String>,
+        package: &str,
+        file: &str,
+    ) {
+        let strings = |key: &str| -> Vec<String> {
+            entity
+                .metadata
+                .get(key)
+                .and_then(|value| serde_json::from_value(value.clone()).ok())
+                .unwrap_or_default()
+        };
+
+        let (id, kind) = match (entity.kind, owner) {
+            (EntityKind::Struct | EntityKind::Class, _) => {
+                let id = format!("{package}.{}", entity.name);
+                self.field_types.insert(id.clone(), strings("field_types"));
+                self.embedded.insert(id.clone(), strings("embedded_types"));
+                (id, TypeNodeKind::Struct)
+            }
+            (EntityKind::Interface, _) => {
+                let id = format!("{package}.{}", entity.name);
+                let methods = strings("methods").into_iter().collect();
+                let embedded = strings("embedded_interfaces");
+                self.embedded.insert(id.clone(), embedded.clone());
+                self.interfaces.insert(id.clone(), (methods, embedded));
+                (id, TypeNodeKind::Interface)
+            }
+            (EntityKind::Variable, scope) => {
+                let text = |key: &str| {
+                    entity
+                        .metadata
+                        .get(key)
+                        .and_then(|value| value.as_str())
+                        .map(str::to_string)
+                };
+                let id = match scope {
+                    Some(scope) => format!("{package}.{scope}.{}", entity.name),
+                    None => format!("{package}.{}", entity.name),
+                };
+                self.variables.push(VariableDecl {
+                    id,
+                    package: package.to_string(),
+                    file: file.to_string(),
+                    line: entity.location.start_line,
+                    var_type: text("var_type"),
+                    value_type: text("value_type"),
+                    value_call: text("value_call"),
+                });
+                return;
+            }
+            (EntityKind::Method | EntityKind::Function, Some(owner)) => {
+                self.method_sets
+                    .entry(format!("{package}.{owner}"))
+                    .or_default()
+                    .insert(entity.name.clone());
+                let id = format!("{package}.{owner}.{}", entity.name);
+                self.calls.insert(id.clone(), strings("function_calls"));
+                self.return_types
+                    .insert(id.clone(), strings("return_types"));
+                (id, TypeNodeKind::Method)
+            }
+            (EntityKind::Function, None) => {
+                let id = format!("{package}.{}", entity.name);
+                self.calls.insert(id.clone(), strings("function_calls"));
+                self.return_types
+                    .insert(id.clone(), strings("return_types"));
+                (id, TypeNodeKind::Function)
+            }
+            _ => return,
+        };
+
+        self.nodes.entry(id.clone()).or_insert_with(|| TypeNode {
+            id,
+            name: entity.name.clone(),
+            kind,
+            package: package.to_string(),
+            file: file.to_string(),
+        });
+    }
+
+    /// Resolve recorded references into the type graph.
+    fn finish(self) -> TypeGraph {
+        let edges = self.edges();
+        TypeGraph {
+            nodes: self.nodes,
+            edges,
+        }
+    }
+
+    /// Resolve recorded references into edges.
+    ///
+    /// Runs once every file is collected, so the nodes and symbol index no
+    /// longer change: the declarations of each kind are resolved in parallel
+    /// on the rayon pool, with all workers reading the builder through a
+    /// shared borrow.
+    pub(super) fn edges(&self) -> BTreeSet<TypeEdge> {
+        let contains = self.field_types.par_iter().flat_map_iter(|(from, types)| {
+            let package = &self.nodes[from].package;
+            types
+                .iter()
+                .filter_map(move |name| self.resolve_type(package, name))
+                .filter(move |to| to != from)
+                .map(move |to| TypeEdge {
+                    from: from.clone(),
+                    to,
+                    kind: TypeEdgeKind::Contains,
+                })
+        });
+
+        let satisfies = self.interfaces.par_iter().flat_map_iter(|(interface, _)| {
+            let required = self.required_methods(interface, &mut BTreeSet::new());
+            self.method_sets
+                .iter()
+                .filter(move |(concrete, methods)| {
+                    let is_concrete = self
+                        .nodes
+                        .get(*concrete)
+                        .is_some_and(|node| node.kind == TypeNodeKind::Struct);
+                    is_concrete && !required.is_empty() && required.is_subset(methods)
+                })
+                .map(move |(concrete, _)| TypeEdge {
+                    from: concrete.clone(),
+                    to: interface.clone(),
+                    kind: TypeEdgeKind::Satisfies,
+                })
+        });
+
+        let mut methods_by_name: HashMap<&str, Vec<&str>> = HashMap::new();
+        for node in self.nodes.values() {
+            if node.kind == TypeNodeKind::Method {
+                methods_by_name
+                    .entry(node.name.as_str())
+                    .or_default()
+                    .push(node.id.as_str());
+            }
+        }
+        let methods_by_name = &methods_by_name;
+        let calls = self.calls.par_iter().flat_map_iter(|(from, calls)| {
+            let package = &self.nodes[from].package;
+            calls
+                .iter()
+                .filter_map(move |call| self.resolve_call(package, call, methods_by_name))
+                .filter(move |to| to != from)
+                .map(move |to| TypeEdge {
+                    from: from.clone(),
+                    to,
+                    kind: TypeEdgeKind::Calls,
+                })
+        });
+
+        contains.chain(satisfies).chain(calls).collect()
+    }
+
+    /// Methods an interface requires, including those of embedded interfaces.
+    pub(super) fn required_methods(
+        &self,
+        interface: &str,
+        visited: &mut BTreeSet<String>,
+    ) -> BTreeSet<String> {
+        if !visited.insert(interface.to_string()) {
+            return BTreeSet::new();
+        }
+        let Some((methods, embedded)) = self.interfaces.get(interface) else {
+            return BTreeSet::new();
+        };
+        let package = &self.nodes[interface].package;
+        let mut required = methods.clone();
+        for name in embedded {
+            if let Some(inner) = self.resolve_type(package, name) {
+                required.extend(self.required_methods(&inner, visited));
+            }
+        }
+        required
+    }
+
+    /// Node id of a type referenced as `Name` or `pkg.Name` from `package`.
+    pub(super) fn resolve_type(&self, package: &str, name: &str) -> Option<String> {
+        match name.rsplit_once('.') {
+            Some((qualifier, base)) => self.find_in_package_named(qualifier, base, |kind| {
+                matches!(kind, TypeNodeKind::Struct | TypeNodeKind::Interface)
+            }),
+            None => {
+                let id = format!("{package}.{name}");
+                self.nodes
+                    .get(&id)
+                    .filter(|node| {
+                        matches!(node.kind, TypeNodeKind::Struct | TypeNodeKind::Interface)
+                    })
+                    .map(|node| node.id.clone())
+            }
+        }
+    }
+
+    /// Declared struct a variable's initial value has, if it can be told
+    /// from the value or the first result type of the function it calls.
+    pub(super) fn concrete_type(&self, variable: &VariableDecl) -> Option<String> {
+        let (package, type_name) = match (&variable.value_type, &variable.value_call) {
+            (Some(value_type), _) => (variable.package.as_str(), value_type.as_str()),
+            (None, Some(call)) => {
+                let function = self.resolve_function(&variable.package, call)?;
+                let result = self.return_types.get(&function)?.first()?;
+                (self.nodes[&function].package.as_str(), result.as_str())
+            }
+            (None, None) => return None,
+        };
+        self.resolve_type(package, type_name.trim_start_matches('*'))
+            .filter(|id| self.nodes[id].kind == TypeNodeKind::Struct)
+    }
+
+    /// Node id of the free function a call expression (`New`, `store.New`) refers to.
+    pub(super) fn resolve_function(&self, package: &str, call: &str) -> Option<String> {
+        self.resolve_call(package, call, &HashMap::new())
+    }
+
+    /// Node id of the function or method a call expression refers to.
+    ///
+    /// Bare names resolve within the caller's package and `pkg.Func` within a
+    /// package of that name; other selectors (`value.Method`) resolve only when
+    /// exactly one method in the graph has that name.
+    fn resolve_call(
+        &self,
+        package: &str,
+        call: &str,
+        methods_by_name: &HashMap<&str, Vec<&str>>,
+    ) -> Option<String> {
+        let call = call.split('(').next().unwrap_or(call).trim();
+        match call.rsplit_once('.') {
+            None => {
+                let id = format!("{package}.{call}");
+                self.nodes
+                    .get(&id)
+                    .filter(|node| node.kind == TypeNodeKind::Function)
+                    .map(|node| node.id.clone())
+            }
+            Some((qualifier, base)) => {
+                let qualifier = qualifier.rsplit('.').next().unwrap_or(qualifier);
+                self.find_in_package_named(qualifier, base, |kind| kind == TypeNodeKind::Function)
+                    .or_else(|| match methods_by_name.get(base).map(Vec::as_slice) {
+                        Some([only]) => Some(only.to_string()),
+                        _ => None,
+                    })
+            }
+        }
+    }
+
+    /// Find `name` in the first package (in sorted order) whose last path segment is `qualifier`.
+    fn find_in_package_named(
+        &self,
+        qualifier: &str,
+        name: &str,
+        accept: impl Fn(TypeNodeKind) -> bool,
+    ) -> Option<String> {
+        self.symbols
+            .get(&(qualifier.to_string(), name.to_string()))?
+            .iter()
+            .find(|id| accept(self.nodes[*id].kind))
+            .cloned()
+    }
+}
+
+/// Name of the function or method (`Type.method`) whose body declares `entity`.
+fn enclosing_function(index: &ParseIndex, entity: &ParsedEntity) -> Option<String> {
+    let parent = index.entities.get(entity.parent.as_deref()?)?;
+    if !matches!(parent.kind, EntityKind::Function | EntityKind::Method) {
+        return None;
+    }
+    let receiver = parent
+        .metadata
+        .get("receiver_base_type")
+        .and_then(|value| value.as_str());
+    Some(match receiver {
+        Some(receiver) => format!("{receiver}.{}", parent.name),
+        None => parent.name.clone(),
+    })
+}
+
+/// `path` relative to `root` with `/` separators.
+fn relative_path(root: &Path, path: &Path) -> String {
+    path.strip_prefix(root)
+        .unwrap_or(path)
+        .components()
+        .map(|c| c.as_os_str().to_string_lossy())
+        .collect::<Vec<_>>()
+        .join("/")
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use std::fs;
+    use tempfile::tempdir;
+
+    fn write(root: &Path, relative: &str, contents: &str) -> PathBuf {
+        let path = root.join(relative);
+        fs::create_dir_all(path.parent().unwrap()).unwrap();
+        fs::write(&path, contents).unwrap();
+        path
+    }
+
+    fn sample_graph() -> TypeGraph {
+        let tmp = tempdir().unwrap();
+        let root = tmp.path();
+        let files = vec![
+            write(
+                root,
+                "store/store.go",
+                "package store\n\ntype Store interface {\n\tGet(key string) string\n}\n\ntype Memory struct {\n\titems map[string]string\n}\n\nfunc (m *Memory) Get(key string) string {\n\treturn m.items[key]\n}\n\nfunc NewMemory() *Memory {\n\treturn &Memory{}\n}\n",
+            ),
+            write(
+                root,
+                "api/server.go",
+                "package api\n\nimport \"example.com/app/store\"\n\ntype Server struct {\n\tstore store.Store\n\tcache *store.Memory\n}\n\nfunc (s *Server) Lookup(key string) string {\n\treturn s.store.Get(key)\n}\n\nfunc New() *Server {\n\treturn &Server{cache: store.NewMemory()}\n}\n",
+            ),
+        ];
+        TypeGraph::from_files(root, &files).unwrap()
+    }
+
+    fn has_edge(graph: &TypeGraph, from: &str, to: &str, kind: TypeEdgeKind) -> bool {
+        graph.edges.contains(&TypeEdge {
+            from: from.to_string(),
+            to: to.to_string(),
+            kind,
+        })
+    }
+
+    #[test]
+    fn records_containment_satisfaction_and_calls() {
+        let graph = sample_graph();
+
+        assert_eq!(graph.nodes["api.Server"].kind, TypeNodeKind::Struct);
+        assert_eq!(graph.nodes["store.Store"].kind, TypeNodeKind::Interface);
+        assert_eq!(graph.nodes["api.Server.Lookup"].kind, TypeNodeKind::Method);
+
+        assert!(has_edge(
+            &graph,
+            "api.Server",
+            "store.Store",
+            TypeEdgeKind::Contains
+        ));
+        assert!(has_edge(
+            &graph,
+            "api.Server",
+            "store.Memory",
+            TypeEdgeKind::Contains
+        ));
+        assert!(has_edge(
+            &graph,
+            "store.Memory",
+            "store.Store",
+            TypeEdgeKind::Satisfies
+        ));
+        assert!(!has_edge(
+            &graph,
+            "api.Server",
+            "store.Store",
+            TypeEdgeKind::Satisfies
+        ));
+        assert!(has_edge(
+            &graph,
+            "api.New",
+            "store.NewMemory",
+            TypeEdgeKind::Calls
+        ));
+        assert!(has_edge(
+            &graph,
+            "api.Server.Lookup",
+            "store.Memory.Get",
+            TypeEdgeKind::Calls
+        ));
+    }
+
+    #[test]
+    fn dot_output_is_clustered_and_deterministic() {
+        let dot = sample_graph().to_dot();
+
+        assert!(dot.starts_with("digraph types {"));
+        assert!(dot.contains("subgraph \"cluster_0\" {\n    label=\"api\";"));
+        assert!(dot.contains("subgraph \"cluster_1\" {\n    label=\"store\";"));
+        assert!(
+            dot.contains("\"store.Memory\" -> \"store.Store\" [style=dashed, arrowhead=empty];")
+        );
+        assert!(dot.contains("\"api.Server.Lookup\" [label=\"Server.Lookup\", shape=ellipse];"));
+        assert_eq!(dot, sample_graph().to_dot());
+    }
+
+    #[test]
+    fn resolution_does_not_depend_on_the_thread_count() {
+        let tmp = tempdir().unwrap();
+        let root = tmp.path();
+        let mut files = vec![
+            write(
+                root,
+                "a/util/util.go",
+                "package util\n\ntype Helper struct{}\n",
+            ),
+            write(
+                root,
+                "b/util/util.go",
+                "package util\n\ntype Helper struct{}\n",
+            ),
+        ];
+        for i in 1..=6 {
+            files.push(write(
+                root,
+                &format!("pkg{i}/service.go"),
+                &format!(
+                    "package pkg{i}\n\nimport \"example.com/app/util\"\n\ntype Service struct {{\n\tprev *pkg{}.Service\n\thelper util.Helper\n}}\n",
+                    i - 1
+                ),
+            ));
+        }
+        let build = |threads: usize| {
+            rayon::ThreadPoolBuilder::new()
+                .num_threads(threads)
+                .build()
+                .unwrap()
+                .install(|| TypeGraph::from_files(root, &files).unwrap())
+        };
+
+        let graph = build(1);
+        assert_eq!(graph, build(4));
+        assert!(has_edge(
+            &graph,
+            "pkg2.Service",
+            "pkg1.Service",
+            TypeEdgeKind::Contains
+        ));
+        // A qualifier shared by two packages resolves to the first in sorted order.
+        assert!(has_edge(
+            &graph,
+            "pkg2.Service",
+            "a/util.Helper",
+            TypeEdgeKind::Contains
+        ));
+        assert!(!has_edge(
+            &graph,
+            "pkg2.Service",
+            "b/util.Helper",
+            TypeEdgeKind::Contains
+        ));
+    }
+
+    #[test]
+    fn pruning_keeps_the_most_connected_nodes() {
+        let mut graph = sample_graph();
+        // Server, Memory and Store all have two edges; ties go to the smaller id.
+        graph.prune_to_most_connected(2);
+
+        assert_eq!(
+            graph.nodes.keys().cloned().collect::<Vec<_>>(),
+            vec!["api.Server".to_string(), "store.Memory".to_string()]
+        );
+        assert!(
+            graph
+                .edges
+                .iter()
+                .all(|edge| graph.nodes.contains_key(&edge.from)
+                    && graph.nodes.contains_key(&edge.to))
+        );
+    }
+}
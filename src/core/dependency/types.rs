@@ -3,8 +3,11 @@
 //! This module contains the core data structures for representing
 //! function nodes, dependency metrics, and module graphs.
 
+use std::collections::{BTreeMap, BTreeSet, VecDeque};
 use std::path::{Path, PathBuf};
 
+use serde::{Deserialize, Serialize};
+
 /// A function or method node in the dependency graph.
 #[derive(Debug, Clone)]
 pub struct FunctionNode {
@@ -163,3 +166,63 @@ pub struct ModuleGraphEdge {
     /// Number of function calls from source to target.
     pub weight: usize,
 }
+
+/// Function-level call graph serialized as an adjacency list.
+///
+/// Produced by [`ProjectDependencyAnalysis::call_graph`](super::ProjectDependencyAnalysis::call_graph)
+/// when `--call-graph` is requested.
+#[derive(Debug, Clone, Default, PartialEq, Serialize, Deserialize)]
+pub struct CallGraph {
+    /// Maximum traversal depth from entry points (`None` = unbounded).
+    pub max_depth: Option<usize>,
+    /// Whether the depth limit cut off part of the reachable graph.
+    pub truncated: bool,
+    /// Function nodes keyed by their unique identifier.
+    pub nodes: BTreeMap<String, CallGraphNode>,
+    /// Caller identifier mapped to the identifiers of the functions it calls.
+    pub adjacency: BTreeMap<String, Vec<String>>,
+}
+
+/// A function node in the serialized call graph.
+#[derive(Debug, Clone, PartialEq, Serialize, Deserialize)]
+pub struct CallGraphNode {
+    /// Simple function name.
+    pub name: String,
+    /// Fully qualified name including receiver/class namespace.
+    pub qualified_name: String,
+    /// Source file path.
+    pub file: String,
+    /// Starting line number in the source file.
+    pub start_line: Option<usize>,
+}
+
+/// Query methods for [`CallGraph`].
+impl CallGraph {
+    /// Returns every function that transitively calls `id`.
+    ///
+    /// This answers "what would break if I change `id`?"; the result is sorted
+    /// and excludes `id` itself unless it is part of a cycle.
+    pub fn callers_of(&self, id: &str) -> Vec<String> {
+        let mut reverse: BTreeMap<&str, Vec<&str>> = BTreeMap::new();
+        for (caller, callees) in &self.adjacency {
+            for callee in callees {
+                reverse
+                    .entry(callee.as_str())
+                    .or_default()
+                    .push(caller.as_str());
+            }
+        }
+
+        let mut seen = BTreeSet::new();
+        let mut queue = VecDeque::from([id]);
+        while let Some(current) = queue.pop_front() {
+            for &caller in reverse.get(current).into_iter().flatten() {
+                if seen.insert(caller) {
+                    queue.push_back(caller);
+                }
+            }
+        }
+
+        seen.into_iter().map(String::from).collect()
+    }
+}
@@ -7,9 +7,13 @@
 use std::io;
 use std::num::{ParseFloatError, ParseIntError};
 use std::str::Utf8Error;
+use std::time::Duration;
 
+use serde::Serialize;
 use thiserror::Error;
 
+use crate::core::config::duration::format_duration;
+
 /// Main result type for valknut operations.
 pub type Result<T> = std::result::Result<T, ValknutError>;
 
@@ -168,6 +172,35 @@ pub enum ValknutError {
         /// Error description
         message: String,
     },
+
+    /// No language adapter handles the file
+    #[error("{}", unsupported_language_message(.extension, .file_path))]
+    UnsupportedLanguage {
+        /// File extension without the leading dot (empty when the file has none)
+        extension: String,
+        /// File path that could not be analyzed
+        file_path: Option<String>,
+    },
+
+    /// Analysis of a single file exceeded its time limit
+    #[error("Analysis of {file_path} timed out after {}", format_duration(*.elapsed))]
+    AnalysisTimeout {
+        /// File whose analysis was abandoned
+        file_path: String,
+        /// Time spent before giving up
+        elapsed: Duration,
+    },
+}
+
+/// Display text of [`ValknutError::UnsupportedLanguage`].
+fn unsupported_language_message(extension: &str, file_path: &Option<String>) -> String {
+    match (extension.is_empty(), file_path) {
+        (false, _) => format!("Unsupported language: no adapter for .{extension} files"),
+        (true, Some(path)) => {
+            format!("Unsupported language: could not determine language for file: {path}")
+        }
+        (true, None) => "Unsupported language".to_string(),
+    }
 }
 
 /// Factory methods and context utilities for [`ValknutError`].
@@ -298,6 +331,25 @@ impl ValknutError {
         }
     }
 
+    /// Create an unsupported language error for a file
+    pub fn unsupported_language(
+        extension: impl Into<String>,
+        file_path: impl Into<String>,
+    ) -> Self {
+        Self::UnsupportedLanguage {
+            extension: extension.into(),
+            file_path: Some(file_path.into()),
+        }
+    }
+
+    /// Create an analysis timeout error for a file
+    pub fn analysis_timeout(file_path: impl Into<String>, elapsed: Duration) -> Self {
+        Self::AnalysisTimeout {
+            file_path: file_path.into(),
+            elapsed,
+        }
+    }
+
     /// Add context to an existing error
     pub fn with_context(mut self, context: impl Into<String>) -> Self {
         match &mut self {
@@ -310,6 +362,115 @@ impl ValknutError {
     }
 }
 
+/// Structured accessors for [`ValknutError`].
+///
+/// Errors that reach a caller wrapped in `anyhow::Error` can be recovered with
+/// `error.downcast_ref::<ValknutError>()` before using these.
+impl ValknutError {
+    /// Stable snake_case name of the variant, e.g. `parse` or `analysis_timeout`
+    pub fn kind(&self) -> &'static str {
+        match self {
+            Self::Io { .. } => "io",
+            Self::Config { .. } => "config",
+            Self::Parse { .. } => "parse",
+            Self::Math { .. } => "math",
+            Self::Graph { .. } => "graph",
+            Self::Lsh { .. } => "lsh",
+            Self::Pipeline { .. } => "pipeline",
+            Self::Cache { .. } => "cache",
+            Self::Serialization { .. } => "serialization",
+            Self::Validation { .. } => "validation",
+            Self::ResourceExhaustion { .. } => "resource_exhaustion",
+            Self::Concurrency { .. } => "concurrency",
+            Self::FeatureUnavailable { .. } => "feature_unavailable",
+            Self::Internal { .. } => "internal",
+            Self::Unsupported { .. } => "unsupported",
+            Self::UnsupportedLanguage { .. } => "unsupported_language",
+            Self::AnalysisTimeout { .. } => "analysis_timeout",
+        }
+    }
+
+    /// File the error refers to, if known
+    pub fn file_path(&self) -> Option<&str> {
+        match self {
+            Self::Parse { file_path, .. } | Self::UnsupportedLanguage { file_path, .. } => {
+                file_path.as_deref()
+            }
+            Self::AnalysisTimeout { file_path, .. } => Some(file_path),
+            _ => None,
+        }
+    }
+
+    /// 1-based line of a parse error, if known
+    pub fn line(&self) -> Option<usize> {
+        match self {
+            Self::Parse { line, .. } => *line,
+            _ => None,
+        }
+    }
+
+    /// 1-based column of a parse error, if known
+    pub fn column(&self) -> Option<usize> {
+        match self {
+            Self::Parse { column, .. } => *column,
+            _ => None,
+        }
+    }
+
+    /// Machine-readable summary for JSON responses
+    pub fn details(&self) -> ErrorDetails {
+        let mut details = ErrorDetails {
+            kind: self.kind(),
+            message: self.to_string(),
+            file_path: self.file_path().map(str::to_string),
+            line: self.line(),
+            column: self.column(),
+            language: None,
+            extension: None,
+            elapsed_ms: None,
+        };
+        match self {
+            Self::Parse { language, .. } => details.language = Some(language.clone()),
+            Self::UnsupportedLanguage { extension, .. } => {
+                details.extension = Some(extension.clone())
+            }
+            Self::AnalysisTimeout { elapsed, .. } => {
+                details.elapsed_ms = Some(elapsed.as_millis() as u64)
+            }
+            _ => {}
+        }
+        details
+    }
+}
+
+/// Serializable form of a [`ValknutError`]; fields that do not apply to the
+/// error's kind are omitted.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize)]
+pub struct ErrorDetails {
+    /// Error kind, see [`ValknutError::kind`]
+    pub kind: &'static str,
+    /// Human-readable message
+    pub message: String,
+    /// File the error refers to
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub file_path: Option<String>,
+    /// 1-based line number
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub line: Option<usize>,
+    /// 1-based column number
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub column: Option<usize>,
+    /// Language being parsed
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub language: Option<String>,
+    /// Extension no adapter handles
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub extension: Option<String>,
+    /// Time spent before a timeout, in milliseconds
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub elapsed_ms: Option<u64>,
+}
+
 // Implement From traits for common error types
 
 /// Conversion from [`io::Error`] to [`ValknutError`].
@@ -773,6 +934,51 @@ mod tests {
         assert!(matches!(err, ValknutError::Io { .. }));
     }
 
+    #[test]
+    fn test_structured_accessors_and_details() {
+        let err = ValknutError::parse_with_location(
+            "go",
+            "unexpected token",
+            "pkg/a.go",
+            Some(7),
+            Some(3),
+        );
+        assert_eq!(err.kind(), "parse");
+        assert_eq!(err.file_path(), Some("pkg/a.go"));
+        assert_eq!((err.line(), err.column()), (Some(7), Some(3)));
+        let details = serde_json::to_value(err.details()).unwrap();
+        assert_eq!(details["kind"], "parse");
+        assert_eq!(details["language"], "go");
+        assert_eq!(details["line"], 7);
+        assert!(details.get("elapsed_ms").is_none());
+
+        let err = ValknutError::unsupported_language("zig", "src/main.zig");
+        assert_eq!(err.kind(), "unsupported_language");
+        assert_eq!(err.file_path(), Some("src/main.zig"));
+        assert_eq!(err.line(), None);
+        assert_eq!(
+            err.to_string(),
+            "Unsupported language: no adapter for .zig files"
+        );
+        assert_eq!(err.details().extension.as_deref(), Some("zig"));
+
+        let err = ValknutError::analysis_timeout("big.py", Duration::from_millis(1500));
+        assert_eq!(err.to_string(), "Analysis of big.py timed out after 1500ms");
+        assert_eq!(err.details().elapsed_ms, Some(1500));
+
+        let wrapped = anyhow::Error::from(ValknutError::parse_with_location(
+            "python",
+            "bad indent",
+            "a.py",
+            Some(2),
+            None,
+        ));
+        let inner = wrapped.downcast_ref::<ValknutError>().unwrap();
+        assert_eq!((inner.file_path(), inner.line()), (Some("a.py"), Some(2)));
+
+        assert_eq!(ValknutError::config("x").details().file_path, None);
+    }
+
     #[test]
     fn test_error_display_formatting() {
         let err = ValknutError::parse_with_location(
@@ -343,7 +343,7 @@ pub trait FeatureExtractor: Send + Sync {
 
 /// Simplified entity representation for feature extraction.
 /// This will be expanded when we implement the full AST module.
-#[derive(Debug, Clone, PartialEq)]
+#[derive(Debug, Clone, PartialEq, Serialize, Deserialize)]
 pub struct CodeEntity {
     /// Unique identifier
     pub id: EntityId,
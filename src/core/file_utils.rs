@@ -6,10 +6,10 @@
 //! Coverage file discovery has been moved to the `coverage_discovery` module.
 
 use crate::core::errors::{Result, ValknutError};
-use crate::lang::registry;
+use crate::lang::{notebook, registry};
 use std::fs;
 use std::io::Read;
-use std::path::Path;
+use std::path::{Path, PathBuf};
 use tracing::warn;
 
 // Re-export coverage discovery types for backward compatibility
@@ -32,7 +32,7 @@ impl FileReader {
 
         // Try to read as UTF-8 first
         match fs::read_to_string(file_path) {
-            Ok(content) => Ok(content),
+            Ok(content) => Ok(notebook::analysis_source(file_path, content)),
             Err(e) => {
                 // Check if this is a UTF-8 error by looking at the error kind
                 if e.kind() == std::io::ErrorKind::InvalidData {
@@ -45,7 +45,7 @@ impl FileReader {
                         "File contained invalid UTF-8, converted with lossy encoding: {}",
                         file_path.display()
                     );
-                    Ok(content)
+                    Ok(notebook::analysis_source(file_path, content))
                 } else {
                     Err(ValknutError::io("Failed to read file", e))
                 }
@@ -140,6 +140,13 @@ pub fn ranges_overlap(lhs: (usize, usize), rhs: (usize, usize)) -> bool {
     lhs_start <= rhs_end && rhs_start <= lhs_end
 }
 
+/// `path` relative to `root`, or `path` unchanged when it is outside `root`.
+pub fn relative_to_root(path: &Path, root: &Path) -> PathBuf {
+    path.strip_prefix(root)
+        .map(Path::to_path_buf)
+        .unwrap_or_else(|_| path.to_path_buf())
+}
+
 #[cfg(test)]
 mod tests {
     use super::*;
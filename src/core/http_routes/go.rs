@@ -0,0 +1,1385 @@
+//! Route registrations, handlers and struct types in Go source.
+
+use std::collections::HashMap;
+use std::path::PathBuf;
+
+use tree_sitter::{Node, Tree};
+
+use super::{
+    GoField, GoStruct, HttpRoute, ParameterLocation, ParameterType, RouteParameter, RouteResponse,
+};
+use crate::core::ast_utils::{find_child_by_kind, walk_tree};
+use crate::core::errors::{Result, ValknutError};
+use crate::lang::registry::create_parser_for_language;
+
+/// HTTP methods in upper case.
+const HTTP_METHODS: &[&str] = &[
+    "GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "CONNECT", "OPTIONS", "TRACE",
+];
+
+/// Router packages by import path; versioned paths such as
+/// `github.com/go-chi/chi/v5` match their unversioned prefix.
+const ROUTER_IMPORTS: &[(&str, Router)] = &[
+    ("net/http", Router::NetHttp),
+    ("github.com/go-chi/chi", Router::Chi),
+    ("github.com/gin-gonic/gin", Router::Gin),
+    ("github.com/labstack/echo", Router::Echo),
+    ("github.com/gorilla/mux", Router::Gorilla),
+];
+
+/// Calls returning a router scoped to the path in their first argument.
+const PREFIX_METHODS: &[&str] = &["Group", "PathPrefix", "Route"];
+
+/// Calls returning a router that keeps its receiver's prefix.
+const ROUTER_METHODS: &[&str] = &["Subrouter", "With"];
+
+/// gorilla/mux modifiers that may follow a registration in a call chain.
+const ROUTE_MODIFIERS: &[&str] = &["Methods", "Name", "Schemes", "Headers", "Queries", "Host"];
+
+/// Calls that decode the request body into a pointer argument.
+const BODY_BINDERS: &[&str] = &[
+    "Bind",
+    "BindJSON",
+    "BindXML",
+    "ShouldBind",
+    "ShouldBindJSON",
+    "ShouldBindXML",
+    "ShouldBindWith",
+    "Decode",
+    "DecodeJSON",
+    "Unmarshal",
+];
+
+/// Calls reading the path parameter named by their first argument.
+const PATH_READERS: &[&str] = &["PathValue", "Param"];
+
+/// Calls reading the query parameter named by their first argument.
+const QUERY_READERS: &[&str] = &[
+    "Query",
+    "DefaultQuery",
+    "GetQuery",
+    "QueryArray",
+    "QueryParam",
+    "FormValue",
+];
+
+/// `strconv` parsers and the parameter type their input must have.
+const CONVERSIONS: &[(&str, ParameterType)] = &[
+    ("strconv.Atoi", ParameterType::Integer),
+    ("strconv.ParseInt", ParameterType::Integer),
+    ("strconv.ParseUint", ParameterType::Integer),
+    ("strconv.ParseFloat", ParameterType::Number),
+    ("strconv.ParseBool", ParameterType::Boolean),
+];
+
+/// gorilla/mux path variable patterns that only match integers.
+const INTEGER_PATTERNS: &[&str] = &["[0-9]+", "\\d+"];
+
+/// Router packages whose registrations are recognised.
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+enum Router {
+    NetHttp,
+    Chi,
+    Gin,
+    Echo,
+    Gorilla,
+}
+
+/// A parsed Go file.
+pub(super) struct GoSource {
+    path: PathBuf,
+    source: String,
+    tree: Tree,
+    routers: Vec<Router>,
+}
+
+/// A method call `receiver.name(args...)`.
+struct MethodCall<'t, 's> {
+    name: &'s str,
+    receiver: Node<'t>,
+    args: Vec<Node<'t>>,
+}
+
+/// Handler expression at a registration.
+struct HandlerRef<'t> {
+    /// Expression as written
+    text: String,
+    /// Named functions it refers to or wraps, as `(display, function name)`
+    candidates: Vec<(String, String)>,
+    /// Function literal it is or wraps
+    literal: Option<Node<'t>>,
+}
+
+/// Router prefixes visible while walking a function.
+#[derive(Debug, Clone, Default)]
+struct Scope {
+    /// Prefix of each router variable
+    prefixes: HashMap<String, String>,
+    /// Prefix of routers not otherwise known
+    default_prefix: String,
+}
+
+/// Prefix lookup for [`Scope`].
+impl Scope {
+    /// Prefix of the router held by `name`.
+    fn prefix(&self, name: &str) -> String {
+        self.prefixes
+            .get(name)
+            .cloned()
+            .unwrap_or_else(|| self.default_prefix.clone())
+    }
+}
+
+/// What a handler declaration or function literal tells about its route.
+#[derive(Debug, Clone, Default)]
+pub(super) struct HandlerInfo {
+    annotations: Annotations,
+    parameters: Vec<RouteParameter>,
+    request_type: Option<String>,
+    methods: Vec<String>,
+}
+
+/// Comment annotations of a handler or registration.
+#[derive(Debug, Clone, Default, PartialEq, Eq)]
+struct Annotations {
+    summary: Option<String>,
+    description: Option<String>,
+    tags: Vec<String>,
+    parameters: Vec<RouteParameter>,
+    request_type: Option<String>,
+    responses: Vec<RouteResponse>,
+}
+
+/// A route registration before its handler is resolved.
+pub(super) struct Registration {
+    methods: Vec<String>,
+    path: String,
+    handler_text: Option<String>,
+    candidates: Vec<(String, String)>,
+    inline: Option<HandlerInfo>,
+    pattern_parameters: Vec<RouteParameter>,
+    annotations: Annotations,
+    file_path: PathBuf,
+    line: usize,
+}
+
+/// Parameters, body type and accepted methods collected from a handler body.
+#[derive(Default)]
+struct BodyAnalysis {
+    parameters: Vec<RouteParameter>,
+    request_type: Option<String>,
+    methods: Vec<String>,
+    /// Declared or constructed type of each local variable
+    var_types: HashMap<String, String>,
+    /// Variables holding a pointer to a value of their recorded type
+    pointer_vars: Vec<String>,
+    /// Variables holding `r.URL.Query()`, `mux.Vars(r)` or `r.Header`
+    readers: HashMap<String, ParameterLocation>,
+    /// Variables holding a parameter value
+    parameter_vars: HashMap<String, (String, ParameterLocation)>,
+}
+
+/// Parsing and extraction methods for [`GoSource`].
+impl GoSource {
+    /// Parse `source`, displayed as `path`.
+    pub(super) fn parse(path: PathBuf, source: String) -> Result<Self> {
+        let mut parser = create_parser_for_language("go")?;
+        let tree = parser
+            .parse(&source, None)
+            .ok_or_else(|| ValknutError::parse("go", "Failed to parse Go source"))?;
+        let mut file = Self {
+            path,
+            source,
+            tree,
+            routers: Vec::new(),
+        };
+        file.routers = file.imported_routers();
+        Ok(file)
+    }
+
+    /// Router packages imported by the file.
+    fn imported_routers(&self) -> Vec<Router> {
+        let mut routers = Vec::new();
+        walk_tree(self.tree.root_node(), &mut |node| {
+            if node.kind() != "import_spec" {
+                return;
+            }
+            let Some(path) = node
+                .child_by_field_name("path")
+                .and_then(|path| self.string_literal(path))
+            else {
+                return;
+            };
+            for (import, router) in ROUTER_IMPORTS {
+                let matches = path == *import
+                    || (*router != Router::NetHttp && path.starts_with(&format!("{import}/")));
+                if matches && !routers.contains(router) {
+                    routers.push(*router);
+                }
+            }
+        });
+        routers
+    }
+
+    /// Whether the file imports `router`.
+    fn uses(&self, router: Router) -> bool {
+        self.routers.contains(&router)
+    }
+
+    /// Router constructors mounted with chi `Mount("/path", newRouter())`,
+    /// as `(function name, prefix)`.
+    pub(super) fn mounts(&self) -> Vec<(String, String)> {
+        if !self.uses(Router::Chi) {
+            return Vec::new();
+        }
+        let mut mounts = Vec::new();
+        walk_tree(self.tree.root_node(), &mut |node| {
+            let Some(call) = self.method_call(node) else {
+                return;
+            };
+            if call.name != "Mount" {
+                return;
+            }
+            let (Some(path), Some(target)) = (self.string_arg(&call, 0), call.args.get(1)) else {
+                return;
+            };
+            let constructor = (target.kind() == "call_expression")
+                .then(|| target.child_by_field_name("function"))
+                .flatten();
+            if let Some(function) = constructor {
+                let name = match function.kind() {
+                    "selector_expression" => function.child_by_field_name("field"),
+                    _ => Some(function),
+                };
+                if let Some(name) = name {
+                    mounts.push((self.text(name).to_string(), normalize_path(&path)));
+                }
+            }
+        });
+        mounts
+    }
+
+    /// Every top-level function and method, by name, as a potential handler.
+    pub(super) fn handlers(&self) -> Vec<(String, HandlerInfo)> {
+        let root = self.tree.root_node();
+        let mut cursor = root.walk();
+        root.named_children(&mut cursor)
+            .filter(|node| matches!(node.kind(), "function_declaration" | "method_declaration"))
+            .filter_map(|declaration| {
+                let name = self.text(declaration.child_by_field_name("name")?);
+                let body = declaration.child_by_field_name("body")?;
+                let mut info = self.analyze_body(body);
+                info.annotations = Annotations::parse(&self.leading_comments(declaration));
+                Some((name.to_string(), info))
+            })
+            .collect()
+    }
+
+    /// Struct type declarations.
+    pub(super) fn structs(&self) -> Vec<GoStruct> {
+        let mut structs = Vec::new();
+        walk_tree(self.tree.root_node(), &mut |node| {
+            if node.kind() != "type_spec" {
+                return;
+            }
+            let (Some(name), Some(body)) = (
+                node.child_by_field_name("name"),
+                node.child_by_field_name("type"),
+            ) else {
+                return;
+            };
+            if body.kind() != "struct_type" {
+                return;
+            }
+            let mut comments = self.leading_comments(node);
+            if comments.is_empty() {
+                if let Some(declaration) = node.parent().filter(|p| p.kind() == "type_declaration")
+                {
+                    comments = self.leading_comments(declaration);
+                }
+            }
+            structs.push(GoStruct {
+                name: self.text(name).to_string(),
+                doc: (!comments.is_empty()).then(|| comments.join(" ")),
+                fields: self.struct_fields(body),
+            });
+        });
+        structs
+    }
+
+    /// Exported, JSON-visible fields of a `struct_type` node.
+    fn struct_fields(&self, struct_type: Node<'_>) -> Vec<GoField> {
+        let Some(list) = find_child_by_kind(&struct_type, "field_declaration_list") else {
+            return Vec::new();
+        };
+        let mut fields = Vec::new();
+        let mut cursor = list.walk();
+        for declaration in list.named_children(&mut cursor) {
+            if declaration.kind() != "field_declaration" {
+                continue;
+            }
+            let Some(go_type) = declaration.child_by_field_name("type") else {
+                continue;
+            };
+            let tag = declaration
+                .child_by_field_name("tag")
+                .and_then(|tag| self.string_literal(tag));
+            let (tag_name, omit_empty) = match tag.as_deref().and_then(json_tag) {
+                Some((name, _)) if name == "-" => continue,
+                Some((name, omit_empty)) => (Some(name), omit_empty),
+                None => (None, false),
+            };
+            let mut names = declaration.walk();
+            for name in declaration.children_by_field_name("name", &mut names) {
+                let name = self.text(name);
+                if !name.starts_with(|c: char| c.is_ascii_uppercase()) {
+                    continue;
+                }
+                fields.push(GoField {
+                    json_name: tag_name
+                        .clone()
+                        .filter(|tag_name| !tag_name.is_empty())
+                        .unwrap_or_else(|| name.to_string()),
+                    go_type: collapse_whitespace(self.text(go_type)),
+                    omit_empty,
+                });
+            }
+        }
+        fields
+    }
+
+    /// Route registrations, with chi mount prefixes from `mounts`.
+    pub(super) fn routes(&self, mounts: &HashMap<String, String>) -> Vec<Registration> {
+        if self.routers.is_empty() {
+            return Vec::new();
+        }
+        let mut registrations = Vec::new();
+        self.visit(
+            self.tree.root_node(),
+            &mut Scope::default(),
+            mounts,
+            &mut registrations,
+        );
+        registrations
+    }
+
+    /// Walk `node`, tracking router prefixes in `scope`.
+    fn visit(
+        &self,
+        node: Node<'_>,
+        scope: &mut Scope,
+        mounts: &HashMap<String, String>,
+        registrations: &mut Vec<Registration>,
+    ) {
+        match node.kind() {
+            "function_declaration" | "method_declaration" => {
+                let mut inner = Scope::default();
+                if let Some(prefix) = node
+                    .child_by_field_name("name")
+                    .and_then(|name| mounts.get(self.text(name)))
+                {
+                    inner.default_prefix = prefix.clone();
+                }
+                if let Some(body) = node.child_by_field_name("body") {
+                    self.visit(body, &mut inner, mounts, registrations);
+                }
+                return;
+            }
+            "short_var_declaration" | "assignment_statement" => {
+                self.assign_routers(node, "left", "right", scope)
+            }
+            "var_spec" => self.assign_routers(node, "name", "value", scope),
+            "call_expression" => {
+                if let Some(call) = self.method_call(node) {
+                    if let Some((callback, prefix)) = self.chi_subrouter(&call, scope) {
+                        self.visit(call.receiver, scope, mounts, registrations);
+                        let mut inner = scope.clone();
+                        if let Some(parameter) = first_parameter_name(callback, &self.source) {
+                            inner.prefixes.insert(parameter, prefix);
+                        }
+                        if let Some(body) = callback.child_by_field_name("body") {
+                            self.visit(body, &mut inner, mounts, registrations);
+                        }
+                        return;
+                    }
+                    if is_chain_head(node) {
+                        registrations.extend(self.registration(node, scope));
+                    }
+                }
+            }
+            _ => {}
+        }
+
+        let mut cursor = node.walk();
+        let children: Vec<Node<'_>> = node.named_children(&mut cursor).collect();
+        for child in children {
+            self.visit(child, scope, mounts, registrations);
+        }
+    }
+
+    /// Record the prefix of routers assigned from `Group`, `PathPrefix`,
+    /// `Route`, `Subrouter` or `With` calls.
+    fn assign_routers(&self, node: Node<'_>, targets: &str, values: &str, scope: &mut Scope) {
+        let targets = field_items(node, targets);
+        let values = field_items(node, values);
+        for (target, value) in targets.into_iter().zip(values) {
+            let Some(call) = self.method_call(value) else {
+                continue;
+            };
+            if PREFIX_METHODS.contains(&call.name) || ROUTER_METHODS.contains(&call.name) {
+                let prefix = self.prefix_of(value, scope);
+                scope.prefixes.insert(self.text(target).to_string(), prefix);
+            }
+        }
+    }
+
+    /// For chi `r.Route("/path", func(r chi.Router) {...})` and
+    /// `r.Group(func(r chi.Router) {...})`, the callback and its router's prefix.
+    fn chi_subrouter<'t>(
+        &self,
+        call: &MethodCall<'t, '_>,
+        scope: &Scope,
+    ) -> Option<(Node<'t>, String)> {
+        if !self.uses(Router::Chi) {
+            return None;
+        }
+        let (path, callback) = match call.name {
+            "Route" => (self.string_arg(call, 0)?, *call.args.get(1)?),
+            "Group" => (String::new(), *call.args.first()?),
+            _ => return None,
+        };
+        (callback.kind() == "func_literal").then(|| {
+            (
+                callback,
+                join_paths(&self.prefix_of(call.receiver, scope), &path),
+            )
+        })
+    }
+
+    /// Prefix of the router an expression evaluates to.
+    fn prefix_of(&self, node: Node<'_>, scope: &Scope) -> String {
+        match node.kind() {
+            "identifier" | "selector_expression" => scope.prefix(self.text(node)),
+            "call_expression" => match self.method_call(node) {
+                Some(call) => {
+                    let base = self.prefix_of(call.receiver, scope);
+                    match self.string_arg(&call, 0) {
+                        Some(path) if PREFIX_METHODS.contains(&call.name) => {
+                            join_paths(&base, &path)
+                        }
+                        _ => base,
+                    }
+                }
+                None => scope.default_prefix.clone(),
+            },
+            _ => scope.default_prefix.clone(),
+        }
+    }
+
+    /// The route registered by the call chain ending at `node`, if any.
+    fn registration(&self, node: Node<'_>, scope: &Scope) -> Option<Registration> {
+        let mut call = self.method_call(node)?;
+        let mut chained_methods = Vec::new();
+        while self.uses(Router::Gorilla) && ROUTE_MODIFIERS.contains(&call.name) {
+            if call.name == "Methods" {
+                chained_methods.extend(call.args.iter().filter_map(|arg| self.http_method(*arg)));
+            }
+            call = self.method_call(call.receiver)?;
+        }
+
+        let (mut methods, pattern, handler) = self.route_target(&call)?;
+        if !chained_methods.is_empty() {
+            methods = chained_methods;
+        }
+        let handler = handler.map(|handler| self.handler_reference(handler));
+        let inline = handler
+            .as_ref()
+            .and_then(|handler| handler.literal)
+            .and_then(|literal| literal.child_by_field_name("body"))
+            .map(|body| self.analyze_body(body));
+
+        let path = join_paths(&self.prefix_of(call.receiver, scope), &pattern);
+        Some(Registration {
+            methods,
+            path: if path.is_empty() {
+                "/".to_string()
+            } else {
+                path
+            },
+            handler_text: handler
+                .as_ref()
+                .filter(|handler| handler.literal.is_none())
+                .map(|handler| handler.text.clone()),
+            candidates: handler
+                .map(|handler| handler.candidates)
+                .unwrap_or_default(),
+            inline,
+            pattern_parameters: pattern_parameters(&pattern),
+            annotations: Annotations::parse(&self.leading_comments(statement_of(node))),
+            file_path: self.path.clone(),
+            line: node.start_position().row + 1,
+        })
+    }
+
+    /// Methods, path pattern and handler of a registration call.
+    fn route_target<'t>(
+        &self,
+        call: &MethodCall<'t, '_>,
+    ) -> Option<(Vec<String>, String, Option<Node<'t>>)> {
+        let name = call.name;
+        let arg = |index: usize| call.args.get(index).copied();
+        // gin takes middleware before the handler, echo after it.
+        let handler_at = |index: usize| {
+            if self.uses(Router::Gin) {
+                call.args
+                    .last()
+                    .copied()
+                    .filter(|_| call.args.len() > index)
+            } else {
+                arg(index)
+            }
+        };
+        let gin_or_echo = self.uses(Router::Gin) || self.uses(Router::Echo);
+
+        let (methods, pattern, handler) = if gin_or_echo
+            && (HTTP_METHODS.contains(&name) || name == "Any")
+        {
+            let methods = if name == "Any" {
+                Vec::new()
+            } else {
+                vec![name.to_string()]
+            };
+            (methods, self.string_arg(call, 0)?, handler_at(1))
+        } else if gin_or_echo && matches!(name, "Handle" | "Add") && call.args.len() >= 3 {
+            (
+                vec![self.http_method(arg(0)?)?],
+                self.string_arg(call, 1)?,
+                handler_at(2),
+            )
+        } else if gin_or_echo && name == "Match" {
+            (
+                self.method_list(arg(0)?),
+                self.string_arg(call, 1)?,
+                handler_at(2),
+            )
+        } else if self.uses(Router::Chi) && is_chi_method(name) {
+            (
+                vec![name.to_ascii_uppercase()],
+                self.string_arg(call, 0)?,
+                arg(1),
+            )
+        } else if self.uses(Router::Chi) && matches!(name, "Method" | "MethodFunc") {
+            (
+                vec![self.http_method(arg(0)?)?],
+                self.string_arg(call, 1)?,
+                arg(2),
+            )
+        } else if matches!(name, "HandleFunc" | "Handle")
+            && call.args.len() == 2
+            && (self.uses(Router::NetHttp) || self.uses(Router::Chi) || self.uses(Router::Gorilla))
+        {
+            let (method, path) = split_pattern(&self.string_arg(call, 0)?);
+            (method.into_iter().collect(), path, arg(1))
+        } else {
+            return None;
+        };
+
+        // gin and echo groups accept "" for the group path itself.
+        (pattern.is_empty() || pattern.starts_with('/')).then_some((methods, pattern, handler))
+    }
+
+    /// What a handler expression refers to, looking through wrappers such as
+    /// `http.HandlerFunc(f)` or `auth(f)`.
+    fn handler_reference<'t>(&self, node: Node<'t>) -> HandlerRef<'t> {
+        let text = collapse_whitespace(self.text(node));
+        match node.kind() {
+            "identifier" => HandlerRef {
+                candidates: vec![(text.clone(), text.clone())],
+                text,
+                literal: None,
+            },
+            "selector_expression" => {
+                let key = node
+                    .child_by_field_name("field")
+                    .map(|field| self.text(field).to_string())
+                    .unwrap_or_default();
+                HandlerRef {
+                    candidates: vec![(text.clone(), key)],
+                    text,
+                    literal: None,
+                }
+            }
+            "func_literal" => HandlerRef {
+                text,
+                candidates: Vec::new(),
+                literal: Some(node),
+            },
+            "call_expression" => {
+                let mut reference = HandlerRef {
+                    text,
+                    candidates: Vec::new(),
+                    literal: None,
+                };
+                if let Some(arguments) = node.child_by_field_name("arguments") {
+                    let mut cursor = arguments.walk();
+                    for argument in arguments.named_children(&mut cursor) {
+                        let wrapped = self.handler_reference(argument);
+                        reference.candidates.extend(wrapped.candidates);
+                        reference.literal = reference.literal.or(wrapped.literal);
+                    }
+                }
+                reference
+            }
+            _ => HandlerRef {
+                text,
+                candidates: Vec::new(),
+                literal: None,
+            },
+        }
+    }
+
+    /// Parameters, request body type and compared methods of a handler body.
+    fn analyze_body(&self, body: Node<'_>) -> HandlerInfo {
+        let mut analysis = BodyAnalysis::default();
+        walk_tree(body, &mut |node| match node.kind() {
+            "short_var_declaration" | "assignment_statement" => {
+                self.record_assignment(node, "left", "right", &mut analysis)
+            }
+            "var_spec" => {
+                if let Some(go_type) = node.child_by_field_name("type") {
+                    for name in field_items(node, "name") {
+                        analysis
+                            .var_types
+                            .insert(self.text(name).to_string(), self.text(go_type).to_string());
+                    }
+                }
+                self.record_assignment(node, "name", "value", &mut analysis);
+            }
+            "call_expression" => self.record_call(node, &mut analysis),
+            "index_expression" => self.record_index(node, &mut analysis),
+            "binary_expression" => {
+                let (Some(left), Some(right)) = (
+                    node.child_by_field_name("left"),
+                    node.child_by_field_name("right"),
+                ) else {
+                    return;
+                };
+                for (subject, method) in [(left, right), (right, left)] {
+                    if self.text(subject).ends_with(".Method") {
+                        analysis.add_methods(self.http_method(method));
+                    }
+                }
+            }
+            "expression_switch_statement" => {
+                let switches_on_method = node
+                    .child_by_field_name("value")
+                    .is_some_and(|value| self.text(value).ends_with(".Method"));
+                if !switches_on_method {
+                    return;
+                }
+                let mut cursor = node.walk();
+                for case in node.named_children(&mut cursor) {
+                    if case.kind() != "expression_case" {
+                        continue;
+                    }
+                    for value in field_items(case, "value") {
+                        analysis.add_methods(self.http_method(value));
+                    }
+                }
+            }
+            _ => {}
+        });
+        analysis.into_info()
+    }
+
+    /// Record local variable types, parameter readers and parameter values.
+    fn record_assignment(
+        &self,
+        node: Node<'_>,
+        targets: &str,
+        values: &str,
+        analysis: &mut BodyAnalysis,
+    ) {
+        let targets = field_items(node, targets);
+        let values = field_items(node, values);
+        for (target, value) in targets.into_iter().zip(values) {
+            if target.kind() != "identifier" {
+                continue;
+            }
+            let name = self.text(target).to_string();
+            if let Some((go_type, pointer)) = self.constructed_type(value) {
+                analysis.var_types.insert(name.clone(), go_type);
+                if pointer {
+                    analysis.pointer_vars.push(name.clone());
+                }
+            } else if let Some(parameter) = self.parameter_read(value, analysis) {
+                analysis.parameter_vars.insert(name, parameter);
+            } else if let Some(location) = self.reader_of(value) {
+                analysis.readers.insert(name, location);
+            }
+        }
+    }
+
+    /// Type constructed by `T{}`, `&T{}` or `new(T)`, and whether the value
+    /// is a pointer.
+    fn constructed_type(&self, value: Node<'_>) -> Option<(String, bool)> {
+        match value.kind() {
+            "composite_literal" => Some((
+                self.text(value.child_by_field_name("type")?).to_string(),
+                false,
+            )),
+            "unary_expression" => {
+                let operand = value.child_by_field_name("operand")?;
+                let (go_type, _) = self.constructed_type(operand)?;
+                Some((go_type, true))
+            }
+            "call_expression" => {
+                let function = value.child_by_field_name("function")?;
+                if self.text(function) != "new" {
+                    return None;
+                }
+                let arguments = value.child_by_field_name("arguments")?;
+                let go_type = arguments.named_child(0)?;
+                Some((self.text(go_type).to_string(), true))
+            }
+            _ => None,
+        }
+    }
+
+    /// Location of the values of a parameter collection: `r.URL.Query()`,
+    /// `mux.Vars(r)` or `r.Header`.
+    fn reader_of(&self, value: Node<'_>) -> Option<ParameterLocation> {
+        match value.kind() {
+            "call_expression" => {
+                let call = self.method_call(value)?;
+                match call.name {
+                    "Query" if call.args.is_empty() => Some(ParameterLocation::Query),
+                    "Vars" => Some(ParameterLocation::Path),
+                    _ => None,
+                }
+            }
+            "selector_expression" => value
+                .child_by_field_name("field")
+                .filter(|field| self.text(*field) == "Header")
+                .map(|_| ParameterLocation::Header),
+            _ => None,
+        }
+    }
+
+    /// Record parameter reads, `strconv` conversions of parameters and body binding.
+    fn record_call(&self, node: Node<'_>, analysis: &mut BodyAnalysis) {
+        if let Some((name, location)) = self.parameter_read(node, analysis) {
+            analysis.add_parameter(name, location);
+            return;
+        }
+
+        let function = node
+            .child_by_field_name("function")
+            .map(|function| self.text(function))
+            .unwrap_or_default();
+        let first_argument = node
+            .child_by_field_name("arguments")
+            .and_then(|arguments| arguments.named_child(0));
+        if let Some((_, schema_type)) = CONVERSIONS.iter().find(|(name, _)| *name == function) {
+            let converted = first_argument.and_then(|argument| match argument.kind() {
+                "identifier" => analysis.parameter_vars.get(self.text(argument)).cloned(),
+                _ => self.parameter_read(argument, analysis),
+            });
+            if let Some((name, location)) = converted {
+                // The conversion is visited before the read it wraps.
+                analysis.add_parameter(name.clone(), location);
+                analysis.set_type(&name, location, *schema_type);
+            }
+            return;
+        }
+
+        let Some(call) = self.method_call(node) else {
+            return;
+        };
+        if !BODY_BINDERS.contains(&call.name) || analysis.request_type.is_some() {
+            return;
+        }
+        analysis.request_type = call.args.iter().find_map(|argument| {
+            let variable = match argument.kind() {
+                "unary_expression" => argument
+                    .child_by_field_name("operand")
+                    .filter(|operand| operand.kind() == "identifier")
+                    .map(|operand| self.text(operand))?,
+                "identifier" => Some(self.text(*argument))
+                    .filter(|name| analysis.pointer_vars.iter().any(|p| p == name))?,
+                _ => return None,
+            };
+            analysis
+                .var_types
+                .get(variable)
+                .map(|go_type| type_name(go_type).0)
+        });
+    }
+
+    /// Record `mux.Vars(r)["id"]` and `vars["id"]` reads.
+    fn record_index(&self, node: Node<'_>, analysis: &mut BodyAnalysis) {
+        let (Some(operand), Some(index)) = (
+            node.child_by_field_name("operand"),
+            node.child_by_field_name("index"),
+        ) else {
+            return;
+        };
+        let location = match operand.kind() {
+            "identifier" => analysis.readers.get(self.text(operand)).copied(),
+            _ => self.reader_of(operand),
+        };
+        if let (Some(location), Some(name)) = (location, self.string_literal(index)) {
+            analysis.add_parameter(name, location);
+        }
+    }
+
+    /// The parameter a call reads, if it is a parameter accessor.
+    fn parameter_read(
+        &self,
+        node: Node<'_>,
+        analysis: &BodyAnalysis,
+    ) -> Option<(String, ParameterLocation)> {
+        let call = self.method_call(node)?;
+        let location = match call.name {
+            "URLParam" => return Some((self.string_arg(&call, 1)?, ParameterLocation::Path)),
+            "GetHeader" => ParameterLocation::Header,
+            "Get" => match call.receiver.kind() {
+                "identifier" => analysis.readers.get(self.text(call.receiver)).copied()?,
+                _ => self.reader_of(call.receiver)?,
+            },
+            name if PATH_READERS.contains(&name) => ParameterLocation::Path,
+            name if QUERY_READERS.contains(&name) => ParameterLocation::Query,
+            _ => return None,
+        };
+        Some((self.string_arg(&call, 0)?, location))
+    }
+
+    /// Upper-case HTTP method named by a string literal or an `http.MethodX` constant.
+    fn http_method(&self, node: Node<'_>) -> Option<String> {
+        let method = match node.kind() {
+            "selector_expression" => self
+                .text(node)
+                .strip_prefix("http.Method")?
+                .to_ascii_uppercase(),
+            _ => self.string_literal(node)?.to_ascii_uppercase(),
+        };
+        HTTP_METHODS.contains(&method.as_str()).then_some(method)
+    }
+
+    /// HTTP methods in a `[]string{...}` literal.
+    fn method_list(&self, node: Node<'_>) -> Vec<String> {
+        let mut methods = Vec::new();
+        walk_tree(node, &mut |element| {
+            if let Some(method) = self.http_method(element) {
+                methods.push(method);
+            }
+        });
+        methods
+    }
+
+    /// `receiver.name(args...)` parts of a call expression.
+    fn method_call<'t>(&self, node: Node<'t>) -> Option<MethodCall<'t, '_>> {
+        if node.kind() != "call_expression" {
+            return None;
+        }
+        let function = node.child_by_field_name("function")?;
+        if function.kind() != "selector_expression" {
+            return None;
+        }
+        let arguments = node.child_by_field_name("arguments")?;
+        let mut cursor = arguments.walk();
+        Some(MethodCall {
+            name: self.text(function.child_by_field_name("field")?),
+            receiver: function.child_by_field_name("operand")?,
+            args: arguments
+                .named_children(&mut cursor)
+                .filter(|argument| argument.kind() != "comment")
+                .collect(),
+        })
+    }
+
+    /// Value of the string literal argument at `index`.
+    fn string_arg(&self, call: &MethodCall<'_, '_>, index: usize) -> Option<String> {
+        call.args
+            .get(index)
+            .and_then(|argument| self.string_literal(*argument))
+    }
+
+    /// Value of an interpreted or raw string literal.
+    fn string_literal(&self, node: Node<'_>) -> Option<String> {
+        let text = self.text(node);
+        match node.kind() {
+            "interpreted_string_literal" => Some(
+                text.trim_matches('"')
+                    .replace("\\\"", "\"")
+                    .replace("\\\\", "\\"),
+            ),
+            "raw_string_literal" => Some(text.trim_matches('`').to_string()),
+            _ => None,
+        }
+    }
+
+    /// Text of the comments directly above `node`, one entry per line.
+    fn leading_comments(&self, node: Node<'_>) -> Vec<String> {
+        let mut comments = Vec::new();
+        let mut next_row = node.start_position().row;
+        let mut current = node.prev_sibling();
+        while let Some(comment) = current.filter(|sibling| sibling.kind() == "comment") {
+            if comment.end_position().row + 1 < next_row {
+                break;
+            }
+            comments.push(self.text(comment));
+            next_row = comment.start_position().row;
+            current = comment.prev_sibling();
+        }
+        comments.into_iter().rev().flat_map(comment_lines).collect()
+    }
+
+    /// Source text of `node`.
+    fn text(&self, node: Node<'_>) -> &str {
+        node.utf8_text(self.source.as_bytes()).unwrap_or_default()
+    }
+}
+
+/// Recording helpers for [`BodyAnalysis`].
+impl BodyAnalysis {
+    /// Add a string parameter unless it is already known.
+    fn add_parameter(&mut self, name: String, location: ParameterLocation) {
+        if !self
+            .parameters
+            .iter()
+            .any(|parameter| parameter.name == name && parameter.location == location)
+        {
+            self.parameters.push(RouteParameter {
+                name,
+                location,
+                schema_type: ParameterType::String,
+                description: None,
+            });
+        }
+    }
+
+    /// Set the type of a known parameter.
+    fn set_type(&mut self, name: &str, location: ParameterLocation, schema_type: ParameterType) {
+        if let Some(parameter) = self
+            .parameters
+            .iter_mut()
+            .find(|parameter| parameter.name == name && parameter.location == location)
+        {
+            parameter.schema_type = schema_type;
+        }
+    }
+
+    /// Add a compared method unless it is already known.
+    fn add_methods(&mut self, method: Option<String>) {
+        if let Some(method) = method {
+            if !self.methods.contains(&method) {
+                self.methods.push(method);
+            }
+        }
+    }
+
+    /// The collected handler information.
+    fn into_info(self) -> HandlerInfo {
+        HandlerInfo {
+            annotations: Annotations::default(),
+            parameters: self.parameters,
+            request_type: self.request_type,
+            methods: self.methods,
+        }
+    }
+}
+
+/// Parsing and merging for [`Annotations`].
+impl Annotations {
+    /// Parse comment lines. Prose before the first annotation provides the
+    /// summary (first line) and description (the rest) when `@Summary` and
+    /// `@Description` are absent.
+    fn parse(lines: &[String]) -> Self {
+        let mut annotations = Self::default();
+        let mut prose = Vec::new();
+        let mut annotated = false;
+        for line in lines {
+            let Some(annotation) = line.strip_prefix('@') else {
+                if !annotated && !line.is_empty() {
+                    prose.push(line.as_str());
+                }
+                continue;
+            };
+            annotated = true;
+            let (key, value) = annotation
+                .split_once(char::is_whitespace)
+                .map(|(key, value)| (key, value.trim()))
+                .unwrap_or((annotation, ""));
+            match key.to_ascii_lowercase().as_str() {
+                "summary" if !value.is_empty() => annotations.summary = Some(value.to_string()),
+                "description" if !value.is_empty() => {
+                    annotations.description = Some(match annotations.description.take() {
+                        Some(description) => format!("{description} {value}"),
+                        None => value.to_string(),
+                    })
+                }
+                "tags" => annotations.tags.extend(
+                    value
+                        .split(',')
+                        .map(str::trim)
+                        .filter(|tag| !tag.is_empty())
+                        .map(str::to_string),
+                ),
+                "param" => annotations.add_param(value),
+                "request" => {
+                    annotations.request_type = value
+                        .split_whitespace()
+                        .next()
+                        .map(|go_type| type_name(go_type).0)
+                }
+                "success" | "failure" | "response" => annotations.add_response(value),
+                _ => {}
+            }
+        }
+
+        if annotations.summary.is_none() {
+            annotations.summary = prose.first().map(|line| line.to_string());
+        }
+        if annotations.description.is_none() && prose.len() > 1 {
+            annotations.description = Some(prose[1..].join(" "));
+        }
+        annotations
+    }
+
+    /// `@Param <name> <in> <type> [required] ["description"]`; `body`
+    /// parameters set the request type.
+    fn add_param(&mut self, value: &str) {
+        let tokens = annotation_tokens(value);
+        let [(name, _), (location, _), (go_type, _), ..] = tokens.as_slice() else {
+            return;
+        };
+        let location = match location.as_str() {
+            "body" => {
+                self.request_type = Some(type_name(go_type).0);
+                return;
+            }
+            "path" => ParameterLocation::Path,
+            "query" => ParameterLocation::Query,
+            "header" => ParameterLocation::Header,
+            _ => return,
+        };
+        let schema_type = match go_type.as_str() {
+            "int" | "integer" | "int32" | "int64" | "uint" | "uint64" => ParameterType::Integer,
+            "number" | "float" | "float32" | "float64" => ParameterType::Number,
+            "bool" | "boolean" => ParameterType::Boolean,
+            _ => ParameterType::String,
+        };
+        self.parameters.push(RouteParameter {
+            name: name.clone(),
+            location,
+            schema_type,
+            description: tokens
+                .iter()
+                .find(|(_, quoted)| *quoted)
+                .map(|(text, _)| text.clone()),
+        });
+    }
+
+    /// `@Success <status> [{object|array}] [Type] ["description"]`, also for
+    /// `@Failure` and `@Response`. Without a status the response is a 200.
+    fn add_response(&mut self, value: &str) {
+        let mut tokens = annotation_tokens(value).into_iter().peekable();
+        let status = match tokens.peek() {
+            Some((token, false))
+                if token == "default" || token.chars().all(|c| c.is_ascii_digit()) =>
+            {
+                let status = token.clone();
+                tokens.next();
+                status
+            }
+            _ => "200".to_string(),
+        };
+        let mut is_array = false;
+        let mut primitive = false;
+        if let Some((kind, false)) = tokens.peek() {
+            if let Some(kind) = kind
+                .strip_prefix('{')
+                .and_then(|kind| kind.strip_suffix('}'))
+            {
+                is_array = kind == "array";
+                primitive = !matches!(kind, "array" | "object");
+                tokens.next();
+            }
+        }
+        let mut type_name_token = None;
+        if let Some((token, false)) = tokens.peek() {
+            type_name_token = Some(token.clone());
+            tokens.next();
+        }
+        let description: Vec<String> = tokens.map(|(text, _)| text).collect();
+
+        let body_type = type_name_token
+            .filter(|_| !primitive)
+            .map(|token| type_name(&token));
+        self.responses.push(RouteResponse {
+            status,
+            is_array: is_array || body_type.as_ref().is_some_and(|(_, array)| *array),
+            type_name: body_type.map(|(name, _)| name),
+            description: (!description.is_empty()).then(|| description.join(" ")),
+        });
+    }
+
+    /// These annotations, with anything they lack taken from `fallback`.
+    fn or(self, fallback: Self) -> Self {
+        Self {
+            summary: self.summary.or(fallback.summary),
+            description: self.description.or(fallback.description),
+            tags: non_empty_or(self.tags, fallback.tags),
+            parameters: non_empty_or(self.parameters, fallback.parameters),
+            request_type: self.request_type.or(fallback.request_type),
+            responses: non_empty_or(self.responses, fallback.responses),
+        }
+    }
+}
+
+/// Resolution of [`Registration`] against the handlers of all files.
+impl Registration {
+    /// The route, completed from the first named handler found in `handlers`
+    /// or from the function literal registered inline. Comments above the
+    /// registration take precedence over the handler's doc comment.
+    pub(super) fn resolve(self, handlers: &HashMap<String, HandlerInfo>) -> HttpRoute {
+        let declared = self
+            .candidates
+            .iter()
+            .find_map(|(display, key)| handlers.get(key).map(|info| (display.clone(), info)));
+        let (handler, info) = match (self.inline, declared) {
+            (Some(info), _) => (None, info),
+            (None, Some((display, info))) => (Some(display), info.clone()),
+            (None, None) => (self.handler_text, HandlerInfo::default()),
+        };
+
+        let annotations = self.annotations.or(info.annotations);
+        let mut parameters = annotations.parameters;
+        for parameter in self.pattern_parameters.into_iter().chain(info.parameters) {
+            let known = parameters
+                .iter()
+                .any(|known| known.name == parameter.name && known.location == parameter.location);
+            if !known {
+                parameters.push(parameter);
+            }
+        }
+
+        HttpRoute {
+            methods: if self.methods.is_empty() {
+                info.methods
+            } else {
+                self.methods
+            },
+            path: self.path,
+            handler,
+            file_path: self.file_path,
+            line: self.line,
+            summary: annotations.summary,
+            description: annotations.description,
+            tags: annotations.tags,
+            parameters,
+            request_type: annotations.request_type.or(info.request_type),
+            responses: annotations.responses,
+        }
+    }
+}
+
+/// `own` unless it is empty.
+fn non_empty_or<T>(own: Vec<T>, fallback: Vec<T>) -> Vec<T> {
+    if own.is_empty() {
+        fallback
+    } else {
+        own
+    }
+}
+
+/// Whether a call is the last of a method chain, i.e. not the receiver of
+/// another call.
+fn is_chain_head(node: Node<'_>) -> bool {
+    let Some(selector) = node.parent().filter(|p| p.kind() == "selector_expression") else {
+        return true;
+    };
+    !selector
+        .parent()
+        .is_some_and(|call| call.kind() == "call_expression")
+}
+
+/// The statement containing `node`, whose leading comments document it.
+fn statement_of(node: Node<'_>) -> Node<'_> {
+    let mut current = node;
+    while let Some(parent) = current.parent() {
+        if matches!(parent.kind(), "block" | "statement_list" | "source_file") {
+            break;
+        }
+        current = parent;
+    }
+    current
+}
+
+/// Nodes of a field, flattening an `expression_list`.
+fn field_items<'t>(node: Node<'t>, field: &str) -> Vec<Node<'t>> {
+    let mut cursor = node.walk();
+    let items: Vec<Node<'t>> = node.children_by_field_name(field, &mut cursor).collect();
+    if let [list] = items.as_slice() {
+        if list.kind() == "expression_list" {
+            let list = *list;
+            let mut cursor = list.walk();
+            return list.named_children(&mut cursor).collect();
+        }
+    }
+    items
+}
+
+/// Name of the first parameter of a function literal.
+fn first_parameter_name(function: Node<'_>, source: &str) -> Option<String> {
+    let parameters = function.child_by_field_name("parameters")?;
+    let mut cursor = parameters.walk();
+    let declaration = parameters
+        .named_children(&mut cursor)
+        .find(|child| child.kind() == "parameter_declaration")?;
+    let name = declaration.child_by_field_name("name")?;
+    name.utf8_text(source.as_bytes()).ok().map(str::to_string)
+}
+
+/// Whether `name` is a chi method registration such as `Get` or `Delete`.
+fn is_chi_method(name: &str) -> bool {
+    let upper = name.to_ascii_uppercase();
+    upper != name
+        && HTTP_METHODS.contains(&upper.as_str())
+        && name[1..] == upper[1..].to_ascii_lowercase()
+}
+
+/// Split a `net/http` pattern such as `GET example.com/items/{id}` into its
+/// method and path.
+fn split_pattern(pattern: &str) -> (Option<String>, String) {
+    let pattern = pattern.trim();
+    let (method, rest) = match pattern.split_once(char::is_whitespace) {
+        Some((method, rest)) if HTTP_METHODS.contains(&method) => {
+            (Some(method.to_string()), rest.trim_start())
+        }
+        _ => (None, pattern),
+    };
+    // Host-specific patterns keep only their path.
+    let path = match rest.find('/') {
+        Some(start) => &rest[start..],
+        None => rest,
+    };
+    (method, path.to_string())
+}
+
+/// Rewrite `:id`, `*path`, `{id:[0-9]+}` and `{path...}` segments into
+/// OpenAPI `{name}` form; the `{$}` end anchor is dropped.
+pub(super) fn normalize_path(path: &str) -> String {
+    path.split('/')
+        .map(|segment| {
+            if let Some(name) = segment.strip_prefix(':') {
+                format!("{{{name}}}")
+            } else if let Some(name) = segment.strip_prefix('*').filter(|name| !name.is_empty()) {
+                format!("{{{name}}}")
+            } else if let Some(inner) = segment
+                .strip_prefix('{')
+                .and_then(|segment| segment.strip_suffix('}'))
+            {
+                let name = inner.split(':').next().unwrap_or(inner);
+                match name.trim_end_matches("...") {
+                    "$" => String::new(),
+                    name => format!("{{{name}}}"),
+                }
+            } else {
+                segment.to_string()
+            }
+        })
+        .collect::<Vec<_>>()
+        .join("/")
+}
+
+/// `prefix` followed by `path`, both normalized; `/` under a prefix is the prefix itself.
+fn join_paths(prefix: &str, path: &str) -> String {
+    let path = normalize_path(path);
+    if prefix.is_empty() {
+        return path;
+    }
+    if path.is_empty() || path == "/" {
+        return prefix.to_string();
+    }
+    format!(
+        "{}/{}",
+        prefix.trim_end_matches('/'),
+        path.trim_start_matches('/')
+    )
+}
+
+/// Integer path parameters declared by gorilla/mux patterns such as `{id:[0-9]+}`.
+fn pattern_parameters(pattern: &str) -> Vec<RouteParameter> {
+    pattern
+        .split('/')
+        .filter_map(|segment| segment.strip_prefix('{')?.strip_suffix('}'))
+        .filter_map(|inner| inner.split_once(':'))
+        .filter(|(_, regex)| INTEGER_PATTERNS.contains(regex))
+        .map(|(name, _)| RouteParameter {
+            name: name.to_string(),
+            location: ParameterLocation::Path,
+            schema_type: ParameterType::Integer,
+            description: None,
+        })
+        .collect()
+}
+
+/// Name and `omitempty` flag of a struct tag's `json` key.
+fn json_tag(tag: &str) -> Option<(String, bool)> {
+    let start = tag.find("json:\"")? + "json:\"".len();
+    let value = &tag[start..];
+    let value = &value[..value.find('"')?];
+    let mut options = value.split(',');
+    let name = options.next().unwrap_or_default().to_string();
+    Some((name, options.any(|option| option == "omitempty")))
+}
+
+/// Bare type name of a Go type expression and whether it is a slice, e.g.
+/// `[]*models.User` gives `("User", true)`.
+fn type_name(go_type: &str) -> (String, bool) {
+    let mut go_type = go_type.trim().trim_start_matches(['*', '&']);
+    let is_array = go_type.starts_with("[]");
+    while let Some(element) = go_type.strip_prefix("[]") {
+        go_type = element.trim_start_matches('*');
+    }
+    let name = go_type.rsplit('.').next().unwrap_or(go_type);
+    let name = name.split(['{', '(']).next().unwrap_or(name);
+    (name.to_string(), is_array)
+}
+
+/// Lines of a comment without comment markers.
+fn comment_lines(comment: &str) -> Vec<String> {
+    let body = match comment.strip_prefix("//") {
+        Some(line) => line,
+        None => comment.trim_start_matches("/*").trim_end_matches("*/"),
+    };
+    body.lines()
+        .map(|line| line.trim().trim_start_matches('*').trim().to_string())
+        .collect()
+}
+
+/// Split annotation arguments on whitespace, keeping double-quoted text
+/// together; each token records whether it was quoted.
+fn annotation_tokens(value: &str) -> Vec<(String, bool)> {
+    let mut tokens = Vec::new();
+    let mut rest = value.trim();
+    while !rest.is_empty() {
+        if let Some(quoted) = rest.strip_prefix('"') {
+            let end = quoted.find('"').unwrap_or(quoted.len());
+            tokens.push((quoted[..end].to_string(), true));
+            rest = quoted.get(end + 1..).unwrap_or_default().trim_start();
+        } else {
+            let end = rest.find(char::is_whitespace).unwrap_or(rest.len());
+            tokens.push((rest[..end].to_string(), false));
+            rest = rest[end..].trim_start();
+        }
+    }
+    tokens
+}
+
+/// `text` with runs of whitespace replaced by single spaces.
+fn collapse_whitespace(text: &str) -> String {
+    text.split_whitespace().collect::<Vec<_>>().join(" ")
+}
@@ -0,0 +1,242 @@
+//! HTTP routes of Go services.
+//!
+//! [`extract_routes`] finds handler registrations of the standard library
+//! (`net/http`, including Go 1.22 `"GET /items/{id}"` patterns), chi, gin,
+//! echo and gorilla/mux in the Go files under a directory. Each route gets
+//! its HTTP methods, its full path (prefixes from chi `Route`/`Group`/`Mount`,
+//! gin and echo groups and gorilla subrouters applied), its handler and what
+//! can be read from that handler:
+//!
+//! - path, query and header parameters from `PathValue`, `chi.URLParam`,
+//!   `mux.Vars`, `Param`, `Query`, `QueryParam` and `Header.Get` calls, typed
+//!   from the `strconv` conversion applied to them;
+//! - the request body type from `Bind`, `ShouldBindJSON`, `Decode` and
+//!   `Unmarshal` calls;
+//! - swag-style comment annotations (`@Summary`, `@Param`, `@Success`,
+//!   `@Failure`) on the handler or the registration, plus `@Request <Type>`
+//!   and `@Response <status> <Type>`.
+//!
+//! Prefixes are followed through variables within a function and into router
+//! constructors passed to chi `Mount`; routers handed to another function as
+//! an argument lose their prefix. [`openapi_document`] turns the routes into a
+//! partial OpenAPI 3.0 document.
+
+mod go;
+pub mod openapi;
+
+use std::collections::{BTreeMap, HashMap};
+use std::path::{Path, PathBuf};
+
+use ignore::WalkBuilder;
+use serde::{Deserialize, Serialize};
+use tracing::warn;
+
+use crate::core::errors::{Result, ValknutError};
+use crate::core::file_utils::FileReader;
+use crate::core::pipeline::discovery::IGNORE_FILE_NAME;
+
+pub use openapi::{openapi_document, OpenApiDocument};
+
+/// Directories never searched for routes.
+const SKIPPED_DIRS: &[&str] = &["vendor", "testdata"];
+
+/// Where a handler reads a parameter from.
+#[derive(Debug, Clone, Copy, PartialEq, Eq, PartialOrd, Ord, Hash, Serialize, Deserialize)]
+#[serde(rename_all = "lowercase")]
+pub enum ParameterLocation {
+    /// A path segment, e.g. `{id}`
+    Path,
+    /// A query string parameter
+    Query,
+    /// A request header
+    Header,
+}
+
+/// JSON type of a parameter value.
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Serialize, Deserialize)]
+#[serde(rename_all = "lowercase")]
+pub enum ParameterType {
+    /// Any text
+    String,
+    /// Parsed with `strconv.Atoi`, `ParseInt` or `ParseUint`
+    Integer,
+    /// Parsed with `strconv.ParseFloat`
+    Number,
+    /// Parsed with `strconv.ParseBool`
+    Boolean,
+}
+
+/// A parameter a handler reads.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct RouteParameter {
+    /// Parameter name as read by the handler
+    pub name: String,
+    /// Where the parameter is read from
+    pub location: ParameterLocation,
+    /// Inferred value type
+    pub schema_type: ParameterType,
+    /// Description from a `@Param` annotation
+    pub description: Option<String>,
+}
+
+/// A response documented by a comment annotation.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct RouteResponse {
+    /// HTTP status code, or `default`
+    pub status: String,
+    /// Go type of the body, without package qualifier
+    pub type_name: Option<String>,
+    /// Whether the body is an array of `type_name`
+    pub is_array: bool,
+    /// Description from the annotation
+    pub description: Option<String>,
+}
+
+/// One registered route.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct HttpRoute {
+    /// Upper-case HTTP methods; empty when the route accepts any method
+    pub methods: Vec<String>,
+    /// Full path in OpenAPI form, e.g. `/users/{id}`
+    pub path: String,
+    /// Handler as written at the registration, e.g. `h.GetUser`; `None` for
+    /// function literals
+    pub handler: Option<String>,
+    /// Registering file, relative to the extraction root
+    pub file_path: PathBuf,
+    /// 1-based line of the registration
+    pub line: usize,
+    /// Short description from `@Summary` or the first doc comment line
+    pub summary: Option<String>,
+    /// Longer description from `@Description` or the rest of the doc comment
+    pub description: Option<String>,
+    /// Tags from `@Tags`
+    pub tags: Vec<String>,
+    /// Parameters read by the handler or annotated with `@Param`
+    pub parameters: Vec<RouteParameter>,
+    /// Go type of the request body, without package qualifier
+    pub request_type: Option<String>,
+    /// Annotated responses
+    pub responses: Vec<RouteResponse>,
+}
+
+/// An exported field of a Go struct.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct GoField {
+    /// JSON name from the `json` tag, or the Go field name
+    pub json_name: String,
+    /// Go type as written, e.g. `[]*models.Item`
+    pub go_type: String,
+    /// Whether the `json` tag has `omitempty`
+    pub omit_empty: bool,
+}
+
+/// A Go struct type that request and response bodies can refer to.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct GoStruct {
+    /// Type name
+    pub name: String,
+    /// Doc comment text
+    pub doc: Option<String>,
+    /// Exported, JSON-visible fields in declaration order
+    pub fields: Vec<GoField>,
+}
+
+/// Routes and struct types found under a root.
+#[derive(Debug, Clone, Default, PartialEq, Eq, Serialize, Deserialize)]
+pub struct RouteSet {
+    /// Routes ordered by path, then method
+    pub routes: Vec<HttpRoute>,
+    /// Struct types by name
+    pub types: BTreeMap<String, GoStruct>,
+}
+
+/// Extract the HTTP routes registered in the Go files under `root` (a
+/// directory or a single file).
+///
+/// Test files, `vendor` and `testdata` directories are skipped, and
+/// `.gitignore` and `.valknutignore` files are honoured. Files that cannot be
+/// read or parsed are skipped with a warning.
+pub fn extract_routes(root: &Path) -> Result<RouteSet> {
+    if !root.exists() {
+        return Err(ValknutError::validation(format!(
+            "Path does not exist: {}",
+            root.display()
+        )));
+    }
+
+    let mut sources = Vec::new();
+    for path in go_files(root) {
+        let source = match FileReader::read_to_string(&path) {
+            Ok(source) => source,
+            Err(err) => {
+                warn!("Skipping {}: {}", path.display(), err);
+                continue;
+            }
+        };
+        let display_path = match path.strip_prefix(root) {
+            Ok(relative) if !relative.as_os_str().is_empty() => relative.to_path_buf(),
+            _ => PathBuf::from(path.file_name().unwrap_or_default()),
+        };
+        match go::GoSource::parse(display_path, source) {
+            Ok(parsed) => sources.push(parsed),
+            Err(err) => warn!("Failed to parse {}: {}", path.display(), err),
+        }
+    }
+
+    let mut mounts = HashMap::new();
+    let mut handlers = HashMap::new();
+    let mut types = BTreeMap::new();
+    for source in &sources {
+        mounts.extend(source.mounts());
+        handlers.extend(source.handlers());
+        types.extend(
+            source
+                .structs()
+                .into_iter()
+                .map(|go_struct| (go_struct.name.clone(), go_struct)),
+        );
+    }
+
+    let mut routes: Vec<HttpRoute> = sources
+        .iter()
+        .flat_map(|source| source.routes(&mounts))
+        .map(|registration| registration.resolve(&handlers))
+        .collect();
+    routes.sort_by(|a, b| {
+        (&a.path, &a.methods, &a.file_path, a.line).cmp(&(
+            &b.path,
+            &b.methods,
+            &b.file_path,
+            b.line,
+        ))
+    });
+
+    Ok(RouteSet { routes, types })
+}
+
+/// Go source files under `root`, excluding tests, sorted by path.
+fn go_files(root: &Path) -> Vec<PathBuf> {
+    if root.is_file() {
+        return vec![root.to_path_buf()];
+    }
+    let mut files: Vec<PathBuf> = WalkBuilder::new(root)
+        .add_custom_ignore_filename(IGNORE_FILE_NAME)
+        .filter_entry(|entry| {
+            !entry.file_type().is_some_and(|kind| kind.is_dir())
+                || !SKIPPED_DIRS.contains(&entry.file_name().to_string_lossy().as_ref())
+        })
+        .build()
+        .filter_map(|entry| entry.ok().map(|entry| entry.into_path()))
+        .filter(|path| path.is_file())
+        .filter(|path| {
+            let name = path.file_name().unwrap_or_default().to_string_lossy();
+            name.ends_with(".go") && !name.ends_with("_test.go")
+        })
+        .collect();
+    files.sort();
+    files
+}
+
+#[cfg(test)]
+mod tests;
@@ -0,0 +1,471 @@
+//! Partial OpenAPI 3.0 documents built from extracted routes.
+//!
+//! Only what the source shows is emitted: paths, methods, parameters, request
+//! bodies and annotated responses, with schemas for the Go structs they refer
+//! to. Each operation records its handler under the `x-handler` extension;
+//! routes accepting any method are listed under `get` with `x-any-method`.
+
+use std::collections::{BTreeMap, BTreeSet, HashSet};
+
+use serde::{Deserialize, Serialize};
+use serde_json::{json, Map, Value};
+
+use super::{GoStruct, HttpRoute, ParameterLocation, ParameterType, RouteSet};
+use crate::core::errors::{Result, ValknutError};
+
+/// OpenAPI version of generated documents.
+const OPENAPI_VERSION: &str = "3.0.3";
+
+/// Placeholder `info.version`; the source does not say which API version it serves.
+const DOCUMENT_VERSION: &str = "0.0.0";
+
+/// Prefix of component schema references.
+const SCHEMA_REF_PREFIX: &str = "#/components/schemas/";
+
+/// Media type of request and response bodies.
+const JSON_MEDIA_TYPE: &str = "application/json";
+
+/// An OpenAPI document.
+#[derive(Debug, Clone, PartialEq, Serialize, Deserialize)]
+pub struct OpenApiDocument {
+    /// OpenAPI version
+    pub openapi: String,
+    /// Title and version
+    pub info: Info,
+    /// Operations by path, then lower-case method
+    pub paths: BTreeMap<String, BTreeMap<String, Operation>>,
+    /// Schemas of referenced struct types
+    #[serde(default, skip_serializing_if = "Components::is_empty")]
+    pub components: Components,
+}
+
+/// The `info` object.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct Info {
+    /// API title
+    pub title: String,
+    /// API version
+    pub version: String,
+}
+
+/// The `components` object.
+#[derive(Debug, Clone, Default, PartialEq, Serialize, Deserialize)]
+pub struct Components {
+    /// JSON schemas by Go type name
+    #[serde(default, skip_serializing_if = "BTreeMap::is_empty")]
+    pub schemas: BTreeMap<String, Value>,
+}
+
+/// One operation of a path.
+#[derive(Debug, Clone, PartialEq, Serialize, Deserialize)]
+#[serde(rename_all = "camelCase")]
+pub struct Operation {
+    /// Unique operation id, from the handler name when there is one
+    pub operation_id: String,
+    /// Short description
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub summary: Option<String>,
+    /// Longer description
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub description: Option<String>,
+    /// Tags
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub tags: Vec<String>,
+    /// Path, query and header parameters
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub parameters: Vec<Parameter>,
+    /// JSON request body
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub request_body: Option<RequestBody>,
+    /// Responses by status code
+    pub responses: BTreeMap<String, Response>,
+    /// Where the route is registered
+    #[serde(rename = "x-handler")]
+    pub handler: HandlerLocation,
+    /// Whether the route accepts any method
+    #[serde(
+        rename = "x-any-method",
+        default,
+        skip_serializing_if = "std::ops::Not::not"
+    )]
+    pub any_method: bool,
+}
+
+/// An operation parameter.
+#[derive(Debug, Clone, PartialEq, Serialize, Deserialize)]
+pub struct Parameter {
+    /// Parameter name
+    pub name: String,
+    /// Parameter location
+    #[serde(rename = "in")]
+    pub location: ParameterLocation,
+    /// Whether the parameter must be present; always true for path parameters
+    pub required: bool,
+    /// Value schema
+    pub schema: Value,
+    /// Description
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub description: Option<String>,
+}
+
+/// A request body.
+#[derive(Debug, Clone, PartialEq, Serialize, Deserialize)]
+pub struct RequestBody {
+    /// Whether the body must be present
+    pub required: bool,
+    /// Schema by media type
+    pub content: BTreeMap<String, MediaType>,
+}
+
+/// A response.
+#[derive(Debug, Clone, PartialEq, Serialize, Deserialize)]
+pub struct Response {
+    /// Description
+    pub description: String,
+    /// Schema by media type, when the body type is known
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub content: Option<BTreeMap<String, MediaType>>,
+}
+
+/// A media type object.
+#[derive(Debug, Clone, PartialEq, Serialize, Deserialize)]
+pub struct MediaType {
+    /// Body schema
+    pub schema: Value,
+}
+
+/// The `x-handler` extension of an operation.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct HandlerLocation {
+    /// Handler as written at the registration
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub name: Option<String>,
+    /// Registering file
+    pub file: String,
+    /// 1-based line of the registration
+    pub line: usize,
+}
+
+/// Helper methods for [`Components`].
+impl Components {
+    /// Whether there is nothing to emit.
+    pub fn is_empty(&self) -> bool {
+        self.schemas.is_empty()
+    }
+}
+
+/// Serialization methods for [`OpenApiDocument`].
+impl OpenApiDocument {
+    /// Render the document as pretty-printed JSON.
+    pub fn to_json(&self) -> Result<String> {
+        serde_json::to_string_pretty(self).map_err(|e| {
+            ValknutError::internal(format!("Failed to serialize OpenAPI document: {e}"))
+        })
+    }
+
+    /// Render the document as YAML.
+    pub fn to_yaml(&self) -> Result<String> {
+        serde_yaml::to_string(self).map_err(|e| {
+            ValknutError::internal(format!("Failed to serialize OpenAPI document: {e}"))
+        })
+    }
+}
+
+/// Build an OpenAPI document titled `title` from `routes`. When the same path
+/// and method are registered more than once, the first registration wins.
+pub fn openapi_document(routes: &RouteSet, title: &str) -> OpenApiDocument {
+    let mut schemas = SchemaBuilder::new(&routes.types);
+    let mut operation_ids = HashSet::new();
+    let mut paths: BTreeMap<String, BTreeMap<String, Operation>> = BTreeMap::new();
+
+    for route in &routes.routes {
+        let methods: Vec<String> = if route.methods.is_empty() {
+            vec!["get".to_string()]
+        } else {
+            route
+                .methods
+                .iter()
+                .map(|method| method.to_ascii_lowercase())
+                .collect()
+        };
+        let operations = paths.entry(route.path.clone()).or_default();
+        for method in methods {
+            if operations.contains_key(&method) {
+                continue;
+            }
+            let built = operation(route, &method, &mut schemas, &mut operation_ids);
+            operations.insert(method, built);
+        }
+    }
+
+    OpenApiDocument {
+        openapi: OPENAPI_VERSION.to_string(),
+        info: Info {
+            title: title.to_string(),
+            version: DOCUMENT_VERSION.to_string(),
+        },
+        paths,
+        components: Components {
+            schemas: schemas.into_schemas(),
+        },
+    }
+}
+
+/// The operation for `route` under `method`.
+fn operation(
+    route: &HttpRoute,
+    method: &str,
+    schemas: &mut SchemaBuilder<'_>,
+    operation_ids: &mut HashSet<String>,
+) -> Operation {
+    let mut parameters: Vec<Parameter> = path_parameters(&route.path)
+        .into_iter()
+        .map(|name| {
+            let known = route.parameters.iter().find(|parameter| {
+                parameter.location == ParameterLocation::Path && parameter.name == name
+            });
+            Parameter {
+                name: name.to_string(),
+                location: ParameterLocation::Path,
+                required: true,
+                schema: parameter_schema(
+                    known.map_or(ParameterType::String, |parameter| parameter.schema_type),
+                ),
+                description: known.and_then(|parameter| parameter.description.clone()),
+            }
+        })
+        .collect();
+    parameters.extend(
+        route
+            .parameters
+            .iter()
+            .filter(|parameter| parameter.location != ParameterLocation::Path)
+            .map(|parameter| Parameter {
+                name: parameter.name.clone(),
+                location: parameter.location,
+                required: false,
+                schema: parameter_schema(parameter.schema_type),
+                description: parameter.description.clone(),
+            }),
+    );
+
+    let mut responses: BTreeMap<String, Response> = BTreeMap::new();
+    for response in &route.responses {
+        let content = response
+            .type_name
+            .as_deref()
+            .map(|type_name| json_content(schemas.body(type_name, response.is_array)));
+        responses
+            .entry(response.status.clone())
+            .or_insert_with(|| Response {
+                description: response
+                    .description
+                    .clone()
+                    .unwrap_or_else(|| format!("{} response", response.status)),
+                content,
+            });
+    }
+    if responses.is_empty() {
+        responses.insert(
+            "default".to_string(),
+            Response {
+                description: "Response not documented".to_string(),
+                content: None,
+            },
+        );
+    }
+
+    Operation {
+        operation_id: operation_id(route, method, operation_ids),
+        summary: route.summary.clone(),
+        description: route.description.clone(),
+        tags: route.tags.clone(),
+        parameters,
+        request_body: route.request_type.as_deref().map(|type_name| RequestBody {
+            required: true,
+            content: json_content(schemas.body(type_name, false)),
+        }),
+        responses,
+        handler: HandlerLocation {
+            name: route.handler.clone(),
+            file: route.file_path.to_string_lossy().replace('\\', "/"),
+            line: route.line,
+        },
+        any_method: route.methods.is_empty(),
+    }
+}
+
+/// A unique operation id: the handler's function name when it is a plain
+/// (possibly qualified) identifier, otherwise `<method>_<path>`.
+fn operation_id(route: &HttpRoute, method: &str, taken: &mut HashSet<String>) -> String {
+    let handler_name = route
+        .handler
+        .as_deref()
+        .filter(|handler| {
+            handler
+                .chars()
+                .all(|c| c.is_alphanumeric() || c == '_' || c == '.')
+        })
+        .and_then(|handler| handler.rsplit('.').next())
+        .filter(|name| !name.is_empty());
+    let base = match handler_name {
+        Some(name) => name.to_string(),
+        None => {
+            let path = identifier_from_path(&route.path);
+            format!("{method}_{}", if path.is_empty() { "root" } else { &path })
+        }
+    };
+
+    let mut candidate = base.clone();
+    let mut suffix = 1;
+    while taken.contains(&candidate) {
+        suffix += 1;
+        candidate = if suffix == 2 && handler_name.is_some() {
+            format!("{base}_{method}")
+        } else {
+            format!("{base}_{suffix}")
+        };
+    }
+    taken.insert(candidate.clone());
+    candidate
+}
+
+/// Path characters outside `[A-Za-z0-9]` collapsed into single underscores.
+fn identifier_from_path(path: &str) -> String {
+    path.split(|c: char| !c.is_ascii_alphanumeric())
+        .filter(|part| !part.is_empty())
+        .collect::<Vec<_>>()
+        .join("_")
+}
+
+/// Names of the `{name}` segments of a path, in order.
+fn path_parameters(path: &str) -> Vec<&str> {
+    path.split('/')
+        .filter_map(|segment| segment.strip_prefix('{')?.strip_suffix('}'))
+        .collect()
+}
+
+/// Schema of a parameter value.
+fn parameter_schema(schema_type: ParameterType) -> Value {
+    let name = match schema_type {
+        ParameterType::String => "string",
+        ParameterType::Integer => "integer",
+        ParameterType::Number => "number",
+        ParameterType::Boolean => "boolean",
+    };
+    json!({ "type": name })
+}
+
+/// `content` of a JSON body with `schema`.
+fn json_content(schema: Value) -> BTreeMap<String, MediaType> {
+    BTreeMap::from([(JSON_MEDIA_TYPE.to_string(), MediaType { schema })])
+}
+
+/// Builds schemas for Go types and collects the struct schemas they reference.
+struct SchemaBuilder<'a> {
+    types: &'a BTreeMap<String, GoStruct>,
+    referenced: BTreeSet<String>,
+}
+
+/// Schema construction methods for [`SchemaBuilder`].
+impl<'a> SchemaBuilder<'a> {
+    /// A builder resolving struct names against `types`.
+    fn new(types: &'a BTreeMap<String, GoStruct>) -> Self {
+        Self {
+            types,
+            referenced: BTreeSet::new(),
+        }
+    }
+
+    /// Schema of a body of type `type_name`, or of an array of it.
+    fn body(&mut self, type_name: &str, is_array: bool) -> Value {
+        let schema = self.schema(type_name);
+        if is_array {
+            json!({ "type": "array", "items": schema })
+        } else {
+            schema
+        }
+    }
+
+    /// Schema of a Go type expression. Known structs become references;
+    /// other named types are kept under `x-go-type`.
+    fn schema(&mut self, go_type: &str) -> Value {
+        let go_type = go_type.trim();
+        if let Some(pointee) = go_type.strip_prefix('*') {
+            return self.schema(pointee);
+        }
+        if go_type == "[]byte" {
+            return json!({ "type": "string", "format": "byte" });
+        }
+        if let Some(element) = go_type.strip_prefix("[]") {
+            return json!({ "type": "array", "items": self.schema(element) });
+        }
+        if let Some(value) = go_type
+            .strip_prefix("map[")
+            .and_then(|rest| rest.split_once(']'))
+            .map(|(_, value)| value)
+        {
+            return json!({ "type": "object", "additionalProperties": self.schema(value) });
+        }
+
+        match go_type {
+            "string" => json!({ "type": "string" }),
+            "int32" | "uint32" | "rune" => json!({ "type": "integer", "format": "int32" }),
+            "int64" | "uint64" => json!({ "type": "integer", "format": "int64" }),
+            "int" | "int8" | "int16" | "uint" | "uint8" | "uint16" | "uintptr" | "byte" => {
+                json!({ "type": "integer" })
+            }
+            "float32" => json!({ "type": "number", "format": "float" }),
+            "float64" => json!({ "type": "number", "format": "double" }),
+            "bool" => json!({ "type": "boolean" }),
+            "time.Time" => json!({ "type": "string", "format": "date-time" }),
+            "interface{}" | "any" | "json.RawMessage" => json!({}),
+            _ => {
+                let name = go_type.rsplit('.').next().unwrap_or(go_type);
+                if self.types.contains_key(name) {
+                    self.referenced.insert(name.to_string());
+                    json!({ "$ref": format!("{SCHEMA_REF_PREFIX}{name}") })
+                } else {
+                    json!({ "x-go-type": go_type })
+                }
+            }
+        }
+    }
+
+    /// Schemas of every referenced struct, following references between structs.
+    fn into_schemas(mut self) -> BTreeMap<String, Value> {
+        let types = self.types;
+        let mut schemas = BTreeMap::new();
+        let mut pending: Vec<String> = self.referenced.iter().cloned().collect();
+        while let Some(name) = pending.pop() {
+            if schemas.contains_key(&name) {
+                continue;
+            }
+            let Some(go_struct) = types.get(&name) else {
+                continue;
+            };
+            let schema = self.struct_schema(go_struct);
+            schemas.insert(name, schema);
+            pending.extend(
+                self.referenced
+                    .iter()
+                    .filter(|name| !schemas.contains_key(*name))
+                    .cloned(),
+            );
+        }
+        schemas
+    }
+
+    /// Object schema of a struct.
+    fn struct_schema(&mut self, go_struct: &GoStruct) -> Value {
+        let mut properties = Map::new();
+        for field in &go_struct.fields {
+            properties.insert(field.json_name.clone(), self.schema(&field.go_type));
+        }
+        let mut schema = json!({ "type": "object", "properties": properties });
+        if let Some(doc) = &go_struct.doc {
+            schema["description"] = Value::String(doc.clone());
+        }
+        schema
+    }
+}
@@ -0,0 +1,501 @@
+use super::*;
+use serde_json::json;
+use std::fs;
+use tempfile::TempDir;
+
+const NET_HTTP_SERVICE: &str = r#"package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+// CreateItemRequest is the payload for creating an item.
+type CreateItemRequest struct {
+	Name     string   `json:"name"`
+	Price    float64  `json:"price,omitempty"`
+	Tags     []string `json:"tags"`
+	internal string
+}
+
+// getItem returns one item.
+func getItem(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	_, _ = id, err
+	verbose := r.URL.Query().Get("verbose")
+	_ = verbose
+}
+
+func createItem(w http.ResponseWriter, r *http.Request) {
+	var req CreateItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return
+	}
+}
+
+func items(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodDelete:
+	}
+}
+
+func main() {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /items/{id}", getItem)
+	mux.HandleFunc("POST /items", createItem)
+	mux.HandleFunc("/items/all", items)
+	http.ListenAndServe(":8080", mux)
+}
+"#;
+
+const CHI_SERVICE: &str = r#"package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func NewRouter(h *Handler) http.Handler {
+	r := chi.NewRouter()
+	r.Route("/users", func(r chi.Router) {
+		r.Get("/", h.ListUsers)
+		r.With(auth).Delete("/{userID}", h.DeleteUser)
+	})
+	r.Mount("/admin", adminRouter())
+	return r
+}
+
+func adminRouter() http.Handler {
+	r := chi.NewRouter()
+	r.Post("/reindex", reindex)
+	return r
+}
+
+// ListUsers lists users.
+// Results are paginated.
+func (h *Handler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	_ = limit
+}
+
+func (h *Handler) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "userID")
+	_ = id
+}
+
+func reindex(w http.ResponseWriter, r *http.Request) {}
+"#;
+
+const GIN_SERVICE: &str = r#"package main
+
+import (
+	"net/http"
+
+	"example.com/shop/models"
+	"github.com/gin-gonic/gin"
+)
+
+type Order struct {
+	ID     int64         `json:"id"`
+	Items  []models.Item `json:"items"`
+	Note   *string       `json:"note,omitempty"`
+	Secret string        `json:"-"`
+}
+
+type Item struct {
+	SKU string `json:"sku"`
+}
+
+type Unused struct {
+	Field string
+}
+
+// CreateOrder godoc
+// @Summary Create an order
+// @Tags orders
+// @Param order body Order true "Order to create"
+// @Success 201 {object} Order
+// @Failure 400 {object} ErrorResponse "invalid payload"
+func CreateOrder(c *gin.Context) {
+	var order Order
+	if err := c.ShouldBindJSON(&order); err != nil {
+		c.JSON(http.StatusBadRequest, nil)
+	}
+}
+
+func setup() *gin.Engine {
+	r := gin.Default()
+	v1 := r.Group("/api/v1")
+	{
+		orders := v1.Group("/orders")
+		orders.POST("", CreateOrder)
+		orders.GET("/:id", func(c *gin.Context) {
+			c.Param("id")
+			c.DefaultQuery("expand", "false")
+		})
+	}
+	return r
+}
+"#;
+
+const ECHO_SERVICE: &str = r#"package main
+
+import (
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+type Handler struct{}
+
+func (h *Handler) Search(c echo.Context) error {
+	page, _ := strconv.Atoi(c.QueryParam("page"))
+	_ = page
+	return nil
+}
+
+func register(e *echo.Echo, h *Handler) {
+	api := e.Group("/api")
+	// @Summary Search products
+	api.GET("/search", h.Search, middleware.Logger())
+	e.Any("/health", health)
+}
+"#;
+
+const GORILLA_SERVICE: &str = r#"package main
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+func routes() *mux.Router {
+	r := mux.NewRouter()
+	api := r.PathPrefix("/api").Subrouter()
+	api.HandleFunc("/products/{id:[0-9]+}", getProduct).Methods("GET", http.MethodHead).Name("product")
+	return r
+}
+
+func getProduct(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	_ = vars["id"]
+	token := r.Header.Get("X-Token")
+	_ = token
+}
+"#;
+
+fn write_files(files: &[(&str, &str)]) -> TempDir {
+    let dir = TempDir::new().unwrap();
+    for (name, source) in files {
+        let path = dir.path().join(name);
+        fs::create_dir_all(path.parent().unwrap()).unwrap();
+        fs::write(path, source).unwrap();
+    }
+    dir
+}
+
+fn extract(files: &[(&str, &str)]) -> RouteSet {
+    let dir = write_files(files);
+    extract_routes(dir.path()).expect("routes should be extracted")
+}
+
+fn route<'a>(routes: &'a RouteSet, path: &str) -> &'a HttpRoute {
+    routes
+        .routes
+        .iter()
+        .find(|route| route.path == path)
+        .unwrap_or_else(|| panic!("no route for {path}: {:#?}", routes.routes))
+}
+
+fn parameter(
+    name: &str,
+    location: ParameterLocation,
+    schema_type: ParameterType,
+) -> RouteParameter {
+    RouteParameter {
+        name: name.to_string(),
+        location,
+        schema_type,
+        description: None,
+    }
+}
+
+#[test]
+fn net_http_patterns_give_methods_parameters_and_body_types() {
+    let routes = extract(&[
+        ("main.go", NET_HTTP_SERVICE),
+        ("main_test.go", "package main\nimport \"net/http\"\nfunc init() { http.HandleFunc(\"/test-only\", nil) }\n"),
+        ("vendor/lib/lib.go", "package lib\nimport \"net/http\"\nfunc init() { http.HandleFunc(\"/vendored\", nil) }\n"),
+    ]);
+
+    let paths: Vec<&str> = routes
+        .routes
+        .iter()
+        .map(|route| route.path.as_str())
+        .collect();
+    assert_eq!(paths, vec!["/items", "/items/all", "/items/{id}"]);
+
+    let get_item = route(&routes, "/items/{id}");
+    assert_eq!(get_item.methods, vec!["GET"]);
+    assert_eq!(get_item.handler.as_deref(), Some("getItem"));
+    assert_eq!(get_item.file_path, PathBuf::from("main.go"));
+    assert_eq!(get_item.line, 41);
+    assert_eq!(
+        get_item.summary.as_deref(),
+        Some("getItem returns one item.")
+    );
+    assert_eq!(
+        get_item.parameters,
+        vec![
+            parameter("id", ParameterLocation::Path, ParameterType::Integer),
+            parameter("verbose", ParameterLocation::Query, ParameterType::String),
+        ]
+    );
+
+    let create_item = route(&routes, "/items");
+    assert_eq!(create_item.methods, vec!["POST"]);
+    assert_eq!(
+        create_item.request_type.as_deref(),
+        Some("CreateItemRequest")
+    );
+
+    // Registered without a method: the methods the handler checks for.
+    assert_eq!(route(&routes, "/items/all").methods, vec!["GET", "DELETE"]);
+
+    let request = &routes.types["CreateItemRequest"];
+    assert_eq!(
+        request.doc.as_deref(),
+        Some("CreateItemRequest is the payload for creating an item.")
+    );
+    let fields: Vec<(&str, bool)> = request
+        .fields
+        .iter()
+        .map(|field| (field.json_name.as_str(), field.omit_empty))
+        .collect();
+    assert_eq!(
+        fields,
+        vec![("name", false), ("price", true), ("tags", false)]
+    );
+}
+
+#[test]
+fn chi_routes_apply_route_and_mount_prefixes() {
+    let routes = extract(&[("router.go", CHI_SERVICE)]);
+
+    let paths: Vec<(&str, &[String])> = routes
+        .routes
+        .iter()
+        .map(|route| (route.path.as_str(), route.methods.as_slice()))
+        .collect();
+    assert_eq!(
+        paths,
+        vec![
+            ("/admin/reindex", &["POST".to_string()][..]),
+            ("/users", &["GET".to_string()][..]),
+            ("/users/{userID}", &["DELETE".to_string()][..]),
+        ]
+    );
+
+    let list = route(&routes, "/users");
+    assert_eq!(list.handler.as_deref(), Some("h.ListUsers"));
+    assert_eq!(list.summary.as_deref(), Some("ListUsers lists users."));
+    assert_eq!(list.description.as_deref(), Some("Results are paginated."));
+    assert_eq!(
+        list.parameters,
+        vec![parameter(
+            "limit",
+            ParameterLocation::Query,
+            ParameterType::Integer
+        )]
+    );
+
+    assert_eq!(
+        route(&routes, "/users/{userID}").parameters,
+        vec![parameter(
+            "userID",
+            ParameterLocation::Path,
+            ParameterType::String
+        )]
+    );
+}
+
+#[test]
+fn gin_groups_annotations_and_inline_handlers() {
+    let routes = extract(&[("main.go", GIN_SERVICE)]);
+
+    let create = route(&routes, "/api/v1/orders");
+    assert_eq!(create.methods, vec!["POST"]);
+    assert_eq!(create.handler.as_deref(), Some("CreateOrder"));
+    assert_eq!(create.summary.as_deref(), Some("Create an order"));
+    assert_eq!(create.tags, vec!["orders"]);
+    assert_eq!(create.request_type.as_deref(), Some("Order"));
+    assert_eq!(
+        create.responses,
+        vec![
+            RouteResponse {
+                status: "201".to_string(),
+                type_name: Some("Order".to_string()),
+                is_array: false,
+                description: None,
+            },
+            RouteResponse {
+                status: "400".to_string(),
+                type_name: Some("ErrorResponse".to_string()),
+                is_array: false,
+                description: Some("invalid payload".to_string()),
+            },
+        ]
+    );
+
+    let show = route(&routes, "/api/v1/orders/{id}");
+    assert_eq!(show.methods, vec!["GET"]);
+    assert_eq!(show.handler, None);
+    assert_eq!(
+        show.parameters,
+        vec![
+            parameter("id", ParameterLocation::Path, ParameterType::String),
+            parameter("expand", ParameterLocation::Query, ParameterType::String),
+        ]
+    );
+
+    let order_fields: Vec<&str> = routes.types["Order"]
+        .fields
+        .iter()
+        .map(|field| field.json_name.as_str())
+        .collect();
+    assert_eq!(order_fields, vec!["id", "items", "note"]);
+}
+
+#[test]
+fn echo_registration_comments_and_any_method_routes() {
+    let routes = extract(&[("main.go", ECHO_SERVICE)]);
+
+    let search = route(&routes, "/api/search");
+    assert_eq!(search.methods, vec!["GET"]);
+    assert_eq!(search.handler.as_deref(), Some("h.Search"));
+    assert_eq!(search.summary.as_deref(), Some("Search products"));
+    assert_eq!(
+        search.parameters,
+        vec![parameter(
+            "page",
+            ParameterLocation::Query,
+            ParameterType::Integer
+        )]
+    );
+
+    let health = route(&routes, "/health");
+    assert!(health.methods.is_empty());
+    assert_eq!(health.handler.as_deref(), Some("health"));
+}
+
+#[test]
+fn gorilla_subrouters_and_chained_methods() {
+    let routes = extract(&[("routes.go", GORILLA_SERVICE)]);
+
+    assert_eq!(routes.routes.len(), 1);
+    let product = &routes.routes[0];
+    assert_eq!(product.path, "/api/products/{id}");
+    assert_eq!(product.methods, vec!["GET", "HEAD"]);
+    assert_eq!(
+        product.parameters,
+        vec![
+            parameter("id", ParameterLocation::Path, ParameterType::Integer),
+            parameter("X-Token", ParameterLocation::Header, ParameterType::String),
+        ]
+    );
+}
+
+#[test]
+fn normalize_path_rewrites_framework_parameters() {
+    assert_eq!(go::normalize_path("/users/:id"), "/users/{id}");
+    assert_eq!(go::normalize_path("/files/*path"), "/files/{path}");
+    assert_eq!(go::normalize_path("/items/{id:[0-9]+}"), "/items/{id}");
+    assert_eq!(go::normalize_path("/static/{rest...}"), "/static/{rest}");
+    assert_eq!(go::normalize_path("/items/{$}"), "/items/");
+}
+
+#[test]
+fn missing_root_is_a_validation_error() {
+    let dir = TempDir::new().unwrap();
+    let err = extract_routes(&dir.path().join("missing")).unwrap_err();
+    assert!(matches!(err, ValknutError::Validation { .. }));
+}
+
+#[test]
+fn openapi_document_references_bound_and_annotated_types() {
+    let routes = extract(&[("main.go", GIN_SERVICE)]);
+    let document = serde_json::to_value(openapi_document(&routes, "Shop")).unwrap();
+
+    assert_eq!(document["openapi"], "3.0.3");
+    assert_eq!(document["info"]["title"], "Shop");
+
+    let create = &document["paths"]["/api/v1/orders"]["post"];
+    assert_eq!(create["operationId"], "CreateOrder");
+    assert_eq!(create["summary"], "Create an order");
+    assert_eq!(
+        create["requestBody"]["content"]["application/json"]["schema"],
+        json!({ "$ref": "#/components/schemas/Order" })
+    );
+    assert_eq!(
+        create["responses"]["201"]["content"]["application/json"]["schema"],
+        json!({ "$ref": "#/components/schemas/Order" })
+    );
+    assert_eq!(create["responses"]["400"]["description"], "invalid payload");
+    assert_eq!(
+        create["responses"]["400"]["content"]["application/json"]["schema"],
+        json!({ "x-go-type": "ErrorResponse" })
+    );
+    assert_eq!(create["x-handler"]["name"], "CreateOrder");
+
+    let show = &document["paths"]["/api/v1/orders/{id}"]["get"];
+    assert_eq!(show["operationId"], "get_api_v1_orders_id");
+    assert_eq!(
+        show["parameters"],
+        json!([
+            { "name": "id", "in": "path", "required": true, "schema": { "type": "string" } },
+            { "name": "expand", "in": "query", "required": false, "schema": { "type": "string" } },
+        ])
+    );
+    assert_eq!(
+        show["responses"],
+        json!({ "default": { "description": "Response not documented" } })
+    );
+
+    let schemas = document["components"]["schemas"].as_object().unwrap();
+    let mut names: Vec<&str> = schemas.keys().map(String::as_str).collect();
+    names.sort();
+    assert_eq!(names, vec!["Item", "Order"]);
+    assert_eq!(
+        schemas["Order"]["properties"],
+        json!({
+            "id": { "type": "integer", "format": "int64" },
+            "items": { "type": "array", "items": { "$ref": "#/components/schemas/Item" } },
+            "note": { "type": "string" },
+        })
+    );
+}
+
+#[test]
+fn openapi_document_marks_any_method_routes_and_renders_yaml() {
+    let routes = extract(&[("main.go", ECHO_SERVICE)]);
+    let document = openapi_document(&routes, "Catalog");
+
+    let health = &document.paths["/health"]["get"];
+    assert!(health.any_method);
+    assert_eq!(health.operation_id, "health");
+
+    let search = &document.paths["/api/search"]["get"];
+    assert_eq!(search.parameters[0].schema, json!({ "type": "integer" }));
+    assert!(document.components.is_empty());
+
+    let yaml = document.to_yaml().unwrap();
+    assert!(yaml.contains("x-any-method: true"));
+    let parsed: OpenApiDocument = serde_yaml::from_str(&yaml).unwrap();
+    assert_eq!(parsed, document);
+}
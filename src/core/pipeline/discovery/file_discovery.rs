@@ -2,49 +2,243 @@
 //!
 //! This module centralizes file discovery so the analysis pipeline only
 //! processes files that are actually tracked (or explicitly requested) while
-//! respecting both repository ignore rules and Valknut configuration globs.
+//! respecting repository ignore rules, `.valknutignore` files and Valknut
+//! configuration globs.
 
-use std::collections::HashSet;
+use std::collections::{BTreeMap, HashSet};
 use std::fs;
 use std::path::{Path, PathBuf};
 
 use git2::Repository;
 use globset::{GlobBuilder, GlobSet, GlobSetBuilder};
-use ignore::WalkBuilder;
+use serde::{Deserialize, Serialize};
 use tracing::{info, warn};
 
+use crate::core::config::byte_size::format_byte_size;
 use crate::core::config::ValknutConfig;
 use crate::core::errors::{Result, ValknutError};
+use crate::lang::detection::{
+    read_first_line, resolve_file_language, shebang_language, LanguageMatch,
+};
+use crate::lang::go_build::BuildTarget;
 
 use crate::core::pipeline::pipeline_config::AnalysisConfig as PipelineAnalysisConfig;
 
+use super::ignore_file::{IgnoreFileMatcher, IGNORE_FILE_NAME};
+use super::parallel_walk::ParallelWalker;
+
+/// Dependency, virtual-environment and build-output directories skipped by
+/// discovery unless `analysis.include_vendor` is set.
+pub const VENDOR_DIRECTORIES: &[&str] = &[
+    "vendor",
+    "third_party",
+    "node_modules",
+    "bower_components",
+    "__pycache__",
+    ".venv",
+    "venv",
+    ".tox",
+    "target",
+    "dist",
+    "build",
+    ".gradle",
+    ".next",
+];
+
+/// Version-control metadata directories, always skipped.
+pub const VCS_DIRECTORIES: &[&str] = &[".git", ".hg", ".svn"];
+
+/// Why discovery left a matching file out of analysis.
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Serialize, Deserialize)]
+pub enum SkipReason {
+    /// The file is larger than `max_file_size_bytes`.
+    #[serde(rename = "skipped_large_file")]
+    LargeFile,
+    /// The file maps to a language valknut has no parser for.
+    #[serde(rename = "language_unknown")]
+    LanguageUnknown,
+    /// Analysis did not reach the file, e.g. because `--timeout` expired.
+    #[serde(rename = "not_analyzed")]
+    NotAnalyzed,
+}
+
+/// Methods for [`SkipReason`].
+impl SkipReason {
+    /// Stable warning code used in reports.
+    pub fn code(self) -> &'static str {
+        match self {
+            Self::LargeFile => "skipped_large_file",
+            Self::LanguageUnknown => "language_unknown",
+            Self::NotAnalyzed => "not_analyzed",
+        }
+    }
+}
+
+/// A file that passed every discovery filter but was not analyzed.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct SkippedFile {
+    /// Path of the skipped file.
+    pub path: PathBuf,
+    /// Why it was skipped.
+    pub reason: SkipReason,
+    /// File size on disk.
+    pub size_bytes: u64,
+    /// Size limit in force when it was skipped.
+    pub limit_bytes: u64,
+    /// Language the file was mapped to, for [`SkipReason::LanguageUnknown`].
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub language: Option<String>,
+}
+
+/// Methods for [`SkippedFile`].
+impl SkippedFile {
+    /// One-line warning, e.g. `skipped_large_file: gen/api.pb.go (3.2MB exceeds 1MB limit)`
+    /// or `language_unknown: infra/main.hcl (no parser for terraform)`.
+    pub fn warning(&self) -> String {
+        let detail = match self.reason {
+            SkipReason::LargeFile => format!(
+                "{} exceeds {} limit",
+                format_byte_size(self.size_bytes),
+                format_byte_size(self.limit_bytes)
+            ),
+            SkipReason::LanguageUnknown => format!(
+                "no parser for {}",
+                self.language.as_deref().unwrap_or("unknown language")
+            ),
+            SkipReason::NotAnalyzed => "timed out before this file was reached".to_string(),
+        };
+        format!("{}: {} ({detail})", self.reason.code(), self.path.display())
+    }
+}
+
+/// Files selected for analysis plus the ones skipped after matching.
+#[derive(Debug, Clone, Default, PartialEq, Eq)]
+pub struct DiscoveredFiles {
+    /// Files to analyze, sorted.
+    pub files: Vec<PathBuf>,
+    /// Files that matched but were skipped, sorted by path.
+    pub skipped: Vec<SkippedFile>,
+}
+
 /// Discover source files for analysis using git metadata when available.
 pub fn discover_files(
     roots: &[PathBuf],
     pipeline_config: &PipelineAnalysisConfig,
     valknut_config: Option<&ValknutConfig>,
 ) -> Result<Vec<PathBuf>> {
+    discover_files_detailed(roots, pipeline_config, valknut_config).map(|found| found.files)
+}
+
+/// Discover source files, also reporting files skipped for exceeding the size
+/// limit or for mapping to a language without a parser.
+pub fn discover_files_detailed(
+    roots: &[PathBuf],
+    pipeline_config: &PipelineAnalysisConfig,
+    valknut_config: Option<&ValknutConfig>,
+) -> Result<DiscoveredFiles> {
     if roots.is_empty() {
-        return Ok(Vec::new());
+        return Ok(DiscoveredFiles::default());
     }
 
+    let no_mappings = BTreeMap::new();
+    let language_mappings = valknut_config
+        .map(|cfg| &cfg.analysis.language_mappings)
+        .unwrap_or(&no_mappings);
+
     let canonical_roots = canonicalize_roots(roots);
     let filter_context = build_filter_context(pipeline_config, valknut_config)?;
+    let use_ignore_files = valknut_config
+        .map(|cfg| cfg.analysis.use_ignore_files)
+        .unwrap_or(true);
+    let walker = valknut_config
+        .map(|cfg| {
+            ParallelWalker::new(cfg.analysis.discovery_fanout_depth)
+                .with_thread_limit(cfg.performance.max_threads)
+        })
+        .unwrap_or_default();
     let (tracked_files, repo_root) = find_repository(&canonical_roots)?;
 
-    let collected = if let Some(tracked) = tracked_files {
+    let mut collected = if let Some(tracked) = tracked_files {
         collect_from_git_tracked(
             tracked,
             &canonical_roots,
             repo_root.as_deref(),
             &filter_context,
+            use_ignore_files,
         )
     } else {
-        collect_from_filesystem_walk(&canonical_roots, &filter_context)
+        collect_from_filesystem_walk(&canonical_roots, &filter_context, use_ignore_files, walker)
     };
+    if let Some(only_files) = valknut_config.and_then(|cfg| cfg.analysis.only_files.as_ref()) {
+        retain_only_files(&mut collected, only_files);
+    }
+    if let Some(target) = valknut_config.and_then(|cfg| cfg.analysis.build_target.as_ref()) {
+        retain_buildable_go_files(&mut collected, target);
+    }
+
+    let mut found = split_oversized(collected, pipeline_config.max_file_size_bytes);
+    split_unknown_languages(&mut found, language_mappings);
+    log_discovery_results(&found.files);
+    Ok(found)
+}
+
+/// Move files larger than `max_file_size_bytes` (0 = unlimited) into `skipped`.
+fn split_oversized(collected: Vec<PathBuf>, max_file_size_bytes: u64) -> DiscoveredFiles {
+    let mut found = DiscoveredFiles::default();
+    for path in collected {
+        let size = fs::metadata(&path)
+            .map(|metadata| metadata.len())
+            .unwrap_or(0);
+        if max_file_size_bytes > 0 && size > max_file_size_bytes {
+            warn!(
+                "Skipping {} ({} bytes exceeds the {}-byte limit)",
+                path.display(),
+                size,
+                max_file_size_bytes
+            );
+            found.skipped.push(SkippedFile {
+                path,
+                reason: SkipReason::LargeFile,
+                size_bytes: size,
+                limit_bytes: max_file_size_bytes,
+                language: None,
+            });
+        } else {
+            found.files.push(path);
+        }
+    }
+    found
+}
 
-    log_discovery_results(&collected);
-    Ok(collected)
+/// Move files mapped to a language without a parser into `skipped`.
+fn split_unknown_languages(found: &mut DiscoveredFiles, mappings: &BTreeMap<String, String>) {
+    if mappings.is_empty() {
+        return;
+    }
+    let mut unknown = Vec::new();
+    found
+        .files
+        .retain(|path| match resolve_file_language(path, mappings) {
+            LanguageMatch::Unsupported(language) => {
+                unknown.push((path.clone(), language));
+                false
+            }
+            LanguageMatch::Supported(_) | LanguageMatch::Unrecognized => true,
+        });
+    for (path, language) in unknown {
+        warn!("Skipping {} (no parser for {})", path.display(), language);
+        let size_bytes = fs::metadata(&path)
+            .map(|metadata| metadata.len())
+            .unwrap_or(0);
+        found.skipped.push(SkippedFile {
+            path,
+            reason: SkipReason::LanguageUnknown,
+            size_bytes,
+            limit_bytes: 0,
+            language: Some(language),
+        });
+    }
+    found.skipped.sort_by(|a, b| a.path.cmp(&b.path));
 }
 
 /// Build the filter context with compiled glob patterns.
@@ -56,24 +250,16 @@ fn build_filter_context(
     Option<GlobSet>,
     Option<GlobSet>,
     HashSet<String>,
-    u64,
 )> {
-    let (include_patterns, mut exclude_patterns, ignore_patterns) =
+    let (include_patterns, exclude_patterns, ignore_patterns) =
         gather_patterns(pipeline_config, valknut_config);
-    exclude_patterns.push("**/.git/**".to_string());
 
     let include_glob = compile_globset(&include_patterns)?;
     let exclude_glob = compile_globset(&exclude_patterns)?;
     let ignore_glob = compile_globset(&ignore_patterns)?;
     let allowed_extensions = allowed_extensions_from(pipeline_config, valknut_config);
 
-    Ok((
-        include_glob,
-        exclude_glob,
-        ignore_glob,
-        allowed_extensions,
-        pipeline_config.max_file_size_bytes,
-    ))
+    Ok((include_glob, exclude_glob, ignore_glob, allowed_extensions))
 }
 
 /// Collect files from git-tracked file list.
@@ -86,11 +272,14 @@ fn collect_from_git_tracked(
         Option<GlobSet>,
         Option<GlobSet>,
         HashSet<String>,
-        u64,
     ),
+    use_ignore_files: bool,
 ) -> Vec<PathBuf> {
-    let (include_glob, exclude_glob, ignore_glob, allowed_extensions, max_file_size) =
-        filter_context;
+    let (include_glob, exclude_glob, ignore_glob, allowed_extensions) = filter_context;
+    let mut ignore_files = match (use_ignore_files, repo_root) {
+        (true, Some(root)) => Some(IgnoreFileMatcher::new(root)),
+        _ => None,
+    };
 
     info!(
         "Found git repository at '{}'. Using git index for file discovery.",
@@ -108,6 +297,14 @@ fn collect_from_git_tracked(
             continue;
         }
 
+        // Explicitly requested files bypass `.valknutignore`.
+        let explicitly_requested = canonical_roots.iter().any(|root| root == &file);
+        if let Some(matcher) = ignore_files.as_mut() {
+            if !explicitly_requested && matcher.is_ignored(&file) {
+                continue;
+            }
+        }
+
         let base = repo_root.unwrap_or_else(|| default_base_for(&file));
         if should_keep(
             &file,
@@ -116,7 +313,6 @@ fn collect_from_git_tracked(
             exclude_glob.as_ref(),
             ignore_glob.as_ref(),
             allowed_extensions,
-            *max_file_size,
         ) {
             add_unique(&mut unique, &mut collected, file);
         }
@@ -134,11 +330,11 @@ fn collect_from_filesystem_walk(
         Option<GlobSet>,
         Option<GlobSet>,
         HashSet<String>,
-        u64,
     ),
+    use_ignore_files: bool,
+    walker: ParallelWalker,
 ) -> Vec<PathBuf> {
-    let (include_glob, exclude_glob, ignore_glob, allowed_extensions, max_file_size) =
-        filter_context;
+    let (include_glob, exclude_glob, ignore_glob, allowed_extensions) = filter_context;
 
     warn!(
         "No git repository found for paths: {:?}. Falling back to filesystem walk. This may be slower.",
@@ -158,7 +354,6 @@ fn collect_from_filesystem_walk(
                 exclude_glob.as_ref(),
                 ignore_glob.as_ref(),
                 allowed_extensions,
-                *max_file_size,
             ) {
                 add_unique(&mut unique, &mut collected, root.clone());
             }
@@ -173,7 +368,8 @@ fn collect_from_filesystem_walk(
             exclude_glob,
             ignore_glob,
             allowed_extensions,
-            *max_file_size,
+            use_ignore_files,
+            walker,
         );
     }
 
@@ -181,7 +377,7 @@ fn collect_from_filesystem_walk(
     collected
 }
 
-/// Walk a directory and collect matching files.
+/// Walk a directory in parallel and collect matching files as they stream in.
 fn walk_directory(
     root: &Path,
     unique: &mut HashSet<PathBuf>,
@@ -190,47 +386,61 @@ fn walk_directory(
     exclude_glob: &Option<GlobSet>,
     ignore_glob: &Option<GlobSet>,
     allowed_extensions: &HashSet<String>,
-    max_file_size: u64,
+    use_ignore_files: bool,
+    walker: ParallelWalker,
 ) {
-    let walker = WalkBuilder::new(root)
-        .standard_filters(true)
-        .git_ignore(true)
-        .git_global(true)
-        .git_exclude(true)
-        .hidden(false)
-        .build();
-
-    for entry in walker {
-        let Ok(dir_entry) = entry else {
-            if let Err(err) = entry {
-                warn!("Failed to walk directory: {err}");
-            }
-            continue;
-        };
+    let mut ignore_files = use_ignore_files.then(|| IgnoreFileMatcher::new(root));
 
-        let is_file = dir_entry
-            .file_type()
-            .map(|ft| ft.is_file())
-            .unwrap_or(false);
-        if !is_file {
-            continue;
+    for path in walker.spawn(root.to_path_buf()) {
+        if let Some(matcher) = ignore_files.as_mut() {
+            if matcher.is_ignored(&path) {
+                continue;
+            }
         }
-
-        let path = dir_entry.path();
         if should_keep(
-            path,
+            &path,
             root,
             include_glob.as_ref(),
             exclude_glob.as_ref(),
             ignore_glob.as_ref(),
             allowed_extensions,
-            max_file_size,
         ) {
-            add_unique(unique, collected, path.to_path_buf());
+            add_unique(unique, collected, path);
         }
     }
 }
 
+/// Drop discovered files that are not in `only_files`.
+fn retain_only_files(collected: &mut Vec<PathBuf>, only_files: &[PathBuf]) {
+    let allowed: HashSet<PathBuf> = canonicalize_roots(only_files).into_iter().collect();
+    collected.retain(|file| {
+        allowed.contains(file) || fs::canonicalize(file).is_ok_and(|path| allowed.contains(&path))
+    });
+    info!(
+        "Restricted discovery to {} of {} requested files",
+        collected.len(),
+        only_files.len()
+    );
+}
+
+/// Drop Go files whose build constraints exclude `target`; other files stay.
+fn retain_buildable_go_files(collected: &mut Vec<PathBuf>, target: &BuildTarget) {
+    let before = collected.len();
+    collected.retain(|file| {
+        if file.extension().and_then(|ext| ext.to_str()) != Some("go") {
+            return true;
+        }
+        let source = fs::read_to_string(file).unwrap_or_default();
+        target.includes_file(file, &source)
+    });
+    info!(
+        "Skipped {} Go file(s) excluded by build constraints for {}/{}",
+        before - collected.len(),
+        target.os,
+        target.arch
+    );
+}
+
 /// Add a path to the collection if not already present.
 fn add_unique(unique: &mut HashSet<PathBuf>, collected: &mut Vec<PathBuf>, path: PathBuf) {
     if unique.insert(path.clone()) {
@@ -266,7 +476,8 @@ fn gather_patterns(
     valknut_config: Option<&ValknutConfig>,
 ) -> (Vec<String>, Vec<String>, Vec<String>) {
     let mut include_patterns = vec!["**/*".to_string()]; // Default baseline include
-    let mut exclude_patterns = default_exclude_patterns();
+    let include_vendor = valknut_config.is_some_and(|cfg| cfg.analysis.include_vendor);
+    let mut exclude_patterns = default_exclude_patterns(include_vendor);
     let mut ignore_patterns = Vec::new();
 
     if let Some(cfg) = valknut_config {
@@ -284,18 +495,23 @@ fn gather_patterns(
     (include_patterns, exclude_patterns, ignore_patterns)
 }
 
-/// Returns default patterns for commonly excluded directories.
-fn default_exclude_patterns() -> Vec<String> {
-    vec![
-        "**/node_modules/**".to_string(),
-        "**/target/**".to_string(),
-        "**/__pycache__/**".to_string(),
-        "**/dist/**".to_string(),
-        "**/build/**".to_string(),
-    ]
+/// Returns default patterns for excluded directories: version-control
+/// metadata always, and [`VENDOR_DIRECTORIES`] unless `include_vendor`.
+fn default_exclude_patterns(include_vendor: bool) -> Vec<String> {
+    let vendor: &[&str] = if include_vendor {
+        &[]
+    } else {
+        VENDOR_DIRECTORIES
+    };
+    VCS_DIRECTORIES
+        .iter()
+        .chain(vendor)
+        .map(|dir| format!("**/{dir}/**"))
+        .collect()
 }
 
-/// Build set of allowed file extensions from configuration.
+/// Build set of allowed file extensions from configuration, including the
+/// extensions named in `analysis.language_mappings`.
 fn allowed_extensions_from(
     pipeline_config: &PipelineAnalysisConfig,
     valknut_config: Option<&ValknutConfig>,
@@ -306,6 +522,7 @@ fn allowed_extensions_from(
             .values()
             .filter(|lang| lang.enabled)
             .flat_map(|lang| lang.file_extensions.iter())
+            .chain(cfg.analysis.language_mappings.keys())
             .map(|ext| ext.trim_start_matches('.').to_ascii_lowercase())
             .collect();
 
@@ -398,23 +615,14 @@ fn should_keep(
     exclude_glob: Option<&GlobSet>,
     ignore_glob: Option<&GlobSet>,
     allowed_extensions: &HashSet<String>,
-    max_file_size_bytes: u64,
 ) -> bool {
-    let extension = match path.extension().and_then(|ext| ext.to_str()) {
-        Some(ext) => ext.to_ascii_lowercase(),
-        None => return false,
-    };
+    let extension = path
+        .extension()
+        .map(|ext| ext.to_string_lossy().to_ascii_lowercase());
 
-    if !allowed_extensions.is_empty() && !allowed_extensions.contains(&extension) {
-        return false;
-    }
-
-    // Check file size limit (0 means unlimited)
-    if max_file_size_bytes > 0 {
-        if let Ok(metadata) = fs::metadata(path) {
-            if metadata.len() > max_file_size_bytes {
-                return false;
-            }
+    if let Some(extension) = &extension {
+        if !allowed_extensions.is_empty() && !allowed_extensions.contains(extension) {
+            return false;
         }
     }
 
@@ -433,10 +641,17 @@ fn should_keep(
     }
 
     if let Some(include) = include_glob {
-        include.is_match(relative)
-    } else {
-        true
+        if !include.is_match(relative) {
+            return false;
+        }
     }
+
+    // Extensionless files are only analyzed when a shebang names a supported interpreter.
+    extension.is_some()
+        || read_first_line(path)
+            .as_deref()
+            .and_then(shebang_language)
+            .is_some()
 }
 
 /// Check if a path is within one of the requested root directories.
@@ -507,7 +722,6 @@ mod tests {
             exclude.as_ref(),
             ignore.as_ref(),
             &allowed,
-            0, // unlimited file size
         ));
 
         let generated_path = base.join("generated/file.rs");
@@ -518,7 +732,6 @@ mod tests {
             exclude.as_ref(),
             ignore.as_ref(),
             &allowed,
-            0,
         ));
 
         let ignored_path = base.join("src/ignored.rs");
@@ -529,7 +742,6 @@ mod tests {
             exclude.as_ref(),
             ignore.as_ref(),
             &allowed,
-            0,
         ));
 
         let wrong_extension = base.join("src/lib.ts");
@@ -540,7 +752,6 @@ mod tests {
             exclude.as_ref(),
             ignore.as_ref(),
             &allowed,
-            0,
         ));
     }
 
@@ -556,6 +767,199 @@ mod tests {
         assert!(!is_within_requested_roots(&roots, outside));
     }
 
+    #[test]
+    fn only_files_restricts_discovery_and_keeps_ignore_rules() {
+        let tmp = tempfile::tempdir().unwrap();
+        let root = tmp.path();
+        for name in ["a.py", "b.py", "c.py"] {
+            fs::write(root.join(name), "x = 1\n").unwrap();
+        }
+        fs::write(root.join(IGNORE_FILE_NAME), "b.py\n").unwrap();
+
+        let pipeline_config = PipelineAnalysisConfig::default();
+        let mut valknut_config = ValknutConfig::default();
+        valknut_config.analysis.only_files = Some(vec![root.join("a.py"), root.join("b.py")]);
+
+        let files = discover_files(
+            &[root.to_path_buf()],
+            &pipeline_config,
+            Some(&valknut_config),
+        )
+        .unwrap();
+        let names: Vec<_> = files
+            .iter()
+            .filter_map(|file| file.file_name()?.to_str())
+            .collect();
+        assert_eq!(names, vec!["a.py"]);
+    }
+
+    #[test]
+    fn build_target_drops_go_files_excluded_by_constraints() {
+        let tmp = tempfile::tempdir().unwrap();
+        let root = tmp.path();
+        fs::write(root.join("poll.go"), "package sys\n").unwrap();
+        fs::write(root.join("poll_windows.go"), "package sys\n").unwrap();
+        fs::write(
+            root.join("epoll.go"),
+            "//go:build linux && amd64\n\npackage sys\n",
+        )
+        .unwrap();
+        fs::write(
+            root.join("kqueue.go"),
+            "// +build darwin freebsd\n\npackage sys\n",
+        )
+        .unwrap();
+        fs::write(root.join("tool_windows.py"), "x = 1\n").unwrap();
+
+        let pipeline_config = PipelineAnalysisConfig::default();
+        let mut valknut_config = ValknutConfig::default();
+        valknut_config.analysis.build_target =
+            Some(BuildTarget::new(Some("linux"), Some("amd64"), Vec::new()));
+
+        let files = discover_files(
+            &[root.to_path_buf()],
+            &pipeline_config,
+            Some(&valknut_config),
+        )
+        .unwrap();
+        let mut names: Vec<_> = files
+            .iter()
+            .filter_map(|file| file.file_name()?.to_str())
+            .collect();
+        names.sort();
+        assert_eq!(names, vec!["epoll.go", "poll.go", "tool_windows.py"]);
+    }
+
+    #[test]
+    fn filesystem_walk_applies_nested_ignore_files_at_any_fanout_depth() {
+        let tmp = tempfile::tempdir().unwrap();
+        let root = tmp.path();
+        fs::create_dir_all(root.join("svc/api/gen")).unwrap();
+        fs::write(root.join("main.py"), "x = 1\n").unwrap();
+        fs::write(root.join("svc/api/handler.py"), "x = 1\n").unwrap();
+        fs::write(root.join("svc/api/gen/stub.py"), "x = 1\n").unwrap();
+        fs::write(root.join("svc").join(IGNORE_FILE_NAME), "gen/\n").unwrap();
+
+        let pipeline_config = PipelineAnalysisConfig::default();
+        let mut valknut_config = ValknutConfig::default();
+        for depth in [0, 1, 4] {
+            valknut_config.analysis.discovery_fanout_depth = depth;
+            let files = discover_files(
+                &[root.to_path_buf()],
+                &pipeline_config,
+                Some(&valknut_config),
+            )
+            .unwrap();
+            let names: Vec<_> = files
+                .iter()
+                .filter_map(|file| file.file_name()?.to_str())
+                .collect();
+            assert_eq!(
+                names,
+                vec!["main.py", "handler.py"],
+                "fan-out depth {depth}"
+            );
+        }
+    }
+
+    #[test]
+    fn vendor_directories_are_skipped_unless_included() {
+        let tmp = tempfile::tempdir().unwrap();
+        let root = tmp.path();
+        fs::create_dir_all(root.join("vendor/github.com/lib")).unwrap();
+        fs::create_dir_all(root.join("web/node_modules/left-pad")).unwrap();
+        fs::write(root.join("main.go"), "package main\n").unwrap();
+        fs::write(root.join("vendor/github.com/lib/lib.go"), "package lib\n").unwrap();
+        fs::write(root.join("web/node_modules/left-pad/index.js"), "x;\n").unwrap();
+
+        let pipeline_config = PipelineAnalysisConfig::default();
+        let mut valknut_config = ValknutConfig::default();
+        let discover = |config: &ValknutConfig| {
+            let mut names: Vec<String> =
+                discover_files(&[root.to_path_buf()], &pipeline_config, Some(config))
+                    .unwrap()
+                    .iter()
+                    .filter_map(|file| Some(file.file_name()?.to_str()?.to_string()))
+                    .collect();
+            names.sort();
+            names
+        };
+
+        assert_eq!(discover(&valknut_config), vec!["main.go"]);
+        valknut_config.analysis.include_vendor = true;
+        assert_eq!(
+            discover(&valknut_config),
+            vec!["index.js", "lib.go", "main.go"]
+        );
+    }
+
+    #[test]
+    fn oversized_files_are_reported_as_skipped() {
+        let tmp = tempfile::tempdir().unwrap();
+        let root = tmp.path();
+        fs::write(root.join("small.py"), "x = 1\n").unwrap();
+        fs::write(root.join("huge.py"), "x = 1\n".repeat(400)).unwrap();
+
+        let mut pipeline_config = PipelineAnalysisConfig::default();
+        pipeline_config.max_file_size_bytes = 1024;
+
+        let found = discover_files_detailed(&[root.to_path_buf()], &pipeline_config, None).unwrap();
+        assert_eq!(found.files.len(), 1);
+        assert!(found.files[0].ends_with("small.py"));
+        assert_eq!(found.skipped.len(), 1);
+        let skipped = &found.skipped[0];
+        assert!(skipped.path.ends_with("huge.py"));
+        assert_eq!(skipped.reason, SkipReason::LargeFile);
+        assert_eq!(skipped.size_bytes, 2400);
+        assert_eq!(skipped.limit_bytes, 1024);
+        assert!(skipped.warning().starts_with("skipped_large_file: "));
+
+        pipeline_config.max_file_size_bytes = 0;
+        let unlimited =
+            discover_files_detailed(&[root.to_path_buf()], &pipeline_config, None).unwrap();
+        assert_eq!(unlimited.files.len(), 2);
+        assert!(unlimited.skipped.is_empty());
+    }
+
+    #[test]
+    fn mixed_language_repos_dispatch_by_extension_and_shebang() {
+        let tmp = tempfile::tempdir().unwrap();
+        let root = tmp.path();
+        fs::write(root.join("main.go"), "package main\n").unwrap();
+        fs::write(root.join("deploy"), "#!/usr/bin/env python3\nprint('hi')\n").unwrap();
+        fs::write(root.join("LICENSE"), "MIT\n").unwrap();
+        fs::write(root.join("main.hcl"), "resource \"x\" \"y\" {}\n").unwrap();
+
+        let pipeline_config = PipelineAnalysisConfig::default();
+        let mut valknut_config = ValknutConfig::default();
+        valknut_config
+            .analysis
+            .language_mappings
+            .insert(".hcl".to_string(), "terraform".to_string());
+
+        let found = discover_files_detailed(
+            &[root.to_path_buf()],
+            &pipeline_config,
+            Some(&valknut_config),
+        )
+        .unwrap();
+        let names: Vec<_> = found
+            .files
+            .iter()
+            .filter_map(|file| file.file_name()?.to_str())
+            .collect();
+        assert_eq!(names, vec!["deploy", "main.go"]);
+
+        assert_eq!(found.skipped.len(), 1);
+        let skipped = &found.skipped[0];
+        assert!(skipped.path.ends_with("main.hcl"));
+        assert_eq!(skipped.reason, SkipReason::LanguageUnknown);
+        assert_eq!(skipped.language.as_deref(), Some("terraform"));
+        assert!(skipped
+            .warning()
+            .ends_with("main.hcl (no parser for terraform)"));
+    }
+
     #[test]
     fn default_base_for_returns_parent_when_available() {
         let path = Path::new("src/lib.rs");
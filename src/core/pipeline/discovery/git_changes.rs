@@ -0,0 +1,117 @@
+//! Files changed since a git revision.
+//!
+//! [`changed_files_since`] is the library equivalent of
+//! `git diff --name-only <rev>`: it lists files that differ between a revision
+//! and the working tree, including staged and unstaged edits. With
+//! `committed_only` the comparison is against `HEAD` instead, so uncommitted
+//! work is ignored. Deleted files are left out because there is nothing left
+//! to analyze.
+
+use std::path::{Path, PathBuf};
+
+use git2::{Delta, Repository};
+
+use crate::core::errors::{Result, ValknutError};
+use crate::core::review::git_error;
+
+/// Absolute paths of files changed since `since` in the repository containing `path`.
+pub fn changed_files_since(path: &Path, since: &str, committed_only: bool) -> Result<Vec<PathBuf>> {
+    let repo = Repository::discover(path).map_err(|err| {
+        ValknutError::validation(format!(
+            "{} is not inside a git repository: {}",
+            path.display(),
+            err.message()
+        ))
+    })?;
+    let workdir = repo.workdir().ok_or_else(|| {
+        ValknutError::validation("Cannot list changed files in a bare git repository")
+    })?;
+
+    let base = repo
+        .revparse_single(since)
+        .and_then(|object| object.peel_to_tree())
+        .map_err(|err| {
+            ValknutError::validation(format!("Unknown git revision '{since}': {}", err.message()))
+        })?;
+    let diff = if committed_only {
+        let head = repo
+            .head()
+            .and_then(|head| head.peel_to_tree())
+            .map_err(|err| git_error("resolve HEAD", err))?;
+        repo.diff_tree_to_tree(Some(&base), Some(&head), None)
+    } else {
+        repo.diff_tree_to_workdir_with_index(Some(&base), None)
+    }
+    .map_err(|err| git_error(&format!("diff against '{since}'"), err))?;
+
+    let mut files: Vec<PathBuf> = diff
+        .deltas()
+        .filter(|delta| delta.status() != Delta::Deleted)
+        .filter_map(|delta| delta.new_file().path().map(|file| workdir.join(file)))
+        .collect();
+    files.sort();
+    files.dedup();
+    Ok(files)
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use std::fs;
+    use tempfile::tempdir;
+
+    /// Stage `files` and commit them on top of `HEAD`.
+    fn commit(repo: &Repository, files: &[&str], message: &str) {
+        let mut index = repo.index().unwrap();
+        for file in files {
+            index.add_path(Path::new(file)).unwrap();
+        }
+        index.write().unwrap();
+        let tree = repo.find_tree(index.write_tree().unwrap()).unwrap();
+        let signature = git2::Signature::now("Test", "test@example.com").unwrap();
+        let parent = repo.head().ok().and_then(|head| head.peel_to_commit().ok());
+        let parents: Vec<_> = parent.iter().collect();
+        repo.commit(
+            Some("HEAD"),
+            &signature,
+            &signature,
+            message,
+            &tree,
+            &parents,
+        )
+        .unwrap();
+    }
+
+    #[test]
+    fn lists_committed_and_uncommitted_changes() {
+        let tmp = tempdir().unwrap();
+        let root = tmp.path();
+        let repo = Repository::init(root).unwrap();
+        for name in ["a.py", "b.py", "c.py"] {
+            fs::write(root.join(name), "x = 1\n").unwrap();
+        }
+        commit(&repo, &["a.py", "b.py", "c.py"], "init");
+
+        fs::write(root.join("a.py"), "x = 2\n").unwrap();
+        commit(&repo, &["a.py"], "change a");
+        fs::write(root.join("b.py"), "x = 3\n").unwrap();
+
+        let workdir = repo.workdir().unwrap().to_path_buf();
+        let all = changed_files_since(root, "HEAD~1", false).unwrap();
+        assert_eq!(all, vec![workdir.join("a.py"), workdir.join("b.py")]);
+
+        let committed = changed_files_since(root, "HEAD~1", true).unwrap();
+        assert_eq!(committed, vec![workdir.join("a.py")]);
+    }
+
+    #[test]
+    fn rejects_unknown_revisions_and_non_repositories() {
+        let tmp = tempdir().unwrap();
+        assert!(changed_files_since(tmp.path(), "HEAD", false).is_err());
+
+        let repo = Repository::init(tmp.path()).unwrap();
+        fs::write(tmp.path().join("a.py"), "x = 1\n").unwrap();
+        commit(&repo, &["a.py"], "init");
+        assert!(changed_files_since(tmp.path(), "no-such-ref", false).is_err());
+    }
+}
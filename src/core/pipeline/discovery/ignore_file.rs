@@ -0,0 +1,129 @@
+//! Hierarchical `.valknutignore` support.
+//!
+//! `.valknutignore` files use exactly the same syntax as `.gitignore`. They may
+//! live at the repository root and in any subdirectory; patterns in a child file
+//! are scoped to that subtree and stack on top of (rather than replace) the
+//! patterns inherited from parent directories.
+
+use std::collections::HashMap;
+use std::path::{Path, PathBuf};
+
+use ignore::gitignore::Gitignore;
+use tracing::warn;
+
+/// File name of Valknut-specific ignore files.
+pub const IGNORE_FILE_NAME: &str = ".valknutignore";
+
+/// Lazily-loaded matcher for nested `.valknutignore` files.
+///
+/// A path's fate is decided by the deepest ignore file with a matching pattern,
+/// so a child file can extend its parents or re-include paths with `!pattern`,
+/// mirroring how git resolves nested `.gitignore` files.
+#[derive(Debug)]
+pub struct IgnoreFileMatcher {
+    /// Top-most directory whose ignore file is consulted.
+    ceiling: PathBuf,
+    /// Parsed ignore file per directory (`None` when the directory has none).
+    cache: HashMap<PathBuf, Option<Gitignore>>,
+}
+
+/// Construction and matching methods for [`IgnoreFileMatcher`].
+impl IgnoreFileMatcher {
+    /// Create a matcher that consults ignore files from `ceiling` downwards.
+    pub fn new(ceiling: impl Into<PathBuf>) -> Self {
+        Self {
+            ceiling: ceiling.into(),
+            cache: HashMap::new(),
+        }
+    }
+
+    /// Returns true when a `.valknutignore` between the ceiling and the file excludes it.
+    pub fn is_ignored(&mut self, path: &Path) -> bool {
+        let Ok(relative) = path.strip_prefix(&self.ceiling) else {
+            return false;
+        };
+
+        let mut directories = vec![self.ceiling.clone()];
+        if let Some(parent) = relative.parent() {
+            let mut current = self.ceiling.clone();
+            for component in parent.components() {
+                current.push(component);
+                directories.push(current.clone());
+            }
+        }
+
+        for directory in directories.iter().rev() {
+            let Some(gitignore) = self.load(directory) else {
+                continue;
+            };
+            let matched = gitignore.matched_path_or_any_parents(path, false);
+            if matched.is_ignore() {
+                return true;
+            }
+            if matched.is_whitelist() {
+                return false;
+            }
+        }
+
+        false
+    }
+
+    /// Load (and cache) the ignore file in `directory`, if present.
+    fn load(&mut self, directory: &Path) -> Option<&Gitignore> {
+        self.cache
+            .entry(directory.to_path_buf())
+            .or_insert_with(|| {
+                let file = directory.join(IGNORE_FILE_NAME);
+                if !file.is_file() {
+                    return None;
+                }
+                let (gitignore, error) = Gitignore::new(&file);
+                if let Some(error) = error {
+                    warn!("Problem reading {}: {}", file.display(), error);
+                }
+                Some(gitignore)
+            })
+            .as_ref()
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use std::fs;
+    use tempfile::tempdir;
+
+    #[test]
+    fn child_ignore_files_stack_on_parent_patterns() {
+        let tmp = tempdir().unwrap();
+        let root = tmp.path();
+        fs::create_dir_all(root.join("web/vendor")).unwrap();
+        fs::write(root.join(IGNORE_FILE_NAME), "*.gen.ts\nfixtures/\n").unwrap();
+        fs::write(
+            root.join("web").join(IGNORE_FILE_NAME),
+            "vendor/\n!keep.gen.ts\n",
+        )
+        .unwrap();
+
+        let mut matcher = IgnoreFileMatcher::new(root);
+
+        assert!(matcher.is_ignored(&root.join("api.gen.ts")));
+        assert!(matcher.is_ignored(&root.join("fixtures/sample.py")));
+        assert!(matcher.is_ignored(&root.join("web/vendor/lib.js")));
+        assert!(matcher.is_ignored(&root.join("web/other.gen.ts")));
+        assert!(!matcher.is_ignored(&root.join("web/keep.gen.ts")));
+        assert!(!matcher.is_ignored(&root.join("web/app.ts")));
+
+        // Child patterns are scoped to their own subtree.
+        assert!(!matcher.is_ignored(&root.join("vendor/lib.js")));
+    }
+
+    #[test]
+    fn paths_outside_ceiling_are_never_ignored() {
+        let tmp = tempdir().unwrap();
+        fs::write(tmp.path().join(IGNORE_FILE_NAME), "*.py\n").unwrap();
+
+        let mut matcher = IgnoreFileMatcher::new(tmp.path().join("sub"));
+        assert!(!matcher.is_ignored(&tmp.path().join("main.py")));
+    }
+}
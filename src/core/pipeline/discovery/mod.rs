@@ -2,14 +2,23 @@
 //!
 //! This module provides:
 //! - Git-aware file discovery
+//! - Changed-file listing for `--since` runs
+//! - Hierarchical `.valknutignore` handling
+//! - Parallel directory walking for repositories without git metadata
 //! - Batched file reading
 //! - Code dictionary management
 //! - Stage orchestration services
 
 pub mod code_dictionary;
 pub mod file_discovery;
+pub mod git_changes;
+pub mod ignore_file;
+pub mod parallel_walk;
 pub mod services;
 
 pub use code_dictionary::*;
 pub use file_discovery::*;
+pub use git_changes::changed_files_since;
+pub use ignore_file::{IgnoreFileMatcher, IGNORE_FILE_NAME};
+pub use parallel_walk::ParallelWalker;
 pub use services::*;
@@ -0,0 +1,291 @@
+//! Parallel directory walking for filesystem discovery.
+//!
+//! Directory listings dominate discovery time on network-mounted filesystems
+//! and very large trees, because every `read_dir` blocks. [`ParallelWalker`]
+//! lists each directory down to a configurable fan-out depth on its own thread
+//! and streams regular files to the caller as they are found. Below that depth
+//! each thread finishes its subtree sequentially. A thread limit caps how many
+//! listing threads run at once; directories that find no free slot are listed
+//! by the thread that reached them. With a limit of one thread the walk runs
+//! on the calling thread.
+//!
+//! Symlinks are followed. Every directory is identified by its device and
+//! inode (its canonical path on non-Unix platforms) and visited at most once,
+//! so symlink cycles terminate.
+
+use std::collections::HashSet;
+use std::fs;
+use std::path::{Path, PathBuf};
+use std::sync::mpsc::{self, Receiver, Sender};
+use std::sync::Mutex;
+use std::thread::{self, Scope};
+
+use tokio::sync::Semaphore;
+use tracing::{debug, warn};
+
+use crate::core::config::AnalysisConfig;
+
+/// Identity of a visited directory.
+#[cfg(unix)]
+type DirKey = (u64, u64);
+/// Identity of a visited directory.
+#[cfg(not(unix))]
+type DirKey = PathBuf;
+
+/// Walks directory trees, fanning out one thread per directory near the root.
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub struct ParallelWalker {
+    fanout_depth: usize,
+    max_threads: Option<usize>,
+}
+
+/// Walking methods for [`ParallelWalker`].
+impl ParallelWalker {
+    /// Create a walker that spawns a thread for every directory less than
+    /// `fanout_depth` levels below the root (0 walks on a single thread).
+    pub fn new(fanout_depth: usize) -> Self {
+        Self {
+            fanout_depth,
+            max_threads: None,
+        }
+    }
+
+    /// Run at most `max_threads` listing threads at once, including the one
+    /// walking the root (`None` leaves the fan-out unbounded).
+    pub fn with_thread_limit(mut self, max_threads: Option<usize>) -> Self {
+        self.max_threads = max_threads;
+        self
+    }
+
+    /// Walk `root` in the background, streaming every regular file found.
+    ///
+    /// The walk stops early once the receiver is dropped. With a thread limit
+    /// of one the walk runs on the calling thread instead, and every file has
+    /// been sent by the time this returns.
+    pub fn spawn(&self, root: PathBuf) -> Receiver<PathBuf> {
+        let (files, receiver) = mpsc::channel();
+        let walk = Walk::new(self.fanout_depth, self.max_threads, files);
+        if self.max_threads == Some(1) {
+            walk.run(&root);
+        } else {
+            thread::spawn(move || walk.run(&root));
+        }
+        receiver
+    }
+
+    /// Walk `root` and return every regular file below it, sorted.
+    pub fn walk(&self, root: &Path) -> Vec<PathBuf> {
+        let mut files: Vec<PathBuf> = self.spawn(root.to_path_buf()).into_iter().collect();
+        files.sort();
+        files
+    }
+}
+
+/// Default implementation for [`ParallelWalker`].
+impl Default for ParallelWalker {
+    /// Returns a walker with the default `analysis.discovery_fanout_depth`.
+    fn default() -> Self {
+        Self::new(AnalysisConfig::default_discovery_fanout_depth())
+    }
+}
+
+/// State shared by the threads of one walk.
+struct Walk {
+    fanout_depth: usize,
+    /// One permit per listing thread that may run besides the root's.
+    threads: Semaphore,
+    visited: Mutex<HashSet<DirKey>>,
+    files: Sender<PathBuf>,
+}
+
+/// Traversal methods for [`Walk`].
+impl Walk {
+    /// Create the shared state for a walk that sends files to `files`.
+    fn new(fanout_depth: usize, max_threads: Option<usize>, files: Sender<PathBuf>) -> Self {
+        let extra_threads = max_threads.map_or(Semaphore::MAX_PERMITS, |max| max.saturating_sub(1));
+        Self {
+            fanout_depth,
+            threads: Semaphore::new(extra_threads),
+            visited: Mutex::new(HashSet::new()),
+            files,
+        }
+    }
+
+    /// Walk `root`, returning once every spawned thread has finished.
+    fn run(&self, root: &Path) {
+        thread::scope(|scope| self.visit(scope, root.to_path_buf(), 0));
+    }
+
+    /// List `dir`, sending its files and descending into its subdirectories.
+    fn visit<'scope, 'env>(
+        &'env self,
+        scope: &'scope Scope<'scope, 'env>,
+        dir: PathBuf,
+        depth: usize,
+    ) {
+        if !self.first_visit(&dir) {
+            debug!("Skipping already visited directory {}", dir.display());
+            return;
+        }
+        let entries = match fs::read_dir(&dir) {
+            Ok(entries) => entries,
+            Err(err) => {
+                warn!("Failed to read directory {}: {err}", dir.display());
+                return;
+            }
+        };
+
+        for entry in entries {
+            let entry = match entry {
+                Ok(entry) => entry,
+                Err(err) => {
+                    warn!("Failed to read entry in {}: {err}", dir.display());
+                    continue;
+                }
+            };
+            let path = entry.path();
+            let Ok(mut file_type) = entry.file_type() else {
+                continue;
+            };
+            if file_type.is_symlink() {
+                // Follow the link; dangling links are skipped.
+                match fs::metadata(&path) {
+                    Ok(metadata) => file_type = metadata.file_type(),
+                    Err(_) => continue,
+                }
+            }
+
+            if file_type.is_dir() {
+                if entry.file_name() == ".git" {
+                    continue;
+                }
+                let permit = if depth < self.fanout_depth {
+                    self.threads.try_acquire().ok()
+                } else {
+                    None
+                };
+                match permit {
+                    Some(permit) => {
+                        scope.spawn(move || {
+                            let _permit = permit;
+                            self.visit(scope, path, depth + 1);
+                        });
+                    }
+                    None => self.visit(scope, path, depth + 1),
+                }
+            } else if file_type.is_file() && self.files.send(path).is_err() {
+                // The receiver hung up; nobody wants the rest of the walk.
+                return;
+            }
+        }
+    }
+
+    /// Record `dir` as visited, returning false if it was reached before.
+    fn first_visit(&self, dir: &Path) -> bool {
+        let Some(key) = dir_key(dir) else {
+            return true;
+        };
+        self.visited
+            .lock()
+            .unwrap_or_else(|poisoned| poisoned.into_inner())
+            .insert(key)
+    }
+}
+
+/// Device and inode of the directory `path` resolves to.
+#[cfg(unix)]
+fn dir_key(path: &Path) -> Option<DirKey> {
+    use std::os::unix::fs::MetadataExt;
+
+    fs::metadata(path)
+        .ok()
+        .map(|metadata| (metadata.dev(), metadata.ino()))
+}
+
+/// Canonical path of the directory `path` resolves to.
+#[cfg(not(unix))]
+fn dir_key(path: &Path) -> Option<DirKey> {
+    fs::canonicalize(path).ok()
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn relative(root: &Path, files: Vec<PathBuf>) -> Vec<String> {
+        files
+            .iter()
+            .map(|file| {
+                file.strip_prefix(root)
+                    .unwrap()
+                    .to_string_lossy()
+                    .replace('\\', "/")
+            })
+            .collect()
+    }
+
+    #[test]
+    fn walk_finds_files_at_every_depth() {
+        let tmp = tempfile::tempdir().unwrap();
+        let root = tmp.path();
+        for dir in ["a/b/c/d", "e", ".git/objects"] {
+            fs::create_dir_all(root.join(dir)).unwrap();
+        }
+        for file in [
+            "top.go",
+            "a/one.go",
+            "a/b/two.go",
+            "a/b/c/d/deep.go",
+            "e/x.py",
+        ] {
+            fs::write(root.join(file), "").unwrap();
+        }
+        fs::write(root.join(".git/objects/pack"), "").unwrap();
+
+        let expected = vec![
+            "a/b/c/d/deep.go",
+            "a/b/two.go",
+            "a/one.go",
+            "e/x.py",
+            "top.go",
+        ];
+        for depth in [0, 1, 2, 8] {
+            let files = ParallelWalker::new(depth).walk(root);
+            assert_eq!(relative(root, files), expected, "fan-out depth {depth}");
+        }
+        for max_threads in [1, 2] {
+            let files = ParallelWalker::new(8)
+                .with_thread_limit(Some(max_threads))
+                .walk(root);
+            assert_eq!(relative(root, files), expected, "{max_threads} threads");
+        }
+    }
+
+    #[test]
+    fn walk_with_one_thread_runs_on_the_caller() {
+        let tmp = tempfile::tempdir().unwrap();
+        let root = tmp.path();
+        fs::create_dir_all(root.join("a/b")).unwrap();
+        fs::write(root.join("a/b/lib.go"), "").unwrap();
+
+        let files = ParallelWalker::new(8)
+            .with_thread_limit(Some(1))
+            .spawn(root.to_path_buf());
+        let found: Vec<PathBuf> = files.try_iter().collect();
+        assert_eq!(relative(root, found), vec!["a/b/lib.go"]);
+    }
+
+    #[cfg(unix)]
+    #[test]
+    fn walk_follows_symlinks_without_looping() {
+        let tmp = tempfile::tempdir().unwrap();
+        let root = tmp.path();
+        fs::create_dir_all(root.join("pkg/sub")).unwrap();
+        fs::write(root.join("pkg/sub/lib.go"), "").unwrap();
+        std::os::unix::fs::symlink(root, root.join("pkg/sub/loop")).unwrap();
+        std::os::unix::fs::symlink(root.join("missing"), root.join("dangling")).unwrap();
+
+        let files = ParallelWalker::default().walk(root);
+        assert_eq!(relative(root, files), vec!["pkg/sub/lib.go"]);
+    }
+}
@@ -23,9 +23,11 @@ use crate::core::pipeline::results::result_types::AnalysisSummary;
 use crate::core::pipeline::{QualityGateResult, QualityGateViolation};
 use crate::detectors::bundled::{BundledDetectionConfig, BundledFileDetector};
 use crate::detectors::cohesion::CohesionAnalysisResults;
+use crate::detectors::complexity::ComplexityReport;
+use crate::lang::notebook::analysis_source;
 use serde::{Deserialize, Serialize};
 
-use super::file_discovery;
+use super::file_discovery::{self, DiscoveredFiles};
 use crate::core::pipeline::pipeline_config::{AnalysisConfig, QualityGateConfig};
 
 /// Service responsible for translating requested roots into concrete files.
@@ -48,6 +50,21 @@ pub trait FileDiscoverer: Send + Sync {
         pipeline_config: &AnalysisConfig,
         valknut_config: Option<&ValknutConfig>,
     ) -> Result<Vec<PathBuf>>;
+
+    /// Discovers files and reports those skipped for exceeding the size limit.
+    ///
+    /// The default implementation reports no skipped files.
+    fn discover_detailed(
+        &self,
+        roots: &[PathBuf],
+        pipeline_config: &AnalysisConfig,
+        valknut_config: Option<&ValknutConfig>,
+    ) -> Result<DiscoveredFiles> {
+        Ok(DiscoveredFiles {
+            files: self.discover(roots, pipeline_config, valknut_config)?,
+            skipped: Vec::new(),
+        })
+    }
 }
 
 /// Default git-aware file discovery implementation.
@@ -69,6 +86,16 @@ impl FileDiscoverer for GitAwareFileDiscoverer {
     ) -> Result<Vec<PathBuf>> {
         file_discovery::discover_files(roots, pipeline_config, valknut_config)
     }
+
+    /// Discovers files using git-aware traversal, keeping oversized files aside.
+    fn discover_detailed(
+        &self,
+        roots: &[PathBuf],
+        pipeline_config: &AnalysisConfig,
+        valknut_config: Option<&ValknutConfig>,
+    ) -> Result<DiscoveredFiles> {
+        file_discovery::discover_files_detailed(roots, pipeline_config, valknut_config)
+    }
 }
 
 /// Factory method for [`GitAwareFileDiscoverer`].
@@ -168,7 +195,7 @@ impl BatchedFileReader {
         let bytes = tokio::fs::read(&path)
             .await
             .map_err(|e| ValknutError::io(format!("Failed to read file {}", path.display()), e))?;
-        let content = Self::bytes_to_string(&path, bytes);
+        let content = analysis_source(&path, Self::bytes_to_string(&path, bytes));
         Ok((path, content))
     }
 
@@ -249,6 +276,9 @@ pub struct StageResultsBundle {
     /// Results from semantic cohesion analysis.
     #[serde(default)]
     pub cohesion: CohesionAnalysisResults,
+    /// Whether the `--timeout` deadline skipped or cut short any stage.
+    #[serde(skip)]
+    pub timed_out: bool,
 }
 
 /// Factory methods for [`StageResultsBundle`].
@@ -292,6 +322,7 @@ impl StageResultsBundle {
                 chokepoints: Vec::new(),
                 clone_groups: Vec::new(),
                 issues_count: 0,
+                call_graph: None,
             },
             lsh: LshAnalysisResults {
                 enabled: false,
@@ -305,6 +336,7 @@ impl StageResultsBundle {
                 tfidf_stats: None,
             },
             cohesion: CohesionAnalysisResults::default(),
+            timed_out: false,
         }
     }
 }
@@ -339,12 +371,14 @@ pub trait StageOrchestrator: Send + Sync {
     /// * `config` - Analysis configuration controlling which stages run
     /// * `paths` - Original root paths requested for analysis
     /// * `files` - Discovered files to analyze
+    /// * `dependency_files` - Files to include in the dependency graph (a superset of `files`)
     /// * `arena_results` - Pre-computed arena analysis results
     async fn run_all_stages(
         &self,
         config: &AnalysisConfig,
         paths: &[PathBuf],
         files: &[PathBuf],
+        dependency_files: &[PathBuf],
         arena_results: &[ArenaAnalysisResult],
     ) -> Result<StageResultsBundle>;
 }
@@ -434,6 +468,8 @@ impl ResultAggregator for DefaultResultAggregator {
             critical_issues,
             doc_health_score: 1.0,
             doc_issue_count: 0,
+            complexity: ComplexityReport::from_results(&complexity.detailed_results),
+            timed_out: false,
         }
     }
 
@@ -633,6 +669,8 @@ impl DefaultResultAggregator {
                     "rs" => "Rust",
                     "go" => "Go",
                     "java" => "Java",
+                    "cs" => "C#",
+                    "kt" => "Kotlin",
                     _ => continue,
                 };
                 languages.insert(lang.to_string());
@@ -10,6 +10,7 @@
 //! - **discovery/**: File discovery and pipeline services
 //! - **health/**: Health metrics and scoring
 //! - **verification/**: Clone detection and verification
+//! - **streaming**: Channel-connected per-file pipeline for incremental output
 //!
 //! ## Key Components
 //!
@@ -41,6 +42,7 @@ pub mod discovery;
 pub mod health;
 pub mod results;
 pub mod stages;
+pub mod streaming;
 pub mod verification;
 
 // Core pipeline modules (kept in root for central orchestration)
@@ -132,6 +134,8 @@ mod inline_tests {
                 critical_issues: 0,
                 doc_health_score: 1.0,
                 doc_issue_count: 0,
+                complexity: Default::default(),
+                timed_out: false,
             },
             structure: StructureAnalysisResults {
                 enabled: true,
@@ -159,6 +163,7 @@ mod inline_tests {
                 chokepoints: vec![],
                 clone_groups: vec![],
                 issues_count: 0,
+                call_graph: None,
             },
             lsh: LshAnalysisResults {
                 enabled: false,
@@ -189,6 +194,11 @@ mod inline_tests {
                 structure_quality_score: 90.0,
                 doc_health_score: 100.0,
             },
+            skipped_files: Vec::new(),
+            generated_code: Default::default(),
+            notebook_metadata: Vec::new(),
+            duplicates: Default::default(),
+            redactions: Default::default(),
         };
 
         let gate_result = pipeline.evaluate_quality_gates(&config, &results);
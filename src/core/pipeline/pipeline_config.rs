@@ -27,7 +27,7 @@ pub struct AnalysisConfig {
     pub exclude_directories: Vec<String>,
     /// Maximum files to analyze (0 = no limit)
     pub max_files: usize,
-    /// Maximum file size in bytes (0 = no limit, default = 500KB)
+    /// Maximum file size in bytes (0 = no limit, default = 1MB)
     pub max_file_size_bytes: u64,
 }
 
@@ -61,7 +61,7 @@ impl Default for AnalysisConfig {
                 "build".to_string(),
             ],
             max_files: 5000,
-            max_file_size_bytes: 500 * 1024, // 500KB default
+            max_file_size_bytes: 1024 * 1024, // 1MB default
         }
     }
 }
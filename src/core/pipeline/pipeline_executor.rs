@@ -4,22 +4,28 @@ use chrono::Utc;
 use std::path::{Path, PathBuf};
 use std::time::Instant;
 use tokio::fs;
-use tracing::{info, warn};
+use tracing::{info, info_span, warn, Instrument};
 use uuid::Uuid;
 use walkdir;
 
+use crate::core::arena_analysis::ArenaAnalysisResult;
 use crate::core::ast_service::AstService;
 use crate::core::config::{DocHealthConfig, ScoringConfig, ValknutConfig};
 use crate::core::errors::{Result, ValknutError};
 use crate::core::featureset::FeatureVector;
+use crate::core::redaction::{RedactionOptions, Redactions};
 use crate::core::scoring::{FeatureScorer, ScoringResult};
 use crate::detectors::complexity::{ComplexityAnalyzer, ComplexityConfig};
 use crate::detectors::coverage::{CoverageConfig as CoverageDetectorConfig, CoverageExtractor};
+use crate::detectors::duplicates::DuplicateCode;
+use crate::detectors::generated::GeneratedCode;
 use crate::detectors::refactoring::{RefactoringAnalyzer, RefactoringConfig};
 use crate::detectors::structure::{StructureConfig, StructureExtractor};
-use std::collections::HashMap;
+use crate::lang::notebook::collect_notebook_metadata;
+use std::collections::{HashMap, HashSet};
 use std::sync::Arc;
 
+use super::discovery::file_discovery::{DiscoveredFiles, SkipReason, SkippedFile};
 use super::discovery::services::StageResultsBundle;
 use super::discovery::services::{
     BatchedFileReader, DefaultResultAggregator, FileBatchReader, FileDiscoverer,
@@ -222,18 +228,42 @@ impl AnalysisPipeline {
 
         // Stage 1: File discovery and reading
         report("Discovering files...", 0.0);
-        let files = self.discover_files(paths).await?;
+        let DiscoveredFiles {
+            files,
+            skipped: mut skipped_files,
+        } = self
+            .discover_files_detailed(paths)
+            .instrument(info_span!("discover_files"))
+            .await?;
         info!("Discovered {} files for analysis", files.len());
 
         report("Reading file contents in batches...", 5.0);
-        let file_contents = self.read_files_batched(&files).await?;
+        let file_contents = self
+            .read_files_batched(&files)
+            .instrument(info_span!("read_files", files = files.len()))
+            .await?;
         info!("Read {} files in batches", file_contents.len());
+        let generated_code = GeneratedCode::detect(&file_contents);
+        let notebook_metadata = collect_notebook_metadata(&files);
+        let redactions = Redactions::collect(
+            file_contents
+                .iter()
+                .map(|(path, source)| (path.as_path(), source.as_str())),
+            self.redaction(),
+        );
+        if !redactions.is_empty() {
+            info!(
+                "Redacting {} string literals and comments",
+                redactions.len()
+            );
+        }
 
         // Stage 2: Arena-based entity extraction
         report("Running arena-based entity extraction...", 7.5);
         let arena_results = self
             .stage_runner
             .run_arena_analysis_with_content(&file_contents)
+            .instrument(info_span!("arena_analysis"))
             .await?;
         info!(
             "Arena analysis completed: {} files processed with {:.2} KB total arena usage",
@@ -241,18 +271,81 @@ impl AnalysisPipeline {
             arena_results.iter().map(|r| r.arena_kb_used()).sum::<f64>()
         );
 
+        // Once the deadline passes, later stages only see the files that were reached.
+        let (files, file_contents, mut timed_out) =
+            self.narrow_to_reached_files(files, file_contents, &arena_results, &mut skipped_files);
+
+        // Generated files stay in the dependency graph but out of the metrics.
+        let generated_paths: HashSet<&Path> = if self.include_generated() {
+            HashSet::new()
+        } else {
+            generated_code
+                .files
+                .iter()
+                .map(|file| file.path.as_path())
+                .collect()
+        };
+        if !generated_paths.is_empty() {
+            info!(
+                "Excluding {} generated files from complexity and coverage metrics",
+                generated_paths.len()
+            );
+        }
+        let metric_files: Vec<PathBuf> = files
+            .iter()
+            .filter(|file| !generated_paths.contains(file.as_path()))
+            .cloned()
+            .collect();
+        let arena_results: Vec<_> = arena_results
+            .into_iter()
+            .filter(|result| !generated_paths.contains(Path::new(result.file_path_str())))
+            .collect();
+
         // Stage 3: Run all analysis stages
         report("Running parallel analysis stages...", 10.0);
-        let stages = self
+        let mut stages = self
             .stage_runner
-            .run_all_stages(&self.config, paths, &files, &arena_results)
+            .run_all_stages(&self.config, paths, &metric_files, &files, &arena_results)
+            .instrument(info_span!("analysis_stages"))
             .await?;
+        if !generated_paths.is_empty() {
+            let generated: Vec<&Path> = generated_paths.iter().copied().collect();
+            stages.coverage.exclude_files(&generated);
+        }
+        timed_out |= stages.timed_out;
+        let duplicates = match self.min_clone_nodes() {
+            Some(_) if self.past_deadline() => {
+                warn!("Skipping duplicate detection: the analysis deadline has passed");
+                timed_out = true;
+                DuplicateCode::default()
+            }
+            Some(min_nodes) => info_span!("duplicate_detection").in_scope(|| {
+                DuplicateCode::detect(
+                    file_contents
+                        .iter()
+                        .filter(|(path, _)| !generated_paths.contains(path.as_path()))
+                        .map(|(path, source)| (path.as_path(), source.as_str())),
+                    min_nodes,
+                )
+            }),
+            None => DuplicateCode::default(),
+        };
 
         // Stage 4: Calculate health metrics
         report("Calculating health metrics...", 90.0);
-        let (mut summary, mut health_metrics) = self.build_metrics(&files, &stages);
-        let documentation_results =
-            self.compute_documentation_health(paths, &files, &mut summary, &mut health_metrics);
+        let (mut summary, health_metrics, documentation_results) = info_span!("health_metrics")
+            .in_scope(|| {
+                let (mut summary, mut health_metrics) = self.build_metrics(&files, &stages);
+                let documentation_results = self.compute_documentation_health(
+                    paths,
+                    &files,
+                    &mut summary,
+                    &mut health_metrics,
+                );
+                (summary, health_metrics, documentation_results)
+            });
+
+        summary.timed_out = timed_out;
 
         report("Analysis complete", 100.0);
         let processing_time = start_time.elapsed().as_secs_f64();
@@ -273,9 +366,92 @@ impl AnalysisPipeline {
             documentation: documentation_results,
             cohesion: stages.cohesion,
             health_metrics,
+            skipped_files,
+            generated_code,
+            notebook_metadata,
+            duplicates,
+            redactions,
         })
     }
 
+    /// Drop files the arena stage never reached before the `--timeout` deadline,
+    /// recording each as [`SkipReason::NotAnalyzed`]. Returns the remaining files
+    /// and contents plus whether anything was dropped.
+    fn narrow_to_reached_files(
+        &self,
+        files: Vec<PathBuf>,
+        file_contents: Vec<(PathBuf, String)>,
+        arena_results: &[ArenaAnalysisResult],
+        skipped_files: &mut Vec<SkippedFile>,
+    ) -> (Vec<PathBuf>, Vec<(PathBuf, String)>, bool) {
+        if !self.past_deadline() {
+            return (files, file_contents, false);
+        }
+
+        let reached: HashSet<&Path> = arena_results
+            .iter()
+            .map(|result| Path::new(result.file_path_str()))
+            .collect();
+        let (file_contents, not_reached): (Vec<_>, Vec<_>) = file_contents
+            .into_iter()
+            .partition(|(path, _)| reached.contains(path.as_path()));
+        if not_reached.is_empty() {
+            return (files, file_contents, false);
+        }
+
+        warn!(
+            "Analysis timed out: {} of {} files were not analyzed",
+            not_reached.len(),
+            not_reached.len() + file_contents.len()
+        );
+        let dropped: HashSet<&Path> = not_reached.iter().map(|(path, _)| path.as_path()).collect();
+        let files = files
+            .into_iter()
+            .filter(|file| !dropped.contains(file.as_path()))
+            .collect();
+        skipped_files.extend(not_reached.iter().map(|(path, content)| SkippedFile {
+            path: path.clone(),
+            reason: SkipReason::NotAnalyzed,
+            size_bytes: content.len() as u64,
+            limit_bytes: 0,
+            language: None,
+        }));
+        skipped_files.sort_by(|a, b| a.path.cmp(&b.path));
+        (files, file_contents, true)
+    }
+
+    /// Whether the `--timeout` deadline has passed.
+    fn past_deadline(&self) -> bool {
+        self.valknut_config
+            .as_ref()
+            .and_then(|config| config.analysis.deadline)
+            .is_some_and(|deadline| Instant::now() >= deadline)
+    }
+
+    /// Whether generated files count towards complexity and coverage metrics.
+    fn include_generated(&self) -> bool {
+        self.valknut_config.as_ref().map_or(
+            crate::core::config::AnalysisConfig::default_include_generated(),
+            |config| config.analysis.include_generated,
+        )
+    }
+
+    /// String literals and comments to strip from the results.
+    fn redaction(&self) -> RedactionOptions {
+        self.valknut_config
+            .as_ref()
+            .map(|config| config.analysis.redaction())
+            .unwrap_or_default()
+    }
+
+    /// Minimum clone size when duplicate detection is enabled.
+    fn min_clone_nodes(&self) -> Option<usize> {
+        self.valknut_config
+            .as_ref()
+            .filter(|config| config.analysis.detect_duplicates)
+            .map(|config| config.analysis.min_clone_nodes)
+    }
+
     /// Build summary and health metrics from stage results.
     fn build_metrics(
         &self,
@@ -352,10 +528,22 @@ impl AnalysisPipeline {
 
     /// Discover files to analyze using git-aware file discovery
     pub(crate) async fn discover_files(&self, paths: &[PathBuf]) -> Result<Vec<PathBuf>> {
+        self.discover_files_detailed(paths)
+            .await
+            .map(|found| found.files)
+    }
+
+    /// Discover files to analyze plus the ones skipped for exceeding the size limit
+    pub(crate) async fn discover_files_detailed(
+        &self,
+        paths: &[PathBuf],
+    ) -> Result<DiscoveredFiles> {
         let start_time = std::time::Instant::now();
-        let mut files =
-            self.file_discoverer
-                .discover(paths, &self.config, self.valknut_config.as_ref())?;
+        let DiscoveredFiles { mut files, skipped } = self.file_discoverer.discover_detailed(
+            paths,
+            &self.config,
+            self.valknut_config.as_ref(),
+        )?;
 
         let discovery_time = start_time.elapsed();
 
@@ -371,7 +559,7 @@ impl AnalysisPipeline {
             info!("Discovered {} files in {:?}", files.len(), discovery_time);
         }
 
-        Ok(files)
+        Ok(DiscoveredFiles { files, skipped })
     }
 
     /// Read multiple files in batches for optimal I/O performance
@@ -517,6 +705,8 @@ impl AnalysisPipeline {
             critical_issues,
             doc_health_score: 1.0,
             doc_issue_count: 0,
+            complexity: Default::default(),
+            timed_out: false,
         };
 
         let placeholder = ComprehensiveAnalysisResult {
@@ -551,6 +741,7 @@ impl AnalysisPipeline {
                 chokepoints: Vec::new(),
                 clone_groups: Vec::new(),
                 issues_count: 0,
+                call_graph: None,
             },
             lsh: super::results::pipeline_results::LshAnalysisResults {
                 enabled: false,
@@ -574,6 +765,11 @@ impl AnalysisPipeline {
             documentation: DocumentationAnalysisResults::default(),
             cohesion: CohesionAnalysisResults::default(),
             health_metrics,
+            skipped_files: Vec::new(),
+            generated_code: Default::default(),
+            notebook_metadata: Vec::new(),
+            duplicates: Default::default(),
+            redactions: Default::default(),
         };
 
         Ok(PipelineResults {
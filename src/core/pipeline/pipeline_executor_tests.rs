@@ -49,6 +49,7 @@ fn sample_complexity_result(
             parameter_count: 2.0,
             lines_of_code: 24.0,
             statement_count: 12.0,
+            expression_complexity: 0.0,
             halstead: HalsteadMetrics::default(),
             technical_debt_score: technical_debt,
             maintainability_index: maintainability,
@@ -107,6 +108,8 @@ fn build_sample_results() -> ComprehensiveAnalysisResult {
         critical_issues: 3,
         doc_health_score: 1.0,
         doc_issue_count: 0,
+        complexity: Default::default(),
+        timed_out: false,
     };
 
     ComprehensiveAnalysisResult {
@@ -141,6 +144,7 @@ fn build_sample_results() -> ComprehensiveAnalysisResult {
             chokepoints: vec![],
             clone_groups: vec![],
             issues_count: 1,
+            call_graph: None,
         },
         lsh: LshAnalysisResults {
             enabled: false,
@@ -176,6 +180,11 @@ fn build_sample_results() -> ComprehensiveAnalysisResult {
             structure_quality_score: 45.0,
             doc_health_score: 100.0,
         },
+        skipped_files: Vec::new(),
+        generated_code: Default::default(),
+        notebook_metadata: Vec::new(),
+        duplicates: Default::default(),
+        redactions: Default::default(),
     }
 }
 
@@ -450,6 +459,7 @@ fn calculate_health_metrics_handles_disabled_modules() {
         chokepoints: Vec::new(),
         clone_groups: Vec::new(),
         issues_count: 0,
+        call_graph: None,
     };
 
     let metrics = aggregator.build_health_metrics(&complexity, &structure, &impact);
@@ -513,6 +523,7 @@ fn calculate_summary_extracts_languages_and_counts_issues() {
         chokepoints: Vec::new(),
         clone_groups: Vec::new(),
         issues_count: 0,
+        call_graph: None,
     };
 
     let summary = aggregator.build_summary(&files, &structure, &complexity, &refactoring, &impact);
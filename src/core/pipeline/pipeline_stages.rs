@@ -3,8 +3,11 @@
 use async_trait::async_trait;
 use futures::future;
 use std::collections::{HashMap, HashSet};
+use std::future::Future;
 use std::path::{Path, PathBuf};
+use std::sync::atomic::{AtomicBool, Ordering};
 use std::sync::Arc;
+use std::time::Instant;
 use tracing::{debug, info, warn};
 
 use super::discovery::services::{StageOrchestrator, StageResultsBundle};
@@ -27,18 +30,23 @@ use super::verification::clone_detection::{
 };
 use crate::core::arena_analysis::{ArenaAnalysisResult, ArenaBatchAnalyzer, ArenaFileAnalyzer};
 use crate::core::ast_service::{AstService, CachedTree};
+use crate::core::concurrency::ConcurrentAnalyzer;
 use crate::core::config::{CoverageConfig, ValknutConfig};
 use crate::core::dependency::{ModuleGraph, ProjectDependencyAnalysis};
 use crate::core::errors::Result;
 use crate::core::featureset::FeatureExtractor;
 use crate::core::file_utils::{CoverageDiscovery, CoverageFile, CoverageFormat};
 use crate::detectors::cohesion::{CohesionAnalysisResults, CohesionExtractor};
-use crate::detectors::complexity::{AstComplexityAnalyzer, ComplexityAnalyzer};
+use crate::detectors::complexity::{
+    AstComplexityAnalyzer, ComplexityAnalyzer, ComplexityConfig, ComplexityThresholds,
+};
 use crate::detectors::coverage::{CoverageConfig as CoverageDetectorConfig, CoverageExtractor};
 use crate::detectors::graph::SimilarityCliquePartitioner;
 use crate::detectors::lsh::LshExtractor;
 use crate::detectors::refactoring::RefactoringAnalyzer;
 use crate::detectors::structure::StructureExtractor;
+use crate::io::cache::IncrementalCache;
+use crate::lang::notebook::analysis_source;
 
 /// Handles all individual analysis stages
 pub struct AnalysisStages {
@@ -65,10 +73,8 @@ impl AnalysisStages {
         ast_service: Arc<AstService>,
         valknut_config: Arc<ValknutConfig>,
     ) -> Self {
-        let ast_complexity_analyzer = AstComplexityAnalyzer::new(
-            crate::detectors::complexity::ComplexityConfig::default(),
-            ast_service.clone(),
-        );
+        let ast_complexity_analyzer =
+            AstComplexityAnalyzer::new(complexity_config(&valknut_config), ast_service.clone());
 
         // Initialize cohesion extractor if enabled in config
         // Wire analysis.exclude_patterns to cohesion config
@@ -97,7 +103,9 @@ impl AnalysisStages {
             lsh_extractor: None,
             coverage_extractor,
             cohesion_extractor,
-            arena_analyzer: ArenaFileAnalyzer::with_ast_service(ast_service.clone()),
+            arena_analyzer: ArenaFileAnalyzer::with_ast_service(ast_service.clone())
+                .with_max_ast_depth(valknut_config.analysis.max_ast_depth)
+                .with_deadline(valknut_config.analysis.deadline),
             ast_service,
             valknut_config,
         }
@@ -113,10 +121,8 @@ impl AnalysisStages {
         ast_service: Arc<AstService>,
         valknut_config: Arc<ValknutConfig>,
     ) -> Self {
-        let ast_complexity_analyzer = AstComplexityAnalyzer::new(
-            crate::detectors::complexity::ComplexityConfig::default(),
-            ast_service.clone(),
-        );
+        let ast_complexity_analyzer =
+            AstComplexityAnalyzer::new(complexity_config(&valknut_config), ast_service.clone());
 
         // Initialize cohesion extractor if enabled in config
         // Wire analysis.exclude_patterns to cohesion config
@@ -145,7 +151,9 @@ impl AnalysisStages {
             lsh_extractor: Some(lsh_extractor),
             coverage_extractor,
             cohesion_extractor,
-            arena_analyzer: ArenaFileAnalyzer::with_ast_service(ast_service.clone()),
+            arena_analyzer: ArenaFileAnalyzer::with_ast_service(ast_service.clone())
+                .with_max_ast_depth(valknut_config.analysis.max_ast_depth)
+                .with_deadline(valknut_config.analysis.deadline),
             ast_service,
             valknut_config,
         }
@@ -206,13 +214,19 @@ impl AnalysisStages {
             .await
     }
 
+    /// Worker pool for per-file stages, sized by `performance.max_threads`.
+    fn worker_pool(&self) -> ConcurrentAnalyzer {
+        ConcurrentAnalyzer::from_performance_config(&self.valknut_config.performance)
+    }
+
     /// Run complexity analysis from pre-extracted arena results (optimized path)
     /// Delegates to ComplexityStage for implementation.
     pub async fn run_complexity_analysis_from_arena_results(
         &self,
         arena_results: &[crate::core::arena_analysis::ArenaAnalysisResult],
     ) -> Result<ComplexityAnalysisResults> {
-        let complexity_stage = ComplexityStage::new(self.ast_complexity_analyzer.clone());
+        let complexity_stage = ComplexityStage::new(self.ast_complexity_analyzer.clone())
+            .with_pool(self.worker_pool());
         complexity_stage.run_from_arena_results(arena_results).await
     }
 
@@ -222,7 +236,8 @@ impl AnalysisStages {
         &self,
         files: &[PathBuf],
     ) -> Result<ComplexityAnalysisResults> {
-        let complexity_stage = ComplexityStage::new(self.ast_complexity_analyzer.clone());
+        let complexity_stage = ComplexityStage::new(self.ast_complexity_analyzer.clone())
+            .with_pool(self.worker_pool());
         complexity_stage.run_from_files(files).await
     }
 
@@ -232,14 +247,19 @@ impl AnalysisStages {
         &self,
         files: &[PathBuf],
     ) -> Result<RefactoringAnalysisResults> {
-        let refactoring_stage = RefactoringStage::new(&self.refactoring_analyzer);
+        let refactoring_stage =
+            RefactoringStage::new(&self.refactoring_analyzer).with_pool(self.worker_pool());
         refactoring_stage.run_refactoring_analysis(files).await
     }
 
     /// Run impact analysis powered by the dependency graph.
     /// Delegates to ImpactStage for implementation.
     pub async fn run_impact_analysis(&self, files: &[PathBuf]) -> Result<ImpactAnalysisResults> {
-        let impact_stage = ImpactStage::new();
+        let graph_config = &self.valknut_config.graph;
+        let mut impact_stage = ImpactStage::new();
+        if graph_config.enable_call_graph {
+            impact_stage = impact_stage.with_call_graph(graph_config.call_graph_depth);
+        }
         impact_stage.run_impact_analysis(files).await
     }
 
@@ -293,7 +313,7 @@ impl AnalysisStages {
         for file_path in files {
             match fs::read_to_string(file_path).await {
                 Ok(source) => {
-                    file_sources.push((file_path.as_path(), source));
+                    file_sources.push((file_path.as_path(), analysis_source(file_path, source)));
                 }
                 Err(e) => {
                     warn!("Failed to read file {}: {}", file_path.display(), e);
@@ -308,7 +328,9 @@ impl AnalysisStages {
         }
 
         // Use ArenaBatchAnalyzer for optimal memory usage
-        let batch_analyzer = ArenaBatchAnalyzer::new();
+        let batch_analyzer = ArenaBatchAnalyzer::new()
+            .with_max_ast_depth(self.valknut_config.analysis.max_ast_depth)
+            .with_deadline(self.valknut_config.analysis.deadline);
 
         // Convert to the format expected by batch analyzer
         let file_refs: Vec<(&std::path::Path, &str)> = file_sources
@@ -349,8 +371,16 @@ impl AnalysisStages {
             return Ok(Vec::new());
         }
 
+        if let Some(cache_dir) = self.incremental_cache_dir() {
+            return self
+                .run_incremental_arena_analysis(file_contents, &cache_dir)
+                .await;
+        }
+
         // Use ArenaBatchAnalyzer for optimal memory usage
-        let batch_analyzer = ArenaBatchAnalyzer::new();
+        let batch_analyzer = ArenaBatchAnalyzer::new()
+            .with_max_ast_depth(self.valknut_config.analysis.max_ast_depth)
+            .with_deadline(self.valknut_config.analysis.deadline);
 
         // Convert to the format expected by batch analyzer
         let file_refs: Vec<(&std::path::Path, &str)> = file_contents
@@ -375,6 +405,90 @@ impl AnalysisStages {
 
         Ok(batch_result.file_results)
     }
+
+    /// Cache directory for incremental analysis, or `None` when caching is disabled.
+    fn incremental_cache_dir(&self) -> Option<PathBuf> {
+        let io = &self.valknut_config.io;
+        if !io.enable_caching {
+            return None;
+        }
+        io.cache_dir.clone().or_else(IncrementalCache::default_dir)
+    }
+
+    /// Run arena analysis, reusing cached entities for files whose content is unchanged.
+    async fn run_incremental_arena_analysis(
+        &self,
+        file_contents: &[(PathBuf, String)],
+        cache_dir: &Path,
+    ) -> Result<Vec<ArenaAnalysisResult>> {
+        let mut cache = IncrementalCache::open(cache_dir)
+            .with_max_size(self.valknut_config.io.cache_max_size_bytes);
+        let stale = cache.stale_paths(file_contents);
+
+        // Materialise cache hits before storing fresh entries.
+        let mut cached_by_path: HashMap<&Path, ArenaAnalysisResult> = file_contents
+            .iter()
+            .filter(|(path, _)| !stale.contains(path))
+            .filter_map(|(path, content)| {
+                debug!(path = %path.display(), "cache hit");
+                cache
+                    .get(path)
+                    .map(|entry| (path.as_path(), entry.to_arena_result(content)))
+            })
+            .collect();
+
+        let to_analyze: Vec<(&Path, &str)> = file_contents
+            .iter()
+            .filter(|(path, _)| stale.contains(path))
+            .map(|(path, content)| (path.as_path(), content.as_str()))
+            .collect();
+        info!(
+            "Incremental analysis: {} of {} files changed, reusing cache for the rest",
+            to_analyze.len(),
+            file_contents.len()
+        );
+
+        let mut fresh_results = ArenaBatchAnalyzer::new()
+            .with_max_ast_depth(self.valknut_config.analysis.max_ast_depth)
+            .with_deadline(self.valknut_config.analysis.deadline)
+            .analyze_batch(to_analyze.clone())
+            .await?
+            .file_results
+            .into_iter()
+            .peekable();
+
+        // The batch leaves out files that failed to parse or were not reached.
+        let mut fresh_by_path: HashMap<&Path, ArenaAnalysisResult> = HashMap::new();
+        for (path, content) in to_analyze {
+            let Some(result) =
+                fresh_results.next_if(|result| Path::new(result.file_path_str()) == path)
+            else {
+                continue;
+            };
+            let imports = crate::lang::adapter_for_file(path)
+                .and_then(|mut adapter| adapter.extract_imports(content))
+                .map(|imports| imports.into_iter().map(|import| import.module).collect())
+                .unwrap_or_default();
+            debug!(path = %path.display(), entities = result.entity_count, "file processed");
+            cache.store(path, content, imports, &result);
+            fresh_by_path.insert(path, result);
+        }
+
+        let results = file_contents
+            .iter()
+            .filter_map(|(path, _)| {
+                fresh_by_path
+                    .remove(path.as_path())
+                    .or_else(|| cached_by_path.remove(path.as_path()))
+            })
+            .collect();
+
+        if let Err(e) = cache.save() {
+            warn!("Failed to persist incremental analysis cache: {}", e);
+        }
+
+        Ok(results)
+    }
 }
 
 /// [`StageOrchestrator`] implementation for [`AnalysisStages`].
@@ -395,6 +509,7 @@ impl StageOrchestrator for AnalysisStages {
         config: &AnalysisConfig,
         paths: &[PathBuf],
         files: &[PathBuf],
+        dependency_files: &[PathBuf],
         arena_results: &[ArenaAnalysisResult],
     ) -> Result<StageResultsBundle> {
         info!(
@@ -404,10 +519,12 @@ impl StageOrchestrator for AnalysisStages {
             arena_results.len()
         );
 
+        let deadline = StageDeadline::new(self.valknut_config.analysis.deadline);
+
         // Run Group 1 (structure + coverage) and Group 2 (complexity + refactoring + impact + lsh) in parallel
         let (group1_results, group2_results) = future::join(
-            self.run_stage_group1(config, paths, arena_results),
-            self.run_stage_group2(config, files, arena_results),
+            self.run_stage_group1(&deadline, config, paths, arena_results),
+            self.run_stage_group2(&deadline, config, files, dependency_files, arena_results),
         )
         .await;
 
@@ -417,7 +534,13 @@ impl StageOrchestrator for AnalysisStages {
         info!("All analysis stages completed");
 
         // Run cohesion analysis separately (requires mutable access via mutex)
-        let cohesion_result = self.run_cohesion_stage(paths, arena_results).await?;
+        let cohesion_result = deadline
+            .run(
+                "cohesion",
+                self.run_cohesion_stage(paths, arena_results),
+                CohesionAnalysisResults::default(),
+            )
+            .await?;
 
         info!("Building results bundle");
         Ok(StageResultsBundle {
@@ -428,6 +551,7 @@ impl StageOrchestrator for AnalysisStages {
             impact: impact_result?,
             lsh: lsh_result?,
             cohesion: cohesion_result,
+            timed_out: deadline.expired(),
         })
     }
 }
@@ -437,6 +561,7 @@ impl AnalysisStages {
     /// Run stage group 1: structure and coverage analysis in parallel.
     async fn run_stage_group1(
         &self,
+        deadline: &StageDeadline,
         config: &AnalysisConfig,
         paths: &[PathBuf],
         arena_results: &[ArenaAnalysisResult],
@@ -444,16 +569,28 @@ impl AnalysisStages {
         Result<StructureAnalysisResults>,
         Result<CoverageAnalysisResults>,
     ) {
-        let structure_future = self.run_structure_stage(config, paths, arena_results);
-        let coverage_future = self.run_coverage_stage(config, paths);
+        let structure_future = deadline.run(
+            "structure",
+            self.run_structure_stage(config, paths, arena_results),
+            StructureAnalysisResults::disabled(),
+        );
+        let coverage_future = deadline.run(
+            "coverage",
+            self.run_coverage_stage(config, paths),
+            CoverageAnalysisResults::disabled(),
+        );
         future::join(structure_future, coverage_future).await
     }
 
     /// Run stage group 2: complexity, refactoring, impact, and LSH analysis in parallel.
+    ///
+    /// Impact analysis builds the dependency graph from `dependency_files`.
     async fn run_stage_group2(
         &self,
+        deadline: &StageDeadline,
         config: &AnalysisConfig,
         files: &[PathBuf],
+        dependency_files: &[PathBuf],
         arena_results: &[ArenaAnalysisResult],
     ) -> (
         Result<ComplexityAnalysisResults>,
@@ -462,10 +599,26 @@ impl AnalysisStages {
         Result<LshAnalysisResults>,
     ) {
         future::join4(
-            self.run_complexity_stage(config, arena_results),
-            self.run_refactoring_stage(config, files),
-            self.run_impact_stage(config, files),
-            self.run_lsh_stage(config, files),
+            deadline.run(
+                "complexity",
+                self.run_complexity_stage(config, arena_results),
+                ComplexityAnalysisResults::disabled(),
+            ),
+            deadline.run(
+                "refactoring",
+                self.run_refactoring_stage(config, files),
+                RefactoringAnalysisResults::disabled(),
+            ),
+            deadline.run(
+                "impact",
+                self.run_impact_stage(config, dependency_files),
+                ImpactAnalysisResults::disabled(),
+            ),
+            deadline.run(
+                "LSH",
+                self.run_lsh_stage(config, files),
+                LshAnalysisResults::disabled(),
+            ),
         )
         .await
     }
@@ -589,6 +742,60 @@ impl AnalysisStages {
     }
 }
 
+/// Bounds the analysis stages by the `--timeout` deadline.
+struct StageDeadline {
+    deadline: Option<Instant>,
+    expired: AtomicBool,
+}
+
+/// Deadline checks for [`StageDeadline`].
+impl StageDeadline {
+    /// Bound stages by `deadline` (`None` runs them to completion).
+    fn new(deadline: Option<Instant>) -> Self {
+        Self {
+            deadline,
+            expired: AtomicBool::new(false),
+        }
+    }
+
+    /// Run `stage` unless the deadline has passed, abandoning it at its next
+    /// await point once the deadline passes. A skipped or abandoned stage
+    /// reports `disabled`.
+    async fn run<T>(
+        &self,
+        name: &str,
+        stage: impl Future<Output = Result<T>>,
+        disabled: T,
+    ) -> Result<T> {
+        let Some(deadline) = self.deadline else {
+            return stage.await;
+        };
+        if Instant::now() < deadline {
+            if let Ok(result) = tokio::time::timeout_at(deadline.into(), stage).await {
+                return result;
+            }
+        }
+        warn!("Skipping {name} analysis: the analysis deadline has passed");
+        self.expired.store(true, Ordering::Relaxed);
+        Ok(disabled)
+    }
+
+    /// Whether any stage was skipped or abandoned.
+    fn expired(&self) -> bool {
+        self.expired.load(Ordering::Relaxed)
+    }
+}
+
+/// Complexity detector settings derived from the analysis configuration.
+fn complexity_config(valknut_config: &ValknutConfig) -> ComplexityConfig {
+    ComplexityConfig {
+        expression_thresholds: ComplexityThresholds::with_warning_threshold(
+            valknut_config.analysis.expression_complexity_threshold,
+        ),
+        ..ComplexityConfig::default()
+    }
+}
+
 #[cfg(test)]
 #[path = "pipeline_stages_tests.rs"]
 mod tests;
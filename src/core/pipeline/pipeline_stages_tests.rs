@@ -407,6 +407,71 @@ pub mod cycle {
     let _ = analysis.chokepoints();
 }
 
+#[test]
+fn dependency_call_graph_follows_go_dispatch_and_respects_depth() {
+    let tmp = tempdir().expect("temp dir");
+    let file_path = tmp.path().join("shapes.go");
+    let content = r#"
+package main
+
+type Shape interface { Area() float64 }
+type Square struct{ s float64 }
+type Circle struct{ r float64 }
+
+func (q Square) Area() float64 { return q.s * q.s }
+func (c Circle) Area() float64 { return 3.14 * c.r * c.r }
+
+func total(shapes []Shape) float64 {
+    sum := 0.0
+    for _, sh := range shapes {
+        sum += sh.Area()
+    }
+    return sum
+}
+
+func report(x float64) {}
+
+func main() {
+    f := total
+    go func() {
+        report(f(nil))
+    }()
+}
+"#;
+    std::fs::write(&file_path, content).expect("write go sample");
+
+    let analysis =
+        ProjectDependencyAnalysis::analyze(&[file_path]).expect("perform dependency analysis");
+    let graph = analysis.call_graph(None);
+    let id_of = |qualified: &str| -> String {
+        graph
+            .nodes
+            .iter()
+            .find(|(_, node)| node.qualified_name == qualified)
+            .map(|(id, _)| id.clone())
+            .unwrap_or_else(|| panic!("missing node {}", qualified))
+    };
+
+    let main_callees = &graph.adjacency[&id_of("main")];
+    assert!(main_callees.contains(&id_of("total")));
+    assert!(main_callees.contains(&id_of("report")));
+
+    let total_callees = &graph.adjacency[&id_of("total")];
+    assert!(total_callees.contains(&id_of("Square::Area")));
+    assert!(total_callees.contains(&id_of("Circle::Area")));
+
+    let impacted = graph.callers_of(&id_of("Circle::Area"));
+    let mut expected = vec![id_of("main"), id_of("total")];
+    expected.sort();
+    assert_eq!(impacted, expected);
+
+    let shallow = analysis.call_graph(Some(1));
+    assert!(shallow.truncated);
+    assert_eq!(shallow.adjacency.len(), 1);
+    assert!(shallow.adjacency.contains_key(&id_of("main")));
+    assert!(!shallow.nodes.contains_key(&id_of("Square::Area")));
+}
+
 #[tokio::test]
 async fn simple_ast_cache_reuses_entries_and_handles_truncation() {
     let stages = build_test_stages();
@@ -718,3 +783,45 @@ pub fn compute(limit: i32) -> i32 {
         "averages should be non-negative"
     );
 }
+
+#[tokio::test]
+async fn stage_deadline_abandons_stages_that_outlive_it() {
+    let deadline = StageDeadline::new(Some(Instant::now() + Duration::from_millis(20)));
+    let stalled = deadline
+        .run(
+            "complexity",
+            future::pending::<Result<ComplexityAnalysisResults>>(),
+            ComplexityAnalysisResults::disabled(),
+        )
+        .await
+        .unwrap();
+    assert!(!stalled.enabled);
+    assert!(deadline.expired());
+
+    let started = AtomicBool::new(false);
+    let skipped = deadline
+        .run(
+            "coverage",
+            async {
+                started.store(true, Ordering::Relaxed);
+                Ok(CoverageAnalysisResults::disabled())
+            },
+            CoverageAnalysisResults::disabled(),
+        )
+        .await
+        .unwrap();
+    assert!(!skipped.enabled);
+    assert!(!started.load(Ordering::Relaxed));
+
+    let unbounded = StageDeadline::new(None);
+    let structure = unbounded
+        .run(
+            "structure",
+            async { Ok(StructureAnalysisResults::disabled()) },
+            StructureAnalysisResults::disabled(),
+        )
+        .await
+        .unwrap();
+    assert!(!structure.enabled);
+    assert!(!unbounded.expired());
+}
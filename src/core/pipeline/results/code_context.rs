@@ -0,0 +1,284 @@
+//! Test-context labeling of analysed code.
+//!
+//! Files under a `tests/` or `testdata/` directory, and Go `*_test.go` files,
+//! are analysed like any other file but their refactoring candidates carry
+//! `"context": "test"`. Consumers can filter on that label, `--exclude-tests`
+//! drops them from the primary output, and [`ContextStatistics`] buckets
+//! test and non-test code separately either way.
+
+use std::collections::BTreeSet;
+use std::path::{Component, Path};
+
+use serde::{Deserialize, Serialize};
+
+use super::result_types::{AnalysisResults, DirectoryHealthTree};
+
+/// Directory names whose contents are test code or fixtures.
+const TEST_DIRECTORIES: &[&str] = &["tests", "testdata"];
+
+/// File name suffixes of test files.
+const TEST_FILE_SUFFIXES: &[&str] = &["_test.go"];
+
+/// Whether code is part of the product or of its tests.
+#[derive(Debug, Clone, Copy, Default, PartialEq, Eq, Hash, Serialize, Deserialize)]
+#[serde(rename_all = "lowercase")]
+pub enum CodeContext {
+    /// Regular source code.
+    #[default]
+    Source,
+    /// Tests, test fixtures and test data.
+    Test,
+}
+
+/// Classification methods for [`CodeContext`].
+impl CodeContext {
+    /// Classify a path (absolute or relative to the project root).
+    pub fn for_path(path: impl AsRef<Path>) -> Self {
+        let path = path.as_ref();
+        let file_name = path.file_name().and_then(|name| name.to_str());
+        let in_test_directory =
+            path.parent()
+                .into_iter()
+                .flat_map(Path::components)
+                .any(|component| match component {
+                    Component::Normal(name) => name
+                        .to_str()
+                        .is_some_and(|name| TEST_DIRECTORIES.contains(&name)),
+                    _ => false,
+                });
+        let is_test_file = file_name.is_some_and(|name| {
+            TEST_FILE_SUFFIXES
+                .iter()
+                .any(|suffix| name.ends_with(suffix))
+        });
+
+        if in_test_directory || is_test_file {
+            Self::Test
+        } else {
+            Self::Source
+        }
+    }
+
+    /// Returns true for test code.
+    pub fn is_test(self) -> bool {
+        self == Self::Test
+    }
+}
+
+/// Totals for one [`CodeContext`].
+#[derive(Debug, Clone, Default, PartialEq, Eq, Serialize, Deserialize)]
+pub struct ContextBucket {
+    /// Files with health scores.
+    pub files: usize,
+    /// Refactoring candidates.
+    pub refactoring_candidates: usize,
+    /// Issues across those candidates.
+    pub issues: usize,
+}
+
+/// Source and test totals, computed before `--exclude-tests` filtering.
+#[derive(Debug, Clone, Default, PartialEq, Eq, Serialize, Deserialize)]
+pub struct ContextStatistics {
+    /// Regular source code.
+    pub source: ContextBucket,
+    /// Tests, test fixtures and test data.
+    pub test: ContextBucket,
+}
+
+/// Aggregation methods for [`ContextStatistics`].
+impl ContextStatistics {
+    /// Bucket the files and candidates of `results` by context.
+    pub fn collect(results: &AnalysisResults) -> Self {
+        let mut stats = Self::default();
+        let files: BTreeSet<&str> = results
+            .file_health
+            .keys()
+            .map(String::as_str)
+            .chain(
+                results
+                    .refactoring_candidates
+                    .iter()
+                    .map(|candidate| candidate.file_path.as_str()),
+            )
+            .collect();
+        for file in files {
+            stats.bucket_mut(results.context_of(file)).files += 1;
+        }
+        for candidate in &results.refactoring_candidates {
+            let bucket = stats.bucket_mut(candidate.context);
+            bucket.refactoring_candidates += 1;
+            bucket.issues += candidate.issue_count;
+        }
+        stats
+    }
+
+    /// Add the totals of another result set.
+    pub fn merge(&mut self, other: &ContextStatistics) {
+        for (bucket, extra) in [
+            (&mut self.source, &other.source),
+            (&mut self.test, &other.test),
+        ] {
+            bucket.files += extra.files;
+            bucket.refactoring_candidates += extra.refactoring_candidates;
+            bucket.issues += extra.issues;
+        }
+    }
+
+    /// The bucket for `context`.
+    pub fn bucket(&self, context: CodeContext) -> &ContextBucket {
+        match context {
+            CodeContext::Source => &self.source,
+            CodeContext::Test => &self.test,
+        }
+    }
+
+    /// Mutable bucket for `context`.
+    fn bucket_mut(&mut self, context: CodeContext) -> &mut ContextBucket {
+        match context {
+            CodeContext::Source => &mut self.source,
+            CodeContext::Test => &mut self.test,
+        }
+    }
+}
+
+/// Test-context filtering for [`AnalysisResults`].
+impl AnalysisResults {
+    /// Drop test-context candidates and file scores from the primary output.
+    ///
+    /// [`AnalysisResults::context_statistics`] keeps the test totals.
+    pub fn exclude_test_context(&mut self) {
+        self.refactoring_candidates
+            .retain(|candidate| !candidate.context.is_test());
+        let file_health = std::mem::take(&mut self.file_health);
+        self.file_health = file_health
+            .into_iter()
+            .filter(|(path, _)| !self.context_of(path).is_test())
+            .collect();
+        let file_complexity = std::mem::take(&mut self.file_complexity);
+        self.file_complexity = file_complexity
+            .into_iter()
+            .filter(|(path, _)| !self.context_of(path).is_test())
+            .collect();
+        let entity_health = std::mem::take(&mut self.entity_health);
+        self.entity_health = entity_health
+            .into_iter()
+            .filter(|(entity_id, _)| !self.context_of(entity_path(entity_id)).is_test())
+            .collect();
+        self.directory_health_tree = (!self.file_health.is_empty())
+            .then(|| DirectoryHealthTree::from_file_health(&self.file_health));
+    }
+
+    /// Context of `path`, ignoring directories above the project root.
+    pub fn context_of(&self, path: &str) -> CodeContext {
+        let path = Path::new(path);
+        CodeContext::for_path(path.strip_prefix(&self.project_root).unwrap_or(path))
+    }
+}
+
+/// File part of an entity id (`path:kind:name`).
+fn entity_path(entity_id: &str) -> &str {
+    entity_id.split(':').next().unwrap_or(entity_id)
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::core::pipeline::results::RefactoringCandidate;
+    use crate::core::scoring::Priority;
+    use std::path::PathBuf;
+
+    #[test]
+    fn labels_test_directories_and_go_test_files() {
+        for path in [
+            "tests/cli-e2e-tests/fixtures/app.py",
+            "pkg/parser/testdata/input.go",
+            "/repo/internal/server_test.go",
+            "crates/core/tests/integration.rs",
+        ] {
+            assert_eq!(CodeContext::for_path(path), CodeContext::Test, "{path}");
+        }
+        for path in [
+            "src/lib.rs",
+            "src/tests.rs",
+            "pkg/testing/helpers.go",
+            "src/contest/tests_util.py",
+        ] {
+            assert_eq!(CodeContext::for_path(path), CodeContext::Source, "{path}");
+        }
+    }
+
+    #[test]
+    fn serializes_as_lowercase_label() {
+        assert_eq!(
+            serde_json::to_value(CodeContext::Test).unwrap(),
+            serde_json::json!("test")
+        );
+        assert_eq!(
+            serde_json::to_value(CodeContext::Source).unwrap(),
+            serde_json::json!("source")
+        );
+    }
+
+    #[test]
+    fn exclude_test_context_keeps_statistics() {
+        let mut results = AnalysisResults::empty();
+        results.project_root = PathBuf::from("/work/tests/project");
+        for (path, context) in [
+            ("src/lib.rs", CodeContext::Source),
+            ("tests/cli.rs", CodeContext::Test),
+        ] {
+            results.file_health.insert(path.to_string(), 0.5);
+            results
+                .entity_health
+                .insert(format!("/work/tests/project/{path}:function:run"), 0.5);
+            results.refactoring_candidates.push(RefactoringCandidate {
+                entity_id: format!("{path}:function:run"),
+                name: "run".to_string(),
+                file_path: path.to_string(),
+                line_range: None,
+                priority: Priority::High,
+                score: 0.8,
+                confidence: 0.9,
+                issues: Vec::new(),
+                suggestions: Vec::new(),
+                issue_count: 2,
+                suggestion_count: 0,
+                coverage_percentage: None,
+                context,
+            });
+        }
+        results.context_statistics = ContextStatistics::collect(&results);
+        results.exclude_test_context();
+
+        assert_eq!(results.refactoring_candidates.len(), 1);
+        assert_eq!(results.refactoring_candidates[0].file_path, "src/lib.rs");
+        assert_eq!(results.file_health.len(), 1);
+        assert_eq!(results.entity_health.len(), 1);
+        assert!(results.directory_health_tree.is_some());
+        assert_eq!(results.context_statistics.source.files, 1);
+        assert_eq!(results.context_statistics.test.files, 1);
+        assert_eq!(results.context_statistics.test.issues, 2);
+    }
+
+    #[test]
+    fn merge_adds_bucket_totals() {
+        let mut stats = ContextStatistics::default();
+        stats.source.files = 2;
+        let other = ContextStatistics {
+            source: ContextBucket {
+                files: 1,
+                refactoring_candidates: 3,
+                issues: 4,
+            },
+            test: ContextBucket {
+                files: 5,
+                refactoring_candidates: 0,
+                issues: 0,
+            },
+        };
+        stats.merge(&other);
+        assert_eq!(stats.source.files, 3);
+        assert_eq!(stats.bucket(CodeContext::Source).issues, 4);
+        assert_eq!(stats.test.files, 5);
+    }
+}
@@ -5,8 +5,11 @@
 //! - Result conversions between formats
 //! - Pipeline results aggregation
 //! - Normalized types for scoring
+//! - Deterministic output ordering
 
+pub mod code_context;
 pub mod normalized_types;
+pub mod ordering;
 pub mod pipeline_results;
 pub mod result_builder;
 pub mod result_conversions;
@@ -18,7 +21,9 @@ mod result_conversions_tests;
 mod result_types_tests;
 
 // Explicit re-exports to avoid name collisions
+pub use code_context::{CodeContext, ContextBucket, ContextStatistics};
 pub use normalized_types::*;
+pub use ordering::serialize_sorted;
 pub use pipeline_results::{
     CloneVerificationResults, ComplexityAnalysisResults, ComprehensiveAnalysisResult,
     CoverageAnalysisResults, CoverageFileInfo, DocumentationAnalysisResults, FileScore,
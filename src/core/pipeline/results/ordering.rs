@@ -0,0 +1,101 @@
+//! Deterministic ordering for analysis output.
+//!
+//! Files are processed in parallel and many results are collected into hash
+//! maps, so the raw order of results varies between runs. Everything written
+//! to reports is put into a canonical order here so identical inputs produce
+//! byte-identical output: files by repo-relative path, entities by line within
+//! a file, and imports alphabetically.
+
+use std::cmp::Ordering;
+use std::collections::{BTreeMap, HashMap};
+
+use serde::{Serialize, Serializer};
+
+use super::result_types::{AnalysisResults, RefactoringCandidate};
+
+#[cfg(test)]
+#[path = "ordering_tests.rs"]
+mod tests;
+
+/// Serialize a [`HashMap`] with its entries sorted by key.
+///
+/// Use with `#[serde(serialize_with = "...")]` on map fields that end up in reports.
+pub fn serialize_sorted<S, K, V>(map: &HashMap<K, V>, serializer: S) -> Result<S::Ok, S::Error>
+where
+    S: Serializer,
+    K: Ord + Serialize,
+    V: Serialize,
+{
+    map.iter().collect::<BTreeMap<_, _>>().serialize(serializer)
+}
+
+/// Canonical ordering methods for [`AnalysisResults`].
+impl AnalysisResults {
+    /// Sort every result list into its canonical order.
+    ///
+    /// Called once results are complete; the order carries no meaning beyond
+    /// making repeated runs over the same tree produce identical output.
+    pub fn sort_deterministically(&mut self) {
+        self.refactoring_candidates.sort_by(compare_candidates);
+
+        self.passes.complexity.detailed_results.sort_by(|a, b| {
+            a.file_path
+                .cmp(&b.file_path)
+                .then(a.start_line.cmp(&b.start_line))
+                .then_with(|| a.entity_id.cmp(&b.entity_id))
+        });
+        self.passes
+            .refactoring
+            .detailed_results
+            .sort_by(|a, b| a.file_path.cmp(&b.file_path));
+
+        for pack in &mut self.coverage_packs {
+            pack.file_info.imports.sort();
+            pack.gaps.sort_by(|a, b| {
+                a.span
+                    .start
+                    .cmp(&b.span.start)
+                    .then(a.span.end.cmp(&b.span.end))
+            });
+            for gap in &mut pack.gaps {
+                gap.symbols.sort_by(|a, b| {
+                    a.line_start
+                        .cmp(&b.line_start)
+                        .then_with(|| a.name.cmp(&b.name))
+                });
+            }
+        }
+        // Packs stay in the detector's priority order; path and id only break ties.
+        self.coverage_packs.sort_by(|a, b| {
+            b.priority_score()
+                .total_cmp(&a.priority_score())
+                .then_with(|| a.path.cmp(&b.path))
+                .then_with(|| a.pack_id.cmp(&b.pack_id))
+        });
+
+        self.rule_findings.sort_by(|a, b| {
+            a.file_path
+                .cmp(&b.file_path)
+                .then(a.line_range.cmp(&b.line_range))
+                .then_with(|| a.rule.cmp(&b.rule))
+                .then_with(|| a.entity.cmp(&b.entity))
+        });
+
+        self.summary.languages.sort();
+        self.summary.languages.dedup();
+        self.warnings.sort();
+        self.skipped_files.sort_by(|a, b| a.path.cmp(&b.path));
+        self.generated_code.sort();
+        self.notebook_metadata.sort_by(|a, b| a.path.cmp(&b.path));
+        self.duplicates.sort();
+    }
+}
+
+/// Order candidates by file, then by starting line, then by id.
+fn compare_candidates(a: &RefactoringCandidate, b: &RefactoringCandidate) -> Ordering {
+    let start = |c: &RefactoringCandidate| c.line_range.map(|(start, _)| start);
+    a.file_path
+        .cmp(&b.file_path)
+        .then(start(a).cmp(&start(b)))
+        .then_with(|| a.entity_id.cmp(&b.entity_id))
+}
@@ -0,0 +1,109 @@
+use super::*;
+use crate::core::scoring::Priority;
+use crate::detectors::coverage::{CoveragePack, FileInfo, PackEffort, PackValue};
+use std::path::PathBuf;
+
+fn candidate(path: &str, start: usize) -> RefactoringCandidate {
+    RefactoringCandidate {
+        entity_id: format!("{path}:{start}"),
+        name: format!("fn_{start}"),
+        file_path: path.to_string(),
+        line_range: Some((start, start + 5)),
+        priority: Priority::Medium,
+        score: 0.5,
+        confidence: 0.9,
+        issues: Vec::new(),
+        suggestions: Vec::new(),
+        issue_count: 0,
+        suggestion_count: 0,
+        coverage_percentage: None,
+        context: Default::default(),
+    }
+}
+
+#[test]
+fn candidates_sort_by_path_then_line() {
+    let mut results = AnalysisResults::empty();
+    results.refactoring_candidates = vec![
+        candidate("src/b.rs", 3),
+        candidate("src/a.rs", 40),
+        candidate("src/b.rs", 1),
+        candidate("src/a.rs", 7),
+    ];
+    results.summary.languages = vec!["Rust".to_string(), "Go".to_string(), "Rust".to_string()];
+    results.warnings = vec!["zeta".to_string(), "alpha".to_string()];
+
+    results.sort_deterministically();
+
+    let order: Vec<&str> = results
+        .refactoring_candidates
+        .iter()
+        .map(|c| c.entity_id.as_str())
+        .collect();
+    assert_eq!(
+        order,
+        vec!["src/a.rs:7", "src/a.rs:40", "src/b.rs:1", "src/b.rs:3"]
+    );
+    assert_eq!(results.summary.languages, vec!["Go", "Rust"]);
+    assert_eq!(results.warnings, vec!["alpha", "zeta"]);
+}
+
+#[test]
+fn hash_maps_serialize_in_key_order() {
+    let mut results = AnalysisResults::empty();
+    for (index, path) in ["src/z.rs", "src/m.rs", "src/a.rs", "lib/k.rs"]
+        .iter()
+        .enumerate()
+    {
+        results.file_health.insert(path.to_string(), index as f64);
+    }
+
+    let json = serde_json::to_string(&results).unwrap();
+    let positions: Vec<usize> = ["lib/k.rs", "src/a.rs", "src/m.rs", "src/z.rs"]
+        .iter()
+        .map(|path| json.find(path).unwrap())
+        .collect();
+    assert!(positions.windows(2).all(|pair| pair[0] < pair[1]), "{json}");
+}
+
+fn pack(path: &str, repo_cov_gain_est: f64) -> CoveragePack {
+    CoveragePack {
+        kind: "file".to_string(),
+        pack_id: format!("cov:{path}"),
+        path: PathBuf::from(path),
+        file_info: FileInfo {
+            loc: 100,
+            coverage_before: 50.0,
+            coverage_after_if_filled: 80.0,
+        },
+        gaps: Vec::new(),
+        value: PackValue {
+            file_cov_gain: 30.0,
+            repo_cov_gain_est,
+        },
+        effort: PackEffort {
+            tests_to_write_est: 1,
+            mocks_est: 0,
+        },
+    }
+}
+
+#[test]
+fn coverage_packs_keep_priority_order_with_path_tiebreak() {
+    let mut results = AnalysisResults::empty();
+    results.coverage_packs = vec![
+        pack("src/a.rs", 1.0),
+        pack("src/z.rs", 4.0),
+        pack("src/c.rs", 2.0),
+        pack("src/b.rs", 2.0),
+    ];
+
+    results.sort_deterministically();
+
+    let order: Vec<&str> = results
+        .coverage_packs
+        .iter()
+        .map(|pack| pack.path.to_str().unwrap())
+        .collect();
+    assert_eq!(order, vec!["src/z.rs", "src/b.rs", "src/c.rs", "src/a.rs"]);
+}
@@ -3,15 +3,21 @@
 use chrono::{DateTime, Utc};
 use serde::{Deserialize, Serialize};
 use std::collections::HashMap;
-use std::path::PathBuf;
+use std::path::{Path, PathBuf};
 
 use super::result_types::AnalysisSummary;
+use crate::core::dependency::CallGraph;
 use crate::core::featureset::FeatureVector;
+use crate::core::pipeline::discovery::file_discovery::SkippedFile;
 use crate::core::pipeline::pipeline_config::AnalysisConfig;
+use crate::core::redaction::Redactions;
 use crate::core::scoring::ScoringResult;
 use crate::detectors::cohesion::CohesionAnalysisResults;
 use crate::detectors::complexity::ComplexityAnalysisResult;
+use crate::detectors::duplicates::DuplicateCode;
+use crate::detectors::generated::GeneratedCode;
 use crate::detectors::refactoring::RefactoringAnalysisResult;
+use crate::lang::notebook::NotebookMetadata;
 
 /// Comprehensive analysis result containing all analysis types
 #[derive(Debug, Clone, Serialize, Deserialize)]
@@ -46,6 +52,21 @@ pub struct ComprehensiveAnalysisResult {
     pub cohesion: CohesionAnalysisResults,
     /// Overall health metrics
     pub health_metrics: HealthMetrics,
+    /// Files that matched discovery but were not analyzed (e.g. over the size limit)
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub skipped_files: Vec<SkippedFile>,
+    /// Files marked as generated and `//go:generate` directives
+    #[serde(default, skip_serializing_if = "GeneratedCode::is_empty")]
+    pub generated_code: GeneratedCode,
+    /// Kernel and code-cell layout of analyzed Jupyter notebooks
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub notebook_metadata: Vec<NotebookMetadata>,
+    /// Structurally identical functions, when duplicate detection is enabled
+    #[serde(default, skip_serializing_if = "DuplicateCode::is_empty")]
+    pub duplicates: DuplicateCode,
+    /// String literals and comments to strip from the public results; never serialized
+    #[serde(skip)]
+    pub redactions: Redactions,
 }
 
 /// Structure analysis results
@@ -145,6 +166,9 @@ pub struct ImpactAnalysisResults {
     pub clone_groups: Vec<serde_json::Value>,
     /// Impact issues count
     pub issues_count: usize,
+    /// Function-level call graph, present when `--call-graph` is enabled
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub call_graph: Option<CallGraph>,
 }
 
 /// Factory methods for [`ImpactAnalysisResults`].
@@ -157,6 +181,7 @@ impl ImpactAnalysisResults {
             chokepoints: Vec::new(),
             clone_groups: Vec::new(),
             issues_count: 0,
+            call_graph: None,
         }
     }
 }
@@ -277,6 +302,38 @@ impl CoverageAnalysisResults {
     }
 }
 
+/// Filtering methods for [`CoverageAnalysisResults`].
+impl CoverageAnalysisResults {
+    /// Drop the coverage gaps reported for `files`.
+    ///
+    /// `gaps_count` shrinks by the gaps removed. The overall percentage is
+    /// the coverage report's own figure and is left as is.
+    pub fn exclude_files(&mut self, files: &[&Path]) {
+        let is_excluded = |pack: &serde_json::Value| {
+            pack.get("path")
+                .and_then(|path| path.as_str())
+                .is_some_and(|path| {
+                    let path = Path::new(path);
+                    files
+                        .iter()
+                        .any(|file| file.ends_with(path) || path.ends_with(file))
+                })
+        };
+        let mut removed_gaps = 0;
+        self.coverage_gaps.retain(|pack| {
+            if !is_excluded(pack) {
+                return true;
+            }
+            removed_gaps += pack
+                .get("gaps")
+                .and_then(|gaps| gaps.as_array())
+                .map_or(0, Vec::len);
+            false
+        });
+        self.gaps_count = self.gaps_count.saturating_sub(removed_gaps);
+    }
+}
+
 /// Documentation analysis results (placeholder until full doc wiring is complete)
 #[derive(Debug, Clone, Serialize, Deserialize, Default)]
 pub struct DocumentationAnalysisResults {
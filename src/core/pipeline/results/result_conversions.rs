@@ -6,9 +6,13 @@ use serde_json::{self, json};
 
 use super::pipeline_results::DocumentationAnalysisResults;
 use crate::core::featureset::FeatureVector;
-use crate::core::pipeline::{PipelineResults, ResultSummary, StageResultsBundle};
+use crate::core::pipeline::{PipelineResults, ResultSummary, SkippedFile, StageResultsBundle};
 use crate::core::scoring::{Priority, ScoringResult};
+use crate::detectors::complexity::ComplexityReport;
+use crate::detectors::duplicates::DuplicateCode;
+use crate::detectors::generated::GeneratedCode;
 
+use super::code_context::{CodeContext, ContextStatistics};
 use super::result_types::*;
 use crate::core::pipeline::discovery::code_dictionary::{
     issue_code_for_category, issue_definition_for_category, suggestion_code_for_kind,
@@ -75,6 +79,8 @@ impl AnalysisResults {
                 critical_issues: 0,
                 doc_health_score: 1.0,
                 doc_issue_count: 0,
+                complexity: ComplexityReport::default(),
+                timed_out: false,
             },
             normalized: None,
             passes: StageResultsBundle::disabled(),
@@ -97,11 +103,22 @@ impl AnalysisResults {
             warnings: Vec::new(),
             health_metrics: None,
             code_dictionary: CodeDictionary::default(),
+            rule_findings: Vec::new(),
+            changed_files_only: false,
+            dependency_report: Vec::new(),
+            interface_report: None,
+            project_health: None,
+            context_statistics: ContextStatistics::default(),
             documentation: None,
             directory_health: HashMap::new(),
             file_health: HashMap::new(),
             entity_health: HashMap::new(),
             directory_health_tree: None,
+            skipped_files: Vec::new(),
+            generated_code: GeneratedCode::default(),
+            notebook_metadata: Vec::new(),
+            duplicates: DuplicateCode::default(),
+            file_complexity: BTreeMap::new(),
         }
     }
 
@@ -171,10 +188,11 @@ impl AnalysisResults {
             if priority_cmp != std::cmp::Ordering::Equal {
                 priority_cmp
             } else {
-                // Secondary sort by average score (descending)
+                // Secondary sort by average score (descending), then by path for stable output
                 b.avg_score
                     .partial_cmp(&a.avg_score)
                     .unwrap_or(std::cmp::Ordering::Equal)
+                    .then_with(|| a.file_path.cmp(&b.file_path))
             }
         });
 
@@ -199,10 +217,26 @@ impl AnalysisResults {
         );
         let statistics =
             Self::build_statistics(&pipeline_results, &summary_stats, priority_distribution);
+        let skipped_files = Self::relative_skipped_files(&pipeline_results, &project_root);
+        let generated_code = pipeline_results
+            .results
+            .generated_code
+            .relative_to(&project_root);
+        let notebook_metadata = pipeline_results
+            .results
+            .notebook_metadata
+            .iter()
+            .map(|notebook| notebook.relative_to(&project_root))
+            .collect();
+        let duplicates = pipeline_results
+            .results
+            .duplicates
+            .relative_to(&project_root);
         let warnings = pipeline_results
             .errors
             .iter()
             .map(|e| e.to_string())
+            .chain(skipped_files.iter().map(SkippedFile::warning))
             .collect();
         let clone_analysis = Self::convert_lsh_to_clone_analysis(&pipeline_results);
         let coverage_packs =
@@ -243,7 +277,7 @@ impl AnalysisResults {
             None
         };
 
-        Self {
+        let mut results = Self {
             project_root,
             summary,
             normalized: None,
@@ -253,15 +287,29 @@ impl AnalysisResults {
             // naming_results: None, // Will be populated by naming analysis
             clone_analysis,
             warnings,
+            skipped_files,
+            generated_code,
+            notebook_metadata,
+            duplicates,
+            file_complexity: BTreeMap::new(),
             coverage_packs,
             health_metrics,
             code_dictionary,
+            rule_findings: Vec::new(),
+            changed_files_only: false,
+            dependency_report: Vec::new(),
+            interface_report: None,
+            project_health: None,
             documentation,
             directory_health,
             file_health,
             entity_health,
             directory_health_tree,
-        }
+            context_statistics: ContextStatistics::default(),
+        };
+        results.context_statistics = ContextStatistics::collect(&results);
+        results.sort_deterministically();
+        results
     }
 
     /// Calculate overall code health score from pipeline summary
@@ -275,6 +323,26 @@ impl AnalysisResults {
         (health_score - score_penalty).clamp(0.0, 1.0)
     }
 
+    /// Skipped files from discovery with paths relative to `project_root`.
+    fn relative_skipped_files(
+        pipeline_results: &PipelineResults,
+        project_root: &Path,
+    ) -> Vec<SkippedFile> {
+        pipeline_results
+            .results
+            .skipped_files
+            .iter()
+            .map(|skipped| SkippedFile {
+                path: skipped
+                    .path
+                    .strip_prefix(project_root)
+                    .map(Path::to_path_buf)
+                    .unwrap_or_else(|_| skipped.path.clone()),
+                ..skipped.clone()
+            })
+            .collect()
+    }
+
     fn build_refactoring_candidates(
         pipeline_results: &PipelineResults,
         project_root: &PathBuf,
@@ -336,6 +404,8 @@ impl AnalysisResults {
             critical_issues: base.critical_issues,
             doc_health_score: base.doc_health_score,
             doc_issue_count: base.doc_issue_count,
+            complexity: base.complexity.clone(),
+            timed_out: base.timed_out,
         }
     }
 
@@ -404,6 +474,7 @@ impl AnalysisResults {
             impact: pipeline_results.results.impact.clone(),
             lsh: pipeline_results.results.lsh.clone(),
             cohesion: pipeline_results.results.cohesion.clone(),
+            timed_out: pipeline_results.results.summary.timed_out,
         }
     }
 
@@ -637,6 +708,7 @@ impl RefactoringCandidate {
         // Generate suggestions based on issues
         let suggestions = generate_suggestions(&issues, &name, line_range);
 
+        let context = CodeContext::for_path(&file_path);
         Self {
             entity_id: result.entity_id.clone(),
             name,
@@ -650,6 +722,7 @@ impl RefactoringCandidate {
             issues,
             suggestions,
             coverage_percentage: None,
+            context,
         }
     }
 
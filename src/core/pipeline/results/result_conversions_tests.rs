@@ -45,6 +45,7 @@ fn sample_candidate(
         issue_count: 1,
         suggestion_count: 0,
         coverage_percentage: None,
+        context: Default::default(),
     }
 }
 
@@ -66,6 +67,8 @@ fn pipeline_results_fixture() -> PipelineResults {
         critical_issues: 0,
         doc_health_score: 1.0,
         doc_issue_count: 0,
+        complexity: Default::default(),
+        timed_out: false,
     };
 
     let structure = StructureAnalysisResults {
@@ -97,6 +100,7 @@ fn pipeline_results_fixture() -> PipelineResults {
         chokepoints: Vec::new(),
         clone_groups: Vec::new(),
         issues_count: 0,
+        call_graph: None,
     };
 
     let lsh = PipelineLshAnalysisResult {
@@ -154,6 +158,11 @@ fn pipeline_results_fixture() -> PipelineResults {
         documentation,
         cohesion: crate::detectors::cohesion::CohesionAnalysisResults::default(),
         health_metrics,
+        skipped_files: Vec::new(),
+        generated_code: Default::default(),
+        notebook_metadata: Vec::new(),
+        duplicates: Default::default(),
+        redactions: Default::default(),
     };
 
     let pipeline_statistics = PipelineStatistics {
@@ -334,6 +343,8 @@ fn test_analysis_summary_default() {
         critical_issues: 1,
         doc_health_score: 1.0,
         doc_issue_count: 0,
+        complexity: Default::default(),
+        timed_out: false,
     };
 
     assert_eq!(summary.files_processed, 10);
@@ -1,14 +1,20 @@
 //! Analysis results and reporting structures.
 
-use std::collections::HashMap;
+use std::collections::{BTreeMap, HashMap};
 use std::path::PathBuf;
 use std::time::Duration;
 
 use serde::{Deserialize, Serialize};
 
-use crate::core::pipeline::StageResultsBundle;
+use super::ordering::serialize_sorted;
+
 use crate::core::pipeline::{CloneVerificationResults, HealthMetrics};
-use crate::core::scoring::Priority;
+use crate::core::pipeline::{SkippedFile, StageResultsBundle};
+use crate::core::scoring::{FileComplexity, Priority};
+use crate::detectors::complexity::ComplexityReport;
+use crate::detectors::duplicates::DuplicateCode;
+use crate::detectors::generated::GeneratedCode;
+use crate::lang::notebook::NotebookMetadata;
 // use crate::detectors::names::{RenamePack, ContractMismatchPack, ConsistencyIssue};
 
 #[cfg(test)]
@@ -44,14 +50,17 @@ pub struct AnalysisResults {
 
     /// Per-directory health scores (0-100, using same formula as overall health)
     #[serde(default, skip_serializing_if = "std::collections::HashMap::is_empty")]
+    #[serde(serialize_with = "serialize_sorted")]
     pub directory_health: std::collections::HashMap<String, f64>,
 
     /// Per-file health scores (0-100, using same formula as overall health)
     #[serde(default, skip_serializing_if = "std::collections::HashMap::is_empty")]
+    #[serde(serialize_with = "serialize_sorted")]
     pub file_health: std::collections::HashMap<String, f64>,
 
     /// Per-entity health scores (0-100, using same formula as overall health)
     #[serde(default, skip_serializing_if = "std::collections::HashMap::is_empty")]
+    #[serde(serialize_with = "serialize_sorted")]
     pub entity_health: std::collections::HashMap<String, f64>,
 
     /// Directory health tree structure for file browser visualization
@@ -74,6 +83,52 @@ pub struct AnalysisResults {
     /// Any warnings or issues encountered
     pub warnings: Vec<String>,
 
+    /// Files that matched discovery but were not analyzed, with project-relative paths
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub skipped_files: Vec<SkippedFile>,
+
+    /// Files marked as generated (with their generator) and `//go:generate` directives
+    #[serde(default, skip_serializing_if = "GeneratedCode::is_empty")]
+    pub generated_code: GeneratedCode,
+
+    /// Kernel name, language and code-cell line ranges of analyzed Jupyter notebooks,
+    /// with project-relative paths
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub notebook_metadata: Vec<NotebookMetadata>,
+
+    /// Clone classes of functions identical after normalizing names, literals and comments
+    #[serde(default, skip_serializing_if = "DuplicateCode::is_empty")]
+    pub duplicates: DuplicateCode,
+
+    /// Mean cyclomatic complexity of each file, raw and corrected for its language baseline
+    #[serde(default, skip_serializing_if = "BTreeMap::is_empty")]
+    pub file_complexity: BTreeMap<String, FileComplexity>,
+
+    /// Findings from configured lint rules
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub rule_findings: Vec<crate::detectors::rules::RuleFinding>,
+
+    /// True when only files changed since a git revision were analyzed (`--since`),
+    /// so project-wide totals cover a partial tree
+    #[serde(default, skip_serializing_if = "std::ops::Not::not")]
+    pub changed_files_only: bool,
+
+    /// Go module dependency hygiene (`--check-deps`): latest versions and known CVEs
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub dependency_report: Vec<crate::core::dependency::DependencyReport>,
+
+    /// Go interface implementations and assertion checks (`--check-interfaces`)
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub interface_report: Option<crate::core::dependency::InterfaceReport>,
+
+    /// Weighted project health score (`--health-score`) and its components
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub project_health: Option<crate::core::project_health::ProjectHealth>,
+
+    /// Files, candidates and issues bucketed into source and test code
+    #[serde(default)]
+    pub context_statistics: super::code_context::ContextStatistics,
+
     /// Dictionary describing issue/suggestion codes for downstream consumers
     #[serde(default, skip_serializing_if = "CodeDictionary::is_empty")]
     pub code_dictionary: CodeDictionary,
@@ -88,15 +143,19 @@ pub struct DocumentationResults {
     pub doc_health_score: f64,
     /// Per-file documentation health
     #[serde(default, skip_serializing_if = "HashMap::is_empty")]
+    #[serde(serialize_with = "serialize_sorted")]
     pub file_doc_health: HashMap<String, f64>,
     /// Per-file doc issue counts
     #[serde(default, skip_serializing_if = "HashMap::is_empty")]
+    #[serde(serialize_with = "serialize_sorted")]
     pub file_doc_issues: HashMap<String, usize>,
     /// Per-directory doc health (0-100)
     #[serde(default, skip_serializing_if = "HashMap::is_empty")]
+    #[serde(serialize_with = "serialize_sorted")]
     pub directory_doc_health: HashMap<String, f64>,
     /// Per-directory doc issue counts
     #[serde(default, skip_serializing_if = "HashMap::is_empty")]
+    #[serde(serialize_with = "serialize_sorted")]
     pub directory_doc_issues: HashMap<String, usize>,
 }
 
@@ -152,6 +211,15 @@ pub struct AnalysisSummary {
     /// Documentation issue count (files/dirs/readmes with gaps)
     #[serde(default)]
     pub doc_issue_count: usize,
+
+    /// Function-level cyclomatic complexity distribution (mean, p95, max)
+    #[serde(default)]
+    pub complexity: ComplexityReport,
+    /// True when `--timeout` expired before the analysis finished; files not
+    /// reached are listed in `skipped_files` as `not_analyzed`, and stages cut
+    /// short report as disabled
+    #[serde(default, skip_serializing_if = "std::ops::Not::not")]
+    pub timed_out: bool,
 }
 
 /// Methods for updating [`AnalysisSummary`] with additional metrics.
@@ -206,6 +274,10 @@ pub struct RefactoringCandidate {
     /// Test coverage percentage (0-100), if coverage data available
     #[serde(skip_serializing_if = "Option::is_none")]
     pub coverage_percentage: Option<f64>,
+
+    /// Whether the entity lives in test code (`tests/`, `testdata/`, `*_test.go`)
+    #[serde(default)]
+    pub context: super::code_context::CodeContext,
 }
 
 /// A specific refactoring issue within an entity
@@ -264,10 +336,12 @@ pub struct RefactoringSuggestion {
 pub struct CodeDictionary {
     /// Issue code definitions keyed by code
     #[serde(default, skip_serializing_if = "HashMap::is_empty")]
+    #[serde(serialize_with = "serialize_sorted")]
     pub issues: std::collections::HashMap<String, CodeDefinition>,
 
     /// Suggestion code definitions keyed by code
     #[serde(default, skip_serializing_if = "HashMap::is_empty")]
+    #[serde(serialize_with = "serialize_sorted")]
     pub suggestions: std::collections::HashMap<String, CodeDefinition>,
 }
 
@@ -334,12 +408,15 @@ pub struct AnalysisStatistics {
     pub avg_entity_processing_time: Duration,
 
     /// Number of features extracted per entity
+    #[serde(serialize_with = "serialize_sorted")]
     pub features_per_entity: HashMap<String, f64>,
 
     /// Distribution of refactoring priorities
+    #[serde(serialize_with = "serialize_sorted")]
     pub priority_distribution: HashMap<String, usize>,
 
     /// Distribution of issues by category
+    #[serde(serialize_with = "serialize_sorted")]
     pub issue_distribution: HashMap<String, usize>,
 
     /// Memory usage statistics
@@ -515,6 +592,7 @@ pub struct TreeStatistics {
     /// Directories with health scores below threshold (configurable)
     pub hotspot_directories: Vec<DirectoryHotspot>,
     /// Health score distribution by depth level
+    #[serde(serialize_with = "serialize_sorted")]
     pub health_by_depth: HashMap<usize, DepthHealthStats>,
 }
 
@@ -544,6 +622,7 @@ pub struct DirectoryHealthScore {
     /// Parent directory path (None for root)
     pub parent: Option<PathBuf>,
     /// Breakdown by issue category
+    #[serde(serialize_with = "serialize_sorted")]
     pub issue_categories: HashMap<String, DirectoryIssueSummary>,
 
     /// Documentation health score for this directory (0.0 = poor, 1.0 = excellent)
@@ -561,6 +640,7 @@ pub struct DirectoryHealthTree {
     /// Root directory health scores
     pub root: DirectoryHealthScore,
     /// Mapping of directory paths to their health scores
+    #[serde(serialize_with = "serialize_sorted")]
     pub directories: HashMap<PathBuf, DirectoryHealthScore>,
     /// Statistics for the entire tree
     pub tree_statistics: TreeStatistics,
@@ -33,6 +33,7 @@ fn sample_candidate(path: &str, severity: f64, priority: Priority) -> Refactorin
         issue_count: 1,
         suggestion_count: 1,
         coverage_percentage: None,
+        context: Default::default(),
     }
 }
 
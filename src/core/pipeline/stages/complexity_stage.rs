@@ -5,17 +5,19 @@
 
 use std::path::PathBuf;
 
-use futures::future;
 use tracing::{debug, warn};
 
 use crate::core::arena_analysis::ArenaAnalysisResult;
+use crate::core::concurrency::ConcurrentAnalyzer;
 use crate::core::errors::Result;
 use crate::core::pipeline::results::pipeline_results::ComplexityAnalysisResults;
 use crate::detectors::complexity::{AstComplexityAnalyzer, ComplexityAnalysisResult};
+use crate::lang::notebook::analysis_source;
 
 /// Complexity analysis stage implementation.
 pub struct ComplexityStage {
     ast_complexity_analyzer: AstComplexityAnalyzer,
+    pool: ConcurrentAnalyzer,
 }
 
 /// Factory and analysis methods for [`ComplexityStage`].
@@ -24,9 +26,16 @@ impl ComplexityStage {
     pub fn new(ast_complexity_analyzer: AstComplexityAnalyzer) -> Self {
         Self {
             ast_complexity_analyzer,
+            pool: ConcurrentAnalyzer::new(),
         }
     }
 
+    /// Use `pool` to bound how many files are analysed at once.
+    pub fn with_pool(mut self, pool: ConcurrentAnalyzer) -> Self {
+        self.pool = pool;
+        self
+    }
+
     /// Run complexity analysis from pre-extracted arena results (optimized path).
     pub async fn run_from_arena_results(
         &self,
@@ -37,40 +46,45 @@ impl ComplexityStage {
             arena_results.len()
         );
 
-        // Use the configured analyzer instance and run analyses in parallel.
-        let analysis_futures = arena_results.iter().map(|arena_result| {
-            let analyzer = self.ast_complexity_analyzer.clone();
-            let file_path_str = arena_result.file_path_str().to_string();
-            let file_path = PathBuf::from(&file_path_str);
-
-            tokio::spawn(async move {
-                match tokio::fs::read_to_string(&file_path).await {
-                    Ok(source) => {
-                        analyzer
-                            .analyze_file_with_results(&file_path_str, &source)
-                            .await
-                    }
-                    Err(e) => {
-                        warn!(
-                            "Could not read file for complexity analysis {}: {}",
-                            file_path.display(),
-                            e
-                        );
-                        Ok(Vec::new())
+        // Run analyses on the worker pool; sources are read inside the worker
+        // so only files currently being analysed are held in memory.
+        let analyzer = self.ast_complexity_analyzer.clone();
+        let file_paths: Vec<String> = arena_results
+            .iter()
+            .map(|arena_result| arena_result.file_path_str().to_string())
+            .collect();
+        let run = self
+            .pool
+            .run(file_paths, move |file_path_str| {
+                let analyzer = analyzer.clone();
+                async move {
+                    let file_path = PathBuf::from(&file_path_str);
+                    match tokio::fs::read_to_string(&file_path).await {
+                        Ok(source) => {
+                            let source = analysis_source(&file_path, source);
+                            analyzer
+                                .analyze_file_with_results(&file_path_str, &source)
+                                .await
+                        }
+                        Err(e) => {
+                            warn!(
+                                "Could not read file for complexity analysis {}: {}",
+                                file_path.display(),
+                                e
+                            );
+                            Ok(Vec::new())
+                        }
                     }
                 }
             })
-        });
-
-        let results_of_results = future::join_all(analysis_futures).await;
+            .await;
 
         // Collect and flatten the results
         let mut detailed_results = Vec::new();
-        for result in results_of_results {
+        for result in run.results {
             match result {
-                Ok(Ok(file_results)) => detailed_results.extend(file_results),
-                Ok(Err(e)) => warn!("Complexity analysis task failed: {}", e),
-                Err(e) => warn!("Tokio spawn failed for complexity analysis: {}", e),
+                Ok(file_results) => detailed_results.extend(file_results),
+                Err(e) => warn!("Complexity analysis task failed: {}", e),
             }
         }
 
@@ -81,27 +95,25 @@ impl ComplexityStage {
     pub async fn run_from_files(&self, files: &[PathBuf]) -> Result<ComplexityAnalysisResults> {
         debug!("Running complexity analysis on {} files", files.len());
 
-        // Parallelize file analysis using tokio::spawn
-        let analysis_futures = files.iter().map(|file_path| {
-            let analyzer = self.ast_complexity_analyzer.clone();
-            let path = file_path.clone();
-
-            tokio::spawn(async move {
-                let file_refs = vec![path.as_path()];
-                analyzer.analyze_files(&file_refs).await
+        // Parallelize file analysis on the bounded worker pool
+        let analyzer = self.ast_complexity_analyzer.clone();
+        let run = self
+            .pool
+            .run(files.to_vec(), move |path| {
+                let analyzer = analyzer.clone();
+                async move {
+                    let file_refs = vec![path.as_path()];
+                    analyzer.analyze_files(&file_refs).await
+                }
             })
-        });
-
-        // Wait for all concurrent analyses to complete
-        let results_of_results = future::join_all(analysis_futures).await;
+            .await;
 
         // Collect and flatten the results
         let mut detailed_results = Vec::new();
-        for result in results_of_results {
+        for result in run.results {
             match result {
-                Ok(Ok(file_results)) => detailed_results.extend(file_results),
-                Ok(Err(e)) => warn!("Complexity analysis task failed: {}", e),
-                Err(e) => warn!("Tokio spawn failed for complexity analysis: {}", e),
+                Ok(file_results) => detailed_results.extend(file_results),
+                Err(e) => warn!("Complexity analysis task failed: {}", e),
             }
         }
 
@@ -12,13 +12,28 @@ use crate::core::errors::Result;
 use crate::core::pipeline::results::pipeline_results::ImpactAnalysisResults;
 
 /// Impact analysis stage implementation.
-pub struct ImpactStage;
+pub struct ImpactStage {
+    /// Export the function-level call graph alongside impact results
+    call_graph: bool,
+    /// Maximum call-graph traversal depth (`None` = unbounded)
+    call_graph_depth: Option<usize>,
+}
 
 /// Factory and analysis methods for [`ImpactStage`].
 impl ImpactStage {
     /// Create a new impact stage.
     pub fn new() -> Self {
-        Self
+        Self {
+            call_graph: false,
+            call_graph_depth: None,
+        }
+    }
+
+    /// Also export the call graph, optionally limited to `max_depth` hops from entry points.
+    pub fn with_call_graph(mut self, max_depth: Option<usize>) -> Self {
+        self.call_graph = true;
+        self.call_graph_depth = max_depth;
+        self
     }
 
     /// Run impact analysis powered by the dependency graph.
@@ -35,6 +50,7 @@ impl ImpactStage {
                 chokepoints: Vec::new(),
                 clone_groups: Vec::new(),
                 issues_count: 0,
+                call_graph: None,
             });
         }
 
@@ -47,6 +63,7 @@ impl ImpactStage {
                 chokepoints: Vec::new(),
                 clone_groups: Vec::new(),
                 issues_count: 0,
+                call_graph: None,
             });
         }
 
@@ -82,6 +99,9 @@ impl ImpactStage {
             .collect::<Vec<_>>();
 
         let issues_count = dependency_cycles.len() + chokepoints.len();
+        let call_graph = self
+            .call_graph
+            .then(|| analysis.call_graph(self.call_graph_depth));
 
         Ok(ImpactAnalysisResults {
             enabled: true,
@@ -89,6 +109,7 @@ impl ImpactStage {
             chokepoints,
             clone_groups: Vec::new(),
             issues_count,
+            call_graph,
         })
     }
 }
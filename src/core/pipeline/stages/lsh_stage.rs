@@ -21,6 +21,7 @@ use crate::core::pipeline::verification::clone_detection::{
 };
 use crate::detectors::graph::SimilarityCliquePartitioner;
 use crate::detectors::lsh::{LshExtractor, LshSimilarityContext};
+use crate::lang::notebook::analysis_source;
 
 /// LSH analysis stage implementation.
 pub struct LshStage<'a> {
@@ -231,7 +232,7 @@ impl<'a> LshStage<'a> {
 
         for file_path in files.iter() {
             let content = match tokio::fs::read_to_string(file_path).await {
-                Ok(content) => content,
+                Ok(content) => analysis_source(file_path, content),
                 Err(e) => {
                     warn!("Failed to read file {}: {}", file_path.display(), e);
                     continue;
@@ -4,9 +4,9 @@
 
 use std::path::PathBuf;
 
-use futures::future;
 use tracing::{debug, warn};
 
+use crate::core::concurrency::ConcurrentAnalyzer;
 use crate::core::errors::Result;
 use crate::core::pipeline::results::pipeline_results::RefactoringAnalysisResults;
 use crate::detectors::refactoring::RefactoringAnalyzer;
@@ -14,6 +14,7 @@ use crate::detectors::refactoring::RefactoringAnalyzer;
 /// Refactoring analysis stage implementation.
 pub struct RefactoringStage<'a> {
     refactoring_analyzer: &'a RefactoringAnalyzer,
+    pool: ConcurrentAnalyzer,
 }
 
 /// Factory and analysis methods for [`RefactoringStage`].
@@ -22,9 +23,16 @@ impl<'a> RefactoringStage<'a> {
     pub fn new(refactoring_analyzer: &'a RefactoringAnalyzer) -> Self {
         Self {
             refactoring_analyzer,
+            pool: ConcurrentAnalyzer::new(),
         }
     }
 
+    /// Use `pool` to bound how many files are analysed at once.
+    pub fn with_pool(mut self, pool: ConcurrentAnalyzer) -> Self {
+        self.pool = pool;
+        self
+    }
+
     /// Run refactoring analysis on the given files.
     pub async fn run_refactoring_analysis(
         &self,
@@ -32,25 +40,22 @@ impl<'a> RefactoringStage<'a> {
     ) -> Result<RefactoringAnalysisResults> {
         debug!("Running refactoring analysis on {} files", files.len());
 
-        // Parallelize file analysis using tokio::spawn
-        let analysis_futures = files.iter().map(|file_path| {
-            // Clone the analyzer (it implements Clone)
-            let analyzer = self.refactoring_analyzer.clone();
-            let path = file_path.clone();
-
-            tokio::spawn(async move { analyzer.analyze_files(&[path]).await })
-        });
-
-        // Wait for all concurrent analyses to complete
-        let results_of_results = future::join_all(analysis_futures).await;
+        // Parallelize file analysis on the bounded worker pool
+        let analyzer = self.refactoring_analyzer.clone();
+        let run = self
+            .pool
+            .run(files.to_vec(), move |path| {
+                let analyzer = analyzer.clone();
+                async move { analyzer.analyze_files(&[path]).await }
+            })
+            .await;
 
         // Collect and flatten the results
         let mut detailed_results = Vec::new();
-        for result in results_of_results {
+        for result in run.results {
             match result {
-                Ok(Ok(file_results)) => detailed_results.extend(file_results),
-                Ok(Err(e)) => warn!("Refactoring analysis task failed: {}", e),
-                Err(e) => warn!("Tokio spawn failed for refactoring analysis: {}", e),
+                Ok(file_results) => detailed_results.extend(file_results),
+                Err(e) => warn!("Refactoring analysis task failed: {}", e),
             }
         }
         let opportunities_count = detailed_results
@@ -0,0 +1,559 @@
+//! Streaming analysis pipeline.
+//!
+//! [`AnalysisPipeline`](super::AnalysisPipeline) produces a single
+//! [`AnalysisResults`](super::AnalysisResults) once every stage has finished.
+//! [`StreamingPipeline`] instead runs per-file work as four stages joined by
+//! bounded channels:
+//!
+//! 1. **discover** – sends each [`DiscoveredFile`] as soon as discovery finishes
+//! 2. **parse** – reads the file and parses it into the shared AST cache
+//! 3. **analyze** – runs complexity analysis on the cached tree
+//! 4. **emit** – hands each [`FileReport`] to a [`ReportSink`]
+//!
+//! The first report is emitted while later files are still being parsed, which
+//! keeps time-to-first-result low on large repositories. Streamed reports carry
+//! per-file complexity only; whole-repository passes (clone detection, health
+//! scoring, refactoring candidates) still need the batch pipeline.
+//!
+//! Every stage also watches a [`CancellationToken`]. Once it is cancelled
+//! (e.g. on Ctrl-C, or when the `--timeout` deadline passes) each stage
+//! stops taking new work, abandons in-flight files, drains and closes its
+//! input channel so upstream senders unblock, and returns. The handle from
+//! [`StreamingPipeline::run`] resolves only after all stage tasks have exited,
+//! so no task outlives a cancelled run.
+
+use std::io::Write;
+use std::path::{Path, PathBuf};
+use std::sync::Arc;
+
+use futures::stream::{self, StreamExt};
+use serde::{Deserialize, Serialize};
+use tokio::sync::mpsc;
+use tokio::task::JoinHandle;
+use tokio_util::sync::CancellationToken;
+use tracing::{debug, error, warn};
+
+use crate::core::ast_service::AstService;
+use crate::core::config::ValknutConfig;
+use crate::core::errors::{Result, ValknutError};
+use crate::detectors::complexity::{
+    ComplexityAnalysisResult, ComplexityAnalyzer, ComplexityConfig,
+};
+use crate::lang::notebook::analysis_source;
+use crate::lang::registry::detect_language_from_path;
+
+use super::discovery::services::{FileDiscoverer, GitAwareFileDiscoverer};
+use super::pipeline_config::AnalysisConfig;
+
+/// Default number of messages buffered between two stages.
+const DEFAULT_CHANNEL_CAPACITY: usize = 64;
+
+/// A file found by the discover stage.
+#[derive(Debug, Clone)]
+pub struct DiscoveredFile {
+    /// Path as returned by discovery.
+    pub path: PathBuf,
+}
+
+/// A file whose source has been read and parsed.
+#[derive(Debug, Clone)]
+pub struct ParsedFile {
+    /// Path as returned by discovery.
+    pub path: PathBuf,
+    /// File contents.
+    pub source: Arc<str>,
+    /// Detected language name.
+    pub language: String,
+}
+
+/// Per-file analysis output emitted by the stream.
+#[derive(Debug, Clone, Serialize, Deserialize)]
+pub struct FileReport {
+    /// File path.
+    pub path: String,
+    /// Detected language name.
+    pub language: String,
+    /// Number of source lines.
+    pub lines: usize,
+    /// Complexity results for each entity in the file.
+    pub entities: Vec<ComplexityAnalysisResult>,
+    /// Read, parse, or analysis failure, if any.
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub error: Option<String>,
+}
+
+/// Counters reported once the stream has drained.
+#[derive(Debug, Clone, Default, PartialEq, Eq, Serialize, Deserialize)]
+pub struct StreamSummary {
+    /// Files found by discovery.
+    pub files_discovered: usize,
+    /// Files analyzed without error.
+    pub files_analyzed: usize,
+    /// Files that failed to read, parse, or analyze.
+    pub files_failed: usize,
+    /// Entities reported across all files.
+    pub entities: usize,
+    /// The run was cancelled before every discovered file was reported.
+    #[serde(default, skip_serializing_if = "std::ops::Not::not")]
+    pub cancelled: bool,
+    /// The cancellation came from the `--timeout` deadline.
+    #[serde(default, skip_serializing_if = "std::ops::Not::not")]
+    pub timed_out: bool,
+}
+
+/// Tuning knobs for [`StreamingPipeline`].
+#[derive(Debug, Clone)]
+pub struct StreamingConfig {
+    /// Messages buffered between two stages before the producer waits.
+    pub channel_capacity: usize,
+    /// Files parsed and analyzed concurrently.
+    pub workers: usize,
+}
+
+/// Default implementation for [`StreamingConfig`].
+impl Default for StreamingConfig {
+    /// Returns a bounded configuration sized to the available CPUs.
+    fn default() -> Self {
+        Self {
+            channel_capacity: DEFAULT_CHANNEL_CAPACITY,
+            workers: std::thread::available_parallelism()
+                .map(|n| n.get())
+                .unwrap_or(1),
+        }
+    }
+}
+
+/// Receives reports as the emit stage produces them.
+pub trait ReportSink: Send {
+    /// Handle one finished file.
+    fn report(&mut self, report: &FileReport) -> Result<()>;
+
+    /// Handle the final counters. Called once after the last report.
+    fn finish(&mut self, summary: &StreamSummary) -> Result<()> {
+        let _ = summary;
+        Ok(())
+    }
+}
+
+/// Writes each report as one NDJSON line, flushing after every record.
+pub struct NdjsonSink<W: Write + Send> {
+    writer: W,
+}
+
+/// Constructor for [`NdjsonSink`].
+impl<W: Write + Send> NdjsonSink<W> {
+    /// Wrap a writer.
+    pub fn new(writer: W) -> Self {
+        Self { writer }
+    }
+
+    /// Serialize `value` with a `type` tag and write it as one line.
+    fn write_record<T: Serialize>(&mut self, kind: &str, value: &T) -> Result<()> {
+        let mut record = serde_json::to_value(value)?;
+        if let serde_json::Value::Object(map) = &mut record {
+            map.insert("type".to_string(), serde_json::Value::from(kind));
+        }
+        serde_json::to_writer(&mut self.writer, &record)?;
+        self.writer
+            .write_all(b"\n")
+            .and_then(|_| self.writer.flush())
+            .map_err(|e| ValknutError::io("Failed to write NDJSON record", e))
+    }
+}
+
+/// [`ReportSink`] implementation for [`NdjsonSink`].
+impl<W: Write + Send> ReportSink for NdjsonSink<W> {
+    /// Write a `{"type":"file",...}` record.
+    fn report(&mut self, report: &FileReport) -> Result<()> {
+        self.write_record("file", report)
+    }
+
+    /// Write a `{"type":"summary",...}` record.
+    fn finish(&mut self, summary: &StreamSummary) -> Result<()> {
+        self.write_record("summary", summary)
+    }
+}
+
+/// Shared state used by the parse and analyze stages.
+struct StageContext {
+    ast_service: Arc<AstService>,
+    complexity_analyzer: ComplexityAnalyzer,
+}
+
+/// Discover → parse → analyze → emit pipeline connected by typed channels.
+pub struct StreamingPipeline {
+    config: Arc<AnalysisConfig>,
+    valknut_config: Option<Arc<ValknutConfig>>,
+    streaming: StreamingConfig,
+    file_discoverer: Arc<dyn FileDiscoverer>,
+    context: Arc<StageContext>,
+    cancel: CancellationToken,
+}
+
+/// Factory, configuration, and execution methods for [`StreamingPipeline`].
+impl StreamingPipeline {
+    /// Create a streaming pipeline with the default discoverer and analyzers.
+    pub fn new(config: AnalysisConfig) -> Self {
+        let ast_service = Arc::new(AstService::new());
+        Self {
+            config: Arc::new(config),
+            valknut_config: None,
+            streaming: StreamingConfig::default(),
+            file_discoverer: GitAwareFileDiscoverer::shared(),
+            context: Arc::new(StageContext {
+                complexity_analyzer: ComplexityAnalyzer::new(
+                    ComplexityConfig::default(),
+                    ast_service.clone(),
+                ),
+                ast_service,
+            }),
+            cancel: CancellationToken::new(),
+        }
+    }
+
+    /// Create a streaming pipeline that also applies `valknut_config` filters.
+    ///
+    /// `performance.max_threads`, when set, bounds the files in flight.
+    pub fn new_with_config(config: AnalysisConfig, valknut_config: ValknutConfig) -> Self {
+        let mut pipeline = Self::new(config);
+        if let Some(workers) = valknut_config.performance.max_threads {
+            pipeline.streaming.workers = workers;
+        }
+        pipeline.valknut_config = Some(Arc::new(valknut_config));
+        pipeline
+    }
+
+    /// Override channel capacity and worker count.
+    pub fn with_streaming_config(mut self, streaming: StreamingConfig) -> Self {
+        self.streaming = streaming;
+        self
+    }
+
+    /// Replace the file discoverer.
+    pub fn with_file_discoverer(mut self, discoverer: Arc<dyn FileDiscoverer>) -> Self {
+        self.file_discoverer = discoverer;
+        self
+    }
+
+    /// Stop every run of this pipeline when `cancel` is cancelled.
+    pub fn with_cancellation(mut self, cancel: CancellationToken) -> Self {
+        self.cancel = cancel;
+        self
+    }
+
+    /// Token that cancels runs of this pipeline.
+    pub fn cancellation_token(&self) -> &CancellationToken {
+        &self.cancel
+    }
+
+    /// Start all stages and return the report channel.
+    ///
+    /// Reports arrive in completion order. The returned handle resolves to the
+    /// number of discovered files, or to the discovery error, once every stage
+    /// task has exited.
+    pub fn run(
+        &self,
+        paths: &[PathBuf],
+    ) -> (mpsc::Receiver<FileReport>, JoinHandle<Result<usize>>) {
+        let capacity = self.streaming.channel_capacity.max(1);
+        let workers = self.streaming.workers.max(1);
+        let (discovered_tx, discovered_rx) = mpsc::channel::<DiscoveredFile>(capacity);
+        let (parsed_tx, parsed_rx) = mpsc::channel::<ParsedFile>(capacity);
+        let (report_tx, report_rx) = mpsc::channel::<FileReport>(capacity);
+
+        let discover = tokio::spawn(discover_stage(
+            paths.to_vec(),
+            self.config.clone(),
+            self.valknut_config.clone(),
+            self.file_discoverer.clone(),
+            discovered_tx,
+            self.cancel.clone(),
+        ));
+        let parse = tokio::spawn(parse_stage(
+            self.context.clone(),
+            workers,
+            discovered_rx,
+            parsed_tx,
+            report_tx.clone(),
+            self.cancel.clone(),
+        ));
+        let analyze = tokio::spawn(analyze_stage(
+            self.context.clone(),
+            workers,
+            parsed_rx,
+            report_tx,
+            self.cancel.clone(),
+        ));
+
+        let handle = tokio::spawn(async move {
+            let discovered = join_stage(discover).await;
+            let parsed = join_stage(parse).await;
+            let analyzed = join_stage(analyze).await;
+            parsed.and(analyzed)?;
+            discovered
+        });
+        (report_rx, handle)
+    }
+
+    /// Run the pipeline and hand every report to `sink` as it arrives.
+    ///
+    /// The `analysis.deadline` of the pipeline's configuration, when set,
+    /// cancels the run once it passes.
+    pub async fn run_to_sink(
+        &self,
+        paths: &[PathBuf],
+        sink: &mut dyn ReportSink,
+    ) -> Result<StreamSummary> {
+        let deadline = self
+            .valknut_config
+            .as_ref()
+            .and_then(|config| config.analysis.deadline);
+        let timer = deadline.map(|deadline| {
+            let cancel = self.cancel.clone();
+            tokio::spawn(async move {
+                tokio::time::sleep_until(deadline.into()).await;
+                cancel.cancel();
+            })
+        });
+        let (mut reports, handle) = self.run(paths);
+        let mut summary = StreamSummary::default();
+
+        while let Some(report) = reports.recv().await {
+            if report.error.is_some() {
+                summary.files_failed += 1;
+            } else {
+                summary.files_analyzed += 1;
+            }
+            summary.entities += report.entities.len();
+            sink.report(&report)?;
+        }
+
+        summary.files_discovered = join_stage(handle).await?;
+        if let Some(timer) = timer {
+            timer.abort();
+        }
+        // Discovery reports no files when it is cancelled before the walk ends.
+        let incomplete = summary.files_discovered == 0
+            || summary.files_analyzed + summary.files_failed < summary.files_discovered;
+        summary.cancelled = self.cancel.is_cancelled() && incomplete;
+        summary.timed_out = summary.cancelled
+            && deadline.is_some_and(|deadline| std::time::Instant::now() >= deadline);
+        sink.finish(&summary)?;
+        Ok(summary)
+    }
+}
+
+/// Await a stage task, converting a panic into a pipeline error.
+async fn join_stage<T>(handle: JoinHandle<Result<T>>) -> Result<T> {
+    handle.await.map_err(|e| {
+        error!(error = %e, "streaming stage task failed");
+        ValknutError::pipeline("streaming", format!("Stage task failed: {e}"))
+    })?
+}
+
+/// Discover files and send them downstream one at a time.
+async fn discover_stage(
+    paths: Vec<PathBuf>,
+    config: Arc<AnalysisConfig>,
+    valknut_config: Option<Arc<ValknutConfig>>,
+    discoverer: Arc<dyn FileDiscoverer>,
+    tx: mpsc::Sender<DiscoveredFile>,
+    cancel: CancellationToken,
+) -> Result<usize> {
+    let discovery = tokio::task::spawn_blocking(move || {
+        discoverer.discover(&paths, &config, valknut_config.as_deref())
+    });
+    // A blocking walk cannot be interrupted; on cancellation its result is discarded.
+    let files = tokio::select! {
+        biased;
+        _ = cancel.cancelled() => return Ok(0),
+        files = discovery => files.map_err(|e| {
+            ValknutError::pipeline("discovery", format!("Discovery task failed: {e}"))
+        })??,
+    };
+
+    let count = files.len();
+    debug!("Streaming discovery found {} files", count);
+    for path in files {
+        let sent = tokio::select! {
+            biased;
+            _ = cancel.cancelled() => false,
+            sent = tx.send(DiscoveredFile { path }) => sent.is_ok(),
+        };
+        if !sent {
+            break;
+        }
+    }
+    Ok(count)
+}
+
+/// Read and parse discovered files; failures go straight to the report channel.
+async fn parse_stage(
+    context: Arc<StageContext>,
+    workers: usize,
+    rx: mpsc::Receiver<DiscoveredFile>,
+    tx: mpsc::Sender<ParsedFile>,
+    failures: mpsc::Sender<FileReport>,
+    cancel: CancellationToken,
+) -> Result<()> {
+    let parsed = receiver_stream(rx, cancel.clone())
+        .map(|file| {
+            let context = context.clone();
+            async move { parse_file(&context, file.path).await }
+        })
+        .buffer_unordered(workers);
+    tokio::pin!(parsed);
+
+    while let Some(outcome) = next_unless_cancelled(&mut parsed, &cancel).await {
+        let sent = match outcome {
+            Ok(file) => send_unless_cancelled(&tx, file, &cancel).await,
+            Err(report) => send_unless_cancelled(&failures, report, &cancel).await,
+        };
+        if !sent {
+            break;
+        }
+    }
+    Ok(())
+}
+
+/// Analyze parsed files and send their reports downstream.
+async fn analyze_stage(
+    context: Arc<StageContext>,
+    workers: usize,
+    rx: mpsc::Receiver<ParsedFile>,
+    tx: mpsc::Sender<FileReport>,
+    cancel: CancellationToken,
+) -> Result<()> {
+    let reports = receiver_stream(rx, cancel.clone())
+        .map(|file| {
+            let context = context.clone();
+            async move { analyze_file(&context, file).await }
+        })
+        .buffer_unordered(workers);
+    tokio::pin!(reports);
+
+    while let Some(report) = next_unless_cancelled(&mut reports, &cancel).await {
+        if !send_unless_cancelled(&tx, report, &cancel).await {
+            break;
+        }
+    }
+    Ok(())
+}
+
+/// Adapt a channel receiver into a stream that ends on cancellation.
+///
+/// A cancelled stream closes the channel and drops whatever is still queued,
+/// so the upstream stage's pending and future sends fail instead of waiting.
+fn receiver_stream<T: Send + 'static>(
+    rx: mpsc::Receiver<T>,
+    cancel: CancellationToken,
+) -> impl futures::Stream<Item = T> + Send {
+    stream::unfold((rx, cancel), |(mut rx, cancel)| async move {
+        let next = tokio::select! {
+            biased;
+            _ = cancel.cancelled() => None,
+            item = rx.recv() => item,
+        };
+        match next {
+            Some(item) => Some((item, (rx, cancel))),
+            None => {
+                rx.close();
+                while rx.try_recv().is_ok() {}
+                None
+            }
+        }
+    })
+}
+
+/// Next item of `stream`, or `None` once `cancel` fires.
+async fn next_unless_cancelled<S>(stream: &mut S, cancel: &CancellationToken) -> Option<S::Item>
+where
+    S: futures::Stream + Unpin,
+{
+    tokio::select! {
+        biased;
+        _ = cancel.cancelled() => None,
+        item = stream.next() => item,
+    }
+}
+
+/// Send `item` downstream; false when the receiver is gone or `cancel` fires.
+async fn send_unless_cancelled<T>(
+    tx: &mpsc::Sender<T>,
+    item: T,
+    cancel: &CancellationToken,
+) -> bool {
+    tokio::select! {
+        biased;
+        _ = cancel.cancelled() => false,
+        sent = tx.send(item) => sent.is_ok(),
+    }
+}
+
+/// Read `path` and warm the AST cache, or describe why that failed.
+async fn parse_file(
+    context: &StageContext,
+    path: PathBuf,
+) -> std::result::Result<ParsedFile, FileReport> {
+    let display = display_path(&path);
+    let language = detect_language_from_path(&display);
+
+    let source: Arc<str> = match tokio::fs::read_to_string(&path).await {
+        Ok(source) => analysis_source(&path, source).into(),
+        Err(e) => return Err(failed_report(display, language, 0, e.to_string())),
+    };
+    if let Err(e) = context.ast_service.get_ast(&display, &source).await {
+        let lines = source.lines().count();
+        return Err(failed_report(display, language, lines, e.to_string()));
+    }
+
+    Ok(ParsedFile {
+        path,
+        source,
+        language,
+    })
+}
+
+/// Run complexity analysis on a parsed file.
+async fn analyze_file(context: &StageContext, file: ParsedFile) -> FileReport {
+    let display = display_path(&file.path);
+    let lines = file.source.lines().count();
+    match context
+        .complexity_analyzer
+        .analyze_file_with_results(&display, &file.source)
+        .await
+    {
+        Ok(entities) => {
+            debug!(path = %display, entities = entities.len(), "file processed");
+            FileReport {
+                path: display,
+                language: file.language,
+                lines,
+                entities,
+                error: None,
+            }
+        }
+        Err(e) => failed_report(display, file.language, lines, e.to_string()),
+    }
+}
+
+/// Build a report for a file that could not be analyzed.
+fn failed_report(path: String, language: String, lines: usize, error: String) -> FileReport {
+    warn!(path = %path, error = %error, "worker failed to analyze file");
+    FileReport {
+        path,
+        language,
+        lines,
+        entities: Vec::new(),
+        error: Some(error),
+    }
+}
+
+/// Render a path for reports.
+fn display_path(path: &Path) -> String {
+    path.to_string_lossy().into_owned()
+}
+
+#[cfg(test)]
+#[path = "streaming_tests.rs"]
+mod tests;
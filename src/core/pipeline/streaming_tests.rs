@@ -0,0 +1,208 @@
+use super::*;
+use std::fs;
+use tempfile::TempDir;
+
+fn python_project(files: usize) -> TempDir {
+    let dir = TempDir::new().unwrap();
+    for i in 0..files {
+        fs::write(
+            dir.path().join(format!("module_{i}.py")),
+            format!("def f_{i}(x):\n    if x > {i}:\n        return x\n    return 0\n"),
+        )
+        .unwrap();
+    }
+    dir
+}
+
+fn python_config() -> AnalysisConfig {
+    let mut config = AnalysisConfig::default();
+    config.file_extensions = vec!["py".to_string()];
+    config
+}
+
+#[derive(Default)]
+struct CollectingSink {
+    reports: Vec<FileReport>,
+    summary: Option<StreamSummary>,
+}
+
+impl ReportSink for CollectingSink {
+    fn report(&mut self, report: &FileReport) -> Result<()> {
+        self.reports.push(report.clone());
+        Ok(())
+    }
+
+    fn finish(&mut self, summary: &StreamSummary) -> Result<()> {
+        self.summary = Some(summary.clone());
+        Ok(())
+    }
+}
+
+#[tokio::test]
+async fn stream_reports_every_discovered_file() {
+    let project = python_project(5);
+    let pipeline = StreamingPipeline::new(python_config());
+    let mut sink = CollectingSink::default();
+
+    let summary = pipeline
+        .run_to_sink(&[project.path().to_path_buf()], &mut sink)
+        .await
+        .unwrap();
+
+    assert_eq!(summary.files_discovered, 5);
+    assert_eq!(summary.files_analyzed + summary.files_failed, 5);
+    assert_eq!(sink.reports.len(), 5);
+    assert_eq!(sink.summary, Some(summary));
+    assert!(sink.reports.iter().all(|r| r.language == "py"));
+}
+
+#[tokio::test]
+async fn first_report_arrives_before_pipeline_completes() {
+    let project = python_project(20);
+    let pipeline = StreamingPipeline::new(python_config()).with_streaming_config(StreamingConfig {
+        channel_capacity: 1,
+        workers: 1,
+    });
+
+    let (mut reports, handle) = pipeline.run(&[project.path().to_path_buf()]);
+    let first = reports.recv().await.expect("first report");
+    assert!(first.path.ends_with(".py"));
+    assert!(
+        !handle.is_finished(),
+        "bounded channels should hold back later stages"
+    );
+
+    let mut remaining = 0;
+    while reports.recv().await.is_some() {
+        remaining += 1;
+    }
+    assert_eq!(remaining, 19);
+    assert_eq!(handle.await.unwrap().unwrap(), 20);
+}
+
+/// Sink that cancels the pipeline after the first report.
+struct CancellingSink {
+    cancel: CancellationToken,
+    reports: usize,
+}
+
+impl ReportSink for CancellingSink {
+    fn report(&mut self, _report: &FileReport) -> Result<()> {
+        self.reports += 1;
+        self.cancel.cancel();
+        Ok(())
+    }
+}
+
+/// Wait until every task spawned on the test runtime has exited.
+async fn assert_no_tasks_outlive_the_run() {
+    let metrics = tokio::runtime::Handle::current().metrics();
+    let deadline = tokio::time::Instant::now() + std::time::Duration::from_secs(5);
+    while metrics.num_alive_tasks() > 0 {
+        assert!(
+            tokio::time::Instant::now() < deadline,
+            "{} task(s) still alive after cancellation",
+            metrics.num_alive_tasks()
+        );
+        tokio::time::sleep(std::time::Duration::from_millis(10)).await;
+    }
+}
+
+#[tokio::test]
+async fn cancellation_stops_every_stage_without_leaking_tasks() {
+    let project = python_project(50);
+    let pipeline = StreamingPipeline::new(python_config()).with_streaming_config(StreamingConfig {
+        channel_capacity: 1,
+        workers: 2,
+    });
+
+    let (mut reports, handle) = pipeline.run(&[project.path().to_path_buf()]);
+    reports.recv().await.expect("first report");
+    // Stop reading: every stage is now blocked on a full channel.
+    pipeline.cancellation_token().cancel();
+
+    let discovered = tokio::time::timeout(std::time::Duration::from_secs(5), handle)
+        .await
+        .expect("stages exit promptly after cancellation")
+        .unwrap()
+        .unwrap();
+    assert_eq!(discovered, 50);
+
+    let mut drained = 0;
+    while reports.recv().await.is_some() {
+        drained += 1;
+    }
+    assert!(drained < 49, "cancelled run should not report every file");
+    assert_no_tasks_outlive_the_run().await;
+}
+
+#[tokio::test]
+async fn cancelled_sink_run_reports_partial_summary() {
+    let project = python_project(50);
+    let cancel = CancellationToken::new();
+    let pipeline = StreamingPipeline::new(python_config())
+        .with_streaming_config(StreamingConfig {
+            channel_capacity: 1,
+            workers: 1,
+        })
+        .with_cancellation(cancel.clone());
+    let mut sink = CancellingSink { cancel, reports: 0 };
+
+    let summary = pipeline
+        .run_to_sink(&[project.path().to_path_buf()], &mut sink)
+        .await
+        .unwrap();
+
+    assert!(summary.cancelled);
+    assert_eq!(summary.files_analyzed + summary.files_failed, sink.reports);
+    assert!(sink.reports < 50);
+    assert_no_tasks_outlive_the_run().await;
+}
+
+#[tokio::test]
+async fn passed_deadline_cancels_the_run_and_reports_timed_out() {
+    let project = python_project(50);
+    let mut valknut_config = ValknutConfig::default();
+    valknut_config.analysis.deadline = Some(std::time::Instant::now());
+    let pipeline = StreamingPipeline::new_with_config(python_config(), valknut_config);
+    let mut sink = CollectingSink::default();
+
+    let summary = pipeline
+        .run_to_sink(&[project.path().to_path_buf()], &mut sink)
+        .await
+        .unwrap();
+
+    assert!(summary.cancelled);
+    assert!(summary.timed_out);
+    assert!(sink.reports.len() < 50);
+    assert_eq!(sink.summary, Some(summary));
+    assert_no_tasks_outlive_the_run().await;
+}
+
+#[test]
+fn ndjson_sink_tags_records() {
+    let mut buffer = Vec::new();
+    {
+        let mut sink = NdjsonSink::new(&mut buffer);
+        sink.report(&FileReport {
+            path: "a.py".to_string(),
+            language: "python".to_string(),
+            lines: 3,
+            entities: Vec::new(),
+            error: None,
+        })
+        .unwrap();
+        sink.finish(&StreamSummary::default()).unwrap();
+    }
+
+    let lines: Vec<serde_json::Value> = String::from_utf8(buffer)
+        .unwrap()
+        .lines()
+        .map(|line| serde_json::from_str(line).unwrap())
+        .collect();
+    assert_eq!(lines.len(), 2);
+    assert_eq!(lines[0]["type"], "file");
+    assert_eq!(lines[0]["path"], "a.py");
+    assert!(lines[0].get("error").is_none());
+    assert_eq!(lines[1]["type"], "summary");
+}
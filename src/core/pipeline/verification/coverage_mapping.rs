@@ -5,6 +5,7 @@
 //! coverage percentages.
 
 use std::collections::HashMap;
+use tracing::warn;
 
 use crate::core::pipeline::CoverageAnalysisResults;
 use crate::detectors::coverage::CoveragePack;
@@ -24,7 +25,7 @@ pub fn convert_coverage_to_packs(coverage_results: &CoverageAnalysisResults) ->
         match serde_json::from_value::<CoveragePack>(gap_value.clone()) {
             Ok(pack) => packs.push(pack),
             Err(e) => {
-                eprintln!("Warning: Failed to deserialize coverage pack: {}", e);
+                warn!("Failed to deserialize coverage pack: {}", e);
                 // Skip invalid packs instead of creating fake data
             }
         }
@@ -0,0 +1,241 @@
+//! Profiling of valknut's own analysis runs.
+//!
+//! A [`ProfileSession`] wraps an analysis run and writes pprof-format profiles
+//! (`go tool pprof`, `pprof`, and most flame-graph viewers read them):
+//!
+//! - CPU profiles are sampled with the `pprof` crate.
+//! - Heap profiles come from jemalloc's sampling profiler and are Linux-only.
+//!   Builds with the `profiling` feature use jemalloc as the global allocator
+//!   with sampling compiled in but inactive until a heap profile is requested.
+//!
+//! Profiling support is compiled in with `--features profiling`; without it,
+//! requesting a profile is an error rather than a silent no-op.
+
+use std::path::{Path, PathBuf};
+
+use tracing::info;
+
+use crate::core::errors::{Result, ValknutError};
+
+/// True when this build can write CPU profiles.
+pub const CPU_PROFILING_AVAILABLE: bool = cfg!(all(feature = "profiling", unix));
+
+/// True when this build can write heap profiles.
+pub const HEAP_PROFILING_AVAILABLE: bool = cfg!(all(feature = "profiling", target_os = "linux"));
+
+/// Default CPU sampling frequency in Hz.
+const DEFAULT_CPU_FREQUENCY: i32 = 997;
+
+/// Which profiles to collect and where to write them.
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct ProfilingOptions {
+    /// Destination for the CPU profile.
+    pub cpu_profile: Option<PathBuf>,
+    /// Destination for the heap profile.
+    pub mem_profile: Option<PathBuf>,
+    /// CPU sampling frequency in Hz.
+    pub cpu_frequency: i32,
+}
+
+/// Default implementation for [`ProfilingOptions`].
+impl Default for ProfilingOptions {
+    /// Returns options that collect nothing.
+    fn default() -> Self {
+        Self {
+            cpu_profile: None,
+            mem_profile: None,
+            cpu_frequency: DEFAULT_CPU_FREQUENCY,
+        }
+    }
+}
+
+/// Query methods for [`ProfilingOptions`].
+impl ProfilingOptions {
+    /// Returns true when any profile was requested.
+    pub fn is_enabled(&self) -> bool {
+        self.cpu_profile.is_some() || self.mem_profile.is_some()
+    }
+}
+
+/// Profilers running for the duration of an analysis.
+pub struct ProfileSession {
+    options: ProfilingOptions,
+    #[cfg(all(feature = "profiling", unix))]
+    cpu: Option<pprof::ProfilerGuard<'static>>,
+}
+
+/// Start and finish methods for [`ProfileSession`].
+impl ProfileSession {
+    /// Start the requested profilers.
+    ///
+    /// Fails if a requested profile is not supported by this build.
+    pub fn start(options: ProfilingOptions) -> Result<Self> {
+        if options.cpu_profile.is_some() && !CPU_PROFILING_AVAILABLE {
+            return Err(unavailable("CPU profiling", "unix"));
+        }
+        if options.mem_profile.is_some() && !HEAP_PROFILING_AVAILABLE {
+            return Err(unavailable("Heap profiling", "Linux"));
+        }
+
+        #[cfg(all(feature = "profiling", target_os = "linux"))]
+        if options.mem_profile.is_some() {
+            heap::activate()?;
+        }
+
+        #[cfg(all(feature = "profiling", unix))]
+        let cpu = match options.cpu_profile {
+            Some(_) => Some(
+                pprof::ProfilerGuardBuilder::default()
+                    .frequency(options.cpu_frequency)
+                    .blocklist(&["libc", "libgcc", "pthread", "vdso"])
+                    .build()
+                    .map_err(|e| {
+                        ValknutError::internal(format!("Failed to start CPU profiler: {e}"))
+                    })?,
+            ),
+            None => None,
+        };
+
+        Ok(Self {
+            options,
+            #[cfg(all(feature = "profiling", unix))]
+            cpu,
+        })
+    }
+
+    /// Stop profiling and write the requested profiles.
+    pub async fn finish(self) -> Result<()> {
+        #[cfg(all(feature = "profiling", unix))]
+        if let (Some(guard), Some(path)) = (&self.cpu, &self.options.cpu_profile) {
+            write_cpu_profile(guard, path)?;
+        }
+
+        #[cfg(all(feature = "profiling", target_os = "linux"))]
+        if let Some(path) = &self.options.mem_profile {
+            let profile = heap::dump().await?;
+            write_profile(path, &profile, "heap")?;
+        }
+
+        Ok(())
+    }
+
+    /// Options the session was started with.
+    pub fn options(&self) -> &ProfilingOptions {
+        &self.options
+    }
+}
+
+/// Error for a profile this build cannot produce.
+fn unavailable(what: &str, platform: &str) -> ValknutError {
+    ValknutError::unsupported(format!(
+        "{what} requires a {platform} build with the `profiling` feature \
+         (cargo build --release --features profiling)"
+    ))
+}
+
+/// Encode the CPU samples collected so far as a pprof protobuf.
+#[cfg(all(feature = "profiling", unix))]
+fn write_cpu_profile(guard: &pprof::ProfilerGuard<'static>, path: &Path) -> Result<()> {
+    use prost::Message;
+
+    let profile = guard
+        .report()
+        .build()
+        .and_then(|report| report.pprof())
+        .map_err(|e| ValknutError::internal(format!("Failed to build CPU profile: {e}")))?;
+    write_profile(path, &profile.encode_to_vec(), "CPU")
+}
+
+/// Write an encoded profile, creating parent directories as needed.
+#[cfg_attr(not(feature = "profiling"), allow(dead_code))]
+fn write_profile(path: &Path, bytes: &[u8], kind: &str) -> Result<()> {
+    if let Some(parent) = path.parent().filter(|p| !p.as_os_str().is_empty()) {
+        std::fs::create_dir_all(parent)
+            .map_err(|e| ValknutError::io(format!("Failed to create {}", parent.display()), e))?;
+    }
+    std::fs::write(path, bytes).map_err(|e| {
+        ValknutError::io(
+            format!("Failed to write {kind} profile to {}", path.display()),
+            e,
+        )
+    })?;
+    info!("Wrote {} profile to {}", kind, path.display());
+    Ok(())
+}
+
+/// jemalloc heap profiling controls.
+#[cfg(all(feature = "profiling", target_os = "linux"))]
+mod heap {
+    use crate::core::errors::{Result, ValknutError};
+
+    /// Turn on allocation sampling.
+    pub(super) fn activate() -> Result<()> {
+        let ctl = controller()?;
+        let mut ctl = ctl.try_lock().map_err(|_| {
+            ValknutError::internal("Heap profiler is already in use by another session")
+        })?;
+        ctl.activate()
+            .map_err(|e| ValknutError::internal(format!("Failed to start heap profiler: {e}")))
+    }
+
+    /// Dump live sampled allocations as a pprof protobuf and stop sampling.
+    pub(super) async fn dump() -> Result<Vec<u8>> {
+        let mut ctl = controller()?.lock().await;
+        let profile = ctl
+            .dump_pprof()
+            .map_err(|e| ValknutError::internal(format!("Failed to dump heap profile: {e}")))?;
+        ctl.deactivate()
+            .map_err(|e| ValknutError::internal(format!("Failed to stop heap profiler: {e}")))?;
+        Ok(profile)
+    }
+
+    /// The process-wide jemalloc profiling controller.
+    fn controller(
+    ) -> Result<&'static std::sync::Arc<tokio::sync::Mutex<jemalloc_pprof::JemallocProfCtl>>> {
+        jemalloc_pprof::PROF_CTL.as_ref().ok_or_else(|| {
+            ValknutError::internal("jemalloc was not started with profiling enabled")
+        })
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[tokio::test]
+    async fn empty_session_writes_nothing() {
+        let session = ProfileSession::start(ProfilingOptions::default()).unwrap();
+        assert!(!session.options().is_enabled());
+        session.finish().await.unwrap();
+    }
+
+    #[cfg(not(all(feature = "profiling", target_os = "linux")))]
+    #[test]
+    fn unsupported_profiles_are_rejected() {
+        let options = ProfilingOptions {
+            mem_profile: Some(PathBuf::from("heap.pb")),
+            ..ProfilingOptions::default()
+        };
+        let err = ProfileSession::start(options).err().unwrap();
+        assert!(err.to_string().contains("profiling"), "{err}");
+    }
+
+    #[cfg(all(feature = "profiling", unix))]
+    #[tokio::test]
+    async fn cpu_profile_is_written() {
+        let tmp = tempfile::tempdir().unwrap();
+        let path = tmp.path().join("cpu.pb");
+        let session = ProfileSession::start(ProfilingOptions {
+            cpu_profile: Some(path.clone()),
+            ..ProfilingOptions::default()
+        })
+        .unwrap();
+        let mut acc = 0u64;
+        for i in 0..5_000_000u64 {
+            acc = acc.wrapping_mul(31).wrapping_add(i);
+        }
+        std::hint::black_box(acc);
+        session.finish().await.unwrap();
+        assert!(std::fs::metadata(&path).unwrap().len() > 0);
+    }
+}
@@ -0,0 +1,599 @@
+//! Project-wide health score.
+//!
+//! [`ProjectHealth`] folds several independent signals into one 0-100 score:
+//!
+//! - **coverage**: the overall line coverage reported by the coverage pass;
+//! - **complexity**: average cyclomatic complexity after per-language
+//!   [`ComplexityBaselines`] correction, full marks at [`COMPLEXITY_IDEAL`] or
+//!   below and none at [`COMPLEXITY_LIMIT`] or above;
+//! - **documentation**: the share of exported symbols preceded by a comment
+//!   (or opening with a docstring in Python);
+//! - **dependencies**: the share of `--check-deps` modules that are neither
+//!   outdated nor affected by a known CVE;
+//! - **license**: whether a `LICENSE`, `LICENCE` or `COPYING` file sits at
+//!   the root of an analyzed path.
+//!
+//! Each criterion carries a weight from [`HealthWeights`]. Criteria without
+//! data (no coverage report, no dependency check) are reported but left out,
+//! and the remaining weights are renormalized so a missing signal neither
+//! rewards nor penalizes the project.
+
+use std::fmt;
+use std::path::{Path, PathBuf};
+
+use ignore::WalkBuilder;
+use serde::{Deserialize, Serialize};
+use tracing::warn;
+
+use crate::core::featureset::CodeEntity;
+use crate::core::file_utils::FileReader;
+use crate::core::pipeline::discovery::IGNORE_FILE_NAME;
+use crate::core::pipeline::AnalysisResults;
+use crate::core::scoring::ComplexityBaselines;
+use crate::io::cache::stats::is_exported;
+use crate::lang::registry::{adapter_for_file, detect_language_from_path};
+
+/// Average cyclomatic complexity that still earns a full complexity score.
+pub const COMPLEXITY_IDEAL: f64 = 5.0;
+
+/// Average cyclomatic complexity at which the complexity score reaches zero.
+pub const COMPLEXITY_LIMIT: f64 = 20.0;
+
+/// File name prefixes recognised as a license file.
+const LICENSE_PREFIXES: &[&str] = &["LICENSE", "LICENCE", "COPYING"];
+
+/// Relative weight of each health criterion.
+///
+/// Weights need not sum to 100; they are normalized over the criteria that
+/// have data.
+#[derive(Debug, Clone, Copy, PartialEq, Serialize, Deserialize)]
+pub struct HealthWeights {
+    /// Weight of the coverage criterion
+    pub coverage: f64,
+    /// Weight of the complexity criterion
+    pub complexity: f64,
+    /// Weight of the documentation criterion
+    pub documentation: f64,
+    /// Weight of the dependency freshness criterion
+    pub dependencies: f64,
+    /// Weight of the license criterion
+    pub license: f64,
+}
+
+/// Default values for [`HealthWeights`].
+impl Default for HealthWeights {
+    fn default() -> Self {
+        Self {
+            coverage: 30.0,
+            complexity: 25.0,
+            documentation: 20.0,
+            dependencies: 15.0,
+            license: 10.0,
+        }
+    }
+}
+
+/// A signal contributing to the project health score.
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Serialize, Deserialize)]
+#[serde(rename_all = "snake_case")]
+pub enum HealthCriterion {
+    /// Overall test coverage
+    Coverage,
+    /// Average cyclomatic complexity
+    Complexity,
+    /// Documentation of exported symbols
+    Documentation,
+    /// Freshness and vulnerability status of dependencies
+    Dependencies,
+    /// Presence of a license file
+    License,
+}
+
+/// Display names for [`HealthCriterion`].
+impl fmt::Display for HealthCriterion {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        f.write_str(match self {
+            Self::Coverage => "coverage",
+            Self::Complexity => "complexity",
+            Self::Documentation => "documentation",
+            Self::Dependencies => "dependencies",
+            Self::License => "license",
+        })
+    }
+}
+
+/// Score of one criterion.
+#[derive(Debug, Clone, PartialEq, Serialize, Deserialize)]
+pub struct HealthComponent {
+    /// Criterion scored
+    pub criterion: HealthCriterion,
+    /// Configured weight of the criterion
+    pub weight: f64,
+    /// Score (0-100), or `None` when there was no data to score
+    pub score: Option<f64>,
+    /// Score before per-language baseline correction, for criteria that apply one
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub raw_score: Option<f64>,
+    /// Measurement behind the score, e.g. `42 of 50 exported symbols documented`
+    pub detail: String,
+}
+
+/// Factory methods for [`HealthComponent`].
+impl HealthComponent {
+    /// A scored component; `score` is clamped to 0-100.
+    pub fn scored(
+        criterion: HealthCriterion,
+        weight: f64,
+        score: f64,
+        detail: impl Into<String>,
+    ) -> Self {
+        Self {
+            criterion,
+            weight,
+            score: Some(score.clamp(0.0, 100.0)),
+            raw_score: None,
+            detail: detail.into(),
+        }
+    }
+
+    /// A component without data, excluded from the aggregate score.
+    pub fn unavailable(criterion: HealthCriterion, weight: f64, detail: impl Into<String>) -> Self {
+        Self {
+            criterion,
+            weight,
+            score: None,
+            raw_score: None,
+            detail: detail.into(),
+        }
+    }
+}
+
+/// Aggregate project health score and the components behind it.
+#[derive(Debug, Clone, PartialEq, Serialize, Deserialize)]
+pub struct ProjectHealth {
+    /// Weighted score (0-100) over the available components
+    pub score: f64,
+    /// Every criterion, including those without data
+    pub components: Vec<HealthComponent>,
+}
+
+/// Scoring methods for [`ProjectHealth`].
+impl ProjectHealth {
+    /// Score the analyzed `paths` using the pass outputs in `results`.
+    ///
+    /// Documentation coverage and the license check read the files under
+    /// `paths`; every other criterion comes from `results`. Complexity is
+    /// corrected with `baselines` before scoring.
+    pub fn assess(
+        paths: &[PathBuf],
+        results: &AnalysisResults,
+        weights: &HealthWeights,
+        baselines: &ComplexityBaselines,
+    ) -> Self {
+        Self::from_components(vec![
+            coverage_component(results, weights.coverage),
+            complexity_component(results, weights.complexity, baselines),
+            documentation_component(paths, weights.documentation),
+            dependencies_component(results, weights.dependencies),
+            license_component(paths, weights.license),
+        ])
+    }
+
+    /// Aggregate `components` into a weighted average of the scored ones.
+    ///
+    /// The score is 0 when no component with a positive weight has data.
+    pub fn from_components(components: Vec<HealthComponent>) -> Self {
+        let (weighted, total_weight) = components
+            .iter()
+            .filter(|component| component.weight > 0.0)
+            .filter_map(|component| component.score.map(|score| (score, component.weight)))
+            .fold((0.0, 0.0), |(sum, total), (score, weight)| {
+                (sum + score * weight, total + weight)
+            });
+        let score = if total_weight > 0.0 {
+            weighted / total_weight
+        } else {
+            0.0
+        };
+        Self { score, components }
+    }
+
+    /// Component for `criterion`, if present.
+    pub fn component(&self, criterion: HealthCriterion) -> Option<&HealthComponent> {
+        self.components
+            .iter()
+            .find(|component| component.criterion == criterion)
+    }
+}
+
+/// Score overall coverage, which the coverage pass already reports as 0-100.
+fn coverage_component(results: &AnalysisResults, weight: f64) -> HealthComponent {
+    match results.passes.coverage.overall_coverage_percentage {
+        Some(percentage) => HealthComponent::scored(
+            HealthCriterion::Coverage,
+            weight,
+            percentage,
+            format!("{percentage:.1}% of lines covered"),
+        ),
+        None => HealthComponent::unavailable(
+            HealthCriterion::Coverage,
+            weight,
+            "no coverage report found",
+        ),
+    }
+}
+
+/// Score the baseline-corrected average cyclomatic complexity of analyzed
+/// functions; the uncorrected score is kept as the raw score.
+fn complexity_component(
+    results: &AnalysisResults,
+    weight: f64,
+    baselines: &ComplexityBaselines,
+) -> HealthComponent {
+    let complexity = &results.passes.complexity;
+    let means = complexity
+        .enabled
+        .then(|| baselines.mean_cyclomatic(&complexity.detailed_results))
+        .flatten();
+    let Some((raw, normalized)) = means else {
+        return HealthComponent::unavailable(
+            HealthCriterion::Complexity,
+            weight,
+            "complexity analysis disabled or no functions analyzed",
+        );
+    };
+    HealthComponent {
+        raw_score: Some(complexity_score(raw)),
+        ..HealthComponent::scored(
+            HealthCriterion::Complexity,
+            weight,
+            complexity_score(normalized),
+            format!(
+                "average cyclomatic complexity {normalized:.1} after per-language baselines (raw {raw:.1})"
+            ),
+        )
+    }
+}
+
+/// Map an average cyclomatic complexity onto 0-100, linearly between
+/// [`COMPLEXITY_IDEAL`] and [`COMPLEXITY_LIMIT`].
+pub fn complexity_score(average: f64) -> f64 {
+    let excess = (average - COMPLEXITY_IDEAL) / (COMPLEXITY_LIMIT - COMPLEXITY_IDEAL);
+    (100.0 * (1.0 - excess)).clamp(0.0, 100.0)
+}
+
+/// Score the share of exported symbols that are documented.
+fn documentation_component(paths: &[PathBuf], weight: f64) -> HealthComponent {
+    let coverage = paths
+        .iter()
+        .map(|path| documentation_coverage(path))
+        .fold(DocCoverage::default(), DocCoverage::merge);
+    match coverage.ratio() {
+        Some(ratio) => HealthComponent::scored(
+            HealthCriterion::Documentation,
+            weight,
+            ratio * 100.0,
+            format!(
+                "{} of {} exported symbols documented",
+                coverage.documented, coverage.exported
+            ),
+        ),
+        None => HealthComponent::unavailable(
+            HealthCriterion::Documentation,
+            weight,
+            "no exported symbols found",
+        ),
+    }
+}
+
+/// Score the share of dependencies that are current and free of known CVEs.
+fn dependencies_component(results: &AnalysisResults, weight: f64) -> HealthComponent {
+    let report = &results.dependency_report;
+    if report.is_empty() {
+        return HealthComponent::unavailable(
+            HealthCriterion::Dependencies,
+            weight,
+            "dependencies not checked (run with --check-deps)",
+        );
+    }
+    let healthy = report
+        .iter()
+        .filter(|dep| !dep.is_outdated() && dep.cve_count == 0)
+        .count();
+    HealthComponent::scored(
+        HealthCriterion::Dependencies,
+        weight,
+        healthy as f64 / report.len() as f64 * 100.0,
+        format!(
+            "{} of {} dependencies current and without known CVEs",
+            healthy,
+            report.len()
+        ),
+    )
+}
+
+/// Full marks when every analyzed root carries a license file.
+fn license_component(paths: &[PathBuf], weight: f64) -> HealthComponent {
+    let roots: Vec<&Path> = paths
+        .iter()
+        .map(|path| {
+            if path.is_dir() {
+                path.as_path()
+            } else {
+                path.parent().unwrap_or(Path::new("."))
+            }
+        })
+        .collect();
+    let licensed = roots.iter().filter(|root| has_license_file(root)).count();
+    let (score, detail) = match (licensed, roots.len()) {
+        (_, 0) | (0, _) => (0.0, "no license file found".to_string()),
+        (found, total) if found == total => (100.0, "license file present".to_string()),
+        (found, total) => (
+            found as f64 / total as f64 * 100.0,
+            format!("license file in {found} of {total} analyzed roots"),
+        ),
+    };
+    HealthComponent::scored(HealthCriterion::License, weight, score, detail)
+}
+
+/// Whether `dir` contains a `LICENSE`, `LICENCE` or `COPYING` file (any case
+/// and extension, e.g. `LICENSE-MIT` or `license.md`).
+pub fn has_license_file(dir: &Path) -> bool {
+    let Ok(entries) = std::fs::read_dir(dir) else {
+        return false;
+    };
+    entries.flatten().any(|entry| {
+        let name = entry.file_name().to_string_lossy().to_uppercase();
+        entry.path().is_file()
+            && LICENSE_PREFIXES
+                .iter()
+                .any(|prefix| name.starts_with(prefix))
+    })
+}
+
+/// Count of documented exported symbols.
+#[derive(Debug, Clone, Copy, Default, PartialEq, Eq)]
+pub struct DocCoverage {
+    /// Exported symbols with documentation
+    pub documented: usize,
+    /// All exported symbols
+    pub exported: usize,
+}
+
+/// Aggregation methods for [`DocCoverage`].
+impl DocCoverage {
+    /// Sum of two counts.
+    pub fn merge(self, other: Self) -> Self {
+        Self {
+            documented: self.documented + other.documented,
+            exported: self.exported + other.exported,
+        }
+    }
+
+    /// Documented share of exported symbols, or `None` when there are none.
+    pub fn ratio(&self) -> Option<f64> {
+        (self.exported > 0).then(|| self.documented as f64 / self.exported as f64)
+    }
+}
+
+/// Documentation coverage of the exported symbols in code files under `root`.
+pub fn documentation_coverage(root: &Path) -> DocCoverage {
+    let mut builder = WalkBuilder::new(root);
+    builder.add_custom_ignore_filename(IGNORE_FILE_NAME);
+
+    builder
+        .build()
+        .filter_map(|entry| entry.ok().map(|entry| entry.into_path()))
+        .filter(|path| path.is_file() && FileReader::is_code_file(path))
+        .map(|path| file_documentation_coverage(&path))
+        .fold(DocCoverage::default(), DocCoverage::merge)
+}
+
+/// Documentation coverage of one file; unparseable files count as empty.
+fn file_documentation_coverage(path: &Path) -> DocCoverage {
+    let Ok(mut adapter) = adapter_for_file(path) else {
+        return DocCoverage::default();
+    };
+    let source = match FileReader::read_to_string(path) {
+        Ok(source) => source,
+        Err(err) => {
+            warn!("Skipping {}: {}", path.display(), err);
+            return DocCoverage::default();
+        }
+    };
+    let entities = match adapter.extract_code_entities(&source, &path.to_string_lossy()) {
+        Ok(entities) => entities,
+        Err(err) => {
+            warn!("Failed to parse {}: {}", path.display(), err);
+            return DocCoverage::default();
+        }
+    };
+
+    let language = detect_language_from_path(&path.to_string_lossy());
+    let lines: Vec<&str> = source.lines().collect();
+    entities
+        .iter()
+        .filter(|entity| is_exported(entity, &language))
+        .map(|entity| DocCoverage {
+            documented: usize::from(is_documented(entity, &language, &lines)),
+            exported: 1,
+        })
+        .fold(DocCoverage::default(), DocCoverage::merge)
+}
+
+/// Whether `entity` is documented.
+///
+/// A symbol is documented when the nearest line above it, skipping
+/// attributes and decorators, is a comment. Python symbols also count when
+/// their body opens with a docstring.
+pub fn is_documented(entity: &CodeEntity, language: &str, lines: &[&str]) -> bool {
+    if language == "py" && opens_with_docstring(&entity.source_code) {
+        return true;
+    }
+    let Some((start_line, _)) = entity.line_range else {
+        return false;
+    };
+    lines
+        .iter()
+        .take(start_line.saturating_sub(1))
+        .rev()
+        .map(|line| line.trim())
+        .find(|line| !(line.starts_with("#[") || line.starts_with('@')))
+        .is_some_and(is_comment_line)
+}
+
+/// Whether a trimmed line is (part of) a comment.
+fn is_comment_line(line: &str) -> bool {
+    ["//", "/*", "*", "#", "--", "\"\"\"", "'''"]
+        .iter()
+        .any(|marker| line.starts_with(marker))
+        || line.ends_with("*/")
+}
+
+/// Whether the first statement after a Python `def`/`class` header is a docstring.
+fn opens_with_docstring(source: &str) -> bool {
+    let mut lines = source.lines().map(str::trim);
+    if !lines.by_ref().any(|line| line.ends_with(':')) {
+        return false;
+    }
+    lines.find(|line| !line.is_empty()).is_some_and(|line| {
+        ["\"\"\"", "'''", "r\"\"\"", "r'''"]
+            .iter()
+            .any(|quote| line.starts_with(quote))
+    })
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use std::fs;
+    use tempfile::TempDir;
+
+    fn entity(name: &str, line: usize, source: &str) -> CodeEntity {
+        let mut entity = CodeEntity::new(format!("id-{name}"), "Function", name, "file")
+            .with_source_code(source);
+        entity.line_range = Some((line, line + 2));
+        entity
+    }
+
+    #[test]
+    fn score_renormalizes_over_available_components() {
+        let health = ProjectHealth::from_components(vec![
+            HealthComponent::scored(HealthCriterion::Coverage, 30.0, 80.0, ""),
+            HealthComponent::scored(HealthCriterion::License, 10.0, 0.0, ""),
+            HealthComponent::unavailable(HealthCriterion::Dependencies, 60.0, ""),
+        ]);
+        assert!((health.score - 60.0).abs() < 1e-9, "{}", health.score);
+        assert_eq!(health.components.len(), 3);
+        assert_eq!(
+            health
+                .component(HealthCriterion::Dependencies)
+                .unwrap()
+                .score,
+            None
+        );
+    }
+
+    #[test]
+    fn zero_weights_and_missing_data_score_zero() {
+        let health = ProjectHealth::from_components(vec![
+            HealthComponent::scored(HealthCriterion::Coverage, 0.0, 90.0, ""),
+            HealthComponent::unavailable(HealthCriterion::License, 10.0, ""),
+        ]);
+        assert_eq!(health.score, 0.0);
+    }
+
+    #[test]
+    fn complexity_score_is_linear_between_bounds() {
+        assert_eq!(complexity_score(2.0), 100.0);
+        assert_eq!(complexity_score(COMPLEXITY_IDEAL), 100.0);
+        assert!((complexity_score(12.5) - 50.0).abs() < 1e-9);
+        assert_eq!(complexity_score(COMPLEXITY_LIMIT), 0.0);
+        assert_eq!(complexity_score(40.0), 0.0);
+    }
+
+    #[test]
+    fn complexity_is_scored_after_language_baselines() {
+        use crate::detectors::complexity::{
+            ComplexityAnalysisResult, ComplexityMetrics, ComplexitySeverity, HalsteadMetrics,
+        };
+
+        let mut results = AnalysisResults::empty();
+        results.passes.complexity.enabled = true;
+        results
+            .passes
+            .complexity
+            .detailed_results
+            .push(ComplexityAnalysisResult {
+                entity_id: "main.go:handle".to_string(),
+                file_path: "main.go".to_string(),
+                line_number: 1,
+                start_line: 1,
+                entity_name: "handle".to_string(),
+                entity_type: "function".to_string(),
+                metrics: ComplexityMetrics {
+                    cyclomatic_complexity: 13.0,
+                    cognitive_complexity: 0.0,
+                    max_nesting_depth: 0.0,
+                    parameter_count: 0.0,
+                    lines_of_code: 1.0,
+                    statement_count: 1.0,
+                    expression_complexity: 0.0,
+                    halstead: HalsteadMetrics::default(),
+                    technical_debt_score: 0.0,
+                    maintainability_index: 100.0,
+                    decision_points: Vec::new(),
+                },
+                issues: Vec::new(),
+                severity: ComplexitySeverity::Low,
+                recommendations: Vec::new(),
+            });
+
+        let baselines =
+            ComplexityBaselines::with_overrides(&[("go".to_string(), 1.3)].into_iter().collect());
+        let component = complexity_component(&results, 25.0, &baselines);
+        assert_eq!(component.score, Some(complexity_score(10.0)));
+        assert_eq!(component.raw_score, Some(complexity_score(13.0)));
+        assert!(
+            component.detail.contains("(raw 13.0)"),
+            "{}",
+            component.detail
+        );
+    }
+
+    #[test]
+    fn comments_and_docstrings_count_as_documentation() {
+        let lines: Vec<&str> = "// Parse reads a config.\nfunc Parse() {}\n\nfunc Load() {}\n"
+            .lines()
+            .collect();
+        assert!(is_documented(&entity("Parse", 2, ""), "go", &lines));
+        assert!(!is_documented(&entity("Load", 4, ""), "go", &lines));
+
+        let lines: Vec<&str> = "/// Runs it.\n#[inline]\npub fn run() {}\n"
+            .lines()
+            .collect();
+        assert!(is_documented(&entity("run", 3, ""), "rs", &lines));
+
+        let documented = entity("load", 1, "def load(path):\n    \"\"\"Load a file.\"\"\"\n");
+        let bare = entity("save", 1, "def save(path):\n    return path\n");
+        assert!(is_documented(&documented, "py", &[]));
+        assert!(!is_documented(&bare, "py", &[]));
+    }
+
+    #[test]
+    fn license_files_are_detected_case_insensitively() {
+        let dir = TempDir::new().unwrap();
+        assert!(!has_license_file(dir.path()));
+        assert_eq!(
+            license_component(&[dir.path().to_path_buf()], 10.0).score,
+            Some(0.0)
+        );
+
+        fs::write(dir.path().join("license.md"), "MIT").unwrap();
+        assert!(has_license_file(dir.path()));
+        assert_eq!(
+            license_component(&[dir.path().to_path_buf()], 10.0).score,
+            Some(100.0)
+        );
+    }
+}
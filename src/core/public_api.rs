@@ -0,0 +1,714 @@
+//! Exported API surface of a package.
+//!
+//! [`top_level_symbols`] lists the exported top-level functions, types,
+//! constants and variables declared directly in a package directory (or a
+//! single file), with their doc comments, signatures and locations. Private
+//! helpers, methods and nested items are left out, giving the compact view of
+//! a library that most callers need.
+//!
+//! When the package lives in a git repository with semver tags (`v1.4.0` or
+//! `1.4.0`), each symbol also gets the first tagged release whose tree
+//! already declared it as `since_version`. Symbols added after the latest tag
+//! have none.
+//!
+//! Symbols also carry how many other files in the repository mention them by
+//! name, with test files counted separately. [`ReferenceIndex`] computes these
+//! counts in a second pass over the whole repository, and [`hot_symbols`] ranks
+//! every exported symbol under a root by them. Like [`crate::core::xref`],
+//! matching is by name only, so same-named symbols share their counts.
+
+use std::collections::{BTreeSet, HashMap, HashSet};
+use std::path::{Path, PathBuf};
+
+use git2::{ObjectType, Repository, Tree};
+use ignore::WalkBuilder;
+use serde::{Deserialize, Serialize};
+use tracing::warn;
+
+use crate::core::dependency::go_dependencies::GoVersion;
+use crate::core::errors::{Result, ValknutError};
+use crate::core::file_utils::FileReader;
+use crate::core::pipeline::discovery::{IGNORE_FILE_NAME, VCS_DIRECTORIES, VENDOR_DIRECTORIES};
+use crate::core::review::is_test_file;
+use crate::core::symbol_search::signature_of;
+use crate::detectors::structure::file::FileAnalyzer;
+use crate::detectors::structure::StructureConfig;
+use crate::lang::common::{EntityKind, ParsedEntity};
+use crate::lang::registry::adapter_for_file;
+
+/// Line prefixes of attributes and decorators between a doc comment and its item.
+const ATTRIBUTE_PREFIXES: &[&str] = &["#[", "@"];
+
+/// Comment markers stripped from doc comment lines, longest first.
+const DOC_MARKERS: &[&str] = &["///", "//!", "//", "/**", "/*", "*/", "*"];
+
+/// Comment marker of hash-commented languages.
+const HASH_MARKER: &str = "#";
+
+/// Number of symbols [`hot_symbols`] returns when the caller does not ask for a count.
+pub const DEFAULT_HOT_SYMBOLS: usize = 20;
+
+/// An exported top-level symbol.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct TopLevelSymbol {
+    /// Symbol name as declared.
+    pub name: String,
+    /// Entity kind reported by the language adapter (`Function`, `Struct`, ...).
+    pub kind: String,
+    /// Declaring file, relative to the package directory.
+    pub file_path: PathBuf,
+    /// 1-based first line of the declaration.
+    pub start_line: usize,
+    /// 1-based last line of the declaration.
+    pub end_line: usize,
+    /// Declaration header, e.g. `func Parse(input string) (*Doc, error)`.
+    pub signature: Option<String>,
+    /// Doc comment or docstring text with comment markers removed.
+    pub doc: Option<String>,
+    /// First semver tag whose tree declared the symbol.
+    pub since_version: Option<String>,
+    /// Non-test files, other than the declaring one, that mention the symbol.
+    #[serde(default)]
+    pub reference_count: usize,
+    /// Test files, other than the declaring one, that mention the symbol.
+    #[serde(default)]
+    pub test_reference_count: usize,
+}
+
+/// List the exported top-level symbols of `package` (a directory, scanned
+/// without descending into subdirectories, or a single file).
+///
+/// Results are ordered by file, line and name. Reference counts cover the
+/// enclosing git working tree, or the package itself outside a repository.
+pub fn top_level_symbols(package: &Path) -> Result<Vec<TopLevelSymbol>> {
+    let mut symbols = declared_symbols(package)?;
+    ReferenceIndex::build(&repository_root(package)).annotate(package_dir(package), &mut symbols);
+    Ok(symbols)
+}
+
+/// [`top_level_symbols`] with zero reference counts, for callers that count
+/// references for many packages against one [`ReferenceIndex`].
+pub fn declared_symbols(package: &Path) -> Result<Vec<TopLevelSymbol>> {
+    if !package.exists() {
+        return Err(ValknutError::validation(format!(
+            "Path does not exist: {}",
+            package.display()
+        )));
+    }
+
+    let mut symbols = Vec::new();
+    for path in package_files(package)? {
+        let source = match FileReader::read_to_string(&path) {
+            Ok(source) => source,
+            Err(err) => {
+                warn!("Skipping {}: {}", path.display(), err);
+                continue;
+            }
+        };
+        let display_path = match path.strip_prefix(package) {
+            Ok(relative) if !relative.as_os_str().is_empty() => relative.to_path_buf(),
+            _ => PathBuf::from(path.file_name().unwrap_or_default()),
+        };
+        let lines: Vec<&str> = source.lines().collect();
+        let hash_comments = path
+            .extension()
+            .is_some_and(|ext| ext == "py" || ext == "pyi");
+        symbols.extend(
+            public_entities(&path, &source)
+                .into_iter()
+                .map(|entity| TopLevelSymbol {
+                    name: entity.name.clone(),
+                    kind: format!("{:?}", entity.kind),
+                    file_path: display_path.clone(),
+                    start_line: entity.location.start_line,
+                    end_line: entity.location.end_line,
+                    signature: signature_of(&entity.to_code_entity(&source)),
+                    doc: doc_comment(&entity, &lines, hash_comments),
+                    since_version: None,
+                    reference_count: 0,
+                    test_reference_count: 0,
+                }),
+        );
+    }
+    symbols.sort_by(|a, b| {
+        (&a.file_path, a.start_line, &a.name).cmp(&(&b.file_path, b.start_line, &b.name))
+    });
+
+    if let Err(err) = annotate_since_versions(package, &mut symbols) {
+        warn!("Could not date symbols from git tags: {}", err);
+    }
+    Ok(symbols)
+}
+
+/// The `limit` exported symbols under `root` that the most other files
+/// mention, with paths relative to `root`.
+///
+/// Every directory holding source files is treated as a package. Symbols are
+/// ranked by `reference_count`, then `test_reference_count`; symbols no other
+/// file mentions are left out.
+pub fn hot_symbols(root: &Path, limit: usize) -> Result<Vec<TopLevelSymbol>> {
+    if !root.is_dir() {
+        return Err(ValknutError::validation(format!(
+            "Path is not a directory: {}",
+            root.display()
+        )));
+    }
+
+    let mut symbols = repository_symbols(root)?;
+    symbols.retain(|symbol| symbol.reference_count + symbol.test_reference_count > 0);
+    symbols.sort_by(|a, b| {
+        (b.reference_count, b.test_reference_count)
+            .cmp(&(a.reference_count, a.test_reference_count))
+            .then_with(|| {
+                (&a.file_path, a.start_line, &a.name).cmp(&(&b.file_path, b.start_line, &b.name))
+            })
+    });
+    symbols.truncate(limit);
+    Ok(symbols)
+}
+
+/// Total `reference_count` of the exported symbols each file under `root`
+/// declares, keyed by path relative to `root`. Files declaring no referenced
+/// symbol are left out.
+pub fn file_reference_counts(root: &Path) -> Result<HashMap<PathBuf, usize>> {
+    let mut counts: HashMap<PathBuf, usize> = HashMap::new();
+    for symbol in repository_symbols(root)? {
+        if symbol.reference_count > 0 {
+            *counts.entry(symbol.file_path).or_default() += symbol.reference_count;
+        }
+    }
+    Ok(counts)
+}
+
+/// Every exported symbol under `root` with its reference counts, treating
+/// each directory holding source files as a package. Paths are relative to
+/// `root`.
+fn repository_symbols(root: &Path) -> Result<Vec<TopLevelSymbol>> {
+    let files = repository_files(root);
+    let directories: BTreeSet<&Path> = files.iter().filter_map(|path| path.parent()).collect();
+    let mut symbols = Vec::new();
+    for directory in directories {
+        let relative = directory.strip_prefix(root).unwrap_or(directory);
+        symbols.extend(
+            declared_symbols(directory)?
+                .into_iter()
+                .map(|symbol| TopLevelSymbol {
+                    file_path: relative.join(&symbol.file_path),
+                    ..symbol
+                }),
+        );
+    }
+
+    ReferenceIndex::from_files(root, &files).annotate(root, &mut symbols);
+    Ok(symbols)
+}
+
+/// Which files mention which identifiers, for counting symbol references.
+#[derive(Debug, Default)]
+pub struct ReferenceIndex {
+    /// Canonical path of every indexed file and whether it holds tests.
+    files: Vec<(PathBuf, bool)>,
+    /// Identifier to the indexed files that mention it, in index order.
+    mentions: HashMap<String, Vec<usize>>,
+}
+
+/// Construction and counting methods for [`ReferenceIndex`].
+impl ReferenceIndex {
+    /// Index the supported source files under `root`, honouring ignore files
+    /// and skipping vendored dependencies.
+    pub fn build(root: &Path) -> Self {
+        Self::from_files(root, &repository_files(root))
+    }
+
+    /// Index `files`, classifying test files by their path under `root`.
+    fn from_files(root: &Path, files: &[PathBuf]) -> Self {
+        let mut index = Self::default();
+        for path in files {
+            let source = match FileReader::read_to_string(path) {
+                Ok(source) => source,
+                Err(err) => {
+                    warn!("Skipping {}: {}", path.display(), err);
+                    continue;
+                }
+            };
+            let id = index.files.len();
+            let relative = path.strip_prefix(root).unwrap_or(path);
+            index.files.push((canonical(path), is_test_file(relative)));
+            for word in identifiers(&source) {
+                let files = index.mentions.entry(word.to_string()).or_default();
+                if files.last() != Some(&id) {
+                    files.push(id);
+                }
+            }
+        }
+        index
+    }
+
+    /// Set the reference counts of `symbols`, whose paths are relative to
+    /// `base`. Mentions in the declaring file itself do not count.
+    pub fn annotate(&self, base: &Path, symbols: &mut [TopLevelSymbol]) {
+        for symbol in symbols {
+            let declared_in = canonical(&base.join(&symbol.file_path));
+            let (mut references, mut test_references) = (0, 0);
+            for &id in self.mentions.get(&symbol.name).into_iter().flatten() {
+                let (path, is_test) = &self.files[id];
+                if *path == declared_in {
+                    continue;
+                }
+                if *is_test {
+                    test_references += 1;
+                } else {
+                    references += 1;
+                }
+            }
+            symbol.reference_count = references;
+            symbol.test_reference_count = test_references;
+        }
+    }
+}
+
+/// Identifier-like words of `source`: runs of letters, digits and underscores
+/// not starting with a digit.
+fn identifiers(source: &str) -> impl Iterator<Item = &str> {
+    source
+        .split(|c: char| !(c.is_alphanumeric() || c == '_'))
+        .filter(|word| word.chars().next().is_some_and(|c| !c.is_ascii_digit()))
+}
+
+/// Supported source files under `root`, outside vendored and VCS directories,
+/// honouring ignore files. Paths are sorted.
+pub fn repository_files(root: &Path) -> Vec<PathBuf> {
+    let mut builder = WalkBuilder::new(root);
+    builder
+        .add_custom_ignore_filename(IGNORE_FILE_NAME)
+        .filter_entry(|entry| {
+            let skipped = entry.depth() > 0
+                && entry.file_type().is_some_and(|kind| kind.is_dir())
+                && entry.file_name().to_str().is_some_and(|name| {
+                    VENDOR_DIRECTORIES.contains(&name) || VCS_DIRECTORIES.contains(&name)
+                });
+            !skipped
+        });
+
+    let mut files: Vec<PathBuf> = builder
+        .build()
+        .filter_map(|entry| match entry {
+            Ok(entry) => Some(entry.into_path()),
+            Err(err) => {
+                warn!("Failed to walk directory: {err}");
+                None
+            }
+        })
+        .filter(|path| path.is_file() && FileReader::is_code_file(path))
+        .collect();
+    files.sort();
+    files
+}
+
+/// Directory that `package`'s symbol paths are relative to.
+fn package_dir(package: &Path) -> &Path {
+    if package.is_file() {
+        package
+            .parent()
+            .filter(|parent| !parent.as_os_str().is_empty())
+            .unwrap_or(Path::new("."))
+    } else {
+        package
+    }
+}
+
+/// Working tree of the git repository containing `package`, or the package
+/// directory itself.
+fn repository_root(package: &Path) -> PathBuf {
+    Repository::discover(package)
+        .ok()
+        .and_then(|repo| repo.workdir().map(Path::to_path_buf))
+        .unwrap_or_else(|| package_dir(package).to_path_buf())
+}
+
+/// `path` with symlinks and relative components resolved, when it exists.
+fn canonical(path: &Path) -> PathBuf {
+    path.canonicalize().unwrap_or_else(|_| path.to_path_buf())
+}
+
+/// Supported source files that make up the package, excluding Go test files.
+fn package_files(package: &Path) -> Result<Vec<PathBuf>> {
+    if package.is_file() {
+        return Ok(vec![package.to_path_buf()]);
+    }
+    let entries = std::fs::read_dir(package).map_err(|e| {
+        ValknutError::io(format!("Failed to read directory {}", package.display()), e)
+    })?;
+    let mut files: Vec<PathBuf> = entries
+        .filter_map(|entry| entry.ok().map(|entry| entry.path()))
+        .filter(|path| path.is_file() && is_package_source(path))
+        .collect();
+    files.sort();
+    Ok(files)
+}
+
+/// Whether a file contributes to the package's public API.
+fn is_package_source(path: &Path) -> bool {
+    let is_test = path
+        .file_name()
+        .and_then(|name| name.to_str())
+        .is_some_and(|name| name.ends_with("_test.go"));
+    FileReader::is_code_file(path) && !is_test
+}
+
+/// Exported top-level entities of one file; unsupported or unparsable files have none.
+fn public_entities(path: &Path, source: &str) -> Vec<ParsedEntity> {
+    let Ok(mut adapter) = adapter_for_file(path) else {
+        return Vec::new();
+    };
+    let index = match adapter.parse_source(source, &path.to_string_lossy()) {
+        Ok(index) => index,
+        Err(err) => {
+            warn!("Failed to parse {}: {}", path.display(), err);
+            return Vec::new();
+        }
+    };
+    let analyzer = FileAnalyzer::new(StructureConfig::default());
+    index
+        .entities
+        .into_values()
+        .filter(|entity| {
+            entity.parent.is_none()
+                && !matches!(entity.kind, EntityKind::Method | EntityKind::Module)
+                && analyzer.is_entity_exported(entity, path, source)
+        })
+        .collect()
+}
+
+/// The entity's docstring, or the comment block directly above it.
+fn doc_comment(entity: &ParsedEntity, lines: &[&str], hash_comments: bool) -> Option<String> {
+    if let Some(docstring) = entity
+        .metadata
+        .get("docstring")
+        .and_then(|value| value.as_str())
+    {
+        let docstring = docstring.trim().trim_matches('"').trim_matches('\'').trim();
+        return (!docstring.is_empty()).then(|| docstring.to_string());
+    }
+
+    let mut index = entity
+        .location
+        .start_line
+        .saturating_sub(1)
+        .min(lines.len());
+    while index > 0 && is_attribute(lines[index - 1]) {
+        index -= 1;
+    }
+    let end = index;
+    while index > 0 && is_comment(lines[index - 1], hash_comments) {
+        index -= 1;
+    }
+    let text = lines[index..end]
+        .iter()
+        .map(|line| strip_comment_marker(line))
+        .filter(|line| !line.is_empty())
+        .collect::<Vec<_>>()
+        .join("\n");
+    (!text.is_empty()).then_some(text)
+}
+
+/// Whether a line is an attribute or decorator.
+fn is_attribute(line: &str) -> bool {
+    let line = line.trim();
+    ATTRIBUTE_PREFIXES
+        .iter()
+        .any(|prefix| line.starts_with(prefix))
+}
+
+/// Whether a line is part of a comment block.
+fn is_comment(line: &str, hash_comments: bool) -> bool {
+    let line = line.trim();
+    if hash_comments {
+        return line.starts_with(HASH_MARKER) && !line.starts_with("#!");
+    }
+    DOC_MARKERS.iter().any(|marker| line.starts_with(marker))
+}
+
+/// Comment text without its marker, trimmed.
+fn strip_comment_marker(line: &str) -> &str {
+    let line = line.trim();
+    let line = DOC_MARKERS
+        .iter()
+        .chain(std::iter::once(&HASH_MARKER))
+        .find_map(|marker| line.strip_prefix(marker))
+        .unwrap_or(line);
+    line.strip_suffix("*/").unwrap_or(line).trim()
+}
+
+/// Fill in `since_version` from the repository's semver tags.
+fn annotate_since_versions(package: &Path, symbols: &mut [TopLevelSymbol]) -> Result<()> {
+    if symbols.is_empty() {
+        return Ok(());
+    }
+    let Ok(repo) = Repository::discover(package) else {
+        return Ok(());
+    };
+    let Some(workdir) = repo.workdir() else {
+        return Ok(());
+    };
+    let relative = canonical(package)
+        .strip_prefix(canonical(workdir))
+        .map(Path::to_path_buf)
+        .unwrap_or_default();
+
+    let git_error = |e: git2::Error| ValknutError::internal(format!("git error: {e}"));
+    let mut tags: Vec<(GoVersion, String)> = repo
+        .tag_names(None)
+        .map_err(git_error)?
+        .iter()
+        .flatten()
+        .filter_map(|tag| semver_tag(tag).map(|version| (version, tag.to_string())))
+        .collect();
+    tags.sort();
+
+    let mut pending: HashMap<String, Vec<usize>> = HashMap::new();
+    for (index, symbol) in symbols.iter().enumerate() {
+        pending.entry(symbol.name.clone()).or_default().push(index);
+    }
+    for (_, tag) in tags {
+        if pending.is_empty() {
+            break;
+        }
+        let tree = repo
+            .revparse_single(&format!("refs/tags/{tag}"))
+            .and_then(|object| object.peel_to_tree())
+            .map_err(git_error)?;
+        for name in exported_names_at(&repo, &tree, &relative) {
+            for index in pending.remove(&name).unwrap_or_default() {
+                symbols[index].since_version = Some(tag.clone());
+            }
+        }
+    }
+    Ok(())
+}
+
+/// Parse a `v1.2.3` or `1.2.3` tag.
+fn semver_tag(tag: &str) -> Option<GoVersion> {
+    GoVersion::parse(tag).or_else(|| GoVersion::parse(&format!("v{tag}")))
+}
+
+/// Names of the exported top-level symbols the package declared in `tree`.
+fn exported_names_at(repo: &Repository, tree: &Tree, package: &Path) -> HashSet<String> {
+    let mut blobs = Vec::new();
+    if package.as_os_str().is_empty() {
+        collect_blobs(tree, Path::new(""), &mut blobs);
+    } else if let Ok(entry) = tree.get_path(package) {
+        match entry.kind() {
+            Some(ObjectType::Blob) => blobs.push((package.to_path_buf(), entry.id())),
+            Some(ObjectType::Tree) => {
+                if let Ok(subtree) = repo.find_tree(entry.id()) {
+                    collect_blobs(&subtree, package, &mut blobs);
+                }
+            }
+            _ => {}
+        }
+    }
+
+    let mut names = HashSet::new();
+    for (path, id) in blobs {
+        if !is_package_source(&path) {
+            continue;
+        }
+        let Ok(blob) = repo.find_blob(id) else {
+            continue;
+        };
+        let Ok(source) = std::str::from_utf8(blob.content()) else {
+            continue;
+        };
+        names.extend(
+            public_entities(&path, source)
+                .into_iter()
+                .map(|entity| entity.name),
+        );
+    }
+    names
+}
+
+/// Files directly inside `tree`, with their paths under `prefix`.
+fn collect_blobs(tree: &Tree, prefix: &Path, blobs: &mut Vec<(PathBuf, git2::Oid)>) {
+    for entry in tree.iter() {
+        if entry.kind() == Some(ObjectType::Blob) {
+            if let Some(name) = entry.name() {
+                blobs.push((prefix.join(name), entry.id()));
+            }
+        }
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use std::fs;
+    use tempfile::tempdir;
+
+    const SHAPES_V1: &str = "package shapes\n\n// Area returns the area of a rectangle.\nfunc Area(w, h float64) float64 {\n\treturn w * h\n}\n\nfunc helper() {}\n";
+
+    const SHAPES_V2: &str = "package shapes\n\n// Area returns the area of a rectangle.\nfunc Area(w, h float64) float64 {\n\treturn w * h\n}\n\n// Perimeter returns the perimeter of a rectangle.\nfunc Perimeter(w, h float64) float64 {\n\treturn 2 * (w + h)\n}\n\nfunc helper() {}\n";
+
+    #[test]
+    fn lists_exported_symbols_with_docs_and_signatures() {
+        let tmp = tempdir().unwrap();
+        fs::write(tmp.path().join("shapes.go"), SHAPES_V2).unwrap();
+        fs::write(
+            tmp.path().join("shapes_test.go"),
+            "package shapes\n\nfunc TestArea(t *testing.T) {}\n",
+        )
+        .unwrap();
+
+        let symbols = top_level_symbols(tmp.path()).unwrap();
+        let names: Vec<_> = symbols.iter().map(|s| s.name.as_str()).collect();
+        assert_eq!(names, vec!["Area", "Perimeter"]);
+
+        let area = &symbols[0];
+        assert_eq!(area.file_path, PathBuf::from("shapes.go"));
+        assert_eq!(area.start_line, 4);
+        assert_eq!(
+            area.signature.as_deref(),
+            Some("func Area(w, h float64) float64")
+        );
+        assert_eq!(
+            area.doc.as_deref(),
+            Some("Area returns the area of a rectangle.")
+        );
+        assert!(area.since_version.is_none());
+    }
+
+    #[test]
+    fn skips_private_python_helpers() {
+        let tmp = tempdir().unwrap();
+        let file = tmp.path().join("client.py");
+        fs::write(
+            &file,
+            "def connect(url):\n    \"\"\"Open a connection.\"\"\"\n    return _dial(url)\n\ndef _dial(url):\n    pass\n",
+        )
+        .unwrap();
+
+        let symbols = top_level_symbols(&file).unwrap();
+        let names: Vec<_> = symbols.iter().map(|s| s.name.as_str()).collect();
+        assert_eq!(names, vec!["connect"]);
+        assert_eq!(symbols[0].file_path, PathBuf::from("client.py"));
+    }
+
+    #[test]
+    fn counts_references_from_other_files_and_tests() {
+        let tmp = tempdir().unwrap();
+        let package = tmp.path().join("shapes");
+        fs::create_dir(&package).unwrap();
+        fs::write(package.join("shapes.go"), SHAPES_V2).unwrap();
+        fs::write(
+            package.join("shapes_test.go"),
+            "package shapes\n\nfunc TestPerimeter(t *testing.T) {\n\t_ = Perimeter(1, 2)\n}\n",
+        )
+        .unwrap();
+        fs::write(
+            tmp.path().join("main.go"),
+            "package main\n\nfunc main() {\n\t_ = shapes.Area(1, 2) + shapes.Area(3, 4)\n}\n",
+        )
+        .unwrap();
+
+        // Outside a git repository only the package itself is searched.
+        let counts = |symbols: &[TopLevelSymbol]| {
+            symbols
+                .iter()
+                .map(|s| (s.name.clone(), s.reference_count, s.test_reference_count))
+                .collect::<Vec<_>>()
+        };
+        let symbols = top_level_symbols(&package).unwrap();
+        assert_eq!(
+            counts(&symbols),
+            vec![("Area".into(), 0, 0), ("Perimeter".into(), 0, 1)]
+        );
+
+        Repository::init(tmp.path()).unwrap();
+        let symbols = top_level_symbols(&package).unwrap();
+        assert_eq!(
+            counts(&symbols),
+            vec![("Area".into(), 1, 0), ("Perimeter".into(), 0, 1)]
+        );
+    }
+
+    #[test]
+    fn hot_symbols_rank_by_reference_count() {
+        let tmp = tempdir().unwrap();
+        let root = tmp.path();
+        fs::create_dir_all(root.join("lib")).unwrap();
+        fs::create_dir_all(root.join("vendor/dep")).unwrap();
+        fs::write(
+            root.join("lib/util.py"),
+            "def parse(text):\n    pass\n\ndef render(doc):\n    pass\n\ndef unused():\n    pass\n",
+        )
+        .unwrap();
+        fs::write(root.join("a.py"), "from lib.util import parse, render\n").unwrap();
+        fs::write(root.join("b.py"), "from lib.util import parse\n").unwrap();
+        fs::write(root.join("test_util.py"), "from lib.util import render\n").unwrap();
+        fs::write(root.join("vendor/dep/c.py"), "parse(render(x))\n").unwrap();
+
+        let hot = hot_symbols(root, DEFAULT_HOT_SYMBOLS).unwrap();
+        let ranked: Vec<_> = hot
+            .iter()
+            .map(|s| (s.name.as_str(), s.reference_count, s.test_reference_count))
+            .collect();
+        assert_eq!(ranked, vec![("parse", 2, 0), ("render", 1, 1)]);
+        assert_eq!(hot[0].file_path, PathBuf::from("lib/util.py"));
+
+        assert_eq!(hot_symbols(root, 1).unwrap().len(), 1);
+        assert!(hot_symbols(&root.join("a.py"), 5).is_err());
+    }
+
+    #[test]
+    fn dates_symbols_from_semver_tags() {
+        let tmp = tempdir().unwrap();
+        let repo = Repository::init(tmp.path()).unwrap();
+        let package = tmp.path().join("shapes");
+        fs::create_dir(&package).unwrap();
+        let signature = git2::Signature::now("dev", "dev@example.com").unwrap();
+
+        let mut commit_and_tag = |source: &str, tag: &str| {
+            fs::write(package.join("shapes.go"), source).unwrap();
+            let mut index = repo.index().unwrap();
+            index.add_path(Path::new("shapes/shapes.go")).unwrap();
+            index.write().unwrap();
+            let tree = repo.find_tree(index.write_tree().unwrap()).unwrap();
+            let parents: Vec<_> = repo
+                .head()
+                .ok()
+                .and_then(|head| head.peel_to_commit().ok())
+                .into_iter()
+                .collect();
+            let parents: Vec<_> = parents.iter().collect();
+            let commit = repo
+                .commit(Some("HEAD"), &signature, &signature, tag, &tree, &parents)
+                .unwrap();
+            let object = repo.find_object(commit, None).unwrap();
+            repo.tag_lightweight(tag, &object, false).unwrap();
+        };
+        commit_and_tag(SHAPES_V1, "v1.0.0");
+        commit_and_tag(SHAPES_V2, "v1.1.0");
+        fs::write(
+            package.join("shapes.go"),
+            format!(
+                "{SHAPES_V2}\n// Volume is unreleased.\nfunc Volume() float64 {{\n\treturn 0\n}}\n"
+            ),
+        )
+        .unwrap();
+
+        let symbols = top_level_symbols(&package).unwrap();
+        let since: Vec<_> = symbols
+            .iter()
+            .map(|s| (s.name.as_str(), s.since_version.as_deref()))
+            .collect();
+        assert_eq!(
+            since,
+            vec![
+                ("Area", Some("v1.0.0")),
+                ("Perimeter", Some("v1.1.0")),
+                ("Volume", None),
+            ]
+        );
+    }
+}
@@ -0,0 +1,615 @@
+//! Structural comparison of two repositories.
+//!
+//! `valknut compare <repo-a> <repo-b>` is meant for services cut from a common
+//! template: it reports where they have drifted apart. Each side is summarised
+//! with [`ProjectSummary::collect`] (directories as packages, their exported
+//! top-level symbols, cross-package calls) plus the `require` directives of its
+//! `go.mod` files, and [`compare_repos`] matches the two summaries by package
+//! path and symbol name:
+//!
+//! - packages present in only one repository;
+//! - functions and methods declared in both under the same name but with a
+//!   different signature (whitespace is not significant);
+//! - types declared in both under the same name;
+//! - module requirements and package dependencies that differ.
+//!
+//! The comparison is symmetric: swapping the repositories only swaps the
+//! [`Repo`] labels.
+
+use std::collections::{BTreeMap, BTreeSet};
+use std::path::Path;
+
+use serde::{Deserialize, Serialize};
+use tracing::warn;
+
+use crate::core::dependency::go_dependencies::parse_go_mod;
+use crate::core::dependency::go_modules::find_module_dirs;
+use crate::core::errors::Result;
+use crate::core::public_api::TopLevelSymbol;
+use crate::io::reports::{PackageSummary, ProjectSummary};
+
+/// Symbol kinds compared by signature.
+const FUNCTION_KINDS: &[&str] = &["Function", "Method"];
+
+/// Symbol kinds reported as shared types.
+const TYPE_KINDS: &[&str] = &["Class", "Interface", "Enum", "Struct"];
+
+/// Which of the two compared repositories an item comes from.
+#[derive(Debug, Clone, Copy, PartialEq, Eq, PartialOrd, Ord, Serialize, Deserialize)]
+#[serde(rename_all = "snake_case")]
+pub enum Repo {
+    /// The first repository given.
+    A,
+    /// The second repository given.
+    B,
+}
+
+/// One side of a comparison: a project summary and its module requirements.
+#[derive(Debug, Clone, Default, PartialEq)]
+pub struct RepoSnapshot {
+    /// Packages, exported symbols and package dependencies.
+    pub summary: ProjectSummary,
+    /// Required module versions from every `go.mod`, keyed by module path.
+    pub modules: BTreeMap<String, String>,
+}
+
+/// Collection methods for [`RepoSnapshot`].
+impl RepoSnapshot {
+    /// Summarise the repository at `root`.
+    ///
+    /// When several `go.mod` files require the same module, the first one in
+    /// path order wins.
+    pub fn collect(root: &Path) -> Result<Self> {
+        let summary = ProjectSummary::collect(root)?;
+        let mut modules = BTreeMap::new();
+        for dir in find_module_dirs(root) {
+            let go_mod = dir.join("go.mod");
+            match std::fs::read_to_string(&go_mod) {
+                Ok(content) => {
+                    for requirement in parse_go_mod(&content) {
+                        modules
+                            .entry(requirement.module)
+                            .or_insert(requirement.version);
+                    }
+                }
+                Err(err) => warn!("Failed to read {}: {}", go_mod.display(), err),
+            }
+        }
+        Ok(Self { summary, modules })
+    }
+}
+
+/// Where a symbol is declared in one repository.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct SymbolLocation {
+    /// Entity kind reported by the language adapter (`Function`, `Struct`, ...).
+    pub kind: String,
+    /// Declaring file, relative to the package directory.
+    pub file_path: String,
+    /// 1-based first line of the declaration.
+    pub line: usize,
+    /// Declaration header, when the adapter reports one.
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub signature: Option<String>,
+}
+
+/// A package found in only one repository.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct PackageOnlyIn {
+    /// Package directory relative to the repository root.
+    pub package: String,
+    /// Repository that has the package.
+    pub repo: Repo,
+    /// Number of source files in the package.
+    pub files: usize,
+    /// Number of exported symbols in the package.
+    pub symbols: usize,
+}
+
+/// A function declared in both repositories with different signatures.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct SignatureMismatch {
+    /// Package directory relative to each repository root.
+    pub package: String,
+    /// Function or method name.
+    pub name: String,
+    /// Declaration in the first repository.
+    pub a: SymbolLocation,
+    /// Declaration in the second repository.
+    pub b: SymbolLocation,
+}
+
+/// A type declared in both repositories.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct SharedType {
+    /// Package directory relative to each repository root.
+    pub package: String,
+    /// Type name.
+    pub name: String,
+    /// Declaration in the first repository.
+    pub a: SymbolLocation,
+    /// Declaration in the second repository.
+    pub b: SymbolLocation,
+}
+
+/// A module required by only one repository, or at different versions.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct ModuleDivergence {
+    /// Module path.
+    pub module: String,
+    /// Version required by the first repository, if any.
+    pub version_a: Option<String>,
+    /// Version required by the second repository, if any.
+    pub version_b: Option<String>,
+}
+
+/// A package dependency found in only one repository.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct DependencyOnlyIn {
+    /// Calling package.
+    pub from: String,
+    /// Called package.
+    pub to: String,
+    /// Repository with the dependency.
+    pub repo: Repo,
+    /// Number of cross-package calls.
+    pub calls: usize,
+}
+
+/// Counts for the comparison headline.
+#[derive(Debug, Clone, Default, PartialEq, Eq, Serialize, Deserialize)]
+pub struct ComparisonSummary {
+    /// Packages in the first repository.
+    pub packages_a: usize,
+    /// Packages in the second repository.
+    pub packages_b: usize,
+    /// Packages present in both.
+    pub shared_packages: usize,
+    /// Functions with the same name and signature in both.
+    pub matching_functions: usize,
+    /// Functions with the same name but a different signature.
+    pub signature_mismatches: usize,
+    /// Types declared in both.
+    pub shared_types: usize,
+    /// Modules required by both at the same version.
+    pub shared_modules: usize,
+    /// Modules that differ in presence or version.
+    pub diverged_modules: usize,
+}
+
+/// Similarities and differences between two repositories.
+#[derive(Debug, Clone, Default, PartialEq, Serialize, Deserialize)]
+pub struct RepoComparison {
+    /// Name of the first repository.
+    pub repo_a: String,
+    /// Name of the second repository.
+    pub repo_b: String,
+    /// Headline counts.
+    pub summary: ComparisonSummary,
+    /// Packages present in only one repository, sorted by path.
+    pub packages_only_in: Vec<PackageOnlyIn>,
+    /// Same-named functions whose signatures differ, sorted by package and name.
+    pub signature_mismatches: Vec<SignatureMismatch>,
+    /// Types declared in both, sorted by package and name.
+    pub shared_types: Vec<SharedType>,
+    /// Module requirements that differ, sorted by module path.
+    pub module_divergence: Vec<ModuleDivergence>,
+    /// Package dependencies present in only one repository.
+    pub dependencies_only_in: Vec<DependencyOnlyIn>,
+}
+
+/// Query methods for [`RepoComparison`].
+impl RepoComparison {
+    /// True when no difference was found.
+    pub fn is_identical(&self) -> bool {
+        self.packages_only_in.is_empty()
+            && self.signature_mismatches.is_empty()
+            && self.module_divergence.is_empty()
+            && self.dependencies_only_in.is_empty()
+    }
+}
+
+/// Compare two repository snapshots.
+pub fn compare_repos(a: &RepoSnapshot, b: &RepoSnapshot) -> RepoComparison {
+    let packages_a = packages_by_path(&a.summary);
+    let packages_b = packages_by_path(&b.summary);
+
+    let mut comparison = RepoComparison {
+        repo_a: a.summary.name.clone(),
+        repo_b: b.summary.name.clone(),
+        ..RepoComparison::default()
+    };
+    comparison.packages_only_in = only_in(&packages_a, &packages_b, Repo::A)
+        .chain(only_in(&packages_b, &packages_a, Repo::B))
+        .collect();
+    comparison
+        .packages_only_in
+        .sort_by(|x, y| x.package.cmp(&y.package).then_with(|| x.repo.cmp(&y.repo)));
+
+    let mut matching_functions = 0;
+    for (path, package_a) in &packages_a {
+        let Some(package_b) = packages_b.get(path) else {
+            continue;
+        };
+        let symbols_a = symbols_by_name(package_a);
+        let symbols_b = symbols_by_name(package_b);
+        for (name, declared_a) in &symbols_a {
+            let Some(declared_b) = symbols_b.get(name) else {
+                continue;
+            };
+            let (matched, mismatched) = pair_functions(declared_a, declared_b);
+            matching_functions += matched;
+            comparison
+                .signature_mismatches
+                .extend(mismatched.into_iter().map(|(a, b)| SignatureMismatch {
+                    package: path.to_string(),
+                    name: name.to_string(),
+                    a: location(a),
+                    b: location(b),
+                }));
+
+            let type_a = declared_a.iter().find(|s| is_kind(s, TYPE_KINDS));
+            let type_b = declared_b.iter().find(|s| is_kind(s, TYPE_KINDS));
+            if let (Some(type_a), Some(type_b)) = (type_a, type_b) {
+                comparison.shared_types.push(SharedType {
+                    package: path.to_string(),
+                    name: name.to_string(),
+                    a: location(type_a),
+                    b: location(type_b),
+                });
+            }
+        }
+    }
+
+    let module_names: BTreeSet<&String> = a.modules.keys().chain(b.modules.keys()).collect();
+    let mut shared_modules = 0;
+    for module in module_names {
+        let version_a = a.modules.get(module);
+        let version_b = b.modules.get(module);
+        if version_a == version_b {
+            shared_modules += 1;
+            continue;
+        }
+        comparison.module_divergence.push(ModuleDivergence {
+            module: module.clone(),
+            version_a: version_a.cloned(),
+            version_b: version_b.cloned(),
+        });
+    }
+
+    let edges_a = dependency_edges(&a.summary);
+    let edges_b = dependency_edges(&b.summary);
+    comparison.dependencies_only_in = edges_only_in(&edges_a, &edges_b, Repo::A)
+        .chain(edges_only_in(&edges_b, &edges_a, Repo::B))
+        .collect();
+    comparison
+        .dependencies_only_in
+        .sort_by(|x, y| (&x.from, &x.to, x.repo).cmp(&(&y.from, &y.to, y.repo)));
+
+    comparison.summary = ComparisonSummary {
+        packages_a: packages_a.len(),
+        packages_b: packages_b.len(),
+        shared_packages: packages_a
+            .keys()
+            .filter(|path| packages_b.contains_key(*path))
+            .count(),
+        matching_functions,
+        signature_mismatches: comparison.signature_mismatches.len(),
+        shared_types: comparison.shared_types.len(),
+        shared_modules,
+        diverged_modules: comparison.module_divergence.len(),
+    };
+    comparison
+}
+
+/// Packages keyed by path.
+fn packages_by_path(summary: &ProjectSummary) -> BTreeMap<&str, &PackageSummary> {
+    summary
+        .packages
+        .iter()
+        .map(|package| (package.path.as_str(), package))
+        .collect()
+}
+
+/// Packages of `ours` that `theirs` lacks, labelled `repo`.
+fn only_in<'a>(
+    ours: &'a BTreeMap<&str, &PackageSummary>,
+    theirs: &'a BTreeMap<&str, &PackageSummary>,
+    repo: Repo,
+) -> impl Iterator<Item = PackageOnlyIn> + 'a {
+    ours.iter()
+        .filter(|(path, _)| !theirs.contains_key(*path))
+        .map(move |(path, package)| PackageOnlyIn {
+            package: path.to_string(),
+            repo,
+            files: package.files,
+            symbols: package.symbols.len(),
+        })
+}
+
+/// Exported symbols of a package grouped by name, in declaration order.
+fn symbols_by_name(package: &PackageSummary) -> BTreeMap<&str, Vec<&TopLevelSymbol>> {
+    let mut by_name: BTreeMap<&str, Vec<&TopLevelSymbol>> = BTreeMap::new();
+    for symbol in &package.symbols {
+        by_name
+            .entry(symbol.name.as_str())
+            .or_default()
+            .push(symbol);
+    }
+    by_name
+}
+
+/// Pair same-named functions: declarations whose signatures match (or cannot
+/// be compared) are counted, and the remaining ones are paired in
+/// declaration order as mismatches. Go methods on different receivers share a
+/// name, so a name can have several declarations on each side.
+fn pair_functions<'a>(
+    a: &[&'a TopLevelSymbol],
+    b: &[&'a TopLevelSymbol],
+) -> (usize, Vec<(&'a TopLevelSymbol, &'a TopLevelSymbol)>) {
+    let mut unmatched_b: Vec<&TopLevelSymbol> = b
+        .iter()
+        .copied()
+        .filter(|s| is_kind(s, FUNCTION_KINDS))
+        .collect();
+    let mut unmatched_a = Vec::new();
+    let mut matched = 0;
+    for symbol in a.iter().copied().filter(|s| is_kind(s, FUNCTION_KINDS)) {
+        let same = unmatched_b
+            .iter()
+            .position(|other| signatures_match(symbol, other));
+        match same {
+            Some(index) => {
+                unmatched_b.remove(index);
+                matched += 1;
+            }
+            None => unmatched_a.push(symbol),
+        }
+    }
+    (matched, unmatched_a.into_iter().zip(unmatched_b).collect())
+}
+
+/// Whether two declarations have the same signature, ignoring whitespace.
+/// Declarations without a signature are assumed to match.
+fn signatures_match(a: &TopLevelSymbol, b: &TopLevelSymbol) -> bool {
+    match (&a.signature, &b.signature) {
+        (Some(x), Some(y)) => normalize_signature(x) == normalize_signature(y),
+        _ => true,
+    }
+}
+
+/// Collapse runs of whitespace so formatting differences are not reported.
+fn normalize_signature(signature: &str) -> String {
+    signature.split_whitespace().collect::<Vec<_>>().join(" ")
+}
+
+/// Whether `symbol` is of one of `kinds`.
+fn is_kind(symbol: &TopLevelSymbol, kinds: &[&str]) -> bool {
+    kinds.contains(&symbol.kind.as_str())
+}
+
+/// Location of a declaration for the report.
+fn location(symbol: &TopLevelSymbol) -> SymbolLocation {
+    SymbolLocation {
+        kind: symbol.kind.clone(),
+        file_path: symbol.file_path.to_string_lossy().into_owned(),
+        line: symbol.start_line,
+        signature: symbol.signature.as_deref().map(normalize_signature),
+    }
+}
+
+/// Package dependencies keyed by caller and callee, with their call counts.
+fn dependency_edges(summary: &ProjectSummary) -> BTreeMap<(&str, &str), usize> {
+    summary
+        .dependencies
+        .iter()
+        .map(|dep| ((dep.from.as_str(), dep.to.as_str()), dep.calls))
+        .collect()
+}
+
+/// Dependencies of `ours` that `theirs` lacks, labelled `repo`.
+fn edges_only_in<'a>(
+    ours: &'a BTreeMap<(&str, &str), usize>,
+    theirs: &'a BTreeMap<(&str, &str), usize>,
+    repo: Repo,
+) -> impl Iterator<Item = DependencyOnlyIn> + 'a {
+    ours.iter()
+        .filter(|(edge, _)| !theirs.contains_key(*edge))
+        .map(move |((from, to), calls)| DependencyOnlyIn {
+            from: from.to_string(),
+            to: to.to_string(),
+            repo,
+            calls: *calls,
+        })
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::io::reports::PackageDependency;
+    use std::path::PathBuf;
+
+    fn symbol(name: &str, kind: &str, signature: &str) -> TopLevelSymbol {
+        TopLevelSymbol {
+            name: name.to_string(),
+            kind: kind.to_string(),
+            file_path: PathBuf::from("server.go"),
+            start_line: 3,
+            end_line: 9,
+            signature: Some(signature.to_string()),
+            doc: None,
+            since_version: None,
+            reference_count: 0,
+            test_reference_count: 0,
+        }
+    }
+
+    fn package(path: &str, symbols: Vec<TopLevelSymbol>) -> PackageSummary {
+        PackageSummary {
+            path: path.to_string(),
+            files: 1,
+            symbols,
+            functions: 0,
+            average_complexity: 0.0,
+            max_complexity: 0,
+        }
+    }
+
+    fn snapshot(
+        name: &str,
+        packages: Vec<PackageSummary>,
+        dependencies: &[(&str, &str)],
+        modules: &[(&str, &str)],
+    ) -> RepoSnapshot {
+        RepoSnapshot {
+            summary: ProjectSummary {
+                name: name.to_string(),
+                packages,
+                dependencies: dependencies
+                    .iter()
+                    .map(|(from, to)| PackageDependency {
+                        from: from.to_string(),
+                        to: to.to_string(),
+                        calls: 2,
+                    })
+                    .collect(),
+            },
+            modules: modules
+                .iter()
+                .map(|(module, version)| (module.to_string(), version.to_string()))
+                .collect(),
+        }
+    }
+
+    fn billing() -> RepoSnapshot {
+        snapshot(
+            "billing",
+            vec![
+                package(
+                    "api",
+                    vec![
+                        symbol("Server", "Struct", "type Server struct"),
+                        symbol(
+                            "Start",
+                            "Method",
+                            "func (s *Server) Start(ctx context.Context) error",
+                        ),
+                        symbol("Health", "Function", "func Health() bool"),
+                    ],
+                ),
+                package(
+                    "invoices",
+                    vec![symbol("Total", "Function", "func Total() int")],
+                ),
+            ],
+            &[("api", "invoices")],
+            &[
+                ("github.com/lib/pq", "v1.10.9"),
+                ("go.uber.org/zap", "v1.26.0"),
+            ],
+        )
+    }
+
+    fn shipping() -> RepoSnapshot {
+        snapshot(
+            "shipping",
+            vec![
+                package(
+                    "api",
+                    vec![
+                        symbol("Server", "Struct", "type Server struct"),
+                        symbol("Start", "Method", "func (s *Server) Start() error"),
+                        symbol("Health", "Function", "func  Health()  bool"),
+                    ],
+                ),
+                package("tracking", Vec::new()),
+            ],
+            &[("api", "tracking")],
+            &[
+                ("go.uber.org/zap", "v1.27.0"),
+                ("github.com/lib/pq", "v1.10.9"),
+            ],
+        )
+    }
+
+    #[test]
+    fn reports_drift_between_templated_services() {
+        let comparison = compare_repos(&billing(), &shipping());
+
+        assert_eq!(comparison.repo_a, "billing");
+        let only: Vec<_> = comparison
+            .packages_only_in
+            .iter()
+            .map(|p| (p.package.as_str(), p.repo))
+            .collect();
+        assert_eq!(only, vec![("invoices", Repo::A), ("tracking", Repo::B)]);
+
+        assert_eq!(comparison.signature_mismatches.len(), 1);
+        let mismatch = &comparison.signature_mismatches[0];
+        assert_eq!(
+            (mismatch.package.as_str(), mismatch.name.as_str()),
+            ("api", "Start")
+        );
+        assert_eq!(
+            mismatch.b.signature.as_deref(),
+            Some("func (s *Server) Start() error")
+        );
+        assert_eq!(comparison.summary.matching_functions, 1);
+
+        assert_eq!(comparison.shared_types.len(), 1);
+        assert_eq!(comparison.shared_types[0].name, "Server");
+
+        assert_eq!(
+            comparison.module_divergence,
+            vec![ModuleDivergence {
+                module: "go.uber.org/zap".to_string(),
+                version_a: Some("v1.26.0".to_string()),
+                version_b: Some("v1.27.0".to_string()),
+            }]
+        );
+        assert_eq!(comparison.summary.shared_modules, 1);
+        let edges: Vec<_> = comparison
+            .dependencies_only_in
+            .iter()
+            .map(|d| (d.to.as_str(), d.repo))
+            .collect();
+        assert_eq!(edges, vec![("invoices", Repo::A), ("tracking", Repo::B)]);
+        assert!(!comparison.is_identical());
+    }
+
+    #[test]
+    fn comparison_is_symmetric() {
+        let forward = compare_repos(&billing(), &shipping());
+        let backward = compare_repos(&shipping(), &billing());
+
+        let flip = |repo: Repo| match repo {
+            Repo::A => Repo::B,
+            Repo::B => Repo::A,
+        };
+        let mut flipped: Vec<_> = backward
+            .packages_only_in
+            .iter()
+            .map(|p| (p.package.clone(), flip(p.repo)))
+            .collect();
+        flipped.sort();
+        let expected: Vec<_> = forward
+            .packages_only_in
+            .iter()
+            .map(|p| (p.package.clone(), p.repo))
+            .collect();
+        assert_eq!(flipped, expected);
+        assert_eq!(
+            backward.signature_mismatches[0].a,
+            forward.signature_mismatches[0].b
+        );
+        assert_eq!(
+            backward.module_divergence[0].version_a,
+            forward.module_divergence[0].version_b
+        );
+        assert_eq!(
+            forward.summary.shared_packages,
+            backward.summary.shared_packages
+        );
+        assert!(compare_repos(&billing(), &billing()).is_identical());
+    }
+}
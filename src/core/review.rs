@@ -0,0 +1,958 @@
+//! Git diff summaries for code review.
+//!
+//! [`review_context`] is the structured form of `git diff <base>...HEAD`: it
+//! diffs `HEAD` against its merge base with `base`, maps the changed lines onto
+//! the functions, methods and types declared in each file, and gathers what a
+//! reviewer needs next to the patch: the package of every changed file, the
+//! signatures of added and modified functions, the types they mention and the
+//! tests that reference them.
+//!
+//! Declarations are taken from the incremental index when it is fresh, so
+//! files `analyze` has already seen are not parsed again.
+
+use std::collections::{BTreeMap, BTreeSet, HashSet};
+use std::fmt::Write as _;
+use std::path::{Path, PathBuf};
+
+use git2::{Commit, Delta, Diff, Oid, Patch, Repository, Tree};
+use serde::{Deserialize, Serialize};
+use tracing::warn;
+
+use crate::core::blame::{entities_for, source_files};
+use crate::core::errors::{Result, ValknutError};
+use crate::core::featureset::CodeEntity;
+use crate::core::file_utils::FileReader;
+use crate::io::cache::IncrementalCache;
+
+/// Entity kinds reported as functions.
+const FUNCTION_KINDS: &[&str] = &["Function", "Method"];
+
+/// Entity kinds reported as types.
+const TYPE_KINDS: &[&str] = &["Class", "Struct", "Interface", "Enum"];
+
+/// Declaration lines scanned for the end of a signature.
+const MAX_SIGNATURE_LINES: usize = 8;
+
+/// Length of abbreviated commit ids in the Markdown document.
+const SHORT_ID_LEN: usize = 7;
+
+/// How a file changed between the merge base and `HEAD`.
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Serialize, Deserialize)]
+#[serde(rename_all = "snake_case")]
+pub enum FileChange {
+    /// The file is new
+    Added,
+    /// The file was edited in place
+    Modified,
+    /// The file was moved, possibly with edits
+    Renamed,
+    /// The file was removed
+    Deleted,
+}
+
+/// How a symbol changed between the merge base and `HEAD`.
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Serialize, Deserialize)]
+#[serde(rename_all = "snake_case")]
+pub enum SymbolChange {
+    /// No symbol of the same kind and name existed in the base version
+    Added,
+    /// Lines of an existing symbol changed
+    Modified,
+}
+
+/// A test that mentions a changed symbol.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct TestReference {
+    /// Test function name, or the file name for tests outside named functions
+    pub name: String,
+    /// Test file, relative to the repository root
+    pub file_path: PathBuf,
+    /// 1-based line of the test
+    pub line: usize,
+}
+
+/// A function, method or type whose lines changed.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct ChangedSymbol {
+    /// Symbol name as declared
+    pub name: String,
+    /// Entity kind reported by the language adapter (`Function`, `Struct`, ...)
+    pub kind: String,
+    /// Whether the symbol is new or was edited
+    pub change: SymbolChange,
+    /// Declaration up to the start of the body
+    pub signature: String,
+    /// 1-based first line of the declaration in `HEAD`
+    pub start_line: usize,
+    /// 1-based last line of the declaration in `HEAD`
+    pub end_line: usize,
+    /// Types declared in the repository that the symbol mentions
+    pub types: Vec<String>,
+    /// Tests that mention the symbol
+    pub tests: Vec<TestReference>,
+}
+
+/// A file touched by the diff.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct ChangedFile {
+    /// Path in `HEAD` (in the base for deleted files), relative to the repository root
+    pub path: PathBuf,
+    /// Path in the base when the file was renamed
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub previous_path: Option<PathBuf>,
+    /// Package clause for Go, Java and Kotlin; the parent directory otherwise
+    pub package: String,
+    /// How the file changed
+    pub change: FileChange,
+    /// Lines added by the diff
+    pub lines_added: usize,
+    /// Lines removed by the diff
+    pub lines_removed: usize,
+    /// Changed symbols ordered by line
+    pub symbols: Vec<ChangedSymbol>,
+}
+
+/// Review context for the changes on `HEAD` since it forked from a base revision.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct ReviewContext {
+    /// Base revision as given
+    pub base: String,
+    /// Commit id of the merge base of `base` and `HEAD`
+    pub merge_base: String,
+    /// Commit id of `HEAD`
+    pub head: String,
+    /// Changed files ordered by path
+    pub files: Vec<ChangedFile>,
+}
+
+/// Rendering methods for [`ReviewContext`].
+impl ReviewContext {
+    /// Number of changed symbols across all files.
+    pub fn symbol_count(&self) -> usize {
+        self.files.iter().map(|file| file.symbols.len()).sum()
+    }
+
+    /// Render the context as a Markdown document.
+    pub fn to_markdown(&self) -> String {
+        let mut out = String::new();
+        let _ = writeln!(out, "# Review context: `{}...HEAD`\n", self.base);
+        let _ = writeln!(
+            out,
+            "Merge base `{}`, head `{}`.\n",
+            short_id(&self.merge_base),
+            short_id(&self.head)
+        );
+        if self.files.is_empty() {
+            out.push_str("No changes between the merge base and HEAD.\n");
+            return out;
+        }
+
+        let added = self
+            .files
+            .iter()
+            .flat_map(|file| &file.symbols)
+            .filter(|symbol| symbol.change == SymbolChange::Added)
+            .count();
+        let _ = writeln!(
+            out,
+            "{} files changed, {} symbols ({} added, {} modified).\n",
+            self.files.len(),
+            self.symbol_count(),
+            added,
+            self.symbol_count() - added
+        );
+
+        out.push_str("## Changed files\n\n");
+        out.push_str("| File | Package | Change | Lines |\n");
+        out.push_str("|---|---|---|---|\n");
+        for file in &self.files {
+            let path = match &file.previous_path {
+                Some(previous) => format!("`{}` → `{}`", previous.display(), file.path.display()),
+                None => format!("`{}`", file.path.display()),
+            };
+            let _ = writeln!(
+                out,
+                "| {} | `{}` | {} | +{} -{} |",
+                path,
+                file.package,
+                file_change_label(file.change),
+                file.lines_added,
+                file.lines_removed
+            );
+        }
+
+        for file in self.files.iter().filter(|file| !file.symbols.is_empty()) {
+            let _ = writeln!(out, "\n## `{}`\n", file.path.display());
+            let fence = file
+                .path
+                .extension()
+                .map(|ext| ext.to_string_lossy().into_owned())
+                .unwrap_or_default();
+            for symbol in &file.symbols {
+                render_symbol(&mut out, symbol, &fence);
+            }
+        }
+        out
+    }
+}
+
+/// Append one symbol section: heading, fenced signature, types and tests.
+fn render_symbol(out: &mut String, symbol: &ChangedSymbol, fence: &str) {
+    let change = match symbol.change {
+        SymbolChange::Added => "added",
+        SymbolChange::Modified => "modified",
+    };
+    let _ = writeln!(
+        out,
+        "### `{}` ({}, {}, lines {}-{})\n",
+        symbol.name, symbol.kind, change, symbol.start_line, symbol.end_line
+    );
+    let _ = writeln!(out, "```{fence}\n{}\n```\n", symbol.signature);
+
+    if symbol.types.is_empty() {
+        out.push_str("- Types: none\n");
+    } else {
+        let types: Vec<String> = symbol
+            .types
+            .iter()
+            .map(|name| format!("`{name}`"))
+            .collect();
+        let _ = writeln!(out, "- Types: {}", types.join(", "));
+    }
+    if symbol.tests.is_empty() {
+        out.push_str("- Tests: none found\n");
+    } else {
+        let tests: Vec<String> = symbol
+            .tests
+            .iter()
+            .map(|test| {
+                format!(
+                    "`{}` (`{}:{}`)",
+                    test.name,
+                    test.file_path.display(),
+                    test.line
+                )
+            })
+            .collect();
+        let _ = writeln!(out, "- Tests: {}", tests.join(", "));
+    }
+    out.push('\n');
+}
+
+/// Lower-case label for a file change.
+fn file_change_label(change: FileChange) -> &'static str {
+    match change {
+        FileChange::Added => "added",
+        FileChange::Modified => "modified",
+        FileChange::Renamed => "renamed",
+        FileChange::Deleted => "deleted",
+    }
+}
+
+/// The first [`SHORT_ID_LEN`] characters of a commit id.
+fn short_id(id: &str) -> &str {
+    &id[..SHORT_ID_LEN.min(id.len())]
+}
+
+/// Summarize the changes on `HEAD` since it forked from `base`, like `git diff base...HEAD`.
+///
+/// Only files under `root` are reported. `cache_dir` holds the incremental
+/// index; without it every file is parsed from scratch.
+pub fn review_context(root: &Path, base: &str, cache_dir: Option<&Path>) -> Result<ReviewContext> {
+    let (repo, workdir, scope) = open_repository(root)?;
+    let BranchDiff {
+        head,
+        merge_base,
+        base_tree,
+        head_tree,
+        diff,
+    } = diff_since_fork(&repo, base)?;
+
+    let structure = cache_dir.map(IncrementalCache::open);
+    let mut changed: Vec<(ChangedFile, Vec<CodeEntity>)> = Vec::new();
+    for (index, delta) in diff.deltas().enumerate() {
+        let old_path = delta.old_file().path().map(Path::to_path_buf);
+        let Some(path) = delta
+            .new_file()
+            .path()
+            .map(Path::to_path_buf)
+            .or(old_path.clone())
+        else {
+            continue;
+        };
+        if !path.starts_with(&scope) {
+            continue;
+        }
+        let change = match delta.status() {
+            Delta::Added | Delta::Copied | Delta::Untracked => FileChange::Added,
+            Delta::Deleted => FileChange::Deleted,
+            Delta::Renamed => FileChange::Renamed,
+            _ => FileChange::Modified,
+        };
+        let lines = changed_lines(&diff, index)?;
+
+        let head_source = match change {
+            FileChange::Deleted => None,
+            _ => blob_text(&repo, &head_tree, &path),
+        };
+        let base_source = match (change, &old_path) {
+            (FileChange::Added, _) | (_, None) => None,
+            (_, Some(old_path)) => blob_text(&repo, &base_tree, old_path),
+        };
+        let package = package_name(
+            &path,
+            head_source
+                .as_deref()
+                .or(base_source.as_deref())
+                .unwrap_or(""),
+        );
+
+        let symbols = match &head_source {
+            Some(source) if FileReader::is_code_file(&path) => {
+                let previous = match (&base_source, &old_path) {
+                    (Some(base_source), Some(old_path)) => {
+                        entities_for(&workdir.join(old_path), base_source, None).unwrap_or_default()
+                    }
+                    _ => Vec::new(),
+                };
+                let current = entities_for(&workdir.join(&path), source, structure.as_ref())
+                    .unwrap_or_default();
+                changed_symbols(current, &previous, &lines.touched)
+            }
+            _ => Vec::new(),
+        };
+
+        let (symbols, entities) = symbols.into_iter().unzip();
+        let file = ChangedFile {
+            previous_path: old_path.filter(|old| change == FileChange::Renamed && *old != path),
+            path,
+            package,
+            change,
+            lines_added: lines.added,
+            lines_removed: lines.removed,
+            symbols,
+        };
+        changed.push((file, entities));
+    }
+
+    let index = if changed.iter().any(|(_, entities)| !entities.is_empty()) {
+        RepositoryIndex::collect(root, &workdir, structure.as_ref())
+    } else {
+        RepositoryIndex::default()
+    };
+    let mut files: Vec<ChangedFile> = changed
+        .into_iter()
+        .map(|(mut file, entities)| {
+            let is_test = is_test_file(&file.path);
+            for (symbol, entity) in file.symbols.iter_mut().zip(&entities) {
+                symbol.types = index.types_in(entity);
+                if !is_test {
+                    symbol.tests = index.tests_mentioning(&symbol.name);
+                }
+            }
+            file
+        })
+        .collect();
+    files.sort_by(|a, b| a.path.cmp(&b.path));
+
+    Ok(ReviewContext {
+        base: base.to_string(),
+        merge_base: merge_base.to_string(),
+        head: head.id().to_string(),
+        files,
+    })
+}
+
+/// Lines `HEAD` added or touched since it forked from `base`, for every file
+/// under `root` that still exists, keyed by absolute path.
+pub fn changed_lines_since(root: &Path, base: &str) -> Result<BTreeMap<PathBuf, BTreeSet<usize>>> {
+    let (repo, workdir, scope) = open_repository(root)?;
+    let branch = diff_since_fork(&repo, base)?;
+    let mut changed = BTreeMap::new();
+    for (index, delta) in branch.diff.deltas().enumerate() {
+        if delta.status() == Delta::Deleted {
+            continue;
+        }
+        let Some(path) = delta.new_file().path() else {
+            continue;
+        };
+        if path.starts_with(&scope) {
+            changed.insert(
+                workdir.join(path),
+                changed_lines(&branch.diff, index)?.touched,
+            );
+        }
+    }
+    Ok(changed)
+}
+
+/// `HEAD` diffed against its merge base with a base revision.
+struct BranchDiff<'r> {
+    /// The commit being reviewed
+    head: Commit<'r>,
+    /// Merge base of `HEAD` and the base revision
+    merge_base: Oid,
+    /// Tree of the merge base
+    base_tree: Tree<'r>,
+    /// Tree of `HEAD`
+    head_tree: Tree<'r>,
+    /// Tree-to-tree diff with renames detected
+    diff: Diff<'r>,
+}
+
+/// Open the repository containing `root`, returning it with its canonical
+/// working directory and the path of `root` inside it.
+pub(crate) fn open_repository(root: &Path) -> Result<(Repository, PathBuf, PathBuf)> {
+    if !root.exists() {
+        return Err(ValknutError::validation(format!(
+            "Path does not exist: {}",
+            root.display()
+        )));
+    }
+    let repo = Repository::discover(root).map_err(|e| {
+        ValknutError::validation(format!(
+            "{} is not in a git repository: {}",
+            root.display(),
+            e.message()
+        ))
+    })?;
+    let workdir = repo
+        .workdir()
+        .ok_or_else(|| ValknutError::validation("Cannot review a bare repository"))?
+        .canonicalize()
+        .map_err(|e| ValknutError::io("Failed to resolve repository root", e))?;
+    let scope = root
+        .canonicalize()
+        .map_err(|e| ValknutError::io(format!("Failed to resolve {}", root.display()), e))?
+        .strip_prefix(&workdir)
+        .map(Path::to_path_buf)
+        .unwrap_or_default();
+
+    Ok((repo, workdir, scope))
+}
+
+/// Diff `HEAD` against its merge base with `base`, like `git diff base...HEAD`.
+fn diff_since_fork<'r>(repo: &'r Repository, base: &str) -> Result<BranchDiff<'r>> {
+    let head = repo
+        .head()
+        .and_then(|head| head.peel_to_commit())
+        .map_err(|e| git_error("resolve HEAD", e))?;
+    let base_commit = repo
+        .revparse_single(base)
+        .and_then(|object| object.peel_to_commit())
+        .map_err(|e| {
+            ValknutError::validation(format!("Unknown git revision '{base}': {}", e.message()))
+        })?;
+    let merge_base = repo.merge_base(base_commit.id(), head.id()).map_err(|e| {
+        ValknutError::validation(format!(
+            "'{base}' and HEAD have no common ancestor: {}",
+            e.message()
+        ))
+    })?;
+    let base_tree = repo
+        .find_commit(merge_base)
+        .and_then(|commit| commit.tree())
+        .map_err(|e| git_error("read the merge base", e))?;
+    let head_tree = head.tree().map_err(|e| git_error("read HEAD", e))?;
+    let mut diff = repo
+        .diff_tree_to_tree(Some(&base_tree), Some(&head_tree), None)
+        .map_err(|e| git_error(&format!("diff '{base}...HEAD'"), e))?;
+    diff.find_similar(None)
+        .map_err(|e| git_error("detect renames", e))?;
+
+    Ok(BranchDiff {
+        head,
+        merge_base,
+        base_tree,
+        head_tree,
+        diff,
+    })
+}
+
+/// Line statistics of one file in a diff.
+#[derive(Debug, Default)]
+struct ChangedLines {
+    /// 1-based lines of the new version that were added, or next to a removal
+    touched: BTreeSet<usize>,
+    /// Lines added
+    added: usize,
+    /// Lines removed
+    removed: usize,
+}
+
+/// Added lines, and the positions of removed ones, of the `index`th file in `diff`.
+fn changed_lines(diff: &Diff, index: usize) -> Result<ChangedLines> {
+    let mut lines = ChangedLines::default();
+    let Some(patch) = Patch::from_diff(diff, index).map_err(|e| git_error("read the diff", e))?
+    else {
+        return Ok(lines);
+    };
+    for hunk_index in 0..patch.num_hunks() {
+        let (hunk, line_count) = patch
+            .hunk(hunk_index)
+            .map_err(|e| git_error("read a diff hunk", e))?;
+        // Removed lines have no new line number; attribute them to the line above.
+        let mut cursor = (hunk.new_start() as usize).saturating_sub(1);
+        for line_index in 0..line_count {
+            let line = patch
+                .line_in_hunk(hunk_index, line_index)
+                .map_err(|e| git_error("read a diff line", e))?;
+            match line.origin() {
+                '+' => {
+                    cursor = line.new_lineno().map_or(cursor + 1, |n| n as usize);
+                    lines.touched.insert(cursor);
+                    lines.added += 1;
+                }
+                '-' => {
+                    lines.touched.insert(cursor.max(1));
+                    lines.removed += 1;
+                }
+                ' ' => cursor = line.new_lineno().map_or(cursor + 1, |n| n as usize),
+                _ => {}
+            }
+        }
+    }
+    Ok(lines)
+}
+
+/// Text of the blob at `path` in `tree`, unless it is missing or binary.
+pub(crate) fn blob_text(repo: &Repository, tree: &Tree, path: &Path) -> Option<String> {
+    let blob = tree
+        .get_path(path)
+        .and_then(|entry| entry.to_object(repo))
+        .and_then(|object| object.peel_to_blob())
+        .ok()?;
+    (!blob.is_binary()).then(|| String::from_utf8_lossy(blob.content()).into_owned())
+}
+
+/// Functions and types of `current` overlapping `touched`, with the entity each came from.
+fn changed_symbols(
+    current: Vec<CodeEntity>,
+    previous: &[CodeEntity],
+    touched: &BTreeSet<usize>,
+) -> Vec<(ChangedSymbol, CodeEntity)> {
+    let existing: HashSet<(&str, &str)> = previous
+        .iter()
+        .map(|entity| (entity.entity_type.as_str(), entity.name.as_str()))
+        .collect();
+    let mut symbols: Vec<(ChangedSymbol, CodeEntity)> = current
+        .into_iter()
+        .filter(|entity| is_function(entity) || is_type(entity))
+        .filter_map(|entity| {
+            let (start_line, end_line) = entity.line_range?;
+            touched.range(start_line..=end_line).next()?;
+            let change = if existing.contains(&(entity.entity_type.as_str(), entity.name.as_str()))
+            {
+                SymbolChange::Modified
+            } else {
+                SymbolChange::Added
+            };
+            let symbol = ChangedSymbol {
+                name: entity.name.clone(),
+                kind: entity.entity_type.clone(),
+                change,
+                signature: signature(&entity.source_code),
+                start_line,
+                end_line,
+                types: Vec::new(),
+                tests: Vec::new(),
+            };
+            Some((symbol, entity))
+        })
+        .collect();
+    symbols.sort_by(|(a, _), (b, _)| (a.start_line, &a.name).cmp(&(b.start_line, &b.name)));
+    symbols
+}
+
+/// Whether an entity is a function or method.
+fn is_function(entity: &CodeEntity) -> bool {
+    FUNCTION_KINDS.contains(&entity.entity_type.as_str())
+}
+
+/// Whether an entity declares a type.
+fn is_type(entity: &CodeEntity) -> bool {
+    TYPE_KINDS.contains(&entity.entity_type.as_str())
+}
+
+/// The declaration of `source` up to its body, on one line.
+///
+/// Leading attributes, decorators and comments are skipped. The signature
+/// ends at the first `{` outside parentheses and brackets, or at a line ending
+/// in `:` for Python.
+pub fn signature(source: &str) -> String {
+    let mut parts: Vec<String> = Vec::new();
+    let mut depth = 0i32;
+    'lines: for line in source.lines().take(MAX_SIGNATURE_LINES) {
+        let trimmed = line.trim();
+        let preamble = trimmed.is_empty()
+            || trimmed.starts_with('@')
+            || trimmed.starts_with("#[")
+            || trimmed.starts_with("//")
+            || trimmed.starts_with("/*")
+            || trimmed.starts_with('*')
+            || trimmed.starts_with('#');
+        if parts.is_empty() && preamble {
+            continue;
+        }
+        for (offset, ch) in trimmed.char_indices() {
+            match ch {
+                '(' | '[' => depth += 1,
+                ')' | ']' => depth -= 1,
+                '{' if depth <= 0 => {
+                    parts.push(trimmed[..offset].trim_end().to_string());
+                    break 'lines;
+                }
+                _ => {}
+            }
+        }
+        parts.push(trimmed.to_string());
+        if depth <= 0 && (trimmed.ends_with(':') || trimmed.ends_with(';')) {
+            break;
+        }
+    }
+    parts.retain(|part| !part.is_empty());
+    parts.join(" ")
+}
+
+/// Package of a changed file: the package clause for Go, Java and Kotlin,
+/// otherwise the parent directory (`.` at the repository root).
+pub fn package_name(path: &Path, source: &str) -> String {
+    let declares_package = path
+        .extension()
+        .and_then(|ext| ext.to_str())
+        .is_some_and(|ext| matches!(ext, "go" | "java" | "kt" | "kts" | "scala"));
+    if declares_package {
+        let clause = source.lines().find_map(|line| {
+            line.trim()
+                .strip_prefix("package ")
+                .and_then(|rest| rest.split_whitespace().next())
+                .map(|name| name.trim_end_matches(';').to_string())
+        });
+        if let Some(clause) = clause {
+            return clause;
+        }
+    }
+    match path.parent() {
+        Some(parent) if !parent.as_os_str().is_empty() => {
+            parent.to_string_lossy().replace('\\', "/")
+        }
+        _ => ".".to_string(),
+    }
+}
+
+/// Whether a file holds tests, by the naming conventions of the supported languages.
+pub fn is_test_file(path: &Path) -> bool {
+    let in_test_dir = path.parent().is_some_and(|parent| {
+        parent.components().any(|component| {
+            matches!(
+                component.as_os_str().to_str(),
+                Some("test" | "tests" | "__tests__" | "spec")
+            )
+        })
+    });
+    let name = path
+        .file_name()
+        .map(|name| name.to_string_lossy().into_owned())
+        .unwrap_or_default();
+    let stem = name.split('.').next().unwrap_or_default();
+    in_test_dir
+        || name.contains(".test.")
+        || name.contains(".spec.")
+        || stem.starts_with("test_")
+        || stem.ends_with("_test")
+        || stem.ends_with("_tests")
+        || stem.ends_with("Test")
+        || stem.ends_with("Tests")
+}
+
+/// A test function, or a whole test file, and the identifiers it uses.
+#[derive(Debug)]
+struct TestSource {
+    /// Test function name, or the file name
+    name: String,
+    /// Test file, relative to the repository root
+    file_path: PathBuf,
+    /// 1-based first line
+    line: usize,
+    /// Identifiers appearing in the source
+    identifiers: HashSet<String>,
+}
+
+/// Tests of one file: its functions, and the file itself for tests outside them.
+#[derive(Debug)]
+struct TestFile {
+    /// Test functions in the file
+    functions: Vec<TestSource>,
+    /// The whole file
+    file: TestSource,
+}
+
+/// Declared type names and test sources of the repository.
+#[derive(Debug, Default)]
+struct RepositoryIndex {
+    /// Names of every class, struct, interface and enum
+    types: BTreeSet<String>,
+    /// Test files with their functions
+    tests: Vec<TestFile>,
+}
+
+/// Lookup methods for [`RepositoryIndex`].
+impl RepositoryIndex {
+    /// Index the working tree under `root`.
+    fn collect(root: &Path, workdir: &Path, structure: Option<&IncrementalCache>) -> Self {
+        let mut index = Self::default();
+        for path in source_files(root) {
+            let source = match FileReader::read_to_string(&path) {
+                Ok(source) => source,
+                Err(err) => {
+                    warn!("Skipping {}: {}", path.display(), err);
+                    continue;
+                }
+            };
+            let entities = entities_for(&path, &source, structure).unwrap_or_default();
+            let relative = path
+                .canonicalize()
+                .ok()
+                .and_then(|absolute| absolute.strip_prefix(workdir).ok().map(Path::to_path_buf))
+                .unwrap_or_else(|| path.clone());
+
+            if !is_test_file(&relative) {
+                index.types.extend(
+                    entities
+                        .iter()
+                        .filter(|entity| is_type(entity))
+                        .map(|entity| entity.name.clone()),
+                );
+                continue;
+            }
+            let functions = entities
+                .iter()
+                .filter(|entity| is_function(entity))
+                .map(|entity| TestSource {
+                    name: entity.name.clone(),
+                    file_path: relative.clone(),
+                    line: entity.line_range.map_or(1, |(start, _)| start),
+                    identifiers: identifiers(&entity.source_code),
+                })
+                .collect();
+            let file = TestSource {
+                name: relative
+                    .file_name()
+                    .map(|name| name.to_string_lossy().into_owned())
+                    .unwrap_or_default(),
+                file_path: relative.clone(),
+                line: 1,
+                identifiers: identifiers(&source),
+            };
+            index.tests.push(TestFile { functions, file });
+        }
+        index
+    }
+
+    /// Declared types mentioned by `entity`, other than itself.
+    fn types_in(&self, entity: &CodeEntity) -> Vec<String> {
+        let used = identifiers(&entity.source_code);
+        self.types
+            .iter()
+            .filter(|name| **name != entity.name && used.contains(name.as_str()))
+            .cloned()
+            .collect()
+    }
+
+    /// Tests mentioning `name`: the test functions that use it, or the test
+    /// file when it is only used outside named functions.
+    fn tests_mentioning(&self, name: &str) -> Vec<TestReference> {
+        let name = name.rsplit(['.', ':']).next().unwrap_or(name);
+        let reference = |test: &TestSource| TestReference {
+            name: test.name.clone(),
+            file_path: test.file_path.clone(),
+            line: test.line,
+        };
+        let mut references = Vec::new();
+        for test_file in &self.tests {
+            let before = references.len();
+            references.extend(
+                test_file
+                    .functions
+                    .iter()
+                    .filter(|test| test.name != name && test.identifiers.contains(name))
+                    .map(reference),
+            );
+            if references.len() == before && test_file.file.identifiers.contains(name) {
+                references.push(reference(&test_file.file));
+            }
+        }
+        references
+    }
+}
+
+/// Identifier-like tokens of `source`.
+fn identifiers(source: &str) -> HashSet<String> {
+    source
+        .split(|ch: char| !(ch.is_alphanumeric() || ch == '_' || ch == '$'))
+        .filter(|token| token.chars().next().is_some_and(|ch| !ch.is_ascii_digit()))
+        .map(str::to_string)
+        .collect()
+}
+
+/// Wrap a libgit2 error with the step that failed.
+pub(crate) fn git_error(step: &str, err: git2::Error) -> ValknutError {
+    ValknutError::internal(format!("Failed to {step}: {}", err.message()))
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use std::fs;
+    use tempfile::tempdir;
+
+    /// Stage everything and commit it on top of `HEAD`.
+    fn commit(repo: &Repository, message: &str) -> git2::Oid {
+        let mut index = repo.index().unwrap();
+        index
+            .add_all(["*"].iter(), git2::IndexAddOption::DEFAULT, None)
+            .unwrap();
+        index.write().unwrap();
+        let tree = repo.find_tree(index.write_tree().unwrap()).unwrap();
+        let signature = git2::Signature::now("Test", "test@example.com").unwrap();
+        let parent = repo.head().ok().and_then(|head| head.peel_to_commit().ok());
+        let parents: Vec<&git2::Commit> = parent.iter().collect();
+        repo.commit(
+            Some("HEAD"),
+            &signature,
+            &signature,
+            message,
+            &tree,
+            &parents,
+        )
+        .unwrap()
+    }
+
+    #[test]
+    fn summarizes_changed_symbols_since_the_merge_base() {
+        let tmp = tempdir().unwrap();
+        let repo = Repository::init(tmp.path()).unwrap();
+        let package = tmp.path().join("shapes");
+        fs::create_dir(&package).unwrap();
+        fs::write(
+            package.join("shapes.go"),
+            "package shapes\n\ntype Shape struct {\n\tSide int\n}\n\nfunc Area(s Shape) int {\n\treturn s.Side * s.Side\n}\n",
+        )
+        .unwrap();
+        fs::write(
+            package.join("shapes_test.go"),
+            "package shapes\n\nimport \"testing\"\n\nfunc TestArea(t *testing.T) {\n\tif Area(Shape{Side: 2}) != 4 {\n\t\tt.Fatal(\"area\")\n\t}\n}\n",
+        )
+        .unwrap();
+        let base = commit(&repo, "add shapes");
+        repo.branch("base", &repo.find_commit(base).unwrap(), false)
+            .unwrap();
+
+        fs::write(
+            package.join("shapes.go"),
+            "package shapes\n\ntype Shape struct {\n\tSide int\n}\n\nfunc Area(s Shape) int {\n\treturn s.Side * s.Side * 1\n}\n\nfunc Perimeter(s Shape) int {\n\treturn 4 * s.Side\n}\n",
+        )
+        .unwrap();
+        commit(&repo, "add perimeter");
+
+        let changed = changed_lines_since(tmp.path(), "base").unwrap();
+        let shapes = tmp.path().canonicalize().unwrap().join("shapes/shapes.go");
+        assert_eq!(changed.keys().collect::<Vec<_>>(), vec![&shapes]);
+        assert_eq!(
+            changed[&shapes].iter().copied().collect::<Vec<_>>(),
+            vec![8, 10, 11, 12, 13]
+        );
+
+        let context = review_context(tmp.path(), "base", None).unwrap();
+        assert_eq!(context.merge_base, base.to_string());
+        assert_eq!(context.files.len(), 1);
+        let file = &context.files[0];
+        assert_eq!(file.path, PathBuf::from("shapes/shapes.go"));
+        assert_eq!(file.package, "shapes");
+        assert_eq!(file.change, FileChange::Modified);
+
+        let symbols: Vec<_> = file
+            .symbols
+            .iter()
+            .map(|symbol| (symbol.name.as_str(), symbol.change))
+            .collect();
+        assert_eq!(
+            symbols,
+            vec![
+                ("Area", SymbolChange::Modified),
+                ("Perimeter", SymbolChange::Added)
+            ]
+        );
+        let perimeter = &file.symbols[1];
+        assert_eq!(perimeter.signature, "func Perimeter(s Shape) int");
+        assert_eq!(perimeter.types, vec!["Shape".to_string()]);
+        assert!(perimeter.tests.is_empty());
+        assert_eq!(
+            file.symbols[0].tests,
+            vec![TestReference {
+                name: "TestArea".to_string(),
+                file_path: PathBuf::from("shapes/shapes_test.go"),
+                line: 5,
+            }]
+        );
+
+        let markdown = context.to_markdown();
+        assert!(markdown.contains("| `shapes/shapes.go` | `shapes` | modified | +5 -1 |"));
+        assert!(markdown.contains("### `Perimeter` (Function, added, lines 11-13)"));
+        assert!(markdown.contains("```go\nfunc Perimeter(s Shape) int\n```"));
+        assert!(markdown.contains("- Tests: `TestArea` (`shapes/shapes_test.go:5`)"));
+    }
+
+    #[test]
+    fn reports_no_changes_on_the_base_itself() {
+        let tmp = tempdir().unwrap();
+        let repo = Repository::init(tmp.path()).unwrap();
+        fs::write(tmp.path().join("main.py"), "def run():\n    return 1\n").unwrap();
+        commit(&repo, "add run");
+
+        let context = review_context(tmp.path(), "HEAD", None).unwrap();
+        assert!(context.files.is_empty());
+        assert!(context
+            .to_markdown()
+            .contains("No changes between the merge base and HEAD."));
+    }
+
+    #[test]
+    fn rejects_unknown_revisions() {
+        let tmp = tempdir().unwrap();
+        let repo = Repository::init(tmp.path()).unwrap();
+        fs::write(tmp.path().join("main.py"), "x = 1\n").unwrap();
+        commit(&repo, "init");
+        assert!(review_context(tmp.path(), "no-such-ref", None).is_err());
+    }
+
+    #[test]
+    fn signatures_stop_at_the_body() {
+        assert_eq!(
+            signature("func Decode(v interface{}) error {\n\treturn nil\n}"),
+            "func Decode(v interface{}) error"
+        );
+        assert_eq!(
+            signature("@cached\ndef load(path,\n         mode='r'):\n    pass"),
+            "def load(path, mode='r'):"
+        );
+        assert_eq!(
+            signature("#[inline]\npub fn add(a: i32, b: i32) -> i32 {\n    a + b\n}"),
+            "pub fn add(a: i32, b: i32) -> i32"
+        );
+    }
+
+    #[test]
+    fn packages_and_test_files() {
+        assert_eq!(
+            package_name(Path::new("api/server.go"), "package api_v1\n"),
+            "api_v1"
+        );
+        assert_eq!(package_name(Path::new("src/app/main.py"), ""), "src/app");
+        assert_eq!(package_name(Path::new("main.py"), ""), ".");
+        assert!(is_test_file(Path::new("shapes/shapes_test.go")));
+        assert!(is_test_file(Path::new("tests/test_api.py")));
+        assert!(is_test_file(Path::new("web/button.spec.tsx")));
+        assert!(!is_test_file(Path::new("src/contest.py")));
+    }
+}
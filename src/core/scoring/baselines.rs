@@ -0,0 +1,178 @@
+//! Per-language complexity baselines.
+//!
+//! The same cyclomatic complexity means different things in different
+//! languages: Go's explicit `if err != nil` checks add a branch per fallible
+//! call, while Python reports errors through exceptions that add none. Each
+//! language gets a baseline factor, its typical function complexity relative
+//! to the cross-language norm, and normalized complexity is the raw value
+//! divided by that factor. The project health score and the per-file
+//! complexity report use normalized values; raw values are reported next to
+//! them.
+
+use std::collections::BTreeMap;
+
+use serde::{Deserialize, Serialize};
+
+use crate::detectors::complexity::ComplexityAnalysisResult;
+use crate::lang::registry::{detect_language_from_path, normalize_language_key};
+
+/// Built-in baseline factors by language key.
+///
+/// Starting points that reflect how far each language's error handling idiom
+/// inflates (explicit checks in Go and C) or deflates (exceptions in Python)
+/// branch counts; measure your own code base and tune them in `valknut.toml`.
+/// Languages not listed use 1.0.
+pub const DEFAULT_COMPLEXITY_BASELINES: &[(&str, f64)] = &[
+    ("go", 1.3),
+    ("c", 1.15),
+    ("cpp", 1.1),
+    ("rs", 1.1),
+    ("java", 1.0),
+    ("cs", 1.0),
+    ("js", 1.0),
+    ("ts", 0.95),
+    ("kt", 0.9),
+    ("py", 0.85),
+];
+
+/// Baseline complexity factor of each language.
+#[derive(Debug, Clone, PartialEq)]
+pub struct ComplexityBaselines {
+    factors: BTreeMap<String, f64>,
+}
+
+/// Built-in factors from [`DEFAULT_COMPLEXITY_BASELINES`].
+impl Default for ComplexityBaselines {
+    fn default() -> Self {
+        Self {
+            factors: DEFAULT_COMPLEXITY_BASELINES
+                .iter()
+                .map(|(language, factor)| (language.to_string(), *factor))
+                .collect(),
+        }
+    }
+}
+
+/// Construction and normalization methods for [`ComplexityBaselines`].
+impl ComplexityBaselines {
+    /// Built-in factors with `overrides` (keyed by language name or
+    /// extension, e.g. `python` or `py`) applied on top.
+    pub fn with_overrides(overrides: &BTreeMap<String, f64>) -> Self {
+        let mut baselines = Self::default();
+        for (language, factor) in overrides {
+            baselines.factors.insert(language_key(language), *factor);
+        }
+        baselines
+    }
+
+    /// Factor of `language`; 1.0 when it has none.
+    pub fn factor(&self, language: &str) -> f64 {
+        self.factors
+            .get(&language_key(language))
+            .copied()
+            .filter(|factor| *factor > 0.0)
+            .unwrap_or(1.0)
+    }
+
+    /// `value` corrected for the baseline of `language`.
+    pub fn normalize(&self, language: &str, value: f64) -> f64 {
+        value / self.factor(language)
+    }
+
+    /// Mean raw and normalized cyclomatic complexity over `results`, or
+    /// `None` when there are no results.
+    pub fn mean_cyclomatic(&self, results: &[ComplexityAnalysisResult]) -> Option<(f64, f64)> {
+        if results.is_empty() {
+            return None;
+        }
+        let (raw, normalized) = results
+            .iter()
+            .fold((0.0, 0.0), |(raw, normalized), result| {
+                let value = result.metrics.cyclomatic_complexity;
+                let language = detect_language_from_path(&result.file_path);
+                (raw + value, normalized + self.normalize(&language, value))
+            });
+        let count = results.len() as f64;
+        Some((raw / count, normalized / count))
+    }
+
+    /// Raw and normalized complexity of each file in `results`, keyed by path.
+    pub fn per_file(
+        &self,
+        results: &[ComplexityAnalysisResult],
+    ) -> BTreeMap<String, FileComplexity> {
+        let mut by_file: BTreeMap<&str, Vec<f64>> = BTreeMap::new();
+        for result in results {
+            by_file
+                .entry(result.file_path.as_str())
+                .or_default()
+                .push(result.metrics.cyclomatic_complexity);
+        }
+
+        by_file
+            .into_iter()
+            .map(|(path, values)| {
+                let language = detect_language_from_path(path);
+                let mean = values.iter().sum::<f64>() / values.len() as f64;
+                let complexity = FileComplexity {
+                    functions: values.len(),
+                    mean_cyclomatic: mean,
+                    normalized_mean_cyclomatic: self.normalize(&language, mean),
+                    baseline: self.factor(&language),
+                    language,
+                };
+                (path.to_string(), complexity)
+            })
+            .collect()
+    }
+}
+
+/// Cyclomatic complexity of one file, before and after baseline correction.
+#[derive(Debug, Clone, PartialEq, Serialize, Deserialize)]
+pub struct FileComplexity {
+    /// Language key of the file, e.g. `go` or `py`
+    pub language: String,
+    /// Functions measured
+    pub functions: usize,
+    /// Mean cyclomatic complexity as measured
+    pub mean_cyclomatic: f64,
+    /// Mean cyclomatic complexity divided by the language baseline
+    pub normalized_mean_cyclomatic: f64,
+    /// Baseline factor applied
+    pub baseline: f64,
+}
+
+/// Canonical key of a configured language name.
+fn language_key(language: &str) -> String {
+    normalize_language_key(language)
+        .map(str::to_string)
+        .unwrap_or_else(|| language.to_ascii_lowercase())
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn overrides_accept_language_names_and_extensions() {
+        let overrides = BTreeMap::from([("python".to_string(), 0.5), ("go".to_string(), 2.0)]);
+        let baselines = ComplexityBaselines::with_overrides(&overrides);
+        assert_eq!(baselines.factor("py"), 0.5);
+        assert_eq!(baselines.normalize("go", 10.0), 5.0);
+        assert_eq!(baselines.factor("rs"), 1.1);
+        assert_eq!(baselines.factor("unknown"), 1.0);
+    }
+
+    #[test]
+    fn go_complexity_counts_for_less_than_python_complexity() {
+        let baselines = ComplexityBaselines::default();
+        assert!(baselines.normalize("go", 10.0) < baselines.normalize("python", 10.0));
+    }
+
+    #[test]
+    fn non_positive_factors_are_ignored() {
+        let overrides = BTreeMap::from([("go".to_string(), 0.0)]);
+        let baselines = ComplexityBaselines::with_overrides(&overrides);
+        assert_eq!(baselines.normalize("go", 7.0), 7.0);
+    }
+}
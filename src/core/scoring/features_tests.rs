@@ -8,6 +8,7 @@ fn create_test_config() -> ScoringConfig {
         confidence_reporting: false,
         weights: WeightsConfig::default(),
         statistical_params: crate::core::config::StatisticalParams::default(),
+        complexity_baselines: Default::default(),
     }
 }
 
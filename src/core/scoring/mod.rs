@@ -2,13 +2,16 @@
 //!
 //! This module provides:
 //! - Bayesian normalization for feature statistics
+//! - Per-language complexity baselines
 //! - Feature scoring and prioritization
 //! - Variance confidence calculations
 
+pub mod baselines;
 pub mod bayesian;
 pub mod features;
 
 // Re-export main types
+pub use baselines::{ComplexityBaselines, FileComplexity};
 pub use bayesian::{BayesianNormalizer, FeaturePrior, FeatureStatistics, VarianceConfidence};
 pub use features::{
     FeatureNormalizer, FeatureScorer, NormalizationStatistics, Priority, ScoringResult,
@@ -0,0 +1,565 @@
+//! Semantic diff between two analysis snapshots.
+//!
+//! A snapshot is the JSON written by `valknut analyze --format json`. Its
+//! per-entity complexity results list every function, method and type that was
+//! analyzed, so they double as a symbol inventory. [`diff_snapshots`] matches
+//! symbols by identity (file and name) rather than by text:
+//!
+//! - a symbol whose name changed but whose body fingerprint (size, branching
+//!   and Halstead operator/operand totals) is unchanged is a rename, not a
+//!   removal plus an addition;
+//! - a same-named symbol that disappeared from one file and appeared in another
+//!   is a move;
+//! - a matched symbol whose kind or metrics changed is reported with the deltas.
+//!
+//! Snapshots do not record imports or signatures, so those are not compared.
+
+use std::collections::{BTreeMap, BTreeSet};
+use std::path::{Path, PathBuf};
+
+use serde::{Deserialize, Serialize};
+use serde_json::Value;
+
+use crate::core::errors::{Result, ValknutError};
+
+/// Symbols extracted from one analysis snapshot.
+#[derive(Debug, Clone, Default, PartialEq)]
+pub struct Snapshot {
+    /// Project root recorded in the snapshot, if any.
+    pub project_root: PathBuf,
+    /// Every analyzed entity, in snapshot order.
+    pub symbols: Vec<SnapshotSymbol>,
+}
+
+/// One function, method or type recorded in a snapshot.
+#[derive(Debug, Clone, PartialEq, Serialize, Deserialize)]
+pub struct SnapshotSymbol {
+    /// Symbol name as extracted by the language adapter.
+    pub name: String,
+    /// Entity kind, e.g. `function`, `method`, `class`.
+    pub kind: String,
+    /// File containing the symbol, relative to the project root.
+    pub file_path: String,
+    /// 1-based start line.
+    pub line: usize,
+    /// Metrics used to fingerprint the symbol body.
+    pub metrics: SymbolMetrics,
+}
+
+/// Body metrics compared between snapshots.
+#[derive(Debug, Clone, Copy, Default, PartialEq, Serialize, Deserialize)]
+pub struct SymbolMetrics {
+    /// Cyclomatic complexity.
+    pub cyclomatic: f64,
+    /// Cognitive complexity.
+    pub cognitive: f64,
+    /// Number of parameters.
+    pub parameters: f64,
+    /// Lines of code.
+    pub lines_of_code: f64,
+    /// Number of statements.
+    pub statements: f64,
+    /// Total operator occurrences (Halstead N1).
+    pub operators: f64,
+    /// Total operand occurrences (Halstead N2).
+    pub operands: f64,
+}
+
+/// Methods for [`SymbolMetrics`].
+impl SymbolMetrics {
+    /// Named metrics reported in deltas, in display order.
+    fn named(&self) -> [(&'static str, f64); 5] {
+        [
+            ("cyclomatic", self.cyclomatic),
+            ("cognitive", self.cognitive),
+            ("parameters", self.parameters),
+            ("lines_of_code", self.lines_of_code),
+            ("statements", self.statements),
+        ]
+    }
+
+    /// True when the fingerprint carries enough signal to identify a body.
+    fn is_identifying(&self) -> bool {
+        self.lines_of_code > 0.0 || self.operators + self.operands > 0.0
+    }
+}
+
+/// How a symbol changed between snapshots.
+#[derive(Debug, Clone, Copy, PartialEq, Eq, PartialOrd, Ord, Serialize, Deserialize)]
+#[serde(rename_all = "snake_case")]
+pub enum SymbolChangeKind {
+    /// Present only in the newer snapshot.
+    Added,
+    /// Present only in the older snapshot.
+    Removed,
+    /// Same body under a new name.
+    Renamed,
+    /// Same name, now in a different file.
+    Moved,
+    /// Same name and file, different entity kind.
+    KindChanged,
+    /// Same symbol with different metrics.
+    Modified,
+}
+
+/// Change in a single metric.
+#[derive(Debug, Clone, PartialEq, Serialize, Deserialize)]
+pub struct MetricDelta {
+    /// Metric name.
+    pub metric: String,
+    /// Value in the older snapshot.
+    pub before: f64,
+    /// Value in the newer snapshot.
+    pub after: f64,
+}
+
+/// One symbol-level change.
+#[derive(Debug, Clone, PartialEq, Serialize, Deserialize)]
+pub struct SymbolChange {
+    /// Kind of change.
+    pub change: SymbolChangeKind,
+    /// Symbol name (the new name for renames, the old one for removals).
+    pub name: String,
+    /// Entity kind.
+    pub kind: String,
+    /// File containing the symbol.
+    pub file_path: String,
+    /// 1-based start line.
+    pub line: usize,
+    /// Name in the older snapshot, for renames.
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub previous_name: Option<String>,
+    /// File in the older snapshot, for moves and cross-file renames.
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub previous_file: Option<String>,
+    /// Entity kind in the older snapshot, for kind changes.
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub previous_kind: Option<String>,
+    /// Metrics that differ between the snapshots.
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub metric_deltas: Vec<MetricDelta>,
+}
+
+/// Counts of each change kind.
+#[derive(Debug, Clone, Default, PartialEq, Eq, Serialize, Deserialize)]
+pub struct DiffSummary {
+    /// Files that gained their first symbols.
+    pub files_added: usize,
+    /// Files that lost all their symbols.
+    pub files_removed: usize,
+    /// Added symbols.
+    pub added: usize,
+    /// Removed symbols.
+    pub removed: usize,
+    /// Renamed symbols.
+    pub renamed: usize,
+    /// Moved symbols.
+    pub moved: usize,
+    /// Symbols whose kind changed.
+    pub kind_changed: usize,
+    /// Symbols whose metrics changed.
+    pub modified: usize,
+}
+
+/// Structural differences between two snapshots.
+#[derive(Debug, Clone, Default, PartialEq, Serialize, Deserialize)]
+pub struct SnapshotDiff {
+    /// Change counts.
+    pub summary: DiffSummary,
+    /// Files with symbols only in the newer snapshot.
+    pub files_added: Vec<String>,
+    /// Files with symbols only in the older snapshot.
+    pub files_removed: Vec<String>,
+    /// Symbol changes, sorted by file, line and name.
+    pub changes: Vec<SymbolChange>,
+}
+
+/// Query methods for [`SnapshotDiff`].
+impl SnapshotDiff {
+    /// True when the snapshots are structurally identical.
+    pub fn is_empty(&self) -> bool {
+        self.files_added.is_empty() && self.files_removed.is_empty() && self.changes.is_empty()
+    }
+}
+
+/// Load a snapshot written by `valknut analyze --format json`.
+pub fn load_snapshot(path: &Path) -> Result<Snapshot> {
+    let content = std::fs::read_to_string(path).map_err(ValknutError::map_io(format!(
+        "Failed to read snapshot {}",
+        path.display()
+    )))?;
+    let value: Value = serde_json::from_str(&content).map_err(ValknutError::map_json_parse(
+        format!("in {}", path.display()),
+    ))?;
+    Snapshot::from_value(&value)
+        .map_err(|e| e.with_context(format!("Invalid snapshot {}", path.display())))
+}
+
+/// Construction methods for [`Snapshot`].
+impl Snapshot {
+    /// Extract the symbol inventory from snapshot JSON.
+    ///
+    /// Accepts both plain analysis results and the `analysis_results` wrapper
+    /// written when an oracle plan is included.
+    pub fn from_value(value: &Value) -> Result<Self> {
+        let results = value.get("analysis_results").unwrap_or(value);
+        let detailed = results
+            .pointer("/passes/complexity/detailed_results")
+            .ok_or_else(|| {
+                ValknutError::validation(
+                    "Snapshot has no passes.complexity.detailed_results; \
+                     re-run `valknut analyze --format json` with complexity analysis enabled",
+                )
+            })?;
+        let raw: Vec<RawSymbol> = serde_json::from_value(detailed.clone())
+            .map_err(ValknutError::map_json_parse("complexity results"))?;
+
+        Ok(Self {
+            project_root: results
+                .get("project_root")
+                .and_then(Value::as_str)
+                .map(PathBuf::from)
+                .unwrap_or_default(),
+            symbols: raw.into_iter().map(RawSymbol::into_symbol).collect(),
+        })
+    }
+}
+
+/// Subset of a complexity result needed for diffing; unknown fields are ignored.
+#[derive(Deserialize)]
+struct RawSymbol {
+    entity_name: String,
+    #[serde(default)]
+    entity_type: String,
+    file_path: String,
+    #[serde(default)]
+    start_line: usize,
+    #[serde(default)]
+    metrics: RawMetrics,
+}
+
+/// Complexity metrics as serialized in a snapshot.
+#[derive(Default, Deserialize)]
+#[serde(default)]
+struct RawMetrics {
+    cyclomatic_complexity: f64,
+    cognitive_complexity: f64,
+    parameter_count: f64,
+    lines_of_code: f64,
+    statement_count: f64,
+    halstead: RawHalstead,
+}
+
+/// Halstead totals as serialized in a snapshot.
+#[derive(Default, Deserialize)]
+#[serde(default)]
+struct RawHalstead {
+    n_1: f64,
+    n_2: f64,
+}
+
+/// Conversion methods for [`RawSymbol`].
+impl RawSymbol {
+    /// Normalize into a [`SnapshotSymbol`].
+    fn into_symbol(self) -> SnapshotSymbol {
+        SnapshotSymbol {
+            name: self.entity_name,
+            kind: self.entity_type.to_ascii_lowercase(),
+            file_path: self.file_path.replace('\\', "/"),
+            line: self.start_line,
+            metrics: SymbolMetrics {
+                cyclomatic: self.metrics.cyclomatic_complexity,
+                cognitive: self.metrics.cognitive_complexity,
+                parameters: self.metrics.parameter_count,
+                lines_of_code: self.metrics.lines_of_code,
+                statements: self.metrics.statement_count,
+                operators: self.metrics.halstead.n_1,
+                operands: self.metrics.halstead.n_2,
+            },
+        }
+    }
+}
+
+/// Compute the structural differences from `before` to `after`.
+pub fn diff_snapshots(before: &Snapshot, after: &Snapshot) -> SnapshotDiff {
+    let mut changes = Vec::new();
+
+    // Pair symbols with the same file and name, in order of appearance.
+    let mut by_identity: BTreeMap<(&str, &str), Vec<&SnapshotSymbol>> = BTreeMap::new();
+    for symbol in &before.symbols {
+        by_identity
+            .entry((symbol.file_path.as_str(), symbol.name.as_str()))
+            .or_default()
+            .push(symbol);
+    }
+    for candidates in by_identity.values_mut() {
+        candidates.reverse();
+    }
+    let mut added = Vec::new();
+    for symbol in &after.symbols {
+        let previous = by_identity
+            .get_mut(&(symbol.file_path.as_str(), symbol.name.as_str()))
+            .and_then(Vec::pop);
+        match previous {
+            Some(previous) => changes.extend(compare_matched(previous, symbol)),
+            None => added.push(symbol),
+        }
+    }
+    let mut removed: Vec<&SnapshotSymbol> = by_identity.into_values().flatten().collect();
+    removed.sort_by(|a, b| (&a.file_path, a.line).cmp(&(&b.file_path, b.line)));
+
+    // Moves: same name and kind, different file; identical bodies win.
+    added.retain(|symbol| {
+        let candidate = take_best(
+            &mut removed,
+            |old| old.name == symbol.name && old.kind == symbol.kind,
+            symbol,
+        );
+        match candidate {
+            Some(old) => {
+                changes.push(SymbolChange {
+                    previous_file: Some(old.file_path.clone()),
+                    metric_deltas: metric_deltas(&old.metrics, &symbol.metrics),
+                    ..change(SymbolChangeKind::Moved, symbol)
+                });
+                false
+            }
+            None => true,
+        }
+    });
+
+    // Renames: same kind and identical body fingerprint; same-file matches win.
+    added.retain(|symbol| {
+        if !symbol.metrics.is_identifying() {
+            return true;
+        }
+        let same_body =
+            |old: &&SnapshotSymbol| old.kind == symbol.kind && old.metrics == symbol.metrics;
+        let position = removed
+            .iter()
+            .position(|old| same_body(old) && old.file_path == symbol.file_path)
+            .or_else(|| removed.iter().position(same_body));
+        match position {
+            Some(index) => {
+                let old = removed.remove(index);
+                changes.push(SymbolChange {
+                    previous_name: Some(old.name.clone()),
+                    previous_file: (old.file_path != symbol.file_path)
+                        .then(|| old.file_path.clone()),
+                    ..change(SymbolChangeKind::Renamed, symbol)
+                });
+                false
+            }
+            None => true,
+        }
+    });
+
+    changes.extend(
+        added
+            .into_iter()
+            .map(|symbol| change(SymbolChangeKind::Added, symbol)),
+    );
+    changes.extend(
+        removed
+            .into_iter()
+            .map(|symbol| change(SymbolChangeKind::Removed, symbol)),
+    );
+    changes.sort_by(|a, b| {
+        (&a.file_path, a.line, &a.name, a.change).cmp(&(&b.file_path, b.line, &b.name, b.change))
+    });
+
+    let files = |snapshot: &Snapshot| -> BTreeSet<String> {
+        snapshot
+            .symbols
+            .iter()
+            .map(|symbol| symbol.file_path.clone())
+            .collect()
+    };
+    let (before_files, after_files) = (files(before), files(after));
+    let files_added: Vec<String> = after_files.difference(&before_files).cloned().collect();
+    let files_removed: Vec<String> = before_files.difference(&after_files).cloned().collect();
+
+    let count = |kind: SymbolChangeKind| changes.iter().filter(|c| c.change == kind).count();
+    let summary = DiffSummary {
+        files_added: files_added.len(),
+        files_removed: files_removed.len(),
+        added: count(SymbolChangeKind::Added),
+        removed: count(SymbolChangeKind::Removed),
+        renamed: count(SymbolChangeKind::Renamed),
+        moved: count(SymbolChangeKind::Moved),
+        kind_changed: count(SymbolChangeKind::KindChanged),
+        modified: count(SymbolChangeKind::Modified),
+    };
+
+    SnapshotDiff {
+        summary,
+        files_added,
+        files_removed,
+        changes,
+    }
+}
+
+/// Report a kind or metric change between two symbols with the same identity.
+fn compare_matched(before: &SnapshotSymbol, after: &SnapshotSymbol) -> Option<SymbolChange> {
+    let metric_deltas = metric_deltas(&before.metrics, &after.metrics);
+    if before.kind != after.kind {
+        return Some(SymbolChange {
+            previous_kind: Some(before.kind.clone()),
+            metric_deltas,
+            ..change(SymbolChangeKind::KindChanged, after)
+        });
+    }
+    if metric_deltas.is_empty() {
+        return None;
+    }
+    Some(SymbolChange {
+        metric_deltas,
+        ..change(SymbolChangeKind::Modified, after)
+    })
+}
+
+/// Remove and return the best match for `symbol` among `pool`, preferring identical bodies.
+fn take_best<'a>(
+    pool: &mut Vec<&'a SnapshotSymbol>,
+    matches: impl Fn(&SnapshotSymbol) -> bool,
+    symbol: &SnapshotSymbol,
+) -> Option<&'a SnapshotSymbol> {
+    let index = pool
+        .iter()
+        .position(|old| matches(old) && old.metrics == symbol.metrics)
+        .or_else(|| pool.iter().position(|old| matches(old)))?;
+    Some(pool.remove(index))
+}
+
+/// Metrics whose values differ between `before` and `after`.
+fn metric_deltas(before: &SymbolMetrics, after: &SymbolMetrics) -> Vec<MetricDelta> {
+    before
+        .named()
+        .into_iter()
+        .zip(after.named())
+        .filter(|((_, old), (_, new))| old != new)
+        .map(|((metric, old), (_, new))| MetricDelta {
+            metric: metric.to_string(),
+            before: old,
+            after: new,
+        })
+        .collect()
+}
+
+/// A change record for `symbol` with no previous-state details.
+fn change(kind: SymbolChangeKind, symbol: &SnapshotSymbol) -> SymbolChange {
+    SymbolChange {
+        change: kind,
+        name: symbol.name.clone(),
+        kind: symbol.kind.clone(),
+        file_path: symbol.file_path.clone(),
+        line: symbol.line,
+        previous_name: None,
+        previous_file: None,
+        previous_kind: None,
+        metric_deltas: Vec::new(),
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use serde_json::json;
+
+    fn symbol(file: &str, name: &str, kind: &str, loc: f64, cyclomatic: f64) -> SnapshotSymbol {
+        SnapshotSymbol {
+            name: name.to_string(),
+            kind: kind.to_string(),
+            file_path: file.to_string(),
+            line: 1,
+            metrics: SymbolMetrics {
+                cyclomatic,
+                lines_of_code: loc,
+                ..SymbolMetrics::default()
+            },
+        }
+    }
+
+    fn snapshot(symbols: Vec<SnapshotSymbol>) -> Snapshot {
+        Snapshot {
+            project_root: PathBuf::new(),
+            symbols,
+        }
+    }
+
+    #[test]
+    fn pure_rename_is_not_an_add_and_remove() {
+        let before = snapshot(vec![symbol("a.py", "load", "function", 12.0, 3.0)]);
+        let after = snapshot(vec![symbol("a.py", "read_config", "function", 12.0, 3.0)]);
+
+        let diff = diff_snapshots(&before, &after);
+        assert_eq!(diff.changes.len(), 1);
+        let change = &diff.changes[0];
+        assert_eq!(change.change, SymbolChangeKind::Renamed);
+        assert_eq!(change.name, "read_config");
+        assert_eq!(change.previous_name.as_deref(), Some("load"));
+        assert_eq!(change.previous_file, None);
+    }
+
+    #[test]
+    fn reports_moves_kind_changes_and_metric_deltas() {
+        let before = snapshot(vec![
+            symbol("a.py", "helper", "function", 5.0, 1.0),
+            symbol("a.py", "Config", "class", 20.0, 2.0),
+            symbol("a.py", "run", "function", 10.0, 2.0),
+            symbol("a.py", "obsolete", "function", 7.0, 4.0),
+        ]);
+        let after = snapshot(vec![
+            symbol("b.py", "helper", "function", 5.0, 1.0),
+            symbol("a.py", "Config", "struct", 20.0, 2.0),
+            symbol("a.py", "run", "function", 14.0, 5.0),
+            symbol("a.py", "fresh", "function", 3.0, 1.0),
+        ]);
+
+        let diff = diff_snapshots(&before, &after);
+        let kind_of = |name: &str| {
+            diff.changes
+                .iter()
+                .find(|change| change.name == name)
+                .map(|change| change.change)
+        };
+        assert_eq!(kind_of("helper"), Some(SymbolChangeKind::Moved));
+        assert_eq!(kind_of("Config"), Some(SymbolChangeKind::KindChanged));
+        assert_eq!(kind_of("run"), Some(SymbolChangeKind::Modified));
+        assert_eq!(kind_of("fresh"), Some(SymbolChangeKind::Added));
+        assert_eq!(kind_of("obsolete"), Some(SymbolChangeKind::Removed));
+
+        let run = diff.changes.iter().find(|c| c.name == "run").unwrap();
+        let metrics: Vec<_> = run
+            .metric_deltas
+            .iter()
+            .map(|d| d.metric.as_str())
+            .collect();
+        assert_eq!(metrics, vec!["cyclomatic", "lines_of_code"]);
+        assert_eq!(diff.files_added, vec!["b.py".to_string()]);
+        assert_eq!(diff.summary.moved, 1);
+    }
+
+    #[test]
+    fn snapshot_reads_oracle_wrapped_results() {
+        let value = json!({
+            "analysis_results": {
+                "project_root": "/repo",
+                "passes": { "complexity": { "detailed_results": [{
+                    "entity_name": "run",
+                    "entity_type": "Function",
+                    "file_path": "src\\main.rs",
+                    "start_line": 4,
+                    "metrics": { "cyclomatic_complexity": 2.0, "halstead": { "n_1": 9.0 } }
+                }]}}
+            }
+        });
+
+        let snapshot = Snapshot::from_value(&value).unwrap();
+        assert_eq!(snapshot.project_root, PathBuf::from("/repo"));
+        assert_eq!(snapshot.symbols[0].kind, "function");
+        assert_eq!(snapshot.symbols[0].file_path, "src/main.rs");
+        assert_eq!(snapshot.symbols[0].metrics.operators, 9.0);
+        assert!(Snapshot::from_value(&json!({ "summary": {} })).is_err());
+    }
+}
@@ -0,0 +1,245 @@
+//! Symbol filters for scoping symbol listings.
+//!
+//! A [`SymbolFilter`] is written as a comma-separated list of criteria:
+//! `exported` keeps symbols visible outside their package or module,
+//! `documented` keeps symbols with a doc comment or docstring, and `changed`
+//! keeps symbols whose lines `HEAD` touched since it forked from a base
+//! revision (the `git diff base...HEAD` that `valknut review` summarises).
+//! A symbol must meet every criterion, so `exported,changed` is the public
+//! API a branch modified.
+//!
+//! Filters are applied through a [`SymbolScope`], which reads the branch diff
+//! once and each file's declarations on first use.
+
+use std::collections::{BTreeMap, BTreeSet, HashMap};
+use std::fmt;
+use std::path::{Path, PathBuf};
+
+use tracing::warn;
+
+use crate::core::errors::{Result, ValknutError};
+use crate::core::file_utils::FileReader;
+use crate::core::review::changed_lines_since;
+use crate::detectors::rules::{EntityFacts, FileAnalysis};
+
+/// Base revision `changed` compares against when none is given.
+pub const DEFAULT_CHANGED_BASE: &str = "main";
+
+/// One criterion of a [`SymbolFilter`].
+#[derive(Debug, Clone, Copy, PartialEq, Eq, PartialOrd, Ord, Hash)]
+pub enum SymbolCriterion {
+    /// Visible outside the declaring package or module
+    Exported,
+    /// Has a doc comment or docstring
+    Documented,
+    /// Overlaps a line changed on the current branch
+    Changed,
+}
+
+/// A conjunction of [`SymbolCriterion`]s.
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct SymbolFilter {
+    /// Criteria a symbol must all meet
+    criteria: BTreeSet<SymbolCriterion>,
+    /// Revision `changed` compares `HEAD` against
+    base: String,
+}
+
+/// A [`SymbolFilter`] bound to the files it is applied to.
+#[derive(Debug)]
+pub struct SymbolScope<'f> {
+    /// The filter being applied
+    filter: &'f SymbolFilter,
+    /// Touched lines per absolute file path, when `changed` is requested
+    changed: BTreeMap<PathBuf, BTreeSet<usize>>,
+    /// Declarations per file, read on first use
+    facts: HashMap<PathBuf, Vec<EntityFacts>>,
+}
+
+/// Keyword and parsing methods for [`SymbolCriterion`].
+impl SymbolCriterion {
+    /// Every criterion, in the order they are documented.
+    pub const ALL: [SymbolCriterion; 3] = [Self::Exported, Self::Documented, Self::Changed];
+
+    /// The keyword naming the criterion.
+    pub fn keyword(self) -> &'static str {
+        match self {
+            Self::Exported => "exported",
+            Self::Documented => "documented",
+            Self::Changed => "changed",
+        }
+    }
+}
+
+/// Construction and scoping methods for [`SymbolFilter`].
+impl SymbolFilter {
+    /// Compare `changed` against `base` instead of [`DEFAULT_CHANGED_BASE`].
+    pub fn with_base(mut self, base: impl Into<String>) -> Self {
+        self.base = base.into();
+        self
+    }
+
+    /// Returns true when the filter includes `criterion`.
+    pub fn contains(&self, criterion: SymbolCriterion) -> bool {
+        self.criteria.contains(&criterion)
+    }
+
+    /// Revision `changed` compares against.
+    pub fn base(&self) -> &str {
+        &self.base
+    }
+
+    /// Bind the filter to the files under `root`, reading the branch diff
+    /// when `changed` is requested.
+    pub fn scope(&self, root: &Path) -> Result<SymbolScope<'_>> {
+        let changed = if self.contains(SymbolCriterion::Changed) {
+            changed_lines_since(root, &self.base)?
+        } else {
+            BTreeMap::new()
+        };
+        Ok(SymbolScope {
+            filter: self,
+            changed,
+            facts: HashMap::new(),
+        })
+    }
+}
+
+/// Filtering methods for [`SymbolScope`].
+impl SymbolScope<'_> {
+    /// Returns true when the symbol `name`, declared in `file` on lines
+    /// `start_line..=end_line`, meets every criterion.
+    pub fn keeps(&mut self, file: &Path, name: &str, start_line: usize, end_line: usize) -> bool {
+        if self.filter.contains(SymbolCriterion::Changed) {
+            let touched = file
+                .canonicalize()
+                .ok()
+                .and_then(|path| self.changed.get(&path))
+                .is_some_and(|lines| lines.range(start_line..=end_line).next().is_some());
+            if !touched {
+                return false;
+            }
+        }
+
+        let wants_exported = self.filter.contains(SymbolCriterion::Exported);
+        let wants_documented = self.filter.contains(SymbolCriterion::Documented);
+        if !wants_exported && !wants_documented {
+            return true;
+        }
+        let facts = self.facts_for(file);
+        let Some(entity) = facts
+            .iter()
+            .find(|entity| entity.name == name && entity.start_line == start_line)
+            .or_else(|| facts.iter().find(|entity| entity.name == name))
+        else {
+            return false;
+        };
+        (!wants_exported || entity.exported) && (!wants_documented || entity.documented)
+    }
+
+    /// Declarations of `file`, read and cached on first use.
+    fn facts_for(&mut self, file: &Path) -> &[EntityFacts] {
+        self.facts.entry(file.to_path_buf()).or_insert_with(|| {
+            match FileReader::read_to_string(file) {
+                Ok(source) => {
+                    FileAnalysis::from_source(&file.to_string_lossy(), &source, &[]).entities
+                }
+                Err(err) => {
+                    warn!("Cannot filter symbols of {}: {}", file.display(), err);
+                    Vec::new()
+                }
+            }
+        })
+    }
+}
+
+/// Parsing for [`SymbolFilter`].
+impl std::str::FromStr for SymbolFilter {
+    type Err = ValknutError;
+
+    /// Parse a comma-separated list such as `exported,changed`.
+    fn from_str(input: &str) -> Result<Self> {
+        let mut criteria = BTreeSet::new();
+        for keyword in input.split(',').map(str::trim) {
+            let criterion = SymbolCriterion::ALL
+                .into_iter()
+                .find(|criterion| criterion.keyword() == keyword)
+                .ok_or_else(|| {
+                    ValknutError::validation(format!(
+                        "invalid symbol filter '{keyword}': expected a comma-separated list of exported, documented or changed"
+                    ))
+                })?;
+            criteria.insert(criterion);
+        }
+        Ok(Self {
+            criteria,
+            base: DEFAULT_CHANGED_BASE.to_string(),
+        })
+    }
+}
+
+/// Display implementation for [`SymbolFilter`].
+impl fmt::Display for SymbolFilter {
+    /// Formats the filter as it is written on the command line.
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        let keywords: Vec<&str> = self.criteria.iter().map(|c| c.keyword()).collect();
+        f.write_str(&keywords.join(","))
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use std::fs;
+    use tempfile::tempdir;
+
+    const GO_SOURCE: &str = "package shapes
+
+// Area returns the area of a square.
+func Area(side int) int {
+\treturn side * side
+}
+
+func Perimeter(side int) int {
+\treturn 4 * side
+}
+
+func scale(side int) int {
+\treturn 2 * side
+}
+";
+
+    #[test]
+    fn filters_parse_from_comma_separated_keywords() {
+        let filter: SymbolFilter = "changed, exported".parse().unwrap();
+        assert!(filter.contains(SymbolCriterion::Exported));
+        assert!(filter.contains(SymbolCriterion::Changed));
+        assert!(!filter.contains(SymbolCriterion::Documented));
+        assert_eq!(filter.to_string(), "exported,changed");
+        assert_eq!(filter.base(), DEFAULT_CHANGED_BASE);
+        assert_eq!(filter.with_base("develop").base(), "develop");
+
+        assert!("exported,public".parse::<SymbolFilter>().is_err());
+        assert!("".parse::<SymbolFilter>().is_err());
+    }
+
+    #[test]
+    fn exported_and_documented_criteria_combine() {
+        let tmp = tempdir().unwrap();
+        let file = tmp.path().join("shapes.go");
+        fs::write(&file, GO_SOURCE).unwrap();
+
+        let kept = |spec: &str| {
+            let filter: SymbolFilter = spec.parse().unwrap();
+            let mut scope = filter.scope(tmp.path()).unwrap();
+            [("Area", 4, 6), ("Perimeter", 8, 10), ("scale", 12, 14)]
+                .into_iter()
+                .filter(|(name, start, end)| scope.keeps(&file, name, *start, *end))
+                .map(|(name, _, _)| name)
+                .collect::<Vec<_>>()
+        };
+        assert_eq!(kept("exported"), vec!["Area", "Perimeter"]);
+        assert_eq!(kept("documented"), vec!["Area"]);
+        assert_eq!(kept("exported,documented"), vec!["Area"]);
+    }
+}
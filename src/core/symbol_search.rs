@@ -0,0 +1,363 @@
+//! Regex search over symbol names.
+//!
+//! [`search_symbols`] parses every supported source file under a root with its
+//! language adapter and returns the entities (functions, methods, types, ...)
+//! whose name matches a pattern, together with their location and a one-line
+//! signature. Patterns use RE2 syntax, the same dialect as Go's `regexp`
+//! package, and match anywhere in the name unless anchored with `^`/`$`.
+//!
+//! Results are ordered by file, line and name and returned one page at a
+//! time. Each page carries an opaque cursor for the next one; a cursor is only
+//! valid for the query that produced it.
+
+use std::path::{Path, PathBuf};
+
+use ignore::WalkBuilder;
+use regex::{Regex, RegexBuilder};
+use serde::{Deserialize, Serialize};
+use tracing::warn;
+use xxhash_rust::xxh3::xxh3_64;
+
+use crate::core::errors::{Result, ValknutError};
+use crate::core::featureset::CodeEntity;
+use crate::core::file_utils::FileReader;
+use crate::core::pipeline::discovery::IGNORE_FILE_NAME;
+use crate::lang::registry::adapter_for_file;
+
+/// Page size used when the caller does not ask for one.
+pub const DEFAULT_PAGE_SIZE: usize = 100;
+
+/// Largest page a single call may return.
+pub const MAX_PAGE_SIZE: usize = 1000;
+
+/// Upper bound on the compiled size of a user-supplied pattern.
+const REGEX_SIZE_LIMIT: usize = 1 << 20;
+
+/// Longest signature reported before truncation, in characters.
+const MAX_SIGNATURE_CHARS: usize = 200;
+
+/// A symbol name search.
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct SymbolQuery {
+    /// RE2-syntax pattern matched against symbol names.
+    pub pattern: String,
+    /// Match names regardless of letter case.
+    pub case_insensitive: bool,
+}
+
+/// A symbol whose name matched the query.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct SymbolMatch {
+    /// Symbol name as declared.
+    pub name: String,
+    /// Entity kind reported by the language adapter (`Function`, `Struct`, ...).
+    pub kind: String,
+    /// File declaring the symbol, relative to the search root.
+    pub file_path: PathBuf,
+    /// 1-based first line of the declaration.
+    pub start_line: usize,
+    /// 1-based last line of the declaration.
+    pub end_line: usize,
+    /// Declaration header with whitespace collapsed, e.g. `func Area(r float64) float64`.
+    pub signature: Option<String>,
+}
+
+/// One page of search results.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct SymbolPage {
+    /// Matches on this page.
+    pub matches: Vec<SymbolMatch>,
+    /// Number of matches across all pages.
+    pub total: usize,
+    /// Cursor for the following page, absent on the last page.
+    pub next_cursor: Option<String>,
+}
+
+/// Search symbol names under `root` (a directory or a single file).
+///
+/// `cursor` continues a previous search and must come from a page returned for
+/// the same query. `limit` is clamped to `1..=MAX_PAGE_SIZE`.
+pub fn search_symbols(
+    root: &Path,
+    query: &SymbolQuery,
+    cursor: Option<&str>,
+    limit: usize,
+) -> Result<SymbolPage> {
+    search_symbols_where(root, query, cursor, limit, |_| true)
+}
+
+/// [`search_symbols`], keeping only the matches `keep` accepts. Pages, totals
+/// and cursors count the kept matches, so `keep` must not change between the
+/// pages of one search.
+pub fn search_symbols_where(
+    root: &Path,
+    query: &SymbolQuery,
+    cursor: Option<&str>,
+    limit: usize,
+    keep: impl FnMut(&SymbolMatch) -> bool,
+) -> Result<SymbolPage> {
+    let regex = compile(query)?;
+    let offset = match cursor {
+        Some(token) => decode_cursor(token, query)?,
+        None => 0,
+    };
+    if !root.exists() {
+        return Err(ValknutError::validation(format!(
+            "Path does not exist: {}",
+            root.display()
+        )));
+    }
+
+    let mut matches = collect_matches(root, &regex);
+    matches.retain(keep);
+    matches.sort_by(|a, b| {
+        (&a.file_path, a.start_line, &a.name).cmp(&(&b.file_path, b.start_line, &b.name))
+    });
+
+    let total = matches.len();
+    let limit = limit.clamp(1, MAX_PAGE_SIZE);
+    let end = offset.saturating_add(limit).min(total);
+    let page = matches.drain(offset.min(total)..end).collect::<Vec<_>>();
+
+    Ok(SymbolPage {
+        matches: page,
+        total,
+        next_cursor: (end < total).then(|| encode_cursor(end, query)),
+    })
+}
+
+/// Compile the query pattern, reporting syntax errors as validation errors.
+fn compile(query: &SymbolQuery) -> Result<Regex> {
+    if query.pattern.is_empty() {
+        return Err(ValknutError::validation("Pattern must not be empty"));
+    }
+    RegexBuilder::new(&query.pattern)
+        .case_insensitive(query.case_insensitive)
+        .size_limit(REGEX_SIZE_LIMIT)
+        .build()
+        .map_err(|e| ValknutError::validation(format!("Invalid pattern: {e}")))
+}
+
+/// Walk `root` and collect every entity whose name matches `regex`.
+fn collect_matches(root: &Path, regex: &Regex) -> Vec<SymbolMatch> {
+    let mut builder = WalkBuilder::new(root);
+    builder.add_custom_ignore_filename(IGNORE_FILE_NAME);
+
+    let mut matches = Vec::new();
+    for entry in builder.build() {
+        let entry = match entry {
+            Ok(entry) => entry,
+            Err(err) => {
+                warn!("Failed to walk directory: {err}");
+                continue;
+            }
+        };
+        let path = entry.path();
+        if !path.is_file() || !FileReader::is_code_file(path) {
+            continue;
+        }
+        let Ok(mut adapter) = adapter_for_file(path) else {
+            continue;
+        };
+        let source = match FileReader::read_to_string(path) {
+            Ok(source) => source,
+            Err(err) => {
+                warn!("Skipping {}: {}", path.display(), err);
+                continue;
+            }
+        };
+        let entities = match adapter.extract_code_entities(&source, &path.to_string_lossy()) {
+            Ok(entities) => entities,
+            Err(err) => {
+                warn!("Failed to parse {}: {}", path.display(), err);
+                continue;
+            }
+        };
+
+        let display_path = match path.strip_prefix(root) {
+            Ok(relative) if !relative.as_os_str().is_empty() => relative.to_path_buf(),
+            _ => path.to_path_buf(),
+        };
+        matches.extend(
+            entities
+                .iter()
+                .filter(|entity| regex.is_match(&entity.name))
+                .map(|entity| to_match(entity, &display_path)),
+        );
+    }
+    matches
+}
+
+/// Convert a matching entity into a search result.
+fn to_match(entity: &CodeEntity, file_path: &Path) -> SymbolMatch {
+    let (start_line, end_line) = entity.line_range.unwrap_or((0, 0));
+    SymbolMatch {
+        name: entity.name.clone(),
+        kind: entity.entity_type.clone(),
+        file_path: file_path.to_path_buf(),
+        start_line,
+        end_line,
+        signature: signature_of(entity),
+    }
+}
+
+/// Signature recorded by the adapter, or the declaration header from source.
+///
+/// The header runs up to the body's opening brace, or up to the end of the
+/// line that opens an indented block (`:`), whichever comes first.
+pub(crate) fn signature_of(entity: &CodeEntity) -> Option<String> {
+    if let Some(signature) = entity
+        .properties
+        .get("signature")
+        .and_then(|value| value.as_str())
+    {
+        return Some(signature.to_string());
+    }
+
+    let mut header = String::new();
+    for line in entity.source_code.lines() {
+        if let Some(brace) = line.find('{') {
+            header.push_str(&line[..brace]);
+            break;
+        }
+        let line = line.trim_end();
+        if let Some(opening) = line.strip_suffix(':') {
+            header.push_str(opening);
+            break;
+        }
+        header.push_str(line);
+        header.push(' ');
+    }
+    let header = header.split_whitespace().collect::<Vec<_>>().join(" ");
+    if header.is_empty() {
+        return None;
+    }
+    Some(match header.char_indices().nth(MAX_SIGNATURE_CHARS) {
+        Some((cut, _)) => format!("{}...", &header[..cut]),
+        None => header.to_string(),
+    })
+}
+
+/// Fingerprint tying a cursor to the query that produced it.
+fn query_fingerprint(query: &SymbolQuery) -> u64 {
+    let flag = if query.case_insensitive { "i" } else { "" };
+    xxh3_64(format!("{flag}/{}", query.pattern).as_bytes())
+}
+
+/// Encode the offset of the next page as an opaque token.
+fn encode_cursor(offset: usize, query: &SymbolQuery) -> String {
+    format!("{offset:x}.{:016x}", query_fingerprint(query))
+}
+
+/// Decode a cursor, rejecting malformed tokens and tokens from other queries.
+fn decode_cursor(token: &str, query: &SymbolQuery) -> Result<usize> {
+    let invalid = || ValknutError::validation(format!("Invalid cursor: {token}"));
+    let (offset, fingerprint) = token.split_once('.').ok_or_else(invalid)?;
+    let offset = usize::from_str_radix(offset, 16).map_err(|_| invalid())?;
+    let fingerprint = u64::from_str_radix(fingerprint, 16).map_err(|_| invalid())?;
+    if fingerprint != query_fingerprint(query) {
+        return Err(ValknutError::validation(
+            "Cursor does not belong to this query",
+        ));
+    }
+    Ok(offset)
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use std::fs;
+    use tempfile::tempdir;
+
+    fn query(pattern: &str, case_insensitive: bool) -> SymbolQuery {
+        SymbolQuery {
+            pattern: pattern.to_string(),
+            case_insensitive,
+        }
+    }
+
+    fn write_handlers(root: &Path) {
+        fs::write(
+            root.join("server.go"),
+            "package server\n\nfunc LoginHandler(w Writer, r *Request) error {\n\treturn nil\n}\n\nfunc logoutHandler() {}\n\nfunc helper() {}\n",
+        )
+        .unwrap();
+        fs::write(
+            root.join("api.py"),
+            "class UploadHandler:\n    def handle(self):\n        pass\n",
+        )
+        .unwrap();
+    }
+
+    #[test]
+    fn matches_names_with_locations_and_signatures() {
+        let tmp = tempdir().unwrap();
+        write_handlers(tmp.path());
+
+        let page = search_symbols(tmp.path(), &query(".*Handler", false), None, 10).unwrap();
+        let names: Vec<_> = page.matches.iter().map(|m| m.name.as_str()).collect();
+        assert_eq!(
+            names,
+            vec!["UploadHandler", "LoginHandler", "logoutHandler"]
+        );
+        assert_eq!(page.total, 3);
+        assert!(page.next_cursor.is_none());
+
+        let login = &page.matches[1];
+        assert_eq!(login.file_path, PathBuf::from("server.go"));
+        assert_eq!((login.start_line, login.end_line), (3, 5));
+        assert_eq!(
+            login.signature.as_deref(),
+            Some("func LoginHandler(w Writer, r *Request) error")
+        );
+        assert_eq!(
+            page.matches[0].signature.as_deref(),
+            Some("class UploadHandler")
+        );
+    }
+
+    #[test]
+    fn case_insensitive_flag_and_anchors() {
+        let tmp = tempdir().unwrap();
+        write_handlers(tmp.path());
+
+        let sensitive = search_symbols(tmp.path(), &query("^login", false), None, 10).unwrap();
+        assert_eq!(sensitive.total, 0);
+
+        let insensitive = search_symbols(tmp.path(), &query("^login", true), None, 10).unwrap();
+        assert_eq!(insensitive.total, 1);
+        assert_eq!(insensitive.matches[0].name, "LoginHandler");
+    }
+
+    #[test]
+    fn cursors_page_through_results() {
+        let tmp = tempdir().unwrap();
+        write_handlers(tmp.path());
+        let handlers = query("Handler$", false);
+
+        let first = search_symbols(tmp.path(), &handlers, None, 2).unwrap();
+        assert_eq!(first.matches.len(), 2);
+        let cursor = first.next_cursor.expect("more results");
+
+        let second = search_symbols(tmp.path(), &handlers, Some(&cursor), 2).unwrap();
+        assert_eq!(second.matches.len(), 1);
+        assert_eq!(second.matches[0].name, "logoutHandler");
+        assert_eq!(second.total, 3);
+        assert!(second.next_cursor.is_none());
+
+        let other = query("Handler$", true);
+        assert!(search_symbols(tmp.path(), &other, Some(&cursor), 2).is_err());
+        assert!(search_symbols(tmp.path(), &handlers, Some("not-a-cursor"), 2).is_err());
+    }
+
+    #[test]
+    fn rejects_invalid_patterns() {
+        let tmp = tempdir().unwrap();
+        for pattern in ["", "(unclosed", "a{2,1}"] {
+            assert!(
+                search_symbols(tmp.path(), &query(pattern, false), None, 10).is_err(),
+                "`{pattern}` should be rejected"
+            );
+        }
+    }
+}
@@ -0,0 +1,721 @@
+//! Token estimation and context budgeting for LLM consumers.
+//!
+//! [`estimate_tokens`] approximates BPE tokenizers such as tiktoken's
+//! `cl100k_base` without shipping a vocabulary: text is pre-split the way the
+//! tokenizer's regex splits it (letter runs, digit groups of up to three,
+//! punctuation runs, whitespace) and each piece is charged according to its
+//! length. On source code this tracks real token counts closely enough for
+//! budgeting, and it is orders of magnitude cheaper than full encoding.
+//!
+//! [`ContextBudget`] ranks candidate files by relevance, reference count and
+//! recency and trims them to fit a token budget using a pluggable
+//! [`TrimStrategy`].
+//!
+//! [`ModelTokenizer`] adapts the estimate to the tokenizer of a named
+//! Anthropic, OpenAI or Gemini model, and [`plan_token_budget`] uses it to
+//! pick the files of a project that fit one model's context window.
+
+use std::collections::HashMap;
+use std::path::Path;
+use std::time::{SystemTime, UNIX_EPOCH};
+
+use serde::{Deserialize, Serialize};
+use tracing::warn;
+use tree_sitter::Node;
+
+use crate::core::errors::{Result, ValknutError};
+use crate::core::file_utils::FileReader;
+use crate::core::public_api::{file_reference_counts, repository_files};
+use crate::lang::adapter_for_file;
+
+/// Average number of characters a BPE tokenizer merges into one token inside a word.
+const CHARS_PER_WORD_TOKEN: usize = 4;
+
+/// Estimate the number of BPE tokens in `text`.
+pub fn estimate_tokens(text: &str) -> usize {
+    let mut tokens = 0;
+    let mut chars = text.chars().peekable();
+
+    while let Some(ch) = chars.next() {
+        if ch.is_alphabetic() || ch == '_' {
+            let mut len = 1;
+            while chars.next_if(|c| c.is_alphabetic() || *c == '_').is_some() {
+                len += 1;
+            }
+            tokens += len.div_ceil(CHARS_PER_WORD_TOKEN);
+        } else if ch.is_ascii_digit() {
+            let mut len = 1;
+            while chars.next_if(char::is_ascii_digit).is_some() {
+                len += 1;
+            }
+            tokens += len.div_ceil(3);
+        } else if ch.is_whitespace() {
+            // A single space is merged into the following word; newlines and
+            // indentation runs cost one token each.
+            let mut run = String::from(ch);
+            while let Some(next) = chars.next_if(|c| c.is_whitespace()) {
+                run.push(next);
+            }
+            if run != " " {
+                tokens += 1;
+            }
+        } else {
+            let mut len = 1;
+            while chars
+                .next_if(|c| !c.is_alphanumeric() && !c.is_whitespace() && *c != '_')
+                .is_some()
+            {
+                len += 1;
+            }
+            tokens += len.div_ceil(2);
+        }
+    }
+
+    tokens
+}
+
+/// How files that do not fit the remaining budget are handled.
+#[derive(Debug, Clone, Copy, Default, PartialEq, Eq, Serialize, Deserialize)]
+#[serde(rename_all = "snake_case")]
+pub enum TrimStrategy {
+    /// Skip files that do not fit; included files are always complete.
+    #[default]
+    DropFiles,
+    /// Replace function bodies with placeholders, keeping signatures, before dropping a file.
+    TruncateBodies,
+}
+
+/// A file offered to the budgeter.
+#[derive(Debug, Clone)]
+pub struct ContextFile {
+    /// Path used for display and language detection.
+    pub path: String,
+    /// Full file contents.
+    pub content: String,
+    /// Query relevance; higher is better (see [`relevance_score`]).
+    pub relevance: f64,
+    /// How many other files mention the file's exported symbols.
+    pub references: usize,
+    /// Last modification time, used to prefer recently touched files.
+    pub modified: Option<SystemTime>,
+}
+
+/// A file selected for the context window.
+#[derive(Debug, Clone, Serialize, Deserialize)]
+pub struct BudgetedFile {
+    /// Path of the file.
+    pub path: String,
+    /// Estimated tokens of the included content.
+    pub tokens: usize,
+    /// Estimated tokens of the original, untrimmed file.
+    pub original_tokens: usize,
+    /// Whether function bodies were elided.
+    pub truncated: bool,
+    /// Content that fits in the budget.
+    pub content: String,
+}
+
+/// Result of fitting files into a budget.
+#[derive(Debug, Clone, Default, Serialize, Deserialize)]
+pub struct BudgetedContext {
+    /// Files included, in ranking order.
+    pub included: Vec<BudgetedFile>,
+    /// Paths that did not fit, in ranking order.
+    pub dropped: Vec<String>,
+    /// Sum of included tokens.
+    pub total_tokens: usize,
+    /// Budget the context was fitted to.
+    pub max_tokens: usize,
+}
+
+/// Token budget for a context window.
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Serialize, Deserialize)]
+pub struct ContextBudget {
+    /// Maximum number of tokens to emit.
+    pub max_tokens: usize,
+    /// Strategy used when a file does not fit whole.
+    pub strategy: TrimStrategy,
+}
+
+/// Ranking and trimming methods for [`ContextBudget`].
+impl ContextBudget {
+    /// Create a budget with the given limit and strategy.
+    pub fn new(max_tokens: usize, strategy: TrimStrategy) -> Self {
+        Self {
+            max_tokens,
+            strategy,
+        }
+    }
+
+    /// Rank files (relevance, then references, then recency, then path) and
+    /// fit as many as possible.
+    pub fn fit(&self, files: Vec<ContextFile>) -> BudgetedContext {
+        self.fit_with(files, estimate_tokens)
+    }
+
+    /// [`ContextBudget::fit`], counting tokens with `count`.
+    pub fn fit_with(
+        &self,
+        mut files: Vec<ContextFile>,
+        count: impl Fn(&str) -> usize,
+    ) -> BudgetedContext {
+        files.sort_by(|a, b| {
+            b.relevance
+                .total_cmp(&a.relevance)
+                .then_with(|| b.references.cmp(&a.references))
+                .then_with(|| b.modified.cmp(&a.modified))
+                .then_with(|| a.path.cmp(&b.path))
+        });
+
+        let mut context = BudgetedContext {
+            max_tokens: self.max_tokens,
+            ..BudgetedContext::default()
+        };
+
+        for file in files {
+            let remaining = self.max_tokens - context.total_tokens;
+            let original_tokens = count(&file.content);
+
+            let selected = if original_tokens <= remaining {
+                Some((file.content, original_tokens, false))
+            } else if self.strategy == TrimStrategy::TruncateBodies {
+                elide_function_bodies(&file.path, &file.content)
+                    .map(|outline| {
+                        let tokens = count(&outline);
+                        (outline, tokens, true)
+                    })
+                    .filter(|(_, tokens, _)| *tokens <= remaining)
+            } else {
+                None
+            };
+
+            match selected {
+                Some((content, tokens, truncated)) => {
+                    context.total_tokens += tokens;
+                    context.included.push(BudgetedFile {
+                        path: file.path,
+                        tokens,
+                        original_tokens,
+                        truncated,
+                        content,
+                    });
+                }
+                None => context.dropped.push(file.path),
+            }
+        }
+
+        context
+    }
+}
+
+/// Score how relevant a file is to a free-text query.
+///
+/// Every path match of a query term earns three points, while content matches earn
+/// diminishing (logarithmic) credit; an empty query scores every file as zero.
+pub fn relevance_score(query: &str, path: &str, content: &str) -> f64 {
+    let path = path.to_lowercase();
+    let content = content.to_lowercase();
+    query
+        .split(|c: char| !c.is_alphanumeric() && c != '_')
+        .filter(|term| term.len() > 1)
+        .map(str::to_lowercase)
+        .map(|term| {
+            let in_path = path.matches(term.as_str()).count() as f64;
+            let in_content = content.matches(term.as_str()).count() as f64;
+            3.0 * in_path + in_content.ln_1p()
+        })
+        .sum()
+}
+
+/// Vendor whose tokenizer a model uses.
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Serialize, Deserialize)]
+#[serde(rename_all = "snake_case")]
+pub enum ModelFamily {
+    /// Claude models.
+    Anthropic,
+    /// GPT and `o`-series models.
+    #[serde(rename = "openai")]
+    OpenAi,
+    /// Gemini models.
+    Gemini,
+}
+
+/// Vendor name as written in prose.
+impl std::fmt::Display for ModelFamily {
+    fn fmt(&self, f: &mut std::fmt::Formatter<'_>) -> std::fmt::Result {
+        f.write_str(match self {
+            ModelFamily::Anthropic => "Anthropic",
+            ModelFamily::OpenAi => "OpenAI",
+            ModelFamily::Gemini => "Gemini",
+        })
+    }
+}
+
+/// A model name prefix and what is known about the model's tokenizer.
+struct ModelProfile {
+    /// Prefix of the model names the profile covers.
+    prefix: &'static str,
+    /// Vendor of the model.
+    family: ModelFamily,
+    /// Tokens the model's tokenizer produces per [`estimate_tokens`] token.
+    tokens_per_estimate: f64,
+    /// Context window in tokens.
+    context_window: usize,
+}
+
+/// Known models, longest prefixes first within each vendor. Claude's
+/// tokenizer splits source code into noticeably more tokens than
+/// `cl100k_base`, while `o200k_base` (GPT-4o and later) and Gemini's
+/// SentencePiece vocabulary merge slightly more.
+const MODEL_PROFILES: &[ModelProfile] = &[
+    ModelProfile {
+        prefix: "claude",
+        family: ModelFamily::Anthropic,
+        tokens_per_estimate: 1.15,
+        context_window: 200_000,
+    },
+    ModelProfile {
+        prefix: "gpt-4.1",
+        family: ModelFamily::OpenAi,
+        tokens_per_estimate: 0.95,
+        context_window: 1_047_576,
+    },
+    ModelProfile {
+        prefix: "gpt-4o",
+        family: ModelFamily::OpenAi,
+        tokens_per_estimate: 0.95,
+        context_window: 128_000,
+    },
+    ModelProfile {
+        prefix: "gpt-4-turbo",
+        family: ModelFamily::OpenAi,
+        tokens_per_estimate: 1.0,
+        context_window: 128_000,
+    },
+    ModelProfile {
+        prefix: "gpt-4",
+        family: ModelFamily::OpenAi,
+        tokens_per_estimate: 1.0,
+        context_window: 8_192,
+    },
+    ModelProfile {
+        prefix: "gpt-3.5-turbo",
+        family: ModelFamily::OpenAi,
+        tokens_per_estimate: 1.0,
+        context_window: 16_385,
+    },
+    ModelProfile {
+        prefix: "o1",
+        family: ModelFamily::OpenAi,
+        tokens_per_estimate: 0.95,
+        context_window: 200_000,
+    },
+    ModelProfile {
+        prefix: "o3",
+        family: ModelFamily::OpenAi,
+        tokens_per_estimate: 0.95,
+        context_window: 200_000,
+    },
+    ModelProfile {
+        prefix: "o4",
+        family: ModelFamily::OpenAi,
+        tokens_per_estimate: 0.95,
+        context_window: 200_000,
+    },
+    ModelProfile {
+        prefix: "gemini-1.5-pro",
+        family: ModelFamily::Gemini,
+        tokens_per_estimate: 0.95,
+        context_window: 2_097_152,
+    },
+    ModelProfile {
+        prefix: "gemini",
+        family: ModelFamily::Gemini,
+        tokens_per_estimate: 0.95,
+        context_window: 1_048_576,
+    },
+];
+
+/// Token counting for a named model.
+#[derive(Debug, Clone, PartialEq, Serialize, Deserialize)]
+pub struct ModelTokenizer {
+    /// Model name as given.
+    pub model: String,
+    /// Vendor of the model.
+    pub family: ModelFamily,
+    /// Context window in tokens.
+    pub context_window: usize,
+    /// Tokens the model's tokenizer produces per [`estimate_tokens`] token.
+    tokens_per_estimate: f64,
+}
+
+/// Lookup and counting methods for [`ModelTokenizer`].
+impl ModelTokenizer {
+    /// The tokenizer of `model`, matched case-insensitively by name prefix
+    /// (`claude-3-5-sonnet`, `gpt-4o-mini`, `gemini-1.5-pro`, ...).
+    pub fn for_model(model: &str) -> Result<Self> {
+        let name = model.trim().to_lowercase();
+        let profile = MODEL_PROFILES
+            .iter()
+            .find(|profile| name.starts_with(profile.prefix))
+            .ok_or_else(|| {
+                let prefixes: Vec<&str> = MODEL_PROFILES.iter().map(|p| p.prefix).collect();
+                ValknutError::validation(format!(
+                    "Unknown model `{model}` (supported prefixes: {})",
+                    prefixes.join(", ")
+                ))
+            })?;
+        Ok(Self {
+            model: model.trim().to_string(),
+            family: profile.family,
+            context_window: profile.context_window,
+            tokens_per_estimate: profile.tokens_per_estimate,
+        })
+    }
+
+    /// Estimate the number of tokens the model's tokenizer produces for `text`.
+    pub fn count(&self, text: &str) -> usize {
+        (estimate_tokens(text) as f64 * self.tokens_per_estimate).ceil() as usize
+    }
+}
+
+/// A file ranked by [`plan_token_budget`].
+#[derive(Debug, Clone, PartialEq, Serialize, Deserialize)]
+pub struct PlannedFile {
+    /// Path relative to the planned root.
+    pub path: String,
+    /// Estimated tokens of the whole file for the planned model.
+    pub tokens: usize,
+    /// Query relevance (zero without a query).
+    pub relevance: f64,
+    /// How many other files mention the file's exported symbols.
+    pub references: usize,
+    /// Last modification time in seconds since the Unix epoch, when known.
+    pub modified: Option<u64>,
+}
+
+/// Files of a project selected to fit one model's token budget.
+#[derive(Debug, Clone, PartialEq, Serialize, Deserialize)]
+pub struct TokenBudgetPlan {
+    /// Model the tokens were estimated for.
+    pub model: String,
+    /// Vendor of the model.
+    pub family: ModelFamily,
+    /// Token budget the files were fitted to.
+    pub budget: usize,
+    /// Sum of the included files' tokens.
+    pub total_tokens: usize,
+    /// Included files, in priority order.
+    pub files: Vec<PlannedFile>,
+    /// Files that did not fit, in priority order.
+    pub dropped: Vec<PlannedFile>,
+}
+
+/// Rank the source files under `root` by relevance to `query`, references to
+/// their exported symbols and recency, and keep as many whole files as fit in
+/// `budget` tokens of `tokenizer`'s model.
+///
+/// Files are read the way [`repository_files`] finds them: ignore files are
+/// honoured and vendored dependencies skipped.
+pub fn plan_token_budget(
+    root: &Path,
+    tokenizer: &ModelTokenizer,
+    budget: usize,
+    query: &str,
+) -> Result<TokenBudgetPlan> {
+    if !root.is_dir() {
+        return Err(ValknutError::validation(format!(
+            "Path is not a directory: {}",
+            root.display()
+        )));
+    }
+
+    let references = file_reference_counts(root)?;
+    let mut candidates = Vec::new();
+    for path in repository_files(root) {
+        let content = match FileReader::read_to_string(&path) {
+            Ok(content) => content,
+            Err(err) => {
+                warn!("Skipping {}: {}", path.display(), err);
+                continue;
+            }
+        };
+        let relative = path.strip_prefix(root).unwrap_or(&path);
+        let display_path = relative.to_string_lossy().into_owned();
+        let modified = std::fs::metadata(&path).and_then(|m| m.modified()).ok();
+        candidates.push(ContextFile {
+            relevance: relevance_score(query, &display_path, &content),
+            references: references.get(relative).copied().unwrap_or(0),
+            modified,
+            path: display_path,
+            content,
+        });
+    }
+
+    let planned: HashMap<String, PlannedFile> = candidates
+        .iter()
+        .map(|file| {
+            let planned = PlannedFile {
+                path: file.path.clone(),
+                tokens: tokenizer.count(&file.content),
+                relevance: file.relevance,
+                references: file.references,
+                modified: file
+                    .modified
+                    .and_then(|time| time.duration_since(UNIX_EPOCH).ok())
+                    .map(|age| age.as_secs()),
+            };
+            (file.path.clone(), planned)
+        })
+        .collect();
+
+    let context = ContextBudget::new(budget, TrimStrategy::DropFiles)
+        .fit_with(candidates, |text| tokenizer.count(text));
+    let lookup = |path: &String| planned[path].clone();
+    Ok(TokenBudgetPlan {
+        model: tokenizer.model.clone(),
+        family: tokenizer.family,
+        budget,
+        total_tokens: context.total_tokens,
+        files: context
+            .included
+            .iter()
+            .map(|file| lookup(&file.path))
+            .collect(),
+        dropped: context.dropped.iter().map(lookup).collect(),
+    })
+}
+
+/// Estimated token cost of a single symbol.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct SymbolTokens {
+    /// Symbol name.
+    pub name: String,
+    /// Entity kind as reported by the language adapter.
+    pub kind: String,
+    /// 1-based inclusive line range, when known.
+    pub line_range: Option<(usize, usize)>,
+    /// Estimated tokens of the symbol's source.
+    pub tokens: usize,
+}
+
+/// Annotate every symbol the language adapter finds in a file with its token estimate.
+pub fn symbol_token_counts(path: &str, content: &str) -> Result<Vec<SymbolTokens>> {
+    let mut adapter = adapter_for_file(Path::new(path))?;
+    let mut symbols: Vec<SymbolTokens> = adapter
+        .extract_code_entities(content, path)?
+        .into_iter()
+        .map(|entity| SymbolTokens {
+            tokens: estimate_tokens(&entity.source_code),
+            name: entity.name,
+            kind: entity.entity_type,
+            line_range: entity.line_range,
+        })
+        .collect();
+    symbols.sort_by_key(|symbol| symbol.line_range);
+    Ok(symbols)
+}
+
+/// Replace every outermost function body with a placeholder, keeping signatures.
+///
+/// Returns `None` when the file's language is unsupported or it cannot be parsed.
+pub fn elide_function_bodies(path: &str, content: &str) -> Option<String> {
+    let mut adapter = adapter_for_file(Path::new(path)).ok()?;
+    let tree = adapter.parse_tree(content).ok()?;
+    let placeholder = if adapter.language_name() == "python" {
+        "..."
+    } else {
+        "{ ... }"
+    };
+
+    let mut bodies = Vec::new();
+    collect_function_bodies(tree.root_node(), &mut bodies);
+    if bodies.is_empty() {
+        return Some(content.to_string());
+    }
+
+    let mut outline = String::with_capacity(content.len());
+    let mut cursor = 0;
+    for (start, end) in bodies {
+        outline.push_str(&content[cursor..start]);
+        outline.push_str(placeholder);
+        cursor = end;
+    }
+    outline.push_str(&content[cursor..]);
+    Some(outline)
+}
+
+/// Collect byte ranges of outermost function bodies in source order.
+fn collect_function_bodies(node: Node, bodies: &mut Vec<(usize, usize)>) {
+    let kind = node.kind();
+    if kind.contains("function") || kind.contains("method") {
+        if let Some(body) = node.child_by_field_name("body") {
+            bodies.push((body.start_byte(), body.end_byte()));
+            return;
+        }
+    }
+
+    let mut cursor = node.walk();
+    for child in node.children(&mut cursor) {
+        collect_function_bodies(child, bodies);
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use std::time::Duration;
+
+    fn file(path: &str, content: &str, relevance: f64) -> ContextFile {
+        ContextFile {
+            path: path.to_string(),
+            content: content.to_string(),
+            relevance,
+            references: 0,
+            modified: None,
+        }
+    }
+
+    #[test]
+    fn estimate_tokens_tracks_bpe_pre_tokenization() {
+        assert_eq!(estimate_tokens(""), 0);
+        assert_eq!(estimate_tokens("hello world"), 4);
+        assert_eq!(estimate_tokens("x = 12345;"), 5);
+        assert_eq!(estimate_tokens("fn main() {}\n"), 5);
+        assert!(estimate_tokens(&"word ".repeat(100)) >= 100);
+    }
+
+    #[test]
+    fn drop_files_prefers_relevant_then_recent_files() {
+        let now = SystemTime::now();
+        let mut stale = file("b.py", "x = 1\n", 0.0);
+        stale.modified = Some(now - Duration::from_secs(3600));
+        let mut fresh = file("c.py", "y = 2\n", 0.0);
+        fresh.modified = Some(now);
+        let relevant = file("a.py", "z = 3\n", 5.0);
+        let huge = file("huge.py", &"value = 1\n".repeat(500), 1.0);
+
+        let budget = ContextBudget::new(8, TrimStrategy::DropFiles);
+        let context = budget.fit(vec![stale, huge, fresh, relevant]);
+
+        let included: Vec<_> = context.included.iter().map(|f| f.path.as_str()).collect();
+        assert_eq!(included, vec!["a.py", "c.py"]);
+        assert_eq!(
+            context.dropped,
+            vec!["huge.py".to_string(), "b.py".to_string()]
+        );
+        assert_eq!(context.total_tokens, 8);
+    }
+
+    #[test]
+    fn referenced_files_rank_before_recent_ones() {
+        let mut fresh = file("fresh.py", "x = 1\n", 0.0);
+        fresh.modified = Some(SystemTime::now());
+        let mut shared = file("shared.py", "y = 2\n", 0.0);
+        shared.references = 3;
+
+        let context = ContextBudget::new(100, TrimStrategy::DropFiles).fit(vec![fresh, shared]);
+        let included: Vec<_> = context.included.iter().map(|f| f.path.as_str()).collect();
+        assert_eq!(included, vec!["shared.py", "fresh.py"]);
+    }
+
+    #[test]
+    fn model_tokenizers_cover_each_vendor() {
+        let claude = ModelTokenizer::for_model("claude-3-5-sonnet").unwrap();
+        let gpt = ModelTokenizer::for_model("GPT-4o-mini").unwrap();
+        let gemini = ModelTokenizer::for_model("gemini-1.5-pro-002").unwrap();
+        assert_eq!(claude.family, ModelFamily::Anthropic);
+        assert_eq!(gpt.family, ModelFamily::OpenAi);
+        assert_eq!(gemini.family, ModelFamily::Gemini);
+        assert_eq!(claude.context_window, 200_000);
+        assert_eq!(gpt.context_window, 128_000);
+        assert_eq!(gemini.context_window, 2_097_152);
+        assert_eq!(
+            ModelTokenizer::for_model("gpt-4").unwrap().context_window,
+            8_192
+        );
+
+        let text = "fn main() { println!(\"hello world\"); }\n".repeat(20);
+        assert!(claude.count(&text) > estimate_tokens(&text));
+        assert!(gpt.count(&text) < claude.count(&text));
+        assert_eq!(claude.count(""), 0);
+
+        let err = ModelTokenizer::for_model("llama-3").unwrap_err();
+        assert!(err.to_string().contains("Unknown model `llama-3`"));
+    }
+
+    #[test]
+    fn plan_token_budget_fits_referenced_files_first() {
+        let tmp = tempfile::tempdir().unwrap();
+        let root = tmp.path();
+        std::fs::create_dir_all(root.join("lib")).unwrap();
+        std::fs::write(root.join("lib/util.py"), "def helper():\n    return 1\n").unwrap();
+        std::fs::write(
+            root.join("app.py"),
+            "from lib.util import helper\n\nprint(helper())\n",
+        )
+        .unwrap();
+        std::fs::write(root.join("big.py"), "value = 1\n".repeat(500)).unwrap();
+
+        let claude = ModelTokenizer::for_model("claude-3-5-sonnet").unwrap();
+        let util_tokens = claude.count("def helper():\n    return 1\n");
+        let plan = plan_token_budget(root, &claude, 40, "").unwrap();
+
+        assert_eq!(plan.model, "claude-3-5-sonnet");
+        assert_eq!(plan.budget, 40);
+        assert_eq!(plan.files[0].path, "lib/util.py");
+        assert_eq!(plan.files[0].references, 1);
+        assert_eq!(plan.files[0].tokens, util_tokens);
+        let included: Vec<_> = plan.files.iter().map(|f| f.path.as_str()).collect();
+        assert_eq!(included, vec!["lib/util.py", "app.py"]);
+        assert_eq!(
+            plan.total_tokens,
+            plan.files.iter().map(|f| f.tokens).sum::<usize>()
+        );
+        assert_eq!(plan.dropped.len(), 1);
+        assert_eq!(plan.dropped[0].path, "big.py");
+        assert!(plan.dropped[0].tokens > 40);
+
+        assert!(plan_token_budget(&root.join("app.py"), &claude, 40, "").is_err());
+    }
+
+    #[test]
+    fn truncate_bodies_keeps_signatures() {
+        let source = "def load(path):\n    data = open(path).read()\n    return parse(data)\n\n\ndef save(path, data):\n    with open(path, 'w') as handle:\n        handle.write(data)\n";
+        let outline = elide_function_bodies("store.py", source).unwrap();
+        assert!(outline.contains("def load(path):"));
+        assert!(outline.contains("def save(path, data):"));
+        assert!(!outline.contains("handle.write"));
+
+        let full = estimate_tokens(source);
+        let budget = ContextBudget::new(full - 1, TrimStrategy::TruncateBodies);
+        let context = budget.fit(vec![file("store.py", source, 1.0)]);
+        assert_eq!(context.included.len(), 1);
+        assert!(context.included[0].truncated);
+        assert_eq!(context.included[0].original_tokens, full);
+
+        let strict = ContextBudget::new(full - 1, TrimStrategy::DropFiles);
+        assert!(strict
+            .fit(vec![file("store.py", source, 1.0)])
+            .included
+            .is_empty());
+    }
+
+    #[test]
+    fn relevance_weights_path_matches_over_content() {
+        let by_path = relevance_score("parser", "src/parser.rs", "fn run() {}");
+        let by_content = relevance_score("parser", "src/lib.rs", "use parser; parser::run();");
+        assert!(by_path > by_content);
+        assert!(by_content > 0.0);
+        assert_eq!(relevance_score("", "src/parser.rs", "parser"), 0.0);
+    }
+
+    #[test]
+    fn symbol_token_counts_cover_each_function() {
+        let source = "def short():\n    return 1\n\n\ndef longer(a, b):\n    total = a + b\n    return total * 2\n";
+        let symbols = symbol_token_counts("calc.py", source).unwrap();
+        let names: Vec<_> = symbols.iter().map(|s| s.name.as_str()).collect();
+        assert_eq!(names, vec!["short", "longer"]);
+        assert!(symbols[1].tokens > symbols[0].tokens);
+    }
+}
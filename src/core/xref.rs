@@ -0,0 +1,269 @@
+//! Symbol-level cross-reference lookup.
+//!
+//! [`find_references`] locates every place a symbol is mentioned by name, without
+//! a running language server. Files with a tree-sitter adapter are searched
+//! syntactically: only identifier nodes whose text equals the symbol count, so
+//! matches inside strings and comments are ignored, and each hit is attributed
+//! to its innermost enclosing function, method or type. Other source files fall
+//! back to a whole-word text scan with no enclosing scope.
+//!
+//! Matching is by name only; there is no type resolution, so same-named symbols
+//! in unrelated scopes are all reported.
+
+use std::path::{Path, PathBuf};
+
+use ignore::WalkBuilder;
+use serde::{Deserialize, Serialize};
+use tracing::warn;
+use tree_sitter::Node;
+
+use crate::core::errors::{Result, ValknutError};
+use crate::core::featureset::CodeEntity;
+use crate::core::file_utils::FileReader;
+use crate::core::pipeline::discovery::IGNORE_FILE_NAME;
+use crate::lang::registry::adapter_for_file;
+
+/// How a reference was located.
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Serialize, Deserialize)]
+#[serde(rename_all = "snake_case")]
+pub enum MatchKind {
+    /// Identifier node in a tree-sitter syntax tree.
+    Syntax,
+    /// Whole-word text match (no parser available for the file).
+    Text,
+}
+
+/// A single location that mentions a symbol.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct SymbolReference {
+    /// File containing the reference, relative to the search root.
+    pub file_path: PathBuf,
+    /// 1-based line number.
+    pub line: usize,
+    /// 1-based column, in bytes.
+    pub column: usize,
+    /// Name of the innermost function, method or type containing the reference.
+    pub enclosing: Option<String>,
+    /// True when this location declares the symbol rather than using it.
+    pub is_definition: bool,
+    /// How the reference was located.
+    pub matched_by: MatchKind,
+}
+
+/// Find every reference to `symbol` under `root` (a directory or a single file).
+///
+/// Qualified names such as `pkg.Func` or `module::func` are matched on their last
+/// segment. Results are sorted by file, line and column.
+pub fn find_references(root: &Path, symbol: &str) -> Result<Vec<SymbolReference>> {
+    let name = unqualified(symbol);
+    if name.is_empty() {
+        return Err(ValknutError::validation("Symbol name must not be empty"));
+    }
+    if !root.exists() {
+        return Err(ValknutError::validation(format!(
+            "Path does not exist: {}",
+            root.display()
+        )));
+    }
+
+    let mut builder = WalkBuilder::new(root);
+    builder.add_custom_ignore_filename(IGNORE_FILE_NAME);
+
+    let mut references = Vec::new();
+    for entry in builder.build() {
+        let entry = match entry {
+            Ok(entry) => entry,
+            Err(err) => {
+                warn!("Failed to walk directory: {err}");
+                continue;
+            }
+        };
+        let path = entry.path();
+        if !path.is_file() || !FileReader::is_code_file(path) {
+            continue;
+        }
+        let source = match FileReader::read_to_string(path) {
+            Ok(source) => source,
+            Err(err) => {
+                warn!("Skipping {}: {}", path.display(), err);
+                continue;
+            }
+        };
+        // Cheap pre-filter: most files never mention the symbol at all.
+        if !source.contains(name) {
+            continue;
+        }
+
+        let display_path = match path.strip_prefix(root) {
+            Ok(relative) if !relative.as_os_str().is_empty() => relative.to_path_buf(),
+            _ => path.to_path_buf(),
+        };
+        references.extend(references_in_source(path, &source, name).into_iter().map(
+            |mut reference| {
+                reference.file_path = display_path.clone();
+                reference
+            },
+        ));
+    }
+
+    references
+        .sort_by(|a, b| (&a.file_path, a.line, a.column).cmp(&(&b.file_path, b.line, b.column)));
+    Ok(references)
+}
+
+/// Find references to `name` in one file's source, using its parser when available.
+pub fn references_in_source(path: &Path, source: &str, name: &str) -> Vec<SymbolReference> {
+    syntax_references(path, source, name).unwrap_or_else(|| text_references(path, source, name))
+}
+
+/// Strip any package or module qualifier from a symbol name.
+fn unqualified(symbol: &str) -> &str {
+    let symbol = symbol.trim();
+    symbol
+        .rsplit(|c| c == '.' || c == ':')
+        .next()
+        .unwrap_or(symbol)
+}
+
+/// Search identifier nodes; `None` when the file has no adapter or fails to parse.
+fn syntax_references(path: &Path, source: &str, name: &str) -> Option<Vec<SymbolReference>> {
+    let mut adapter = adapter_for_file(path).ok()?;
+    let tree = adapter.parse_tree(source).ok()?;
+    let entities = adapter
+        .extract_code_entities(source, &path.to_string_lossy())
+        .unwrap_or_default();
+
+    let mut hits = Vec::new();
+    collect_identifiers(tree.root_node(), source.as_bytes(), name, &mut hits);
+
+    Some(
+        hits.into_iter()
+            .map(|(line, column)| {
+                let is_definition = entities
+                    .iter()
+                    .any(|entity| entity.name == name && starts_on(entity, line));
+                SymbolReference {
+                    file_path: path.to_path_buf(),
+                    line,
+                    column,
+                    enclosing: enclosing_entity(&entities, line, name, is_definition),
+                    is_definition,
+                    matched_by: MatchKind::Syntax,
+                }
+            })
+            .collect(),
+    )
+}
+
+/// Collect 1-based (line, column) positions of identifier leaves whose text is `name`.
+fn collect_identifiers(node: Node, source: &[u8], name: &str, hits: &mut Vec<(usize, usize)>) {
+    let mut cursor = node.walk();
+    let mut stack = vec![node];
+    while let Some(current) = stack.pop() {
+        if current.child_count() == 0 {
+            if current.kind().ends_with("identifier")
+                && current.utf8_text(source).is_ok_and(|text| text == name)
+            {
+                let start = current.start_position();
+                hits.push((start.row + 1, start.column + 1));
+            }
+            continue;
+        }
+        stack.extend(current.children(&mut cursor));
+    }
+    hits.sort_unstable();
+}
+
+/// Returns true when `entity` starts on `line`.
+fn starts_on(entity: &CodeEntity, line: usize) -> bool {
+    entity.line_range.is_some_and(|(start, _)| start == line)
+}
+
+/// Name of the smallest entity spanning `line`, skipping the symbol's own declaration.
+fn enclosing_entity(
+    entities: &[CodeEntity],
+    line: usize,
+    name: &str,
+    is_definition: bool,
+) -> Option<String> {
+    entities
+        .iter()
+        .filter(|entity| !(is_definition && entity.name == name && starts_on(entity, line)))
+        .filter_map(|entity| {
+            let (start, end) = entity.line_range?;
+            (start <= line && line <= end).then_some((end - start, entity))
+        })
+        .min_by_key(|(span, _)| *span)
+        .map(|(_, entity)| entity.name.clone())
+}
+
+/// Whole-word text scan used when no parser is available.
+fn text_references(path: &Path, source: &str, name: &str) -> Vec<SymbolReference> {
+    let is_word = |c: char| c.is_alphanumeric() || c == '_';
+    let mut references = Vec::new();
+
+    for (index, line) in source.lines().enumerate() {
+        for (offset, _) in line.match_indices(name) {
+            let before = line[..offset].chars().next_back();
+            let after = line[offset + name.len()..].chars().next();
+            if before.is_some_and(is_word) || after.is_some_and(is_word) {
+                continue;
+            }
+            references.push(SymbolReference {
+                file_path: path.to_path_buf(),
+                line: index + 1,
+                column: offset + 1,
+                enclosing: None,
+                is_definition: false,
+                matched_by: MatchKind::Text,
+            });
+        }
+    }
+    references
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use std::fs;
+    use tempfile::tempdir;
+
+    #[test]
+    fn finds_go_references_with_enclosing_scope() {
+        let tmp = tempdir().unwrap();
+        let root = tmp.path();
+        fs::write(
+            root.join("main.go"),
+            "package main\n\nfunc Load() int {\n\treturn 1\n}\n\nfunc run() {\n\t// Load is called here\n\t_ = Load()\n\ts := \"Load\"\n\t_ = s\n}\n",
+        )
+        .unwrap();
+
+        let references = find_references(root, "main.Load").unwrap();
+        assert_eq!(references.len(), 2);
+
+        assert_eq!(references[0].file_path, PathBuf::from("main.go"));
+        assert_eq!((references[0].line, references[0].column), (3, 6));
+        assert!(references[0].is_definition);
+
+        assert_eq!((references[1].line, references[1].column), (9, 6));
+        assert!(!references[1].is_definition);
+        assert_eq!(references[1].enclosing.as_deref(), Some("run"));
+        assert_eq!(references[1].matched_by, MatchKind::Syntax);
+    }
+
+    #[test]
+    fn text_fallback_matches_whole_words_only() {
+        let source = "load(x)\nreload(x)\ny = load\n";
+        let references = text_references(Path::new("script.lua"), source, "load");
+
+        let positions: Vec<_> = references.iter().map(|r| (r.line, r.column)).collect();
+        assert_eq!(positions, vec![(1, 1), (3, 5)]);
+        assert!(references.iter().all(|r| r.matched_by == MatchKind::Text));
+    }
+
+    #[test]
+    fn rejects_empty_symbol() {
+        let tmp = tempdir().unwrap();
+        assert!(find_references(tmp.path(), "  ").is_err());
+    }
+}
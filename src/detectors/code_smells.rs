@@ -0,0 +1,702 @@
+//! Well-known Go code smells.
+//!
+//! [`detect_code_smells`] runs one [`CodeSmellCheck`] over a Go source file and
+//! returns every [`CodeSmell`] it finds. The checks are lexical: the source is
+//! tokenized with comments and literals dropped, top-level declarations are
+//! walked in order, and only the shapes each check cares about are parsed.
+//!
+//! - [`CodeSmellCheck::ManyReturnValues`]: functions returning more than `max`
+//!   values, which usually want a result struct.
+//! - [`CodeSmellCheck::InterfaceParams`]: exported functions and methods of
+//!   exported types taking `interface{}` (or `any`) parameters.
+//! - [`CodeSmellCheck::PackageGlobals`]: package-level `var` state outside
+//!   `package main`; blank assertions (`var _ I = T{}`) and sentinel errors
+//!   built with `errors.New` or `fmt.Errorf` are allowed.
+//! - [`CodeSmellCheck::InitSideEffects`]: `init()` functions that call
+//!   anything other than a built-in or start a goroutine.
+//! - [`CodeSmellCheck::LibraryPanic`]: `panic` calls outside `package main`,
+//!   except in `Must...` functions, whose contract is to panic.
+
+use serde::{Deserialize, Serialize};
+
+use super::lock_contention::{matching, tokenize, Token};
+use crate::lang::go::is_exported;
+
+/// Calls an `init()` may make without having side effects: built-ins and
+/// conversions to predeclared types.
+const PURE_CALLS: &[&str] = &[
+    "append", "cap", "complex", "copy", "delete", "imag", "len", "make", "max", "min", "new",
+    "real", "bool", "byte", "rune", "string", "int", "int8", "int16", "int32", "int64", "uint",
+    "uint8", "uint16", "uint32", "uint64", "uintptr", "float32", "float64",
+];
+
+/// Keywords that can precede `(` without being a call.
+const KEYWORDS: &[&str] = &["if", "for", "switch", "return", "func", "range", "case"];
+
+/// Calls that build sentinel errors, allowed as package-level variables.
+const ERROR_CONSTRUCTORS: &[(&str, &str)] = &[("errors", "New"), ("fmt", "Errorf")];
+
+/// A code smell check and its parameters.
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Serialize, Deserialize)]
+#[serde(rename_all = "snake_case")]
+pub enum CodeSmellCheck {
+    /// Functions returning more than `max` values.
+    ManyReturnValues {
+        /// Largest number of results allowed.
+        max: usize,
+    },
+    /// `interface{}` or `any` parameters of exported functions and methods.
+    InterfaceParams,
+    /// Package-level variables outside `package main`.
+    PackageGlobals,
+    /// `init()` functions with side effects.
+    InitSideEffects,
+    /// `panic` calls outside `package main`.
+    LibraryPanic,
+}
+
+/// One occurrence of a code smell.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct CodeSmell {
+    /// Function or variable the smell was found in.
+    pub name: String,
+    /// 1-based line of the declaration or statement.
+    pub line: usize,
+    /// What is wrong, naming the entity.
+    pub message: String,
+}
+
+/// A top-level function declaration.
+pub(crate) struct FunctionDecl {
+    /// Function name.
+    pub(crate) name: String,
+    /// Receiver type name, for methods.
+    pub(crate) receiver: Option<String>,
+    /// 1-based line of `func`.
+    pub(crate) line: usize,
+    /// Token range of the parameters, parentheses excluded.
+    pub(crate) params: (usize, usize),
+    /// Number of results.
+    pub(crate) results: usize,
+    /// Token range of the result types, parentheses excluded.
+    pub(crate) result_types: (usize, usize),
+    /// Token range of the body, braces excluded; `None` for declarations
+    /// without a body.
+    pub(crate) body: Option<(usize, usize)>,
+}
+
+/// A top-level `type` spec.
+pub(crate) struct TypeSpec {
+    /// Type name.
+    pub(crate) name: String,
+    /// 1-based line of the spec.
+    pub(crate) line: usize,
+    /// Whether the type is a struct.
+    pub(crate) is_struct: bool,
+}
+
+/// A top-level `var` spec.
+struct VarSpec {
+    /// Declared names, blank identifiers excluded.
+    names: Vec<String>,
+    /// 1-based line of the spec.
+    line: usize,
+    /// Whether the value is a sentinel error.
+    sentinel_error: bool,
+}
+
+/// Top-level declarations of one file.
+#[derive(Default)]
+pub(crate) struct GoFile {
+    /// Package clause name.
+    pub(crate) package: String,
+    /// Function and method declarations, in source order.
+    pub(crate) functions: Vec<FunctionDecl>,
+    /// `type` specs, in source order.
+    pub(crate) types: Vec<TypeSpec>,
+    /// `var` specs, in source order.
+    vars: Vec<VarSpec>,
+}
+
+/// Find the occurrences of `check` in Go `source`.
+pub fn detect_code_smells(source: &str, check: CodeSmellCheck) -> Vec<CodeSmell> {
+    let tokens = tokenize(source);
+    let file = GoFile::parse(&tokens);
+    let library = file.package != "main";
+    match check {
+        CodeSmellCheck::ManyReturnValues { max } => file
+            .functions
+            .iter()
+            .filter(|function| function.results > max)
+            .map(|function| CodeSmell {
+                name: function.name.clone(),
+                line: function.line,
+                message: format!(
+                    "{} `{}` returns {} values (max {max})",
+                    function.kind_name(),
+                    function.name,
+                    function.results
+                ),
+            })
+            .collect(),
+        CodeSmellCheck::InterfaceParams => file
+            .functions
+            .iter()
+            .filter(|function| function.is_public() && takes_empty_interface(&tokens, function))
+            .map(|function| CodeSmell {
+                name: function.name.clone(),
+                line: function.line,
+                message: format!(
+                    "exported {} `{}` takes an `interface{{}}` parameter",
+                    function.kind_name(),
+                    function.name
+                ),
+            })
+            .collect(),
+        CodeSmellCheck::PackageGlobals if library => file
+            .vars
+            .iter()
+            .filter(|spec| !spec.sentinel_error)
+            .flat_map(|spec| {
+                spec.names.iter().map(|name| CodeSmell {
+                    name: name.clone(),
+                    line: spec.line,
+                    message: format!(
+                        "package-level variable `{name}` in package `{}` holds global state",
+                        file.package
+                    ),
+                })
+            })
+            .collect(),
+        CodeSmellCheck::InitSideEffects => file
+            .functions
+            .iter()
+            .filter(|function| function.name == "init" && function.receiver.is_none())
+            .filter_map(|function| {
+                let (start, end) = function.body?;
+                let (line, effect) = first_side_effect(&tokens[start..end])?;
+                Some(CodeSmell {
+                    name: function.name.clone(),
+                    line,
+                    message: format!("`init` has side effects: {effect}"),
+                })
+            })
+            .collect(),
+        CodeSmellCheck::LibraryPanic if library => file
+            .functions
+            .iter()
+            .filter(|function| {
+                !function.name.starts_with("Must") && !function.name.starts_with("must")
+            })
+            .flat_map(|function| {
+                let (start, end) = function.body.unwrap_or_default();
+                let body = &tokens[start..end];
+                (0..body.len())
+                    .filter(|&i| is_call(body, i) && body[i].text == "panic")
+                    .map(|i| CodeSmell {
+                        name: function.name.clone(),
+                        line: body[i].line,
+                        message: format!(
+                            "`panic` in library {} `{}`; return an error instead",
+                            function.kind_name(),
+                            function.name
+                        ),
+                    })
+                    .collect::<Vec<_>>()
+            })
+            .collect(),
+        CodeSmellCheck::PackageGlobals | CodeSmellCheck::LibraryPanic => Vec::new(),
+    }
+}
+
+/// Declaration parsing for [`GoFile`].
+impl GoFile {
+    /// Walk the top-level declarations of `tokens`.
+    pub(crate) fn parse(tokens: &[Token]) -> Self {
+        let mut file = Self::default();
+        let mut i = 0;
+        while i < tokens.len() {
+            match tokens[i].text.as_str() {
+                "package" => {
+                    if let Some(name) = tokens.get(i + 1) {
+                        file.package = name.text.clone();
+                    }
+                    i += 2;
+                }
+                // The tokenizer drops the path literal, leaving at most an
+                // alias on the line of a single-line import.
+                "import" if !tokens.get(i + 1).is_some_and(|t| t.is('(')) => {
+                    let line = tokens[i].line;
+                    i += 1;
+                    if tokens.get(i).is_some_and(|t| t.line == line) {
+                        i += 1;
+                    }
+                }
+                keyword @ ("import" | "const" | "type" | "var") => {
+                    let specs = if tokens.get(i + 1).is_some_and(|t| t.is('(')) {
+                        let close = matching(tokens, i + 1);
+                        let specs = split_specs(tokens, i + 2, close);
+                        i = close + 1;
+                        specs
+                    } else {
+                        let end = spec_end(tokens, i + 1, tokens.len());
+                        let specs = vec![(i + 1, end)];
+                        i = end;
+                        specs
+                    };
+                    match keyword {
+                        "var" => file
+                            .vars
+                            .extend(specs.into_iter().map(|spec| var_spec(tokens, spec))),
+                        "type" => file
+                            .types
+                            .extend(specs.into_iter().filter_map(|spec| type_spec(tokens, spec))),
+                        _ => {}
+                    }
+                }
+                "func" => match function_decl(tokens, i) {
+                    Some((function, next)) => {
+                        file.functions.push(function);
+                        i = next;
+                    }
+                    None => i += 1,
+                },
+                _ => i += 1,
+            }
+        }
+        file
+    }
+}
+
+/// Classification helpers for [`FunctionDecl`].
+impl FunctionDecl {
+    /// `function` or `method`.
+    fn kind_name(&self) -> &'static str {
+        if self.receiver.is_some() {
+            "method"
+        } else {
+            "function"
+        }
+    }
+
+    /// Whether the declaration is part of the package API: an exported
+    /// function, or an exported method of an exported type.
+    fn is_public(&self) -> bool {
+        is_exported(&self.name) && self.receiver.as_deref().map_or(true, is_exported)
+    }
+}
+
+/// Parse the function declaration whose `func` is at `start`, returning it
+/// and the index after its body (or signature).
+fn function_decl(tokens: &[Token], start: usize) -> Option<(FunctionDecl, usize)> {
+    let mut i = start + 1;
+    let mut receiver = None;
+    if tokens.get(i)?.is('(') {
+        let close = matching(tokens, i);
+        let type_end = tokens[i + 1..close]
+            .iter()
+            .position(|t| t.is('['))
+            .map_or(close, |offset| i + 1 + offset);
+        receiver = tokens[i + 1..type_end]
+            .iter()
+            .rev()
+            .find(|t| t.is_ident())
+            .map(|t| t.text.clone());
+        i = close + 1;
+    }
+    let name = tokens.get(i).filter(|t| t.is_ident())?.text.clone();
+    i += 1;
+    if tokens.get(i)?.is('[') {
+        i = matching(tokens, i) + 1;
+    }
+    if !tokens.get(i)?.is('(') {
+        return None;
+    }
+    let params_close = matching(tokens, i);
+    let params = (i + 1, params_close);
+    i = params_close + 1;
+
+    let results_start = i;
+    let results = match tokens.get(i) {
+        Some(t) if t.is('(') => {
+            let close = matching(tokens, i);
+            let count = split_top_level(tokens, i + 1, close, ',').len();
+            i = close + 1;
+            count
+        }
+        Some(t) if t.is('{') || t.line != tokens[params_close].line => 0,
+        Some(_) => 1,
+        None => 0,
+    };
+
+    let body = body_open(tokens, i).map(|open| (open, matching(tokens, open)));
+    let result_types = if tokens.get(results_start).is_some_and(|t| t.is('(')) {
+        (results_start + 1, i - 1)
+    } else if results == 0 {
+        (results_start, results_start)
+    } else {
+        // An unparenthesized result runs up to the body, or is one identifier.
+        (
+            results_start,
+            body.map_or(results_start + 1, |(open, _)| open),
+        )
+    };
+    let next = body.map_or(i, |(_, close)| close + 1);
+    Some((
+        FunctionDecl {
+            name,
+            receiver,
+            line: tokens[start].line,
+            params,
+            results,
+            result_types,
+            body: body.map(|(open, close)| (open + 1, close)),
+        },
+        next,
+    ))
+}
+
+/// Index of the `{` opening a function body, scanning the rest of the
+/// signature from `from`. The brace must be on the signature's last line.
+fn body_open(tokens: &[Token], from: usize) -> Option<usize> {
+    let mut i = from;
+    while let Some(token) = tokens.get(i) {
+        if i > 0 && token.line != tokens[i - 1].line {
+            return None;
+        }
+        if token.is('{') {
+            return Some(i);
+        }
+        if token.is('(') || token.is('[') {
+            i = matching(tokens, i) + 1;
+        } else if (token.text == "interface" || token.text == "struct")
+            && tokens.get(i + 1).is_some_and(|t| t.is('{'))
+        {
+            i = matching(tokens, i + 1) + 1;
+        } else {
+            i += 1;
+        }
+    }
+    None
+}
+
+/// Split `start..end` at `separator` tokens outside brackets, dropping
+/// empty parts.
+pub(crate) fn split_top_level(
+    tokens: &[Token],
+    start: usize,
+    end: usize,
+    separator: char,
+) -> Vec<(usize, usize)> {
+    let mut parts = Vec::new();
+    let mut depth = 0usize;
+    let mut part_start = start;
+    for (i, token) in tokens.iter().enumerate().take(end).skip(start) {
+        if token.is('(') || token.is('[') || token.is('{') {
+            depth += 1;
+        } else if token.is(')') || token.is(']') || token.is('}') {
+            depth = depth.saturating_sub(1);
+        } else if depth == 0 && token.is(separator) {
+            parts.push((part_start, i));
+            part_start = i + 1;
+        }
+    }
+    parts.push((part_start, end));
+    parts.retain(|(start, end)| start < end);
+    parts
+}
+
+/// Exclusive end of the declaration spec starting at `start`: the next token
+/// outside brackets on a later line, or `limit`.
+fn spec_end(tokens: &[Token], start: usize, limit: usize) -> usize {
+    let mut depth = 0usize;
+    for i in start..limit {
+        let token = &tokens[i];
+        if i > start && depth == 0 && token.line != tokens[i - 1].line {
+            return i;
+        }
+        if token.is('(') || token.is('[') || token.is('{') {
+            depth += 1;
+        } else if token.is(')') || token.is(']') || token.is('}') {
+            depth = depth.saturating_sub(1);
+        }
+    }
+    limit
+}
+
+/// The specs of a parenthesized declaration group spanning `start..end`.
+fn split_specs(tokens: &[Token], start: usize, end: usize) -> Vec<(usize, usize)> {
+    let mut specs = Vec::new();
+    let mut i = start;
+    while i < end {
+        let next = spec_end(tokens, i, end);
+        if !tokens[i].is(';') {
+            specs.push((i, next));
+        }
+        i = next;
+    }
+    specs
+}
+
+/// Names and value of the `var` spec spanning `start..end`.
+fn var_spec(tokens: &[Token], (start, end): (usize, usize)) -> VarSpec {
+    let spec = &tokens[start..end];
+    let mut names = Vec::new();
+    let mut i = 0;
+    while let Some(token) = spec.get(i).filter(|t| t.is_ident()) {
+        if token.text != "_" {
+            names.push(token.text.clone());
+        }
+        if !spec.get(i + 1).is_some_and(|t| t.is(',')) {
+            break;
+        }
+        i += 2;
+    }
+    let value = spec
+        .iter()
+        .position(|t| t.is('='))
+        .map_or(&spec[spec.len()..], |at| &spec[at + 1..]);
+    let sentinel_error = ERROR_CONSTRUCTORS.iter().any(|(package, function)| {
+        value.len() > 3
+            && value[0].text == *package
+            && value[1].is('.')
+            && value[2].text == *function
+            && value[3].is('(')
+    });
+    VarSpec {
+        names,
+        line: tokens[start].line,
+        sentinel_error,
+    }
+}
+
+/// Name and kind of the `type` spec spanning `start..end`; aliases
+/// (`type A = B`) are skipped.
+fn type_spec(tokens: &[Token], (start, end): (usize, usize)) -> Option<TypeSpec> {
+    let spec = &tokens[start..end];
+    let name = spec.first().filter(|t| t.is_ident())?;
+    let mut i = 1;
+    if spec.get(i).is_some_and(|t| t.is('[')) {
+        i = matching(tokens, start + i) - start + 1;
+    }
+    if spec.get(i).is_some_and(|t| t.is('=')) {
+        return None;
+    }
+    Some(TypeSpec {
+        name: name.text.clone(),
+        line: name.line,
+        is_struct: spec.get(i).is_some_and(|t| t.text == "struct")
+            && spec.get(i + 1).is_some_and(|t| t.is('{')),
+    })
+}
+
+/// Whether any parameter of `function` is `interface{}` or `any`.
+fn takes_empty_interface(tokens: &[Token], function: &FunctionDecl) -> bool {
+    let (start, end) = function.params;
+    let params = &tokens[start..end];
+    (0..params.len()).any(|i| {
+        let after_dot = i > 0 && params[i - 1].is('.');
+        match params[i].text.as_str() {
+            "interface" => {
+                params.get(i + 1).is_some_and(|t| t.is('{'))
+                    && params.get(i + 2).is_some_and(|t| t.is('}'))
+            }
+            // `any any` declares a parameter named `any`; only the type counts.
+            "any" => !after_dot && !params.get(i + 1).is_some_and(|t| t.is_ident()),
+            _ => false,
+        }
+    })
+}
+
+/// Whether `tokens[i]` is an identifier called as a function, not a method
+/// or a qualified call.
+fn is_call(tokens: &[Token], i: usize) -> bool {
+    tokens[i].is_ident()
+        && tokens.get(i + 1).is_some_and(|t| t.is('('))
+        && !(i > 0 && tokens[i - 1].is('.'))
+}
+
+/// Line and description of the first side effect in an `init` body.
+fn first_side_effect(body: &[Token]) -> Option<(usize, String)> {
+    (0..body.len()).find_map(|i| {
+        let token = &body[i];
+        if token.text == "go" {
+            return Some((token.line, "starts a goroutine".to_string()));
+        }
+        if !token.is_ident() || !body.get(i + 1).is_some_and(|t| t.is('(')) {
+            return None;
+        }
+        if KEYWORDS.contains(&token.text.as_str()) {
+            return None;
+        }
+        let qualified = i > 1 && body[i - 1].is('.') && body[i - 2].is_ident();
+        if !qualified && PURE_CALLS.contains(&token.text.as_str()) {
+            return None;
+        }
+        let callee = if qualified {
+            format!("{}.{}", body[i - 2].text, token.text)
+        } else {
+            token.text.clone()
+        };
+        Some((token.line, format!("calls `{callee}`")))
+    })
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    /// Source of package `i` of `packages`, as `generate_go_project` in
+    /// `benchmarks/src/performance.rs` writes it.
+    fn performance_package(i: usize, packages: usize) -> String {
+        let prev = (i + packages - 1) % packages;
+        let mut source = format!("package pkg{i}\n\nimport \"example.com/app/pkg{prev}\"\n");
+        for t in 0..20 {
+            source.push_str(&format!(
+                r#"
+type Store{t} interface {{
+	Get{t}(key string) string
+}}
+
+type Memory{t} struct {{
+	next *pkg{prev}.Memory{t}
+	store pkg{prev}.Store{t}
+}}
+
+func (m *Memory{t}) Get{t}(key string) string {{
+	return m.store.Get{t}(key)
+}}
+
+func NewMemory{t}() *Memory{t} {{
+	return &Memory{t}{{next: pkg{prev}.NewMemory{t}()}}
+}}
+"#
+            ));
+        }
+        source
+    }
+
+    /// The fixture package with `extra` appended.
+    fn fixture_with(extra: &str) -> String {
+        format!("{}{extra}", performance_package(1, 100))
+    }
+
+    fn lines(smells: &[CodeSmell]) -> Vec<(&str, usize)> {
+        smells
+            .iter()
+            .map(|smell| (smell.name.as_str(), smell.line))
+            .collect()
+    }
+
+    const ALL_CHECKS: [CodeSmellCheck; 5] = [
+        CodeSmellCheck::ManyReturnValues { max: 3 },
+        CodeSmellCheck::InterfaceParams,
+        CodeSmellCheck::PackageGlobals,
+        CodeSmellCheck::InitSideEffects,
+        CodeSmellCheck::LibraryPanic,
+    ];
+
+    #[test]
+    fn performance_fixture_is_clean() {
+        let source = performance_package(0, 100);
+        for check in ALL_CHECKS {
+            assert!(detect_code_smells(&source, check).is_empty(), "{check:?}");
+        }
+    }
+
+    #[test]
+    fn many_return_values_counts_results() {
+        let base = performance_package(1, 100).lines().count();
+        let source = fixture_with(
+            "\nfunc (m *Memory0) Stats() (hits, misses int, size int64, err error) {\n\treturn 0, 0, 0, nil\n}\n\nfunc Pair() (map[string]int, error) {\n\treturn nil, nil\n}\n",
+        );
+
+        let smells = detect_code_smells(&source, CodeSmellCheck::ManyReturnValues { max: 3 });
+        assert_eq!(lines(&smells), vec![("Stats", base + 2)]);
+        assert_eq!(smells[0].message, "method `Stats` returns 4 values (max 3)");
+        assert_eq!(
+            detect_code_smells(&source, CodeSmellCheck::ManyReturnValues { max: 1 }).len(),
+            2
+        );
+    }
+
+    #[test]
+    fn interface_params_flag_only_the_public_api() {
+        let source = fixture_with(
+            "\nfunc (m *Memory0) Put(key string, value interface{}) {}\n\nfunc Encode(v any) []byte {\n\treturn nil\n}\n\nfunc encode(v interface{}) {}\n\nfunc (h *hidden) Put(v interface{}) {}\n\nfunc Typed(v interface{ Len() int }) {}\n",
+        );
+
+        let smells = detect_code_smells(&source, CodeSmellCheck::InterfaceParams);
+        let names: Vec<&str> = smells.iter().map(|smell| smell.name.as_str()).collect();
+        assert_eq!(names, vec!["Put", "Encode"]);
+        assert_eq!(
+            smells[0].message,
+            "exported method `Put` takes an `interface{}` parameter"
+        );
+    }
+
+    #[test]
+    fn package_globals_skip_main_sentinels_and_assertions() {
+        let extra = "\nvar cache = map[string]*Memory0{\n\t\"a\": nil,\n}\n\nvar (\n\tErrMissing = errors.New(\"missing\")\n\thits, misses int\n\t_ Store0 = (*Memory0)(nil)\n)\n";
+        let source = fixture_with(extra);
+
+        let smells = detect_code_smells(&source, CodeSmellCheck::PackageGlobals);
+        let names: Vec<&str> = smells.iter().map(|smell| smell.name.as_str()).collect();
+        assert_eq!(names, vec!["cache", "hits", "misses"]);
+        assert_eq!(
+            smells[0].message,
+            "package-level variable `cache` in package `pkg1` holds global state"
+        );
+
+        let main = source.replacen("package pkg1", "package main", 1);
+        assert!(detect_code_smells(&main, CodeSmellCheck::PackageGlobals).is_empty());
+    }
+
+    #[test]
+    fn init_side_effects_ignore_builtin_setup() {
+        let pure = fixture_with(
+            "\nvar table []int\n\nfunc init() {\n\ttable = make([]int, 0, len(\"abc\"))\n}\n",
+        );
+        assert!(detect_code_smells(&pure, CodeSmellCheck::InitSideEffects).is_empty());
+
+        let base = performance_package(1, 100).lines().count();
+        let source = fixture_with(
+            "\nfunc init() {\n\tif len(os.Args) > 1 {\n\t\thttp.HandleFunc(\"/\", nil)\n\t}\n}\n",
+        );
+        let smells = detect_code_smells(&source, CodeSmellCheck::InitSideEffects);
+        assert_eq!(lines(&smells), vec![("init", base + 4)]);
+        assert_eq!(
+            smells[0].message,
+            "`init` has side effects: calls `http.HandleFunc`"
+        );
+
+        let goroutine = fixture_with("\nfunc init() {\n\tgo func() {}()\n}\n");
+        assert_eq!(
+            detect_code_smells(&goroutine, CodeSmellCheck::InitSideEffects)[0].message,
+            "`init` has side effects: starts a goroutine"
+        );
+    }
+
+    #[test]
+    fn library_panics_spare_main_and_must_functions() {
+        let base = performance_package(1, 100).lines().count();
+        let source = fixture_with(
+            "\nfunc (m *Memory0) Load(key string) string {\n\tif key == \"\" {\n\t\tpanic(\"empty key\")\n\t}\n\treturn m.Get0(key)\n}\n\nfunc MustLoad(m *Memory0) string {\n\tpanic(\"unreachable\")\n}\n\nfunc (m *Memory0) Recover() { m.panic() }\n",
+        );
+
+        let smells = detect_code_smells(&source, CodeSmellCheck::LibraryPanic);
+        assert_eq!(lines(&smells), vec![("Load", base + 4)]);
+        assert_eq!(
+            smells[0].message,
+            "`panic` in library method `Load`; return an error instead"
+        );
+
+        let main = source.replacen("package pkg1", "package main", 1);
+        assert!(detect_code_smells(&main, CodeSmellCheck::LibraryPanic).is_empty());
+
+        // A declaration right after a single-line import is still parsed.
+        let imported = "package lib\n\nimport f \"fmt\"\nfunc Load() {\n\tpanic(f.Sprint(1))\n}\n";
+        let smells = detect_code_smells(imported, CodeSmellCheck::LibraryPanic);
+        assert_eq!(lines(&smells), vec![("Load", 5)]);
+    }
+}
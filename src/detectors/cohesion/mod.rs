@@ -39,8 +39,10 @@ pub struct CohesionAnalysisResults {
     /// Whether cohesion analysis was enabled
     pub enabled: bool,
     /// Per-file cohesion scores
+    #[serde(serialize_with = "crate::core::pipeline::results::ordering::serialize_sorted")]
     pub file_scores: HashMap<PathBuf, FileCohesionScore>,
     /// Per-folder cohesion scores
+    #[serde(serialize_with = "crate::core::pipeline::results::ordering::serialize_sorted")]
     pub folder_scores: HashMap<PathBuf, FolderCohesionScore>,
     /// Detected issues
     pub issues: Vec<CohesionIssue>,
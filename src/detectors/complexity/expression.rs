@@ -0,0 +1,103 @@
+//! Expression-level complexity.
+//!
+//! Cyclomatic and cognitive complexity count control flow; this metric scores
+//! how hard the expressions themselves are to read. Every call and type
+//! assertion adds one plus the number of calls and assertions it is nested
+//! inside, so `f(g(h(x)))` scores 1 + 2 + 3 while three separate calls score
+//! 3. Method chains are not nesting: `a.b().c()` scores 2. Every `&&`/`||`
+//! adds one, switching between them inside one chain adds one more, and a
+//! type assertion to an anonymous type (`x.(interface{ Close() error })`)
+//! adds one. Function literals start over at depth zero.
+
+/// Calls whose arguments are nested one level deeper than the callee.
+const CALL_KINDS: &[&str] = &["call_expression", "call"];
+
+/// Type assertions and casts, whose operand is nested one level deeper.
+const ASSERTION_KINDS: &[&str] = &["type_assertion_expression", "as_expression"];
+
+/// Binary nodes that may carry a boolean operator.
+const LOGICAL_KINDS: &[&str] = &["binary_expression", "boolean_operator"];
+
+/// Boolean chain operators across the supported grammars.
+const LOGICAL_OPERATORS: &[&str] = &["&&", "||", "and", "or"];
+
+/// Function literals, whose bodies are scored as if they stood alone.
+const CLOSURE_KINDS: &[&str] = &[
+    "func_literal",
+    "arrow_function",
+    "function_expression",
+    "lambda",
+    "closure_expression",
+];
+
+/// Named types that make a type assertion simple.
+const NAMED_TYPE_KINDS: &[&str] = &[
+    "type_identifier",
+    "qualified_type",
+    "pointer_type",
+    "generic_type",
+];
+
+/// Score the expressions under `root` (usually a function node).
+pub fn calculate_expression_complexity(root: tree_sitter::Node<'_>, source: &str) -> f64 {
+    // (node, call/assertion nesting depth, enclosing boolean operator)
+    let mut stack: Vec<(tree_sitter::Node<'_>, u32, Option<&str>)> = vec![(root, 0, None)];
+    let mut score = 0u32;
+
+    while let Some((node, depth, chain_operator)) = stack.pop() {
+        let kind = node.kind();
+        let mut child_depth = depth;
+        let mut child_operator = None;
+        let mut callee_id = None;
+
+        if CLOSURE_KINDS.contains(&kind) {
+            child_depth = 0;
+        } else if CALL_KINDS.contains(&kind) {
+            score += 1 + depth;
+            child_depth = depth + 1;
+            callee_id = node
+                .child_by_field_name("function")
+                .map(|callee| callee.id());
+        } else if ASSERTION_KINDS.contains(&kind) {
+            score += 1 + depth;
+            if node
+                .child_by_field_name("type")
+                .is_some_and(|ty| !NAMED_TYPE_KINDS.contains(&ty.kind()))
+            {
+                score += 1;
+            }
+            child_depth = depth + 1;
+        } else if LOGICAL_KINDS.contains(&kind) {
+            if let Some(operator) = logical_operator(&node, source) {
+                score += 1;
+                if chain_operator.is_some_and(|outer| outer != operator) {
+                    score += 1;
+                }
+                child_operator = Some(operator);
+            }
+        } else if kind == "parenthesized_expression" {
+            child_operator = chain_operator;
+        }
+
+        let mut cursor = node.walk();
+        for child in node.named_children(&mut cursor) {
+            // A method chain's receiver sits at the same depth as the call.
+            let depth = if Some(child.id()) == callee_id {
+                depth
+            } else {
+                child_depth
+            };
+            stack.push((child, depth, child_operator));
+        }
+    }
+
+    f64::from(score)
+}
+
+/// The boolean operator of a binary node, or `None` for arithmetic and
+/// comparisons.
+fn logical_operator<'s>(node: &tree_sitter::Node<'_>, source: &'s str) -> Option<&'s str> {
+    let operator = node.child_by_field_name("operator")?;
+    let text = source.get(operator.start_byte()..operator.end_byte())?;
+    LOGICAL_OPERATORS.contains(&text).then_some(text)
+}
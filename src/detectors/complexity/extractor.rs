@@ -16,6 +16,7 @@ use crate::core::ast_service::AstService;
 use crate::core::errors::Result;
 use crate::core::featureset::{CodeEntity, ExtractionContext, FeatureDefinition, FeatureExtractor};
 use crate::core::file_utils::ranges_overlap;
+use crate::lang::notebook::analysis_source;
 
 /// Feature extractor implementation for AST-based complexity
 pub struct AstComplexityExtractor {
@@ -67,7 +68,7 @@ impl AstComplexityExtractor {
         }
 
         let source = match tokio::fs::read_to_string(file_path).await {
-            Ok(contents) => contents,
+            Ok(contents) => analysis_source(Path::new(file_path), contents),
             Err(error) => {
                 warn!(
                     "Complexity extractor failed to read {}: {}",
@@ -3,6 +3,7 @@
 //! This module replaces the text-based complexity analysis with proper AST-based
 //! calculation using the central AST service for accurate complexity metrics.
 
+mod expression;
 mod extractor;
 mod halstead;
 pub mod types;
@@ -19,12 +20,14 @@ use crate::core::ast_service::{AstService, ComplexityMetrics as AstComplexityMet
 use crate::core::ast_utils::find_entity_node;
 use crate::core::errors::Result;
 use crate::core::featureset::{CodeEntity, EntityId};
+use crate::lang::notebook::analysis_source;
 
 // Re-export types from submodule
 pub use types::{
     ComplexityAnalysisResult, ComplexityConfig, ComplexityIssue, ComplexityIssueType,
-    ComplexityMetrics, ComplexitySeverity, ComplexityThresholds, DecisionPointInfo,
-    HalsteadMetrics,
+    ComplexityMetrics, ComplexityReport, ComplexitySeverity, ComplexityThresholds,
+    DecisionPointInfo, FunctionComplexity, HalsteadMetrics,
+    DEFAULT_EXPRESSION_COMPLEXITY_THRESHOLD,
 };
 
 /// AST-based complexity analyzer - the CORRECT implementation
@@ -59,6 +62,7 @@ impl AstComplexityAnalyzer {
         for file_path in file_paths {
             match fs::read_to_string(file_path).await {
                 Ok(source) => {
+                    let source = analysis_source(file_path, source);
                     match self
                         .analyze_file_with_results(file_path.to_string_lossy().as_ref(), &source)
                         .await
@@ -159,6 +163,7 @@ impl AstComplexityAnalyzer {
             "excessive_nesting" => ComplexityIssueType::DeepNesting,
             "too_many_parameters" => ComplexityIssueType::TooManyParameters,
             "large_file" => ComplexityIssueType::LongFile,
+            "high_expression_complexity" => ComplexityIssueType::HighExpressionComplexity,
             _ => ComplexityIssueType::HighTechnicalDebt,
         }
     }
@@ -407,6 +412,7 @@ impl AstComplexityAnalyzer {
         let parameter_count = self.count_parameters_in_entity(entity, context)?;
         let statement_count = self.count_statements_in_entity(entity, context)?;
         let halstead = self.calculate_halstead_for_entity(entity, context)?;
+        let expression_complexity = self.calculate_expression_complexity(entity, context);
         let maintainability_index =
             self.calculate_maintainability_index(entity_cyclomatic, lines_of_code, &halstead);
 
@@ -417,6 +423,7 @@ impl AstComplexityAnalyzer {
             parameter_count,
             lines_of_code,
             statement_count,
+            expression_complexity,
             halstead,
             technical_debt_score: self.calculate_technical_debt(
                 entity_cyclomatic,
@@ -484,6 +491,17 @@ impl AstComplexityAnalyzer {
         ))
     }
 
+    /// Calculate expression complexity for an entity
+    fn calculate_expression_complexity(
+        &self,
+        entity: &CodeEntity,
+        context: &crate::core::ast_service::AstContext<'_>,
+    ) -> f64 {
+        find_entity_node(context, entity).map_or(0.0, |node| {
+            expression::calculate_expression_complexity(node, context.source)
+        })
+    }
+
     /// Locates the parameters node for a function AST node.
     fn locate_parameters_node<'a>(
         &self,
@@ -690,6 +708,16 @@ impl AstComplexityAnalyzer {
             "Reduce nesting by using early returns or extracting functions",
         );
 
+        self.check_metric_threshold(
+            &mut issues,
+            entity_id,
+            metrics.expression_complexity,
+            &self.config.expression_thresholds,
+            "high_expression_complexity",
+            "Expression complexity",
+            "Name intermediate results and split long boolean conditions into helpers",
+        );
+
         issues
     }
 
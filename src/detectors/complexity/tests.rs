@@ -402,3 +402,107 @@ fn test_complexity_thresholds_validation() {
     assert!(file_thresholds.medium < file_thresholds.high);
     assert!(file_thresholds.high < file_thresholds.very_high);
 }
+
+fn function_result(name: &str, entity_type: &str, cyclomatic: f64) -> ComplexityAnalysisResult {
+    ComplexityAnalysisResult {
+        entity_id: format!("src/lib.py:{name}"),
+        file_path: "src/lib.py".to_string(),
+        line_number: 1,
+        start_line: 1,
+        entity_name: name.to_string(),
+        entity_type: entity_type.to_string(),
+        metrics: ComplexityMetrics {
+            cyclomatic_complexity: cyclomatic,
+            cognitive_complexity: 0.0,
+            max_nesting_depth: 0.0,
+            parameter_count: 0.0,
+            lines_of_code: 1.0,
+            statement_count: 1.0,
+            expression_complexity: 0.0,
+            halstead: HalsteadMetrics::default(),
+            technical_debt_score: 0.0,
+            maintainability_index: 100.0,
+            decision_points: Vec::new(),
+        },
+        issues: Vec::new(),
+        severity: ComplexitySeverity::Low,
+        recommendations: Vec::new(),
+    }
+}
+
+#[test]
+fn test_complexity_report_aggregates_functions_only() {
+    let mut results: Vec<_> = (1..=20)
+        .map(|i| function_result(&format!("f{i}"), "function", f64::from(i)))
+        .collect();
+    results.push(function_result("Widget", "class", 99.0));
+    results.push(function_result("render", "Method", 30.0));
+
+    let report = ComplexityReport::from_results(&results);
+
+    assert_eq!(report.function_count, 21);
+    assert!((report.mean_cyclomatic - 240.0 / 21.0).abs() < 1e-9);
+    assert_eq!(report.p95_cyclomatic, 20.0);
+    assert_eq!(report.max_cyclomatic, 30.0);
+    assert_eq!(report.most_complex.as_ref().unwrap().name, "render");
+
+    let exceeding = ComplexityReport::functions_exceeding(&results, 18.0);
+    let names: Vec<_> = exceeding.iter().map(|f| f.name.as_str()).collect();
+    assert_eq!(names, vec!["render", "f20", "f19"]);
+
+    assert_eq!(
+        ComplexityReport::from_results(&[]),
+        ComplexityReport::default()
+    );
+}
+
+#[tokio::test]
+async fn test_go_expression_complexity() {
+    let mut config = ComplexityConfig::default();
+    config.expression_thresholds = ComplexityThresholds::with_warning_threshold(5.0);
+    let analyzer = AstComplexityAnalyzer::new(config, Arc::new(AstService::new()));
+
+    let go_source = r#"
+package main
+
+func nested(x int) bool {
+	return check(parse(load(x))) && x > 0 || x < -10
+}
+
+func flat(x int) bool {
+	a := load(x)
+	b := parse(a)
+	return check(b)
+}
+
+func asserted(v any) int {
+	return v.(interface{ Len() int }).Len()
+}
+"#;
+
+    let results = analyzer
+        .analyze_file_with_results("main.go", go_source)
+        .await
+        .unwrap();
+    let by_name = |name: &str| {
+        results
+            .iter()
+            .find(|result| result.entity_name == name)
+            .unwrap_or_else(|| panic!("missing {name}"))
+    };
+
+    // Calls nested 0, 1 and 2 deep, `||`, and `&&` switching operator.
+    let nested = by_name("nested");
+    assert_eq!(nested.metrics.expression_complexity, 9.0);
+    assert!(nested
+        .issues
+        .iter()
+        .any(|issue| issue.issue_type == "HighExpressionComplexity"));
+
+    let flat = by_name("flat");
+    assert_eq!(flat.metrics.expression_complexity, 3.0);
+    assert!(flat.issues.is_empty());
+
+    // Method call, plus an assertion nested in its receiver to an anonymous type.
+    assert_eq!(by_name("asserted").metrics.expression_complexity, 3.0);
+}
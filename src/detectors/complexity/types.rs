@@ -2,6 +2,9 @@
 
 use serde::{Deserialize, Serialize};
 
+/// Default expression complexity above which a function is reported.
+pub const DEFAULT_EXPRESSION_COMPLEXITY_THRESHOLD: f64 = 20.0;
+
 /// Configuration for complexity analysis
 #[derive(Debug, Clone, Serialize, Deserialize)]
 pub struct ComplexityConfig {
@@ -19,6 +22,9 @@ pub struct ComplexityConfig {
     pub file_length_thresholds: ComplexityThresholds,
     /// Function length thresholds (lines)
     pub function_length_thresholds: ComplexityThresholds,
+    /// Expression complexity thresholds; issues are reported above `high`
+    #[serde(default = "ComplexityThresholds::default_expression")]
+    pub expression_thresholds: ComplexityThresholds,
 }
 
 /// Default implementation for [`ComplexityConfig`].
@@ -33,6 +39,7 @@ impl Default for ComplexityConfig {
             parameter_thresholds: ComplexityThresholds::default_parameters(),
             file_length_thresholds: ComplexityThresholds::default_file_length(),
             function_length_thresholds: ComplexityThresholds::default_function_length(),
+            expression_thresholds: ComplexityThresholds::default_expression(),
         }
     }
 }
@@ -107,6 +114,22 @@ impl ComplexityThresholds {
             very_high: 100.0,
         }
     }
+
+    /// Returns default thresholds for expression complexity.
+    pub fn default_expression() -> Self {
+        Self::with_warning_threshold(DEFAULT_EXPRESSION_COMPLEXITY_THRESHOLD)
+    }
+
+    /// Thresholds whose `high` level is `warning`, with the other levels
+    /// scaled around it.
+    pub fn with_warning_threshold(warning: f64) -> Self {
+        Self {
+            low: warning / 4.0,
+            medium: warning / 2.0,
+            high: warning,
+            very_high: warning * 2.0,
+        }
+    }
 }
 
 /// Complexity severity levels
@@ -164,6 +187,7 @@ pub enum ComplexityIssueType {
     LongFunction,
     LongFile,
     HighTechnicalDebt,
+    HighExpressionComplexity,
 }
 
 /// Enhanced complexity metrics from AST analysis
@@ -181,6 +205,9 @@ pub struct ComplexityMetrics {
     pub lines_of_code: f64,
     /// Number of statements
     pub statement_count: f64,
+    /// Expression complexity from nested calls, boolean chains and type assertions
+    #[serde(default)]
+    pub expression_complexity: f64,
     /// Halstead complexity metrics
     pub halstead: HalsteadMetrics,
     /// Technical debt score
@@ -263,3 +290,95 @@ pub struct ComplexityIssue {
     pub metric_value: f64,
     pub threshold: f64,
 }
+
+/// Cyclomatic complexity of a single function or method.
+#[derive(Debug, Clone, PartialEq, Serialize, Deserialize)]
+pub struct FunctionComplexity {
+    /// Entity ID of the function
+    pub entity_id: String,
+    /// Function or method name
+    pub name: String,
+    /// File declaring the function
+    pub file_path: String,
+    /// First line of the function
+    pub start_line: usize,
+    /// Real cyclomatic complexity from AST
+    pub cyclomatic_complexity: f64,
+    /// Expression complexity from nested calls, boolean chains and type assertions
+    #[serde(default)]
+    pub expression_complexity: f64,
+}
+
+/// Distribution of function-level cyclomatic complexity across a project.
+#[derive(Debug, Clone, Default, PartialEq, Serialize, Deserialize)]
+pub struct ComplexityReport {
+    /// Number of functions and methods measured
+    pub function_count: usize,
+    /// Mean cyclomatic complexity
+    pub mean_cyclomatic: f64,
+    /// 95th percentile cyclomatic complexity (nearest-rank)
+    pub p95_cyclomatic: f64,
+    /// Highest cyclomatic complexity
+    pub max_cyclomatic: f64,
+    /// The function with the highest cyclomatic complexity
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub most_complex: Option<FunctionComplexity>,
+}
+
+/// Aggregation methods for [`ComplexityReport`].
+impl ComplexityReport {
+    /// Summarize the functions and methods among `results`; other entities are ignored.
+    pub fn from_results(results: &[ComplexityAnalysisResult]) -> Self {
+        let mut functions: Vec<FunctionComplexity> = results
+            .iter()
+            .filter_map(FunctionComplexity::from_result)
+            .collect();
+        if functions.is_empty() {
+            return Self::default();
+        }
+        functions.sort_by(|a, b| a.cyclomatic_complexity.total_cmp(&b.cyclomatic_complexity));
+
+        let count = functions.len();
+        let total: f64 = functions.iter().map(|f| f.cyclomatic_complexity).sum();
+        let p95_rank = ((count as f64) * 0.95).ceil() as usize;
+        Self {
+            function_count: count,
+            mean_cyclomatic: total / count as f64,
+            p95_cyclomatic: functions[p95_rank.clamp(1, count) - 1].cyclomatic_complexity,
+            max_cyclomatic: functions[count - 1].cyclomatic_complexity,
+            most_complex: functions.pop(),
+        }
+    }
+
+    /// Functions and methods whose cyclomatic complexity exceeds `threshold`, most complex first.
+    pub fn functions_exceeding(
+        results: &[ComplexityAnalysisResult],
+        threshold: f64,
+    ) -> Vec<FunctionComplexity> {
+        let mut exceeding: Vec<FunctionComplexity> = results
+            .iter()
+            .filter_map(FunctionComplexity::from_result)
+            .filter(|f| f.cyclomatic_complexity > threshold)
+            .collect();
+        exceeding.sort_by(|a, b| b.cyclomatic_complexity.total_cmp(&a.cyclomatic_complexity));
+        exceeding
+    }
+}
+
+/// Conversion from detector results for [`FunctionComplexity`].
+impl FunctionComplexity {
+    /// Extract the function-level view of a result, or `None` for non-function entities.
+    fn from_result(result: &ComplexityAnalysisResult) -> Option<Self> {
+        let is_function = ["function", "method"]
+            .iter()
+            .any(|kind| result.entity_type.eq_ignore_ascii_case(kind));
+        is_function.then(|| Self {
+            entity_id: result.entity_id.clone(),
+            name: result.entity_name.clone(),
+            file_path: result.file_path.clone(),
+            start_line: result.start_line,
+            cyclomatic_complexity: result.metrics.cyclomatic_complexity,
+            expression_complexity: result.metrics.expression_complexity,
+        })
+    }
+}
@@ -0,0 +1,404 @@
+//! Constructor bypass detection for Go structs.
+//!
+//! A struct whose package offers a constructor (`NewComplexStruct` returning
+//! `ComplexStruct` or `*ComplexStruct`) usually relies on it to enforce
+//! invariants. [`detect_constructor_bypass`] finds the constructors of every
+//! struct, counts their call sites, and reports the files that build the same
+//! struct with a composite literal (`ComplexStruct{Field: v}` or
+//! `store.ComplexStruct{...}`) instead.
+//!
+//! A struct is reported when its constructors are called somewhere and a
+//! literal elsewhere sets its fields. A `//valknut:require-constructor`
+//! comment above the type declaration makes the check mandatory: every
+//! literal of the type is reported, including empty ones, whether or not the
+//! constructor is used. Literals inside the type's own constructors and
+//! methods are always allowed.
+//!
+//! Types are matched by name: unqualified literals and calls refer to the
+//! file's own package directory, and `pkg.Type` refers to the packages whose
+//! clause name or directory name is `pkg`.
+
+use std::collections::{BTreeMap, HashMap, HashSet};
+
+use serde::{Deserialize, Serialize};
+
+use super::code_smells::{split_top_level, GoFile};
+use super::lock_contention::{matching, package_of, qualify, tokenize, Token};
+use crate::lang::go::is_exported;
+
+/// Directive comment that makes constructor use mandatory for a type.
+pub const REQUIRE_CONSTRUCTOR_DIRECTIVE: &str = "//valknut:require-constructor";
+
+/// Tokens after which `Type{` is a composite literal rather than, say, a
+/// result type followed by a function body.
+const LITERAL_PREFIXES: &[&str] = &["=", "(", ",", "{", "[", "&", ":", "return"];
+
+/// A file that builds a struct with literals instead of its constructor.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct ConstructorBypass {
+    /// Struct type: `<package>.<Type>`, bare in the root package.
+    pub type_name: String,
+    /// Constructors of the type, sorted.
+    pub constructors: Vec<String>,
+    /// Constructor call sites across the analysed files.
+    pub constructor_calls: usize,
+    /// Whether the type carries [`REQUIRE_CONSTRUCTOR_DIRECTIVE`].
+    pub required: bool,
+    /// File building the type with literals.
+    pub file: String,
+    /// 1-based lines of the literals in `file`.
+    pub lines: Vec<usize>,
+}
+
+/// A struct type and what is known about building it.
+#[derive(Debug, Default)]
+struct StructInfo {
+    /// Whether the declaration carries the directive.
+    required: bool,
+    /// Constructor names.
+    constructors: Vec<String>,
+    /// Constructor call sites.
+    calls: usize,
+    /// Literal sites by file: line and whether the literal sets any field.
+    literals: BTreeMap<String, Vec<(usize, bool)>>,
+}
+
+/// One parsed file.
+struct ParsedFile<'a> {
+    /// Path relative to the project root.
+    path: &'a str,
+    /// Package directory.
+    package: String,
+    /// Tokens of the source.
+    tokens: Vec<Token>,
+    /// Top-level declarations.
+    decls: GoFile,
+}
+
+/// Analyse Go `files` (project-relative path and source) and report every
+/// file that bypasses a struct's constructor, sorted by type and file.
+pub fn detect_constructor_bypass(files: &[(String, String)]) -> Vec<ConstructorBypass> {
+    let parsed: Vec<ParsedFile> = files
+        .iter()
+        .map(|(path, source)| {
+            let tokens = tokenize(source);
+            ParsedFile {
+                path,
+                package: package_of(path),
+                decls: GoFile::parse(&tokens),
+                tokens,
+            }
+        })
+        .collect();
+
+    // Struct types by package directory and name, and the clause name of
+    // every package directory.
+    let mut structs: BTreeMap<(String, String), StructInfo> = BTreeMap::new();
+    let mut clause_names: HashMap<String, String> = HashMap::new();
+    for ((_, source), file) in files.iter().zip(&parsed) {
+        clause_names.insert(file.package.clone(), file.decls.package.clone());
+        let lines: Vec<&str> = source.lines().collect();
+        for spec in file.decls.types.iter().filter(|spec| spec.is_struct) {
+            let info = structs
+                .entry((file.package.clone(), spec.name.clone()))
+                .or_default();
+            info.required |= has_directive(&lines, spec.line);
+        }
+    }
+
+    // Constructors: `New...`/`new...` functions returning a struct of their
+    // own package.
+    let mut constructors: HashMap<(String, String), (String, String)> = HashMap::new();
+    for file in &parsed {
+        for function in file.decls.functions.iter().filter(|f| f.receiver.is_none()) {
+            if !function.name.starts_with("New") && !function.name.starts_with("new") {
+                continue;
+            }
+            let (start, end) = function.result_types;
+            let returned = file.tokens[start..end]
+                .iter()
+                .enumerate()
+                .filter(|(i, token)| {
+                    token.is_ident() && !(*i > 0 && file.tokens[start + i - 1].is('.'))
+                })
+                .find_map(|(_, token)| {
+                    let key = (file.package.clone(), token.text.clone());
+                    structs.contains_key(&key).then_some(key)
+                });
+            if let Some(key) = returned {
+                let info = structs.get_mut(&key).expect("constructor type is a struct");
+                if !info.constructors.contains(&function.name) {
+                    info.constructors.push(function.name.clone());
+                }
+                constructors.insert((file.package.clone(), function.name.clone()), key);
+            }
+        }
+    }
+
+    // Only struct and constructor names can start a site worth resolving.
+    let names: HashSet<String> = structs
+        .keys()
+        .chain(constructors.keys())
+        .map(|(_, name)| name.clone())
+        .collect();
+    for file in &parsed {
+        let tokens = &file.tokens;
+        for i in 0..tokens.len() {
+            let token = &tokens[i];
+            let next = tokens.get(i + 1);
+            if !names.contains(&token.text) || !next.is_some_and(|t| t.is('(') || t.is('{')) {
+                continue;
+            }
+            let qualifier = (i > 1 && tokens[i - 1].is('.') && tokens[i - 2].is_ident())
+                .then(|| tokens[i - 2].text.as_str());
+            let packages = resolve(qualifier, &file.package, &clause_names);
+
+            if next.is_some_and(|t| t.is('(')) {
+                if i > 0 && tokens[i - 1].text == "func" {
+                    continue;
+                }
+                for package in packages {
+                    if let Some(key) = constructors.get(&(package, token.text.clone())) {
+                        if let Some(info) = structs.get_mut(key) {
+                            info.calls += 1;
+                        }
+                    }
+                }
+                continue;
+            }
+
+            let start = if qualifier.is_some() { i - 2 } else { i };
+            let is_literal =
+                start > 0 && LITERAL_PREFIXES.contains(&tokens[start - 1].text.as_str());
+            if !is_literal {
+                continue;
+            }
+            for package in packages {
+                let Some(info) = structs.get_mut(&(package.clone(), token.text.clone())) else {
+                    continue;
+                };
+                if builds_own_type(file, &package, &token.text, &info.constructors, i) {
+                    continue;
+                }
+                let close = matching(tokens, i + 1);
+                let sets_fields =
+                    split_top_level(tokens, i + 2, close, ',')
+                        .into_iter()
+                        .any(|(start, _)| {
+                            let keyed = tokens.get(start + 1).is_some_and(|t| t.is(':'));
+                            !keyed || is_exported(&tokens[start].text)
+                        });
+                info.literals
+                    .entry(file.path.to_string())
+                    .or_default()
+                    .push((token.line, sets_fields));
+            }
+        }
+    }
+
+    let mut bypasses = Vec::new();
+    for ((package, name), info) in structs {
+        let enforced = info.required || (!info.constructors.is_empty() && info.calls > 0);
+        if !enforced {
+            continue;
+        }
+        let mut constructors = info.constructors;
+        constructors.sort();
+        for (file, literals) in info.literals {
+            let lines: Vec<usize> = literals
+                .into_iter()
+                .filter(|(_, sets_fields)| info.required || *sets_fields)
+                .map(|(line, _)| line)
+                .collect();
+            if lines.is_empty() {
+                continue;
+            }
+            bypasses.push(ConstructorBypass {
+                type_name: qualify(&package, &name),
+                constructors: constructors.clone(),
+                constructor_calls: info.calls,
+                required: info.required,
+                file,
+                lines,
+            });
+        }
+    }
+    bypasses
+}
+
+/// Whether the comment block directly above `line` holds the directive.
+fn has_directive(lines: &[&str], line: usize) -> bool {
+    lines[..line.saturating_sub(1).min(lines.len())]
+        .iter()
+        .rev()
+        .map(|line| line.trim())
+        .take_while(|line| line.starts_with("//"))
+        .any(|line| line.starts_with(REQUIRE_CONSTRUCTOR_DIRECTIVE))
+}
+
+/// Package directories a name with `qualifier` can refer to from `package`.
+fn resolve(
+    qualifier: Option<&str>,
+    package: &str,
+    clause_names: &HashMap<String, String>,
+) -> Vec<String> {
+    let Some(qualifier) = qualifier else {
+        return vec![package.to_string()];
+    };
+    clause_names
+        .iter()
+        .filter(|(dir, clause)| {
+            let dir_name = dir.rsplit('/').next().unwrap_or(dir);
+            dir.as_str() != package && (clause.as_str() == qualifier || dir_name == qualifier)
+        })
+        .map(|(dir, _)| dir.clone())
+        .collect()
+}
+
+/// Whether token `index` of `file` lies in a constructor or method of the
+/// struct `name` declared in `package`.
+fn builds_own_type(
+    file: &ParsedFile,
+    package: &str,
+    name: &str,
+    constructors: &[String],
+    index: usize,
+) -> bool {
+    file.package == package
+        && file.decls.functions.iter().any(|function| {
+            let owns = match &function.receiver {
+                Some(receiver) => receiver == name,
+                None => constructors.contains(&function.name),
+            };
+            owns && function
+                .body
+                .is_some_and(|(start, end)| (start..end).contains(&index))
+        })
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    const STORE: &str = r#"package store
+
+// ComplexStruct keeps its index in sync with its items.
+type ComplexStruct struct {
+	Name  string
+	Items []string
+	index map[string]int
+}
+
+// NewComplexStruct builds a ComplexStruct with its index.
+func NewComplexStruct(name string) *ComplexStruct {
+	return &ComplexStruct{Name: name, index: map[string]int{}}
+}
+
+func (c *ComplexStruct) Clone() *ComplexStruct {
+	return &ComplexStruct{Name: c.Name, index: c.index}
+}
+
+func Default() ComplexStruct {
+	return ComplexStruct{Name: "default"}
+}
+
+func Empty() *ComplexStruct {
+	return &ComplexStruct{}
+}
+
+// Plain has no constructor.
+type Plain struct {
+	Value int
+}
+
+//valknut:require-constructor
+type Handle struct {
+	fd int
+}
+
+func newHandle(fd int) *Handle {
+	return &Handle{fd: fd}
+}
+"#;
+
+    const API: &str = r#"package api
+
+import "example.com/app/store"
+
+func Build() []*store.ComplexStruct {
+	a := store.NewComplexStruct("a")
+	b := &store.ComplexStruct{Name: "b", Items: nil}
+	return []*store.ComplexStruct{a, b}
+}
+
+func Plain() store.Plain {
+	return store.Plain{Value: 1}
+}
+
+func open() store.Handle {
+	return store.Handle{}
+}
+"#;
+
+    fn detect() -> Vec<ConstructorBypass> {
+        detect_constructor_bypass(&[
+            ("store/store.go".to_string(), STORE.to_string()),
+            ("api/api.go".to_string(), API.to_string()),
+        ])
+    }
+
+    #[test]
+    fn literals_bypassing_a_used_constructor_are_reported_per_file() {
+        let bypasses = detect();
+        let complex: Vec<(&str, &[usize])> = bypasses
+            .iter()
+            .filter(|bypass| bypass.type_name == "store.ComplexStruct")
+            .map(|bypass| (bypass.file.as_str(), bypass.lines.as_slice()))
+            .collect();
+        // The constructor, the method and the empty literal are allowed.
+        assert_eq!(
+            complex,
+            vec![("api/api.go", &[7][..]), ("store/store.go", &[20][..])]
+        );
+
+        let api = &bypasses[0];
+        assert_eq!(api.constructors, vec!["NewComplexStruct".to_string()]);
+        assert_eq!(api.constructor_calls, 1);
+        assert!(!api.required);
+        assert!(bypasses
+            .iter()
+            .all(|bypass| bypass.type_name != "store.Plain"));
+    }
+
+    #[test]
+    fn directive_makes_every_literal_a_bypass() {
+        let bypasses = detect();
+        let handle: Vec<&ConstructorBypass> = bypasses
+            .iter()
+            .filter(|bypass| bypass.type_name == "store.Handle")
+            .collect();
+        assert_eq!(handle.len(), 1);
+        assert_eq!(handle[0].file, "api/api.go");
+        assert_eq!(handle[0].lines, vec![16]);
+        assert!(handle[0].required);
+        assert_eq!(handle[0].constructors, vec!["newHandle".to_string()]);
+        assert_eq!(handle[0].constructor_calls, 0);
+    }
+
+    #[test]
+    fn unused_constructors_and_result_types_are_not_literals() {
+        let source = "package shapes\n\ntype Circle struct {\n\tRadius float64\n}\n\nfunc NewCircle() *Circle {\n\treturn &Circle{Radius: 1}\n}\n\nfunc Unit() Circle {\n\treturn Circle{Radius: 1}\n}\n";
+        let files = [("shapes/circle.go".to_string(), source.to_string())];
+        assert!(detect_constructor_bypass(&files).is_empty());
+
+        let caller = "package main\n\nfunc main() {\n\t_ = shapes.NewCircle()\n}\n";
+        let files = [
+            files[0].clone(),
+            ("cmd/main.go".to_string(), caller.to_string()),
+        ];
+        let bypasses = detect_constructor_bypass(&files);
+        assert_eq!(bypasses.len(), 1);
+        assert_eq!(bypasses[0].type_name, "shapes.Circle");
+        assert_eq!(bypasses[0].lines, vec![12]);
+    }
+}
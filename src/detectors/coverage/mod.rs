@@ -173,13 +173,7 @@ impl CoverageExtractor {
 
     /// Sort packs by priority (highest value/effort ratio first).
     fn sort_packs_by_priority(&self, packs: &mut [CoveragePack]) {
-        packs.sort_by(|a, b| {
-            let score_a = a.value.repo_cov_gain_est / (a.effort.tests_to_write_est as f64 + 1.0);
-            let score_b = b.value.repo_cov_gain_est / (b.effort.tests_to_write_est as f64 + 1.0);
-            score_b
-                .partial_cmp(&score_a)
-                .unwrap_or(std::cmp::Ordering::Equal)
-        });
+        packs.sort_by(|a, b| b.priority_score().total_cmp(&a.priority_score()));
     }
 
     async fn build_gaps_for_file(
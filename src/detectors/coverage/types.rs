@@ -113,6 +113,15 @@ pub struct CoveragePack {
     pub effort: PackEffort,
 }
 
+/// Ranking helpers for [`CoveragePack`].
+impl CoveragePack {
+    /// Estimated repository coverage gain per test to write; packs are
+    /// reported from the highest score down.
+    pub fn priority_score(&self) -> f64 {
+        self.value.repo_cov_gain_est / (self.effort.tests_to_write_est as f64 + 1.0)
+    }
+}
+
 /// File-level coverage information
 #[derive(Debug, Clone, Serialize, Deserialize)]
 pub struct FileInfo {
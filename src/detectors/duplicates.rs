@@ -0,0 +1,427 @@
+//! Exact structural clone detection.
+//!
+//! Every function is reduced to a fingerprint of its normalized syntax tree:
+//! node kinds and nesting are kept, while identifier names, literal values and
+//! comments are dropped. Functions with equal fingerprints are structurally
+//! identical (type-2 clones in the clone detection literature), so
+//! copy-pasted code that differs only in a name or a constant ends up in the
+//! same clone class. Unlike the LSH pass this finds exact matches only, but it
+//! needs no similarity threshold.
+
+use std::collections::hash_map::DefaultHasher;
+use std::collections::HashMap;
+use std::hash::{Hash, Hasher};
+use std::path::{Path, PathBuf};
+
+use rayon::prelude::*;
+use serde::{Deserialize, Serialize};
+use tree_sitter::Node;
+
+use crate::core::ast_utils::walk_tree;
+use crate::core::file_utils::relative_to_root;
+use crate::lang::registry::{create_parser_for_language, grammar_key_for_path};
+
+/// Default minimum function size, in normalized AST nodes.
+pub const DEFAULT_MIN_CLONE_NODES: usize = 50;
+
+/// Node kinds of function and method definitions across the supported grammars.
+const FUNCTION_KINDS: &[&str] = &[
+    "function_definition",
+    "function_declaration",
+    "function_item",
+    "method_declaration",
+    "method_definition",
+    "constructor_declaration",
+    "local_function_statement",
+];
+
+/// Kind fragments marking literal nodes, whose subtree is hashed as one leaf.
+const LITERAL_KIND_MARKERS: &[&str] = &[
+    "string",
+    "number",
+    "integer",
+    "float",
+    "char",
+    "rune",
+    "imaginary",
+    "boolean",
+    "true",
+    "false",
+];
+
+/// Marker hashed when the traversal leaves a node, so nesting is part of the fingerprint.
+const CLOSE_MARKER: &str = ")";
+
+/// One function of a clone class.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct CloneInstance {
+    /// File declaring the function.
+    pub path: PathBuf,
+    /// Function name, or `<anonymous>`.
+    pub name: String,
+    /// 1-based first line.
+    pub start_line: usize,
+    /// 1-based last line.
+    pub end_line: usize,
+}
+
+/// Functions that are identical after normalization.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct CloneClass {
+    /// Hex fingerprint of the normalized syntax tree.
+    pub fingerprint: String,
+    /// Size of each function, in normalized AST nodes.
+    pub node_count: usize,
+    /// The clones, sorted by path and line.
+    pub instances: Vec<CloneInstance>,
+}
+
+/// Pair enumeration for [`CloneClass`].
+impl CloneClass {
+    /// Every clone pair in the class.
+    pub fn pairs(&self) -> impl Iterator<Item = (&CloneInstance, &CloneInstance)> {
+        self.instances
+            .iter()
+            .enumerate()
+            .flat_map(move |(index, first)| {
+                self.instances[index + 1..]
+                    .iter()
+                    .map(move |second| (first, second))
+            })
+    }
+}
+
+/// Clone classes found in an analysis run.
+#[derive(Debug, Clone, Default, PartialEq, Eq, Serialize, Deserialize)]
+pub struct DuplicateCode {
+    /// Clone classes, largest functions first.
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub classes: Vec<CloneClass>,
+}
+
+/// Detection and query methods for [`DuplicateCode`].
+impl DuplicateCode {
+    /// Group the functions of the given files into clone classes.
+    ///
+    /// Functions smaller than `min_nodes` normalized AST nodes are ignored, as
+    /// are files without a tree-sitter grammar. A class nested entirely inside
+    /// the instances of a larger class is dropped: the outer clone already
+    /// reports it.
+    pub fn detect<'a>(
+        files: impl IntoIterator<Item = (&'a Path, &'a str)>,
+        min_nodes: usize,
+    ) -> Self {
+        let files: Vec<(&Path, &str)> = files.into_iter().collect();
+        let functions: Vec<(u64, usize, CloneInstance)> = files
+            .par_iter()
+            .flat_map_iter(|(path, source)| function_fingerprints(path, source))
+            .filter(|(_, node_count, _)| *node_count >= min_nodes)
+            .collect();
+
+        let mut groups: HashMap<u64, (usize, Vec<CloneInstance>)> = HashMap::new();
+        for (fingerprint, node_count, instance) in functions {
+            groups
+                .entry(fingerprint)
+                .or_insert_with(|| (node_count, Vec::new()))
+                .1
+                .push(instance);
+        }
+
+        let mut classes: Vec<CloneClass> = groups
+            .into_iter()
+            .filter(|(_, (_, instances))| instances.len() > 1)
+            .map(|(fingerprint, (node_count, instances))| CloneClass {
+                fingerprint: format!("{fingerprint:016x}"),
+                node_count,
+                instances,
+            })
+            .collect();
+        sort_classes(&mut classes);
+
+        let mut kept: Vec<CloneClass> = Vec::with_capacity(classes.len());
+        for class in classes {
+            let nested = class.instances.iter().all(|instance| {
+                kept.iter()
+                    .flat_map(|outer| &outer.instances)
+                    .any(|outer| contains(outer, instance))
+            });
+            if !nested {
+                kept.push(class);
+            }
+        }
+        Self { classes: kept }
+    }
+
+    /// The same classes with paths relative to `root` where possible.
+    pub fn relative_to(&self, root: &Path) -> Self {
+        let classes = self
+            .classes
+            .iter()
+            .map(|class| CloneClass {
+                instances: class
+                    .instances
+                    .iter()
+                    .map(|instance| CloneInstance {
+                        path: relative_to_root(&instance.path, root),
+                        ..instance.clone()
+                    })
+                    .collect(),
+                ..class.clone()
+            })
+            .collect();
+        Self { classes }
+    }
+
+    /// Add the classes of another run; classes with the same fingerprint are joined.
+    pub fn merge(&mut self, other: DuplicateCode) {
+        for class in other.classes {
+            match self
+                .classes
+                .iter_mut()
+                .find(|existing| existing.fingerprint == class.fingerprint)
+            {
+                Some(existing) => {
+                    for instance in class.instances {
+                        if !existing.instances.contains(&instance) {
+                            existing.instances.push(instance);
+                        }
+                    }
+                }
+                None => self.classes.push(class),
+            }
+        }
+        self.sort();
+    }
+
+    /// Sort classes largest first and instances by path and line.
+    pub fn sort(&mut self) {
+        sort_classes(&mut self.classes);
+    }
+
+    /// Whether no clones were found.
+    pub fn is_empty(&self) -> bool {
+        self.classes.is_empty()
+    }
+
+    /// Number of clone pairs across all classes.
+    pub fn pair_count(&self) -> usize {
+        self.classes
+            .iter()
+            .map(|class| class.instances.len() * (class.instances.len() - 1) / 2)
+            .sum()
+    }
+}
+
+/// Sort instances by location and classes by size, then first location.
+fn sort_classes(classes: &mut [CloneClass]) {
+    for class in classes.iter_mut() {
+        class
+            .instances
+            .sort_by(|a, b| (&a.path, a.start_line).cmp(&(&b.path, b.start_line)));
+    }
+    classes.sort_by(|a, b| {
+        b.node_count.cmp(&a.node_count).then_with(|| {
+            let first = |class: &CloneClass| {
+                class
+                    .instances
+                    .first()
+                    .map(|instance| (instance.path.clone(), instance.start_line))
+            };
+            first(a).cmp(&first(b))
+        })
+    });
+}
+
+/// Whether `inner` lies within `outer` in the same file.
+fn contains(outer: &CloneInstance, inner: &CloneInstance) -> bool {
+    outer.path == inner.path
+        && outer.start_line <= inner.start_line
+        && inner.end_line <= outer.end_line
+        && outer != inner
+}
+
+/// Fingerprint, node count and location of every function in a file.
+fn function_fingerprints(path: &Path, source: &str) -> Vec<(u64, usize, CloneInstance)> {
+    let language = grammar_key_for_path(&path.to_string_lossy());
+    let Ok(mut parser) = create_parser_for_language(&language) else {
+        return Vec::new();
+    };
+    let Some(tree) = parser.parse(source, None) else {
+        return Vec::new();
+    };
+
+    let mut functions = Vec::new();
+    walk_tree(tree.root_node(), &mut |node| {
+        if !FUNCTION_KINDS.contains(&node.kind()) {
+            return;
+        }
+        let (fingerprint, node_count) = normalized_fingerprint(node);
+        let name = node
+            .child_by_field_name("name")
+            .and_then(|name| name.utf8_text(source.as_bytes()).ok())
+            .unwrap_or("<anonymous>")
+            .to_string();
+        functions.push((
+            fingerprint,
+            node_count,
+            CloneInstance {
+                path: path.to_path_buf(),
+                name,
+                start_line: node.start_position().row + 1,
+                end_line: node.end_position().row + 1,
+            },
+        ));
+    });
+    functions
+}
+
+/// Hash of the normalized subtree rooted at `root`, and its node count.
+///
+/// Node kinds and nesting are hashed; identifier and literal text is not, a
+/// literal's subtree counts as a single node, and comments are skipped.
+fn normalized_fingerprint(root: Node) -> (u64, usize) {
+    let mut hasher = DefaultHasher::new();
+    let mut node_count = 0;
+    let mut cursor = root.walk();
+    let mut depth = 0usize;
+    loop {
+        let node = cursor.node();
+        let is_comment = node.is_extra() || node.kind().contains("comment");
+        let is_literal = !is_comment && is_literal_kind(node.kind());
+        if !is_comment {
+            node_count += 1;
+            let kind = if is_literal { "literal" } else { node.kind() };
+            kind.hash(&mut hasher);
+        }
+        if !is_comment && !is_literal && cursor.goto_first_child() {
+            depth += 1;
+            continue;
+        }
+
+        // Close finished nodes until one has a next sibling.
+        let mut close = !is_comment;
+        loop {
+            if close {
+                CLOSE_MARKER.hash(&mut hasher);
+            }
+            close = true;
+            if depth == 0 {
+                return (hasher.finish(), node_count);
+            }
+            if cursor.goto_next_sibling() {
+                break;
+            }
+            cursor.goto_parent();
+            depth -= 1;
+        }
+    }
+}
+
+/// Whether a node kind is a literal whose value should be ignored.
+fn is_literal_kind(kind: &str) -> bool {
+    LITERAL_KIND_MARKERS
+        .iter()
+        .any(|marker| kind.contains(marker))
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    const HANDLERS: &str = r#"package api
+
+// Retry policy for the orders endpoint.
+func ordersRetries(attempts int) int {
+	total := 0
+	for i := 0; i < attempts; i++ {
+		if i%2 == 0 {
+			total += 3
+		} else {
+			total -= 1
+		}
+	}
+	return total * 10
+}
+
+func paymentsRetries(tries int) int {
+	sum := 0
+	for j := 0; j < tries; j++ {
+		// Payments back off harder.
+		if j%2 == 0 {
+			sum += 5
+		} else {
+			sum -= 1
+		}
+	}
+	return sum * 10
+}
+
+func invoicesRetries(tries int) int {
+	sum := 0
+	for j := 0; j < tries; j++ {
+		if j%2 == 0 {
+			sum += 5
+		}
+	}
+	return sum * 10
+}
+"#;
+
+    fn detect(files: &[(&str, &str)], min_nodes: usize) -> DuplicateCode {
+        DuplicateCode::detect(
+            files
+                .iter()
+                .map(|(path, source)| (Path::new(*path), *source)),
+            min_nodes,
+        )
+    }
+
+    #[test]
+    fn groups_functions_differing_only_in_names_literals_and_comments() {
+        let duplicates = detect(&[("api/handlers.go", HANDLERS)], 10);
+        assert_eq!(duplicates.classes.len(), 1);
+        let class = &duplicates.classes[0];
+        let names: Vec<&str> = class
+            .instances
+            .iter()
+            .map(|instance| instance.name.as_str())
+            .collect();
+        assert_eq!(names, vec!["ordersRetries", "paymentsRetries"]);
+        assert_eq!(class.instances[0].start_line, 4);
+        assert_eq!(class.instances[0].end_line, 14);
+        assert_eq!(class.pairs().count(), 1);
+        assert_eq!(duplicates.pair_count(), 1);
+    }
+
+    #[test]
+    fn min_nodes_filters_small_functions() {
+        let duplicates = detect(&[("api/handlers.go", HANDLERS)], 1_000);
+        assert!(duplicates.is_empty());
+    }
+
+    #[test]
+    fn clones_across_files_and_nested_classes() {
+        let python =
+            "def area(w, h):\n    def scale(x):\n        return x * 2\n    return scale(w) * h\n";
+        let copy =
+            "def surface(a, b):\n    def grow(y):\n        return y * 7\n    return grow(a) * b\n";
+        let duplicates = detect(&[("geo/a.py", python), ("geo/b.py", copy)], 5);
+
+        // The nested `scale`/`grow` clones are covered by the outer class.
+        assert_eq!(duplicates.classes.len(), 1);
+        let paths: Vec<&Path> = duplicates.classes[0]
+            .instances
+            .iter()
+            .map(|instance| instance.path.as_path())
+            .collect();
+        assert_eq!(paths, vec![Path::new("geo/a.py"), Path::new("geo/b.py")]);
+
+        let relative = duplicates.relative_to(Path::new("geo"));
+        assert_eq!(relative.classes[0].instances[0].path, Path::new("a.py"));
+
+        let mut merged = DuplicateCode::default();
+        merged.merge(duplicates.clone());
+        merged.merge(duplicates.clone());
+        assert_eq!(merged, duplicates);
+    }
+}
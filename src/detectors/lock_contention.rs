@@ -0,0 +1,1055 @@
+//! Lock-ordering analysis for Go mutexes.
+//!
+//! [`detect_lock_contention`] finds the `sync.Mutex` and `sync.RWMutex` fields
+//! of every struct (and package-level mutex variables), then walks each
+//! function body in source order, tracking which of those locks are held.
+//! Taking a lock while holding another records a [`LockOrder`]; a call made
+//! while holding a lock records an order for every lock the callee takes,
+//! following call chains up to a configurable depth. Two functions that take
+//! the same pair of locks in opposite orders can deadlock each other and are
+//! reported as a [`LockOrderViolation`].
+//!
+//! Locks are identified by the struct field that declares them
+//! (`store.Parent.mu`), so every instance of a type shares one identity, the
+//! usual granularity for lock-order checking. Lock expressions are resolved
+//! through the receiver, the parameters and the declared field types; locks
+//! reached through local variables or other packages are not tracked.
+
+use std::collections::{BTreeMap, BTreeSet, HashMap, HashSet};
+use std::path::Path;
+
+use serde::{Deserialize, Serialize};
+
+/// Methods that acquire a lock.
+const ACQUIRE_METHODS: &[&str] = &["Lock", "RLock"];
+
+/// Methods that release a lock.
+const RELEASE_METHODS: &[&str] = &["Unlock", "RUnlock"];
+
+/// Go keywords that can precede `(` without being a call.
+const KEYWORDS: &[&str] = &[
+    "if",
+    "for",
+    "switch",
+    "select",
+    "return",
+    "func",
+    "go",
+    "defer",
+    "range",
+    "case",
+    "var",
+    "const",
+    "type",
+    "map",
+    "chan",
+    "struct",
+    "interface",
+    "else",
+];
+
+/// A mutex declared as a struct field or a package-level variable.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct MutexField {
+    /// Lock identity: `<package>.<Type>.<field>`, or `<package>.<var>`.
+    pub lock: String,
+    /// Whether the mutex is a `sync.RWMutex`.
+    pub read_write: bool,
+    /// Declaring file relative to the project root.
+    pub file: String,
+    /// 1-based declaration line.
+    pub line: usize,
+}
+
+/// `second` was acquired while `first` was held.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct LockOrder {
+    /// Lock held first.
+    pub first: String,
+    /// Lock acquired while `first` was held.
+    pub second: String,
+    /// Function holding `first`.
+    pub function: String,
+    /// File of `function` relative to the project root.
+    pub file: String,
+    /// 1-based line acquiring `second`, or calling into the function that does.
+    pub line: usize,
+    /// Call chain from `function` to the function acquiring `second`; empty
+    /// when `function` acquires it itself.
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub via: Vec<String>,
+}
+
+/// A pair of locks taken in both orders, which can deadlock.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct LockOrderViolation {
+    /// An order taking the lower-named lock first.
+    pub forward: LockOrder,
+    /// An order taking the same locks the other way round.
+    pub reverse: LockOrder,
+}
+
+/// A function that holds more than one lock, directly or through its callees.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct LockingFunction {
+    /// Function identity: `<package>.<name>` or `<package>.<Type>.<method>`.
+    pub function: String,
+    /// File relative to the project root.
+    pub file: String,
+    /// 1-based line of the declaration.
+    pub line: usize,
+    /// Locks the function acquires, sorted.
+    pub locks: Vec<String>,
+}
+
+/// Mutexes, nested locking and lock-order inversions found in Go sources.
+#[derive(Debug, Clone, Default, PartialEq, Eq, Serialize, Deserialize)]
+pub struct LockContentionReport {
+    /// Every mutex field and variable, sorted by lock.
+    pub mutexes: Vec<MutexField>,
+    /// Functions acquiring two or more locks, sorted by function.
+    pub multi_lock_functions: Vec<LockingFunction>,
+    /// Every lock acquired while another was held, sorted.
+    pub orders: Vec<LockOrder>,
+    /// Lock pairs acquired in both orders, sorted by lock pair.
+    pub violations: Vec<LockOrderViolation>,
+}
+
+/// Analyse Go `files` (project-relative path and source), following calls made
+/// under a lock up to `max_depth` levels deep (0 = only locks taken directly).
+pub fn detect_lock_contention(
+    files: &[(String, String)],
+    max_depth: usize,
+) -> LockContentionReport {
+    let mut model = Model::default();
+    let parsed: Vec<(String, Vec<Token>)> = files
+        .iter()
+        .map(|(path, source)| (path.clone(), tokenize(source)))
+        .collect();
+    for (path, tokens) in &parsed {
+        model.collect(path, tokens);
+    }
+
+    let events: BTreeMap<String, Vec<Event>> = model
+        .functions
+        .iter()
+        .map(|(id, function)| {
+            let tokens = &parsed[function.file_index].1;
+            (id.clone(), model.events(function, tokens))
+        })
+        .collect();
+    let analysis = Analysis { events: &events };
+
+    let mut orders = BTreeSet::new();
+    let mut multi_lock_functions = Vec::new();
+    for (id, function) in &model.functions {
+        orders.extend(analysis.orders(id, function, max_depth));
+        let locks = analysis.acquired(id, max_depth + 1, &mut HashSet::new());
+        if locks.len() > 1 {
+            multi_lock_functions.push(LockingFunction {
+                function: id.clone(),
+                file: function.file.clone(),
+                line: function.line,
+                locks: locks.into_keys().collect(),
+            });
+        }
+    }
+    let orders: Vec<LockOrder> = orders.into_iter().map(|OrderKey(order)| order).collect();
+
+    let mut first_by_pair: BTreeMap<(&str, &str), &LockOrder> = BTreeMap::new();
+    for order in &orders {
+        first_by_pair
+            .entry((&order.first, &order.second))
+            .or_insert(order);
+    }
+    let violations = first_by_pair
+        .iter()
+        .filter(|((first, second), _)| first < second)
+        .filter_map(|((first, second), forward)| {
+            let reverse = first_by_pair.get(&(*second, *first))?;
+            Some(LockOrderViolation {
+                forward: (*forward).clone(),
+                reverse: (*reverse).clone(),
+            })
+        })
+        .collect();
+
+    let mut mutexes = model.mutexes;
+    mutexes.sort_by(|a, b| a.lock.cmp(&b.lock));
+    LockContentionReport {
+        mutexes,
+        multi_lock_functions,
+        orders,
+        violations,
+    }
+}
+
+/// Sort key for [`LockOrder`]: lock pair, then location.
+#[derive(PartialEq, Eq)]
+struct OrderKey(LockOrder);
+
+/// Ordering for [`OrderKey`].
+impl Ord for OrderKey {
+    fn cmp(&self, other: &Self) -> std::cmp::Ordering {
+        let key = |o: &LockOrder| (o.first.clone(), o.second.clone(), o.file.clone(), o.line);
+        key(&self.0)
+            .cmp(&key(&other.0))
+            .then_with(|| self.0.function.cmp(&other.0.function))
+            .then_with(|| self.0.via.cmp(&other.0.via))
+    }
+}
+
+/// Partial ordering for [`OrderKey`], consistent with [`Ord`].
+impl PartialOrd for OrderKey {
+    fn partial_cmp(&self, other: &Self) -> Option<std::cmp::Ordering> {
+        Some(self.cmp(other))
+    }
+}
+
+/// A lexical token of Go source.
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub(crate) struct Token {
+    /// Identifier, keyword or number text, or a single punctuation character.
+    pub(crate) text: String,
+    /// 1-based line.
+    pub(crate) line: usize,
+}
+
+/// Token methods.
+impl Token {
+    /// Whether the token is an identifier or keyword.
+    pub(crate) fn is_ident(&self) -> bool {
+        self.text
+            .starts_with(|c: char| c.is_alphabetic() || c == '_')
+    }
+
+    /// Whether the token is the punctuation character `c`.
+    pub(crate) fn is(&self, c: char) -> bool {
+        self.text.len() == c.len_utf8() && self.text.starts_with(c)
+    }
+}
+
+/// Split Go source into identifiers and punctuation, dropping comments and
+/// string, raw string and rune literals.
+pub(crate) fn tokenize(source: &str) -> Vec<Token> {
+    let mut tokens = Vec::new();
+    let mut chars = source.chars().peekable();
+    let mut line = 1;
+    while let Some(c) = chars.next() {
+        match c {
+            '\n' => line += 1,
+            c if c.is_whitespace() => {}
+            '/' if chars.peek() == Some(&'/') => while chars.next_if(|&n| n != '\n').is_some() {},
+            '/' if chars.peek() == Some(&'*') => {
+                chars.next();
+                let mut prev = ' ';
+                for n in chars.by_ref() {
+                    if n == '\n' {
+                        line += 1;
+                    }
+                    if prev == '*' && n == '/' {
+                        break;
+                    }
+                    prev = n;
+                }
+            }
+            '"' | '\'' => {
+                while let Some(n) = chars.next() {
+                    match n {
+                        '\\' => {
+                            chars.next();
+                        }
+                        '\n' => {
+                            line += 1;
+                            break;
+                        }
+                        n if n == c => break,
+                        _ => {}
+                    }
+                }
+            }
+            '`' => {
+                for n in chars.by_ref() {
+                    if n == '\n' {
+                        line += 1;
+                    }
+                    if n == '`' {
+                        break;
+                    }
+                }
+            }
+            c if c.is_alphanumeric() || c == '_' => {
+                let mut text = c.to_string();
+                while let Some(n) = chars.next_if(|n| n.is_alphanumeric() || *n == '_') {
+                    text.push(n);
+                }
+                tokens.push(Token { text, line });
+            }
+            c => tokens.push(Token {
+                text: c.to_string(),
+                line,
+            }),
+        }
+    }
+    tokens
+}
+
+/// Index of the bracket closing the one at `open`, or the last token.
+pub(crate) fn matching(tokens: &[Token], open: usize) -> usize {
+    let (opening, closing) = match tokens[open].text.as_str() {
+        "(" => ('(', ')'),
+        "[" => ('[', ']'),
+        _ => ('{', '}'),
+    };
+    let mut depth = 0usize;
+    for (index, token) in tokens.iter().enumerate().skip(open) {
+        if token.is(opening) {
+            depth += 1;
+        } else if token.is(closing) {
+            depth -= 1;
+            if depth == 0 {
+                return index;
+            }
+        }
+    }
+    tokens.len().saturating_sub(1)
+}
+
+/// Package of a file: its directory relative to the project root.
+pub(crate) fn package_of(path: &str) -> String {
+    match Path::new(path).parent() {
+        Some(parent) if !parent.as_os_str().is_empty() => parent.to_string_lossy().into_owned(),
+        _ => ".".to_string(),
+    }
+}
+
+/// Qualify `name` with its package; root-package names stay bare.
+pub(crate) fn qualify(package: &str, name: &str) -> String {
+    if package == "." {
+        name.to_string()
+    } else {
+        format!("{package}.{name}")
+    }
+}
+
+/// Fields of a struct type.
+#[derive(Debug, Default)]
+struct StructInfo {
+    /// Field name to the last identifier of its type (`*Child` -> `Child`).
+    field_types: HashMap<String, String>,
+    /// Names of the fields holding a mutex (`Mutex`/`RWMutex` when embedded).
+    mutexes: HashSet<String>,
+}
+
+/// A function or method declaration.
+#[derive(Debug)]
+struct FunctionInfo {
+    /// Index of the declaring file in the analysed files.
+    file_index: usize,
+    /// Declaring file.
+    file: String,
+    /// Package of the declaring file.
+    package: String,
+    /// 1-based line of `func`.
+    line: usize,
+    /// Receiver variable and type name, for methods.
+    receiver: Option<(Option<String>, String)>,
+    /// Parameter name to the last identifier of its type.
+    params: HashMap<String, String>,
+    /// Token range of the body, braces excluded.
+    body: (usize, usize),
+}
+
+/// Lock and call events of a function body, in source order.
+#[derive(Debug, Clone, PartialEq, Eq)]
+enum Event {
+    /// A lock was taken.
+    Acquire(String, usize),
+    /// A lock was released (deferred releases are not events).
+    Release(String),
+    /// A function of the analysed sources was called.
+    Call(String, usize),
+}
+
+/// Declarations collected from every file.
+#[derive(Debug, Default)]
+struct Model {
+    /// Structs by package and name.
+    structs: HashMap<(String, String), StructInfo>,
+    /// Package-level mutex variables by package and name.
+    package_mutexes: HashSet<(String, String)>,
+    /// Every mutex, for the report.
+    mutexes: Vec<MutexField>,
+    /// Functions and methods by identity.
+    functions: BTreeMap<String, FunctionInfo>,
+    /// Number of files collected so far.
+    file_count: usize,
+}
+
+/// Declaration collection and body scanning for [`Model`].
+impl Model {
+    /// Record the structs, mutex variables and functions declared in one file.
+    fn collect(&mut self, path: &str, tokens: &[Token]) {
+        let package = package_of(path);
+        let file_index = self.file_count;
+        self.file_count += 1;
+        let mut depth = 0usize;
+        let mut i = 0;
+        while i < tokens.len() {
+            let token = &tokens[i];
+            if token.is('{') {
+                depth += 1;
+            } else if token.is('}') {
+                depth = depth.saturating_sub(1);
+            } else if depth == 0 && token.text == "func" {
+                i = self.collect_function(path, &package, file_index, tokens, i);
+                continue;
+            } else if depth == 0
+                && token.text == "struct"
+                && tokens.get(i + 1).is_some_and(|t| t.is('{'))
+            {
+                let close = matching(tokens, i + 1);
+                if let Some(name) = struct_name(tokens, i) {
+                    self.collect_struct(path, &package, &name, &tokens[i + 2..close]);
+                }
+                i = close + 1;
+                continue;
+            } else if depth == 0 && is_sync_mutex(tokens, i) && i > 0 && tokens[i - 1].is_ident() {
+                // `var mu sync.Mutex` or `var a, b sync.RWMutex`.
+                let read_write = tokens[i + 2].text == "RWMutex";
+                let mut name = i - 1;
+                loop {
+                    let var = &tokens[name];
+                    self.package_mutexes
+                        .insert((package.clone(), var.text.clone()));
+                    self.mutexes.push(MutexField {
+                        lock: qualify(&package, &var.text),
+                        read_write,
+                        file: path.to_string(),
+                        line: var.line,
+                    });
+                    if name >= 2 && tokens[name - 1].is(',') && tokens[name - 2].is_ident() {
+                        name -= 2;
+                    } else {
+                        break;
+                    }
+                }
+                i += 3;
+                continue;
+            }
+            i += 1;
+        }
+    }
+
+    /// Record the fields of struct `name` from the tokens between its braces.
+    fn collect_struct(&mut self, path: &str, package: &str, name: &str, body: &[Token]) {
+        let mut info = StructInfo::default();
+        let mut lines: Vec<Vec<&Token>> = Vec::new();
+        let mut nested = 0usize;
+        for token in body {
+            if token.is('{') {
+                nested += 1;
+            } else if token.is('}') {
+                nested = nested.saturating_sub(1);
+                continue;
+            }
+            if nested > 0 {
+                continue;
+            }
+            match lines.last_mut() {
+                Some(line) if line[0].line == token.line => line.push(token),
+                _ => lines.push(vec![token]),
+            }
+        }
+
+        for line in lines {
+            // `a, b T` names its fields; `T`, `*T` and `pkg.T` embed one.
+            let mut names = Vec::new();
+            let mut index = 0;
+            while line.get(index).is_some_and(|t| t.is_ident()) {
+                names.push(line[index]);
+                if line.get(index + 1).is_some_and(|t| t.is(',')) {
+                    index += 2;
+                } else {
+                    index += 1;
+                    break;
+                }
+            }
+            let mut type_tokens = &line[index..];
+            if type_tokens.is_empty() || type_tokens[0].is('.') {
+                names.clear();
+                type_tokens = &line[..];
+            }
+            let Some(type_name) = type_tokens.iter().rev().find(|t| t.is_ident()) else {
+                continue;
+            };
+            let is_mutex = type_tokens.len() >= 3
+                && type_tokens[type_tokens.len() - 3].text == "sync"
+                && type_tokens[type_tokens.len() - 2].is('.')
+                && matches!(type_name.text.as_str(), "Mutex" | "RWMutex");
+            if names.is_empty() {
+                names.push(type_name);
+            }
+            for field in names {
+                info.field_types
+                    .insert(field.text.clone(), type_name.text.clone());
+                if is_mutex {
+                    info.mutexes.insert(field.text.clone());
+                    self.mutexes.push(MutexField {
+                        lock: qualify(package, &format!("{name}.{}", field.text)),
+                        read_write: type_name.text == "RWMutex",
+                        file: path.to_string(),
+                        line: field.line,
+                    });
+                }
+            }
+        }
+        self.structs
+            .insert((package.to_string(), name.to_string()), info);
+    }
+
+    /// Record the function declared at `start` and return the index after it.
+    fn collect_function(
+        &mut self,
+        path: &str,
+        package: &str,
+        file_index: usize,
+        tokens: &[Token],
+        start: usize,
+    ) -> usize {
+        let mut i = start + 1;
+        let mut receiver = None;
+        if tokens.get(i).is_some_and(|t| t.is('(')) {
+            let close = matching(tokens, i);
+            let idents: Vec<&Token> = tokens[i + 1..close]
+                .iter()
+                .filter(|t| t.is_ident())
+                .collect();
+            receiver = match idents.as_slice() {
+                [ty] => Some((None, ty.text.clone())),
+                [name, ty, ..] => Some((Some(name.text.clone()), ty.text.clone())),
+                [] => None,
+            };
+            i = close + 1;
+        }
+        let Some(name) = tokens.get(i).filter(|t| t.is_ident()) else {
+            return i;
+        };
+        i += 1;
+        if tokens.get(i).is_some_and(|t| t.is('[')) {
+            i = matching(tokens, i) + 1;
+        }
+        if !tokens.get(i).is_some_and(|t| t.is('(')) {
+            return i;
+        }
+        let params_close = matching(tokens, i);
+        let params = parse_params(&tokens[i + 1..params_close]);
+
+        // The body brace must end the signature line (semicolon insertion).
+        let mut j = params_close + 1;
+        let mut parens = 0usize;
+        let body_open = loop {
+            let Some(token) = tokens.get(j) else {
+                return j;
+            };
+            if parens == 0 && token.line != tokens[j - 1].line {
+                return j;
+            }
+            if token.is('(') || token.is('[') {
+                parens += 1;
+            } else if token.is(')') || token.is(']') {
+                parens = parens.saturating_sub(1);
+            } else if parens == 0 && token.is('{') {
+                break j;
+            }
+            j += 1;
+        };
+        let body_close = matching(tokens, body_open);
+
+        let id = match &receiver {
+            Some((_, ty)) => qualify(package, &format!("{ty}.{}", name.text)),
+            None => qualify(package, &name.text),
+        };
+        self.functions.insert(
+            id,
+            FunctionInfo {
+                file_index,
+                file: path.to_string(),
+                package: package.to_string(),
+                line: tokens[start].line,
+                receiver,
+                params,
+                body: (body_open + 1, body_close),
+            },
+        );
+        body_close + 1
+    }
+
+    /// Lock and call events of `function`, in source order.
+    fn events(&self, function: &FunctionInfo, tokens: &[Token]) -> Vec<Event> {
+        let (start, end) = function.body;
+        let mut events = Vec::new();
+        let mut deferred_line = None;
+        let mut i = start;
+        while i < end {
+            let token = &tokens[i];
+            if deferred_line.is_some_and(|line| line != token.line) {
+                deferred_line = None;
+            }
+            match token.text.as_str() {
+                "defer" if !tokens.get(i + 1).is_some_and(|t| t.text == "func") => {
+                    deferred_line = Some(token.line);
+                    i += 1;
+                    continue;
+                }
+                // Goroutines run concurrently, so their locks are not nested in
+                // ours; deferred closures run when every lock is about to go.
+                "go" | "defer" => {
+                    i = skip_call(tokens, i + 1, end);
+                    continue;
+                }
+                _ => {}
+            }
+            if !token.is_ident() || (i > start && tokens[i - 1].is('.')) {
+                i += 1;
+                continue;
+            }
+
+            let mut chain = vec![token.text.as_str()];
+            let mut k = i + 1;
+            while k + 1 < end && tokens[k].is('.') && tokens[k + 1].is_ident() {
+                chain.push(&tokens[k + 1].text);
+                k += 2;
+            }
+            let called = k < end && tokens[k].is('(');
+            if called && !KEYWORDS.contains(&chain[0]) {
+                let method = chain[chain.len() - 1];
+                let no_args = tokens.get(k + 1).is_some_and(|t| t.is(')'));
+                if chain.len() >= 2 && no_args && ACQUIRE_METHODS.contains(&method) {
+                    if deferred_line.is_none() {
+                        if let Some(lock) = self.resolve_lock(function, &chain[..chain.len() - 1]) {
+                            events.push(Event::Acquire(lock, token.line));
+                        }
+                    }
+                } else if chain.len() >= 2 && no_args && RELEASE_METHODS.contains(&method) {
+                    if deferred_line.is_none() {
+                        if let Some(lock) = self.resolve_lock(function, &chain[..chain.len() - 1]) {
+                            events.push(Event::Release(lock));
+                        }
+                    }
+                } else if let Some(callee) = self.resolve_call(function, &chain) {
+                    events.push(Event::Call(callee, token.line));
+                }
+            }
+            i = k;
+        }
+        events
+    }
+
+    /// Type of the expression `path` (`r`, `r.child`) inside `function`.
+    fn resolve_type(&self, function: &FunctionInfo, path: &[&str]) -> Option<String> {
+        let root = path.first()?;
+        let mut ty = match &function.receiver {
+            Some((Some(name), ty)) if name == root => ty.clone(),
+            _ => function.params.get(*root)?.clone(),
+        };
+        for field in &path[1..] {
+            ty = self
+                .structs
+                .get(&(function.package.clone(), ty))?
+                .field_types
+                .get(*field)?
+                .clone();
+        }
+        Some(ty)
+    }
+
+    /// Lock identity of the mutex expression `path` (`r.mu`, `r`, `mu`).
+    fn resolve_lock(&self, function: &FunctionInfo, path: &[&str]) -> Option<String> {
+        let package = &function.package;
+        if let [owner @ .., field] = path {
+            if !owner.is_empty() {
+                if let Some(ty) = self.resolve_type(function, owner) {
+                    let declares = self
+                        .structs
+                        .get(&(package.clone(), ty.clone()))
+                        .is_some_and(|info| info.mutexes.contains(*field));
+                    if declares {
+                        return Some(qualify(package, &format!("{ty}.{field}")));
+                    }
+                }
+            }
+        }
+        if let Some(ty) = self.resolve_type(function, path) {
+            // A struct embedding its mutex locks through the struct itself.
+            let info = self.structs.get(&(package.clone(), ty.clone()))?;
+            let embedded = ["Mutex", "RWMutex"]
+                .into_iter()
+                .find(|name| info.mutexes.contains(*name))?;
+            return Some(qualify(package, &format!("{ty}.{embedded}")));
+        }
+        match path {
+            [var]
+                if self
+                    .package_mutexes
+                    .contains(&(package.clone(), var.to_string())) =>
+            {
+                Some(qualify(package, var))
+            }
+            _ => None,
+        }
+    }
+
+    /// Identity of the function called through `chain`, when it is declared
+    /// in the analysed sources.
+    fn resolve_call(&self, function: &FunctionInfo, chain: &[&str]) -> Option<String> {
+        let package = &function.package;
+        let id = match chain {
+            [name] => qualify(package, name),
+            [owner @ .., method] => {
+                let ty = self.resolve_type(function, owner)?;
+                qualify(package, &format!("{ty}.{method}"))
+            }
+            [] => return None,
+        };
+        self.functions.contains_key(&id).then_some(id)
+    }
+}
+
+/// Name of the struct type whose `struct` keyword is at `index`, skipping
+/// type parameters (`type List[T any] struct`).
+fn struct_name(tokens: &[Token], index: usize) -> Option<String> {
+    let mut i = index.checked_sub(1)?;
+    if tokens[i].is(']') {
+        let mut depth = 0usize;
+        loop {
+            if tokens[i].is(']') {
+                depth += 1;
+            } else if tokens[i].is('[') {
+                depth -= 1;
+                if depth == 0 {
+                    break;
+                }
+            }
+            i = i.checked_sub(1)?;
+        }
+        i = i.checked_sub(1)?;
+    }
+    let name = &tokens[i];
+    (name.is_ident() && !KEYWORDS.contains(&name.text.as_str())).then(|| name.text.clone())
+}
+
+/// Whether `sync.Mutex` or `sync.RWMutex` starts at `index`.
+fn is_sync_mutex(tokens: &[Token], index: usize) -> bool {
+    tokens.get(index).is_some_and(|t| t.text == "sync")
+        && tokens.get(index + 1).is_some_and(|t| t.is('.'))
+        && tokens
+            .get(index + 2)
+            .is_some_and(|t| matches!(t.text.as_str(), "Mutex" | "RWMutex"))
+}
+
+/// Parameter names and type names from the tokens between a signature's
+/// parentheses; `a, b *T` gives both names the type `T`.
+fn parse_params(tokens: &[Token]) -> HashMap<String, String> {
+    let mut params = HashMap::new();
+    let mut pending = Vec::new();
+    let mut segment: Vec<&Token> = Vec::new();
+    let mut depth = 0usize;
+    let end = Token {
+        text: ",".to_string(),
+        line: 0,
+    };
+    for token in tokens.iter().chain([&end]) {
+        if token.is('(') || token.is('[') || token.is('{') {
+            depth += 1;
+        } else if token.is(')') || token.is(']') || token.is('}') {
+            depth = depth.saturating_sub(1);
+        } else if depth == 0 && token.is(',') {
+            match segment.as_slice() {
+                [name] if name.is_ident() => pending.push(name.text.clone()),
+                [name, rest @ ..] if name.is_ident() => {
+                    if let Some(ty) = rest.iter().rev().find(|t| t.is_ident()) {
+                        params.insert(name.text.clone(), ty.text.clone());
+                        for pending in pending.drain(..) {
+                            params.insert(pending, ty.text.clone());
+                        }
+                    }
+                }
+                _ => {}
+            }
+            segment.clear();
+            continue;
+        }
+        segment.push(token);
+    }
+    params
+}
+
+/// Index after the call or function literal starting at `start` (used to skip
+/// `go` statements).
+fn skip_call(tokens: &[Token], start: usize, end: usize) -> usize {
+    let mut i = start;
+    if tokens.get(i).is_some_and(|t| t.text == "func") {
+        while i < end && !tokens[i].is('{') {
+            i += 1;
+        }
+        if i < end {
+            i = matching(tokens, i) + 1;
+        }
+    } else {
+        while i < end && !tokens[i].is('(') {
+            i += 1;
+        }
+    }
+    if i < end && tokens[i].is('(') {
+        i = matching(tokens, i) + 1;
+    }
+    i.min(end)
+}
+
+/// Interprocedural queries over the events of every function.
+struct Analysis<'a> {
+    events: &'a BTreeMap<String, Vec<Event>>,
+}
+
+/// Lock-set and ordering queries for [`Analysis`].
+impl Analysis<'_> {
+    /// Locks acquired by `id` or, within `depth - 1` further calls, its
+    /// callees, each with the call chain that reaches it (empty when `id`
+    /// acquires it itself).
+    fn acquired(
+        &self,
+        id: &str,
+        depth: usize,
+        visiting: &mut HashSet<String>,
+    ) -> BTreeMap<String, Vec<String>> {
+        let mut locks = BTreeMap::new();
+        if depth == 0 || !visiting.insert(id.to_string()) {
+            return locks;
+        }
+        for event in self.events.get(id).into_iter().flatten() {
+            match event {
+                Event::Acquire(lock, _) => {
+                    locks.insert(lock.clone(), Vec::new());
+                }
+                Event::Call(callee, _) => {
+                    for (lock, mut chain) in self.acquired(callee, depth - 1, visiting) {
+                        chain.insert(0, callee.clone());
+                        locks.entry(lock).or_insert(chain);
+                    }
+                }
+                Event::Release(_) => {}
+            }
+        }
+        visiting.remove(id);
+        locks
+    }
+
+    /// Orders recorded while walking the body of `id`.
+    fn orders(&self, id: &str, function: &FunctionInfo, max_depth: usize) -> Vec<OrderKey> {
+        let mut orders = Vec::new();
+        let mut held: Vec<String> = Vec::new();
+        let mut record = |held: &[String], second: &str, line: usize, via: Vec<String>| {
+            for first in held.iter().filter(|first| *first != second) {
+                orders.push(OrderKey(LockOrder {
+                    first: first.clone(),
+                    second: second.to_string(),
+                    function: id.to_string(),
+                    file: function.file.clone(),
+                    line,
+                    via: via.clone(),
+                }));
+            }
+        };
+        for event in self.events.get(id).into_iter().flatten() {
+            match event {
+                Event::Acquire(lock, line) => {
+                    record(&held, lock, *line, Vec::new());
+                    held.push(lock.clone());
+                }
+                Event::Release(lock) => {
+                    if let Some(index) = held.iter().rposition(|h| h == lock) {
+                        held.remove(index);
+                    }
+                }
+                Event::Call(callee, line) if !held.is_empty() => {
+                    let mut visiting = HashSet::from([id.to_string()]);
+                    for (lock, mut chain) in self.acquired(callee, max_depth, &mut visiting) {
+                        chain.insert(0, callee.clone());
+                        record(&held, &lock, *line, chain);
+                    }
+                }
+                Event::Call(..) => {}
+            }
+        }
+        orders
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    const COMPLEX_STRUCT: &str = r#"package store
+
+import "sync"
+
+// ComplexStruct guards its children with its own lock.
+type ComplexStruct struct {
+	mu       sync.RWMutex
+	children map[string]*Child
+	child    *Child
+	name     string `json:"name"`
+}
+
+type Child struct {
+	mu    sync.RWMutex
+	value int
+}
+
+type Counter struct {
+	sync.Mutex
+	hits int
+}
+
+var registryMu sync.Mutex
+
+func (c *ComplexStruct) Update(v int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.child.mu.Lock()
+	c.child.value = v
+	c.child.mu.Unlock()
+}
+
+func (c *Child) Refresh(parent *ComplexStruct) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	parent.Name()
+}
+
+func (c *ComplexStruct) Name() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.name
+}
+
+func (c *ComplexStruct) Snapshot() int {
+	c.mu.RLock()
+	v := c.child.value
+	c.mu.RUnlock()
+	c.child.mu.RLock()
+	defer c.child.mu.RUnlock()
+	go func() {
+		c.mu.Lock()
+		c.mu.Unlock()
+	}()
+	return v
+}
+
+func (k *Counter) Hit() {
+	registryMu.Lock()
+	k.Lock()
+	k.hits++
+	k.Unlock()
+	registryMu.Unlock()
+}
+"#;
+
+    fn analyze(max_depth: usize) -> LockContentionReport {
+        detect_lock_contention(
+            &[("store/store.go".to_string(), COMPLEX_STRUCT.to_string())],
+            max_depth,
+        )
+    }
+
+    #[test]
+    fn finds_mutex_fields_and_package_variables() {
+        let report = analyze(3);
+        let mutexes: Vec<(&str, bool, usize)> = report
+            .mutexes
+            .iter()
+            .map(|m| (m.lock.as_str(), m.read_write, m.line))
+            .collect();
+        assert_eq!(
+            mutexes,
+            vec![
+                ("store.Child.mu", true, 14),
+                ("store.ComplexStruct.mu", true, 7),
+                ("store.Counter.Mutex", false, 19),
+                ("store.registryMu", false, 23),
+            ]
+        );
+    }
+
+    #[test]
+    fn reports_inverted_lock_orders_through_calls() {
+        let report = analyze(3);
+        let orders: Vec<(&str, &str, &str, usize, Vec<&str>)> = report
+            .orders
+            .iter()
+            .map(|o| {
+                (
+                    o.first.as_str(),
+                    o.second.as_str(),
+                    o.function.as_str(),
+                    o.line,
+                    o.via.iter().map(String::as_str).collect(),
+                )
+            })
+            .collect();
+        assert_eq!(
+            orders,
+            vec![
+                (
+                    "store.Child.mu",
+                    "store.ComplexStruct.mu",
+                    "store.Child.Refresh",
+                    36,
+                    vec!["store.ComplexStruct.Name"]
+                ),
+                (
+                    "store.ComplexStruct.mu",
+                    "store.Child.mu",
+                    "store.ComplexStruct.Update",
+                    28,
+                    vec![]
+                ),
+                (
+                    "store.registryMu",
+                    "store.Counter.Mutex",
+                    "store.Counter.Hit",
+                    60,
+                    vec![]
+                ),
+            ]
+        );
+
+        assert_eq!(report.violations.len(), 1);
+        let violation = &report.violations[0];
+        assert_eq!(violation.forward.function, "store.Child.Refresh");
+        assert_eq!(violation.reverse.function, "store.ComplexStruct.Update");
+
+        let multi: Vec<&str> = report
+            .multi_lock_functions
+            .iter()
+            .map(|f| f.function.as_str())
+            .collect();
+        assert_eq!(
+            multi,
+            vec![
+                "store.Child.Refresh",
+                "store.ComplexStruct.Snapshot",
+                "store.ComplexStruct.Update",
+                "store.Counter.Hit"
+            ]
+        );
+    }
+
+    #[test]
+    fn call_depth_limits_interprocedural_orders() {
+        let report = analyze(0);
+        assert!(report.violations.is_empty());
+        assert!(report.orders.iter().all(|order| order.via.is_empty()));
+    }
+}
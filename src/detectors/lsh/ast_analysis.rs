@@ -3,6 +3,7 @@
 //! This module provides functionality for analyzing AST structure and detecting
 //! common code patterns (stop motifs) that should be excluded from clone detection.
 
+use std::path::Path;
 use std::sync::Arc;
 
 use tokio::fs;
@@ -16,6 +17,7 @@ use crate::core::ast_utils::{
 use crate::core::errors::Result;
 use crate::core::featureset::CodeEntity;
 use crate::lang::common::{EntityKind, ParseIndex};
+use crate::lang::notebook::analysis_source;
 
 use super::config::DedupeConfig;
 use super::signatures::shingles::count_tokens;
@@ -52,7 +54,7 @@ impl AstAnalyzer {
     ) -> Result<Option<EntityAstStats>> {
         let mut cache_key = entity.file_path.clone();
         let source = match fs::read_to_string(&entity.file_path).await {
-            Ok(content) => content,
+            Ok(content) => analysis_source(Path::new(&entity.file_path), content),
             Err(err) => {
                 debug!(
                     "Falling back to entity source for AST metrics ({}): {}",
@@ -0,0 +1,909 @@
+//! Built-in rules selectable by identifier.
+//!
+//! Adding a rule means implementing [`Rule`], listing it in [`BUILTIN_RULES`]
+//! (with `fixable: true` when it implements [`Rule::fix`]) and constructing it
+//! in [`builtin_rule`].
+
+use std::collections::BTreeMap;
+use std::path::Path;
+
+use serde::Serialize;
+use serde_json::Value;
+use tracing::warn;
+
+use super::{FileAnalysis, ProjectAnalysis, Rule, RuleConfig, RuleFinding, RuleMeta, RuleSeverity};
+use crate::core::dependency::PackageGraph;
+use crate::core::errors::{Result, ValknutError};
+use crate::core::review::is_test_file;
+use crate::detectors::code_smells::{detect_code_smells, CodeSmellCheck};
+use crate::detectors::constructor_usage::{detect_constructor_bypass, ConstructorBypass};
+use crate::detectors::lock_contention::detect_lock_contention;
+use crate::lang::common::EntityKind;
+use crate::lang::registry::language_key_for_path;
+
+/// Registry entry of a built-in rule.
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Serialize)]
+pub struct BuiltinRule {
+    /// Identifier used in `builtin = "..."`.
+    pub id: &'static str,
+    /// Whether `valknut lint --fix` can rewrite violations safely.
+    pub fixable: bool,
+}
+
+/// The built-in rules.
+pub const BUILTIN_RULES: &[BuiltinRule] = &[
+    BuiltinRule {
+        id: "max-function-length",
+        fixable: false,
+    },
+    BuiltinRule {
+        id: "max-cyclomatic-complexity",
+        fixable: false,
+    },
+    BuiltinRule {
+        id: "max-parameters",
+        fixable: false,
+    },
+    BuiltinRule {
+        id: "max-file-lines",
+        fixable: false,
+    },
+    BuiltinRule {
+        id: "exported-types-documented",
+        fixable: false,
+    },
+    BuiltinRule {
+        id: "exported-functions-documented",
+        fixable: false,
+    },
+    BuiltinRule {
+        id: "max-package-exports",
+        fixable: false,
+    },
+    BuiltinRule {
+        id: "no-circular-imports",
+        fixable: false,
+    },
+    BuiltinRule {
+        id: "lock-ordering",
+        fixable: false,
+    },
+    BuiltinRule {
+        id: "go-constructor-bypass",
+        fixable: false,
+    },
+    BuiltinRule {
+        id: "go-max-return-values",
+        fixable: false,
+    },
+    BuiltinRule {
+        id: "go-interface-params",
+        fixable: false,
+    },
+    BuiltinRule {
+        id: "go-package-globals",
+        fixable: false,
+    },
+    BuiltinRule {
+        id: "go-init-side-effects",
+        fixable: false,
+    },
+    BuiltinRule {
+        id: "go-library-panic",
+        fixable: false,
+    },
+    BuiltinRule {
+        id: "trailing-newline",
+        fixable: true,
+    },
+    BuiltinRule {
+        id: "comment-capitalization",
+        fixable: true,
+    },
+    BuiltinRule {
+        id: "no-blank-lines-before-closing-brace",
+        fixable: true,
+    },
+];
+
+/// Instantiate the built-in rule `id` from its configuration.
+pub fn builtin_rule(id: &str, config: &RuleConfig) -> Result<Box<dyn Rule>> {
+    let meta = config.meta();
+    let params = &config.params;
+    let rule: Box<dyn Rule> = match id {
+        "max-function-length" => {
+            check_params(params, &["max_lines"])?;
+            Box::new(MaxFunctionLength {
+                meta,
+                max_lines: param_usize(params, "max_lines", 50)?,
+            })
+        }
+        "max-cyclomatic-complexity" => {
+            check_params(params, &["max"])?;
+            Box::new(MaxCyclomaticComplexity {
+                meta,
+                max: param_usize(params, "max", 10)? as f64,
+            })
+        }
+        "max-parameters" => {
+            check_params(params, &["max"])?;
+            Box::new(MaxParameters {
+                meta,
+                max: param_usize(params, "max", 5)?,
+            })
+        }
+        "max-file-lines" => {
+            check_params(params, &["max_lines"])?;
+            Box::new(MaxFileLines {
+                meta,
+                max_lines: param_usize(params, "max_lines", 500)?,
+            })
+        }
+        "exported-types-documented" => {
+            check_params(params, &[])?;
+            Box::new(ExportedTypesDocumented { meta })
+        }
+        "exported-functions-documented" => {
+            check_params(params, &[])?;
+            Box::new(ExportedFunctionsDocumented { meta })
+        }
+        "max-package-exports" => {
+            check_params(params, &["max"])?;
+            Box::new(MaxPackageExports {
+                meta,
+                max: param_usize(params, "max", 40)?,
+            })
+        }
+        "no-circular-imports" => {
+            check_params(params, &[])?;
+            Box::new(NoCircularImports { meta })
+        }
+        "lock-ordering" => {
+            check_params(params, &["max_depth"])?;
+            Box::new(LockOrdering {
+                meta,
+                max_depth: param_usize(params, "max_depth", 3)?,
+            })
+        }
+        "go-constructor-bypass" => {
+            check_params(params, &[])?;
+            Box::new(GoConstructorBypass { meta })
+        }
+        "go-max-return-values" => {
+            check_params(params, &["max"])?;
+            let max = param_usize(params, "max", 3)?;
+            Box::new(GoCodeSmell {
+                meta,
+                check: CodeSmellCheck::ManyReturnValues { max },
+            })
+        }
+        "go-interface-params"
+        | "go-package-globals"
+        | "go-init-side-effects"
+        | "go-library-panic" => {
+            check_params(params, &[])?;
+            let check = match id {
+                "go-interface-params" => CodeSmellCheck::InterfaceParams,
+                "go-package-globals" => CodeSmellCheck::PackageGlobals,
+                "go-init-side-effects" => CodeSmellCheck::InitSideEffects,
+                _ => CodeSmellCheck::LibraryPanic,
+            };
+            Box::new(GoCodeSmell { meta, check })
+        }
+        "trailing-newline" => {
+            check_params(params, &[])?;
+            Box::new(TrailingNewline { meta })
+        }
+        "comment-capitalization" => {
+            check_params(params, &[])?;
+            Box::new(CommentCapitalization { meta })
+        }
+        "no-blank-lines-before-closing-brace" => {
+            check_params(params, &[])?;
+            Box::new(NoBlankLinesBeforeClosingBrace { meta })
+        }
+        _ => {
+            let ids: Vec<&str> = BUILTIN_RULES.iter().map(|rule| rule.id).collect();
+            return Err(ValknutError::config(format!(
+                "unknown built-in rule `{id}` (available: {})",
+                ids.join(", ")
+            )));
+        }
+    };
+    Ok(rule)
+}
+
+/// Reject parameters a rule does not take.
+fn check_params(params: &BTreeMap<String, Value>, allowed: &[&str]) -> Result<()> {
+    match params.keys().find(|key| !allowed.contains(&key.as_str())) {
+        Some(key) => Err(ValknutError::config(format!("unknown parameter `{key}`"))),
+        None => Ok(()),
+    }
+}
+
+/// Read a non-negative integer parameter, falling back to `default`.
+fn param_usize(params: &BTreeMap<String, Value>, key: &str, default: usize) -> Result<usize> {
+    match params.get(key) {
+        None => Ok(default),
+        Some(value) => value.as_u64().map(|n| n as usize).ok_or_else(|| {
+            ValknutError::config(format!("parameter `{key}` must be a non-negative integer"))
+        }),
+    }
+}
+
+/// Flags functions and methods longer than `max_lines`.
+struct MaxFunctionLength {
+    meta: RuleMeta,
+    max_lines: usize,
+}
+
+/// Flags functions and methods whose cyclomatic complexity exceeds `max`.
+struct MaxCyclomaticComplexity {
+    meta: RuleMeta,
+    max: f64,
+}
+
+/// Flags functions and methods taking more than `max` parameters.
+struct MaxParameters {
+    meta: RuleMeta,
+    max: usize,
+}
+
+/// Flags files longer than `max_lines`.
+struct MaxFileLines {
+    meta: RuleMeta,
+    max_lines: usize,
+}
+
+/// Flags exported classes, structs, interfaces and enums without documentation.
+struct ExportedTypesDocumented {
+    meta: RuleMeta,
+}
+
+/// Flags exported functions and methods without documentation.
+struct ExportedFunctionsDocumented {
+    meta: RuleMeta,
+}
+
+/// Flags directories whose files export more than `max` top-level symbols.
+struct MaxPackageExports {
+    meta: RuleMeta,
+    max: usize,
+}
+
+/// Flags import cycles between Go and Java packages.
+struct NoCircularImports {
+    meta: RuleMeta,
+}
+
+/// Flags Go mutexes acquired in opposite orders by different functions,
+/// following calls made under a lock up to `max_depth` levels.
+struct LockOrdering {
+    meta: RuleMeta,
+    max_depth: usize,
+}
+
+/// Flags Go files that build a struct with a literal instead of its
+/// constructor, when the constructor is used elsewhere or required by a
+/// `//valknut:require-constructor` directive.
+struct GoConstructorBypass {
+    meta: RuleMeta,
+}
+
+/// Flags one Go code smell in non-test Go files.
+struct GoCodeSmell {
+    meta: RuleMeta,
+    check: CodeSmellCheck,
+}
+
+/// Flags non-empty files that do not end with a newline.
+struct TrailingNewline {
+    meta: RuleMeta,
+}
+
+/// Flags line comments whose first word starts with a lowercase letter.
+struct CommentCapitalization {
+    meta: RuleMeta,
+}
+
+/// Flags blank lines directly before a line starting with `}`.
+struct NoBlankLinesBeforeClosingBrace {
+    meta: RuleMeta,
+}
+
+/// [`Rule`] implementation for [`MaxFunctionLength`].
+impl Rule for MaxFunctionLength {
+    fn name(&self) -> &str {
+        &self.meta.name
+    }
+
+    fn severity(&self) -> RuleSeverity {
+        self.meta.severity
+    }
+
+    fn check(&self, file: &FileAnalysis) -> Vec<RuleFinding> {
+        file.entities
+            .iter()
+            .filter(|entity| entity.is_function() && entity.lines > self.max_lines)
+            .map(|entity| {
+                self.meta.finding(file, Some(entity), || {
+                    format!(
+                        "{} `{}` is {} lines long (max {})",
+                        entity.kind_name(),
+                        entity.name,
+                        entity.lines,
+                        self.max_lines
+                    )
+                })
+            })
+            .collect()
+    }
+}
+
+/// [`Rule`] implementation for [`MaxCyclomaticComplexity`].
+impl Rule for MaxCyclomaticComplexity {
+    fn name(&self) -> &str {
+        &self.meta.name
+    }
+
+    fn severity(&self) -> RuleSeverity {
+        self.meta.severity
+    }
+
+    fn check(&self, file: &FileAnalysis) -> Vec<RuleFinding> {
+        file.entities
+            .iter()
+            .filter(|entity| entity.is_function())
+            .filter_map(|entity| {
+                let complexity = entity.complexity.filter(|value| *value > self.max)?;
+                Some(self.meta.finding(file, Some(entity), || {
+                    format!(
+                        "{} `{}` has cyclomatic complexity {:.0} (max {:.0})",
+                        entity.kind_name(),
+                        entity.name,
+                        complexity,
+                        self.max
+                    )
+                }))
+            })
+            .collect()
+    }
+}
+
+/// [`Rule`] implementation for [`MaxParameters`].
+impl Rule for MaxParameters {
+    fn name(&self) -> &str {
+        &self.meta.name
+    }
+
+    fn severity(&self) -> RuleSeverity {
+        self.meta.severity
+    }
+
+    fn check(&self, file: &FileAnalysis) -> Vec<RuleFinding> {
+        file.entities
+            .iter()
+            .filter(|entity| entity.is_function() && entity.params > self.max)
+            .map(|entity| {
+                self.meta.finding(file, Some(entity), || {
+                    format!(
+                        "{} `{}` takes {} parameters (max {})",
+                        entity.kind_name(),
+                        entity.name,
+                        entity.params,
+                        self.max
+                    )
+                })
+            })
+            .collect()
+    }
+}
+
+/// [`Rule`] implementation for [`MaxFileLines`].
+impl Rule for MaxFileLines {
+    fn name(&self) -> &str {
+        &self.meta.name
+    }
+
+    fn severity(&self) -> RuleSeverity {
+        self.meta.severity
+    }
+
+    fn check(&self, file: &FileAnalysis) -> Vec<RuleFinding> {
+        if file.line_count <= self.max_lines {
+            return Vec::new();
+        }
+        vec![self.meta.finding(file, None, || {
+            format!(
+                "file is {} lines long (max {})",
+                file.line_count, self.max_lines
+            )
+        })]
+    }
+}
+
+/// [`Rule`] implementation for [`ExportedTypesDocumented`].
+impl Rule for ExportedTypesDocumented {
+    fn name(&self) -> &str {
+        &self.meta.name
+    }
+
+    fn severity(&self) -> RuleSeverity {
+        self.meta.severity
+    }
+
+    fn check(&self, file: &FileAnalysis) -> Vec<RuleFinding> {
+        file.entities
+            .iter()
+            .filter(|entity| entity.is_type() && entity.exported && !entity.documented)
+            .map(|entity| {
+                self.meta.finding(file, Some(entity), || {
+                    format!(
+                        "exported {} `{}` has no doc comment",
+                        entity.kind_name(),
+                        entity.name
+                    )
+                })
+            })
+            .collect()
+    }
+}
+
+/// [`Rule`] implementation for [`ExportedFunctionsDocumented`].
+impl Rule for ExportedFunctionsDocumented {
+    fn name(&self) -> &str {
+        &self.meta.name
+    }
+
+    fn severity(&self) -> RuleSeverity {
+        self.meta.severity
+    }
+
+    fn check(&self, file: &FileAnalysis) -> Vec<RuleFinding> {
+        file.entities
+            .iter()
+            .filter(|entity| entity.is_function() && entity.exported && !entity.documented)
+            .map(|entity| {
+                self.meta.finding(file, Some(entity), || {
+                    format!(
+                        "exported {} `{}` has no doc comment",
+                        entity.kind_name(),
+                        entity.name
+                    )
+                })
+            })
+            .collect()
+    }
+}
+
+/// [`Rule`] implementation for [`MaxPackageExports`].
+impl Rule for MaxPackageExports {
+    fn name(&self) -> &str {
+        &self.meta.name
+    }
+
+    fn severity(&self) -> RuleSeverity {
+        self.meta.severity
+    }
+
+    fn check_project(&self, project: &ProjectAnalysis<'_>) -> Vec<RuleFinding> {
+        // Methods are reached through their type, so they do not widen the
+        // package surface on their own.
+        let mut exports: BTreeMap<String, usize> = BTreeMap::new();
+        for file in project.files {
+            let count = file
+                .entities
+                .iter()
+                .filter(|entity| entity.exported && entity.kind != EntityKind::Method)
+                .count();
+            *exports.entry(package_dir(&file.path)).or_default() += count;
+        }
+
+        exports
+            .into_iter()
+            .filter(|(_, count)| *count > self.max)
+            .map(|(dir, count)| {
+                let message =
+                    || format!("package `{dir}` exports {count} symbols (max {})", self.max);
+                self.meta.project_finding(dir.clone(), None, message)
+            })
+            .collect()
+    }
+}
+
+/// [`Rule`] implementation for [`NoCircularImports`].
+impl Rule for NoCircularImports {
+    fn name(&self) -> &str {
+        &self.meta.name
+    }
+
+    fn severity(&self) -> RuleSeverity {
+        self.meta.severity
+    }
+
+    fn check_project(&self, project: &ProjectAnalysis<'_>) -> Vec<RuleFinding> {
+        let graph = match PackageGraph::from_projects(&[project.root.to_path_buf()]) {
+            Ok(graph) => graph,
+            Err(e) => {
+                warn!(
+                    "Failed to build package graph for {}: {}",
+                    self.meta.name, e
+                );
+                return Vec::new();
+            }
+        };
+
+        graph
+            .cycles
+            .iter()
+            .map(|cycle| {
+                // Cycles span packages rather than files, so they are reported
+                // against the project root.
+                self.meta.project_finding(".", None, || {
+                    format!("import cycle: {} -> {}", cycle.join(" -> "), cycle[0])
+                })
+            })
+            .collect()
+    }
+}
+
+/// [`Rule`] implementation for [`LockOrdering`].
+impl Rule for LockOrdering {
+    fn name(&self) -> &str {
+        &self.meta.name
+    }
+
+    fn severity(&self) -> RuleSeverity {
+        self.meta.severity
+    }
+
+    fn check_project(&self, project: &ProjectAnalysis<'_>) -> Vec<RuleFinding> {
+        let sources = go_sources(project, &self.meta.name, |_| true);
+        detect_lock_contention(&sources, self.max_depth)
+            .violations
+            .into_iter()
+            .map(|violation| {
+                let (forward, reverse) = (&violation.forward, &violation.reverse);
+                let message = || {
+                    format!(
+                        "lock order inversion: `{}` locks `{}` then `{}`{} but `{}` ({}:{}) \
+                         locks `{}` then `{}`{}",
+                        forward.function,
+                        forward.first,
+                        forward.second,
+                        via(&forward.via),
+                        reverse.function,
+                        reverse.file,
+                        reverse.line,
+                        reverse.first,
+                        reverse.second,
+                        via(&reverse.via)
+                    )
+                };
+                self.meta.project_finding(
+                    forward.file.clone(),
+                    Some((forward.line, forward.line)),
+                    message,
+                )
+            })
+            .collect()
+    }
+}
+
+/// Project-relative path and source of the analysed Go files `keep` accepts.
+fn go_sources(
+    project: &ProjectAnalysis<'_>,
+    rule: &str,
+    keep: impl Fn(&FileAnalysis) -> bool,
+) -> Vec<(String, String)> {
+    project
+        .files
+        .iter()
+        .filter(|file| file.language.as_deref() == Some("go") && keep(file))
+        .filter_map(
+            |file| match std::fs::read_to_string(project.root.join(&file.path)) {
+                Ok(source) => Some((file.path.clone(), source)),
+                Err(e) => {
+                    warn!("Failed to read {} for {}: {}", file.path, rule, e);
+                    None
+                }
+            },
+        )
+        .collect()
+}
+
+/// [`Rule`] implementation for [`GoConstructorBypass`].
+impl Rule for GoConstructorBypass {
+    fn name(&self) -> &str {
+        &self.meta.name
+    }
+
+    fn severity(&self) -> RuleSeverity {
+        self.meta.severity
+    }
+
+    fn check_project(&self, project: &ProjectAnalysis<'_>) -> Vec<RuleFinding> {
+        // Tests build fixtures directly, so they neither use nor bypass constructors.
+        let sources = go_sources(project, &self.meta.name, |file| {
+            !is_test_file(Path::new(&file.path))
+        });
+        detect_constructor_bypass(&sources)
+            .into_iter()
+            .map(|bypass| {
+                let first = bypass.lines[0];
+                let last = bypass.lines[bypass.lines.len() - 1];
+                self.meta
+                    .project_finding(bypass.file.clone(), Some((first, last)), || {
+                        bypass_message(&bypass)
+                    })
+            })
+            .collect()
+    }
+}
+
+/// Default message of a [`GoConstructorBypass`] finding.
+fn bypass_message(bypass: &ConstructorBypass) -> String {
+    let lines: Vec<String> = bypass.lines.iter().map(ToString::to_string).collect();
+    let constructors = if bypass.constructors.is_empty() {
+        "a constructor".to_string()
+    } else {
+        let names: Vec<String> = bypass
+            .constructors
+            .iter()
+            .map(|name| format!("`{name}`"))
+            .collect();
+        names.join(" or ")
+    };
+    if bypass.required {
+        format!(
+            "`{}` requires {constructors} (//valknut:require-constructor) but is built \
+             with a literal at line {}",
+            bypass.type_name,
+            lines.join(", ")
+        )
+    } else {
+        format!(
+            "`{}` is built with a literal at line {} while {} call site(s) use {constructors}",
+            bypass.type_name,
+            lines.join(", "),
+            bypass.constructor_calls
+        )
+    }
+}
+
+/// ` (via a -> b)` for a non-empty call chain.
+fn via(chain: &[String]) -> String {
+    if chain.is_empty() {
+        String::new()
+    } else {
+        format!(" (via {})", chain.join(" -> "))
+    }
+}
+
+/// [`Rule`] implementation for [`GoCodeSmell`].
+impl Rule for GoCodeSmell {
+    fn name(&self) -> &str {
+        &self.meta.name
+    }
+
+    fn severity(&self) -> RuleSeverity {
+        self.meta.severity
+    }
+
+    fn check_source(&self, file: &FileAnalysis, source: &str) -> Vec<RuleFinding> {
+        // Tests may keep fixtures in globals and panic freely.
+        if file.language.as_deref() != Some("go") || is_test_file(Path::new(&file.path)) {
+            return Vec::new();
+        }
+        detect_code_smells(source, self.check)
+            .into_iter()
+            .map(|smell| {
+                let mut finding = self
+                    .meta
+                    .line_finding(file, (smell.line, smell.line), || smell.message);
+                finding.entity = Some(smell.name);
+                finding
+            })
+            .collect()
+    }
+}
+
+/// [`Rule`] implementation for [`TrailingNewline`].
+impl Rule for TrailingNewline {
+    fn name(&self) -> &str {
+        &self.meta.name
+    }
+
+    fn severity(&self) -> RuleSeverity {
+        self.meta.severity
+    }
+
+    fn check_source(&self, file: &FileAnalysis, source: &str) -> Vec<RuleFinding> {
+        if source.is_empty() || source.ends_with('\n') {
+            return Vec::new();
+        }
+        let last = source.lines().count();
+        vec![self.meta.line_finding(file, (last, last), || {
+            "file does not end with a newline".to_string()
+        })]
+    }
+
+    fn fixable(&self) -> bool {
+        true
+    }
+
+    fn fix(&self, _path: &str, source: &str) -> Option<String> {
+        (!source.is_empty() && !source.ends_with('\n')).then(|| format!("{source}\n"))
+    }
+}
+
+/// [`Rule`] implementation for [`CommentCapitalization`].
+impl Rule for CommentCapitalization {
+    fn name(&self) -> &str {
+        &self.meta.name
+    }
+
+    fn severity(&self) -> RuleSeverity {
+        self.meta.severity
+    }
+
+    fn check_source(&self, file: &FileAnalysis, source: &str) -> Vec<RuleFinding> {
+        let Some(prefix) = file.language.as_deref().and_then(line_comment_prefix) else {
+            return Vec::new();
+        };
+        source
+            .lines()
+            .enumerate()
+            .filter_map(|(index, line)| {
+                let word = lowercase_comment_start(line, prefix)?;
+                let line_number = index + 1;
+                Some(
+                    self.meta
+                        .line_finding(file, (line_number, line_number), || {
+                            format!("comment starts with lowercase `{word}`")
+                        }),
+                )
+            })
+            .collect()
+    }
+
+    fn fixable(&self) -> bool {
+        true
+    }
+
+    fn fix(&self, path: &str, source: &str) -> Option<String> {
+        let prefix = language_key_for_path(Path::new(path))
+            .as_deref()
+            .and_then(line_comment_prefix)?;
+        let mut changed = false;
+        let fixed: String = source
+            .split_inclusive('\n')
+            .map(|line| match lowercase_comment_start(line, prefix) {
+                Some(word) => {
+                    changed = true;
+                    let at = word.as_ptr() as usize - line.as_ptr() as usize;
+                    let mut chars = word.chars();
+                    let first = chars.next().map(|c| c.to_uppercase().to_string());
+                    format!(
+                        "{}{}{}{}",
+                        &line[..at],
+                        first.unwrap_or_default(),
+                        chars.as_str(),
+                        &line[at + word.len()..]
+                    )
+                }
+                None => line.to_string(),
+            })
+            .collect();
+        changed.then_some(fixed)
+    }
+}
+
+/// [`Rule`] implementation for [`NoBlankLinesBeforeClosingBrace`].
+impl Rule for NoBlankLinesBeforeClosingBrace {
+    fn name(&self) -> &str {
+        &self.meta.name
+    }
+
+    fn severity(&self) -> RuleSeverity {
+        self.meta.severity
+    }
+
+    fn check_source(&self, file: &FileAnalysis, source: &str) -> Vec<RuleFinding> {
+        let lines: Vec<&str> = source.lines().collect();
+        blank_runs_before_brace(&lines)
+            .into_iter()
+            .map(|(start, end)| {
+                let count = end - start;
+                self.meta.line_finding(file, (start + 1, end), || {
+                    format!("{count} blank line(s) before closing brace")
+                })
+            })
+            .collect()
+    }
+
+    fn fixable(&self) -> bool {
+        true
+    }
+
+    fn fix(&self, _path: &str, source: &str) -> Option<String> {
+        let lines: Vec<&str> = source.split_inclusive('\n').collect();
+        let runs = blank_runs_before_brace(&lines);
+        if runs.is_empty() {
+            return None;
+        }
+        let fixed = lines
+            .iter()
+            .enumerate()
+            .filter(|(index, _)| {
+                !runs
+                    .iter()
+                    .any(|(start, end)| (*start..*end).contains(index))
+            })
+            .map(|(_, line)| *line)
+            .collect();
+        Some(fixed)
+    }
+}
+
+/// Line comment marker of a language, for languages with a single one.
+fn line_comment_prefix(language: &str) -> Option<&'static str> {
+    match language {
+        "py" => Some("#"),
+        "ts" | "js" | "rs" | "go" | "c" | "cpp" | "java" | "cs" | "kt" | "swift" => Some("//"),
+        _ => None,
+    }
+}
+
+/// First word of a plain line comment when it starts with a lowercase letter.
+///
+/// Only whole-line comments are considered, and doc comments, directives
+/// (`#!`, `#[`, `//go:`), commented-out code and words that look like
+/// identifiers (`snake_case`, `camelCase`, `path.to`) are left alone, since
+/// capitalising those would change their meaning.
+fn lowercase_comment_start<'a>(line: &'a str, prefix: &str) -> Option<&'a str> {
+    let text = line.trim_start().strip_prefix(prefix)?;
+    if text.starts_with(['/', '!', '[', '#', '*']) || !text.starts_with([' ', '\t']) {
+        return None;
+    }
+    let text = text.trim();
+    let word = text.split_whitespace().next()?;
+    let looks_like_code = text.ends_with([';', '{', '}', '(', ')', ','])
+        || text.contains(" = ")
+        || text.contains("://");
+    let plain_word = word.chars().all(|c| c.is_ascii_lowercase())
+        || (word.ends_with(':')
+            && word[..word.len() - 1]
+                .chars()
+                .all(|c| c.is_ascii_lowercase()));
+    (word.starts_with(|c: char| c.is_ascii_lowercase()) && plain_word && !looks_like_code)
+        .then_some(word)
+}
+
+/// Index ranges `start..end` of blank lines directly followed by a line
+/// starting with `}`.
+fn blank_runs_before_brace(lines: &[&str]) -> Vec<(usize, usize)> {
+    let mut runs = Vec::new();
+    let mut start = None;
+    for (index, line) in lines.iter().enumerate() {
+        if line.trim().is_empty() {
+            start.get_or_insert(index);
+            continue;
+        }
+        if let Some(start) = start.take() {
+            // A blank run at the top of the file follows no block.
+            if start > 0 && line.trim_start().starts_with('}') {
+                runs.push((start, index));
+            }
+        }
+    }
+    runs
+}
+
+/// Directory of a project-relative file path, `.` for the project root.
+fn package_dir(path: &str) -> String {
+    match Path::new(path).parent() {
+        Some(parent) if !parent.as_os_str().is_empty() => parent.to_string_lossy().into_owned(),
+        _ => ".".to_string(),
+    }
+}
@@ -0,0 +1,409 @@
+//! Expression language for custom rules.
+//!
+//! An expression is a predicate over one entity's facts; the rule reports
+//! every entity for which it holds. Grammar:
+//!
+//! ```text
+//! expr       := and ( "||" and )*
+//! and        := unary ( "&&" unary )*
+//! unary      := "!" unary | "(" expr ")" | comparison | field
+//! comparison := field ( "==" | "!=" | "<" | "<=" | ">" | ">=" ) literal
+//! literal    := number | word | "quoted string" | true | false
+//! ```
+//!
+//! Fields: `kind` and `name` (strings), `lines`, `params` and `complexity`
+//! (numbers), `exported` and `documented` (booleans). A bare boolean field is
+//! shorthand for `field == true`. Comparisons against `complexity` are false
+//! for entities the complexity pass did not measure.
+
+use super::{EntityFacts, FileAnalysis, Rule, RuleFinding, RuleMeta, RuleSeverity};
+use crate::core::errors::{Result, ValknutError};
+
+/// Entity facts addressable from expressions.
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub enum Field {
+    /// Entity kind, e.g. `function` or `class`.
+    Kind,
+    /// Entity name.
+    Name,
+    /// Lines spanned by the entity.
+    Lines,
+    /// Parameter count.
+    Params,
+    /// Cyclomatic complexity.
+    Complexity,
+    /// Exported under language conventions.
+    Exported,
+    /// Has a doc comment or docstring.
+    Documented,
+}
+
+/// Comparison operators.
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub enum CmpOp {
+    /// `==`
+    Eq,
+    /// `!=`
+    Ne,
+    /// `<`
+    Lt,
+    /// `<=`
+    Le,
+    /// `>`
+    Gt,
+    /// `>=`
+    Ge,
+}
+
+/// Literal operands.
+#[derive(Debug, Clone, PartialEq)]
+pub enum Literal {
+    /// A number such as `50` or `2.5`.
+    Number(f64),
+    /// A bare word or quoted string.
+    Text(String),
+    /// `true` or `false`.
+    Bool(bool),
+}
+
+/// A parsed rule expression.
+#[derive(Debug, Clone, PartialEq)]
+pub enum Expr {
+    /// Both operands hold.
+    And(Box<Expr>, Box<Expr>),
+    /// Either operand holds.
+    Or(Box<Expr>, Box<Expr>),
+    /// The operand does not hold.
+    Not(Box<Expr>),
+    /// A field compared with a literal.
+    Compare(Field, CmpOp, Literal),
+}
+
+/// A configured rule backed by an [`Expr`].
+#[derive(Debug, Clone)]
+pub struct ExprRule {
+    meta: RuleMeta,
+    source: String,
+    expr: Expr,
+}
+
+/// Lexical tokens.
+#[derive(Debug, Clone, PartialEq)]
+enum Token {
+    Word(String),
+    Number(f64),
+    Text(String),
+    Op(&'static str),
+}
+
+/// Recursive-descent parser over a token list.
+struct Parser {
+    tokens: Vec<Token>,
+    pos: usize,
+}
+
+/// Parsing and evaluation methods for [`Expr`].
+impl Expr {
+    /// Parse `source`, type-checking every comparison.
+    pub fn parse(source: &str) -> Result<Self> {
+        let mut parser = Parser {
+            tokens: tokenize(source)?,
+            pos: 0,
+        };
+        let expr = parser.or()?;
+        match parser.tokens.get(parser.pos) {
+            None => Ok(expr),
+            Some(token) => Err(syntax_error(format!("unexpected {}", describe(token)))),
+        }
+    }
+
+    /// Evaluate against one entity.
+    pub fn matches(&self, entity: &EntityFacts) -> bool {
+        match self {
+            Expr::And(left, right) => left.matches(entity) && right.matches(entity),
+            Expr::Or(left, right) => left.matches(entity) || right.matches(entity),
+            Expr::Not(inner) => !inner.matches(entity),
+            Expr::Compare(field, op, literal) => compare(entity, *field, *op, literal),
+        }
+    }
+}
+
+/// Construction methods for [`ExprRule`].
+impl ExprRule {
+    /// Parse `source` into a rule reporting under `meta`.
+    pub fn parse(meta: RuleMeta, source: &str) -> Result<Self> {
+        Ok(Self {
+            meta,
+            source: source.to_string(),
+            expr: Expr::parse(source)?,
+        })
+    }
+}
+
+/// [`Rule`] implementation for [`ExprRule`].
+impl Rule for ExprRule {
+    fn name(&self) -> &str {
+        &self.meta.name
+    }
+
+    fn severity(&self) -> RuleSeverity {
+        self.meta.severity
+    }
+
+    fn check(&self, file: &FileAnalysis) -> Vec<RuleFinding> {
+        file.entities
+            .iter()
+            .filter(|entity| self.expr.matches(entity))
+            .map(|entity| {
+                self.meta.finding(file, Some(entity), || {
+                    format!(
+                        "{} `{}` matches `{}`",
+                        entity.kind_name(),
+                        entity.name,
+                        self.source
+                    )
+                })
+            })
+            .collect()
+    }
+}
+
+/// Grammar productions for [`Parser`].
+impl Parser {
+    /// `expr := and ( "||" and )*`
+    fn or(&mut self) -> Result<Expr> {
+        let mut expr = self.and()?;
+        while self.eat_op("||") {
+            expr = Expr::Or(Box::new(expr), Box::new(self.and()?));
+        }
+        Ok(expr)
+    }
+
+    /// `and := unary ( "&&" unary )*`
+    fn and(&mut self) -> Result<Expr> {
+        let mut expr = self.unary()?;
+        while self.eat_op("&&") {
+            expr = Expr::And(Box::new(expr), Box::new(self.unary()?));
+        }
+        Ok(expr)
+    }
+
+    /// `unary := "!" unary | "(" expr ")" | comparison | field`
+    fn unary(&mut self) -> Result<Expr> {
+        if self.eat_op("!") {
+            return Ok(Expr::Not(Box::new(self.unary()?)));
+        }
+        if self.eat_op("(") {
+            let expr = self.or()?;
+            if !self.eat_op(")") {
+                return Err(syntax_error("expected `)`"));
+            }
+            return Ok(expr);
+        }
+
+        let field = match self.next() {
+            Some(Token::Word(word)) => parse_field(&word)?,
+            Some(token) => {
+                return Err(syntax_error(format!(
+                    "expected a field, found {}",
+                    describe(&token)
+                )))
+            }
+            None => return Err(syntax_error("unexpected end of expression")),
+        };
+
+        let op = match self.tokens.get(self.pos) {
+            Some(Token::Op(op)) => match *op {
+                "==" => CmpOp::Eq,
+                "!=" => CmpOp::Ne,
+                "<" => CmpOp::Lt,
+                "<=" => CmpOp::Le,
+                ">" => CmpOp::Gt,
+                ">=" => CmpOp::Ge,
+                _ => return bare_field(field),
+            },
+            _ => return bare_field(field),
+        };
+        self.pos += 1;
+
+        let literal = match self.next() {
+            Some(Token::Number(n)) => Literal::Number(n),
+            Some(Token::Text(text)) => Literal::Text(text),
+            Some(Token::Word(word)) if word == "true" || word == "false" => {
+                Literal::Bool(word == "true")
+            }
+            Some(Token::Word(word)) => Literal::Text(word),
+            Some(Token::Op(op)) => {
+                return Err(syntax_error(format!("expected a value, found `{op}`")))
+            }
+            None => return Err(syntax_error("expected a value after the operator")),
+        };
+        check_types(field, op, &literal)?;
+        Ok(Expr::Compare(field, op, literal))
+    }
+
+    /// Consume the next token.
+    fn next(&mut self) -> Option<Token> {
+        let token = self.tokens.get(self.pos).cloned();
+        self.pos += usize::from(token.is_some());
+        token
+    }
+
+    /// Consume `op` if it is the next token.
+    fn eat_op(&mut self, op: &str) -> bool {
+        let found = matches!(self.tokens.get(self.pos), Some(Token::Op(next)) if *next == op);
+        self.pos += usize::from(found);
+        found
+    }
+}
+
+/// Split `source` into tokens.
+fn tokenize(source: &str) -> Result<Vec<Token>> {
+    const OPERATORS: &[&str] = &["==", "!=", "<=", ">=", "&&", "||", "<", ">", "!", "(", ")"];
+
+    let mut tokens = Vec::new();
+    let mut rest = source.trim_start();
+    while !rest.is_empty() {
+        if let Some(op) = OPERATORS.iter().find(|op| rest.starts_with(**op)) {
+            tokens.push(Token::Op(op));
+            rest = &rest[op.len()..];
+        } else if let Some(quote) = rest.chars().next().filter(|c| *c == '"' || *c == '\'') {
+            let body = &rest[1..];
+            let end = body
+                .find(quote)
+                .ok_or_else(|| syntax_error("unterminated string"))?;
+            tokens.push(Token::Text(body[..end].to_string()));
+            rest = &body[end + 1..];
+        } else {
+            let end = rest
+                .find(|c: char| c.is_whitespace() || "=!<>&|()\"'".contains(c))
+                .unwrap_or(rest.len());
+            if end == 0 {
+                return Err(syntax_error(format!(
+                    "unexpected character `{}`",
+                    rest.chars().next().unwrap_or_default()
+                )));
+            }
+            let word = &rest[..end];
+            tokens.push(match word.parse::<f64>() {
+                Ok(number) => Token::Number(number),
+                Err(_) => Token::Word(word.to_string()),
+            });
+            rest = &rest[end..];
+        }
+        rest = rest.trim_start();
+    }
+    Ok(tokens)
+}
+
+/// Resolve a field name.
+fn parse_field(word: &str) -> Result<Field> {
+    Ok(match word {
+        "kind" => Field::Kind,
+        "name" => Field::Name,
+        "lines" => Field::Lines,
+        "params" => Field::Params,
+        "complexity" => Field::Complexity,
+        "exported" => Field::Exported,
+        "documented" => Field::Documented,
+        _ => {
+            return Err(syntax_error(format!(
+                "unknown field `{word}` (expected kind, name, lines, params, complexity, \
+                 exported or documented)"
+            )))
+        }
+    })
+}
+
+/// A boolean field used on its own means `field == true`.
+fn bare_field(field: Field) -> Result<Expr> {
+    let literal = Literal::Bool(true);
+    check_types(field, CmpOp::Eq, &literal)?;
+    Ok(Expr::Compare(field, CmpOp::Eq, literal))
+}
+
+/// Reject comparisons whose operand types do not fit the field.
+fn check_types(field: Field, op: CmpOp, literal: &Literal) -> Result<()> {
+    let equality = matches!(op, CmpOp::Eq | CmpOp::Ne);
+    let valid = match field {
+        Field::Kind | Field::Name => equality && matches!(literal, Literal::Text(_)),
+        Field::Lines | Field::Params | Field::Complexity => {
+            matches!(literal, Literal::Number(_))
+        }
+        Field::Exported | Field::Documented => equality && matches!(literal, Literal::Bool(_)),
+    };
+    if valid {
+        Ok(())
+    } else {
+        Err(syntax_error(format!(
+            "`{}` cannot be compared with {:?} using {:?}",
+            field_name(field),
+            literal,
+            op
+        )))
+    }
+}
+
+/// Evaluate one comparison.
+fn compare(entity: &EntityFacts, field: Field, op: CmpOp, literal: &Literal) -> bool {
+    match (field, literal) {
+        (Field::Kind, Literal::Text(text)) => equal(op, entity.kind_name() == text),
+        (Field::Name, Literal::Text(text)) => equal(op, entity.name == *text),
+        (Field::Exported, Literal::Bool(flag)) => equal(op, entity.exported == *flag),
+        (Field::Documented, Literal::Bool(flag)) => equal(op, entity.documented == *flag),
+        (Field::Lines, Literal::Number(n)) => ordered(op, entity.lines as f64, *n),
+        (Field::Params, Literal::Number(n)) => ordered(op, entity.params as f64, *n),
+        (Field::Complexity, Literal::Number(n)) => entity
+            .complexity
+            .is_some_and(|complexity| ordered(op, complexity, *n)),
+        _ => false,
+    }
+}
+
+/// Apply an equality operator to the result of an equality test.
+fn equal(op: CmpOp, same: bool) -> bool {
+    match op {
+        CmpOp::Ne => !same,
+        _ => same,
+    }
+}
+
+/// Apply a comparison operator to two numbers.
+fn ordered(op: CmpOp, left: f64, right: f64) -> bool {
+    match op {
+        CmpOp::Eq => left == right,
+        CmpOp::Ne => left != right,
+        CmpOp::Lt => left < right,
+        CmpOp::Le => left <= right,
+        CmpOp::Gt => left > right,
+        CmpOp::Ge => left >= right,
+    }
+}
+
+/// Field name as written in expressions.
+fn field_name(field: Field) -> &'static str {
+    match field {
+        Field::Kind => "kind",
+        Field::Name => "name",
+        Field::Lines => "lines",
+        Field::Params => "params",
+        Field::Complexity => "complexity",
+        Field::Exported => "exported",
+        Field::Documented => "documented",
+    }
+}
+
+/// Describe a token for error messages.
+fn describe(token: &Token) -> String {
+    match token {
+        Token::Word(word) => format!("`{word}`"),
+        Token::Number(number) => format!("`{number}`"),
+        Token::Text(text) => format!("\"{text}\""),
+        Token::Op(op) => format!("`{op}`"),
+    }
+}
+
+/// Build an expression syntax error.
+fn syntax_error(message: impl Into<String>) -> ValknutError {
+    ValknutError::config(format!("invalid expression: {}", message.into()))
+}
@@ -0,0 +1,245 @@
+//! Per-file facts that rules are checked against.
+
+use std::collections::hash_map::DefaultHasher;
+use std::hash::{Hash, Hasher};
+use std::path::Path;
+
+use crate::core::errors::{Result, ValknutError};
+use crate::core::review::signature;
+use crate::detectors::complexity::ComplexityAnalysisResult;
+use crate::detectors::structure::file::FileAnalyzer;
+use crate::detectors::structure::StructureConfig;
+use crate::lang::common::{EntityKind, ParsedEntity};
+use crate::lang::go_build::build_constraints;
+use crate::lang::notebook::analysis_source;
+use crate::lang::registry::{adapter_for_file, language_key_for_path};
+
+/// Line prefixes that start a comment in the supported languages.
+const COMMENT_PREFIXES: &[&str] = &["//", "/*", "*", "#", "--", "\"\"\""];
+
+/// Line prefixes of attributes, decorators and annotations between a comment and its item.
+const ATTRIBUTE_PREFIXES: &[&str] = &["#[", "@"];
+
+/// A parsed file and the facts rules need about it.
+#[derive(Debug, Clone, PartialEq)]
+pub struct FileAnalysis {
+    /// Path relative to the project root.
+    pub path: String,
+    /// Language key, when the file's language is supported.
+    pub language: Option<String>,
+    /// Number of lines in the file.
+    pub line_count: usize,
+    /// Go build constraints (`//go:build` expressions, all of which must
+    /// hold); empty for unconstrained files and other languages.
+    pub build_constraints: Vec<String>,
+    /// Entities found in the file, in source order.
+    pub entities: Vec<EntityFacts>,
+}
+
+/// Facts about one entity, as exposed to rules and rule expressions.
+#[derive(Debug, Clone, PartialEq)]
+pub struct EntityFacts {
+    /// Entity name.
+    pub name: String,
+    /// Entity kind.
+    pub kind: EntityKind,
+    /// First line (1-based).
+    pub start_line: usize,
+    /// Last line (1-based).
+    pub end_line: usize,
+    /// Number of lines the entity spans.
+    pub lines: usize,
+    /// Number of parameters (functions and methods).
+    pub params: usize,
+    /// Cyclomatic complexity, when the complexity pass measured the entity.
+    pub complexity: Option<f64>,
+    /// Whether the entity is exported under the language's conventions.
+    pub exported: bool,
+    /// Whether the entity has a doc comment or docstring.
+    pub documented: bool,
+    /// Declaration up to the start of the body, on one line.
+    pub signature: String,
+    /// Hash of the entity's text with its own name blanked out, so a renamed
+    /// but otherwise unchanged entity keeps its fingerprint.
+    pub fingerprint: u64,
+    /// Build constraints inherited from the file.
+    pub build_constraints: Vec<String>,
+}
+
+/// Construction methods for [`FileAnalysis`].
+impl FileAnalysis {
+    /// Read `path` (relative to `root`) and build its facts.
+    pub fn load(root: &Path, path: &str, complexity: &[ComplexityAnalysisResult]) -> Result<Self> {
+        let full_path = root.join(path);
+        let source = std::fs::read_to_string(&full_path)
+            .map_err(|e| ValknutError::io(format!("Failed to read {}", full_path.display()), e))?;
+        let source = analysis_source(&full_path, source);
+        Ok(Self::from_source(path, &source, complexity))
+    }
+
+    /// Build facts for `source`; unsupported languages yield no entities.
+    pub fn from_source(path: &str, source: &str, complexity: &[ComplexityAnalysisResult]) -> Self {
+        let file_path = Path::new(path);
+        let mut entities = adapter_for_file(file_path)
+            .and_then(|mut adapter| adapter.parse_source(source, path))
+            .map(|index| {
+                let analyzer = FileAnalyzer::new(StructureConfig::default());
+                let lines: Vec<&str> = source.lines().collect();
+                let mut entities: Vec<EntityFacts> = index
+                    .entities
+                    .values()
+                    .map(|entity| {
+                        EntityFacts::new(entity, &lines, complexity, || {
+                            analyzer.is_entity_exported(entity, file_path, source)
+                        })
+                    })
+                    .collect();
+                entities.sort_by(|a, b| (a.start_line, &a.name).cmp(&(b.start_line, &b.name)));
+                entities
+            })
+            .unwrap_or_default();
+
+        let language = language_key_for_path(file_path);
+        let build_constraints = match language.as_deref() {
+            Some("go") => build_constraints(file_path, source),
+            _ => Vec::new(),
+        };
+        for entity in &mut entities {
+            entity.build_constraints = build_constraints.clone();
+        }
+
+        Self {
+            path: path.to_string(),
+            language,
+            line_count: source.lines().count(),
+            build_constraints,
+            entities,
+        }
+    }
+}
+
+/// Construction methods for [`EntityFacts`].
+impl EntityFacts {
+    /// Collect facts for a parsed entity.
+    fn new(
+        entity: &ParsedEntity,
+        lines: &[&str],
+        complexity: &[ComplexityAnalysisResult],
+        exported: impl FnOnce() -> bool,
+    ) -> Self {
+        let start_line = entity.location.start_line;
+        let end_line = entity.location.end_line.max(start_line);
+        let measured = complexity
+            .iter()
+            .find(|result| result.entity_name == entity.name && result.start_line == start_line)
+            .or_else(|| {
+                complexity
+                    .iter()
+                    .find(|result| result.entity_name == entity.name)
+            });
+        let params = measured
+            .map(|result| result.metrics.parameter_count as usize)
+            .or_else(|| {
+                entity
+                    .metadata
+                    .get("parameters")
+                    .and_then(|value| value.as_array())
+                    .map(Vec::len)
+            })
+            .unwrap_or(0);
+
+        let text = lines
+            .get(start_line.saturating_sub(1)..end_line.min(lines.len()))
+            .map(|text| text.join("\n"))
+            .unwrap_or_default();
+
+        Self {
+            name: entity.name.clone(),
+            kind: entity.kind,
+            start_line,
+            end_line,
+            lines: end_line - start_line + 1,
+            params,
+            complexity: measured.map(|result| result.metrics.cyclomatic_complexity),
+            exported: exported(),
+            documented: entity.metadata.contains_key("docstring")
+                || has_leading_comment(lines, start_line),
+            signature: signature(&text),
+            fingerprint: fingerprint(&text, &entity.name),
+            build_constraints: Vec::new(),
+        }
+    }
+
+    /// Returns true for functions and methods.
+    pub fn is_function(&self) -> bool {
+        matches!(self.kind, EntityKind::Function | EntityKind::Method)
+    }
+
+    /// Returns true for classes, structs, interfaces and enums.
+    pub fn is_type(&self) -> bool {
+        matches!(
+            self.kind,
+            EntityKind::Class | EntityKind::Struct | EntityKind::Interface | EntityKind::Enum
+        )
+    }
+
+    /// Lowercase name of the entity kind, as used in rule expressions.
+    pub fn kind_name(&self) -> &'static str {
+        match self.kind {
+            EntityKind::Function => "function",
+            EntityKind::Method => "method",
+            EntityKind::Class => "class",
+            EntityKind::Interface => "interface",
+            EntityKind::Module => "module",
+            EntityKind::Variable => "variable",
+            EntityKind::Constant => "constant",
+            EntityKind::Enum => "enum",
+            EntityKind::Struct => "struct",
+            EntityKind::FunctionClause => "function_clause",
+        }
+    }
+}
+
+/// Hash of `text` with every occurrence of the identifier `name` removed and
+/// whitespace collapsed.
+fn fingerprint(text: &str, name: &str) -> u64 {
+    let mut hasher = DefaultHasher::new();
+    let mut token = String::new();
+    let flush = |token: &mut String, hasher: &mut DefaultHasher| {
+        if !token.is_empty() && token != name {
+            token.hash(hasher);
+        }
+        token.clear();
+    };
+    for ch in text.chars() {
+        if ch.is_alphanumeric() || ch == '_' {
+            token.push(ch);
+            continue;
+        }
+        flush(&mut token, &mut hasher);
+        if !ch.is_whitespace() {
+            ch.hash(&mut hasher);
+        }
+    }
+    flush(&mut token, &mut hasher);
+    hasher.finish()
+}
+
+/// Returns true when a comment sits directly above `start_line`, skipping attributes.
+fn has_leading_comment(lines: &[&str], start_line: usize) -> bool {
+    let mut index = start_line.saturating_sub(1);
+    while index > 0 {
+        index -= 1;
+        let line = lines.get(index).map(|line| line.trim()).unwrap_or_default();
+        if ATTRIBUTE_PREFIXES
+            .iter()
+            .any(|prefix| line.starts_with(prefix))
+        {
+            continue;
+        }
+        return COMMENT_PREFIXES
+            .iter()
+            .any(|prefix| line.starts_with(prefix));
+    }
+    false
+}
@@ -0,0 +1,205 @@
+//! Symbol-level differences between two analyses of a file.
+//!
+//! [`FileAnalysis::diff`] compares the entities of two [`FileAnalysis`]
+//! values, typically the same file before and after a refactor. Entities are
+//! paired by name and kind in source order. A paired entity whose signature
+//! or, with the same signature, whose body changed, or that now starts on
+//! another line or in another file, is reported as such. Among the unpaired ones, an entity that vanished and one
+//! of the same kind that appeared with the same fingerprint (its text with
+//! the name blanked out) are a single rename, even when the rename also moved
+//! it; everything else is an addition or a removal.
+
+use std::collections::BTreeMap;
+
+use serde::{Deserialize, Serialize};
+
+use super::facts::{EntityFacts, FileAnalysis};
+
+/// How a symbol differs between two analyses.
+#[derive(Debug, Clone, Copy, PartialEq, Eq, PartialOrd, Ord, Serialize, Deserialize)]
+#[serde(rename_all = "snake_case")]
+pub enum SymbolDiffKind {
+    /// Present only in the newer analysis.
+    Added,
+    /// Present only in the older analysis.
+    Removed,
+    /// Same name and kind, different declaration.
+    SignatureChanged,
+    /// Same name, kind and declaration, different body.
+    BodyChanged,
+    /// Same name and kind, now on another line or in another file.
+    Moved,
+    /// Same body under a new name, possibly also moved.
+    Renamed,
+}
+
+/// One symbol-level difference.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct SymbolDiff {
+    /// Kind of difference.
+    pub change: SymbolDiffKind,
+    /// Symbol name (the new name for renames, the old one for removals).
+    pub name: String,
+    /// Entity kind, as used in rule expressions (`function`, `struct`, ...).
+    pub kind: String,
+    /// File containing the symbol (the older file for removals).
+    pub file_path: String,
+    /// 1-based start line (in the older file for removals).
+    pub line: usize,
+    /// Declaration up to the start of the body (the old one for removals).
+    pub signature: String,
+    /// Name in the older analysis, for renames.
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub previous_name: Option<String>,
+    /// File in the older analysis, when it differs.
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub previous_file: Option<String>,
+    /// Start line in the older analysis, for moves and renames.
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub previous_line: Option<usize>,
+    /// Declaration in the older analysis, when it differs.
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub previous_signature: Option<String>,
+}
+
+/// Symbol-level differences between two analyses of a file.
+#[derive(Debug, Clone, Default, PartialEq, Eq, Serialize, Deserialize)]
+pub struct FileDiff {
+    /// Path of the older analysis.
+    pub before_path: String,
+    /// Path of the newer analysis.
+    pub after_path: String,
+    /// Differences sorted by line and name.
+    pub changes: Vec<SymbolDiff>,
+}
+
+/// Query methods for [`FileDiff`].
+impl FileDiff {
+    /// True when both analyses declare the same symbols in the same places.
+    pub fn is_empty(&self) -> bool {
+        self.changes.is_empty()
+    }
+
+    /// Differences of one kind, in order.
+    pub fn of_kind(&self, change: SymbolDiffKind) -> impl Iterator<Item = &SymbolDiff> {
+        self.changes
+            .iter()
+            .filter(move |diff| diff.change == change)
+    }
+}
+
+/// Comparison methods for [`FileAnalysis`].
+impl FileAnalysis {
+    /// Symbol-level differences from `self` (older) to `other` (newer).
+    pub fn diff(&self, other: &FileAnalysis) -> FileDiff {
+        let mut changes = Vec::new();
+
+        // Pair entities with the same name and kind, in order of appearance.
+        let mut by_identity: BTreeMap<(&str, &str), Vec<&EntityFacts>> = BTreeMap::new();
+        for entity in &self.entities {
+            by_identity
+                .entry((entity.name.as_str(), entity.kind_name()))
+                .or_default()
+                .push(entity);
+        }
+        for candidates in by_identity.values_mut() {
+            candidates.reverse();
+        }
+        let mut added = Vec::new();
+        for entity in &other.entities {
+            let previous = by_identity
+                .get_mut(&(entity.name.as_str(), entity.kind_name()))
+                .and_then(Vec::pop);
+            match previous {
+                Some(previous) => changes.extend(self.compare_paired(previous, other, entity)),
+                None => added.push(entity),
+            }
+        }
+        let mut removed: Vec<&EntityFacts> = by_identity.into_values().flatten().collect();
+        removed.sort_by_key(|entity| entity.start_line);
+
+        // Renames: an unpaired entity of the same kind with the same fingerprint.
+        for entity in added {
+            let renamed_from = removed
+                .iter()
+                .position(|old| old.kind == entity.kind && old.fingerprint == entity.fingerprint);
+            match renamed_from {
+                Some(index) => {
+                    let old = removed.remove(index);
+                    changes.push(SymbolDiff {
+                        previous_name: Some(old.name.clone()),
+                        previous_file: self.previous_file(other),
+                        previous_line: Some(old.start_line),
+                        previous_signature: changed_signature(old, entity),
+                        ..symbol_diff(SymbolDiffKind::Renamed, entity, &other.path)
+                    });
+                }
+                None => changes.push(symbol_diff(SymbolDiffKind::Added, entity, &other.path)),
+            }
+        }
+        changes.extend(
+            removed
+                .into_iter()
+                .map(|entity| symbol_diff(SymbolDiffKind::Removed, entity, &self.path)),
+        );
+
+        changes.sort_by(|a, b| (a.line, &a.name, a.change).cmp(&(b.line, &b.name, b.change)));
+        FileDiff {
+            before_path: self.path.clone(),
+            after_path: other.path.clone(),
+            changes,
+        }
+    }
+
+    /// Signature or body change and move of a paired entity, if any.
+    fn compare_paired(
+        &self,
+        old: &EntityFacts,
+        other: &FileAnalysis,
+        new: &EntityFacts,
+    ) -> Vec<SymbolDiff> {
+        let mut changes = Vec::new();
+        if let Some(previous_signature) = changed_signature(old, new) {
+            changes.push(SymbolDiff {
+                previous_signature: Some(previous_signature),
+                ..symbol_diff(SymbolDiffKind::SignatureChanged, new, &other.path)
+            });
+        } else if old.fingerprint != new.fingerprint {
+            changes.push(symbol_diff(SymbolDiffKind::BodyChanged, new, &other.path));
+        }
+        if old.start_line != new.start_line || self.path != other.path {
+            changes.push(SymbolDiff {
+                previous_file: self.previous_file(other),
+                previous_line: Some(old.start_line),
+                ..symbol_diff(SymbolDiffKind::Moved, new, &other.path)
+            });
+        }
+        changes
+    }
+
+    /// Path of `self`, when `other` has a different one.
+    fn previous_file(&self, other: &FileAnalysis) -> Option<String> {
+        (self.path != other.path).then(|| self.path.clone())
+    }
+}
+
+/// A difference of `change` for `entity` in `path`, without previous values.
+fn symbol_diff(change: SymbolDiffKind, entity: &EntityFacts, path: &str) -> SymbolDiff {
+    SymbolDiff {
+        change,
+        name: entity.name.clone(),
+        kind: entity.kind_name().to_string(),
+        file_path: path.to_string(),
+        line: entity.start_line,
+        signature: entity.signature.clone(),
+        previous_name: None,
+        previous_file: None,
+        previous_line: None,
+        previous_signature: None,
+    }
+}
+
+/// The old signature, when it differs from the new one.
+fn changed_signature(old: &EntityFacts, new: &EntityFacts) -> Option<String> {
+    (old.signature != new.signature).then(|| old.signature.clone())
+}
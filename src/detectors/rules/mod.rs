@@ -0,0 +1,475 @@
+//! Configurable lint rules evaluated after analysis.
+//!
+//! Rules are declared in the `rules` section of the configuration file (or,
+//! for `valknut check`, the `[[check.rules]]` tables of `valknut.toml`). Each
+//! rule has a name, a severity, and one of a built-in rule identifier (with
+//! optional parameters), a predicate written in a small expression language
+//! over per-entity facts, or a jq-style query over the JSON report:
+//!
+//! ```yaml
+//! rules:
+//!   - name: short-functions
+//!     severity: error
+//!     builtin: max-function-length
+//!     params: { max_lines: 50 }
+//!   - name: documented-types
+//!     severity: warning
+//!     builtin: exported-types-documented
+//!   - name: few-parameters
+//!     severity: info
+//!     expr: "kind == function && params > 4"
+//!     message: "Group related parameters into a struct"
+//!   - name: no-huge-functions
+//!     query: ".passes.complexity.detailed_results[] | select(.metrics.lines_of_code > 200)"
+//! ```
+//!
+//! After analysis the [`RuleEngine`] builds a [`FileAnalysis`] for every
+//! analysed file and hands it to each [`Rule`], then hands the whole
+//! [`ProjectAnalysis`] to rules that look across files (package exports,
+//! import cycles, report queries). Layout rules such as `trailing-newline`
+//! read the file's text instead, and can rewrite it: `valknut lint --fix`
+//! applies [`RuleEngine::fix`] for every rule whose registry entry in
+//! [`BUILTIN_RULES`] is `fixable`. New built-in rules implement [`Rule`] and
+//! are registered in [`builtin_rule`].
+
+mod builtin;
+mod expr;
+mod facts;
+mod file_diff;
+mod query;
+
+use std::cell::OnceCell;
+use std::collections::{BTreeMap, BTreeSet, HashSet};
+use std::fmt;
+use std::path::Path;
+
+use serde::{Deserialize, Serialize};
+use tracing::warn;
+
+use crate::core::errors::{Result, ValknutError};
+use crate::core::pipeline::AnalysisResults;
+use crate::detectors::complexity::ComplexityAnalysisResult;
+
+pub use builtin::{builtin_rule, BuiltinRule, BUILTIN_RULES};
+pub use expr::{CmpOp, Expr, ExprRule, Field, Literal};
+pub use facts::{EntityFacts, FileAnalysis};
+pub use file_diff::{FileDiff, SymbolDiff, SymbolDiffKind};
+pub use query::{Query, QueryRule};
+
+/// Severity of a rule and of the findings it produces.
+#[derive(Debug, Clone, Copy, PartialEq, Eq, PartialOrd, Ord, Hash, Serialize, Deserialize)]
+#[serde(rename_all = "lowercase")]
+pub enum RuleSeverity {
+    /// Informational finding.
+    Info,
+    /// Finding that should be addressed.
+    Warning,
+    /// Finding that violates a hard project rule.
+    Error,
+}
+
+/// A rule declaration from the configuration file.
+#[derive(Debug, Clone, PartialEq, Serialize, Deserialize)]
+pub struct RuleConfig {
+    /// Unique rule name reported on findings.
+    pub name: String,
+
+    /// Severity of findings (`error`, `warning` or `info`).
+    #[serde(default = "RuleConfig::default_severity")]
+    pub severity: RuleSeverity,
+
+    /// Identifier of a built-in rule; see [`BUILTIN_RULES`].
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub builtin: Option<String>,
+
+    /// Predicate over entity facts, e.g. `kind == function && lines > 50`.
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub expr: Option<String>,
+
+    /// jq-style filter over the JSON report; see [`Query`].
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub query: Option<String>,
+
+    /// Message reported instead of the rule's default message.
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub message: Option<String>,
+
+    /// Parameters for a built-in rule.
+    #[serde(default, skip_serializing_if = "BTreeMap::is_empty")]
+    pub params: BTreeMap<String, serde_json::Value>,
+}
+
+/// A rule violation found in one file.
+#[derive(Debug, Clone, PartialEq, Serialize, Deserialize)]
+pub struct RuleFinding {
+    /// Name of the rule that produced the finding.
+    pub rule: String,
+    /// Severity of the rule.
+    pub severity: RuleSeverity,
+    /// Human-readable description of the violation.
+    pub message: String,
+    /// File path relative to the project root.
+    pub file_path: String,
+    /// Entity the finding refers to, if any.
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub entity: Option<String>,
+    /// Line range (start, end) of the offending code, if known.
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub line_range: Option<(usize, usize)>,
+}
+
+/// A lint rule checked against every analysed file, the whole project, or both.
+pub trait Rule: Send + Sync {
+    /// Name reported on findings.
+    fn name(&self) -> &str;
+
+    /// Severity reported on findings.
+    fn severity(&self) -> RuleSeverity;
+
+    /// Check one file and return its findings.
+    fn check(&self, _file: &FileAnalysis) -> Vec<RuleFinding> {
+        Vec::new()
+    }
+
+    /// Check the text of one file, for rules about layout rather than entities.
+    fn check_source(&self, _file: &FileAnalysis, _source: &str) -> Vec<RuleFinding> {
+        Vec::new()
+    }
+
+    /// Check the project as a whole, after every file has been checked.
+    fn check_project(&self, _project: &ProjectAnalysis<'_>) -> Vec<RuleFinding> {
+        Vec::new()
+    }
+
+    /// Whether [`fix`](Rule::fix) can rewrite violations of this rule.
+    fn fixable(&self) -> bool {
+        false
+    }
+
+    /// `source` (of the project-relative `path`) with this rule's violations
+    /// fixed, or `None` when there is nothing the rule can fix.
+    fn fix(&self, _path: &str, _source: &str) -> Option<String> {
+        None
+    }
+}
+
+/// Everything project-level rules are checked against.
+pub struct ProjectAnalysis<'a> {
+    /// Project root the file paths are relative to.
+    pub root: &'a Path,
+    /// Facts for every analysed file, sorted by path.
+    pub files: &'a [FileAnalysis],
+    /// Analysis results the facts were built from.
+    pub results: &'a AnalysisResults,
+    /// JSON form of `results`, serialized on first use.
+    report: OnceCell<serde_json::Value>,
+}
+
+/// Name, severity and message override shared by configured rules.
+#[derive(Debug, Clone, PartialEq)]
+pub struct RuleMeta {
+    /// Rule name.
+    pub name: String,
+    /// Rule severity.
+    pub severity: RuleSeverity,
+    /// Message replacing the rule's default message.
+    pub message: Option<String>,
+}
+
+/// Evaluates a set of rules against analysis results.
+#[derive(Default)]
+pub struct RuleEngine {
+    rules: Vec<Box<dyn Rule>>,
+}
+
+/// A file's text after the fixable rules of a [`RuleEngine`] were applied.
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct SourceFix {
+    /// Fixed text.
+    pub source: String,
+    /// Names of the rules that changed the text, in application order.
+    pub rules: Vec<String>,
+}
+
+/// Display implementation for [`RuleSeverity`].
+impl fmt::Display for RuleSeverity {
+    /// Formats the severity as its configuration keyword.
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        f.write_str(match self {
+            RuleSeverity::Info => "info",
+            RuleSeverity::Warning => "warning",
+            RuleSeverity::Error => "error",
+        })
+    }
+}
+
+/// Default value providers for [`RuleConfig`].
+impl RuleConfig {
+    /// Rules without an explicit severity report warnings.
+    const fn default_severity() -> RuleSeverity {
+        RuleSeverity::Warning
+    }
+
+    /// Name, severity and message of this rule.
+    pub fn meta(&self) -> RuleMeta {
+        RuleMeta {
+            name: self.name.clone(),
+            severity: self.severity,
+            message: self.message.clone(),
+        }
+    }
+}
+
+/// Construction and report access for [`ProjectAnalysis`].
+impl<'a> ProjectAnalysis<'a> {
+    /// Wrap the analysed files of `results`.
+    pub fn new(root: &'a Path, files: &'a [FileAnalysis], results: &'a AnalysisResults) -> Self {
+        Self {
+            root,
+            files,
+            results,
+            report: OnceCell::new(),
+        }
+    }
+
+    /// The results as the JSON document `analyze --format json` writes.
+    pub fn report(&self) -> &serde_json::Value {
+        self.report.get_or_init(|| {
+            serde_json::to_value(self.results).unwrap_or_else(|e| {
+                warn!("Failed to serialize analysis results for rules: {}", e);
+                serde_json::Value::Null
+            })
+        })
+    }
+
+    /// Express `path` relative to the project root when it lies beneath it.
+    pub fn relative_path(&self, path: &str) -> String {
+        relative_path(self.root, path)
+    }
+}
+
+/// Finding construction methods for [`RuleMeta`].
+impl RuleMeta {
+    /// Build a finding in `file`, using the configured message when present.
+    pub fn finding(
+        &self,
+        file: &FileAnalysis,
+        entity: Option<&EntityFacts>,
+        default_message: impl FnOnce() -> String,
+    ) -> RuleFinding {
+        RuleFinding {
+            rule: self.name.clone(),
+            severity: self.severity,
+            message: self.message.clone().unwrap_or_else(default_message),
+            file_path: file.path.clone(),
+            entity: entity.map(|entity| entity.name.clone()),
+            line_range: entity.map(|entity| (entity.start_line, entity.end_line)),
+        }
+    }
+
+    /// Build a finding for lines of `file` that belong to no single entity.
+    pub fn line_finding(
+        &self,
+        file: &FileAnalysis,
+        line_range: (usize, usize),
+        default_message: impl FnOnce() -> String,
+    ) -> RuleFinding {
+        RuleFinding {
+            rule: self.name.clone(),
+            severity: self.severity,
+            message: self.message.clone().unwrap_or_else(default_message),
+            file_path: file.path.clone(),
+            entity: None,
+            line_range: Some(line_range),
+        }
+    }
+
+    /// Build a finding for a path that is not a single analysed file, such as
+    /// a package directory.
+    pub fn project_finding(
+        &self,
+        path: impl Into<String>,
+        line_range: Option<(usize, usize)>,
+        default_message: impl FnOnce() -> String,
+    ) -> RuleFinding {
+        RuleFinding {
+            rule: self.name.clone(),
+            severity: self.severity,
+            message: self.message.clone().unwrap_or_else(default_message),
+            file_path: path.into(),
+            entity: None,
+            line_range,
+        }
+    }
+}
+
+/// Construction and evaluation methods for [`RuleEngine`].
+impl RuleEngine {
+    /// Create an engine with no rules.
+    pub fn new() -> Self {
+        Self::default()
+    }
+
+    /// Build an engine from configured rules, rejecting invalid declarations.
+    pub fn from_configs(configs: &[RuleConfig]) -> Result<Self> {
+        let mut engine = Self::new();
+        let mut names = HashSet::new();
+        for (index, config) in configs.iter().enumerate() {
+            let field = format!("rules[{index}]");
+            if config.name.trim().is_empty() {
+                return Err(ValknutError::config_field(
+                    "Rule name must not be empty",
+                    field,
+                ));
+            }
+            if !names.insert(config.name.as_str()) {
+                return Err(ValknutError::config_field(
+                    format!("Duplicate rule name `{}`", config.name),
+                    field,
+                ));
+            }
+
+            let rule = match (&config.builtin, &config.expr, &config.query) {
+                (Some(id), None, None) => builtin_rule(id, config),
+                (None, Some(source), None) if config.params.is_empty() => {
+                    ExprRule::parse(config.meta(), source)
+                        .map(|rule| Box::new(rule) as Box<dyn Rule>)
+                }
+                (None, None, Some(source)) if config.params.is_empty() => {
+                    QueryRule::parse(config.meta(), source)
+                        .map(|rule| Box::new(rule) as Box<dyn Rule>)
+                }
+                (None, Some(_), None) | (None, None, Some(_)) => Err(ValknutError::config(
+                    "`params` only apply to built-in rules",
+                )),
+                _ => Err(ValknutError::config(
+                    "exactly one of `builtin`, `expr` or `query` must be set",
+                )),
+            };
+            let rule = rule.map_err(|e| {
+                ValknutError::config_field(format!("Invalid rule `{}`: {e}", config.name), field)
+            })?;
+            engine.rules.push(rule);
+        }
+        Ok(engine)
+    }
+
+    /// Add a rule implemented in code.
+    pub fn with_rule(mut self, rule: Box<dyn Rule>) -> Self {
+        self.rules.push(rule);
+        self
+    }
+
+    /// Returns true when the engine has no rules.
+    pub fn is_empty(&self) -> bool {
+        self.rules.is_empty()
+    }
+
+    /// Run every rule against one file.
+    pub fn check_file(&self, file: &FileAnalysis) -> Vec<RuleFinding> {
+        self.rules
+            .iter()
+            .flat_map(|rule| rule.check(file))
+            .collect()
+    }
+
+    /// Run every rule against one file and its text.
+    pub fn check_file_source(&self, file: &FileAnalysis, source: &str) -> Vec<RuleFinding> {
+        self.rules
+            .iter()
+            .flat_map(|rule| {
+                let mut findings = rule.check(file);
+                findings.extend(rule.check_source(file, source));
+                findings
+            })
+            .collect()
+    }
+
+    /// Returns true when the rule named `name` can fix its own violations.
+    pub fn is_fixable(&self, name: &str) -> bool {
+        self.rules
+            .iter()
+            .any(|rule| rule.name() == name && rule.fixable())
+    }
+
+    /// Apply every fixable rule to `source` (of the project-relative `path`)
+    /// in declaration order. Returns `None` when no rule changed the text.
+    pub fn fix(&self, path: &str, source: &str) -> Option<SourceFix> {
+        let mut fixed = SourceFix {
+            source: source.to_string(),
+            rules: Vec::new(),
+        };
+        for rule in self.rules.iter().filter(|rule| rule.fixable()) {
+            if let Some(text) = rule.fix(path, &fixed.source) {
+                if text != fixed.source {
+                    fixed.source = text;
+                    fixed.rules.push(rule.name().to_string());
+                }
+            }
+        }
+        (!fixed.rules.is_empty()).then_some(fixed)
+    }
+
+    /// Run every rule against each file in `results`, then against the
+    /// project as a whole, sorted by file and line.
+    ///
+    /// Files are read from `results.project_root`; files that can no longer be
+    /// read are skipped with a warning.
+    pub fn evaluate(&self, results: &AnalysisResults) -> Vec<RuleFinding> {
+        if self.is_empty() {
+            return Vec::new();
+        }
+
+        let root = results.project_root.as_path();
+        let mut complexity: BTreeMap<String, Vec<ComplexityAnalysisResult>> = BTreeMap::new();
+        for entry in &results.passes.complexity.detailed_results {
+            complexity
+                .entry(relative_path(root, &entry.file_path))
+                .or_default()
+                .push(entry.clone());
+        }
+        let files: BTreeSet<String> = complexity
+            .keys()
+            .cloned()
+            .chain(
+                results
+                    .file_health
+                    .keys()
+                    .map(|path| relative_path(root, path)),
+            )
+            .collect();
+
+        let mut findings = Vec::new();
+        let mut analyses = Vec::with_capacity(files.len());
+        for path in files {
+            let entries = complexity.get(&path).map(Vec::as_slice).unwrap_or_default();
+            match std::fs::read_to_string(root.join(&path)) {
+                Ok(source) => {
+                    let file = FileAnalysis::from_source(&path, &source, entries);
+                    findings.extend(self.check_file_source(&file, &source));
+                    analyses.push(file);
+                }
+                Err(e) => warn!("Skipping rules for {}: {}", path, e),
+            }
+        }
+        let project = ProjectAnalysis::new(root, &analyses, results);
+        for rule in &self.rules {
+            findings.extend(rule.check_project(&project));
+        }
+        findings.sort_by(|a, b| {
+            (&a.file_path, a.line_range, &a.rule).cmp(&(&b.file_path, b.line_range, &b.rule))
+        });
+        findings
+    }
+}
+
+/// Express `path` relative to `root` when it lies beneath it.
+pub fn relative_path(root: &Path, path: &str) -> String {
+    Path::new(path)
+        .strip_prefix(root)
+        .map(|relative| relative.to_string_lossy().into_owned())
+        .unwrap_or_else(|_| path.to_string())
+}
+
+#[cfg(test)]
+mod tests;
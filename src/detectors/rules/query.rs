@@ -0,0 +1,697 @@
+//! jq-style queries over the JSON report.
+//!
+//! A query rule runs a filter over the document `analyze --format json`
+//! writes and reports every value the filter produces other than `null` and
+//! `false`. The filter language is a subset of jq:
+//!
+//! ```text
+//! pipe     := comma ( "|" comma )*
+//! comma    := or ( "," or )*
+//! or       := and ( "or" and )*
+//! and      := compare ( "and" compare )*
+//! compare  := postfix ( ( "==" | "!=" | "<" | "<=" | ">" | ">=" ) postfix )?
+//! postfix  := term ( .name | ."quoted name" | "[" "]" | "[" pipe "]" )*
+//! term     := "." | .name | ."quoted name" | number | "string" | true | false
+//!           | null | "(" pipe ")" | "[" pipe "]" | function
+//! function := select(pipe) | map(pipe) | test("regex") | startswith("text")
+//!           | endswith("text") | length | keys | not | empty
+//! ```
+//!
+//! Lookups never fail: a field of a non-object is `null` and iterating a
+//! scalar yields nothing, as with jq's `?` operator, so a query written for
+//! one report section does not break on a report without it.
+//!
+//! A produced object locates its finding through `file_path` (or `file`),
+//! `start_line` (or `line`) and `end_line`, names it through `entity_name`
+//! (or `name`), and may carry a `message`:
+//!
+//! ```text
+//! .passes.complexity.detailed_results[] | select(.metrics.cyclomatic_complexity > 25)
+//! ```
+
+use std::cmp::Ordering;
+
+use regex::Regex;
+use serde_json::Value;
+
+use super::{CmpOp, ProjectAnalysis, Rule, RuleFinding, RuleMeta, RuleSeverity};
+use crate::core::errors::{Result, ValknutError};
+
+/// Longest JSON excerpt quoted in a default finding message.
+const MAX_EXCERPT_CHARS: usize = 120;
+
+/// A parsed filter.
+#[derive(Debug, Clone)]
+enum Filter {
+    /// `.`
+    Identity,
+    /// A constant.
+    Literal(Value),
+    /// `target.key`
+    Field(Box<Filter>, String),
+    /// `target[index]`
+    Index(Box<Filter>, Box<Filter>),
+    /// `target[]`
+    Iterate(Box<Filter>),
+    /// `[inner]`
+    Collect(Box<Filter>),
+    /// `left | right`
+    Pipe(Box<Filter>, Box<Filter>),
+    /// `left, right`
+    Comma(Box<Filter>, Box<Filter>),
+    /// `left and right`
+    And(Box<Filter>, Box<Filter>),
+    /// `left or right`
+    Or(Box<Filter>, Box<Filter>),
+    /// `left <op> right`
+    Compare(Box<Filter>, CmpOp, Box<Filter>),
+    /// `select(condition)`
+    Select(Box<Filter>),
+    /// `map(inner)`
+    Map(Box<Filter>),
+    /// `test("regex")`
+    Test(Regex),
+    /// `startswith("text")`
+    StartsWith(String),
+    /// `endswith("text")`
+    EndsWith(String),
+    /// `length`
+    Length,
+    /// `keys`
+    Keys,
+    /// `not`
+    Not,
+    /// `empty`
+    Empty,
+}
+
+/// A parsed jq-style query.
+#[derive(Debug, Clone)]
+pub struct Query {
+    filter: Filter,
+}
+
+/// A configured rule backed by a [`Query`] over the JSON report.
+#[derive(Debug, Clone)]
+pub struct QueryRule {
+    meta: RuleMeta,
+    source: String,
+    query: Query,
+}
+
+/// Lexical tokens.
+#[derive(Debug, Clone, PartialEq)]
+enum Token {
+    /// `.` on its own
+    Dot,
+    /// `.name` or `."name"`
+    Field(String),
+    Word(String),
+    Number(f64),
+    Text(String),
+    Op(&'static str),
+}
+
+/// Recursive-descent parser over a token list.
+struct Parser {
+    tokens: Vec<Token>,
+    pos: usize,
+}
+
+/// Parsing and evaluation methods for [`Query`].
+impl Query {
+    /// Parse `source`.
+    pub fn parse(source: &str) -> Result<Self> {
+        let mut parser = Parser {
+            tokens: tokenize(source)?,
+            pos: 0,
+        };
+        let filter = parser.pipe()?;
+        match parser.tokens.get(parser.pos) {
+            None => Ok(Self { filter }),
+            Some(token) => Err(syntax_error(format!("unexpected {}", describe(token)))),
+        }
+    }
+
+    /// Every value the query produces for `input`.
+    pub fn apply(&self, input: &Value) -> Vec<Value> {
+        let mut out = Vec::new();
+        self.filter.eval(input, &mut out);
+        out
+    }
+}
+
+/// Construction methods for [`QueryRule`].
+impl QueryRule {
+    /// Parse `source` into a rule reporting under `meta`.
+    pub fn parse(meta: RuleMeta, source: &str) -> Result<Self> {
+        Ok(Self {
+            meta,
+            source: source.to_string(),
+            query: Query::parse(source)?,
+        })
+    }
+
+    /// Build the finding for one produced value.
+    fn finding(&self, project: &ProjectAnalysis<'_>, value: &Value) -> RuleFinding {
+        let lookup = |keys: &[&str]| keys.iter().find_map(|key| value.get(*key));
+        let path = lookup(&["file_path", "file"])
+            .and_then(Value::as_str)
+            .map(|path| project.relative_path(path))
+            .unwrap_or_else(|| ".".to_string());
+        let line = |keys: &[&str]| {
+            lookup(keys)
+                .and_then(Value::as_u64)
+                .map(|line| line as usize)
+        };
+        let line_range = line(&["start_line", "line"])
+            .map(|start| (start, line(&["end_line"]).unwrap_or(start).max(start)));
+        let entity = lookup(&["entity_name", "name"])
+            .and_then(Value::as_str)
+            .map(str::to_string);
+
+        let mut finding = self.meta.project_finding(path, line_range, || {
+            if let Some(message) = value.get("message").and_then(Value::as_str) {
+                return message.to_string();
+            }
+            match &entity {
+                Some(name) => format!("`{name}` matches `{}`", self.source),
+                None => format!("`{}` produced {}", self.source, excerpt(value)),
+            }
+        });
+        finding.entity = entity;
+        finding
+    }
+}
+
+/// [`Rule`] implementation for [`QueryRule`].
+impl Rule for QueryRule {
+    fn name(&self) -> &str {
+        &self.meta.name
+    }
+
+    fn severity(&self) -> RuleSeverity {
+        self.meta.severity
+    }
+
+    fn check_project(&self, project: &ProjectAnalysis<'_>) -> Vec<RuleFinding> {
+        self.query
+            .apply(project.report())
+            .iter()
+            .filter(|value| truthy(value))
+            .map(|value| self.finding(project, value))
+            .collect()
+    }
+}
+
+/// Evaluation for [`Filter`].
+impl Filter {
+    /// Append every output for `input` to `out`.
+    fn eval(&self, input: &Value, out: &mut Vec<Value>) {
+        match self {
+            Filter::Identity => out.push(input.clone()),
+            Filter::Literal(value) => out.push(value.clone()),
+            Filter::Field(target, key) => {
+                for value in target.outputs(input) {
+                    out.push(value.get(key).cloned().unwrap_or(Value::Null));
+                }
+            }
+            Filter::Index(target, index) => {
+                let indices = index.outputs(input);
+                for value in target.outputs(input) {
+                    for index in &indices {
+                        out.push(index_value(&value, index));
+                    }
+                }
+            }
+            Filter::Iterate(target) => {
+                for value in target.outputs(input) {
+                    match value {
+                        Value::Array(items) => out.extend(items),
+                        Value::Object(map) => out.extend(map.into_iter().map(|(_, v)| v)),
+                        _ => {}
+                    }
+                }
+            }
+            Filter::Collect(inner) => out.push(Value::Array(inner.outputs(input))),
+            Filter::Pipe(left, right) => {
+                for value in left.outputs(input) {
+                    right.eval(&value, out);
+                }
+            }
+            Filter::Comma(left, right) => {
+                left.eval(input, out);
+                right.eval(input, out);
+            }
+            Filter::And(left, right) | Filter::Or(left, right) => {
+                let is_and = matches!(self, Filter::And(..));
+                for value in left.outputs(input) {
+                    // `false and _` and `true or _` short-circuit.
+                    if truthy(&value) != is_and {
+                        out.push(Value::Bool(!is_and));
+                        continue;
+                    }
+                    for value in right.outputs(input) {
+                        out.push(Value::Bool(truthy(&value)));
+                    }
+                }
+            }
+            Filter::Compare(left, op, right) => {
+                let rights = right.outputs(input);
+                for left in left.outputs(input) {
+                    for right in &rights {
+                        out.push(Value::Bool(compare(*op, compare_values(&left, right))));
+                    }
+                }
+            }
+            Filter::Select(condition) => {
+                if condition.outputs(input).iter().any(truthy) {
+                    out.push(input.clone());
+                }
+            }
+            Filter::Map(inner) => {
+                let items: Vec<&Value> = match input {
+                    Value::Array(items) => items.iter().collect(),
+                    Value::Object(map) => map.values().collect(),
+                    _ => return,
+                };
+                let mapped = items.into_iter().flat_map(|item| inner.outputs(item));
+                out.push(Value::Array(mapped.collect()));
+            }
+            Filter::Test(regex) => {
+                out.push(Value::Bool(
+                    input.as_str().is_some_and(|text| regex.is_match(text)),
+                ));
+            }
+            Filter::StartsWith(prefix) => {
+                out.push(Value::Bool(
+                    input
+                        .as_str()
+                        .is_some_and(|text| text.starts_with(prefix.as_str())),
+                ));
+            }
+            Filter::EndsWith(suffix) => {
+                out.push(Value::Bool(
+                    input
+                        .as_str()
+                        .is_some_and(|text| text.ends_with(suffix.as_str())),
+                ));
+            }
+            Filter::Length => out.push(match input {
+                Value::Null => Value::from(0),
+                Value::Array(items) => Value::from(items.len()),
+                Value::Object(map) => Value::from(map.len()),
+                Value::String(text) => Value::from(text.chars().count()),
+                Value::Number(number) => Value::from(number.as_f64().unwrap_or_default().abs()),
+                Value::Bool(_) => Value::Null,
+            }),
+            Filter::Keys => match input {
+                Value::Object(map) => out.push(Value::Array(
+                    map.keys().cloned().map(Value::String).collect(),
+                )),
+                Value::Array(items) => {
+                    out.push(Value::Array((0..items.len()).map(Value::from).collect()))
+                }
+                _ => {}
+            },
+            Filter::Not => out.push(Value::Bool(!truthy(input))),
+            Filter::Empty => {}
+        }
+    }
+
+    /// Every output for `input`, collected.
+    fn outputs(&self, input: &Value) -> Vec<Value> {
+        let mut out = Vec::new();
+        self.eval(input, &mut out);
+        out
+    }
+}
+
+/// Grammar productions for [`Parser`].
+impl Parser {
+    /// `pipe := comma ( "|" comma )*`
+    fn pipe(&mut self) -> Result<Filter> {
+        let mut filter = self.comma()?;
+        while self.eat_op("|") {
+            filter = Filter::Pipe(Box::new(filter), Box::new(self.comma()?));
+        }
+        Ok(filter)
+    }
+
+    /// `comma := or ( "," or )*`
+    fn comma(&mut self) -> Result<Filter> {
+        let mut filter = self.or()?;
+        while self.eat_op(",") {
+            filter = Filter::Comma(Box::new(filter), Box::new(self.or()?));
+        }
+        Ok(filter)
+    }
+
+    /// `or := and ( "or" and )*`
+    fn or(&mut self) -> Result<Filter> {
+        let mut filter = self.and()?;
+        while self.eat_word("or") {
+            filter = Filter::Or(Box::new(filter), Box::new(self.and()?));
+        }
+        Ok(filter)
+    }
+
+    /// `and := compare ( "and" compare )*`
+    fn and(&mut self) -> Result<Filter> {
+        let mut filter = self.compare()?;
+        while self.eat_word("and") {
+            filter = Filter::And(Box::new(filter), Box::new(self.compare()?));
+        }
+        Ok(filter)
+    }
+
+    /// `compare := postfix ( op postfix )?`
+    fn compare(&mut self) -> Result<Filter> {
+        let left = self.postfix()?;
+        let op = match self.tokens.get(self.pos) {
+            Some(Token::Op(op)) => match *op {
+                "==" => CmpOp::Eq,
+                "!=" => CmpOp::Ne,
+                "<" => CmpOp::Lt,
+                "<=" => CmpOp::Le,
+                ">" => CmpOp::Gt,
+                ">=" => CmpOp::Ge,
+                _ => return Ok(left),
+            },
+            _ => return Ok(left),
+        };
+        self.pos += 1;
+        let right = self.postfix()?;
+        Ok(Filter::Compare(Box::new(left), op, Box::new(right)))
+    }
+
+    /// `postfix := term ( .name | "[" "]" | "[" pipe "]" )*`
+    fn postfix(&mut self) -> Result<Filter> {
+        let mut filter = self.term()?;
+        loop {
+            if let Some(Token::Field(name)) = self.tokens.get(self.pos) {
+                filter = Filter::Field(Box::new(filter), name.clone());
+                self.pos += 1;
+            } else if self.eat_op("[") {
+                if self.eat_op("]") {
+                    filter = Filter::Iterate(Box::new(filter));
+                } else {
+                    let index = self.pipe()?;
+                    self.expect_op("]")?;
+                    filter = Filter::Index(Box::new(filter), Box::new(index));
+                }
+            } else {
+                return Ok(filter);
+            }
+        }
+    }
+
+    /// `term := "." | .name | literal | "(" pipe ")" | "[" pipe "]" | function`
+    fn term(&mut self) -> Result<Filter> {
+        if self.eat_op("(") {
+            let filter = self.pipe()?;
+            self.expect_op(")")?;
+            return Ok(filter);
+        }
+        if self.eat_op("[") {
+            if self.eat_op("]") {
+                return Ok(Filter::Literal(Value::Array(Vec::new())));
+            }
+            let filter = self.pipe()?;
+            self.expect_op("]")?;
+            return Ok(Filter::Collect(Box::new(filter)));
+        }
+
+        match self.next() {
+            Some(Token::Dot) => Ok(Filter::Identity),
+            Some(Token::Field(name)) => Ok(Filter::Field(Box::new(Filter::Identity), name)),
+            Some(Token::Number(number)) => Ok(Filter::Literal(Value::from(number))),
+            Some(Token::Text(text)) => Ok(Filter::Literal(Value::String(text))),
+            Some(Token::Word(word)) => self.word(&word),
+            Some(Token::Op(op)) => Err(syntax_error(format!("unexpected `{op}`"))),
+            None => Err(syntax_error("unexpected end of query")),
+        }
+    }
+
+    /// A keyword, constant or function call.
+    fn word(&mut self, word: &str) -> Result<Filter> {
+        Ok(match word {
+            "true" => Filter::Literal(Value::Bool(true)),
+            "false" => Filter::Literal(Value::Bool(false)),
+            "null" => Filter::Literal(Value::Null),
+            "length" => Filter::Length,
+            "keys" => Filter::Keys,
+            "not" => Filter::Not,
+            "empty" => Filter::Empty,
+            "select" => Filter::Select(Box::new(self.filter_argument()?)),
+            "map" => Filter::Map(Box::new(self.filter_argument()?)),
+            "test" => {
+                let pattern = self.text_argument()?;
+                let regex = Regex::new(&pattern)
+                    .map_err(|e| syntax_error(format!("invalid regex {pattern:?}: {e}")))?;
+                Filter::Test(regex)
+            }
+            "startswith" => Filter::StartsWith(self.text_argument()?),
+            "endswith" => Filter::EndsWith(self.text_argument()?),
+            _ => return Err(syntax_error(format!("unknown function `{word}`"))),
+        })
+    }
+
+    /// `"(" pipe ")"`
+    fn filter_argument(&mut self) -> Result<Filter> {
+        self.expect_op("(")?;
+        let filter = self.pipe()?;
+        self.expect_op(")")?;
+        Ok(filter)
+    }
+
+    /// `"(" "string" ")"`
+    fn text_argument(&mut self) -> Result<String> {
+        self.expect_op("(")?;
+        let text = match self.next() {
+            Some(Token::Text(text)) => text,
+            Some(token) => {
+                return Err(syntax_error(format!(
+                    "expected a string, found {}",
+                    describe(&token)
+                )))
+            }
+            None => return Err(syntax_error("expected a string")),
+        };
+        self.expect_op(")")?;
+        Ok(text)
+    }
+
+    /// Consume the next token.
+    fn next(&mut self) -> Option<Token> {
+        let token = self.tokens.get(self.pos).cloned();
+        self.pos += usize::from(token.is_some());
+        token
+    }
+
+    /// Consume `op` if it is the next token.
+    fn eat_op(&mut self, op: &str) -> bool {
+        let found = matches!(self.tokens.get(self.pos), Some(Token::Op(next)) if *next == op);
+        self.pos += usize::from(found);
+        found
+    }
+
+    /// Consume the keyword `word` if it is the next token.
+    fn eat_word(&mut self, word: &str) -> bool {
+        let found = matches!(self.tokens.get(self.pos), Some(Token::Word(next)) if next == word);
+        self.pos += usize::from(found);
+        found
+    }
+
+    /// Consume `op` or fail.
+    fn expect_op(&mut self, op: &str) -> Result<()> {
+        if self.eat_op(op) {
+            Ok(())
+        } else {
+            Err(syntax_error(format!("expected `{op}`")))
+        }
+    }
+}
+
+/// Split `source` into tokens.
+fn tokenize(source: &str) -> Result<Vec<Token>> {
+    const OPERATORS: &[&str] = &[
+        "==", "!=", "<=", ">=", "<", ">", "|", ",", "(", ")", "[", "]",
+    ];
+
+    let mut tokens = Vec::new();
+    let mut rest = source.trim_start();
+    while !rest.is_empty() {
+        if let Some(after_dot) = rest.strip_prefix('.') {
+            if after_dot.starts_with('"') {
+                let (name, remaining) = quoted(after_dot)?;
+                tokens.push(Token::Field(name));
+                rest = remaining;
+            } else {
+                let end = after_dot
+                    .find(|c: char| !(c.is_alphanumeric() || c == '_'))
+                    .unwrap_or(after_dot.len());
+                tokens.push(match end {
+                    0 => Token::Dot,
+                    _ => Token::Field(after_dot[..end].to_string()),
+                });
+                rest = &after_dot[end..];
+            }
+        } else if let Some(op) = OPERATORS.iter().find(|op| rest.starts_with(**op)) {
+            tokens.push(Token::Op(op));
+            rest = &rest[op.len()..];
+        } else if rest.starts_with('"') {
+            let (text, remaining) = quoted(rest)?;
+            tokens.push(Token::Text(text));
+            rest = remaining;
+        } else if rest
+            .trim_start_matches('-')
+            .starts_with(|c: char| c.is_ascii_digit())
+        {
+            let end = rest[1..]
+                .find(|c: char| !(c.is_ascii_alphanumeric() || matches!(c, '.' | '+' | '-')))
+                .map_or(rest.len(), |end| end + 1);
+            let number = rest[..end]
+                .parse::<f64>()
+                .map_err(|_| syntax_error(format!("invalid number `{}`", &rest[..end])))?;
+            tokens.push(Token::Number(number));
+            rest = &rest[end..];
+        } else {
+            let end = rest
+                .find(|c: char| !(c.is_alphanumeric() || c == '_'))
+                .unwrap_or(rest.len());
+            if end == 0 {
+                return Err(syntax_error(format!(
+                    "unexpected character `{}`",
+                    rest.chars().next().unwrap_or_default()
+                )));
+            }
+            tokens.push(Token::Word(rest[..end].to_string()));
+            rest = &rest[end..];
+        }
+        rest = rest.trim_start();
+    }
+    Ok(tokens)
+}
+
+/// Read a double-quoted string with `\"`, `\\`, `\n` and `\t` escapes,
+/// returning its text and the input after the closing quote.
+fn quoted(input: &str) -> Result<(String, &str)> {
+    let mut text = String::new();
+    let mut chars = input.char_indices().skip(1);
+    while let Some((index, c)) = chars.next() {
+        match c {
+            '"' => return Ok((text, &input[index + 1..])),
+            '\\' => match chars.next() {
+                Some((_, 'n')) => text.push('\n'),
+                Some((_, 't')) => text.push('\t'),
+                Some((_, escaped)) => text.push(escaped),
+                None => break,
+            },
+            c => text.push(c),
+        }
+    }
+    Err(syntax_error("unterminated string"))
+}
+
+/// jq truthiness: everything except `null` and `false`.
+fn truthy(value: &Value) -> bool {
+    !matches!(value, Value::Null | Value::Bool(false))
+}
+
+/// `value[index]` for an array position or an object key; anything else is `null`.
+fn index_value(value: &Value, index: &Value) -> Value {
+    let found = match (value, index) {
+        (Value::Array(items), Value::Number(number)) => {
+            let position = number.as_f64().unwrap_or_default() as i64;
+            let position = if position < 0 {
+                items.len() as i64 + position
+            } else {
+                position
+            };
+            usize::try_from(position)
+                .ok()
+                .and_then(|position| items.get(position))
+        }
+        (Value::Object(map), Value::String(key)) => map.get(key),
+        _ => None,
+    };
+    found.cloned().unwrap_or(Value::Null)
+}
+
+/// jq's total order: null < false < true < numbers < strings < arrays < objects.
+fn compare_values(left: &Value, right: &Value) -> Ordering {
+    fn rank(value: &Value) -> u8 {
+        match value {
+            Value::Null => 0,
+            Value::Bool(false) => 1,
+            Value::Bool(true) => 2,
+            Value::Number(_) => 3,
+            Value::String(_) => 4,
+            Value::Array(_) => 5,
+            Value::Object(_) => 6,
+        }
+    }
+
+    match (left, right) {
+        (Value::Number(a), Value::Number(b)) => a
+            .as_f64()
+            .unwrap_or_default()
+            .total_cmp(&b.as_f64().unwrap_or_default()),
+        (Value::String(a), Value::String(b)) => a.cmp(b),
+        (Value::Array(a), Value::Array(b)) => a
+            .iter()
+            .zip(b)
+            .map(|(a, b)| compare_values(a, b))
+            .find(|ordering| ordering.is_ne())
+            .unwrap_or_else(|| a.len().cmp(&b.len())),
+        (Value::Object(a), Value::Object(b)) => a.keys().cmp(b.keys()).then_with(|| {
+            a.values()
+                .zip(b.values())
+                .map(|(a, b)| compare_values(a, b))
+                .find(|ordering| ordering.is_ne())
+                .unwrap_or(Ordering::Equal)
+        }),
+        _ => rank(left).cmp(&rank(right)),
+    }
+}
+
+/// Apply a comparison operator to an ordering.
+fn compare(op: CmpOp, ordering: Ordering) -> bool {
+    match op {
+        CmpOp::Eq => ordering.is_eq(),
+        CmpOp::Ne => ordering.is_ne(),
+        CmpOp::Lt => ordering.is_lt(),
+        CmpOp::Le => ordering.is_le(),
+        CmpOp::Gt => ordering.is_gt(),
+        CmpOp::Ge => ordering.is_ge(),
+    }
+}
+
+/// Compact JSON of `value`, shortened for messages.
+fn excerpt(value: &Value) -> String {
+    let json = value.to_string();
+    if json.chars().count() <= MAX_EXCERPT_CHARS {
+        return json;
+    }
+    let mut short: String = json.chars().take(MAX_EXCERPT_CHARS).collect();
+    short.push('…');
+    short
+}
+
+/// Describe a token for error messages.
+fn describe(token: &Token) -> String {
+    match token {
+        Token::Dot => "`.`".to_string(),
+        Token::Field(name) => format!("`.{name}`"),
+        Token::Word(word) => format!("`{word}`"),
+        Token::Number(number) => format!("`{number}`"),
+        Token::Text(text) => format!("\"{text}\""),
+        Token::Op(op) => format!("`{op}`"),
+    }
+}
+
+/// Build a query syntax error.
+fn syntax_error(message: impl Into<String>) -> ValknutError {
+    ValknutError::config(format!("invalid query: {}", message.into()))
+}
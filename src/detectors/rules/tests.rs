@@ -0,0 +1,679 @@
+use super::*;
+use crate::lang::common::EntityKind;
+
+const GO_SOURCE: &str = r#"package shapes
+
+// Circle is a round shape.
+type Circle struct {
+	Radius float64
+}
+
+type Square struct {
+	Side float64
+}
+
+type hidden struct{}
+
+func Area(a int, b int, c int, d int, e int, f int) int {
+	return a + b + c + d + e + f
+}
+"#;
+
+fn rule(name: &str, severity: RuleSeverity) -> RuleConfig {
+    RuleConfig {
+        name: name.to_string(),
+        severity,
+        builtin: None,
+        expr: None,
+        query: None,
+        message: None,
+        params: BTreeMap::new(),
+    }
+}
+
+fn builtin(name: &str, id: &str) -> RuleConfig {
+    RuleConfig {
+        builtin: Some(id.to_string()),
+        ..rule(name, RuleSeverity::Warning)
+    }
+}
+
+fn expr(name: &str, source: &str) -> RuleConfig {
+    RuleConfig {
+        expr: Some(source.to_string()),
+        ..rule(name, RuleSeverity::Info)
+    }
+}
+
+fn query(name: &str, source: &str) -> RuleConfig {
+    RuleConfig {
+        query: Some(source.to_string()),
+        ..rule(name, RuleSeverity::Warning)
+    }
+}
+
+fn facts(kind: EntityKind, lines: usize, params: usize) -> EntityFacts {
+    EntityFacts {
+        name: "run".to_string(),
+        kind,
+        start_line: 1,
+        end_line: lines,
+        lines,
+        params,
+        complexity: None,
+        exported: true,
+        documented: false,
+        signature: "fn run()".to_string(),
+        fingerprint: 0,
+        build_constraints: Vec::new(),
+    }
+}
+
+#[test]
+fn expressions_combine_comparisons_and_bare_fields() {
+    let expr = Expr::parse("kind == function && (lines > 50 || params >= 4) && !documented")
+        .expect("expression parses");
+
+    assert!(expr.matches(&facts(EntityKind::Function, 60, 0)));
+    assert!(expr.matches(&facts(EntityKind::Function, 10, 4)));
+    assert!(!expr.matches(&facts(EntityKind::Function, 10, 3)));
+    assert!(!expr.matches(&facts(EntityKind::Class, 60, 0)));
+
+    let exported = Expr::parse("exported && name == \"run\"").expect("expression parses");
+    assert!(exported.matches(&facts(EntityKind::Method, 1, 0)));
+}
+
+#[test]
+fn unmeasured_complexity_never_matches() {
+    let expr = Expr::parse("complexity < 100").expect("expression parses");
+    let mut entity = facts(EntityKind::Function, 5, 0);
+    assert!(!expr.matches(&entity));
+
+    entity.complexity = Some(3.0);
+    assert!(expr.matches(&entity));
+}
+
+#[test]
+fn expressions_reject_syntax_and_type_errors() {
+    for source in [
+        "",
+        "lines >",
+        "lines > 5 &&",
+        "(lines > 5",
+        "size > 5",
+        "lines > big",
+        "kind < function",
+        "exported == 1",
+        "lines",
+        "lines > 5 6",
+    ] {
+        assert!(Expr::parse(source).is_err(), "`{source}` should not parse");
+    }
+}
+
+#[test]
+fn builtin_rules_flag_go_entities() {
+    let file = FileAnalysis::from_source("shapes/shapes.go", GO_SOURCE, &[]);
+    assert_eq!(file.language.as_deref(), Some("go"));
+
+    let mut params = builtin("few-params", "max-parameters");
+    params
+        .params
+        .insert("max".to_string(), serde_json::json!(5));
+    let engine = RuleEngine::from_configs(&[
+        builtin("documented-types", "exported-types-documented"),
+        params,
+    ])
+    .expect("rules are valid");
+
+    let findings = engine.check_file(&file);
+    let flagged: Vec<(&str, Option<&str>)> = findings
+        .iter()
+        .map(|finding| (finding.rule.as_str(), finding.entity.as_deref()))
+        .collect();
+    assert_eq!(
+        flagged,
+        vec![
+            ("documented-types", Some("Square")),
+            ("few-params", Some("Area")),
+        ]
+    );
+    assert_eq!(
+        findings[1].message,
+        "function `Area` takes 6 parameters (max 5)"
+    );
+}
+
+#[test]
+fn go_entities_inherit_the_file_build_constraints() {
+    let source = format!("//go:build linux && !cgo\n\n{GO_SOURCE}");
+    let file = FileAnalysis::from_source("shapes/shapes_amd64.go", &source, &[]);
+    let expected = vec!["linux && !cgo".to_string(), "amd64".to_string()];
+    assert_eq!(file.build_constraints, expected);
+    assert!(!file.entities.is_empty());
+    assert!(file
+        .entities
+        .iter()
+        .all(|entity| entity.build_constraints == expected));
+
+    let unconstrained = FileAnalysis::from_source("shapes/shapes.go", GO_SOURCE, &[]);
+    assert!(unconstrained.build_constraints.is_empty());
+    let python = FileAnalysis::from_source("tools/gen_linux.py", "x = 1\n", &[]);
+    assert!(python.build_constraints.is_empty());
+}
+
+#[test]
+fn file_rules_report_without_entity_and_honour_message_override() {
+    let source = "x = 1\n".repeat(12);
+    let file = FileAnalysis::from_source("script.py", &source, &[]);
+
+    let mut config = builtin("small-files", "max-file-lines");
+    config
+        .params
+        .insert("max_lines".to_string(), serde_json::json!(10));
+    config.message = Some("split this module".to_string());
+    let findings = RuleEngine::from_configs(&[config])
+        .expect("rule is valid")
+        .check_file(&file);
+
+    assert_eq!(findings.len(), 1);
+    assert_eq!(findings[0].message, "split this module");
+    assert_eq!(findings[0].entity, None);
+    assert_eq!(findings[0].line_range, None);
+}
+
+#[test]
+fn invalid_rule_declarations_are_rejected() {
+    let mut with_params = expr("custom", "lines > 5");
+    with_params
+        .params
+        .insert("max".to_string(), serde_json::json!(1));
+    let mut bad_param = builtin("long", "max-function-length");
+    bad_param
+        .params
+        .insert("max".to_string(), serde_json::json!(1));
+    let mut negative = builtin("long", "max-function-length");
+    negative
+        .params
+        .insert("max_lines".to_string(), serde_json::json!(-1));
+    let both = RuleConfig {
+        expr: Some("lines > 5".to_string()),
+        ..builtin("both", "max-file-lines")
+    };
+
+    let invalid = [
+        vec![
+            builtin("dup", "max-file-lines"),
+            builtin("dup", "max-parameters"),
+        ],
+        vec![builtin(" ", "max-file-lines")],
+        vec![builtin("unknown", "no-such-rule")],
+        vec![rule("empty", RuleSeverity::Error)],
+        vec![both],
+        vec![with_params],
+        vec![bad_param],
+        vec![negative],
+        vec![expr("broken", "lines >")],
+        vec![query("broken", ".passes | select(")],
+        vec![query("regex", r#".name | test("(")"#)],
+    ];
+    for configs in invalid {
+        assert!(
+            RuleEngine::from_configs(&configs).is_err(),
+            "{configs:?} should be rejected"
+        );
+    }
+}
+
+#[test]
+fn layout_rules_report_source_lines() {
+    let source = "fn main() {\n    // start the server\n    run();\n\n\n}\n// Done\n/// doc comment\n// x = 1\nfn end() {}";
+    let file = FileAnalysis::from_source("main.rs", source, &[]);
+    let engine = RuleEngine::from_configs(&[
+        builtin("newline", "trailing-newline"),
+        builtin("comments", "comment-capitalization"),
+        builtin("braces", "no-blank-lines-before-closing-brace"),
+    ])
+    .expect("rules are valid");
+
+    let findings = engine.check_file_source(&file, source);
+    let findings: Vec<(&str, Option<(usize, usize)>)> = findings
+        .iter()
+        .map(|finding| (finding.rule.as_str(), finding.line_range))
+        .collect();
+    assert_eq!(
+        findings,
+        vec![
+            ("newline", Some((10, 10))),
+            ("comments", Some((2, 2))),
+            ("braces", Some((4, 5))),
+        ]
+    );
+    assert!(engine.check_file(&file).is_empty());
+}
+
+#[test]
+fn go_code_smell_rules_report_only_when_enabled() {
+    let source = "package store\n\nvar cache = map[string]int{}\n\nfunc Load(key interface{}) (int, int, int, int) {\n\tpanic(\"todo\")\n}\n";
+    let file = FileAnalysis::from_source("store/store.go", source, &[]);
+
+    let mut returns = builtin("returns", "go-max-return-values");
+    returns
+        .params
+        .insert("max".to_string(), serde_json::json!(4));
+    let engine = RuleEngine::from_configs(&[
+        returns,
+        builtin("globals", "go-package-globals"),
+        builtin("panics", "go-library-panic"),
+    ])
+    .expect("rules are valid");
+
+    let findings = engine.check_file_source(&file, source);
+    let findings: Vec<(&str, Option<&str>, Option<(usize, usize)>)> = findings
+        .iter()
+        .map(|finding| {
+            (
+                finding.rule.as_str(),
+                finding.entity.as_deref(),
+                finding.line_range,
+            )
+        })
+        .collect();
+    assert_eq!(
+        findings,
+        vec![
+            ("globals", Some("cache"), Some((3, 3))),
+            ("panics", Some("Load"), Some((6, 6))),
+        ]
+    );
+
+    let test_file = FileAnalysis::from_source("store/store_test.go", source, &[]);
+    assert!(engine.check_file_source(&test_file, source).is_empty());
+}
+
+#[test]
+fn fix_applies_fixable_rules_in_order() {
+    let source = "def run():\n    # retry once\n    return 1";
+    let mut length = builtin("length", "max-file-lines");
+    length
+        .params
+        .insert("max_lines".to_string(), serde_json::json!(1));
+    let engine = RuleEngine::from_configs(&[
+        length,
+        builtin("newline", "trailing-newline"),
+        builtin("comments", "comment-capitalization"),
+    ])
+    .expect("rules are valid");
+
+    assert!(!engine.is_fixable("length"));
+    assert!(engine.is_fixable("comments"));
+    let fixed = engine.fix("app.py", source).expect("source changes");
+    assert_eq!(fixed.source, "def run():\n    # Retry once\n    return 1\n");
+    assert_eq!(fixed.rules, vec!["newline", "comments"]);
+    assert_eq!(engine.fix("app.py", &fixed.source), None);
+
+    let fixable: Vec<&str> = BUILTIN_RULES
+        .iter()
+        .filter(|rule| rule.fixable)
+        .map(|rule| rule.id)
+        .collect();
+    assert_eq!(
+        fixable,
+        [
+            "trailing-newline",
+            "comment-capitalization",
+            "no-blank-lines-before-closing-brace"
+        ]
+    );
+}
+
+#[test]
+fn blank_lines_before_closing_braces_are_removed() {
+    let source = "\nclass A {\n  int x;\n\n  \n}\n\nvoid f() {}\n";
+    let engine =
+        RuleEngine::from_configs(&[builtin("braces", "no-blank-lines-before-closing-brace")])
+            .expect("rule is valid");
+    let fixed = engine.fix("A.java", source).expect("source changes");
+    assert_eq!(fixed.source, "\nclass A {\n  int x;\n}\n\nvoid f() {}\n");
+}
+
+#[test]
+fn rule_configs_deserialize_with_default_severity() {
+    let configs: Vec<RuleConfig> = serde_yaml::from_str(
+        r#"
+- name: short-functions
+  severity: error
+  builtin: max-function-length
+  params: { max_lines: 30 }
+- name: wide
+  expr: "params > 4"
+"#,
+    )
+    .expect("rules deserialize");
+
+    assert_eq!(configs[0].severity, RuleSeverity::Error);
+    assert_eq!(configs[0].params["max_lines"], serde_json::json!(30));
+    assert_eq!(configs[1].severity, RuleSeverity::Warning);
+    assert!(RuleEngine::from_configs(&configs).is_ok());
+}
+
+#[test]
+fn evaluate_reads_files_relative_to_project_root() {
+    let dir = tempfile::tempdir().expect("tempdir");
+    std::fs::write(dir.path().join("big.py"), "x = 1\n".repeat(3)).expect("write file");
+
+    let mut results = AnalysisResults::empty();
+    results.project_root = dir.path().to_path_buf();
+    for path in ["big.py", "missing.py"] {
+        results
+            .file_health
+            .insert(dir.path().join(path).to_string_lossy().into_owned(), 1.0);
+    }
+
+    let mut config = builtin("small-files", "max-file-lines");
+    config
+        .params
+        .insert("max_lines".to_string(), serde_json::json!(2));
+    let findings = RuleEngine::from_configs(&[config])
+        .expect("rule is valid")
+        .evaluate(&results);
+
+    assert_eq!(findings.len(), 1);
+    assert_eq!(findings[0].file_path, "big.py");
+    assert_eq!(findings[0].message, "file is 3 lines long (max 2)");
+    assert!(RuleEngine::new().evaluate(&results).is_empty());
+}
+
+#[test]
+fn queries_filter_and_collect_json() {
+    let report = serde_json::json!({
+        "results": [
+            { "name": "parse", "score": 12, "tags": ["hot"] },
+            { "name": "render", "score": 3, "tags": [] },
+            { "name": "parse_args", "score": 30 },
+        ],
+    });
+    let run = |source: &str| Query::parse(source).expect(source).apply(&report);
+
+    assert_eq!(
+        run(r#".results[] | select(.score > 10 and (.name | startswith("parse"))) | .name"#),
+        vec![serde_json::json!("parse"), serde_json::json!("parse_args")]
+    );
+    assert_eq!(
+        run(".results | map(.tags | length)"),
+        vec![serde_json::json!([1, 0, 0])]
+    );
+    assert_eq!(
+        run(r#".results[-1]."name", .missing.deeper, (.results | length)"#),
+        vec![
+            serde_json::json!("parse_args"),
+            serde_json::Value::Null,
+            serde_json::json!(3)
+        ]
+    );
+    assert_eq!(
+        run(r#"[.results[].name | select(test("^r") | not)]"#),
+        vec![serde_json::json!(["parse", "parse_args"])]
+    );
+    assert!(run(".results[0].name[]").is_empty());
+    assert!(Query::parse(".results[] | frobnicate").is_err());
+}
+
+#[test]
+fn project_rules_report_packages_functions_and_query_matches() {
+    let dir = tempfile::tempdir().expect("tempdir");
+    std::fs::create_dir(dir.path().join("shapes")).expect("create package");
+    std::fs::write(dir.path().join("shapes/shapes.go"), GO_SOURCE).expect("write file");
+
+    let mut results = AnalysisResults::empty();
+    results.project_root = dir.path().to_path_buf();
+    let file_path = dir.path().join("shapes/shapes.go");
+    results
+        .file_health
+        .insert(file_path.to_string_lossy().into_owned(), 0.4);
+
+    let mut exports = builtin("narrow-packages", "max-package-exports");
+    exports
+        .params
+        .insert("max".to_string(), serde_json::json!(2));
+    let findings = RuleEngine::from_configs(&[
+        builtin("documented-functions", "exported-functions-documented"),
+        exports,
+        builtin("acyclic", "no-circular-imports"),
+        query("unhealthy", ".file_health | select(length > 0) | keys"),
+    ])
+    .expect("rules are valid")
+    .evaluate(&results);
+
+    let flagged: Vec<(&str, &str, Option<&str>)> = findings
+        .iter()
+        .map(|finding| {
+            (
+                finding.rule.as_str(),
+                finding.file_path.as_str(),
+                finding.entity.as_deref(),
+            )
+        })
+        .collect();
+    assert_eq!(
+        flagged,
+        vec![
+            ("unhealthy", ".", None),
+            ("narrow-packages", "shapes", None),
+            ("documented-functions", "shapes/shapes.go", Some("Area")),
+        ]
+    );
+    assert_eq!(
+        findings[1].message,
+        "package `shapes` exports 3 symbols (max 2)"
+    );
+    assert!(findings[0].message.starts_with("`.file_health"));
+}
+
+#[test]
+fn lock_ordering_reports_inversions_across_files() {
+    let dir = tempfile::tempdir().expect("tempdir");
+    std::fs::create_dir(dir.path().join("cache")).expect("create package");
+    std::fs::write(
+        dir.path().join("cache/cache.go"),
+        "package cache\n\nimport \"sync\"\n\ntype Cache struct {\n\tmu    sync.Mutex\n\tstats *Stats\n}\n\ntype Stats struct {\n\tmu sync.RWMutex\n}\n\nfunc (c *Cache) Put() {\n\tc.mu.Lock()\n\tdefer c.mu.Unlock()\n\tc.stats.record()\n}\n",
+    )
+    .expect("write cache");
+    std::fs::write(
+        dir.path().join("cache/stats.go"),
+        "package cache\n\nfunc (s *Stats) record() {\n\ts.mu.Lock()\n\ts.mu.Unlock()\n}\n\nfunc (s *Stats) Reset(c *Cache) {\n\ts.mu.Lock()\n\tdefer s.mu.Unlock()\n\tc.mu.Lock()\n\tc.mu.Unlock()\n}\n",
+    )
+    .expect("write stats");
+
+    let mut results = AnalysisResults::empty();
+    results.project_root = dir.path().to_path_buf();
+    for name in ["cache.go", "stats.go"] {
+        let path = dir.path().join("cache").join(name);
+        results
+            .file_health
+            .insert(path.to_string_lossy().into_owned(), 1.0);
+    }
+
+    let evaluate = |max_depth: u64| {
+        let mut config = builtin("lock-order", "lock-ordering");
+        config
+            .params
+            .insert("max_depth".to_string(), serde_json::json!(max_depth));
+        RuleEngine::from_configs(&[config])
+            .expect("rules are valid")
+            .evaluate(&results)
+    };
+
+    let findings = evaluate(3);
+    assert_eq!(findings.len(), 1);
+    assert_eq!(findings[0].file_path, "cache/cache.go");
+    assert_eq!(findings[0].line_range, Some((17, 17)));
+    assert_eq!(
+        findings[0].message,
+        "lock order inversion: `cache.Cache.Put` locks `cache.Cache.mu` then \
+         `cache.Stats.mu` (via cache.Stats.record) but `cache.Stats.Reset` \
+         (cache/stats.go:11) locks `cache.Stats.mu` then `cache.Cache.mu`"
+    );
+    assert!(evaluate(0).is_empty());
+}
+
+#[test]
+fn constructor_bypass_reports_literals_outside_tests() {
+    let dir = tempfile::tempdir().expect("tempdir");
+    std::fs::create_dir_all(dir.path().join("store")).expect("create package");
+    std::fs::create_dir_all(dir.path().join("api")).expect("create package");
+    let files = [
+        (
+            "store/store.go",
+            "package store\n\n//valknut:require-constructor\ntype ComplexStruct struct {\n\tName string\n}\n\nfunc NewComplexStruct(name string) *ComplexStruct {\n\treturn &ComplexStruct{Name: name}\n}\n",
+        ),
+        (
+            "api/api.go",
+            "package api\n\nfunc Build() *store.ComplexStruct {\n\treturn &store.ComplexStruct{Name: \"api\"}\n}\n",
+        ),
+        (
+            "api/api_test.go",
+            "package api\n\nvar fixture = store.ComplexStruct{Name: \"test\"}\n",
+        ),
+    ];
+    let mut results = AnalysisResults::empty();
+    results.project_root = dir.path().to_path_buf();
+    for (path, source) in files {
+        let path = dir.path().join(path);
+        std::fs::write(&path, source).expect("write source");
+        results
+            .file_health
+            .insert(path.to_string_lossy().into_owned(), 1.0);
+    }
+
+    let findings = RuleEngine::from_configs(&[builtin("constructors", "go-constructor-bypass")])
+        .expect("rules are valid")
+        .evaluate(&results);
+    assert_eq!(findings.len(), 1);
+    assert_eq!(findings[0].file_path, "api/api.go");
+    assert_eq!(findings[0].line_range, Some((4, 4)));
+    assert_eq!(
+        findings[0].message,
+        "`store.ComplexStruct` requires `NewComplexStruct` (//valknut:require-constructor) \
+         but is built with a literal at line 4"
+    );
+}
+
+const SHAPES_BEFORE: &str = "package shapes
+
+func Area(side int) int {
+\treturn side * side
+}
+
+func perimeter(side int) int {
+\treturn 4 * side
+}
+
+func Volume(side int) int {
+\treturn side * side * side
+}
+";
+
+const SHAPES_AFTER: &str = "package shapes
+
+func Area(side int, scale int) int {
+\treturn side * side * scale
+}
+
+func Volume(side int) int {
+\treturn side * side * side
+}
+
+func Perimeter(side int) int {
+\treturn 4 * side
+}
+
+func Diagonal(side int) float64 {
+\treturn 1.41 * float64(side)
+}
+";
+
+#[test]
+fn file_diff_reports_signatures_moves_renames_and_additions() {
+    let before = FileAnalysis::from_source("shapes.go", SHAPES_BEFORE, &[]);
+    let after = FileAnalysis::from_source("shapes.go", SHAPES_AFTER, &[]);
+    let diff = before.diff(&after);
+
+    let changes: Vec<_> = diff
+        .changes
+        .iter()
+        .map(|change| (change.change, change.name.as_str(), change.line))
+        .collect();
+    assert_eq!(
+        changes,
+        vec![
+            (SymbolDiffKind::SignatureChanged, "Area", 3),
+            (SymbolDiffKind::Moved, "Volume", 7),
+            (SymbolDiffKind::Renamed, "Perimeter", 11),
+            (SymbolDiffKind::Added, "Diagonal", 15),
+        ]
+    );
+    assert_eq!(
+        diff.changes[0].previous_signature.as_deref(),
+        Some("func Area(side int) int")
+    );
+    assert_eq!(
+        diff.changes[0].signature,
+        "func Area(side int, scale int) int"
+    );
+    assert_eq!(diff.changes[1].previous_line, Some(11));
+    assert_eq!(diff.changes[2].previous_name.as_deref(), Some("perimeter"));
+    assert_eq!(diff.changes[2].previous_line, Some(7));
+    assert!(diff.changes[2].previous_file.is_none());
+
+    let json = serde_json::to_value(&diff).unwrap();
+    assert_eq!(json["changes"][0]["change"], "signature_changed");
+    assert_eq!(json["changes"][3]["kind"], "function");
+    assert!(json["changes"][3].get("previous_name").is_none());
+
+    assert!(before.diff(&before).is_empty());
+}
+
+#[test]
+fn file_diff_reports_a_moved_rename_once() {
+    let before = FileAnalysis::from_source("shapes.go", SHAPES_BEFORE, &[]);
+    let after = FileAnalysis::from_source("geometry/shapes.go", SHAPES_AFTER, &[]);
+    let diff = before.diff(&after);
+
+    let perimeter: Vec<_> = diff
+        .changes
+        .iter()
+        .filter(|change| change.name.eq_ignore_ascii_case("perimeter"))
+        .collect();
+    assert_eq!(perimeter.len(), 1);
+    assert_eq!(perimeter[0].change, SymbolDiffKind::Renamed);
+    assert_eq!(perimeter[0].file_path, "geometry/shapes.go");
+    assert_eq!(perimeter[0].previous_file.as_deref(), Some("shapes.go"));
+    assert_eq!(diff.of_kind(SymbolDiffKind::Removed).count(), 0);
+    // Area stays on line 3 but changed file, so it moved as well.
+    assert!(diff
+        .of_kind(SymbolDiffKind::Moved)
+        .any(|change| change.name == "Area" && change.previous_line == Some(3)));
+}
+
+#[test]
+fn file_diff_reports_body_changes_but_not_reformatting() {
+    let before = FileAnalysis::from_source("shapes.go", SHAPES_BEFORE, &[]);
+    let edited = SHAPES_BEFORE
+        .replace("return side * side\n", "return  side *  side\n")
+        .replace("return 4 * side", "return side + side + side + side");
+    let after = FileAnalysis::from_source("shapes.go", &edited, &[]);
+    let diff = before.diff(&after);
+
+    let changes: Vec<_> = diff
+        .changes
+        .iter()
+        .map(|change| (change.change, change.name.as_str(), change.line))
+        .collect();
+    assert_eq!(changes, vec![(SymbolDiffKind::BodyChanged, "perimeter", 7)]);
+    assert!(diff.changes[0].previous_signature.is_none());
+    assert_eq!(
+        serde_json::to_value(&diff).unwrap()["changes"][0]["change"],
+        "body_changed"
+    );
+}
@@ -7,7 +7,8 @@ use std::path::PathBuf;
 
 /// Code file extensions recognized for structure analysis
 pub const CODE_EXTENSIONS: &[&str] = &[
-    "py", "pyi", "js", "mjs", "ts", "jsx", "tsx", "rs", "go", "java", "cpp", "c", "h", "hpp",
+    "py", "pyi", "ipynb", "js", "mjs", "ts", "jsx", "tsx", "rs", "go", "java", "cs", "kt", "cpp",
+    "c", "h", "hpp",
 ];
 
 /// Check if an extension is a recognized code file extension
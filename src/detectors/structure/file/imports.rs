@@ -136,7 +136,7 @@ impl ImportResolver {
                 .and_then(|value| value.as_str())
                 .map(|vis| vis.contains("pub"))
                 .unwrap_or(false),
-            "py" | "pyi" => {
+            "py" | "pyi" | "ipynb" => {
                 if entity.name.starts_with('_') {
                     return false;
                 }
@@ -152,6 +152,14 @@ impl ImportResolver {
                 self.line_has_export_keyword(content, entity.location.start_line)
             }
             "java" => self.line_has_keyword(content, entity.location.start_line, "public"),
+            "c" | "h" => {
+                let is_static = entity
+                    .metadata
+                    .get("is_static")
+                    .and_then(|value| value.as_bool())
+                    .unwrap_or(false);
+                entity.parent.is_none() && !is_static
+            }
             _ => entity.parent.is_none(),
         }
     }
@@ -327,6 +335,11 @@ impl ImportResolver {
             candidates.push(current_dir.join(module));
         }
 
+        // C headers commonly live in a top-level `include/` directory.
+        if import.import_type.ends_with("include") {
+            candidates.push(project_root.join("include").join(module));
+        }
+
         for candidate in candidates {
             if let Some(resolved) = self.resolve_candidate_path(&candidate) {
                 return Some(resolved);
@@ -1109,6 +1109,61 @@ def run():
     );
 }
 
+#[test]
+fn test_c_includes_link_sources_to_headers() {
+    let temp_dir = TempDir::new().unwrap();
+    let root_path = temp_dir.path();
+    fs::create_dir_all(root_path.join("include")).unwrap();
+    fs::create_dir_all(root_path.join("src")).unwrap();
+    let header = root_path.join("include").join("uart.h");
+    let source = root_path.join("src").join("uart.c");
+    let main_file = root_path.join("src").join("main.c");
+
+    fs::write(&header, "void uart_init(int baud);\n").unwrap();
+    fs::write(
+        &source,
+        r#"#include "uart.h"
+#include <stdint.h>
+
+static int divisor(int baud) { return 16000000 / baud; }
+
+void uart_init(int baud) { (void)divisor(baud); }
+"#,
+    )
+    .unwrap();
+    fs::write(
+        &main_file,
+        "#include \"uart.h\"\n\nint main(void) { uart_init(9600); return 0; }\n",
+    )
+    .unwrap();
+
+    let analyzer = FileAnalyzer::new(create_test_config());
+    let graph: CohesionGraph = Graph::new_undirected();
+
+    let header_metrics = analyzer
+        .collect_dependency_metrics(&header, Some(root_path), &graph)
+        .unwrap();
+    for importer in [&source, &main_file] {
+        assert!(
+            header_metrics
+                .incoming_importers
+                .contains(&analyzer.canonicalize_path(importer)),
+            "{} should include uart.h",
+            importer.display()
+        );
+    }
+
+    let source_metrics = analyzer
+        .collect_dependency_metrics(&source, Some(root_path), &graph)
+        .unwrap();
+    let exports: Vec<&str> = source_metrics
+        .exports
+        .iter()
+        .map(|entity| entity.name.as_str())
+        .collect();
+    assert_eq!(exports, vec!["uart_init"]);
+}
+
 #[test]
 fn test_collect_dependency_metrics_without_project_root() {
     let temp_dir = TempDir::new().unwrap();
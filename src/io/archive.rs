@@ -0,0 +1,357 @@
+//! Analysis of compressed source archives.
+//!
+//! [`ExtractedArchive::extract`] unpacks a `.tar.gz`, `.zip`, `.tar.bz2`,
+//! `.tar.xz` or plain `.tar` archive into a private temporary directory so it
+//! can be analyzed like a local tree, and removes the directory on drop.
+//! Entries are decompressed and written one at a time straight from the
+//! archive file; the archive itself is never copied. Because the directory
+//! holds exactly the archive's contents, result paths are relative to the
+//! archive root.
+//!
+//! Only regular files and directories are extracted. Symlinks, hard links,
+//! device nodes and entries whose path would leave the archive root are
+//! skipped. Password-protected ZIP entries fail the extraction with an
+//! [`Unsupported`](ValknutError::Unsupported) error.
+
+use std::fs::File;
+use std::io::{BufReader, Read};
+use std::path::{Path, PathBuf};
+
+use tracing::{info, warn};
+use zip::result::ZipError;
+
+use crate::core::errors::{Result, ValknutError};
+
+/// Archive formats recognised by file name.
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub enum ArchiveFormat {
+    /// Uncompressed tarball (`.tar`)
+    Tar,
+    /// Gzip-compressed tarball (`.tar.gz`, `.tgz`)
+    TarGz,
+    /// Bzip2-compressed tarball (`.tar.bz2`, `.tbz2`, `.tbz`)
+    TarBz2,
+    /// XZ-compressed tarball (`.tar.xz`, `.txz`)
+    TarXz,
+    /// ZIP archive (`.zip`)
+    Zip,
+}
+
+/// File name suffixes of each format, checked in order.
+const SUFFIXES: &[(&str, ArchiveFormat)] = &[
+    (".tar.gz", ArchiveFormat::TarGz),
+    (".tgz", ArchiveFormat::TarGz),
+    (".tar.bz2", ArchiveFormat::TarBz2),
+    (".tbz2", ArchiveFormat::TarBz2),
+    (".tbz", ArchiveFormat::TarBz2),
+    (".tar.xz", ArchiveFormat::TarXz),
+    (".txz", ArchiveFormat::TarXz),
+    (".tar", ArchiveFormat::Tar),
+    (".zip", ArchiveFormat::Zip),
+];
+
+/// Detection methods for [`ArchiveFormat`].
+impl ArchiveFormat {
+    /// Format of `path` from its file name, ignoring case.
+    pub fn detect(path: &Path) -> Option<Self> {
+        let name = path.file_name()?.to_string_lossy().to_ascii_lowercase();
+        SUFFIXES
+            .iter()
+            .find(|(suffix, _)| name.len() > suffix.len() && name.ends_with(suffix))
+            .map(|(_, format)| *format)
+    }
+}
+
+/// An archive unpacked into a temporary directory.
+#[derive(Debug)]
+pub struct ExtractedArchive {
+    /// Archive that was extracted.
+    pub archive: PathBuf,
+    /// Detected archive format.
+    pub format: ArchiveFormat,
+    /// Number of regular files extracted.
+    pub files: usize,
+    /// Extraction directory; removed on drop.
+    root: PathBuf,
+}
+
+/// Extraction and accessor methods for [`ExtractedArchive`].
+impl ExtractedArchive {
+    /// Extract `archive`, whose format is detected from its file name.
+    pub fn extract(archive: &Path) -> Result<Self> {
+        let format = ArchiveFormat::detect(archive).ok_or_else(|| {
+            ValknutError::unsupported(format!(
+                "{} is not a supported archive (.tar.gz, .zip, .tar.bz2, .tar.xz or .tar)",
+                archive.display()
+            ))
+        })?;
+        let root = std::env::temp_dir().join(format!("valknut-archive-{}", uuid::Uuid::new_v4()));
+        // Construct first so the directory is cleaned up on every error path below.
+        let mut extracted = Self {
+            archive: archive.to_path_buf(),
+            format,
+            files: 0,
+            root,
+        };
+        std::fs::create_dir_all(&extracted.root).map_err(|e| {
+            ValknutError::io(format!("Failed to create {}", extracted.root.display()), e)
+        })?;
+
+        let file = File::open(archive)
+            .map_err(|e| ValknutError::io(format!("Failed to open {}", archive.display()), e))?;
+        info!(
+            "Extracting {} into {}",
+            archive.display(),
+            extracted.root.display()
+        );
+        let reader = BufReader::new(file);
+        extracted.files = match format {
+            ArchiveFormat::Tar => extract_tar(reader, archive, &extracted.root)?,
+            ArchiveFormat::TarGz => extract_tar(
+                flate2::read::GzDecoder::new(reader),
+                archive,
+                &extracted.root,
+            )?,
+            ArchiveFormat::TarBz2 => extract_tar(
+                bzip2::read::BzDecoder::new(reader),
+                archive,
+                &extracted.root,
+            )?,
+            ArchiveFormat::TarXz => {
+                extract_tar(xz2::read::XzDecoder::new(reader), archive, &extracted.root)?
+            }
+            ArchiveFormat::Zip => extract_zip(reader.into_inner(), archive, &extracted.root)?,
+        };
+        Ok(extracted)
+    }
+
+    /// Directory holding the archive's contents.
+    pub fn root(&self) -> &Path {
+        &self.root
+    }
+}
+
+/// Removes the extraction directory.
+impl Drop for ExtractedArchive {
+    fn drop(&mut self) {
+        if let Err(e) = std::fs::remove_dir_all(&self.root) {
+            if e.kind() != std::io::ErrorKind::NotFound {
+                warn!("Failed to remove {}: {}", self.root.display(), e);
+            }
+        }
+    }
+}
+
+/// Returns true when `path` names an archive file rather than a directory.
+pub fn is_archive(path: &Path) -> bool {
+    path.is_file() && ArchiveFormat::detect(path).is_some()
+}
+
+/// Unpack the regular files and directories of a tar stream into `root`.
+fn extract_tar(reader: impl Read, archive: &Path, root: &Path) -> Result<usize> {
+    let corrupt = |e: std::io::Error| {
+        ValknutError::io(
+            format!("Failed to read tar archive {}", archive.display()),
+            e,
+        )
+    };
+    let mut tarball = tar::Archive::new(reader);
+    let mut files = 0;
+    for entry in tarball.entries().map_err(corrupt)? {
+        let mut entry = entry.map_err(corrupt)?;
+        let kind = entry.header().entry_type();
+        if !kind.is_file() && !kind.is_dir() {
+            continue;
+        }
+        // `unpack_in` refuses entries that would land outside `root`.
+        let unpacked = entry.unpack_in(root).map_err(corrupt)?;
+        if !unpacked {
+            warn!(
+                "Skipping {} entry outside the archive root: {}",
+                archive.display(),
+                entry
+                    .path()
+                    .map(|p| p.display().to_string())
+                    .unwrap_or_default()
+            );
+        } else if kind.is_file() {
+            files += 1;
+        }
+    }
+    Ok(files)
+}
+
+/// Unpack the regular files and directories of a ZIP archive into `root`.
+fn extract_zip(file: File, archive: &Path, root: &Path) -> Result<usize> {
+    let mut zip = zip::ZipArchive::new(file).map_err(|e| zip_error(archive, e))?;
+    let mut files = 0;
+    for index in 0..zip.len() {
+        let mut entry = zip.by_index(index).map_err(|e| zip_error(archive, e))?;
+        let Some(relative) = entry.enclosed_name() else {
+            warn!(
+                "Skipping {} entry outside the archive root: {}",
+                archive.display(),
+                entry.name()
+            );
+            continue;
+        };
+        let target = root.join(relative);
+        if entry.is_dir() {
+            std::fs::create_dir_all(&target).map_err(|e| {
+                ValknutError::io(format!("Failed to create {}", target.display()), e)
+            })?;
+            continue;
+        }
+        if !entry.is_file() {
+            continue;
+        }
+        if let Some(parent) = target.parent() {
+            std::fs::create_dir_all(parent).map_err(|e| {
+                ValknutError::io(format!("Failed to create {}", parent.display()), e)
+            })?;
+        }
+        let mut output = File::create(&target)
+            .map_err(|e| ValknutError::io(format!("Failed to create {}", target.display()), e))?;
+        std::io::copy(&mut entry, &mut output).map_err(|e| {
+            ValknutError::io(
+                format!(
+                    "Failed to extract {} from {}",
+                    entry.name(),
+                    archive.display()
+                ),
+                e,
+            )
+        })?;
+        files += 1;
+    }
+    Ok(files)
+}
+
+/// Error for a ZIP archive that cannot be read; encrypted entries get a
+/// dedicated message since no password can be supplied.
+fn zip_error(archive: &Path, error: ZipError) -> ValknutError {
+    match error {
+        ZipError::UnsupportedArchive(message)
+            if message == ZipError::PASSWORD_REQUIRED
+                || message.to_ascii_lowercase().contains("encrypt") =>
+        {
+            ValknutError::unsupported(format!(
+                "{} is password-protected; extract it yourself and analyze the directory",
+                archive.display()
+            ))
+        }
+        ZipError::Io(e) => ValknutError::io(
+            format!("Failed to read ZIP archive {}", archive.display()),
+            e,
+        ),
+        other => ValknutError::validation(format!(
+            "Failed to read ZIP archive {}: {}",
+            archive.display(),
+            other
+        )),
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use std::io::Write;
+
+    fn tar_bytes(entries: &[(&str, &str)]) -> Vec<u8> {
+        let mut builder = tar::Builder::new(Vec::new());
+        for (path, contents) in entries {
+            let mut header = tar::Header::new_gnu();
+            header.set_size(contents.len() as u64);
+            header.set_mode(0o644);
+            header.set_cksum();
+            builder
+                .append_data(&mut header, path, contents.as_bytes())
+                .unwrap();
+        }
+        builder.into_inner().unwrap()
+    }
+
+    #[test]
+    fn detects_formats_from_file_names() {
+        let cases = [
+            ("repo.tar.gz", Some(ArchiveFormat::TarGz)),
+            ("REPO.TGZ", Some(ArchiveFormat::TarGz)),
+            ("repo.zip", Some(ArchiveFormat::Zip)),
+            ("repo.tar.bz2", Some(ArchiveFormat::TarBz2)),
+            ("repo.tar.xz", Some(ArchiveFormat::TarXz)),
+            ("repo.tar", Some(ArchiveFormat::Tar)),
+            ("main.go", None),
+            (".zip", None),
+        ];
+        for (name, expected) in cases {
+            assert_eq!(ArchiveFormat::detect(Path::new(name)), expected, "{name}");
+        }
+    }
+
+    #[test]
+    fn extracts_tar_gz_relative_to_the_archive_root() {
+        let dir = tempfile::tempdir().unwrap();
+        let path = dir.path().join("repo.tar.gz");
+        let mut encoder =
+            flate2::write::GzEncoder::new(File::create(&path).unwrap(), Default::default());
+        encoder
+            .write_all(&tar_bytes(&[
+                ("repo/main.go", "package main\n"),
+                ("repo/pkg/util.go", "package pkg\n"),
+            ]))
+            .unwrap();
+        encoder.finish().unwrap();
+
+        let extracted = ExtractedArchive::extract(&path).unwrap();
+        assert_eq!(extracted.format, ArchiveFormat::TarGz);
+        assert_eq!(extracted.files, 2);
+        assert_eq!(
+            std::fs::read_to_string(extracted.root().join("repo/pkg/util.go")).unwrap(),
+            "package pkg\n"
+        );
+
+        let root = extracted.root().to_path_buf();
+        drop(extracted);
+        assert!(!root.exists());
+    }
+
+    #[test]
+    fn extracts_zip_files_and_directories() {
+        let dir = tempfile::tempdir().unwrap();
+        let path = dir.path().join("repo.zip");
+        let mut writer = zip::ZipWriter::new(File::create(&path).unwrap());
+        let options = zip::write::SimpleFileOptions::default();
+        writer.add_directory("src/", options).unwrap();
+        writer.start_file("src/lib.rs", options).unwrap();
+        writer.write_all(b"pub fn f() {}\n").unwrap();
+        writer.finish().unwrap();
+
+        let extracted = ExtractedArchive::extract(&path).unwrap();
+        assert_eq!(extracted.files, 1);
+        assert_eq!(
+            std::fs::read_to_string(extracted.root().join("src/lib.rs")).unwrap(),
+            "pub fn f() {}\n"
+        );
+    }
+
+    #[test]
+    fn corrupt_archives_fail_and_clean_up() {
+        let dir = tempfile::tempdir().unwrap();
+        let path = dir.path().join("broken.zip");
+        std::fs::write(&path, b"not a zip").unwrap();
+        assert!(ExtractedArchive::extract(&path).is_err());
+        assert!(ExtractedArchive::extract(&dir.path().join("notes.txt")).is_err());
+    }
+
+    #[test]
+    fn password_protected_zip_entries_get_a_clear_error() {
+        let error = zip_error(
+            Path::new("secret.zip"),
+            ZipError::UnsupportedArchive(ZipError::PASSWORD_REQUIRED),
+        );
+        assert!(matches!(error, ValknutError::Unsupported { .. }));
+        assert!(error
+            .to_string()
+            .contains("secret.zip is password-protected"));
+    }
+}
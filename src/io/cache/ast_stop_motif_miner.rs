@@ -3,6 +3,7 @@
 //! This module provides the `AstStopMotifMiner` for mining AST-based patterns.
 
 use std::collections::{HashMap, HashSet};
+use tracing::warn;
 
 use super::language_adapters::{
     GoLanguageAdapter, JavaScriptLanguageAdapter, LanguageAdapter, PythonLanguageAdapter,
@@ -83,13 +84,13 @@ impl AstStopMotifMiner {
 
             let Ok(parse_index) = adapter.parse_source(&function.source_code, &function.file_path)
             else {
-                eprintln!("Failed to parse source code for {}", function.id);
+                warn!("Failed to parse source code for {}", function.id);
                 continue;
             };
 
             match adapter.extract_ast_patterns(&parse_index, &function.source_code) {
                 Ok(patterns) => all_patterns.extend(patterns),
-                Err(e) => eprintln!(
+                Err(e) => warn!(
                     "Failed to extract AST patterns from {}: {:?}",
                     function.id, e
                 ),
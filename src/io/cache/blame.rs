@@ -0,0 +1,169 @@
+//! Persistent cache of per-file git blame.
+//!
+//! Blame is expensive to compute, so `valknut blame` stores the hunks of each
+//! file next to the incremental index. An entry is reused while both the
+//! committed blob and the working-tree content of the file are unchanged.
+
+use std::collections::HashMap;
+use std::fs;
+use std::path::{Path, PathBuf};
+
+use serde::{Deserialize, Serialize};
+
+use crate::core::errors::{Result, ValknutError, ValknutResultExt};
+
+/// Current on-disk format version of the blame index.
+const INDEX_VERSION: u32 = 1;
+
+/// File name of the blame index inside the cache directory.
+const INDEX_FILE_NAME: &str = "blame.v1.json";
+
+/// A run of consecutive lines last modified by the same commit.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct BlameHunkRecord {
+    /// 1-based first line of the hunk in the working-tree file
+    pub start_line: usize,
+    /// Number of lines in the hunk
+    pub lines: usize,
+    /// Commit that last modified the lines (empty when not committed yet)
+    pub commit: String,
+    /// Author name of that commit
+    pub author_name: String,
+    /// Author email of that commit
+    pub author_email: String,
+    /// Author time in seconds since the Unix epoch
+    pub time_secs: i64,
+}
+
+/// Cached blame for one file.
+#[derive(Debug, Clone, Serialize, Deserialize)]
+pub struct CachedBlame {
+    /// Blob id of the file at HEAD (empty when the file is untracked)
+    pub head_blob: String,
+    /// Hex-encoded SHA-256 of the working-tree content
+    pub sha256: String,
+    /// Hunks in line order
+    pub hunks: Vec<BlameHunkRecord>,
+}
+
+/// Serialized form of the blame index.
+#[derive(Debug, Clone, Default, Serialize, Deserialize)]
+struct BlameIndex {
+    /// Index format version
+    version: u32,
+    /// Entries keyed by canonical file path
+    entries: HashMap<String, CachedBlame>,
+}
+
+/// Blame hunks keyed by file, persisted as JSON in the cache directory.
+#[derive(Debug)]
+pub struct BlameCache {
+    /// Directory holding the index file
+    cache_dir: PathBuf,
+    /// Loaded index
+    index: BlameIndex,
+    /// Whether entries were added since loading
+    dirty: bool,
+}
+
+/// Loading, lookup, and persistence methods for [`BlameCache`].
+impl BlameCache {
+    /// Open the cache in `cache_dir`, starting empty when no usable index exists.
+    pub fn open<P: AsRef<Path>>(cache_dir: P) -> Self {
+        let cache_dir = cache_dir.as_ref().to_path_buf();
+        let index = match Self::load_index(&cache_dir.join(INDEX_FILE_NAME)) {
+            Ok(Some(index)) if index.version == INDEX_VERSION => index,
+            Ok(_) => BlameIndex {
+                version: INDEX_VERSION,
+                entries: HashMap::new(),
+            },
+            Err(e) => {
+                tracing::warn!("Ignoring unreadable blame index: {}", e);
+                BlameIndex {
+                    version: INDEX_VERSION,
+                    entries: HashMap::new(),
+                }
+            }
+        };
+
+        Self {
+            cache_dir,
+            index,
+            dirty: false,
+        }
+    }
+
+    /// Cached hunks for `path` if they were computed for the same blob and content.
+    pub fn get(&self, path: &Path, head_blob: &str, sha256: &str) -> Option<&[BlameHunkRecord]> {
+        self.index
+            .entries
+            .get(&cache_key(path))
+            .filter(|entry| entry.head_blob == head_blob && entry.sha256 == sha256)
+            .map(|entry| entry.hunks.as_slice())
+    }
+
+    /// Record freshly computed hunks for `path`.
+    pub fn store(&mut self, path: &Path, entry: CachedBlame) {
+        self.index.entries.insert(cache_key(path), entry);
+        self.dirty = true;
+    }
+
+    /// Persist the index when it changed, dropping entries for deleted files.
+    pub fn save(&mut self) -> Result<()> {
+        if !self.dirty {
+            return Ok(());
+        }
+        self.index.entries.retain(|key, _| Path::new(key).exists());
+
+        fs::create_dir_all(&self.cache_dir).map_err(|e| {
+            ValknutError::io(
+                format!(
+                    "Failed to create cache directory: {}",
+                    self.cache_dir.display()
+                ),
+                e,
+            )
+        })?;
+        let index_path = self.cache_dir.join(INDEX_FILE_NAME);
+        let temp_path = index_path.with_extension(format!("{}.tmp", uuid::Uuid::new_v4()));
+        let content = serde_json::to_string(&self.index).map_json_err("blame index")?;
+        fs::write(&temp_path, content).map_err(|e| {
+            ValknutError::io(
+                format!("Failed to write cache file: {}", temp_path.display()),
+                e,
+            )
+        })?;
+        fs::rename(&temp_path, &index_path).map_err(|e| {
+            ValknutError::io(
+                format!("Failed to rename cache file: {}", index_path.display()),
+                e,
+            )
+        })?;
+        self.dirty = false;
+        Ok(())
+    }
+
+    /// Read the index file if it exists.
+    fn load_index(index_path: &Path) -> Result<Option<BlameIndex>> {
+        if !index_path.exists() {
+            return Ok(None);
+        }
+        let content = fs::read_to_string(index_path).map_err(|e| {
+            ValknutError::io(
+                format!("Failed to read cache file: {}", index_path.display()),
+                e,
+            )
+        })?;
+        serde_json::from_str(&content)
+            .map(Some)
+            .map_json_err("blame index")
+    }
+}
+
+/// Canonical key for a file so the same file maps to one entry.
+fn cache_key(path: &Path) -> String {
+    path.canonicalize()
+        .unwrap_or_else(|_| path.to_path_buf())
+        .to_string_lossy()
+        .into_owned()
+}
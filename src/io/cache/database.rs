@@ -0,0 +1,611 @@
+//! SQLite storage for the incremental analysis cache.
+//!
+//! The [`IncrementalCache`](super::IncrementalCache) index is kept in
+//! `incremental.v2.sqlite` inside the cache directory, in four tables:
+//!
+//! - `meta`: format and tool version of the stored entries
+//! - `files`: one row per analyzed file with its hash, mtime, line count and recency
+//! - `symbols`: the entities extracted from each file
+//! - `dependencies`: the module specifiers each file imports
+//!
+//! Saving only rewrites the rows of files analyzed during the run and deletes
+//! only files the run found missing, so runs over overlapping trees keep each
+//! other's entries. Single entries or symbols can be queried without loading
+//! the rest of the cache (see [`CacheDatabase::entry`] and
+//! [`CacheDatabase::symbols_modified_since`]).
+
+use std::collections::HashMap;
+use std::fs;
+use std::path::{Path, PathBuf};
+use std::time::Duration;
+
+use rusqlite::{params, Connection, ErrorCode, OptionalExtension, Transaction};
+
+use crate::core::errors::{Result, ValknutError, ValknutResultExt};
+use crate::core::featureset::CodeEntity;
+
+use super::incremental::{entry_size, CachedFileEntry, IncrementalCache};
+
+/// Current format version of the incremental database.
+const SCHEMA_VERSION: u32 = 2;
+
+/// File name of the incremental database inside the cache directory.
+pub const DATABASE_FILE_NAME: &str = "incremental.v2.sqlite";
+
+/// Context of errors reading the database.
+const READ_CONTEXT: &str = "reading the incremental database";
+
+/// Context of errors writing the database.
+const WRITE_CONTEXT: &str = "writing the incremental database";
+
+/// How long to wait for another run holding the write lock.
+const BUSY_TIMEOUT: Duration = Duration::from_secs(5);
+
+/// Tables and indexes of the incremental database.
+const SCHEMA: &str = "
+CREATE TABLE IF NOT EXISTS meta (
+    key TEXT PRIMARY KEY,
+    value TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS files (
+    id INTEGER PRIMARY KEY,
+    path TEXT NOT NULL UNIQUE,
+    display_path TEXT NOT NULL,
+    mtime_secs INTEGER NOT NULL,
+    sha256 TEXT NOT NULL,
+    lines_of_code INTEGER NOT NULL,
+    cached_at_secs INTEGER NOT NULL,
+    size_bytes INTEGER NOT NULL,
+    last_used INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS files_mtime ON files (mtime_secs);
+CREATE TABLE IF NOT EXISTS symbols (
+    file_id INTEGER NOT NULL REFERENCES files (id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    kind TEXT NOT NULL,
+    name TEXT NOT NULL,
+    start_line INTEGER,
+    end_line INTEGER,
+    entity TEXT NOT NULL,
+    PRIMARY KEY (file_id, position)
+);
+CREATE INDEX IF NOT EXISTS symbols_name ON symbols (name);
+CREATE TABLE IF NOT EXISTS dependencies (
+    file_id INTEGER NOT NULL REFERENCES files (id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    module TEXT NOT NULL,
+    PRIMARY KEY (file_id, position)
+);
+CREATE INDEX IF NOT EXISTS dependencies_module ON dependencies (module);
+";
+
+/// Delete the least recently used files whose entries no longer fit in `?1` bytes.
+const EVICT: &str = "
+DELETE FROM files WHERE id IN (
+    SELECT id FROM (
+        SELECT id, SUM(size_bytes) OVER (ORDER BY last_used DESC, id DESC) AS kept_bytes
+        FROM files
+    )
+    WHERE kept_bytes > ?1
+)";
+
+/// An entity from the cache together with the file it was extracted from.
+#[derive(Debug, Clone)]
+pub struct CachedSymbol {
+    /// Canonical path of the file
+    pub path: PathBuf,
+
+    /// Modification time of the file in seconds since the Unix epoch
+    pub mtime_secs: u64,
+
+    /// The cached entity
+    pub entity: CodeEntity,
+}
+
+/// A cached entry with the bookkeeping the in-memory index needs.
+pub(super) struct StoredEntry {
+    /// Canonical file path
+    pub key: String,
+
+    /// Serialized size counted against the cache size limit
+    pub size_bytes: u64,
+
+    /// The cached analysis data
+    pub entry: CachedFileEntry,
+}
+
+/// Connection to the incremental database in a cache directory.
+#[derive(Debug)]
+pub struct CacheDatabase {
+    connection: Connection,
+}
+
+/// Opening, querying and writing methods for [`CacheDatabase`].
+impl CacheDatabase {
+    /// Open the database in `cache_dir` if one exists.
+    pub fn open(cache_dir: &Path) -> Result<Option<Self>> {
+        let path = cache_dir.join(DATABASE_FILE_NAME);
+        if !path.exists() {
+            return Ok(None);
+        }
+        let connection = connect(&path).map_err(database_error(&path))?;
+        Ok(Some(Self { connection }))
+    }
+
+    /// Open the database in `cache_dir`, creating the directory and the
+    /// database as needed. A file that is not a database is replaced.
+    pub fn create(cache_dir: &Path) -> Result<Self> {
+        fs::create_dir_all(cache_dir).map_err(|e| {
+            ValknutError::io(
+                format!("Failed to create cache directory: {}", cache_dir.display()),
+                e,
+            )
+        })?;
+
+        let path = cache_dir.join(DATABASE_FILE_NAME);
+        let connection = match connect(&path) {
+            Err(rusqlite::Error::SqliteFailure(e, _)) if e.code == ErrorCode::NotADatabase => {
+                tracing::warn!("Replacing corrupt incremental database: {}", path.display());
+                fs::remove_file(&path).map_err(|e| {
+                    ValknutError::io(format!("Failed to remove {}", path.display()), e)
+                })?;
+                connect(&path)
+            }
+            connection => connection,
+        }
+        .map_err(database_error(&path))?;
+        Ok(Self { connection })
+    }
+
+    /// Whether the stored entries were written by this format and tool version.
+    pub fn is_compatible(&self) -> Result<bool> {
+        let version = self.meta("version")?;
+        let tool_version = self.meta("tool_version")?;
+        Ok(version == Some(SCHEMA_VERSION.to_string())
+            && tool_version.as_deref() == Some(env!("CARGO_PKG_VERSION")))
+    }
+
+    /// A value from the `meta` table.
+    fn meta(&self, key: &str) -> Result<Option<String>> {
+        self.connection
+            .query_row("SELECT value FROM meta WHERE key = ?1", [key], |row| {
+                row.get(0)
+            })
+            .optional()
+            .map_generic_err(READ_CONTEXT)
+    }
+
+    /// The cached entry for a file, without loading any other file.
+    pub fn entry(&self, path: &Path) -> Result<Option<CachedFileEntry>> {
+        let key = IncrementalCache::cache_key(path);
+        let file = self
+            .connection
+            .query_row(
+                "SELECT id, display_path, mtime_secs, sha256, lines_of_code, cached_at_secs
+                 FROM files WHERE path = ?1",
+                [&key],
+                |row| {
+                    Ok((
+                        row.get::<_, i64>(0)?,
+                        CachedFileEntry {
+                            display_path: row.get(1)?,
+                            mtime_secs: row.get::<_, i64>(2)? as u64,
+                            sha256: row.get(3)?,
+                            imports: Vec::new(),
+                            lines_of_code: row.get::<_, i64>(4)? as usize,
+                            entities: Vec::new(),
+                            cached_at_secs: row.get::<_, i64>(5)? as u64,
+                        },
+                    ))
+                },
+            )
+            .optional()
+            .map_generic_err(READ_CONTEXT)?;
+        let Some((id, mut entry)) = file else {
+            return Ok(None);
+        };
+
+        let mut statement = self
+            .connection
+            .prepare_cached("SELECT entity FROM symbols WHERE file_id = ?1 ORDER BY position")
+            .map_generic_err(READ_CONTEXT)?;
+        let entities = statement
+            .query_map([id], |row| row.get::<_, String>(0))
+            .and_then(|rows| rows.collect::<rusqlite::Result<Vec<_>>>())
+            .map_generic_err(READ_CONTEXT)?;
+        entry.entities = entities
+            .iter()
+            .map(|entity| serde_json::from_str(entity))
+            .collect::<serde_json::Result<_>>()
+            .map_json_err("cached entity")?;
+
+        let mut statement = self
+            .connection
+            .prepare_cached("SELECT module FROM dependencies WHERE file_id = ?1 ORDER BY position")
+            .map_generic_err(READ_CONTEXT)?;
+        entry.imports = statement
+            .query_map([id], |row| row.get(0))
+            .and_then(|rows| rows.collect())
+            .map_generic_err(READ_CONTEXT)?;
+        Ok(Some(entry))
+    }
+
+    /// Number of stored files and the serialized size of their entries.
+    pub(super) fn totals(&self) -> Result<(usize, u64)> {
+        self.connection
+            .query_row(
+                "SELECT COUNT(*), COALESCE(SUM(size_bytes), 0) FROM files",
+                [],
+                |row| Ok((row.get::<_, i64>(0)? as usize, row.get::<_, i64>(1)? as u64)),
+            )
+            .map_generic_err(READ_CONTEXT)
+    }
+
+    /// Entities of every cached file modified at or after `since_secs`
+    /// (seconds since the Unix epoch), ordered by file and position.
+    pub fn symbols_modified_since(&self, since_secs: u64) -> Result<Vec<CachedSymbol>> {
+        let mut statement = self
+            .connection
+            .prepare_cached(
+                "SELECT files.path, files.mtime_secs, symbols.entity
+                 FROM symbols JOIN files ON files.id = symbols.file_id
+                 WHERE files.mtime_secs >= ?1
+                 ORDER BY files.path, symbols.position",
+            )
+            .map_generic_err(READ_CONTEXT)?;
+        let rows = statement
+            .query_map([since_secs as i64], |row| {
+                Ok((
+                    row.get::<_, String>(0)?,
+                    row.get::<_, i64>(1)? as u64,
+                    row.get::<_, String>(2)?,
+                ))
+            })
+            .and_then(|rows| rows.collect::<rusqlite::Result<Vec<_>>>())
+            .map_generic_err(READ_CONTEXT)?;
+
+        rows.into_iter()
+            .map(|(path, mtime_secs, entity)| {
+                Ok(CachedSymbol {
+                    path: PathBuf::from(path),
+                    mtime_secs,
+                    entity: serde_json::from_str(&entity).map_json_err("cached entity")?,
+                })
+            })
+            .collect()
+    }
+
+    /// Every stored entry, from least to most recently used.
+    pub(super) fn load(&self) -> Result<Vec<StoredEntry>> {
+        let mut files = self
+            .connection
+            .prepare(
+                "SELECT id, path, display_path, mtime_secs, sha256, lines_of_code,
+                        cached_at_secs, size_bytes
+                 FROM files ORDER BY last_used",
+            )
+            .map_generic_err(READ_CONTEXT)?;
+        let mut stored: Vec<StoredEntry> = Vec::new();
+        let mut positions: HashMap<i64, usize> = HashMap::new();
+        let rows = files
+            .query_map([], |row| {
+                Ok((
+                    row.get::<_, i64>(0)?,
+                    StoredEntry {
+                        key: row.get(1)?,
+                        size_bytes: row.get::<_, i64>(7)? as u64,
+                        entry: CachedFileEntry {
+                            display_path: row.get(2)?,
+                            mtime_secs: row.get::<_, i64>(3)? as u64,
+                            sha256: row.get(4)?,
+                            imports: Vec::new(),
+                            lines_of_code: row.get::<_, i64>(5)? as usize,
+                            entities: Vec::new(),
+                            cached_at_secs: row.get::<_, i64>(6)? as u64,
+                        },
+                    },
+                ))
+            })
+            .map_generic_err(READ_CONTEXT)?;
+        for row in rows {
+            let (id, entry) = row.map_generic_err(READ_CONTEXT)?;
+            positions.insert(id, stored.len());
+            stored.push(entry);
+        }
+
+        let mut symbols = self
+            .connection
+            .prepare("SELECT file_id, entity FROM symbols ORDER BY file_id, position")
+            .map_generic_err(READ_CONTEXT)?;
+        let rows = symbols
+            .query_map([], |row| {
+                Ok((row.get::<_, i64>(0)?, row.get::<_, String>(1)?))
+            })
+            .map_generic_err(READ_CONTEXT)?;
+        for row in rows {
+            let (id, entity) = row.map_generic_err(READ_CONTEXT)?;
+            if let Some(&position) = positions.get(&id) {
+                let entity = serde_json::from_str(&entity).map_json_err("cached entity")?;
+                stored[position].entry.entities.push(entity);
+            }
+        }
+
+        let mut dependencies = self
+            .connection
+            .prepare("SELECT file_id, module FROM dependencies ORDER BY file_id, position")
+            .map_generic_err(READ_CONTEXT)?;
+        let rows = dependencies
+            .query_map([], |row| {
+                Ok((row.get::<_, i64>(0)?, row.get::<_, String>(1)?))
+            })
+            .map_generic_err(READ_CONTEXT)?;
+        for row in rows {
+            let (id, module) = row.map_generic_err(READ_CONTEXT)?;
+            if let Some(&position) = positions.get(&id) {
+                stored[position].entry.imports.push(module);
+            }
+        }
+
+        Ok(stored)
+    }
+
+    /// Apply the changes of one run in a single transaction.
+    ///
+    /// Rows of the `gone` files are deleted and the `changed` entries replace
+    /// their previous rows; the files in `recency` (least recently used first)
+    /// become the most recently used. Rows of any other file are left alone.
+    /// With a nonzero `max_bytes`, the least recently used files that no
+    /// longer fit are evicted.
+    pub(super) fn write(
+        &mut self,
+        changed: &[(String, CachedFileEntry)],
+        gone: &[String],
+        recency: &[String],
+        max_bytes: u64,
+    ) -> Result<()> {
+        let compatible = self.is_compatible()?;
+        let tx = self
+            .connection
+            .transaction()
+            .map_generic_err(WRITE_CONTEXT)?;
+        if !compatible {
+            tx.execute_batch("DELETE FROM files; DELETE FROM meta;")
+                .map_generic_err(WRITE_CONTEXT)?;
+            tx.execute(
+                "INSERT INTO meta (key, value) VALUES ('version', ?1), ('tool_version', ?2)",
+                params![SCHEMA_VERSION.to_string(), env!("CARGO_PKG_VERSION")],
+            )
+            .map_generic_err(WRITE_CONTEXT)?;
+        }
+
+        {
+            let mut delete = tx
+                .prepare("DELETE FROM files WHERE path = ?1")
+                .map_generic_err(WRITE_CONTEXT)?;
+            for key in gone.iter().chain(changed.iter().map(|(key, _)| key)) {
+                delete.execute([key]).map_generic_err(WRITE_CONTEXT)?;
+            }
+        }
+        for (key, entry) in changed {
+            insert_entry(&tx, key, entry)?;
+        }
+
+        let next_used: i64 = tx
+            .query_row(
+                "SELECT COALESCE(MAX(last_used), -1) + 1 FROM files",
+                [],
+                |row| row.get(0),
+            )
+            .map_generic_err(WRITE_CONTEXT)?;
+        {
+            let mut touch = tx
+                .prepare("UPDATE files SET last_used = ?1 WHERE path = ?2")
+                .map_generic_err(WRITE_CONTEXT)?;
+            for (position, key) in recency.iter().enumerate() {
+                touch
+                    .execute(params![next_used + position as i64, key])
+                    .map_generic_err(WRITE_CONTEXT)?;
+            }
+        }
+
+        if max_bytes > 0 {
+            let evicted = tx
+                .execute(EVICT, [max_bytes as i64])
+                .map_generic_err(WRITE_CONTEXT)?;
+            if evicted > 0 {
+                tracing::debug!(evicted, "incremental cache over size limit");
+            }
+        }
+
+        tx.commit().map_generic_err(WRITE_CONTEXT)
+    }
+}
+
+/// Insert the rows of one file; any previous rows must already be deleted.
+fn insert_entry(tx: &Transaction<'_>, key: &str, entry: &CachedFileEntry) -> Result<()> {
+    tx.execute(
+        "INSERT INTO files (path, display_path, mtime_secs, sha256, lines_of_code,
+                            cached_at_secs, size_bytes, last_used)
+         VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7, 0)",
+        params![
+            key,
+            entry.display_path,
+            entry.mtime_secs as i64,
+            entry.sha256,
+            entry.lines_of_code as i64,
+            entry.cached_at_secs as i64,
+            entry_size(entry) as i64,
+        ],
+    )
+    .map_generic_err(WRITE_CONTEXT)?;
+    let file_id = tx.last_insert_rowid();
+
+    let mut symbol = tx
+        .prepare_cached(
+            "INSERT INTO symbols (file_id, position, kind, name, start_line, end_line, entity)
+             VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7)",
+        )
+        .map_generic_err(WRITE_CONTEXT)?;
+    for (position, entity) in entry.entities.iter().enumerate() {
+        let json = serde_json::to_string(entity).map_json_err("cached entity")?;
+        let (start_line, end_line) = entity.line_range.map_or((None, None), |(start, end)| {
+            (Some(start as i64), Some(end as i64))
+        });
+        symbol
+            .execute(params![
+                file_id,
+                position as i64,
+                entity.entity_type,
+                entity.name,
+                start_line,
+                end_line,
+                json,
+            ])
+            .map_generic_err(WRITE_CONTEXT)?;
+    }
+
+    let mut dependency = tx
+        .prepare_cached("INSERT INTO dependencies (file_id, position, module) VALUES (?1, ?2, ?3)")
+        .map_generic_err(WRITE_CONTEXT)?;
+    for (position, module) in entry.imports.iter().enumerate() {
+        dependency
+            .execute(params![file_id, position as i64, module])
+            .map_generic_err(WRITE_CONTEXT)?;
+    }
+    Ok(())
+}
+
+/// Open a connection to `path` and make sure the schema exists.
+fn connect(path: &Path) -> rusqlite::Result<Connection> {
+    let connection = Connection::open(path)?;
+    connection.busy_timeout(BUSY_TIMEOUT)?;
+    connection.pragma_update(None, "foreign_keys", true)?;
+    connection.pragma_update_and_check(None, "journal_mode", "WAL", |_| Ok(()))?;
+    connection.execute_batch(SCHEMA)?;
+    Ok(connection)
+}
+
+/// Map a SQLite error opening `path` to a cache error.
+fn database_error(path: &Path) -> impl FnOnce(rusqlite::Error) -> ValknutError + '_ {
+    move |e| ValknutError::Cache {
+        message: format!("Failed to open {}: {}", path.display(), e),
+        key: Some(path.to_string_lossy().into_owned()),
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use tempfile::TempDir;
+
+    fn entry(path: &Path, mtime_secs: u64, names: &[&str], imports: &[&str]) -> CachedFileEntry {
+        CachedFileEntry {
+            display_path: path.to_string_lossy().into_owned(),
+            mtime_secs,
+            sha256: "abc".to_string(),
+            imports: imports.iter().map(|import| import.to_string()).collect(),
+            lines_of_code: 3,
+            entities: names
+                .iter()
+                .map(|name| CodeEntity::new(*name, "Function", *name, path.to_string_lossy()))
+                .collect(),
+            cached_at_secs: mtime_secs,
+        }
+    }
+
+    #[test]
+    fn entries_round_trip_and_single_files_are_read_directly() {
+        let dir = TempDir::new().unwrap();
+        let path = dir.path().join("app.py");
+        let key = IncrementalCache::cache_key(&path);
+        let changed = [(key.clone(), entry(&path, 10, &["main", "run"], &["util"]))];
+
+        let mut database = CacheDatabase::create(dir.path()).unwrap();
+        assert!(!database.is_compatible().unwrap());
+        database
+            .write(&changed, &[], std::slice::from_ref(&key), 0)
+            .unwrap();
+
+        let database = CacheDatabase::open(dir.path()).unwrap().unwrap();
+        assert!(database.is_compatible().unwrap());
+        let cached = database.entry(&path).unwrap().unwrap();
+        assert_eq!(cached.imports, vec!["util".to_string()]);
+        let names: Vec<&str> = cached.entities.iter().map(|e| e.name.as_str()).collect();
+        assert_eq!(names, ["main", "run"]);
+        assert!(database
+            .entry(&dir.path().join("other.py"))
+            .unwrap()
+            .is_none());
+
+        let stored = database.load().unwrap();
+        assert_eq!(stored.len(), 1);
+        assert_eq!(stored[0].key, key);
+        assert_eq!(stored[0].size_bytes, entry_size(&cached));
+        assert_eq!(database.totals().unwrap(), (1, entry_size(&cached)));
+    }
+
+    #[test]
+    fn symbols_modified_since_skips_older_files() {
+        let dir = TempDir::new().unwrap();
+        let old = dir.path().join("old.py");
+        let new = dir.path().join("new.py");
+        let changed: Vec<(String, CachedFileEntry)> = [
+            (&old, entry(&old, 100, &["legacy"], &[])),
+            (&new, entry(&new, 200, &["fresh", "newer"], &[])),
+        ]
+        .into_iter()
+        .map(|(path, entry)| (IncrementalCache::cache_key(path), entry))
+        .collect();
+        let keys: Vec<String> = changed.iter().map(|(key, _)| key.clone()).collect();
+
+        let mut database = CacheDatabase::create(dir.path()).unwrap();
+        database.write(&changed, &[], &keys, 0).unwrap();
+
+        let symbols = database.symbols_modified_since(150).unwrap();
+        let names: Vec<&str> = symbols.iter().map(|s| s.entity.name.as_str()).collect();
+        assert_eq!(names, ["fresh", "newer"]);
+        assert!(symbols.iter().all(|symbol| symbol.mtime_secs == 200));
+        assert_eq!(database.symbols_modified_since(0).unwrap().len(), 3);
+    }
+
+    #[test]
+    fn gone_files_are_deleted_with_their_rows_and_others_are_kept() {
+        let dir = TempDir::new().unwrap();
+        let gone = dir.path().join("gone.py");
+        let kept = dir.path().join("kept.py");
+        let gone_key = IncrementalCache::cache_key(&gone);
+
+        let mut database = CacheDatabase::create(dir.path()).unwrap();
+        database
+            .write(
+                &[(gone_key.clone(), entry(&gone, 10, &["f"], &["os"]))],
+                &[],
+                std::slice::from_ref(&gone_key),
+                0,
+            )
+            .unwrap();
+        let kept_key = IncrementalCache::cache_key(&kept);
+        database
+            .write(
+                &[(kept_key.clone(), entry(&kept, 10, &["g"], &[]))],
+                &[],
+                &[kept_key],
+                0,
+            )
+            .unwrap();
+        database.write(&[], &[gone_key], &[], 0).unwrap();
+
+        assert!(database.entry(&gone).unwrap().is_none());
+        assert!(database.entry(&kept).unwrap().is_some());
+        let orphans: i64 = database
+            .connection
+            .query_row(
+                "SELECT (SELECT COUNT(*) FROM symbols WHERE entity LIKE '%gone.py%')
+                      + (SELECT COUNT(*) FROM dependencies)",
+                [],
+                |row| row.get(0),
+            )
+            .unwrap();
+        assert_eq!(orphans, 0);
+    }
+}
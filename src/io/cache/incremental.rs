@@ -0,0 +1,580 @@
+//! Content-hash index for incremental re-analysis.
+//!
+//! Every analyzed file is recorded with its modification time, SHA-256 content
+//! hash and the entities extracted from it. On the next run, files whose hash
+//! is unchanged reuse the stored entities instead of being parsed again. When a
+//! file changes, every cached file that imports it (directly or transitively) is
+//! treated as stale as well, so cross-file results never mix old and new data.
+//!
+//! The index is bounded by the serialized size of its entries (`--cache-max-size`,
+//! 1 GiB by default). The files read or stored during a run are kept in an
+//! [`LruCache`] ordered by last use, and that order is persisted with the index
+//! on save, so the files that have not been part of a run for longest are
+//! evicted first.
+//!
+//! The index is persisted in a SQLite database (see [`CacheDatabase`]). Lookups
+//! read the rows of one file at a time, and saving writes only the files the
+//! run stored or found missing, so runs over overlapping trees keep each
+//! other's entries.
+
+use std::collections::{HashMap, HashSet};
+use std::fs;
+use std::path::{Path, PathBuf};
+use std::sync::{Mutex, PoisonError};
+use std::time::{Duration, SystemTime, UNIX_EPOCH};
+
+use serde::{Deserialize, Serialize};
+use sha2::{Digest, Sha256};
+
+use crate::core::arena_analysis::ArenaAnalysisResult;
+use crate::core::errors::Result;
+use crate::core::featureset::CodeEntity;
+use crate::core::interning::intern;
+
+use super::database::CacheDatabase;
+use super::lru::LruCache;
+
+/// File name of the JSON index used before the SQLite database, removed on save.
+const LEGACY_INDEX_FILE_NAME: &str = "incremental.v1.json";
+
+/// File stems that name their containing directory rather than themselves.
+const PACKAGE_ENTRY_STEMS: [&str; 4] = ["mod", "__init__", "index", "lib"];
+
+/// Cached analysis data for a single file.
+#[derive(Debug, Clone, Serialize, Deserialize)]
+pub struct CachedFileEntry {
+    /// Path as it was presented to the analyzer (used in entity IDs)
+    pub display_path: String,
+
+    /// Modification time in seconds since the Unix epoch
+    pub mtime_secs: u64,
+
+    /// Hex-encoded SHA-256 of the file content
+    pub sha256: String,
+
+    /// Module specifiers imported by the file
+    pub imports: Vec<String>,
+
+    /// Lines of code (non-blank, non-comment lines)
+    pub lines_of_code: usize,
+
+    /// Entities extracted from the file
+    pub entities: Vec<CodeEntity>,
+
+    /// When the entry was written, in seconds since the Unix epoch (0 if unknown)
+    #[serde(default)]
+    pub cached_at_secs: u64,
+}
+
+/// Conversion helpers for [`CachedFileEntry`].
+impl CachedFileEntry {
+    /// Rebuild an arena analysis result from the cached entities.
+    pub fn to_arena_result(&self, source_code: &str) -> ArenaAnalysisResult {
+        ArenaAnalysisResult {
+            entity_count: self.entities.len(),
+            file_path: intern(&self.display_path),
+            entity_extraction_time: Duration::ZERO,
+            total_analysis_time: Duration::ZERO,
+            arena_bytes_used: 0,
+            memory_efficiency_score: 0.0,
+            entities: self.entities.clone(),
+            lines_of_code: self.lines_of_code,
+            source_code: source_code.to_string(),
+        }
+    }
+}
+
+/// Persistent content-hash cache used to skip re-parsing unchanged files.
+#[derive(Debug)]
+pub struct IncrementalCache {
+    /// Directory holding the index database
+    cache_dir: PathBuf,
+
+    /// The index database, when one with compatible entries exists
+    database: Option<Mutex<CacheDatabase>>,
+
+    /// Entries read or stored during the run keyed by canonical file path
+    /// (`None` for files without one), least recently used first
+    entries: LruCache<String, Option<CachedFileEntry>>,
+
+    /// Keys of entries stored since the index was opened
+    changed: HashSet<String>,
+
+    /// Bound on the serialized size of the saved entries (0 = unbounded)
+    max_bytes: u64,
+}
+
+/// Loading, lookup, and persistence methods for [`IncrementalCache`].
+impl IncrementalCache {
+    /// Default cache directory (`~/.cache/valknut` on Linux).
+    pub fn default_dir() -> Option<PathBuf> {
+        dirs::cache_dir().map(|dir| dir.join("valknut"))
+    }
+
+    /// Default bound on the serialized size of the index: 1 GiB.
+    pub const fn default_max_size_bytes() -> u64 {
+        1 << 30
+    }
+
+    /// Open the cache in `cache_dir`, starting empty when no usable index exists.
+    ///
+    /// A missing, unreadable, or outdated index is not an error: it simply
+    /// means every file will be analyzed from scratch. The cache is unbounded
+    /// until [`with_max_size`](Self::with_max_size) sets a limit.
+    pub fn open<P: AsRef<Path>>(cache_dir: P) -> Self {
+        let cache_dir = cache_dir.as_ref().to_path_buf();
+        let database = match Self::connect(&cache_dir) {
+            Ok(database) => database,
+            Err(e) => {
+                tracing::warn!("Ignoring unreadable incremental index: {}", e);
+                None
+            }
+        };
+
+        Self {
+            cache_dir,
+            database: database.map(Mutex::new),
+            entries: LruCache::new(0),
+            changed: HashSet::new(),
+            max_bytes: 0,
+        }
+    }
+
+    /// Open the database in `cache_dir` if it holds compatible entries.
+    fn connect(cache_dir: &Path) -> Result<Option<CacheDatabase>> {
+        match CacheDatabase::open(cache_dir)? {
+            Some(database) if database.is_compatible()? => Ok(Some(database)),
+            _ => Ok(None),
+        }
+    }
+
+    /// Run a query against the index database, treating a missing or
+    /// unreadable index as empty.
+    fn query<T: Default>(&self, query: impl FnOnce(&CacheDatabase) -> Result<T>) -> T {
+        let Some(database) = &self.database else {
+            return T::default();
+        };
+        let database = database.lock().unwrap_or_else(PoisonError::into_inner);
+        query(&database).unwrap_or_else(|e| {
+            tracing::warn!("Failed to read the incremental index: {}", e);
+            T::default()
+        })
+    }
+
+    /// Bound the index to `max_bytes` of serialized entries (0 = unbounded).
+    /// The least recently used entries that no longer fit are evicted on save.
+    pub fn with_max_size(mut self, max_bytes: u64) -> Self {
+        self.max_bytes = max_bytes;
+        self
+    }
+
+    /// Serialized size of the saved entries in bytes.
+    pub fn size_bytes(&self) -> u64 {
+        self.query(CacheDatabase::totals).1
+    }
+
+    /// Number of files recorded in the saved index.
+    pub fn len(&self) -> usize {
+        self.query(CacheDatabase::totals).0
+    }
+
+    /// Whether the saved index holds no entries.
+    pub fn is_empty(&self) -> bool {
+        self.len() == 0
+    }
+
+    /// Hex-encoded SHA-256 of file content.
+    pub fn content_hash(content: &str) -> String {
+        format!("{:x}", Sha256::digest(content.as_bytes()))
+    }
+
+    /// Look up the cached entry for a file, regardless of freshness, marking it
+    /// as recently used. Only the rows of this file are read from the index.
+    pub fn get(&self, path: &Path) -> Option<CachedFileEntry> {
+        let key = Self::cache_key(path);
+        if let Some(entry) = self.entries.get(&key) {
+            return entry;
+        }
+        let entry = self.query(|database| database.entry(path));
+        self.entries.insert(key, entry.clone(), 0);
+        entry
+    }
+
+    /// Every saved entry with its canonical file path, least recently used
+    /// first. Unlike [`get`](Self::get), this reads the whole index.
+    pub fn entries(&self) -> Vec<(PathBuf, CachedFileEntry)> {
+        self.query(CacheDatabase::load)
+            .into_iter()
+            .map(|stored| (PathBuf::from(stored.key), stored.entry))
+            .collect()
+    }
+
+    /// Whether the cached entry for `path` matches `content`.
+    pub fn is_fresh(&self, path: &Path, content: &str) -> bool {
+        self.get(path)
+            .is_some_and(|entry| entry.sha256 == Self::content_hash(content))
+    }
+
+    /// Determine which files must be re-analyzed.
+    ///
+    /// A file is stale when it has no entry, its content hash changed, or it
+    /// imports (transitively) another stale file.
+    pub fn stale_paths(&self, files: &[(PathBuf, String)]) -> HashSet<PathBuf> {
+        let cached: HashMap<&PathBuf, CachedFileEntry> = files
+            .iter()
+            .filter_map(|(path, _)| self.get(path).map(|entry| (path, entry)))
+            .collect();
+        let mut stale = HashSet::new();
+        let mut changed_modules = HashSet::new();
+
+        for (path, content) in files {
+            let is_fresh = cached.get(path).is_some_and(|entry| {
+                entry.display_path == path.to_string_lossy()
+                    && entry.sha256 == Self::content_hash(content)
+            });
+            if !is_fresh {
+                stale.insert(path.clone());
+                changed_modules.extend(module_names(path));
+            }
+        }
+
+        // Propagate through importers until no new file becomes stale.
+        loop {
+            let newly_stale: Vec<&PathBuf> = files
+                .iter()
+                .map(|(path, _)| path)
+                .filter(|path| !stale.contains(*path))
+                .filter(|path| {
+                    cached.get(path).is_some_and(|entry| {
+                        entry
+                            .imports
+                            .iter()
+                            .any(|import| imports_any(import, &changed_modules))
+                    })
+                })
+                .collect();
+
+            if newly_stale.is_empty() {
+                break;
+            }
+            for path in newly_stale {
+                changed_modules.extend(module_names(path));
+                stale.insert(path.clone());
+            }
+        }
+
+        stale
+    }
+
+    /// Record fresh analysis data for a file, written to the index on save.
+    pub fn store(
+        &mut self,
+        path: &Path,
+        content: &str,
+        imports: Vec<String>,
+        result: &ArenaAnalysisResult,
+    ) {
+        let mtime_secs = fs::metadata(path)
+            .and_then(|meta| meta.modified())
+            .map_or(0, unix_secs);
+
+        let key = Self::cache_key(path);
+        let entry = CachedFileEntry {
+            display_path: path.to_string_lossy().into_owned(),
+            mtime_secs,
+            sha256: Self::content_hash(content),
+            imports,
+            lines_of_code: result.lines_of_code,
+            entities: result.entities.clone(),
+            cached_at_secs: unix_secs(SystemTime::now()),
+        };
+        self.entries.insert(key.clone(), Some(entry), 0);
+        self.changed.insert(key);
+    }
+
+    /// Persist the run in one transaction.
+    ///
+    /// Stored entries are written and every file read or stored becomes the
+    /// most recently used; of those, files that no longer exist are dropped.
+    /// Files the run never looked at are left as they are, since another run
+    /// may be working on them.
+    pub fn save(&mut self) -> Result<()> {
+        let recency = self.entries.keys();
+        let (present, gone): (Vec<String>, Vec<String>) =
+            recency.into_iter().partition(|key| Path::new(key).exists());
+        let changed: Vec<(String, CachedFileEntry)> = present
+            .iter()
+            .filter(|key| self.changed.contains(*key))
+            .filter_map(|key| Some((key.clone(), self.entries.get(key).flatten()?)))
+            .collect();
+
+        let mut database = CacheDatabase::create(&self.cache_dir)?;
+        database.write(&changed, &gone, &present, self.max_bytes)?;
+        self.database = Some(Mutex::new(database));
+        self.entries = LruCache::new(0);
+        self.changed.clear();
+
+        let legacy_index = self.cache_dir.join(LEGACY_INDEX_FILE_NAME);
+        if legacy_index.exists() {
+            if let Err(e) = fs::remove_file(&legacy_index) {
+                tracing::warn!("Failed to remove {}: {}", legacy_index.display(), e);
+            }
+        }
+        Ok(())
+    }
+
+    /// Canonical key for a file so the same file maps to one entry.
+    pub(super) fn cache_key(path: &Path) -> String {
+        path.canonicalize()
+            .unwrap_or_else(|_| path.to_path_buf())
+            .to_string_lossy()
+            .into_owned()
+    }
+}
+
+/// Size an entry counts against the cache size limit.
+pub(super) fn entry_size(entry: &CachedFileEntry) -> u64 {
+    serde_json::to_vec(entry).map_or(0, |bytes| bytes.len() as u64)
+}
+
+/// Seconds since the Unix epoch, or 0 for times before it.
+pub(super) fn unix_secs(time: SystemTime) -> u64 {
+    time.duration_since(UNIX_EPOCH)
+        .map_or(0, |duration| duration.as_secs())
+}
+
+/// Names under which other files may import `path`.
+///
+/// Package entry files (`mod.rs`, `__init__.py`, `index.ts`, `lib.rs`) are
+/// imported by their directory name, everything else by its file stem.
+pub(super) fn module_names(path: &Path) -> Vec<String> {
+    let Some(stem) = path.file_stem().and_then(|s| s.to_str()) else {
+        return Vec::new();
+    };
+    if PACKAGE_ENTRY_STEMS.contains(&stem) {
+        path.parent()
+            .and_then(|parent| parent.file_name())
+            .and_then(|name| name.to_str())
+            .map(|name| vec![name.to_string()])
+            .unwrap_or_default()
+    } else {
+        vec![stem.to_string()]
+    }
+}
+
+/// Whether an import specifier references any of the given module names.
+///
+/// Matching is by path segment, which deliberately errs towards invalidating
+/// too much rather than too little.
+pub(super) fn imports_any(import: &str, modules: &HashSet<String>) -> bool {
+    import
+        .split(|c: char| matches!(c, '.' | '/' | ':' | '\\'))
+        .any(|segment| modules.contains(segment))
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::io::cache::database::DATABASE_FILE_NAME;
+    use tempfile::TempDir;
+
+    fn analyzed(path: &Path, content: &str) -> ArenaAnalysisResult {
+        CachedFileEntry {
+            display_path: path.to_string_lossy().into_owned(),
+            mtime_secs: 0,
+            sha256: IncrementalCache::content_hash(content),
+            imports: Vec::new(),
+            lines_of_code: content.lines().count(),
+            entities: vec![CodeEntity::new(
+                "id",
+                "Function",
+                "f",
+                path.to_string_lossy(),
+            )],
+            cached_at_secs: 0,
+        }
+        .to_arena_result(content)
+    }
+
+    #[test]
+    fn unchanged_files_are_fresh_after_reload() {
+        let dir = TempDir::new().unwrap();
+        let file = dir.path().join("a.py");
+        fs::write(&file, "def f(): pass\n").unwrap();
+        let content = fs::read_to_string(&file).unwrap();
+
+        let mut cache = IncrementalCache::open(dir.path().join("cache"));
+        let files = vec![(file.clone(), content.clone())];
+        assert!(cache.stale_paths(&files).contains(&file));
+
+        cache.store(&file, &content, Vec::new(), &analyzed(&file, &content));
+        cache.save().unwrap();
+
+        let reloaded = IncrementalCache::open(dir.path().join("cache"));
+        assert_eq!(reloaded.len(), 1);
+        assert!(reloaded.is_fresh(&file, &content));
+        assert!(reloaded.get(&file).unwrap().cached_at_secs > 0);
+        assert!(reloaded.stale_paths(&files).is_empty());
+        assert_eq!(reloaded.get(&file).unwrap().entities.len(), 1);
+    }
+
+    #[test]
+    fn changed_content_marks_file_and_importers_stale() {
+        let dir = TempDir::new().unwrap();
+        let util = dir.path().join("util.py");
+        let app = dir.path().join("app.py");
+        let main = dir.path().join("main.py");
+        let other = dir.path().join("other.py");
+        for path in [&util, &app, &main, &other] {
+            fs::write(path, "x = 1\n").unwrap();
+        }
+
+        let mut cache = IncrementalCache::open(dir.path().join("cache"));
+        cache.store(&util, "x = 1\n", Vec::new(), &analyzed(&util, "x = 1\n"));
+        cache.store(
+            &app,
+            "x = 1\n",
+            vec!["pkg.util".into()],
+            &analyzed(&app, "x = 1\n"),
+        );
+        cache.store(
+            &main,
+            "x = 1\n",
+            vec!["app".into()],
+            &analyzed(&main, "x = 1\n"),
+        );
+        cache.store(
+            &other,
+            "x = 1\n",
+            vec!["os".into()],
+            &analyzed(&other, "x = 1\n"),
+        );
+
+        let files = vec![
+            (util.clone(), "x = 2\n".to_string()),
+            (app.clone(), "x = 1\n".to_string()),
+            (main.clone(), "x = 1\n".to_string()),
+            (other.clone(), "x = 1\n".to_string()),
+        ];
+        let stale = cache.stale_paths(&files);
+
+        assert!(stale.contains(&util));
+        assert!(stale.contains(&app), "direct importer must be re-analyzed");
+        assert!(
+            stale.contains(&main),
+            "transitive importer must be re-analyzed"
+        );
+        assert!(!stale.contains(&other));
+    }
+
+    #[test]
+    fn package_entry_files_are_named_by_directory() {
+        assert_eq!(
+            module_names(Path::new("src/io/mod.rs")),
+            vec!["io".to_string()]
+        );
+        assert_eq!(
+            module_names(Path::new("pkg/__init__.py")),
+            vec!["pkg".to_string()]
+        );
+        assert_eq!(
+            module_names(Path::new("src/cache.rs")),
+            vec!["cache".to_string()]
+        );
+    }
+
+    #[test]
+    fn least_recently_used_entries_are_evicted_past_the_size_limit() {
+        let dir = TempDir::new().unwrap();
+        let paths: Vec<PathBuf> = ["a.py", "b.py", "c.py"]
+            .iter()
+            .map(|name| dir.path().join(name))
+            .collect();
+        for path in &paths {
+            fs::write(path, "x = 1\n").unwrap();
+        }
+
+        let mut cache = IncrementalCache::open(dir.path().join("cache"));
+        for path in &paths[..2] {
+            cache.store(path, "x = 1\n", Vec::new(), &analyzed(path, "x = 1\n"));
+        }
+        cache.save().unwrap();
+        let per_entry = cache.size_bytes() / 2;
+
+        // Reading `a` in the next run makes `b` the least recently used entry.
+        let mut cache =
+            IncrementalCache::open(dir.path().join("cache")).with_max_size(per_entry * 2);
+        assert!(cache.get(&paths[0]).is_some());
+        cache.store(
+            &paths[2],
+            "x = 1\n",
+            Vec::new(),
+            &analyzed(&paths[2], "x = 1\n"),
+        );
+        cache.save().unwrap();
+        assert_eq!(cache.len(), 2);
+        assert!(cache.get(&paths[1]).is_none());
+
+        let mut cache = IncrementalCache::open(dir.path().join("cache")).with_max_size(per_entry);
+        assert!(cache.get(&paths[2]).is_some());
+        cache.save().unwrap();
+
+        let reloaded = IncrementalCache::open(dir.path().join("cache"));
+        assert_eq!(reloaded.len(), 1);
+        assert!(reloaded.get(&paths[2]).is_some());
+    }
+
+    #[test]
+    fn overlapping_runs_keep_each_others_entries() {
+        let dir = TempDir::new().unwrap();
+        let cache_dir = dir.path().join("cache");
+        let a = dir.path().join("a.py");
+        let b = dir.path().join("b.py");
+        for path in [&a, &b] {
+            fs::write(path, "x = 1\n").unwrap();
+        }
+
+        let mut first = IncrementalCache::open(&cache_dir);
+        let mut second = IncrementalCache::open(&cache_dir);
+        first.store(&a, "x = 1\n", Vec::new(), &analyzed(&a, "x = 1\n"));
+        second.store(&b, "x = 1\n", Vec::new(), &analyzed(&b, "x = 1\n"));
+        first.save().unwrap();
+        second.save().unwrap();
+
+        let reloaded = IncrementalCache::open(&cache_dir);
+        assert_eq!(reloaded.len(), 2);
+        assert!(reloaded.get(&a).is_some());
+
+        // Only a run that looks up a deleted file drops its entry.
+        fs::remove_file(&b).unwrap();
+        let mut unrelated = IncrementalCache::open(&cache_dir);
+        assert!(unrelated.get(&a).is_some());
+        unrelated.save().unwrap();
+        assert_eq!(unrelated.len(), 2);
+
+        let mut cache = IncrementalCache::open(&cache_dir);
+        assert!(cache.get(&b).is_some());
+        cache.save().unwrap();
+        assert_eq!(cache.len(), 1);
+        assert!(cache.get(&b).is_none());
+    }
+
+    #[test]
+    fn corrupt_database_starts_empty_and_is_replaced_on_save() {
+        let dir = TempDir::new().unwrap();
+        let file = dir.path().join("a.py");
+        fs::write(&file, "x = 1\n").unwrap();
+        let cache_dir = dir.path().join("cache");
+        fs::create_dir_all(&cache_dir).unwrap();
+        fs::write(cache_dir.join(DATABASE_FILE_NAME), "not a database").unwrap();
+        fs::write(cache_dir.join(LEGACY_INDEX_FILE_NAME), "{}").unwrap();
+
+        let mut cache = IncrementalCache::open(&cache_dir);
+        assert!(cache.is_empty());
+        cache.store(&file, "x = 1\n", Vec::new(), &analyzed(&file, "x = 1\n"));
+        cache.save().unwrap();
+
+        assert_eq!(IncrementalCache::open(&cache_dir).len(), 1);
+        assert!(!cache_dir.join(LEGACY_INDEX_FILE_NAME).exists());
+    }
+}
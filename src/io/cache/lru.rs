@@ -0,0 +1,357 @@
+//! Size-bounded least-recently-used cache.
+//!
+//! [`LruCache`] keeps its entries in a doubly-linked list ordered by last use,
+//! with a hash map from key to list node, so lookups, insertions and evictions
+//! are O(1). Every entry carries a size in bytes; once the total exceeds the
+//! configured maximum, least-recently-used entries are evicted until it fits
+//! again. All methods take `&self` and lock an internal mutex, so one cache can
+//! be shared between threads.
+
+use std::collections::HashMap;
+use std::fmt;
+use std::hash::Hash;
+use std::sync::{Mutex, MutexGuard, PoisonError};
+
+/// Slot of a node in [`LruList::nodes`].
+type NodeId = usize;
+
+/// An entry linked into the recency list.
+struct Node<K, V> {
+    key: K,
+    value: V,
+    size: u64,
+    /// Next more recently used node
+    prev: Option<NodeId>,
+    /// Next less recently used node
+    next: Option<NodeId>,
+}
+
+/// Recency list and key index guarded by the [`LruCache`] mutex.
+///
+/// Nodes live in a slab so links are plain indices; freed slots are reused.
+struct LruList<K, V> {
+    nodes: Vec<Option<Node<K, V>>>,
+    free: Vec<NodeId>,
+    index: HashMap<K, NodeId>,
+    /// Most recently used node
+    head: Option<NodeId>,
+    /// Least recently used node
+    tail: Option<NodeId>,
+    total_bytes: u64,
+    max_bytes: u64,
+}
+
+/// List manipulation for [`LruList`].
+impl<K: Eq + Hash + Clone, V> LruList<K, V> {
+    fn node(&self, id: NodeId) -> &Node<K, V> {
+        self.nodes[id].as_ref().expect("linked node is occupied")
+    }
+
+    fn node_mut(&mut self, id: NodeId) -> &mut Node<K, V> {
+        self.nodes[id].as_mut().expect("linked node is occupied")
+    }
+
+    /// Detach a node from its neighbours, leaving it in the slab.
+    fn unlink(&mut self, id: NodeId) {
+        let (prev, next) = {
+            let node = self.node(id);
+            (node.prev, node.next)
+        };
+        match prev {
+            Some(prev) => self.node_mut(prev).next = next,
+            None => self.head = next,
+        }
+        match next {
+            Some(next) => self.node_mut(next).prev = prev,
+            None => self.tail = prev,
+        }
+    }
+
+    /// Link a detached node in as the most recently used.
+    fn push_front(&mut self, id: NodeId) {
+        let head = self.head;
+        {
+            let node = self.node_mut(id);
+            node.prev = None;
+            node.next = head;
+        }
+        match head {
+            Some(head) => self.node_mut(head).prev = Some(id),
+            None => self.tail = Some(id),
+        }
+        self.head = Some(id);
+    }
+
+    /// Mark a node as the most recently used.
+    fn touch(&mut self, id: NodeId) {
+        if self.head != Some(id) {
+            self.unlink(id);
+            self.push_front(id);
+        }
+    }
+
+    /// Unlink a node, free its slot and return its key and value.
+    fn remove_node(&mut self, id: NodeId) -> (K, V) {
+        self.unlink(id);
+        let node = self.nodes[id].take().expect("linked node is occupied");
+        self.free.push(id);
+        self.index.remove(&node.key);
+        self.total_bytes -= node.size;
+        (node.key, node.value)
+    }
+
+    /// Evict least-recently-used entries until the total fits the maximum.
+    fn evict(&mut self) -> Vec<(K, V)> {
+        let mut evicted = Vec::new();
+        while self.max_bytes > 0 && self.total_bytes > self.max_bytes {
+            let Some(tail) = self.tail else {
+                break;
+            };
+            evicted.push(self.remove_node(tail));
+        }
+        evicted
+    }
+}
+
+/// Thread-safe map bounded by the total size of its entries, evicting the
+/// least recently used entries first.
+pub struct LruCache<K, V> {
+    list: Mutex<LruList<K, V>>,
+}
+
+/// Construction, lookup and eviction methods for [`LruCache`].
+impl<K: Eq + Hash + Clone, V> LruCache<K, V> {
+    /// An empty cache holding at most `max_bytes` (0 = unbounded).
+    pub fn new(max_bytes: u64) -> Self {
+        Self {
+            list: Mutex::new(LruList {
+                nodes: Vec::new(),
+                free: Vec::new(),
+                index: HashMap::new(),
+                head: None,
+                tail: None,
+                total_bytes: 0,
+                max_bytes,
+            }),
+        }
+    }
+
+    /// Lock the list; a panic in another thread never leaves it half-linked,
+    /// so a poisoned lock is recovered.
+    fn list(&self) -> MutexGuard<'_, LruList<K, V>> {
+        self.list.lock().unwrap_or_else(PoisonError::into_inner)
+    }
+
+    /// Insert or replace `key` as the most recently used entry and return the
+    /// entries evicted to make room, least recently used first.
+    ///
+    /// An entry larger than the maximum on its own is evicted immediately.
+    pub fn insert(&self, key: K, value: V, size: u64) -> Vec<(K, V)> {
+        let mut list = self.list();
+        if let Some(&id) = list.index.get(&key) {
+            list.remove_node(id);
+        }
+
+        let node = Node {
+            key: key.clone(),
+            value,
+            size,
+            prev: None,
+            next: None,
+        };
+        let id = match list.free.pop() {
+            Some(id) => {
+                list.nodes[id] = Some(node);
+                id
+            }
+            None => {
+                list.nodes.push(Some(node));
+                list.nodes.len() - 1
+            }
+        };
+        list.index.insert(key, id);
+        list.total_bytes += size;
+        list.push_front(id);
+        list.evict()
+    }
+
+    /// A copy of the value for `key`, marking it as the most recently used.
+    pub fn get(&self, key: &K) -> Option<V>
+    where
+        V: Clone,
+    {
+        let mut list = self.list();
+        let id = *list.index.get(key)?;
+        list.touch(id);
+        Some(list.node(id).value.clone())
+    }
+
+    /// Mark `key` as the most recently used; returns whether it is cached.
+    pub fn touch(&self, key: &K) -> bool {
+        let mut list = self.list();
+        match list.index.get(key) {
+            Some(&id) => {
+                list.touch(id);
+                true
+            }
+            None => false,
+        }
+    }
+
+    /// Whether `key` is cached, without affecting its recency.
+    pub fn contains(&self, key: &K) -> bool {
+        self.list().index.contains_key(key)
+    }
+
+    /// Remove `key`, returning its value.
+    pub fn remove(&self, key: &K) -> Option<V> {
+        let mut list = self.list();
+        let id = *list.index.get(key)?;
+        Some(list.remove_node(id).1)
+    }
+
+    /// Remove every entry whose key does not satisfy `keep`.
+    pub fn retain(&self, mut keep: impl FnMut(&K) -> bool) {
+        let mut list = self.list();
+        let dropped: Vec<NodeId> = list
+            .index
+            .iter()
+            .filter(|(key, _)| !keep(key))
+            .map(|(_, &id)| id)
+            .collect();
+        for id in dropped {
+            list.remove_node(id);
+        }
+    }
+
+    /// Change the maximum size (0 = unbounded) and return the entries evicted
+    /// to fit it, least recently used first.
+    pub fn set_max_bytes(&self, max_bytes: u64) -> Vec<(K, V)> {
+        let mut list = self.list();
+        list.max_bytes = max_bytes;
+        list.evict()
+    }
+
+    /// Maximum total size in bytes (0 = unbounded).
+    pub fn max_bytes(&self) -> u64 {
+        self.list().max_bytes
+    }
+
+    /// Total size of the cached entries in bytes.
+    pub fn total_bytes(&self) -> u64 {
+        self.list().total_bytes
+    }
+
+    /// Number of cached entries.
+    pub fn len(&self) -> usize {
+        self.list().index.len()
+    }
+
+    /// Whether the cache holds no entries.
+    pub fn is_empty(&self) -> bool {
+        self.list().index.is_empty()
+    }
+
+    /// Keys from least to most recently used.
+    pub fn keys(&self) -> Vec<K> {
+        let list = self.list();
+        let mut keys = Vec::with_capacity(list.index.len());
+        let mut cursor = list.tail;
+        while let Some(id) = cursor {
+            let node = list.node(id);
+            keys.push(node.key.clone());
+            cursor = node.prev;
+        }
+        keys
+    }
+}
+
+/// Summarises the cache without requiring `Debug` keys or values.
+impl<K, V> fmt::Debug for LruCache<K, V> {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        let list = self.list.lock().unwrap_or_else(PoisonError::into_inner);
+        f.debug_struct("LruCache")
+            .field("len", &list.index.len())
+            .field("total_bytes", &list.total_bytes)
+            .field("max_bytes", &list.max_bytes)
+            .finish()
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use std::sync::Arc;
+    use std::thread;
+
+    #[test]
+    fn evicts_least_recently_used_entries_past_the_size_limit() {
+        let cache = LruCache::new(30);
+        assert!(cache.insert("a", 1, 10).is_empty());
+        assert!(cache.insert("b", 2, 10).is_empty());
+        assert!(cache.insert("c", 3, 10).is_empty());
+
+        assert_eq!(cache.get(&"a"), Some(1));
+        assert_eq!(cache.insert("d", 4, 10), vec![("b", 2)]);
+        assert_eq!(cache.keys(), vec!["c", "a", "d"]);
+        assert_eq!(cache.total_bytes(), 30);
+
+        assert_eq!(cache.insert("e", 5, 25), vec![("c", 3), ("a", 1), ("d", 4)]);
+        assert_eq!(cache.keys(), vec!["e"]);
+    }
+
+    #[test]
+    fn replacing_an_entry_updates_its_size_and_recency() {
+        let cache = LruCache::new(0);
+        cache.insert("a", 1, 10);
+        cache.insert("b", 2, 10);
+        cache.insert("a", 3, 5);
+
+        assert_eq!(cache.len(), 2);
+        assert_eq!(cache.total_bytes(), 15);
+        assert_eq!(cache.keys(), vec!["b", "a"]);
+        assert_eq!(cache.remove(&"a"), Some(3));
+        assert!(!cache.contains(&"a"));
+        assert_eq!(cache.total_bytes(), 10);
+    }
+
+    #[test]
+    fn oversized_entries_and_lowered_limits_evict() {
+        let cache = LruCache::new(10);
+        assert_eq!(cache.insert("big", 1, 11), vec![("big", 1)]);
+        assert!(cache.is_empty());
+
+        cache.insert("a", 1, 5);
+        cache.insert("b", 2, 5);
+        assert!(cache.touch(&"a"));
+        assert_eq!(cache.set_max_bytes(5), vec![("b", 2)]);
+        assert_eq!(cache.keys(), vec!["a"]);
+
+        cache.retain(|key| *key != "a");
+        assert!(cache.is_empty());
+        assert_eq!(cache.total_bytes(), 0);
+    }
+
+    #[test]
+    fn concurrent_inserts_respect_the_limit() {
+        let cache = Arc::new(LruCache::new(100));
+        let handles: Vec<_> = (0..4)
+            .map(|thread| {
+                let cache = Arc::clone(&cache);
+                thread::spawn(move || {
+                    for i in 0..50 {
+                        cache.insert(thread * 100 + i, i, 7);
+                        cache.get(&(thread * 100 + i / 2));
+                    }
+                })
+            })
+            .collect();
+        for handle in handles {
+            handle.join().unwrap();
+        }
+
+        assert!(cache.total_bytes() <= 100);
+        assert_eq!(cache.len(), cache.keys().len());
+        assert_eq!(cache.total_bytes(), 7 * cache.len() as u64);
+    }
+}
@@ -1,8 +1,13 @@
 //! Cache implementation with support for stop-motifs and other analysis caches.
 
 mod ast_stop_motif_miner;
+pub mod blame;
+pub mod database;
+pub mod incremental;
 pub mod language_adapters;
+pub mod lru;
 mod pattern_miner;
+pub mod stats;
 pub mod types;
 
 use std::collections::{HashMap, HashSet};
@@ -17,10 +22,14 @@ use sha2::{Digest, Sha256};
 use crate::core::errors::{Result, ValknutError, ValknutResultExt};
 
 // Re-export types from submodules
+pub use database::{CacheDatabase, CachedSymbol};
+pub use incremental::{CachedFileEntry, IncrementalCache};
 pub use language_adapters::{
     GoLanguageAdapter, JavaScriptLanguageAdapter, LanguageAdapter, PythonLanguageAdapter,
     RustLanguageAdapter, TypeScriptLanguageAdapter,
 };
+pub use lru::LruCache;
+pub use stats::RepositoryStats;
 pub use types::{
     AstExtractionConfig, AstPattern, AstPatternExtractor, AstPatternType, PatternThresholds,
 };
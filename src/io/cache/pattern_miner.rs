@@ -7,6 +7,7 @@ use std::time::{Duration, SystemTime, UNIX_EPOCH};
 
 use rayon::prelude::*;
 use sha2::{Digest, Sha256};
+use tracing::warn;
 
 use super::{
     AstPatternCategory, AstStopMotifEntry, AstStopMotifMiner, CacheRefreshPolicy, CodebaseInfo,
@@ -90,7 +91,7 @@ impl PatternMiner {
         let ast_patterns = ast_miner
             .mine_ast_stop_motifs(&codebase_info.functions)
             .unwrap_or_else(|e| {
-                eprintln!("Failed to mine AST patterns: {:?}", e);
+                warn!("Failed to mine AST patterns: {:?}", e);
                 Vec::new()
             });
 
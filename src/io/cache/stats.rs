@@ -0,0 +1,480 @@
+//! Aggregate repository metrics computed from the incremental cache.
+//!
+//! [`RepositoryStats::collect`] summarises the entries that a previous
+//! `valknut analyze` run stored in the [`IncrementalCache`]: file, line and
+//! symbol totals, a per-language breakdown, the largest files and the most
+//! imported internal modules. No file is parsed; the working tree is only
+//! read to hash file contents, which tells how many cached entries are still
+//! fresh. Cyclomatic complexity is estimated lexically from the cached entity
+//! source, since the cache does not store detector results.
+
+use std::collections::{BTreeMap, HashMap, HashSet};
+use std::path::{Path, PathBuf};
+use std::time::SystemTime;
+
+use ignore::WalkBuilder;
+use serde::{Deserialize, Serialize};
+use tracing::warn;
+
+use super::incremental::{imports_any, module_names, unix_secs, CachedFileEntry, IncrementalCache};
+use crate::core::errors::{Result, ValknutError};
+use crate::core::featureset::CodeEntity;
+use crate::core::pipeline::discovery::IGNORE_FILE_NAME;
+use crate::lang::registry::{detect_language_from_path, extension_is_supported};
+
+/// Keywords that each add one decision point to a function.
+const DECISION_KEYWORDS: &[&str] = &[
+    "if", "elif", "for", "foreach", "while", "case", "catch", "except", "when",
+];
+
+/// Per-language totals.
+#[derive(Debug, Clone, PartialEq, Serialize, Deserialize)]
+pub struct LanguageStats {
+    /// Registered language key (`py`, `rs`, ...).
+    pub language: String,
+    /// Number of cached files.
+    pub files: usize,
+    /// Lines of code across those files.
+    pub lines: usize,
+    /// Symbols (entities) across those files.
+    pub symbols: usize,
+}
+
+/// Size figures for a single file.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct FileStats {
+    /// File path as it was presented to the analyzer.
+    pub path: String,
+    /// Lines of code.
+    pub lines: usize,
+    /// Number of symbols (entities).
+    pub symbols: usize,
+}
+
+/// An internal module and how many files import it.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct ImportedModule {
+    /// Module name as other files import it (file stem or package directory).
+    pub module: String,
+    /// Number of cached files importing it.
+    pub importers: usize,
+}
+
+/// How well the cache covers the current working tree.
+#[derive(Debug, Clone, PartialEq, Serialize, Deserialize)]
+pub struct CacheHealth {
+    /// Supported source files found under the roots.
+    pub files_on_disk: usize,
+    /// Files whose cached entry matches their current content.
+    pub hits: usize,
+    /// Files with an outdated entry.
+    pub stale: usize,
+    /// Files with no entry at all.
+    pub uncached: usize,
+    /// `hits / files_on_disk`, or 0 when there are no files.
+    pub hit_rate: f64,
+    /// Age of the oldest entry in scope, when entry timestamps are known.
+    pub oldest_entry_age_secs: Option<u64>,
+}
+
+/// Aggregate metrics for the files under a set of roots.
+#[derive(Debug, Clone, PartialEq, Serialize, Deserialize)]
+pub struct RepositoryStats {
+    /// Cached files under the roots that still exist.
+    pub total_files: usize,
+    /// Lines of code across those files.
+    pub total_lines: usize,
+    /// Symbols (entities) across those files.
+    pub total_symbols: usize,
+    /// Symbols visible outside their file or package.
+    pub exported_symbols: usize,
+    /// Mean estimated cyclomatic complexity of functions and methods.
+    pub average_cyclomatic_complexity: f64,
+    /// Per-language totals, largest first.
+    pub languages: Vec<LanguageStats>,
+    /// Largest files by lines of code.
+    pub largest_by_lines: Vec<FileStats>,
+    /// Largest files by symbol count.
+    pub largest_by_symbols: Vec<FileStats>,
+    /// Internal modules imported by the most files.
+    pub most_imported: Vec<ImportedModule>,
+    /// Cache coverage of the working tree.
+    pub cache: CacheHealth,
+}
+
+/// Construction methods for [`RepositoryStats`].
+impl RepositoryStats {
+    /// Summarise the cache entries under `roots`, keeping `top` entries per ranking.
+    pub fn collect(cache: &IncrementalCache, roots: &[PathBuf], top: usize) -> Result<Self> {
+        let roots = roots
+            .iter()
+            .map(|root| {
+                root.canonicalize().map_err(|e| {
+                    ValknutError::io(format!("Failed to resolve {}", root.display()), e)
+                })
+            })
+            .collect::<Result<Vec<_>>>()?;
+
+        let all_entries = cache.entries();
+        let entries: Vec<(&Path, &CachedFileEntry)> = all_entries
+            .iter()
+            .map(|(path, entry)| (path.as_path(), entry))
+            .filter(|(path, _)| roots.iter().any(|root| path.starts_with(root)))
+            .filter(|(path, _)| path.exists())
+            .collect();
+
+        Ok(Self::from_entries(
+            &entries,
+            top,
+            health(cache, &roots, &entries),
+        ))
+    }
+
+    /// Compute the metrics for already-scoped entries.
+    fn from_entries(entries: &[(&Path, &CachedFileEntry)], top: usize, cache: CacheHealth) -> Self {
+        let mut languages: BTreeMap<String, LanguageStats> = BTreeMap::new();
+        let mut files = Vec::with_capacity(entries.len());
+        let mut exported_symbols = 0;
+        let mut complexity_total = 0;
+        let mut function_count = 0;
+
+        for (path, entry) in entries {
+            let language = detect_language_from_path(&path.to_string_lossy());
+            let stats = languages
+                .entry(language.clone())
+                .or_insert_with(|| LanguageStats {
+                    language: language.clone(),
+                    files: 0,
+                    lines: 0,
+                    symbols: 0,
+                });
+            stats.files += 1;
+            stats.lines += entry.lines_of_code;
+            stats.symbols += entry.entities.len();
+
+            for entity in &entry.entities {
+                if is_exported(entity, &language) {
+                    exported_symbols += 1;
+                }
+                if matches!(entity.entity_type.as_str(), "Function" | "Method") {
+                    complexity_total += estimate_cyclomatic(&entity.source_code);
+                    function_count += 1;
+                }
+            }
+            files.push(FileStats {
+                path: entry.display_path.clone(),
+                lines: entry.lines_of_code,
+                symbols: entry.entities.len(),
+            });
+        }
+
+        let mut languages: Vec<LanguageStats> = languages.into_values().collect();
+        languages.sort_by(|a, b| {
+            b.lines
+                .cmp(&a.lines)
+                .then_with(|| a.language.cmp(&b.language))
+        });
+
+        let mut largest_by_lines = files.clone();
+        largest_by_lines.sort_by(|a, b| b.lines.cmp(&a.lines).then_with(|| a.path.cmp(&b.path)));
+        largest_by_lines.truncate(top);
+        let mut largest_by_symbols = files;
+        largest_by_symbols
+            .sort_by(|a, b| b.symbols.cmp(&a.symbols).then_with(|| a.path.cmp(&b.path)));
+        largest_by_symbols.truncate(top);
+
+        Self {
+            total_files: entries.len(),
+            total_lines: languages.iter().map(|stats| stats.lines).sum(),
+            total_symbols: languages.iter().map(|stats| stats.symbols).sum(),
+            exported_symbols,
+            average_cyclomatic_complexity: if function_count == 0 {
+                0.0
+            } else {
+                complexity_total as f64 / function_count as f64
+            },
+            languages,
+            largest_by_lines,
+            largest_by_symbols,
+            most_imported: most_imported(entries, top),
+            cache,
+        }
+    }
+}
+
+/// Compare the working tree under `roots` against the cache.
+fn health(
+    cache: &IncrementalCache,
+    roots: &[PathBuf],
+    entries: &[(&Path, &CachedFileEntry)],
+) -> CacheHealth {
+    let mut files_on_disk = 0;
+    let mut hits = 0;
+    let mut stale = 0;
+    for root in roots {
+        let mut builder = WalkBuilder::new(root);
+        builder.add_custom_ignore_filename(IGNORE_FILE_NAME);
+        for entry in builder.build() {
+            let entry = match entry {
+                Ok(entry) => entry,
+                Err(err) => {
+                    warn!("Failed to walk directory: {err}");
+                    continue;
+                }
+            };
+            let path = entry.path();
+            let supported = path
+                .extension()
+                .is_some_and(|ext| extension_is_supported(&ext.to_string_lossy()));
+            if !supported || !path.is_file() {
+                continue;
+            }
+            files_on_disk += 1;
+            if cache.get(path).is_none() {
+                continue;
+            }
+            match std::fs::read_to_string(path) {
+                Ok(content) if cache.is_fresh(path, &content) => hits += 1,
+                _ => stale += 1,
+            }
+        }
+    }
+
+    let now = unix_secs(SystemTime::now());
+    let oldest_entry_age_secs = entries
+        .iter()
+        .map(|(_, entry)| entry.cached_at_secs)
+        .filter(|cached_at| *cached_at > 0)
+        .min()
+        .map(|cached_at| now.saturating_sub(cached_at));
+
+    CacheHealth {
+        files_on_disk,
+        hits,
+        stale,
+        uncached: files_on_disk - hits - stale,
+        hit_rate: if files_on_disk == 0 {
+            0.0
+        } else {
+            hits as f64 / files_on_disk as f64
+        },
+        oldest_entry_age_secs,
+    }
+}
+
+/// Internal modules ranked by the number of files importing them.
+///
+/// A module is internal when one of the scoped files is importable under its
+/// name; imports are matched by path segment like cache invalidation is.
+fn most_imported(entries: &[(&Path, &CachedFileEntry)], top: usize) -> Vec<ImportedModule> {
+    let internal: HashSet<String> = entries
+        .iter()
+        .flat_map(|(path, _)| module_names(path))
+        .collect();
+
+    let mut importers: HashMap<&str, usize> = HashMap::new();
+    for (path, entry) in entries {
+        let own = module_names(path);
+        let imported: HashSet<&str> = internal
+            .iter()
+            .filter(|module| !own.contains(module))
+            .filter(|module| {
+                let module = HashSet::from([(*module).clone()]);
+                entry
+                    .imports
+                    .iter()
+                    .any(|import| imports_any(import, &module))
+            })
+            .map(String::as_str)
+            .collect();
+        for module in imported {
+            *importers.entry(module).or_insert(0) += 1;
+        }
+    }
+
+    let mut ranked: Vec<ImportedModule> = importers
+        .into_iter()
+        .map(|(module, importers)| ImportedModule {
+            module: module.to_string(),
+            importers,
+        })
+        .collect();
+    ranked.sort_by(|a, b| {
+        b.importers
+            .cmp(&a.importers)
+            .then_with(|| a.module.cmp(&b.module))
+    });
+    ranked.truncate(top);
+    ranked
+}
+
+/// Whether a symbol is visible outside its file or package.
+///
+/// Adapters that record visibility are trusted; otherwise the language's
+/// convention decides: capitalised Go names, Python names without a leading
+/// underscore, JavaScript/TypeScript declarations starting with `export`, and
+/// C/C++ declarations that are not `static`.
+pub(crate) fn is_exported(entity: &CodeEntity, language: &str) -> bool {
+    if let Some(is_public) = entity.properties.get("is_public").and_then(|v| v.as_bool()) {
+        return is_public;
+    }
+    if let Some(visibility) = entity.properties.get("visibility").and_then(|v| v.as_str()) {
+        return matches!(visibility, "public" | "pub");
+    }
+    let source = entity.source_code.trim_start();
+    match language {
+        "go" => crate::lang::go::is_exported(&entity.name),
+        "py" => !entity.name.starts_with('_'),
+        "js" | "ts" => source.starts_with("export"),
+        "c" | "cpp" => !source.starts_with("static"),
+        _ => false,
+    }
+}
+
+/// Estimate cyclomatic complexity as one plus the decision points in `source`.
+///
+/// Branching keywords and short-circuit operators are counted on the raw
+/// text, so keywords inside strings or comments are counted too.
+pub fn estimate_cyclomatic(source: &str) -> usize {
+    let keywords = source
+        .split(|c: char| !(c.is_alphanumeric() || c == '_'))
+        .filter(|word| DECISION_KEYWORDS.contains(word))
+        .count();
+    1 + keywords + source.matches("&&").count() + source.matches("||").count()
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn entity(kind: &str, name: &str, source: &str) -> CodeEntity {
+        CodeEntity::new(format!("id-{name}"), kind, name, "file").with_source_code(source)
+    }
+
+    fn entry(
+        path: &str,
+        lines: usize,
+        imports: &[&str],
+        entities: Vec<CodeEntity>,
+    ) -> CachedFileEntry {
+        CachedFileEntry {
+            display_path: path.to_string(),
+            mtime_secs: 0,
+            sha256: String::new(),
+            imports: imports.iter().map(|s| s.to_string()).collect(),
+            lines_of_code: lines,
+            entities,
+            cached_at_secs: 0,
+        }
+    }
+
+    fn no_cache() -> CacheHealth {
+        CacheHealth {
+            files_on_disk: 0,
+            hits: 0,
+            stale: 0,
+            uncached: 0,
+            hit_rate: 0.0,
+            oldest_entry_age_secs: None,
+        }
+    }
+
+    #[test]
+    fn estimates_complexity_from_decision_points() {
+        assert_eq!(estimate_cyclomatic("fn f() { 1 }"), 1);
+        assert_eq!(
+            estimate_cyclomatic("if a && b { for x in y {} } else if c || d {}"),
+            6
+        );
+        assert_eq!(estimate_cyclomatic("let iffy = elif_count;"), 1);
+    }
+
+    #[test]
+    fn aggregates_totals_languages_and_rankings() {
+        let util = entry(
+            "src/util.py",
+            40,
+            &[],
+            vec![
+                entity("Function", "helper", "def helper():\n    if x: pass"),
+                entity("Function", "_private", "def _private(): pass"),
+            ],
+        );
+        let app = entry(
+            "src/app.py",
+            120,
+            &["pkg.util", "os"],
+            vec![entity("Class", "App", "class App: pass")],
+        );
+        let main = entry(
+            "src/main.go",
+            10,
+            &["example.com/lib/util"],
+            vec![entity("Function", "Run", "func Run() {}")],
+        );
+        let entries = vec![
+            (Path::new("/repo/src/util.py"), &util),
+            (Path::new("/repo/src/app.py"), &app),
+            (Path::new("/repo/src/main.go"), &main),
+        ];
+
+        let stats = RepositoryStats::from_entries(&entries, 2, no_cache());
+
+        assert_eq!(stats.total_files, 3);
+        assert_eq!(stats.total_lines, 170);
+        assert_eq!(stats.total_symbols, 4);
+        assert_eq!(stats.exported_symbols, 3, "_private is not exported");
+        assert!((stats.average_cyclomatic_complexity - 4.0 / 3.0).abs() < 1e-9);
+
+        assert_eq!(stats.languages[0].language, "py");
+        assert_eq!(stats.languages[0].files, 2);
+        assert_eq!(stats.languages[1].language, "go");
+
+        let by_lines: Vec<&str> = stats
+            .largest_by_lines
+            .iter()
+            .map(|f| f.path.as_str())
+            .collect();
+        assert_eq!(by_lines, vec!["src/app.py", "src/util.py"]);
+        assert_eq!(stats.largest_by_symbols[0].path, "src/util.py");
+
+        assert_eq!(
+            stats.most_imported,
+            vec![ImportedModule {
+                module: "util".to_string(),
+                importers: 2,
+            }]
+        );
+    }
+
+    #[test]
+    fn reports_cache_hit_rate_for_the_working_tree() {
+        let dir = tempfile::tempdir().unwrap();
+        let root = dir.path().join("repo");
+        std::fs::create_dir_all(&root).unwrap();
+        let fresh = root.join("fresh.py");
+        let changed = root.join("changed.py");
+        let new = root.join("new.py");
+        for path in [&fresh, &changed, &new] {
+            std::fs::write(path, "x = 1\n").unwrap();
+        }
+        std::fs::write(root.join("notes.txt"), "ignored").unwrap();
+
+        let mut cache = IncrementalCache::open(dir.path().join("cache"));
+        for path in [&fresh, &changed] {
+            let result = entry(&path.to_string_lossy(), 1, &[], Vec::new()).to_arena_result("");
+            cache.store(path, "x = 1\n", Vec::new(), &result);
+        }
+        cache.save().unwrap();
+        std::fs::write(&changed, "x = 2\n").unwrap();
+
+        let stats = RepositoryStats::collect(&cache, &[root], 5).unwrap();
+        assert_eq!(stats.total_files, 2);
+        assert_eq!(stats.cache.files_on_disk, 3);
+        assert_eq!(stats.cache.hits, 1);
+        assert_eq!(stats.cache.stale, 1);
+        assert_eq!(stats.cache.uncached, 1);
+        assert!((stats.cache.hit_rate - 1.0 / 3.0).abs() < 1e-9);
+        assert!(stats.cache.oldest_entry_age_secs.is_some());
+    }
+}
@@ -0,0 +1,269 @@
+//! Temporary checkouts of remote Git repositories.
+//!
+//! [`RemoteCheckout::fetch`] materialises a single revision of a remote
+//! repository in a private temporary directory so it can be analyzed like a
+//! local tree. Fetches can be shallow (the CLI defaults to `--depth 1`) and
+//! authenticate through the user's git configuration: the SSH agent for SSH remotes and the
+//! configured credential helpers for HTTP(S). The directory is removed when the
+//! checkout is dropped.
+
+use std::path::{Path, PathBuf};
+
+use git2::{
+    build::CheckoutBuilder, Config, Cred, CredentialType, Direction, FetchOptions, Oid,
+    RemoteCallbacks, Repository,
+};
+use tracing::{info, warn};
+
+use crate::core::errors::{Result, ValknutError};
+use crate::core::review::git_error;
+
+/// Maximum credential attempts per fetch before giving up (libgit2 retries indefinitely).
+const MAX_CREDENTIAL_ATTEMPTS: usize = 3;
+
+/// Returns true when `target` looks like a Git remote URL rather than a local path.
+pub fn is_remote_url(target: &str) -> bool {
+    const SCHEMES: [&str; 5] = ["https://", "http://", "ssh://", "git://", "git+ssh://"];
+    if SCHEMES.iter().any(|scheme| target.starts_with(scheme)) {
+        return true;
+    }
+    // scp-like syntax: user@host:path
+    match (target.find('@'), target.find(':')) {
+        (Some(at), Some(colon)) => at < colon && !target.contains("://"),
+        _ => false,
+    }
+}
+
+/// A revision of a remote repository checked out into a temporary directory.
+#[derive(Debug)]
+pub struct RemoteCheckout {
+    /// Remote URL that was fetched.
+    pub url: String,
+    /// Requested branch, tag or commit (`None` for the remote's default branch).
+    pub git_ref: Option<String>,
+    /// Commit that was checked out.
+    pub commit: String,
+    /// Working tree location; removed on drop.
+    path: PathBuf,
+}
+
+/// Fetch and accessor methods for [`RemoteCheckout`].
+impl RemoteCheckout {
+    /// Fetch `git_ref` (or the default branch) from `url` and check it out.
+    ///
+    /// `depth` limits fetched history; `0` fetches everything.
+    pub fn fetch(url: &str, git_ref: Option<&str>, depth: u32) -> Result<Self> {
+        let path = std::env::temp_dir().join(format!("valknut-remote-{}", uuid::Uuid::new_v4()));
+        // Construct first so the directory is cleaned up on every error path below.
+        let mut checkout = Self {
+            url: url.to_string(),
+            git_ref: git_ref.map(str::to_string),
+            commit: String::new(),
+            path,
+        };
+        std::fs::create_dir_all(&checkout.path).map_err(|e| {
+            ValknutError::io(format!("Failed to create {}", checkout.path.display()), e)
+        })?;
+
+        info!(
+            "Fetching {} ({}) into {}",
+            url,
+            git_ref.unwrap_or("HEAD"),
+            checkout.path.display()
+        );
+        let repo = Repository::init(&checkout.path)
+            .map_err(|e| git_error("initialize the checkout", e))?;
+        let mut remote = repo
+            .remote_anonymous(url)
+            .map_err(|e| git_error("create the remote", e))?;
+        let config = Config::open_default().map_err(|e| git_error("read the git config", e))?;
+
+        let advertised: Vec<(String, Oid)> = {
+            // The connection is closed when it goes out of scope.
+            let connection = remote
+                .connect_auth(Direction::Fetch, Some(credential_callbacks(&config)), None)
+                .map_err(|e| git_error("connect to the remote", e))?;
+            connection
+                .list()
+                .map_err(|e| git_error("list remote refs", e))?
+                .iter()
+                .map(|head| (head.name().to_string(), head.oid()))
+                .collect()
+        };
+
+        let refspec = resolve_refspec(&advertised, git_ref)?;
+        let mut options = FetchOptions::new();
+        options.remote_callbacks(credential_callbacks(&config));
+        if depth > 0 {
+            options.depth(i32::try_from(depth).unwrap_or(i32::MAX));
+        }
+        remote
+            .fetch(&[refspec.as_str()], Some(&mut options), None)
+            .map_err(|e| git_error(&format!("fetch {refspec}"), e))?;
+
+        let mut fetched = None;
+        repo.fetchhead_foreach(|_, _, oid, _| {
+            fetched.get_or_insert(*oid);
+            true
+        })
+        .map_err(|e| git_error("read FETCH_HEAD", e))?;
+        let oid = fetched.ok_or_else(|| {
+            ValknutError::internal(format!("Fetching {refspec} from {url} returned no commits"))
+        })?;
+        let commit = repo
+            .find_object(oid, None)
+            .and_then(|object| object.peel_to_commit())
+            .map_err(|e| git_error("resolve the fetched commit", e))?;
+
+        repo.set_head_detached(commit.id())
+            .map_err(|e| git_error("set HEAD", e))?;
+        repo.checkout_head(Some(CheckoutBuilder::new().force()))
+            .map_err(|e| git_error("check out the fetched commit", e))?;
+
+        checkout.commit = commit.id().to_string();
+        Ok(checkout)
+    }
+
+    /// Working tree of the checkout.
+    pub fn path(&self) -> &Path {
+        &self.path
+    }
+}
+
+/// Removes the temporary working tree.
+impl Drop for RemoteCheckout {
+    fn drop(&mut self) {
+        if let Err(e) = std::fs::remove_dir_all(&self.path) {
+            if e.kind() != std::io::ErrorKind::NotFound {
+                warn!("Failed to remove {}: {}", self.path.display(), e);
+            }
+        }
+    }
+}
+
+/// Pick the refspec to fetch for `git_ref` from the refs a remote advertises.
+///
+/// Branches win over tags of the same name, matching `git checkout`. A ref that
+/// is not advertised is assumed to be a commit id and fetched directly.
+fn resolve_refspec(advertised: &[(String, Oid)], git_ref: Option<&str>) -> Result<String> {
+    let Some(git_ref) = git_ref else {
+        return Ok("HEAD".to_string());
+    };
+    let candidates = [
+        git_ref.to_string(),
+        format!("refs/heads/{git_ref}"),
+        format!("refs/tags/{git_ref}"),
+    ];
+    for candidate in &candidates {
+        if advertised.iter().any(|(name, _)| name == candidate) {
+            return Ok(candidate.clone());
+        }
+    }
+
+    let is_commit_id = git_ref.len() >= 7 && git_ref.chars().all(|c| c.is_ascii_hexdigit());
+    if is_commit_id {
+        // Abbreviated ids can only be resolved against advertised heads.
+        if let Some((_, oid)) = advertised
+            .iter()
+            .find(|(_, oid)| oid.to_string().starts_with(git_ref))
+        {
+            return Ok(oid.to_string());
+        }
+        if git_ref.len() == 40 {
+            return Ok(git_ref.to_string());
+        }
+    }
+    Err(ValknutError::validation(format!(
+        "Remote has no branch, tag or commit named '{git_ref}' (use a full 40-character commit id)"
+    )))
+}
+
+/// Credential callbacks that defer to the SSH agent and git credential helpers.
+fn credential_callbacks(config: &Config) -> RemoteCallbacks<'_> {
+    let mut attempts = 0;
+    let mut callbacks = RemoteCallbacks::new();
+    callbacks.credentials(move |url, username, allowed| {
+        attempts += 1;
+        if attempts > MAX_CREDENTIAL_ATTEMPTS {
+            return Err(git2::Error::from_str(
+                "authentication failed; check your git credential helper or SSH agent",
+            ));
+        }
+        if allowed.contains(CredentialType::SSH_KEY) {
+            return Cred::ssh_key_from_agent(username.unwrap_or("git"));
+        }
+        if allowed.contains(CredentialType::USER_PASS_PLAINTEXT) {
+            return Cred::credential_helper(config, url, username);
+        }
+        Cred::default()
+    });
+    callbacks
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use std::fs;
+    use tempfile::tempdir;
+
+    #[test]
+    fn recognises_remote_urls() {
+        assert!(is_remote_url("https://github.com/org/repo"));
+        assert!(is_remote_url("ssh://git@example.com/org/repo.git"));
+        assert!(is_remote_url("git@github.com:org/repo.git"));
+        assert!(!is_remote_url("./src"));
+        assert!(!is_remote_url("/home/user/repo"));
+        assert!(!is_remote_url("C:\\code\\repo"));
+    }
+
+    #[test]
+    fn resolve_refspec_prefers_branches_then_tags_then_commits() {
+        let oid = Oid::from_str("0123456789abcdef0123456789abcdef01234567").unwrap();
+        let advertised = vec![
+            ("HEAD".to_string(), oid),
+            ("refs/heads/main".to_string(), oid),
+            ("refs/tags/v1.0".to_string(), oid),
+        ];
+
+        assert_eq!(resolve_refspec(&advertised, None).unwrap(), "HEAD");
+        assert_eq!(
+            resolve_refspec(&advertised, Some("main")).unwrap(),
+            "refs/heads/main"
+        );
+        assert_eq!(
+            resolve_refspec(&advertised, Some("v1.0")).unwrap(),
+            "refs/tags/v1.0"
+        );
+        assert_eq!(
+            resolve_refspec(&advertised, Some("0123456")).unwrap(),
+            oid.to_string()
+        );
+        assert!(resolve_refspec(&advertised, Some("missing")).is_err());
+    }
+
+    #[test]
+    fn fetch_checks_out_requested_branch_and_cleans_up() {
+        let origin = tempdir().unwrap();
+        let repo = Repository::init(origin.path()).unwrap();
+        fs::write(origin.path().join("lib.py"), "def f():\n    return 1\n").unwrap();
+
+        let mut index = repo.index().unwrap();
+        index.add_path(Path::new("lib.py")).unwrap();
+        let tree = repo.find_tree(index.write_tree().unwrap()).unwrap();
+        let signature = git2::Signature::now("Test", "test@example.com").unwrap();
+        let commit = repo
+            .commit(Some("HEAD"), &signature, &signature, "init", &tree, &[])
+            .unwrap();
+        repo.branch("feature", &repo.find_commit(commit).unwrap(), false)
+            .unwrap();
+
+        let url = origin.path().to_string_lossy().into_owned();
+        let checkout = RemoteCheckout::fetch(&url, Some("feature"), 0).unwrap();
+        let path = checkout.path().to_path_buf();
+        assert_eq!(checkout.commit, commit.to_string());
+        assert!(path.join("lib.py").exists());
+
+        drop(checkout);
+        assert!(!path.exists());
+    }
+}
@@ -1,6 +1,7 @@
 use std::env;
 use std::fs;
 use std::path::{Path, PathBuf};
+use tracing::warn;
 
 use super::error::ReportError;
 
@@ -96,8 +97,8 @@ pub(super) fn copy_js_assets_to_output<P: AsRef<Path>>(output_dir: P) -> Result<
         }
 
         if !copied {
-            eprintln!(
-                "Warning: JavaScript asset {} not found; the interactive tree may not render",
+            warn!(
+                "JavaScript asset {} not found; the interactive tree may not render",
                 src
             );
         }
@@ -120,7 +121,7 @@ pub fn copy_webpage_assets_to_output<P: AsRef<Path>>(output_dir: P) -> Result<()
 
     for (relative_source, target_dir) in assets {
         if !try_copy_asset(relative_source, target_dir, output_dir, &search_roots)? {
-            eprintln!("Warning: asset {} not found", relative_source);
+            warn!("asset {} not found", relative_source);
         }
     }
 
@@ -3,10 +3,10 @@
 use crate::api::config_types::AnalysisConfig;
 use crate::core::config::ReportFormat;
 use crate::core::pipeline::{
-    AnalysisResults, CodeDictionary, DepthHealthStats, DirectoryHealthScore, DirectoryHealthTree,
-    DirectoryHotspot, DirectoryIssueSummary, FileRefactoringGroup, NormalizedAnalysisResults,
-    NormalizedEntity, RefactoringCandidate, RefactoringIssue, RefactoringSuggestion,
-    TreeStatistics,
+    AnalysisResults, CodeContext, CodeDictionary, DepthHealthStats, DirectoryHealthScore,
+    DirectoryHealthTree, DirectoryHotspot, DirectoryIssueSummary, FileRefactoringGroup,
+    NormalizedAnalysisResults, NormalizedEntity, RefactoringCandidate, RefactoringIssue,
+    RefactoringSuggestion, TreeStatistics,
 };
 use crate::core::scoring::Priority;
 use chrono::Utc;
@@ -16,6 +16,7 @@ use std::collections::HashMap;
 use std::fs::{self, File};
 use std::io::BufWriter;
 use std::path::{Path, PathBuf};
+use tracing::warn;
 
 use super::assets::{
     copy_js_assets_to_output, copy_theme_css_to_output, copy_webpage_assets_to_output,
@@ -52,7 +53,7 @@ impl Default for ReportGenerator {
 
         if let Some(templates_dir) = detect_templates_dir() {
             if let Err(err) = load_templates_from_dir(&mut generator.handlebars, &templates_dir) {
-                eprintln!("Failed to load external templates: {}", err);
+                warn!("Failed to load external templates: {}", err);
             } else {
                 generator.templates_dir = Some(templates_dir);
             }
@@ -125,6 +126,26 @@ impl ReportGenerator {
         self.render_template_to_path(SONAR_TEMPLATE_NAME, results, output_path)
     }
 
+    pub fn generate_sarif_report<P: AsRef<Path>>(
+        &self,
+        results: &AnalysisResults,
+        output_path: P,
+    ) -> Result<(), ReportError> {
+        let log = super::sarif::build_sarif_log(results);
+        let file = File::create(output_path.as_ref())?;
+        serde_json::to_writer_pretty(BufWriter::new(file), &log)?;
+        Ok(())
+    }
+
+    pub fn generate_junit_report<P: AsRef<Path>>(
+        &self,
+        results: &AnalysisResults,
+        output_path: P,
+    ) -> Result<(), ReportError> {
+        fs::write(output_path, super::junit::build_junit_report(results))?;
+        Ok(())
+    }
+
     pub fn generate_report_with_oracle<P: AsRef<Path>>(
         &self,
         results: &AnalysisResults,
@@ -653,6 +674,7 @@ impl ReportGenerator {
             entity.name.clone()
         };
 
+        let context = CodeContext::for_path(&file_path);
         RefactoringCandidate {
             entity_id: entity.id.clone(),
             name,
@@ -666,6 +688,7 @@ impl ReportGenerator {
             issue_count: entity.issues.len(),
             suggestion_count: entity.suggestions.len(),
             coverage_percentage: None,
+            context,
         }
     }
     fn derive_entity_name(&self, entity: &NormalizedEntity) -> String {
@@ -73,6 +73,7 @@ fn create_test_results() -> AnalysisResults {
         issue_count: 1,
         suggestion_count: 1,
         coverage_percentage: None,
+        context: Default::default(),
     }];
     results.statistics.total_duration = Duration::from_millis(1500);
     results.statistics.avg_file_processing_time = Duration::from_millis(500);
@@ -304,6 +305,55 @@ fn test_generate_markdown_sonar_and_csv_table_reports() {
     assert!(sonar_content.contains("\"issues\""));
 }
 
+#[test]
+fn test_generate_sarif_report() {
+    let temp_dir = TempDir::new().unwrap();
+    let generator = ReportGenerator::new();
+    let results = create_test_results();
+
+    let sarif_path = temp_dir.path().join("valknut.sarif");
+    generator
+        .generate_sarif_report(&results, &sarif_path)
+        .expect("sarif report");
+    let sarif: serde_json::Value =
+        serde_json::from_str(&fs::read_to_string(&sarif_path).unwrap()).unwrap();
+
+    assert_eq!(sarif["version"], "2.1.0");
+    let run = &sarif["runs"][0];
+    assert_eq!(run["tool"]["driver"]["name"], "valknut");
+    assert_eq!(run["tool"]["driver"]["rules"][0]["id"], "complexity.high");
+
+    let result = &run["results"][0];
+    assert_eq!(result["ruleId"], "complexity.high");
+    assert_eq!(result["ruleIndex"], 0);
+    assert_eq!(result["level"], "warning");
+    let location = &result["locations"][0]["physicalLocation"];
+    assert_eq!(location["artifactLocation"]["uri"], "src/test.rs");
+    assert_eq!(location["region"]["startLine"], 10);
+    assert_eq!(location["region"]["endLine"], 50);
+
+    let notifications = &run["invocations"][0]["toolExecutionNotifications"];
+    assert_eq!(notifications[0]["message"]["text"], "Test warning");
+}
+
+#[test]
+fn test_generate_junit_report() {
+    let temp_dir = TempDir::new().unwrap();
+    let generator = ReportGenerator::new();
+    let results = create_test_results();
+
+    let junit_path = temp_dir.path().join("valknut-junit.xml");
+    generator
+        .generate_junit_report(&results, &junit_path)
+        .expect("junit report");
+    let xml = fs::read_to_string(&junit_path).unwrap();
+
+    assert!(xml.contains("<testsuite name=\"src/test.rs\""));
+    assert!(xml.contains("<testcase classname=\"src\" name=\"complexity.high\""));
+    assert!(xml.contains("<failure type=\"warning\""));
+    assert!(xml.contains("src/test.rs:10: "));
+}
+
 #[test]
 fn test_generate_html_report_default_template() {
     let temp_dir = TempDir::new().unwrap();
@@ -655,6 +705,7 @@ fn test_add_files_to_hierarchy_basic() {
         issue_count: 3,
         suggestion_count: 1,
         coverage_percentage: None,
+        context: Default::default(),
     };
 
     let file_groups = vec![FileRefactoringGroup {
@@ -735,6 +786,7 @@ fn test_add_files_to_hierarchy_nested_directories() {
         issue_count: 1,
         suggestion_count: 0,
         coverage_percentage: None,
+        context: Default::default(),
     };
 
     let lib_candidate = RefactoringCandidate {
@@ -750,6 +802,7 @@ fn test_add_files_to_hierarchy_nested_directories() {
         issue_count: 5,
         suggestion_count: 2,
         coverage_percentage: None,
+        context: Default::default(),
     };
 
     let file_groups = vec![
@@ -884,6 +937,7 @@ fn test_add_files_to_hierarchy_preserves_existing_children() {
         issue_count: 1,
         suggestion_count: 0,
         coverage_percentage: None,
+        context: Default::default(),
     };
 
     let file_groups = vec![FileRefactoringGroup {
@@ -1012,6 +1066,7 @@ fn test_build_unified_hierarchy_sorts_by_priority() {
         issue_count: 2,
         suggestion_count: 0,
         coverage_percentage: None,
+        context: Default::default(),
     };
     let medium_entity = RefactoringCandidate {
         entity_id: "src/medium.rs::function".to_string(),
@@ -1026,6 +1081,7 @@ fn test_build_unified_hierarchy_sorts_by_priority() {
         issue_count: 1,
         suggestion_count: 0,
         coverage_percentage: None,
+        context: Default::default(),
     };
     let core_entity = RefactoringCandidate {
         entity_id: "src/core/lib.rs::helper".to_string(),
@@ -1040,6 +1096,7 @@ fn test_build_unified_hierarchy_sorts_by_priority() {
         issue_count: 1,
         suggestion_count: 0,
         coverage_percentage: None,
+        context: Default::default(),
     };
 
     let file_groups = vec![
@@ -1184,6 +1241,7 @@ fn test_add_files_to_hierarchy_enriches_metadata() {
         issue_count: 1,
         suggestion_count: 1,
         coverage_percentage: None,
+        context: Default::default(),
     };
 
     let file_groups = vec![FileRefactoringGroup {
@@ -1281,6 +1339,7 @@ fn test_create_file_groups_from_candidates_groups_stats() {
         issue_count: 2,
         suggestion_count: 0,
         coverage_percentage: None,
+        context: Default::default(),
     };
     let mut candidate_b = candidate_a.clone();
     candidate_b.entity_id = "src/lib.rs::beta".to_string();
@@ -1302,6 +1361,7 @@ fn test_create_file_groups_from_candidates_groups_stats() {
         issue_count: 1,
         suggestion_count: 0,
         coverage_percentage: None,
+        context: Default::default(),
     };
 
     let groups = create_file_groups_from_candidates(&[
@@ -5,6 +5,7 @@ use serde::Serialize;
 use serde_json::Value;
 use std::fs;
 use std::path::Path;
+use tracing::warn;
 
 #[cfg(test)]
 #[path = "helpers_tests.rs"]
@@ -53,7 +54,7 @@ const JS_PREFIXES: &[&str] = &[
 /// Serialize a value to JSON for template consumption. Returns `Value::Null` on error.
 pub fn safe_json_value<T: Serialize>(value: T) -> Value {
     serde_json::to_value(value).unwrap_or_else(|e| {
-        eprintln!("Warning: Failed to serialize value to JSON: {}", e);
+        warn!("Failed to serialize value to JSON: {}", e);
         Value::Null
     })
 }
@@ -601,8 +602,8 @@ fn register_inline_file_helper(handlebars: &mut Handlebars<'static>, config: Inl
                     }
                 }
 
-                eprintln!(
-                    "Warning: {} file '{}' not found, using empty content",
+                warn!(
+                    "{} file '{}' not found, using empty content",
                     file_type, file_path
                 );
                 Ok(())
@@ -0,0 +1,292 @@
+//! JUnit XML export.
+//!
+//! Renders analysis findings as a JUnit report so CI systems that display test
+//! results natively (Jenkins, GitLab, CircleCI) can show them without a
+//! plugin. Every analysed file becomes a `<testsuite>`; each rule the file
+//! violates is a `<testcase>` whose `classname` is the file's package
+//! (directory) path and whose `name` is the rule, with one `<failure>` listing
+//! every violation. Files without findings get a single passing
+//! `no_violations` case so the pass count reflects the whole tree.
+//!
+//! The findings are the ones the SARIF export reports (complexity issues,
+//! refactoring issues, file split recommendations and lint rule findings),
+//! plus a `missing_docs` case for files with documentation gaps.
+
+use std::collections::BTreeMap;
+use std::fmt::Write as _;
+use std::path::Path;
+
+use quick_xml::escape::escape;
+
+use super::sarif::{complexity_level, priority_level, rule_level};
+use crate::core::pipeline::AnalysisResults;
+
+/// Test case name of a file without findings.
+const PASSING_CASE: &str = "no_violations";
+
+/// Rule name of documentation gaps.
+const MISSING_DOCS_RULE: &str = "missing_docs";
+
+/// One violation of a rule in a file.
+struct Violation {
+    line: Option<usize>,
+    level: &'static str,
+    message: String,
+}
+
+/// Rule violations per file, keyed by report path then rule name.
+#[derive(Default)]
+struct JunitBuilder {
+    files: BTreeMap<String, BTreeMap<String, Vec<Violation>>>,
+}
+
+/// Collection and rendering methods for [`JunitBuilder`].
+impl JunitBuilder {
+    /// Make sure `file` gets a suite even when it has no findings.
+    fn add_file(&mut self, file: String) {
+        self.files.entry(file).or_default();
+    }
+
+    /// Record a violation of `rule` in `file`.
+    fn push(&mut self, file: String, rule: &str, violation: Violation) {
+        self.files
+            .entry(file)
+            .or_default()
+            .entry(rule.to_string())
+            .or_default()
+            .push(violation);
+    }
+
+    /// Render the collected files as a `<testsuites>` document.
+    fn finish(self) -> String {
+        let cases: usize = self.files.values().map(|rules| rules.len().max(1)).sum();
+        let failures: usize = self.files.values().map(BTreeMap::len).sum();
+
+        let mut xml = String::from("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n");
+        let _ = writeln!(
+            xml,
+            "<testsuites name=\"valknut\" tests=\"{cases}\" failures=\"{failures}\" errors=\"0\">"
+        );
+        for (file, rules) in &self.files {
+            let classname = escape(package_path(file)).into_owned();
+            let file_attr = escape(file.as_str()).into_owned();
+            let _ = writeln!(
+                xml,
+                "  <testsuite name=\"{file_attr}\" tests=\"{}\" failures=\"{}\" errors=\"0\" skipped=\"0\">",
+                rules.len().max(1),
+                rules.len()
+            );
+            if rules.is_empty() {
+                let _ = writeln!(
+                    xml,
+                    "    <testcase classname=\"{classname}\" name=\"{PASSING_CASE}\" file=\"{file_attr}\"/>"
+                );
+            }
+            for (rule, violations) in rules {
+                let _ = writeln!(
+                    xml,
+                    "    <testcase classname=\"{classname}\" name=\"{}\" file=\"{file_attr}\">",
+                    escape(rule.as_str())
+                );
+                let message = match violations.as_slice() {
+                    [only] => only.message.clone(),
+                    _ => format!("{} violations of {rule}", violations.len()),
+                };
+                let details: Vec<String> = violations
+                    .iter()
+                    .map(|violation| match violation.line {
+                        Some(line) => format!("{file}:{line}: {}", violation.message),
+                        None => format!("{file}: {}", violation.message),
+                    })
+                    .collect();
+                let _ = writeln!(
+                    xml,
+                    "      <failure type=\"{}\" message=\"{}\">{}</failure>",
+                    most_severe(violations),
+                    escape(message.as_str()),
+                    escape(details.join("\n").as_str())
+                );
+                xml.push_str("    </testcase>\n");
+            }
+            xml.push_str("  </testsuite>\n");
+        }
+        xml.push_str("</testsuites>\n");
+        xml
+    }
+}
+
+/// Build a JUnit XML report for `results`.
+pub fn build_junit_report(results: &AnalysisResults) -> String {
+    let root = results.project_root.as_path();
+    let mut builder = JunitBuilder::default();
+
+    for file in results.file_health.keys() {
+        builder.add_file(report_path(root, file));
+    }
+
+    for entity in &results.passes.complexity.detailed_results {
+        let file = report_path(root, &entity.file_path);
+        builder.add_file(file.clone());
+        for issue in &entity.issues {
+            builder.push(
+                file.clone(),
+                &issue.issue_type,
+                Violation {
+                    line: Some(entity.start_line),
+                    level: complexity_level(&issue.severity),
+                    message: format!(
+                        "{} `{}`: {} (value {:.1}, threshold {:.1})",
+                        entity.entity_type,
+                        entity.entity_name,
+                        issue.description,
+                        issue.metric_value,
+                        issue.threshold
+                    ),
+                },
+            );
+        }
+    }
+
+    for candidate in &results.refactoring_candidates {
+        let level = priority_level(candidate.priority);
+        for issue in &candidate.issues {
+            let title = results
+                .code_dictionary
+                .issues
+                .get(&issue.code)
+                .map(|def| def.title.clone())
+                .unwrap_or_else(|| issue.category.clone());
+            builder.push(
+                report_path(root, &candidate.file_path),
+                &issue.code,
+                Violation {
+                    line: candidate.line_range.map(|(start, _)| start),
+                    level,
+                    message: format!("`{}`: {}", candidate.name, title),
+                },
+            );
+        }
+    }
+
+    for pack in &results.passes.structure.file_splitting_recommendations {
+        let Some(file) = pack.get("file").and_then(serde_json::Value::as_str) else {
+            continue;
+        };
+        builder.push(
+            report_path(root, file),
+            "file_split",
+            Violation {
+                line: None,
+                level: "warning",
+                message: "File should be split".to_string(),
+            },
+        );
+    }
+
+    for finding in &results.rule_findings {
+        builder.push(
+            report_path(root, &finding.file_path),
+            &finding.rule,
+            Violation {
+                line: finding.line_range.map(|(start, _)| start),
+                level: rule_level(finding.severity),
+                message: finding.message.clone(),
+            },
+        );
+    }
+
+    if let Some(documentation) = &results.documentation {
+        for (file, &gaps) in &documentation.file_doc_issues {
+            if gaps == 0 {
+                continue;
+            }
+            builder.push(
+                report_path(root, file),
+                MISSING_DOCS_RULE,
+                Violation {
+                    line: None,
+                    level: "note",
+                    message: format!("{gaps} undocumented item(s)"),
+                },
+            );
+        }
+    }
+
+    builder.finish()
+}
+
+/// Path of a file relative to the project root, with forward slashes.
+fn report_path(root: &Path, path: &str) -> String {
+    let path = Path::new(path);
+    let relative = path.strip_prefix(root).unwrap_or(path);
+    let relative = relative.to_string_lossy().replace('\\', "/");
+    relative.trim_start_matches("./").to_string()
+}
+
+/// Directory of a report path, `.` for files at the project root.
+fn package_path(file: &str) -> &str {
+    match file.rsplit_once('/') {
+        Some((dir, _)) if !dir.is_empty() => dir,
+        _ => ".",
+    }
+}
+
+/// The most severe SARIF level among `violations`, used as the failure type.
+fn most_severe(violations: &[Violation]) -> &'static str {
+    ["error", "warning"]
+        .into_iter()
+        .find(|level| violations.iter().any(|violation| violation.level == *level))
+        .unwrap_or("note")
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::detectors::rules::{RuleFinding, RuleSeverity};
+
+    fn finding(rule: &str, severity: RuleSeverity, file: &str, line: usize) -> RuleFinding {
+        RuleFinding {
+            rule: rule.to_string(),
+            severity,
+            message: format!("{rule} <violated> & reported"),
+            file_path: file.to_string(),
+            entity: None,
+            line_range: Some((line, line + 5)),
+        }
+    }
+
+    #[test]
+    fn files_become_suites_and_rules_become_cases() {
+        let mut results = AnalysisResults::empty();
+        results.file_health.insert("src/clean.go".to_string(), 1.0);
+        results.rule_findings.extend([
+            finding("short-functions", RuleSeverity::Info, "src/api/run.go", 10),
+            finding("short-functions", RuleSeverity::Error, "src/api/run.go", 40),
+            finding("no-todo", RuleSeverity::Warning, "main.go", 3),
+        ]);
+
+        let xml = build_junit_report(&results);
+        assert!(xml.starts_with("<?xml"));
+        assert!(xml.contains("<testsuites name=\"valknut\" tests=\"3\" failures=\"2\""));
+        assert!(xml.contains(
+            "<testcase classname=\"src\" name=\"no_violations\" file=\"src/clean.go\"/>"
+        ));
+        assert!(xml.contains(
+            "<testcase classname=\"src/api\" name=\"short-functions\" file=\"src/api/run.go\">"
+        ));
+        assert!(
+            xml.contains("<failure type=\"error\" message=\"2 violations of short-functions\">")
+        );
+        assert!(xml.contains("src/api/run.go:40: short-functions &lt;violated&gt; &amp; reported"));
+        assert!(xml.contains("<testcase classname=\".\" name=\"no-todo\" file=\"main.go\">"));
+    }
+
+    #[test]
+    fn paths_are_relative_to_the_project_root() {
+        let root = Path::new("/repo");
+        assert_eq!(report_path(root, "/repo/pkg/a.go"), "pkg/a.go");
+        assert_eq!(report_path(root, "./pkg\\b.go"), "pkg/b.go");
+        assert_eq!(package_path("pkg/sub/a.go"), "pkg/sub");
+        assert_eq!(package_path("a.go"), ".");
+    }
+}
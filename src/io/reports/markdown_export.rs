@@ -0,0 +1,459 @@
+//! Markdown project summary for `valknut export --format markdown`.
+//!
+//! [`ProjectSummary::collect`] groups the supported source files under a root
+//! by directory, treating each directory as a package. Every package gets its
+//! exported top-level symbols with their reference counts (see
+//! [`crate::core::public_api`]) and a lexical cyclomatic complexity estimate
+//! of its functions. Cross-package call edges come from the function-level
+//! dependency analysis.
+//!
+//! [`ProjectSummary::to_markdown`] renders the summary for pasting into a
+//! document or pull request: a Mermaid dependency diagram GitHub renders
+//! natively, a complexity heatmap drawn with Unicode block characters, and a
+//! section per package with a symbol table.
+
+use std::collections::BTreeMap;
+use std::fmt::Write as _;
+use std::path::{Path, PathBuf};
+
+use ignore::WalkBuilder;
+use serde::{Deserialize, Serialize};
+use tracing::warn;
+
+use crate::core::dependency::{relative_dir, ProjectDependencyAnalysis};
+use crate::core::errors::{Result, ValknutError};
+use crate::core::file_utils::FileReader;
+use crate::core::pipeline::discovery::IGNORE_FILE_NAME;
+use crate::core::public_api::{declared_symbols, ReferenceIndex, TopLevelSymbol};
+use crate::io::cache::stats::estimate_cyclomatic;
+use crate::lang::registry::adapter_for_file;
+
+/// Width in characters of a full heatmap bar.
+const BAR_WIDTH: usize = 24;
+
+/// Partial block characters, one to seven eighths of a cell.
+const PARTIAL_BLOCKS: [char; 7] = ['▏', '▎', '▍', '▌', '▋', '▊', '▉'];
+
+/// Longest doc comment excerpt shown in a symbol table, in characters.
+const DOC_EXCERPT_CHARS: usize = 80;
+
+/// One directory of source files.
+#[derive(Debug, Clone, PartialEq, Serialize, Deserialize)]
+pub struct PackageSummary {
+    /// Directory relative to the export root (`.` for the root itself).
+    pub path: String,
+    /// Number of supported source files directly in the directory.
+    pub files: usize,
+    /// Exported top-level symbols with their doc comments.
+    pub symbols: Vec<TopLevelSymbol>,
+    /// Number of functions and methods.
+    pub functions: usize,
+    /// Mean estimated cyclomatic complexity per function (0 without functions).
+    pub average_complexity: f64,
+    /// Highest estimated cyclomatic complexity of any function.
+    pub max_complexity: usize,
+}
+
+/// Calls from one package into another.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct PackageDependency {
+    /// Calling package path.
+    pub from: String,
+    /// Called package path.
+    pub to: String,
+    /// Number of cross-package calls.
+    pub calls: usize,
+}
+
+/// Packages under a root and the calls between them.
+#[derive(Debug, Clone, Default, PartialEq, Serialize, Deserialize)]
+pub struct ProjectSummary {
+    /// Display name of the exported root.
+    pub name: String,
+    /// Packages sorted by path.
+    pub packages: Vec<PackageSummary>,
+    /// Cross-package dependencies sorted by caller, then callee.
+    pub dependencies: Vec<PackageDependency>,
+}
+
+/// Collection and rendering methods for [`ProjectSummary`].
+impl ProjectSummary {
+    /// Summarise every package under `root`.
+    ///
+    /// `.gitignore` and `.valknutignore` files are honoured. A failing
+    /// dependency analysis leaves the summary without dependencies rather than
+    /// failing the export.
+    pub fn collect(root: &Path) -> Result<Self> {
+        if !root.is_dir() {
+            return Err(ValknutError::validation(format!(
+                "Export root is not a directory: {}",
+                root.display()
+            )));
+        }
+
+        let files = source_files(root);
+        let mut by_dir: BTreeMap<PathBuf, Vec<PathBuf>> = BTreeMap::new();
+        for file in &files {
+            let dir = file.parent().unwrap_or(root).to_path_buf();
+            by_dir.entry(dir).or_default().push(file.clone());
+        }
+
+        let references = ReferenceIndex::build(root);
+        let mut packages = Vec::with_capacity(by_dir.len());
+        for (dir, dir_files) in &by_dir {
+            let mut symbols = declared_symbols(dir)?;
+            references.annotate(dir, &mut symbols);
+            let complexities: Vec<usize> = dir_files
+                .iter()
+                .flat_map(|file| function_complexities(file))
+                .collect();
+            let total: usize = complexities.iter().sum();
+            packages.push(PackageSummary {
+                path: relative_dir(root, dir),
+                files: dir_files.len(),
+                symbols,
+                functions: complexities.len(),
+                average_complexity: if complexities.is_empty() {
+                    0.0
+                } else {
+                    total as f64 / complexities.len() as f64
+                },
+                max_complexity: complexities.iter().copied().max().unwrap_or(0),
+            });
+        }
+
+        let dependencies = match ProjectDependencyAnalysis::analyze(&files) {
+            Ok(analysis) => package_dependencies(root, &analysis),
+            Err(err) => {
+                warn!("Dependency analysis failed: {}", err);
+                Vec::new()
+            }
+        };
+
+        let name = root
+            .canonicalize()
+            .ok()
+            .and_then(|path| path.file_name().map(|n| n.to_string_lossy().into_owned()))
+            .unwrap_or_else(|| root.display().to_string());
+
+        Ok(Self {
+            name,
+            packages,
+            dependencies,
+        })
+    }
+
+    /// Render the summary as a Markdown document.
+    pub fn to_markdown(&self) -> String {
+        let mut out = String::new();
+        let symbol_count: usize = self.packages.iter().map(|p| p.symbols.len()).sum();
+        let _ = writeln!(out, "# {}\n", self.name);
+        let _ = writeln!(
+            out,
+            "{} packages, {} exported symbols, {} package dependencies.\n",
+            self.packages.len(),
+            symbol_count,
+            self.dependencies.len()
+        );
+
+        out.push_str("## Dependency graph\n\n");
+        if self.dependencies.is_empty() {
+            out.push_str("No calls cross package boundaries.\n\n");
+        } else {
+            out.push_str(&self.mermaid_graph());
+            out.push('\n');
+        }
+
+        out.push_str("## Complexity heatmap\n\n");
+        out.push_str(&self.complexity_heatmap());
+        out.push('\n');
+
+        for package in &self.packages {
+            render_package(&mut out, package);
+        }
+        out
+    }
+
+    /// The package dependency graph as a fenced Mermaid flowchart.
+    pub fn mermaid_graph(&self) -> String {
+        let ids: BTreeMap<&str, usize> = self
+            .packages
+            .iter()
+            .enumerate()
+            .map(|(index, package)| (package.path.as_str(), index))
+            .collect();
+
+        let mut out = String::from("```mermaid\ngraph LR\n");
+        for package in &self.packages {
+            let connected = self
+                .dependencies
+                .iter()
+                .any(|dep| dep.from == package.path || dep.to == package.path);
+            if connected {
+                let _ = writeln!(
+                    out,
+                    "    p{}[\"{}\"]",
+                    ids[package.path.as_str()],
+                    package.path.replace('"', "#quot;")
+                );
+            }
+        }
+        for dep in &self.dependencies {
+            if let (Some(from), Some(to)) = (ids.get(dep.from.as_str()), ids.get(dep.to.as_str())) {
+                let _ = writeln!(out, "    p{from} -->|{}| p{to}", dep.calls);
+            }
+        }
+        out.push_str("```\n");
+        out
+    }
+
+    /// Average complexity per package as a bar chart in a fenced text block.
+    pub fn complexity_heatmap(&self) -> String {
+        let measured: Vec<&PackageSummary> =
+            self.packages.iter().filter(|p| p.functions > 0).collect();
+        if measured.is_empty() {
+            return "No functions found.\n".to_string();
+        }
+
+        let peak = measured
+            .iter()
+            .map(|p| p.average_complexity)
+            .fold(0.0, f64::max);
+        let label_width = measured
+            .iter()
+            .map(|p| p.path.chars().count())
+            .max()
+            .unwrap_or(0);
+        let mut out = String::from("```text\n");
+        for package in measured {
+            let bar = complexity_bar(package.average_complexity, peak, BAR_WIDTH);
+            let _ = writeln!(
+                out,
+                "{:<label_width$}  {:<BAR_WIDTH$}  avg {:.1}, max {}",
+                package.path, bar, package.average_complexity, package.max_complexity
+            );
+        }
+        out.push_str("```\n");
+        out
+    }
+}
+
+/// Append one package section with its symbol table.
+fn render_package(out: &mut String, package: &PackageSummary) {
+    let _ = writeln!(out, "## `{}`\n", package.path);
+    let _ = writeln!(
+        out,
+        "{} files, {} functions, average complexity {:.1}.\n",
+        package.files, package.functions, package.average_complexity
+    );
+    if package.symbols.is_empty() {
+        out.push_str("No exported symbols.\n\n");
+        return;
+    }
+
+    out.push_str("| Symbol | Kind | Location | Description |\n");
+    out.push_str("| --- | --- | --- | --- |\n");
+    for symbol in &package.symbols {
+        let _ = writeln!(
+            out,
+            "| `{}` | {} | {}:{} | {} |",
+            escape_cell(&symbol.name),
+            symbol.kind,
+            symbol.file_path.display(),
+            symbol.start_line,
+            symbol
+                .doc
+                .as_deref()
+                .map(doc_excerpt)
+                .map(|doc| escape_cell(&doc))
+                .unwrap_or_default()
+        );
+    }
+    out.push('\n');
+}
+
+/// A bar `width` cells long at `peak`, drawn with eighth-cell precision.
+pub fn complexity_bar(value: f64, peak: f64, width: usize) -> String {
+    if peak <= 0.0 || value <= 0.0 {
+        return String::new();
+    }
+    let eighths = ((value / peak).min(1.0) * (width * 8) as f64).round() as usize;
+    let mut bar = "█".repeat(eighths / 8);
+    if eighths % 8 > 0 {
+        bar.push(PARTIAL_BLOCKS[eighths % 8 - 1]);
+    }
+    bar
+}
+
+/// The first sentence of a doc comment, cut to [`DOC_EXCERPT_CHARS`].
+pub fn doc_excerpt(doc: &str) -> String {
+    let paragraph = doc
+        .split("\n\n")
+        .next()
+        .unwrap_or_default()
+        .split_whitespace()
+        .collect::<Vec<_>>()
+        .join(" ");
+    let sentence = match paragraph.find(". ") {
+        Some(end) => &paragraph[..=end],
+        None => paragraph.as_str(),
+    };
+    if sentence.chars().count() <= DOC_EXCERPT_CHARS {
+        return sentence.to_string();
+    }
+    let cut: String = sentence.chars().take(DOC_EXCERPT_CHARS - 1).collect();
+    format!("{}…", cut.trim_end())
+}
+
+/// Escape text for a Markdown table cell.
+fn escape_cell(text: &str) -> String {
+    text.replace('|', "\\|")
+}
+
+/// Supported source files under `root`, sorted.
+fn source_files(root: &Path) -> Vec<PathBuf> {
+    let mut files: Vec<PathBuf> = WalkBuilder::new(root)
+        .add_custom_ignore_filename(IGNORE_FILE_NAME)
+        .build()
+        .filter_map(|entry| entry.ok().map(|entry| entry.into_path()))
+        .filter(|path| path.is_file() && FileReader::is_code_file(path))
+        .filter(|path| adapter_for_file(path).is_ok())
+        .collect();
+    files.sort();
+    files
+}
+
+/// Estimated cyclomatic complexity of each function and method in `path`.
+fn function_complexities(path: &Path) -> Vec<usize> {
+    let Ok(mut adapter) = adapter_for_file(path) else {
+        return Vec::new();
+    };
+    let source = match FileReader::read_to_string(path) {
+        Ok(source) => source,
+        Err(err) => {
+            warn!("Skipping {}: {}", path.display(), err);
+            return Vec::new();
+        }
+    };
+    match adapter.extract_code_entities(&source, &path.to_string_lossy()) {
+        Ok(entities) => entities
+            .iter()
+            .filter(|entity| matches!(entity.entity_type.as_str(), "Function" | "Method"))
+            .map(|entity| estimate_cyclomatic(&entity.source_code))
+            .collect(),
+        Err(err) => {
+            warn!("Failed to parse {}: {}", path.display(), err);
+            Vec::new()
+        }
+    }
+}
+
+/// Fold the file-level module graph into calls between package directories.
+fn package_dependencies(
+    root: &Path,
+    analysis: &ProjectDependencyAnalysis,
+) -> Vec<PackageDependency> {
+    let graph = analysis.module_graph();
+    let package_of = |index: usize| {
+        let path = &graph.nodes[index].path;
+        relative_dir(root, path.parent().unwrap_or(root))
+    };
+
+    let mut calls: BTreeMap<(String, String), usize> = BTreeMap::new();
+    for edge in &graph.edges {
+        let (from, to) = (package_of(edge.source), package_of(edge.target));
+        if from != to {
+            *calls.entry((from, to)).or_default() += edge.weight;
+        }
+    }
+    calls
+        .into_iter()
+        .map(|((from, to), calls)| PackageDependency { from, to, calls })
+        .collect()
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn package(path: &str, functions: usize, average: f64) -> PackageSummary {
+        PackageSummary {
+            path: path.to_string(),
+            files: 1,
+            symbols: Vec::new(),
+            functions,
+            average_complexity: average,
+            max_complexity: average.ceil() as usize,
+        }
+    }
+
+    #[test]
+    fn bars_scale_to_the_peak_in_eighths() {
+        assert_eq!(complexity_bar(4.0, 4.0, 4), "████");
+        assert_eq!(complexity_bar(1.0, 4.0, 4), "█");
+        assert_eq!(complexity_bar(1.25, 4.0, 4), "█▎");
+        assert_eq!(complexity_bar(0.0, 4.0, 4), "");
+    }
+
+    #[test]
+    fn doc_excerpts_keep_the_first_sentence() {
+        assert_eq!(
+            doc_excerpt("Parse a config file. Returns an error\nwhen missing."),
+            "Parse a config file."
+        );
+        assert_eq!(
+            doc_excerpt("Line one\ncontinues.\n\nDetails."),
+            "Line one continues."
+        );
+        let long = "word ".repeat(40);
+        let excerpt = doc_excerpt(&long);
+        assert!(excerpt.ends_with('…'));
+        assert_eq!(excerpt.chars().count(), DOC_EXCERPT_CHARS);
+    }
+
+    #[test]
+    fn markdown_renders_mermaid_heatmap_and_tables() {
+        let mut core = package("core", 2, 6.0);
+        core.symbols.push(TopLevelSymbol {
+            name: "Load".to_string(),
+            kind: "Function".to_string(),
+            file_path: PathBuf::from("load.go"),
+            start_line: 12,
+            end_line: 20,
+            signature: None,
+            doc: Some("Load reads a | separated file.".to_string()),
+            since_version: None,
+            reference_count: 0,
+            test_reference_count: 0,
+        });
+        let summary = ProjectSummary {
+            name: "demo".to_string(),
+            packages: vec![package(".", 1, 3.0), core, package("docs", 0, 0.0)],
+            dependencies: vec![PackageDependency {
+                from: ".".to_string(),
+                to: "core".to_string(),
+                calls: 4,
+            }],
+        };
+
+        let markdown = summary.to_markdown();
+        assert!(markdown.starts_with("# demo\n"));
+        assert!(markdown.contains(
+            "```mermaid\ngraph LR\n    p0[\".\"]\n    p1[\"core\"]\n    p0 -->|4| p1\n```"
+        ));
+        assert!(
+            !markdown.contains("p2["),
+            "unconnected packages stay out of the diagram"
+        );
+        assert!(markdown.contains("core  ████████████████████████  avg 6.0, max 6"));
+        assert!(
+            !markdown.contains("docs  "),
+            "packages without functions have no bar"
+        );
+        assert!(markdown
+            .contains("| `Load` | Function | load.go:12 | Load reads a \\| separated file. |"));
+        assert!(markdown.contains(
+            "## `docs`\n\n1 files, 0 functions, average complexity 0.0.\n\nNo exported symbols."
+        ));
+    }
+}
@@ -3,6 +3,10 @@ mod error;
 mod generator;
 mod helpers;
 mod hierarchy;
+mod junit;
+mod markdown_export;
+mod sarif;
+mod schema;
 mod templates;
 
 pub use error::ReportError;
@@ -12,3 +16,9 @@ pub use hierarchy::{
     build_unified_hierarchy_with_health, create_file_groups_from_candidates,
     create_file_groups_from_health,
 };
+pub use junit::build_junit_report;
+pub use markdown_export::{PackageDependency, PackageSummary, ProjectSummary};
+pub use sarif::{build_sarif_log, SARIF_SCHEMA, SARIF_VERSION};
+pub use schema::{
+    validate_analysis_results, SchemaViolation, ViolationKind, ANALYSIS_RESULTS_SCHEMA,
+};
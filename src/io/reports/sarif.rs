@@ -0,0 +1,436 @@
+//! SARIF 2.1.0 export.
+//!
+//! Converts analysis results into a Static Analysis Results Interchange Format
+//! log, the format consumed by GitHub code scanning and most IDE problem
+//! panes. Complexity issues, refactoring issues, structure recommendations and
+//! configured lint rule findings each become a SARIF `result` with a `ruleId`,
+//! `message` and physical location; analysis warnings are reported as tool
+//! execution notifications.
+
+use std::collections::BTreeMap;
+use std::path::Path;
+
+use serde_json::{json, Value};
+
+use crate::core::pipeline::{AnalysisResults, RefactoringCandidate};
+use crate::core::scoring::Priority;
+use crate::detectors::complexity::ComplexityAnalysisResult;
+use crate::detectors::rules::{RuleFinding, RuleSeverity};
+
+/// SARIF specification version emitted.
+pub const SARIF_VERSION: &str = "2.1.0";
+
+/// JSON schema URI for SARIF 2.1.0 logs.
+pub const SARIF_SCHEMA: &str = "https://json.schemastore.org/sarif-2.1.0.json";
+
+/// Base id that result locations are relative to.
+const SOURCE_ROOT_ID: &str = "SRCROOT";
+
+/// A rule referenced by at least one result.
+struct SarifRule {
+    description: String,
+    help: Option<String>,
+    level: &'static str,
+}
+
+/// Accumulates rules and results while walking the analysis output.
+#[derive(Default)]
+struct SarifBuilder {
+    rules: BTreeMap<String, SarifRule>,
+    results: Vec<(String, Value)>,
+}
+
+/// Collection methods for [`SarifBuilder`].
+impl SarifBuilder {
+    /// Register `rule_id` (first registration wins) and record a result for it.
+    fn push(
+        &mut self,
+        rule_id: &str,
+        rule: impl FnOnce() -> SarifRule,
+        level: &'static str,
+        message: String,
+        location: Value,
+    ) {
+        self.rules.entry(rule_id.to_string()).or_insert_with(rule);
+        self.results.push((
+            rule_id.to_string(),
+            json!({
+                "ruleId": rule_id,
+                "level": level,
+                "message": { "text": message },
+                "locations": [location],
+            }),
+        ));
+    }
+
+    /// Assemble the `tool.driver.rules` array and results with matching `ruleIndex`es.
+    fn finish(self) -> (Vec<Value>, Vec<Value>) {
+        let index: BTreeMap<&str, usize> = self
+            .rules
+            .keys()
+            .enumerate()
+            .map(|(i, id)| (id.as_str(), i))
+            .collect();
+
+        let results = self
+            .results
+            .iter()
+            .map(|(rule_id, result)| {
+                let mut result = result.clone();
+                result["ruleIndex"] = json!(index[rule_id.as_str()]);
+                result
+            })
+            .collect();
+
+        let rules = self
+            .rules
+            .iter()
+            .map(|(id, rule)| {
+                let mut descriptor = json!({
+                    "id": id,
+                    "shortDescription": { "text": rule.description },
+                    "defaultConfiguration": { "level": rule.level },
+                });
+                if let Some(help) = &rule.help {
+                    descriptor["fullDescription"] = json!({ "text": help });
+                    descriptor["help"] = json!({ "text": help });
+                }
+                descriptor
+            })
+            .collect();
+
+        (rules, results)
+    }
+}
+
+/// Build a SARIF 2.1.0 log for `results`.
+pub fn build_sarif_log(results: &AnalysisResults) -> Value {
+    let mut builder = SarifBuilder::default();
+
+    for entity in &results.passes.complexity.detailed_results {
+        add_complexity_results(&mut builder, entity);
+    }
+    for candidate in &results.refactoring_candidates {
+        add_refactoring_results(&mut builder, results, candidate);
+    }
+    add_structure_results(&mut builder, results);
+    for finding in &results.rule_findings {
+        add_rule_finding(&mut builder, finding);
+    }
+
+    let (rules, sarif_results) = builder.finish();
+    let notifications: Vec<Value> = results
+        .warnings
+        .iter()
+        .map(|warning| json!({ "level": "warning", "message": { "text": warning } }))
+        .collect();
+
+    let mut run = json!({
+        "tool": {
+            "driver": {
+                "name": "valknut",
+                "version": crate::VERSION,
+                "informationUri": "https://github.com/sibyllinesoft/valknut",
+                "rules": rules,
+            }
+        },
+        "invocations": [{
+            "executionSuccessful": true,
+            "toolExecutionNotifications": notifications,
+        }],
+        "results": sarif_results,
+    });
+    if let Some(root_uri) = source_root_uri(&results.project_root) {
+        run["originalUriBaseIds"] = json!({ SOURCE_ROOT_ID: { "uri": root_uri } });
+    }
+
+    json!({
+        "$schema": SARIF_SCHEMA,
+        "version": SARIF_VERSION,
+        "runs": [run],
+    })
+}
+
+/// Add one result per complexity issue of an entity.
+fn add_complexity_results(builder: &mut SarifBuilder, entity: &ComplexityAnalysisResult) {
+    for issue in &entity.issues {
+        let level = complexity_level(&issue.severity);
+        let message = format!(
+            "{} `{}`: {} (value {:.1}, threshold {:.1})",
+            entity.entity_type,
+            entity.entity_name,
+            issue.description,
+            issue.metric_value,
+            issue.threshold
+        );
+        builder.push(
+            &issue.issue_type,
+            || SarifRule {
+                description: humanize(&issue.issue_type),
+                help: Some(issue.recommendation.clone()).filter(|text| !text.is_empty()),
+                level,
+            },
+            level,
+            message,
+            location(
+                &entity.file_path,
+                Some((entity.start_line, entity.start_line)),
+            ),
+        );
+    }
+}
+
+/// Add one result per refactoring issue of a candidate.
+fn add_refactoring_results(
+    builder: &mut SarifBuilder,
+    results: &AnalysisResults,
+    candidate: &RefactoringCandidate,
+) {
+    let level = priority_level(candidate.priority);
+    for issue in &candidate.issues {
+        let definition = results.code_dictionary.issues.get(&issue.code);
+        let title = definition
+            .map(|def| def.title.clone())
+            .unwrap_or_else(|| humanize(&issue.category));
+        builder.push(
+            &issue.code,
+            || SarifRule {
+                description: title.clone(),
+                help: definition.map(|def| def.summary.clone()),
+                level,
+            },
+            level,
+            format!("`{}`: {}", candidate.name, title),
+            location(&candidate.file_path, candidate.line_range),
+        );
+    }
+}
+
+/// Add results for directory reorganization and file split recommendations.
+fn add_structure_results(builder: &mut SarifBuilder, results: &AnalysisResults) {
+    let structure = &results.passes.structure;
+
+    for pack in &structure.directory_recommendations {
+        let Some(dir) = pack.get("dir").and_then(Value::as_str) else {
+            continue;
+        };
+        builder.push(
+            "directory_reorganization",
+            || SarifRule {
+                description: "Directory should be reorganized".to_string(),
+                help: Some(
+                    "The directory is large or loosely connected; split it into the proposed \
+                     partitions."
+                        .to_string(),
+                ),
+                level: "note",
+            },
+            "note",
+            format!("Directory `{dir}` would benefit from being split into subdirectories"),
+            location(dir, None),
+        );
+    }
+
+    for pack in &structure.file_splitting_recommendations {
+        let Some(file) = pack.get("file").and_then(Value::as_str) else {
+            continue;
+        };
+        let reasons: Vec<&str> = pack
+            .get("reasons")
+            .and_then(Value::as_array)
+            .map(|reasons| reasons.iter().filter_map(Value::as_str).collect())
+            .unwrap_or_default();
+        let message = if reasons.is_empty() {
+            format!("File `{file}` should be split")
+        } else {
+            format!("File `{file}` should be split: {}", reasons.join("; "))
+        };
+        builder.push(
+            "file_split",
+            || SarifRule {
+                description: "File should be split".to_string(),
+                help: Some(
+                    "The file is large or has low cohesion; move related entities into the \
+                     suggested files."
+                        .to_string(),
+                ),
+                level: "warning",
+            },
+            "warning",
+            message,
+            location(file, None),
+        );
+    }
+}
+
+/// Add a result for a configured lint rule finding, keyed by the rule name.
+fn add_rule_finding(builder: &mut SarifBuilder, finding: &RuleFinding) {
+    let level = rule_level(finding.severity);
+    builder.push(
+        &finding.rule,
+        || SarifRule {
+            description: humanize(&finding.rule),
+            help: None,
+            level,
+        },
+        level,
+        finding.message.clone(),
+        location(&finding.file_path, finding.line_range),
+    );
+}
+
+/// Build a SARIF `location` for a path relative to the project root.
+fn location(path: &str, lines: Option<(usize, usize)>) -> Value {
+    let mut physical = json!({
+        "artifactLocation": {
+            "uri": relative_uri(path),
+            "uriBaseId": SOURCE_ROOT_ID,
+        }
+    });
+    if let Some((start, end)) = lines {
+        // SARIF lines are 1-based and endLine may not precede startLine.
+        let start = start.max(1);
+        physical["region"] = json!({ "startLine": start, "endLine": end.max(start) });
+    }
+    json!({ "physicalLocation": physical })
+}
+
+/// Absolute `file://` URI for the project root, when it is absolute.
+fn source_root_uri(root: &Path) -> Option<String> {
+    if !root.is_absolute() {
+        return None;
+    }
+    url::Url::from_directory_path(root)
+        .ok()
+        .map(|url| url.to_string())
+}
+
+/// Percent-encode a relative path as a URI reference with forward slashes.
+fn relative_uri(path: &str) -> String {
+    let path = path.replace('\\', "/");
+    let path = path.trim_start_matches("./");
+    let mut uri = String::with_capacity(path.len());
+    for byte in path.bytes() {
+        match byte {
+            b'A'..=b'Z' | b'a'..=b'z' | b'0'..=b'9' | b'-' | b'.' | b'_' | b'~' | b'/' => {
+                uri.push(byte as char);
+            }
+            _ => uri.push_str(&format!("%{byte:02X}")),
+        }
+    }
+    uri
+}
+
+/// SARIF level for a complexity severity label.
+pub(super) fn complexity_level(severity: &str) -> &'static str {
+    match severity.to_ascii_lowercase().as_str() {
+        "critical" | "veryhigh" | "very_high" => "error",
+        "high" | "medium" => "warning",
+        _ => "note",
+    }
+}
+
+/// SARIF level for a refactoring priority.
+pub(super) fn priority_level(priority: Priority) -> &'static str {
+    match priority {
+        Priority::Critical => "error",
+        Priority::High | Priority::Medium => "warning",
+        Priority::Low | Priority::None => "note",
+    }
+}
+
+/// SARIF level for a lint rule severity.
+pub(super) fn rule_level(severity: RuleSeverity) -> &'static str {
+    match severity {
+        RuleSeverity::Error => "error",
+        RuleSeverity::Warning => "warning",
+        RuleSeverity::Info => "note",
+    }
+}
+
+/// Turn an identifier like `high_cyclomatic_complexity` into `High cyclomatic complexity`.
+fn humanize(identifier: &str) -> String {
+    let text = identifier.replace(['_', '-'], " ");
+    let mut chars = text.chars();
+    match chars.next() {
+        Some(first) => first.to_uppercase().chain(chars).collect(),
+        None => String::new(),
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn relative_uri_uses_forward_slashes_and_escapes() {
+        assert_eq!(relative_uri("src\\my file.rs"), "src/my%20file.rs");
+        assert_eq!(relative_uri("./lib/a#b.py"), "lib/a%23b.py");
+    }
+
+    #[test]
+    fn location_clamps_region_to_valid_lines() {
+        let loc = location("src/lib.rs", Some((0, 0)));
+        let region = &loc["physicalLocation"]["region"];
+        assert_eq!(region["startLine"], 1);
+        assert_eq!(region["endLine"], 1);
+        assert!(location("src", None)["physicalLocation"]
+            .get("region")
+            .is_none());
+    }
+
+    #[test]
+    fn builder_assigns_rule_indexes_in_rule_order() {
+        let mut builder = SarifBuilder::default();
+        for rule_id in ["zeta", "alpha", "zeta"] {
+            builder.push(
+                rule_id,
+                || SarifRule {
+                    description: humanize(rule_id),
+                    help: None,
+                    level: "note",
+                },
+                "note",
+                "message".to_string(),
+                location("a.py", Some((3, 4))),
+            );
+        }
+
+        let (rules, results) = builder.finish();
+        assert_eq!(rules.len(), 2);
+        assert_eq!(rules[0]["id"], "alpha");
+        let indexes: Vec<_> = results.iter().map(|r| r["ruleIndex"].clone()).collect();
+        assert_eq!(indexes, vec![json!(1), json!(0), json!(1)]);
+    }
+
+    #[test]
+    fn rule_findings_map_severity_to_level() {
+        let mut results = AnalysisResults::empty();
+        results.rule_findings.push(RuleFinding {
+            rule: "short-functions".to_string(),
+            severity: RuleSeverity::Info,
+            message: "function `run` is 80 lines long (max 50)".to_string(),
+            file_path: "src/main.py".to_string(),
+            entity: Some("run".to_string()),
+            line_range: Some((10, 89)),
+        });
+
+        let log = build_sarif_log(&results);
+        let result = &log["runs"][0]["results"][0];
+        assert_eq!(result["ruleId"], "short-functions");
+        assert_eq!(result["level"], "note");
+        assert_eq!(
+            result["locations"][0]["physicalLocation"]["region"]["endLine"],
+            89
+        );
+    }
+
+    #[test]
+    fn humanize_capitalizes_identifiers() {
+        assert_eq!(
+            humanize("high_cyclomatic_complexity"),
+            "High cyclomatic complexity"
+        );
+        assert_eq!(humanize(""), "");
+    }
+}
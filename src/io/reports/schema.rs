@@ -0,0 +1,280 @@
+//! JSON Schema of the analysis output.
+//!
+//! [`ANALYSIS_RESULTS_SCHEMA`] describes the document written by
+//! `--format json`/`jsonl` and is compiled into the binary, so
+//! `valknut validate` can check a previously generated file against the
+//! schema of the running version. Only the subset of JSON Schema the embedded
+//! schema uses is evaluated: `type`, `enum`, `required`, `properties`,
+//! `items`, `additionalProperties` and local `$ref`s. Unknown fields are
+//! accepted, so output from a newer version still validates as long as no
+//! field was removed or changed type.
+
+use std::fmt;
+
+use serde_json::{Map, Value};
+
+/// The embedded analysis results schema.
+pub const ANALYSIS_RESULTS_SCHEMA: &str = include_str!("analysis_results.schema.json");
+
+/// Key under which `--oracle` nests the analysis results.
+const ORACLE_RESULTS_KEY: &str = "analysis_results";
+
+/// One way a document differs from the schema.
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct SchemaViolation {
+    /// Location in the document, e.g. `refactoring_candidates[2].priority`.
+    pub path: String,
+    /// What is wrong at `path`.
+    pub kind: ViolationKind,
+}
+
+/// Kinds of [`SchemaViolation`].
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub enum ViolationKind {
+    /// A required field is absent.
+    Missing,
+    /// A field has a different JSON type than the schema declares.
+    TypeChanged {
+        /// Types allowed by the schema, e.g. `integer` or `array | null`.
+        expected: String,
+        /// Type found in the document.
+        found: &'static str,
+    },
+    /// A value is not one of the schema's `enum` values.
+    UnexpectedValue {
+        /// The value found in the document.
+        found: String,
+    },
+}
+
+/// Renders violations as `path: problem`.
+impl fmt::Display for SchemaViolation {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        let path = if self.path.is_empty() {
+            "<root>"
+        } else {
+            &self.path
+        };
+        match &self.kind {
+            ViolationKind::Missing => write!(f, "{path}: missing required field"),
+            ViolationKind::TypeChanged { expected, found } => {
+                write!(f, "{path}: expected {expected}, found {found}")
+            }
+            ViolationKind::UnexpectedValue { found } => {
+                write!(f, "{path}: unexpected value {found}")
+            }
+        }
+    }
+}
+
+/// Check an analysis output document against the embedded schema.
+///
+/// Documents written with `--oracle`, which nest the results under
+/// `analysis_results`, are checked at that key.
+pub fn validate_analysis_results(document: &Value) -> Vec<SchemaViolation> {
+    let schema: Value =
+        serde_json::from_str(ANALYSIS_RESULTS_SCHEMA).expect("embedded schema is valid JSON");
+    let (document, path) = match document.get(ORACLE_RESULTS_KEY) {
+        Some(nested) if document.get("summary").is_none() => (nested, ORACLE_RESULTS_KEY),
+        _ => (document, ""),
+    };
+    let mut violations = Vec::new();
+    Validator { root: &schema }.check(&schema, document, path, &mut violations);
+    violations
+}
+
+/// Evaluates schema nodes, resolving `$ref`s against the root schema.
+struct Validator<'a> {
+    root: &'a Value,
+}
+
+/// Keyword evaluation for [`Validator`].
+impl<'a> Validator<'a> {
+    /// Append every violation of `schema` by `value` at `path`.
+    fn check(
+        &self,
+        schema: &'a Value,
+        value: &Value,
+        path: &str,
+        violations: &mut Vec<SchemaViolation>,
+    ) {
+        let schema = self.resolve(schema);
+
+        if let Some(expected) = schema.get("type") {
+            let allowed: Vec<&str> = match expected {
+                Value::String(name) => vec![name.as_str()],
+                Value::Array(names) => names.iter().filter_map(Value::as_str).collect(),
+                _ => Vec::new(),
+            };
+            if !allowed.is_empty() && !allowed.iter().any(|name| has_type(value, name)) {
+                violations.push(SchemaViolation {
+                    path: path.to_string(),
+                    kind: ViolationKind::TypeChanged {
+                        expected: allowed.join(" | "),
+                        found: type_name(value),
+                    },
+                });
+                return;
+            }
+        }
+
+        if let Some(Value::Array(allowed)) = schema.get("enum") {
+            if !allowed.contains(value) {
+                violations.push(SchemaViolation {
+                    path: path.to_string(),
+                    kind: ViolationKind::UnexpectedValue {
+                        found: value.to_string(),
+                    },
+                });
+            }
+        }
+
+        match value {
+            Value::Object(object) => self.check_object(schema, object, path, violations),
+            Value::Array(items) => {
+                if let Some(item_schema) = schema.get("items") {
+                    for (index, item) in items.iter().enumerate() {
+                        self.check(item_schema, item, &format!("{path}[{index}]"), violations);
+                    }
+                }
+            }
+            _ => {}
+        }
+    }
+
+    /// Apply `required`, `properties` and `additionalProperties` to an object.
+    fn check_object(
+        &self,
+        schema: &'a Value,
+        object: &Map<String, Value>,
+        path: &str,
+        violations: &mut Vec<SchemaViolation>,
+    ) {
+        if let Some(Value::Array(required)) = schema.get("required") {
+            for field in required.iter().filter_map(Value::as_str) {
+                if !object.contains_key(field) {
+                    violations.push(SchemaViolation {
+                        path: join(path, field),
+                        kind: ViolationKind::Missing,
+                    });
+                }
+            }
+        }
+
+        let properties = schema.get("properties").and_then(Value::as_object);
+        let additional = schema
+            .get("additionalProperties")
+            .filter(|additional| additional.is_object());
+        for (field, field_value) in object {
+            let field_schema = properties
+                .and_then(|properties| properties.get(field))
+                .or(additional);
+            if let Some(field_schema) = field_schema {
+                self.check(field_schema, field_value, &join(path, field), violations);
+            }
+        }
+    }
+
+    /// Follow a local `$ref` (`#/$defs/name`) to its target.
+    fn resolve(&self, schema: &'a Value) -> &'a Value {
+        let mut schema = schema;
+        while let Some(pointer) = schema
+            .get("$ref")
+            .and_then(Value::as_str)
+            .and_then(|reference| reference.strip_prefix('#'))
+        {
+            match self.root.pointer(pointer) {
+                Some(target) => schema = target,
+                None => break,
+            }
+        }
+        schema
+    }
+}
+
+/// Whether `value` is of the JSON Schema type `name`.
+fn has_type(value: &Value, name: &str) -> bool {
+    match name {
+        "integer" => value.is_i64() || value.is_u64(),
+        "number" => value.is_number(),
+        _ => type_name(value) == name,
+    }
+}
+
+/// JSON Schema type name of `value`.
+fn type_name(value: &Value) -> &'static str {
+    match value {
+        Value::Null => "null",
+        Value::Bool(_) => "boolean",
+        Value::Number(number) if number.is_f64() => "number",
+        Value::Number(_) => "integer",
+        Value::String(_) => "string",
+        Value::Array(_) => "array",
+        Value::Object(_) => "object",
+    }
+}
+
+/// `path.field`, or `field` at the root.
+fn join(path: &str, field: &str) -> String {
+    if path.is_empty() {
+        field.to_string()
+    } else {
+        format!("{path}.{field}")
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::core::pipeline::AnalysisResults;
+    use serde_json::json;
+
+    fn empty_results() -> Value {
+        serde_json::to_value(AnalysisResults::empty()).unwrap()
+    }
+
+    #[test]
+    fn current_output_matches_the_embedded_schema() {
+        let violations = validate_analysis_results(&empty_results());
+        assert!(violations.is_empty(), "{violations:?}");
+    }
+
+    #[test]
+    fn missing_and_retyped_fields_are_reported() {
+        let mut document = empty_results();
+        document["summary"]
+            .as_object_mut()
+            .unwrap()
+            .remove("files_processed");
+        document["summary"]["languages"] = json!("go");
+        document["refactoring_candidates"] = json!([{ "priority": "Urgent" }]);
+
+        let violations = validate_analysis_results(&document);
+        let rendered: Vec<String> = violations.iter().map(ToString::to_string).collect();
+        assert!(rendered.contains(&"summary.files_processed: missing required field".to_string()));
+        assert!(rendered.contains(&"summary.languages: expected array, found string".to_string()));
+        assert!(rendered.contains(
+            &"refactoring_candidates[0].priority: unexpected value \"Urgent\"".to_string()
+        ));
+        assert!(rendered
+            .contains(&"refactoring_candidates[0].entity_id: missing required field".to_string()));
+    }
+
+    #[test]
+    fn oracle_output_is_checked_at_the_nested_results() {
+        let document = json!({ "analysis_results": { "summary": {} } });
+        let violations = validate_analysis_results(&document);
+        assert!(violations.contains(&SchemaViolation {
+            path: "analysis_results.passes".to_string(),
+            kind: ViolationKind::Missing,
+        }));
+    }
+
+    #[test]
+    fn unknown_fields_and_whole_floats_are_accepted() {
+        let mut document = empty_results();
+        document["added_in_a_later_version"] = json!(true);
+        document["summary"]["code_health_score"] = json!(1);
+        assert!(validate_analysis_results(&document).is_empty());
+    }
+}
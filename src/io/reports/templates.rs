@@ -3,6 +3,7 @@ use std::fs;
 use std::path::{Path, PathBuf};
 
 use handlebars::Handlebars;
+use tracing::warn;
 
 use super::error::ReportError;
 
@@ -15,26 +16,26 @@ pub(super) fn register_fallback_template(handlebars: &mut Handlebars<'static>) {
     if let Err(err) = handlebars
         .register_template_string(FALLBACK_TEMPLATE_NAME, include_str!("./default_report.hbs"))
     {
-        eprintln!("Failed to register fallback HTML template: {}", err);
+        warn!("Failed to register fallback HTML template: {}", err);
     }
 
     if let Err(err) = handlebars.register_template_string(
         MARKDOWN_TEMPLATE_NAME,
         include_str!("./default_markdown.hbs"),
     ) {
-        eprintln!("Failed to register fallback Markdown template: {}", err);
+        warn!("Failed to register fallback Markdown template: {}", err);
     }
 
     if let Err(err) =
         handlebars.register_template_string(CSV_TEMPLATE_NAME, include_str!("./default_csv.hbs"))
     {
-        eprintln!("Failed to register fallback CSV template: {}", err);
+        warn!("Failed to register fallback CSV template: {}", err);
     }
 
     if let Err(err) = handlebars
         .register_template_string(SONAR_TEMPLATE_NAME, include_str!("./default_sonar.hbs"))
     {
-        eprintln!("Failed to register fallback Sonar template: {}", err);
+        warn!("Failed to register fallback Sonar template: {}", err);
     }
 }
 
@@ -0,0 +1,328 @@
+//! Versioned archive of analysis snapshots (`valknut archive`).
+//!
+//! [`ArchiveStore`] keeps one gzip-compressed `<tag>.json.gz` per tag in a
+//! directory (`~/.valknut/archives` by default), next to an `index.v1.json`
+//! that records when each snapshot was archived, its compressed size and how
+//! many files it covers, so listing never decompresses an archive. Snapshots
+//! are the JSON written by `valknut analyze --format json`; `valknut diff`
+//! accepts `archive:<tag>` in place of a file to compare them.
+
+use std::fs::{self, File};
+use std::io::{BufReader, BufWriter, Write};
+use std::path::{Path, PathBuf};
+
+use chrono::{DateTime, Utc};
+use flate2::read::GzDecoder;
+use flate2::write::GzEncoder;
+use flate2::Compression;
+use serde::{Deserialize, Serialize};
+use serde_json::Value;
+
+use crate::core::errors::{Result, ValknutError, ValknutResultExt};
+
+/// Prefix that makes a `valknut diff` argument name an archived tag.
+pub const ARCHIVE_REF_PREFIX: &str = "archive:";
+
+/// Current on-disk format version of the archive index.
+const INDEX_VERSION: u32 = 1;
+
+/// File name of the archive index inside the archive directory.
+const INDEX_FILE_NAME: &str = "index.v1.json";
+
+/// File name suffix of an archived snapshot.
+const ARCHIVE_SUFFIX: &str = ".json.gz";
+
+/// One archived snapshot.
+#[derive(Debug, Clone, PartialEq, Serialize, Deserialize)]
+pub struct ArchiveEntry {
+    /// Tag the snapshot was archived under
+    pub tag: String,
+    /// When the snapshot was archived
+    pub archived_at: DateTime<Utc>,
+    /// Compressed size in bytes
+    pub size_bytes: u64,
+    /// Number of files the analysis covered
+    pub file_count: usize,
+}
+
+/// Serialized form of the archive index.
+#[derive(Debug, Clone, Default, Serialize, Deserialize)]
+struct ArchiveIndex {
+    /// Index format version
+    version: u32,
+    /// Entries in the order they were archived
+    entries: Vec<ArchiveEntry>,
+}
+
+/// Directory of tagged, compressed analysis snapshots.
+#[derive(Debug, Clone)]
+pub struct ArchiveStore {
+    /// Directory holding the archives and their index
+    dir: PathBuf,
+}
+
+/// Archiving, listing, loading and pruning methods for [`ArchiveStore`].
+impl ArchiveStore {
+    /// Default archive directory (`~/.valknut/archives`).
+    pub fn default_dir() -> Option<PathBuf> {
+        dirs::home_dir().map(|home| home.join(".valknut").join("archives"))
+    }
+
+    /// Use the archives in `dir`; nothing is created until the first save.
+    pub fn open<P: AsRef<Path>>(dir: P) -> Self {
+        Self {
+            dir: dir.as_ref().to_path_buf(),
+        }
+    }
+
+    /// Path of the archive file for `tag`.
+    pub fn path_for(&self, tag: &str) -> PathBuf {
+        self.dir.join(format!("{tag}{ARCHIVE_SUFFIX}"))
+    }
+
+    /// Compress `snapshot` into the archive for `tag`.
+    ///
+    /// An existing archive with the same tag is only replaced when `overwrite`
+    /// is set.
+    pub fn save(&self, tag: &str, snapshot: &Value, overwrite: bool) -> Result<ArchiveEntry> {
+        validate_tag(tag)?;
+        let mut index = self.load_index()?;
+        let existing = index.entries.iter().position(|entry| entry.tag == tag);
+        if existing.is_some() && !overwrite {
+            return Err(ValknutError::validation(format!(
+                "Archive '{tag}' already exists; pass --force to replace it"
+            )));
+        }
+
+        fs::create_dir_all(&self.dir).map_err(ValknutError::map_io(format!(
+            "Failed to create archive directory: {}",
+            self.dir.display()
+        )))?;
+        let path = self.path_for(tag);
+        let temp_path = path.with_extension(format!("{}.tmp", uuid::Uuid::new_v4()));
+        let file = File::create(&temp_path).map_err(ValknutError::map_io(format!(
+            "Failed to write archive: {}",
+            temp_path.display()
+        )))?;
+        let mut encoder = GzEncoder::new(BufWriter::new(file), Compression::default());
+        serde_json::to_writer(&mut encoder, snapshot).map_json_err("archived snapshot")?;
+        encoder
+            .finish()
+            .and_then(|mut writer| writer.flush())
+            .map_err(ValknutError::map_io(format!(
+                "Failed to write archive: {}",
+                temp_path.display()
+            )))?;
+        fs::rename(&temp_path, &path).map_err(ValknutError::map_io(format!(
+            "Failed to rename archive: {}",
+            path.display()
+        )))?;
+
+        let entry = ArchiveEntry {
+            tag: tag.to_string(),
+            archived_at: Utc::now(),
+            size_bytes: fs::metadata(&path).map_or(0, |meta| meta.len()),
+            file_count: file_count(snapshot),
+        };
+        if let Some(position) = existing {
+            index.entries.remove(position);
+        }
+        index.entries.push(entry.clone());
+        self.save_index(&index)?;
+        Ok(entry)
+    }
+
+    /// Archived snapshots from oldest to newest, skipping any whose file was
+    /// deleted.
+    pub fn list(&self) -> Result<Vec<ArchiveEntry>> {
+        let mut entries: Vec<ArchiveEntry> = self
+            .load_index()?
+            .entries
+            .into_iter()
+            .filter(|entry| self.path_for(&entry.tag).exists())
+            .collect();
+        entries.sort_by(|a, b| a.archived_at.cmp(&b.archived_at));
+        Ok(entries)
+    }
+
+    /// Decompress the snapshot archived under `tag`.
+    pub fn load(&self, tag: &str) -> Result<Value> {
+        validate_tag(tag)?;
+        let path = self.path_for(tag);
+        if !path.exists() {
+            return Err(ValknutError::validation(format!(
+                "No archive tagged '{tag}' in {}",
+                self.dir.display()
+            )));
+        }
+        let file = File::open(&path).map_err(ValknutError::map_io(format!(
+            "Failed to read archive: {}",
+            path.display()
+        )))?;
+        serde_json::from_reader(GzDecoder::new(BufReader::new(file))).map_err(
+            ValknutError::map_json_parse(format!("in {}", path.display())),
+        )
+    }
+
+    /// Delete all but the `keep_last` most recently archived snapshots and
+    /// return the removed entries, oldest first.
+    pub fn prune(&self, keep_last: usize) -> Result<Vec<ArchiveEntry>> {
+        let entries = self.list()?;
+        let split = entries.len().saturating_sub(keep_last);
+        let (removed, kept) = entries.split_at(split);
+        if removed.is_empty() {
+            return Ok(Vec::new());
+        }
+
+        for entry in removed {
+            let path = self.path_for(&entry.tag);
+            fs::remove_file(&path).map_err(ValknutError::map_io(format!(
+                "Failed to delete archive: {}",
+                path.display()
+            )))?;
+        }
+        self.save_index(&ArchiveIndex {
+            version: INDEX_VERSION,
+            entries: kept.to_vec(),
+        })?;
+        Ok(removed.to_vec())
+    }
+
+    /// Read the index, starting empty when it does not exist yet.
+    fn load_index(&self) -> Result<ArchiveIndex> {
+        let index_path = self.dir.join(INDEX_FILE_NAME);
+        if !index_path.exists() {
+            return Ok(ArchiveIndex {
+                version: INDEX_VERSION,
+                entries: Vec::new(),
+            });
+        }
+        let content = fs::read_to_string(&index_path).map_err(ValknutError::map_io(format!(
+            "Failed to read archive index: {}",
+            index_path.display()
+        )))?;
+        let index: ArchiveIndex = serde_json::from_str(&content).map_json_err("archive index")?;
+        if index.version != INDEX_VERSION {
+            return Err(ValknutError::validation(format!(
+                "Unsupported archive index version {} in {}",
+                index.version,
+                index_path.display()
+            )));
+        }
+        Ok(index)
+    }
+
+    /// Persist the index atomically.
+    fn save_index(&self, index: &ArchiveIndex) -> Result<()> {
+        let index_path = self.dir.join(INDEX_FILE_NAME);
+        let temp_path = index_path.with_extension(format!("{}.tmp", uuid::Uuid::new_v4()));
+        let content = serde_json::to_string_pretty(index).map_json_err("archive index")?;
+        fs::write(&temp_path, content).map_err(ValknutError::map_io(format!(
+            "Failed to write archive index: {}",
+            temp_path.display()
+        )))?;
+        fs::rename(&temp_path, &index_path).map_err(ValknutError::map_io(format!(
+            "Failed to rename archive index: {}",
+            index_path.display()
+        )))
+    }
+}
+
+/// The tag named by an `archive:<tag>` reference, if `reference` is one.
+pub fn parse_archive_ref(reference: &str) -> Option<&str> {
+    reference.strip_prefix(ARCHIVE_REF_PREFIX)
+}
+
+/// Reject tags that are empty, hidden, or could escape the archive directory.
+fn validate_tag(tag: &str) -> Result<()> {
+    let valid = !tag.is_empty()
+        && !tag.starts_with('.')
+        && tag
+            .chars()
+            .all(|c| c.is_ascii_alphanumeric() || matches!(c, '.' | '_' | '-' | '+'));
+    if valid {
+        Ok(())
+    } else {
+        Err(ValknutError::validation(format!(
+            "Invalid archive tag '{tag}': use letters, digits, '.', '_', '-' or '+', not starting with '.'"
+        )))
+    }
+}
+
+/// Files covered by a snapshot: `summary.files_processed`, unwrapping the
+/// `analysis_results` wrapper written with an oracle plan.
+fn file_count(snapshot: &Value) -> usize {
+    let results = snapshot.get("analysis_results").unwrap_or(snapshot);
+    results
+        .pointer("/summary/files_processed")
+        .and_then(Value::as_u64)
+        .map_or(0, |count| count as usize)
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use serde_json::json;
+    use tempfile::TempDir;
+
+    fn snapshot(files: usize) -> Value {
+        json!({
+            "summary": { "files_processed": files },
+            "passes": { "complexity": { "detailed_results": [] } },
+        })
+    }
+
+    #[test]
+    fn saved_snapshots_round_trip_and_are_listed() {
+        let dir = TempDir::new().unwrap();
+        let store = ArchiveStore::open(dir.path().join("archives"));
+
+        let entry = store.save("v1.2.3", &snapshot(12), false).unwrap();
+        assert_eq!(entry.file_count, 12);
+        assert!(entry.size_bytes > 0);
+        assert!(store.path_for("v1.2.3").ends_with("v1.2.3.json.gz"));
+        assert_eq!(store.load("v1.2.3").unwrap(), snapshot(12));
+
+        store.save("v1.2.4", &snapshot(13), false).unwrap();
+        let tags: Vec<_> = store.list().unwrap().into_iter().map(|e| e.tag).collect();
+        assert_eq!(tags, vec!["v1.2.3", "v1.2.4"]);
+    }
+
+    #[test]
+    fn existing_tags_need_overwrite() {
+        let dir = TempDir::new().unwrap();
+        let store = ArchiveStore::open(dir.path());
+
+        store.save("nightly", &snapshot(1), false).unwrap();
+        assert!(store.save("nightly", &snapshot(2), false).is_err());
+        store.save("nightly", &snapshot(2), true).unwrap();
+
+        let entries = store.list().unwrap();
+        assert_eq!(entries.len(), 1);
+        assert_eq!(entries[0].file_count, 2);
+        assert!(store.save("../escape", &snapshot(1), false).is_err());
+        assert!(store.load("missing").is_err());
+    }
+
+    #[test]
+    fn prune_keeps_the_most_recent_archives() {
+        let dir = TempDir::new().unwrap();
+        let store = ArchiveStore::open(dir.path());
+        for tag in ["a", "b", "c"] {
+            store.save(tag, &snapshot(1), false).unwrap();
+        }
+
+        let removed: Vec<_> = store.prune(1).unwrap().into_iter().map(|e| e.tag).collect();
+        assert_eq!(removed, vec!["a", "b"]);
+        assert!(!store.path_for("a").exists());
+        let kept: Vec<_> = store.list().unwrap().into_iter().map(|e| e.tag).collect();
+        assert_eq!(kept, vec!["c"]);
+        assert!(store.prune(5).unwrap().is_empty());
+    }
+
+    #[test]
+    fn archive_references_name_tags() {
+        assert_eq!(parse_archive_ref("archive:v1.2.3"), Some("v1.2.3"));
+        assert_eq!(parse_archive_ref("before.json"), None);
+    }
+}
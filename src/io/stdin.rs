@@ -0,0 +1,209 @@
+//! Analysis of source code piped through stdin.
+//!
+//! The pipeline works on files, so [`StdinSource`] stages the piped source in a
+//! private temporary directory under its virtual path (`--stdin-path`) and
+//! removes it on drop. Because the staged tree contains only that file, result
+//! paths are exactly the virtual path the caller supplied.
+//!
+//! Without a virtual path the language is inferred from a `#!` line, falling
+//! back to Go.
+
+use std::io::Read;
+use std::path::{Component, Path, PathBuf};
+
+use tracing::warn;
+
+use crate::core::errors::{Result, ValknutError};
+use crate::lang::registry::language_key_for_path;
+
+/// Virtual file name used when no `--stdin-path` is given.
+const DEFAULT_STEM: &str = "stdin";
+
+/// Extension assumed when neither a path nor a shebang identifies the language.
+const DEFAULT_EXTENSION: &str = "go";
+
+/// Source read from stdin, staged on disk for analysis.
+#[derive(Debug)]
+pub struct StdinSource {
+    /// Path the source is reported under, relative to [`root`](Self::root).
+    pub virtual_path: PathBuf,
+    /// Temporary directory holding the staged file; removed on drop.
+    root: PathBuf,
+}
+
+/// Staging and accessor methods for [`StdinSource`].
+impl StdinSource {
+    /// Read all of `reader` and stage it under `stdin_path` (or an inferred name).
+    pub fn read(mut reader: impl Read, stdin_path: Option<&Path>) -> Result<Self> {
+        let mut source = String::new();
+        reader
+            .read_to_string(&mut source)
+            .map_err(|e| ValknutError::io("Failed to read source from stdin", e))?;
+        Self::from_source(&source, stdin_path)
+    }
+
+    /// Stage `source` under `stdin_path` (or an inferred name).
+    pub fn from_source(source: &str, stdin_path: Option<&Path>) -> Result<Self> {
+        let virtual_path = match stdin_path {
+            Some(path) => normalize_virtual_path(path)?,
+            None => infer_virtual_path(source),
+        };
+        if language_key_for_path(&virtual_path).is_none() {
+            return Err(ValknutError::unsupported(format!(
+                "Cannot determine a supported language for --stdin-path {}",
+                virtual_path.display()
+            )));
+        }
+
+        let root = std::env::temp_dir().join(format!("valknut-stdin-{}", uuid::Uuid::new_v4()));
+        // Construct first so the directory is cleaned up if writing fails.
+        let staged = Self { virtual_path, root };
+        let file = staged.file();
+        if let Some(parent) = file.parent() {
+            std::fs::create_dir_all(parent).map_err(|e| {
+                ValknutError::io(format!("Failed to create {}", parent.display()), e)
+            })?;
+        }
+        std::fs::write(&file, source)
+            .map_err(|e| ValknutError::io(format!("Failed to write {}", file.display()), e))?;
+        Ok(staged)
+    }
+
+    /// Directory to analyze.
+    pub fn root(&self) -> &Path {
+        &self.root
+    }
+
+    /// Location of the staged file on disk.
+    pub fn file(&self) -> PathBuf {
+        self.root.join(&self.virtual_path)
+    }
+}
+
+/// Removes the staging directory.
+impl Drop for StdinSource {
+    fn drop(&mut self) {
+        if let Err(e) = std::fs::remove_dir_all(&self.root) {
+            if e.kind() != std::io::ErrorKind::NotFound {
+                warn!("Failed to remove {}: {}", self.root.display(), e);
+            }
+        }
+    }
+}
+
+/// Make `path` relative so it stays inside the staging directory.
+///
+/// Absolute paths under the working directory become relative to it; other
+/// absolute paths keep only their file name.
+fn normalize_virtual_path(path: &Path) -> Result<PathBuf> {
+    let relative = if path.is_absolute() {
+        std::env::current_dir()
+            .ok()
+            .and_then(|cwd| path.strip_prefix(cwd).ok().map(Path::to_path_buf))
+            .or_else(|| path.file_name().map(PathBuf::from))
+            .unwrap_or_default()
+    } else {
+        path.to_path_buf()
+    };
+
+    let mut normalized = PathBuf::new();
+    for component in relative.components() {
+        match component {
+            Component::Normal(part) => normalized.push(part),
+            Component::CurDir => {}
+            _ => {
+                return Err(ValknutError::validation(format!(
+                    "--stdin-path must not leave the project: {}",
+                    path.display()
+                )))
+            }
+        }
+    }
+    if normalized.as_os_str().is_empty() {
+        return Err(ValknutError::validation("--stdin-path must name a file"));
+    }
+    Ok(normalized)
+}
+
+/// Virtual path for source without `--stdin-path`: `stdin.<ext>`.
+pub fn infer_virtual_path(source: &str) -> PathBuf {
+    let extension = source
+        .lines()
+        .next()
+        .and_then(shebang_extension)
+        .unwrap_or(DEFAULT_EXTENSION);
+    PathBuf::from(format!("{DEFAULT_STEM}.{extension}"))
+}
+
+/// File extension implied by a `#!` interpreter line.
+fn shebang_extension(first_line: &str) -> Option<&'static str> {
+    let mut words = first_line.strip_prefix("#!")?.split_whitespace();
+    let mut interpreter = basename(words.next()?);
+    if interpreter == "env" {
+        // `#!/usr/bin/env -S python3 -u`: skip env's own flags.
+        interpreter = basename(words.find(|word| !word.starts_with('-'))?);
+    }
+
+    match interpreter {
+        name if name.starts_with("python") => Some("py"),
+        "node" | "nodejs" | "deno" | "bun" => Some("js"),
+        "ts-node" | "tsx" => Some("ts"),
+        "go" | "gorun" => Some("go"),
+        "rust-script" => Some("rs"),
+        _ => None,
+    }
+}
+
+/// Final path segment of an interpreter path.
+fn basename(word: &str) -> &str {
+    word.rsplit('/').next().unwrap_or(word)
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn stages_source_under_the_virtual_path() {
+        let staged = StdinSource::read(
+            "package main\n\nfunc main() {}\n".as_bytes(),
+            Some(Path::new("./cmd/tool/main.go")),
+        )
+        .unwrap();
+        assert_eq!(staged.virtual_path, PathBuf::from("cmd/tool/main.go"));
+        assert_eq!(
+            std::fs::read_to_string(staged.file()).unwrap(),
+            "package main\n\nfunc main() {}\n"
+        );
+
+        let root = staged.root().to_path_buf();
+        drop(staged);
+        assert!(!root.exists());
+    }
+
+    #[test]
+    fn rejects_paths_outside_the_project_and_unknown_languages() {
+        assert!(StdinSource::from_source("", Some(Path::new("../main.go"))).is_err());
+        assert!(StdinSource::from_source("", Some(Path::new("notes.unknown"))).is_err());
+    }
+
+    #[test]
+    fn infers_language_from_shebang_or_defaults_to_go() {
+        assert_eq!(
+            infer_virtual_path("#!/usr/bin/env -S python3 -u\nprint(1)\n"),
+            PathBuf::from("stdin.py")
+        );
+        assert_eq!(
+            infer_virtual_path("#!/usr/local/bin/node\n"),
+            PathBuf::from("stdin.js")
+        );
+        assert_eq!(
+            infer_virtual_path("#!/bin/sh\necho hi\n"),
+            PathBuf::from("stdin.go")
+        );
+        assert_eq!(
+            infer_virtual_path("package main\n"),
+            PathBuf::from("stdin.go")
+        );
+    }
+}
@@ -0,0 +1,559 @@
+//! Filesystem change detection for watch mode.
+//!
+//! [`EventWatcher`] turns the operating system's filesystem notifications into
+//! [`FileChange`]s, so the tree is walked only once, at startup. Where
+//! notifications are unavailable (some network filesystems, exhausted inotify
+//! watches) [`FileWatcher`] falls back to [`PollingWatcher`], which snapshots
+//! the modification time and size of every supported source file under a root
+//! and reports what changed between polls. Both honour `.gitignore` and
+//! `.valknutignore`, so ignored trees never trigger re-analysis. [`Debouncer`]
+//! coalesces bursts of changes (editors often write a file several times per
+//! save) into a single batch once the tree is quiet.
+
+use std::collections::{BTreeMap, HashMap, HashSet};
+use std::path::{Path, PathBuf};
+use std::sync::mpsc;
+use std::time::{Duration, Instant, SystemTime};
+
+use ignore::gitignore::{Gitignore, GitignoreBuilder};
+use ignore::{Match, WalkBuilder};
+use notify::{Event, EventKind, RecommendedWatcher, RecursiveMode, Watcher};
+use serde::{Deserialize, Serialize};
+use tracing::warn;
+
+use crate::core::errors::{Result, ValknutError};
+use crate::core::file_utils::FileReader;
+use crate::core::pipeline::discovery::IGNORE_FILE_NAME;
+
+/// Kind of change observed for a file.
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Serialize, Deserialize)]
+#[serde(rename_all = "lowercase")]
+pub enum FileChangeKind {
+    /// The file was created or modified.
+    Update,
+    /// The file was removed.
+    Delete,
+}
+
+/// A single file change.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct FileChange {
+    /// Absolute path of the changed file.
+    pub path: PathBuf,
+    /// What happened to the file.
+    pub kind: FileChangeKind,
+}
+
+/// Fingerprint used to decide whether a file changed between polls.
+type FileStamp = (Option<SystemTime>, u64);
+
+/// Snapshot-based watcher over the supported source files beneath a root.
+#[derive(Debug)]
+pub struct PollingWatcher {
+    /// Directory being watched.
+    root: PathBuf,
+    /// Last observed fingerprint per file.
+    snapshot: HashMap<PathBuf, FileStamp>,
+}
+
+/// Construction and polling methods for [`PollingWatcher`].
+impl PollingWatcher {
+    /// Create a watcher and take the initial snapshot of `root`.
+    pub fn new(root: impl Into<PathBuf>) -> Self {
+        let root = root.into();
+        let snapshot = scan(&root);
+        Self { root, snapshot }
+    }
+
+    /// Directory being watched.
+    pub fn root(&self) -> &Path {
+        &self.root
+    }
+
+    /// Number of files currently tracked.
+    pub fn tracked_files(&self) -> usize {
+        self.snapshot.len()
+    }
+
+    /// Rescan the tree and return the changes since the previous poll, sorted by path.
+    pub fn poll(&mut self) -> Vec<FileChange> {
+        let current = scan(&self.root);
+        let mut changes = Vec::new();
+
+        for (path, stamp) in &current {
+            if self.snapshot.get(path) != Some(stamp) {
+                changes.push(FileChange {
+                    path: path.clone(),
+                    kind: FileChangeKind::Update,
+                });
+            }
+        }
+        for path in self.snapshot.keys() {
+            if !current.contains_key(path) {
+                changes.push(FileChange {
+                    path: path.clone(),
+                    kind: FileChangeKind::Delete,
+                });
+            }
+        }
+
+        self.snapshot = current;
+        changes.sort_by(|a, b| a.path.cmp(&b.path));
+        changes
+    }
+}
+
+/// Watcher driven by the operating system's filesystem notifications.
+pub struct EventWatcher {
+    /// Directory being watched.
+    root: PathBuf,
+    /// Keeps the notification subscription alive; dropping it stops delivery.
+    _watcher: RecommendedWatcher,
+    /// Notifications delivered since the last poll.
+    events: mpsc::Receiver<notify::Result<Event>>,
+    /// Supported source files currently present under the root.
+    known: HashSet<PathBuf>,
+    /// Ignore rules of each directory, loaded on first use.
+    ignores: HashMap<PathBuf, Gitignore>,
+}
+
+/// Construction and event draining methods for [`EventWatcher`].
+impl EventWatcher {
+    /// Subscribe to notifications for `root` and record the files already present.
+    pub fn new(root: impl Into<PathBuf>) -> Result<Self> {
+        let root = root.into();
+        let (sender, events) = mpsc::channel();
+        let watch_error = |e: notify::Error| {
+            ValknutError::internal(format!("Failed to watch {}: {}", root.display(), e))
+        };
+        let mut watcher = notify::recommended_watcher(move |event: notify::Result<Event>| {
+            // The receiver only goes away together with the watcher.
+            let _ = sender.send(event);
+        })
+        .map_err(watch_error)?;
+        // Subscribe before the initial walk so no change in between is missed.
+        watcher
+            .watch(&root, RecursiveMode::Recursive)
+            .map_err(watch_error)?;
+        let known = scan(&root).into_keys().collect();
+
+        Ok(Self {
+            root,
+            _watcher: watcher,
+            events,
+            known,
+            ignores: HashMap::new(),
+        })
+    }
+
+    /// Directory being watched.
+    pub fn root(&self) -> &Path {
+        &self.root
+    }
+
+    /// Number of files currently tracked.
+    pub fn tracked_files(&self) -> usize {
+        self.known.len()
+    }
+
+    /// Drain the notifications received since the previous poll, without
+    /// blocking, and return the resulting changes sorted by path.
+    pub fn poll(&mut self) -> Vec<FileChange> {
+        let mut changes = BTreeMap::new();
+        let mut lost_events = false;
+        while let Ok(event) = self.events.try_recv() {
+            match event {
+                Ok(event) if matches!(event.kind, EventKind::Access(_)) => {}
+                Ok(event) => {
+                    for path in event.paths {
+                        self.apply(path, &mut changes);
+                    }
+                }
+                Err(err) => {
+                    warn!("Filesystem notification error: {err}");
+                    lost_events = true;
+                }
+            }
+        }
+        if lost_events {
+            self.resync(&mut changes);
+        }
+
+        changes
+            .into_iter()
+            .map(|(path, kind)| FileChange { path, kind })
+            .collect()
+    }
+
+    /// Translate a notification about `path` into changes of tracked files.
+    fn apply(&mut self, path: PathBuf, changes: &mut BTreeMap<PathBuf, FileChangeKind>) {
+        if is_ignore_file(&path) {
+            if let Some(dir) = path.parent() {
+                self.ignores.remove(dir);
+            }
+        }
+
+        if path.is_dir() {
+            // A directory created or moved in: everything beneath it is new.
+            if !self.is_ignored(&path, true) {
+                for file in scan(&path).into_keys() {
+                    self.known.insert(file.clone());
+                    changes.insert(file, FileChangeKind::Update);
+                }
+            }
+        } else if path.is_file() {
+            let tracked = self.known.contains(&path)
+                || (FileReader::is_code_file(&path) && !self.is_ignored(&path, false));
+            if tracked {
+                self.known.insert(path.clone());
+                changes.insert(path, FileChangeKind::Update);
+            }
+        } else {
+            // Removed or moved away; a directory takes every file beneath it along.
+            let gone: Vec<PathBuf> = self
+                .known
+                .iter()
+                .filter(|known| known.starts_with(&path))
+                .cloned()
+                .collect();
+            for file in gone {
+                self.known.remove(&file);
+                changes.insert(file, FileChangeKind::Delete);
+            }
+        }
+    }
+
+    /// Reconcile the tracked files with a fresh walk after notifications were lost.
+    fn resync(&mut self, changes: &mut BTreeMap<PathBuf, FileChangeKind>) {
+        let current: HashSet<PathBuf> = scan(&self.root).into_keys().collect();
+        for file in current.difference(&self.known) {
+            changes.insert(file.clone(), FileChangeKind::Update);
+        }
+        for file in self.known.difference(&current) {
+            changes.insert(file.clone(), FileChangeKind::Delete);
+        }
+        self.known = current;
+    }
+
+    /// Whether `path` is hidden or excluded by an ignore file, as in the initial walk.
+    fn is_ignored(&mut self, path: &Path, is_dir: bool) -> bool {
+        let Ok(relative) = path.strip_prefix(&self.root) else {
+            return true;
+        };
+        let hidden = relative
+            .components()
+            .any(|component| component.as_os_str().to_string_lossy().starts_with('.'));
+        if hidden {
+            return true;
+        }
+
+        // The ignore file closest to the path decides, as in git.
+        let root = self.root.clone();
+        for dir in path.ancestors().skip(1) {
+            if !dir.starts_with(&root) {
+                break;
+            }
+            let rules = self
+                .ignores
+                .entry(dir.to_path_buf())
+                .or_insert_with(|| load_ignore_rules(dir));
+            match rules.matched_path_or_any_parents(path, is_dir) {
+                Match::Ignore(_) => return true,
+                Match::Whitelist(_) => return false,
+                Match::None => {}
+            }
+        }
+        false
+    }
+}
+
+/// Source of file changes for watch mode.
+pub enum FileWatcher {
+    /// Filesystem notifications.
+    Events(EventWatcher),
+    /// Periodic rescans, where notifications are unavailable.
+    Polling(PollingWatcher),
+}
+
+/// Construction and polling methods for [`FileWatcher`].
+impl FileWatcher {
+    /// Watch `root` through filesystem notifications, falling back to polling
+    /// when they cannot be set up or when `force_polling` is set.
+    pub fn new(root: impl Into<PathBuf>, force_polling: bool) -> Self {
+        let root = root.into();
+        if !force_polling {
+            match EventWatcher::new(&root) {
+                Ok(watcher) => return Self::Events(watcher),
+                Err(e) => warn!("{e}; falling back to polling"),
+            }
+        }
+        Self::Polling(PollingWatcher::new(root))
+    }
+
+    /// Whether changes are found by rescanning the tree.
+    pub fn is_polling(&self) -> bool {
+        matches!(self, Self::Polling(_))
+    }
+
+    /// Number of files currently tracked.
+    pub fn tracked_files(&self) -> usize {
+        match self {
+            Self::Events(watcher) => watcher.tracked_files(),
+            Self::Polling(watcher) => watcher.tracked_files(),
+        }
+    }
+
+    /// Changes since the previous call, sorted by path.
+    pub fn poll(&mut self) -> Vec<FileChange> {
+        match self {
+            Self::Events(watcher) => watcher.poll(),
+            Self::Polling(watcher) => watcher.poll(),
+        }
+    }
+}
+
+/// Whether `path` names an ignore file whose rules the watcher caches.
+fn is_ignore_file(path: &Path) -> bool {
+    path.file_name()
+        .is_some_and(|name| name == ".gitignore" || name == IGNORE_FILE_NAME)
+}
+
+/// Rules from the `.gitignore` and `.valknutignore` files directly in `dir`.
+fn load_ignore_rules(dir: &Path) -> Gitignore {
+    let mut builder = GitignoreBuilder::new(dir);
+    for name in [".gitignore", IGNORE_FILE_NAME] {
+        let file = dir.join(name);
+        if file.is_file() {
+            if let Some(err) = builder.add(&file) {
+                warn!("Failed to read {}: {err}", file.display());
+            }
+        }
+    }
+    builder.build().unwrap_or_else(|err| {
+        warn!("Invalid ignore rules in {}: {err}", dir.display());
+        Gitignore::empty()
+    })
+}
+
+/// Fingerprint every supported source file under `root`.
+fn scan(root: &Path) -> HashMap<PathBuf, FileStamp> {
+    let mut builder = WalkBuilder::new(root);
+    builder.add_custom_ignore_filename(IGNORE_FILE_NAME);
+
+    let mut snapshot = HashMap::new();
+    for entry in builder.build() {
+        let entry = match entry {
+            Ok(entry) => entry,
+            Err(err) => {
+                warn!("Failed to walk directory: {err}");
+                continue;
+            }
+        };
+        let path = entry.path();
+        if !FileReader::is_code_file(path) {
+            continue;
+        }
+        let Ok(metadata) = entry.metadata() else {
+            continue;
+        };
+        if metadata.is_file() {
+            snapshot.insert(
+                path.to_path_buf(),
+                (metadata.modified().ok(), metadata.len()),
+            );
+        }
+    }
+    snapshot
+}
+
+/// Coalesces bursts of file changes until the tree has been quiet for a window.
+#[derive(Debug)]
+pub struct Debouncer {
+    /// Quiet period required before a batch is released.
+    window: Duration,
+    /// Latest change kind per path since the last released batch.
+    pending: BTreeMap<PathBuf, FileChangeKind>,
+    /// When the most recent change was recorded.
+    last_change: Option<Instant>,
+}
+
+/// Recording and release methods for [`Debouncer`].
+impl Debouncer {
+    /// Create a debouncer with the given quiet window.
+    pub fn new(window: Duration) -> Self {
+        Self {
+            window,
+            pending: BTreeMap::new(),
+            last_change: None,
+        }
+    }
+
+    /// Record changes observed at `now`; a later change to a path supersedes earlier ones.
+    pub fn record(&mut self, changes: impl IntoIterator<Item = FileChange>, now: Instant) {
+        let mut recorded = false;
+        for change in changes {
+            self.pending.insert(change.path, change.kind);
+            recorded = true;
+        }
+        if recorded {
+            self.last_change = Some(now);
+        }
+    }
+
+    /// Returns true when changes are waiting to be released.
+    pub fn has_pending(&self) -> bool {
+        !self.pending.is_empty()
+    }
+
+    /// Release the pending batch if no change has been recorded for a full window.
+    pub fn take_ready(&mut self, now: Instant) -> Option<Vec<FileChange>> {
+        let last_change = self.last_change?;
+        if now.duration_since(last_change) < self.window {
+            return None;
+        }
+        Some(self.take_all())
+    }
+
+    /// Release everything pending regardless of the window (used when shutting down).
+    pub fn take_all(&mut self) -> Vec<FileChange> {
+        self.last_change = None;
+        std::mem::take(&mut self.pending)
+            .into_iter()
+            .map(|(path, kind)| FileChange { path, kind })
+            .collect()
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use std::fs;
+    use tempfile::tempdir;
+
+    fn change(path: &str, kind: FileChangeKind) -> FileChange {
+        FileChange {
+            path: PathBuf::from(path),
+            kind,
+        }
+    }
+
+    #[test]
+    fn poll_reports_updates_and_deletes() {
+        let tmp = tempdir().unwrap();
+        let root = tmp.path();
+        fs::write(root.join("keep.py"), "x = 1\n").unwrap();
+        fs::write(root.join("gone.py"), "y = 2\n").unwrap();
+        fs::write(root.join("notes.txt"), "ignored").unwrap();
+
+        let mut watcher = PollingWatcher::new(root);
+        assert_eq!(watcher.tracked_files(), 2);
+        assert!(watcher.poll().is_empty());
+
+        fs::write(root.join("keep.py"), "x = 1\nz = 3\n").unwrap();
+        fs::write(root.join("new.py"), "w = 4\n").unwrap();
+        fs::remove_file(root.join("gone.py")).unwrap();
+        fs::write(root.join("notes.txt"), "still ignored").unwrap();
+
+        let changes = watcher.poll();
+        assert_eq!(
+            changes,
+            vec![
+                FileChange {
+                    path: root.join("gone.py"),
+                    kind: FileChangeKind::Delete,
+                },
+                FileChange {
+                    path: root.join("keep.py"),
+                    kind: FileChangeKind::Update,
+                },
+                FileChange {
+                    path: root.join("new.py"),
+                    kind: FileChangeKind::Update,
+                },
+            ]
+        );
+        assert!(watcher.poll().is_empty());
+    }
+
+    /// Poll until `expected` changes have arrived or a few seconds have passed.
+    fn poll_until(watcher: &mut EventWatcher, expected: usize) -> Vec<FileChange> {
+        let mut changes: BTreeMap<PathBuf, FileChangeKind> = BTreeMap::new();
+        let deadline = Instant::now() + Duration::from_secs(5);
+        while changes.len() < expected && Instant::now() < deadline {
+            std::thread::sleep(Duration::from_millis(20));
+            changes.extend(watcher.poll().into_iter().map(|c| (c.path, c.kind)));
+        }
+        changes
+            .into_iter()
+            .map(|(path, kind)| FileChange { path, kind })
+            .collect()
+    }
+
+    #[test]
+    fn event_watcher_reports_changes_outside_ignored_paths() {
+        let tmp = tempdir().unwrap();
+        let root = tmp.path().canonicalize().unwrap();
+        fs::create_dir_all(root.join("pkg")).unwrap();
+        fs::write(root.join(".gitignore"), "build/\n").unwrap();
+        fs::write(root.join("pkg/gone.py"), "y = 2\n").unwrap();
+
+        let mut watcher = EventWatcher::new(&root).unwrap();
+        assert_eq!(watcher.tracked_files(), 1);
+
+        fs::create_dir_all(root.join("build")).unwrap();
+        fs::write(root.join("build/out.py"), "ignored = 1\n").unwrap();
+        fs::write(root.join("notes.txt"), "ignored").unwrap();
+        fs::write(root.join("new.py"), "w = 4\n").unwrap();
+        fs::remove_dir_all(root.join("pkg")).unwrap();
+
+        assert_eq!(
+            poll_until(&mut watcher, 2),
+            vec![
+                FileChange {
+                    path: root.join("new.py"),
+                    kind: FileChangeKind::Update,
+                },
+                FileChange {
+                    path: root.join("pkg/gone.py"),
+                    kind: FileChangeKind::Delete,
+                },
+            ]
+        );
+        assert_eq!(watcher.tracked_files(), 1);
+    }
+
+    #[test]
+    fn debouncer_waits_for_quiet_window_and_coalesces() {
+        let window = Duration::from_millis(200);
+        let mut debouncer = Debouncer::new(window);
+        let start = Instant::now();
+
+        debouncer.record([change("a.py", FileChangeKind::Update)], start);
+        debouncer.record(
+            [
+                change("a.py", FileChangeKind::Delete),
+                change("b.py", FileChangeKind::Update),
+            ],
+            start + Duration::from_millis(150),
+        );
+
+        assert!(debouncer
+            .take_ready(start + Duration::from_millis(300))
+            .is_none());
+
+        let batch = debouncer
+            .take_ready(start + Duration::from_millis(350))
+            .expect("quiet window elapsed");
+        assert_eq!(
+            batch,
+            vec![
+                change("a.py", FileChangeKind::Delete),
+                change("b.py", FileChangeKind::Update),
+            ]
+        );
+        assert!(!debouncer.has_pending());
+        assert!(debouncer
+            .take_ready(start + Duration::from_secs(10))
+            .is_none());
+    }
+}
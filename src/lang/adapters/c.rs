@@ -0,0 +1,682 @@
+//! C language adapter with tree-sitter integration.
+//!
+//! Targets C99 translation units and headers. Extracts function definitions
+//! and prototypes, `struct`/`union`/`enum` definitions, `typedef` aliases and
+//! preprocessor macros. Macros are not expanded; each `#define` becomes a
+//! [`EntityKind::Constant`] entity whose `references` metadata lists the lines
+//! of the same file that use it. `#include` directives are reported by
+//! [`LanguageAdapter::extract_imports`] so headers and the `.c` files that
+//! include them are linked in the project import graph.
+
+use std::collections::{HashMap, HashSet};
+use std::time::Instant;
+use tracing::warn;
+use tree_sitter::{Language, Node, Parser, Tree};
+
+use super::super::common::{
+    check_recursion_depth, check_syntax, create_base_metadata, extract_identifiers_by_kinds,
+    generate_entity_id, parse_until, sort_and_dedup, EntityKind, LanguageAdapter, ParseIndex,
+    ParsedEntity, SourceLocation, DEFAULT_MAX_RECURSION_DEPTH,
+};
+use super::super::registry::{create_parser_for_language, get_tree_sitter_language};
+use crate::core::ast_utils::{node_text_normalized, walk_tree};
+use crate::core::errors::{Result, ValknutError};
+use crate::core::featureset::CodeEntity;
+use crate::detectors::structure::config::ImportStatement;
+
+/// Node kinds of `#define` directives.
+const MACRO_DEFINITION_KINDS: &[&str] = &["preproc_def", "preproc_function_def"];
+
+/// C-specific parsing and analysis
+pub struct CAdapter {
+    /// Tree-sitter parser for C
+    parser: Parser,
+
+    /// Language instance
+    language: Language,
+
+    /// Deepest AST node entity extraction visits
+    max_depth: usize,
+
+    /// Instant after which parses are abandoned (`--timeout`)
+    deadline: Option<Instant>,
+}
+
+/// Parsing and entity extraction methods for [`CAdapter`].
+impl CAdapter {
+    /// Create a new C adapter
+    pub fn new() -> Result<Self> {
+        let language = get_tree_sitter_language("c")?;
+        let parser = create_parser_for_language("c")?;
+
+        Ok(Self {
+            parser,
+            language,
+            max_depth: DEFAULT_MAX_RECURSION_DEPTH,
+            deadline: None,
+        })
+    }
+
+    /// Parse C source code and extract entities
+    pub fn parse_source(&mut self, source_code: &str, file_path: &str) -> Result<ParseIndex> {
+        let tree = parse_until(&mut self.parser, source_code, self.deadline).ok_or_else(|| {
+            ValknutError::parse_with_location(
+                "c",
+                "Failed to parse C source code",
+                file_path,
+                None,
+                None,
+            )
+        })?;
+        check_syntax(&tree, "c", file_path)?;
+        let root = tree.root_node();
+
+        let mut index = ParseIndex::new();
+        let defined = defined_functions(root, source_code);
+        let mut entity_id_counter = 0;
+
+        // Stack entries: (node, parent_id, depth)
+        let mut stack: Vec<(Node, Option<String>, usize)> = vec![(root, None, 0)];
+        while let Some((node, parent_id, depth)) = stack.pop() {
+            check_recursion_depth(node, file_path, depth, self.max_depth)?;
+            let new_parent_id = match self.node_to_entity(
+                node,
+                source_code,
+                file_path,
+                parent_id.clone(),
+                &defined,
+                &mut entity_id_counter,
+            ) {
+                Some(entity) => {
+                    let entity_id = entity.id.clone();
+                    index.add_entity(entity);
+                    Some(entity_id)
+                }
+                None => parent_id,
+            };
+
+            let mut cursor = node.walk();
+            let children: Vec<_> = node.children(&mut cursor).collect();
+            for child in children.into_iter().rev() {
+                stack.push((child, new_parent_id.clone(), depth + 1));
+            }
+        }
+
+        record_macro_references(&mut index, root, source_code);
+        Ok(index)
+    }
+
+    /// Extract entities from C code and convert to CodeEntity format
+    pub fn extract_code_entities(
+        &mut self,
+        source_code: &str,
+        file_path: &str,
+    ) -> Result<Vec<CodeEntity>> {
+        let parse_index = self.parse_source(source_code, file_path)?;
+        Ok(parse_index
+            .entities
+            .values()
+            .map(|entity| entity.to_code_entity(source_code))
+            .collect())
+    }
+
+    /// Convert a node to an entity. Prototypes of functions defined in the
+    /// same file are skipped so each function appears once.
+    fn node_to_entity(
+        &self,
+        node: Node,
+        source_code: &str,
+        file_path: &str,
+        parent_id: Option<String>,
+        defined: &HashSet<String>,
+        entity_id_counter: &mut usize,
+    ) -> Option<ParsedEntity> {
+        let kind = determine_entity_kind(&node)?;
+        let name = extract_name(&node, source_code)?;
+        if node.kind() == "declaration" && defined.contains(&name) {
+            return None;
+        }
+
+        *entity_id_counter += 1;
+        let location = SourceLocation::from_positions(
+            file_path,
+            node.start_position().row,
+            node.start_position().column,
+            node.end_position().row,
+            node.end_position().column,
+        );
+        let mut metadata = create_base_metadata(node.kind(), node.start_byte(), node.end_byte());
+        extract_entity_metadata(&node, source_code, &mut metadata);
+
+        Some(ParsedEntity {
+            id: generate_entity_id(file_path, kind, *entity_id_counter),
+            kind,
+            name,
+            parent: parent_id,
+            children: Vec::new(),
+            location,
+            metadata,
+        })
+    }
+}
+
+/// [`LanguageAdapter`] implementation for C source code.
+impl LanguageAdapter for CAdapter {
+    /// Parses source code into a tree-sitter AST.
+    fn parse_tree(&mut self, source: &str) -> Result<Tree> {
+        parse_until(&mut self.parser, source, self.deadline)
+            .ok_or_else(|| ValknutError::parse("c", "Failed to parse C source code"))
+    }
+
+    /// Parses C source code and returns a parse index.
+    fn parse_source(&mut self, source: &str, file_path: &str) -> Result<ParseIndex> {
+        CAdapter::parse_source(self, source, file_path)
+    }
+
+    /// Extracts the names of all called functions and function-like macros.
+    fn extract_function_calls(&mut self, source: &str) -> Result<Vec<String>> {
+        let tree = self.parse_tree(source)?;
+        let mut calls = Vec::new();
+        walk_tree(tree.root_node(), &mut |node: Node| {
+            if node.kind() == "call_expression" {
+                if let Some(function) = node.child_by_field_name("function") {
+                    calls.push(text_of(&function, source));
+                }
+            }
+        });
+        sort_and_dedup(&mut calls);
+        Ok(calls)
+    }
+
+    /// Extracts all identifier tokens from the source.
+    fn extract_identifiers(&mut self, source: &str) -> Result<Vec<String>> {
+        let tree = self.parse_tree(source)?;
+        let mut identifiers = extract_identifiers_by_kinds(
+            tree.root_node(),
+            source,
+            &["identifier", "field_identifier", "type_identifier"],
+        );
+        sort_and_dedup(&mut identifiers);
+        Ok(identifiers)
+    }
+
+    /// Counts distinct code blocks in the source.
+    fn count_distinct_blocks(&mut self, source: &str) -> Result<usize> {
+        let index = CAdapter::parse_source(self, source, "<memory>")?;
+        Ok(index.count_distinct_blocks())
+    }
+
+    /// Returns the language name ("c").
+    fn language_name(&self) -> &str {
+        "c"
+    }
+
+    /// Bounds entity extraction to `depth` levels of AST nesting.
+    fn set_max_ast_depth(&mut self, depth: usize) {
+        self.max_depth = depth;
+    }
+
+    /// Abandons parses still running once `deadline` passes.
+    fn set_parse_deadline(&mut self, deadline: Option<Instant>) {
+        self.deadline = deadline;
+    }
+
+    /// Extracts `#include` directives, including those inside include guards.
+    ///
+    /// Quoted includes have import type `include`; angle-bracket includes have
+    /// import type `system_include`.
+    fn extract_imports(&mut self, source: &str) -> Result<Vec<ImportStatement>> {
+        let tree = self.parse_tree(source)?;
+        let mut imports = Vec::new();
+        walk_tree(tree.root_node(), &mut |node: Node| {
+            if node.kind() != "preproc_include" {
+                return;
+            }
+            let Some(path) = node.child_by_field_name("path") else {
+                return;
+            };
+            let import_type = match path.kind() {
+                "system_lib_string" => "system_include",
+                _ => "include",
+            };
+            let module = text_of(&path, source)
+                .trim_matches(|c| matches!(c, '"' | '<' | '>'))
+                .to_string();
+            imports.push(ImportStatement {
+                module,
+                imports: None,
+                import_type: import_type.to_string(),
+                line_number: node.start_position().row + 1,
+            });
+        });
+        Ok(imports)
+    }
+
+    /// Extracts code entities from C source code.
+    fn extract_code_entities(&mut self, source: &str, file_path: &str) -> Result<Vec<CodeEntity>> {
+        CAdapter::extract_code_entities(self, source, file_path)
+    }
+}
+
+/// Default implementation for [`CAdapter`].
+impl Default for CAdapter {
+    /// Returns a new C adapter, or a minimal fallback on failure.
+    fn default() -> Self {
+        Self::new().unwrap_or_else(|e| {
+            warn!("Failed to create C adapter, using minimal fallback: {}", e);
+            CAdapter {
+                parser: tree_sitter::Parser::new(),
+                language: get_tree_sitter_language("c")
+                    .unwrap_or_else(|_| tree_sitter_c::LANGUAGE.into()),
+                max_depth: DEFAULT_MAX_RECURSION_DEPTH,
+                deadline: None,
+            }
+        })
+    }
+}
+
+/// Determine entity kind from node kind, returning None for non-entity nodes.
+fn determine_entity_kind(node: &Node) -> Option<EntityKind> {
+    match node.kind() {
+        "function_definition" => Some(EntityKind::Function),
+        "declaration" if prototype_declarator(node).is_some() => Some(EntityKind::Function),
+        "struct_specifier" | "union_specifier" if node.child_by_field_name("body").is_some() => {
+            Some(EntityKind::Struct)
+        }
+        "enum_specifier" if node.child_by_field_name("body").is_some() => Some(EntityKind::Enum),
+        "type_definition" if !defines_aggregate(node) => Some(EntityKind::Interface),
+        kind if MACRO_DEFINITION_KINDS.contains(&kind) => Some(EntityKind::Constant),
+        _ => None,
+    }
+}
+
+/// Extract the name of an entity; anonymous aggregates take their typedef name.
+fn extract_name(node: &Node, source_code: &str) -> Option<String> {
+    match node.kind() {
+        "function_definition" => node
+            .child_by_field_name("declarator")
+            .and_then(|declarator| declarator_name(declarator, source_code)),
+        "declaration" => prototype_declarator(node)
+            .and_then(|declarator| declarator_name(declarator, source_code)),
+        "struct_specifier" | "union_specifier" | "enum_specifier" => node
+            .child_by_field_name("name")
+            .map(|name| text_of(&name, source_code))
+            .or_else(|| typedef_names(node, source_code).into_iter().next()),
+        "type_definition" => typedef_names(node, source_code).into_iter().next(),
+        _ => node
+            .child_by_field_name("name")
+            .map(|name| text_of(&name, source_code)),
+    }
+}
+
+/// Extract metadata based on node kind.
+fn extract_entity_metadata(
+    node: &Node,
+    source_code: &str,
+    metadata: &mut HashMap<String, serde_json::Value>,
+) {
+    match node.kind() {
+        "function_definition" | "declaration" => {
+            extract_function_metadata(node, source_code, metadata)
+        }
+        "struct_specifier" | "union_specifier" => {
+            let aggregate = node.kind().trim_end_matches("_specifier");
+            metadata.insert("aggregate".to_string(), serde_json::json!(aggregate));
+            let fields = field_names(node, source_code);
+            if !fields.is_empty() {
+                metadata.insert("fields".to_string(), serde_json::json!(fields));
+            }
+            insert_typedef_names(node, source_code, metadata);
+        }
+        "enum_specifier" => {
+            let enumerators = enumerators(node, source_code);
+            if !enumerators.is_empty() {
+                metadata.insert("enumerators".to_string(), serde_json::json!(enumerators));
+            }
+            insert_typedef_names(node, source_code, metadata);
+        }
+        "type_definition" => {
+            metadata.insert("is_typedef".to_string(), serde_json::Value::Bool(true));
+            if let Some(ty) = node.child_by_field_name("type") {
+                metadata.insert(
+                    "aliased_type".to_string(),
+                    serde_json::json!(text_of(&ty, source_code)),
+                );
+            }
+            let names = typedef_names(node, source_code);
+            if names.len() > 1 {
+                metadata.insert("typedef_names".to_string(), serde_json::json!(names));
+            }
+            let is_function_pointer = node
+                .child_by_field_name("declarator")
+                .and_then(function_declarator)
+                .is_some();
+            if is_function_pointer {
+                metadata.insert(
+                    "is_function_pointer".to_string(),
+                    serde_json::Value::Bool(true),
+                );
+            }
+        }
+        "preproc_def" | "preproc_function_def" => {
+            let macro_kind = if node.kind() == "preproc_def" {
+                "object"
+            } else {
+                "function"
+            };
+            metadata.insert("macro_kind".to_string(), serde_json::json!(macro_kind));
+            if let Some(parameters) = node.child_by_field_name("parameters") {
+                let mut cursor = parameters.walk();
+                let names: Vec<String> = parameters
+                    .named_children(&mut cursor)
+                    .map(|parameter| text_of(&parameter, source_code))
+                    .collect();
+                metadata.insert("parameters".to_string(), serde_json::json!(names));
+            }
+            if let Some(value) = node.child_by_field_name("value") {
+                metadata.insert(
+                    "value".to_string(),
+                    serde_json::json!(text_of(&value, source_code)),
+                );
+            }
+        }
+        _ => {}
+    }
+}
+
+/// Record return type, parameters, storage class and signature of a function.
+fn extract_function_metadata(
+    node: &Node,
+    source_code: &str,
+    metadata: &mut HashMap<String, serde_json::Value>,
+) {
+    let declarator = match node.kind() {
+        "declaration" => prototype_declarator(node),
+        _ => node.child_by_field_name("declarator"),
+    };
+    let Some(declarator) = declarator else {
+        return;
+    };
+
+    if node.kind() == "declaration" {
+        metadata.insert("is_prototype".to_string(), serde_json::Value::Bool(true));
+    }
+
+    let mut cursor = node.walk();
+    for child in node.children(&mut cursor) {
+        if child.kind() == "storage_class_specifier" {
+            let specifier = text_of(&child, source_code);
+            if matches!(specifier.as_str(), "static" | "inline" | "extern") {
+                metadata.insert(format!("is_{specifier}"), serde_json::Value::Bool(true));
+            }
+        }
+    }
+
+    if let Some(ty) = node.child_by_field_name("type") {
+        let pointers = pointer_depth(declarator);
+        metadata.insert(
+            "return_type".to_string(),
+            serde_json::json!(format!(
+                "{}{}",
+                text_of(&ty, source_code),
+                "*".repeat(pointers)
+            )),
+        );
+    }
+
+    if let Some(parameters) = function_declarator(declarator)
+        .and_then(|function| function.child_by_field_name("parameters"))
+    {
+        metadata.insert(
+            "parameters".to_string(),
+            serde_json::json!(parameter_names(&parameters, source_code)),
+        );
+    }
+
+    let signature_end = node
+        .child_by_field_name("body")
+        .map_or(node.end_byte(), |body| body.start_byte());
+    let signature = source_code
+        .get(node.start_byte()..signature_end)
+        .unwrap_or_default()
+        .split_whitespace()
+        .collect::<Vec<_>>()
+        .join(" ");
+    metadata.insert(
+        "signature".to_string(),
+        serde_json::json!(signature.trim_end_matches(';').trim_end()),
+    );
+}
+
+/// Names of the functions defined (with a body) in the translation unit.
+fn defined_functions(root: Node, source_code: &str) -> HashSet<String> {
+    let mut defined = HashSet::new();
+    walk_tree(root, &mut |node: Node| {
+        if node.kind() == "function_definition" {
+            if let Some(name) = extract_name(&node, source_code) {
+                defined.insert(name);
+            }
+        }
+    });
+    defined
+}
+
+/// Declarator of a function prototype, or None for other declarations
+/// (variables, function pointers).
+fn prototype_declarator<'a>(node: &Node<'a>) -> Option<Node<'a>> {
+    let mut cursor = node.walk();
+    let declarators: Vec<Node<'a>> = node
+        .children_by_field_name("declarator", &mut cursor)
+        .collect();
+    declarators.into_iter().find(|declarator| {
+        function_declarator(*declarator)
+            .and_then(|function| function.child_by_field_name("declarator"))
+            .is_some_and(|inner| inner.kind() == "identifier")
+    })
+}
+
+/// Innermost function declarator in a declarator chain (`*make(void)` -> `make(void)`).
+fn function_declarator(declarator: Node) -> Option<Node> {
+    let mut current = declarator;
+    loop {
+        match current.kind() {
+            "function_declarator" => return Some(current),
+            "pointer_declarator" | "attributed_declarator" => {
+                current = current.child_by_field_name("declarator")?
+            }
+            "parenthesized_declarator" => current = current.named_child(0)?,
+            _ => return None,
+        }
+    }
+}
+
+/// Number of pointer declarators wrapping the function declarator (the return type's indirection).
+fn pointer_depth(declarator: Node) -> usize {
+    let mut depth = 0;
+    let mut current = declarator;
+    while current.kind() == "pointer_declarator" {
+        depth += 1;
+        match current.child_by_field_name("declarator") {
+            Some(inner) => current = inner,
+            None => break,
+        }
+    }
+    depth
+}
+
+/// Identifier declared by a declarator chain (`*items[8]` -> `items`).
+fn declarator_name(declarator: Node, source_code: &str) -> Option<String> {
+    let mut current = declarator;
+    loop {
+        match current.kind() {
+            "identifier" | "type_identifier" | "field_identifier" => {
+                return Some(text_of(&current, source_code))
+            }
+            "parenthesized_declarator" => current = current.named_child(0)?,
+            _ => current = current.child_by_field_name("declarator")?,
+        }
+    }
+}
+
+/// Parameter names of a parameter list; unnamed parameters are reported by type.
+fn parameter_names(parameters: &Node, source_code: &str) -> Vec<String> {
+    let mut cursor = parameters.walk();
+    let mut names = Vec::new();
+    for parameter in parameters.named_children(&mut cursor) {
+        match parameter.kind() {
+            "parameter_declaration" => {
+                let name = parameter
+                    .child_by_field_name("declarator")
+                    .and_then(|declarator| declarator_name(declarator, source_code));
+                match name {
+                    Some(name) => names.push(name),
+                    None => names.push(text_of(&parameter, source_code)),
+                }
+            }
+            "variadic_parameter" => names.push("...".to_string()),
+            _ => {}
+        }
+    }
+    // `f(void)` declares no parameters.
+    if names == ["void"] {
+        names.clear();
+    }
+    names
+}
+
+/// Returns true for a typedef whose type is a struct, union or enum with a body.
+///
+/// Such typedefs are reported through the aggregate itself rather than as a
+/// separate alias entity.
+fn defines_aggregate(node: &Node) -> bool {
+    node.child_by_field_name("type").is_some_and(|ty| {
+        matches!(
+            ty.kind(),
+            "struct_specifier" | "union_specifier" | "enum_specifier"
+        ) && ty.child_by_field_name("body").is_some()
+    })
+}
+
+/// Names declared by a typedef, or by the typedef enclosing an aggregate.
+fn typedef_names(node: &Node, source_code: &str) -> Vec<String> {
+    let typedef = if node.kind() == "type_definition" {
+        *node
+    } else {
+        match node.parent() {
+            Some(parent) if parent.kind() == "type_definition" => parent,
+            _ => return Vec::new(),
+        }
+    };
+    let mut cursor = typedef.walk();
+    let declarators: Vec<Node> = typedef
+        .children_by_field_name("declarator", &mut cursor)
+        .collect();
+    declarators
+        .into_iter()
+        .filter_map(|declarator| declarator_name(declarator, source_code))
+        .collect()
+}
+
+/// Record the typedef names of an aggregate declared inside a typedef.
+fn insert_typedef_names(
+    node: &Node,
+    source_code: &str,
+    metadata: &mut HashMap<String, serde_json::Value>,
+) {
+    let names = typedef_names(node, source_code);
+    if !names.is_empty() {
+        metadata.insert("typedef_names".to_string(), serde_json::json!(names));
+    }
+}
+
+/// Member names of a struct or union body.
+fn field_names(node: &Node, source_code: &str) -> Vec<String> {
+    let Some(body) = node.child_by_field_name("body") else {
+        return Vec::new();
+    };
+    let mut fields = Vec::new();
+    let mut cursor = body.walk();
+    for field in body.named_children(&mut cursor) {
+        if field.kind() != "field_declaration" {
+            continue;
+        }
+        let mut field_cursor = field.walk();
+        let declarators: Vec<Node> = field
+            .children_by_field_name("declarator", &mut field_cursor)
+            .collect();
+        fields.extend(
+            declarators
+                .into_iter()
+                .filter_map(|declarator| declarator_name(declarator, source_code)),
+        );
+    }
+    fields
+}
+
+/// Enumerator names of an enum body.
+fn enumerators(node: &Node, source_code: &str) -> Vec<String> {
+    let Some(body) = node.child_by_field_name("body") else {
+        return Vec::new();
+    };
+    let mut cursor = body.walk();
+    body.named_children(&mut cursor)
+        .filter(|child| child.kind() == "enumerator")
+        .filter_map(|child| child.child_by_field_name("name"))
+        .map(|name| text_of(&name, source_code))
+        .collect()
+}
+
+/// Attach the 1-based lines that use each macro to its definition entities.
+///
+/// Uses are identifiers in code, `#ifdef`/`#ifndef` names and `defined(...)`
+/// operands; macro bodies are opaque to the parser and are not searched.
+fn record_macro_references(index: &mut ParseIndex, root: Node, source_code: &str) {
+    let mut references: HashMap<String, Vec<usize>> = index
+        .entities
+        .values()
+        .filter(|entity| entity.metadata.contains_key("macro_kind"))
+        .map(|entity| (entity.name.clone(), Vec::new()))
+        .collect();
+    if references.is_empty() {
+        return;
+    }
+
+    walk_tree(root, &mut |node: Node| {
+        if !matches!(node.kind(), "identifier" | "type_identifier") {
+            return;
+        }
+        let is_definition_name = node
+            .parent()
+            .is_some_and(|parent| MACRO_DEFINITION_KINDS.contains(&parent.kind()));
+        if is_definition_name {
+            return;
+        }
+        if let Some(lines) = references.get_mut(&text_of(&node, source_code)) {
+            lines.push(node.start_position().row + 1);
+        }
+    });
+
+    for entity in index.entities.values_mut() {
+        if !entity.metadata.contains_key("macro_kind") {
+            continue;
+        }
+        if let Some(lines) = references.get(&entity.name) {
+            let mut lines = lines.clone();
+            sort_and_dedup(&mut lines);
+            entity
+                .metadata
+                .insert("references".to_string(), serde_json::json!(lines));
+        }
+    }
+}
+
+/// Whitespace-normalized source text of a node.
+fn text_of(node: &Node, source_code: &str) -> String {
+    node_text_normalized(node, source_code)
+        .map(|text| text.trim().to_string())
+        .unwrap_or_default()
+}
+
+#[cfg(test)]
+#[path = "c_tests.rs"]
+mod tests;
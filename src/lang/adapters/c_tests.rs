@@ -0,0 +1,256 @@
+use super::*;
+
+fn entity<'a>(index: &'a ParseIndex, name: &str) -> &'a ParsedEntity {
+    index
+        .entities
+        .values()
+        .find(|e| e.name == name)
+        .unwrap_or_else(|| panic!("entity {name} not found"))
+}
+
+fn strings(entity: &ParsedEntity, key: &str) -> Vec<String> {
+    entity
+        .metadata
+        .get(key)
+        .and_then(|value| value.as_array())
+        .map(|values| {
+            values
+                .iter()
+                .filter_map(|v| v.as_str().map(str::to_string))
+                .collect()
+        })
+        .unwrap_or_default()
+}
+
+#[test]
+fn test_c_adapter_creation() {
+    let adapter = CAdapter::new();
+    assert!(adapter.is_ok());
+}
+
+#[test]
+fn test_functions_and_prototypes() {
+    let mut adapter = CAdapter::new().unwrap();
+    let source = r#"
+int uart_write(const char *buf, int len);
+void uart_flush(void);
+int printf(const char *fmt, ...);
+
+static inline unsigned char *rx_buffer(int slot) {
+    return 0;
+}
+
+int uart_write(const char *buf, int len) {
+    return len;
+}
+"#;
+
+    let index = adapter.parse_source(source, "uart.c").unwrap();
+    let functions: Vec<&str> = index
+        .entities
+        .values()
+        .filter(|e| e.kind == EntityKind::Function)
+        .map(|e| e.name.as_str())
+        .collect();
+    assert_eq!(
+        functions.len(),
+        4,
+        "prototype of a defined function is dropped"
+    );
+
+    let write = entity(&index, "uart_write");
+    assert!(!write.metadata.contains_key("is_prototype"));
+    assert_eq!(strings(write, "parameters"), vec!["buf", "len"]);
+    assert_eq!(write.metadata["return_type"], "int");
+    assert_eq!(
+        write.metadata["signature"],
+        "int uart_write(const char *buf, int len)"
+    );
+
+    let flush = entity(&index, "uart_flush");
+    assert_eq!(flush.metadata["is_prototype"], true);
+    assert!(strings(flush, "parameters").is_empty());
+
+    assert_eq!(
+        strings(entity(&index, "printf"), "parameters"),
+        vec!["fmt", "..."]
+    );
+
+    let rx = entity(&index, "rx_buffer");
+    assert_eq!(rx.metadata["is_static"], true);
+    assert_eq!(rx.metadata["is_inline"], true);
+    assert_eq!(rx.metadata["return_type"], "unsigned char*");
+}
+
+#[test]
+fn test_structs_unions_and_typedefs() {
+    let mut adapter = CAdapter::new().unwrap();
+    let source = r#"
+struct packet {
+    unsigned char id;
+    unsigned char payload[32], crc;
+};
+
+typedef struct {
+    int x;
+    int y;
+} point_t, *point_ptr;
+
+union reg {
+    unsigned int word;
+    struct { unsigned char lo, hi; } bytes;
+};
+
+typedef unsigned long tick_t;
+typedef void (*isr_handler)(int irq);
+"#;
+
+    let index = adapter.parse_source(source, "types.h").unwrap();
+
+    let packet = entity(&index, "packet");
+    assert_eq!(packet.kind, EntityKind::Struct);
+    assert_eq!(packet.metadata["aggregate"], "struct");
+    assert_eq!(strings(packet, "fields"), vec!["id", "payload", "crc"]);
+
+    let point = entity(&index, "point_t");
+    assert_eq!(point.kind, EntityKind::Struct);
+    assert_eq!(
+        strings(point, "typedef_names"),
+        vec!["point_t", "point_ptr"]
+    );
+    assert!(
+        !index
+            .entities
+            .values()
+            .any(|e| e.kind == EntityKind::Interface && e.name == "point_t"),
+        "typedef'd aggregates are reported once"
+    );
+
+    let reg = entity(&index, "reg");
+    assert_eq!(reg.metadata["aggregate"], "union");
+    assert_eq!(strings(reg, "fields"), vec!["word", "bytes"]);
+
+    let tick = entity(&index, "tick_t");
+    assert_eq!(tick.kind, EntityKind::Interface);
+    assert_eq!(tick.metadata["is_typedef"], true);
+    assert_eq!(tick.metadata["aliased_type"], "unsigned long");
+
+    let isr = entity(&index, "isr_handler");
+    assert_eq!(isr.kind, EntityKind::Interface);
+    assert_eq!(isr.metadata["is_function_pointer"], true);
+}
+
+#[test]
+fn test_enums() {
+    let mut adapter = CAdapter::new().unwrap();
+    let source = r#"
+enum pin_mode { PIN_INPUT, PIN_OUTPUT = 4, PIN_ANALOG };
+
+typedef enum { LOW, HIGH } level_t;
+
+enum pin_mode current_mode(void);
+"#;
+
+    let index = adapter.parse_source(source, "gpio.h").unwrap();
+
+    let mode = entity(&index, "pin_mode");
+    assert_eq!(mode.kind, EntityKind::Enum);
+    assert_eq!(
+        strings(mode, "enumerators"),
+        vec!["PIN_INPUT", "PIN_OUTPUT", "PIN_ANALOG"]
+    );
+
+    let level = entity(&index, "level_t");
+    assert_eq!(level.kind, EntityKind::Enum);
+    assert_eq!(strings(level, "enumerators"), vec!["LOW", "HIGH"]);
+
+    // An enum used as a return type is not a second definition.
+    let enums = index
+        .entities
+        .values()
+        .filter(|e| e.kind == EntityKind::Enum)
+        .count();
+    assert_eq!(enums, 2);
+}
+
+#[test]
+fn test_macro_definitions_and_uses() {
+    let mut adapter = CAdapter::new().unwrap();
+    let source = r#"#ifndef CONFIG_H
+#define CONFIG_H
+
+#define BAUD_RATE 115200
+#define MIN(a, b) ((a) < (b) ? (a) : (b))
+#define UNUSED 0
+
+int divisor(int clock) {
+    return clock / BAUD_RATE;
+}
+
+int clamp(int v) {
+    return MIN(v, BAUD_RATE);
+}
+
+#endif
+"#;
+
+    let index = adapter.parse_source(source, "config.h").unwrap();
+
+    let baud = entity(&index, "BAUD_RATE");
+    assert_eq!(baud.kind, EntityKind::Constant);
+    assert_eq!(baud.metadata["macro_kind"], "object");
+    assert_eq!(baud.metadata["value"], "115200");
+    assert_eq!(baud.metadata["references"], serde_json::json!([9, 13]));
+
+    let min = entity(&index, "MIN");
+    assert_eq!(min.metadata["macro_kind"], "function");
+    assert_eq!(strings(min, "parameters"), vec!["a", "b"]);
+    assert_eq!(min.metadata["references"], serde_json::json!([13]));
+
+    let guard = entity(&index, "CONFIG_H");
+    assert_eq!(guard.metadata["references"], serde_json::json!([1]));
+
+    assert_eq!(
+        entity(&index, "UNUSED").metadata["references"],
+        serde_json::json!([])
+    );
+}
+
+#[test]
+fn test_extract_imports() {
+    let mut adapter = CAdapter::new().unwrap();
+    let source = r#"#ifndef DRIVER_H
+#define DRIVER_H
+
+#include <stdint.h>
+#include "hal/uart.h"
+
+#endif
+"#;
+
+    let imports = adapter.extract_imports(source).unwrap();
+    assert_eq!(imports.len(), 2, "includes inside header guards are found");
+
+    assert_eq!(imports[0].module, "stdint.h");
+    assert_eq!(imports[0].import_type, "system_include");
+    assert_eq!(imports[0].line_number, 4);
+
+    assert_eq!(imports[1].module, "hal/uart.h");
+    assert_eq!(imports[1].import_type, "include");
+    assert_eq!(imports[1].line_number, 5);
+}
+
+#[test]
+fn test_function_calls() {
+    let mut adapter = CAdapter::new().unwrap();
+    let source = r#"
+void tick(void) {
+    led_toggle();
+    uart_write("x", 1);
+}
+"#;
+
+    let calls = adapter.extract_function_calls(source).unwrap();
+    assert!(calls.contains(&"led_toggle".to_string()));
+    assert!(calls.contains(&"uart_write".to_string()));
+}
@@ -1,12 +1,14 @@
 //! C++ language adapter with tree-sitter integration.
 
 use std::collections::HashMap;
+use std::time::Instant;
 use tree_sitter::{Language, Node, Parser, Tree};
 
 use super::super::common::{
-    create_base_metadata, extract_identifiers_by_kinds, extract_node_text, generate_entity_id,
-    sort_and_dedup, EntityExtractor, EntityKind, LanguageAdapter, ParseIndex, ParsedEntity,
-    SourceLocation,
+    check_recursion_depth, check_syntax, create_base_metadata, extract_identifiers_by_kinds,
+    extract_node_text, generate_entity_id, parse_until, sort_and_dedup, EntityExtractor,
+    EntityKind, LanguageAdapter, ParseIndex, ParsedEntity, SourceLocation,
+    DEFAULT_MAX_RECURSION_DEPTH,
 };
 use super::super::registry::{create_parser_for_language, get_tree_sitter_language};
 use crate::core::ast_utils::{find_child_by_kind, node_text_normalized, walk_tree};
@@ -21,6 +23,12 @@ pub struct CppAdapter {
 
     /// Language instance
     language: Language,
+
+    /// Deepest AST node entity extraction visits
+    max_depth: usize,
+
+    /// Instant after which parses are abandoned (`--timeout`)
+    deadline: Option<Instant>,
 }
 
 /// Parsing and entity extraction methods for [`CppAdapter`].
@@ -30,15 +38,26 @@ impl CppAdapter {
         let language = get_tree_sitter_language("cpp")?;
         let parser = create_parser_for_language("cpp")?;
 
-        Ok(Self { parser, language })
+        Ok(Self {
+            parser,
+            language,
+            max_depth: DEFAULT_MAX_RECURSION_DEPTH,
+            deadline: None,
+        })
     }
 
     /// Parse C++ source code and extract entities
     pub fn parse_source(&mut self, source_code: &str, file_path: &str) -> Result<ParseIndex> {
-        let tree = self
-            .parser
-            .parse(source_code, None)
-            .ok_or_else(|| ValknutError::parse("cpp", "Failed to parse C++ source code"))?;
+        let tree = parse_until(&mut self.parser, source_code, self.deadline).ok_or_else(|| {
+            ValknutError::parse_with_location(
+                "cpp",
+                "Failed to parse C++ source code",
+                file_path,
+                None,
+                None,
+            )
+        })?;
+        check_syntax(&tree, "cpp", file_path)?;
 
         let mut index = ParseIndex::new();
         let mut entity_id_counter = 0;
@@ -73,6 +92,7 @@ impl CppAdapter {
     }
 
     /// Iterative entity extraction for C++ - avoids stack overflow on deeply nested code.
+    /// Fails once a node lies deeper than the configured maximum AST depth.
     fn extract_entities_iterative_cpp(
         &self,
         root: Node,
@@ -81,23 +101,12 @@ impl CppAdapter {
         index: &mut ParseIndex,
         entity_id_counter: &mut usize,
     ) -> Result<()> {
-        // Stack entries: (node, parent_id, namespace_context)
-        let mut stack: Vec<(Node, Option<String>, Vec<String>)> = vec![(root, None, Vec::new())];
-
-        while let Some((node, parent_id, namespace_ctx)) = stack.pop() {
-            // For ERROR nodes, still try to process children - they may contain valid code
-            // This handles partial parse failures gracefully
-            if node.is_error() || node.kind() == "ERROR" {
-                // Push children of error node - they might be valid
-                let mut cursor = node.walk();
-                let children: Vec<_> = node.children(&mut cursor).collect();
-                for child in children.into_iter().rev() {
-                    if !child.is_error() {
-                        stack.push((child, parent_id.clone(), namespace_ctx.clone()));
-                    }
-                }
-                continue;
-            }
+        // Stack entries: (node, parent_id, namespace_context, depth)
+        let mut stack: Vec<(Node, Option<String>, Vec<String>, usize)> =
+            vec![(root, None, Vec::new(), 0)];
+
+        while let Some((node, parent_id, namespace_ctx, depth)) = stack.pop() {
+            check_recursion_depth(node, file_path, depth, self.max_depth)?;
 
             // Skip preprocessor directives that don't contain code blocks
             let skip_kinds = [
@@ -138,7 +147,12 @@ impl CppAdapter {
             let mut cursor = node.walk();
             let children: Vec<_> = node.children(&mut cursor).collect();
             for child in children.into_iter().rev() {
-                stack.push((child, new_parent_id.clone(), new_namespace_ctx.clone()));
+                stack.push((
+                    child,
+                    new_parent_id.clone(),
+                    new_namespace_ctx.clone(),
+                    depth + 1,
+                ));
             }
         }
 
@@ -839,9 +853,17 @@ impl LanguageAdapter for CppAdapter {
         "cpp"
     }
 
+    fn set_max_ast_depth(&mut self, depth: usize) {
+        self.max_depth = depth;
+    }
+
+    /// Abandons parses still running once `deadline` passes.
+    fn set_parse_deadline(&mut self, deadline: Option<Instant>) {
+        self.deadline = deadline;
+    }
+
     fn parse_tree(&mut self, source_code: &str) -> Result<Tree> {
-        self.parser
-            .parse(source_code, None)
+        parse_until(&mut self.parser, source_code, self.deadline)
             .ok_or_else(|| ValknutError::parse("cpp", "Failed to parse C++ source code"))
     }
 
@@ -1281,29 +1281,21 @@ namespace detail {
 }
 
 #[test]
-fn test_regression_partial_parse_recovery() {
-    // Ensure we recover entities from files with parse errors
+fn test_parse_source_reports_syntax_error_location() {
     let mut adapter = CppAdapter::new().unwrap();
-    let source = r#"
-class ValidClass {
-    void validMethod();
-};
-
-// Some invalid syntax that might cause parse errors
-extern template class SomeTemplate<int>;
-
-class AnotherValid {
-    void anotherMethod();
-};
-"#;
-
-    let index = adapter.parse_source(source, "partial.cpp").unwrap();
-
-    // Should find at least some classes despite potential parse errors
-    let classes: Vec<_> = index
-        .entities
-        .values()
-        .filter(|e| e.kind == EntityKind::Class)
-        .collect();
-    assert!(classes.len() >= 1);
+    let source = "class ValidClass {\n    void validMethod();\n};\n}\n";
+
+    match adapter.parse_source(source, "broken.cpp") {
+        Err(crate::core::errors::ValknutError::Parse {
+            file_path,
+            line,
+            column,
+            ..
+        }) => {
+            assert_eq!(file_path.as_deref(), Some("broken.cpp"));
+            assert_eq!(line, Some(4));
+            assert_eq!(column, Some(1));
+        }
+        other => panic!("expected a located syntax error, got {other:?}"),
+    }
 }
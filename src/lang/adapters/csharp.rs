@@ -0,0 +1,982 @@
+//! C# language adapter with tree-sitter integration.
+//!
+//! Extracts namespaces (block and file-scoped), classes, records, structs,
+//! interfaces and enums, methods with full signatures including nullable
+//! reference annotations, and properties with their accessors. `using`
+//! directives are reported by [`LanguageAdapter::extract_imports`].
+//! Declarations of the same `partial` type are merged into a single entity
+//! (see [`merge_partial_types`]). LINQ query expressions are counted but
+//! treated as opaque: calls inside a query are not attributed to the
+//! enclosing method.
+
+use std::collections::HashMap;
+use std::time::Instant;
+use tracing::warn;
+use tree_sitter::{Language, Node, Parser, Tree};
+
+use super::super::common::{
+    check_syntax, create_base_metadata, extract_identifiers_by_kinds, generate_entity_id,
+    parse_until, simple_type_name, sort_and_dedup, text_of, EntityExtractor, EntityKind,
+    LanguageAdapter, ParseIndex, ParsedEntity, SourceLocation, DEFAULT_MAX_RECURSION_DEPTH,
+};
+use super::super::registry::{create_parser_for_language, get_tree_sitter_language};
+use crate::core::ast_utils::{find_child_by_kind, walk_tree};
+use crate::core::errors::{Result, ValknutError};
+use crate::core::featureset::CodeEntity;
+use crate::detectors::structure::config::ImportStatement;
+
+/// Node kinds that declare a named type.
+const TYPE_DECLARATION_KINDS: &[&str] = &[
+    "class_declaration",
+    "record_declaration",
+    "record_struct_declaration",
+    "struct_declaration",
+    "interface_declaration",
+    "enum_declaration",
+];
+
+/// Node kinds that declare a namespace.
+const NAMESPACE_KINDS: &[&str] = &["namespace_declaration", "file_scoped_namespace_declaration"];
+
+/// Node kinds that declare a method-like member.
+const METHOD_KINDS: &[&str] = &[
+    "method_declaration",
+    "constructor_declaration",
+    "local_function_statement",
+];
+
+/// C#-specific parsing and analysis
+pub struct CSharpAdapter {
+    /// Tree-sitter parser for C#
+    parser: Parser,
+
+    /// Language instance
+    language: Language,
+
+    /// Deepest AST node entity extraction visits
+    max_depth: usize,
+
+    /// Instant after which parses are abandoned (`--timeout`)
+    deadline: Option<Instant>,
+}
+
+/// Parsing and entity extraction methods for [`CSharpAdapter`].
+impl CSharpAdapter {
+    /// Create a new C# adapter
+    pub fn new() -> Result<Self> {
+        let language = get_tree_sitter_language("cs")?;
+        let parser = create_parser_for_language("cs")?;
+
+        Ok(Self {
+            parser,
+            language,
+            max_depth: DEFAULT_MAX_RECURSION_DEPTH,
+            deadline: None,
+        })
+    }
+
+    /// Parse C# source code and extract entities, merging partial types
+    pub fn parse_source(&mut self, source_code: &str, file_path: &str) -> Result<ParseIndex> {
+        let tree = parse_until(&mut self.parser, source_code, self.deadline).ok_or_else(|| {
+            ValknutError::parse_with_location(
+                "cs",
+                "Failed to parse C# source code",
+                file_path,
+                None,
+                None,
+            )
+        })?;
+        check_syntax(&tree, "cs", file_path)?;
+
+        let mut index = ParseIndex::new();
+        let mut entity_id_counter = 0;
+        self.extract_entities_iterative(
+            tree.root_node(),
+            source_code,
+            file_path,
+            &mut index,
+            &mut entity_id_counter,
+        )?;
+        merge_partial_types(&mut index);
+
+        Ok(index)
+    }
+
+    /// Extract entities from C# code and convert to CodeEntity format
+    pub fn extract_code_entities(
+        &mut self,
+        source_code: &str,
+        file_path: &str,
+    ) -> Result<Vec<CodeEntity>> {
+        let parse_index = self.parse_source(source_code, file_path)?;
+        Ok(parse_index
+            .entities
+            .values()
+            .map(|entity| entity.to_code_entity(source_code))
+            .collect())
+    }
+
+    /// Determine entity kind from node kind, returning None for non-entity nodes.
+    fn determine_entity_kind(node: &Node) -> Option<EntityKind> {
+        match node.kind() {
+            kind if NAMESPACE_KINDS.contains(&kind) => Some(EntityKind::Module),
+            "record_declaration" if is_record_struct(node) => Some(EntityKind::Struct),
+            "class_declaration" | "record_declaration" => Some(EntityKind::Class),
+            "struct_declaration" | "record_struct_declaration" => Some(EntityKind::Struct),
+            "interface_declaration" => Some(EntityKind::Interface),
+            "enum_declaration" => Some(EntityKind::Enum),
+            "method_declaration" | "constructor_declaration" => Some(EntityKind::Method),
+            "local_function_statement" => Some(EntityKind::Function),
+            "property_declaration" => Some(EntityKind::Variable),
+            _ => None,
+        }
+    }
+
+    /// Extract metadata based on node kind.
+    fn extract_entity_metadata(
+        &self,
+        node: &Node,
+        source_code: &str,
+        metadata: &mut HashMap<String, serde_json::Value>,
+    ) {
+        match node.kind() {
+            kind if NAMESPACE_KINDS.contains(&kind) => {
+                if kind == "file_scoped_namespace_declaration" {
+                    metadata.insert("is_file_scoped".to_string(), serde_json::Value::Bool(true));
+                }
+            }
+            kind if TYPE_DECLARATION_KINDS.contains(&kind) => {
+                self.extract_type_metadata(node, source_code, metadata)
+            }
+            kind if METHOD_KINDS.contains(&kind) => {
+                self.extract_method_metadata(node, source_code, metadata)
+            }
+            "property_declaration" => self.extract_property_metadata(node, source_code, metadata),
+            _ => {}
+        }
+    }
+
+    /// Extract base types, generics, modifiers and the qualified name of a type.
+    fn extract_type_metadata(
+        &self,
+        node: &Node,
+        source_code: &str,
+        metadata: &mut HashMap<String, serde_json::Value>,
+    ) {
+        insert_modifiers(node, source_code, metadata);
+
+        let type_parameters = type_parameters(node, source_code);
+        if !type_parameters.is_empty() {
+            metadata.insert(
+                "type_parameters".to_string(),
+                serde_json::json!(type_parameters),
+            );
+        }
+        let constraints = type_constraints(node, source_code);
+        if !constraints.is_empty() {
+            metadata.insert(
+                "type_constraints".to_string(),
+                serde_json::json!(constraints),
+            );
+        }
+
+        let base_types = base_types(node, source_code);
+        if !base_types.is_empty() {
+            metadata.insert("base_types".to_string(), serde_json::json!(base_types));
+        }
+
+        match node.kind() {
+            "record_declaration" | "record_struct_declaration" => {
+                metadata.insert("is_record".to_string(), serde_json::Value::Bool(true));
+                if let Some(parameters) = find_child_by_kind(node, "parameter_list") {
+                    let parameters = parameter_list(&parameters, source_code);
+                    metadata.insert(
+                        "record_components".to_string(),
+                        serde_json::json!(parameters.names),
+                    );
+                }
+            }
+            "enum_declaration" => {
+                let members = enum_members(node, source_code);
+                metadata.insert("enum_members".to_string(), serde_json::json!(members));
+            }
+            _ => {}
+        }
+
+        if let Some(namespace) = enclosing_namespace(node, source_code) {
+            metadata.insert(
+                "namespace".to_string(),
+                serde_json::Value::String(namespace),
+            );
+        }
+        metadata.insert(
+            "qualified_name".to_string(),
+            serde_json::Value::String(qualified_name(node, source_code)),
+        );
+    }
+
+    /// Extract the signature of a method, constructor or local function.
+    fn extract_method_metadata(
+        &self,
+        node: &Node,
+        source_code: &str,
+        metadata: &mut HashMap<String, serde_json::Value>,
+    ) {
+        let modifiers = insert_modifiers(node, source_code, metadata);
+
+        let parameters = node
+            .child_by_field_name("parameters")
+            .or_else(|| find_child_by_kind(node, "parameter_list"))
+            .map(|parameters| parameter_list(&parameters, source_code))
+            .unwrap_or_default();
+        metadata.insert(
+            "parameters".to_string(),
+            serde_json::json!(parameters.names),
+        );
+        metadata.insert(
+            "parameter_types".to_string(),
+            serde_json::json!(parameters.types),
+        );
+        if !parameters.nullable.is_empty() {
+            metadata.insert(
+                "nullable_parameters".to_string(),
+                serde_json::json!(parameters.nullable),
+            );
+        }
+        if parameters.is_extension {
+            metadata.insert("is_extension".to_string(), serde_json::Value::Bool(true));
+        }
+
+        if node.kind() == "constructor_declaration" {
+            metadata.insert("is_constructor".to_string(), serde_json::Value::Bool(true));
+        } else if let Some(return_type) = node
+            .child_by_field_name("returns")
+            .or_else(|| node.child_by_field_name("type"))
+        {
+            metadata.insert(
+                "return_type".to_string(),
+                serde_json::Value::String(text_of(&return_type, source_code)),
+            );
+            if return_type.kind() == "nullable_type" {
+                metadata.insert(
+                    "returns_nullable".to_string(),
+                    serde_json::Value::Bool(true),
+                );
+            }
+        }
+
+        let type_parameters = type_parameters(node, source_code);
+        if !type_parameters.is_empty() {
+            metadata.insert(
+                "type_parameters".to_string(),
+                serde_json::json!(type_parameters),
+            );
+        }
+        let constraints = type_constraints(node, source_code);
+        if !constraints.is_empty() {
+            metadata.insert(
+                "type_constraints".to_string(),
+                serde_json::json!(constraints),
+            );
+        }
+
+        if modifiers.iter().any(|modifier| modifier == "async") {
+            metadata.insert("is_async".to_string(), serde_json::Value::Bool(true));
+        }
+        if find_child_by_kind(node, "arrow_expression_clause").is_some() {
+            metadata.insert(
+                "is_expression_bodied".to_string(),
+                serde_json::Value::Bool(true),
+            );
+        }
+
+        let queries = count_linq_queries(node);
+        if queries > 0 {
+            metadata.insert("linq_queries".to_string(), serde_json::json!(queries));
+        }
+        metadata.insert(
+            "function_calls".to_string(),
+            serde_json::json!(collect_calls(node, source_code)),
+        );
+    }
+
+    /// Extract the type, nullability and accessors of a property.
+    fn extract_property_metadata(
+        &self,
+        node: &Node,
+        source_code: &str,
+        metadata: &mut HashMap<String, serde_json::Value>,
+    ) {
+        insert_modifiers(node, source_code, metadata);
+        metadata.insert("is_property".to_string(), serde_json::Value::Bool(true));
+
+        if let Some(ty) = node.child_by_field_name("type") {
+            metadata.insert(
+                "property_type".to_string(),
+                serde_json::Value::String(text_of(&ty, source_code)),
+            );
+            if ty.kind() == "nullable_type" {
+                metadata.insert("is_nullable".to_string(), serde_json::Value::Bool(true));
+            }
+        }
+
+        if let Some(accessors) = node
+            .child_by_field_name("accessors")
+            .or_else(|| find_child_by_kind(node, "accessor_list"))
+        {
+            metadata.insert(
+                "accessors".to_string(),
+                serde_json::json!(accessor_names(&accessors, source_code)),
+            );
+        } else if find_child_by_kind(node, "arrow_expression_clause").is_some() {
+            metadata.insert("accessors".to_string(), serde_json::json!(["get"]));
+            metadata.insert(
+                "is_expression_bodied".to_string(),
+                serde_json::Value::Bool(true),
+            );
+        }
+
+        let queries = count_linq_queries(node);
+        if queries > 0 {
+            metadata.insert("linq_queries".to_string(), serde_json::json!(queries));
+        }
+    }
+
+    /// Build an import statement from a `using` directive.
+    fn import_statement(
+        &self,
+        node: &Node,
+        source_code: &str,
+        line_number: usize,
+    ) -> Option<ImportStatement> {
+        let alias = node.child_by_field_name("name");
+        let mut cursor = node.walk();
+        let children: Vec<Node> = node.children(&mut cursor).collect();
+        let is_static = children.iter().any(|child| child.kind() == "static");
+        let target = children
+            .iter()
+            .rev()
+            .find(|child| child.is_named() && Some(**child) != alias)
+            .map(|child| text_of(child, source_code))?;
+
+        let (module, imports, import_type) = match (alias, is_static) {
+            (Some(alias), _) => (target, Some(vec![text_of(&alias, source_code)]), "alias"),
+            (None, true) => (target, None, "static"),
+            (None, false) => (target, None, "namespace"),
+        };
+
+        Some(ImportStatement {
+            module,
+            imports,
+            import_type: import_type.to_string(),
+            line_number,
+        })
+    }
+}
+
+/// [`LanguageAdapter`] implementation for C# source code.
+impl LanguageAdapter for CSharpAdapter {
+    /// Parses source code into a tree-sitter AST.
+    fn parse_tree(&mut self, source: &str) -> Result<Tree> {
+        parse_until(&mut self.parser, source, self.deadline)
+            .ok_or_else(|| ValknutError::parse("cs", "Failed to parse C# source"))
+    }
+
+    /// Parses C# source code and returns a parse index.
+    fn parse_source(&mut self, source: &str, file_path: &str) -> Result<ParseIndex> {
+        CSharpAdapter::parse_source(self, source, file_path)
+    }
+
+    /// Extracts invocation and constructor targets outside LINQ queries.
+    fn extract_function_calls(&mut self, source: &str) -> Result<Vec<String>> {
+        let tree = self.parse_tree(source)?;
+        Ok(collect_calls(&tree.root_node(), source))
+    }
+
+    /// Extracts all identifier tokens from the source.
+    fn extract_identifiers(&mut self, source: &str) -> Result<Vec<String>> {
+        let tree = self.parse_tree(source)?;
+        Ok(extract_identifiers_by_kinds(
+            tree.root_node(),
+            source,
+            &["identifier"],
+        ))
+    }
+
+    /// Counts distinct code blocks in the source.
+    fn count_distinct_blocks(&mut self, source: &str) -> Result<usize> {
+        let index = CSharpAdapter::parse_source(self, source, "<memory>")?;
+        Ok(index.count_distinct_blocks())
+    }
+
+    /// Returns the language name ("cs").
+    fn language_name(&self) -> &str {
+        "cs"
+    }
+
+    /// Bounds entity extraction to `depth` levels of AST nesting.
+    fn set_max_ast_depth(&mut self, depth: usize) {
+        self.max_depth = depth;
+    }
+
+    /// Abandons parses still running once `deadline` passes.
+    fn set_parse_deadline(&mut self, deadline: Option<Instant>) {
+        self.deadline = deadline;
+    }
+
+    /// Extracts `using` directives, including those nested in namespaces.
+    fn extract_imports(&mut self, source: &str) -> Result<Vec<ImportStatement>> {
+        let tree = self.parse_tree(source)?;
+        let mut imports = Vec::new();
+        walk_tree(tree.root_node(), &mut |node| {
+            if node.kind() == "using_directive" {
+                imports.extend(self.import_statement(&node, source, node.start_position().row + 1));
+            }
+        });
+        Ok(imports)
+    }
+
+    /// Extracts code entities from C# source code.
+    fn extract_code_entities(&mut self, source: &str, file_path: &str) -> Result<Vec<CodeEntity>> {
+        CSharpAdapter::extract_code_entities(self, source, file_path)
+    }
+}
+
+/// [`EntityExtractor`] implementation providing the language-specific node conversion.
+impl EntityExtractor for CSharpAdapter {
+    fn max_depth(&self) -> usize {
+        self.max_depth
+    }
+
+    fn node_to_entity(
+        &self,
+        node: Node,
+        source_code: &str,
+        file_path: &str,
+        parent_id: Option<String>,
+        entity_id_counter: &mut usize,
+    ) -> Result<Option<ParsedEntity>> {
+        let Some(entity_kind) = Self::determine_entity_kind(&node) else {
+            return Ok(None);
+        };
+
+        let name = node
+            .child_by_field_name("name")
+            .map(|name| text_of(&name, source_code))
+            .unwrap_or_else(|| entity_kind.fallback_name(*entity_id_counter));
+
+        *entity_id_counter += 1;
+        let entity_id = generate_entity_id(file_path, entity_kind, *entity_id_counter);
+        let location = SourceLocation::from_positions(
+            file_path,
+            node.start_position().row,
+            node.start_position().column,
+            node.end_position().row,
+            node.end_position().column,
+        );
+        let mut metadata = create_base_metadata(node.kind(), node.start_byte(), node.end_byte());
+        self.extract_entity_metadata(&node, source_code, &mut metadata);
+
+        Ok(Some(ParsedEntity {
+            id: entity_id,
+            kind: entity_kind,
+            name,
+            parent: parent_id,
+            children: Vec::new(),
+            location,
+            metadata,
+        }))
+    }
+}
+
+/// Default implementation for [`CSharpAdapter`].
+impl Default for CSharpAdapter {
+    /// Returns a new C# adapter, or a minimal fallback on failure.
+    fn default() -> Self {
+        Self::new().unwrap_or_else(|e| {
+            warn!("Failed to create C# adapter, using minimal fallback: {}", e);
+            CSharpAdapter {
+                parser: tree_sitter::Parser::new(),
+                language: get_tree_sitter_language("cs")
+                    .unwrap_or_else(|_| tree_sitter_c_sharp::LANGUAGE.into()),
+                max_depth: DEFAULT_MAX_RECURSION_DEPTH,
+                deadline: None,
+            }
+        })
+    }
+}
+
+/// Merge declarations of the same `partial` type into the earliest one.
+///
+/// Declarations are matched by kind, qualified name and generic arity, so the
+/// function also works on an index combining several files. The surviving
+/// entity collects the union of all base types and attributes plus a
+/// `partial_locations` list of every declaration's span; members of the
+/// dropped declarations are re-parented onto it.
+pub fn merge_partial_types(index: &mut ParseIndex) {
+    let mut groups: HashMap<(EntityKind, String, usize), Vec<String>> = HashMap::new();
+    for entity in index.entities.values() {
+        let is_partial = entity
+            .metadata
+            .get("is_partial")
+            .and_then(|value| value.as_bool())
+            .unwrap_or(false);
+        let Some(qualified) = entity
+            .metadata
+            .get("qualified_name")
+            .and_then(|value| value.as_str())
+        else {
+            continue;
+        };
+        if !is_partial {
+            continue;
+        }
+        let arity = metadata_strings(entity, "type_parameters").len();
+        groups
+            .entry((entity.kind, qualified.to_string(), arity))
+            .or_default()
+            .push(entity.id.clone());
+    }
+
+    for mut ids in groups.into_values().filter(|ids| ids.len() > 1) {
+        ids.sort_by(|a, b| {
+            let a = &index.entities[a].location;
+            let b = &index.entities[b].location;
+            (&a.file_path, a.start_line).cmp(&(&b.file_path, b.start_line))
+        });
+        let survivor_id = ids.remove(0);
+
+        let mut locations = vec![partial_location(&index.entities[&survivor_id])];
+        let mut base_types = metadata_strings(&index.entities[&survivor_id], "base_types");
+        let mut attributes = metadata_strings(&index.entities[&survivor_id], "attributes");
+        let mut children = Vec::new();
+        for id in &ids {
+            let Some(merged) = index.entities.remove(id) else {
+                continue;
+            };
+            locations.push(partial_location(&merged));
+            base_types.extend(metadata_strings(&merged, "base_types"));
+            attributes.extend(metadata_strings(&merged, "attributes"));
+            children.extend(merged.children);
+            if let Some(file_ids) = index.entities_by_file.get_mut(&merged.location.file_path) {
+                file_ids.retain(|file_id| file_id != id);
+            }
+        }
+
+        for entity in index.entities.values_mut() {
+            if entity
+                .parent
+                .as_ref()
+                .is_some_and(|parent| ids.contains(parent))
+            {
+                entity.parent = Some(survivor_id.clone());
+            }
+        }
+
+        let survivor = index
+            .entities
+            .get_mut(&survivor_id)
+            .expect("partial survivor stays in the index");
+        survivor.children.extend(children);
+        sort_and_dedup(&mut base_types);
+        sort_and_dedup(&mut attributes);
+        if !base_types.is_empty() {
+            survivor
+                .metadata
+                .insert("base_types".to_string(), serde_json::json!(base_types));
+        }
+        if !attributes.is_empty() {
+            survivor
+                .metadata
+                .insert("attributes".to_string(), serde_json::json!(attributes));
+        }
+        survivor.metadata.insert(
+            "partial_locations".to_string(),
+            serde_json::json!(locations),
+        );
+    }
+}
+
+/// Span of one partial declaration as stored in `partial_locations`.
+fn partial_location(entity: &ParsedEntity) -> serde_json::Value {
+    serde_json::json!({
+        "file_path": entity.location.file_path,
+        "start_line": entity.location.start_line,
+        "end_line": entity.location.end_line,
+    })
+}
+
+/// String array stored under `key` in an entity's metadata.
+fn metadata_strings(entity: &ParsedEntity, key: &str) -> Vec<String> {
+    entity
+        .metadata
+        .get(key)
+        .and_then(|value| value.as_array())
+        .map(|values| {
+            values
+                .iter()
+                .filter_map(|value| value.as_str().map(str::to_string))
+                .collect()
+        })
+        .unwrap_or_default()
+}
+
+/// True for `record struct` declarations in grammars that share `record_declaration`.
+fn is_record_struct(node: &Node) -> bool {
+    let name = node.child_by_field_name("name");
+    let mut cursor = node.walk();
+    let is_struct = node
+        .children(&mut cursor)
+        .take_while(|child| Some(*child) != name)
+        .any(|child| child.kind() == "struct");
+    is_struct
+}
+
+/// Dotted namespace enclosing a node, combining nested and file-scoped namespaces.
+fn enclosing_namespace(node: &Node, source_code: &str) -> Option<String> {
+    let mut segments = Vec::new();
+    let mut root = *node;
+    let mut current = node.parent();
+    while let Some(candidate) = current {
+        if NAMESPACE_KINDS.contains(&candidate.kind()) {
+            if let Some(name) = candidate.child_by_field_name("name") {
+                segments.push(text_of(&name, source_code));
+            }
+        }
+        root = candidate;
+        current = candidate.parent();
+    }
+
+    // Older grammars place file-scoped namespace members next to the declaration.
+    if segments.is_empty() {
+        if let Some(name) = find_child_by_kind(&root, "file_scoped_namespace_declaration")
+            .and_then(|declaration| declaration.child_by_field_name("name"))
+        {
+            segments.push(text_of(&name, source_code));
+        }
+    }
+
+    if segments.is_empty() {
+        return None;
+    }
+    segments.reverse();
+    Some(segments.join("."))
+}
+
+/// Namespace-qualified name of a type, including enclosing types (`Acme.Outer.Inner`).
+fn qualified_name(node: &Node, source_code: &str) -> String {
+    let mut segments: Vec<String> = node
+        .child_by_field_name("name")
+        .map(|name| text_of(&name, source_code))
+        .into_iter()
+        .collect();
+    let mut current = node.parent();
+    while let Some(candidate) = current {
+        if TYPE_DECLARATION_KINDS.contains(&candidate.kind()) {
+            if let Some(name) = candidate.child_by_field_name("name") {
+                segments.push(text_of(&name, source_code));
+            }
+        }
+        current = candidate.parent();
+    }
+    if let Some(namespace) = enclosing_namespace(node, source_code) {
+        segments.push(namespace);
+    }
+    segments.reverse();
+    segments.join(".")
+}
+
+/// Accessibility applied when a declaration has no explicit modifier.
+fn default_visibility(node: &Node) -> &'static str {
+    let mut current = node.parent();
+    while let Some(candidate) = current {
+        match candidate.kind() {
+            "interface_declaration" => return "public",
+            kind if TYPE_DECLARATION_KINDS.contains(&kind) => return "private",
+            _ => current = candidate.parent(),
+        }
+    }
+    "internal"
+}
+
+/// Record modifiers, visibility and attributes; returns the modifier keywords.
+fn insert_modifiers(
+    node: &Node,
+    source_code: &str,
+    metadata: &mut HashMap<String, serde_json::Value>,
+) -> Vec<String> {
+    let mut keywords = Vec::new();
+    let mut attributes = Vec::new();
+    let mut cursor = node.walk();
+    for child in node.children(&mut cursor) {
+        match child.kind() {
+            "modifier" => keywords.push(text_of(&child, source_code)),
+            "attribute_list" => {
+                let mut inner = child.walk();
+                for attribute in child.named_children(&mut inner) {
+                    if let Some(name) = attribute.child_by_field_name("name") {
+                        attributes.push(simple_type_name(&text_of(&name, source_code)));
+                    }
+                }
+            }
+            _ => {}
+        }
+    }
+
+    let visibility = match (
+        keywords.iter().any(|keyword| keyword == "public"),
+        keywords.iter().any(|keyword| keyword == "protected"),
+        keywords.iter().any(|keyword| keyword == "internal"),
+        keywords.iter().any(|keyword| keyword == "private"),
+    ) {
+        (true, _, _, _) => "public",
+        (_, true, true, _) => "protected internal",
+        (_, true, _, true) => "private protected",
+        (_, true, _, _) => "protected",
+        (_, _, true, _) => "internal",
+        (_, _, _, true) => "private",
+        _ => default_visibility(node),
+    };
+    metadata.insert(
+        "visibility".to_string(),
+        serde_json::Value::String(visibility.to_string()),
+    );
+    for flag in [
+        "static", "abstract", "sealed", "partial", "readonly", "virtual", "override",
+    ] {
+        if keywords.iter().any(|keyword| keyword == flag) {
+            metadata.insert(format!("is_{flag}"), serde_json::Value::Bool(true));
+        }
+    }
+    if !keywords.is_empty() {
+        metadata.insert("modifiers".to_string(), serde_json::json!(keywords));
+    }
+    if !attributes.is_empty() {
+        metadata.insert("attributes".to_string(), serde_json::json!(attributes));
+    }
+    keywords
+}
+
+/// Type parameter names, including variance (`out T`).
+fn type_parameters(node: &Node, source_code: &str) -> Vec<String> {
+    let Some(parameters) = node
+        .child_by_field_name("type_parameters")
+        .or_else(|| find_child_by_kind(node, "type_parameter_list"))
+    else {
+        return Vec::new();
+    };
+    let mut cursor = parameters.walk();
+    parameters
+        .named_children(&mut cursor)
+        .filter(|child| child.kind() == "type_parameter")
+        .map(|child| text_of(&child, source_code))
+        .collect()
+}
+
+/// `where` clauses constraining type parameters (`where T : class, new()`).
+fn type_constraints(node: &Node, source_code: &str) -> Vec<String> {
+    let mut cursor = node.walk();
+    node.children(&mut cursor)
+        .filter(|child| child.kind() == "type_parameter_constraints_clause")
+        .map(|child| text_of(&child, source_code))
+        .collect()
+}
+
+/// Types listed after the `:` of a type declaration.
+///
+/// C# does not distinguish a base class from implemented interfaces
+/// syntactically, so all of them are reported together.
+fn base_types(node: &Node, source_code: &str) -> Vec<String> {
+    let Some(list) = find_child_by_kind(node, "base_list") else {
+        return Vec::new();
+    };
+    let mut cursor = list.walk();
+    list.named_children(&mut cursor)
+        .filter_map(|child| match child.kind() {
+            "argument_list" => None,
+            "primary_constructor_base_type" => child
+                .child_by_field_name("type")
+                .or_else(|| child.named_child(0))
+                .map(|ty| text_of(&ty, source_code)),
+            _ => Some(text_of(&child, source_code)),
+        })
+        .collect()
+}
+
+/// Members declared in an enum body.
+fn enum_members(node: &Node, source_code: &str) -> Vec<String> {
+    let Some(body) = node
+        .child_by_field_name("body")
+        .or_else(|| find_child_by_kind(node, "enum_member_declaration_list"))
+    else {
+        return Vec::new();
+    };
+    let mut cursor = body.walk();
+    body.named_children(&mut cursor)
+        .filter(|child| child.kind() == "enum_member_declaration")
+        .filter_map(|child| child.child_by_field_name("name"))
+        .map(|name| text_of(&name, source_code))
+        .collect()
+}
+
+/// Parameters of a method, constructor or record declaration.
+#[derive(Debug, Default)]
+struct Parameters {
+    /// Parameter names in declaration order.
+    names: Vec<String>,
+    /// Declared types, including `ref`/`out`/`params` modifiers.
+    types: Vec<String>,
+    /// Names of parameters with a nullable (`T?`) type.
+    nullable: Vec<String>,
+    /// Whether the first parameter carries `this` (an extension method).
+    is_extension: bool,
+}
+
+/// Names, types and nullability of a `parameter_list`.
+fn parameter_list(parameters: &Node, source_code: &str) -> Parameters {
+    let mut result = Parameters::default();
+    let mut cursor = parameters.walk();
+    for (position, parameter) in parameters
+        .named_children(&mut cursor)
+        .filter(|child| matches!(child.kind(), "parameter" | "parameter_array"))
+        .enumerate()
+    {
+        let name_node = parameter.child_by_field_name("name").or_else(|| {
+            let mut inner = parameter.walk();
+            let last = parameter
+                .named_children(&mut inner)
+                .filter(|child| child.kind() == "identifier")
+                .last();
+            last
+        });
+        let name = name_node
+            .map(|name| text_of(&name, source_code))
+            .unwrap_or_default();
+        // `params` arrays do not label their type in every grammar version.
+        let ty = parameter
+            .child_by_field_name("type")
+            .or_else(|| name_node.and_then(|name| name.prev_named_sibling()));
+
+        let mut inner = parameter.walk();
+        let modifiers: Vec<String> = parameter
+            .children(&mut inner)
+            .take_while(|child| Some(*child) != ty && Some(*child) != name_node)
+            .filter(|child| child.kind() != "attribute_list")
+            .map(|child| text_of(&child, source_code))
+            .collect();
+        if position == 0 && modifiers.iter().any(|modifier| modifier == "this") {
+            result.is_extension = true;
+        }
+
+        if let Some(ty) = ty {
+            let declared = text_of(&ty, source_code);
+            let passing: Vec<&String> = modifiers
+                .iter()
+                .filter(|modifier| matches!(modifier.as_str(), "ref" | "out" | "in" | "params"))
+                .collect();
+            result.types.push(match passing.as_slice() {
+                [] => declared,
+                modifiers => format!(
+                    "{} {}",
+                    modifiers
+                        .iter()
+                        .map(|modifier| modifier.as_str())
+                        .collect::<Vec<_>>()
+                        .join(" "),
+                    declared
+                ),
+            });
+            if ty.kind() == "nullable_type" {
+                result.nullable.push(name.clone());
+            }
+        }
+        result.names.push(name);
+    }
+    result
+}
+
+/// Accessor keywords of a property (`get`, `set`, `init`).
+fn accessor_names(accessors: &Node, source_code: &str) -> Vec<String> {
+    let mut cursor = accessors.walk();
+    accessors
+        .named_children(&mut cursor)
+        .filter(|child| child.kind() == "accessor_declaration")
+        .filter_map(|child| {
+            child
+                .child_by_field_name("name")
+                .map(|name| text_of(&name, source_code))
+                .or_else(|| {
+                    let mut inner = child.walk();
+                    let keyword = child
+                        .children(&mut inner)
+                        .find(|token| matches!(token.kind(), "get" | "set" | "init"))
+                        .map(|token| token.kind().to_string());
+                    keyword
+                })
+        })
+        .collect()
+}
+
+/// Number of LINQ query expressions (`from x in xs ...`) inside a node.
+fn count_linq_queries(node: &Node) -> usize {
+    let mut count = 0;
+    walk_tree(*node, &mut |child| {
+        if child.kind() == "query_expression" {
+            count += 1;
+        }
+    });
+    count
+}
+
+/// Invocation targets (`obj.Name` or `Name`) and constructed types (`new Type`).
+///
+/// LINQ query expressions are opaque: their clauses are not descended into.
+fn collect_calls(node: &Node, source_code: &str) -> Vec<String> {
+    let mut calls = Vec::new();
+    let mut stack = vec![*node];
+    while let Some(current) = stack.pop() {
+        match current.kind() {
+            "query_expression" => continue,
+            "invocation_expression" => {
+                if let Some(function) = current.child_by_field_name("function") {
+                    calls.push(call_target(&function, source_code));
+                }
+            }
+            "object_creation_expression" => {
+                if let Some(ty) = current.child_by_field_name("type") {
+                    calls.push(format!(
+                        "new {}",
+                        simple_type_name(&text_of(&ty, source_code))
+                    ));
+                }
+            }
+            _ => {}
+        }
+        let mut cursor = current.walk();
+        let children: Vec<Node> = current.children(&mut cursor).collect();
+        stack.extend(children.into_iter().rev());
+    }
+    sort_and_dedup(&mut calls);
+    calls
+}
+
+/// Name of an invoked function with generic arguments removed.
+fn call_target(function: &Node, source_code: &str) -> String {
+    match function.kind() {
+        "member_access_expression" => {
+            let name = function
+                .child_by_field_name("name")
+                .map(|name| simple_type_name(&text_of(&name, source_code)))
+                .unwrap_or_default();
+            match function.child_by_field_name("expression") {
+                Some(object) => format!("{}.{}", text_of(&object, source_code), name),
+                None => name,
+            }
+        }
+        _ => simple_type_name(&text_of(function, source_code)),
+    }
+}
+
+#[cfg(test)]
+#[path = "csharp_tests.rs"]
+mod tests;
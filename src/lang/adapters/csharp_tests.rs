@@ -0,0 +1,281 @@
+use super::*;
+
+fn entity<'a>(index: &'a ParseIndex, name: &str) -> &'a ParsedEntity {
+    index
+        .entities
+        .values()
+        .find(|e| e.name == name)
+        .unwrap_or_else(|| panic!("entity {name} not found"))
+}
+
+fn strings(entity: &ParsedEntity, key: &str) -> Vec<String> {
+    metadata_strings(entity, key)
+}
+
+#[test]
+fn test_csharp_adapter_creation() {
+    let adapter = CSharpAdapter::new();
+    assert!(adapter.is_ok());
+}
+
+#[test]
+fn test_type_declarations() {
+    let mut adapter = CSharpAdapter::new().unwrap();
+    let source = r#"
+namespace Acme.Orders
+{
+    public abstract class Repository<T> : Base, IDisposable where T : class
+    {
+        internal class Cursor { }
+    }
+
+    interface IStore<in K, out V> : IReadable<K> { }
+
+    public enum Status { Open, Closed }
+
+    public record Point(int X, int Y);
+
+    public readonly struct Money { }
+}
+"#;
+
+    let index = adapter.parse_source(source, "Repository.cs").unwrap();
+
+    let namespace = entity(&index, "Acme.Orders");
+    assert_eq!(namespace.kind, EntityKind::Module);
+
+    let repository = entity(&index, "Repository");
+    assert_eq!(repository.kind, EntityKind::Class);
+    assert_eq!(
+        strings(repository, "base_types"),
+        vec!["Base", "IDisposable"]
+    );
+    assert_eq!(strings(repository, "type_parameters"), vec!["T"]);
+    assert_eq!(
+        strings(repository, "type_constraints"),
+        vec!["where T : class"]
+    );
+    assert_eq!(repository.metadata["visibility"], "public");
+    assert_eq!(repository.metadata["is_abstract"], true);
+    assert_eq!(repository.metadata["namespace"], "Acme.Orders");
+    assert_eq!(repository.parent.as_deref(), Some(namespace.id.as_str()));
+
+    let cursor = entity(&index, "Cursor");
+    assert_eq!(
+        cursor.metadata["qualified_name"],
+        "Acme.Orders.Repository.Cursor"
+    );
+    assert_eq!(cursor.metadata["visibility"], "internal");
+
+    let store = entity(&index, "IStore");
+    assert_eq!(store.kind, EntityKind::Interface);
+    assert_eq!(store.metadata["visibility"], "internal");
+    assert_eq!(strings(store, "base_types"), vec!["IReadable<K>"]);
+
+    let status = entity(&index, "Status");
+    assert_eq!(status.kind, EntityKind::Enum);
+    assert_eq!(strings(status, "enum_members"), vec!["Open", "Closed"]);
+
+    let point = entity(&index, "Point");
+    assert_eq!(point.kind, EntityKind::Class);
+    assert_eq!(point.metadata["is_record"], true);
+    assert_eq!(strings(point, "record_components"), vec!["X", "Y"]);
+
+    let money = entity(&index, "Money");
+    assert_eq!(money.kind, EntityKind::Struct);
+    assert_eq!(money.metadata["is_readonly"], true);
+}
+
+#[test]
+fn test_file_scoped_namespace() {
+    let mut adapter = CSharpAdapter::new().unwrap();
+    let source = r#"
+namespace Acme.Billing;
+
+public class Invoice { }
+"#;
+
+    let index = adapter.parse_source(source, "Invoice.cs").unwrap();
+    let namespace = entity(&index, "Acme.Billing");
+    assert_eq!(namespace.metadata["is_file_scoped"], true);
+
+    let invoice = entity(&index, "Invoice");
+    assert_eq!(invoice.metadata["qualified_name"], "Acme.Billing.Invoice");
+}
+
+#[test]
+fn test_method_signatures_with_nullable_annotations() {
+    let mut adapter = CSharpAdapter::new().unwrap();
+    let source = r#"
+public static class Loader
+{
+    public static async Task<string?> LoadAsync(this Stream stream, string? path, ref int count)
+    {
+        var reader = new StreamReader(stream);
+        return await reader.ReadToEndAsync();
+    }
+
+    [Obsolete]
+    protected internal virtual T Pick<T>(List<T> items) where T : new() => items.First();
+}
+"#;
+
+    let index = adapter.parse_source(source, "Loader.cs").unwrap();
+
+    let load = entity(&index, "LoadAsync");
+    assert_eq!(load.kind, EntityKind::Method);
+    assert_eq!(strings(load, "parameters"), vec!["stream", "path", "count"]);
+    assert_eq!(
+        strings(load, "parameter_types"),
+        vec!["Stream", "string?", "ref int"]
+    );
+    assert_eq!(strings(load, "nullable_parameters"), vec!["path"]);
+    assert_eq!(load.metadata["return_type"], "Task<string?>");
+    assert_eq!(load.metadata["is_async"], true);
+    assert_eq!(load.metadata["is_extension"], true);
+    assert_eq!(
+        strings(load, "function_calls"),
+        vec!["new StreamReader", "reader.ReadToEndAsync"]
+    );
+
+    let pick = entity(&index, "Pick");
+    assert_eq!(pick.metadata["visibility"], "protected internal");
+    assert_eq!(pick.metadata["is_virtual"], true);
+    assert_eq!(pick.metadata["is_expression_bodied"], true);
+    assert_eq!(strings(pick, "attributes"), vec!["Obsolete"]);
+    assert_eq!(strings(pick, "type_parameters"), vec!["T"]);
+    assert_eq!(strings(pick, "function_calls"), vec!["items.First"]);
+}
+
+#[test]
+fn test_properties() {
+    let mut adapter = CSharpAdapter::new().unwrap();
+    let source = r#"
+class Customer
+{
+    public string Name { get; init; }
+    public string? Email { get; set; }
+    public bool HasEmail => Email != null;
+}
+"#;
+
+    let index = adapter.parse_source(source, "Customer.cs").unwrap();
+
+    let name = entity(&index, "Name");
+    assert_eq!(name.kind, EntityKind::Variable);
+    assert_eq!(name.metadata["property_type"], "string");
+    assert_eq!(strings(name, "accessors"), vec!["get", "init"]);
+
+    let email = entity(&index, "Email");
+    assert_eq!(email.metadata["is_nullable"], true);
+
+    let has_email = entity(&index, "HasEmail");
+    assert_eq!(has_email.metadata["is_expression_bodied"], true);
+    assert_eq!(strings(has_email, "accessors"), vec!["get"]);
+}
+
+#[test]
+fn test_partial_classes_are_merged() {
+    let mut adapter = CSharpAdapter::new().unwrap();
+    let source = r#"
+namespace Acme
+{
+    public partial class Order : IComparable<Order>
+    {
+        public void Submit() { }
+    }
+
+    [Serializable]
+    public partial class Order : IDisposable
+    {
+        public void Dispose() { }
+    }
+}
+"#;
+
+    let index = adapter.parse_source(source, "Order.cs").unwrap();
+    let orders: Vec<_> = index
+        .entities
+        .values()
+        .filter(|e| e.name == "Order")
+        .collect();
+    assert_eq!(orders.len(), 1, "partial declarations should be merged");
+
+    let order = orders[0];
+    assert_eq!(order.location.start_line, 4);
+    assert_eq!(
+        strings(order, "base_types"),
+        vec!["IComparable<Order>", "IDisposable"]
+    );
+    assert_eq!(strings(order, "attributes"), vec!["Serializable"]);
+    assert_eq!(
+        order.metadata["partial_locations"]
+            .as_array()
+            .map(|locations| locations.len()),
+        Some(2)
+    );
+
+    for method in ["Submit", "Dispose"] {
+        assert_eq!(
+            entity(&index, method).parent.as_deref(),
+            Some(order.id.as_str())
+        );
+    }
+    assert_eq!(index.get_entities_in_file("Order.cs").len(), 4);
+}
+
+#[test]
+fn test_linq_queries_are_opaque() {
+    let mut adapter = CSharpAdapter::new().unwrap();
+    let source = r#"
+class Report
+{
+    IEnumerable<string> Names(IEnumerable<Customer> customers)
+    {
+        Log("query");
+        return from c in customers
+               where c.IsActive()
+               select c.Name;
+    }
+}
+"#;
+
+    let index = adapter.parse_source(source, "Report.cs").unwrap();
+    let names = entity(&index, "Names");
+    assert_eq!(names.metadata["linq_queries"], 1);
+    assert_eq!(strings(names, "function_calls"), vec!["Log"]);
+}
+
+#[test]
+fn test_using_directives() {
+    let mut adapter = CSharpAdapter::new().unwrap();
+    let source = r#"
+using System;
+using static System.Math;
+using Json = Newtonsoft.Json;
+
+namespace Acme
+{
+    using System.Linq;
+}
+"#;
+
+    let imports = adapter.extract_imports(source).unwrap();
+    assert_eq!(imports.len(), 4);
+
+    assert_eq!(imports[0].module, "System");
+    assert_eq!(imports[0].import_type, "namespace");
+    assert_eq!(imports[1].module, "System.Math");
+    assert_eq!(imports[1].import_type, "static");
+    assert_eq!(imports[2].module, "Newtonsoft.Json");
+    assert_eq!(imports[2].imports, Some(vec!["Json".to_string()]));
+    assert_eq!(imports[2].import_type, "alias");
+    assert_eq!(imports[3].module, "System.Linq");
+    assert_eq!(imports[3].line_number, 9);
+}
+
+#[test]
+fn test_language_name() {
+    let adapter = CSharpAdapter::new().unwrap();
+    assert_eq!(adapter.language_name(), "cs");
+}
@@ -1,12 +1,15 @@
 //! Go language adapter with tree-sitter integration.
 
 use std::collections::HashMap;
+use std::time::Instant;
+use tracing::warn;
 use tree_sitter::{Language, Node, Parser, Tree};
 
 use super::super::common::{
-    create_base_metadata, extract_identifiers_by_kinds, extract_node_text, generate_entity_id,
-    sort_and_dedup, EntityExtractor, EntityKind, LanguageAdapter, ParseIndex, ParsedEntity,
-    SourceLocation,
+    check_recursion_depth, check_syntax, create_base_metadata, extract_identifiers_by_kinds,
+    extract_node_text, generate_entity_id, parse_until, sort_and_dedup, EntityExtractor,
+    EntityKind, LanguageAdapter, ParseIndex, ParsedEntity, SourceLocation,
+    DEFAULT_MAX_RECURSION_DEPTH,
 };
 use super::super::registry::{create_parser_for_language, get_tree_sitter_language};
 use crate::core::ast_utils::{find_child_by_kind, node_text_normalized, walk_tree};
@@ -21,6 +24,12 @@ pub struct GoAdapter {
 
     /// Language instance
     language: Language,
+
+    /// Deepest AST node entity extraction visits
+    max_depth: usize,
+
+    /// Instant after which parses are abandoned (`--timeout`)
+    deadline: Option<Instant>,
 }
 
 /// Parsing and entity extraction methods for [`GoAdapter`].
@@ -30,15 +39,26 @@ impl GoAdapter {
         let language = get_tree_sitter_language("go")?;
         let parser = create_parser_for_language("go")?;
 
-        Ok(Self { parser, language })
+        Ok(Self {
+            parser,
+            language,
+            max_depth: DEFAULT_MAX_RECURSION_DEPTH,
+            deadline: None,
+        })
     }
 
     /// Parse Go source code and extract entities
     pub fn parse_source(&mut self, source_code: &str, file_path: &str) -> Result<ParseIndex> {
-        let tree = self
-            .parser
-            .parse(source_code, None)
-            .ok_or_else(|| ValknutError::parse("go", "Failed to parse Go source code"))?;
+        let tree = parse_until(&mut self.parser, source_code, self.deadline).ok_or_else(|| {
+            ValknutError::parse_with_location(
+                "go",
+                "Failed to parse Go source code",
+                file_path,
+                None,
+                None,
+            )
+        })?;
+        check_syntax(&tree, "go", file_path)?;
 
         let mut index = ParseIndex::new();
         let mut entity_id_counter = 0;
@@ -81,6 +101,7 @@ impl GoAdapter {
         parent_id: Option<String>,
         index: &mut ParseIndex,
         entity_id_counter: &mut usize,
+        depth: usize,
     ) -> Result<()> {
         let entity_kind = match node.kind() {
             "const_declaration" => EntityKind::Constant,
@@ -89,8 +110,13 @@ impl GoAdapter {
         };
 
         let identifiers = self.extract_all_identifiers_from_declaration(&node, source_code)?;
+        let bindings = if entity_kind == EntityKind::Variable {
+            Self::collect_var_bindings(&node, source_code)?
+        } else {
+            Vec::new()
+        };
 
-        for identifier in identifiers {
+        for (position, identifier) in identifiers.into_iter().enumerate() {
             *entity_id_counter += 1;
             let entity_id = generate_entity_id(file_path, entity_kind, *entity_id_counter);
 
@@ -102,7 +128,11 @@ impl GoAdapter {
                 node.end_position().column,
             );
 
-            let metadata = create_base_metadata(node.kind(), node.start_byte(), node.end_byte());
+            let mut metadata =
+                create_base_metadata(node.kind(), node.start_byte(), node.end_byte());
+            if let Some(binding) = bindings.get(position) {
+                binding.insert_into(&mut metadata);
+            }
 
             let entity = ParsedEntity {
                 id: entity_id,
@@ -124,6 +154,7 @@ impl GoAdapter {
             parent_id,
             index,
             entity_id_counter,
+            depth,
         )
     }
 
@@ -137,10 +168,12 @@ impl GoAdapter {
         index: &mut ParseIndex,
         entity_id_counter: &mut usize,
     ) -> Result<()> {
-        // Stack entries: (node, parent_id)
-        let mut stack: Vec<(Node, Option<String>)> = vec![(root, None)];
+        // Stack entries: (node, parent_id, depth)
+        let mut stack: Vec<(Node, Option<String>, usize)> = vec![(root, None, 0)];
+
+        while let Some((node, parent_id, depth)) = stack.pop() {
+            check_recursion_depth(node, file_path, depth, self.max_depth)?;
 
-        while let Some((node, parent_id)) = stack.pop() {
             // Handle grouped const/var declarations specially
             if node.kind() == "const_declaration" || node.kind() == "var_declaration" {
                 self.handle_grouped_declaration(
@@ -150,6 +183,7 @@ impl GoAdapter {
                     parent_id.clone(),
                     index,
                     entity_id_counter,
+                    depth,
                 )?;
                 // handle_grouped_declaration processes children via traverse_children,
                 // but those children don't contain nested entities, so we continue
@@ -175,7 +209,7 @@ impl GoAdapter {
             let mut cursor = node.walk();
             let children: Vec<_> = node.children(&mut cursor).collect();
             for child in children.into_iter().rev() {
-                stack.push((child, new_parent_id.clone()));
+                stack.push((child, new_parent_id.clone(), depth + 1));
             }
         }
 
@@ -222,7 +256,7 @@ impl GoAdapter {
     ) -> Result<()> {
         let mut cursor = spec_node.walk();
         for child in spec_node.children(&mut cursor) {
-            if child.kind() == "identifier" {
+            if matches!(child.kind(), "identifier" | "blank_identifier") {
                 identifiers.push(child.utf8_text(source_code.as_bytes())?.to_string());
             }
         }
@@ -246,6 +280,106 @@ impl GoAdapter {
         Ok(())
     }
 
+    /// Declared type and value shape of every variable in a `var` declaration,
+    /// in the order [`extract_all_identifiers_from_declaration`] lists them.
+    ///
+    /// [`extract_all_identifiers_from_declaration`]: Self::extract_all_identifiers_from_declaration
+    fn collect_var_bindings(node: &Node, source_code: &str) -> Result<Vec<VarBinding>> {
+        let mut specs = Vec::new();
+        let mut cursor = node.walk();
+        for child in node.children(&mut cursor) {
+            match child.kind() {
+                "var_spec" => specs.push(child),
+                "var_spec_list" => {
+                    let mut list_cursor = child.walk();
+                    specs.extend(
+                        child
+                            .children(&mut list_cursor)
+                            .filter(|spec| spec.kind() == "var_spec"),
+                    );
+                }
+                _ => {}
+            }
+        }
+
+        let mut bindings = Vec::new();
+        for spec in specs {
+            let var_type = spec
+                .child_by_field_name("type")
+                .map(|t| t.utf8_text(source_code.as_bytes()).map(str::to_string))
+                .transpose()?;
+            let values: Vec<Node> = spec
+                .child_by_field_name("value")
+                .map(|list| {
+                    let mut list_cursor = list.walk();
+                    list.named_children(&mut list_cursor).collect()
+                })
+                .unwrap_or_default();
+
+            let mut spec_cursor = spec.walk();
+            let names = spec
+                .children(&mut spec_cursor)
+                .filter(|child| matches!(child.kind(), "identifier" | "blank_identifier"));
+            for (position, _) in names.enumerate() {
+                let mut binding = VarBinding {
+                    var_type: var_type.clone(),
+                    ..VarBinding::default()
+                };
+                if let Some(value) = values.get(position) {
+                    Self::describe_value(value, source_code, &mut binding)?;
+                }
+                bindings.push(binding);
+            }
+        }
+        Ok(bindings)
+    }
+
+    /// Record the concrete type (`&Memory{}`, `(*Memory)(nil)`) or the callee
+    /// (`NewMemory()`) of a variable's initial value.
+    fn describe_value(value: &Node, source_code: &str, binding: &mut VarBinding) -> Result<()> {
+        let text = |node: Node| -> Result<String> {
+            Ok(node.utf8_text(source_code.as_bytes())?.trim().to_string())
+        };
+        let unparenthesized = |text: String| -> String {
+            text.trim_start_matches('(')
+                .trim_end_matches(')')
+                .trim()
+                .to_string()
+        };
+        match value.kind() {
+            "composite_literal" => {
+                binding.value_type = value.child_by_field_name("type").map(text).transpose()?;
+            }
+            "unary_expression" => {
+                let operand = value
+                    .child_by_field_name("operand")
+                    .filter(|operand| operand.kind() == "composite_literal")
+                    .and_then(|literal| literal.child_by_field_name("type"));
+                if let Some(literal_type) = operand {
+                    binding.value_type = Some(format!("*{}", text(literal_type)?));
+                }
+            }
+            "call_expression" => {
+                let Some(function) = value.child_by_field_name("function") else {
+                    return Ok(());
+                };
+                if function.kind() == "parenthesized_expression" {
+                    // Conversion such as `(*Memory)(nil)`
+                    binding.value_type = Some(unparenthesized(text(function)?));
+                } else {
+                    binding.value_call = Some(text(function)?);
+                }
+            }
+            "type_conversion_expression" => {
+                if let Some(converted) = value.child_by_field_name("type") {
+                    binding.value_type = Some(unparenthesized(text(converted)?));
+                }
+            }
+            _ => {}
+        }
+        Ok(())
+    }
+
     /// Determine entity kind from node kind, returning None for non-entity nodes.
     fn determine_entity_kind(&self, node: &Node, source_code: &str) -> Result<Option<EntityKind>> {
         Ok(match node.kind() {
@@ -405,13 +539,19 @@ impl GoAdapter {
             .transpose()?
             .unwrap_or_default();
 
-        let receiver_type = if node.kind() == "method_declaration" {
+        let receiver = if node.kind() == "method_declaration" {
             node.child_by_field_name("receiver")
-                .map(|r| r.utf8_text(source_code.as_bytes()).map(|s| s.to_string()))
-                .transpose()?
         } else {
             None
         };
+        let receiver_type = receiver
+            .map(|r| r.utf8_text(source_code.as_bytes()).map(|s| s.to_string()))
+            .transpose()?;
+        let receiver_base_type = receiver
+            .and_then(|r| Self::find_descendant_of_kind(&r, "type_identifier"))
+            .map(|t| t.utf8_text(source_code.as_bytes()).map(|s| s.to_string()))
+            .transpose()?;
+        let function_calls = Self::collect_function_calls(node, source_code);
 
         metadata.insert("parameters".to_string(), serde_json::json!(parameters));
         if !return_types.is_empty() {
@@ -423,10 +563,91 @@ impl GoAdapter {
                 serde_json::Value::String(receiver),
             );
         }
+        if let Some(base_type) = receiver_base_type {
+            metadata.insert(
+                "receiver_base_type".to_string(),
+                serde_json::Value::String(base_type),
+            );
+        }
+        metadata.insert(
+            "function_calls".to_string(),
+            serde_json::json!(function_calls),
+        );
 
         Ok(())
     }
 
+    /// Collect call targets and function-value references inside a function body.
+    ///
+    /// Calls made from `go func() { ... }()` and `defer` closures are attributed to
+    /// the enclosing function. Functions used as values (`h := handle`,
+    /// `http.HandleFunc("/", handle)`) are recorded as well so that reachability
+    /// follows them even though they are not invoked directly.
+    fn collect_function_calls(node: &Node, source_code: &str) -> Vec<String> {
+        let Some(body) = node.child_by_field_name("body") else {
+            return Vec::new();
+        };
+
+        let mut calls = Vec::new();
+        walk_tree(body, &mut |child| match child.kind() {
+            "call_expression" => {
+                if let Some(callee) = child.child_by_field_name("function") {
+                    if callee.kind() != "func_literal" {
+                        Self::push_reference(&callee, source_code, &mut calls);
+                    }
+                }
+                if let Some(arguments) = child.child_by_field_name("arguments") {
+                    Self::push_value_references(&arguments, source_code, &mut calls);
+                }
+            }
+            "short_var_declaration" | "assignment_statement" => {
+                if let Some(right) = child.child_by_field_name("right") {
+                    Self::push_value_references(&right, source_code, &mut calls);
+                }
+            }
+            "var_spec" => {
+                if let Some(value) = child.child_by_field_name("value") {
+                    Self::push_value_references(&value, source_code, &mut calls);
+                }
+            }
+            _ => {}
+        });
+
+        sort_and_dedup(&mut calls);
+        calls
+    }
+
+    /// Record bare identifiers and selectors in an expression list as function-value references.
+    fn push_value_references(list: &Node, source_code: &str, calls: &mut Vec<String>) {
+        let mut cursor = list.walk();
+        for value in list.named_children(&mut cursor) {
+            if matches!(value.kind(), "identifier" | "selector_expression") {
+                Self::push_reference(&value, source_code, calls);
+            }
+        }
+    }
+
+    /// Push the normalized text of a callee or function-value node.
+    fn push_reference(node: &Node, source_code: &str, calls: &mut Vec<String>) {
+        if let Ok(text) = node_text_normalized(node, source_code) {
+            let cleaned = text.trim();
+            if !cleaned.is_empty() && !matches!(cleaned, "nil" | "true" | "false") {
+                calls.push(cleaned.to_string());
+            }
+        }
+    }
+
+    /// Depth-first search for the first descendant of the given kind.
+    fn find_descendant_of_kind<'a>(node: &Node<'a>, kind: &str) -> Option<Node<'a>> {
+        let mut found = None;
+        walk_tree(*node, &mut |child| {
+            if found.is_none() && child.kind() == kind {
+                found = Some(child);
+            }
+        });
+        found
+    }
+
     /// Extract struct-specific metadata
     fn extract_struct_metadata(
         &self,
@@ -438,7 +659,8 @@ impl GoAdapter {
             return Ok(());
         };
 
-        let (fields, embedded_types) = self.parse_struct_fields(&struct_type, source_code)?;
+        let (fields, embedded_types, mut field_types) =
+            self.parse_struct_fields(&struct_type, source_code)?;
 
         metadata.insert("fields".to_string(), serde_json::json!(fields));
         if !embedded_types.is_empty() {
@@ -447,21 +669,26 @@ impl GoAdapter {
                 serde_json::json!(embedded_types),
             );
         }
+        sort_and_dedup(&mut field_types);
+        if !field_types.is_empty() {
+            metadata.insert("field_types".to_string(), serde_json::json!(field_types));
+        }
 
         Ok(())
     }
 
-    /// Parse struct fields and embedded types from a struct_type node
+    /// Parse struct fields, embedded types, and the named types of fields from a struct_type node
     fn parse_struct_fields<'a>(
         &self,
         struct_node: &Node<'a>,
         source_code: &'a str,
-    ) -> Result<(Vec<String>, Vec<&'a str>)> {
+    ) -> Result<(Vec<String>, Vec<&'a str>, Vec<String>)> {
         let mut fields = Vec::new();
         let mut embedded_types = Vec::new();
+        let mut field_types = Vec::new();
 
         let Some(field_list) = find_child_by_kind(struct_node, "field_declaration_list") else {
-            return Ok((fields, embedded_types));
+            return Ok((fields, embedded_types, field_types));
         };
 
         let mut cursor = field_list.walk();
@@ -475,9 +702,29 @@ impl GoAdapter {
                 &mut fields,
                 &mut embedded_types,
             )?;
+            if let Some(field_type) = field_child.child_by_field_name("type") {
+                Self::collect_type_names(&field_type, source_code, &mut field_types);
+            }
         }
 
-        Ok((fields, embedded_types))
+        Ok((fields, embedded_types, field_types))
+    }
+
+    /// Named types referenced by a type expression (`*Store`, `[]db.Conn`, `map[string]Item`).
+    fn collect_type_names(node: &Node, source_code: &str, names: &mut Vec<String>) {
+        match node.kind() {
+            "type_identifier" | "qualified_type" => {
+                if let Ok(text) = node.utf8_text(source_code.as_bytes()) {
+                    names.push(text.to_string());
+                }
+            }
+            _ => {
+                let mut cursor = node.walk();
+                for child in node.named_children(&mut cursor) {
+                    Self::collect_type_names(&child, source_code, names);
+                }
+            }
+        }
     }
 
     /// Parse a single field declaration
@@ -494,7 +741,9 @@ impl GoAdapter {
         for child in field_node.children(&mut cursor) {
             if child.kind() == "field_identifier" {
                 field_name = Some(child.utf8_text(source_code.as_bytes())?.to_string());
-            } else if child.kind() == "type_identifier" && field_name.is_none() {
+            } else if matches!(child.kind(), "type_identifier" | "qualified_type")
+                && field_name.is_none()
+            {
                 embedded_types.push(child.utf8_text(source_code.as_bytes())?);
             }
         }
@@ -645,8 +894,7 @@ impl GoAdapter {
 impl LanguageAdapter for GoAdapter {
     /// Parses source code into a tree-sitter AST.
     fn parse_tree(&mut self, source: &str) -> Result<Tree> {
-        self.parser
-            .parse(source, None)
+        parse_until(&mut self.parser, source, self.deadline)
             .ok_or_else(|| ValknutError::parse("go", "Failed to parse Go source"))
     }
 
@@ -707,6 +955,16 @@ impl LanguageAdapter for GoAdapter {
         "go"
     }
 
+    /// Bounds entity extraction to `depth` levels of AST nesting.
+    fn set_max_ast_depth(&mut self, depth: usize) {
+        self.max_depth = depth;
+    }
+
+    /// Abandons parses still running once `deadline` passes.
+    fn set_parse_deadline(&mut self, deadline: Option<Instant>) {
+        self.deadline = deadline;
+    }
+
     /// Extracts import statements from Go source code.
     fn extract_imports(&mut self, source: &str) -> Result<Vec<ImportStatement>> {
         let mut imports = Vec::new();
@@ -756,6 +1014,33 @@ impl LanguageAdapter for GoAdapter {
     }
 }
 
+/// Declared type and initial value shape of one variable in a `var` spec.
+#[derive(Debug, Clone, Default)]
+struct VarBinding {
+    /// Declared type, as written (`store.Store`).
+    var_type: Option<String>,
+    /// Concrete type of a literal or conversion value (`*Memory`).
+    value_type: Option<String>,
+    /// Callee of a call value (`store.NewMemory`).
+    value_call: Option<String>,
+}
+
+/// Metadata export for [`VarBinding`].
+impl VarBinding {
+    /// Store the known parts as `var_type`, `value_type` and `value_call` metadata.
+    fn insert_into(&self, metadata: &mut HashMap<String, serde_json::Value>) {
+        for (key, value) in [
+            ("var_type", &self.var_type),
+            ("value_type", &self.value_type),
+            ("value_call", &self.value_call),
+        ] {
+            if let Some(value) = value {
+                metadata.insert(key.to_string(), serde_json::Value::String(value.clone()));
+            }
+        }
+    }
+}
+
 /// Create source location from a tree-sitter node.
 fn create_go_source_location(file_path: &str, node: &Node) -> SourceLocation {
     SourceLocation::from_positions(
@@ -770,6 +1055,10 @@ fn create_go_source_location(file_path: &str, node: &Node) -> SourceLocation {
 /// [`EntityExtractor`] implementation providing the language-specific node conversion.
 /// Go overrides `extract_entities_recursive` to handle grouped const/var declarations.
 impl EntityExtractor for GoAdapter {
+    fn max_depth(&self) -> usize {
+        self.max_depth
+    }
+
     fn node_to_entity(
         &self,
         node: Node,
@@ -814,7 +1103,9 @@ impl EntityExtractor for GoAdapter {
         parent_id: Option<String>,
         index: &mut ParseIndex,
         entity_id_counter: &mut usize,
+        depth: usize,
     ) -> Result<()> {
+        check_recursion_depth(node, file_path, depth, self.max_depth)?;
         if node.kind() == "const_declaration" || node.kind() == "var_declaration" {
             return self.handle_grouped_declaration(
                 node,
@@ -823,6 +1114,7 @@ impl EntityExtractor for GoAdapter {
                 parent_id,
                 index,
                 entity_id_counter,
+                depth,
             );
         }
 
@@ -842,6 +1134,7 @@ impl EntityExtractor for GoAdapter {
                 Some(entity_id),
                 index,
                 entity_id_counter,
+                depth,
             )?;
         } else {
             self.traverse_children(
@@ -851,6 +1144,7 @@ impl EntityExtractor for GoAdapter {
                 parent_id,
                 index,
                 entity_id_counter,
+                depth,
             )?;
         }
 
@@ -858,19 +1152,57 @@ impl EntityExtractor for GoAdapter {
     }
 }
 
+/// Whether a Go identifier is exported (starts with an upper-case letter).
+pub fn is_exported(name: &str) -> bool {
+    name.chars().next().is_some_and(char::is_uppercase)
+}
+
+/// Patterns listed by `//go:embed` directives in a Go source file.
+///
+/// Patterns are returned in directive order and may carry the `all:` prefix.
+/// Double-quoted and back-quoted patterns are unquoted, so names containing
+/// spaces survive intact.
+pub fn embed_patterns(source: &str) -> Vec<String> {
+    let mut patterns = Vec::new();
+    for line in source.lines() {
+        let Some(directive) = line.trim_start().strip_prefix("//go:embed") else {
+            continue;
+        };
+        if !directive.starts_with(char::is_whitespace) {
+            continue;
+        }
+
+        let mut rest = directive.trim_start();
+        while !rest.is_empty() {
+            let quote = rest.chars().next().filter(|c| *c == '"' || *c == '`');
+            let (pattern, remainder) = match quote {
+                Some(quote) => match rest[1..].find(quote) {
+                    Some(end) => (&rest[1..end + 1], &rest[end + 2..]),
+                    None => (&rest[1..], ""),
+                },
+                None => rest.split_at(rest.find(char::is_whitespace).unwrap_or(rest.len())),
+            };
+            if !pattern.is_empty() {
+                patterns.push(pattern.to_string());
+            }
+            rest = remainder.trim_start();
+        }
+    }
+    patterns
+}
+
 /// Default implementation for [`GoAdapter`].
 impl Default for GoAdapter {
     /// Returns a new Go adapter, or a minimal fallback on failure.
     fn default() -> Self {
         Self::new().unwrap_or_else(|e| {
-            eprintln!(
-                "Warning: Failed to create Go adapter, using minimal fallback: {}",
-                e
-            );
+            warn!("Failed to create Go adapter, using minimal fallback: {}", e);
             GoAdapter {
                 parser: tree_sitter::Parser::new(),
                 language: get_tree_sitter_language("go")
                     .unwrap_or_else(|_| tree_sitter_go::LANGUAGE.into()),
+                max_depth: DEFAULT_MAX_RECURSION_DEPTH,
+                deadline: None,
             }
         })
     }
@@ -75,6 +75,34 @@ type Point struct {
     assert!(fields.is_some());
 }
 
+#[test]
+fn test_struct_field_types() {
+    let mut adapter = GoAdapter::new().unwrap();
+    let source_code = r#"
+package main
+
+type Server struct {
+    Logger
+    store  *Store
+    conns  []db.Conn
+    cache  map[string]Item
+    name   string
+}
+"#;
+
+    let entities = adapter
+        .extract_code_entities(source_code, "server.go")
+        .unwrap();
+    let server = entities.iter().find(|e| e.name == "Server").unwrap();
+
+    let field_types: Vec<String> =
+        serde_json::from_value(server.properties["field_types"].clone()).unwrap();
+    assert_eq!(
+        field_types,
+        vec!["Item", "Logger", "Store", "db.Conn", "string"]
+    );
+}
+
 #[test]
 fn test_interface_parsing() {
     let mut adapter = GoAdapter::new().unwrap();
@@ -160,6 +188,56 @@ func (r *Rectangle) Scale(factor float64) {
     assert!(receiver_type.is_some());
 }
 
+#[test]
+fn test_function_calls_include_closures_and_function_values() {
+    let mut adapter = GoAdapter::new().unwrap();
+    let source_code = r#"
+package main
+
+func (s *Server) Handle(store Store) {
+    s.log("start")
+    store.Get()
+}
+
+func main() {
+    h := process
+    http.HandleFunc("/", serve)
+    go func() {
+        report(h(nil))
+    }()
+}
+"#;
+
+    let entities = adapter
+        .extract_code_entities(source_code, "main.go")
+        .unwrap();
+
+    let calls_of = |name: &str| -> Vec<String> {
+        let entity = entities.iter().find(|e| e.name == name).unwrap();
+        serde_json::from_value(entity.properties["function_calls"].clone()).unwrap()
+    };
+
+    let handle_calls = calls_of("Handle");
+    assert!(handle_calls.contains(&"s.log".to_string()));
+    assert!(handle_calls.contains(&"store.Get".to_string()));
+
+    let main_calls = calls_of("main");
+    for expected in ["process", "serve", "report", "h", "http.HandleFunc"] {
+        assert!(
+            main_calls.contains(&expected.to_string()),
+            "missing {} in {:?}",
+            expected,
+            main_calls
+        );
+    }
+
+    let handle = entities.iter().find(|e| e.name == "Handle").unwrap();
+    assert_eq!(
+        handle.properties.get("receiver_base_type"),
+        Some(&serde_json::json!("Server"))
+    );
+}
+
 #[test]
 fn test_const_and_var() {
     let mut adapter = GoAdapter::new().unwrap();
@@ -317,3 +395,61 @@ import (
         assert_eq!(imports.len(), 7, "Should have 7 imports total");
     }
 }
+
+#[test]
+fn test_embed_patterns() {
+    let source = r#"package migrations
+
+import "embed"
+
+//go:embed schema/*.sql seed.sql
+var migrations embed.FS
+
+//go:embed "templates/page one.html" `all:static`
+var assets embed.FS
+
+//go:embedded is not a directive
+// go:embed needs no space after the slashes
+"#;
+
+    assert_eq!(
+        embed_patterns(source),
+        vec![
+            "schema/*.sql",
+            "seed.sql",
+            "templates/page one.html",
+            "all:static"
+        ]
+    );
+}
+
+#[test]
+fn test_parse_source_rejects_nesting_past_max_depth() {
+    let mut adapter = GoAdapter::new().unwrap();
+
+    let shallow = adapter
+        .parse_source(
+            "package deep\n\nvar v = ((1))\n\nfunc f() int { return ((1)) }\n",
+            "deep.go",
+        )
+        .expect("shallow source is within the limit");
+    assert!(shallow.entities.values().any(|entity| entity.name == "f"));
+    assert!(shallow.entities.values().any(|entity| entity.name == "v"));
+
+    let nesting = crate::lang::common::DEFAULT_MAX_RECURSION_DEPTH;
+    let (open, close) = ("(".repeat(nesting), ")".repeat(nesting));
+    for deep in [
+        format!("package deep\n\nfunc f() int {{ return {open}1{close} }}\n"),
+        format!("package deep\n\nvar v = {open}1{close}\n"),
+    ] {
+        match adapter.parse_source(&deep, "deep.go") {
+            Err(ValknutError::Parse {
+                message, file_path, ..
+            }) => {
+                assert!(message.contains("maximum recursion depth of 256"));
+                assert_eq!(file_path.as_deref(), Some("deep.go"));
+            }
+            other => panic!("expected a recursion depth error, got {other:?}"),
+        }
+    }
+}
@@ -0,0 +1,799 @@
+//! Java language adapter with tree-sitter integration.
+//!
+//! Extracts classes, records, interfaces, enums and annotation types together
+//! with their hierarchy (`extends`/`implements`), generics and modifiers, and
+//! methods with full signatures including checked exceptions and `@Override`
+//! relationships. Anonymous inner classes and lambdas assigned to named
+//! variables are captured as entities of their own. Imports from the JDK are
+//! recognised but left out of [`LanguageAdapter::extract_imports`] unless
+//! [`JavaAdapter::with_jdk_imports`] is enabled.
+
+use std::collections::HashMap;
+use std::time::Instant;
+use tracing::warn;
+use tree_sitter::{Language, Node, Parser, Tree};
+
+use super::super::common::{
+    check_syntax, create_base_metadata, extract_identifiers_by_kinds, generate_entity_id,
+    parse_until, simple_type_name, sort_and_dedup, text_of, EntityExtractor, EntityKind,
+    LanguageAdapter, ParseIndex, ParsedEntity, SourceLocation, DEFAULT_MAX_RECURSION_DEPTH,
+};
+use super::super::registry::{create_parser_for_language, get_tree_sitter_language};
+use crate::core::ast_utils::{find_child_by_kind, walk_tree};
+use crate::core::errors::{Result, ValknutError};
+use crate::core::featureset::CodeEntity;
+use crate::detectors::structure::config::ImportStatement;
+
+/// Node kinds that declare a named type.
+const TYPE_DECLARATION_KINDS: &[&str] = &[
+    "class_declaration",
+    "record_declaration",
+    "interface_declaration",
+    "annotation_type_declaration",
+    "enum_declaration",
+];
+
+/// Package prefixes that belong to the JDK.
+const JDK_PACKAGE_PREFIXES: &[&str] = &[
+    "java",
+    "jdk",
+    "sun",
+    "com.sun",
+    "org.ietf.jgss",
+    "org.w3c.dom",
+    "org.xml.sax",
+    "javax.accessibility",
+    "javax.annotation.processing",
+    "javax.crypto",
+    "javax.imageio",
+    "javax.lang.model",
+    "javax.management",
+    "javax.naming",
+    "javax.net",
+    "javax.print",
+    "javax.script",
+    "javax.security",
+    "javax.smartcardio",
+    "javax.sound",
+    "javax.sql",
+    "javax.swing",
+    "javax.tools",
+    "javax.transaction.xa",
+    "javax.xml",
+];
+
+/// Returns true when `name` (a package or qualified type name) is part of the JDK.
+///
+/// Only the `javax` packages shipped with the JDK match; Java EE packages such
+/// as `javax.servlet` or `javax.persistence` are third-party libraries.
+pub fn is_jdk_package(name: &str) -> bool {
+    JDK_PACKAGE_PREFIXES.iter().any(|prefix| {
+        name == *prefix
+            || name
+                .strip_prefix(prefix)
+                .is_some_and(|rest| rest.starts_with('.'))
+    })
+}
+
+/// Package part of a qualified type or member name (`com.acme.Order.NONE` -> `com.acme`).
+///
+/// Segments up to the first one that starts with an uppercase letter form the
+/// package; without one, the last segment is assumed to be the type.
+pub fn package_of_qualified_name(name: &str) -> String {
+    let segments: Vec<&str> = name.split('.').collect();
+    let type_index = segments
+        .iter()
+        .position(|segment| segment.starts_with(|c: char| c.is_ascii_uppercase()))
+        .unwrap_or(segments.len().saturating_sub(1));
+    segments[..type_index].join(".")
+}
+
+/// Java-specific parsing and analysis
+pub struct JavaAdapter {
+    /// Tree-sitter parser for Java
+    parser: Parser,
+
+    /// Language instance
+    language: Language,
+
+    /// Whether JDK imports are reported by `extract_imports`
+    include_jdk_imports: bool,
+
+    /// Deepest AST node entity extraction visits
+    max_depth: usize,
+
+    /// Instant after which parses are abandoned (`--timeout`)
+    deadline: Option<Instant>,
+}
+
+/// Parsing and entity extraction methods for [`JavaAdapter`].
+impl JavaAdapter {
+    /// Create a new Java adapter
+    pub fn new() -> Result<Self> {
+        let language = get_tree_sitter_language("java")?;
+        let parser = create_parser_for_language("java")?;
+
+        Ok(Self {
+            parser,
+            language,
+            include_jdk_imports: false,
+            max_depth: DEFAULT_MAX_RECURSION_DEPTH,
+            deadline: None,
+        })
+    }
+
+    /// Report imports of JDK packages (`java.*`, `javax.swing`, ...) as well.
+    pub fn with_jdk_imports(mut self, include: bool) -> Self {
+        self.include_jdk_imports = include;
+        self
+    }
+
+    /// Parse Java source code and extract entities
+    pub fn parse_source(&mut self, source_code: &str, file_path: &str) -> Result<ParseIndex> {
+        let tree = parse_until(&mut self.parser, source_code, self.deadline).ok_or_else(|| {
+            ValknutError::parse_with_location(
+                "java",
+                "Failed to parse Java source code",
+                file_path,
+                None,
+                None,
+            )
+        })?;
+        check_syntax(&tree, "java", file_path)?;
+
+        let mut index = ParseIndex::new();
+        let mut entity_id_counter = 0;
+        self.extract_entities_iterative(
+            tree.root_node(),
+            source_code,
+            file_path,
+            &mut index,
+            &mut entity_id_counter,
+        )?;
+
+        Ok(index)
+    }
+
+    /// Extract entities from Java code and convert to CodeEntity format
+    pub fn extract_code_entities(
+        &mut self,
+        source_code: &str,
+        file_path: &str,
+    ) -> Result<Vec<CodeEntity>> {
+        let parse_index = self.parse_source(source_code, file_path)?;
+        Ok(parse_index
+            .entities
+            .values()
+            .map(|entity| entity.to_code_entity(source_code))
+            .collect())
+    }
+
+    /// Name of the package declared by a compilation unit, if any.
+    pub fn package_name(&mut self, source_code: &str) -> Result<Option<String>> {
+        let tree = self.parse_tree(source_code)?;
+        Ok(declared_package(tree.root_node(), source_code))
+    }
+
+    /// Determine entity kind from node kind, returning None for non-entity nodes.
+    fn determine_entity_kind(node: &Node) -> Option<EntityKind> {
+        match node.kind() {
+            "class_declaration" | "record_declaration" => Some(EntityKind::Class),
+            "interface_declaration" | "annotation_type_declaration" => Some(EntityKind::Interface),
+            "enum_declaration" => Some(EntityKind::Enum),
+            "method_declaration"
+            | "constructor_declaration"
+            | "compact_constructor_declaration" => Some(EntityKind::Method),
+            "object_creation_expression" if find_child_by_kind(node, "class_body").is_some() => {
+                Some(EntityKind::Class)
+            }
+            "lambda_expression" if lambda_binding(node).is_some() => Some(EntityKind::Function),
+            _ => None,
+        }
+    }
+
+    /// Extract the name of an entity from its AST node
+    fn extract_name(node: &Node, source_code: &str, counter: usize) -> Option<String> {
+        match node.kind() {
+            "object_creation_expression" => {
+                let base = node
+                    .child_by_field_name("type")
+                    .map(|ty| simple_type_name(&text_of(&ty, source_code)))?;
+                Some(format!("anonymous_{}_{}", base, counter))
+            }
+            "lambda_expression" => lambda_binding(node)
+                .and_then(|binding| binding.utf8_text(source_code.as_bytes()).ok())
+                .map(str::to_string),
+            _ => node
+                .child_by_field_name("name")
+                .map(|name| text_of(&name, source_code)),
+        }
+    }
+
+    /// Extract metadata based on node kind.
+    fn extract_entity_metadata(
+        &self,
+        node: &Node,
+        source_code: &str,
+        metadata: &mut HashMap<String, serde_json::Value>,
+    ) {
+        match node.kind() {
+            kind if TYPE_DECLARATION_KINDS.contains(&kind) => {
+                self.extract_type_metadata(node, source_code, metadata)
+            }
+            "method_declaration"
+            | "constructor_declaration"
+            | "compact_constructor_declaration" => {
+                self.extract_method_metadata(node, source_code, metadata)
+            }
+            "object_creation_expression" => {
+                metadata.insert("is_anonymous".to_string(), serde_json::Value::Bool(true));
+                if let Some(ty) = node.child_by_field_name("type") {
+                    metadata.insert(
+                        "base_type".to_string(),
+                        serde_json::Value::String(text_of(&ty, source_code)),
+                    );
+                }
+            }
+            "lambda_expression" => self.extract_lambda_metadata(node, source_code, metadata),
+            _ => {}
+        }
+    }
+
+    /// Extract hierarchy, generics and modifiers of a type declaration.
+    fn extract_type_metadata(
+        &self,
+        node: &Node,
+        source_code: &str,
+        metadata: &mut HashMap<String, serde_json::Value>,
+    ) {
+        insert_modifiers(node, source_code, metadata);
+
+        let type_parameters = type_parameters(node, source_code);
+        if !type_parameters.is_empty() {
+            metadata.insert(
+                "type_parameters".to_string(),
+                serde_json::json!(type_parameters),
+            );
+        }
+
+        if let Some(superclass) = superclass(node, source_code) {
+            metadata.insert(
+                "superclass".to_string(),
+                serde_json::Value::String(superclass),
+            );
+        }
+        let interfaces = implemented_interfaces(node, source_code);
+        if !interfaces.is_empty() {
+            let key = if node.kind() == "interface_declaration" {
+                "extends_interfaces"
+            } else {
+                "interfaces"
+            };
+            metadata.insert(key.to_string(), serde_json::json!(interfaces));
+        }
+
+        match node.kind() {
+            "record_declaration" => {
+                metadata.insert("is_record".to_string(), serde_json::Value::Bool(true));
+                if let Some(parameters) = node.child_by_field_name("parameters") {
+                    let (names, _) = formal_parameters(&parameters, source_code);
+                    metadata.insert("record_components".to_string(), serde_json::json!(names));
+                }
+            }
+            "annotation_type_declaration" => {
+                metadata.insert(
+                    "is_annotation_type".to_string(),
+                    serde_json::Value::Bool(true),
+                );
+            }
+            "enum_declaration" => {
+                let constants = enum_constants(node, source_code);
+                metadata.insert("enum_constants".to_string(), serde_json::json!(constants));
+            }
+            _ => {}
+        }
+
+        if let Some(package) = declared_package(root_of(node), source_code) {
+            metadata.insert("package".to_string(), serde_json::Value::String(package));
+        }
+    }
+
+    /// Extract the signature of a method or constructor.
+    fn extract_method_metadata(
+        &self,
+        node: &Node,
+        source_code: &str,
+        metadata: &mut HashMap<String, serde_json::Value>,
+    ) {
+        let annotations = insert_modifiers(node, source_code, metadata);
+
+        let (names, types) = node
+            .child_by_field_name("parameters")
+            .map(|parameters| formal_parameters(&parameters, source_code))
+            .unwrap_or_default();
+        metadata.insert("parameters".to_string(), serde_json::json!(names));
+        metadata.insert("parameter_types".to_string(), serde_json::json!(types));
+
+        if node.kind() == "method_declaration" {
+            if let Some(return_type) = node.child_by_field_name("type") {
+                metadata.insert(
+                    "return_type".to_string(),
+                    serde_json::Value::String(text_of(&return_type, source_code)),
+                );
+            }
+        } else {
+            metadata.insert("is_constructor".to_string(), serde_json::Value::Bool(true));
+        }
+
+        let type_parameters = type_parameters(node, source_code);
+        if !type_parameters.is_empty() {
+            metadata.insert(
+                "type_parameters".to_string(),
+                serde_json::json!(type_parameters),
+            );
+        }
+
+        let throws = thrown_exceptions(node, source_code);
+        if !throws.is_empty() {
+            metadata.insert("throws".to_string(), serde_json::json!(throws));
+        }
+
+        if annotations.iter().any(|name| name == "Override") {
+            metadata.insert("is_override".to_string(), serde_json::Value::Bool(true));
+            let candidates = enclosing_supertypes(node, source_code);
+            if !candidates.is_empty() {
+                metadata.insert(
+                    "override_candidates".to_string(),
+                    serde_json::json!(candidates),
+                );
+            }
+        }
+
+        metadata.insert(
+            "function_calls".to_string(),
+            serde_json::json!(collect_calls(node, source_code)),
+        );
+    }
+
+    /// Extract parameters and calls of a lambda bound to a variable.
+    fn extract_lambda_metadata(
+        &self,
+        node: &Node,
+        source_code: &str,
+        metadata: &mut HashMap<String, serde_json::Value>,
+    ) {
+        metadata.insert("is_lambda".to_string(), serde_json::Value::Bool(true));
+
+        let parameters = node
+            .child_by_field_name("parameters")
+            .map(|parameters| match parameters.kind() {
+                "identifier" => vec![text_of(&parameters, source_code)],
+                "formal_parameters" => formal_parameters(&parameters, source_code).0,
+                _ => {
+                    let mut cursor = parameters.walk();
+                    parameters
+                        .named_children(&mut cursor)
+                        .map(|param| text_of(&param, source_code))
+                        .collect()
+                }
+            })
+            .unwrap_or_default();
+        metadata.insert("parameters".to_string(), serde_json::json!(parameters));
+        metadata.insert(
+            "function_calls".to_string(),
+            serde_json::json!(collect_calls(node, source_code)),
+        );
+    }
+
+    /// Build an import statement, or `None` for filtered JDK imports.
+    fn import_statement(
+        &self,
+        node: &Node,
+        source_code: &str,
+        line_number: usize,
+    ) -> Option<ImportStatement> {
+        let mut cursor = node.walk();
+        let children: Vec<Node> = node.children(&mut cursor).collect();
+        let is_static = children.iter().any(|child| child.kind() == "static");
+        let is_star = children.iter().any(|child| child.kind() == "asterisk");
+        let name = children
+            .iter()
+            .find(|child| matches!(child.kind(), "scoped_identifier" | "identifier"))
+            .map(|child| text_of(child, source_code))?;
+
+        if !self.include_jdk_imports && is_jdk_package(&name) {
+            return None;
+        }
+
+        let (module, imports) = match (is_star, name.rsplit_once('.')) {
+            (true, _) | (false, None) => (name.clone(), None),
+            (false, Some((module, member))) => (module.to_string(), Some(vec![member.to_string()])),
+        };
+        let import_type = match (is_static, is_star) {
+            (true, _) => "static",
+            (false, true) => "star",
+            (false, false) => "named",
+        };
+
+        Some(ImportStatement {
+            module,
+            imports,
+            import_type: import_type.to_string(),
+            line_number,
+        })
+    }
+}
+
+/// [`LanguageAdapter`] implementation for Java source code.
+impl LanguageAdapter for JavaAdapter {
+    /// Parses source code into a tree-sitter AST.
+    fn parse_tree(&mut self, source: &str) -> Result<Tree> {
+        parse_until(&mut self.parser, source, self.deadline)
+            .ok_or_else(|| ValknutError::parse("java", "Failed to parse Java source"))
+    }
+
+    /// Parses Java source code and returns a parse index.
+    fn parse_source(&mut self, source: &str, file_path: &str) -> Result<ParseIndex> {
+        JavaAdapter::parse_source(self, source, file_path)
+    }
+
+    /// Extracts all method invocation and constructor targets from the source.
+    fn extract_function_calls(&mut self, source: &str) -> Result<Vec<String>> {
+        let tree = self.parse_tree(source)?;
+        Ok(collect_calls(&tree.root_node(), source))
+    }
+
+    /// Extracts all identifier tokens from the source.
+    fn extract_identifiers(&mut self, source: &str) -> Result<Vec<String>> {
+        let tree = self.parse_tree(source)?;
+        Ok(extract_identifiers_by_kinds(
+            tree.root_node(),
+            source,
+            &["identifier", "type_identifier"],
+        ))
+    }
+
+    /// Counts distinct code blocks in the source.
+    fn count_distinct_blocks(&mut self, source: &str) -> Result<usize> {
+        let index = JavaAdapter::parse_source(self, source, "<memory>")?;
+        Ok(index.count_distinct_blocks())
+    }
+
+    /// Returns the language name ("java").
+    fn language_name(&self) -> &str {
+        "java"
+    }
+
+    /// Bounds entity extraction to `depth` levels of AST nesting.
+    fn set_max_ast_depth(&mut self, depth: usize) {
+        self.max_depth = depth;
+    }
+
+    /// Abandons parses still running once `deadline` passes.
+    fn set_parse_deadline(&mut self, deadline: Option<Instant>) {
+        self.deadline = deadline;
+    }
+
+    /// Extracts import declarations, skipping JDK packages unless enabled.
+    fn extract_imports(&mut self, source: &str) -> Result<Vec<ImportStatement>> {
+        let tree = self.parse_tree(source)?;
+        let root = tree.root_node();
+        let mut cursor = root.walk();
+        Ok(root
+            .children(&mut cursor)
+            .filter(|child| child.kind() == "import_declaration")
+            .filter_map(|child| {
+                self.import_statement(&child, source, child.start_position().row + 1)
+            })
+            .collect())
+    }
+
+    /// Extracts code entities from Java source code.
+    fn extract_code_entities(&mut self, source: &str, file_path: &str) -> Result<Vec<CodeEntity>> {
+        JavaAdapter::extract_code_entities(self, source, file_path)
+    }
+}
+
+/// [`EntityExtractor`] implementation providing the language-specific node conversion.
+impl EntityExtractor for JavaAdapter {
+    fn max_depth(&self) -> usize {
+        self.max_depth
+    }
+
+    fn node_to_entity(
+        &self,
+        node: Node,
+        source_code: &str,
+        file_path: &str,
+        parent_id: Option<String>,
+        entity_id_counter: &mut usize,
+    ) -> Result<Option<ParsedEntity>> {
+        let Some(entity_kind) = Self::determine_entity_kind(&node) else {
+            return Ok(None);
+        };
+
+        let name = Self::extract_name(&node, source_code, *entity_id_counter)
+            .unwrap_or_else(|| entity_kind.fallback_name(*entity_id_counter));
+
+        *entity_id_counter += 1;
+        let entity_id = generate_entity_id(file_path, entity_kind, *entity_id_counter);
+        let location = SourceLocation::from_positions(
+            file_path,
+            node.start_position().row,
+            node.start_position().column,
+            node.end_position().row,
+            node.end_position().column,
+        );
+        let mut metadata = create_base_metadata(node.kind(), node.start_byte(), node.end_byte());
+        self.extract_entity_metadata(&node, source_code, &mut metadata);
+
+        Ok(Some(ParsedEntity {
+            id: entity_id,
+            kind: entity_kind,
+            name,
+            parent: parent_id,
+            children: Vec::new(),
+            location,
+            metadata,
+        }))
+    }
+}
+
+/// Default implementation for [`JavaAdapter`].
+impl Default for JavaAdapter {
+    /// Returns a new Java adapter, or a minimal fallback on failure.
+    fn default() -> Self {
+        Self::new().unwrap_or_else(|e| {
+            warn!(
+                "Failed to create Java adapter, using minimal fallback: {}",
+                e
+            );
+            JavaAdapter {
+                parser: tree_sitter::Parser::new(),
+                language: get_tree_sitter_language("java")
+                    .unwrap_or_else(|_| tree_sitter_java::LANGUAGE.into()),
+                include_jdk_imports: false,
+                max_depth: DEFAULT_MAX_RECURSION_DEPTH,
+                deadline: None,
+            }
+        })
+    }
+}
+
+/// Root `program` node containing `node`.
+fn root_of<'a>(node: &Node<'a>) -> Node<'a> {
+    let mut current = *node;
+    while let Some(parent) = current.parent() {
+        current = parent;
+    }
+    current
+}
+
+/// Package declared at the top of a compilation unit.
+fn declared_package(root: Node, source_code: &str) -> Option<String> {
+    let declaration = find_child_by_kind(&root, "package_declaration")?;
+    let mut cursor = declaration.walk();
+    let name = declaration
+        .named_children(&mut cursor)
+        .find(|child| matches!(child.kind(), "scoped_identifier" | "identifier"))
+        .map(|name| text_of(&name, source_code));
+    name
+}
+
+/// Variable name a lambda is bound to by a declaration or simple assignment.
+fn lambda_binding<'a>(node: &Node<'a>) -> Option<Node<'a>> {
+    let parent = node.parent()?;
+    match parent.kind() {
+        "variable_declarator" if parent.child_by_field_name("value") == Some(*node) => {
+            parent.child_by_field_name("name")
+        }
+        "assignment_expression" if parent.child_by_field_name("right") == Some(*node) => {
+            let left = parent.child_by_field_name("left")?;
+            match left.kind() {
+                "identifier" => Some(left),
+                "field_access" => left.child_by_field_name("field"),
+                _ => None,
+            }
+        }
+        _ => None,
+    }
+}
+
+/// Record modifiers, visibility and annotations; returns the annotation names.
+fn insert_modifiers(
+    node: &Node,
+    source_code: &str,
+    metadata: &mut HashMap<String, serde_json::Value>,
+) -> Vec<String> {
+    let mut keywords = Vec::new();
+    let mut annotations = Vec::new();
+    if let Some(modifiers) = find_child_by_kind(node, "modifiers") {
+        let mut cursor = modifiers.walk();
+        for child in modifiers.children(&mut cursor) {
+            match child.kind() {
+                "marker_annotation" | "annotation" => {
+                    if let Some(name) = child.child_by_field_name("name") {
+                        annotations.push(simple_type_name(&text_of(&name, source_code)));
+                    }
+                }
+                _ => keywords.push(text_of(&child, source_code)),
+            }
+        }
+    }
+
+    let visibility = ["public", "protected", "private"]
+        .into_iter()
+        .find(|level| keywords.iter().any(|keyword| keyword == level))
+        .unwrap_or("package");
+    metadata.insert(
+        "visibility".to_string(),
+        serde_json::Value::String(visibility.to_string()),
+    );
+    for flag in ["static", "abstract", "final", "sealed", "default"] {
+        if keywords.iter().any(|keyword| keyword == flag) {
+            metadata.insert(format!("is_{flag}"), serde_json::Value::Bool(true));
+        }
+    }
+    if !keywords.is_empty() {
+        metadata.insert("modifiers".to_string(), serde_json::json!(keywords));
+    }
+    if !annotations.is_empty() {
+        metadata.insert("annotations".to_string(), serde_json::json!(annotations));
+    }
+    annotations
+}
+
+/// Type parameters with their bounds (`T extends Comparable<T>`).
+fn type_parameters(node: &Node, source_code: &str) -> Vec<String> {
+    let Some(parameters) = node.child_by_field_name("type_parameters") else {
+        return Vec::new();
+    };
+    let mut cursor = parameters.walk();
+    parameters
+        .named_children(&mut cursor)
+        .filter(|child| child.kind() == "type_parameter")
+        .map(|child| text_of(&child, source_code))
+        .collect()
+}
+
+/// The `extends` clause of a class declaration.
+fn superclass(node: &Node, source_code: &str) -> Option<String> {
+    let clause = node.child_by_field_name("superclass")?;
+    let mut cursor = clause.walk();
+    let ty = clause
+        .named_children(&mut cursor)
+        .next()
+        .map(|ty| text_of(&ty, source_code));
+    ty
+}
+
+/// Types listed in an `implements` clause, or an interface's `extends` clause.
+fn implemented_interfaces(node: &Node, source_code: &str) -> Vec<String> {
+    let clause = node
+        .child_by_field_name("interfaces")
+        .or_else(|| find_child_by_kind(node, "super_interfaces"))
+        .or_else(|| find_child_by_kind(node, "extends_interfaces"));
+    let Some(clause) = clause else {
+        return Vec::new();
+    };
+    let Some(list) = find_child_by_kind(&clause, "type_list") else {
+        return Vec::new();
+    };
+    let mut cursor = list.walk();
+    list.named_children(&mut cursor)
+        .map(|ty| text_of(&ty, source_code))
+        .collect()
+}
+
+/// Constants declared in an enum body.
+fn enum_constants(node: &Node, source_code: &str) -> Vec<String> {
+    let Some(body) = node.child_by_field_name("body") else {
+        return Vec::new();
+    };
+    let mut cursor = body.walk();
+    body.named_children(&mut cursor)
+        .filter(|child| child.kind() == "enum_constant")
+        .filter_map(|child| child.child_by_field_name("name"))
+        .map(|name| text_of(&name, source_code))
+        .collect()
+}
+
+/// Parameter names and types of a `formal_parameters` list.
+fn formal_parameters(parameters: &Node, source_code: &str) -> (Vec<String>, Vec<String>) {
+    let mut names = Vec::new();
+    let mut types = Vec::new();
+    let mut cursor = parameters.walk();
+    for parameter in parameters.named_children(&mut cursor) {
+        match parameter.kind() {
+            "formal_parameter" => {
+                if let Some(name) = parameter.child_by_field_name("name") {
+                    names.push(text_of(&name, source_code));
+                }
+                if let Some(ty) = parameter.child_by_field_name("type") {
+                    types.push(text_of(&ty, source_code));
+                }
+            }
+            "spread_parameter" => {
+                let mut inner = parameter.walk();
+                for child in parameter.named_children(&mut inner) {
+                    match child.kind() {
+                        "modifiers" => {}
+                        "variable_declarator" => {
+                            if let Some(name) = child.child_by_field_name("name") {
+                                names.push(text_of(&name, source_code));
+                            }
+                        }
+                        _ => types.push(format!("{}...", text_of(&child, source_code))),
+                    }
+                }
+            }
+            _ => {}
+        }
+    }
+    (names, types)
+}
+
+/// Exception types listed in a `throws` clause.
+fn thrown_exceptions(node: &Node, source_code: &str) -> Vec<String> {
+    let Some(throws) = find_child_by_kind(node, "throws") else {
+        return Vec::new();
+    };
+    let mut cursor = throws.walk();
+    throws
+        .named_children(&mut cursor)
+        .map(|ty| text_of(&ty, source_code))
+        .collect()
+}
+
+/// Supertypes of the type enclosing a method, which an `@Override` may target.
+fn enclosing_supertypes(node: &Node, source_code: &str) -> Vec<String> {
+    let mut current = node.parent();
+    while let Some(candidate) = current {
+        if TYPE_DECLARATION_KINDS.contains(&candidate.kind()) {
+            let mut supertypes: Vec<String> =
+                superclass(&candidate, source_code).into_iter().collect();
+            supertypes.extend(implemented_interfaces(&candidate, source_code));
+            return supertypes;
+        }
+        if candidate.kind() == "object_creation_expression" {
+            return candidate
+                .child_by_field_name("type")
+                .map(|ty| vec![text_of(&ty, source_code)])
+                .unwrap_or_default();
+        }
+        current = candidate.parent();
+    }
+    Vec::new()
+}
+
+/// Method invocation targets (`obj.name` or `name`) and constructed types (`new Type`).
+fn collect_calls(node: &Node, source_code: &str) -> Vec<String> {
+    let mut calls = Vec::new();
+    walk_tree(*node, &mut |child| match child.kind() {
+        "method_invocation" => {
+            let Some(name) = child.child_by_field_name("name") else {
+                return;
+            };
+            let name = text_of(&name, source_code);
+            match child.child_by_field_name("object") {
+                Some(object) => calls.push(format!("{}.{}", text_of(&object, source_code), name)),
+                None => calls.push(name),
+            }
+        }
+        "object_creation_expression" => {
+            if let Some(ty) = child.child_by_field_name("type") {
+                calls.push(format!(
+                    "new {}",
+                    simple_type_name(&text_of(&ty, source_code))
+                ));
+            }
+        }
+        _ => {}
+    });
+    sort_and_dedup(&mut calls);
+    calls
+}
+
+#[cfg(test)]
+#[path = "java_tests.rs"]
+mod tests;
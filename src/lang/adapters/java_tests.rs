@@ -0,0 +1,292 @@
+use super::*;
+
+fn entity<'a>(index: &'a ParseIndex, name: &str) -> &'a ParsedEntity {
+    index
+        .entities
+        .values()
+        .find(|e| e.name == name)
+        .unwrap_or_else(|| panic!("entity {name} not found"))
+}
+
+fn strings(entity: &ParsedEntity, key: &str) -> Vec<String> {
+    entity
+        .metadata
+        .get(key)
+        .and_then(|value| value.as_array())
+        .map(|values| {
+            values
+                .iter()
+                .filter_map(|v| v.as_str().map(str::to_string))
+                .collect()
+        })
+        .unwrap_or_default()
+}
+
+#[test]
+fn test_java_adapter_creation() {
+    let adapter = JavaAdapter::new();
+    assert!(adapter.is_ok());
+}
+
+#[test]
+fn test_class_hierarchy() {
+    let mut adapter = JavaAdapter::new().unwrap();
+    let source = r#"
+package com.acme.orders;
+
+public abstract class Repository<T extends Entity> extends Base implements Closeable, Iterable<T> {
+}
+
+interface Store<K, V> extends Readable<K>, Writable<V> {
+}
+
+@interface Audited {
+}
+
+public enum Status implements Labelled {
+    OPEN, CLOSED;
+}
+
+record Point(int x, int y) {
+}
+"#;
+
+    let index = adapter.parse_source(source, "Repository.java").unwrap();
+
+    let repository = entity(&index, "Repository");
+    assert_eq!(repository.kind, EntityKind::Class);
+    assert_eq!(repository.metadata["superclass"], "Base");
+    assert_eq!(
+        strings(repository, "interfaces"),
+        vec!["Closeable", "Iterable<T>"]
+    );
+    assert_eq!(
+        strings(repository, "type_parameters"),
+        vec!["T extends Entity"]
+    );
+    assert_eq!(repository.metadata["visibility"], "public");
+    assert_eq!(repository.metadata["is_abstract"], true);
+    assert_eq!(repository.metadata["package"], "com.acme.orders");
+
+    let store = entity(&index, "Store");
+    assert_eq!(store.kind, EntityKind::Interface);
+    assert_eq!(
+        strings(store, "extends_interfaces"),
+        vec!["Readable<K>", "Writable<V>"]
+    );
+    assert_eq!(store.metadata["visibility"], "package");
+
+    let audited = entity(&index, "Audited");
+    assert_eq!(audited.kind, EntityKind::Interface);
+    assert_eq!(audited.metadata["is_annotation_type"], true);
+
+    let status = entity(&index, "Status");
+    assert_eq!(status.kind, EntityKind::Enum);
+    assert_eq!(strings(status, "enum_constants"), vec!["OPEN", "CLOSED"]);
+    assert_eq!(strings(status, "interfaces"), vec!["Labelled"]);
+
+    let point = entity(&index, "Point");
+    assert_eq!(point.kind, EntityKind::Class);
+    assert_eq!(point.metadata["is_record"], true);
+    assert_eq!(strings(point, "record_components"), vec!["x", "y"]);
+}
+
+#[test]
+fn test_method_signatures() {
+    let mut adapter = JavaAdapter::new().unwrap();
+    let source = r#"
+public class Loader extends AbstractLoader implements Runnable {
+    public Loader(String path) throws IOException {
+        this.path = path;
+    }
+
+    @Override
+    public void run() {
+        load(path);
+    }
+
+    protected static <T extends Comparable<T>> List<T> load(String path, int... flags)
+            throws IOException, ParseException {
+        return reader.read(new File(path));
+    }
+}
+"#;
+
+    let index = adapter.parse_source(source, "Loader.java").unwrap();
+    let loader = entity(&index, "Loader");
+    assert_eq!(loader.kind, EntityKind::Class);
+
+    let constructor = index
+        .entities
+        .values()
+        .find(|e| e.kind == EntityKind::Method && e.name == "Loader")
+        .unwrap();
+    assert_eq!(constructor.metadata["is_constructor"], true);
+    assert_eq!(strings(constructor, "throws"), vec!["IOException"]);
+    assert_eq!(constructor.parent.as_ref(), Some(&loader.id));
+
+    let run = entity(&index, "run");
+    assert_eq!(run.metadata["is_override"], true);
+    assert_eq!(run.metadata["return_type"], "void");
+    assert_eq!(
+        strings(run, "override_candidates"),
+        vec!["AbstractLoader", "Runnable"]
+    );
+    assert_eq!(strings(run, "function_calls"), vec!["load"]);
+
+    let load = entity(&index, "load");
+    assert_eq!(load.metadata["return_type"], "List<T>");
+    assert_eq!(strings(load, "parameters"), vec!["path", "flags"]);
+    assert_eq!(strings(load, "parameter_types"), vec!["String", "int..."]);
+    assert_eq!(
+        strings(load, "type_parameters"),
+        vec!["T extends Comparable<T>"]
+    );
+    assert_eq!(
+        strings(load, "throws"),
+        vec!["IOException", "ParseException"]
+    );
+    assert_eq!(load.metadata["visibility"], "protected");
+    assert_eq!(load.metadata["is_static"], true);
+    assert_eq!(
+        strings(load, "function_calls"),
+        vec!["new File", "reader.read"]
+    );
+}
+
+#[test]
+fn test_anonymous_classes_and_named_lambdas() {
+    let mut adapter = JavaAdapter::new().unwrap();
+    let source = r#"
+class Handlers {
+    private Runnable task;
+
+    void register() {
+        Comparator<String> byLength = (a, b) -> Integer.compare(a.length(), b.length());
+        task = () -> System.out.println("run");
+        Runnable listener = new Runnable() {
+            @Override
+            public void run() {
+                notifyAll();
+            }
+        };
+        executor.submit(() -> work());
+    }
+}
+"#;
+
+    let index = adapter.parse_source(source, "Handlers.java").unwrap();
+
+    let by_length = entity(&index, "byLength");
+    assert_eq!(by_length.kind, EntityKind::Function);
+    assert_eq!(by_length.metadata["is_lambda"], true);
+    assert_eq!(strings(by_length, "parameters"), vec!["a", "b"]);
+
+    let task = entity(&index, "task");
+    assert_eq!(task.kind, EntityKind::Function);
+
+    let anonymous = index
+        .entities
+        .values()
+        .find(|e| e.metadata.get("is_anonymous").is_some())
+        .unwrap();
+    assert_eq!(anonymous.kind, EntityKind::Class);
+    assert!(anonymous.name.starts_with("anonymous_Runnable_"));
+    assert_eq!(anonymous.metadata["base_type"], "Runnable");
+
+    let run = entity(&index, "run");
+    assert_eq!(run.parent.as_ref(), Some(&anonymous.id));
+    assert_eq!(strings(run, "override_candidates"), vec!["Runnable"]);
+
+    // Lambdas passed as arguments are not bound to a name and stay anonymous.
+    let functions = index
+        .entities
+        .values()
+        .filter(|e| e.kind == EntityKind::Function)
+        .count();
+    assert_eq!(functions, 2);
+}
+
+#[test]
+fn test_extract_imports_excludes_jdk_by_default() {
+    let source = r#"
+package com.acme.app;
+
+import java.util.List;
+import javax.swing.JPanel;
+import javax.inject.Inject;
+import com.acme.core.Order;
+import com.acme.util.*;
+import static org.junit.Assert.assertEquals;
+
+class App {}
+"#;
+
+    let mut adapter = JavaAdapter::new().unwrap();
+    let imports = adapter.extract_imports(source).unwrap();
+    let modules: Vec<_> = imports.iter().map(|i| i.module.as_str()).collect();
+    assert_eq!(
+        modules,
+        vec![
+            "javax.inject",
+            "com.acme.core",
+            "com.acme.util",
+            "org.junit.Assert"
+        ]
+    );
+    assert_eq!(imports[1].imports, Some(vec!["Order".to_string()]));
+    assert_eq!(imports[1].import_type, "named");
+    assert_eq!(imports[2].imports, None);
+    assert_eq!(imports[2].import_type, "star");
+    assert_eq!(imports[3].import_type, "static");
+    assert_eq!(imports[3].line_number, 9);
+
+    let mut adapter = JavaAdapter::new().unwrap().with_jdk_imports(true);
+    assert_eq!(adapter.extract_imports(source).unwrap().len(), 6);
+    assert_eq!(
+        adapter.package_name(source).unwrap().as_deref(),
+        Some("com.acme.app")
+    );
+}
+
+#[test]
+fn test_jdk_package_classification() {
+    assert!(is_jdk_package("java.util"));
+    assert!(is_jdk_package("java.util.concurrent.Future"));
+    assert!(is_jdk_package("javax.swing"));
+    assert!(is_jdk_package("org.w3c.dom.Node"));
+    assert!(!is_jdk_package("javax.servlet.http"));
+    assert!(!is_jdk_package("javafx.scene"));
+    assert!(!is_jdk_package("com.acme"));
+
+    assert_eq!(package_of_qualified_name("com.acme.Order"), "com.acme");
+    assert_eq!(package_of_qualified_name("com.acme.Order.NONE"), "com.acme");
+    assert_eq!(package_of_qualified_name("com.acme.order"), "com.acme");
+}
+
+#[test]
+fn test_language_adapter_trait_methods() {
+    let mut adapter = JavaAdapter::new().unwrap();
+    let source = r#"
+class Greeter {
+    String greet(String name) {
+        return format(name);
+    }
+}
+"#;
+
+    assert_eq!(adapter.language_name(), "java");
+    let identifiers = adapter.extract_identifiers(source).unwrap();
+    assert!(identifiers.contains(&"Greeter".to_string()));
+    assert!(identifiers.contains(&"name".to_string()));
+    assert_eq!(
+        adapter.extract_function_calls(source).unwrap(),
+        vec!["format"]
+    );
+    assert!(adapter.count_distinct_blocks(source).unwrap() >= 2);
+
+    let entities = adapter
+        .extract_code_entities(source, "Greeter.java")
+        .unwrap();
+    assert!(entities.iter().any(|e| e.entity_type == "Method"));
+}
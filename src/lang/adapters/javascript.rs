@@ -1,12 +1,24 @@
 //! JavaScript language adapter with tree-sitter integration.
-
+//!
+//! Besides entities, the adapter reads the module-level syntax of ES2022 and
+//! CommonJS files ([`JavaScriptAdapter::extract_module_syntax`]): static
+//! imports, `export ... from` re-exports, `require()` calls and dynamic
+//! `import()` expressions, the names a module exports, and its top-level
+//! declarations and class methods. JSDoc `@param` and `@returns` tags above
+//! functions, methods and classes are parsed into [`JsDoc`] and attached to
+//! their entities under the `jsdoc` metadata key.
+
+use serde::{Deserialize, Serialize};
 use std::collections::HashMap;
+use std::time::Instant;
+use tracing::warn;
 use tree_sitter::{Language, Node, Parser, Tree};
 
 use super::super::common::{
-    create_base_metadata, extract_identifiers_by_kinds, extract_js_function_calls,
-    generate_entity_id, normalize_module_literal, parse_require_import, sort_and_dedup,
-    EntityExtractor, EntityKind, LanguageAdapter, ParseIndex, ParsedEntity, SourceLocation,
+    check_syntax, create_base_metadata, extract_identifiers_by_kinds, extract_js_function_calls,
+    generate_entity_id, normalize_module_literal, parse_require_import, parse_until,
+    sort_and_dedup, EntityExtractor, EntityKind, LanguageAdapter, ParseIndex, ParsedEntity,
+    SourceLocation, DEFAULT_MAX_RECURSION_DEPTH,
 };
 use super::super::registry::{create_parser_for_language, get_tree_sitter_language};
 use crate::core::ast_utils::{
@@ -21,6 +33,154 @@ use crate::detectors::structure::config::ImportStatement;
 #[path = "javascript_tests.rs"]
 mod tests;
 
+/// Nodes whose contents only run on some paths through a module: a
+/// `require()` beneath one is a conditional dependency.
+const DEFERRED_KINDS: &[&str] = &[
+    "function_declaration",
+    "generator_function_declaration",
+    "function_expression",
+    "generator_function",
+    "arrow_function",
+    "method_definition",
+    "if_statement",
+    "switch_statement",
+    "try_statement",
+    "ternary_expression",
+    "for_statement",
+    "for_in_statement",
+    "while_statement",
+    "do_statement",
+];
+
+/// Initializers that make a variable declaration a function.
+const FUNCTION_VALUE_KINDS: &[&str] = &[
+    "arrow_function",
+    "function_expression",
+    "generator_function",
+];
+
+/// Declarations whose doc comment sits above an enclosing node.
+const DOC_WRAPPER_KINDS: &[&str] = &[
+    "export_statement",
+    "variable_declarator",
+    "lexical_declaration",
+    "variable_declaration",
+];
+
+/// How one JavaScript module depends on another.
+#[derive(Debug, Clone, Copy, PartialEq, Eq, PartialOrd, Ord, Hash, Serialize, Deserialize)]
+#[serde(rename_all = "snake_case")]
+pub enum JsDependencyKind {
+    /// Static `import` declaration
+    Import,
+    /// `export ... from` re-export
+    ReExport,
+    /// CommonJS `require()` call
+    Require,
+    /// Dynamic `import()` expression
+    DynamicImport,
+}
+
+/// A module specifier referenced by a JavaScript file.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct JsModuleReference {
+    /// Specifier as written (`./util.js`, `react`, `node:fs`)
+    pub specifier: String,
+    /// How the module is loaded
+    pub kind: JsDependencyKind,
+    /// Names taken from the module: `default`, `*`, or exported names
+    pub names: Vec<String>,
+    /// 1-based line of the reference
+    pub line: usize,
+    /// Whether the module is only loaded on some paths: every dynamic
+    /// `import()`, and `require()` calls inside functions, branches, loops
+    /// or `try` blocks
+    pub conditional: bool,
+}
+
+/// Kind of a declaration in the symbol index.
+#[derive(Debug, Clone, Copy, PartialEq, Eq, PartialOrd, Ord, Hash, Serialize, Deserialize)]
+#[serde(rename_all = "snake_case")]
+pub enum JsSymbolKind {
+    Function,
+    Class,
+    Method,
+    Constant,
+    Variable,
+}
+
+/// A top-level declaration or class method of a JavaScript module.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct JsSymbol {
+    /// Declared name; private methods keep their `#`
+    pub name: String,
+    /// Kind of declaration
+    pub kind: JsSymbolKind,
+    /// 1-based line of the declaration
+    pub line: usize,
+    /// Whether the declaration is part of an `export` statement
+    pub exported: bool,
+    /// Class declaring the method
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub class: Option<String>,
+    /// JSDoc above the declaration
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub jsdoc: Option<JsDoc>,
+}
+
+/// Module-level syntax of a JavaScript file.
+#[derive(Debug, Clone, Default, PartialEq, Eq)]
+pub struct JsModuleSyntax {
+    /// Referenced modules in source order
+    pub references: Vec<JsModuleReference>,
+    /// Names the module exports (`default` for default exports and
+    /// `module.exports`), sorted
+    pub exports: Vec<String>,
+    /// Top-level declarations and class methods in source order
+    pub symbols: Vec<JsSymbol>,
+}
+
+/// A parsed `/** ... */` comment.
+#[derive(Debug, Clone, Default, PartialEq, Eq, Serialize, Deserialize)]
+pub struct JsDoc {
+    /// Text before the first tag
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub summary: Option<String>,
+    /// `@param` tags in order
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub params: Vec<JsDocParam>,
+    /// `@returns` (or `@return`) tag
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub returns: Option<JsDocReturns>,
+}
+
+/// A JSDoc `@param` tag.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct JsDocParam {
+    /// Parameter name, without brackets or default value
+    pub name: String,
+    /// Type expression between the braces
+    #[serde(rename = "type", default, skip_serializing_if = "Option::is_none")]
+    pub type_expr: Option<String>,
+    /// Whether the name was written in brackets
+    #[serde(default)]
+    pub optional: bool,
+    /// Text after the name
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub description: Option<String>,
+}
+
+/// A JSDoc `@returns` tag.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct JsDocReturns {
+    /// Type expression between the braces
+    #[serde(rename = "type", default, skip_serializing_if = "Option::is_none")]
+    pub type_expr: Option<String>,
+    /// Text after the type
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub description: Option<String>,
+}
+
 /// JavaScript-specific parsing and analysis
 pub struct JavaScriptAdapter {
     /// Tree-sitter parser for JavaScript
@@ -28,6 +188,12 @@ pub struct JavaScriptAdapter {
 
     /// Language instance
     language: Language,
+
+    /// Deepest AST node entity extraction visits
+    max_depth: usize,
+
+    /// Instant after which parses are abandoned (`--timeout`)
+    deadline: Option<Instant>,
 }
 
 /// Parsing and entity extraction methods for [`JavaScriptAdapter`].
@@ -37,14 +203,26 @@ impl JavaScriptAdapter {
         let language = get_tree_sitter_language("js")?;
         let parser = create_parser_for_language("js")?;
 
-        Ok(Self { parser, language })
+        Ok(Self {
+            parser,
+            language,
+            max_depth: DEFAULT_MAX_RECURSION_DEPTH,
+            deadline: None,
+        })
     }
 
     /// Parse JavaScript source code and extract entities
     pub fn parse_source(&mut self, source_code: &str, file_path: &str) -> Result<ParseIndex> {
-        let tree = self.parser.parse(source_code, None).ok_or_else(|| {
-            ValknutError::parse("javascript", "Failed to parse JavaScript source code")
+        let tree = parse_until(&mut self.parser, source_code, self.deadline).ok_or_else(|| {
+            ValknutError::parse_with_location(
+                "javascript",
+                "Failed to parse JavaScript source code",
+                file_path,
+                None,
+                None,
+            )
         })?;
+        check_syntax(&tree, "javascript", file_path)?;
 
         let mut index = ParseIndex::new();
         let mut entity_id_counter = 0;
@@ -81,9 +259,11 @@ impl JavaScriptAdapter {
     /// Determine entity kind from node kind, returning None for non-entity nodes.
     fn determine_entity_kind(&self, node: &Node, source_code: &str) -> Result<Option<EntityKind>> {
         Ok(match node.kind() {
-            "function_declaration" | "function_expression" | "arrow_function" => {
-                Some(EntityKind::Function)
-            }
+            "function_declaration"
+            | "generator_function_declaration"
+            | "function_expression"
+            | "generator_function"
+            | "arrow_function" => Some(EntityKind::Function),
             "method_definition" => Some(EntityKind::Method),
             "class_declaration" => Some(EntityKind::Class),
             "variable_declaration" | "lexical_declaration" => {
@@ -101,12 +281,20 @@ impl JavaScriptAdapter {
     fn extract_name(&self, node: &Node, source_code: &str) -> Result<Option<String>> {
         match node.kind() {
             "function_declaration"
+            | "generator_function_declaration"
             | "class_declaration"
             | "function_expression"
+            | "generator_function"
             | "arrow_function" => find_child_text(node, source_code, &["identifier"]),
-            "method_definition" => {
-                find_child_text(node, source_code, &["property_identifier", "identifier"])
-            }
+            "method_definition" => find_child_text(
+                node,
+                source_code,
+                &[
+                    "property_identifier",
+                    "private_property_identifier",
+                    "identifier",
+                ],
+            ),
             "variable_declaration" | "lexical_declaration" => {
                 extract_variable_declarator_name(node, source_code)
             }
@@ -183,12 +371,457 @@ impl JavaScriptAdapter {
     }
 }
 
+/// Module syntax extraction for [`JavaScriptAdapter`].
+impl JavaScriptAdapter {
+    /// Read the imports, requires, exports and top-level declarations of a
+    /// JavaScript module.
+    pub fn extract_module_syntax(&mut self, source: &str) -> Result<JsModuleSyntax> {
+        let tree = self.parse_tree(source)?;
+        let root = tree.root_node();
+        let mut syntax = JsModuleSyntax {
+            symbols: module_symbols(&root, source),
+            ..JsModuleSyntax::default()
+        };
+
+        // Stack entries: (node, inside a deferred node)
+        let mut stack = vec![(root, false)];
+        while let Some((node, deferred)) = stack.pop() {
+            match node.kind() {
+                "import_statement" => syntax.references.extend(import_reference(&node, source)),
+                "export_statement" => collect_export(&node, source, &mut syntax),
+                "call_expression" => {
+                    syntax
+                        .references
+                        .extend(call_reference(&node, source, deferred));
+                }
+                "assignment_expression" if !deferred => {
+                    syntax.exports.extend(commonjs_export(&node, source));
+                }
+                _ => {}
+            }
+
+            let deferred = deferred || DEFERRED_KINDS.contains(&node.kind());
+            let mut cursor = node.walk();
+            let children: Vec<_> = node.named_children(&mut cursor).collect();
+            for child in children.into_iter().rev() {
+                stack.push((child, deferred));
+            }
+        }
+
+        sort_and_dedup(&mut syntax.exports);
+        Ok(syntax)
+    }
+}
+
+/// Text of a node, or `""` when it is not valid UTF-8.
+fn node_text<'s>(node: &Node, source: &'s str) -> &'s str {
+    node.utf8_text(source.as_bytes()).unwrap_or_default()
+}
+
+/// The value of a string literal or a template literal without substitutions.
+fn string_literal(node: &Node, source: &str) -> Option<String> {
+    match node.kind() {
+        "string" => {}
+        "template_string" => {
+            let mut cursor = node.walk();
+            if node
+                .named_children(&mut cursor)
+                .any(|child| child.kind() == "template_substitution")
+            {
+                return None;
+            }
+        }
+        _ => return None,
+    }
+    Some(normalize_module_literal(node_text(node, source)))
+}
+
+/// Build a reference located at `node`.
+fn module_reference(
+    specifier: String,
+    kind: JsDependencyKind,
+    names: Vec<String>,
+    node: &Node,
+    conditional: bool,
+) -> JsModuleReference {
+    JsModuleReference {
+        specifier,
+        kind,
+        names,
+        line: node.start_position().row + 1,
+        conditional,
+    }
+}
+
+/// Names listed by `named_imports` or `export_clause`: the alias when
+/// `use_alias` is set and one is given, the original name otherwise.
+fn specifier_names(list: &Node, source: &str, use_alias: bool) -> Vec<String> {
+    let mut cursor = list.walk();
+    list.named_children(&mut cursor)
+        .filter(|child| matches!(child.kind(), "import_specifier" | "export_specifier"))
+        .filter_map(|specifier| {
+            let alias = use_alias
+                .then(|| specifier.child_by_field_name("alias"))
+                .flatten();
+            let name = alias.or_else(|| specifier.child_by_field_name("name"))?;
+            Some(normalize_module_literal(node_text(&name, source)))
+        })
+        .collect()
+}
+
+/// The reference made by an `import` declaration.
+fn import_reference(node: &Node, source: &str) -> Option<JsModuleReference> {
+    let specifier = string_literal(&node.child_by_field_name("source")?, source)?;
+    let mut names = Vec::new();
+    let mut cursor = node.walk();
+    for clause in node
+        .named_children(&mut cursor)
+        .filter(|child| child.kind() == "import_clause")
+    {
+        let mut clause_cursor = clause.walk();
+        for part in clause.named_children(&mut clause_cursor) {
+            match part.kind() {
+                "identifier" => names.push("default".to_string()),
+                "namespace_import" => names.push("*".to_string()),
+                "named_imports" => names.extend(specifier_names(&part, source, false)),
+                _ => {}
+            }
+        }
+    }
+    Some(module_reference(
+        specifier,
+        JsDependencyKind::Import,
+        names,
+        node,
+        false,
+    ))
+}
+
+/// Record the re-export or the exported names of an `export` statement.
+fn collect_export(node: &Node, source: &str, syntax: &mut JsModuleSyntax) {
+    let mut cursor = node.walk();
+    let children: Vec<_> = node.children(&mut cursor).collect();
+
+    if let Some(source_node) = node.child_by_field_name("source") {
+        let Some(specifier) = string_literal(&source_node, source) else {
+            return;
+        };
+        let mut names = Vec::new();
+        for child in &children {
+            match child.kind() {
+                "*" => names.push("*".to_string()),
+                "namespace_export" => {
+                    names.push("*".to_string());
+                    let mut inner = child.walk();
+                    if let Some(alias) = child.named_children(&mut inner).last() {
+                        syntax
+                            .exports
+                            .push(normalize_module_literal(node_text(&alias, source)));
+                    }
+                }
+                "export_clause" => {
+                    names.extend(specifier_names(child, source, false));
+                    syntax.exports.extend(specifier_names(child, source, true));
+                }
+                _ => {}
+            }
+        }
+        syntax.references.push(module_reference(
+            specifier,
+            JsDependencyKind::ReExport,
+            names,
+            node,
+            false,
+        ));
+        return;
+    }
+
+    if children.iter().any(|child| child.kind() == "default") {
+        syntax.exports.push("default".to_string());
+        return;
+    }
+    if let Some(declaration) = node.child_by_field_name("declaration") {
+        syntax.exports.extend(
+            declared_symbols(&declaration, source, true)
+                .into_iter()
+                .filter(|symbol| symbol.class.is_none())
+                .map(|symbol| symbol.name),
+        );
+    }
+    for clause in children
+        .iter()
+        .filter(|child| child.kind() == "export_clause")
+    {
+        syntax.exports.extend(specifier_names(clause, source, true));
+    }
+}
+
+/// The reference made by a `require()` call or dynamic `import()`.
+fn call_reference(node: &Node, source: &str, deferred: bool) -> Option<JsModuleReference> {
+    let function = node.child_by_field_name("function")?;
+    let kind = match function.kind() {
+        "import" => JsDependencyKind::DynamicImport,
+        "identifier" if node_text(&function, source) == "require" => JsDependencyKind::Require,
+        _ => return None,
+    };
+    let arguments = node.child_by_field_name("arguments")?;
+    let specifier = string_literal(&arguments.named_child(0)?, source)?;
+    let names = match kind {
+        JsDependencyKind::Require => destructured_names(node, source),
+        _ => Vec::new(),
+    };
+    let conditional = deferred || kind == JsDependencyKind::DynamicImport;
+    Some(module_reference(specifier, kind, names, node, conditional))
+}
+
+/// Properties destructured from a call: `const { a, b: c } = require("x")`
+/// takes `a` and `b`.
+fn destructured_names(call: &Node, source: &str) -> Vec<String> {
+    let Some(pattern) = call
+        .parent()
+        .filter(|parent| parent.kind() == "variable_declarator")
+        .and_then(|declarator| declarator.child_by_field_name("name"))
+        .filter(|name| name.kind() == "object_pattern")
+    else {
+        return Vec::new();
+    };
+    let mut cursor = pattern.walk();
+    pattern
+        .named_children(&mut cursor)
+        .filter_map(|property| match property.kind() {
+            "shorthand_property_identifier_pattern" => Some(node_text(&property, source)),
+            "pair_pattern" => property
+                .child_by_field_name("key")
+                .map(|key| node_text(&key, source)),
+            _ => None,
+        })
+        .map(str::to_string)
+        .collect()
+}
+
+/// The name exported by a top-level CommonJS assignment: `default` for
+/// `module.exports = ...`, `name` for `exports.name = ...`.
+fn commonjs_export(node: &Node, source: &str) -> Option<String> {
+    let left = node.child_by_field_name("left")?;
+    if left.kind() != "member_expression" {
+        return None;
+    }
+    let object = node_text(&left.child_by_field_name("object")?, source);
+    let property = node_text(&left.child_by_field_name("property")?, source);
+    match (object, property) {
+        ("module", "exports") => Some("default".to_string()),
+        ("exports" | "module.exports", name) => Some(name.to_string()),
+        _ => None,
+    }
+}
+
+/// Top-level declarations (including exported ones) and their class methods.
+fn module_symbols(root: &Node, source: &str) -> Vec<JsSymbol> {
+    let mut symbols = Vec::new();
+    let mut cursor = root.walk();
+    for statement in root.named_children(&mut cursor) {
+        if statement.kind() == "export_statement" {
+            if let Some(declaration) = statement.child_by_field_name("declaration") {
+                symbols.extend(declared_symbols(&declaration, source, true));
+            }
+        } else {
+            symbols.extend(declared_symbols(&statement, source, false));
+        }
+    }
+    symbols
+}
+
+/// Symbols introduced by one declaration; classes are followed by their methods.
+fn declared_symbols(node: &Node, source: &str, exported: bool) -> Vec<JsSymbol> {
+    let symbol = |name: &Node, kind, class: Option<&str>, doc_node: &Node| JsSymbol {
+        name: node_text(name, source).to_string(),
+        kind,
+        line: doc_node.start_position().row + 1,
+        exported,
+        class: class.map(str::to_string),
+        jsdoc: preceding_jsdoc(doc_node, source),
+    };
+
+    match node.kind() {
+        "function_declaration" | "generator_function_declaration" => node
+            .child_by_field_name("name")
+            .map(|name| vec![symbol(&name, JsSymbolKind::Function, None, node)])
+            .unwrap_or_default(),
+        "class_declaration" => {
+            let Some(name) = node.child_by_field_name("name") else {
+                return Vec::new();
+            };
+            let class_name = node_text(&name, source);
+            let mut symbols = vec![symbol(&name, JsSymbolKind::Class, None, node)];
+            if let Some(body) = node.child_by_field_name("body") {
+                let mut cursor = body.walk();
+                for method in body
+                    .named_children(&mut cursor)
+                    .filter(|member| member.kind() == "method_definition")
+                {
+                    if let Some(method_name) = method.child_by_field_name("name") {
+                        let mut entry = symbol(
+                            &method_name,
+                            JsSymbolKind::Method,
+                            Some(class_name),
+                            &method,
+                        );
+                        entry.exported = false;
+                        symbols.push(entry);
+                    }
+                }
+            }
+            symbols
+        }
+        "lexical_declaration" | "variable_declaration" => {
+            let is_const = is_const_declaration(node, source).unwrap_or(false);
+            let mut cursor = node.walk();
+            node.named_children(&mut cursor)
+                .filter(|child| child.kind() == "variable_declarator")
+                .filter_map(|declarator| {
+                    let name = declarator
+                        .child_by_field_name("name")
+                        .filter(|name| name.kind() == "identifier")?;
+                    let is_function = declarator
+                        .child_by_field_name("value")
+                        .is_some_and(|value| FUNCTION_VALUE_KINDS.contains(&value.kind()));
+                    let kind = match (is_function, is_const) {
+                        (true, _) => JsSymbolKind::Function,
+                        (false, true) => JsSymbolKind::Constant,
+                        (false, false) => JsSymbolKind::Variable,
+                    };
+                    Some(symbol(&name, kind, None, node))
+                })
+                .collect()
+        }
+        _ => Vec::new(),
+    }
+}
+
+/// The JSDoc comment directly above a declaration, looking past the
+/// `export` statement or variable declaration that wraps it.
+fn preceding_jsdoc(node: &Node, source: &str) -> Option<JsDoc> {
+    let mut anchor = *node;
+    while let Some(parent) = anchor
+        .parent()
+        .filter(|parent| DOC_WRAPPER_KINDS.contains(&parent.kind()))
+    {
+        anchor = parent;
+    }
+    let comment = anchor
+        .prev_named_sibling()
+        .filter(|sibling| sibling.kind() == "comment")?;
+    if comment.end_position().row + 1 < anchor.start_position().row {
+        return None;
+    }
+    parse_jsdoc(node_text(&comment, source))
+}
+
+/// Parse a `/** ... */` comment; other comments yield `None`.
+pub fn parse_jsdoc(comment: &str) -> Option<JsDoc> {
+    let body = comment.strip_prefix("/**")?.strip_suffix("*/")?;
+
+    // Each entry is the summary (no tag) or one tag with its continuation lines.
+    let mut blocks: Vec<(Option<&str>, String)> = vec![(None, String::new())];
+    for line in body.lines() {
+        let line = line.trim();
+        let line = line.strip_prefix('*').unwrap_or(line).trim();
+        if line.is_empty() {
+            continue;
+        }
+        if let Some(tagged) = line.strip_prefix('@') {
+            let (tag, rest) = tagged
+                .split_once(char::is_whitespace)
+                .unwrap_or((tagged, ""));
+            blocks.push((Some(tag), rest.trim().to_string()));
+        } else if let Some((_, text)) = blocks.last_mut() {
+            if !text.is_empty() {
+                text.push(' ');
+            }
+            text.push_str(line);
+        }
+    }
+
+    let mut doc = JsDoc::default();
+    for (tag, text) in blocks {
+        match tag {
+            None => doc.summary = non_empty(&text),
+            Some("param" | "arg" | "argument") => doc.params.extend(parse_param_tag(&text)),
+            Some("returns" | "return") => {
+                let (type_expr, description) = split_type(&text);
+                doc.returns = Some(JsDocReturns {
+                    type_expr,
+                    description: non_empty(description),
+                });
+            }
+            Some(_) => {}
+        }
+    }
+    Some(doc)
+}
+
+/// Parse the text after `@param`: `{type} name description`, where the
+/// name may be written `[name]` or `[name=default]`.
+fn parse_param_tag(text: &str) -> Option<JsDocParam> {
+    let (type_expr, rest) = split_type(text);
+    let rest = rest.trim_start();
+    let split = match rest.strip_prefix('[') {
+        Some(bracketed) => bracketed.find(']').map(|end| end + 2),
+        None => rest.find(char::is_whitespace),
+    };
+    let (token, description) = rest.split_at(split.unwrap_or(rest.len()));
+    let optional = token.starts_with('[');
+    let name = token
+        .trim_start_matches('[')
+        .trim_end_matches(']')
+        .split('=')
+        .next()
+        .unwrap_or_default()
+        .trim();
+    if name.is_empty() {
+        return None;
+    }
+    Some(JsDocParam {
+        name: name.to_string(),
+        type_expr,
+        optional,
+        description: non_empty(description.trim_start().trim_start_matches('-')),
+    })
+}
+
+/// Split a leading `{type}` (braces may nest) from the rest of a tag.
+fn split_type(text: &str) -> (Option<String>, &str) {
+    let text = text.trim_start();
+    if !text.starts_with('{') {
+        return (None, text);
+    }
+    let mut depth = 0;
+    for (index, c) in text.char_indices() {
+        match c {
+            '{' => depth += 1,
+            '}' => {
+                depth -= 1;
+                if depth == 0 {
+                    return (non_empty(&text[1..index]), &text[index + 1..]);
+                }
+            }
+            _ => {}
+        }
+    }
+    (None, text)
+}
+
+/// Trimmed text, or `None` when it is blank.
+fn non_empty(text: &str) -> Option<String> {
+    let text = text.trim();
+    (!text.is_empty()).then(|| text.to_string())
+}
+
 /// [`LanguageAdapter`] implementation for JavaScript source code.
 impl LanguageAdapter for JavaScriptAdapter {
     /// Parses source code into a tree-sitter AST.
     fn parse_tree(&mut self, source: &str) -> Result<Tree> {
-        self.parser
-            .parse(source, None)
+        parse_until(&mut self.parser, source, self.deadline)
             .ok_or_else(|| ValknutError::parse("javascript", "Failed to parse JavaScript source"))
     }
 
@@ -228,6 +861,16 @@ impl LanguageAdapter for JavaScriptAdapter {
         "javascript"
     }
 
+    /// Bounds entity extraction to `depth` levels of AST nesting.
+    fn set_max_ast_depth(&mut self, depth: usize) {
+        self.max_depth = depth;
+    }
+
+    /// Abandons parses still running once `deadline` passes.
+    fn set_parse_deadline(&mut self, deadline: Option<Instant>) {
+        self.deadline = deadline;
+    }
+
     /// Extracts import and require statements from JavaScript source.
     fn extract_imports(&mut self, source: &str) -> Result<Vec<ImportStatement>> {
         Ok(crate::lang::common::extract_imports_common(
@@ -248,6 +891,10 @@ impl LanguageAdapter for JavaScriptAdapter {
 
 /// [`EntityExtractor`] implementation providing the language-specific node conversion.
 impl EntityExtractor for JavaScriptAdapter {
+    fn max_depth(&self) -> usize {
+        self.max_depth
+    }
+
     fn node_to_entity(
         &self,
         node: Node,
@@ -287,6 +934,14 @@ impl EntityExtractor for JavaScriptAdapter {
             }
             _ => {}
         }
+        if matches!(
+            entity_kind,
+            EntityKind::Function | EntityKind::Method | EntityKind::Class
+        ) {
+            if let Some(doc) = preceding_jsdoc(&node, source_code) {
+                metadata.insert("jsdoc".to_string(), serde_json::json!(doc));
+            }
+        }
 
         Ok(Some(ParsedEntity {
             id: entity_id,
@@ -305,14 +960,16 @@ impl Default for JavaScriptAdapter {
     /// Returns a new JavaScript adapter, or a minimal fallback on failure.
     fn default() -> Self {
         Self::new().unwrap_or_else(|e| {
-            eprintln!(
-                "Warning: Failed to create JavaScript adapter, using minimal fallback: {}",
+            warn!(
+                "Failed to create JavaScript adapter, using minimal fallback: {}",
                 e
             );
             JavaScriptAdapter {
                 parser: tree_sitter::Parser::new(),
                 language: get_tree_sitter_language("js")
                     .unwrap_or_else(|_| tree_sitter_javascript::LANGUAGE.into()),
+                max_depth: DEFAULT_MAX_RECURSION_DEPTH,
+                deadline: None,
             }
         })
     }
@@ -415,3 +415,149 @@ const { promisify } = require('util');
             .contains(&"Router".to_string()));
     }
 }
+
+mod module_syntax_tests {
+    use super::*;
+
+    const MODULE_SOURCE: &str = r#"
+import fs, { readFile as read } from 'node:fs';
+import * as util from "./util.js";
+export { helper as aid } from './helpers.js';
+export * from './all.js';
+const { join, resolve: res } = require('path');
+
+/**
+ * Load a plugin on demand.
+ * @param {string} name - plugin name
+ * @returns {Promise<object>}
+ */
+export async function loadPlugin(name) {
+    if (name === 'legacy') {
+        return require('./legacy.cjs');
+    }
+    return import(`./plugins/${name}.js`).then(() => import('./plugins/base.js'));
+}
+
+export class Registry {
+    /** @param {Map<string, object>} [entries] */
+    constructor(entries) {}
+
+    async *#drain() {}
+}
+
+export const VERSION = '1.0';
+let cache = null;
+module.exports.extra = cache;
+"#;
+
+    #[test]
+    fn test_extract_module_references() {
+        let mut adapter = JavaScriptAdapter::new().unwrap();
+        let syntax = adapter.extract_module_syntax(MODULE_SOURCE).unwrap();
+
+        let references: Vec<_> = syntax
+            .references
+            .iter()
+            .map(|r| (r.specifier.as_str(), r.kind, r.conditional))
+            .collect();
+        assert_eq!(
+            references,
+            vec![
+                ("node:fs", JsDependencyKind::Import, false),
+                ("./util.js", JsDependencyKind::Import, false),
+                ("./helpers.js", JsDependencyKind::ReExport, false),
+                ("./all.js", JsDependencyKind::ReExport, false),
+                ("path", JsDependencyKind::Require, false),
+                ("./legacy.cjs", JsDependencyKind::Require, true),
+                ("./plugins/base.js", JsDependencyKind::DynamicImport, true),
+            ]
+        );
+        assert_eq!(syntax.references[0].names, vec!["default", "readFile"]);
+        assert_eq!(syntax.references[1].names, vec!["*"]);
+        assert_eq!(syntax.references[2].names, vec!["helper"]);
+        assert_eq!(syntax.references[4].names, vec!["join", "resolve"]);
+        assert_eq!(syntax.references[0].line, 2);
+        assert_eq!(
+            syntax.exports,
+            vec!["Registry", "VERSION", "aid", "extra", "loadPlugin"]
+        );
+    }
+
+    #[test]
+    fn test_extract_module_symbols_with_jsdoc() {
+        let mut adapter = JavaScriptAdapter::new().unwrap();
+        let syntax = adapter.extract_module_syntax(MODULE_SOURCE).unwrap();
+
+        let symbols: Vec<_> = syntax
+            .symbols
+            .iter()
+            .map(|s| (s.name.as_str(), s.kind, s.exported))
+            .collect();
+        assert_eq!(
+            symbols,
+            vec![
+                ("loadPlugin", JsSymbolKind::Function, true),
+                ("Registry", JsSymbolKind::Class, true),
+                ("constructor", JsSymbolKind::Method, false),
+                ("#drain", JsSymbolKind::Method, false),
+                ("VERSION", JsSymbolKind::Constant, true),
+                ("cache", JsSymbolKind::Variable, false),
+            ]
+        );
+
+        let load = &syntax.symbols[0];
+        let doc = load.jsdoc.as_ref().expect("loadPlugin has JSDoc");
+        assert_eq!(doc.summary.as_deref(), Some("Load a plugin on demand."));
+        assert_eq!(doc.params[0].name, "name");
+        assert_eq!(doc.params[0].type_expr.as_deref(), Some("string"));
+        assert_eq!(doc.params[0].description.as_deref(), Some("plugin name"));
+        assert_eq!(
+            doc.returns.as_ref().and_then(|r| r.type_expr.as_deref()),
+            Some("Promise<object>")
+        );
+
+        let constructor = &syntax.symbols[2];
+        assert_eq!(constructor.class.as_deref(), Some("Registry"));
+        let param = &constructor.jsdoc.as_ref().unwrap().params[0];
+        assert!(param.optional);
+        assert_eq!(param.type_expr.as_deref(), Some("Map<string, object>"));
+    }
+
+    #[test]
+    fn test_jsdoc_attached_to_entities() {
+        let mut adapter = JavaScriptAdapter::new().unwrap();
+        let index = adapter.parse_source(MODULE_SOURCE, "plugins.js").unwrap();
+
+        let load = index
+            .get_entities_in_file("plugins.js")
+            .into_iter()
+            .find(|entity| entity.name == "loadPlugin")
+            .expect("loadPlugin entity");
+        assert_eq!(load.metadata["jsdoc"]["params"][0]["type"], "string");
+        assert_eq!(load.metadata["is_async"], Value::Bool(true));
+
+        let drain = index
+            .get_entities_in_file("plugins.js")
+            .into_iter()
+            .find(|entity| entity.name == "#drain");
+        assert!(drain.is_some_and(|entity| entity.kind == EntityKind::Method));
+    }
+
+    #[test]
+    fn test_parse_jsdoc_tags() {
+        let doc = parse_jsdoc(
+            "/**\n * Sum values.\n * @arg {number[]} values\n * @param {{ round: boolean }} [opts={}] - options\n * @return {number} total\n */",
+        )
+        .unwrap();
+        assert_eq!(doc.params.len(), 2);
+        assert_eq!(doc.params[0].name, "values");
+        assert_eq!(doc.params[1].name, "opts");
+        assert_eq!(
+            doc.params[1].type_expr.as_deref(),
+            Some("{ round: boolean }")
+        );
+        assert_eq!(doc.params[1].description.as_deref(), Some("options"));
+        assert_eq!(doc.returns.unwrap().description.as_deref(), Some("total"));
+        assert!(parse_jsdoc("// not a doc comment").is_none());
+    }
+}
@@ -0,0 +1,1026 @@
+//! Kotlin language adapter with tree-sitter integration.
+//!
+//! Extracts classes (including `data`, `sealed`, `enum`, `value` and
+//! `annotation` classes), interfaces, `object` declarations and companion
+//! objects, functions with their receiver, nullable-aware parameter and return
+//! types and `suspend` flag, and class- or file-level properties. `import`
+//! directives are reported by [`LanguageAdapter::extract_imports`].
+//!
+//! Scope functions (`apply`, `let`, `run`, ...) and DSL builders (receiver-less
+//! calls taking only a trailing lambda) are recorded by name but otherwise
+//! treated as ordinary calls: the receiver they bind inside the lambda is not
+//! modelled, so calls inside the lambda belong to the enclosing function.
+//!
+//! Several node kinds were renamed between Kotlin grammar releases, so names
+//! and types are looked up by field first and by the older child kinds second.
+
+use std::collections::HashMap;
+use std::time::Instant;
+use tracing::warn;
+use tree_sitter::{Language, Node, Parser, Tree};
+
+use super::super::common::{
+    check_syntax, create_base_metadata, extract_identifiers_by_kinds, generate_entity_id,
+    parse_until, simple_type_name, sort_and_dedup, text_of, EntityExtractor, EntityKind,
+    LanguageAdapter, ParseIndex, ParsedEntity, SourceLocation, DEFAULT_MAX_RECURSION_DEPTH,
+};
+use super::super::registry::{create_parser_for_language, get_tree_sitter_language};
+use crate::core::ast_utils::{find_child_by_kind, walk_tree};
+use crate::core::errors::{Result, ValknutError};
+use crate::core::featureset::CodeEntity;
+use crate::detectors::structure::config::ImportStatement;
+
+/// Node kinds that declare a class-like type.
+const TYPE_DECLARATION_KINDS: &[&str] = &[
+    "class_declaration",
+    "object_declaration",
+    "companion_object",
+];
+
+/// Node kinds of a class body.
+const CLASS_BODY_KINDS: &[&str] = &["class_body", "enum_class_body"];
+
+/// Node kinds that open a local scope: declarations inside them are not members.
+const LOCAL_SCOPE_KINDS: &[&str] = &[
+    "function_body",
+    "function_declaration",
+    "secondary_constructor",
+    "anonymous_initializer",
+    "anonymous_function",
+    "lambda_literal",
+    "getter",
+    "setter",
+    "block",
+    "statements",
+];
+
+/// Node kinds of an identifier, across grammar versions.
+const IDENTIFIER_KINDS: &[&str] = &["identifier", "simple_identifier", "type_identifier"];
+
+/// Standard library scope functions.
+const SCOPE_FUNCTIONS: &[&str] = &[
+    "let",
+    "run",
+    "with",
+    "apply",
+    "also",
+    "takeIf",
+    "takeUnless",
+];
+
+/// Modifier keywords reported as `is_<keyword>` flags.
+const MODIFIER_FLAGS: &[&str] = &[
+    "data",
+    "sealed",
+    "enum",
+    "annotation",
+    "value",
+    "inner",
+    "abstract",
+    "open",
+    "override",
+    "suspend",
+    "inline",
+    "operator",
+    "infix",
+    "tailrec",
+    "external",
+    "const",
+    "lateinit",
+];
+
+/// Kotlin-specific parsing and analysis
+pub struct KotlinAdapter {
+    /// Tree-sitter parser for Kotlin
+    parser: Parser,
+
+    /// Language instance
+    language: Language,
+
+    /// Deepest AST node entity extraction visits
+    max_depth: usize,
+
+    /// Instant after which parses are abandoned (`--timeout`)
+    deadline: Option<Instant>,
+}
+
+/// Parsing and entity extraction methods for [`KotlinAdapter`].
+impl KotlinAdapter {
+    /// Create a new Kotlin adapter
+    pub fn new() -> Result<Self> {
+        let language = get_tree_sitter_language("kt")?;
+        let parser = create_parser_for_language("kt")?;
+
+        Ok(Self {
+            parser,
+            language,
+            max_depth: DEFAULT_MAX_RECURSION_DEPTH,
+            deadline: None,
+        })
+    }
+
+    /// Parse Kotlin source code and extract entities
+    pub fn parse_source(&mut self, source_code: &str, file_path: &str) -> Result<ParseIndex> {
+        let tree = parse_until(&mut self.parser, source_code, self.deadline).ok_or_else(|| {
+            ValknutError::parse_with_location(
+                "kt",
+                "Failed to parse Kotlin source code",
+                file_path,
+                None,
+                None,
+            )
+        })?;
+        check_syntax(&tree, "kt", file_path)?;
+
+        let mut index = ParseIndex::new();
+        let mut entity_id_counter = 0;
+        self.extract_entities_iterative(
+            tree.root_node(),
+            source_code,
+            file_path,
+            &mut index,
+            &mut entity_id_counter,
+        )?;
+
+        Ok(index)
+    }
+
+    /// Extract entities from Kotlin code and convert to CodeEntity format
+    pub fn extract_code_entities(
+        &mut self,
+        source_code: &str,
+        file_path: &str,
+    ) -> Result<Vec<CodeEntity>> {
+        let parse_index = self.parse_source(source_code, file_path)?;
+        Ok(parse_index
+            .entities
+            .values()
+            .map(|entity| entity.to_code_entity(source_code))
+            .collect())
+    }
+
+    /// Determine entity kind from node kind, returning None for non-entity nodes.
+    fn determine_entity_kind(node: &Node, source_code: &str) -> Option<EntityKind> {
+        match node.kind() {
+            "class_declaration" if has_token(node, "interface") => Some(EntityKind::Interface),
+            "class_declaration"
+                if find_child_by_kind(node, "enum_class_body").is_some()
+                    || modifier_keywords(node, source_code)
+                        .0
+                        .iter()
+                        .any(|m| m == "enum") =>
+            {
+                Some(EntityKind::Enum)
+            }
+            "class_declaration" | "object_declaration" | "companion_object" => {
+                Some(EntityKind::Class)
+            }
+            "function_declaration" if is_member(node) => Some(EntityKind::Method),
+            "function_declaration" => Some(EntityKind::Function),
+            "secondary_constructor" => Some(EntityKind::Method),
+            "property_declaration" if !is_local(node) => Some(EntityKind::Variable),
+            _ => None,
+        }
+    }
+
+    /// Extract metadata based on node kind.
+    fn extract_entity_metadata(
+        &self,
+        node: &Node,
+        source_code: &str,
+        metadata: &mut HashMap<String, serde_json::Value>,
+    ) {
+        match node.kind() {
+            kind if TYPE_DECLARATION_KINDS.contains(&kind) => {
+                self.extract_type_metadata(node, source_code, metadata)
+            }
+            "function_declaration" | "secondary_constructor" => {
+                self.extract_function_metadata(node, source_code, metadata)
+            }
+            "property_declaration" => self.extract_property_metadata(node, source_code, metadata),
+            _ => {}
+        }
+    }
+
+    /// Extract supertypes, generics, constructor properties and the qualified name of a type.
+    fn extract_type_metadata(
+        &self,
+        node: &Node,
+        source_code: &str,
+        metadata: &mut HashMap<String, serde_json::Value>,
+    ) {
+        insert_modifiers(node, source_code, metadata);
+
+        match node.kind() {
+            "object_declaration" => {
+                metadata.insert("is_object".to_string(), serde_json::Value::Bool(true));
+            }
+            "companion_object" => {
+                metadata.insert("is_object".to_string(), serde_json::Value::Bool(true));
+                metadata.insert("is_companion".to_string(), serde_json::Value::Bool(true));
+            }
+            _ if has_token(node, "fun") => {
+                metadata.insert(
+                    "is_fun_interface".to_string(),
+                    serde_json::Value::Bool(true),
+                );
+            }
+            _ => {}
+        }
+
+        let type_parameters = type_parameters(node, source_code);
+        if !type_parameters.is_empty() {
+            metadata.insert(
+                "type_parameters".to_string(),
+                serde_json::json!(type_parameters),
+            );
+        }
+
+        let supertypes = supertypes(node, source_code);
+        if !supertypes.is_empty() {
+            metadata.insert("supertypes".to_string(), serde_json::json!(supertypes));
+        }
+
+        if let Some(constructor) = find_child_by_kind(node, "primary_constructor") {
+            let parameters = class_parameters(&constructor, source_code);
+            metadata.insert(
+                "constructor_parameters".to_string(),
+                serde_json::json!(parameters.names),
+            );
+            metadata.insert(
+                "constructor_parameter_types".to_string(),
+                serde_json::json!(parameters.types),
+            );
+            if !parameters.properties.is_empty() {
+                metadata.insert(
+                    "constructor_properties".to_string(),
+                    serde_json::json!(parameters.properties),
+                );
+            }
+        }
+
+        let entries = enum_entries(node, source_code);
+        if !entries.is_empty() {
+            metadata.insert("enum_entries".to_string(), serde_json::json!(entries));
+        }
+
+        if let Some(package) = declared_package(node, source_code) {
+            metadata.insert("package".to_string(), serde_json::Value::String(package));
+        }
+        metadata.insert(
+            "qualified_name".to_string(),
+            serde_json::Value::String(qualified_name(node, source_code)),
+        );
+    }
+
+    /// Extract the signature, receiver and call behaviour of a function.
+    fn extract_function_metadata(
+        &self,
+        node: &Node,
+        source_code: &str,
+        metadata: &mut HashMap<String, serde_json::Value>,
+    ) {
+        insert_modifiers(node, source_code, metadata);
+
+        let parameters = find_child_by_kind(node, "function_value_parameters")
+            .map(|parameters| function_parameters(&parameters, source_code))
+            .unwrap_or_default();
+        metadata.insert(
+            "parameters".to_string(),
+            serde_json::json!(parameters.names),
+        );
+        metadata.insert(
+            "parameter_types".to_string(),
+            serde_json::json!(parameters.types),
+        );
+        if !parameters.nullable.is_empty() {
+            metadata.insert(
+                "nullable_parameters".to_string(),
+                serde_json::json!(parameters.nullable),
+            );
+        }
+
+        if node.kind() == "secondary_constructor" {
+            metadata.insert("is_constructor".to_string(), serde_json::Value::Bool(true));
+        } else {
+            if let Some(receiver) = receiver_type(node, source_code) {
+                metadata.insert(
+                    "receiver_type".to_string(),
+                    serde_json::Value::String(receiver),
+                );
+                metadata.insert("is_extension".to_string(), serde_json::Value::Bool(true));
+            }
+            if let Some(return_type) = return_type(node) {
+                let text = text_of(&return_type, source_code);
+                if is_nullable(&return_type, &text) {
+                    metadata.insert(
+                        "returns_nullable".to_string(),
+                        serde_json::Value::Bool(true),
+                    );
+                }
+                metadata.insert("return_type".to_string(), serde_json::Value::String(text));
+            }
+        }
+
+        let type_parameters = type_parameters(node, source_code);
+        if !type_parameters.is_empty() {
+            metadata.insert(
+                "type_parameters".to_string(),
+                serde_json::json!(type_parameters),
+            );
+        }
+
+        if find_child_by_kind(node, "function_body").is_some_and(|body| has_token(&body, "=")) {
+            metadata.insert(
+                "is_expression_bodied".to_string(),
+                serde_json::Value::Bool(true),
+            );
+        }
+
+        let calls = collect_calls(node, source_code);
+        if !calls.scope_functions.is_empty() {
+            metadata.insert(
+                "scope_functions".to_string(),
+                serde_json::json!(calls.scope_functions),
+            );
+        }
+        if !calls.dsl_builders.is_empty() {
+            metadata.insert(
+                "dsl_builders".to_string(),
+                serde_json::json!(calls.dsl_builders),
+            );
+        }
+        metadata.insert(
+            "function_calls".to_string(),
+            serde_json::json!(calls.targets),
+        );
+    }
+
+    /// Extract the type, mutability, nullability and delegate of a property.
+    fn extract_property_metadata(
+        &self,
+        node: &Node,
+        source_code: &str,
+        metadata: &mut HashMap<String, serde_json::Value>,
+    ) {
+        insert_modifiers(node, source_code, metadata);
+        metadata.insert("is_property".to_string(), serde_json::Value::Bool(true));
+        metadata.insert(
+            "is_mutable".to_string(),
+            serde_json::Value::Bool(has_token(node, "var")),
+        );
+
+        if let Some(ty) = find_child_by_kind(node, "variable_declaration")
+            .and_then(|declaration| type_after_colon(&declaration))
+        {
+            let text = text_of(&ty, source_code);
+            if is_nullable(&ty, &text) {
+                metadata.insert("is_nullable".to_string(), serde_json::Value::Bool(true));
+            }
+            metadata.insert("property_type".to_string(), serde_json::Value::String(text));
+        }
+        if let Some(receiver) = receiver_type(node, source_code) {
+            metadata.insert(
+                "receiver_type".to_string(),
+                serde_json::Value::String(receiver),
+            );
+            metadata.insert("is_extension".to_string(), serde_json::Value::Bool(true));
+        }
+        if let Some(delegate) = find_child_by_kind(node, "property_delegate") {
+            let text = text_of(&delegate, source_code);
+            metadata.insert(
+                "delegate".to_string(),
+                serde_json::Value::String(text.trim_start_matches("by").trim().to_string()),
+            );
+        }
+    }
+
+    /// Build an import statement from an `import` directive.
+    fn import_statement(
+        &self,
+        node: &Node,
+        source_code: &str,
+        line_number: usize,
+    ) -> Option<ImportStatement> {
+        let mut cursor = node.walk();
+        let children: Vec<Node> = node.children(&mut cursor).collect();
+        let name = children
+            .iter()
+            .find(|child| {
+                matches!(child.kind(), "qualified_identifier" | "identifier")
+                    || IDENTIFIER_KINDS.contains(&child.kind())
+            })
+            .map(|child| text_of(child, source_code))?;
+        let is_star = children
+            .iter()
+            .any(|child| matches!(child.kind(), "*" | "wildcard_import"));
+        let alias = find_child_by_kind(node, "import_alias")
+            .and_then(|alias| first_identifier(&alias))
+            .or_else(|| {
+                children
+                    .iter()
+                    .skip_while(|child| child.kind() != "as")
+                    .find(|child| IDENTIFIER_KINDS.contains(&child.kind()))
+                    .copied()
+            })
+            .map(|alias| text_of(&alias, source_code));
+
+        let (module, imports, import_type) = match (alias, is_star, name.rsplit_once('.')) {
+            (Some(alias), _, _) => (name, Some(vec![alias]), "alias"),
+            (None, true, _) => (name, None, "star"),
+            (None, false, Some((module, member))) => {
+                (module.to_string(), Some(vec![member.to_string()]), "named")
+            }
+            (None, false, None) => (name, None, "named"),
+        };
+
+        Some(ImportStatement {
+            module,
+            imports,
+            import_type: import_type.to_string(),
+            line_number,
+        })
+    }
+}
+
+/// [`LanguageAdapter`] implementation for Kotlin source code.
+impl LanguageAdapter for KotlinAdapter {
+    /// Parses source code into a tree-sitter AST.
+    fn parse_tree(&mut self, source: &str) -> Result<Tree> {
+        parse_until(&mut self.parser, source, self.deadline)
+            .ok_or_else(|| ValknutError::parse("kt", "Failed to parse Kotlin source"))
+    }
+
+    /// Parses Kotlin source code and returns a parse index.
+    fn parse_source(&mut self, source: &str, file_path: &str) -> Result<ParseIndex> {
+        KotlinAdapter::parse_source(self, source, file_path)
+    }
+
+    /// Extracts call targets, including scope functions and DSL builders.
+    fn extract_function_calls(&mut self, source: &str) -> Result<Vec<String>> {
+        let tree = self.parse_tree(source)?;
+        Ok(collect_calls(&tree.root_node(), source).targets)
+    }
+
+    /// Extracts all identifier tokens from the source.
+    fn extract_identifiers(&mut self, source: &str) -> Result<Vec<String>> {
+        let tree = self.parse_tree(source)?;
+        Ok(extract_identifiers_by_kinds(
+            tree.root_node(),
+            source,
+            IDENTIFIER_KINDS,
+        ))
+    }
+
+    /// Counts distinct code blocks in the source.
+    fn count_distinct_blocks(&mut self, source: &str) -> Result<usize> {
+        let index = KotlinAdapter::parse_source(self, source, "<memory>")?;
+        Ok(index.count_distinct_blocks())
+    }
+
+    /// Returns the language name ("kt").
+    fn language_name(&self) -> &str {
+        "kt"
+    }
+
+    /// Bounds entity extraction to `depth` levels of AST nesting.
+    fn set_max_ast_depth(&mut self, depth: usize) {
+        self.max_depth = depth;
+    }
+
+    /// Abandons parses still running once `deadline` passes.
+    fn set_parse_deadline(&mut self, deadline: Option<Instant>) {
+        self.deadline = deadline;
+    }
+
+    /// Extracts `import` directives.
+    fn extract_imports(&mut self, source: &str) -> Result<Vec<ImportStatement>> {
+        let tree = self.parse_tree(source)?;
+        let mut imports = Vec::new();
+        walk_tree(tree.root_node(), &mut |node| {
+            if matches!(node.kind(), "import" | "import_header") && node.is_named() {
+                imports.extend(self.import_statement(&node, source, node.start_position().row + 1));
+            }
+        });
+        Ok(imports)
+    }
+
+    /// Extracts code entities from Kotlin source code.
+    fn extract_code_entities(&mut self, source: &str, file_path: &str) -> Result<Vec<CodeEntity>> {
+        KotlinAdapter::extract_code_entities(self, source, file_path)
+    }
+}
+
+/// [`EntityExtractor`] implementation providing the language-specific node conversion.
+impl EntityExtractor for KotlinAdapter {
+    fn max_depth(&self) -> usize {
+        self.max_depth
+    }
+
+    fn node_to_entity(
+        &self,
+        node: Node,
+        source_code: &str,
+        file_path: &str,
+        parent_id: Option<String>,
+        entity_id_counter: &mut usize,
+    ) -> Result<Option<ParsedEntity>> {
+        let Some(entity_kind) = Self::determine_entity_kind(&node, source_code) else {
+            return Ok(None);
+        };
+
+        let name = declared_name(&node, source_code).unwrap_or_else(|| match node.kind() {
+            "companion_object" => "Companion".to_string(),
+            "secondary_constructor" => "constructor".to_string(),
+            _ => entity_kind.fallback_name(*entity_id_counter),
+        });
+
+        *entity_id_counter += 1;
+        let entity_id = generate_entity_id(file_path, entity_kind, *entity_id_counter);
+        let location = SourceLocation::from_positions(
+            file_path,
+            node.start_position().row,
+            node.start_position().column,
+            node.end_position().row,
+            node.end_position().column,
+        );
+        let mut metadata = create_base_metadata(node.kind(), node.start_byte(), node.end_byte());
+        self.extract_entity_metadata(&node, source_code, &mut metadata);
+
+        Ok(Some(ParsedEntity {
+            id: entity_id,
+            kind: entity_kind,
+            name,
+            parent: parent_id,
+            children: Vec::new(),
+            location,
+            metadata,
+        }))
+    }
+}
+
+/// Default implementation for [`KotlinAdapter`].
+impl Default for KotlinAdapter {
+    /// Returns a new Kotlin adapter, or a minimal fallback on failure.
+    fn default() -> Self {
+        Self::new().unwrap_or_else(|e| {
+            warn!(
+                "Failed to create Kotlin adapter, using minimal fallback: {}",
+                e
+            );
+            KotlinAdapter {
+                parser: tree_sitter::Parser::new(),
+                language: get_tree_sitter_language("kt")
+                    .unwrap_or_else(|_| tree_sitter_kotlin_ng::LANGUAGE.into()),
+                max_depth: DEFAULT_MAX_RECURSION_DEPTH,
+                deadline: None,
+            }
+        })
+    }
+}
+
+/// True when a direct child of `node` is the anonymous token `token`.
+fn has_token(node: &Node, token: &str) -> bool {
+    let mut cursor = node.walk();
+    let found = node
+        .children(&mut cursor)
+        .any(|child| !child.is_named() && child.kind() == token);
+    found
+}
+
+/// First direct child that is an identifier.
+fn first_identifier<'a>(node: &Node<'a>) -> Option<Node<'a>> {
+    let mut cursor = node.walk();
+    let found = node
+        .named_children(&mut cursor)
+        .find(|child| IDENTIFIER_KINDS.contains(&child.kind()));
+    found
+}
+
+/// The node naming a declaration: the `name` field, else its first identifier.
+fn name_node<'a>(node: &Node<'a>) -> Option<Node<'a>> {
+    node.child_by_field_name("name")
+        .or_else(|| first_identifier(node))
+}
+
+/// Declared name of a class, object, function or property.
+fn declared_name(node: &Node, source_code: &str) -> Option<String> {
+    match node.kind() {
+        "property_declaration" => find_child_by_kind(node, "variable_declaration")
+            .or_else(|| find_child_by_kind(node, "multi_variable_declaration"))
+            .map(|declaration| text_of(&declaration, source_code))
+            .map(|text| {
+                text.split(':')
+                    .next()
+                    .unwrap_or_default()
+                    .trim()
+                    .to_string()
+            }),
+        "secondary_constructor" => None,
+        _ => name_node(node).map(|name| text_of(&name, source_code)),
+    }
+}
+
+/// True when the nearest enclosing scope of `node` is a class body.
+fn is_member(node: &Node) -> bool {
+    let mut current = node.parent();
+    while let Some(candidate) = current {
+        match candidate.kind() {
+            kind if CLASS_BODY_KINDS.contains(&kind) => return true,
+            kind if LOCAL_SCOPE_KINDS.contains(&kind) => return false,
+            "source_file" => return false,
+            _ => current = candidate.parent(),
+        }
+    }
+    false
+}
+
+/// True when `node` is declared inside a function, lambda or accessor.
+fn is_local(node: &Node) -> bool {
+    let mut current = node.parent();
+    while let Some(candidate) = current {
+        match candidate.kind() {
+            kind if CLASS_BODY_KINDS.contains(&kind) => return false,
+            kind if LOCAL_SCOPE_KINDS.contains(&kind) => return true,
+            "source_file" => return false,
+            _ => current = candidate.parent(),
+        }
+    }
+    false
+}
+
+/// Modifier keywords and annotation names of a declaration.
+fn modifier_keywords(node: &Node, source_code: &str) -> (Vec<String>, Vec<String>) {
+    let mut keywords = Vec::new();
+    let mut annotations = Vec::new();
+    let Some(modifiers) = find_child_by_kind(node, "modifiers") else {
+        return (keywords, annotations);
+    };
+
+    let mut stack = vec![modifiers];
+    while let Some(current) = stack.pop() {
+        if current.kind() == "annotation" {
+            let text = text_of(&current, source_code);
+            let name = text.trim_start_matches('@');
+            let name = name.split(['(', ' ']).next().unwrap_or(name);
+            annotations.push(simple_type_name(name));
+            continue;
+        }
+        if current.child_count() == 0 {
+            let text = text_of(&current, source_code);
+            if !text.is_empty() && text.chars().all(|c| c.is_ascii_alphabetic()) {
+                keywords.push(text);
+            }
+            continue;
+        }
+        let mut cursor = current.walk();
+        let children: Vec<Node> = current.children(&mut cursor).collect();
+        stack.extend(children.into_iter().rev());
+    }
+    (keywords, annotations)
+}
+
+/// Record modifiers, visibility and annotations of a declaration.
+fn insert_modifiers(
+    node: &Node,
+    source_code: &str,
+    metadata: &mut HashMap<String, serde_json::Value>,
+) {
+    let (keywords, annotations) = modifier_keywords(node, source_code);
+
+    // Kotlin declarations are public unless stated otherwise.
+    let visibility = ["private", "protected", "internal", "public"]
+        .into_iter()
+        .find(|visibility| keywords.iter().any(|keyword| keyword == visibility))
+        .unwrap_or("public");
+    metadata.insert(
+        "visibility".to_string(),
+        serde_json::Value::String(visibility.to_string()),
+    );
+    for flag in MODIFIER_FLAGS {
+        if keywords.iter().any(|keyword| keyword == flag) {
+            metadata.insert(format!("is_{flag}"), serde_json::Value::Bool(true));
+        }
+    }
+    if !keywords.is_empty() {
+        metadata.insert("modifiers".to_string(), serde_json::json!(keywords));
+    }
+    if !annotations.is_empty() {
+        metadata.insert("annotations".to_string(), serde_json::json!(annotations));
+    }
+}
+
+/// Type parameter declarations, including variance and bounds (`out T : Any`).
+fn type_parameters(node: &Node, source_code: &str) -> Vec<String> {
+    let Some(parameters) = find_child_by_kind(node, "type_parameters") else {
+        return Vec::new();
+    };
+    let mut cursor = parameters.walk();
+    parameters
+        .named_children(&mut cursor)
+        .filter(|child| child.kind() == "type_parameter")
+        .map(|child| text_of(&child, source_code))
+        .collect()
+}
+
+/// Supertypes after the `:` of a class or object, without constructor arguments
+/// or `by` delegation.
+fn supertypes(node: &Node, source_code: &str) -> Vec<String> {
+    let mut specifiers = Vec::new();
+    let mut cursor = node.walk();
+    for child in node.named_children(&mut cursor) {
+        match child.kind() {
+            "delegation_specifier" => specifiers.push(child),
+            "delegation_specifiers" => {
+                let mut inner = child.walk();
+                specifiers.extend(
+                    child
+                        .named_children(&mut inner)
+                        .filter(|specifier| specifier.kind() == "delegation_specifier"),
+                );
+            }
+            _ => {}
+        }
+    }
+    specifiers
+        .iter()
+        .map(|specifier| {
+            let text = text_of(specifier, source_code);
+            let text = text.split(" by ").next().unwrap_or(&text);
+            text.split('(').next().unwrap_or(text).trim().to_string()
+        })
+        .filter(|supertype| !supertype.is_empty())
+        .collect()
+}
+
+/// Entry names of an enum class body.
+fn enum_entries(node: &Node, source_code: &str) -> Vec<String> {
+    let Some(body) = find_child_by_kind(node, "enum_class_body") else {
+        return Vec::new();
+    };
+    let mut cursor = body.walk();
+    body.named_children(&mut cursor)
+        .filter(|child| child.kind() == "enum_entry")
+        .filter_map(|entry| name_node(&entry))
+        .map(|name| text_of(&name, source_code))
+        .collect()
+}
+
+/// Package declared by the file containing `node`.
+fn declared_package(node: &Node, source_code: &str) -> Option<String> {
+    let mut root = *node;
+    while let Some(parent) = root.parent() {
+        root = parent;
+    }
+    let header = find_child_by_kind(&root, "package_header")?;
+    let mut cursor = header.walk();
+    let name = header
+        .named_children(&mut cursor)
+        .find(|child| {
+            matches!(child.kind(), "qualified_identifier" | "identifier")
+                || IDENTIFIER_KINDS.contains(&child.kind())
+        })
+        .map(|name| text_of(&name, source_code));
+    name
+}
+
+/// Package-qualified name of a type, including enclosing types (`com.acme.Outer.Inner`).
+fn qualified_name(node: &Node, source_code: &str) -> String {
+    let own = declared_name(node, source_code).unwrap_or_else(|| "Companion".to_string());
+    let mut segments = vec![own];
+    let mut current = node.parent();
+    while let Some(candidate) = current {
+        if TYPE_DECLARATION_KINDS.contains(&candidate.kind()) {
+            segments.push(
+                declared_name(&candidate, source_code).unwrap_or_else(|| "Companion".to_string()),
+            );
+        }
+        current = candidate.parent();
+    }
+    if let Some(package) = declared_package(node, source_code) {
+        segments.push(package);
+    }
+    segments.reverse();
+    segments.join(".")
+}
+
+/// The type following the first `:` token among a node's children.
+fn type_after_colon<'a>(node: &Node<'a>) -> Option<Node<'a>> {
+    let mut cursor = node.walk();
+    let found = node
+        .children(&mut cursor)
+        .skip_while(|child| child.kind() != ":")
+        .find(|child| child.is_named());
+    found
+}
+
+/// Declared return type of a function: the type after `:` following its parameters.
+fn return_type<'a>(node: &Node<'a>) -> Option<Node<'a>> {
+    let mut cursor = node.walk();
+    let found = node
+        .children(&mut cursor)
+        .skip_while(|child| child.kind() != "function_value_parameters")
+        .skip_while(|child| child.kind() != ":")
+        .find(|child| child.is_named())
+        .filter(|child| !matches!(child.kind(), "function_body" | "type_constraints"));
+    found
+}
+
+/// Receiver type of an extension function or property (`fun String.slug()`).
+fn receiver_type(node: &Node, source_code: &str) -> Option<String> {
+    if let Some(receiver) = find_child_by_kind(node, "receiver_type") {
+        return Some(text_of(&receiver, source_code));
+    }
+    // Older grammars place the receiver type and `.` directly before the name.
+    let name = name_node(node);
+    let mut cursor = node.walk();
+    let children: Vec<Node> = node
+        .children(&mut cursor)
+        .take_while(|child| Some(*child) != name)
+        .collect();
+    let dot = children.iter().rposition(|child| child.kind() == ".")?;
+    children[..dot]
+        .iter()
+        .rev()
+        .find(|child| child.is_named() && !matches!(child.kind(), "modifiers" | "type_parameters"))
+        .map(|receiver| text_of(receiver, source_code))
+}
+
+/// True for `T?` types.
+fn is_nullable(ty: &Node, text: &str) -> bool {
+    ty.kind() == "nullable_type" || text.ends_with('?')
+}
+
+/// Parameters of a function or class declaration.
+#[derive(Debug, Default)]
+struct Parameters {
+    /// Parameter names in declaration order.
+    names: Vec<String>,
+    /// Declared types, prefixed with `vararg` where applicable.
+    types: Vec<String>,
+    /// Names of parameters with a nullable (`T?`) type.
+    nullable: Vec<String>,
+    /// Constructor parameters declared with `val` or `var`.
+    properties: Vec<String>,
+}
+
+/// Names, types and nullability of `function_value_parameters`.
+fn function_parameters(parameters: &Node, source_code: &str) -> Parameters {
+    let mut result = Parameters::default();
+    let mut cursor = parameters.walk();
+    for child in parameters.named_children(&mut cursor) {
+        // Newer grammars wrap each parameter with its modifiers and default value.
+        let parameter = match child.kind() {
+            "parameter" => child,
+            "function_value_parameter" => match find_child_by_kind(&child, "parameter") {
+                Some(parameter) => parameter,
+                None => continue,
+            },
+            _ => continue,
+        };
+        let is_vararg = parameter_modifiers(&parameter).is_some_and(|modifiers| {
+            text_of(&modifiers, source_code)
+                .split_whitespace()
+                .any(|word| word == "vararg")
+        });
+        push_parameter(&mut result, &parameter, source_code, is_vararg);
+    }
+    result
+}
+
+/// The `parameter_modifiers` preceding a function parameter.
+fn parameter_modifiers<'a>(parameter: &Node<'a>) -> Option<Node<'a>> {
+    parameter
+        .prev_named_sibling()
+        .filter(|sibling| sibling.kind() == "parameter_modifiers")
+        .or_else(|| {
+            parameter
+                .parent()
+                .filter(|parent| parent.kind() == "function_value_parameter")
+                .and_then(|parent| find_child_by_kind(&parent, "parameter_modifiers"))
+        })
+}
+
+/// Names, types and `val`/`var` properties of a primary constructor.
+fn class_parameters(constructor: &Node, source_code: &str) -> Parameters {
+    let mut result = Parameters::default();
+    let mut parameters = Vec::new();
+    walk_tree(*constructor, &mut |node| {
+        if node.kind() == "class_parameter" {
+            parameters.push(node);
+        }
+    });
+    for parameter in parameters {
+        let is_vararg = find_child_by_kind(&parameter, "modifiers")
+            .is_some_and(|modifiers| text_of(&modifiers, source_code).contains("vararg"));
+        push_parameter(&mut result, &parameter, source_code, is_vararg);
+        if has_token(&parameter, "val") || has_token(&parameter, "var") {
+            if let Some(name) = result.names.last() {
+                result.properties.push(name.clone());
+            }
+        }
+    }
+    result
+}
+
+/// Append one parameter's name, type and nullability.
+fn push_parameter(result: &mut Parameters, parameter: &Node, source_code: &str, vararg: bool) {
+    let name = name_node(parameter)
+        .map(|name| text_of(&name, source_code))
+        .unwrap_or_default();
+    if let Some(ty) = type_after_colon(parameter) {
+        let declared = text_of(&ty, source_code);
+        if is_nullable(&ty, &declared) {
+            result.nullable.push(name.clone());
+        }
+        result.types.push(if vararg {
+            format!("vararg {declared}")
+        } else {
+            declared
+        });
+    }
+    result.names.push(name);
+}
+
+/// Calls made inside a node.
+#[derive(Debug, Default)]
+struct Calls {
+    /// Every call target (`obj.name` or `name`).
+    targets: Vec<String>,
+    /// Scope functions used (`apply`, `let`, ...).
+    scope_functions: Vec<String>,
+    /// Receiver-less calls whose only argument is a trailing lambda (`html { ... }`).
+    dsl_builders: Vec<String>,
+}
+
+/// Collect call targets, scope functions and DSL builder calls.
+///
+/// Both kinds of lambda call are treated as opaque: calls inside the lambda are
+/// attributed to the enclosing function as usual.
+fn collect_calls(node: &Node, source_code: &str) -> Calls {
+    let mut calls = Calls::default();
+    walk_tree(*node, &mut |child| {
+        if child.kind() != "call_expression" {
+            return;
+        }
+        let Some(callee) = child.named_child(0) else {
+            return;
+        };
+        let target = call_target(&callee, source_code);
+        if target.is_empty() {
+            return;
+        }
+        let name = target.rsplit('.').next().unwrap_or(&target).to_string();
+
+        if SCOPE_FUNCTIONS.contains(&name.as_str()) {
+            calls.scope_functions.push(name.clone());
+        } else if IDENTIFIER_KINDS.contains(&callee.kind()) && takes_only_trailing_lambda(&child) {
+            calls.dsl_builders.push(name.clone());
+        }
+        calls.targets.push(target);
+    });
+    sort_and_dedup(&mut calls.targets);
+    sort_and_dedup(&mut calls.scope_functions);
+    sort_and_dedup(&mut calls.dsl_builders);
+    calls
+}
+
+/// Name of a called function with generic arguments removed.
+fn call_target(callee: &Node, source_code: &str) -> String {
+    let text = text_of(callee, source_code);
+    match text.rsplit_once('.') {
+        Some((receiver, name)) => format!(
+            "{}.{}",
+            receiver.trim_end_matches('?'),
+            simple_type_name(name)
+        ),
+        None => simple_type_name(&text),
+    }
+}
+
+/// True when a call passes a trailing lambda and no parenthesized arguments.
+fn takes_only_trailing_lambda(call: &Node) -> bool {
+    let mut has_lambda = false;
+    let mut has_arguments = false;
+    let mut stack: Vec<Node> = {
+        let mut cursor = call.walk();
+        let children: Vec<Node> = call.named_children(&mut cursor).skip(1).collect();
+        children
+    };
+    while let Some(current) = stack.pop() {
+        match current.kind() {
+            "annotated_lambda" | "lambda_literal" => has_lambda = true,
+            "value_arguments" => has_arguments |= current.named_child_count() > 0,
+            "call_suffix" => {
+                let mut cursor = current.walk();
+                stack.extend(current.named_children(&mut cursor));
+            }
+            _ => {}
+        }
+    }
+    has_lambda && !has_arguments
+}
+
+#[cfg(test)]
+#[path = "kotlin_tests.rs"]
+mod tests;
@@ -0,0 +1,217 @@
+use super::*;
+
+fn entity<'a>(index: &'a ParseIndex, name: &str) -> &'a ParsedEntity {
+    index
+        .entities
+        .values()
+        .find(|e| e.name == name)
+        .unwrap_or_else(|| panic!("entity {name} not found"))
+}
+
+fn strings(entity: &ParsedEntity, key: &str) -> Vec<String> {
+    entity
+        .metadata
+        .get(key)
+        .and_then(|value| value.as_array())
+        .map(|values| {
+            values
+                .iter()
+                .filter_map(|value| value.as_str().map(str::to_string))
+                .collect()
+        })
+        .unwrap_or_default()
+}
+
+#[test]
+fn test_kotlin_adapter_creation() {
+    let adapter = KotlinAdapter::new();
+    assert!(adapter.is_ok());
+}
+
+#[test]
+fn test_class_declarations() {
+    let mut adapter = KotlinAdapter::new().unwrap();
+    let source = r#"
+package com.acme.orders
+
+data class Order(val id: String, var note: String?, count: Int) : Entity, Comparable<Order>
+
+sealed class Result<out T> {
+    class Success<T>(val value: T) : Result<T>()
+    object Loading : Result<Nothing>()
+}
+
+enum class Status { OPEN, CLOSED }
+
+interface Repository<T> {
+    fun find(id: String): T?
+}
+
+object Registry {
+    const val VERSION = 1
+}
+
+class Service {
+    companion object {
+        fun create(): Service = Service()
+    }
+}
+"#;
+
+    let index = adapter.parse_source(source, "Order.kt").unwrap();
+
+    let order = entity(&index, "Order");
+    assert_eq!(order.kind, EntityKind::Class);
+    assert_eq!(order.metadata["is_data"], true);
+    assert_eq!(order.metadata["visibility"], "public");
+    assert_eq!(order.metadata["package"], "com.acme.orders");
+    assert_eq!(order.metadata["qualified_name"], "com.acme.orders.Order");
+    assert_eq!(
+        strings(order, "constructor_parameters"),
+        vec!["id", "note", "count"]
+    );
+    assert_eq!(strings(order, "constructor_properties"), vec!["id", "note"]);
+    assert_eq!(
+        strings(order, "supertypes"),
+        vec!["Entity", "Comparable<Order>"]
+    );
+
+    let result = entity(&index, "Result");
+    assert_eq!(result.metadata["is_sealed"], true);
+    assert_eq!(strings(result, "type_parameters"), vec!["out T"]);
+
+    let success = entity(&index, "Success");
+    assert_eq!(success.parent.as_deref(), Some(result.id.as_str()));
+    assert_eq!(strings(success, "supertypes"), vec!["Result<T>"]);
+    assert_eq!(
+        success.metadata["qualified_name"],
+        "com.acme.orders.Result.Success"
+    );
+
+    let loading = entity(&index, "Loading");
+    assert_eq!(loading.metadata["is_object"], true);
+
+    let status = entity(&index, "Status");
+    assert_eq!(status.kind, EntityKind::Enum);
+    assert_eq!(strings(status, "enum_entries"), vec!["OPEN", "CLOSED"]);
+
+    let repository = entity(&index, "Repository");
+    assert_eq!(repository.kind, EntityKind::Interface);
+
+    let registry = entity(&index, "Registry");
+    assert_eq!(registry.metadata["is_object"], true);
+    let version = entity(&index, "VERSION");
+    assert_eq!(version.kind, EntityKind::Variable);
+    assert_eq!(version.metadata["is_const"], true);
+    assert_eq!(version.metadata["is_mutable"], false);
+
+    let companion = entity(&index, "Companion");
+    assert_eq!(companion.metadata["is_companion"], true);
+    let create = entity(&index, "create");
+    assert_eq!(create.kind, EntityKind::Method);
+    assert_eq!(create.parent.as_deref(), Some(companion.id.as_str()));
+    assert_eq!(create.metadata["is_expression_bodied"], true);
+}
+
+#[test]
+fn test_function_signatures() {
+    let mut adapter = KotlinAdapter::new().unwrap();
+    let source = r#"
+suspend fun fetch(url: String, retries: Int?): Response? {
+    val local = 1
+    return client.get(url)
+}
+
+fun String.slugify(vararg separators: Char): String = lowercase()
+
+private inline fun <reified T> parse(json: String): T = decode(json)
+
+var counter: Int? = null
+"#;
+
+    let index = adapter.parse_source(source, "api.kt").unwrap();
+
+    let fetch = entity(&index, "fetch");
+    assert_eq!(fetch.kind, EntityKind::Function);
+    assert_eq!(fetch.metadata["is_suspend"], true);
+    assert_eq!(strings(fetch, "parameters"), vec!["url", "retries"]);
+    assert_eq!(strings(fetch, "parameter_types"), vec!["String", "Int?"]);
+    assert_eq!(strings(fetch, "nullable_parameters"), vec!["retries"]);
+    assert_eq!(fetch.metadata["return_type"], "Response?");
+    assert_eq!(fetch.metadata["returns_nullable"], true);
+    assert!(strings(fetch, "function_calls").contains(&"client.get".to_string()));
+    assert!(
+        index.entities.values().all(|e| e.name != "local"),
+        "local variables are not entities"
+    );
+
+    let slugify = entity(&index, "slugify");
+    assert_eq!(slugify.metadata["is_extension"], true);
+    assert_eq!(slugify.metadata["receiver_type"], "String");
+    assert_eq!(strings(slugify, "parameter_types"), vec!["vararg Char"]);
+    assert_eq!(slugify.metadata["return_type"], "String");
+
+    let parse = entity(&index, "parse");
+    assert_eq!(parse.metadata["visibility"], "private");
+    assert_eq!(parse.metadata["is_inline"], true);
+    assert_eq!(strings(parse, "type_parameters").len(), 1);
+
+    let counter = entity(&index, "counter");
+    assert_eq!(counter.metadata["is_mutable"], true);
+    assert_eq!(counter.metadata["is_nullable"], true);
+    assert_eq!(counter.metadata["property_type"], "Int?");
+}
+
+#[test]
+fn test_scope_functions_and_dsl_builders_are_opaque_calls() {
+    let mut adapter = KotlinAdapter::new().unwrap();
+    let source = r#"
+fun render(user: User?) {
+    user?.let { greet(it) }
+    val view = View().apply { title = "x" }
+    html {
+        body { text("hi") }
+    }
+    items.forEach { log(it) }
+}
+"#;
+
+    let index = adapter.parse_source(source, "View.kt").unwrap();
+    let render = entity(&index, "render");
+    assert_eq!(strings(render, "scope_functions"), vec!["apply", "let"]);
+    assert_eq!(strings(render, "dsl_builders"), vec!["body", "html"]);
+
+    let calls = strings(render, "function_calls");
+    for call in ["greet", "text", "log", "items.forEach"] {
+        assert!(
+            calls.contains(&call.to_string()),
+            "missing {call} in {calls:?}"
+        );
+    }
+}
+
+#[test]
+fn test_extract_imports() {
+    let mut adapter = KotlinAdapter::new().unwrap();
+    let source = r#"
+package com.acme
+
+import kotlinx.coroutines.launch
+import com.acme.model.*
+import com.acme.util.Strings as S
+"#;
+
+    let imports = adapter.extract_imports(source).unwrap();
+    assert_eq!(imports.len(), 3);
+
+    assert_eq!(imports[0].module, "kotlinx.coroutines");
+    assert_eq!(imports[0].imports, Some(vec!["launch".to_string()]));
+    assert_eq!(imports[0].import_type, "named");
+
+    assert_eq!(imports[1].module, "com.acme.model");
+    assert_eq!(imports[1].import_type, "star");
+
+    assert_eq!(imports[2].module, "com.acme.util.Strings");
+    assert_eq!(imports[2].imports, Some(vec!["S".to_string()]));
+    assert_eq!(imports[2].import_type, "alias");
+}
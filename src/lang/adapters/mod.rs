@@ -3,16 +3,24 @@
 //! This module contains adapters for parsing and analyzing code in
 //! various programming languages using tree-sitter.
 
+pub mod c;
 pub mod cpp;
+pub mod csharp;
 pub mod go;
+pub mod java;
 pub mod javascript;
+pub mod kotlin;
 pub mod python;
 pub mod rust_lang;
 pub mod typescript;
 
+pub use c::CAdapter;
 pub use cpp::CppAdapter;
+pub use csharp::CSharpAdapter;
 pub use go::GoAdapter;
+pub use java::JavaAdapter;
 pub use javascript::JavaScriptAdapter;
+pub use kotlin::KotlinAdapter;
 pub use python::PythonAdapter;
 pub use rust_lang::RustAdapter;
 pub use typescript::TypeScriptAdapter;
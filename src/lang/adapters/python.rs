@@ -2,14 +2,17 @@
 
 use std::collections::HashMap;
 use std::sync::Arc;
+use std::time::Instant;
 
 use async_trait::async_trait;
+use tracing::warn;
 use tree_sitter::{Language, Node, Parser, Tree, TreeCursor};
 
 use super::super::common::{
-    create_base_metadata, extract_identifiers_by_kinds, extract_node_text,
-    find_boilerplate_patterns, generate_entity_id, sort_and_dedup, EntityExtractor, EntityKind,
-    LanguageAdapter, ParseIndex, ParsedEntity, SourceLocation,
+    check_recursion_depth, check_syntax, create_base_metadata, extract_identifiers_by_kinds,
+    extract_node_text, find_boilerplate_patterns, generate_entity_id, parse_until, sort_and_dedup,
+    EntityExtractor, EntityKind, LanguageAdapter, ParseIndex, ParsedEntity, SourceLocation,
+    DEFAULT_MAX_RECURSION_DEPTH,
 };
 use super::super::registry::{create_parser_for_language, get_tree_sitter_language};
 use crate::core::errors::{Result, ValknutError};
@@ -31,6 +34,12 @@ pub struct PythonAdapter {
 
     /// Language instance
     language: Language,
+
+    /// Deepest AST node entity extraction visits
+    max_depth: usize,
+
+    /// Instant after which parses are abandoned (`--timeout`)
+    deadline: Option<Instant>,
 }
 
 /// Parsing and entity extraction methods for [`PythonAdapter`].
@@ -40,15 +49,26 @@ impl PythonAdapter {
         let language = get_tree_sitter_language("py")?;
         let parser = create_parser_for_language("py")?;
 
-        Ok(Self { parser, language })
+        Ok(Self {
+            parser,
+            language,
+            max_depth: DEFAULT_MAX_RECURSION_DEPTH,
+            deadline: None,
+        })
     }
 
     /// Parse Python source code and extract entities
     pub fn parse_source(&mut self, source_code: &str, file_path: &str) -> Result<ParseIndex> {
-        let tree = self
-            .parser
-            .parse(source_code, None)
-            .ok_or_else(|| ValknutError::parse("python", "Failed to parse Python source code"))?;
+        let tree = parse_until(&mut self.parser, source_code, self.deadline).ok_or_else(|| {
+            ValknutError::parse_with_location(
+                "python",
+                "Failed to parse Python source code",
+                file_path,
+                None,
+                None,
+            )
+        })?;
+        check_syntax(&tree, "python", file_path)?;
 
         let mut index = ParseIndex::new();
         let mut entity_id_counter = 0;
@@ -88,10 +108,16 @@ impl PythonAdapter {
         source_code: &str,
         file_path: &str,
     ) -> Result<InternedParseIndex> {
-        let tree = self
-            .parser
-            .parse(source_code, None)
-            .ok_or_else(|| ValknutError::parse("python", "Failed to parse Python source code"))?;
+        let tree = parse_until(&mut self.parser, source_code, self.deadline).ok_or_else(|| {
+            ValknutError::parse_with_location(
+                "python",
+                "Failed to parse Python source code",
+                file_path,
+                None,
+                None,
+            )
+        })?;
+        check_syntax(&tree, "python", file_path)?;
 
         let mut index = InternedParseIndex::new();
         let mut entity_id_counter = 0;
@@ -165,21 +191,42 @@ impl PythonAdapter {
     ) -> Result<Vec<&'a str>> {
         let mut parameters = Vec::new();
         let mut cursor = node.walk();
-        for child in node.children(&mut cursor) {
-            if child.kind() == "identifier" {
-                parameters.push(child.utf8_text(source_code.as_bytes())?);
+        for child in node.named_children(&mut cursor) {
+            if let Some(name) = Self::parameter_name(&child, source_code)? {
+                parameters.push(name);
             }
         }
         Ok(parameters)
     }
 
-    /// Scan function children for parameters, decorators, and return annotation.
+    /// Extract the bound name of a single parameter node, if it has one.
+    fn parameter_name<'a>(param: &Node<'a>, source_code: &'a str) -> Result<Option<&'a str>> {
+        let name_node = match param.kind() {
+            "identifier" => Some(*param),
+            "default_parameter" | "typed_default_parameter" => param.child_by_field_name("name"),
+            "typed_parameter" | "list_splat_pattern" | "dictionary_splat_pattern" => {
+                let mut cursor = param.walk();
+                let found = param
+                    .named_children(&mut cursor)
+                    .find(|child| child.kind() == "identifier");
+                found
+            }
+            _ => None,
+        };
+        match name_node {
+            Some(node) if node.kind() == "identifier" => {
+                Ok(Some(node.utf8_text(source_code.as_bytes())?))
+            }
+            _ => Ok(None),
+        }
+    }
+
+    /// Scan function children for parameters and return annotation.
     fn scan_function_children<'a>(
         node: &Node<'a>,
         source_code: &'a str,
-    ) -> Result<(Vec<&'a str>, bool, Option<String>)> {
+    ) -> Result<(Vec<&'a str>, Option<String>)> {
         let mut parameters = Vec::new();
-        let mut has_decorators = false;
         let mut return_annotation = None;
 
         let mut cursor = node.walk();
@@ -188,14 +235,95 @@ impl PythonAdapter {
                 "parameters" => {
                     parameters = Self::extract_parameters_from_node(&child, source_code)?;
                 }
-                "decorator" => has_decorators = true,
                 "type" => {
                     return_annotation = Some(child.utf8_text(source_code.as_bytes())?.to_string());
                 }
                 _ => {}
             }
         }
-        Ok((parameters, has_decorators, return_annotation))
+        Ok((parameters, return_annotation))
+    }
+
+    /// Collect decorator names for a function or class definition.
+    ///
+    /// tree-sitter attaches decorators to the wrapping `decorated_definition`
+    /// node rather than the definition itself, so the parent is inspected.
+    /// Call arguments are dropped, so `@app.route("/")` yields `app.route`.
+    fn extract_decorators(node: &Node, source_code: &str) -> Vec<String> {
+        let Some(parent) = node.parent() else {
+            return Vec::new();
+        };
+        if parent.kind() != "decorated_definition" {
+            return Vec::new();
+        }
+
+        let mut cursor = parent.walk();
+        parent
+            .children(&mut cursor)
+            .filter(|child| child.kind() == "decorator")
+            .filter_map(|decorator| {
+                let expr = decorator.named_child(0)?;
+                let target = if expr.kind() == "call" {
+                    expr.child_by_field_name("function")?
+                } else {
+                    expr
+                };
+                target
+                    .utf8_text(source_code.as_bytes())
+                    .ok()
+                    .map(str::to_string)
+            })
+            .collect()
+    }
+
+    /// Extract the docstring of a function or class body, if present.
+    fn extract_docstring(node: &Node, source_code: &str) -> Option<String> {
+        let body = node.child_by_field_name("body")?;
+        let first = body.named_child(0)?;
+        if first.kind() != "expression_statement" {
+            return None;
+        }
+        let literal = first.named_child(0)?;
+        if literal.kind() != "string" {
+            return None;
+        }
+        let raw = literal.utf8_text(source_code.as_bytes()).ok()?;
+        Some(Self::strip_string_delimiters(raw).trim().to_string())
+    }
+
+    /// Remove prefix characters and quotes from a Python string literal.
+    fn strip_string_delimiters(raw: &str) -> &str {
+        let unprefixed = raw.trim_start_matches(|c: char| "rRuUbBfF".contains(c));
+        for quote in ["\"\"\"", "'''", "\"", "'"] {
+            if let Some(inner) = unprefixed
+                .strip_prefix(quote)
+                .and_then(|rest| rest.strip_suffix(quote))
+            {
+                return inner;
+            }
+        }
+        unprefixed
+    }
+
+    /// Collect the string entries of an `__all__ = [...]` assignment.
+    fn extract_all_exports(node: &Node, source_code: &str) -> Option<Vec<String>> {
+        let left = node.child_by_field_name("left")?;
+        if left.utf8_text(source_code.as_bytes()).ok()? != "__all__" {
+            return None;
+        }
+        let right = node.child_by_field_name("right")?;
+        if !matches!(right.kind(), "list" | "tuple") {
+            return None;
+        }
+
+        let mut cursor = right.walk();
+        let exports = right
+            .named_children(&mut cursor)
+            .filter(|child| child.kind() == "string")
+            .filter_map(|child| child.utf8_text(source_code.as_bytes()).ok())
+            .map(|raw| Self::strip_string_delimiters(raw).to_string())
+            .collect();
+        Some(exports)
     }
 
     /// Extract function-specific metadata
@@ -205,8 +333,8 @@ impl PythonAdapter {
         source_code: &str,
         metadata: &mut HashMap<String, serde_json::Value>,
     ) -> Result<()> {
-        let (parameters, has_decorators, return_annotation) =
-            Self::scan_function_children(node, source_code)?;
+        let (parameters, return_annotation) = Self::scan_function_children(node, source_code)?;
+        let decorators = Self::extract_decorators(node, source_code);
 
         let mut function_calls = Vec::new();
         self.extract_function_calls_recursive(*node, source_code, &mut function_calls)?;
@@ -214,8 +342,15 @@ impl PythonAdapter {
         metadata.insert("parameters".to_string(), serde_json::json!(parameters));
         metadata.insert(
             "has_decorators".to_string(),
-            serde_json::Value::Bool(has_decorators),
+            serde_json::Value::Bool(!decorators.is_empty()),
         );
+        metadata.insert("decorators".to_string(), serde_json::json!(decorators));
+        if let Some(docstring) = Self::extract_docstring(node, source_code) {
+            metadata.insert(
+                "docstring".to_string(),
+                serde_json::Value::String(docstring),
+            );
+        }
         if let Some(return_type) = return_annotation {
             metadata.insert(
                 "return_annotation".to_string(),
@@ -236,11 +371,14 @@ impl PythonAdapter {
     }
 
     /// Extract base class names from an argument_list node.
+    ///
+    /// Dotted bases such as `abc.ABC` are kept; keyword arguments like
+    /// `metaclass=...` are not base classes and are skipped.
     fn extract_base_classes<'a>(arg_list: &Node, source_code: &'a str) -> Vec<&'a str> {
         let mut arg_cursor = arg_list.walk();
         arg_list
             .children(&mut arg_cursor)
-            .filter(|child| child.kind() == "identifier")
+            .filter(|child| matches!(child.kind(), "identifier" | "attribute"))
             .filter_map(|child| child.utf8_text(source_code.as_bytes()).ok())
             .collect()
     }
@@ -252,27 +390,24 @@ impl PythonAdapter {
         source_code: &str,
         metadata: &mut HashMap<String, serde_json::Value>,
     ) -> Result<()> {
-        let mut cursor = node.walk();
-        let mut base_classes = Vec::new();
-        let mut has_decorators = false;
-
-        for child in node.children(&mut cursor) {
-            match child.kind() {
-                "argument_list" => {
-                    base_classes = Self::extract_base_classes(&child, source_code);
-                }
-                "decorator" => {
-                    has_decorators = true;
-                }
-                _ => {}
-            }
-        }
+        let base_classes = node
+            .child_by_field_name("superclasses")
+            .map(|arg_list| Self::extract_base_classes(&arg_list, source_code))
+            .unwrap_or_default();
+        let decorators = Self::extract_decorators(node, source_code);
 
         metadata.insert("base_classes".to_string(), serde_json::json!(base_classes));
         metadata.insert(
             "has_decorators".to_string(),
-            serde_json::Value::Bool(has_decorators),
+            serde_json::Value::Bool(!decorators.is_empty()),
         );
+        metadata.insert("decorators".to_string(), serde_json::json!(decorators));
+        if let Some(docstring) = Self::extract_docstring(node, source_code) {
+            metadata.insert(
+                "docstring".to_string(),
+                serde_json::Value::String(docstring),
+            );
+        }
 
         Ok(())
     }
@@ -286,7 +421,10 @@ impl PythonAdapter {
         parent_id: Option<InternedString>,
         index: &mut InternedParseIndex,
         entity_id_counter: &mut usize,
+        depth: usize,
     ) -> Result<()> {
+        check_recursion_depth(node, file_path, depth, self.max_depth)?;
+
         // Check if this node represents an entity we care about
         if let Some(entity) = self.node_to_interned_entity(
             node,
@@ -308,6 +446,7 @@ impl PythonAdapter {
                     Some(entity_id),
                     index,
                     entity_id_counter,
+                    depth + 1,
                 )?;
             }
         } else {
@@ -321,6 +460,7 @@ impl PythonAdapter {
                     parent_id,
                     index,
                     entity_id_counter,
+                    depth + 1,
                 )?;
             }
         }
@@ -337,10 +477,12 @@ impl PythonAdapter {
         index: &mut InternedParseIndex,
         entity_id_counter: &mut usize,
     ) -> Result<()> {
-        // Stack entries: (node, parent_id)
-        let mut stack: Vec<(Node, Option<InternedString>)> = vec![(root, None)];
+        // Stack entries: (node, parent_id, depth)
+        let mut stack: Vec<(Node, Option<InternedString>, usize)> = vec![(root, None, 0)];
+
+        while let Some((node, parent_id, depth)) = stack.pop() {
+            check_recursion_depth(node, file_path, depth, self.max_depth)?;
 
-        while let Some((node, parent_id)) = stack.pop() {
             // Process this node
             let new_parent_id = if let Some(entity) = self.node_to_interned_entity(
                 node,
@@ -360,7 +502,7 @@ impl PythonAdapter {
             let mut cursor = node.walk();
             let children: Vec<_> = node.children(&mut cursor).collect();
             for child in children.into_iter().rev() {
-                stack.push((child, new_parent_id));
+                stack.push((child, new_parent_id, depth + 1));
             }
         }
 
@@ -588,7 +730,7 @@ impl PythonAdapter {
                 *block_count += 1;
             }
             "if_statement" | "for_statement" | "while_statement" | "try_statement"
-            | "with_statement" => {
+            | "with_statement" | "match_statement" | "case_clause" => {
                 *block_count += 1;
             }
             _ => {}
@@ -614,7 +756,10 @@ impl PythonAdapter {
             | "class_definition"
             | "if_statement"
             | "for_statement"
-            | "while_statement" => {
+            | "while_statement"
+            | "match_statement"
+            | "case_clause"
+            | "named_expression" => {
                 normalized_parts.push(node.kind().to_string());
             }
             "identifier" => {
@@ -647,14 +792,16 @@ impl Default for PythonAdapter {
     /// Returns a new Python adapter, or a minimal fallback on failure.
     fn default() -> Self {
         Self::new().unwrap_or_else(|e| {
-            eprintln!(
-                "Warning: Failed to create Python adapter, using minimal fallback: {}",
+            warn!(
+                "Failed to create Python adapter, using minimal fallback: {}",
                 e
             );
             PythonAdapter {
                 parser: tree_sitter::Parser::new(),
                 language: get_tree_sitter_language("py")
                     .unwrap_or_else(|_| tree_sitter_python::LANGUAGE.into()),
+                max_depth: DEFAULT_MAX_RECURSION_DEPTH,
+                deadline: None,
             }
         })
     }
@@ -665,8 +812,7 @@ impl Default for PythonAdapter {
 impl LanguageAdapter for PythonAdapter {
     /// Parses source code into a tree-sitter AST.
     fn parse_tree(&mut self, source: &str) -> Result<Tree> {
-        self.parser
-            .parse(source, None)
+        parse_until(&mut self.parser, source, self.deadline)
             .ok_or_else(|| ValknutError::parse("python", "Failed to parse Python source"))
     }
 
@@ -746,21 +892,25 @@ impl LanguageAdapter for PythonAdapter {
         "python"
     }
 
+    /// Bounds entity extraction to `depth` levels of AST nesting.
+    fn set_max_ast_depth(&mut self, depth: usize) {
+        self.max_depth = depth;
+    }
+
+    /// Abandons parses still running once `deadline` passes.
+    fn set_parse_deadline(&mut self, deadline: Option<Instant>) {
+        self.deadline = deadline;
+    }
+
     /// Extracts import statements from Python source code.
+    ///
+    /// Imports are read from the syntax tree so parenthesised multi-line
+    /// imports are handled and code-like text inside string literals is
+    /// ignored. Relative imports keep their leading dots (e.g. `..utils`).
     fn extract_imports(&mut self, source: &str) -> Result<Vec<ImportStatement>> {
+        let tree = self.parse_tree(source)?;
         let mut imports = Vec::new();
-
-        for (line_number, line) in source.lines().enumerate() {
-            let trimmed = line.trim();
-            if trimmed.is_empty() || trimmed.starts_with('#') {
-                continue;
-            }
-
-            if let Some(stmt) = Self::parse_import_line(trimmed, line_number + 1) {
-                imports.push(stmt);
-            }
-        }
-
+        Self::collect_imports(tree.root_node(), source, &mut imports);
         Ok(imports)
     }
 
@@ -785,6 +935,10 @@ impl LanguageAdapter for PythonAdapter {
 
 /// [`EntityExtractor`] implementation providing the language-specific node conversion.
 impl EntityExtractor for PythonAdapter {
+    fn max_depth(&self) -> usize {
+        self.max_depth
+    }
+
     fn node_to_entity(
         &self,
         node: Node,
@@ -822,6 +976,11 @@ impl EntityExtractor for PythonAdapter {
             EntityKind::Class => {
                 self.extract_class_metadata(&node, source_code, &mut metadata)?;
             }
+            EntityKind::Variable | EntityKind::Constant => {
+                if let Some(exports) = Self::extract_all_exports(&node, source_code) {
+                    metadata.insert("exports".to_string(), serde_json::json!(exports));
+                }
+            }
             _ => {}
         }
 
@@ -839,46 +998,75 @@ impl EntityExtractor for PythonAdapter {
 
 /// Import parsing helper methods for PythonAdapter.
 impl PythonAdapter {
-    /// Parse a single import line into an ImportStatement if valid.
-    fn parse_import_line(trimmed: &str, line_number: usize) -> Option<ImportStatement> {
-        if let Some(import_part) = trimmed.strip_prefix("import ") {
-            return Some(Self::parse_simple_import(import_part, line_number));
-        }
-
-        if let Some(from_part) = trimmed.strip_prefix("from ") {
-            return Self::parse_from_import(from_part, line_number);
+    /// Walk the tree in source order and collect import statements.
+    fn collect_imports(root: Node, source: &str, imports: &mut Vec<ImportStatement>) {
+        let mut stack = vec![root];
+        while let Some(node) = stack.pop() {
+            match node.kind() {
+                "import_statement" => Self::collect_simple_imports(&node, source, imports),
+                "import_from_statement" | "future_import_statement" => {
+                    if let Some(stmt) = Self::parse_from_import_node(&node, source) {
+                        imports.push(stmt);
+                    }
+                }
+                _ => {
+                    let mut cursor = node.walk();
+                    let children: Vec<_> = node.children(&mut cursor).collect();
+                    stack.extend(children.into_iter().rev());
+                }
+            }
         }
+    }
 
-        None
+    /// Resolve the imported (non-aliased) name of a `dotted_name` or `aliased_import`.
+    fn imported_name(node: &Node, source: &str) -> Option<String> {
+        let target = if node.kind() == "aliased_import" {
+            node.child_by_field_name("name")?
+        } else {
+            *node
+        };
+        target.utf8_text(source.as_bytes()).ok().map(str::to_string)
     }
 
-    /// Parse a simple "import module" statement.
-    fn parse_simple_import(import_part: &str, line_number: usize) -> ImportStatement {
-        let module = import_part
-            .split_whitespace()
-            .next()
-            .unwrap_or("")
-            .to_string();
-        ImportStatement {
-            module,
-            imports: None,
-            import_type: "module".to_string(),
-            line_number,
+    /// Collect one statement per module in `import a, b as c`.
+    fn collect_simple_imports(node: &Node, source: &str, imports: &mut Vec<ImportStatement>) {
+        let line_number = node.start_position().row + 1;
+        let mut cursor = node.walk();
+        for name_node in node.children_by_field_name("name", &mut cursor) {
+            if let Some(module) = Self::imported_name(&name_node, source) {
+                imports.push(ImportStatement {
+                    module,
+                    imports: None,
+                    import_type: "module".to_string(),
+                    line_number,
+                });
+            }
         }
     }
 
-    /// Parse a "from module import ..." statement.
-    fn parse_from_import(from_part: &str, line_number: usize) -> Option<ImportStatement> {
-        let import_pos = from_part.find(" import ")?;
-        let module = from_part[..import_pos].trim().to_string();
-        let import_list = from_part[import_pos + 8..].trim();
+    /// Parse a `from module import ...` statement node.
+    fn parse_from_import_node(node: &Node, source: &str) -> Option<ImportStatement> {
+        let module = if node.kind() == "future_import_statement" {
+            "__future__".to_string()
+        } else {
+            node.child_by_field_name("module_name")?
+                .utf8_text(source.as_bytes())
+                .ok()?
+                .to_string()
+        };
+
+        let mut cursor = node.walk();
+        let is_star = node
+            .children(&mut cursor)
+            .any(|child| child.kind() == "wildcard_import");
 
-        let (specific_imports, import_type) = if import_list == "*" {
+        let (specific_imports, import_type) = if is_star {
             (None, "star")
         } else {
-            let imports: Vec<String> = import_list
-                .split(',')
-                .map(|s| s.trim().to_string())
+            let mut name_cursor = node.walk();
+            let imports: Vec<String> = node
+                .children_by_field_name("name", &mut name_cursor)
+                .filter_map(|name_node| Self::imported_name(&name_node, source))
                 .collect();
             (Some(imports), "named")
         };
@@ -887,7 +1075,7 @@ impl PythonAdapter {
             module,
             imports: specific_imports,
             import_type: import_type.to_string(),
-            line_number,
+            line_number: node.start_position().row + 1,
         })
     }
 }
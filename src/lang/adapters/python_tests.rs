@@ -313,3 +313,160 @@ import numpy as np
             .contains(&"Counter".to_string()));
     }
 }
+
+#[test]
+fn test_decorators_and_docstrings_are_captured() {
+    let mut adapter = PythonAdapter::new().unwrap();
+    let source = r#"
+@dataclass(frozen=True)
+class Point(Base, abc.ABC, metaclass=Meta):
+    """A 2D point."""
+
+    @property
+    def norm(self) -> float:
+        '''Euclidean norm.'''
+        return 0.0
+"#;
+    let index = adapter.parse_source(source, "point.py").unwrap();
+    let entities = index.get_entities_in_file("point.py");
+
+    let class = entities.iter().find(|e| e.name == "Point").unwrap();
+    assert_eq!(
+        class.metadata["decorators"],
+        serde_json::json!(["dataclass"])
+    );
+    assert_eq!(class.metadata["has_decorators"], serde_json::json!(true));
+    assert_eq!(
+        class.metadata["docstring"],
+        serde_json::json!("A 2D point.")
+    );
+    assert_eq!(
+        class.metadata["base_classes"],
+        serde_json::json!(["Base", "abc.ABC"])
+    );
+
+    let method = entities.iter().find(|e| e.name == "norm").unwrap();
+    assert_eq!(
+        method.metadata["decorators"],
+        serde_json::json!(["property"])
+    );
+    assert_eq!(
+        method.metadata["docstring"],
+        serde_json::json!("Euclidean norm.")
+    );
+    assert_eq!(method.metadata["parameters"], serde_json::json!(["self"]));
+}
+
+#[test]
+fn test_typed_and_default_parameters_are_named() {
+    let mut adapter = PythonAdapter::new().unwrap();
+    let source = "def f(a, b: int, c=1, d: str = 'x', *args, **kwargs):\n    pass\n";
+    let index = adapter.parse_source(source, "params.py").unwrap();
+    let entities = index.get_entities_in_file("params.py");
+    let function = entities.iter().find(|e| e.name == "f").unwrap();
+    assert_eq!(
+        function.metadata["parameters"],
+        serde_json::json!(["a", "b", "c", "d", "args", "kwargs"])
+    );
+}
+
+#[test]
+fn test_dunder_all_exports_are_recorded() {
+    let mut adapter = PythonAdapter::new().unwrap();
+    let source = "__all__ = [\"load\", 'dump']\n";
+    let index = adapter.parse_source(source, "api.py").unwrap();
+    let entities = index.get_entities_in_file("api.py");
+    let all = entities.iter().find(|e| e.name == "__all__").unwrap();
+    assert_eq!(all.metadata["exports"], serde_json::json!(["load", "dump"]));
+}
+
+#[test]
+fn test_imports_ignore_string_literals_and_handle_multiline() {
+    let mut adapter = PythonAdapter::new().unwrap();
+    let source = r#"
+from .models import (
+    User,
+    Group as G,
+)
+TEMPLATE = """
+import os
+from fake import thing
+"""
+"#;
+    let imports = adapter.extract_imports(source).unwrap();
+    assert_eq!(imports.len(), 1);
+    assert_eq!(imports[0].module, ".models");
+    assert_eq!(
+        imports[0].imports,
+        Some(vec!["User".to_string(), "Group".to_string()])
+    );
+    assert_eq!(imports[0].line_number, 2);
+}
+
+#[test]
+fn test_match_and_walrus_are_parsed() {
+    let mut adapter = PythonAdapter::new().unwrap();
+    let source = r#"
+def dispatch(command):
+    if (n := len(command)) > 3:
+        return n
+    match command:
+        case ["go", direction]:
+            return direction
+        case _:
+            return None
+"#;
+    let index = adapter.parse_source(source, "dispatch.py").unwrap();
+    let entities = index.get_entities_in_file("dispatch.py");
+    assert!(entities.iter().any(|e| e.name == "dispatch"));
+    assert!(!entities.iter().any(|e| e.name == "n"));
+
+    let normalized = adapter.normalize_source(source).unwrap();
+    assert!(normalized.contains("match_statement"));
+    assert!(normalized.contains("named_expression"));
+
+    let blocks = adapter.count_distinct_blocks(source).unwrap();
+    assert!(blocks >= 5);
+}
+
+#[test]
+fn test_parse_source_rejects_nesting_past_max_depth() {
+    let mut adapter = PythonAdapter::new().unwrap();
+    let nesting = crate::lang::common::DEFAULT_MAX_RECURSION_DEPTH;
+    let deep = format!("x = {}1{}\n", "(".repeat(nesting), ")".repeat(nesting));
+
+    assert!(adapter.parse_source("x = ((1))\n", "deep.py").is_ok());
+    assert!(matches!(
+        adapter.parse_source(&deep, "deep.py"),
+        Err(ValknutError::Parse { .. })
+    ));
+    assert!(matches!(
+        adapter.parse_source_interned(&deep, "deep.py"),
+        Err(ValknutError::Parse { .. })
+    ));
+}
+
+#[test]
+fn test_parse_source_interned_reports_syntax_error_location() {
+    let mut adapter = PythonAdapter::new().unwrap();
+    match adapter.parse_source_interned("def ok():\n    pass\n)\n", "broken.py") {
+        Err(ValknutError::Parse { line, column, .. }) => {
+            assert_eq!((line, column), (Some(3), Some(1)));
+        }
+        other => panic!("expected a located parse error, got {other:?}"),
+    }
+}
+
+#[test]
+fn test_set_max_ast_depth_bounds_extraction_per_adapter() {
+    let source = "x = ((((1))))\n";
+    let mut shallow = PythonAdapter::new().unwrap();
+    shallow.set_max_ast_depth(4);
+    assert!(matches!(
+        shallow.parse_source(source, "nested.py"),
+        Err(ValknutError::Parse { .. })
+    ));
+
+    let mut default = PythonAdapter::new().unwrap();
+    assert!(default.parse_source(source, "nested.py").is_ok());
+}
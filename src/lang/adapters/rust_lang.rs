@@ -2,11 +2,14 @@
 
 use serde_json::{self, Value};
 use std::collections::HashMap;
+use std::time::Instant;
+use tracing::warn;
 use tree_sitter::{Language, Node, Parser, Tree};
 
 use super::super::common::{
-    create_base_metadata, extract_identifiers_by_kinds, generate_entity_id, sort_and_dedup,
-    EntityExtractor, EntityKind, LanguageAdapter, ParseIndex, ParsedEntity, SourceLocation,
+    check_syntax, create_base_metadata, extract_identifiers_by_kinds, generate_entity_id,
+    parse_until, sort_and_dedup, EntityExtractor, EntityKind, LanguageAdapter, ParseIndex,
+    ParsedEntity, SourceLocation, DEFAULT_MAX_RECURSION_DEPTH,
 };
 use super::super::registry::{create_parser_for_language, get_tree_sitter_language};
 use crate::core::ast_utils::{node_text_normalized, walk_tree};
@@ -25,6 +28,12 @@ pub struct RustAdapter {
 
     /// Language instance
     language: Language,
+
+    /// Deepest AST node entity extraction visits
+    max_depth: usize,
+
+    /// Instant after which parses are abandoned (`--timeout`)
+    deadline: Option<Instant>,
 }
 
 /// Parsing and entity extraction methods for [`RustAdapter`].
@@ -34,15 +43,26 @@ impl RustAdapter {
         let language = get_tree_sitter_language("rs")?;
         let parser = create_parser_for_language("rs")?;
 
-        Ok(Self { parser, language })
+        Ok(Self {
+            parser,
+            language,
+            max_depth: DEFAULT_MAX_RECURSION_DEPTH,
+            deadline: None,
+        })
     }
 
     /// Parse Rust source code and extract entities
     pub fn parse_source(&mut self, source_code: &str, file_path: &str) -> Result<ParseIndex> {
-        let tree = self
-            .parser
-            .parse(source_code, None)
-            .ok_or_else(|| ValknutError::parse("rust", "Failed to parse Rust source code"))?;
+        let tree = parse_until(&mut self.parser, source_code, self.deadline).ok_or_else(|| {
+            ValknutError::parse_with_location(
+                "rust",
+                "Failed to parse Rust source code",
+                file_path,
+                None,
+                None,
+            )
+        })?;
+        check_syntax(&tree, "rust", file_path)?;
 
         let mut index = ParseIndex::new();
         let mut entity_id_counter = 0;
@@ -195,19 +215,29 @@ impl RustAdapter {
         let is_const = Self::has_modifier(node, "const");
 
         let mut parameters = Vec::new();
-        let mut return_type = None;
+        let mut parameter_ownership = Vec::new();
+        let mut self_kind = None;
         let mut visibility = "private".to_string();
+        let mut lifetimes = Vec::new();
+        let mut generic_params = Vec::new();
+        let mut where_clause = None;
 
         let mut cursor = node.walk();
         for child in node.children(&mut cursor) {
             match child.kind() {
-                "parameters" => parameters = Self::extract_parameters(&child, source_code)?,
+                "parameters" => {
+                    parameters = Self::extract_parameters(&child, source_code)?;
+                    parameter_ownership = Self::extract_parameter_ownership(&child, source_code)?;
+                    self_kind = Self::extract_self_kind(&child);
+                }
                 "visibility_modifier" => {
                     visibility = child.utf8_text(source_code.as_bytes())?.to_string()
                 }
-                "type_identifier" | "reference_type" | "tuple_type" | "array_type"
-                | "generic_type" => {
-                    return_type = Some(child.utf8_text(source_code.as_bytes())?.to_string());
+                "type_parameters" => {
+                    (lifetimes, generic_params) = Self::split_type_parameters(&child, source_code)?;
+                }
+                "where_clause" => {
+                    where_clause = Some(child.utf8_text(source_code.as_bytes())?.to_string());
                 }
                 _ => {}
             }
@@ -218,13 +248,240 @@ impl RustAdapter {
         metadata.insert("is_unsafe".to_string(), Value::Bool(is_unsafe));
         metadata.insert("is_const".to_string(), Value::Bool(is_const));
         metadata.insert("visibility".to_string(), Value::String(visibility));
-        if let Some(ret_type) = return_type {
-            metadata.insert("return_type".to_string(), Value::String(ret_type));
+        if let Some(return_node) = node.child_by_field_name("return_type") {
+            let return_type = return_node.utf8_text(source_code.as_bytes())?;
+            metadata.insert(
+                "return_type".to_string(),
+                Value::String(return_type.to_string()),
+            );
+            metadata.insert(
+                "return_ownership".to_string(),
+                Value::String(Self::ownership_of(&return_node).to_string()),
+            );
+        }
+        if let Some(kind) = self_kind {
+            metadata.insert("self_kind".to_string(), Value::String(kind.to_string()));
+        }
+        if !parameter_ownership.is_empty() {
+            metadata.insert(
+                "parameter_ownership".to_string(),
+                Value::Array(parameter_ownership),
+            );
         }
+        Self::insert_generics(metadata, lifetimes, generic_params);
+        if let Some(clause) = where_clause {
+            metadata.insert("where_clause".to_string(), Value::String(clause));
+        }
+        Self::insert_impl_context(node, source_code, metadata)?;
 
         Ok(())
     }
 
+    /// Describe how each typed parameter is passed: owned, borrowed, mutably borrowed or raw.
+    fn extract_parameter_ownership(params_node: &Node, source_code: &str) -> Result<Vec<Value>> {
+        let mut ownership = Vec::new();
+        let mut cursor = params_node.walk();
+        for param in params_node.children(&mut cursor) {
+            if param.kind() != "parameter" {
+                continue;
+            }
+            let (Some(pattern), Some(param_type)) = (
+                param.child_by_field_name("pattern"),
+                param.child_by_field_name("type"),
+            ) else {
+                continue;
+            };
+            ownership.push(serde_json::json!({
+                "name": pattern.utf8_text(source_code.as_bytes())?,
+                "type": param_type.utf8_text(source_code.as_bytes())?,
+                "ownership": Self::ownership_of(&param_type),
+            }));
+        }
+        Ok(ownership)
+    }
+
+    /// Classify the receiver of a method (`self`, `&self`, `&mut self`), if any.
+    fn extract_self_kind(params_node: &Node) -> Option<&'static str> {
+        let mut cursor = params_node.walk();
+        let self_param = params_node
+            .children(&mut cursor)
+            .find(|child| child.kind() == "self_parameter")?;
+
+        let kind = if !Self::has_child_kind(&self_param, "&") {
+            "owned"
+        } else if Self::has_child_kind(&self_param, "mutable_specifier") {
+            "mutably_borrowed"
+        } else {
+            "borrowed"
+        };
+        Some(kind)
+    }
+
+    /// Classify a type node by the ownership it conveys.
+    fn ownership_of(type_node: &Node) -> &'static str {
+        match type_node.kind() {
+            "reference_type" if Self::has_child_kind(type_node, "mutable_specifier") => {
+                "mutably_borrowed"
+            }
+            "reference_type" => "borrowed",
+            "pointer_type" => "raw_pointer",
+            _ => "owned",
+        }
+    }
+
+    /// Split a `type_parameters` node into declared lifetimes and type/const parameter names.
+    fn split_type_parameters(
+        params_node: &Node,
+        source_code: &str,
+    ) -> Result<(Vec<String>, Vec<String>)> {
+        let mut lifetimes = Vec::new();
+        let mut generics = Vec::new();
+        let mut cursor = params_node.walk();
+
+        for param in params_node.children(&mut cursor) {
+            match param.kind() {
+                "lifetime" => {
+                    lifetimes.push(param.utf8_text(source_code.as_bytes())?.to_string());
+                }
+                "lifetime_parameter" => {
+                    let name = param
+                        .child_by_field_name("name")
+                        .or_else(|| param.named_child(0));
+                    if let Some(name) = name {
+                        lifetimes.push(name.utf8_text(source_code.as_bytes())?.to_string());
+                    }
+                }
+                "type_identifier" => {
+                    generics.push(param.utf8_text(source_code.as_bytes())?.to_string());
+                }
+                "type_parameter"
+                | "constrained_type_parameter"
+                | "optional_type_parameter"
+                | "const_parameter" => {
+                    let mut inner = param.walk();
+                    let name = param.child_by_field_name("name").or_else(|| {
+                        param
+                            .children(&mut inner)
+                            .find(|c| matches!(c.kind(), "type_identifier" | "identifier"))
+                    });
+                    if let Some(name) = name {
+                        generics.push(name.utf8_text(source_code.as_bytes())?.to_string());
+                    }
+                }
+                _ => {}
+            }
+        }
+
+        Ok((lifetimes, generics))
+    }
+
+    /// Record declared lifetimes and generic parameters when present.
+    fn insert_generics(
+        metadata: &mut HashMap<String, Value>,
+        lifetimes: Vec<String>,
+        generic_params: Vec<String>,
+    ) {
+        if !lifetimes.is_empty() {
+            metadata.insert("lifetimes".to_string(), serde_json::json!(lifetimes));
+        }
+        if !generic_params.is_empty() {
+            metadata.insert(
+                "generic_parameters".to_string(),
+                serde_json::json!(generic_params),
+            );
+        }
+    }
+
+    /// Record the implemented type (and trait) for functions defined inside an `impl` block.
+    fn insert_impl_context(
+        node: &Node,
+        source_code: &str,
+        metadata: &mut HashMap<String, Value>,
+    ) -> Result<()> {
+        let Some(impl_node) = node
+            .parent()
+            .filter(|parent| parent.kind() == "declaration_list")
+            .and_then(|list| list.parent())
+            .filter(|grandparent| grandparent.kind() == "impl_item")
+        else {
+            return Ok(());
+        };
+
+        if let Some(impl_type) = impl_node.child_by_field_name("type") {
+            metadata.insert(
+                "impl_type".to_string(),
+                Value::String(impl_type.utf8_text(source_code.as_bytes())?.to_string()),
+            );
+        }
+        if let Some(impl_trait) = impl_node.child_by_field_name("trait") {
+            metadata.insert(
+                "impl_trait".to_string(),
+                Value::String(impl_trait.utf8_text(source_code.as_bytes())?.to_string()),
+            );
+        }
+        metadata.insert("is_method".to_string(), Value::Bool(true));
+
+        Ok(())
+    }
+
+    /// Collect traits named in `#[derive(...)]` attributes attached to an item.
+    fn collect_derives(node: &Node, source_code: &str) -> Vec<String> {
+        let mut attributes = Vec::new();
+        let mut sibling = node.prev_sibling();
+        while let Some(current) = sibling {
+            match current.kind() {
+                "attribute_item" => attributes.push(current),
+                "line_comment" | "block_comment" => {}
+                _ => break,
+            }
+            sibling = current.prev_sibling();
+        }
+
+        let mut derives = Vec::new();
+        for attribute in attributes.iter().rev() {
+            let Ok(text) = attribute.utf8_text(source_code.as_bytes()) else {
+                continue;
+            };
+            let Some(list) = text
+                .trim()
+                .strip_prefix("#[")
+                .and_then(|rest| rest.strip_suffix(']'))
+                .and_then(|inner| inner.trim().strip_prefix("derive"))
+                .and_then(|rest| rest.trim().strip_prefix('('))
+                .and_then(|rest| rest.strip_suffix(')'))
+            else {
+                continue;
+            };
+            derives.extend(
+                list.split(',')
+                    .map(|name| name.trim())
+                    .filter(|name| !name.is_empty())
+                    .map(str::to_string),
+            );
+        }
+        derives
+    }
+
+    /// Build the `::`-separated path of inline modules enclosing a node.
+    fn module_path(&self, node: &Node, source_code: &str) -> Result<Option<String>> {
+        let mut segments = Vec::new();
+        let mut current = node.parent();
+        while let Some(parent) = current {
+            if parent.kind() == "mod_item" {
+                if let Some(name) = self.extract_name(&parent, source_code)? {
+                    segments.push(name);
+                }
+            }
+            current = parent.parent();
+        }
+
+        if segments.is_empty() {
+            return Ok(None);
+        }
+        segments.reverse();
+        Ok(Some(segments.join("::")))
+    }
+
     /// Collect child text of the specified kind from a node.
     fn collect_child_text<'a>(node: &Node, source_code: &'a str, kind: &str) -> Vec<&'a str> {
         let mut cursor = node.walk();
@@ -265,6 +522,7 @@ impl RustAdapter {
     ) -> Result<()> {
         let mut fields = Vec::new();
         let mut visibility = "private".to_string();
+        let mut lifetimes = Vec::new();
         let mut generic_params = Vec::new();
 
         let mut cursor = node.walk();
@@ -282,12 +540,7 @@ impl RustAdapter {
                     visibility = child.utf8_text(source_code.as_bytes())?.to_string();
                 }
                 "type_parameters" => {
-                    generic_params = Self::extract_nested_identifiers(
-                        &child,
-                        source_code,
-                        "type_parameter",
-                        "type_identifier",
-                    )?;
+                    (lifetimes, generic_params) = Self::split_type_parameters(&child, source_code)?;
                 }
                 _ => {}
             }
@@ -295,11 +548,10 @@ impl RustAdapter {
 
         metadata.insert("fields".to_string(), serde_json::json!(fields));
         metadata.insert("visibility".to_string(), Value::String(visibility));
-        if !generic_params.is_empty() {
-            metadata.insert(
-                "generic_parameters".to_string(),
-                serde_json::json!(generic_params),
-            );
+        Self::insert_generics(metadata, lifetimes, generic_params);
+        let derives = Self::collect_derives(node, source_code);
+        if !derives.is_empty() {
+            metadata.insert("derives".to_string(), serde_json::json!(derives));
         }
 
         Ok(())
@@ -315,6 +567,8 @@ impl RustAdapter {
         let mut cursor = node.walk();
         let mut variants = Vec::new();
         let mut visibility = "private".to_string();
+        let mut lifetimes = Vec::new();
+        let mut generic_params = Vec::new();
 
         for child in node.children(&mut cursor) {
             match child.kind() {
@@ -324,12 +578,20 @@ impl RustAdapter {
                 "visibility_modifier" => {
                     visibility = self.extract_visibility(&child, source_code)?;
                 }
+                "type_parameters" => {
+                    (lifetimes, generic_params) = Self::split_type_parameters(&child, source_code)?;
+                }
                 _ => {}
             }
         }
 
         metadata.insert("variants".to_string(), serde_json::json!(variants));
         metadata.insert("visibility".to_string(), Value::String(visibility));
+        Self::insert_generics(metadata, lifetimes, generic_params);
+        let derives = Self::collect_derives(node, source_code);
+        if !derives.is_empty() {
+            metadata.insert("derives".to_string(), serde_json::json!(derives));
+        }
 
         Ok(())
     }
@@ -496,8 +758,7 @@ impl RustAdapter {
 impl LanguageAdapter for RustAdapter {
     /// Parses source code into a tree-sitter AST.
     fn parse_tree(&mut self, source: &str) -> Result<Tree> {
-        self.parser
-            .parse(source, None)
+        parse_until(&mut self.parser, source, self.deadline)
             .ok_or_else(|| ValknutError::parse("rust", "Failed to parse Rust source"))
     }
 
@@ -567,6 +828,16 @@ impl LanguageAdapter for RustAdapter {
         "rust"
     }
 
+    /// Bounds entity extraction to `depth` levels of AST nesting.
+    fn set_max_ast_depth(&mut self, depth: usize) {
+        self.max_depth = depth;
+    }
+
+    /// Abandons parses still running once `deadline` passes.
+    fn set_parse_deadline(&mut self, deadline: Option<Instant>) {
+        self.deadline = deadline;
+    }
+
     /// Extracts use statements and mod declarations from Rust source.
     fn extract_imports(&mut self, source: &str) -> Result<Vec<ImportStatement>> {
         let mut imports = Vec::new();
@@ -605,6 +876,10 @@ impl LanguageAdapter for RustAdapter {
 
 /// [`EntityExtractor`] implementation providing the language-specific node conversion.
 impl EntityExtractor for RustAdapter {
+    fn max_depth(&self) -> usize {
+        self.max_depth
+    }
+
     fn node_to_entity(
         &self,
         node: Node,
@@ -635,6 +910,17 @@ impl EntityExtractor for RustAdapter {
 
         let mut metadata = create_base_metadata(node.kind(), node.start_byte(), node.end_byte());
         self.extract_entity_metadata(&entity_kind, &node, source_code, &mut metadata)?;
+        // Restricted forms such as `pub(crate)` are not part of the public API.
+        let is_public = metadata
+            .get("visibility")
+            .and_then(Value::as_str)
+            .map(|visibility| visibility == "pub");
+        if let Some(is_public) = is_public {
+            metadata.insert("is_public".to_string(), Value::Bool(is_public));
+        }
+        if let Some(module_path) = self.module_path(&node, source_code)? {
+            metadata.insert("module_path".to_string(), Value::String(module_path));
+        }
 
         Ok(Some(ParsedEntity {
             id: entity_id,
@@ -732,14 +1018,16 @@ impl Default for RustAdapter {
     /// Returns a new Rust adapter, or a minimal fallback on failure.
     fn default() -> Self {
         Self::new().unwrap_or_else(|e| {
-            eprintln!(
-                "Warning: Failed to create Rust adapter, using minimal fallback: {}",
+            warn!(
+                "Failed to create Rust adapter, using minimal fallback: {}",
                 e
             );
             RustAdapter {
                 parser: tree_sitter::Parser::new(),
                 language: get_tree_sitter_language("rs")
                     .unwrap_or_else(|_| tree_sitter_rust::LANGUAGE.into()),
+                max_depth: DEFAULT_MAX_RECURSION_DEPTH,
+                deadline: None,
             }
         })
     }
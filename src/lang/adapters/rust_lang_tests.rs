@@ -349,6 +349,148 @@ static GLOBAL_COUNT: AtomicUsize = AtomicUsize::new(0);
             .unwrap();
         assert_eq!(global_static.entity_type, "Constant");
     }
+
+    #[test]
+    fn test_ownership_and_lifetime_metadata() {
+        let mut adapter = RustAdapter::new().unwrap();
+        let source_code = r#"
+pub struct Parser<'a, T: Clone> {
+    input: &'a str,
+    items: Vec<T>,
+}
+
+impl<'a, T: Clone> Parser<'a, T> {
+    pub fn longest<'b>(&'b self, other: &'b str, buf: &mut Vec<T>, count: usize) -> &'b str
+    where
+        T: Default,
+    {
+        other
+    }
+
+    pub(crate) fn into_items(self) -> Vec<T> {
+        self.items
+    }
+
+    fn reset(&mut self) {}
+}
+"#;
+
+        let entities = adapter
+            .extract_code_entities(source_code, "parser.rs")
+            .unwrap();
+
+        let parser = entities.iter().find(|e| e.name == "Parser").unwrap();
+        assert_eq!(
+            parser.properties.get("lifetimes"),
+            Some(&serde_json::json!(["'a"]))
+        );
+        assert_eq!(
+            parser.properties.get("generic_parameters"),
+            Some(&serde_json::json!(["T"]))
+        );
+        assert_eq!(parser.properties.get("is_public"), Some(&Value::Bool(true)));
+
+        let longest = entities.iter().find(|e| e.name == "longest").unwrap();
+        assert_eq!(
+            longest.properties.get("lifetimes"),
+            Some(&serde_json::json!(["'b"]))
+        );
+        assert_eq!(
+            longest.properties.get("self_kind"),
+            Some(&Value::String("borrowed".to_string()))
+        );
+        assert_eq!(
+            longest.properties.get("return_type"),
+            Some(&Value::String("&'b str".to_string()))
+        );
+        assert_eq!(
+            longest.properties.get("return_ownership"),
+            Some(&Value::String("borrowed".to_string()))
+        );
+        assert_eq!(
+            longest.properties.get("impl_type"),
+            Some(&Value::String("Parser<'a, T>".to_string()))
+        );
+        assert!(longest.properties.contains_key("where_clause"));
+        assert_eq!(
+            longest.properties.get("parameter_ownership"),
+            Some(&serde_json::json!([
+                {"name": "other", "type": "&'b str", "ownership": "borrowed"},
+                {"name": "buf", "type": "&mut Vec<T>", "ownership": "mutably_borrowed"},
+                {"name": "count", "type": "usize", "ownership": "owned"},
+            ]))
+        );
+
+        let into_items = entities.iter().find(|e| e.name == "into_items").unwrap();
+        assert_eq!(
+            into_items.properties.get("self_kind"),
+            Some(&Value::String("owned".to_string()))
+        );
+        assert_eq!(
+            into_items.properties.get("is_public"),
+            Some(&Value::Bool(false))
+        );
+
+        let reset = entities.iter().find(|e| e.name == "reset").unwrap();
+        assert_eq!(
+            reset.properties.get("self_kind"),
+            Some(&Value::String("mutably_borrowed".to_string()))
+        );
+    }
+
+    #[test]
+    fn test_derives_impl_trait_and_module_path() {
+        let mut adapter = RustAdapter::new().unwrap();
+        let source_code = r#"
+pub mod net {
+    pub mod http {
+        /// A request header.
+        #[derive(Debug, Clone, serde::Serialize)]
+        #[serde(rename_all = "camelCase")]
+        pub struct Header {
+            name: String,
+        }
+
+        impl std::fmt::Display for Header {
+            fn fmt(&self, f: &mut std::fmt::Formatter<'_>) -> std::fmt::Result {
+                Ok(())
+            }
+        }
+    }
+}
+"#;
+
+        let entities = adapter
+            .extract_code_entities(source_code, "net.rs")
+            .unwrap();
+
+        let header = entities.iter().find(|e| e.name == "Header").unwrap();
+        assert_eq!(
+            header.properties.get("derives"),
+            Some(&serde_json::json!(["Debug", "Clone", "serde::Serialize"]))
+        );
+        assert_eq!(
+            header.properties.get("module_path"),
+            Some(&Value::String("net::http".to_string()))
+        );
+
+        let http = entities.iter().find(|e| e.name == "http").unwrap();
+        assert_eq!(
+            http.properties.get("module_path"),
+            Some(&Value::String("net".to_string()))
+        );
+
+        let fmt = entities.iter().find(|e| e.name == "fmt").unwrap();
+        assert_eq!(
+            fmt.properties.get("impl_trait"),
+            Some(&Value::String("std::fmt::Display".to_string()))
+        );
+        assert_eq!(
+            fmt.properties.get("impl_type"),
+            Some(&Value::String("Header".to_string()))
+        );
+        assert_eq!(fmt.properties.get("is_method"), Some(&Value::Bool(true)));
+    }
 }
 
 mod import_tests {
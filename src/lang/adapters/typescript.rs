@@ -1,12 +1,15 @@
 //! TypeScript language adapter with tree-sitter integration.
 
 use std::collections::HashMap;
+use std::time::Instant;
+use tracing::warn;
 use tree_sitter::{Language, Node, Parser, Tree};
 
 use super::super::common::{
-    create_base_metadata, extract_identifiers_by_kinds, extract_js_function_calls,
-    generate_entity_id, normalize_module_literal, parse_require_import, sort_and_dedup,
-    EntityExtractor, EntityKind, LanguageAdapter, ParseIndex, ParsedEntity, SourceLocation,
+    check_syntax, create_base_metadata, extract_identifiers_by_kinds, extract_js_function_calls,
+    generate_entity_id, normalize_module_literal, parse_require_import, parse_until,
+    sort_and_dedup, EntityExtractor, EntityKind, LanguageAdapter, ParseIndex, ParsedEntity,
+    SourceLocation, DEFAULT_MAX_RECURSION_DEPTH,
 };
 use super::super::registry::{create_parser_for_language, get_tree_sitter_language};
 use crate::core::ast_utils::{
@@ -28,6 +31,15 @@ pub struct TypeScriptAdapter {
 
     /// Language instance
     language: Language,
+
+    /// Whether sources are parsed with the JSX-aware TSX grammar
+    jsx: bool,
+
+    /// Deepest AST node entity extraction visits
+    max_depth: usize,
+
+    /// Instant after which parses are abandoned (`--timeout`)
+    deadline: Option<Instant>,
 }
 
 /// Parsing and entity extraction methods for [`TypeScriptAdapter`].
@@ -37,14 +49,41 @@ impl TypeScriptAdapter {
         let language = get_tree_sitter_language("ts")?;
         let parser = create_parser_for_language("ts")?;
 
-        Ok(Self { parser, language })
+        Ok(Self {
+            parser,
+            language,
+            jsx: false,
+            max_depth: DEFAULT_MAX_RECURSION_DEPTH,
+            deadline: None,
+        })
+    }
+
+    /// Create a TypeScript adapter for `.tsx` sources using the JSX-aware grammar
+    pub fn new_tsx() -> Result<Self> {
+        let language = get_tree_sitter_language("tsx")?;
+        let parser = create_parser_for_language("tsx")?;
+
+        Ok(Self {
+            parser,
+            language,
+            jsx: true,
+            max_depth: DEFAULT_MAX_RECURSION_DEPTH,
+            deadline: None,
+        })
     }
 
     /// Parse TypeScript source code and extract entities
     pub fn parse_source(&mut self, source_code: &str, file_path: &str) -> Result<ParseIndex> {
-        let tree = self.parser.parse(source_code, None).ok_or_else(|| {
-            ValknutError::parse("typescript", "Failed to parse TypeScript source code")
+        let tree = parse_until(&mut self.parser, source_code, self.deadline).ok_or_else(|| {
+            ValknutError::parse_with_location(
+                "typescript",
+                "Failed to parse TypeScript source code",
+                file_path,
+                None,
+                None,
+            )
         })?;
+        check_syntax(&tree, "typescript", file_path)?;
 
         let mut index = ParseIndex::new();
         let mut entity_id_counter = 0;
@@ -151,6 +190,7 @@ impl TypeScriptAdapter {
         }
 
         metadata.insert("parameters".to_string(), serde_json::json!(parameters));
+        insert_type_parameters(node, source_code, metadata);
         metadata.insert("is_async".to_string(), serde_json::Value::Bool(is_async));
         metadata.insert(
             "is_generator".to_string(),
@@ -200,6 +240,7 @@ impl TypeScriptAdapter {
             "is_abstract".to_string(),
             serde_json::Value::Bool(is_abstract),
         );
+        insert_type_parameters(node, source_code, metadata);
 
         Ok(())
     }
@@ -265,18 +306,150 @@ impl TypeScriptAdapter {
         source_code: &str,
         metadata: &mut HashMap<String, serde_json::Value>,
     ) -> Result<()> {
+        if node.kind() == "type_alias_declaration" {
+            return self.extract_type_alias_metadata(node, source_code, metadata);
+        }
+
         let mut cursor = node.walk();
         let mut extends_interfaces = Vec::new();
+        let mut members = Vec::new();
 
         for child in node.children(&mut cursor) {
-            if child.kind() == "extends_clause" {
-                extends_interfaces = self.extract_type_identifiers(&child, source_code)?;
+            match child.kind() {
+                "extends_type_clause" | "extends_clause" => {
+                    extends_interfaces = self.extract_type_identifiers(&child, source_code)?;
+                }
+                "interface_body" | "object_type" => {
+                    members = extract_object_type_members(&child, source_code);
+                }
+                _ => {}
             }
         }
 
         if !extends_interfaces.is_empty() {
             metadata.insert("extends".to_string(), serde_json::json!(extends_interfaces));
         }
+        metadata.insert("members".to_string(), serde_json::json!(members));
+        insert_type_parameters(node, source_code, metadata);
+
+        Ok(())
+    }
+
+    /// Extract metadata for `type X = ...` aliases, including union members
+    fn extract_type_alias_metadata(
+        &self,
+        node: &Node,
+        source_code: &str,
+        metadata: &mut HashMap<String, serde_json::Value>,
+    ) -> Result<()> {
+        metadata.insert("is_type_alias".to_string(), serde_json::Value::Bool(true));
+        insert_type_parameters(node, source_code, metadata);
+
+        let Some(value) = node.child_by_field_name("value") else {
+            return Ok(());
+        };
+
+        metadata.insert(
+            "aliased_type".to_string(),
+            serde_json::Value::String(value.utf8_text(source_code.as_bytes())?.to_string()),
+        );
+
+        match value.kind() {
+            "union_type" => {
+                let mut members = Vec::new();
+                collect_union_members(&value, source_code, &mut members);
+                metadata.insert("union_members".to_string(), serde_json::json!(members));
+            }
+            "object_type" => {
+                let members = extract_object_type_members(&value, source_code);
+                metadata.insert("members".to_string(), serde_json::json!(members));
+            }
+            _ => {}
+        }
+
+        Ok(())
+    }
+
+    /// Extract variable/constant metadata: declared type and `as const` assertions
+    fn extract_variable_metadata(
+        &self,
+        node: &Node,
+        source_code: &str,
+        metadata: &mut HashMap<String, serde_json::Value>,
+    ) -> Result<()> {
+        let Some(declarator) = first_variable_declarator(node) else {
+            return Ok(());
+        };
+
+        if let Some(type_annotation) = declarator.child_by_field_name("type") {
+            metadata.insert(
+                "type_annotation".to_string(),
+                serde_json::Value::String(annotation_text(&type_annotation, source_code)),
+            );
+        }
+
+        let as_const = declarator
+            .child_by_field_name("value")
+            .map(|value| is_const_assertion(&value))
+            .unwrap_or(false);
+        metadata.insert("as_const".to_string(), serde_json::Value::Bool(as_const));
+
+        Ok(())
+    }
+
+    /// Record React hook signatures and, for TSX sources, component prop types.
+    ///
+    /// Hooks are functions named `useXxx`; components are PascalCase functions
+    /// (or arrow functions bound to a const) in `.tsx` files.
+    fn extract_react_metadata(
+        &self,
+        node: &Node,
+        name: &str,
+        source_code: &str,
+        metadata: &mut HashMap<String, serde_json::Value>,
+    ) -> Result<()> {
+        let function_node = match node.kind() {
+            "function_declaration" => Some(*node),
+            "variable_declaration" | "lexical_declaration" => first_variable_declarator(node)
+                .and_then(|declarator| declarator.child_by_field_name("value"))
+                .filter(|value| matches!(value.kind(), "arrow_function" | "function_expression")),
+            _ => None,
+        };
+        let Some(function_node) = function_node else {
+            return Ok(());
+        };
+
+        if is_hook_name(name) {
+            let params = function_node
+                .child_by_field_name("parameters")
+                .or_else(|| function_node.child_by_field_name("parameter"))
+                .map(|p| p.utf8_text(source_code.as_bytes()))
+                .transpose()?
+                .unwrap_or("()");
+            let params = if params.starts_with('(') {
+                params.to_string()
+            } else {
+                format!("({})", params)
+            };
+            let return_type = function_node
+                .child_by_field_name("return_type")
+                .map(|r| format!(": {}", annotation_text(&r, source_code)))
+                .unwrap_or_default();
+
+            metadata.insert("is_hook".to_string(), serde_json::Value::Bool(true));
+            metadata.insert(
+                "hook_signature".to_string(),
+                serde_json::Value::String(format!("{}{}{}", name, params, return_type)),
+            );
+        } else if self.jsx && is_component_name(name) {
+            metadata.insert("is_component".to_string(), serde_json::Value::Bool(true));
+            if let Some(props_type) = first_parameter_type(&function_node, source_code) {
+                metadata.insert(
+                    "props_type".to_string(),
+                    serde_json::Value::String(props_type),
+                );
+            }
+        }
 
         Ok(())
     }
@@ -326,8 +499,7 @@ impl TypeScriptAdapter {
 impl LanguageAdapter for TypeScriptAdapter {
     /// Parses source code into a tree-sitter AST.
     fn parse_tree(&mut self, source: &str) -> Result<Tree> {
-        self.parser
-            .parse(source, None)
+        parse_until(&mut self.parser, source, self.deadline)
             .ok_or_else(|| ValknutError::parse("typescript", "Failed to parse TypeScript source"))
     }
 
@@ -369,6 +541,16 @@ impl LanguageAdapter for TypeScriptAdapter {
         "typescript"
     }
 
+    /// Bounds entity extraction to `depth` levels of AST nesting.
+    fn set_max_ast_depth(&mut self, depth: usize) {
+        self.max_depth = depth;
+    }
+
+    /// Abandons parses still running once `deadline` passes.
+    fn set_parse_deadline(&mut self, deadline: Option<Instant>) {
+        self.deadline = deadline;
+    }
+
     /// Extracts import and require statements from TypeScript source.
     fn extract_imports(&mut self, source: &str) -> Result<Vec<ImportStatement>> {
         Ok(crate::lang::common::extract_imports_common(source, "type "))
@@ -386,6 +568,10 @@ impl LanguageAdapter for TypeScriptAdapter {
 
 /// [`EntityExtractor`] implementation providing the language-specific node conversion.
 impl EntityExtractor for TypeScriptAdapter {
+    fn max_depth(&self) -> usize {
+        self.max_depth
+    }
+
     fn node_to_entity(
         &self,
         node: Node,
@@ -409,6 +595,12 @@ impl EntityExtractor for TypeScriptAdapter {
         let mut metadata = create_base_metadata(node.kind(), node.start_byte(), node.end_byte());
 
         self.extract_entity_metadata(entity_kind, &node, source_code, &mut metadata)?;
+        if matches!(
+            entity_kind,
+            EntityKind::Function | EntityKind::Constant | EntityKind::Variable
+        ) {
+            self.extract_react_metadata(&node, &name, source_code, &mut metadata)?;
+        }
 
         Ok(Some(ParsedEntity {
             id: entity_id,
@@ -433,6 +625,111 @@ fn create_source_location(file_path: &str, node: &Node) -> SourceLocation {
     )
 }
 
+/// Insert the generic type parameters (`<T extends X>`) declared on a node, if any.
+fn insert_type_parameters(
+    node: &Node,
+    source_code: &str,
+    metadata: &mut HashMap<String, serde_json::Value>,
+) {
+    let Some(type_params) = node.child_by_field_name("type_parameters") else {
+        return;
+    };
+
+    let mut cursor = type_params.walk();
+    let params: Vec<String> = type_params
+        .named_children(&mut cursor)
+        .filter(|child| child.kind() == "type_parameter")
+        .filter_map(|child| child.utf8_text(source_code.as_bytes()).ok())
+        .map(String::from)
+        .collect();
+
+    if !params.is_empty() {
+        metadata.insert("type_parameters".to_string(), serde_json::json!(params));
+    }
+}
+
+/// Flatten a (possibly nested) union type into its member type texts.
+fn collect_union_members(node: &Node, source_code: &str, members: &mut Vec<String>) {
+    if node.kind() != "union_type" {
+        if let Ok(text) = node.utf8_text(source_code.as_bytes()) {
+            members.push(text.trim().to_string());
+        }
+        return;
+    }
+
+    let mut cursor = node.walk();
+    for child in node.named_children(&mut cursor) {
+        collect_union_members(&child, source_code, members);
+    }
+}
+
+/// Collect property and method names declared in an interface body or object type.
+fn extract_object_type_members(body: &Node, source_code: &str) -> Vec<String> {
+    let mut cursor = body.walk();
+    body.named_children(&mut cursor)
+        .filter(|child| matches!(child.kind(), "property_signature" | "method_signature"))
+        .filter_map(|child| child.child_by_field_name("name"))
+        .filter_map(|name| name.utf8_text(source_code.as_bytes()).ok())
+        .map(String::from)
+        .collect()
+}
+
+/// Find the first `variable_declarator` of a variable/lexical declaration.
+fn first_variable_declarator<'a>(node: &Node<'a>) -> Option<Node<'a>> {
+    let mut cursor = node.walk();
+    let declarator = node
+        .named_children(&mut cursor)
+        .find(|child| child.kind() == "variable_declarator");
+    declarator
+}
+
+/// Check whether an expression is an `... as const` assertion.
+fn is_const_assertion(value: &Node) -> bool {
+    value.kind() == "as_expression"
+        && value
+            .child(value.child_count().saturating_sub(1))
+            .map(|last| last.kind() == "const")
+            .unwrap_or(false)
+}
+
+/// Text of a `type_annotation` node without the leading colon.
+fn annotation_text(annotation: &Node, source_code: &str) -> String {
+    annotation
+        .utf8_text(source_code.as_bytes())
+        .unwrap_or_default()
+        .trim_start_matches(':')
+        .trim()
+        .to_string()
+}
+
+/// Type annotation of the first formal parameter (the props type for components).
+fn first_parameter_type(function_node: &Node, source_code: &str) -> Option<String> {
+    let params = function_node.child_by_field_name("parameters")?;
+    let mut cursor = params.walk();
+    let first = params
+        .named_children(&mut cursor)
+        .find(|child| matches!(child.kind(), "required_parameter" | "optional_parameter"))?;
+    first
+        .child_by_field_name("type")
+        .map(|annotation| annotation_text(&annotation, source_code))
+}
+
+/// React hook naming convention: `use` followed by an uppercase letter.
+fn is_hook_name(name: &str) -> bool {
+    name.strip_prefix("use")
+        .and_then(|rest| rest.chars().next())
+        .map(|c| c.is_ascii_uppercase())
+        .unwrap_or(false)
+}
+
+/// React component naming convention: PascalCase identifier.
+fn is_component_name(name: &str) -> bool {
+    name.chars()
+        .next()
+        .map(|c| c.is_ascii_uppercase())
+        .unwrap_or(false)
+}
+
 /// Entity metadata extraction dispatch for TypeScriptAdapter.
 impl TypeScriptAdapter {
     fn extract_entity_metadata(
@@ -455,6 +752,9 @@ impl TypeScriptAdapter {
             EntityKind::Enum => {
                 self.extract_enum_metadata(node, source_code, metadata)?;
             }
+            EntityKind::Constant | EntityKind::Variable => {
+                self.extract_variable_metadata(node, source_code, metadata)?;
+            }
             _ => {}
         }
         Ok(())
@@ -466,14 +766,17 @@ impl Default for TypeScriptAdapter {
     /// Returns a new TypeScript adapter, or a minimal fallback on failure.
     fn default() -> Self {
         Self::new().unwrap_or_else(|e| {
-            eprintln!(
-                "Warning: Failed to create TypeScript adapter, using minimal fallback: {}",
+            warn!(
+                "Failed to create TypeScript adapter, using minimal fallback: {}",
                 e
             );
             TypeScriptAdapter {
                 parser: tree_sitter::Parser::new(),
                 language: get_tree_sitter_language("ts")
                     .unwrap_or_else(|_| tree_sitter_typescript::LANGUAGE_TYPESCRIPT.into()),
+                jsx: false,
+                max_depth: DEFAULT_MAX_RECURSION_DEPTH,
+                deadline: None,
             }
         })
     }
@@ -405,3 +405,103 @@ let counter: number = 0;
         .expect("missing counter variable");
     assert_eq!(variable_entity.entity_type, "Variable");
 }
+
+fn entity_by_name<'a>(index: &'a ParseIndex, name: &str) -> &'a ParsedEntity {
+    index
+        .entities
+        .values()
+        .find(|entity| entity.name == name)
+        .unwrap_or_else(|| panic!("missing entity {}", name))
+}
+
+#[test]
+fn test_extracts_generics_unions_and_const_assertions() {
+    let mut adapter = TypeScriptAdapter::new().expect("adapter");
+    let source = r#"
+interface Repository<T extends { id: string }> {
+    items: T[];
+    find(id: string): T | undefined;
+}
+type Status = 'idle' | 'loading' | 'error';
+const ROUTES = ['home', 'about'] as const;
+const limit: number = 10;
+function first<T, K extends keyof T>(items: T[], key: K): T | undefined {
+    return items[0];
+}
+"#;
+    let index = adapter.parse_source(source, "types.ts").expect("parse");
+
+    let repo = entity_by_name(&index, "Repository");
+    assert_eq!(
+        repo.metadata.get("type_parameters"),
+        Some(&serde_json::json!(["T extends { id: string }"]))
+    );
+    assert_eq!(
+        repo.metadata.get("members"),
+        Some(&serde_json::json!(["items", "find"]))
+    );
+
+    let status = entity_by_name(&index, "Status");
+    assert_eq!(
+        status.metadata.get("union_members"),
+        Some(&serde_json::json!(["'idle'", "'loading'", "'error'"]))
+    );
+
+    let routes = entity_by_name(&index, "ROUTES");
+    assert_eq!(routes.metadata.get("as_const"), Some(&Value::Bool(true)));
+
+    let limit = entity_by_name(&index, "limit");
+    assert_eq!(limit.metadata.get("as_const"), Some(&Value::Bool(false)));
+    assert_eq!(
+        limit.metadata.get("type_annotation"),
+        Some(&Value::String("number".to_string()))
+    );
+
+    let first = entity_by_name(&index, "first");
+    assert_eq!(
+        first.metadata.get("type_parameters"),
+        Some(&serde_json::json!(["T", "K extends keyof T"]))
+    );
+}
+
+#[test]
+fn test_tsx_components_and_hooks() {
+    let mut adapter = TypeScriptAdapter::new_tsx().expect("tsx adapter");
+    let source = r#"
+interface ButtonProps { label: string }
+export function Button({ label }: ButtonProps) {
+    return <button>{label}</button>;
+}
+export const Card = (props: CardProps) => <div>{props.title}</div>;
+export function useCounter(initial: number): [number, () => void] {
+    return [initial, () => {}];
+}
+"#;
+    let index = adapter.parse_source(source, "App.tsx").expect("parse tsx");
+
+    let button = entity_by_name(&index, "Button");
+    assert_eq!(
+        button.metadata.get("is_component"),
+        Some(&Value::Bool(true))
+    );
+    assert_eq!(
+        button.metadata.get("props_type"),
+        Some(&Value::String("ButtonProps".to_string()))
+    );
+
+    let card = entity_by_name(&index, "Card");
+    assert_eq!(
+        card.metadata.get("props_type"),
+        Some(&Value::String("CardProps".to_string()))
+    );
+
+    let hook = entity_by_name(&index, "useCounter");
+    assert_eq!(hook.metadata.get("is_hook"), Some(&Value::Bool(true)));
+    assert_eq!(
+        hook.metadata.get("hook_signature"),
+        Some(&Value::String(
+            "useCounter(initial: number): [number, () => void]".to_string()
+        ))
+    );
+    assert!(hook.metadata.get("is_component").is_none());
+}
@@ -0,0 +1,244 @@
+//! Reusable scratch buffers for the built-in parsers.
+//!
+//! Parsing a file allocates buffers that only live as long as the parse: the
+//! bytes [`PluginRegistry::parse_file`](super::PluginRegistry::parse_file)
+//! reads and the `Vec<char>` each hand-written parser scans. On large trees
+//! those allocations grow with the number of files, so they are taken from
+//! process-wide pools ([`SOURCE_BYTES`], [`SOURCE_CHARS`]) and handed back
+//! when the parse finishes. Results such as [`FileAnalysis`](super::FileAnalysis)
+//! are still allocated normally since they outlive the parse.
+//!
+//! A buffer grown past [`MAX_POOLED_BYTES`] by an unusually large file is
+//! released instead of being pooled, so one memory-intensive parse does not
+//! keep its peak footprint alive for the rest of the run.
+
+use std::mem::size_of;
+use std::ops::{Deref, DerefMut};
+use std::sync::atomic::{AtomicBool, AtomicUsize, Ordering};
+use std::sync::Mutex;
+
+/// Largest buffer, in bytes, returned to a pool.
+pub const MAX_POOLED_BYTES: usize = 4 * 1024 * 1024;
+
+/// Buffers kept per pool; enough for one per parsing thread on most machines.
+pub const MAX_POOLED_BUFFERS: usize = 64;
+
+/// File contents read by the plugin registry.
+pub static SOURCE_BYTES: BufferPool<u8> = BufferPool::new(MAX_POOLED_BUFFERS, MAX_POOLED_BYTES);
+
+/// Decoded source scanned by the hand-written parsers.
+pub static SOURCE_CHARS: BufferPool<char> = BufferPool::new(MAX_POOLED_BUFFERS, MAX_POOLED_BYTES);
+
+/// Take a buffer from [`SOURCE_CHARS`] holding the characters of `source`.
+pub fn source_chars(source: &str) -> PooledBuffer<'static, char> {
+    let mut chars = SOURCE_CHARS.take();
+    chars.extend(source.chars());
+    chars
+}
+
+/// A pool of `Vec<T>` buffers shared across threads.
+#[derive(Debug)]
+pub struct BufferPool<T> {
+    buffers: Mutex<Vec<Vec<T>>>,
+    max_buffers: usize,
+    max_bytes: usize,
+    enabled: AtomicBool,
+    created: AtomicUsize,
+    reused: AtomicUsize,
+}
+
+/// Factory, allocation, and statistics methods for [`BufferPool`].
+impl<T> BufferPool<T> {
+    /// Create a pool keeping up to `max_buffers` buffers of at most `max_bytes` each.
+    pub const fn new(max_buffers: usize, max_bytes: usize) -> Self {
+        Self {
+            buffers: Mutex::new(Vec::new()),
+            max_buffers,
+            max_bytes,
+            enabled: AtomicBool::new(true),
+            created: AtomicUsize::new(0),
+            reused: AtomicUsize::new(0),
+        }
+    }
+
+    /// Take an empty buffer, reusing a pooled allocation when one is available.
+    pub fn take(&self) -> PooledBuffer<'_, T> {
+        let pooled = if self.enabled.load(Ordering::Relaxed) {
+            self.buffers
+                .lock()
+                .ok()
+                .and_then(|mut buffers| buffers.pop())
+        } else {
+            None
+        };
+        let buffer = match pooled {
+            Some(buffer) => {
+                self.reused.fetch_add(1, Ordering::Relaxed);
+                buffer
+            }
+            None => {
+                self.created.fetch_add(1, Ordering::Relaxed);
+                Vec::new()
+            }
+        };
+        PooledBuffer { buffer, pool: self }
+    }
+
+    /// Turn pooling on or off. Disabling drops the pooled buffers, and every
+    /// [`take`](Self::take) allocates afresh until pooling is enabled again.
+    pub fn set_enabled(&self, enabled: bool) {
+        self.enabled.store(enabled, Ordering::Relaxed);
+        if !enabled {
+            if let Ok(mut buffers) = self.buffers.lock() {
+                buffers.clear();
+            }
+        }
+    }
+
+    /// Get pool statistics
+    pub fn statistics(&self) -> BufferPoolStatistics {
+        BufferPoolStatistics {
+            created_count: self.created.load(Ordering::Relaxed),
+            reused_count: self.reused.load(Ordering::Relaxed),
+            pooled_count: self.buffers.lock().map(|b| b.len()).unwrap_or(0),
+        }
+    }
+
+    /// Keep `buffer` for reuse unless the pool is full, disabled, or the
+    /// buffer is too large to be worth holding on to.
+    fn give_back(&self, mut buffer: Vec<T>) {
+        if !self.enabled.load(Ordering::Relaxed)
+            || buffer.capacity() == 0
+            || buffer.capacity().saturating_mul(size_of::<T>()) > self.max_bytes
+        {
+            return;
+        }
+        buffer.clear();
+        if let Ok(mut buffers) = self.buffers.lock() {
+            if buffers.len() < self.max_buffers {
+                buffers.push(buffer);
+            }
+        }
+    }
+}
+
+/// A buffer on loan from a [`BufferPool`], returned to it when dropped.
+#[derive(Debug)]
+pub struct PooledBuffer<'a, T> {
+    buffer: Vec<T>,
+    pool: &'a BufferPool<T>,
+}
+
+/// Dereferences to the underlying `Vec`.
+impl<T> Deref for PooledBuffer<'_, T> {
+    type Target = Vec<T>;
+
+    fn deref(&self) -> &Vec<T> {
+        &self.buffer
+    }
+}
+
+/// Mutable access to the underlying `Vec`.
+impl<T> DerefMut for PooledBuffer<'_, T> {
+    fn deref_mut(&mut self) -> &mut Vec<T> {
+        &mut self.buffer
+    }
+}
+
+/// Returns the buffer to its pool.
+impl<T> Drop for PooledBuffer<'_, T> {
+    fn drop(&mut self) {
+        self.pool.give_back(std::mem::take(&mut self.buffer));
+    }
+}
+
+/// Statistics for buffer pool usage
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub struct BufferPoolStatistics {
+    /// Buffers allocated because the pool was empty or disabled.
+    pub created_count: usize,
+    /// Buffers served from the pool.
+    pub reused_count: usize,
+    /// Buffers currently waiting in the pool.
+    pub pooled_count: usize,
+}
+
+/// Analysis methods for [`BufferPoolStatistics`].
+impl BufferPoolStatistics {
+    /// Fraction of takes served from the pool.
+    pub fn reuse_rate(&self) -> f64 {
+        let total = self.created_count + self.reused_count;
+        if total == 0 {
+            0.0
+        } else {
+            self.reused_count as f64 / total as f64
+        }
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn buffers_are_reused_after_drop() {
+        let pool: BufferPool<u8> = BufferPool::new(4, 1024);
+        let capacity = {
+            let mut buffer = pool.take();
+            buffer.extend_from_slice(b"module Shop");
+            buffer.capacity()
+        };
+        assert_eq!(pool.statistics().pooled_count, 1);
+
+        let buffer = pool.take();
+        assert!(buffer.is_empty());
+        assert_eq!(buffer.capacity(), capacity);
+        let stats = pool.statistics();
+        assert_eq!((stats.created_count, stats.reused_count), (1, 1));
+        assert_eq!(stats.reuse_rate(), 0.5);
+    }
+
+    #[test]
+    fn oversized_and_surplus_buffers_are_released() {
+        let pool: BufferPool<char> = BufferPool::new(1, 64);
+        {
+            let mut large = pool.take();
+            large.extend(std::iter::repeat('x').take(100));
+        }
+        assert_eq!(pool.statistics().pooled_count, 0);
+
+        {
+            let mut first = pool.take();
+            let mut second = pool.take();
+            first.push('a');
+            second.push('b');
+        }
+        assert_eq!(pool.statistics().pooled_count, 1);
+    }
+
+    #[test]
+    fn disabled_pool_allocates_every_buffer() {
+        let pool: BufferPool<u8> = BufferPool::new(4, 1024);
+        pool.take().push(1);
+        pool.set_enabled(false);
+        assert_eq!(pool.statistics().pooled_count, 0);
+
+        pool.take().push(2);
+        pool.take().push(3);
+        let stats = pool.statistics();
+        assert_eq!((stats.created_count, stats.reused_count), (3, 0));
+        assert_eq!(stats.pooled_count, 0);
+
+        pool.set_enabled(true);
+        pool.take().push(4);
+        assert!(pool.take().capacity() > 0);
+        assert_eq!(pool.statistics().reused_count, 1);
+    }
+
+    #[test]
+    fn source_chars_decodes_into_a_pooled_buffer() {
+        let chars = source_chars("défaut");
+        assert_eq!(chars.len(), 6);
+        assert_eq!(chars[1], 'é');
+    }
+}
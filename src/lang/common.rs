@@ -1,12 +1,13 @@
 //! Common AST and parsing abstractions.
 
 use crate::core::ast_utils::{count_all_nodes, node_text_normalized, walk_tree};
-use crate::core::errors::Result;
+use crate::core::errors::{Result, ValknutError};
 use crate::core::featureset::CodeEntity;
 use crate::detectors::structure::config::ImportStatement;
 use async_trait::async_trait;
 use serde::{Deserialize, Serialize};
-use tree_sitter::{Node, Tree};
+use std::time::Instant;
+use tree_sitter::{Node, ParseOptions, ParseState, Parser, Tree};
 
 /// Common entity types across all languages
 #[derive(Debug, Clone, Copy, PartialEq, Eq, Hash, Serialize, Deserialize)]
@@ -20,6 +21,7 @@ pub enum EntityKind {
     Constant,
     Enum,
     Struct,
+    FunctionClause,
 }
 
 /// Utility methods for [`EntityKind`].
@@ -36,6 +38,7 @@ impl EntityKind {
             EntityKind::Constant => "constant",
             EntityKind::Enum => "enum",
             EntityKind::Struct => "struct",
+            EntityKind::FunctionClause => "function_clause",
         };
         format!("anonymous_{}_{}", kind_str, counter)
     }
@@ -328,6 +331,16 @@ pub trait LanguageAdapter: Send + Sync {
     /// Get language name
     fn language_name(&self) -> &str;
 
+    /// Fail entity extraction on nodes nested deeper than `depth`
+    /// (`analysis.max_ast_depth`). Adapters whose traversal is not bounded
+    /// ignore it.
+    fn set_max_ast_depth(&mut self, _depth: usize) {}
+
+    /// Abandon parses still running once `deadline` passes (`--timeout`), so
+    /// they fail instead of finishing. Adapters that do not parse with
+    /// tree-sitter ignore it.
+    fn set_parse_deadline(&mut self, _deadline: Option<Instant>) {}
+
     /// Extract import statements from source code
     fn extract_imports(&mut self, _source: &str) -> Result<Vec<ImportStatement>> {
         Ok(Vec::new())
@@ -596,11 +609,108 @@ pub fn extract_node_text(
     Ok(None)
 }
 
+/// Whitespace-normalized source text of a node, or an empty string.
+pub fn text_of(node: &Node, source_code: &str) -> String {
+    node_text_normalized(node, source_code)
+        .map(|text| text.trim().to_string())
+        .unwrap_or_default()
+}
+
+/// Unqualified, non-generic name of a type (`java.util.Map<K, V>` -> `Map`).
+///
+/// Shared by the Java, C# and Kotlin adapters, whose type names are dotted
+/// paths with angle-bracket type arguments.
+pub fn simple_type_name(type_text: &str) -> String {
+    let base = type_text.split('<').next().unwrap_or(type_text);
+    base.rsplit('.').next().unwrap_or(base).trim().to_string()
+}
+
+/// Default for `analysis.max_ast_depth`, the deepest AST node entity
+/// extraction visits.
+///
+/// Real code rarely nests past a few dozen levels, while machine-generated
+/// sources can nest deep enough to overflow the stack.
+pub const DEFAULT_MAX_RECURSION_DEPTH: usize = 256;
+
+/// Fail with a parse error when `node`, `depth` levels below the traversal
+/// root, lies deeper than `limit`.
+pub fn check_recursion_depth(
+    node: Node,
+    file_path: &str,
+    depth: usize,
+    limit: usize,
+) -> Result<()> {
+    if depth <= limit {
+        return Ok(());
+    }
+    Err(ValknutError::parse_with_location(
+        node.language().name().unwrap_or("unknown"),
+        format!("AST nesting exceeds the maximum recursion depth of {limit}"),
+        file_path,
+        Some(node.start_position().row + 1),
+        Some(node.start_position().column + 1),
+    ))
+}
+
+/// Fail with a parse error located at the first `ERROR` or `MISSING` node of
+/// `tree`, in document order.
+pub fn check_syntax(tree: &Tree, language: &str, file_path: &str) -> Result<()> {
+    let mut node = tree.root_node();
+    if !node.has_error() {
+        return Ok(());
+    }
+    while !node.is_error() && !node.is_missing() {
+        let mut cursor = node.walk();
+        let Some(child) = node.children(&mut cursor).find(|child| child.has_error()) else {
+            break;
+        };
+        node = child;
+    }
+
+    let message = if node.is_missing() {
+        format!("Syntax error: missing `{}`", node.kind())
+    } else {
+        "Syntax error".to_string()
+    };
+    Err(ValknutError::parse_with_location(
+        language,
+        message,
+        file_path,
+        Some(node.start_position().row + 1),
+        Some(node.start_position().column + 1),
+    ))
+}
+
+/// Parse `source` with `parser`, abandoning the parse once `deadline` passes.
+///
+/// Returns `None` for an abandoned parse and leaves `parser` ready for the
+/// next source.
+pub fn parse_until(parser: &mut Parser, source: &str, deadline: Option<Instant>) -> Option<Tree> {
+    let Some(deadline) = deadline else {
+        return parser.parse(source, None);
+    };
+    let bytes = source.as_bytes();
+    let mut expired = |_: &ParseState| Instant::now() >= deadline;
+    let tree = parser.parse_with_options(
+        &mut |offset, _| &bytes[offset.min(bytes.len())..],
+        None,
+        Some(ParseOptions::new().progress_callback(&mut expired)),
+    );
+    if tree.is_none() {
+        // A cancelled parser resumes its old parse on the next call otherwise.
+        parser.reset();
+    }
+    tree
+}
+
 /// Trait for language adapters that extract entities from AST nodes.
 ///
 /// Provides default implementations for recursive AST traversal.
 /// Implementors only need to define the language-specific `node_to_entity` method.
 pub trait EntityExtractor {
+    /// Deepest AST node the traversal visits (`analysis.max_ast_depth`).
+    fn max_depth(&self) -> usize;
+
     /// Convert a tree-sitter node to a ParsedEntity if it represents an entity.
     ///
     /// This is the language-specific method that each adapter must implement.
@@ -617,6 +727,8 @@ pub trait EntityExtractor {
     /// Recursively extract entities from the AST.
     ///
     /// Default implementation that handles the common traversal pattern.
+    /// `depth` is the nesting level of `node` below the traversal root; the
+    /// traversal fails once it exceeds [`max_depth`](Self::max_depth).
     fn extract_entities_recursive(
         &self,
         node: Node,
@@ -625,7 +737,9 @@ pub trait EntityExtractor {
         parent_id: Option<String>,
         index: &mut ParseIndex,
         entity_id_counter: &mut usize,
+        depth: usize,
     ) -> Result<()> {
+        check_recursion_depth(node, file_path, depth, self.max_depth())?;
         if let Some(entity) = self.node_to_entity(
             node,
             source_code,
@@ -642,6 +756,7 @@ pub trait EntityExtractor {
                 Some(entity_id),
                 index,
                 entity_id_counter,
+                depth,
             )?;
         } else {
             self.traverse_children(
@@ -651,6 +766,7 @@ pub trait EntityExtractor {
                 parent_id,
                 index,
                 entity_id_counter,
+                depth,
             )?;
         }
         Ok(())
@@ -659,6 +775,7 @@ pub trait EntityExtractor {
     /// Traverse and process all child nodes recursively.
     ///
     /// Default implementation that iterates over children and calls extract_entities_recursive.
+    /// `depth` is the nesting level of `node`; children are visited one level deeper.
     fn traverse_children(
         &self,
         node: Node,
@@ -667,6 +784,7 @@ pub trait EntityExtractor {
         parent_id: Option<String>,
         index: &mut ParseIndex,
         entity_id_counter: &mut usize,
+        depth: usize,
     ) -> Result<()> {
         let mut cursor = node.walk();
         for child in node.children(&mut cursor) {
@@ -677,6 +795,7 @@ pub trait EntityExtractor {
                 parent_id.clone(),
                 index,
                 entity_id_counter,
+                depth + 1,
             )?;
         }
         Ok(())
@@ -686,7 +805,7 @@ pub trait EntityExtractor {
     ///
     /// This avoids stack overflow on deeply nested code by using an explicit stack
     /// instead of the call stack. Preferred over `extract_entities_recursive` for
-    /// production use.
+    /// production use. Fails once a node lies deeper than [`max_depth`](Self::max_depth).
     fn extract_entities_iterative(
         &self,
         root: Node,
@@ -695,10 +814,12 @@ pub trait EntityExtractor {
         index: &mut ParseIndex,
         entity_id_counter: &mut usize,
     ) -> Result<()> {
-        // Stack entries: (node, parent_id)
-        let mut stack: Vec<(Node, Option<String>)> = vec![(root, None)];
+        // Stack entries: (node, parent_id, depth)
+        let mut stack: Vec<(Node, Option<String>, usize)> = vec![(root, None, 0)];
+
+        while let Some((node, parent_id, depth)) = stack.pop() {
+            check_recursion_depth(node, file_path, depth, self.max_depth())?;
 
-        while let Some((node, parent_id)) = stack.pop() {
             // Process this node
             let new_parent_id = if let Some(entity) = self.node_to_entity(
                 node,
@@ -718,7 +839,7 @@ pub trait EntityExtractor {
             let mut cursor = node.walk();
             let children: Vec<_> = node.children(&mut cursor).collect();
             for child in children.into_iter().rev() {
-                stack.push((child, new_parent_id.clone()));
+                stack.push((child, new_parent_id.clone(), depth + 1));
             }
         }
 
@@ -726,6 +847,108 @@ pub trait EntityExtractor {
     }
 }
 
+/// A token of a hand-written lexer with its character range and 1-based
+/// first and last lines.
+#[derive(Debug, Clone)]
+pub(crate) struct Spanned<T> {
+    pub(crate) kind: T,
+    pub(crate) line: usize,
+    pub(crate) end_line: usize,
+    pub(crate) start: usize,
+    pub(crate) end: usize,
+}
+
+/// What a language lexer found at a non-whitespace position.
+pub(crate) enum Lexeme<T> {
+    /// A token ending just before the index
+    Token(T, usize),
+    /// Text to drop (a comment or directive) ending just before the index
+    Skip(usize),
+}
+
+/// Split `chars` into tokens for the tree-sitter-less parsers.
+///
+/// Whitespace is skipped here; `lex` is called at every other position and
+/// decides what starts there. Line numbers are tracked across tokens and
+/// skipped text alike.
+pub(crate) fn tokenize<T>(
+    chars: &[char],
+    lex: impl Fn(&[char], usize) -> Lexeme<T>,
+) -> Vec<Spanned<T>> {
+    let mut tokens = Vec::new();
+    let mut line = 1;
+    let mut i = 0;
+
+    while i < chars.len() {
+        let start = i;
+        let kind = if chars[i].is_whitespace() {
+            i += 1;
+            None
+        } else {
+            match lex(chars, i) {
+                Lexeme::Token(kind, end) => {
+                    i = end;
+                    Some(kind)
+                }
+                Lexeme::Skip(end) => {
+                    i = end;
+                    None
+                }
+            }
+        };
+        i = i.min(chars.len());
+        let newlines = chars[start..i].iter().filter(|&&c| c == '\n').count();
+        if let Some(kind) = kind {
+            tokens.push(Spanned {
+                kind,
+                line,
+                end_line: line + newlines,
+                start,
+                end: i,
+            });
+        }
+        line += newlines;
+    }
+    tokens
+}
+
+/// Last line of the token before `index`, or 1 at the start of input.
+pub(crate) fn previous_line<T>(tokens: &[Spanned<T>], index: usize) -> usize {
+    index
+        .checked_sub(1)
+        .and_then(|index| tokens.get(index))
+        .map_or(1, |token| token.end_line)
+}
+
+/// Index of the newline ending the line that contains `i`.
+pub(crate) fn line_end(chars: &[char], i: usize) -> usize {
+    chars[i..]
+        .iter()
+        .position(|&c| c == '\n')
+        .map_or(chars.len(), |offset| i + offset)
+}
+
+/// End of the number starting at `i`: digits, letters, `_` and a `.` followed
+/// by a digit (`1_000`, `0xFF`, `3.14`).
+pub(crate) fn scan_number(chars: &[char], mut i: usize) -> usize {
+    while i < chars.len()
+        && (chars[i].is_alphanumeric()
+            || chars[i] == '_'
+            || (chars[i] == '.' && chars.get(i + 1).is_some_and(|c| c.is_ascii_digit())))
+    {
+        i += 1;
+    }
+    i
+}
+
+/// The first of `operators` starting at `i`, or the single character there.
+pub(crate) fn scan_operator(chars: &[char], i: usize, operators: &[&str]) -> String {
+    operators
+        .iter()
+        .find(|op| chars[i..].iter().take(op.len()).copied().eq(op.chars()))
+        .map_or_else(|| chars[i].to_string(), |op| op.to_string())
+}
+
 #[cfg(test)]
 mod tests {
     use super::*;
@@ -1079,4 +1302,17 @@ mod tests {
         assert_eq!(interned[0].name_str(), "Dummy");
         assert_eq!(adapter.call_count.load(Ordering::SeqCst), 1);
     }
+
+    #[test]
+    fn parse_until_abandons_a_parse_that_outlives_the_deadline() {
+        let mut parser = crate::lang::registry::create_parser_for_language("py").unwrap();
+        // Far more work than the parser can finish before the check that
+        // notices the expired deadline.
+        let endless = "value = [1, 2, 3]\n".repeat(50_000);
+        assert!(parse_until(&mut parser, &endless, Some(Instant::now())).is_none());
+
+        let tree = parse_until(&mut parser, "def ok():\n    pass\n", None).unwrap();
+        assert!(!tree.root_node().has_error());
+        assert_eq!(tree.root_node().end_position().row, 2);
+    }
 }
@@ -0,0 +1,208 @@
+//! Per-file language detection for mixed-language repositories.
+//!
+//! A file's language comes from, in order: a user mapping for its extension
+//! (`analysis.language_mappings`, e.g. `hcl = "terraform"`), the extensions of
+//! the registered adapters, and finally the interpreter named on its shebang
+//! line (`#!/usr/bin/env python3`). Mappings are passed in by the caller, so
+//! only discovery sees them; they may name languages valknut has no parser
+//! for, and such files resolve to [`LanguageMatch::Unsupported`] so discovery
+//! can report them as `language_unknown` instead of failing the run.
+
+use std::collections::BTreeMap;
+use std::io::{BufRead, BufReader, Read};
+use std::path::Path;
+
+use super::registry::{builtin_key_for_path, normalize_language_key};
+
+/// Bytes read when looking for a shebang line.
+const SHEBANG_READ_LIMIT: u64 = 256;
+
+/// Outcome of resolving a file's language.
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub enum LanguageMatch {
+    /// A registered adapter handles the file; holds its language key.
+    Supported(&'static str),
+    /// The file was mapped to a language without a registered adapter.
+    Unsupported(String),
+    /// Neither the extension nor the shebang identifies a language.
+    Unrecognized,
+}
+
+/// Detect the language key of a file from its path and first line.
+///
+/// Registered extensions win; the shebang in `first_line` is consulted only
+/// when the extension says nothing.
+pub fn detect_language(path: &Path, first_line: &str) -> Option<&'static str> {
+    match resolve_language(path, Some(first_line), &BTreeMap::new()) {
+        LanguageMatch::Supported(key) => Some(key),
+        LanguageMatch::Unsupported(_) | LanguageMatch::Unrecognized => None,
+    }
+}
+
+/// Resolve a file's language, applying `mappings` before the registered extensions.
+///
+/// `first_line` is only needed for files whose extension is not recognised.
+pub fn resolve_language(
+    path: &Path,
+    first_line: Option<&str>,
+    mappings: &BTreeMap<String, String>,
+) -> LanguageMatch {
+    if let Some(language) = extension_of(path).and_then(|ext| {
+        mappings
+            .iter()
+            .find(|(mapped, _)| normalize_extension(mapped) == ext)
+            .map(|(_, language)| language)
+    }) {
+        return match normalize_language_key(language) {
+            Some(key) => LanguageMatch::Supported(key),
+            None => LanguageMatch::Unsupported(language.clone()),
+        };
+    }
+    if let Some(key) = builtin_key_for_path(path) {
+        return LanguageMatch::Supported(key);
+    }
+    first_line
+        .and_then(shebang_language)
+        .map_or(LanguageMatch::Unrecognized, LanguageMatch::Supported)
+}
+
+/// Resolve the language of a file on disk, reading its first line only when
+/// the extension is not recognised.
+pub fn resolve_file_language(path: &Path, mappings: &BTreeMap<String, String>) -> LanguageMatch {
+    match resolve_language(path, None, mappings) {
+        LanguageMatch::Unrecognized => {
+            let first_line = read_first_line(path);
+            resolve_language(path, first_line.as_deref(), mappings)
+        }
+        resolved => resolved,
+    }
+}
+
+/// Language key named by a `#!` line, if any.
+///
+/// `env` and its options (`#!/usr/bin/env -S python3 -u`) are skipped and
+/// version suffixes ignored, so `python3.12` and `pypy3` map to Python.
+pub fn shebang_language(first_line: &str) -> Option<&'static str> {
+    let command = first_line.strip_prefix("#!")?;
+    let mut words = command.split_whitespace();
+    let mut program = basename(words.next()?);
+    if program == "env" {
+        program = basename(words.find(|word| !word.starts_with('-') && !word.contains('='))?);
+    }
+    let name = program.trim_end_matches(|c: char| c.is_ascii_digit() || c == '.');
+    match name {
+        "python" | "pypy" => Some("py"),
+        "node" | "nodejs" | "bun" => Some("js"),
+        "ts-node" | "tsx" | "deno" => Some("ts"),
+        "rust-script" | "cargo" => Some("rs"),
+        "gorun" => Some("go"),
+        "java" => Some("java"),
+        "kotlin" => Some("kt"),
+        _ => None,
+    }
+}
+
+/// First line of a file, read from at most [`SHEBANG_READ_LIMIT`] bytes.
+pub fn read_first_line(path: &Path) -> Option<String> {
+    let file = std::fs::File::open(path).ok()?;
+    let mut line = String::new();
+    BufReader::new(file.take(SHEBANG_READ_LIMIT))
+        .read_line(&mut line)
+        .ok()?;
+    Some(line.trim_end().to_string())
+}
+
+/// Lower-case extension without the leading dot.
+fn normalize_extension(ext: &str) -> String {
+    ext.trim().trim_start_matches('.').to_ascii_lowercase()
+}
+
+/// Lower-case extension of `path`.
+fn extension_of(path: &Path) -> Option<String> {
+    path.extension()
+        .map(|ext| normalize_extension(&ext.to_string_lossy()))
+}
+
+/// Final path component of a program path.
+fn basename(program: &str) -> &str {
+    program.rsplit('/').next().unwrap_or(program)
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn mappings(pairs: &[(&str, &str)]) -> BTreeMap<String, String> {
+        pairs
+            .iter()
+            .map(|(ext, lang)| (ext.to_string(), lang.to_string()))
+            .collect()
+    }
+
+    #[test]
+    fn extension_wins_over_shebang() {
+        assert_eq!(
+            detect_language(Path::new("svc/main.go"), "#!/usr/bin/env python3"),
+            Some("go")
+        );
+        assert_eq!(detect_language(Path::new("web/app.tsx"), ""), Some("ts"));
+    }
+
+    #[test]
+    fn shebang_identifies_extensionless_scripts() {
+        for (line, expected) in [
+            ("#!/usr/bin/env python3", Some("py")),
+            ("#!/usr/bin/python3.12 -u", Some("py")),
+            ("#!/usr/bin/env -S node --experimental-modules", Some("js")),
+            ("#!/usr/bin/env FOO=1 deno run", Some("ts")),
+            ("#!/bin/bash", None),
+            ("print('no shebang')", None),
+        ] {
+            assert_eq!(
+                detect_language(Path::new("bin/deploy"), line),
+                expected,
+                "{line}"
+            );
+        }
+    }
+
+    #[test]
+    fn mappings_override_extensions_and_may_name_unknown_languages() {
+        let mappings = mappings(&[(".hcl", "terraform"), ("pyx", "python"), ("H", "c")]);
+        assert_eq!(
+            resolve_language(Path::new("infra/main.hcl"), None, &mappings),
+            LanguageMatch::Unsupported("terraform".to_string())
+        );
+        assert_eq!(
+            resolve_language(Path::new("fast/core.pyx"), None, &mappings),
+            LanguageMatch::Supported("py")
+        );
+        assert_eq!(
+            resolve_language(Path::new("include/uart.h"), None, &mappings),
+            LanguageMatch::Supported("c")
+        );
+        assert_eq!(
+            resolve_language(Path::new("README.md"), None, &mappings),
+            LanguageMatch::Unrecognized
+        );
+    }
+
+    #[test]
+    fn resolves_files_on_disk_by_shebang() {
+        let dir = tempfile::tempdir().unwrap();
+        let script = dir.path().join("release");
+        std::fs::write(&script, "#!/usr/bin/env python3\nprint('hi')\n").unwrap();
+        let notes = dir.path().join("NOTES");
+        std::fs::write(&notes, "plain text\n").unwrap();
+
+        let none = BTreeMap::new();
+        assert_eq!(
+            resolve_file_language(&script, &none),
+            LanguageMatch::Supported("py")
+        );
+        assert_eq!(
+            resolve_file_language(&notes, &none),
+            LanguageMatch::Unrecognized
+        );
+    }
+}
@@ -0,0 +1,197 @@
+//! Canonical formatting of the doc comments valknut reads.
+//!
+//! Rules and documentation metrics treat an entity as documented when a
+//! comment sits directly above it (attributes and decorators aside). This
+//! module rewrites those comment blocks into one canonical shape without
+//! touching anything else in the file:
+//!
+//! - one space after the comment marker (`///Parses` becomes `/// Parses`),
+//!   except for tool directives such as `//go:generate` or `//nolint`;
+//! - no trailing whitespace on comment lines;
+//! - no blank lines between an explicit doc comment (`///` or a `/** */`
+//!   block) and the item it documents.
+
+use std::collections::BTreeSet;
+use std::path::Path;
+
+use crate::core::errors::Result;
+use crate::lang::registry::{adapter_for_file, language_key_for_path};
+
+/// Line prefixes of attributes and decorators between a comment and its item.
+const ATTRIBUTE_PREFIXES: &[&str] = &["#[", "@"];
+
+/// Comment markers of the C-family languages, longest first.
+const SLASH_MARKERS: &[&str] = &["///", "//", "*"];
+
+/// Comment markers of hash-commented languages.
+const HASH_MARKERS: &[&str] = &["#"];
+
+/// Directive words that must stay glued to their marker.
+const DIRECTIVE_WORDS: &[&str] = &["nolint", "export", "extern", "line", "noinspection"];
+
+/// Reformat the doc comments in `source`, returning `None` when the file is
+/// already canonical or its language is not supported.
+pub fn format_doc_comments(path: &Path, source: &str) -> Result<Option<String>> {
+    let Some(language) = language_key_for_path(path) else {
+        return Ok(None);
+    };
+    let Ok(mut adapter) = adapter_for_file(path) else {
+        return Ok(None);
+    };
+    let index = adapter.parse_source(source, &path.to_string_lossy())?;
+    let markers = if language == "py" {
+        HASH_MARKERS
+    } else {
+        SLASH_MARKERS
+    };
+
+    let mut lines: Vec<String> = source.split('\n').map(str::to_string).collect();
+    let mut removed = BTreeSet::new();
+    let start_lines: BTreeSet<usize> = index
+        .entities
+        .values()
+        .map(|entity| entity.location.start_line)
+        .collect();
+    for start_line in start_lines {
+        removed.extend(format_block_above(&mut lines, start_line, markers));
+    }
+
+    let formatted = lines
+        .into_iter()
+        .enumerate()
+        .filter(|(index, _)| !removed.contains(index))
+        .map(|(_, line)| line)
+        .collect::<Vec<_>>()
+        .join("\n");
+    Ok((formatted != source).then_some(formatted))
+}
+
+/// Canonicalize the comment block above the entity starting at `start_line`
+/// (1-based) and return the indices of blank lines to drop.
+fn format_block_above(lines: &mut [String], start_line: usize, markers: &[&str]) -> Vec<usize> {
+    let mut index = start_line.saturating_sub(1);
+    while index > 0 && is_attribute(&lines[index - 1]) {
+        index -= 1;
+    }
+
+    let mut blanks = Vec::new();
+    while index > 0 && lines[index - 1].trim().is_empty() {
+        index -= 1;
+        blanks.push(index);
+    }
+    let Some(last) = index.checked_sub(1) else {
+        return Vec::new();
+    };
+    let last_line = lines[last].trim();
+    let explicit_doc = last_line.starts_with("///") || last_line.ends_with("*/");
+    if !is_comment(last_line, markers) && !last_line.ends_with("*/") {
+        return Vec::new();
+    }
+
+    let mut first = last;
+    while first > 0 && is_comment(lines[first - 1].trim(), markers) {
+        first -= 1;
+    }
+    for line in &mut lines[first..=last] {
+        *line = canonical_comment_line(line, markers);
+    }
+
+    if explicit_doc {
+        blanks
+    } else {
+        Vec::new()
+    }
+}
+
+/// Rewrite one comment line: trailing whitespace removed and a space after
+/// the marker when the text starts right after it (or after a tab).
+/// Deeper indentation, as in doc examples, is kept.
+fn canonical_comment_line(line: &str, markers: &[&str]) -> String {
+    let carriage_return = if line.ends_with('\r') { "\r" } else { "" };
+    let line = line.trim_end();
+    let body = line.trim_start();
+    let indent = &line[..line.len() - body.len()];
+    let Some(marker) = markers.iter().find(|marker| body.starts_with(**marker)) else {
+        return format!("{line}{carriage_return}");
+    };
+    let text = &body[marker.len()..];
+    let trimmed = text.trim_start_matches([' ', '\t']);
+    let needs_space =
+        trimmed.chars().next().is_some_and(char::is_alphanumeric) && !is_directive(trimmed);
+    let text = if needs_space && !text.starts_with(' ') {
+        format!(" {trimmed}")
+    } else {
+        text.to_string()
+    };
+    format!("{indent}{marker}{text}{carriage_return}")
+}
+
+/// Whether a trimmed line is a comment under `markers`.
+fn is_comment(line: &str, markers: &[&str]) -> bool {
+    line.starts_with("/*") || markers.iter().any(|marker| line.starts_with(marker))
+}
+
+/// Whether a line is an attribute or decorator.
+fn is_attribute(line: &str) -> bool {
+    let line = line.trim();
+    ATTRIBUTE_PREFIXES
+        .iter()
+        .any(|prefix| line.starts_with(prefix))
+}
+
+/// Whether comment text is a tool directive (`go:generate`, `nolint`, ...).
+fn is_directive(text: &str) -> bool {
+    let word: String = text
+        .chars()
+        .take_while(|c| c.is_ascii_alphanumeric() || *c == '_' || *c == '-')
+        .collect();
+    text[word.len()..].starts_with(':') || DIRECTIVE_WORDS.contains(&word.as_str())
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn format(path: &str, source: &str) -> String {
+        format_doc_comments(Path::new(path), source)
+            .unwrap()
+            .unwrap_or_else(|| source.to_string())
+    }
+
+    #[test]
+    fn adds_marker_space_and_strips_trailing_whitespace() {
+        let source = "///Parses input.   \n///\tSecond line\npub fn parse() {}\n";
+        assert_eq!(
+            format("lib.rs", source),
+            "/// Parses input.\n/// Second line\npub fn parse() {}\n"
+        );
+    }
+
+    #[test]
+    fn joins_explicit_doc_comments_to_their_item() {
+        let source = "/// Entry point.\n\n#[inline]\npub fn run() {}\n";
+        assert_eq!(
+            format("lib.rs", source),
+            "/// Entry point.\n#[inline]\npub fn run() {}\n"
+        );
+    }
+
+    #[test]
+    fn leaves_detached_plain_comments_and_directives_alone() {
+        let go = "package main\n\n//go:generate stringer -type=Kind\n//nolint:gocyclo\nfunc Run() {}\n\n// Section.\n\nfunc Other() {}\n";
+        assert_eq!(format_doc_comments(Path::new("main.go"), go).unwrap(), None);
+    }
+
+    #[test]
+    fn formats_python_comments_and_skips_unknown_languages() {
+        let python = "#Loads the config.\n@cache\ndef load():\n    pass\n";
+        assert_eq!(
+            format("config.py", python),
+            "# Loads the config.\n@cache\ndef load():\n    pass\n"
+        );
+        assert_eq!(
+            format_doc_comments(Path::new("notes.txt"), "///x\n").unwrap(),
+            None
+        );
+    }
+}
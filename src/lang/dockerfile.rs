@@ -0,0 +1,935 @@
+//! Built-in Dockerfile parser and container build graph.
+//!
+//! [`parse_dockerfile`] splits a Dockerfile into its build stages: the image or
+//! earlier stage each `FROM` builds on, the files `COPY` and `ADD` bring in
+//! from the build context or from another stage (`--from`), the `RUN`
+//! commands, and the variables `ENV` and `ARG` declare. Line continuations,
+//! comments, the `escape` parser directive and BuildKit heredocs are
+//! understood, and global `ARG` defaults are substituted into `FROM` lines.
+//!
+//! [`DockerfileParser`] reports the stages as plugin entities with language
+//! `"dockerfile"`. [`DockerGraph`] joins the stages of every Dockerfile under
+//! a set of roots into a `depends_on` graph between stages, with the external
+//! images each stage pulls in listed separately.
+
+use std::collections::{BTreeMap, BTreeSet};
+use std::path::{Path, PathBuf};
+
+use ignore::WalkBuilder;
+use serde::{Deserialize, Serialize};
+use tracing::warn;
+
+use crate::core::errors::{Result, ValknutError};
+use crate::core::pipeline::discovery::IGNORE_FILE_NAME;
+use crate::lang::common::EntityKind;
+use crate::lang::plugins::{decode_source, FileAnalysis, LanguageParser, PluginEntity};
+
+/// Language name reported for Dockerfiles.
+pub const DOCKERFILE_LANGUAGE: &str = "dockerfile";
+
+/// File names that are Dockerfiles on their own or with a `.<variant>` suffix.
+const DOCKERFILE_NAMES: &[&str] = &["dockerfile", "containerfile"];
+
+/// Whether `path` names a Dockerfile: `Dockerfile`, `Containerfile`,
+/// `Dockerfile.<variant>` or `<name>.dockerfile`.
+pub fn is_dockerfile(path: &Path) -> bool {
+    let Some(name) = path.file_name().and_then(|name| name.to_str()) else {
+        return false;
+    };
+    let name = name.to_ascii_lowercase();
+    if name.ends_with(".dockerignore") {
+        return false;
+    }
+    name.ends_with(".dockerfile")
+        || DOCKERFILE_NAMES.iter().any(|base| {
+            name == *base
+                || name
+                    .strip_prefix(base)
+                    .is_some_and(|rest| rest.starts_with('.'))
+        })
+}
+
+/// Parser for Dockerfiles.
+#[derive(Debug, Clone)]
+pub struct DockerfileParser {
+    extensions: Vec<String>,
+}
+
+/// Default implementation for [`DockerfileParser`].
+impl Default for DockerfileParser {
+    /// Returns a parser for `.dockerfile` files; the plugin registry also
+    /// routes `Dockerfile` and `Dockerfile.<variant>` here.
+    fn default() -> Self {
+        Self {
+            extensions: vec![DOCKERFILE_LANGUAGE.to_string()],
+        }
+    }
+}
+
+/// [`LanguageParser`] implementation for [`DockerfileParser`].
+impl LanguageParser for DockerfileParser {
+    fn name(&self) -> &str {
+        DOCKERFILE_LANGUAGE
+    }
+
+    fn extensions(&self) -> &[String] {
+        &self.extensions
+    }
+
+    fn parse(&self, path: &Path, source: &[u8]) -> Result<FileAnalysis> {
+        let source = decode_source(DOCKERFILE_LANGUAGE, path, source)?;
+        Ok(stages_to_analysis(&parse_dockerfile(source)))
+    }
+}
+
+/// What a stage reads files from.
+#[derive(Debug, Clone, PartialEq, Eq, PartialOrd, Ord, Serialize, Deserialize)]
+#[serde(tag = "kind", content = "name", rename_all = "snake_case")]
+pub enum BuildSource {
+    /// An earlier stage of the same Dockerfile, by name.
+    Stage(String),
+    /// An image reference such as `golang:1.22` or `scratch`.
+    Image(String),
+}
+
+/// Accessors for [`BuildSource`].
+impl BuildSource {
+    /// Stage name or image reference.
+    pub fn name(&self) -> &str {
+        match self {
+            Self::Stage(name) | Self::Image(name) => name,
+        }
+    }
+}
+
+/// Files brought into a stage by `COPY` or `ADD`.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct DockerCopy {
+    /// `COPY` or `ADD`.
+    pub instruction: String,
+    /// 1-based line of the instruction.
+    pub line: usize,
+    /// Source paths (or `ADD` URLs) as written; heredoc files are left out.
+    pub sources: Vec<String>,
+    /// Destination path inside the stage.
+    pub destination: String,
+    /// Stage or image copied out of with `--from`; `None` for the build context.
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub from: Option<BuildSource>,
+}
+
+/// One `FROM` section of a Dockerfile.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct DockerStage {
+    /// Name given with `AS` (lower-cased, as Docker matches it), or the
+    /// stage's index when unnamed.
+    pub name: String,
+    /// 0-based position of the stage in the Dockerfile.
+    pub index: usize,
+    /// What the stage is built `FROM`, with global `ARG` defaults substituted.
+    pub base: BuildSource,
+    /// `--platform` of the `FROM` line, as written.
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub platform: Option<String>,
+    /// 1-based line of the `FROM` instruction.
+    pub start_line: usize,
+    /// 1-based last line of the stage's last instruction.
+    pub end_line: usize,
+    /// `COPY` and `ADD` instructions, in order.
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub copies: Vec<DockerCopy>,
+    /// `RUN` commands without their flags; heredoc bodies follow on new lines.
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub run: Vec<String>,
+    /// Stages and images mounted with `RUN --mount=...,from=<source>`.
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub mounts: Vec<BuildSource>,
+    /// Variables set by `ENV`; later assignments win.
+    #[serde(default, skip_serializing_if = "BTreeMap::is_empty")]
+    pub env: BTreeMap<String, String>,
+    /// Build arguments declared by `ARG`, with their defaults.
+    #[serde(default, skip_serializing_if = "BTreeMap::is_empty")]
+    pub args: BTreeMap<String, Option<String>>,
+}
+
+/// Accessors for [`DockerStage`].
+impl DockerStage {
+    /// Everything the stage reads from: its base, `COPY --from` sources and
+    /// `RUN` mounts.
+    pub fn sources(&self) -> BTreeSet<&BuildSource> {
+        std::iter::once(&self.base)
+            .chain(self.copies.iter().filter_map(|copy| copy.from.as_ref()))
+            .chain(&self.mounts)
+            .collect()
+    }
+
+    /// The `FROM` line the stage was declared with, normalised.
+    pub fn signature(&self) -> String {
+        let mut signature = String::from("FROM ");
+        if let Some(platform) = &self.platform {
+            signature.push_str(&format!("--platform={platform} "));
+        }
+        signature.push_str(self.base.name());
+        if self.name != self.index.to_string() {
+            signature.push_str(&format!(" AS {}", self.name));
+        }
+        signature
+    }
+}
+
+/// Extract the build stages of one Dockerfile.
+///
+/// Instructions before the first `FROM` other than `ARG` are ignored, as are
+/// instructions that do not shape the build graph (`WORKDIR`, `CMD`, ...).
+pub fn parse_dockerfile(source: &str) -> Vec<DockerStage> {
+    let mut stages: Vec<DockerStage> = Vec::new();
+    let mut global_args = BTreeMap::new();
+
+    for instruction in instructions(source) {
+        if instruction.keyword == "FROM" {
+            if let Some(stage) = from_stage(&instruction, &stages, &global_args) {
+                stages.push(stage);
+            }
+            continue;
+        }
+        let Some((stage, earlier)) = stages.split_last_mut() else {
+            if instruction.keyword == "ARG" {
+                global_args.extend(parse_args(&instruction.arguments));
+            }
+            continue;
+        };
+        stage.end_line = instruction.end_line;
+
+        match instruction.keyword.as_str() {
+            "COPY" | "ADD" => {
+                let first_line = instruction.arguments.lines().next().unwrap_or_default();
+                let (flags, rest) = split_flags(first_line);
+                let mut paths = argument_list(rest);
+                let Some(destination) = paths.pop() else {
+                    continue;
+                };
+                paths.retain(|path| !path.starts_with("<<"));
+                stage.copies.push(DockerCopy {
+                    instruction: instruction.keyword.clone(),
+                    line: instruction.line,
+                    sources: paths,
+                    destination,
+                    from: flag_value(&flags, "from").map(|from| resolve(from, earlier, true)),
+                });
+            }
+            "RUN" => {
+                let (flags, command) = split_flags(&instruction.arguments);
+                for (name, value) in &flags {
+                    if name != "mount" {
+                        continue;
+                    }
+                    stage.mounts.extend(
+                        value
+                            .split(',')
+                            .filter_map(|option| option.strip_prefix("from="))
+                            .map(|from| resolve(from, earlier, true)),
+                    );
+                }
+                let command = if command.starts_with('[') {
+                    argument_list(command).join(" ")
+                } else {
+                    command.to_string()
+                };
+                stage.run.push(command);
+            }
+            "ENV" => stage.env.extend(parse_env(&instruction.arguments)),
+            "ARG" => stage.args.extend(parse_args(&instruction.arguments)),
+            _ => {}
+        }
+    }
+    stages
+}
+
+/// Report `stages` as plugin entities: one `Module` per stage, with
+/// `block_type` `stage`, its `ENV` names as `attributes`, the stages and
+/// images it reads from in `depends_on` and its `FROM` line as `signature`.
+/// External images are the file's `imports`.
+pub fn stages_to_analysis(stages: &[DockerStage]) -> FileAnalysis {
+    let mut entities = Vec::with_capacity(stages.len());
+    let mut images = BTreeSet::new();
+    for stage in stages {
+        let sources = stage.sources();
+        for source in &sources {
+            if let BuildSource::Image(image) = source {
+                images.insert(image.clone());
+            }
+        }
+        let depends_on: BTreeSet<String> = sources
+            .iter()
+            .map(|source| source.name().to_string())
+            .collect();
+        entities.push(PluginEntity {
+            name: stage.name.clone(),
+            kind: EntityKind::Module,
+            start_line: stage.start_line,
+            end_line: stage.end_line,
+            parent: None,
+            block_type: Some("stage".to_string()),
+            attributes: stage.env.keys().cloned().collect(),
+            depends_on: depends_on.into_iter().collect(),
+            signature: Some(stage.signature()),
+        });
+    }
+
+    FileAnalysis {
+        language: Some(DOCKERFILE_LANGUAGE.to_string()),
+        entities,
+        imports: images.into_iter().collect(),
+        tables_referenced: Vec::new(),
+    }
+}
+
+/// Build a stage from its `FROM` instruction; `None` when no image is named.
+fn from_stage(
+    instruction: &Instruction,
+    earlier: &[DockerStage],
+    global_args: &BTreeMap<String, Option<String>>,
+) -> Option<DockerStage> {
+    let (flags, rest) = split_flags(&instruction.arguments);
+    let words = words(rest);
+    let base = expand(words.first()?, global_args);
+    let alias = match words.get(1..) {
+        Some([keyword, name, ..]) if keyword.eq_ignore_ascii_case("as") => {
+            Some(name.to_ascii_lowercase())
+        }
+        _ => None,
+    };
+    let index = earlier.len();
+
+    Some(DockerStage {
+        name: alias.unwrap_or_else(|| index.to_string()),
+        index,
+        base: resolve(&base, earlier, false),
+        platform: flag_value(&flags, "platform").map(str::to_string),
+        start_line: instruction.line,
+        end_line: instruction.end_line,
+        copies: Vec::new(),
+        run: Vec::new(),
+        mounts: Vec::new(),
+        env: BTreeMap::new(),
+        args: BTreeMap::new(),
+    })
+}
+
+/// The earlier stage `reference` names, or the image it names otherwise.
+/// `--from` may also name a stage by index; `FROM` may not.
+fn resolve(reference: &str, earlier: &[DockerStage], by_index: bool) -> BuildSource {
+    let name = reference.to_ascii_lowercase();
+    earlier
+        .iter()
+        .find(|stage| stage.name == name || (by_index && stage.index.to_string() == reference))
+        .map_or_else(
+            || BuildSource::Image(reference.to_string()),
+            |stage| BuildSource::Stage(stage.name.clone()),
+        )
+}
+
+/// An instruction with its continuation lines joined.
+#[derive(Debug, Clone, PartialEq)]
+struct Instruction {
+    /// Upper-cased instruction keyword (`FROM`, `RUN`, ...).
+    keyword: String,
+    /// Text after the keyword; heredoc bodies follow on new lines.
+    arguments: String,
+    /// 1-based first line.
+    line: usize,
+    /// 1-based last line, including continuations and heredocs.
+    end_line: usize,
+}
+
+/// Split `source` into instructions, dropping comments and blank lines.
+fn instructions(source: &str) -> Vec<Instruction> {
+    let lines: Vec<&str> = source.lines().collect();
+    let escape = escape_character(&lines);
+    let mut instructions = Vec::new();
+    let mut index = 0;
+
+    while index < lines.len() {
+        let first = lines[index].trim();
+        if first.is_empty() || first.starts_with('#') {
+            index += 1;
+            continue;
+        }
+        let line = index + 1;
+        let mut text = String::new();
+        while index < lines.len() {
+            let current = lines[index].trim_end();
+            index += 1;
+            let trimmed = current.trim_start();
+            if !text.is_empty() {
+                // Comments and blank lines inside a continuation are dropped.
+                if trimmed.is_empty() || trimmed.starts_with('#') {
+                    continue;
+                }
+                if trimmed.len() < current.len() && !text.ends_with(char::is_whitespace) {
+                    text.push(' ');
+                }
+            }
+            match trimmed.strip_suffix(escape) {
+                Some(head) => text.push_str(head),
+                None => {
+                    text.push_str(trimmed);
+                    break;
+                }
+            }
+        }
+
+        let (keyword, arguments) = text
+            .split_once(char::is_whitespace)
+            .unwrap_or((text.as_str(), ""));
+        let keyword = keyword.to_ascii_uppercase();
+        let mut arguments = arguments.trim().to_string();
+        if matches!(keyword.as_str(), "RUN" | "COPY" | "ADD") {
+            for marker in heredoc_markers(&arguments) {
+                while index < lines.len() {
+                    let body = lines[index];
+                    index += 1;
+                    if body.trim() == marker {
+                        break;
+                    }
+                    arguments.push('\n');
+                    arguments.push_str(body);
+                }
+            }
+        }
+
+        instructions.push(Instruction {
+            keyword,
+            arguments,
+            line,
+            end_line: index,
+        });
+    }
+    instructions
+}
+
+/// Line-continuation character from a leading `# escape=` parser directive.
+fn escape_character(lines: &[&str]) -> char {
+    for line in lines {
+        let Some((key, value)) = line
+            .trim()
+            .strip_prefix('#')
+            .and_then(|directive| directive.split_once('='))
+        else {
+            break;
+        };
+        let key = key.trim();
+        if key.eq_ignore_ascii_case("escape") {
+            return value.trim().chars().next().unwrap_or('\\');
+        }
+        if !key.chars().all(|c| c.is_ascii_alphabetic()) {
+            break;
+        }
+    }
+    '\\'
+}
+
+/// Terminators of the `<<EOF` / `<<-"EOF"` heredocs an instruction opens.
+fn heredoc_markers(arguments: &str) -> Vec<String> {
+    let mut markers = Vec::new();
+    let mut rest = arguments;
+    while let Some(position) = rest.find("<<") {
+        rest = &rest[position + 2..];
+        if rest.starts_with('<') {
+            // `<<<` is a shell here-string, not a heredoc.
+            rest = rest.trim_start_matches('<');
+            continue;
+        }
+        let marker: String = rest
+            .trim_start_matches('-')
+            .trim_start_matches(['"', '\''])
+            .chars()
+            .take_while(|c| c.is_alphanumeric() || *c == '_')
+            .collect();
+        if !marker.is_empty() {
+            markers.push(marker);
+        }
+    }
+    markers
+}
+
+/// Leading `--name=value` flags of an instruction and the text after them.
+fn split_flags(arguments: &str) -> (Vec<(String, String)>, &str) {
+    let mut flags = Vec::new();
+    let mut rest = arguments.trim_start();
+    while let Some(flag) = rest.strip_prefix("--") {
+        let end = flag.find(char::is_whitespace).unwrap_or(flag.len());
+        let (name, value) = flag[..end].split_once('=').unwrap_or((&flag[..end], ""));
+        flags.push((name.to_ascii_lowercase(), value.to_string()));
+        rest = flag[end..].trim_start();
+    }
+    (flags, rest)
+}
+
+/// Value of the flag called `name`, if present.
+fn flag_value<'a>(flags: &'a [(String, String)], name: &str) -> Option<&'a str> {
+    flags
+        .iter()
+        .find(|(flag, _)| flag == name)
+        .map(|(_, value)| value.as_str())
+}
+
+/// Arguments in JSON exec form (`["a", "b"]`) or as shell words.
+fn argument_list(text: &str) -> Vec<String> {
+    if text.starts_with('[') {
+        if let Ok(list) = serde_json::from_str::<Vec<String>>(text) {
+            return list;
+        }
+    }
+    words(text)
+}
+
+/// `ENV KEY=value ...` pairs, or the legacy `ENV KEY value with spaces`.
+fn parse_env(arguments: &str) -> Vec<(String, String)> {
+    let words = words(arguments);
+    match words.first() {
+        Some(first) if first.contains('=') => words
+            .iter()
+            .filter_map(|word| word.split_once('='))
+            .map(|(key, value)| (key.to_string(), value.to_string()))
+            .collect(),
+        Some(_) => {
+            let (key, value) = arguments
+                .split_once(char::is_whitespace)
+                .unwrap_or((arguments, ""));
+            vec![(key.to_string(), value.trim().to_string())]
+        }
+        None => Vec::new(),
+    }
+}
+
+/// `ARG NAME[=default] ...` declarations.
+fn parse_args(arguments: &str) -> Vec<(String, Option<String>)> {
+    words(arguments)
+        .into_iter()
+        .map(|word| match word.split_once('=') {
+            Some((name, default)) => (name.to_string(), Some(default.to_string())),
+            None => (word, None),
+        })
+        .collect()
+}
+
+/// Substitute `$NAME`, `${NAME}` and `${NAME:-default}` with build argument
+/// defaults. References to unknown or valueless arguments are left as written.
+fn expand(text: &str, args: &BTreeMap<String, Option<String>>) -> String {
+    let mut expanded = String::new();
+    let mut rest = text;
+    while let Some(position) = rest.find('$') {
+        expanded.push_str(&rest[..position]);
+        let after = &rest[position + 1..];
+        let (name, fallback, consumed) = match after.strip_prefix('{') {
+            Some(body) => match body.find('}') {
+                Some(end) => {
+                    let (name, fallback) = match body[..end].split_once(":-") {
+                        Some((name, fallback)) => (name, Some(fallback)),
+                        None => (&body[..end], None),
+                    };
+                    (name, fallback, end + 2)
+                }
+                None => ("", None, 0),
+            },
+            None => {
+                let end = after
+                    .find(|c: char| !(c.is_alphanumeric() || c == '_'))
+                    .unwrap_or(after.len());
+                (&after[..end], None, end)
+            }
+        };
+        let value = args
+            .get(name)
+            .cloned()
+            .flatten()
+            .or_else(|| fallback.map(str::to_string));
+        match value {
+            Some(value) if !name.is_empty() => expanded.push_str(&value),
+            _ => expanded.push_str(&rest[position..position + 1 + consumed]),
+        }
+        rest = &rest[position + 1 + consumed..];
+    }
+    expanded.push_str(rest);
+    expanded
+}
+
+/// Split shell-style words, removing quotes and backslash escapes.
+fn words(text: &str) -> Vec<String> {
+    let mut words = Vec::new();
+    let mut current = String::new();
+    let mut in_word = false;
+    let mut quote: Option<char> = None;
+    let mut chars = text.chars();
+    while let Some(c) = chars.next() {
+        match (quote, c) {
+            (Some(open), c) if c == open => quote = None,
+            (None, '"' | '\'') => {
+                quote = Some(c);
+                in_word = true;
+            }
+            (None | Some('"'), '\\') => {
+                current.extend(chars.next());
+                in_word = true;
+            }
+            (None, c) if c.is_whitespace() => {
+                if in_word {
+                    words.push(std::mem::take(&mut current));
+                    in_word = false;
+                }
+            }
+            (_, c) => {
+                current.push(c);
+                in_word = true;
+            }
+        }
+    }
+    if in_word {
+        words.push(current);
+    }
+    words
+}
+
+/// Stage-level build graph of the Dockerfiles under a set of roots.
+///
+/// Stages are keyed `<dockerfile>#<stage>`, the Dockerfile path being
+/// relative to its root.
+#[derive(Debug, Clone, Default, PartialEq, Eq, Serialize, Deserialize)]
+pub struct DockerGraph {
+    /// Dockerfiles by path relative to their root, with their stages in order
+    pub files: BTreeMap<String, Vec<DockerStage>>,
+    /// Edges from a stage to the stages it builds on, copies out of or mounts
+    pub depends_on: BTreeMap<String, BTreeSet<String>>,
+    /// External images each stage builds on, copies out of or mounts
+    pub images: BTreeMap<String, BTreeSet<String>>,
+}
+
+/// Construction and export methods for [`DockerGraph`].
+impl DockerGraph {
+    /// Parse every Dockerfile under `roots`.
+    pub fn from_paths(roots: &[PathBuf]) -> Result<Self> {
+        let mut dockerfiles = Vec::new();
+        for root in roots {
+            let mut builder = WalkBuilder::new(root);
+            builder.add_custom_ignore_filename(IGNORE_FILE_NAME);
+            for entry in builder.build().filter_map(|entry| entry.ok()) {
+                let path = entry.path();
+                if !path.is_file() || !is_dockerfile(path) {
+                    continue;
+                }
+                let source = std::fs::read(path).map_err(|e| {
+                    ValknutError::io(format!("Failed to read {}", path.display()), e)
+                })?;
+                let relative = path.strip_prefix(root).unwrap_or(path);
+                let relative = if relative.as_os_str().is_empty() {
+                    Path::new(path.file_name().unwrap_or_default())
+                } else {
+                    relative
+                };
+                match decode_source(DOCKERFILE_LANGUAGE, path, &source) {
+                    Ok(source) => {
+                        dockerfiles.push((relative.to_path_buf(), parse_dockerfile(source)))
+                    }
+                    Err(err) => warn!("Skipping {}: {}", path.display(), err),
+                }
+            }
+        }
+        Ok(Self::from_dockerfiles(dockerfiles))
+    }
+
+    /// Build the graph from already parsed Dockerfiles, keyed by their
+    /// root-relative paths.
+    pub fn from_dockerfiles(dockerfiles: Vec<(PathBuf, Vec<DockerStage>)>) -> Self {
+        let mut graph = Self::default();
+        for (path, stages) in dockerfiles {
+            let file = path.to_string_lossy().replace('\\', "/");
+            for stage in &stages {
+                let key = stage_key(&file, &stage.name);
+                for source in stage.sources() {
+                    match source {
+                        BuildSource::Stage(name) => graph
+                            .depends_on
+                            .entry(key.clone())
+                            .or_default()
+                            .insert(stage_key(&file, name)),
+                        BuildSource::Image(image) => graph
+                            .images
+                            .entry(key.clone())
+                            .or_default()
+                            .insert(image.clone()),
+                    };
+                }
+            }
+            graph.files.insert(file, stages);
+        }
+        graph
+    }
+
+    /// Whether no Dockerfiles were found.
+    pub fn is_empty(&self) -> bool {
+        self.files.is_empty()
+    }
+
+    /// Total number of build stages.
+    pub fn stage_count(&self) -> usize {
+        self.files.values().map(Vec::len).sum()
+    }
+
+    /// Total number of stage-to-stage edges.
+    pub fn edge_count(&self) -> usize {
+        self.depends_on.values().map(BTreeSet::len).sum()
+    }
+
+    /// Render the graph in Graphviz DOT format.
+    ///
+    /// Stages are boxes and external images ellipses. An edge to the stage or
+    /// image a stage is built `FROM` is solid; `COPY --from` and mount edges
+    /// are dashed.
+    pub fn to_dot(&self) -> String {
+        let mut dot = String::from("digraph docker {\n    rankdir=LR;\n");
+        let images: BTreeSet<&String> = self.images.values().flatten().collect();
+        for image in images {
+            dot.push_str(&format!("    \"{image}\" [shape=ellipse];\n"));
+        }
+        for (file, stages) in &self.files {
+            for stage in stages {
+                dot.push_str(&format!(
+                    "    \"{}\" [shape=box];\n",
+                    stage_key(file, &stage.name)
+                ));
+            }
+        }
+        for (file, stages) in &self.files {
+            for stage in stages {
+                let key = stage_key(file, &stage.name);
+                let base = match &stage.base {
+                    BuildSource::Stage(name) => stage_key(file, name),
+                    BuildSource::Image(image) => image.clone(),
+                };
+                let targets = self
+                    .depends_on
+                    .get(&key)
+                    .into_iter()
+                    .chain(self.images.get(&key))
+                    .flatten();
+                for target in targets {
+                    let style = if *target == base {
+                        ""
+                    } else {
+                        " [style=dashed]"
+                    };
+                    dot.push_str(&format!("    \"{key}\" -> \"{target}\"{style};\n"));
+                }
+            }
+        }
+        dot.push_str("}\n");
+        dot
+    }
+
+    /// Render the graph as pretty-printed JSON.
+    pub fn to_json(&self) -> Result<String> {
+        serde_json::to_string_pretty(self)
+            .map_err(|e| ValknutError::internal(format!("Failed to serialize Docker graph: {e}")))
+    }
+}
+
+/// Graph key of a stage.
+fn stage_key(file: &str, stage: &str) -> String {
+    format!("{file}#{stage}")
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use std::fs;
+    use tempfile::tempdir;
+
+    const DOCKERFILE: &str = r#"# syntax=docker/dockerfile:1
+ARG GO_VERSION=1.22
+ARG RUNTIME
+
+FROM --platform=$BUILDPLATFORM golang:${GO_VERSION}-alpine AS Builder
+WORKDIR /src
+COPY go.mod go.sum ./
+RUN --mount=type=cache,target=/go/pkg/mod \
+    go mod download
+COPY . .
+ENV CGO_ENABLED=0 GOFLAGS="-trimpath -mod=readonly"
+RUN go build -o /out/server ./cmd/server
+
+FROM node:20 AS assets
+# The UI bundle
+COPY ["web/package.json", "web/package-lock.json", "/web/"]
+RUN <<EOF
+npm ci
+npm run build
+EOF
+
+FROM ${RUNTIME:-gcr.io/distroless/static}
+ARG PORT=8080
+ENV PORT $PORT
+COPY --from=builder /out/server /server
+COPY --from=1 /web/dist /static
+COPY --from=nginx:1.25 /etc/nginx/mime.types /etc/
+ENTRYPOINT ["/server"]
+"#;
+
+    #[test]
+    fn extracts_stages_with_copies_runs_and_env() {
+        let stages = parse_dockerfile(DOCKERFILE);
+        let names: Vec<_> = stages.iter().map(|stage| stage.name.as_str()).collect();
+        assert_eq!(names, vec!["builder", "assets", "2"]);
+
+        let builder = &stages[0];
+        assert_eq!(
+            builder.base,
+            BuildSource::Image("golang:1.22-alpine".to_string())
+        );
+        assert_eq!(builder.platform.as_deref(), Some("$BUILDPLATFORM"));
+        assert_eq!((builder.start_line, builder.end_line), (5, 12));
+        assert_eq!(builder.copies[0].sources, vec!["go.mod", "go.sum"]);
+        assert_eq!(builder.copies[0].destination, "./");
+        assert!(builder.copies[0].from.is_none());
+        assert_eq!(
+            builder.run,
+            vec!["go mod download", "go build -o /out/server ./cmd/server"]
+        );
+        assert_eq!(builder.env["GOFLAGS"], "-trimpath -mod=readonly");
+        assert_eq!(
+            builder.signature(),
+            "FROM --platform=$BUILDPLATFORM golang:1.22-alpine AS builder"
+        );
+
+        let assets = &stages[1];
+        assert_eq!(
+            assets.copies[0].sources,
+            vec!["web/package.json", "web/package-lock.json"]
+        );
+        assert_eq!(assets.run, vec!["<<EOF\nnpm ci\nnpm run build"]);
+        assert_eq!(assets.end_line, 20);
+
+        let runtime = &stages[2];
+        assert_eq!(
+            runtime.base,
+            BuildSource::Image("gcr.io/distroless/static".to_string())
+        );
+        assert_eq!(runtime.env["PORT"], "$PORT");
+        assert_eq!(runtime.args["PORT"].as_deref(), Some("8080"));
+        let from: Vec<_> = runtime
+            .copies
+            .iter()
+            .map(|copy| copy.from.clone().unwrap())
+            .collect();
+        assert_eq!(
+            from,
+            vec![
+                BuildSource::Stage("builder".to_string()),
+                BuildSource::Stage("assets".to_string()),
+                BuildSource::Image("nginx:1.25".to_string()),
+            ]
+        );
+    }
+
+    #[test]
+    fn stages_become_plugin_entities() {
+        let analysis = stages_to_analysis(&parse_dockerfile(DOCKERFILE));
+        assert_eq!(analysis.language.as_deref(), Some("dockerfile"));
+        assert_eq!(
+            analysis.imports,
+            vec![
+                "gcr.io/distroless/static",
+                "golang:1.22-alpine",
+                "nginx:1.25",
+                "node:20"
+            ]
+        );
+        let runtime = &analysis.entities[2];
+        assert_eq!(runtime.kind, EntityKind::Module);
+        assert_eq!(runtime.block_type.as_deref(), Some("stage"));
+        assert_eq!(runtime.attributes, vec!["PORT"]);
+        assert_eq!(
+            runtime.depends_on,
+            vec![
+                "assets",
+                "builder",
+                "gcr.io/distroless/static",
+                "nginx:1.25"
+            ]
+        );
+        assert_eq!(
+            runtime.signature.as_deref(),
+            Some("FROM gcr.io/distroless/static")
+        );
+    }
+
+    #[test]
+    fn honours_escape_directive_and_stage_references() {
+        let source = "# escape=`\nFROM mcr.microsoft.com/windows/servercore AS base\nRUN powershell -Command `\n    Write-Host hi\nFROM base\nRUN --mount=type=bind,from=base,target=/b dir\n";
+        let stages = parse_dockerfile(source);
+        assert_eq!(stages[0].run, vec!["powershell -Command Write-Host hi"]);
+        assert_eq!(stages[1].base, BuildSource::Stage("base".to_string()));
+        assert_eq!(
+            stages[1].mounts,
+            vec![BuildSource::Stage("base".to_string())]
+        );
+        assert_eq!(stages[1].signature(), "FROM base");
+    }
+
+    #[test]
+    fn recognises_dockerfile_names() {
+        for name in [
+            "Dockerfile",
+            "Dockerfile.prod",
+            "api.dockerfile",
+            "Containerfile",
+        ] {
+            assert!(is_dockerfile(Path::new(name)), "{name}");
+        }
+        for name in [
+            "Dockerfile.dockerignore",
+            "dockerfiles.md",
+            "docker-compose.yml",
+        ] {
+            assert!(!is_dockerfile(Path::new(name)), "{name}");
+        }
+    }
+
+    #[test]
+    fn graph_links_stages_and_images_per_file() {
+        let tmp = tempdir().unwrap();
+        fs::create_dir(tmp.path().join("api")).unwrap();
+        fs::write(tmp.path().join("api/Dockerfile"), DOCKERFILE).unwrap();
+        fs::write(
+            tmp.path().join("worker.dockerfile"),
+            "FROM python:3.12-slim\nCOPY worker/ /app/\n",
+        )
+        .unwrap();
+
+        let graph = DockerGraph::from_paths(&[tmp.path().to_path_buf()]).unwrap();
+        assert_eq!(
+            graph.files.keys().collect::<Vec<_>>(),
+            vec!["api/Dockerfile", "worker.dockerfile"]
+        );
+        assert_eq!(graph.stage_count(), 4);
+        assert_eq!(
+            graph.depends_on["api/Dockerfile#2"],
+            BTreeSet::from([
+                "api/Dockerfile#assets".to_string(),
+                "api/Dockerfile#builder".to_string()
+            ])
+        );
+        assert_eq!(graph.edge_count(), 2);
+        assert_eq!(
+            graph.images["worker.dockerfile#0"],
+            BTreeSet::from(["python:3.12-slim".to_string()])
+        );
+
+        let dot = graph.to_dot();
+        assert!(dot.contains("\"node:20\" [shape=ellipse];"));
+        assert!(dot.contains("\"api/Dockerfile#builder\" [shape=box];"));
+        assert!(dot.contains("\"api/Dockerfile#builder\" -> \"golang:1.22-alpine\";"));
+        assert!(dot.contains("\"api/Dockerfile#2\" -> \"api/Dockerfile#builder\" [style=dashed];"));
+        assert!(graph.to_json().unwrap().contains("\"kind\": \"stage\""));
+    }
+}
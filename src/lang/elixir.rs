@@ -0,0 +1,1208 @@
+//! Built-in Elixir parser.
+//!
+//! [`ElixirParser`] reads `.ex` and `.exs` files into a [`FileAnalysis`] with
+//! language `"elixir"`:
+//!
+//! - `defmodule` blocks become `Module` entities (block type `defmodule`),
+//!   named by their nesting (`MyApp.Accounts.User`, with `__MODULE__`
+//!   expanded). Their `depends_on` lists the modules named by `use`,
+//!   `import`, `alias` and `require`, and their `attributes` are the
+//!   `defstruct` field names;
+//! - each `use`/`import`/`alias`/`require` is also a `Module` child whose
+//!   block type is the macro (`alias MyApp.{Repo, User}` yields one per
+//!   module), and the module names are reported as [`FileAnalysis::imports`];
+//! - `def`, `defp`, `defmacro` and `defmacrop` definitions become `Function`
+//!   entities named `Module.name/arity` that span all their clauses, with the
+//!   matching `@spec` (without the `@spec` prefix) as their `signature`;
+//! - every clause is a separate `FunctionClause` child named
+//!   `Module.name/arity#n` (1-based, in source order) whose `signature` is its
+//!   head including any guard, e.g. `fetch(%User{id: id}, opts) when is_list(opts)`.
+//!   Bodiless heads that only declare default arguments are not clauses.
+//!
+//! Files inside an umbrella app (a directory with its own `mix.exs` under the
+//! `apps_path` of an umbrella `mix.exs`) also report the app as a `Module`
+//! entity with block type `umbrella_app` spanning the whole file. It is named
+//! by the app's `app:` atom, lists the sibling apps it depends on
+//! (`in_umbrella: true`) in `depends_on`, and is the parent of the file's
+//! top-level modules.
+
+use std::collections::BTreeMap;
+use std::path::Path;
+
+use crate::core::errors::Result;
+use crate::lang::buffer_pool::source_chars;
+use crate::lang::common::{self, line_end, scan_number, scan_operator, EntityKind, Lexeme};
+use crate::lang::plugins::{decode_source, FileAnalysis, LanguageParser, PluginEntity};
+
+/// Language name reported for Elixir files.
+pub const ELIXIR_LANGUAGE: &str = "elixir";
+
+/// Macros that define a function clause.
+const DEFINITIONS: &[&str] = &["def", "defp", "defmacro", "defmacrop"];
+
+/// Macros that make another module's names available.
+const DIRECTIVES: &[&str] = &["use", "import", "alias", "require"];
+
+/// Multi-character operators, longest first.
+const OPERATORS: &[&str] = &[
+    "===", "!==", "\\\\", "::", "->", "<-", "=>", "|>", "<>", "<<", ">>", "==", "!=", "<=", ">=",
+    "&&", "||", "++", "--", "..", "=~",
+];
+
+/// Operators that continue an expression onto the next line when they start it.
+const LEADING_OPERATORS: &[&str] = &[
+    "|>", "|", "++", "--", "<>", "||", "&&", "==", "!=", "===", "!==", "=~", "..", "::", "->",
+    "=>", "=", "+", "*", "/", "<", ">", "<=", ">=", ".", ",",
+];
+
+/// Word operators that continue an expression across a line break.
+const WORD_OPERATORS: &[&str] = &["when", "and", "or", "in", "not"];
+
+/// Parser for Elixir source files.
+#[derive(Debug, Clone)]
+pub struct ElixirParser {
+    extensions: Vec<String>,
+}
+
+/// Default implementation for [`ElixirParser`].
+impl Default for ElixirParser {
+    /// Returns a parser for `.ex` and `.exs` files.
+    fn default() -> Self {
+        Self {
+            extensions: vec!["ex".to_string(), "exs".to_string()],
+        }
+    }
+}
+
+/// [`LanguageParser`] implementation for [`ElixirParser`].
+impl LanguageParser for ElixirParser {
+    fn name(&self) -> &str {
+        ELIXIR_LANGUAGE
+    }
+
+    fn extensions(&self) -> &[String] {
+        &self.extensions
+    }
+
+    fn parse(&self, path: &Path, source: &[u8]) -> Result<FileAnalysis> {
+        let source = decode_source(ELIXIR_LANGUAGE, path, source)?;
+        let mut analysis = parse_elixir(source);
+        if let Some(app) = UmbrellaApp::containing(path) {
+            app.adopt(&mut analysis, source.lines().count().max(1));
+        }
+        Ok(analysis)
+    }
+}
+
+/// Extract the modules, directives, structs and function clauses of one file.
+pub fn parse_elixir(source: &str) -> FileAnalysis {
+    let chars = source_chars(source);
+    let tokens = tokenize(&chars);
+    let mut reader = ElixirReader {
+        chars: &chars,
+        tokens: &tokens,
+        index: 0,
+        depth: 0,
+        blocks: Vec::new(),
+        modules: Vec::new(),
+        functions: BTreeMap::new(),
+        specs: BTreeMap::new(),
+        entities: Vec::new(),
+        imports: Vec::new(),
+    };
+    reader.file();
+    reader.finish()
+}
+
+/// `module.name`, or `name` outside any module.
+fn qualify(module: Option<&str>, name: &str) -> String {
+    match module {
+        Some(module) => format!("{module}.{name}"),
+        None => name.to_string(),
+    }
+}
+
+/// An entity whose `end` has not been read yet.
+struct OpenBlock {
+    /// Index of the entity in [`ElixirReader::entities`].
+    slot: usize,
+    /// Block depth inside the entity's `do`.
+    depth: usize,
+    /// Whether the block is a `defmodule`.
+    module: bool,
+}
+
+/// How a definition head ends.
+enum HeadEnd {
+    /// `do ... end` block; the cursor is on `do`.
+    Block,
+    /// `, do: expression`; the cursor is on the `do:` keyword.
+    Keyword,
+    /// No body (a default-argument head, or malformed input).
+    Bodiless,
+}
+
+/// Single-pass reader over the tokens of one file.
+struct ElixirReader<'a> {
+    chars: &'a [char],
+    tokens: &'a [Spanned],
+    index: usize,
+    /// Open `do`/`fn` blocks.
+    depth: usize,
+    /// Modules and clauses whose `end` is still ahead, innermost last.
+    blocks: Vec<OpenBlock>,
+    /// Names and entity slots of the enclosing modules, innermost last.
+    modules: Vec<(String, usize)>,
+    /// `Module.name/arity` -> (function entity slot, clause count).
+    functions: BTreeMap<String, (usize, usize)>,
+    /// `Module.name/arity` -> rendered `@spec`.
+    specs: BTreeMap<String, String>,
+    entities: Vec<PluginEntity>,
+    imports: Vec<String>,
+}
+
+/// Statement readers for [`ElixirReader`].
+impl<'a> ElixirReader<'a> {
+    /// Read tokens until the end of input.
+    fn file(&mut self) {
+        while let Some(token) = self.current() {
+            match token {
+                Token::Ident(word) if word == "defmodule" && self.at_statement_start() => {
+                    self.module()
+                }
+                Token::Ident(word)
+                    if DEFINITIONS.contains(&word.as_str()) && self.at_statement_start() =>
+                {
+                    self.definition()
+                }
+                Token::Ident(word)
+                    if DIRECTIVES.contains(&word.as_str()) && self.at_statement_start() =>
+                {
+                    self.directive()
+                }
+                Token::Ident(word) if word == "defstruct" && self.at_statement_start() => {
+                    self.defstruct()
+                }
+                Token::Punct(op) if op == "@" && self.ident_at(1) == Some("spec") => self.spec(),
+                Token::Ident(word) if word == "do" || word == "fn" => {
+                    self.depth += 1;
+                    self.index += 1;
+                }
+                Token::Ident(word) if word == "end" => self.close_block(),
+                _ => self.index += 1,
+            }
+        }
+    }
+
+    /// Read `defmodule Name do`.
+    fn module(&mut self) {
+        let start_line = self.line();
+        self.index += 1;
+        let Some(name) = self.take_ident() else {
+            return;
+        };
+        let full_name = match self.module_name() {
+            Some(outer) if !name.starts_with("__MODULE__") => format!("{outer}.{name}"),
+            _ => self.expand(&name),
+        };
+        let parent = self.module_name().map(str::to_string);
+        let slot = self.push(entity(
+            full_name.clone(),
+            EntityKind::Module,
+            "defmodule",
+            start_line,
+            start_line,
+            parent.as_deref(),
+        ));
+        match self.scan_head() {
+            HeadEnd::Block => {
+                self.index += 1;
+                self.depth += 1;
+                self.modules.push((full_name, slot));
+                self.blocks.push(OpenBlock {
+                    slot,
+                    depth: self.depth,
+                    module: true,
+                });
+            }
+            HeadEnd::Keyword => {
+                self.index += 1;
+                self.skip_expression();
+                self.entities[slot].end_line = self.previous_line();
+            }
+            HeadEnd::Bodiless => {}
+        }
+    }
+
+    /// Read one `def`/`defp`/`defmacro`/`defmacrop` clause.
+    fn definition(&mut self) {
+        let start_line = self.line();
+        let Some(Token::Ident(keyword)) = self.current() else {
+            return;
+        };
+        self.index += 1;
+        let Some(head_start) = self.tokens.get(self.index).map(|t| t.start) else {
+            return;
+        };
+        let Some(name) = self.take_ident() else {
+            return;
+        };
+        let arity = if self.adjacent_open_paren() {
+            self.count_args()
+        } else {
+            0
+        };
+        let end = self.scan_head();
+        let head_end = match end {
+            HeadEnd::Block => self.tokens[self.index - 1].end,
+            // Skip the `,` before `do:`
+            HeadEnd::Keyword => self.tokens[self.index - 2].end,
+            HeadEnd::Bodiless => return,
+        };
+        let head = self.text(head_start, head_end);
+
+        let key = qualify(self.module_name(), &format!("{name}/{arity}"));
+        let parent = self.module_name().map(str::to_string);
+        let (function_slot, clauses) = match self.functions.get(&key) {
+            Some(&(slot, clauses)) => (slot, clauses),
+            None => {
+                let slot = self.push(entity(
+                    key.clone(),
+                    EntityKind::Function,
+                    keyword,
+                    start_line,
+                    start_line,
+                    parent.as_deref(),
+                ));
+                (slot, 0)
+            }
+        };
+        self.functions
+            .insert(key.clone(), (function_slot, clauses + 1));
+        let mut clause = entity(
+            format!("{key}#{}", clauses + 1),
+            EntityKind::FunctionClause,
+            keyword,
+            start_line,
+            start_line,
+            Some(&key),
+        );
+        clause.signature = Some(head);
+        let slot = self.push(clause);
+
+        match end {
+            HeadEnd::Block => {
+                self.index += 1;
+                self.depth += 1;
+                self.blocks.push(OpenBlock {
+                    slot,
+                    depth: self.depth,
+                    module: false,
+                });
+            }
+            _ => {
+                self.index += 1;
+                self.skip_expression();
+                self.entities[slot].end_line = self.previous_line();
+            }
+        }
+    }
+
+    /// Read a `use`, `import`, `alias` or `require` directive.
+    fn directive(&mut self) {
+        let start_line = self.line();
+        let Some(Token::Ident(keyword)) = self.current() else {
+            return;
+        };
+        self.index += 1;
+        let base = match self.current() {
+            Some(Token::Ident(name)) => self.expand(name),
+            Some(Token::Atom(name)) => format!(":{name}"),
+            _ => return,
+        };
+        self.index += 1;
+
+        let mut names = Vec::new();
+        if self.punct_at(0) == Some(".") && self.punct_at(1) == Some("{") {
+            self.index += 2;
+            while let Some(token) = self.current() {
+                match token {
+                    Token::Ident(name) => names.push(format!("{base}.{name}")),
+                    Token::Punct(op) if op == "," => {}
+                    Token::Punct(op) if op == "}" => {
+                        self.index += 1;
+                        break;
+                    }
+                    _ => break,
+                }
+                self.index += 1;
+            }
+        } else {
+            names.push(base);
+        }
+        self.skip_expression();
+        let end_line = self.previous_line();
+
+        let module = self
+            .modules
+            .last()
+            .map(|(name, slot)| (name.clone(), *slot));
+        for name in names {
+            self.push(entity(
+                name.clone(),
+                EntityKind::Module,
+                keyword,
+                start_line,
+                end_line,
+                module.as_ref().map(|(module, _)| module.as_str()),
+            ));
+            if let Some((_, slot)) = &module {
+                self.entities[*slot].depends_on.push(name.clone());
+            }
+            if !self.imports.contains(&name) {
+                self.imports.push(name);
+            }
+        }
+    }
+
+    /// Read `defstruct` and record its field names on the enclosing module.
+    fn defstruct(&mut self) {
+        self.index += 1;
+        let start = self.index;
+        self.skip_expression();
+
+        let mut fields = Vec::new();
+        let mut nesting = 0usize;
+        let mut field_depth = 0usize;
+        for (offset, spanned) in self.tokens[start..self.index].iter().enumerate() {
+            let opens_list = matches!(&spanned.kind, Token::Punct(op) if op == "(" || op == "[");
+            if offset == field_depth && opens_list {
+                // `defstruct [..]`, `defstruct([..])`
+                field_depth += 1;
+            }
+            let previous = offset
+                .checked_sub(1)
+                .map(|offset| &self.tokens[start + offset].kind);
+            let after_separator = match previous {
+                None => true,
+                Some(Token::Punct(op)) => op == "," || op == "(" || op == "[",
+                _ => false,
+            };
+            match &spanned.kind {
+                Token::Atom(name) | Token::Keyword(name)
+                    if nesting == field_depth && after_separator =>
+                {
+                    fields.push(name.clone());
+                }
+                Token::Punct(op) if matches!(op.as_str(), "(" | "[" | "{" | "<<") => nesting += 1,
+                Token::Punct(op) if matches!(op.as_str(), ")" | "]" | "}" | ">>") => {
+                    nesting = nesting.saturating_sub(1)
+                }
+                _ => {}
+            }
+        }
+
+        if let Some(&(_, slot)) = self.modules.last() {
+            self.entities[slot].attributes.extend(fields);
+        }
+    }
+
+    /// Read `@spec name(args) :: result` and remember it for `name/arity`.
+    fn spec(&mut self) {
+        self.index += 2;
+        let Some(start) = self.tokens.get(self.index).map(|t| t.start) else {
+            return;
+        };
+        let Some(name) = self.take_ident() else {
+            return;
+        };
+        let arity = if self.adjacent_open_paren() {
+            self.count_args()
+        } else {
+            0
+        };
+        self.skip_expression();
+        let text = self.text(start, self.tokens[self.index - 1].end);
+        let key = qualify(self.module_name(), &format!("{name}/{arity}"));
+        self.specs.entry(key).or_insert(text);
+    }
+
+    /// Handle `end`, closing the innermost open module or clause it ends.
+    fn close_block(&mut self) {
+        if self
+            .blocks
+            .last()
+            .is_some_and(|block| block.depth == self.depth)
+        {
+            let block = self.blocks.pop().expect("checked above");
+            self.entities[block.slot].end_line = self.line();
+            if block.module {
+                self.modules.pop();
+            }
+        }
+        self.depth = self.depth.saturating_sub(1);
+        self.index += 1;
+    }
+
+    /// Advance to the `do` or `, do:` ending a `defmodule` or definition head.
+    fn scan_head(&mut self) -> HeadEnd {
+        let mut nesting = 0usize;
+        while let Some(token) = self.current() {
+            if nesting == 0 {
+                match token {
+                    Token::Ident(word) if word == "do" => return HeadEnd::Block,
+                    Token::Ident(word) if word == "end" => return HeadEnd::Bodiless,
+                    Token::Punct(op)
+                        if op == ","
+                            && matches!(self.token_at(1), Some(Token::Keyword(k)) if k == "do") =>
+                    {
+                        self.index += 1;
+                        return HeadEnd::Keyword;
+                    }
+                    _ if self.ends_expression() => return HeadEnd::Bodiless,
+                    _ => {}
+                }
+            }
+            match token {
+                Token::Punct(op) if is_open(op) => nesting += 1,
+                Token::Punct(op) if is_close(op) => {
+                    if nesting == 0 {
+                        return HeadEnd::Bodiless;
+                    }
+                    nesting -= 1;
+                }
+                _ => {}
+            }
+            self.index += 1;
+        }
+        HeadEnd::Bodiless
+    }
+
+    /// Consume one expression. It ends at the first line break outside
+    /// brackets and `do`/`fn` blocks that no operator carries over, at `;`,
+    /// or before an `end` or bracket closing the enclosing construct.
+    fn skip_expression(&mut self) {
+        let mut nesting = 0usize;
+        let mut blocks = 0usize;
+        while let Some(token) = self.current() {
+            if nesting == 0 && blocks == 0 {
+                match token {
+                    Token::Ident(word) if word == "end" => return,
+                    Token::Punct(op) if is_close(op) => return,
+                    Token::Punct(op) if op == ";" => {
+                        self.index += 1;
+                        return;
+                    }
+                    _ if self.ends_expression() => return,
+                    _ => {}
+                }
+            }
+            match token {
+                Token::Punct(op) if is_open(op) => nesting += 1,
+                Token::Punct(op) if is_close(op) => nesting -= 1,
+                Token::Ident(word) if word == "do" || word == "fn" => blocks += 1,
+                Token::Ident(word) if word == "end" => blocks -= 1,
+                _ => {}
+            }
+            self.index += 1;
+        }
+    }
+
+    /// Consume a parenthesised argument list and return its length.
+    fn count_args(&mut self) -> usize {
+        self.index += 1;
+        let mut nesting = 0usize;
+        let mut commas = 0;
+        let mut empty = true;
+        while let Some(token) = self.current() {
+            self.index += 1;
+            match token {
+                Token::Punct(op) if is_close(op) && nesting == 0 => break,
+                Token::Punct(op) if is_close(op) => nesting -= 1,
+                Token::Punct(op) if is_open(op) => nesting += 1,
+                Token::Punct(op) if op == "," && nesting == 0 => commas += 1,
+                _ => {}
+            }
+            empty = false;
+        }
+        if empty {
+            0
+        } else {
+            commas + 1
+        }
+    }
+
+    /// Whether the token at the cursor starts a new line that no operator
+    /// carries the previous expression onto.
+    fn ends_expression(&self) -> bool {
+        let (Some(previous), Some(current)) = (
+            self.index.checked_sub(1).and_then(|i| self.tokens.get(i)),
+            self.tokens.get(self.index),
+        ) else {
+            return false;
+        };
+        if current.line <= previous.end_line {
+            return false;
+        }
+        let carried_by_previous = match &previous.kind {
+            Token::Punct(op) => !is_close(op),
+            Token::Keyword(_) => true,
+            Token::Ident(word) => WORD_OPERATORS.contains(&word.as_str()),
+            _ => false,
+        };
+        let carried_by_current = match &current.kind {
+            Token::Punct(op) => LEADING_OPERATORS.contains(&op.as_str()),
+            Token::Ident(word) => WORD_OPERATORS.contains(&word.as_str()) && word != "not",
+            _ => false,
+        };
+        !carried_by_previous && !carried_by_current
+    }
+
+    /// Whether the cursor is the first token of a statement.
+    fn at_statement_start(&self) -> bool {
+        match self.index.checked_sub(1).map(|i| &self.tokens[i]) {
+            None => true,
+            Some(previous) => {
+                previous.end_line < self.line()
+                    || matches!(&previous.kind, Token::Punct(op) if op == ";")
+                    || matches!(&previous.kind, Token::Ident(word) if word == "do")
+            }
+        }
+    }
+
+    /// Whether a `(` immediately follows the previous token (a call, not a grouping).
+    fn adjacent_open_paren(&self) -> bool {
+        self.punct_at(0) == Some("(")
+            && self.tokens[self.index].start == self.tokens[self.index - 1].end
+    }
+
+    /// Name of the innermost enclosing module.
+    fn module_name(&self) -> Option<&str> {
+        self.modules.last().map(|(name, _)| name.as_str())
+    }
+
+    /// Replace a leading `__MODULE__` with the enclosing module's name.
+    fn expand(&self, name: &str) -> String {
+        match (name.strip_prefix("__MODULE__"), self.module_name()) {
+            (Some(rest), Some(module)) => format!("{module}{rest}"),
+            _ => name.to_string(),
+        }
+    }
+
+    /// Source between two character offsets with whitespace collapsed.
+    fn text(&self, start: usize, end: usize) -> String {
+        let raw: String = self.chars[start..end].iter().collect();
+        raw.split_whitespace().collect::<Vec<_>>().join(" ")
+    }
+
+    /// Append an entity and return its slot.
+    fn push(&mut self, entity: PluginEntity) -> usize {
+        self.entities.push(entity);
+        self.entities.len() - 1
+    }
+
+    /// Token at the cursor.
+    fn current(&self) -> Option<&'a Token> {
+        self.token_at(0)
+    }
+
+    /// Token `offset` tokens after the cursor.
+    fn token_at(&self, offset: usize) -> Option<&'a Token> {
+        self.tokens.get(self.index + offset).map(|t| &t.kind)
+    }
+
+    /// Identifier `offset` tokens after the cursor.
+    fn ident_at(&self, offset: usize) -> Option<&'a str> {
+        match self.token_at(offset) {
+            Some(Token::Ident(word)) => Some(word),
+            _ => None,
+        }
+    }
+
+    /// Punctuation `offset` tokens after the cursor.
+    fn punct_at(&self, offset: usize) -> Option<&'a str> {
+        match self.token_at(offset) {
+            Some(Token::Punct(op)) => Some(op),
+            _ => None,
+        }
+    }
+
+    /// Consume the identifier at the cursor.
+    fn take_ident(&mut self) -> Option<String> {
+        let word = self.ident_at(0)?.to_string();
+        self.index += 1;
+        Some(word)
+    }
+
+    /// 1-based line of the token at the cursor.
+    fn line(&self) -> usize {
+        self.tokens.get(self.index).map_or(1, |t| t.line)
+    }
+
+    /// 1-based last line of the last consumed token.
+    fn previous_line(&self) -> usize {
+        common::previous_line(self.tokens, self.index)
+    }
+
+    /// Close anything left open, attach specs and span functions over their clauses.
+    fn finish(mut self) -> FileAnalysis {
+        let last_line = self.tokens.last().map_or(1, |t| t.end_line);
+        for block in std::mem::take(&mut self.blocks) {
+            self.entities[block.slot].end_line = last_line;
+        }
+
+        let mut clause_ends: BTreeMap<&str, usize> = BTreeMap::new();
+        for entity in &self.entities {
+            if entity.kind == EntityKind::FunctionClause {
+                if let Some(parent) = entity.parent.as_deref() {
+                    let end = clause_ends.entry(parent).or_default();
+                    *end = (*end).max(entity.end_line);
+                }
+            }
+        }
+        let clause_ends: BTreeMap<String, usize> = clause_ends
+            .into_iter()
+            .map(|(name, end)| (name.to_string(), end))
+            .collect();
+        for (key, &(slot, _)) in &self.functions {
+            let function = &mut self.entities[slot];
+            if let Some(&end) = clause_ends.get(key) {
+                function.end_line = end;
+            }
+            function.signature = self.specs.get(key).cloned();
+        }
+        for entity in &mut self.entities {
+            if entity.kind == EntityKind::Module {
+                entity.depends_on.sort();
+                entity.depends_on.dedup();
+            }
+        }
+
+        FileAnalysis {
+            language: Some(ELIXIR_LANGUAGE.to_string()),
+            entities: self.entities,
+            imports: self.imports,
+            tables_referenced: Vec::new(),
+        }
+    }
+}
+
+/// Whether `op` opens a bracket.
+fn is_open(op: &str) -> bool {
+    matches!(op, "(" | "[" | "{" | "<<")
+}
+
+/// Whether `op` closes a bracket.
+fn is_close(op: &str) -> bool {
+    matches!(op, ")" | "]" | "}" | ">>")
+}
+
+/// A [`PluginEntity`] with no attributes, dependencies or signature yet.
+fn entity(
+    name: String,
+    kind: EntityKind,
+    block_type: &str,
+    start_line: usize,
+    end_line: usize,
+    parent: Option<&str>,
+) -> PluginEntity {
+    PluginEntity {
+        name,
+        kind,
+        start_line,
+        end_line,
+        parent: parent.map(str::to_string),
+        block_type: Some(block_type.to_string()),
+        attributes: Vec::new(),
+        depends_on: Vec::new(),
+        signature: None,
+    }
+}
+
+/// An app of an umbrella project that contains the parsed file.
+#[derive(Debug, Clone, PartialEq, Eq)]
+struct UmbrellaApp {
+    /// The app's `app:` name, or its directory name.
+    name: String,
+    /// Sibling apps listed as `in_umbrella: true` dependencies, sorted.
+    depends_on: Vec<String>,
+}
+
+/// Discovery methods for [`UmbrellaApp`].
+impl UmbrellaApp {
+    /// The umbrella app whose `mix.exs` is the nearest one above `path`, if
+    /// the directory above that app is its umbrella's `apps_path`.
+    fn containing(path: &Path) -> Option<Self> {
+        let app_dir = path
+            .ancestors()
+            .skip(1)
+            .find(|dir| dir.join("mix.exs").is_file())?;
+        let apps_dir = app_dir.parent()?;
+        let umbrella = apps_dir.parent()?;
+        let umbrella_mix = std::fs::read_to_string(umbrella.join("mix.exs")).ok()?;
+        let apps_path = project_setting(&umbrella_mix, "apps_path")?;
+        if umbrella.join(apps_path) != apps_dir {
+            return None;
+        }
+
+        let app_mix = std::fs::read_to_string(app_dir.join("mix.exs")).ok()?;
+        let name = project_setting(&app_mix, "app")
+            .or_else(|| Some(app_dir.file_name()?.to_string_lossy().into_owned()))?;
+        Some(Self {
+            name,
+            depends_on: umbrella_dependencies(&app_mix),
+        })
+    }
+
+    /// Add the app entity to `analysis` and adopt its top-level modules.
+    fn adopt(self, analysis: &mut FileAnalysis, line_count: usize) {
+        for entity in &mut analysis.entities {
+            if entity.parent.is_none() && entity.block_type.as_deref() == Some("defmodule") {
+                entity.parent = Some(self.name.clone());
+            }
+        }
+        let mut app = entity(
+            self.name,
+            EntityKind::Module,
+            "umbrella_app",
+            1,
+            line_count,
+            None,
+        );
+        app.depends_on = self.depends_on;
+        analysis.entities.insert(0, app);
+    }
+}
+
+/// Value of a `key: "string"` or `key: :atom` project setting in a `mix.exs`.
+fn project_setting(mix_exs: &str, key: &str) -> Option<String> {
+    let chars = source_chars(mix_exs);
+    let tokens = tokenize(&chars);
+    tokens
+        .windows(2)
+        .find_map(|pair| match (&pair[0].kind, &pair[1].kind) {
+            (Token::Keyword(k), Token::Str(value) | Token::Atom(value)) if k == key => {
+                Some(value.clone())
+            }
+            _ => None,
+        })
+}
+
+/// Names of `{:app, in_umbrella: true}` dependencies in a `mix.exs`, sorted.
+fn umbrella_dependencies(mix_exs: &str) -> Vec<String> {
+    let chars = source_chars(mix_exs);
+    let tokens = tokenize(&chars);
+    let mut names: Vec<String> = tokens
+        .windows(5)
+        .filter_map(|window| {
+            let kinds: Vec<&Token> = window.iter().map(|t| &t.kind).collect();
+            match kinds.as_slice() {
+                [Token::Punct(open), Token::Atom(name), Token::Punct(comma), Token::Keyword(key), Token::Ident(value)]
+                    if open == "{" && comma == "," && key == "in_umbrella" && value == "true" =>
+                {
+                    Some(name.clone())
+                }
+                _ => None,
+            }
+        })
+        .collect();
+    names.sort();
+    names.dedup();
+    names
+}
+
+/// Lexical token of the Elixir subset the parser needs.
+#[derive(Debug, Clone, PartialEq)]
+enum Token {
+    /// Identifier, alias or dotted name (`fetch`, `valid?`, `MyApp.Repo`)
+    Ident(String),
+    /// Keyword-list key without its colon (`do:`, `in_umbrella:`)
+    Keyword(String),
+    /// Atom without its colon (`:ok`, `:"quoted atom"`)
+    Atom(String),
+    /// Single-line double-quoted string, without its quotes
+    Str(String),
+    /// Number, character, charlist, heredoc or sigil
+    Literal,
+    /// Operator or other punctuation (`::`, `\\`, `(`)
+    Punct(String),
+}
+
+/// An Elixir token with its character range and lines.
+type Spanned = common::Spanned<Token>;
+
+/// Split `chars` into tokens, skipping whitespace and comments.
+fn tokenize(chars: &[char]) -> Vec<Spanned> {
+    common::tokenize(chars, lex)
+}
+
+/// Lex the token or comment starting at `i`.
+fn lex(chars: &[char], mut i: usize) -> Lexeme<Token> {
+    let c = chars[i];
+    let next = chars.get(i + 1).copied();
+    let start = i;
+    let kind = match c {
+        '#' => return Lexeme::Skip(line_end(chars, i)),
+        '"' | '\'' if chars[i..].starts_with(&[c, c, c]) => {
+            i = skip_heredoc(chars, i + 3, c);
+            Token::Literal
+        }
+        '"' => {
+            i = skip_quoted(chars, i + 1, '"');
+            let text: String = chars[start + 1..i.saturating_sub(1).max(start + 1)]
+                .iter()
+                .collect();
+            Token::Str(text)
+        }
+        '\'' => {
+            i = skip_quoted(chars, i + 1, '\'');
+            Token::Literal
+        }
+        '~' if next.is_some_and(|c| c.is_ascii_alphabetic()) => {
+            i = skip_sigil(chars, i + 1);
+            Token::Literal
+        }
+        '?' if next.is_some() && !previous_is_ident(chars, i) => {
+            i += if next == Some('\\') { 3 } else { 2 };
+            Token::Literal
+        }
+        ':' if next == Some(':') => {
+            i += 2;
+            Token::Punct("::".to_string())
+        }
+        ':' if next == Some('"') => {
+            i = skip_quoted(chars, i + 2, '"');
+            Token::Atom(
+                chars[start + 2..i.saturating_sub(1).max(start + 2)]
+                    .iter()
+                    .collect(),
+            )
+        }
+        ':' if next.is_some_and(is_ident_start) => {
+            i = scan_ident(chars, i + 1);
+            Token::Atom(chars[start + 1..i].iter().collect())
+        }
+        c if c.is_ascii_digit() => {
+            i = scan_number(chars, i);
+            Token::Literal
+        }
+        c if is_ident_start(c) => {
+            i = scan_ident(chars, i);
+            let word: String = chars[start..i].iter().collect();
+            if chars.get(i) == Some(&':') && chars.get(i + 1) != Some(&':') {
+                i += 1;
+                Token::Keyword(word)
+            } else {
+                Token::Ident(word)
+            }
+        }
+        _ => {
+            let op = scan_operator(chars, i, OPERATORS);
+            i += op.chars().count();
+            Token::Punct(op)
+        }
+    };
+    Lexeme::Token(kind, i)
+}
+
+/// Whether `c` can start an identifier, alias or atom.
+fn is_ident_start(c: char) -> bool {
+    c.is_alphabetic() || c == '_'
+}
+
+/// Whether the character before `i` belongs to an identifier (`valid?` vs `?a`).
+fn previous_is_ident(chars: &[char], i: usize) -> bool {
+    i.checked_sub(1)
+        .and_then(|p| chars.get(p))
+        .is_some_and(|&c| c.is_alphanumeric() || c == '_' || c == '?' || c == '!')
+}
+
+/// End of the identifier starting at `i`, including a trailing `?`/`!` and
+/// `.`-separated segments (`MyApp.Repo.get!`).
+fn scan_ident(chars: &[char], mut i: usize) -> usize {
+    loop {
+        while i < chars.len() && (chars[i].is_alphanumeric() || chars[i] == '_') {
+            i += 1;
+        }
+        if i < chars.len() && (chars[i] == '?' || chars[i] == '!') {
+            i += 1;
+            return i;
+        }
+        if chars.get(i) == Some(&'.') && chars.get(i + 1).is_some_and(|&c| is_ident_start(c)) {
+            i += 1;
+            continue;
+        }
+        return i;
+    }
+}
+
+/// Index just past the `close` quote ending a string that starts at `i`,
+/// skipping escapes and `#{...}` interpolations.
+fn skip_quoted(chars: &[char], mut i: usize, close: char) -> usize {
+    while i < chars.len() {
+        match chars[i] {
+            '\\' => i += 2,
+            '#' if chars.get(i + 1) == Some(&'{') => i = skip_interpolation(chars, i + 2),
+            c if c == close => return i + 1,
+            _ => i += 1,
+        }
+    }
+    chars.len()
+}
+
+/// Index just past the `}` closing an interpolation whose body starts at `i`.
+fn skip_interpolation(chars: &[char], mut i: usize) -> usize {
+    let mut depth = 1;
+    while i < chars.len() {
+        match chars[i] {
+            '"' => {
+                i = skip_quoted(chars, i + 1, '"');
+                continue;
+            }
+            '{' => depth += 1,
+            '}' => {
+                depth -= 1;
+                if depth == 0 {
+                    return i + 1;
+                }
+            }
+            _ => {}
+        }
+        i += 1;
+    }
+    chars.len()
+}
+
+/// Index just past the triple `quote` closing a heredoc whose body starts at `i`.
+fn skip_heredoc(chars: &[char], mut i: usize, quote: char) -> usize {
+    while i < chars.len() {
+        if chars[i] == '\\' {
+            i += 2;
+            continue;
+        }
+        if chars[i..].starts_with(&[quote, quote, quote]) {
+            return i + 3;
+        }
+        i += 1;
+    }
+    chars.len()
+}
+
+/// Index just past a sigil whose name starts at `i` (after the `~`),
+/// including its modifiers.
+fn skip_sigil(chars: &[char], mut i: usize) -> usize {
+    while i < chars.len() && chars[i].is_ascii_alphabetic() {
+        i += 1;
+    }
+    let Some(&open) = chars.get(i) else {
+        return chars.len();
+    };
+    i = if (open == '"' || open == '\'') && chars[i..].starts_with(&[open, open, open]) {
+        skip_heredoc(chars, i + 3, open)
+    } else {
+        let close = match open {
+            '(' => ')',
+            '[' => ']',
+            '{' => '}',
+            '<' => '>',
+            other => other,
+        };
+        let mut j = i + 1;
+        while j < chars.len() && chars[j] != close {
+            j += if chars[j] == '\\' { 2 } else { 1 };
+        }
+        j + 1
+    };
+    while i < chars.len() && chars[i].is_ascii_alphabetic() {
+        i += 1;
+    }
+    i.min(chars.len())
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use std::fs;
+    use tempfile::tempdir;
+
+    const ACCOUNTS_EX: &str = r#"defmodule MyApp.Accounts do
+  @moduledoc """
+  Account lookups. Not a `def` or an `end`.
+  """
+  use Ecto.Schema
+  import Ecto.Query, only: [from: 2]
+  alias MyApp.{Repo, Mailer}
+  require Logger
+
+  defmodule User do
+    defstruct [:id, :email, role: :member, tags: []]
+  end
+
+  @spec fetch(integer() | map(), keyword()) ::
+          {:ok, User.t()} | {:error, :not_found}
+  def fetch(id, opts \\ [])
+
+  def fetch(%User{id: id} = user, opts) when is_list(opts) do
+    Logger.debug("fetch #{inspect(user)}")
+    fetch(id, opts)
+  end
+
+  def fetch(id, _opts) when is_integer(id) do
+    case Repo.get(User, id) do
+      nil -> {:error, :not_found}
+      user -> {:ok, user}
+    end
+  end
+
+  def count, do: Repo.aggregate(User, :count)
+
+  defp normalize(email),
+    do: email |> String.trim() |> String.downcase()
+
+  defmacro with_user(id, do: block) do
+    quote do
+      fn -> unquote(block) end
+    end
+  end
+end
+"#;
+
+    fn entity<'a>(analysis: &'a FileAnalysis, name: &str) -> &'a PluginEntity {
+        analysis
+            .entities
+            .iter()
+            .find(|entity| entity.name == name)
+            .unwrap_or_else(|| panic!("missing {name} in {:#?}", analysis.entities))
+    }
+
+    #[test]
+    fn extracts_modules_directives_and_structs() {
+        let analysis = parse_elixir(ACCOUNTS_EX);
+        assert_eq!(analysis.language.as_deref(), Some("elixir"));
+        assert_eq!(
+            analysis.imports,
+            vec![
+                "Ecto.Schema",
+                "Ecto.Query",
+                "MyApp.Repo",
+                "MyApp.Mailer",
+                "Logger"
+            ]
+        );
+
+        let accounts = entity(&analysis, "MyApp.Accounts");
+        assert_eq!(accounts.kind, EntityKind::Module);
+        assert_eq!((accounts.start_line, accounts.end_line), (1, 40));
+        assert_eq!(
+            accounts.depends_on,
+            vec![
+                "Ecto.Query",
+                "Ecto.Schema",
+                "Logger",
+                "MyApp.Mailer",
+                "MyApp.Repo"
+            ]
+        );
+        assert_eq!(
+            entity(&analysis, "Ecto.Query").block_type.as_deref(),
+            Some("import")
+        );
+
+        let user = entity(&analysis, "MyApp.Accounts.User");
+        assert_eq!(user.parent.as_deref(), Some("MyApp.Accounts"));
+        assert_eq!((user.start_line, user.end_line), (10, 12));
+        assert_eq!(user.attributes, vec!["id", "email", "role", "tags"]);
+    }
+
+    #[test]
+    fn records_each_clause_separately() {
+        let analysis = parse_elixir(ACCOUNTS_EX);
+
+        let fetch = entity(&analysis, "MyApp.Accounts.fetch/2");
+        assert_eq!(fetch.kind, EntityKind::Function);
+        assert_eq!(fetch.block_type.as_deref(), Some("def"));
+        assert_eq!((fetch.start_line, fetch.end_line), (18, 28));
+        assert_eq!(
+            fetch.signature.as_deref(),
+            Some("fetch(integer() | map(), keyword()) :: {:ok, User.t()} | {:error, :not_found}")
+        );
+
+        let clauses: Vec<&PluginEntity> = analysis
+            .entities
+            .iter()
+            .filter(|entity| entity.kind == EntityKind::FunctionClause)
+            .filter(|entity| entity.parent.as_deref() == Some("MyApp.Accounts.fetch/2"))
+            .collect();
+        assert_eq!(clauses.len(), 2);
+        assert_eq!(clauses[0].name, "MyApp.Accounts.fetch/2#1");
+        assert_eq!(
+            clauses[0].signature.as_deref(),
+            Some("fetch(%User{id: id} = user, opts) when is_list(opts)")
+        );
+        assert_eq!((clauses[0].start_line, clauses[0].end_line), (18, 21));
+        assert_eq!(
+            clauses[1].signature.as_deref(),
+            Some("fetch(id, _opts) when is_integer(id)")
+        );
+        assert_eq!((clauses[1].start_line, clauses[1].end_line), (23, 28));
+    }
+
+    #[test]
+    fn handles_keyword_bodies_and_macros() {
+        let analysis = parse_elixir(ACCOUNTS_EX);
+
+        let count = entity(&analysis, "MyApp.Accounts.count/0#1");
+        assert_eq!((count.start_line, count.end_line), (30, 30));
+        assert_eq!(count.signature.as_deref(), Some("count"));
+
+        let normalize = entity(&analysis, "MyApp.Accounts.normalize/1#1");
+        assert_eq!(normalize.block_type.as_deref(), Some("defp"));
+        assert_eq!((normalize.start_line, normalize.end_line), (32, 33));
+
+        let with_user = entity(&analysis, "MyApp.Accounts.with_user/2");
+        assert_eq!(with_user.block_type.as_deref(), Some("defmacro"));
+        assert_eq!((with_user.start_line, with_user.end_line), (35, 39));
+        assert!(with_user.signature.is_none());
+    }
+
+    #[test]
+    fn umbrella_apps_become_modules() {
+        let tmp = tempdir().unwrap();
+        let root = tmp.path();
+        fs::write(
+            root.join("mix.exs"),
+            "defmodule Shop.Umbrella.MixProject do\n  use Mix.Project\n\n  def project do\n    [apps_path: \"apps\", deps: []]\n  end\nend\n",
+        )
+        .unwrap();
+        let app = root.join("apps/billing");
+        fs::create_dir_all(app.join("lib")).unwrap();
+        fs::write(
+            app.join("mix.exs"),
+            "defmodule Billing.MixProject do\n  use Mix.Project\n\n  def project do\n    [app: :billing, deps: deps()]\n  end\n\n  defp deps do\n    [{:accounts, in_umbrella: true}, {:decimal, \"~> 2.0\"}]\n  end\nend\n",
+        )
+        .unwrap();
+        let source = "defmodule Billing.Invoice do\n  def total(lines), do: Enum.sum(lines)\nend\n";
+        let path = app.join("lib/invoice.ex");
+        fs::write(&path, source).unwrap();
+
+        let analysis = ElixirParser::default()
+            .parse(&path, source.as_bytes())
+            .unwrap();
+        let billing = entity(&analysis, "billing");
+        assert_eq!(billing.block_type.as_deref(), Some("umbrella_app"));
+        assert_eq!((billing.start_line, billing.end_line), (1, 3));
+        assert_eq!(billing.depends_on, vec!["accounts"]);
+        assert_eq!(
+            entity(&analysis, "Billing.Invoice").parent.as_deref(),
+            Some("billing")
+        );
+
+        let standalone = ElixirParser::default()
+            .parse(Path::new("invoice.ex"), source.as_bytes())
+            .unwrap();
+        assert!(standalone
+            .entities
+            .iter()
+            .all(|entity| entity.block_type.as_deref() != Some("umbrella_app")));
+    }
+}
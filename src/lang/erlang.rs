@@ -0,0 +1,615 @@
+//! Built-in Erlang parser.
+//!
+//! [`ErlangParser`] reads `.erl` and `.hrl` files into a [`FileAnalysis`] with
+//! language `"erlang"`, using the same shapes as the
+//! [Elixir parser](super::elixir):
+//!
+//! - `-module(name).` becomes a `Module` entity (block type `module`) that
+//!   spans the rest of the file; its `depends_on` lists the modules named by
+//!   `-import` and `-behaviour` attributes, which are also `Module` children
+//!   whose block type is the attribute;
+//! - functions become `Function` entities named `module:name/arity` that span
+//!   all their clauses, with the matching `-spec` (without the `-spec`
+//!   prefix) as their `signature`;
+//! - every clause is a separate `FunctionClause` child named
+//!   `module:name/arity#n` (1-based) whose `signature` is its head including
+//!   any guard, e.g. `lookup(Key, #state{table = T}) when is_atom(Key)`;
+//! - `-record(name, {...})` becomes a `Struct` entity (block type `record`)
+//!   whose `attributes` are the field names.
+//!
+//! Imported modules and `-include`/`-include_lib` paths are reported as
+//! [`FileAnalysis::imports`].
+
+use std::collections::BTreeMap;
+use std::path::Path;
+
+use crate::core::errors::Result;
+use crate::lang::buffer_pool::source_chars;
+use crate::lang::common::{self, line_end, scan_operator, EntityKind, Lexeme};
+use crate::lang::plugins::{decode_source, FileAnalysis, LanguageParser, PluginEntity};
+
+/// Language name reported for Erlang files.
+pub const ERLANG_LANGUAGE: &str = "erlang";
+
+/// Multi-character operators, longest first.
+const OPERATORS: &[&str] = &[
+    "=:=", "=/=", "...", "->", "<-", "<=", "=>", ":=", "::", "||", "=<", ">=", "==", "/=", "++",
+    "--", "<<", ">>",
+];
+
+/// Keywords closed by `end`.
+const BLOCK_KEYWORDS: &[&str] = &["begin", "case", "if", "receive", "try"];
+
+/// Parser for Erlang source and header files.
+#[derive(Debug, Clone)]
+pub struct ErlangParser {
+    extensions: Vec<String>,
+}
+
+/// Default implementation for [`ErlangParser`].
+impl Default for ErlangParser {
+    /// Returns a parser for `.erl` and `.hrl` files.
+    fn default() -> Self {
+        Self {
+            extensions: vec!["erl".to_string(), "hrl".to_string()],
+        }
+    }
+}
+
+/// [`LanguageParser`] implementation for [`ErlangParser`].
+impl LanguageParser for ErlangParser {
+    fn name(&self) -> &str {
+        ERLANG_LANGUAGE
+    }
+
+    fn extensions(&self) -> &[String] {
+        &self.extensions
+    }
+
+    fn parse(&self, path: &Path, source: &[u8]) -> Result<FileAnalysis> {
+        let source = decode_source(ERLANG_LANGUAGE, path, source)?;
+        Ok(parse_erlang(source))
+    }
+}
+
+/// Extract the module, attributes, records and function clauses of one file.
+pub fn parse_erlang(source: &str) -> FileAnalysis {
+    let chars = source_chars(source);
+    let tokens = tokenize(&chars);
+    let mut reader = ErlangReader {
+        chars: &chars,
+        module: None,
+        functions: BTreeMap::new(),
+        specs: BTreeMap::new(),
+        entities: Vec::new(),
+        imports: Vec::new(),
+    };
+    for form in tokens.split(|token| token.kind == Token::Dot) {
+        reader.form(form);
+    }
+    reader.finish(tokens.last().map_or(1, |t| t.line))
+}
+
+/// Reader over the forms (`.`-terminated top-level declarations) of one file.
+struct ErlangReader<'a> {
+    chars: &'a [char],
+    /// Name and entity slot of the `-module`.
+    module: Option<(String, usize)>,
+    /// `module:name/arity` -> (function entity slot, clause count).
+    functions: BTreeMap<String, (usize, usize)>,
+    /// `module:name/arity` -> rendered `-spec`.
+    specs: BTreeMap<String, String>,
+    entities: Vec<PluginEntity>,
+    imports: Vec<String>,
+}
+
+/// Form readers for [`ErlangReader`].
+impl<'a> ErlangReader<'a> {
+    /// Read one attribute or function form.
+    fn form(&mut self, form: &[Spanned]) {
+        match (kind_at(form, 0), kind_at(form, 1)) {
+            (Some(Token::Punct(dash)), Some(Token::Atom(name))) if dash == "-" => {
+                self.attribute(name, form[0].line, &form[2..])
+            }
+            (Some(Token::Atom(_)), Some(Token::Punct(open))) if open == "(" => self.function(form),
+            _ => {}
+        }
+    }
+
+    /// Read `-name(...)` given the tokens after the attribute name.
+    fn attribute(&mut self, name: &str, line: usize, rest: &[Spanned]) {
+        let end_line = rest.last().map_or(line, |t| t.line);
+        let arguments = match kind_at(rest, 0) {
+            Some(Token::Punct(open)) if open == "(" => &rest[1..],
+            _ => rest,
+        };
+        match name {
+            "module" => {
+                if let Some(Token::Atom(module)) = kind_at(arguments, 0) {
+                    let slot = self.push(entity(
+                        module.clone(),
+                        EntityKind::Module,
+                        "module",
+                        line,
+                        end_line,
+                        None,
+                    ));
+                    self.module = Some((module.clone(), slot));
+                }
+            }
+            "import" | "behaviour" | "behavior" => {
+                if let Some(Token::Atom(target)) = kind_at(arguments, 0) {
+                    let parent = self.module.clone();
+                    self.push(entity(
+                        target.clone(),
+                        EntityKind::Module,
+                        name,
+                        line,
+                        end_line,
+                        parent.as_ref().map(|(module, _)| module.as_str()),
+                    ));
+                    if let Some((_, slot)) = parent {
+                        self.entities[slot].depends_on.push(target.clone());
+                    }
+                    if name == "import" {
+                        self.add_import(target);
+                    }
+                }
+            }
+            "include" | "include_lib" => {
+                if let Some(Token::Str(include)) = kind_at(arguments, 0) {
+                    self.add_import(include);
+                }
+            }
+            "spec" => {
+                // `-spec name(...) -> ...` or `-spec module:name(...) -> ...`
+                let spec = match (kind_at(arguments, 0), kind_at(arguments, 1)) {
+                    (Some(Token::Atom(_)), Some(Token::Punct(colon))) if colon == ":" => {
+                        &arguments[2..]
+                    }
+                    _ => arguments,
+                };
+                let (Some(first), Some(last)) = (spec.first(), spec.last()) else {
+                    return;
+                };
+                let Token::Atom(function) = &first.kind else {
+                    return;
+                };
+                let arity = count_args(&spec[1..]);
+                let key = self.qualify(&format!("{function}/{arity}"));
+                let text = self.text(first.start, last.end);
+                self.specs.entry(key).or_insert(text);
+            }
+            "record" => {
+                let Some(Token::Atom(record)) = kind_at(arguments, 0) else {
+                    return;
+                };
+                let parent = self.module.as_ref().map(|(module, _)| module.clone());
+                let mut entity = entity(
+                    record.clone(),
+                    EntityKind::Struct,
+                    "record",
+                    line,
+                    end_line,
+                    parent.as_deref(),
+                );
+                entity.attributes = record_fields(arguments);
+                self.push(entity);
+            }
+            _ => {}
+        }
+    }
+
+    /// Read a function form, one clause per `;` outside nested expressions.
+    fn function(&mut self, form: &[Spanned]) {
+        let mut nesting = 0usize;
+        let mut clause_start = 0;
+        let mut arrow = None;
+        for (index, token) in form.iter().enumerate() {
+            match &token.kind {
+                Token::Punct(op) if matches!(op.as_str(), "(" | "[" | "{" | "<<") => nesting += 1,
+                Token::Punct(op) if matches!(op.as_str(), ")" | "]" | "}" | ">>") => {
+                    nesting = nesting.saturating_sub(1)
+                }
+                Token::Atom(word) if BLOCK_KEYWORDS.contains(&word.as_str()) => nesting += 1,
+                Token::Atom(word) if word == "fun" && opens_fun(&form[index + 1..]) => nesting += 1,
+                Token::Atom(word) if word == "end" => nesting = nesting.saturating_sub(1),
+                Token::Punct(op) if op == "->" && nesting == 0 && arrow.is_none() => {
+                    arrow = Some(index)
+                }
+                Token::Punct(op) if op == ";" && nesting == 0 => {
+                    // Before the arrow, `;` separates guard alternatives.
+                    if let Some(arrow) = arrow.take() {
+                        self.clause(&form[clause_start..index], arrow - clause_start);
+                        clause_start = index + 1;
+                    }
+                }
+                _ => {}
+            }
+        }
+        if let Some(arrow) = arrow {
+            self.clause(&form[clause_start..], arrow - clause_start);
+        }
+    }
+
+    /// Record one clause whose head ends before `clause[arrow]`.
+    fn clause(&mut self, clause: &[Spanned], arrow: usize) {
+        let (Some(first), Some(last)) = (clause.first(), clause.last()) else {
+            return;
+        };
+        let Token::Atom(name) = &first.kind else {
+            return;
+        };
+        let arity = count_args(&clause[1..]);
+        let head = self.text(first.start, clause[arrow - 1].end);
+
+        let key = self.qualify(&format!("{name}/{arity}"));
+        let module = self.module.as_ref().map(|(module, _)| module.clone());
+        let (function_slot, clauses) = match self.functions.get(&key) {
+            Some(&entry) => entry,
+            None => {
+                let slot = self.push(entity(
+                    key.clone(),
+                    EntityKind::Function,
+                    "function",
+                    first.line,
+                    last.line,
+                    module.as_deref(),
+                ));
+                (slot, 0)
+            }
+        };
+        self.functions
+            .insert(key.clone(), (function_slot, clauses + 1));
+        let function = &mut self.entities[function_slot];
+        function.end_line = function.end_line.max(last.line);
+
+        let mut clause = entity(
+            format!("{key}#{}", clauses + 1),
+            EntityKind::FunctionClause,
+            "function",
+            first.line,
+            last.line,
+            Some(&key),
+        );
+        clause.signature = Some(head);
+        self.push(clause);
+    }
+
+    /// `module:name`, or `name` outside a module (header files).
+    fn qualify(&self, name: &str) -> String {
+        match &self.module {
+            Some((module, _)) => format!("{module}:{name}"),
+            None => name.to_string(),
+        }
+    }
+
+    /// Source between two character offsets with whitespace collapsed.
+    fn text(&self, start: usize, end: usize) -> String {
+        let raw: String = self.chars[start..end].iter().collect();
+        raw.split_whitespace().collect::<Vec<_>>().join(" ")
+    }
+
+    /// Add an import once, in source order.
+    fn add_import(&mut self, import: &str) {
+        if !self.imports.iter().any(|existing| existing == import) {
+            self.imports.push(import.to_string());
+        }
+    }
+
+    /// Append an entity and return its slot.
+    fn push(&mut self, entity: PluginEntity) -> usize {
+        self.entities.push(entity);
+        self.entities.len() - 1
+    }
+
+    /// Extend the module to the end of the file and attach specs.
+    fn finish(mut self, last_line: usize) -> FileAnalysis {
+        if let Some((_, slot)) = &self.module {
+            let module = &mut self.entities[*slot];
+            module.end_line = last_line;
+            module.depends_on.sort();
+            module.depends_on.dedup();
+        }
+        for (key, &(slot, _)) in &self.functions {
+            self.entities[slot].signature = self.specs.get(key).cloned();
+        }
+
+        FileAnalysis {
+            language: Some(ERLANG_LANGUAGE.to_string()),
+            entities: self.entities,
+            imports: self.imports,
+            tables_referenced: Vec::new(),
+        }
+    }
+}
+
+/// Whether `fun` followed by `rest` starts an anonymous function closed by
+/// `end` (`fun(X) -> ... end`, `fun Loop(X) -> ... end`) rather than a
+/// reference such as `fun lists:map/2`.
+fn opens_fun(rest: &[Spanned]) -> bool {
+    let is_open = |kind: Option<&Token>| matches!(kind, Some(Token::Punct(op)) if op == "(");
+    is_open(kind_at(rest, 0))
+        || (matches!(kind_at(rest, 0), Some(Token::Var(_))) && is_open(kind_at(rest, 1)))
+}
+
+/// Kind of the token at `index`, if any.
+fn kind_at(tokens: &[Spanned], index: usize) -> Option<&Token> {
+    tokens.get(index).map(|t| &t.kind)
+}
+
+/// Length of the parenthesised argument list at the start of `tokens`.
+fn count_args(tokens: &[Spanned]) -> usize {
+    if !matches!(kind_at(tokens, 0), Some(Token::Punct(open)) if open == "(") {
+        return 0;
+    }
+    let mut nesting = 0usize;
+    let mut commas = 0;
+    for (offset, token) in tokens[1..].iter().enumerate() {
+        match &token.kind {
+            Token::Punct(op) if matches!(op.as_str(), ")" | "]" | "}" | ">>") => {
+                if nesting == 0 {
+                    return if offset == 0 { 0 } else { commas + 1 };
+                }
+                nesting -= 1;
+            }
+            Token::Punct(op) if matches!(op.as_str(), "(" | "[" | "{" | "<<") => nesting += 1,
+            Token::Punct(op) if op == "," && nesting == 0 => commas += 1,
+            _ => {}
+        }
+    }
+    commas + 1
+}
+
+/// Field names of `name, {field, field = default :: type, ...}`.
+fn record_fields(arguments: &[Spanned]) -> Vec<String> {
+    let mut fields = Vec::new();
+    let mut nesting = 0usize;
+    let mut previous: Option<&Token> = None;
+    for token in arguments {
+        match &token.kind {
+            Token::Atom(name)
+                if nesting == 1
+                    && matches!(previous, Some(Token::Punct(op)) if op == "{" || op == ",") =>
+            {
+                fields.push(name.clone());
+            }
+            Token::Punct(op) if matches!(op.as_str(), "(" | "[" | "{" | "<<") => nesting += 1,
+            Token::Punct(op) if matches!(op.as_str(), ")" | "]" | "}" | ">>") => {
+                nesting = nesting.saturating_sub(1)
+            }
+            _ => {}
+        }
+        previous = Some(&token.kind);
+    }
+    fields
+}
+
+/// A [`PluginEntity`] with no attributes, dependencies or signature yet.
+fn entity(
+    name: String,
+    kind: EntityKind,
+    block_type: &str,
+    start_line: usize,
+    end_line: usize,
+    parent: Option<&str>,
+) -> PluginEntity {
+    PluginEntity {
+        name,
+        kind,
+        start_line,
+        end_line,
+        parent: parent.map(str::to_string),
+        block_type: Some(block_type.to_string()),
+        attributes: Vec::new(),
+        depends_on: Vec::new(),
+        signature: None,
+    }
+}
+
+/// Lexical token of the Erlang subset the parser needs.
+#[derive(Debug, Clone, PartialEq)]
+enum Token {
+    /// Atom, unquoted or without its quotes (`ok`, `'EXIT'`)
+    Atom(String),
+    /// Variable (`State`, `_Opts`)
+    Var(String),
+    /// String, without its quotes
+    Str(String),
+    /// Number or character literal
+    Literal,
+    /// Operator or other punctuation (`->`, `:`, `#`)
+    Punct(String),
+    /// The `.` that ends a form
+    Dot,
+}
+
+/// An Erlang token with its character range and lines.
+type Spanned = common::Spanned<Token>;
+
+/// Split `chars` into tokens, skipping whitespace and comments.
+fn tokenize(chars: &[char]) -> Vec<Spanned> {
+    common::tokenize(chars, lex)
+}
+
+/// Lex the token or comment starting at `i`.
+fn lex(chars: &[char], mut i: usize) -> Lexeme<Token> {
+    let c = chars[i];
+    let next = chars.get(i + 1).copied();
+    let start = i;
+    let kind = match c {
+        '%' => return Lexeme::Skip(line_end(chars, i)),
+        '"' | '\'' => {
+            i += 1;
+            let mut text = String::new();
+            while i < chars.len() && chars[i] != c {
+                if chars[i] == '\\' {
+                    text.extend(chars.get(i + 1));
+                    i += 2;
+                    continue;
+                }
+                text.push(chars[i]);
+                i += 1;
+            }
+            i += 1;
+            if c == '"' {
+                Token::Str(text)
+            } else {
+                Token::Atom(text)
+            }
+        }
+        '$' => {
+            i += if next == Some('\\') { 3 } else { 2 };
+            Token::Literal
+        }
+        '.' if next.map_or(true, |c| c.is_whitespace() || c == '%') => {
+            i += 1;
+            Token::Dot
+        }
+        c if c.is_ascii_digit() => {
+            while i < chars.len()
+                && (chars[i].is_alphanumeric()
+                    || chars[i] == '_'
+                    || chars[i] == '#'
+                    || (chars[i] == '.' && chars.get(i + 1).is_some_and(|c| c.is_ascii_digit()))
+                    || ((chars[i] == '-' || chars[i] == '+') && matches!(chars[i - 1], 'e' | 'E')))
+            {
+                i += 1;
+            }
+            Token::Literal
+        }
+        c if c.is_alphabetic() || c == '_' => {
+            while i < chars.len()
+                && (chars[i].is_alphanumeric() || chars[i] == '_' || chars[i] == '@')
+            {
+                i += 1;
+            }
+            let word: String = chars[start..i].iter().collect();
+            if c.is_uppercase() || c == '_' {
+                Token::Var(word)
+            } else {
+                Token::Atom(word)
+            }
+        }
+        _ => {
+            let op = scan_operator(chars, i, OPERATORS);
+            i += op.chars().count();
+            Token::Punct(op)
+        }
+    };
+    Lexeme::Token(kind, i)
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    const CACHE_ERL: &str = r#"%% Key-value cache server.
+-module(cache).
+-behaviour(gen_server).
+
+-include_lib("kernel/include/logger.hrl").
+-import(lists, [foldl/3]).
+
+-export([start_link/0, lookup/2]).
+-export([init/1, handle_call/3]).
+
+-record(state, {table :: ets:tid(), hits = 0 :: non_neg_integer(),
+                misses = 0}).
+
+-spec lookup(atom(), #state{}) -> {ok, term()} | miss.
+lookup(Key, #state{table = T}) when is_atom(Key); is_binary(Key) ->
+    case ets:lookup(T, Key) of
+        [{Key, Value}] -> {ok, Value};
+        [] -> miss
+    end;
+lookup(_Key, _State) ->
+    miss.
+
+start_link() -> gen_server:start_link({local, ?MODULE}, ?MODULE, [], []).
+
+init([]) ->
+    Sum = foldl(fun(X, Acc) -> X + Acc end, 0, [1, 2]),
+    Apply = fun lists:reverse/1,
+    {ok, #state{table = ets:new(?MODULE, [set]), hits = Sum + length(Apply([]))}}.
+
+handle_call({get, Key}, _From, State) ->
+    {reply, lookup(Key, State), State};
+handle_call(stop, _From, State) ->
+    {stop, normal, ok, State}.
+"#;
+
+    fn entity<'a>(analysis: &'a FileAnalysis, name: &str) -> &'a PluginEntity {
+        analysis
+            .entities
+            .iter()
+            .find(|entity| entity.name == name)
+            .unwrap_or_else(|| panic!("missing {name} in {:#?}", analysis.entities))
+    }
+
+    #[test]
+    fn extracts_module_attributes_and_records() {
+        let analysis = parse_erlang(CACHE_ERL);
+        assert_eq!(analysis.language.as_deref(), Some("erlang"));
+        assert_eq!(analysis.imports, vec!["kernel/include/logger.hrl", "lists"]);
+
+        let cache = entity(&analysis, "cache");
+        assert_eq!(cache.kind, EntityKind::Module);
+        assert_eq!((cache.start_line, cache.end_line), (2, 33));
+        assert_eq!(cache.depends_on, vec!["gen_server", "lists"]);
+        assert_eq!(
+            entity(&analysis, "gen_server").block_type.as_deref(),
+            Some("behaviour")
+        );
+
+        let state = entity(&analysis, "state");
+        assert_eq!(state.kind, EntityKind::Struct);
+        assert_eq!((state.start_line, state.end_line), (11, 12));
+        assert_eq!(state.attributes, vec!["table", "hits", "misses"]);
+    }
+
+    #[test]
+    fn records_each_clause_separately() {
+        let analysis = parse_erlang(CACHE_ERL);
+
+        let lookup = entity(&analysis, "cache:lookup/2");
+        assert_eq!(lookup.kind, EntityKind::Function);
+        assert_eq!((lookup.start_line, lookup.end_line), (15, 21));
+        assert_eq!(
+            lookup.signature.as_deref(),
+            Some("lookup(atom(), #state{}) -> {ok, term()} | miss")
+        );
+
+        let first = entity(&analysis, "cache:lookup/2#1");
+        assert_eq!(first.kind, EntityKind::FunctionClause);
+        assert_eq!(first.parent.as_deref(), Some("cache:lookup/2"));
+        assert_eq!(
+            first.signature.as_deref(),
+            Some("lookup(Key, #state{table = T}) when is_atom(Key); is_binary(Key)")
+        );
+        assert_eq!((first.start_line, first.end_line), (15, 19));
+        assert_eq!(
+            entity(&analysis, "cache:lookup/2#2").signature.as_deref(),
+            Some("lookup(_Key, _State)")
+        );
+
+        assert_eq!(
+            entity(&analysis, "cache:start_link/0#1")
+                .signature
+                .as_deref(),
+            Some("start_link()")
+        );
+        let init = entity(&analysis, "cache:init/1");
+        assert_eq!((init.start_line, init.end_line), (25, 28));
+        assert!(analysis
+            .entities
+            .iter()
+            .all(|entity| entity.name != "cache:init/1#2"));
+        assert_eq!(
+            entity(&analysis, "cache:handle_call/3#2")
+                .signature
+                .as_deref(),
+            Some("handle_call(stop, _From, State)")
+        );
+    }
+}
@@ -0,0 +1,409 @@
+//! Go build constraints.
+//!
+//! `go build` compiles a file only when its build constraints hold for the
+//! target: the `//go:build` expression in the file header (or, in code that
+//! predates Go 1.17, its `// +build` lines) and the `_GOOS`, `_GOARCH` or
+//! `_GOOS_GOARCH` suffix of the file name. [`build_constraints`] extracts them
+//! as `//go:build` expressions and [`BuildTarget::satisfies`] evaluates them
+//! for a `GOOS`/`GOARCH` pair and a set of `-tags`.
+
+use std::path::Path;
+
+/// `GOOS` values recognized in file name suffixes.
+pub const KNOWN_OS: &[&str] = &[
+    "aix",
+    "android",
+    "darwin",
+    "dragonfly",
+    "freebsd",
+    "hurd",
+    "illumos",
+    "ios",
+    "js",
+    "linux",
+    "nacl",
+    "netbsd",
+    "openbsd",
+    "plan9",
+    "solaris",
+    "wasip1",
+    "windows",
+    "zos",
+];
+
+/// `GOARCH` values recognized in file name suffixes.
+pub const KNOWN_ARCH: &[&str] = &[
+    "386",
+    "amd64",
+    "amd64p32",
+    "arm",
+    "armbe",
+    "arm64",
+    "arm64be",
+    "loong64",
+    "mips",
+    "mipsle",
+    "mips64",
+    "mips64le",
+    "mips64p32",
+    "mips64p32le",
+    "ppc",
+    "ppc64",
+    "ppc64le",
+    "riscv",
+    "riscv64",
+    "s390",
+    "s390x",
+    "sparc",
+    "sparc64",
+    "wasm",
+];
+
+/// `GOOS` values that satisfy the `unix` constraint.
+const UNIX_OS: &[&str] = &[
+    "aix",
+    "android",
+    "darwin",
+    "dragonfly",
+    "freebsd",
+    "hurd",
+    "illumos",
+    "ios",
+    "linux",
+    "netbsd",
+    "openbsd",
+    "solaris",
+];
+
+/// Prefix of a Go 1.17+ constraint line.
+const GO_BUILD_PREFIX: &str = "//go:build";
+
+/// Prefix of a legacy constraint line.
+const PLUS_BUILD_PREFIX: &str = "// +build";
+
+/// The platform and tags a build is for, as `GOOS`, `GOARCH` and `-tags`.
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct BuildTarget {
+    /// Target operating system, e.g. `linux`.
+    pub os: String,
+    /// Target architecture, e.g. `amd64`.
+    pub arch: String,
+    /// Extra tags enabled with `-tags`.
+    pub tags: Vec<String>,
+}
+
+/// Construction and evaluation methods for [`BuildTarget`].
+impl BuildTarget {
+    /// Target for `os` and `arch`; either defaults to the host's, like an
+    /// unset `GOOS` or `GOARCH`.
+    pub fn new(os: Option<&str>, arch: Option<&str>, tags: Vec<String>) -> Self {
+        Self {
+            os: os.map_or_else(host_os, str::to_string),
+            arch: arch.map_or_else(host_arch, str::to_string),
+            tags,
+        }
+    }
+
+    /// True when every constraint holds for this target. Malformed
+    /// expressions are treated as satisfied so a typo never hides a file.
+    pub fn satisfies(&self, constraints: &[String]) -> bool {
+        constraints
+            .iter()
+            .all(|expr| evaluate(expr, &|tag| self.has_tag(tag)).unwrap_or(true))
+    }
+
+    /// True when `go build` would compile the file at `path` for this target.
+    pub fn includes_file(&self, path: &Path, source: &str) -> bool {
+        self.satisfies(&build_constraints(path, source))
+    }
+
+    /// Whether a single tag is set. Besides `GOOS`, `GOARCH` and `-tags`, this
+    /// covers the tags the go tool implies: `unix`, `gc`, every `go1.N`
+    /// release tag, and `linux`/`solaris`/`darwin` on android, illumos and ios.
+    fn has_tag(&self, tag: &str) -> bool {
+        tag == self.os
+            || tag == self.arch
+            || self.tags.iter().any(|t| t == tag)
+            || tag == "gc"
+            || tag.starts_with("go1.")
+            || (tag == "unix" && UNIX_OS.contains(&self.os.as_str()))
+            || matches!(
+                (self.os.as_str(), tag),
+                ("android", "linux") | ("illumos", "solaris") | ("ios", "darwin")
+            )
+    }
+}
+
+/// Build constraints of a Go file as `//go:build` expressions, all of which
+/// must hold: the header constraint, then the file name suffix constraint.
+pub fn build_constraints(path: &Path, source: &str) -> Vec<String> {
+    let mut constraints = header_constraints(source);
+    constraints.extend(file_name_constraint(path));
+    constraints
+}
+
+/// Constraint lines from the comments that precede the package clause. A
+/// `//go:build` line wins over `// +build` lines, as it does for the go tool.
+fn header_constraints(source: &str) -> Vec<String> {
+    let mut plus_build = Vec::new();
+    let mut in_block = false;
+    for line in source.lines() {
+        let line = line.trim();
+        if in_block {
+            in_block = !line.contains("*/");
+            continue;
+        }
+        if line.is_empty() {
+            continue;
+        }
+        if let Some(rest) = line.strip_prefix(GO_BUILD_PREFIX) {
+            if rest.is_empty() || rest.starts_with(char::is_whitespace) {
+                return vec![rest.trim().to_string()];
+            }
+        } else if let Some(rest) = line.strip_prefix(PLUS_BUILD_PREFIX) {
+            if rest.is_empty() || rest.starts_with(char::is_whitespace) {
+                plus_build.push(plus_build_expression(rest));
+            }
+        } else if line.starts_with("/*") {
+            in_block = !line.contains("*/");
+        } else if !line.starts_with("//") {
+            break;
+        }
+    }
+    plus_build
+}
+
+/// Translate the options of a `// +build` line (space = or, comma = and) into
+/// a `//go:build` expression.
+fn plus_build_expression(options: &str) -> String {
+    let terms: Vec<Vec<&str>> = options
+        .split_whitespace()
+        .map(|term| term.split(',').filter(|f| !f.is_empty()).collect())
+        .filter(|factors: &Vec<&str>| !factors.is_empty())
+        .collect();
+    terms
+        .iter()
+        .map(|factors| {
+            let joined = factors.join(" && ");
+            if factors.len() > 1 && terms.len() > 1 {
+                format!("({joined})")
+            } else {
+                joined
+            }
+        })
+        .collect::<Vec<_>>()
+        .join(" || ")
+}
+
+/// Constraint implied by a `_GOOS`, `_GOARCH` or `_GOOS_GOARCH` suffix
+/// (before an optional `_test`), using the go tool's rules.
+fn file_name_constraint(path: &Path) -> Option<String> {
+    let name = path.file_name()?.to_str()?;
+    let name = name.split('.').next().unwrap_or(name);
+    let (_, suffix) = name.split_once('_')?;
+    let mut parts: Vec<&str> = suffix.split('_').collect();
+    if parts.last() == Some(&"test") {
+        parts.pop();
+    }
+    match parts.as_slice() {
+        [.., os, arch] if KNOWN_OS.contains(os) && KNOWN_ARCH.contains(arch) => {
+            Some(format!("{os} && {arch}"))
+        }
+        [.., last] if KNOWN_OS.contains(last) || KNOWN_ARCH.contains(last) => {
+            Some(last.to_string())
+        }
+        _ => None,
+    }
+}
+
+/// Evaluate a `//go:build` expression; `None` when it does not parse.
+fn evaluate(expr: &str, has_tag: &dyn Fn(&str) -> bool) -> Option<bool> {
+    let tokens = tokenize(expr)?;
+    let mut parser = Parser {
+        tokens: &tokens,
+        pos: 0,
+        has_tag,
+    };
+    let value = parser.or()?;
+    (parser.pos == tokens.len()).then_some(value)
+}
+
+/// Tokens of a `//go:build` expression.
+#[derive(Debug, Clone, PartialEq, Eq)]
+enum Token {
+    /// `(`
+    Open,
+    /// `)`
+    Close,
+    /// `!`
+    Not,
+    /// `&&`
+    And,
+    /// `||`
+    Or,
+    /// A build tag.
+    Tag(String),
+}
+
+/// Split an expression into tokens; `None` on a stray character.
+fn tokenize(expr: &str) -> Option<Vec<Token>> {
+    let mut tokens = Vec::new();
+    let mut chars = expr.chars().peekable();
+    while let Some(ch) = chars.next() {
+        match ch {
+            c if c.is_whitespace() => {}
+            '(' => tokens.push(Token::Open),
+            ')' => tokens.push(Token::Close),
+            '!' => tokens.push(Token::Not),
+            '&' if chars.next_if_eq(&'&').is_some() => tokens.push(Token::And),
+            '|' if chars.next_if_eq(&'|').is_some() => tokens.push(Token::Or),
+            c if c.is_alphanumeric() || c == '_' || c == '.' => {
+                let mut tag = c.to_string();
+                while let Some(next) =
+                    chars.next_if(|n| n.is_alphanumeric() || *n == '_' || *n == '.')
+                {
+                    tag.push(next);
+                }
+                tokens.push(Token::Tag(tag));
+            }
+            _ => return None,
+        }
+    }
+    Some(tokens)
+}
+
+/// Recursive-descent evaluator: `||` binds looser than `&&`, which binds
+/// looser than `!`.
+struct Parser<'a> {
+    tokens: &'a [Token],
+    pos: usize,
+    has_tag: &'a dyn Fn(&str) -> bool,
+}
+
+/// Grammar rules for [`Parser`].
+impl Parser<'_> {
+    /// `and ('||' and)*`
+    fn or(&mut self) -> Option<bool> {
+        let mut value = self.and()?;
+        while self.eat(&Token::Or) {
+            value |= self.and()?;
+        }
+        Some(value)
+    }
+
+    /// `not ('&&' not)*`
+    fn and(&mut self) -> Option<bool> {
+        let mut value = self.not()?;
+        while self.eat(&Token::And) {
+            value &= self.not()?;
+        }
+        Some(value)
+    }
+
+    /// `'!' not | '(' or ')' | tag`
+    fn not(&mut self) -> Option<bool> {
+        if self.eat(&Token::Not) {
+            return self.not().map(|value| !value);
+        }
+        if self.eat(&Token::Open) {
+            let value = self.or()?;
+            return self.eat(&Token::Close).then_some(value);
+        }
+        match self.tokens.get(self.pos)? {
+            Token::Tag(tag) => {
+                self.pos += 1;
+                Some((self.has_tag)(tag))
+            }
+            _ => None,
+        }
+    }
+
+    /// Consume the next token if it is `token`.
+    fn eat(&mut self, token: &Token) -> bool {
+        let matched = self.tokens.get(self.pos) == Some(token);
+        if matched {
+            self.pos += 1;
+        }
+        matched
+    }
+}
+
+/// Host `GOOS`, translated from Rust's name for it.
+fn host_os() -> String {
+    match std::env::consts::OS {
+        "macos" => "darwin",
+        other => other,
+    }
+    .to_string()
+}
+
+/// Host `GOARCH`, translated from Rust's name for it.
+fn host_arch() -> String {
+    match std::env::consts::ARCH {
+        "x86_64" => "amd64",
+        "x86" => "386",
+        "aarch64" => "arm64",
+        "powerpc64" => "ppc64",
+        "loongarch64" => "loong64",
+        other => other,
+    }
+    .to_string()
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn target(os: &str, arch: &str, tags: &[&str]) -> BuildTarget {
+        BuildTarget::new(
+            Some(os),
+            Some(arch),
+            tags.iter().map(|t| t.to_string()).collect(),
+        )
+    }
+
+    #[test]
+    fn extracts_header_and_file_name_constraints() {
+        let go_build = "// Copyright 2024\n\n//go:build linux && (amd64 || arm64)\n// +build linux\n\npackage sys\n";
+        assert_eq!(
+            build_constraints(Path::new("sys/poll.go"), go_build),
+            vec!["linux && (amd64 || arm64)"]
+        );
+
+        let plus_build =
+            "/* legacy */\n// +build linux,386 darwin\n// +build !cgo\n\npackage sys\n";
+        assert_eq!(
+            build_constraints(Path::new("sys/poll_windows_amd64_test.go"), plus_build),
+            vec!["(linux && 386) || darwin", "!cgo", "windows && amd64"]
+        );
+
+        let late = "package sys\n\n//go:build linux\n";
+        assert!(build_constraints(Path::new("sys/linux.go"), late).is_empty());
+        assert_eq!(
+            build_constraints(Path::new("sys/zerrors_arm64.go"), late),
+            vec!["arm64"]
+        );
+    }
+
+    #[test]
+    fn evaluates_constraints_like_go_build() {
+        let linux = target("linux", "amd64", &[]);
+        let darwin = target("darwin", "arm64", &["integration"]);
+        let android = target("android", "arm64", &[]);
+
+        let expr = |e: &str| vec![e.to_string()];
+        assert!(linux.satisfies(&expr("linux && (amd64 || arm64)")));
+        assert!(!darwin.satisfies(&expr("linux && (amd64 || arm64)")));
+        assert!(darwin.satisfies(&expr("unix && !windows && go1.18")));
+        assert!(darwin.satisfies(&expr("integration")));
+        assert!(!linux.satisfies(&expr("integration || ignore")));
+        assert!(android.satisfies(&expr("linux")));
+        assert!(linux.satisfies(&expr("linux &&")));
+        assert!(linux.satisfies(&[]));
+
+        let source = "//go:build !windows\n\npackage sys\n";
+        assert!(linux.includes_file(Path::new("sys/poll_linux.go"), source));
+        assert!(!darwin.includes_file(Path::new("sys/poll_linux.go"), source));
+        assert!(!target("windows", "amd64", &[]).includes_file(Path::new("sys/poll.go"), source));
+    }
+}
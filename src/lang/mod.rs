@@ -1,26 +1,58 @@
 //! Language-specific parsing and AST processing modules.
 
 pub mod adapters;
+pub mod buffer_pool;
 pub mod common;
+pub mod detection;
+pub mod doc_comments;
+pub mod dockerfile;
+pub mod elixir;
+pub mod erlang;
+pub mod go_build;
+pub mod notebook;
+pub mod plugins;
+pub mod protobuf;
 pub mod registry;
+pub mod ruby;
+pub mod sql;
+pub mod swift;
+pub mod terraform;
 
 // Re-export adapters for backward compatibility
+pub use adapters::c;
 pub use adapters::cpp;
+pub use adapters::csharp;
 pub use adapters::go;
+pub use adapters::java;
 pub use adapters::javascript;
+pub use adapters::kotlin;
 pub use adapters::python;
 pub use adapters::rust_lang;
 pub use adapters::typescript;
 
 // Re-export common types and traits for easier access
 pub use common::{EntityKind, LanguageAdapter, ParseIndex, ParsedEntity, SourceLocation};
+pub use detection::{detect_language, resolve_file_language, LanguageMatch};
+pub use doc_comments::format_doc_comments;
+pub use dockerfile::{DockerGraph, DockerfileParser};
+pub use elixir::ElixirParser;
+pub use erlang::ErlangParser;
+pub use go_build::{build_constraints, BuildTarget};
+pub use notebook::{is_notebook_path, Notebook, NotebookMetadata};
+pub use plugins::{FileAnalysis, LanguageParser, PluginConfig, PluginRegistry};
+pub use protobuf::{ProtoGraph, ProtoParser};
 pub use registry::{
     adapter_for_file, adapter_for_language, create_parser_for_language, detect_language_from_path,
     extension_is_supported, get_tree_sitter_language, language_key_for_path, registered_languages,
     LanguageInfo, LanguageStability,
 };
+pub use ruby::RubyParser;
+pub use sql::{GoEmbeds, SqlParser};
+pub use swift::{merge_types as merge_swift_types, SwiftParser, SwiftType};
+pub use terraform::{TerraformGraph, TerraformParser};
 
 // Re-export individual adapters
 pub use adapters::{
-    CppAdapter, GoAdapter, JavaScriptAdapter, PythonAdapter, RustAdapter, TypeScriptAdapter,
+    CAdapter, CSharpAdapter, CppAdapter, GoAdapter, JavaAdapter, JavaScriptAdapter, KotlinAdapter,
+    PythonAdapter, RustAdapter, TypeScriptAdapter,
 };
@@ -0,0 +1,258 @@
+//! Jupyter notebook (`.ipynb`) support.
+//!
+//! The code cells of a Python notebook are concatenated, in order, into one
+//! virtual Python file that the regular Python adapter analyzes. Each cell is
+//! preceded by a `# %% [cell N]` marker line, so cell boundaries survive as
+//! virtual line numbers; [`NotebookCell`] records where every cell landed.
+//! Markdown and raw cells, and all cell outputs, are dropped.
+
+use std::path::{Path, PathBuf};
+
+use serde::{Deserialize, Serialize};
+use serde_json::Value;
+use tracing::warn;
+
+use crate::core::errors::{Result, ValknutError};
+use crate::core::file_utils::relative_to_root;
+
+/// File extension of Jupyter notebooks.
+pub const NOTEBOOK_EXTENSION: &str = "ipynb";
+
+/// Whether `path` names a Jupyter notebook.
+pub fn is_notebook_path(path: &Path) -> bool {
+    path.extension()
+        .is_some_and(|ext| ext.eq_ignore_ascii_case(NOTEBOOK_EXTENSION))
+}
+
+/// Source to analyze for `path`: notebooks are replaced by their extracted
+/// Python code, other files are returned unchanged. A notebook that cannot be
+/// parsed yields empty source so the rest of the run is unaffected.
+pub fn analysis_source(path: &Path, content: String) -> String {
+    if !is_notebook_path(path) {
+        return content;
+    }
+    match Notebook::parse(path, &content) {
+        Ok(notebook) => notebook.source,
+        Err(err) => {
+            warn!("Skipping notebook {}: {}", path.display(), err);
+            String::new()
+        }
+    }
+}
+
+/// Metadata of every notebook among `files`, read from disk.
+/// Notebooks that cannot be read or parsed are left out.
+pub fn collect_notebook_metadata(files: &[PathBuf]) -> Vec<NotebookMetadata> {
+    files
+        .iter()
+        .filter(|path| is_notebook_path(path))
+        .filter_map(|path| {
+            let json = std::fs::read_to_string(path).ok()?;
+            Notebook::parse(path, &json).ok()
+        })
+        .map(|notebook| notebook.metadata)
+        .collect()
+}
+
+/// Where one code cell landed in the extracted source.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct NotebookCell {
+    /// Position of the cell in the notebook, counting markdown and raw cells.
+    pub index: usize,
+    /// First virtual line of the cell's code (1-based).
+    pub start_line: usize,
+    /// Last virtual line of the cell's code (inclusive).
+    pub end_line: usize,
+}
+
+/// Kernel and cell layout of an analyzed notebook.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct NotebookMetadata {
+    /// Path of the notebook.
+    pub path: PathBuf,
+    /// Kernel name from `metadata.kernelspec.name`, e.g. `python3`.
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub kernel_name: Option<String>,
+    /// Kernel language from `metadata.kernelspec.language` or `metadata.language_info.name`.
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub language: Option<String>,
+    /// Code cells that were extracted, in notebook order.
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub cells: Vec<NotebookCell>,
+}
+
+/// Methods for [`NotebookMetadata`].
+impl NotebookMetadata {
+    /// The same metadata with `path` made relative to `root`.
+    pub fn relative_to(&self, root: &Path) -> Self {
+        Self {
+            path: relative_to_root(&self.path, root),
+            ..self.clone()
+        }
+    }
+
+    /// Whether the kernel runs Python; notebooks that do not declare a
+    /// language are assumed to.
+    pub fn is_python(&self) -> bool {
+        self.language
+            .as_deref()
+            .map_or(true, |language| language.eq_ignore_ascii_case("python"))
+    }
+}
+
+/// A parsed notebook: its metadata plus the extracted Python source.
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct Notebook {
+    /// Kernel and cell layout.
+    pub metadata: NotebookMetadata,
+    /// Code cells joined into one Python file; empty for non-Python kernels.
+    pub source: String,
+}
+
+/// Parsing methods for [`Notebook`].
+impl Notebook {
+    /// Parse nbformat 4 JSON and extract its code cells.
+    pub fn parse(path: &Path, json: &str) -> Result<Self> {
+        let document: Value = serde_json::from_str(json)
+            .map_err(|e| ValknutError::parse("ipynb", format!("invalid notebook JSON: {e}")))?;
+        let cells = document
+            .get("cells")
+            .and_then(Value::as_array)
+            .ok_or_else(|| {
+                ValknutError::parse(
+                    "ipynb",
+                    "notebook has no `cells` list (nbformat 4 required)",
+                )
+            })?;
+
+        let meta = document.get("metadata");
+        let kernelspec = meta.and_then(|meta| meta.get("kernelspec"));
+        let text = |value: Option<&Value>| value.and_then(Value::as_str).map(str::to_string);
+        let mut metadata = NotebookMetadata {
+            path: path.to_path_buf(),
+            kernel_name: text(kernelspec.and_then(|spec| spec.get("name"))),
+            language: text(kernelspec.and_then(|spec| spec.get("language"))).or_else(|| {
+                text(
+                    meta.and_then(|meta| meta.get("language_info"))
+                        .and_then(|info| info.get("name")),
+                )
+            }),
+            cells: Vec::new(),
+        };
+        if !metadata.is_python() {
+            return Ok(Self {
+                metadata,
+                source: String::new(),
+            });
+        }
+
+        let mut source = String::new();
+        let mut line = 0;
+        for (index, cell) in cells.iter().enumerate() {
+            if cell.get("cell_type").and_then(Value::as_str) != Some("code") {
+                continue;
+            }
+            let code = cell_source(cell);
+            if code.trim().is_empty() {
+                continue;
+            }
+            source.push_str(&format!("# %% [cell {index}]\n"));
+            line += 1;
+            let start_line = line + 1;
+            // A `%%bash`-style cell magic makes the whole cell foreign code.
+            let foreign = code.trim_start().starts_with("%%");
+            for code_line in code.lines() {
+                if foreign || is_magic_line(code_line) {
+                    source.push_str("# ");
+                }
+                source.push_str(code_line);
+                source.push('\n');
+                line += 1;
+            }
+            metadata.cells.push(NotebookCell {
+                index,
+                start_line,
+                end_line: line,
+            });
+        }
+
+        Ok(Self { metadata, source })
+    }
+}
+
+/// A cell's `source`, which nbformat stores as a string or a list of lines.
+fn cell_source(cell: &Value) -> String {
+    match cell.get("source") {
+        Some(Value::String(source)) => source.clone(),
+        Some(Value::Array(lines)) => lines.iter().filter_map(Value::as_str).collect(),
+        _ => String::new(),
+    }
+}
+
+/// IPython line magics (`%timeit`) and shell escapes (`!pip install`) are not
+/// Python; they are commented out so the line numbering is kept.
+fn is_magic_line(line: &str) -> bool {
+    let trimmed = line.trim_start();
+    trimmed.starts_with('%') || trimmed.starts_with('!')
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    const NOTEBOOK: &str = r##"{
+      "nbformat": 4,
+      "metadata": {
+        "kernelspec": {"name": "python3", "language": "python", "display_name": "Python 3"}
+      },
+      "cells": [
+        {"cell_type": "markdown", "source": ["# Load data\n"]},
+        {"cell_type": "code", "source": ["import pandas as pd\n", "%matplotlib inline\n"],
+         "outputs": [{"output_type": "stream", "text": ["ignored\n"]}]},
+        {"cell_type": "code", "source": ""},
+        {"cell_type": "code", "source": "def load(path):\n    return pd.read_csv(path)"},
+        {"cell_type": "code", "source": "%%bash\nls data/"}
+      ]
+    }"##;
+
+    #[test]
+    fn extracts_code_cells_with_virtual_lines() {
+        let notebook = Notebook::parse(Path::new("etl.ipynb"), NOTEBOOK).unwrap();
+
+        assert_eq!(notebook.metadata.kernel_name.as_deref(), Some("python3"));
+        assert_eq!(notebook.metadata.language.as_deref(), Some("python"));
+        assert_eq!(
+            notebook.source,
+            "# %% [cell 1]\nimport pandas as pd\n# %matplotlib inline\n\
+             # %% [cell 3]\ndef load(path):\n    return pd.read_csv(path)\n\
+             # %% [cell 4]\n# %%bash\n# ls data/\n"
+        );
+        let ranges: Vec<_> = notebook
+            .metadata
+            .cells
+            .iter()
+            .map(|cell| (cell.index, cell.start_line, cell.end_line))
+            .collect();
+        assert_eq!(ranges, vec![(1, 2, 3), (3, 5, 6), (4, 8, 9)]);
+    }
+
+    #[test]
+    fn non_python_kernels_and_other_files_are_handled() {
+        let julia = r#"{"metadata": {"language_info": {"name": "julia"}},
+                        "cells": [{"cell_type": "code", "source": "x = 1"}]}"#;
+        let notebook = Notebook::parse(Path::new("model.ipynb"), julia).unwrap();
+        assert_eq!(notebook.metadata.language.as_deref(), Some("julia"));
+        assert!(notebook.source.is_empty());
+        assert!(notebook.metadata.cells.is_empty());
+
+        assert!(Notebook::parse(Path::new("bad.ipynb"), "{}").is_err());
+        assert_eq!(
+            analysis_source(Path::new("bad.ipynb"), "not json".into()),
+            ""
+        );
+        assert_eq!(
+            analysis_source(Path::new("app.py"), "x = 1\n".into()),
+            "x = 1\n"
+        );
+    }
+}
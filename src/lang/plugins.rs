@@ -0,0 +1,660 @@
+//! Out-of-process language parser plugins.
+//!
+//! A plugin is any executable placed in the plugin directory
+//! (`plugins.directory`, default `.valknut/plugins`). Running plugins as child
+//! processes keeps the host isolated: a plugin that crashes, hangs, or prints
+//! garbage produces an error for that file instead of taking valknut down, and
+//! plugins can be written in any language without linking against valknut.
+//!
+//! Plugins speak JSON over stdio:
+//!
+//! - `<plugin> describe` prints the plugin's [`PluginManifest`], e.g.
+//!   `{"name": "rules-dsl", "extensions": ["rules"]}`.
+//! - `<plugin> parse <path>` receives the file contents on stdin and prints a
+//!   [`FileAnalysis`], e.g.
+//!   `{"entities": [{"name": "deny_all", "kind": "Function", "start_line": 1, "end_line": 4}]}`.
+//!
+//! A non-zero exit status is treated as a parse failure and the plugin's
+//! stderr is included in the error. Each invocation is killed after
+//! `plugins.timeout_ms`.
+
+use std::collections::BTreeMap;
+use std::io::{Read, Write};
+use std::path::{Path, PathBuf};
+use std::process::{Command, Stdio};
+use std::thread;
+use std::time::{Duration, Instant};
+
+use serde::{Deserialize, Serialize};
+use tracing::warn;
+
+use crate::core::errors::{Result, ValknutError};
+use crate::lang::buffer_pool::SOURCE_BYTES;
+use crate::lang::common::EntityKind;
+use crate::lang::dockerfile::{is_dockerfile, DockerfileParser, DOCKERFILE_LANGUAGE};
+use crate::lang::elixir::ElixirParser;
+use crate::lang::erlang::ErlangParser;
+use crate::lang::protobuf::ProtoParser;
+use crate::lang::ruby::RubyParser;
+use crate::lang::sql::SqlParser;
+use crate::lang::swift::SwiftParser;
+use crate::lang::terraform::TerraformParser;
+
+/// How often a running plugin is polled for completion.
+const POLL_INTERVAL: Duration = Duration::from_millis(10);
+
+/// Plugin discovery settings.
+#[derive(Debug, Clone, PartialEq, Serialize, Deserialize)]
+pub struct PluginConfig {
+    /// Directory scanned for plugin executables.
+    #[serde(default = "default_plugin_directory")]
+    pub directory: PathBuf,
+
+    /// Maximum time a single plugin invocation may run, in milliseconds.
+    #[serde(default = "default_plugin_timeout_ms")]
+    pub timeout_ms: u64,
+}
+
+/// Default plugin directory, relative to the working directory.
+fn default_plugin_directory() -> PathBuf {
+    PathBuf::from(".valknut/plugins")
+}
+
+/// Default per-invocation plugin timeout.
+const fn default_plugin_timeout_ms() -> u64 {
+    10_000
+}
+
+/// Default implementation for [`PluginConfig`].
+impl Default for PluginConfig {
+    /// Returns the default plugin settings.
+    fn default() -> Self {
+        Self {
+            directory: default_plugin_directory(),
+            timeout_ms: default_plugin_timeout_ms(),
+        }
+    }
+}
+
+/// Validation methods for [`PluginConfig`].
+impl PluginConfig {
+    /// Validate plugin settings.
+    pub fn validate(&self) -> Result<()> {
+        if self.timeout_ms == 0 {
+            return Err(ValknutError::config_field(
+                "Plugin timeout must be greater than 0",
+                "plugins.timeout_ms",
+            ));
+        }
+        Ok(())
+    }
+}
+
+/// Identity and file extensions reported by a plugin's `describe` command.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct PluginManifest {
+    /// Display name of the language or parser.
+    pub name: String,
+    /// File extensions handled, without leading dots.
+    pub extensions: Vec<String>,
+}
+
+/// An entity declared in a file parsed by a plugin.
+#[derive(Debug, Clone, PartialEq, Serialize, Deserialize)]
+pub struct PluginEntity {
+    /// Entity name.
+    pub name: String,
+    /// Entity kind (`Function`, `Class`, `Struct`, ...).
+    pub kind: EntityKind,
+    /// 1-based first line.
+    pub start_line: usize,
+    /// 1-based last line.
+    pub end_line: usize,
+    /// Name of the enclosing entity, if any.
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub parent: Option<String>,
+    /// Language-specific block or declaration keyword (`resource`, `variable`, ...).
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub block_type: Option<String>,
+    /// Attribute or field names declared directly in the entity.
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub attributes: Vec<String>,
+    /// Names of other entities this entity references.
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub depends_on: Vec<String>,
+    /// Rendered signature of a function or method, e.g. `find(id, scope: ...)`.
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub signature: Option<String>,
+}
+
+/// Everything a plugin reports about one file.
+#[derive(Debug, Clone, Default, PartialEq, Serialize, Deserialize)]
+pub struct FileAnalysis {
+    /// Language of the file, when the parser reports one.
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub language: Option<String>,
+    /// Declared entities.
+    #[serde(default)]
+    pub entities: Vec<PluginEntity>,
+    /// Modules or files the source imports.
+    #[serde(default)]
+    pub imports: Vec<String>,
+    /// Database tables the file declares or queries (SQL files).
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub tables_referenced: Vec<String>,
+}
+
+/// A parser for a language valknut does not support natively.
+pub trait LanguageParser: Send + Sync {
+    /// Display name of the parser.
+    fn name(&self) -> &str;
+
+    /// File extensions handled, without leading dots.
+    fn extensions(&self) -> &[String];
+
+    /// Parse one file.
+    fn parse(&self, path: &Path, source: &[u8]) -> Result<FileAnalysis>;
+}
+
+/// Decode `source` for a built-in parser, failing with a parse error located
+/// at the first byte that is not valid UTF-8.
+pub fn decode_source<'a>(language: &str, path: &Path, source: &'a [u8]) -> Result<&'a str> {
+    std::str::from_utf8(source).map_err(|e| {
+        let valid = &source[..e.valid_up_to()];
+        let line_start = valid
+            .iter()
+            .rposition(|&byte| byte == b'\n')
+            .map_or(0, |newline| newline + 1);
+        ValknutError::parse_with_location(
+            language,
+            format!("{} is not valid UTF-8: {e}", path.display()),
+            path.display().to_string(),
+            Some(valid.iter().filter(|&&byte| byte == b'\n').count() + 1),
+            Some(valid.len() - line_start + 1),
+        )
+    })
+}
+
+/// A [`LanguageParser`] backed by a plugin executable.
+#[derive(Debug, Clone)]
+pub struct ExternalParser {
+    executable: PathBuf,
+    manifest: PluginManifest,
+    timeout: Duration,
+}
+
+/// Loading methods for [`ExternalParser`].
+impl ExternalParser {
+    /// Start the plugin with `describe` and validate its manifest.
+    pub fn load(executable: &Path, timeout: Duration) -> Result<Self> {
+        let output = run_plugin(executable, &["describe"], &[], timeout, None)?;
+        let mut manifest: PluginManifest = serde_json::from_slice(&output)
+            .map_err(|e| plugin_error(executable, format!("invalid describe output: {e}")))?;
+        if manifest.name.trim().is_empty() {
+            return Err(plugin_error(executable, "describe returned an empty name"));
+        }
+        for extension in &mut manifest.extensions {
+            *extension = extension.trim_start_matches('.').to_ascii_lowercase();
+        }
+        manifest
+            .extensions
+            .retain(|extension| !extension.is_empty());
+        if manifest.extensions.is_empty() {
+            return Err(plugin_error(executable, "describe returned no extensions"));
+        }
+
+        Ok(Self {
+            executable: executable.to_path_buf(),
+            manifest,
+            timeout,
+        })
+    }
+
+    /// Path of the plugin executable.
+    pub fn executable(&self) -> &Path {
+        &self.executable
+    }
+}
+
+/// [`LanguageParser`] implementation for [`ExternalParser`].
+impl LanguageParser for ExternalParser {
+    fn name(&self) -> &str {
+        &self.manifest.name
+    }
+
+    fn extensions(&self) -> &[String] {
+        &self.manifest.extensions
+    }
+
+    fn parse(&self, path: &Path, source: &[u8]) -> Result<FileAnalysis> {
+        let path_arg = path.to_string_lossy();
+        let output = run_plugin(
+            &self.executable,
+            &["parse", &path_arg],
+            source,
+            self.timeout,
+            Some(path),
+        )?;
+        serde_json::from_slice(&output).map_err(|e| {
+            plugin_error(
+                &self.executable,
+                format!("invalid parse output for {}: {e}", path.display()),
+            )
+        })
+    }
+}
+
+/// A plugin that could not be loaded.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize)]
+pub struct PluginLoadError {
+    /// Plugin executable.
+    pub path: PathBuf,
+    /// Why loading failed.
+    pub message: String,
+}
+
+/// Loaded plugins, indexed by file extension.
+#[derive(Default)]
+pub struct PluginRegistry {
+    parsers: Vec<Box<dyn LanguageParser>>,
+    by_extension: BTreeMap<String, usize>,
+    errors: Vec<PluginLoadError>,
+}
+
+/// Loading and lookup methods for [`PluginRegistry`].
+impl PluginRegistry {
+    /// Load every plugin executable in the configured directory.
+    ///
+    /// A missing directory yields an empty registry. Plugins that fail to load
+    /// are recorded in [`errors`](Self::errors) rather than aborting the load.
+    pub fn load(config: &PluginConfig) -> Self {
+        let mut registry = Self::default();
+        registry.load_directory(config);
+        registry
+    }
+
+    /// Register the built-in parsers, then load the plugin directory.
+    ///
+    /// Built-in parsers claim their extensions first, so a plugin cannot
+    /// shadow them.
+    pub fn with_builtins(config: &PluginConfig) -> Self {
+        let mut registry = Self::default();
+        registry.register(Box::new(TerraformParser::default()));
+        registry.register(Box::new(RubyParser::new(
+            "ruby",
+            Duration::from_millis(config.timeout_ms),
+        )));
+        registry.register(Box::new(SqlParser::default()));
+        registry.register(Box::new(ProtoParser::default()));
+        registry.register(Box::new(ElixirParser::default()));
+        registry.register(Box::new(ErlangParser::default()));
+        registry.register(Box::new(SwiftParser::default()));
+        registry.register(Box::new(DockerfileParser::default()));
+        registry.load_directory(config);
+        registry
+    }
+
+    /// Load every plugin executable in the configured directory into `self`.
+    fn load_directory(&mut self, config: &PluginConfig) {
+        let timeout = Duration::from_millis(config.timeout_ms);
+        for executable in plugin_executables(&config.directory) {
+            match ExternalParser::load(&executable, timeout) {
+                Ok(parser) => self.register(Box::new(parser)),
+                Err(err) => {
+                    warn!("Skipping plugin {}: {}", executable.display(), err);
+                    self.errors.push(PluginLoadError {
+                        path: executable,
+                        message: err.to_string(),
+                    });
+                }
+            }
+        }
+    }
+
+    /// Add a parser. Extensions already claimed by an earlier parser are not reassigned.
+    pub fn register(&mut self, parser: Box<dyn LanguageParser>) {
+        let index = self.parsers.len();
+        for extension in parser.extensions() {
+            if let Some(&owner) = self.by_extension.get(extension) {
+                warn!(
+                    "Plugin {} also handles .{}, which {} already claimed",
+                    parser.name(),
+                    extension,
+                    self.parsers[owner].name()
+                );
+                continue;
+            }
+            self.by_extension.insert(extension.clone(), index);
+        }
+        self.parsers.push(parser);
+    }
+
+    /// Loaded parsers in load order.
+    pub fn parsers(&self) -> impl Iterator<Item = &dyn LanguageParser> {
+        self.parsers.iter().map(|parser| parser.as_ref())
+    }
+
+    /// Plugins that failed to load.
+    pub fn errors(&self) -> &[PluginLoadError] {
+        &self.errors
+    }
+
+    /// The parser registered for `path`'s extension, if any. Dockerfiles
+    /// named `Dockerfile` or `Dockerfile.<variant>` count as `.dockerfile`.
+    pub fn parser_for(&self, path: &Path) -> Option<&dyn LanguageParser> {
+        let extension = if is_dockerfile(path) {
+            DOCKERFILE_LANGUAGE.to_string()
+        } else {
+            path.extension()?.to_string_lossy().to_ascii_lowercase()
+        };
+        self.by_extension
+            .get(&extension)
+            .map(|&index| self.parsers[index].as_ref())
+    }
+
+    /// Parse `path` with the plugin registered for its extension.
+    pub fn parse_file(&self, path: &Path) -> Result<FileAnalysis> {
+        let parser = self.parser_for(path).ok_or_else(|| {
+            ValknutError::unsupported(format!("No plugin handles {}", path.display()))
+        })?;
+        let mut source = SOURCE_BYTES.take();
+        std::fs::File::open(path)
+            .and_then(|mut file| file.read_to_end(&mut source))
+            .map_err(|e| ValknutError::io(format!("Failed to read {}", path.display()), e))?;
+        parser.parse(path, &source)
+    }
+}
+
+/// Executables directly inside `dir`, sorted by path.
+fn plugin_executables(dir: &Path) -> Vec<PathBuf> {
+    let Ok(entries) = std::fs::read_dir(dir) else {
+        return Vec::new();
+    };
+    let mut executables: Vec<PathBuf> = entries
+        .filter_map(|entry| entry.ok().map(|entry| entry.path()))
+        .filter(|path| is_executable(path))
+        .collect();
+    executables.sort();
+    executables
+}
+
+/// Returns true for regular files the current platform can execute.
+#[cfg(unix)]
+fn is_executable(path: &Path) -> bool {
+    use std::os::unix::fs::PermissionsExt;
+    path.metadata()
+        .is_ok_and(|meta| meta.is_file() && meta.permissions().mode() & 0o111 != 0)
+}
+
+/// Returns true for regular files the current platform can execute.
+#[cfg(not(unix))]
+fn is_executable(path: &Path) -> bool {
+    path.is_file()
+        && path.extension().is_some_and(|ext| {
+            ["exe", "bat", "cmd"]
+                .iter()
+                .any(|known| ext.eq_ignore_ascii_case(known))
+        })
+}
+
+/// Run a plugin command, feeding `stdin` and returning stdout on success.
+/// When the command is analyzing `file`, running past `timeout` is reported
+/// as an [`AnalysisTimeout`](ValknutError::AnalysisTimeout) for that file.
+pub(super) fn run_plugin(
+    executable: &Path,
+    args: &[&str],
+    stdin: &[u8],
+    timeout: Duration,
+    file: Option<&Path>,
+) -> Result<Vec<u8>> {
+    let mut child = Command::new(executable)
+        .args(args)
+        .stdin(Stdio::piped())
+        .stdout(Stdio::piped())
+        .stderr(Stdio::piped())
+        .spawn()
+        .map_err(|e| {
+            ValknutError::io(
+                format!("Failed to start plugin {}", executable.display()),
+                e,
+            )
+        })?;
+
+    // Pipes are serviced on their own threads so a chatty plugin cannot deadlock the host.
+    let input = stdin.to_vec();
+    let mut child_stdin = child.stdin.take();
+    let writer = thread::spawn(move || {
+        if let Some(pipe) = child_stdin.as_mut() {
+            // A plugin that exits without reading its input is not an error here.
+            let _ = pipe.write_all(&input);
+        }
+    });
+    let stdout = drain(child.stdout.take());
+    let stderr = drain(child.stderr.take());
+
+    let started = Instant::now();
+    let deadline = started + timeout;
+    let status = loop {
+        match child.try_wait() {
+            Ok(Some(status)) => break status,
+            Ok(None) if Instant::now() >= deadline => {
+                let _ = child.kill();
+                let _ = child.wait();
+                return Err(match file {
+                    Some(file) => ValknutError::analysis_timeout(
+                        file.display().to_string(),
+                        started.elapsed(),
+                    ),
+                    None => plugin_error(
+                        executable,
+                        format!("timed out after {} ms", timeout.as_millis()),
+                    ),
+                });
+            }
+            Ok(None) => thread::sleep(POLL_INTERVAL),
+            Err(e) => {
+                return Err(ValknutError::io(
+                    format!("Failed to wait for plugin {}", executable.display()),
+                    e,
+                ))
+            }
+        }
+    };
+
+    let _ = writer.join();
+    let stdout = stdout.join().unwrap_or_default();
+    let stderr = stderr.join().unwrap_or_default();
+    if !status.success() {
+        let stderr = String::from_utf8_lossy(&stderr);
+        return Err(plugin_error(
+            executable,
+            format!("exited with {status}: {}", stderr.trim()),
+        ));
+    }
+    Ok(stdout)
+}
+
+/// Read a child pipe to the end on a background thread.
+fn drain<R: Read + Send + 'static>(pipe: Option<R>) -> thread::JoinHandle<Vec<u8>> {
+    thread::spawn(move || {
+        let mut buffer = Vec::new();
+        if let Some(mut pipe) = pipe {
+            let _ = pipe.read_to_end(&mut buffer);
+        }
+        buffer
+    })
+}
+
+/// Error attributed to a specific plugin.
+pub(super) fn plugin_error(executable: &Path, message: impl std::fmt::Display) -> ValknutError {
+    ValknutError::internal(format!("Plugin {}: {}", executable.display(), message))
+}
+
+#[cfg(all(test, unix))]
+mod tests {
+    use super::*;
+    use std::fs;
+    use std::os::unix::fs::PermissionsExt;
+    use tempfile::tempdir;
+
+    fn write_plugin(dir: &Path, name: &str, script: &str) -> PathBuf {
+        let path = dir.join(name);
+        fs::write(&path, format!("#!/bin/sh\n{script}")).unwrap();
+        fs::set_permissions(&path, fs::Permissions::from_mode(0o755)).unwrap();
+        path
+    }
+
+    const RULES_PLUGIN: &str = r#"case "$1" in
+  describe) echo '{"name": "rules-dsl", "extensions": [".Rules"]}' ;;
+  parse) lines=$(wc -l); echo "{\"entities\": [{\"name\": \"deny_all\", \"kind\": \"Function\", \"start_line\": 1, \"end_line\": $lines}], \"imports\": [\"base\"]}" ;;
+esac
+"#;
+
+    fn config(dir: &Path) -> PluginConfig {
+        PluginConfig {
+            directory: dir.to_path_buf(),
+            timeout_ms: 2_000,
+        }
+    }
+
+    #[test]
+    fn loads_plugins_and_parses_files_by_extension() {
+        let tmp = tempdir().unwrap();
+        write_plugin(tmp.path(), "rules", RULES_PLUGIN);
+        fs::write(tmp.path().join("README.txt"), "not a plugin").unwrap();
+
+        let registry = PluginRegistry::load(&config(tmp.path()));
+        assert!(registry.errors().is_empty());
+        let names: Vec<&str> = registry.parsers().map(|parser| parser.name()).collect();
+        assert_eq!(names, vec!["rules-dsl"]);
+
+        let source = tmp.path().join("policy.rules");
+        fs::write(&source, "deny all\nunless admin\nend\n").unwrap();
+        let analysis = registry.parse_file(&source).unwrap();
+        assert_eq!(analysis.imports, vec!["base"]);
+        assert_eq!(analysis.entities[0].name, "deny_all");
+        assert_eq!(analysis.entities[0].kind, EntityKind::Function);
+        assert_eq!(analysis.entities[0].end_line, 3);
+
+        assert!(registry.parser_for(Path::new("main.go")).is_none());
+    }
+
+    #[test]
+    fn failing_plugins_are_reported_without_crashing_the_host() {
+        let tmp = tempdir().unwrap();
+        write_plugin(tmp.path(), "a-garbage", "echo not json\n");
+        write_plugin(tmp.path(), "b-hangs", "sleep 30\n");
+        write_plugin(
+            tmp.path(),
+            "c-crashes-on-parse",
+            r#"case "$1" in
+  describe) echo '{"name": "crashy", "extensions": ["crash"]}' ;;
+  parse) echo "boom" >&2; kill -SEGV $$ ;;
+esac
+"#,
+        );
+
+        let mut config = config(tmp.path());
+        config.timeout_ms = 300;
+        let registry = PluginRegistry::load(&config);
+
+        let failed: Vec<_> = registry
+            .errors()
+            .iter()
+            .map(|error| {
+                error
+                    .path
+                    .file_name()
+                    .unwrap()
+                    .to_string_lossy()
+                    .into_owned()
+            })
+            .collect();
+        assert_eq!(failed, vec!["a-garbage", "b-hangs"]);
+        assert!(registry.errors()[1].message.contains("timed out"));
+
+        let source = tmp.path().join("input.crash");
+        fs::write(&source, "x").unwrap();
+        let err = registry.parse_file(&source).unwrap_err();
+        assert!(err.to_string().contains("Plugin"), "{err}");
+    }
+
+    #[test]
+    fn slow_parses_time_out_for_the_file() {
+        let tmp = tempdir().unwrap();
+        write_plugin(
+            tmp.path(),
+            "slow",
+            r#"case "$1" in
+  describe) echo '{"name": "slow", "extensions": ["slow"]}' ;;
+  parse) sleep 30 ;;
+esac
+"#,
+        );
+        let mut config = config(tmp.path());
+        config.timeout_ms = 300;
+        let registry = PluginRegistry::load(&config);
+
+        let source = tmp.path().join("input.slow");
+        fs::write(&source, "x").unwrap();
+        let err = registry.parse_file(&source).unwrap_err();
+        assert_eq!(err.kind(), "analysis_timeout");
+        assert_eq!(err.file_path(), Some(source.to_string_lossy().as_ref()));
+    }
+
+    #[test]
+    fn invalid_utf8_is_located() {
+        let err =
+            decode_source("sql", Path::new("q.sql"), b"select 1;\nselect '\xff';").unwrap_err();
+        assert_eq!(err.file_path(), Some("q.sql"));
+        assert_eq!((err.line(), err.column()), (Some(2), Some(9)));
+    }
+
+    #[test]
+    fn missing_directory_loads_nothing() {
+        let registry = PluginRegistry::load(&config(Path::new("/definitely/missing/plugins")));
+        assert_eq!(registry.parsers().count(), 0);
+        assert!(registry.errors().is_empty());
+    }
+
+    #[test]
+    fn builtin_parsers_take_precedence_over_plugins() {
+        let tmp = tempdir().unwrap();
+        write_plugin(
+            tmp.path(),
+            "tf-shadow",
+            r#"echo '{"name": "tf-shadow", "extensions": ["tf"]}'
+"#,
+        );
+
+        let registry = PluginRegistry::with_builtins(&config(tmp.path()));
+        let names: Vec<&str> = registry.parsers().map(|parser| parser.name()).collect();
+        assert_eq!(
+            names,
+            vec![
+                "terraform",
+                "ruby",
+                "sql",
+                "protobuf",
+                "elixir",
+                "erlang",
+                "swift",
+                "dockerfile",
+                "tf-shadow"
+            ]
+        );
+
+        let source = tmp.path().join("main.tf");
+        fs::write(&source, "variable \"region\" {\n  default = \"eu\"\n}\n").unwrap();
+        let analysis = registry.parse_file(&source).unwrap();
+        assert_eq!(analysis.language.as_deref(), Some("terraform"));
+        assert_eq!(analysis.entities[0].name, "var.region");
+        assert_eq!(analysis.entities[0].attributes, vec!["default"]);
+
+        let dockerfile = tmp.path().join("Dockerfile.prod");
+        fs::write(&dockerfile, "FROM alpine:3.20 AS base\n").unwrap();
+        let analysis = registry.parse_file(&dockerfile).unwrap();
+        assert_eq!(analysis.language.as_deref(), Some("dockerfile"));
+        assert_eq!(analysis.entities[0].name, "base");
+    }
+}
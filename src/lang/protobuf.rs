@@ -0,0 +1,960 @@
+//! Built-in Protocol Buffers parser.
+//!
+//! [`ProtoParser`] reads proto3 (and most proto2) `.proto` files into a
+//! [`FileAnalysis`] with language `"protobuf"`:
+//!
+//! - `package` declarations become a `Module` entity;
+//! - messages become `Struct` entities with block type `message`, named by
+//!   their nesting (`Order.Item`). Their `attributes` are the field names in
+//!   declaration order, including `oneof` variants, and their `depends_on`
+//!   lists the message and enum types those fields use. Each field is a
+//!   `Variable` child (block type `field`) whose `signature` is its label,
+//!   type and number (`repeated string = 3`, `map<string, Item> = 4`);
+//! - `oneof` groups are `Enum` children of their message (block type
+//!   `oneof`) listing their variants, which are field children of the group;
+//! - enums are `Enum` entities (block type `enum`) whose `attributes` are the
+//!   value names;
+//! - services are `Interface` entities (block type `service`) whose RPCs are
+//!   `Method` children with a signature such as
+//!   `Watch(WatchRequest) returns (stream Event)`.
+//!
+//! Type names are reported as written, without a leading `.`. `import` paths
+//! are reported as [`FileAnalysis::imports`], and [`ProtoGraph`] resolves them
+//! across a tree into the dependency graph between `.proto` files.
+
+use std::collections::{BTreeMap, BTreeSet};
+use std::path::{Path, PathBuf};
+
+use ignore::WalkBuilder;
+use serde::{Deserialize, Serialize};
+use tracing::warn;
+
+use crate::core::errors::{Result, ValknutError};
+use crate::core::pipeline::discovery::IGNORE_FILE_NAME;
+use crate::lang::buffer_pool::source_chars;
+use crate::lang::common::{self, line_end, EntityKind, Lexeme};
+use crate::lang::plugins::{decode_source, FileAnalysis, LanguageParser, PluginEntity};
+
+/// Language name reported for Protocol Buffers files.
+pub const PROTO_LANGUAGE: &str = "protobuf";
+
+/// Scalar field types, which never name a message or enum.
+const SCALAR_TYPES: &[&str] = &[
+    "bool", "bytes", "double", "fixed32", "fixed64", "float", "int32", "int64", "sfixed32",
+    "sfixed64", "sint32", "sint64", "string", "uint32", "uint64",
+];
+
+/// Field labels that may precede a field type.
+const FIELD_LABELS: &[&str] = &["optional", "repeated", "required"];
+
+/// Parser for Protocol Buffers definition files.
+#[derive(Debug, Clone)]
+pub struct ProtoParser {
+    extensions: Vec<String>,
+}
+
+/// Default implementation for [`ProtoParser`].
+impl Default for ProtoParser {
+    /// Returns a parser for `.proto` files.
+    fn default() -> Self {
+        Self {
+            extensions: vec!["proto".to_string()],
+        }
+    }
+}
+
+/// [`LanguageParser`] implementation for [`ProtoParser`].
+impl LanguageParser for ProtoParser {
+    fn name(&self) -> &str {
+        PROTO_LANGUAGE
+    }
+
+    fn extensions(&self) -> &[String] {
+        &self.extensions
+    }
+
+    fn parse(&self, path: &Path, source: &[u8]) -> Result<FileAnalysis> {
+        let source = decode_source(PROTO_LANGUAGE, path, source)?;
+        Ok(parse_proto(source))
+    }
+}
+
+/// Extract the package, imports, messages, enums and services of one file.
+pub fn parse_proto(source: &str) -> FileAnalysis {
+    let chars = source_chars(source);
+    let tokens = tokenize(&chars);
+    let mut reader = ProtoReader {
+        tokens: &tokens,
+        index: 0,
+        entities: Vec::new(),
+        imports: Vec::new(),
+    };
+    reader.file();
+
+    FileAnalysis {
+        language: Some(PROTO_LANGUAGE.to_string()),
+        entities: reader.entities,
+        imports: reader.imports,
+        tables_referenced: Vec::new(),
+    }
+}
+
+/// Whether a field type is a user-defined message or enum.
+fn is_message_type(type_name: &str) -> bool {
+    !SCALAR_TYPES.contains(&type_name)
+}
+
+/// `parent.name`, or `name` at the top level.
+fn qualify(parent: Option<&str>, name: &str) -> String {
+    match parent {
+        Some(parent) => format!("{parent}.{name}"),
+        None => name.to_string(),
+    }
+}
+
+/// Recursive-descent reader over the tokens of one file.
+struct ProtoReader<'a> {
+    tokens: &'a [Spanned],
+    index: usize,
+    entities: Vec<PluginEntity>,
+    imports: Vec<String>,
+}
+
+/// Statement and block readers for [`ProtoReader`].
+impl<'a> ProtoReader<'a> {
+    /// Read top-level statements until the end of input.
+    fn file(&mut self) {
+        while let Some(token) = self.current() {
+            match token {
+                Token::Ident(word) if word == "package" => self.package(),
+                Token::Ident(word) if word == "import" => self.import(),
+                Token::Ident(word) if word == "message" => self.message(None),
+                Token::Ident(word) if word == "enum" => self.enumeration(None),
+                Token::Ident(word) if word == "service" => self.service(),
+                _ => self.skip_statement(),
+            }
+        }
+    }
+
+    /// `package foo.bar;`
+    fn package(&mut self) {
+        let line = self.line();
+        self.index += 1;
+        if let Some(name) = self.take_ident() {
+            self.entities.push(entity(
+                name,
+                EntityKind::Module,
+                "package",
+                line,
+                line,
+                None,
+            ));
+        }
+        self.skip_statement();
+    }
+
+    /// `import [public|weak] "path";`
+    fn import(&mut self) {
+        self.index += 1;
+        if matches!(self.ident(), Some("public" | "weak")) {
+            self.index += 1;
+        }
+        if let Some(Token::Str(path)) = self.current() {
+            self.imports.push(path.clone());
+        }
+        self.skip_statement();
+    }
+
+    /// `message Name { ... }`, including nested messages, enums and oneofs.
+    fn message(&mut self, parent: Option<&str>) {
+        let start_line = self.line();
+        self.index += 1;
+        let Some(name) = self.open_block() else {
+            return;
+        };
+        let name = qualify(parent, &name);
+        let slot = self.entities.len();
+        self.entities.push(entity(
+            name.clone(),
+            EntityKind::Struct,
+            "message",
+            start_line,
+            start_line,
+            parent,
+        ));
+
+        let mut fields = Vec::new();
+        let mut types = BTreeSet::new();
+        while let Some(token) = self.current() {
+            match token {
+                Token::Punct('}') => {
+                    self.index += 1;
+                    break;
+                }
+                Token::Ident(word) if word == "message" => self.message(Some(&name)),
+                Token::Ident(word) if word == "enum" => self.enumeration(Some(&name)),
+                Token::Ident(word) if word == "oneof" => {
+                    let (variants, variant_types) = self.oneof(&name);
+                    fields.extend(variants);
+                    types.extend(variant_types);
+                }
+                Token::Ident(word) if is_field_start(word) => {
+                    if let Some((field, field_types)) = self.field(&name, &name) {
+                        fields.push(field);
+                        types.extend(field_types);
+                    }
+                }
+                _ => self.skip_statement(),
+            }
+        }
+
+        let end_line = self.previous_line();
+        let message = &mut self.entities[slot];
+        message.end_line = end_line;
+        message.attributes = fields;
+        message.depends_on = types.into_iter().collect();
+    }
+
+    /// `oneof name { ... }` inside `message`; returns its variants and their types.
+    fn oneof(&mut self, message: &str) -> (Vec<String>, BTreeSet<String>) {
+        let start_line = self.line();
+        self.index += 1;
+        let mut variants = Vec::new();
+        let mut types = BTreeSet::new();
+        let Some(name) = self.open_block() else {
+            return (variants, types);
+        };
+        let name = format!("{message}.{name}");
+        let slot = self.entities.len();
+        self.entities.push(entity(
+            name.clone(),
+            EntityKind::Enum,
+            "oneof",
+            start_line,
+            start_line,
+            Some(message),
+        ));
+
+        while let Some(token) = self.current() {
+            match token {
+                Token::Punct('}') => {
+                    self.index += 1;
+                    break;
+                }
+                Token::Ident(word) if is_field_start(word) => {
+                    if let Some((variant, variant_types)) = self.field(message, &name) {
+                        variants.push(variant);
+                        types.extend(variant_types);
+                    }
+                }
+                _ => self.skip_statement(),
+            }
+        }
+
+        let end_line = self.previous_line();
+        let oneof = &mut self.entities[slot];
+        oneof.end_line = end_line;
+        oneof.attributes = variants.clone();
+        oneof.depends_on = types.iter().cloned().collect();
+        (variants, types)
+    }
+
+    /// A field of `message` declared inside `parent` (the message or one of its
+    /// oneofs); returns the field name and the message types it uses.
+    ///
+    /// Statements that do not parse as a field are skipped.
+    fn field(&mut self, message: &str, parent: &str) -> Option<(String, Vec<String>)> {
+        let start = self.index;
+        let start_line = self.line();
+        let Some((name, signature, types)) = self.field_parts() else {
+            self.index = start;
+            self.skip_statement();
+            return None;
+        };
+        self.skip_statement();
+
+        let mut field = entity(
+            format!("{message}.{name}"),
+            EntityKind::Variable,
+            "field",
+            start_line,
+            self.previous_line(),
+            Some(parent),
+        );
+        field.signature = Some(signature);
+        field.depends_on = types.clone();
+        self.entities.push(field);
+        Some((name, types))
+    }
+
+    /// `[label] Type name = N` or `map<K, V> name = N`, up to the field number.
+    fn field_parts(&mut self) -> Option<(String, String, Vec<String>)> {
+        let mut signature = String::new();
+        if let Some(label) = self.ident().filter(|word| FIELD_LABELS.contains(word)) {
+            signature = format!("{label} ");
+            self.index += 1;
+        }
+        let mut types = Vec::new();
+        if self.ident() == Some("map") && self.punct_at(1) == Some('<') {
+            self.index += 2;
+            let key = self.take_type()?;
+            self.expect(',')?;
+            let value = self.take_type()?;
+            self.expect('>')?;
+            signature.push_str(&format!("map<{key}, {value}>"));
+            types.extend([key, value].into_iter().filter(|ty| is_message_type(ty)));
+        } else {
+            let type_name = self.take_type()?;
+            signature.push_str(&type_name);
+            if is_message_type(&type_name) {
+                types.push(type_name);
+            }
+        }
+        let name = self.take_ident()?;
+        self.expect('=')?;
+        let number = self.take_ident()?;
+        signature.push_str(&format!(" = {number}"));
+        Some((name, signature, types))
+    }
+
+    /// `enum Name { VALUE = 0; ... }`
+    fn enumeration(&mut self, parent: Option<&str>) {
+        let start_line = self.line();
+        self.index += 1;
+        let Some(name) = self.open_block() else {
+            return;
+        };
+        let mut values = Vec::new();
+        while let Some(token) = self.current() {
+            match token {
+                Token::Punct('}') => {
+                    self.index += 1;
+                    break;
+                }
+                Token::Ident(word)
+                    if !matches!(word.as_str(), "option" | "reserved")
+                        && self.punct_at(1) == Some('=') =>
+                {
+                    values.push(word.clone());
+                    self.skip_statement();
+                }
+                _ => self.skip_statement(),
+            }
+        }
+
+        let mut enumeration = entity(
+            qualify(parent, &name),
+            EntityKind::Enum,
+            "enum",
+            start_line,
+            self.previous_line(),
+            parent,
+        );
+        enumeration.attributes = values;
+        self.entities.push(enumeration);
+    }
+
+    /// `service Name { rpc ...; }`
+    fn service(&mut self) {
+        let start_line = self.line();
+        self.index += 1;
+        let Some(name) = self.open_block() else {
+            return;
+        };
+        let slot = self.entities.len();
+        self.entities.push(entity(
+            name.clone(),
+            EntityKind::Interface,
+            "service",
+            start_line,
+            start_line,
+            None,
+        ));
+
+        let mut rpcs = Vec::new();
+        let mut types = BTreeSet::new();
+        while let Some(token) = self.current() {
+            match token {
+                Token::Punct('}') => {
+                    self.index += 1;
+                    break;
+                }
+                Token::Ident(word) if word == "rpc" => {
+                    let start = self.index;
+                    match self.rpc(&name) {
+                        Some((rpc, rpc_types)) => {
+                            rpcs.push(rpc);
+                            types.extend(rpc_types);
+                        }
+                        None => {
+                            self.index = start;
+                            self.skip_statement();
+                        }
+                    }
+                }
+                _ => self.skip_statement(),
+            }
+        }
+
+        let end_line = self.previous_line();
+        let service = &mut self.entities[slot];
+        service.end_line = end_line;
+        service.attributes = rpcs;
+        service.depends_on = types.into_iter().collect();
+    }
+
+    /// `rpc Name ([stream] Request) returns ([stream] Response) ;|{ ... }`
+    fn rpc(&mut self, service: &str) -> Option<(String, Vec<String>)> {
+        let start_line = self.line();
+        self.index += 1;
+        let name = self.take_ident()?;
+        let (input, input_type) = self.rpc_message()?;
+        if self.take_ident().as_deref() != Some("returns") {
+            return None;
+        }
+        let (output, output_type) = self.rpc_message()?;
+        self.skip_statement();
+
+        let types: BTreeSet<String> = [input_type, output_type].into_iter().collect();
+        let mut rpc = entity(
+            format!("{service}.{name}"),
+            EntityKind::Method,
+            "rpc",
+            start_line,
+            self.previous_line(),
+            Some(service),
+        );
+        rpc.signature = Some(format!("{name}({input}) returns ({output})"));
+        rpc.depends_on = types.iter().cloned().collect();
+        self.entities.push(rpc);
+        Some((name, types.into_iter().collect()))
+    }
+
+    /// `([stream] Type)`; returns the rendered argument and the type.
+    fn rpc_message(&mut self) -> Option<(String, String)> {
+        self.expect('(')?;
+        let stream = self.ident() == Some("stream") && self.punct_at(1) != Some(')');
+        if stream {
+            self.index += 1;
+        }
+        let type_name = self.take_type()?;
+        self.expect(')')?;
+        let rendered = if stream {
+            format!("stream {type_name}")
+        } else {
+            type_name.clone()
+        };
+        Some((rendered, type_name))
+    }
+
+    /// Read `Name {` and return the name, or skip the statement if it is not a block.
+    fn open_block(&mut self) -> Option<String> {
+        let start = self.index;
+        let name = self.take_ident();
+        if name.is_some() && self.expect('{').is_some() {
+            return name;
+        }
+        self.index = start;
+        self.skip_statement();
+        None
+    }
+
+    /// Skip to the end of the current statement: past the next `;` or the end
+    /// of a `{ ... }` block, whichever comes first. Stops before a `}` that
+    /// closes the enclosing block, and always consumes at least one token.
+    fn skip_statement(&mut self) {
+        if matches!(self.current(), Some(Token::Punct('}'))) {
+            if !self.is_enclosing_close() {
+                self.index += 1;
+            }
+            return;
+        }
+        let mut depth = 0usize;
+        while let Some(token) = self.current() {
+            match token {
+                Token::Punct('{') => depth += 1,
+                Token::Punct('}') if depth == 0 => return,
+                Token::Punct('}') => {
+                    depth -= 1;
+                    if depth == 0 {
+                        self.index += 1;
+                        return;
+                    }
+                }
+                Token::Punct(';') if depth == 0 => {
+                    self.index += 1;
+                    return;
+                }
+                _ => {}
+            }
+            self.index += 1;
+        }
+    }
+
+    /// Whether the `}` at the cursor closes a block that is still open.
+    ///
+    /// Block readers consume their own `}`, so a `}` reached by
+    /// [`skip_statement`](Self::skip_statement) at statement start only closes
+    /// a block when one is open; at the top level it is stray and skipped.
+    fn is_enclosing_close(&self) -> bool {
+        let mut depth = 0isize;
+        for token in &self.tokens[..self.index] {
+            match token.kind {
+                Token::Punct('{') => depth += 1,
+                Token::Punct('}') => depth -= 1,
+                _ => {}
+            }
+        }
+        depth > 0
+    }
+
+    /// Token at the cursor.
+    fn current(&self) -> Option<&'a Token> {
+        self.tokens.get(self.index).map(|t| &t.kind)
+    }
+
+    /// Identifier at the cursor.
+    fn ident(&self) -> Option<&'a str> {
+        match self.current() {
+            Some(Token::Ident(word)) => Some(word),
+            _ => None,
+        }
+    }
+
+    /// Punctuation `offset` tokens after the cursor.
+    fn punct_at(&self, offset: usize) -> Option<char> {
+        match self.tokens.get(self.index + offset).map(|t| &t.kind) {
+            Some(Token::Punct(c)) => Some(*c),
+            _ => None,
+        }
+    }
+
+    /// Consume the identifier at the cursor.
+    fn take_ident(&mut self) -> Option<String> {
+        let word = self.ident()?.to_string();
+        self.index += 1;
+        Some(word)
+    }
+
+    /// Consume a type name, dropping a leading `.` (fully-qualified names).
+    fn take_type(&mut self) -> Option<String> {
+        self.take_ident()
+            .map(|name| name.trim_start_matches('.').to_string())
+    }
+
+    /// Consume `c` at the cursor.
+    fn expect(&mut self, c: char) -> Option<()> {
+        (self.punct_at(0) == Some(c)).then(|| self.index += 1)
+    }
+
+    /// 1-based line of the token at the cursor.
+    fn line(&self) -> usize {
+        self.tokens.get(self.index).map_or(1, |t| t.line)
+    }
+
+    /// 1-based line of the last consumed token.
+    fn previous_line(&self) -> usize {
+        common::previous_line(self.tokens, self.index)
+    }
+}
+
+/// Whether `word` can start a field declaration.
+fn is_field_start(word: &str) -> bool {
+    !matches!(
+        word,
+        "option" | "reserved" | "extensions" | "extend" | "group"
+    )
+}
+
+/// A [`PluginEntity`] with no attributes, dependencies or signature yet.
+fn entity(
+    name: String,
+    kind: EntityKind,
+    block_type: &str,
+    start_line: usize,
+    end_line: usize,
+    parent: Option<&str>,
+) -> PluginEntity {
+    PluginEntity {
+        name,
+        kind,
+        start_line,
+        end_line,
+        parent: parent.map(str::to_string),
+        block_type: Some(block_type.to_string()),
+        attributes: Vec::new(),
+        depends_on: Vec::new(),
+        signature: None,
+    }
+}
+
+/// Lexical token of the proto grammar subset the parser needs.
+#[derive(Debug, Clone, PartialEq)]
+enum Token {
+    /// Identifier, dotted type name or number (`Order`, `.google.protobuf.Any`, `3`)
+    Ident(String),
+    /// Quoted string, without its quotes
+    Str(String),
+    /// Any punctuation character
+    Punct(char),
+}
+
+/// A token with its character range and 1-based lines.
+type Spanned = common::Spanned<Token>;
+
+/// Split `chars` into tokens, skipping whitespace and comments.
+fn tokenize(chars: &[char]) -> Vec<Spanned> {
+    common::tokenize(chars, lex)
+}
+
+/// Lex the token or comment starting at `i`.
+fn lex(chars: &[char], mut i: usize) -> Lexeme<Token> {
+    let c = chars[i];
+    let start = i;
+    let kind = match c {
+        '/' if chars.get(i + 1) == Some(&'/') => return Lexeme::Skip(line_end(chars, i)),
+        '/' if chars.get(i + 1) == Some(&'*') => {
+            i += 2;
+            while i < chars.len() && !(chars[i] == '*' && chars.get(i + 1) == Some(&'/')) {
+                i += 1;
+            }
+            return Lexeme::Skip(i + 2);
+        }
+        '"' | '\'' => {
+            let mut text = String::new();
+            i += 1;
+            while i < chars.len() && chars[i] != c && chars[i] != '\n' {
+                if chars[i] == '\\' {
+                    text.extend(chars.get(i + 1));
+                    i += 2;
+                    continue;
+                }
+                text.push(chars[i]);
+                i += 1;
+            }
+            i += 1;
+            Token::Str(text)
+        }
+        c if is_ident_char(c) => {
+            while i < chars.len() && is_ident_char(chars[i]) {
+                i += 1;
+            }
+            Token::Ident(chars[start..i].iter().collect())
+        }
+        _ => {
+            i += 1;
+            Token::Punct(c)
+        }
+    };
+    Lexeme::Token(kind, i)
+}
+
+/// Characters of identifiers, dotted type names and numbers.
+fn is_ident_char(c: char) -> bool {
+    c.is_alphanumeric() || c == '_' || c == '.'
+}
+
+/// Import graph of the `.proto` files under a set of roots.
+#[derive(Debug, Clone, Default, PartialEq, Eq, Serialize, Deserialize)]
+pub struct ProtoGraph {
+    /// Proto files by path relative to their root, with their package
+    pub files: BTreeMap<String, Option<String>>,
+    /// Edges from a file to the files it imports
+    pub imports: BTreeMap<String, BTreeSet<String>>,
+    /// Imports that match no file under the roots (well-known types,
+    /// third-party dependencies), by importing file
+    pub unresolved: BTreeMap<String, BTreeSet<String>>,
+}
+
+/// Construction and export methods for [`ProtoGraph`].
+impl ProtoGraph {
+    /// Parse every `.proto` file under `roots` and resolve their imports.
+    pub fn from_paths(roots: &[PathBuf]) -> Result<Self> {
+        let parser = ProtoParser::default();
+        let mut analyses = Vec::new();
+        for root in roots {
+            let mut builder = WalkBuilder::new(root);
+            builder.add_custom_ignore_filename(IGNORE_FILE_NAME);
+            for entry in builder.build().filter_map(|entry| entry.ok()) {
+                let path = entry.path();
+                if !path.is_file() || path.extension().map_or(true, |ext| ext != "proto") {
+                    continue;
+                }
+                let source = std::fs::read(path).map_err(|e| {
+                    ValknutError::io(format!("Failed to read {}", path.display()), e)
+                })?;
+                let relative = path.strip_prefix(root).unwrap_or(path);
+                match parser.parse(path, &source) {
+                    Ok(analysis) => analyses.push((relative.to_path_buf(), analysis)),
+                    Err(err) => warn!("Skipping {}: {}", path.display(), err),
+                }
+            }
+        }
+        Ok(Self::from_analyses(&analyses))
+    }
+
+    /// Build the graph from already parsed files, keyed by their root-relative paths.
+    ///
+    /// An import resolves to the file at exactly that path or, failing that,
+    /// to the only file whose path ends with it, so trees that keep their
+    /// protos under a directory such as `proto/` resolve without an include
+    /// path.
+    pub fn from_analyses(analyses: &[(PathBuf, FileAnalysis)]) -> Self {
+        let mut graph = Self::default();
+        for (path, analysis) in analyses {
+            let package = analysis
+                .entities
+                .iter()
+                .find(|entity| entity.block_type.as_deref() == Some("package"))
+                .map(|entity| entity.name.clone());
+            graph.files.insert(proto_key(path), package);
+        }
+        for (path, analysis) in analyses {
+            let from = proto_key(path);
+            for import in &analysis.imports {
+                match graph.resolve(import) {
+                    Some(to) => graph.imports.entry(from.clone()).or_default().insert(to),
+                    None => graph
+                        .unresolved
+                        .entry(from.clone())
+                        .or_default()
+                        .insert(import.clone()),
+                };
+            }
+        }
+        graph
+    }
+
+    /// The file an import path refers to, if it is in the graph.
+    fn resolve(&self, import: &str) -> Option<String> {
+        if self.files.contains_key(import) {
+            return Some(import.to_string());
+        }
+        let suffix = format!("/{import}");
+        let mut matches = self.files.keys().filter(|file| file.ends_with(&suffix));
+        match (matches.next(), matches.next()) {
+            (Some(file), None) => Some(file.clone()),
+            _ => None,
+        }
+    }
+
+    /// Whether no `.proto` files were found.
+    pub fn is_empty(&self) -> bool {
+        self.files.is_empty()
+    }
+
+    /// Total number of resolved import edges.
+    pub fn edge_count(&self) -> usize {
+        self.imports.values().map(BTreeSet::len).sum()
+    }
+
+    /// Render the graph in Graphviz DOT format.
+    pub fn to_dot(&self) -> String {
+        let mut dot = String::from("digraph protobuf {\n    rankdir=LR;\n");
+        for file in self.files.keys() {
+            dot.push_str(&format!("    \"{file}\";\n"));
+        }
+        for (from, targets) in &self.imports {
+            for to in targets {
+                dot.push_str(&format!("    \"{from}\" -> \"{to}\";\n"));
+            }
+        }
+        dot.push_str("}\n");
+        dot
+    }
+
+    /// Render the graph as pretty-printed JSON.
+    pub fn to_json(&self) -> Result<String> {
+        serde_json::to_string_pretty(self)
+            .map_err(|e| ValknutError::internal(format!("Failed to serialize proto graph: {e}")))
+    }
+}
+
+/// Graph key of a proto file: its path with `/` separators.
+fn proto_key(path: &Path) -> String {
+    path.to_string_lossy().replace('\\', "/")
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    const ORDERS_PROTO: &str = r#"
+syntax = "proto3";
+
+package shop.orders.v1;
+
+import "google/protobuf/timestamp.proto";
+import public "shop/common/money.proto";
+
+option go_package = "example.com/shop/orders/v1;ordersv1";
+
+// An order placed by a customer.
+message Order {
+  string id = 1;
+  repeated Item items = 2 [packed = true];
+  map<string, string> labels = 3;
+  .google.protobuf.Timestamp created_at = 4;
+  Status status = 5;
+
+  message Item {
+    string sku = 1;
+    shop.common.Money price = 2;
+  }
+
+  oneof payment {
+    string card_token = 6;
+    Invoice invoice = 7;
+  }
+
+  reserved 8, 9;
+}
+
+enum Status {
+  option allow_alias = true;
+  STATUS_UNSPECIFIED = 0;
+  STATUS_PAID = 1;
+  /* deprecated */ STATUS_SHIPPED = 2 [deprecated = true];
+}
+
+service OrderService {
+  rpc GetOrder(GetOrderRequest) returns (Order);
+  rpc WatchOrders(WatchRequest) returns (stream Order) {
+    option (google.api.http) = { get: "/v1/orders:watch" };
+  }
+}
+"#;
+
+    fn entity<'a>(analysis: &'a FileAnalysis, name: &str) -> &'a PluginEntity {
+        analysis
+            .entities
+            .iter()
+            .find(|entity| entity.name == name)
+            .unwrap_or_else(|| panic!("missing {name}"))
+    }
+
+    #[test]
+    fn extracts_messages_fields_and_oneofs() {
+        let analysis = parse_proto(ORDERS_PROTO);
+        assert_eq!(analysis.language.as_deref(), Some("protobuf"));
+        assert_eq!(
+            analysis.imports,
+            vec!["google/protobuf/timestamp.proto", "shop/common/money.proto"]
+        );
+        assert_eq!(entity(&analysis, "shop.orders.v1").kind, EntityKind::Module);
+
+        let order = entity(&analysis, "Order");
+        assert_eq!(order.kind, EntityKind::Struct);
+        assert_eq!((order.start_line, order.end_line), (12, 30));
+        assert_eq!(
+            order.attributes,
+            vec![
+                "id",
+                "items",
+                "labels",
+                "created_at",
+                "status",
+                "card_token",
+                "invoice"
+            ]
+        );
+        assert_eq!(
+            order.depends_on,
+            vec!["Invoice", "Item", "Status", "google.protobuf.Timestamp"]
+        );
+
+        let items = entity(&analysis, "Order.items");
+        assert_eq!(items.parent.as_deref(), Some("Order"));
+        assert_eq!(items.signature.as_deref(), Some("repeated Item = 2"));
+        assert_eq!(
+            entity(&analysis, "Order.labels").signature.as_deref(),
+            Some("map<string, string> = 3")
+        );
+
+        let item = entity(&analysis, "Order.Item");
+        assert_eq!(item.parent.as_deref(), Some("Order"));
+        assert_eq!(item.depends_on, vec!["shop.common.Money"]);
+
+        let payment = entity(&analysis, "Order.payment");
+        assert_eq!(payment.block_type.as_deref(), Some("oneof"));
+        assert_eq!(payment.attributes, vec!["card_token", "invoice"]);
+        assert_eq!(
+            entity(&analysis, "Order.invoice").parent.as_deref(),
+            Some("Order.payment")
+        );
+    }
+
+    #[test]
+    fn extracts_enums_and_services() {
+        let analysis = parse_proto(ORDERS_PROTO);
+        let status = entity(&analysis, "Status");
+        assert_eq!(status.kind, EntityKind::Enum);
+        assert_eq!(
+            status.attributes,
+            vec!["STATUS_UNSPECIFIED", "STATUS_PAID", "STATUS_SHIPPED"]
+        );
+
+        let service = entity(&analysis, "OrderService");
+        assert_eq!(service.kind, EntityKind::Interface);
+        assert_eq!(service.attributes, vec!["GetOrder", "WatchOrders"]);
+        assert_eq!(
+            service.depends_on,
+            vec!["GetOrderRequest", "Order", "WatchRequest"]
+        );
+        assert_eq!((service.start_line, service.end_line), (39, 44));
+
+        let watch = entity(&analysis, "OrderService.WatchOrders");
+        assert_eq!(watch.kind, EntityKind::Method);
+        assert_eq!(
+            watch.signature.as_deref(),
+            Some("WatchOrders(WatchRequest) returns (stream Order)")
+        );
+        assert_eq!(watch.depends_on, vec!["Order", "WatchRequest"]);
+        assert_eq!(
+            entity(&analysis, "OrderService.GetOrder")
+                .signature
+                .as_deref(),
+            Some("GetOrder(GetOrderRequest) returns (Order)")
+        );
+    }
+
+    #[test]
+    fn graph_resolves_imports_by_path_and_suffix() {
+        let analyses = vec![
+            (
+                PathBuf::from("proto/shop/orders/v1/orders.proto"),
+                parse_proto(ORDERS_PROTO),
+            ),
+            (
+                PathBuf::from("proto/shop/common/money.proto"),
+                parse_proto("syntax = \"proto3\";\npackage shop.common;\nmessage Money { int64 units = 1; }\n"),
+            ),
+        ];
+        let graph = ProtoGraph::from_analyses(&analyses);
+        assert_eq!(
+            graph.files["proto/shop/common/money.proto"].as_deref(),
+            Some("shop.common")
+        );
+        assert_eq!(
+            graph.imports["proto/shop/orders/v1/orders.proto"],
+            BTreeSet::from(["proto/shop/common/money.proto".to_string()])
+        );
+        assert_eq!(
+            graph.unresolved["proto/shop/orders/v1/orders.proto"],
+            BTreeSet::from(["google/protobuf/timestamp.proto".to_string()])
+        );
+        assert_eq!(graph.edge_count(), 1);
+        assert!(graph.to_dot().contains(
+            "\"proto/shop/orders/v1/orders.proto\" -> \"proto/shop/common/money.proto\";"
+        ));
+    }
+
+    #[test]
+    fn recovers_from_malformed_statements() {
+        let analysis = parse_proto(
+            "message A {\n  string = ;\n  int32 count = 1;\n}\n}\nmessage B { A a = 1; }\n",
+        );
+        assert_eq!(entity(&analysis, "A").attributes, vec!["count"]);
+        assert_eq!(entity(&analysis, "B").depends_on, vec!["A"]);
+    }
+}
@@ -4,10 +4,15 @@ use std::path::Path;
 use tree_sitter::Language;
 
 use crate::core::errors::{Result, ValknutError};
+use crate::lang::c::CAdapter;
 use crate::lang::common::LanguageAdapter;
 use crate::lang::cpp::CppAdapter;
+use crate::lang::csharp::CSharpAdapter;
+use crate::lang::detection::{read_first_line, shebang_language};
 use crate::lang::go::GoAdapter;
+use crate::lang::java::JavaAdapter;
 use crate::lang::javascript::JavaScriptAdapter;
+use crate::lang::kotlin::KotlinAdapter;
 use crate::lang::python::PythonAdapter;
 use crate::lang::rust_lang::RustAdapter;
 use crate::lang::typescript::TypeScriptAdapter;
@@ -38,9 +43,9 @@ const REGISTERED_LANGUAGES: &[LanguageInfo] = &[
     LanguageInfo {
         key: "py",
         name: "Python",
-        extensions: &["py", "pyi"],
+        extensions: &["py", "pyi", "ipynb"],
         status: LanguageStability::Stable,
-        notes: "Full analysis & refactoring",
+        notes: "Full analysis & refactoring; notebook code cells",
     },
     LanguageInfo {
         key: "ts",
@@ -70,6 +75,13 @@ const REGISTERED_LANGUAGES: &[LanguageInfo] = &[
         status: LanguageStability::Beta,
         notes: "AST parsing & structure checks",
     },
+    LanguageInfo {
+        key: "c",
+        name: "C",
+        extensions: &["c"],
+        status: LanguageStability::Beta,
+        notes: "Structs, unions, typedefs, prototypes, macros",
+    },
     LanguageInfo {
         key: "cpp",
         name: "C++",
@@ -77,6 +89,27 @@ const REGISTERED_LANGUAGES: &[LanguageInfo] = &[
         status: LanguageStability::Beta,
         notes: "Classes, namespaces, templates",
     },
+    LanguageInfo {
+        key: "java",
+        name: "Java",
+        extensions: &["java"],
+        status: LanguageStability::Beta,
+        notes: "Class hierarchy, generics, annotations",
+    },
+    LanguageInfo {
+        key: "cs",
+        name: "C#",
+        extensions: &["cs"],
+        status: LanguageStability::Beta,
+        notes: "Namespaces, partial types, nullable signatures",
+    },
+    LanguageInfo {
+        key: "kt",
+        name: "Kotlin",
+        extensions: &["kt", "kts"],
+        status: LanguageStability::Beta,
+        notes: "Data/sealed classes, objects, extension and suspend functions",
+    },
 ];
 
 /// Return the languages that are compiled into this build.
@@ -85,36 +118,93 @@ pub fn registered_languages() -> &'static [LanguageInfo] {
 }
 
 /// Identify the canonical language key for a file path.
+///
+/// Files without an extension are identified by their shebang line, which is
+/// read from disk.
 pub fn language_key_for_path(path: &Path) -> Option<String> {
+    if let Some(key) = builtin_key_for_path(path) {
+        return Some(key.to_string());
+    }
+    if path.extension().is_some() {
+        return None;
+    }
+    read_first_line(path)
+        .and_then(|line| shebang_language(&line))
+        .map(str::to_string)
+}
+
+/// Language key implied by the path alone, using only the registered extensions.
+pub(super) fn builtin_key_for_path(path: &Path) -> Option<&'static str> {
+    if let Some(key) = c_header_key(path) {
+        return Some(key);
+    }
     let ext = path.extension()?.to_string_lossy().to_ascii_lowercase();
     if ext.is_empty() {
         return None;
     }
+    find_language_by_extension(&ext).map(|info| info.key)
+}
 
-    find_language_by_extension(&ext).map(|info| info.key.to_string())
+/// `.h` files default to C++; a header next to a `.c` file of the same name
+/// (`uart.h` beside `uart.c`) is treated as C.
+fn c_header_key(path: &Path) -> Option<&'static str> {
+    let is_header = path
+        .extension()
+        .is_some_and(|ext| ext.eq_ignore_ascii_case("h"));
+    (is_header && path.with_extension("c").is_file()).then_some("c")
 }
 
 /// Create a language adapter suitable for analysing the provided file.
 pub fn adapter_for_file(path: &Path) -> Result<Box<dyn LanguageAdapter>> {
     let key = language_key_for_path(path).ok_or_else(|| {
-        ValknutError::unsupported(format!(
-            "Could not determine language for file: {}",
-            path.display()
-        ))
+        let extension = path
+            .extension()
+            .map(|ext| ext.to_string_lossy().into_owned())
+            .unwrap_or_default();
+        ValknutError::unsupported_language(extension, path.display().to_string())
     })?;
 
+    if is_tsx_path(path) {
+        return adapter_for_language("tsx");
+    }
+
     adapter_for_language(&key)
 }
 
+/// Returns true when the path points at a TSX (JSX-flavoured TypeScript) file.
+pub fn is_tsx_path(path: &Path) -> bool {
+    path.extension()
+        .map(|ext| ext.to_string_lossy().eq_ignore_ascii_case("tsx"))
+        .unwrap_or(false)
+}
+
+/// Identify the tree-sitter grammar key for a file path.
+///
+/// This matches [`detect_language_from_path`] except that `.tsx` files map to
+/// `"tsx"` so they are parsed with the JSX-aware TypeScript grammar.
+pub fn grammar_key_for_path(file_path: &str) -> String {
+    if is_tsx_path(Path::new(file_path)) {
+        return "tsx".to_string();
+    }
+    detect_language_from_path(file_path)
+}
+
 /// Create a language adapter for a specific language key (usually an extension).
 pub fn adapter_for_language(language: &str) -> Result<Box<dyn LanguageAdapter>> {
     match normalize_language_key(language) {
         Some("py") => Ok(Box::new(PythonAdapter::new()?)),
         Some("js") => Ok(Box::new(JavaScriptAdapter::new()?)),
+        Some("ts") if language.eq_ignore_ascii_case("tsx") => {
+            Ok(Box::new(TypeScriptAdapter::new_tsx()?))
+        }
         Some("ts") => Ok(Box::new(TypeScriptAdapter::new()?)),
         Some("rs") => Ok(Box::new(RustAdapter::new()?)),
         Some("go") => Ok(Box::new(GoAdapter::new()?)),
+        Some("c") => Ok(Box::new(CAdapter::new()?)),
         Some("cpp") => Ok(Box::new(CppAdapter::new()?)),
+        Some("java") => Ok(Box::new(JavaAdapter::new()?)),
+        Some("cs") => Ok(Box::new(CSharpAdapter::new()?)),
+        Some("kt") => Ok(Box::new(KotlinAdapter::new()?)),
         _ => Err(ValknutError::unsupported(format!(
             "Language adapter for '{}' is not yet implemented",
             language
@@ -124,13 +214,22 @@ pub fn adapter_for_language(language: &str) -> Result<Box<dyn LanguageAdapter>>
 
 /// Get tree-sitter language for a given language key
 pub fn get_tree_sitter_language(language_key: &str) -> Result<Language> {
+    // TSX shares the "ts" key but needs the JSX-aware grammar.
+    if language_key.eq_ignore_ascii_case("tsx") {
+        return Ok(tree_sitter_typescript::LANGUAGE_TSX.into());
+    }
+
     match normalize_language_key(language_key) {
         Some("py") => Ok(tree_sitter_python::LANGUAGE.into()),
         Some("rs") => Ok(tree_sitter_rust::LANGUAGE.into()),
         Some("js") => Ok(tree_sitter_javascript::LANGUAGE.into()),
         Some("ts") => Ok(tree_sitter_typescript::LANGUAGE_TYPESCRIPT.into()),
         Some("go") => Ok(tree_sitter_go::LANGUAGE.into()),
+        Some("c") => Ok(tree_sitter_c::LANGUAGE.into()),
         Some("cpp") => Ok(tree_sitter_cpp::LANGUAGE.into()),
+        Some("java") => Ok(tree_sitter_java::LANGUAGE.into()),
+        Some("cs") => Ok(tree_sitter_c_sharp::LANGUAGE.into()),
+        Some("kt") => Ok(tree_sitter_kotlin_ng::LANGUAGE.into()),
         _ => Err(ValknutError::unsupported(format!(
             "No tree-sitter grammar for: {}",
             language_key
@@ -138,14 +237,9 @@ pub fn get_tree_sitter_language(language_key: &str) -> Result<Language> {
     }
 }
 
-/// Detect language key from file path
+/// Detect language key from file path, or `"txt"` when it is not recognised.
 pub fn detect_language_from_path(file_path: &str) -> String {
-    std::path::Path::new(file_path)
-        .extension()
-        .and_then(|ext| ext.to_str())
-        .and_then(|ext| find_language_by_extension(&ext.to_ascii_lowercase()))
-        .map(|info| info.key.to_string())
-        .unwrap_or_else(|| "txt".to_string())
+    language_key_for_path(Path::new(file_path)).unwrap_or_else(|| "txt".to_string())
 }
 
 /// Create a new parser for the given language
@@ -167,6 +261,12 @@ pub fn extension_is_supported(ext: &str) -> bool {
     find_language_by_extension(&normalized).is_some()
 }
 
+/// Language key the registered extensions assign to `ext` (with or without
+/// leading dot).
+pub fn language_key_for_extension(ext: &str) -> Option<&'static str> {
+    find_language_by_extension(ext).map(|info| info.key)
+}
+
 /// Finds the language info for a given file extension.
 fn find_language_by_extension(ext: &str) -> Option<&'static LanguageInfo> {
     let target = ext.trim_start_matches('.').to_ascii_lowercase();
@@ -178,16 +278,20 @@ fn find_language_by_extension(ext: &str) -> Option<&'static LanguageInfo> {
 }
 
 /// Normalizes a language identifier to its canonical key.
-fn normalize_language_key(language: &str) -> Option<&'static str> {
+pub fn normalize_language_key(language: &str) -> Option<&'static str> {
     match language.to_ascii_lowercase().as_str() {
         "py" | "pyw" | "python" => Some("py"),
         "js" | "jsx" | "mjs" | "cjs" | "javascript" => Some("js"),
         "ts" | "tsx" | "cts" | "mts" | "typescript" => Some("ts"),
         "rs" | "rust" => Some("rs"),
         "go" | "golang" => Some("go"),
+        "c" => Some("c"),
         "cpp" | "cxx" | "cc" | "c++" | "hpp" | "hxx" | "hh" | "h++" | "h" | "cplusplus" => {
             Some("cpp")
         }
+        "java" => Some("java"),
+        "cs" | "csharp" | "c#" => Some("cs"),
+        "kt" | "kts" | "kotlin" => Some("kt"),
         other => registered_languages()
             .iter()
             .find(|info| info.key == other)
@@ -226,7 +330,7 @@ mod tests {
 
     #[test]
     fn test_adapter_creation_supported_languages() {
-        for lang in ["py", "js", "ts", "rs", "go", "cpp"] {
+        for lang in ["py", "js", "ts", "rs", "go", "c", "cpp", "java", "cs", "kt"] {
             let adapter = adapter_for_language(lang);
             assert!(adapter.is_ok(), "adapter for {} should be available", lang);
         }
@@ -241,6 +345,8 @@ mod tests {
             "rust",
             "golang",
             "cplusplus",
+            "csharp",
+            "kotlin",
         ] {
             let adapter = adapter_for_language(alias);
             assert!(
@@ -254,7 +360,8 @@ mod tests {
     #[test]
     fn test_extension_support() {
         for ext in [
-            "py", ".pyi", "JSX", "mjs", "TS", "tsx", "rs", "go", "cpp", "hpp", "cc",
+            "py", ".pyi", "ipynb", "JSX", "mjs", "TS", "tsx", "rs", "go", "c", "cpp", "hpp", "cc",
+            "java", "cs", "kt", "kts",
         ] {
             assert!(
                 extension_is_supported(ext),
@@ -262,19 +369,19 @@ mod tests {
                 ext
             );
         }
-        assert!(!extension_is_supported("java"));
+        assert!(!extension_is_supported("txt"));
     }
 
     #[test]
     fn test_tree_sitter_functions() {
         // Test get_tree_sitter_language
-        for lang in ["py", "rs", "js", "ts", "go", "cpp"] {
+        for lang in ["py", "rs", "js", "ts", "go", "c", "cpp", "java", "cs", "kt"] {
             let result = get_tree_sitter_language(lang);
             assert!(result.is_ok(), "Language {} should be supported", lang);
         }
 
         // Test create_parser_for_language
-        for lang in ["py", "rs", "js", "ts", "go", "cpp"] {
+        for lang in ["py", "rs", "js", "ts", "go", "c", "cpp", "java", "cs", "kt"] {
             let result = create_parser_for_language(lang);
             assert!(result.is_ok(), "Should create parser for {}", lang);
         }
@@ -287,7 +394,60 @@ mod tests {
         assert_eq!(detect_language_from_path("test.cjs"), "js");
         assert_eq!(detect_language_from_path("test.ts"), "ts");
         assert_eq!(detect_language_from_path("test.go"), "go");
+        assert_eq!(detect_language_from_path("test.c"), "c");
         assert_eq!(detect_language_from_path("test.cpp"), "cpp");
         assert_eq!(detect_language_from_path("test.hpp"), "cpp");
+        assert_eq!(detect_language_from_path("Test.java"), "java");
+        assert_eq!(detect_language_from_path("Program.cs"), "cs");
+        assert_eq!(detect_language_from_path("Main.kt"), "kt");
+        assert_eq!(detect_language_from_path("build.gradle.kts"), "kt");
+    }
+
+    #[test]
+    fn test_headers_next_to_c_sources_are_c() {
+        let dir = tempfile::tempdir().unwrap();
+        for name in ["uart.c", "uart.h", "widget.h"] {
+            std::fs::write(dir.path().join(name), "").unwrap();
+        }
+
+        let uart = dir.path().join("uart.h");
+        assert_eq!(language_key_for_path(&uart), Some("c".to_string()));
+        assert_eq!(detect_language_from_path(&uart.to_string_lossy()), "c");
+        assert_eq!(adapter_for_file(&uart).unwrap().language_name(), "c");
+        assert_eq!(
+            language_key_for_path(&dir.path().join("widget.h")),
+            Some("cpp".to_string())
+        );
+    }
+
+    #[test]
+    fn test_tsx_uses_jsx_aware_grammar() {
+        assert_eq!(grammar_key_for_path("src/App.tsx"), "tsx");
+        assert_eq!(grammar_key_for_path("src/app.ts"), "ts");
+        assert_eq!(detect_language_from_path("src/App.tsx"), "ts");
+
+        let mut parser = create_parser_for_language("tsx").unwrap();
+        let tree = parser
+            .parse("const App = () => <div className=\"x\">hi</div>;", None)
+            .unwrap();
+        assert!(
+            !tree.root_node().has_error(),
+            "TSX grammar should accept JSX"
+        );
+
+        let adapter = adapter_for_file(Path::new("src/App.tsx"));
+        assert!(adapter.is_ok(), "TSX adapter should be available");
+    }
+
+    #[test]
+    fn test_unknown_extensions_report_unsupported_language() {
+        let Err(err) = adapter_for_file(Path::new("src/main.zig")) else {
+            panic!("no adapter should handle .zig files");
+        };
+        assert!(matches!(
+            &err,
+            ValknutError::UnsupportedLanguage { extension, .. } if extension == "zig"
+        ));
+        assert_eq!(err.file_path(), Some("src/main.zig"));
     }
 }
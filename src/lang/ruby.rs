@@ -0,0 +1,242 @@
+//! Built-in Ruby parser backed by Ripper.
+//!
+//! [`RubyParser`] pipes each file through the Ruby interpreter running the
+//! bundled `ruby_ripper.rb` script, which walks Ripper's syntax tree and
+//! prints a [`FileAnalysis`]. Running Ruby out of process keeps the parser
+//! faithful to the interpreter the project actually uses, and reuses the
+//! plugin subprocess handling (timeouts, stderr capture) from
+//! [`plugins`](super::plugins).
+//!
+//! Reported entities:
+//!
+//! - classes and modules, named by their nesting (`Admin::User`), with the
+//!   superclass, mixed-in modules and association targets in `depends_on`
+//!   and `attr_*` names in `attributes`;
+//! - methods, named `Owner#name` or `Owner.name` for singleton methods, with a
+//!   `signature` listing positional, keyword, splat and block parameters
+//!   (default values are shown as `...`, and a trailing `{ ... }` marks
+//!   methods that `yield` without a block parameter);
+//! - `include`/`extend`/`prepend` calls, as `Module` entities whose
+//!   `block_type` is the macro;
+//! - ActiveRecord associations (`belongs_to`, `has_one`, `has_many`,
+//!   `has_and_belongs_to_many`), as `Variable` entities whose `block_type` is
+//!   the macro, whose `attributes` are the option names and whose
+//!   `depends_on` is the target model (`class_name:` or the inflected name).
+//!
+//! `require` paths are reported as imports; `require_relative` paths are
+//! prefixed with `./`.
+
+use std::path::{Path, PathBuf};
+use std::time::Duration;
+
+use crate::core::errors::Result;
+use crate::lang::plugins::{plugin_error, run_plugin, FileAnalysis, LanguageParser};
+
+/// Language name reported for Ruby files.
+pub const RUBY_LANGUAGE: &str = "ruby";
+
+/// Ripper-based extractor run by the Ruby interpreter.
+const RIPPER_SCRIPT: &str = include_str!("ruby_ripper.rb");
+
+/// Parser for Ruby files.
+#[derive(Debug, Clone)]
+pub struct RubyParser {
+    ruby: PathBuf,
+    timeout: Duration,
+    extensions: Vec<String>,
+}
+
+/// Construction methods for [`RubyParser`].
+impl RubyParser {
+    /// Create a parser that runs `ruby` (a path or a name looked up on `PATH`).
+    pub fn new(ruby: impl Into<PathBuf>, timeout: Duration) -> Self {
+        Self {
+            ruby: ruby.into(),
+            timeout,
+            extensions: vec!["rb".to_string(), "rake".to_string()],
+        }
+    }
+}
+
+/// [`LanguageParser`] implementation for [`RubyParser`].
+impl LanguageParser for RubyParser {
+    fn name(&self) -> &str {
+        RUBY_LANGUAGE
+    }
+
+    fn extensions(&self) -> &[String] {
+        &self.extensions
+    }
+
+    fn parse(&self, path: &Path, source: &[u8]) -> Result<FileAnalysis> {
+        let output = run_plugin(
+            &self.ruby,
+            &["-e", RIPPER_SCRIPT],
+            source,
+            self.timeout,
+            Some(path),
+        )?;
+        let mut analysis: FileAnalysis = serde_json::from_slice(&output).map_err(|e| {
+            plugin_error(
+                &self.ruby,
+                format!("invalid Ripper output for {}: {e}", path.display()),
+            )
+        })?;
+        analysis
+            .language
+            .get_or_insert_with(|| RUBY_LANGUAGE.to_string());
+        Ok(analysis)
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::lang::common::EntityKind;
+    use crate::lang::plugins::PluginEntity;
+    use std::process::Command;
+
+    const POST_RB: &str = r#"require "active_support/concern"
+require_relative "concerns/publishable"
+
+module Blog
+  class Post < ApplicationRecord
+    include Publishable
+    extend FriendlyId
+    attr_accessor :preview, :draft_token
+
+    belongs_to :author, class_name: "User"
+    has_many :comments, dependent: :destroy
+    has_many :categories, through: :taggings
+    belongs_to :subject, polymorphic: true
+
+    def self.search(query, limit: 10, **filters)
+      where(title: query).limit(limit)
+    end
+
+    def publish!(at = Time.now, *channels, notify:, &callback)
+      update!(published_at: at)
+    end
+
+    def each_reader
+      readers.each { |reader| yield reader }
+    end
+  end
+end
+"#;
+
+    fn ruby_available() -> bool {
+        Command::new("ruby")
+            .arg("--version")
+            .output()
+            .is_ok_and(|output| output.status.success())
+    }
+
+    fn entity<'a>(analysis: &'a FileAnalysis, name: &str) -> &'a PluginEntity {
+        analysis
+            .entities
+            .iter()
+            .find(|entity| entity.name == name)
+            .unwrap_or_else(|| panic!("missing {name} in {:#?}", analysis.entities))
+    }
+
+    fn parse(source: &str) -> FileAnalysis {
+        RubyParser::new("ruby", Duration::from_secs(20))
+            .parse(Path::new("post.rb"), source.as_bytes())
+            .unwrap()
+    }
+
+    #[test]
+    fn extracts_rails_model_structure() {
+        if !ruby_available() {
+            eprintln!("skipping: ruby is not installed");
+            return;
+        }
+        let analysis = parse(POST_RB);
+        assert_eq!(analysis.language.as_deref(), Some("ruby"));
+        assert_eq!(
+            analysis.imports,
+            vec!["active_support/concern", "./concerns/publishable"]
+        );
+
+        let post = entity(&analysis, "Blog::Post");
+        assert_eq!(post.kind, EntityKind::Class);
+        assert_eq!(post.parent.as_deref(), Some("Blog"));
+        assert_eq!((post.start_line, post.end_line), (5, 26));
+        assert_eq!(post.attributes, vec!["draft_token", "preview"]);
+        assert_eq!(
+            post.depends_on,
+            vec![
+                "ApplicationRecord",
+                "Category",
+                "Comment",
+                "FriendlyId",
+                "Publishable",
+                "User"
+            ]
+        );
+
+        let comments = entity(&analysis, "comments");
+        assert_eq!(comments.block_type.as_deref(), Some("has_many"));
+        assert_eq!(comments.attributes, vec!["dependent"]);
+        assert_eq!(comments.depends_on, vec!["Comment"]);
+        assert!(entity(&analysis, "subject").depends_on.is_empty());
+        assert_eq!(
+            entity(&analysis, "FriendlyId").block_type.as_deref(),
+            Some("extend")
+        );
+    }
+
+    #[test]
+    fn renders_method_signatures() {
+        if !ruby_available() {
+            eprintln!("skipping: ruby is not installed");
+            return;
+        }
+        let analysis = parse(POST_RB);
+
+        let search = entity(&analysis, "Blog::Post.search");
+        assert_eq!(search.kind, EntityKind::Method);
+        assert_eq!(
+            search.signature.as_deref(),
+            Some("search(query, limit: ..., **filters)")
+        );
+        assert_eq!((search.start_line, search.end_line), (15, 17));
+
+        assert_eq!(
+            entity(&analysis, "Blog::Post#publish!")
+                .signature
+                .as_deref(),
+            Some("publish!(at = ..., *channels, notify:, &callback)")
+        );
+        assert_eq!(
+            entity(&analysis, "Blog::Post#each_reader")
+                .signature
+                .as_deref(),
+            Some("each_reader() { ... }")
+        );
+    }
+
+    #[test]
+    fn syntax_errors_fail_the_file() {
+        if !ruby_available() {
+            eprintln!("skipping: ruby is not installed");
+            return;
+        }
+        let err = RubyParser::new("ruby", Duration::from_secs(20))
+            .parse(Path::new("broken.rb"), b"class Broken\n  def oops(\nend\n")
+            .unwrap_err();
+        assert!(err.to_string().contains("syntax error"), "{err}");
+    }
+
+    #[test]
+    fn missing_interpreter_is_reported() {
+        let err = RubyParser::new("/definitely/missing/ruby", Duration::from_secs(1))
+            .parse(Path::new("post.rb"), POST_RB.as_bytes())
+            .unwrap_err();
+        assert!(
+            err.to_string().contains("/definitely/missing/ruby"),
+            "{err}"
+        );
+    }
+}
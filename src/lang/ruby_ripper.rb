@@ -0,0 +1,354 @@
+# Declaration extractor behind valknut's built-in Ruby parser.
+#
+# Reads Ruby source on stdin and prints a FileAnalysis document (see
+# src/lang/plugins.rs) on stdout. Only the standard library is used, so any
+# Ruby >= 2.5 can run it. A syntax error exits with status 1.
+
+require "json"
+require "ripper"
+
+# Sexp builder that appends the line of the closing `end` to scope nodes.
+#
+# Scope rules end in `end` and are reduced without lookahead, so `lineno`
+# still points at that keyword when the event fires.
+class ScopeBuilder < Ripper::SexpBuilderPP
+  %i[class module sclass def defs].each do |event|
+    define_method(:"on_#{event}") { |*args| [event, *args, lineno] }
+  end
+end
+
+# Walks the sexp and collects entities and required files.
+class Extractor
+  ASSOCIATIONS = %w[belongs_to has_one has_many has_and_belongs_to_many].freeze
+  MIXINS = %w[include extend prepend].freeze
+  ATTRIBUTE_MACROS = %w[attr_reader attr_writer attr_accessor].freeze
+  SINGULAR_ASSOCIATIONS = %w[belongs_to has_one].freeze
+
+  attr_reader :entities, :imports
+
+  def initialize
+    @entities = []
+    @imports = []
+    @scopes = []
+    @singleton = false
+    @in_method = false
+  end
+
+  def visit(node)
+    return unless node.is_a?(Array)
+
+    case node.first
+    when :class, :module then visit_namespace(node)
+    when :sclass then visit_singleton_class(node)
+    when :def then visit_def(node[1], node[2], node[3], node.last, @singleton)
+    when :defs then visit_def(node[3], node[4], node[5], node.last, true)
+    when :command then visit_call(node[1], node[2]) || visit_children(node)
+    when :method_add_arg
+      fcall = node[1]
+      handled = fcall.is_a?(Array) && fcall.first == :fcall && visit_call(fcall[1], node[2])
+      visit_children(node) unless handled
+    when :vcall, :var_ref then visit_call(node[1], nil)
+    else visit_children(node)
+    end
+  end
+
+  private
+
+  def visit_children(node)
+    node.each { |child| visit(child) }
+  end
+
+  def visit_namespace(node)
+    is_class = node.first == :class
+    cpath = node[1]
+    body = is_class ? node[3] : node[2]
+    name = const_name(cpath) || "(anonymous)"
+    owner = @scopes.last
+    entity = entity(
+      owner ? "#{owner["name"]}::#{name}" : name,
+      is_class ? "Class" : "Module",
+      line_of(cpath),
+      node.last,
+      owner
+    )
+    entity["block_type"] = node.first.to_s
+    superclass = is_class && const_name(node[2])
+    entity["depends_on"] << superclass if superclass
+
+    @entities << entity
+    @scopes.push(entity)
+    singleton, @singleton = @singleton, false
+    visit(body)
+    @singleton = singleton
+    @scopes.pop
+  end
+
+  def visit_singleton_class(node)
+    singleton, @singleton = @singleton, true
+    visit(node[2])
+    @singleton = singleton
+  end
+
+  def visit_def(name_token, params, body, end_line, singleton)
+    owner = @scopes.last
+    method_name = name_token[1]
+    start_line = name_token[2][0]
+    # Endless methods (`def ready? = true`) have no bodystmt and no `end`.
+    end_line = start_line unless body.is_a?(Array) && body.first == :bodystmt
+
+    qualified = if owner
+                  "#{owner["name"]}#{singleton ? "." : "#"}#{method_name}"
+                else
+                  method_name
+                end
+    entity = entity(qualified, owner ? "Method" : "Function", start_line, end_line, owner)
+    signature = "#{method_name}(#{parameters(params).join(", ")})"
+    signature += " { ... }" if yields?(body) && !block_parameter?(params)
+    entity["signature"] = signature
+    @entities << entity
+
+    in_method, @in_method = @in_method, true
+    visit(body)
+    @in_method = in_method
+  end
+
+  # Handles `require`, mixins, attribute macros and associations.
+  # Returns true when the call was recognised.
+  def visit_call(name_token, args)
+    return false unless name_token.is_a?(Array) && name_token.first == :@ident
+
+    name = name_token[1]
+    line = name_token[2][0]
+    values = arguments(args)
+    case name
+    when "require"
+      path = string_value(values.first)
+      @imports << path if path
+      !path.nil?
+    when "require_relative"
+      path = string_value(values.first)
+      @imports << (path.start_with?(".") ? path : "./#{path}") if path
+      !path.nil?
+    else
+      return false if @in_method
+
+      if MIXINS.include?(name)
+        mixin(name, values, line)
+      elsif ATTRIBUTE_MACROS.include?(name)
+        attribute_macro(values)
+      elsif ASSOCIATIONS.include?(name)
+        association(name, values, line)
+      else
+        false
+      end
+    end
+  end
+
+  def mixin(macro, values, line)
+    owner = @scopes.last
+    modules = values.map { |value| const_name(value) }.compact
+    modules.each do |module_name|
+      entity = entity(module_name, "Module", line, line, owner)
+      entity["block_type"] = macro
+      @entities << entity
+      owner["depends_on"] << module_name if owner
+    end
+    !modules.empty?
+  end
+
+  def attribute_macro(values)
+    owner = @scopes.last
+    names = values.map { |value| symbol_value(value) }.compact
+    owner["attributes"].concat(names) if owner
+    !names.empty?
+  end
+
+  def association(macro, values, line)
+    owner = @scopes.last
+    name = symbol_value(values.first)
+    return false unless owner && name
+
+    options = hash_options(values)
+    entity = entity(name, "Variable", line, line, owner)
+    entity["block_type"] = macro
+    entity["attributes"] = options.keys.sort
+    unless options["polymorphic"]
+      target = options["class_name"] if options["class_name"].is_a?(String)
+      target ||= camelize(SINGULAR_ASSOCIATIONS.include?(macro) ? name : singularize(name))
+      entity["depends_on"] << target
+      owner["depends_on"] << target
+    end
+    @entities << entity
+    true
+  end
+
+  def entity(name, kind, start_line, end_line, owner)
+    {
+      "name" => name,
+      "kind" => kind,
+      "start_line" => start_line,
+      "end_line" => end_line,
+      "parent" => owner && owner["name"],
+      "attributes" => [],
+      "depends_on" => []
+    }
+  end
+
+  # Rendered parameters of a `params` node; default values are elided.
+  def parameters(params)
+    params = params[1] if params.is_a?(Array) && params.first == :paren
+    return [] unless params.is_a?(Array) && params.first == :params
+
+    _, required, optional, rest, post, keywords, keyword_rest, block = params
+    rendered = []
+    rendered.concat(Array(required).map { |param| parameter_name(param) })
+    rendered.concat(Array(optional).map { |param, _| "#{parameter_name(param)} = ..." })
+    rendered << splat("*", rest) if rest.is_a?(Array)
+    rendered.concat(Array(post).map { |param| parameter_name(param) })
+    Array(keywords).each do |label, default|
+      rendered << (default ? "#{label[1]} ..." : label[1])
+    end
+    rendered << splat("**", keyword_rest) if keyword_rest.is_a?(Array)
+    rendered << splat("&", block) if block.is_a?(Array)
+    rendered.reject(&:empty?)
+  end
+
+  def splat(prefix, node)
+    case node.first
+    when :args_forward then "..."
+    when :nokw_param then "**nil"
+    when :excessed_comma then ""
+    else "#{prefix}#{node[1] ? parameter_name(node[1]) : ""}"
+    end
+  end
+
+  def parameter_name(param)
+    return "(...)" if param.is_a?(Array) && param.first == :mlhs
+    return param[1] if param.is_a?(Array) && param.first.to_s.start_with?("@")
+
+    "?"
+  end
+
+  def block_parameter?(params)
+    params = params[1] if params.is_a?(Array) && params.first == :paren
+    params.is_a?(Array) && params.first == :params && params[7].is_a?(Array)
+  end
+
+  def yields?(node)
+    return false unless node.is_a?(Array)
+    return true if %i[yield yield0].include?(node.first)
+    # Nested definitions own their yields.
+    return false if %i[def defs class module sclass].include?(node.first)
+
+    node.any? { |child| yields?(child) }
+  end
+
+  def arguments(node)
+    return [] unless node.is_a?(Array)
+
+    node = node[1] if node.first == :arg_paren
+    return [] unless node.is_a?(Array)
+
+    node = node[1] if node.first == :args_add_block
+    return [] unless node.is_a?(Array)
+
+    node.first.is_a?(Symbol) ? [node] : node
+  end
+
+  def hash_options(values)
+    hash = values.find { |value| value.is_a?(Array) && value.first == :bare_assoc_hash }
+    return {} unless hash
+
+    hash[1].each_with_object({}) do |assoc, options|
+      next unless assoc.is_a?(Array) && assoc.first == :assoc_new
+
+      key = assoc[1]
+      key = key.first == :@label ? key[1].chomp(":") : symbol_value(key)
+      next unless key
+
+      value = assoc[2]
+      options[key] = string_value(value) || symbol_value(value) || const_name(value) ||
+                     !falsy_literal?(value)
+    end
+  end
+
+  def const_name(node)
+    return nil unless node.is_a?(Array)
+
+    case node.first
+    when :@const then node[1]
+    when :const_ref, :var_ref, :top_const_ref then const_name(node[1])
+    when :const_path_ref
+      left = const_name(node[1])
+      right = const_name(node[2])
+      left && right && "#{left}::#{right}"
+    end
+  end
+
+  def string_value(node)
+    return nil unless node.is_a?(Array) && node.first == :string_literal
+
+    content = node[1]
+    parts = content.is_a?(Array) ? content[1..-1] : []
+    return nil unless parts.all? { |part| part.is_a?(Array) && part.first == :@tstring_content }
+
+    parts.map { |part| part[1] }.join
+  end
+
+  def symbol_value(node)
+    return nil unless node.is_a?(Array)
+
+    case node.first
+    when :symbol_literal, :symbol then symbol_value(node[1])
+    when :dyna_symbol then string_value([:string_literal, node[1]])
+    when :@ident, :@const, :@kw then node[1]
+    end
+  end
+
+  def falsy_literal?(node)
+    node.is_a?(Array) && node.first == :var_ref && %w[false nil].include?(node[1][1])
+  end
+
+  def line_of(node)
+    return nil unless node.is_a?(Array)
+    return node[2][0] if node.first.to_s.start_with?("@")
+
+    node.each do |child|
+      line = line_of(child)
+      return line if line
+    end
+    nil
+  end
+
+  def singularize(word)
+    return word.sub(/ies\z/, "y") if word.end_with?("ies")
+    return word.sub(/(ss|x|z|ch|sh)es\z/, '\1') if word =~ /(ss|x|z|ch|sh)es\z/
+    return word.chomp("s") if word.end_with?("s") && !word.end_with?("ss")
+
+    word
+  end
+
+  def camelize(word)
+    word.split("/").map { |part| part.split("_").map(&:capitalize).join }.join("::")
+  end
+end
+
+source = $stdin.read.force_encoding(Encoding::UTF_8)
+builder = ScopeBuilder.new(source)
+tree = builder.parse
+if tree.nil? || builder.error?
+  warn "syntax error near line #{builder.lineno}"
+  exit 1
+end
+
+extractor = Extractor.new
+extractor.visit(tree)
+extractor.entities.each do |entity|
+  entity["attributes"] = entity["attributes"].uniq.sort
+  entity["depends_on"] = entity["depends_on"].uniq.sort
+end
+puts JSON.generate(
+  "language" => "ruby",
+  "entities" => extractor.entities,
+  "imports" => extractor.imports.uniq
+)
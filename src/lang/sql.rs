@@ -0,0 +1,1189 @@
+//! Built-in SQL parser.
+//!
+//! [`SqlParser`] splits `.sql` files into statements and reports:
+//!
+//! - `CREATE TABLE` schemas, as `Struct` entities with block type `table`
+//!   whose `attributes` are the column names and whose `depends_on` lists the
+//!   tables their foreign keys reference. Each column is a `Variable` child
+//!   (block type `column`) whose `signature` is its type and constraints and
+//!   whose `attributes` name those constraints (`not_null`, `primary_key`,
+//!   ...). Table-level constraints are `Variable` children with block type
+//!   `constraint`;
+//! - `SELECT`, `INSERT`, `UPDATE` and `DELETE` statements, as `Function`
+//!   entities whose block type is the verb, whose `signature` is the
+//!   statement pattern (keywords upper-cased, literals and parameters replaced
+//!   by `?`) and whose `depends_on` lists the tables the statement reads or
+//!   writes. Statements annotated with a `-- name: GetUser :one` comment (sqlc
+//!   and yesql style) take that name; others are named `<verb>@<line>`.
+//!
+//! Every table a file creates or references is listed in
+//! [`FileAnalysis::tables_referenced`]. [`GoEmbeds`] links `.sql` files back to
+//! the Go sources that `//go:embed` them.
+
+use std::collections::{BTreeMap, BTreeSet};
+use std::path::{Path, PathBuf};
+
+use globset::{GlobBuilder, GlobMatcher};
+use ignore::WalkBuilder;
+use tracing::warn;
+
+use crate::core::errors::Result;
+use crate::core::file_utils::FileReader;
+use crate::core::pipeline::discovery::IGNORE_FILE_NAME;
+use crate::lang::buffer_pool::source_chars;
+use crate::lang::common::EntityKind;
+use crate::lang::plugins::{decode_source, FileAnalysis, LanguageParser, PluginEntity};
+
+/// Language name reported for SQL files.
+pub const SQL_LANGUAGE: &str = "sql";
+
+/// Statement verbs reported as query entities.
+const QUERY_VERBS: &[&str] = &["SELECT", "INSERT", "UPDATE", "DELETE"];
+
+/// Keywords that end a column's type and start its constraints.
+const COLUMN_CONSTRAINTS: &[&str] = &[
+    "AUTOINCREMENT",
+    "AUTO_INCREMENT",
+    "CHECK",
+    "COLLATE",
+    "CONSTRAINT",
+    "DEFAULT",
+    "GENERATED",
+    "IDENTITY",
+    "NOT",
+    "NULL",
+    "PRIMARY",
+    "REFERENCES",
+    "UNIQUE",
+];
+
+/// Keywords that start a table-level constraint in `CREATE TABLE`.
+const TABLE_CONSTRAINTS: &[&str] = &[
+    "CHECK",
+    "CONSTRAINT",
+    "EXCLUDE",
+    "FOREIGN",
+    "INDEX",
+    "KEY",
+    "PRIMARY",
+    "UNIQUE",
+];
+
+/// Keywords upper-cased in query patterns and never taken for table names.
+const KEYWORDS: &[&str] = &[
+    "ALL",
+    "AND",
+    "AS",
+    "ASC",
+    "BETWEEN",
+    "BY",
+    "CASE",
+    "CONFLICT",
+    "CROSS",
+    "DELETE",
+    "DESC",
+    "DISTINCT",
+    "DO",
+    "ELSE",
+    "END",
+    "EXCEPT",
+    "EXISTS",
+    "FALSE",
+    "FOR",
+    "FROM",
+    "FULL",
+    "GROUP",
+    "HAVING",
+    "IF",
+    "ILIKE",
+    "IN",
+    "INNER",
+    "INSERT",
+    "INTERSECT",
+    "INTO",
+    "IS",
+    "JOIN",
+    "LATERAL",
+    "LEFT",
+    "LIKE",
+    "LIMIT",
+    "NATURAL",
+    "NOT",
+    "NOTHING",
+    "NULL",
+    "OF",
+    "OFFSET",
+    "ON",
+    "ONLY",
+    "OR",
+    "ORDER",
+    "OUTER",
+    "OVER",
+    "PARTITION",
+    "RECURSIVE",
+    "RETURNING",
+    "RIGHT",
+    "SELECT",
+    "SET",
+    "TABLE",
+    "THEN",
+    "TRUE",
+    "UNION",
+    "UPDATE",
+    "USING",
+    "VALUES",
+    "WHEN",
+    "WHERE",
+    "WITH",
+];
+
+/// Parser for SQL files.
+#[derive(Debug, Clone)]
+pub struct SqlParser {
+    extensions: Vec<String>,
+}
+
+/// Default implementation for [`SqlParser`].
+impl Default for SqlParser {
+    /// Returns a parser for `.sql` files.
+    fn default() -> Self {
+        Self {
+            extensions: vec!["sql".to_string()],
+        }
+    }
+}
+
+/// [`LanguageParser`] implementation for [`SqlParser`].
+impl LanguageParser for SqlParser {
+    fn name(&self) -> &str {
+        SQL_LANGUAGE
+    }
+
+    fn extensions(&self) -> &[String] {
+        &self.extensions
+    }
+
+    fn parse(&self, path: &Path, source: &[u8]) -> Result<FileAnalysis> {
+        let source = decode_source(SQL_LANGUAGE, path, source)?;
+        Ok(parse_sql(source))
+    }
+}
+
+/// Whether `path` is a `.sql` file.
+pub fn is_sql_file(path: &Path) -> bool {
+    path.extension()
+        .and_then(|ext| ext.to_str())
+        .is_some_and(|ext| ext.eq_ignore_ascii_case(SQL_LANGUAGE))
+}
+
+/// Extract the schemas, queries and referenced tables of one SQL file.
+pub fn parse_sql(source: &str) -> FileAnalysis {
+    let tokens = tokenize(source);
+    let mut entities = Vec::new();
+    let mut tables = BTreeSet::new();
+
+    for statement in tokens.split(|t| t.kind == Token::Symbol(";".to_string())) {
+        let name = statement.iter().find_map(|t| match &t.kind {
+            Token::QueryName(name) => Some(name.clone()),
+            _ => None,
+        });
+        let body: Vec<Spanned> = statement
+            .iter()
+            .filter(|t| !matches!(t.kind, Token::QueryName(_)))
+            .cloned()
+            .collect();
+        if body.is_empty() {
+            continue;
+        }
+
+        let ctes = cte_names(&body);
+        let referenced: BTreeSet<String> = table_references(&body)
+            .into_iter()
+            .filter(|table| !ctes.contains(table))
+            .collect();
+        tables.extend(referenced.iter().cloned());
+
+        if let Some(table) = create_table(&body) {
+            tables.insert(table[0].name.clone());
+            entities.extend(table);
+        } else if let Some(verb) = query_verb(&body) {
+            let start_line = body[0].line;
+            entities.push(PluginEntity {
+                name: name.unwrap_or_else(|| format!("{}@{start_line}", verb.to_lowercase())),
+                kind: EntityKind::Function,
+                start_line,
+                end_line: body[body.len() - 1].line,
+                parent: None,
+                block_type: Some(verb.to_lowercase()),
+                attributes: Vec::new(),
+                depends_on: referenced.into_iter().collect(),
+                signature: Some(render(&body, true)),
+            });
+        }
+    }
+
+    FileAnalysis {
+        language: Some(SQL_LANGUAGE.to_string()),
+        entities,
+        imports: Vec::new(),
+        tables_referenced: tables.into_iter().collect(),
+    }
+}
+
+/// Entities of a `CREATE TABLE` statement: the table followed by its columns
+/// and constraints. `None` for any other statement.
+fn create_table(tokens: &[Spanned]) -> Option<Vec<PluginEntity>> {
+    if !is_word(tokens.first()?, "CREATE") {
+        return None;
+    }
+    let mut index = 1;
+    while tokens.get(index).is_some_and(|t| {
+        [
+            "OR",
+            "REPLACE",
+            "TEMP",
+            "TEMPORARY",
+            "UNLOGGED",
+            "GLOBAL",
+            "LOCAL",
+        ]
+        .iter()
+        .any(|word| is_word(t, word))
+    }) {
+        index += 1;
+    }
+    if !is_word(tokens.get(index)?, "TABLE") {
+        return None;
+    }
+    index = skip_words(tokens, index + 1, &["IF", "NOT", "EXISTS"]);
+    let (table_name, after_name) = qualified_name(tokens, index)?;
+
+    let start_line = tokens[0].line;
+    let mut table = PluginEntity {
+        name: table_name.clone(),
+        kind: EntityKind::Struct,
+        start_line,
+        end_line: tokens[tokens.len() - 1].line,
+        parent: None,
+        block_type: Some("table".to_string()),
+        attributes: Vec::new(),
+        depends_on: Vec::new(),
+        signature: None,
+    };
+    let mut children = Vec::new();
+    let mut foreign_tables = BTreeSet::new();
+
+    if tokens
+        .get(after_name)
+        .is_some_and(|t| t.kind.is_symbol("("))
+    {
+        let close = matching_close(tokens, after_name);
+        for definition in split_top_level(&tokens[after_name + 1..close]) {
+            let Some(first) = definition.first() else {
+                continue;
+            };
+            let references = referenced_tables(definition);
+            foreign_tables.extend(references.iter().cloned());
+            let entity = if TABLE_CONSTRAINTS.iter().any(|word| is_word(first, word)) {
+                table_constraint(definition, &table_name)
+            } else {
+                let column = column(definition, &table_name);
+                table.attributes.push(column.name.clone());
+                column
+            };
+            children.push(PluginEntity {
+                depends_on: references,
+                ..entity
+            });
+        }
+    }
+
+    foreign_tables.remove(&table_name);
+    table.depends_on = foreign_tables.into_iter().collect();
+    let mut entities = vec![table];
+    entities.extend(children);
+    Some(entities)
+}
+
+/// Column definition: name, then type up to the first constraint keyword.
+fn column(definition: &[Spanned], table: &str) -> PluginEntity {
+    let name = identifier(&definition[0]).unwrap_or_default();
+    let rest = &definition[1..];
+    let type_end = rest
+        .iter()
+        .position(|t| COLUMN_CONSTRAINTS.iter().any(|word| is_word(t, word)))
+        .unwrap_or(rest.len());
+    PluginEntity {
+        name,
+        kind: EntityKind::Variable,
+        start_line: definition[0].line,
+        end_line: definition[definition.len() - 1].line,
+        parent: Some(table.to_string()),
+        block_type: Some("column".to_string()),
+        attributes: constraint_kinds(&rest[type_end..]),
+        depends_on: Vec::new(),
+        signature: Some(render(rest, false)).filter(|signature| !signature.is_empty()),
+    }
+}
+
+/// Table-level constraint, named by its `CONSTRAINT` name or its kind.
+fn table_constraint(definition: &[Spanned], table: &str) -> PluginEntity {
+    let kinds = constraint_kinds(definition);
+    let name = if is_word(&definition[0], "CONSTRAINT") {
+        definition.get(1).and_then(identifier)
+    } else {
+        None
+    };
+    PluginEntity {
+        name: name
+            .or_else(|| kinds.first().cloned())
+            .unwrap_or_else(|| "constraint".to_string()),
+        kind: EntityKind::Variable,
+        start_line: definition[0].line,
+        end_line: definition[definition.len() - 1].line,
+        parent: Some(table.to_string()),
+        block_type: Some("constraint".to_string()),
+        attributes: kinds,
+        depends_on: Vec::new(),
+        signature: Some(render(definition, false)),
+    }
+}
+
+/// Kinds of the constraints in `tokens` (`primary_key`, `not_null`, ...), sorted.
+fn constraint_kinds(tokens: &[Spanned]) -> Vec<String> {
+    let mut kinds = BTreeSet::new();
+    let mut depth = 0usize;
+    for (index, token) in tokens.iter().enumerate() {
+        if token.kind.is_symbol("(") {
+            depth += 1;
+        } else if token.kind.is_symbol(")") {
+            depth = depth.saturating_sub(1);
+        }
+        if depth > 0 {
+            continue;
+        }
+        let next_is = |word: &str| tokens.get(index + 1).is_some_and(|t| is_word(t, word));
+        let kind = match token.kind.upper().as_deref() {
+            Some("PRIMARY") => "primary_key",
+            Some("FOREIGN") => "foreign_key",
+            Some("REFERENCES") => "references",
+            Some("UNIQUE") => "unique",
+            Some("CHECK") => "check",
+            Some("DEFAULT") => "default",
+            Some("EXCLUDE") => "exclude",
+            Some("GENERATED") | Some("IDENTITY") => "generated",
+            Some("AUTOINCREMENT") | Some("AUTO_INCREMENT") => "generated",
+            Some("INDEX") | Some("KEY") if index == 0 => "index",
+            Some("NOT") if next_is("NULL") => "not_null",
+            _ => continue,
+        };
+        kinds.insert(kind.to_string());
+    }
+    kinds.into_iter().collect()
+}
+
+/// Verb of a query statement, looking past `WITH` common table expressions.
+fn query_verb(tokens: &[Spanned]) -> Option<String> {
+    let first = tokens.first()?.kind.upper()?;
+    if first != "WITH" {
+        return QUERY_VERBS.contains(&first.as_str()).then_some(first);
+    }
+    let mut depth = 0usize;
+    for token in tokens {
+        if token.kind.is_symbol("(") {
+            depth += 1;
+        } else if token.kind.is_symbol(")") {
+            depth = depth.saturating_sub(1);
+        } else if depth == 0 {
+            if let Some(word) = token
+                .kind
+                .upper()
+                .filter(|w| QUERY_VERBS.contains(&w.as_str()))
+            {
+                return Some(word);
+            }
+        }
+    }
+    None
+}
+
+/// Names defined by a leading `WITH` clause.
+fn cte_names(tokens: &[Spanned]) -> BTreeSet<String> {
+    let mut names = BTreeSet::new();
+    if !tokens.first().is_some_and(|t| is_word(t, "WITH")) {
+        return names;
+    }
+    let mut index = skip_words(tokens, 1, &["RECURSIVE"]);
+    while let Some(name) = tokens.get(index).and_then(identifier) {
+        names.insert(name);
+        index += 1;
+        if tokens.get(index).is_some_and(|t| t.kind.is_symbol("(")) {
+            index = matching_close(tokens, index) + 1;
+        }
+        index = skip_words(tokens, index, &["AS", "NOT", "MATERIALIZED"]);
+        if !tokens.get(index).is_some_and(|t| t.kind.is_symbol("(")) {
+            break;
+        }
+        index = matching_close(tokens, index) + 1;
+        if !tokens.get(index).is_some_and(|t| t.kind.is_symbol(",")) {
+            break;
+        }
+        index += 1;
+    }
+    names
+}
+
+/// Tables named by `REFERENCES` in a column or constraint definition.
+fn referenced_tables(tokens: &[Spanned]) -> Vec<String> {
+    let found: BTreeSet<String> = tokens
+        .iter()
+        .enumerate()
+        .filter(|(_, t)| is_word(t, "REFERENCES"))
+        .filter_map(|(index, _)| qualified_name(tokens, index + 1).map(|(name, _)| name))
+        .collect();
+    found.into_iter().collect()
+}
+
+/// Tables read or written by a statement: the targets of `FROM`, `JOIN`,
+/// `INTO`, `UPDATE`, `TABLE`, `REFERENCES` and `CREATE INDEX ... ON`.
+///
+/// `FROM` inside a function call (`EXTRACT(YEAR FROM ts)`) or after
+/// `IS DISTINCT` is not a table reference, nor is a name called like a
+/// function (`FROM generate_series(1, 10)`).
+fn table_references(tokens: &[Spanned]) -> Vec<String> {
+    let mut found = Vec::new();
+    let is_index = tokens.len() > 1
+        && is_word(&tokens[0], "CREATE")
+        && tokens[1..4.min(tokens.len())]
+            .iter()
+            .any(|t| is_word(t, "INDEX"));
+    // One entry per open parenthesis: whether it opens a subquery.
+    let mut parens: Vec<bool> = Vec::new();
+
+    for (index, token) in tokens.iter().enumerate() {
+        if token.kind.is_symbol("(") {
+            let next = tokens.get(index + 1);
+            parens.push(next.is_some_and(|t| is_word(t, "SELECT") || is_word(t, "WITH")));
+            continue;
+        }
+        if token.kind.is_symbol(")") {
+            parens.pop();
+            continue;
+        }
+        let in_query = parens.last().copied().unwrap_or(true);
+        let Some(word) = token.kind.upper() else {
+            continue;
+        };
+        let after_distinct = index > 0 && is_word(&tokens[index - 1], "DISTINCT");
+        let mut cursor = match word.as_str() {
+            "FROM" | "JOIN" if in_query && !after_distinct => index + 1,
+            "INTO" | "TABLE" | "REFERENCES" => index + 1,
+            "UPDATE" if index == 0 || !tokens.get(index + 1).is_some_and(|t| is_word(t, "SET")) => {
+                index + 1
+            }
+            "ON" if is_index => index + 1,
+            _ => continue,
+        };
+        loop {
+            cursor = skip_words(tokens, cursor, &["IF", "NOT", "EXISTS", "ONLY", "LATERAL"]);
+            let Some((name, after)) = qualified_name(tokens, cursor) else {
+                break;
+            };
+            if tokens.get(after).is_some_and(|t| t.kind.is_symbol("(")) && word != "INTO" {
+                break;
+            }
+            found.push(name);
+            if word != "FROM" {
+                break;
+            }
+            // `FROM a AS x, b y` lists several tables.
+            cursor = skip_words(tokens, after, &["AS"]);
+            if tokens.get(cursor).is_some_and(|t| identifier(t).is_some()) {
+                cursor += 1;
+            }
+            if !tokens.get(cursor).is_some_and(|t| t.kind.is_symbol(",")) {
+                break;
+            }
+            cursor += 1;
+        }
+    }
+    found
+}
+
+/// A dotted name (`schema.table`) starting at `index`, and the index after it.
+fn qualified_name(tokens: &[Spanned], index: usize) -> Option<(String, usize)> {
+    let mut name = identifier(tokens.get(index)?)?;
+    let mut index = index + 1;
+    while tokens.get(index).is_some_and(|t| t.kind.is_symbol(".")) {
+        let Some(part) = tokens.get(index + 1).and_then(identifier) else {
+            break;
+        };
+        name.push('.');
+        name.push_str(&part);
+        index += 2;
+    }
+    Some((name, index))
+}
+
+/// The identifier a token names, if it is a quoted name or a non-keyword word.
+fn identifier(token: &Spanned) -> Option<String> {
+    match &token.kind {
+        Token::Quoted(name) => Some(name.clone()),
+        Token::Word(word) if !KEYWORDS.contains(&word.to_uppercase().as_str()) => {
+            Some(word.clone())
+        }
+        _ => None,
+    }
+}
+
+/// Index of the first token at or after `index` that is not one of `words`.
+fn skip_words(tokens: &[Spanned], mut index: usize, words: &[&str]) -> usize {
+    while tokens
+        .get(index)
+        .is_some_and(|t| words.iter().any(|word| is_word(t, word)))
+    {
+        index += 1;
+    }
+    index
+}
+
+/// Whether `token` is the word `word`, ignoring case.
+fn is_word(token: &Spanned, word: &str) -> bool {
+    matches!(&token.kind, Token::Word(w) if w.eq_ignore_ascii_case(word))
+}
+
+/// Index of the parenthesis closing the one at `open` (or `tokens.len()`).
+fn matching_close(tokens: &[Spanned], open: usize) -> usize {
+    let mut depth = 0usize;
+    for (index, token) in tokens.iter().enumerate().skip(open) {
+        if token.kind.is_symbol("(") {
+            depth += 1;
+        } else if token.kind.is_symbol(")") {
+            depth = depth.saturating_sub(1);
+            if depth == 0 {
+                return index;
+            }
+        }
+    }
+    tokens.len()
+}
+
+/// Split `tokens` on commas outside parentheses.
+fn split_top_level(tokens: &[Spanned]) -> Vec<&[Spanned]> {
+    let mut parts = Vec::new();
+    let mut depth = 0usize;
+    let mut start = 0;
+    for (index, token) in tokens.iter().enumerate() {
+        if token.kind.is_symbol("(") {
+            depth += 1;
+        } else if token.kind.is_symbol(")") {
+            depth = depth.saturating_sub(1);
+        } else if depth == 0 && token.kind.is_symbol(",") {
+            parts.push(&tokens[start..index]);
+            start = index + 1;
+        }
+    }
+    parts.push(&tokens[start..]);
+    parts.retain(|part| !part.is_empty());
+    parts
+}
+
+/// Render tokens on one line, keeping the original spacing between them.
+///
+/// With `pattern`, keywords are upper-cased, literals and parameters become
+/// `?`, and `IN (...)` lists of placeholders collapse to `IN (?)`.
+fn render(tokens: &[Spanned], pattern: bool) -> String {
+    let mut out = String::new();
+    let mut index = 0;
+    while index < tokens.len() {
+        let token = &tokens[index];
+        let text = match &token.kind {
+            Token::Word(word) if pattern && KEYWORDS.contains(&word.to_uppercase().as_str()) => {
+                word.to_uppercase()
+            }
+            Token::Word(word) => word.clone(),
+            Token::Quoted(name) => format!("\"{name}\""),
+            Token::Literal(_) | Token::Param(_) if pattern => "?".to_string(),
+            Token::Literal(text) | Token::Param(text) | Token::Symbol(text) => text.clone(),
+            Token::QueryName(_) => String::new(),
+        };
+        let glued = token.kind.is_symbol(",")
+            || token.kind.is_symbol(")")
+            || token.kind.is_symbol(".")
+            || token.kind.is_symbol("::")
+            || out.ends_with('(')
+            || out.ends_with('.')
+            || out.ends_with("::");
+        if !out.is_empty() && token.spaced && !glued {
+            out.push(' ');
+        }
+        out.push_str(&text);
+
+        if pattern && is_word(token, "IN") {
+            if let Some(close) = placeholder_list(tokens, index + 1) {
+                out.push_str(" (?)");
+                index = close + 1;
+                continue;
+            }
+        }
+        index += 1;
+    }
+    out
+}
+
+/// Index of the `)` closing a parenthesized list of literals and parameters
+/// opened at `open`, or `None` when the list holds anything else.
+fn placeholder_list(tokens: &[Spanned], open: usize) -> Option<usize> {
+    if !tokens.get(open)?.kind.is_symbol("(") {
+        return None;
+    }
+    let close = matching_close(tokens, open);
+    let only_values = tokens
+        .get(open + 1..close)?
+        .iter()
+        .all(|t| matches!(t.kind, Token::Literal(_) | Token::Param(_)) || t.kind.is_symbol(","));
+    (close < tokens.len() && close > open + 1 && only_values).then_some(close)
+}
+
+/// `.sql` files embedded by Go sources through `//go:embed` directives.
+#[derive(Debug, Clone, Default, PartialEq, Eq)]
+pub struct GoEmbeds {
+    embedded_by: BTreeMap<PathBuf, BTreeSet<PathBuf>>,
+}
+
+/// Discovery and lookup methods for [`GoEmbeds`].
+impl GoEmbeds {
+    /// Match the `//go:embed` patterns of every Go file under `root` against
+    /// the `.sql` files next to or below it.
+    ///
+    /// Patterns are resolved relative to the Go file's directory; a pattern
+    /// naming a directory embeds every file beneath it, as `go build` does.
+    pub fn scan(root: &Path) -> Self {
+        let mut builder = WalkBuilder::new(root);
+        builder.add_custom_ignore_filename(IGNORE_FILE_NAME);
+        let (mut go_files, mut sql_files) = (Vec::new(), Vec::new());
+        for path in builder
+            .build()
+            .filter_map(|entry| entry.ok().map(|entry| entry.into_path()))
+            .filter(|path| path.is_file())
+        {
+            if is_sql_file(&path) {
+                sql_files.push(path);
+            } else if path.extension().is_some_and(|ext| ext == "go") {
+                go_files.push(path);
+            }
+        }
+
+        let mut embeds = Self::default();
+        for go_file in go_files {
+            let source = match FileReader::read_to_string(&go_file) {
+                Ok(source) => source,
+                Err(err) => {
+                    warn!("Skipping {}: {}", go_file.display(), err);
+                    continue;
+                }
+            };
+            let matchers: Vec<GlobMatcher> = go_embed_patterns(&source)
+                .iter()
+                .filter_map(|pattern| embed_matcher(pattern, &go_file))
+                .collect();
+            let dir = go_file.parent().unwrap_or(Path::new(""));
+            for sql_file in &sql_files {
+                let Ok(relative) = sql_file.strip_prefix(dir) else {
+                    continue;
+                };
+                let embedded = relative
+                    .ancestors()
+                    .filter(|path| !path.as_os_str().is_empty())
+                    .any(|path| matchers.iter().any(|matcher| matcher.is_match(path)));
+                if embedded {
+                    embeds
+                        .embedded_by
+                        .entry(sql_file.clone())
+                        .or_default()
+                        .insert(go_file.clone());
+                }
+            }
+        }
+        embeds
+    }
+
+    /// Go files embedding `sql_file`, sorted.
+    pub fn embedders(&self, sql_file: &Path) -> Vec<&Path> {
+        self.embedded_by
+            .get(sql_file)
+            .map(|files| files.iter().map(PathBuf::as_path).collect())
+            .unwrap_or_default()
+    }
+
+    /// Whether no `.sql` file is embedded.
+    pub fn is_empty(&self) -> bool {
+        self.embedded_by.is_empty()
+    }
+}
+
+/// Patterns of the `//go:embed` directives in a Go source, in order.
+///
+/// Patterns may be quoted with `"` or `` ` `` to contain spaces; the `all:`
+/// prefix (which also embeds hidden files) is dropped.
+pub fn go_embed_patterns(source: &str) -> Vec<String> {
+    let mut patterns = Vec::new();
+    for line in source.lines() {
+        let Some(rest) = line.trim_start().strip_prefix("//go:embed") else {
+            continue;
+        };
+        if !rest.starts_with(char::is_whitespace) {
+            continue;
+        }
+        let mut chars = rest.trim().chars().peekable();
+        while let Some(&c) = chars.peek() {
+            if c.is_whitespace() {
+                chars.next();
+                continue;
+            }
+            let pattern: String = if c == '"' || c == '`' {
+                chars.next();
+                chars.by_ref().take_while(|&next| next != c).collect()
+            } else {
+                chars
+                    .by_ref()
+                    .take_while(|next| !next.is_whitespace())
+                    .collect()
+            };
+            let pattern = pattern.strip_prefix("all:").unwrap_or(&pattern);
+            if !pattern.is_empty() {
+                patterns.push(pattern.to_string());
+            }
+        }
+    }
+    patterns
+}
+
+/// Glob matching paths relative to the directory of `go_file`.
+fn embed_matcher(pattern: &str, go_file: &Path) -> Option<GlobMatcher> {
+    match GlobBuilder::new(pattern).literal_separator(true).build() {
+        Ok(glob) => Some(glob.compile_matcher()),
+        Err(err) => {
+            warn!(
+                "Ignoring //go:embed pattern {:?} in {}: {}",
+                pattern,
+                go_file.display(),
+                err
+            );
+            None
+        }
+    }
+}
+
+/// Lexical token of the SQL subset the parser needs.
+#[derive(Debug, Clone, PartialEq)]
+enum Token {
+    /// Unquoted identifier or keyword
+    Word(String),
+    /// Quoted identifier (`"name"`, `` `name` ``, `[name]`), without quotes
+    Quoted(String),
+    /// String, number or dollar-quoted literal, as written
+    Literal(String),
+    /// Bind parameter (`$1`, `?`, `:name`, `@name`), as written
+    Param(String),
+    /// Punctuation or operator (`(`, `,`, `.`, `::`, `<=`, ...)
+    Symbol(String),
+    /// Name from a `-- name: GetUser :one` annotation
+    QueryName(String),
+}
+
+/// Query methods for [`Token`].
+impl Token {
+    /// Whether the token is the symbol `symbol`.
+    fn is_symbol(&self, symbol: &str) -> bool {
+        matches!(self, Self::Symbol(s) if s == symbol)
+    }
+
+    /// Upper-cased text of a word token.
+    fn upper(&self) -> Option<String> {
+        match self {
+            Self::Word(word) => Some(word.to_uppercase()),
+            _ => None,
+        }
+    }
+}
+
+/// A token with its 1-based line and whether whitespace preceded it.
+#[derive(Debug, Clone)]
+struct Spanned {
+    kind: Token,
+    line: usize,
+    spaced: bool,
+}
+
+/// Split `source` into tokens, skipping comments other than query names.
+fn tokenize(source: &str) -> Vec<Spanned> {
+    let chars = source_chars(source);
+    let mut tokens = Vec::new();
+    let mut line = 1;
+    let mut spaced = false;
+    let mut i = 0;
+
+    while i < chars.len() {
+        let c = chars[i];
+        let next = chars.get(i + 1).copied();
+        let start = i;
+        let start_line = line;
+        let kind = match c {
+            c if c.is_whitespace() => {
+                line += usize::from(c == '\n');
+                spaced = true;
+                i += 1;
+                continue;
+            }
+            '-' if next == Some('-') => {
+                let end = chars[i..]
+                    .iter()
+                    .position(|&ch| ch == '\n')
+                    .map_or(chars.len(), |offset| i + offset);
+                let comment: String = chars[i + 2..end].iter().collect();
+                i = end;
+                spaced = true;
+                match query_name(&comment) {
+                    Some(name) => Token::QueryName(name),
+                    None => continue,
+                }
+            }
+            '/' if next == Some('*') => {
+                i += 2;
+                while i < chars.len() && !(chars[i] == '*' && chars.get(i + 1) == Some(&'/')) {
+                    line += usize::from(chars[i] == '\n');
+                    i += 1;
+                }
+                i = (i + 2).min(chars.len());
+                spaced = true;
+                continue;
+            }
+            '\'' => {
+                i = skip_quoted(&chars, i, '\'', &mut line);
+                Token::Literal(chars[start..i].iter().collect())
+            }
+            '"' | '`' | '[' => {
+                let close = if c == '[' { ']' } else { c };
+                i = skip_quoted(&chars, i, close, &mut line);
+                let end = if chars.get(i - 1) == Some(&close) && i - 1 > start {
+                    i - 1
+                } else {
+                    i
+                };
+                Token::Quoted(chars[start + 1..end].iter().collect())
+            }
+            '$' if next.is_some_and(|n| n.is_ascii_digit()) => {
+                i += 1;
+                while i < chars.len() && chars[i].is_ascii_digit() {
+                    i += 1;
+                }
+                Token::Param(chars[start..i].iter().collect())
+            }
+            '$' => match dollar_quote_end(&chars, i) {
+                Some(end) => {
+                    line += chars[i..end].iter().filter(|&&ch| ch == '\n').count();
+                    i = end;
+                    Token::Literal(chars[start..i].iter().collect())
+                }
+                None => {
+                    i += 1;
+                    Token::Symbol("$".to_string())
+                }
+            },
+            '?' => {
+                i += 1;
+                Token::Param("?".to_string())
+            }
+            ':' | '@' if next.is_some_and(is_word_start) => {
+                i += 1;
+                while i < chars.len() && is_word_char(chars[i]) {
+                    i += 1;
+                }
+                Token::Param(chars[start..i].iter().collect())
+            }
+            c if c.is_ascii_digit() || (c == '.' && next.is_some_and(|n| n.is_ascii_digit())) => {
+                while i < chars.len() && (chars[i].is_ascii_alphanumeric() || chars[i] == '.') {
+                    i += 1;
+                }
+                Token::Literal(chars[start..i].iter().collect())
+            }
+            c if is_word_start(c) => {
+                while i < chars.len() && is_word_char(chars[i]) {
+                    i += 1;
+                }
+                Token::Word(chars[start..i].iter().collect())
+            }
+            _ => {
+                let pair: String = chars[i..(i + 2).min(chars.len())].iter().collect();
+                let len =
+                    if ["::", "<=", ">=", "<>", "!=", "||", "->", "=>"].contains(&pair.as_str()) {
+                        2
+                    } else {
+                        1
+                    };
+                i += len;
+                Token::Symbol(chars[start..i].iter().collect())
+            }
+        };
+        tokens.push(Spanned {
+            kind,
+            line: start_line,
+            spaced,
+        });
+        spaced = false;
+    }
+    tokens
+}
+
+/// Name declared by a `name: GetUser :one` comment body.
+fn query_name(comment: &str) -> Option<String> {
+    let rest = comment.trim_start().strip_prefix("name:")?;
+    rest.split_whitespace()
+        .next()
+        .filter(|name| !name.starts_with(':'))
+        .map(str::to_string)
+}
+
+/// Index after the quoted run opened at `start` and closed by `close`; a
+/// doubled closing character is an escaped one.
+fn skip_quoted(chars: &[char], start: usize, close: char, line: &mut usize) -> usize {
+    let mut i = start + 1;
+    while i < chars.len() {
+        if chars[i] == close {
+            if chars.get(i + 1) == Some(&close) {
+                i += 2;
+                continue;
+            }
+            return i + 1;
+        }
+        *line += usize::from(chars[i] == '\n');
+        i += 1;
+    }
+    i
+}
+
+/// Index after the PostgreSQL dollar-quoted string (`$$...$$`, `$tag$...$tag$`)
+/// starting at `start`, if one starts there.
+fn dollar_quote_end(chars: &[char], start: usize) -> Option<usize> {
+    let tag_len = chars[start + 1..]
+        .iter()
+        .position(|&c| c == '$')
+        .filter(|&len| {
+            chars[start + 1..start + 1 + len]
+                .iter()
+                .all(|&c| is_word_char(c))
+        })?;
+    let tag = &chars[start..start + tag_len + 2];
+    let body = start + tag.len();
+    (body..=chars.len().saturating_sub(tag.len()))
+        .find(|&i| chars[i..i + tag.len()] == *tag)
+        .map(|i| i + tag.len())
+}
+
+/// Whether `c` can start an unquoted identifier.
+fn is_word_start(c: char) -> bool {
+    c.is_alphabetic() || c == '_'
+}
+
+/// Whether `c` can continue an unquoted identifier.
+fn is_word_char(c: char) -> bool {
+    c.is_alphanumeric() || c == '_' || c == '$'
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use std::fs;
+    use tempfile::TempDir;
+
+    const SCHEMA_SQL: &str = r#"-- Accounts and their sessions.
+CREATE TABLE IF NOT EXISTS users (
+    id BIGSERIAL PRIMARY KEY,
+    email VARCHAR(255) NOT NULL UNIQUE,
+    status TEXT DEFAULT 'active',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE TABLE sessions (
+    id UUID,
+    user_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    expires_at TIMESTAMPTZ,
+    CONSTRAINT sessions_pk PRIMARY KEY (id, user_id)
+);
+"#;
+
+    const QUERIES_SQL: &str = r#"-- name: GetUser :one
+SELECT id, email FROM users WHERE id = $1 AND status = 'active';
+
+-- name: ListSessions :many
+SELECT s.id, u.email
+FROM sessions s
+JOIN users u ON u.id = s.user_id
+WHERE s.expires_at > now() AND u.id IN ($1, $2, 3)
+ORDER BY s.expires_at DESC;
+
+-- name: CreateUser :exec
+INSERT INTO users (email, created_at) VALUES (?, now());
+
+UPDATE users SET status = 'gone' WHERE extract(year FROM created_at) < 2020;
+
+WITH stale AS (SELECT user_id FROM sessions WHERE expires_at < now())
+DELETE FROM sessions WHERE user_id IN (SELECT user_id FROM stale);
+"#;
+
+    fn entity<'a>(analysis: &'a FileAnalysis, name: &str) -> &'a PluginEntity {
+        analysis
+            .entities
+            .iter()
+            .find(|entity| entity.name == name)
+            .unwrap_or_else(|| panic!("missing {name} in {:#?}", analysis.entities))
+    }
+
+    fn child<'a>(analysis: &'a FileAnalysis, parent: &str, name: &str) -> &'a PluginEntity {
+        analysis
+            .entities
+            .iter()
+            .find(|entity| entity.name == name && entity.parent.as_deref() == Some(parent))
+            .unwrap_or_else(|| panic!("missing {parent}.{name}"))
+    }
+
+    #[test]
+    fn extracts_table_schemas() {
+        let analysis = parse_sql(SCHEMA_SQL);
+        assert_eq!(analysis.language.as_deref(), Some("sql"));
+        assert_eq!(analysis.tables_referenced, vec!["sessions", "users"]);
+
+        let users = entity(&analysis, "users");
+        assert_eq!(users.kind, EntityKind::Struct);
+        assert_eq!(users.block_type.as_deref(), Some("table"));
+        assert_eq!((users.start_line, users.end_line), (2, 7));
+        assert_eq!(
+            users.attributes,
+            vec!["id", "email", "status", "created_at"]
+        );
+        assert!(users.depends_on.is_empty());
+
+        let email = child(&analysis, "users", "email");
+        assert_eq!(email.block_type.as_deref(), Some("column"));
+        assert_eq!(
+            email.signature.as_deref(),
+            Some("VARCHAR(255) NOT NULL UNIQUE")
+        );
+        assert_eq!(email.attributes, vec!["not_null", "unique"]);
+        assert_eq!(
+            child(&analysis, "users", "created_at").signature.as_deref(),
+            Some("TIMESTAMP WITH TIME ZONE NOT NULL")
+        );
+        assert_eq!(
+            child(&analysis, "users", "status").signature.as_deref(),
+            Some("TEXT DEFAULT 'active'")
+        );
+
+        let sessions = entity(&analysis, "sessions");
+        assert_eq!(sessions.attributes, vec!["id", "user_id", "expires_at"]);
+        assert_eq!(sessions.depends_on, vec!["users"]);
+        let user_id = child(&analysis, "sessions", "user_id");
+        assert_eq!(user_id.depends_on, vec!["users"]);
+        assert_eq!(user_id.attributes, vec!["not_null", "references"]);
+        let pk = child(&analysis, "sessions", "sessions_pk");
+        assert_eq!(pk.block_type.as_deref(), Some("constraint"));
+        assert_eq!(pk.attributes, vec!["primary_key"]);
+        assert_eq!(
+            pk.signature.as_deref(),
+            Some("CONSTRAINT sessions_pk PRIMARY KEY (id, user_id)")
+        );
+    }
+
+    #[test]
+    fn extracts_named_queries_and_patterns() {
+        let analysis = parse_sql(QUERIES_SQL);
+        assert_eq!(analysis.tables_referenced, vec!["sessions", "users"]);
+
+        let get_user = entity(&analysis, "GetUser");
+        assert_eq!(get_user.kind, EntityKind::Function);
+        assert_eq!(get_user.block_type.as_deref(), Some("select"));
+        assert_eq!(get_user.start_line, 2);
+        assert_eq!(get_user.depends_on, vec!["users"]);
+        assert_eq!(
+            get_user.signature.as_deref(),
+            Some("SELECT id, email FROM users WHERE id = ? AND status = ?")
+        );
+
+        let list = entity(&analysis, "ListSessions");
+        assert_eq!((list.start_line, list.end_line), (5, 9));
+        assert_eq!(list.depends_on, vec!["sessions", "users"]);
+        assert!(list
+            .signature
+            .as_deref()
+            .unwrap()
+            .ends_with("u.id IN (?) ORDER BY s.expires_at DESC"));
+
+        let create = entity(&analysis, "CreateUser");
+        assert_eq!(create.block_type.as_deref(), Some("insert"));
+        assert_eq!(
+            create.signature.as_deref(),
+            Some("INSERT INTO users (email, created_at) VALUES (?, now())")
+        );
+
+        let update = entity(&analysis, "update@14");
+        assert_eq!(update.depends_on, vec!["users"]);
+
+        let delete = entity(&analysis, "delete@16");
+        assert_eq!(delete.block_type.as_deref(), Some("delete"));
+        assert_eq!(delete.depends_on, vec!["sessions"]);
+    }
+
+    #[test]
+    fn from_lists_and_dollar_quotes() {
+        let analysis = parse_sql(
+            "SELECT * FROM orders o, public.customers AS c WHERE note = $$a; b$$;\n\
+             SELECT * FROM generate_series(1, 3);\n",
+        );
+        assert_eq!(
+            analysis.tables_referenced,
+            vec!["orders", "public.customers"]
+        );
+        assert_eq!(analysis.entities.len(), 2);
+        assert_eq!(analysis.entities[1].name, "select@2");
+        assert!(analysis.entities[1].depends_on.is_empty());
+    }
+
+    #[test]
+    fn parses_go_embed_directives() {
+        let source = "package db\n\n//go:embed schema.sql \"queries dir/*.sql\"\n\
+                      //go:embed all:migrations\nvar files embed.FS\n// go:embed ignored.sql\n";
+        assert_eq!(
+            go_embed_patterns(source),
+            vec!["schema.sql", "queries dir/*.sql", "migrations"]
+        );
+    }
+
+    #[test]
+    fn links_sql_files_to_embedding_go_files() {
+        let dir = TempDir::new().unwrap();
+        let root = dir.path();
+        fs::create_dir_all(root.join("db/migrations/v1")).unwrap();
+        fs::create_dir_all(root.join("db/queries")).unwrap();
+        fs::write(
+            root.join("db/embed.go"),
+            "package db\n\n//go:embed migrations queries/*.sql\nvar fs embed.FS\n",
+        )
+        .unwrap();
+        fs::write(root.join("db/migrations/v1/001.sql"), "SELECT 1;").unwrap();
+        fs::write(root.join("db/queries/users.sql"), "SELECT 1;").unwrap();
+        fs::write(root.join("db/schema.sql"), "SELECT 1;").unwrap();
+
+        let embeds = GoEmbeds::scan(root);
+        let embed_go = root.join("db/embed.go");
+        assert_eq!(
+            embeds.embedders(&root.join("db/migrations/v1/001.sql")),
+            vec![embed_go.as_path()]
+        );
+        assert_eq!(
+            embeds.embedders(&root.join("db/queries/users.sql")),
+            vec![embed_go.as_path()]
+        );
+        assert!(embeds.embedders(&root.join("db/schema.sql")).is_empty());
+    }
+}
@@ -0,0 +1,1466 @@
+//! Built-in Swift parser.
+//!
+//! [`SwiftParser`] reads `.swift` files into a [`FileAnalysis`] with language
+//! `"swift"`:
+//!
+//! - `class`, `actor`, `struct`, `enum` and `protocol` declarations become
+//!   `Class`, `Struct`, `Enum` and `Interface` entities (block type: the
+//!   keyword), named by their nesting (`Store.Item`). Their `depends_on` lists
+//!   the superclass and adopted protocols, and their `attributes` are the
+//!   property names, or the case names of an enum;
+//! - `func`, `init`, `deinit` and `subscript` become `Function` entities, or
+//!   `Method` entities inside a type, named with their argument labels the way
+//!   Swift spells them (`Store.load(id:)`, `Store.init(_:)`), so overloads stay
+//!   distinct;
+//! - `var` and `let` declarations outside function bodies become `Variable` and
+//!   `Constant` entities. Property wrappers (`@Published`, `@State`, ...) are
+//!   listed in their `depends_on`;
+//! - `typealias` declarations become `Interface` entities with block type
+//!   `typealias`, as TypeScript type aliases do;
+//! - the `signature` of every declaration is its head as written, including
+//!   attributes, access modifiers (`public`, `internal`, `fileprivate`,
+//!   `private`, `open`) and `async`/`throws` effects, up to the body;
+//! - `import` paths are reported as [`FileAnalysis::imports`].
+//!
+//! Extensions add members to a type declared elsewhere, so they are merged
+//! rather than reported as declarations of their own: members of
+//! `extension Store` are children of `Store`, and the protocols the extension
+//! adopts join the type's `depends_on`. When the type is declared in the same
+//! file the extension disappears into it; otherwise the file reports a single
+//! `Class` entity with block type `extension` for the extended type (the
+//! type's own kind is not known). [`merge_types`] combines the declarations
+//! and extensions of a type across files.
+
+use std::collections::BTreeMap;
+use std::path::Path;
+
+use crate::core::errors::Result;
+use crate::lang::buffer_pool::source_chars;
+use crate::lang::common::{self, line_end, scan_number, scan_operator, EntityKind, Lexeme};
+use crate::lang::plugins::{decode_source, FileAnalysis, LanguageParser, PluginEntity};
+
+/// Language name reported for Swift files.
+pub const SWIFT_LANGUAGE: &str = "swift";
+
+/// Block type of the entity standing in for an extended type.
+const EXTENSION: &str = "extension";
+
+/// Declaration modifiers that may precede the declaration keyword.
+const MODIFIERS: &[&str] = &[
+    "public",
+    "private",
+    "fileprivate",
+    "internal",
+    "open",
+    "package",
+    "final",
+    "static",
+    "override",
+    "mutating",
+    "nonmutating",
+    "convenience",
+    "required",
+    "lazy",
+    "weak",
+    "unowned",
+    "dynamic",
+    "optional",
+    "indirect",
+    "nonisolated",
+    "distributed",
+    "prefix",
+    "postfix",
+    "infix",
+];
+
+/// Keywords that start a declaration.
+const DECLARATIONS: &[&str] = &[
+    "class",
+    "actor",
+    "struct",
+    "enum",
+    "protocol",
+    "extension",
+    "func",
+    "init",
+    "deinit",
+    "subscript",
+    "var",
+    "let",
+    "typealias",
+    "associatedtype",
+    "case",
+    "import",
+    "operator",
+    "precedencegroup",
+    "macro",
+];
+
+/// Upper-case attributes that are not property wrappers.
+const NON_WRAPPER_ATTRIBUTES: &[&str] = &[
+    "IBOutlet",
+    "IBAction",
+    "IBInspectable",
+    "IBDesignable",
+    "IBSegueAction",
+    "NSManaged",
+    "NSCopying",
+    "NSApplicationMain",
+    "UIApplicationMain",
+    "Sendable",
+    "GKInspectable",
+];
+
+/// Multi-character operators the reader cares about, longest first.
+const OPERATORS: &[&str] = &["...", "..<", "->"];
+
+/// Tokens that leave an expression or type unfinished at the end of a line.
+const TRAILING_CONTINUATIONS: &[&str] = &[
+    "=", ",", ".", "->", "&", "|", "+", "-", "*", "/", "%", "<", ":", "...", "..<", "(", "[",
+];
+
+/// Tokens that continue the previous line's expression or type when they start a line.
+const LEADING_CONTINUATIONS: &[&str] = &[".", "->", "=", "&", "|", "+", "*", "/", "?", ":"];
+
+/// Words that continue a declaration head when they start a line.
+const LEADING_WORDS: &[&str] = &["where", "as", "is", "async", "throws", "rethrows", "in"];
+
+/// Parser for Swift source files.
+#[derive(Debug, Clone)]
+pub struct SwiftParser {
+    extensions: Vec<String>,
+}
+
+/// Default implementation for [`SwiftParser`].
+impl Default for SwiftParser {
+    /// Returns a parser for `.swift` files.
+    fn default() -> Self {
+        Self {
+            extensions: vec!["swift".to_string()],
+        }
+    }
+}
+
+/// [`LanguageParser`] implementation for [`SwiftParser`].
+impl LanguageParser for SwiftParser {
+    fn name(&self) -> &str {
+        SWIFT_LANGUAGE
+    }
+
+    fn extensions(&self) -> &[String] {
+        &self.extensions
+    }
+
+    fn parse(&self, path: &Path, source: &[u8]) -> Result<FileAnalysis> {
+        let source = decode_source(SWIFT_LANGUAGE, path, source)?;
+        Ok(parse_swift(source))
+    }
+}
+
+/// Extract the types, extensions, functions, properties and imports of one file.
+pub fn parse_swift(source: &str) -> FileAnalysis {
+    let chars = source_chars(source);
+    let tokens = tokenize(&chars);
+    let mut reader = SwiftReader {
+        chars: &chars,
+        tokens: &tokens,
+        index: 0,
+        entities: Vec::new(),
+        imports: Vec::new(),
+    };
+    reader.declarations(None);
+    reader.finish()
+}
+
+/// A Swift type with its declaration and every extension of it merged.
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct SwiftType {
+    /// Qualified type name (`Store`, `Store.Item`).
+    pub name: String,
+    /// Kind of the declaration; `Class` when only extensions were seen.
+    pub kind: EntityKind,
+    /// File declaring the type, when it was among the merged files.
+    pub declared_in: Option<String>,
+    /// Files extending the type, in merge order.
+    pub extended_in: Vec<String>,
+    /// Superclass and protocols, from the declaration and all extensions.
+    pub conforms_to: Vec<String>,
+    /// Names of the methods, properties and nested types, from every file.
+    pub members: Vec<String>,
+}
+
+/// Merge the types declared and extended across parsed files, keyed by
+/// `(path, analysis)`. Types come out sorted by name.
+pub fn merge_types<'a>(
+    files: impl IntoIterator<Item = (&'a str, &'a FileAnalysis)>,
+) -> Vec<SwiftType> {
+    let mut types: BTreeMap<String, SwiftType> = BTreeMap::new();
+    let mut members: Vec<(String, String)> = Vec::new();
+    for (path, analysis) in files {
+        for entity in &analysis.entities {
+            if let Some(parent) = &entity.parent {
+                members.push((parent.clone(), entity.name.clone()));
+            }
+            let extension = entity.block_type.as_deref() == Some(EXTENSION);
+            if !extension && !is_type(entity) {
+                continue;
+            }
+
+            let merged = types
+                .entry(entity.name.clone())
+                .or_insert_with(|| SwiftType {
+                    name: entity.name.clone(),
+                    kind: EntityKind::Class,
+                    declared_in: None,
+                    extended_in: Vec::new(),
+                    conforms_to: Vec::new(),
+                    members: Vec::new(),
+                });
+            if extension {
+                merged.extended_in.push(path.to_string());
+            } else {
+                merged.kind = entity.kind;
+                merged.declared_in = Some(path.to_string());
+            }
+            for name in &entity.depends_on {
+                if !merged.conforms_to.contains(name) {
+                    merged.conforms_to.push(name.clone());
+                }
+            }
+        }
+    }
+
+    for (parent, member) in members {
+        if let Some(merged) = types.get_mut(&parent) {
+            if !merged.members.contains(&member) {
+                merged.members.push(member);
+            }
+        }
+    }
+    types.into_values().collect()
+}
+
+/// Whether an entity declares a type (not an extension, function or property).
+fn is_type(entity: &PluginEntity) -> bool {
+    matches!(
+        entity.block_type.as_deref(),
+        Some("class" | "actor" | "struct" | "enum" | "protocol")
+    )
+}
+
+/// The type or extension whose body is being read.
+struct Container {
+    /// Qualified name members are parented to.
+    name: String,
+    /// Index of the type or extension entity.
+    slot: usize,
+}
+
+/// Modifiers and attributes read before a declaration keyword.
+#[derive(Default)]
+struct Prefix {
+    /// Index of the first token of the declaration.
+    start: usize,
+    /// Attribute names without `@`.
+    attributes: Vec<String>,
+}
+
+/// Single-pass reader over the tokens of one file.
+struct SwiftReader<'a> {
+    chars: &'a [char],
+    tokens: &'a [Spanned],
+    index: usize,
+    entities: Vec<PluginEntity>,
+    imports: Vec<String>,
+}
+
+/// Declaration readers for [`SwiftReader`].
+impl<'a> SwiftReader<'a> {
+    /// Read declarations until the `}` closing `container`, or the end of input
+    /// at the top level. Returns the line of the closing brace.
+    fn declarations(&mut self, container: Option<&Container>) -> usize {
+        while let Some(token) = self.current() {
+            match token {
+                Token::Punct(op) if op == "}" => {
+                    let line = self.tokens[self.index].line;
+                    self.index += 1;
+                    if container.is_some() {
+                        return line;
+                    }
+                }
+                Token::Punct(op) if is_open(op) => {
+                    self.skip_group();
+                }
+                _ => match self.prefix() {
+                    Some((prefix, keyword)) => self.declaration(container, prefix, &keyword),
+                    None => self.index += 1,
+                },
+            }
+        }
+        self.previous_line()
+    }
+
+    /// Read attributes and modifiers from the cursor. Returns them with the
+    /// declaration keyword that follows, leaving the cursor on the keyword,
+    /// or `None` (cursor unmoved) when no declaration starts here.
+    fn prefix(&mut self) -> Option<(Prefix, String)> {
+        let start = self.index;
+        let mut prefix = Prefix {
+            start,
+            ..Prefix::default()
+        };
+        loop {
+            match self.current() {
+                Some(Token::Punct(op)) if op == "@" => {
+                    let Some(name) = self.ident_at(1).map(str::to_string) else {
+                        break;
+                    };
+                    self.index += 2;
+                    if self.adjacent_open_paren() {
+                        self.skip_group();
+                    }
+                    prefix.attributes.push(name);
+                }
+                Some(Token::Ident(word)) if MODIFIERS.contains(&word.as_str()) => {
+                    self.index += 1;
+                    // `private(set)`
+                    if self.adjacent_open_paren() {
+                        self.skip_group();
+                    }
+                }
+                // `class func`, `class var`: a modifier, not a declaration
+                Some(Token::Ident(word))
+                    if word == "class"
+                        && self.ident_at(1).is_some_and(|next| {
+                            MODIFIERS.contains(&next)
+                                || matches!(next, "func" | "var" | "let" | "subscript")
+                        }) =>
+                {
+                    self.index += 1;
+                }
+                Some(Token::Ident(word)) if DECLARATIONS.contains(&word.as_str()) => {
+                    let keyword = word.clone();
+                    return Some((prefix, keyword));
+                }
+                _ => break,
+            }
+        }
+        self.index = start;
+        None
+    }
+
+    /// Read the declaration introduced by `keyword` (the cursor is on it).
+    fn declaration(&mut self, container: Option<&Container>, prefix: Prefix, keyword: &str) {
+        match keyword {
+            "class" | "actor" | "struct" | "enum" | "protocol" => {
+                self.type_declaration(container, prefix, keyword)
+            }
+            "extension" => self.extension(prefix),
+            "func" | "init" | "deinit" | "subscript" => self.function(container, prefix, keyword),
+            "var" | "let" => self.property(container, prefix, keyword),
+            "typealias" => self.typealias(container, prefix),
+            "case" => self.enum_case(container),
+            "import" => self.import(),
+            _ => {
+                self.index += 1;
+                self.skip_tail(true);
+            }
+        }
+    }
+
+    /// Read `class`/`actor`/`struct`/`enum`/`protocol Name<...>: Supertypes { ... }`.
+    fn type_declaration(&mut self, container: Option<&Container>, prefix: Prefix, keyword: &str) {
+        let start_line = self.tokens[prefix.start].line;
+        self.index += 1;
+        let Some(name) = self.take_ident() else {
+            return;
+        };
+        let kind = match keyword {
+            "struct" => EntityKind::Struct,
+            "enum" => EntityKind::Enum,
+            "protocol" => EntityKind::Interface,
+            _ => EntityKind::Class,
+        };
+        let qualified = qualify(container.map(|c| c.name.as_str()), &name);
+        let Some((supertypes, body)) = self.type_head() else {
+            return;
+        };
+
+        let mut declared = entity(
+            qualified.clone(),
+            kind,
+            keyword,
+            start_line,
+            start_line,
+            container.map(|c| c.name.as_str()),
+        );
+        declared.signature = Some(self.text(self.tokens[prefix.start].start, body));
+        declared.depends_on = supertypes;
+        let slot = self.push(declared);
+        self.index += 1;
+        let end_line = self.declarations(Some(&Container {
+            name: qualified,
+            slot,
+        }));
+        self.entities[slot].end_line = end_line;
+    }
+
+    /// Read `extension Name<...>: Protocols where ... { ... }`.
+    fn extension(&mut self, prefix: Prefix) {
+        let start_line = self.tokens[prefix.start].line;
+        self.index += 1;
+        let Some(name) = self.take_dotted_ident() else {
+            return;
+        };
+        let Some((protocols, body)) = self.type_head() else {
+            return;
+        };
+
+        let mut extension = entity(
+            name.clone(),
+            EntityKind::Class,
+            EXTENSION,
+            start_line,
+            start_line,
+            None,
+        );
+        extension.signature = Some(self.text(self.tokens[prefix.start].start, body));
+        extension.depends_on = protocols;
+        let slot = self.push(extension);
+        self.index += 1;
+        let end_line = self.declarations(Some(&Container { name, slot }));
+        self.entities[slot].end_line = end_line;
+    }
+
+    /// Read generic parameters, the inheritance clause and any `where` clause
+    /// of a type or extension. Leaves the cursor on the body's `{` and returns
+    /// the supertypes with the character offset of the `{`.
+    fn type_head(&mut self) -> Option<(Vec<String>, usize)> {
+        if self.punct_at(0) == Some("<") {
+            self.skip_angles();
+        }
+        let mut supertypes = Vec::new();
+        if self.punct_at(0) == Some(":") {
+            self.index += 1;
+            let mut current = String::new();
+            let mut angles = 0usize;
+            while let Some(token) = self.current() {
+                match token {
+                    Token::Punct(op) if op == "{" && angles == 0 => break,
+                    Token::Ident(word) if word == "where" && angles == 0 => break,
+                    Token::Punct(op) if op == "," && angles == 0 => {
+                        push_supertype(&mut supertypes, &mut current);
+                    }
+                    Token::Punct(op) if op == "<" => angles += 1,
+                    Token::Punct(op) if op == ">" => angles = angles.saturating_sub(1),
+                    Token::Punct(op) if op == "}" => return None,
+                    Token::Ident(word) if angles == 0 => current.push_str(word),
+                    Token::Punct(op) if op == "." && angles == 0 => current.push('.'),
+                    _ => {}
+                }
+                self.index += 1;
+            }
+            push_supertype(&mut supertypes, &mut current);
+        }
+        while let Some(token) = self.current() {
+            match token {
+                Token::Punct(op) if op == "{" => {
+                    return Some((supertypes, self.tokens[self.index].start));
+                }
+                Token::Punct(op) if op == "}" => return None,
+                Token::Punct(op) if op == "(" || op == "[" => self.skip_group(),
+                _ => self.index += 1,
+            }
+        }
+        None
+    }
+
+    /// Read a `func`, `init`, `deinit` or `subscript` declaration and skip its body.
+    fn function(&mut self, container: Option<&Container>, prefix: Prefix, keyword: &str) {
+        let start_line = self.tokens[prefix.start].line;
+        self.index += 1;
+        let base = match keyword {
+            "func" => {
+                let Some(name) = self.function_name() else {
+                    return;
+                };
+                name
+            }
+            _ => keyword.to_string(),
+        };
+        // Failable initializers: `init?` / `init!`
+        if keyword == "init" && matches!(self.punct_at(0), Some("?" | "!")) {
+            self.index += 1;
+        }
+        if self.punct_at(0) == Some("<") {
+            self.skip_angles();
+        }
+        let name = if keyword == "deinit" {
+            base
+        } else if self.punct_at(0) == Some("(") {
+            format!("{base}({})", self.argument_labels())
+        } else {
+            return;
+        };
+
+        let head_end = self.head_end();
+        let signature = self.text(self.tokens[prefix.start].start, head_end);
+        let end_line = self.skip_tail(true);
+        let kind = if container.is_some() {
+            EntityKind::Method
+        } else {
+            EntityKind::Function
+        };
+        let mut function = entity(
+            qualify(container.map(|c| c.name.as_str()), &name),
+            kind,
+            keyword,
+            start_line,
+            end_line,
+            container.map(|c| c.name.as_str()),
+        );
+        function.signature = Some(signature);
+        self.push(function);
+    }
+
+    /// Name after `func`: an identifier or an operator (`==`, `<*>`).
+    fn function_name(&mut self) -> Option<String> {
+        if let Some(name) = self.take_ident() {
+            return Some(name);
+        }
+        let mut name = String::new();
+        while let Some(Token::Punct(op)) = self.current() {
+            if op == "(" || (op == "<" && !name.is_empty() && self.punct_at(1) != Some("(")) {
+                break;
+            }
+            name.push_str(op);
+            self.index += 1;
+        }
+        (!name.is_empty()).then_some(name)
+    }
+
+    /// Read a parameter list (cursor on `(`) and render its argument labels,
+    /// e.g. `id:_:in:` for `(id: Int, _ value: T, in scope: Scope)`.
+    fn argument_labels(&mut self) -> String {
+        let open = self.index;
+        self.skip_group();
+        let close = self.index - 1;
+
+        let mut labels = String::new();
+        let mut expecting_label = true;
+        let mut depth = 0usize;
+        for token in &self.tokens[open + 1..close] {
+            match &token.kind {
+                Token::Punct(op) if is_open(op) || op == "<" => depth += 1,
+                Token::Punct(op) if is_close(op) || op == ">" => depth = depth.saturating_sub(1),
+                Token::Punct(op) if op == "," && depth == 0 => expecting_label = true,
+                Token::Ident(word) if expecting_label && depth == 0 => {
+                    labels.push_str(word);
+                    labels.push(':');
+                    expecting_label = false;
+                }
+                _ => {}
+            }
+        }
+        labels
+    }
+
+    /// Read a `var`/`let` declaration with its type, initializer and accessors.
+    fn property(&mut self, container: Option<&Container>, prefix: Prefix, keyword: &str) {
+        let start_line = self.tokens[prefix.start].line;
+        self.index += 1;
+        let Some(name) = self.take_ident() else {
+            // Tuple patterns such as `let (a, b) = pair`
+            self.skip_tail(false);
+            return;
+        };
+        let head_end = self.head_end();
+        let signature = self.text(self.tokens[prefix.start].start, head_end);
+        let end_line = self.skip_tail(false);
+
+        let kind = if keyword == "let" {
+            EntityKind::Constant
+        } else {
+            EntityKind::Variable
+        };
+        let mut property = entity(
+            qualify(container.map(|c| c.name.as_str()), &name),
+            kind,
+            keyword,
+            start_line,
+            end_line,
+            container.map(|c| c.name.as_str()),
+        );
+        property.signature = Some(signature);
+        property.depends_on = prefix
+            .attributes
+            .into_iter()
+            .filter(|attribute| is_property_wrapper(attribute))
+            .collect();
+        self.push(property);
+        if let Some(container) = container {
+            self.entities[container.slot].attributes.push(name);
+        }
+    }
+
+    /// Read `typealias Name<...> = Type`.
+    fn typealias(&mut self, container: Option<&Container>, prefix: Prefix) {
+        let start_line = self.tokens[prefix.start].line;
+        self.index += 1;
+        let Some(name) = self.take_ident() else {
+            return;
+        };
+        let end_line = self.skip_tail(false);
+        let end = self.tokens[self.index - 1].end;
+        let mut alias = entity(
+            qualify(container.map(|c| c.name.as_str()), &name),
+            EntityKind::Interface,
+            "typealias",
+            start_line,
+            end_line,
+            container.map(|c| c.name.as_str()),
+        );
+        alias.signature = Some(self.text(self.tokens[prefix.start].start, end));
+        self.push(alias);
+    }
+
+    /// Read `case a, b(Int), c = 1`, recording the case names on the enum.
+    fn enum_case(&mut self, container: Option<&Container>) {
+        self.index += 1;
+        let mut names = Vec::new();
+        let mut expecting_name = true;
+        let mut last_line = self.previous_line();
+        while let Some(token) = self.current() {
+            if self.tokens[self.index].line > last_line && !expecting_name {
+                break;
+            }
+            match token {
+                Token::Ident(word) if expecting_name => {
+                    names.push(word.clone());
+                    expecting_name = false;
+                }
+                Token::Punct(op) if op == "," => expecting_name = true,
+                Token::Punct(op) if op == "(" => {
+                    self.skip_group();
+                    last_line = self.previous_line();
+                    continue;
+                }
+                Token::Punct(op) if op == ";" => {
+                    self.index += 1;
+                    break;
+                }
+                Token::Punct(op) if op == "}" || op == "{" => break,
+                _ => {}
+            }
+            last_line = self.tokens[self.index].end_line;
+            self.index += 1;
+        }
+        if let Some(container) = container {
+            self.entities[container.slot].attributes.extend(names);
+        }
+    }
+
+    /// Read `import Module`, `import struct Module.Type` or `@testable import Module`.
+    fn import(&mut self) {
+        self.index += 1;
+        if self.ident_at(1).is_some()
+            && matches!(
+                self.ident_at(0),
+                Some(
+                    "struct" | "class" | "enum" | "protocol" | "func" | "var" | "let" | "typealias"
+                )
+            )
+        {
+            self.index += 1;
+        }
+        if let Some(path) = self.take_dotted_ident() {
+            if !self.imports.contains(&path) {
+                self.imports.push(path);
+            }
+        }
+    }
+
+    /// Merge extensions into the types they extend and assemble the analysis.
+    fn finish(self) -> FileAnalysis {
+        let mut entities: Vec<PluginEntity> = Vec::new();
+        let mut extensions: Vec<PluginEntity> = Vec::new();
+        for entity in self.entities {
+            if entity.block_type.as_deref() == Some(EXTENSION) {
+                extensions.push(entity);
+            } else {
+                entities.push(entity);
+            }
+        }
+
+        for extension in extensions {
+            let target = entities.iter().position(|candidate| {
+                candidate.name == extension.name
+                    && (is_type(candidate) || candidate.block_type.as_deref() == Some(EXTENSION))
+            });
+            match target {
+                Some(index) => {
+                    let merged = &mut entities[index];
+                    if merged.block_type.as_deref() == Some(EXTENSION) {
+                        merged.start_line = merged.start_line.min(extension.start_line);
+                        merged.end_line = merged.end_line.max(extension.end_line);
+                    }
+                    for name in extension.depends_on {
+                        if !merged.depends_on.contains(&name) {
+                            merged.depends_on.push(name);
+                        }
+                    }
+                    merged.attributes.extend(extension.attributes);
+                }
+                None => entities.push(extension),
+            }
+        }
+        entities.sort_by_key(|entity| (entity.start_line, entity.end_line));
+
+        FileAnalysis {
+            language: Some(SWIFT_LANGUAGE.to_string()),
+            entities,
+            imports: self.imports,
+            tables_referenced: Vec::new(),
+        }
+    }
+}
+
+/// Token navigation helpers for [`SwiftReader`].
+impl<'a> SwiftReader<'a> {
+    fn current(&self) -> Option<&'a Token> {
+        self.tokens.get(self.index).map(|token| &token.kind)
+    }
+
+    fn ident_at(&self, offset: usize) -> Option<&'a str> {
+        match self
+            .tokens
+            .get(self.index + offset)
+            .map(|token| &token.kind)
+        {
+            Some(Token::Ident(word)) => Some(word),
+            _ => None,
+        }
+    }
+
+    fn punct_at(&self, offset: usize) -> Option<&'a str> {
+        match self
+            .tokens
+            .get(self.index + offset)
+            .map(|token| &token.kind)
+        {
+            Some(Token::Punct(op)) => Some(op),
+            _ => None,
+        }
+    }
+
+    /// Consume and return an identifier.
+    fn take_ident(&mut self) -> Option<String> {
+        let word = self.ident_at(0)?.to_string();
+        self.index += 1;
+        Some(word)
+    }
+
+    /// Consume a dotted name such as `Foundation.URL`.
+    fn take_dotted_ident(&mut self) -> Option<String> {
+        let mut name = self.take_ident()?;
+        while self.punct_at(0) == Some(".") && self.ident_at(1).is_some() {
+            name.push('.');
+            name.push_str(self.ident_at(1).unwrap_or_default());
+            self.index += 2;
+        }
+        Some(name)
+    }
+
+    /// Whether the cursor is on a `(` directly after the previous token.
+    fn adjacent_open_paren(&self) -> bool {
+        self.punct_at(0) == Some("(")
+            && self.index > 0
+            && self.tokens[self.index - 1].end == self.tokens[self.index].start
+    }
+
+    /// Line of the token before the cursor.
+    fn previous_line(&self) -> usize {
+        common::previous_line(self.tokens, self.index)
+    }
+
+    /// Skip a bracketed group starting at the cursor, including its closing bracket.
+    fn skip_group(&mut self) {
+        let mut depth = 0usize;
+        while let Some(token) = self.current() {
+            self.index += 1;
+            match token {
+                Token::Punct(op) if is_open(op) => depth += 1,
+                Token::Punct(op) if is_close(op) => {
+                    depth = depth.saturating_sub(1);
+                    if depth == 0 {
+                        return;
+                    }
+                }
+                _ => {}
+            }
+        }
+    }
+
+    /// Skip generic parameters or arguments starting at a `<` under the cursor.
+    fn skip_angles(&mut self) {
+        let mut depth = 0usize;
+        while let Some(token) = self.current() {
+            match token {
+                Token::Punct(op) if op == "<" => depth += 1,
+                Token::Punct(op) if op == ">" => {
+                    depth = depth.saturating_sub(1);
+                    if depth == 0 {
+                        self.index += 1;
+                        return;
+                    }
+                }
+                Token::Punct(op) if op == "{" || op == "}" => return,
+                _ => {}
+            }
+            self.index += 1;
+        }
+    }
+
+    /// Character offset where the head of the declaration at the cursor ends:
+    /// before its body, accessor block or initializer.
+    fn head_end(&self) -> usize {
+        let mut index = self.index;
+        let mut last_line = self.previous_line();
+        let mut end = self
+            .index
+            .checked_sub(1)
+            .map_or(0, |previous| self.tokens[previous].end);
+        let mut depth = 0usize;
+        while let Some(token) = self.tokens.get(index) {
+            if depth == 0 {
+                if token.line > last_line && !self.continues(index) {
+                    break;
+                }
+                match &token.kind {
+                    Token::Punct(op) if matches!(op.as_str(), "{" | "}" | "=" | ";") => break,
+                    _ => {}
+                }
+            }
+            match &token.kind {
+                Token::Punct(op) if op == "(" || op == "[" => depth += 1,
+                Token::Punct(op) if op == ")" || op == "]" => depth = depth.saturating_sub(1),
+                _ => {}
+            }
+            end = token.end;
+            last_line = token.end_line;
+            index += 1;
+        }
+        end
+    }
+
+    /// Skip the rest of a declaration: its type, initializer expression and
+    /// body or accessor block. `{` on a new line opens the body only when
+    /// `braced_body` is set (functions); a property ends at its line.
+    /// Returns the line the declaration ends on.
+    fn skip_tail(&mut self, braced_body: bool) -> usize {
+        let mut seen_initializer = false;
+        let mut last_line = self.previous_line();
+        while let Some(token) = self.current() {
+            let spanned = &self.tokens[self.index];
+            let brace_on_new_line =
+                braced_body && !seen_initializer && matches!(token, Token::Punct(op) if op == "{");
+            if spanned.line > last_line && !self.continues(self.index) && !brace_on_new_line {
+                break;
+            }
+            match token {
+                Token::Punct(op) if op == "}" => break,
+                Token::Punct(op) if op == ";" => {
+                    self.index += 1;
+                    break;
+                }
+                Token::Punct(op) if op == "{" => {
+                    self.skip_group();
+                    last_line = self.previous_line();
+                    if !seen_initializer {
+                        break;
+                    }
+                    continue;
+                }
+                Token::Punct(op) if op == "(" || op == "[" => {
+                    self.skip_group();
+                    last_line = self.previous_line();
+                    continue;
+                }
+                Token::Punct(op) if op == "=" => seen_initializer = true,
+                _ => {}
+            }
+            last_line = spanned.end_line;
+            self.index += 1;
+        }
+        self.previous_line()
+    }
+
+    /// Whether the token at `index`, which starts a new line, continues the
+    /// expression or type of the line before it.
+    fn continues(&self, index: usize) -> bool {
+        let previous = index
+            .checked_sub(1)
+            .map(|previous| &self.tokens[previous].kind);
+        let trailing = matches!(previous, Some(Token::Punct(op)) if TRAILING_CONTINUATIONS.contains(&op.as_str()));
+        let leading = match &self.tokens[index].kind {
+            Token::Punct(op) => LEADING_CONTINUATIONS.contains(&op.as_str()),
+            Token::Ident(word) => LEADING_WORDS.contains(&word.as_str()),
+            _ => false,
+        };
+        trailing || leading
+    }
+
+    /// Source text between two character offsets with whitespace collapsed.
+    fn text(&self, start: usize, end: usize) -> String {
+        let text: String = self.chars[start..end.max(start)].iter().collect();
+        text.split_whitespace().collect::<Vec<_>>().join(" ")
+    }
+
+    fn push(&mut self, entity: PluginEntity) -> usize {
+        self.entities.push(entity);
+        self.entities.len() - 1
+    }
+}
+
+/// `parent.name`, or `name` at the top level.
+fn qualify(parent: Option<&str>, name: &str) -> String {
+    match parent {
+        Some(parent) => format!("{parent}.{name}"),
+        None => name.to_string(),
+    }
+}
+
+/// Add a collected supertype name (generic arguments already dropped).
+fn push_supertype(supertypes: &mut Vec<String>, current: &mut String) {
+    let name = std::mem::take(current);
+    if !name.is_empty() && !supertypes.contains(&name) {
+        supertypes.push(name);
+    }
+}
+
+/// Whether an attribute names a property wrapper type (`@Published`, `@State`)
+/// rather than a built-in attribute (`@objc`) or a global actor (`@MainActor`).
+fn is_property_wrapper(attribute: &str) -> bool {
+    attribute.starts_with(|c: char| c.is_ascii_uppercase())
+        && !attribute.ends_with("Actor")
+        && !NON_WRAPPER_ATTRIBUTES.contains(&attribute)
+}
+
+/// Whether `op` opens a bracket.
+fn is_open(op: &str) -> bool {
+    matches!(op, "(" | "[" | "{")
+}
+
+/// Whether `op` closes a bracket.
+fn is_close(op: &str) -> bool {
+    matches!(op, ")" | "]" | "}")
+}
+
+/// A [`PluginEntity`] with no attributes, dependencies or signature yet.
+fn entity(
+    name: String,
+    kind: EntityKind,
+    block_type: &str,
+    start_line: usize,
+    end_line: usize,
+    parent: Option<&str>,
+) -> PluginEntity {
+    PluginEntity {
+        name,
+        kind,
+        start_line,
+        end_line,
+        parent: parent.map(str::to_string),
+        block_type: Some(block_type.to_string()),
+        attributes: Vec::new(),
+        depends_on: Vec::new(),
+        signature: None,
+    }
+}
+
+/// Lexical token of the Swift subset the parsers need.
+#[derive(Debug, Clone, PartialEq)]
+pub(crate) enum Token {
+    /// Identifier or keyword, without backticks (`load`, `default`)
+    Ident(String),
+    /// Single-line string literal without its quotes or escapes resolved
+    Str(String),
+    /// Number, multi-line string or raw string
+    Literal,
+    /// Operator or other punctuation (`->`, `(`, `@`)
+    Punct(String),
+}
+
+/// A Swift token with its character range and lines.
+pub(crate) type Spanned = common::Spanned<Token>;
+
+/// Split `chars` into tokens, skipping whitespace, comments and compiler
+/// control lines (`#if`, `#else`, `#endif`), whose branches are all read.
+pub(crate) fn tokenize(chars: &[char]) -> Vec<Spanned> {
+    common::tokenize(chars, lex)
+}
+
+/// Lex the token or comment starting at `i`.
+fn lex(chars: &[char], mut i: usize) -> Lexeme<Token> {
+    let c = chars[i];
+    let next = chars.get(i + 1).copied();
+    let start = i;
+    let kind = match c {
+        '/' if next == Some('/') => return Lexeme::Skip(line_end(chars, i)),
+        '/' if next == Some('*') => return Lexeme::Skip(skip_block_comment(chars, i + 2)),
+        '#' if is_control_line(chars, i) => return Lexeme::Skip(line_end(chars, i)),
+        '#' if matches!(next, Some('#' | '"')) => {
+            i = skip_raw_string(chars, i);
+            Token::Literal
+        }
+        '"' if chars[i..].starts_with(&['"', '"', '"']) => {
+            i = skip_multiline_string(chars, i + 3);
+            Token::Literal
+        }
+        '"' => {
+            i = skip_string(chars, i + 1);
+            let text: String = chars[start + 1..i.saturating_sub(1).max(start + 1)]
+                .iter()
+                .collect();
+            Token::Str(text)
+        }
+        '`' => {
+            let end = chars[i + 1..]
+                .iter()
+                .position(|&c| c == '`' || c == '\n')
+                .map_or(chars.len(), |offset| i + 1 + offset);
+            let word: String = chars[i + 1..end].iter().collect();
+            i = (end + 1).min(chars.len());
+            Token::Ident(word)
+        }
+        c if c.is_ascii_digit() => {
+            i = scan_number(chars, i);
+            Token::Literal
+        }
+        c if is_ident_start(c) => {
+            i += 1;
+            while i < chars.len() && (chars[i].is_alphanumeric() || chars[i] == '_') {
+                i += 1;
+            }
+            Token::Ident(chars[start..i].iter().collect())
+        }
+        _ => {
+            let op = scan_operator(chars, i, OPERATORS);
+            i += op.chars().count();
+            Token::Punct(op)
+        }
+    };
+    Lexeme::Token(kind, i)
+}
+
+/// Whether `c` can start an identifier (`$0` and `$value` included).
+fn is_ident_start(c: char) -> bool {
+    c.is_alphabetic() || c == '_' || c == '$'
+}
+
+/// Whether the `#` at `i` starts a compiler control statement.
+fn is_control_line(chars: &[char], i: usize) -> bool {
+    let word: String = chars[i + 1..]
+        .iter()
+        .take_while(|c| c.is_ascii_alphabetic())
+        .collect();
+    matches!(
+        word.as_str(),
+        "if" | "elseif" | "else" | "endif" | "sourceLocation" | "warning" | "error"
+    )
+}
+
+/// Index just past a (possibly nested) `/* */` comment whose body starts at `i`.
+fn skip_block_comment(chars: &[char], mut i: usize) -> usize {
+    let mut depth = 1;
+    while i < chars.len() {
+        if chars[i] == '/' && chars.get(i + 1) == Some(&'*') {
+            depth += 1;
+            i += 2;
+        } else if chars[i] == '*' && chars.get(i + 1) == Some(&'/') {
+            depth -= 1;
+            i += 2;
+            if depth == 0 {
+                return i;
+            }
+        } else {
+            i += 1;
+        }
+    }
+    chars.len()
+}
+
+/// Index just past the closing quote of a string whose body starts at `i`,
+/// skipping escapes and `\( ... )` interpolations.
+fn skip_string(chars: &[char], mut i: usize) -> usize {
+    while i < chars.len() {
+        match chars[i] {
+            '\\' if chars.get(i + 1) == Some(&'(') => i = skip_interpolation(chars, i + 2),
+            '\\' => i += 2,
+            '"' => return i + 1,
+            '\n' => return i,
+            _ => i += 1,
+        }
+    }
+    chars.len()
+}
+
+/// Index just past a `"""` string whose body starts at `i`.
+fn skip_multiline_string(chars: &[char], mut i: usize) -> usize {
+    while i < chars.len() {
+        if chars[i..].starts_with(&['"', '"', '"']) {
+            return i + 3;
+        }
+        match chars[i] {
+            '\\' if chars.get(i + 1) == Some(&'(') => i = skip_interpolation(chars, i + 2),
+            '\\' => i += 2,
+            _ => i += 1,
+        }
+    }
+    chars.len()
+}
+
+/// Index just past a raw string such as `#"..."#` or `##"""..."""##` starting at `i`.
+fn skip_raw_string(chars: &[char], i: usize) -> usize {
+    let hashes = chars[i..].iter().take_while(|&&c| c == '#').count();
+    let mut j = i + hashes;
+    let quotes = chars[j..].iter().take_while(|&&c| c == '"').count();
+    if quotes == 0 {
+        // `##` without a string: treat the hashes as punctuation-free noise
+        return j;
+    }
+    let delimiter = if quotes >= 3 { 3 } else { 1 };
+    j += delimiter;
+    while j < chars.len() {
+        if chars[j..].iter().take(delimiter).all(|&c| c == '"')
+            && chars.len() >= j + delimiter + hashes
+            && chars[j + delimiter..j + delimiter + hashes]
+                .iter()
+                .all(|&c| c == '#')
+        {
+            return j + delimiter + hashes;
+        }
+        j += 1;
+    }
+    chars.len()
+}
+
+/// Index just past the `)` closing an interpolation whose body starts at `i`.
+fn skip_interpolation(chars: &[char], mut i: usize) -> usize {
+    let mut depth = 1;
+    while i < chars.len() {
+        match chars[i] {
+            '(' => depth += 1,
+            ')' => {
+                depth -= 1;
+                if depth == 0 {
+                    return i + 1;
+                }
+            }
+            '"' => {
+                i = skip_string(chars, i + 1);
+                continue;
+            }
+            _ => {}
+        }
+        i += 1;
+    }
+    chars.len()
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    const STORE_SWIFT: &str = r#"import Foundation
+@testable import Shop
+import struct Models.Item
+
+/// Loads and caches items.
+@MainActor
+public final class Store: NSObject, ObservableObject {
+    @Published private(set) var items: [Item] = []
+    public let client: APIClient
+    private var cache = [String: Item]() {
+        didSet { save() }
+    }
+
+    public init(client: APIClient) {
+        self.client = client
+    }
+
+    init?(_ url: URL, retries: Int = 3) {
+        return nil
+    }
+
+    deinit {}
+
+    public func load(id: Int) async throws -> Item {
+        let item = try await client.fetch(id: id)
+        items.append(item)
+        return item
+    }
+
+    func load(named name: String) async -> Item? {
+        "\(name) \("nested")" == "" ? nil : items.first
+    }
+
+    fileprivate func save() {}
+
+    class func shared() -> Store { fatalError() }
+
+    enum Sort: String, CaseIterable {
+        case name, price
+        case date(ascending: Bool)
+    }
+}
+
+extension Store: Identifiable {
+    public var count: Int { items.count }
+
+    func clear() {
+        items.removeAll()
+    }
+}
+
+protocol Cache {
+    associatedtype Key: Hashable
+    func value(for key: Key) -> Item?
+    var size: Int { get }
+}
+
+extension String {
+    var trimmed: String { trimmingCharacters(in: .whitespaces) }
+}
+
+extension String: Cache {}
+
+public typealias Handler = (Result<Item, Error>) -> Void
+
+struct Counter {
+    @State var value = 0
+    @AppStorage("limit") var limit: Int = 10
+}
+"#;
+
+    fn entity<'a>(analysis: &'a FileAnalysis, name: &str) -> &'a PluginEntity {
+        analysis
+            .entities
+            .iter()
+            .find(|entity| entity.name == name)
+            .unwrap_or_else(|| panic!("missing {name} in {:#?}", analysis.entities))
+    }
+
+    #[test]
+    fn extracts_types_with_supertypes_members_and_modifiers() {
+        let analysis = parse_swift(STORE_SWIFT);
+        assert_eq!(analysis.language.as_deref(), Some("swift"));
+        assert_eq!(analysis.imports, vec!["Foundation", "Shop", "Models.Item"]);
+
+        let store = entity(&analysis, "Store");
+        assert_eq!(store.kind, EntityKind::Class);
+        assert_eq!((store.start_line, store.end_line), (6, 42));
+        assert_eq!(
+            store.signature.as_deref(),
+            Some("@MainActor public final class Store: NSObject, ObservableObject")
+        );
+        assert_eq!(
+            store.depends_on,
+            vec!["NSObject", "ObservableObject", "Identifiable"]
+        );
+        assert_eq!(store.attributes, vec!["items", "client", "cache", "count"]);
+
+        let sort = entity(&analysis, "Store.Sort");
+        assert_eq!(sort.kind, EntityKind::Enum);
+        assert_eq!(sort.parent.as_deref(), Some("Store"));
+        assert_eq!(sort.attributes, vec!["name", "price", "date"]);
+        assert_eq!(sort.depends_on, vec!["String", "CaseIterable"]);
+
+        let cache = entity(&analysis, "Cache");
+        assert_eq!(cache.kind, EntityKind::Interface);
+        assert_eq!(cache.attributes, vec!["size"]);
+
+        let handler = entity(&analysis, "Handler");
+        assert_eq!(handler.kind, EntityKind::Interface);
+        assert_eq!(handler.block_type.as_deref(), Some("typealias"));
+        assert_eq!(
+            handler.signature.as_deref(),
+            Some("public typealias Handler = (Result<Item, Error>) -> Void")
+        );
+    }
+
+    #[test]
+    fn functions_are_named_by_argument_labels() {
+        let analysis = parse_swift(STORE_SWIFT);
+
+        let load = entity(&analysis, "Store.load(id:)");
+        assert_eq!(load.kind, EntityKind::Method);
+        assert_eq!((load.start_line, load.end_line), (24, 28));
+        assert_eq!(
+            load.signature.as_deref(),
+            Some("public func load(id: Int) async throws -> Item")
+        );
+        let named = entity(&analysis, "Store.load(named:)");
+        assert_eq!(
+            named.signature.as_deref(),
+            Some("func load(named name: String) async -> Item?")
+        );
+        assert_eq!((named.start_line, named.end_line), (30, 32));
+
+        assert_eq!(
+            entity(&analysis, "Store.init(client:)")
+                .block_type
+                .as_deref(),
+            Some("init")
+        );
+        assert_eq!(
+            entity(&analysis, "Store.init(_:retries:)")
+                .signature
+                .as_deref(),
+            Some("init?(_ url: URL, retries: Int = 3)")
+        );
+        assert!(analysis.entities.iter().any(|e| e.name == "Store.deinit"));
+        assert_eq!(
+            entity(&analysis, "Store.save()").signature.as_deref(),
+            Some("fileprivate func save()")
+        );
+        assert_eq!(
+            entity(&analysis, "Store.shared()").signature.as_deref(),
+            Some("class func shared() -> Store")
+        );
+
+        let requirement = entity(&analysis, "Cache.value(for:)");
+        assert_eq!((requirement.start_line, requirement.end_line), (54, 54));
+        assert_eq!(requirement.parent.as_deref(), Some("Cache"));
+    }
+
+    #[test]
+    fn properties_record_wrappers_and_accessors() {
+        let analysis = parse_swift(STORE_SWIFT);
+
+        let items = entity(&analysis, "Store.items");
+        assert_eq!(items.kind, EntityKind::Variable);
+        assert_eq!(
+            items.signature.as_deref(),
+            Some("@Published private(set) var items: [Item]")
+        );
+        assert_eq!(items.depends_on, vec!["Published"]);
+        assert_eq!(entity(&analysis, "Store.client").kind, EntityKind::Constant);
+        assert_eq!(
+            (
+                entity(&analysis, "Store.cache").start_line,
+                entity(&analysis, "Store.cache").end_line
+            ),
+            (10, 12)
+        );
+
+        assert_eq!(entity(&analysis, "Counter.value").depends_on, vec!["State"]);
+        assert_eq!(
+            entity(&analysis, "Counter.limit").depends_on,
+            vec!["AppStorage"]
+        );
+        assert_eq!(
+            entity(&analysis, "Counter").attributes,
+            vec!["value", "limit"]
+        );
+    }
+
+    #[test]
+    fn extensions_merge_into_their_type() {
+        let analysis = parse_swift(STORE_SWIFT);
+
+        // Declared in this file: the extension's members join the class.
+        assert_eq!(
+            analysis
+                .entities
+                .iter()
+                .filter(|entity| entity.name == "Store")
+                .count(),
+            1
+        );
+        let clear = entity(&analysis, "Store.clear()");
+        assert_eq!(clear.parent.as_deref(), Some("Store"));
+        assert_eq!(clear.kind, EntityKind::Method);
+
+        // Declared elsewhere: one merged extension entity.
+        let string = entity(&analysis, "String");
+        assert_eq!(string.block_type.as_deref(), Some("extension"));
+        assert_eq!((string.start_line, string.end_line), (58, 62));
+        assert_eq!(string.depends_on, vec!["Cache"]);
+        assert_eq!(string.attributes, vec!["trimmed"]);
+        assert_eq!(
+            entity(&analysis, "String.trimmed").parent.as_deref(),
+            Some("String")
+        );
+    }
+
+    #[test]
+    fn merge_types_combines_extensions_across_files() {
+        let model = parse_swift("struct User: Codable {\n    let id: Int\n}\n");
+        let formatting = parse_swift(
+            "extension User: CustomStringConvertible {\n    var description: String { \"\\(id)\" }\n}\n",
+        );
+        let actions = parse_swift("extension User {\n    func rename(to name: String) {}\n}\n");
+
+        let merged = merge_types([
+            ("Model.swift", &model),
+            ("User+Formatting.swift", &formatting),
+            ("User+Actions.swift", &actions),
+        ]);
+        assert_eq!(merged.len(), 1);
+        let user = &merged[0];
+        assert_eq!(user.kind, EntityKind::Struct);
+        assert_eq!(user.declared_in.as_deref(), Some("Model.swift"));
+        assert_eq!(
+            user.extended_in,
+            vec!["User+Formatting.swift", "User+Actions.swift"]
+        );
+        assert_eq!(user.conforms_to, vec!["Codable", "CustomStringConvertible"]);
+        assert_eq!(
+            user.members,
+            vec!["User.id", "User.description", "User.rename(to:)"]
+        );
+    }
+
+    #[test]
+    fn strings_comments_and_directives_do_not_confuse_the_reader() {
+        let source = r##"/* class Hidden {
+   /* nested */ func nope() {}
+} */
+let banner = """
+    struct NotAType {}
+    """
+let raw = #"func fake() { "quoted" }"#
+#if os(iOS)
+func platform() -> String { "iOS" }
+#else
+func platform() -> String { "other" }
+#endif
+"##;
+        let analysis = parse_swift(source);
+        let names: Vec<&str> = analysis.entities.iter().map(|e| e.name.as_str()).collect();
+        assert_eq!(names, vec!["banner", "raw", "platform()", "platform()"]);
+        assert_eq!(
+            (
+                analysis.entities[1].start_line,
+                analysis.entities[1].end_line
+            ),
+            (7, 7)
+        );
+        assert_eq!(analysis.entities[3].start_line, 11);
+    }
+
+    #[test]
+    fn lone_dollar_signs_are_single_tokens() {
+        let chars: Vec<char> = "let sum = $ + $0".chars().collect();
+        let kinds: Vec<Token> = tokenize(&chars).into_iter().map(|t| t.kind).collect();
+        assert_eq!(
+            kinds[3..],
+            [
+                Token::Ident("$".to_string()),
+                Token::Punct("+".to_string()),
+                Token::Ident("$0".to_string()),
+            ]
+        );
+    }
+}
@@ -0,0 +1,724 @@
+//! Built-in Terraform (HCL) parser.
+//!
+//! [`TerraformParser`] extracts the top-level `resource`, `data`, `variable`,
+//! `output` and `module` blocks of `.tf` files into a [`FileAnalysis`] with
+//! language `"terraform"`. Expressions are not evaluated: each block records
+//! its type, its address (`aws_instance.web`, `data.aws_ami.ubuntu`,
+//! `var.region`, `output.ip`, `module.vpc`), the names of its attributes, and
+//! the addresses its expressions reference. [`TerraformGraph`] joins those
+//! references across files into a `depends_on` graph.
+//!
+//! [`PluginRegistry::with_builtins`](super::PluginRegistry::with_builtins)
+//! registers the parser ahead of external plugins, so it needs no plugin
+//! executable.
+
+use std::collections::{BTreeMap, BTreeSet};
+use std::path::{Path, PathBuf};
+
+use ignore::WalkBuilder;
+use serde::{Deserialize, Serialize};
+use tracing::warn;
+
+use crate::core::errors::{Result, ValknutError};
+use crate::core::pipeline::discovery::IGNORE_FILE_NAME;
+use crate::lang::buffer_pool::source_chars;
+use crate::lang::common::EntityKind;
+use crate::lang::plugins::{decode_source, FileAnalysis, LanguageParser, PluginEntity};
+
+/// Language name reported for Terraform files.
+pub const TERRAFORM_LANGUAGE: &str = "terraform";
+
+/// Root-level names that look like traversals but never name a block.
+const BUILTIN_ROOTS: &[&str] = &["count", "each", "local", "path", "self", "terraform"];
+
+/// Parser for Terraform configuration files.
+#[derive(Debug, Clone)]
+pub struct TerraformParser {
+    extensions: Vec<String>,
+}
+
+/// Default implementation for [`TerraformParser`].
+impl Default for TerraformParser {
+    /// Returns a parser for `.tf` files.
+    fn default() -> Self {
+        Self {
+            extensions: vec!["tf".to_string()],
+        }
+    }
+}
+
+/// [`LanguageParser`] implementation for [`TerraformParser`].
+impl LanguageParser for TerraformParser {
+    fn name(&self) -> &str {
+        TERRAFORM_LANGUAGE
+    }
+
+    fn extensions(&self) -> &[String] {
+        &self.extensions
+    }
+
+    fn parse(&self, path: &Path, source: &[u8]) -> Result<FileAnalysis> {
+        let source = decode_source(TERRAFORM_LANGUAGE, path, source)?;
+        Ok(parse_terraform(source))
+    }
+}
+
+/// Extract the blocks of one Terraform file.
+pub fn parse_terraform(source: &str) -> FileAnalysis {
+    let tokens = tokenize(source);
+    let mut entities = Vec::new();
+    let mut index = 0;
+
+    while index < tokens.len() {
+        let Token::Ident(block_type) = &tokens[index].kind else {
+            index += 1;
+            continue;
+        };
+        let start_line = tokens[index].line;
+        let mut labels = Vec::new();
+        let mut cursor = index + 1;
+        while let Some(Token::Str(label) | Token::Ident(label)) =
+            tokens.get(cursor).map(|t| &t.kind)
+        {
+            labels.push(label.clone());
+            cursor += 1;
+        }
+        if !matches!(tokens.get(cursor).map(|t| &t.kind), Some(Token::Open('{'))) {
+            index = skip_line(&tokens, index);
+            continue;
+        }
+        let close = matching_close(&tokens, cursor);
+        if let Some((name, kind)) = block_address(block_type, &labels) {
+            let body = &tokens[cursor + 1..close.min(tokens.len())];
+            let mut depends_on = references(body);
+            depends_on.remove(&name);
+            entities.push(PluginEntity {
+                name,
+                kind,
+                start_line,
+                end_line: tokens.get(close).map_or(start_line, |t| t.line),
+                parent: None,
+                block_type: Some(block_type.clone()),
+                attributes: attribute_names(body),
+                depends_on: depends_on.into_iter().collect(),
+                signature: None,
+            });
+        }
+        index = close + 1;
+    }
+
+    FileAnalysis {
+        language: Some(TERRAFORM_LANGUAGE.to_string()),
+        entities,
+        imports: Vec::new(),
+        tables_referenced: Vec::new(),
+    }
+}
+
+/// Address and entity kind of a top-level block, or `None` for blocks that
+/// declare nothing referenceable (`provider`, `terraform`, `locals`, ...).
+fn block_address(block_type: &str, labels: &[String]) -> Option<(String, EntityKind)> {
+    match (block_type, labels) {
+        ("resource", [kind, name]) => Some((format!("{kind}.{name}"), EntityKind::Struct)),
+        ("data", [kind, name]) => Some((format!("data.{kind}.{name}"), EntityKind::Struct)),
+        ("variable", [name]) => Some((format!("var.{name}"), EntityKind::Variable)),
+        ("output", [name]) => Some((format!("output.{name}"), EntityKind::Constant)),
+        ("module", [name]) => Some((format!("module.{name}"), EntityKind::Module)),
+        _ => None,
+    }
+}
+
+/// Names of the attributes assigned directly in a block body, sorted.
+fn attribute_names(body: &[Spanned]) -> Vec<String> {
+    let mut names = BTreeSet::new();
+    let mut depth = 0usize;
+    let mut line_start = true;
+    for (position, token) in body.iter().enumerate() {
+        match &token.kind {
+            Token::Open(_) => depth += 1,
+            Token::Close(_) => depth = depth.saturating_sub(1),
+            Token::Ident(name) if depth == 0 && line_start => {
+                if matches!(body.get(position + 1).map(|t| &t.kind), Some(Token::Assign)) {
+                    names.insert(name.clone());
+                }
+            }
+            _ => {}
+        }
+        line_start = matches!(token.kind, Token::Newline) || matches!(token.kind, Token::Open('{'));
+    }
+    names.into_iter().collect()
+}
+
+/// Addresses referenced by the expressions in `tokens`.
+fn references(tokens: &[Spanned]) -> BTreeSet<String> {
+    let mut found = BTreeSet::new();
+    let mut index = 0;
+    while index < tokens.len() {
+        let after_dot = index > 0 && matches!(tokens[index - 1].kind, Token::Dot);
+        let path = traversal(&tokens[index..]);
+        if !after_dot {
+            if let Some(address) = reference_address(&path) {
+                found.insert(address);
+            }
+        }
+        index += path.len().max(1) * 2 - 1;
+    }
+    found
+}
+
+/// The `a.b.c` identifiers starting at `tokens[0]`.
+fn traversal(tokens: &[Spanned]) -> Vec<&str> {
+    let mut parts = Vec::new();
+    let mut index = 0;
+    while let Some(Token::Ident(part)) = tokens.get(index).map(|t| &t.kind) {
+        parts.push(part.as_str());
+        if !matches!(tokens.get(index + 1).map(|t| &t.kind), Some(Token::Dot)) {
+            break;
+        }
+        index += 2;
+    }
+    parts
+}
+
+/// Block address named by a traversal, if it refers to a declared block.
+fn reference_address(path: &[&str]) -> Option<String> {
+    match path {
+        ["var", name, ..] => Some(format!("var.{name}")),
+        ["module", name, ..] => Some(format!("module.{name}")),
+        ["data", kind, name, ..] => Some(format!("data.{kind}.{name}")),
+        [kind, name, ..] if is_resource_type(kind) && !is_index(name) => {
+            Some(format!("{kind}.{name}"))
+        }
+        _ => None,
+    }
+}
+
+/// Resource types are `<provider>_<type>`, e.g. `aws_instance`.
+fn is_resource_type(name: &str) -> bool {
+    !BUILTIN_ROOTS.contains(&name)
+        && name.contains('_')
+        && name.starts_with(|c: char| c.is_ascii_lowercase())
+}
+
+/// Splat and numeric traversal steps such as `0` in `foo.0.bar`.
+fn is_index(name: &str) -> bool {
+    name.chars().all(|c| c.is_ascii_digit())
+}
+
+/// Index of the first token on the line after `index`.
+fn skip_line(tokens: &[Spanned], index: usize) -> usize {
+    tokens[index..]
+        .iter()
+        .position(|t| matches!(t.kind, Token::Newline))
+        .map_or(tokens.len(), |offset| index + offset + 1)
+}
+
+/// Index of the bracket closing the one opened at `open` (or `tokens.len()`).
+fn matching_close(tokens: &[Spanned], open: usize) -> usize {
+    let mut depth = 0usize;
+    for (index, token) in tokens.iter().enumerate().skip(open) {
+        match token.kind {
+            Token::Open(_) => depth += 1,
+            Token::Close(_) => {
+                depth = depth.saturating_sub(1);
+                if depth == 0 {
+                    return index;
+                }
+            }
+            _ => {}
+        }
+    }
+    tokens.len()
+}
+
+/// Lexical token of the HCL subset the parser needs.
+#[derive(Debug, Clone, PartialEq)]
+enum Token {
+    /// Identifier or bare number (`aws_instance`, `0`)
+    Ident(String),
+    /// Quoted string or heredoc, without interpolations
+    Str(String),
+    /// `.`
+    Dot,
+    /// Single `=` (comparison operators are [`Token::Other`])
+    Assign,
+    /// `{`, `[` or `(`
+    Open(char),
+    /// `}`, `]` or `)`
+    Close(char),
+    /// End of line
+    Newline,
+    /// Any other punctuation
+    Other,
+}
+
+/// A token with its 1-based line.
+#[derive(Debug, Clone)]
+struct Spanned {
+    kind: Token,
+    line: usize,
+}
+
+/// Split `source` into tokens, skipping comments.
+///
+/// Interpolations inside strings and heredocs (`"${var.name}"`) are lexed in
+/// place so their references are seen; their braces are dropped so they never
+/// affect block structure.
+fn tokenize(source: &str) -> Vec<Spanned> {
+    let chars = source_chars(source);
+    let mut tokens = Vec::new();
+    let mut line = 1;
+    let mut i = 0;
+
+    while i < chars.len() {
+        let c = chars[i];
+        let next = chars.get(i + 1).copied();
+        match c {
+            '\n' => {
+                tokens.push(Spanned {
+                    kind: Token::Newline,
+                    line,
+                });
+                line += 1;
+                i += 1;
+            }
+            '#' => i = skip_to_newline(&chars, i),
+            '/' if next == Some('/') => i = skip_to_newline(&chars, i),
+            '/' if next == Some('*') => {
+                i += 2;
+                while i < chars.len() && !(chars[i] == '*' && chars.get(i + 1) == Some(&'/')) {
+                    line += usize::from(chars[i] == '\n');
+                    i += 1;
+                }
+                i += 2;
+            }
+            '"' => {
+                let start_line = line;
+                let mut text = String::new();
+                let mut inner = Vec::new();
+                i += 1;
+                while i < chars.len() && chars[i] != '"' && chars[i] != '\n' {
+                    if chars[i] == '\\' {
+                        text.extend(chars.get(i..i + 2).unwrap_or(&chars[i..]));
+                        i += 2;
+                        continue;
+                    }
+                    if chars[i] == '$' && chars.get(i + 1) == Some(&'{') {
+                        i = lex_interpolation(&chars, i + 2, line, &mut inner);
+                        continue;
+                    }
+                    text.push(chars[i]);
+                    i += 1;
+                }
+                i += 1;
+                tokens.push(Spanned {
+                    kind: Token::Str(text),
+                    line: start_line,
+                });
+                tokens.extend(inner);
+            }
+            '<' if next == Some('<') => {
+                i = lex_heredoc(&chars, i, &mut line, &mut tokens);
+            }
+            '{' | '[' | '(' => {
+                tokens.push(Spanned {
+                    kind: Token::Open(c),
+                    line,
+                });
+                i += 1;
+            }
+            '}' | ']' | ')' => {
+                tokens.push(Spanned {
+                    kind: Token::Close(c),
+                    line,
+                });
+                i += 1;
+            }
+            '.' => {
+                tokens.push(Spanned {
+                    kind: Token::Dot,
+                    line,
+                });
+                i += 1;
+            }
+            '=' if !matches!(next, Some('=' | '>')) => {
+                tokens.push(Spanned {
+                    kind: Token::Assign,
+                    line,
+                });
+                i += 1;
+            }
+            '=' | '!' | '<' | '>' => {
+                tokens.push(Spanned {
+                    kind: Token::Other,
+                    line,
+                });
+                i += if next == Some('=') || next == Some('>') {
+                    2
+                } else {
+                    1
+                };
+            }
+            c if is_ident_char(c) => {
+                let start = i;
+                while i < chars.len() && is_ident_char(chars[i]) {
+                    i += 1;
+                }
+                tokens.push(Spanned {
+                    kind: Token::Ident(chars[start..i].iter().collect()),
+                    line,
+                });
+            }
+            c if c.is_whitespace() => i += 1,
+            _ => {
+                tokens.push(Spanned {
+                    kind: Token::Other,
+                    line,
+                });
+                i += 1;
+            }
+        }
+    }
+    tokens
+}
+
+/// Lex a `${ ... }` interpolation starting after `${`; returns the index after its `}`.
+fn lex_interpolation(chars: &[char], start: usize, line: usize, out: &mut Vec<Spanned>) -> usize {
+    let mut depth = 1usize;
+    let mut end = start;
+    while end < chars.len() && chars[end] != '\n' {
+        match chars[end] {
+            '{' => depth += 1,
+            '}' => {
+                depth -= 1;
+                if depth == 0 {
+                    break;
+                }
+            }
+            _ => {}
+        }
+        end += 1;
+    }
+    let inner: String = chars[start..end.min(chars.len())].iter().collect();
+    out.extend(
+        tokenize(&inner)
+            .into_iter()
+            .filter(|t| !matches!(t.kind, Token::Open(_) | Token::Close(_) | Token::Newline))
+            .map(|t| Spanned { line, ..t }),
+    );
+    // An unterminated interpolation stops at the newline, which is left in place.
+    end + usize::from(chars.get(end) == Some(&'}'))
+}
+
+/// Lex a `<<EOF` / `<<-EOF` heredoc starting at `start`; returns the index after it.
+///
+/// Not a heredoc when no marker follows `<<`, in which case `<<` is punctuation.
+fn lex_heredoc(chars: &[char], start: usize, line: &mut usize, out: &mut Vec<Spanned>) -> usize {
+    let mut i = start + 2;
+    if chars.get(i) == Some(&'-') {
+        i += 1;
+    }
+    let marker_start = i;
+    while i < chars.len() && is_ident_char(chars[i]) {
+        i += 1;
+    }
+    if i == marker_start {
+        out.push(Spanned {
+            kind: Token::Other,
+            line: *line,
+        });
+        return start + 2;
+    }
+    let marker: String = chars[marker_start..i].iter().collect();
+    let start_line = *line;
+    let mut body = String::new();
+    let mut inner = Vec::new();
+    i = skip_to_newline(chars, i);
+
+    while i < chars.len() {
+        // `i` sits on the newline ending the previous heredoc line.
+        *line += 1;
+        i += 1;
+        let line_end = skip_to_newline(chars, i);
+        let text: String = chars[i..line_end].iter().collect();
+        if text.trim() == marker {
+            i = line_end;
+            break;
+        }
+        let mut j = i;
+        while j < line_end {
+            if chars[j] == '$' && chars.get(j + 1) == Some(&'{') {
+                j = lex_interpolation(chars, j + 2, *line, &mut inner);
+            } else {
+                j += 1;
+            }
+        }
+        body.push_str(&text);
+        body.push('\n');
+        i = line_end;
+    }
+
+    out.push(Spanned {
+        kind: Token::Str(body),
+        line: start_line,
+    });
+    out.extend(inner);
+    i
+}
+
+/// Index of the next newline at or after `i` (or the end of input).
+fn skip_to_newline(chars: &[char], i: usize) -> usize {
+    chars[i..]
+        .iter()
+        .position(|&c| c == '\n')
+        .map_or(chars.len(), |offset| i + offset)
+}
+
+/// Characters allowed in HCL identifiers (plus digits for bare numbers).
+fn is_ident_char(c: char) -> bool {
+    c.is_alphanumeric() || c == '_' || c == '-'
+}
+
+/// `depends_on` graph of the Terraform blocks under a set of roots.
+#[derive(Debug, Clone, Default, PartialEq, Eq, Serialize, Deserialize)]
+pub struct TerraformGraph {
+    /// Declared blocks by address, with the file declaring each one
+    pub blocks: BTreeMap<String, PathBuf>,
+    /// Edges from a block to the declared blocks it references
+    pub depends_on: BTreeMap<String, BTreeSet<String>>,
+}
+
+/// Construction and export methods for [`TerraformGraph`].
+impl TerraformGraph {
+    /// Parse every `.tf` file under `roots` and join their references.
+    ///
+    /// References to blocks that are not declared under the roots (such as
+    /// resources in remote modules) are dropped.
+    pub fn from_paths(roots: &[PathBuf]) -> Result<Self> {
+        let parser = TerraformParser::default();
+        let mut analyses = Vec::new();
+        for root in roots {
+            let mut builder = WalkBuilder::new(root);
+            builder.add_custom_ignore_filename(IGNORE_FILE_NAME);
+            for entry in builder.build().filter_map(|entry| entry.ok()) {
+                let path = entry.path();
+                if !path.is_file() || path.extension().map_or(true, |ext| ext != "tf") {
+                    continue;
+                }
+                let source = std::fs::read(path).map_err(|e| {
+                    ValknutError::io(format!("Failed to read {}", path.display()), e)
+                })?;
+                match parser.parse(path, &source) {
+                    Ok(analysis) => analyses.push((path.to_path_buf(), analysis)),
+                    Err(err) => warn!("Skipping {}: {}", path.display(), err),
+                }
+            }
+        }
+        Ok(Self::from_analyses(&analyses))
+    }
+
+    /// Build the graph from already parsed files.
+    pub fn from_analyses(analyses: &[(PathBuf, FileAnalysis)]) -> Self {
+        let mut graph = Self::default();
+        for (path, analysis) in analyses {
+            for entity in &analysis.entities {
+                graph.blocks.insert(entity.name.clone(), path.clone());
+            }
+        }
+        for (_, analysis) in analyses {
+            for entity in &analysis.entities {
+                let targets: BTreeSet<String> = entity
+                    .depends_on
+                    .iter()
+                    .filter(|target| graph.blocks.contains_key(*target))
+                    .cloned()
+                    .collect();
+                if !targets.is_empty() {
+                    graph
+                        .depends_on
+                        .entry(entity.name.clone())
+                        .or_default()
+                        .extend(targets);
+                }
+            }
+        }
+        graph
+    }
+
+    /// Whether no Terraform blocks were found.
+    pub fn is_empty(&self) -> bool {
+        self.blocks.is_empty()
+    }
+
+    /// Total number of `depends_on` edges.
+    pub fn edge_count(&self) -> usize {
+        self.depends_on.values().map(BTreeSet::len).sum()
+    }
+
+    /// Render the graph in Graphviz DOT format.
+    pub fn to_dot(&self) -> String {
+        let mut dot = String::from("digraph terraform {\n    rankdir=LR;\n");
+        for address in self.blocks.keys() {
+            dot.push_str(&format!("    \"{address}\";\n"));
+        }
+        for (from, targets) in &self.depends_on {
+            for to in targets {
+                dot.push_str(&format!("    \"{from}\" -> \"{to}\";\n"));
+            }
+        }
+        dot.push_str("}\n");
+        dot
+    }
+
+    /// Render the graph as pretty-printed JSON.
+    pub fn to_json(&self) -> Result<String> {
+        serde_json::to_string_pretty(self).map_err(|e| {
+            ValknutError::internal(format!("Failed to serialize Terraform graph: {e}"))
+        })
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    const MAIN_TF: &str = r#"
+terraform {
+  required_version = ">= 1.5"
+}
+
+variable "region" {
+  type    = string
+  default = "eu-west-1"
+}
+
+# The AMI every instance boots from
+data "aws_ami" "ubuntu" {
+  most_recent = true
+  filter {
+    name   = "name"
+    values = ["ubuntu/*"]
+  }
+}
+
+resource "aws_instance" "web" {
+  ami           = data.aws_ami.ubuntu.id
+  instance_type = var.size == "large" ? "m5.large" : "t3.micro"
+  subnet_id     = module.vpc.private_subnets[0]
+  tags = {
+    Name = "web-${var.region}"
+  }
+  user_data = <<-EOT
+    #!/bin/bash
+    echo ${aws_s3_bucket.assets.bucket}
+  EOT
+  depends_on = [aws_security_group.web]
+}
+
+module "vpc" {
+  source = "terraform-aws-modules/vpc/aws"
+  cidr   = "10.0.0.0/16"
+}
+
+output "web_ip" {
+  value = aws_instance.web.public_ip
+}
+"#;
+
+    fn entity<'a>(analysis: &'a FileAnalysis, name: &str) -> &'a PluginEntity {
+        analysis
+            .entities
+            .iter()
+            .find(|entity| entity.name == name)
+            .unwrap_or_else(|| panic!("missing {name}"))
+    }
+
+    #[test]
+    fn extracts_blocks_with_attributes() {
+        let analysis = parse_terraform(MAIN_TF);
+        assert_eq!(analysis.language.as_deref(), Some("terraform"));
+        let names: Vec<_> = analysis.entities.iter().map(|e| e.name.as_str()).collect();
+        assert_eq!(
+            names,
+            vec![
+                "var.region",
+                "data.aws_ami.ubuntu",
+                "aws_instance.web",
+                "module.vpc",
+                "output.web_ip"
+            ]
+        );
+
+        let web = entity(&analysis, "aws_instance.web");
+        assert_eq!(web.block_type.as_deref(), Some("resource"));
+        assert_eq!(web.kind, EntityKind::Struct);
+        assert_eq!((web.start_line, web.end_line), (20, 32));
+        assert_eq!(
+            web.attributes,
+            vec![
+                "ami",
+                "depends_on",
+                "instance_type",
+                "subnet_id",
+                "tags",
+                "user_data"
+            ]
+        );
+
+        let ami = entity(&analysis, "data.aws_ami.ubuntu");
+        assert_eq!(ami.attributes, vec!["most_recent"]);
+        assert_eq!(entity(&analysis, "var.region").kind, EntityKind::Variable);
+    }
+
+    #[test]
+    fn records_references_from_expressions_strings_and_heredocs() {
+        let analysis = parse_terraform(MAIN_TF);
+        assert_eq!(
+            entity(&analysis, "aws_instance.web").depends_on,
+            vec![
+                "aws_s3_bucket.assets",
+                "aws_security_group.web",
+                "data.aws_ami.ubuntu",
+                "module.vpc",
+                "var.region",
+                "var.size"
+            ]
+        );
+        assert_eq!(
+            entity(&analysis, "output.web_ip").depends_on,
+            vec!["aws_instance.web"]
+        );
+        assert!(entity(&analysis, "module.vpc").depends_on.is_empty());
+    }
+
+    #[test]
+    fn graph_keeps_edges_between_declared_blocks() {
+        let graph =
+            TerraformGraph::from_analyses(&[(PathBuf::from("main.tf"), parse_terraform(MAIN_TF))]);
+        assert_eq!(graph.blocks.len(), 5);
+        assert_eq!(
+            graph.depends_on["aws_instance.web"],
+            BTreeSet::from([
+                "data.aws_ami.ubuntu".to_string(),
+                "module.vpc".to_string(),
+                "var.region".to_string()
+            ])
+        );
+        assert_eq!(graph.edge_count(), 4);
+        assert!(graph
+            .to_dot()
+            .contains("\"output.web_ip\" -> \"aws_instance.web\";"));
+    }
+
+    #[test]
+    fn ignores_builtin_traversals_and_comments() {
+        let analysis = parse_terraform(
+            "resource \"aws_eip\" \"ip\" {\n  count = length(var.names) // aws_fake.one\n  name  = each.key\n  path  = path.module\n  /* aws_fake.two */\n}\n",
+        );
+        assert_eq!(analysis.entities[0].depends_on, vec!["var.names"]);
+        assert_eq!(
+            analysis.entities[0].attributes,
+            vec!["count", "name", "path"]
+        );
+    }
+}
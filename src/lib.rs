@@ -72,32 +72,66 @@
 #![cfg_attr(test, allow(clippy::unwrap_used))]
 #![cfg_attr(test, allow(clippy::expect_used))]
 
-// Memory allocator selection (mutually exclusive)
-#[cfg(all(feature = "mimalloc", not(feature = "jemalloc")))]
+// Memory allocator selection (mutually exclusive); heap profiling needs jemalloc's profiler
+#[cfg(all(
+    feature = "mimalloc",
+    not(feature = "jemalloc"),
+    not(all(feature = "profiling", target_os = "linux"))
+))]
 #[global_allocator]
 static ALLOC: mimalloc::MiMalloc = mimalloc::MiMalloc;
 
-#[cfg(all(feature = "jemalloc", not(feature = "mimalloc")))]
+#[cfg(all(
+    feature = "jemalloc",
+    not(feature = "mimalloc"),
+    not(all(feature = "profiling", target_os = "linux"))
+))]
 #[global_allocator]
 static ALLOC: jemallocator::Jemalloc = jemallocator::Jemalloc;
 
+#[cfg(all(feature = "profiling", target_os = "linux"))]
+#[global_allocator]
+static ALLOC: tikv_jemallocator::Jemalloc = tikv_jemallocator::Jemalloc;
+
+/// Compile in jemalloc heap sampling (every 512 KiB) but leave it off until
+/// a heap profile is requested.
+#[cfg(all(feature = "profiling", target_os = "linux"))]
+#[allow(non_upper_case_globals)]
+#[export_name = "malloc_conf"]
+pub static malloc_conf: &[u8] = b"prof:true,prof_active:false,lg_prof_sample:19\0";
+
 // Core analysis engine modules
 pub mod core {
     //! Core analysis algorithms and data structures.
 
     pub mod arena_analysis;
     pub mod ast;
+    pub mod blame;
+    pub mod changed_symbols;
+    pub mod concurrency;
     pub mod config;
     pub mod coverage_discovery;
     pub mod dependency;
     pub mod errors;
     pub mod featureset;
     pub mod file_utils;
+    pub mod http_routes;
     pub mod interned_entities;
     pub mod interning;
     pub mod partitioning;
     pub mod pipeline;
+    pub mod profiling;
+    pub mod project_health;
+    pub mod public_api;
+    pub mod redaction;
+    pub mod repo_compare;
+    pub mod review;
     pub mod scoring;
+    pub mod snapshot_diff;
+    pub mod symbol_filter;
+    pub mod symbol_search;
+    pub mod token_budget;
+    pub mod xref;
 
     // Re-export AST types at original paths for backward compatibility
     pub use ast::service as ast_service;
@@ -113,12 +147,18 @@ pub mod detectors {
     //! Specialized code analysis detectors.
 
     pub mod bundled;
+    pub mod code_smells;
     pub mod cohesion;
     pub mod complexity;
+    pub mod constructor_usage;
     pub mod coverage;
+    pub mod duplicates;
+    pub mod generated;
     pub mod graph;
+    pub mod lock_contention;
     pub mod lsh;
     pub mod refactoring;
+    pub mod rules;
     pub mod structure;
 }
 
@@ -130,8 +170,13 @@ pub mod lang;
 pub mod io {
     //! I/O operations, caching, and report generation.
 
+    pub mod archive;
     pub mod cache;
+    pub mod remote;
     pub mod reports;
+    pub mod snapshot_archive;
+    pub mod stdin;
+    pub mod watch;
 }
 
 // AI refactoring oracle
@@ -153,6 +198,7 @@ pub mod api {
 pub use crate::core::pipeline::AnalysisResults;
 pub use api::config_types::AnalysisConfig;
 pub use api::engine::ValknutEngine;
+pub use core::concurrency::WorkerPool;
 pub use core::errors::{Result, ValknutError, ValknutResultExt};
 
 #[cfg(test)]
@@ -33,7 +33,7 @@ pub const SKIP_DIRS: &[&str] = &[
 
 /// Source file extensions to include in codebase bundles
 pub const SOURCE_EXTENSIONS: &[&str] = &[
-    "rs", "py", "js", "ts", "tsx", "jsx", "go", "java", "cpp", "c", "h", "hpp", "cs", "php",
+    "rs", "py", "js", "ts", "tsx", "jsx", "go", "java", "cpp", "c", "h", "hpp", "cs", "kt", "php",
 ];
 
 /// Bundle builder for creating codebase bundles for AI analysis.
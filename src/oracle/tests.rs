@@ -65,6 +65,7 @@ fn sample_candidate(
         issue_count: 1,
         suggestion_count: 1,
         coverage_percentage: None,
+        context: Default::default(),
     }
 }
 
@@ -89,6 +90,8 @@ fn analysis_results_fixture(project_root: &Path) -> AnalysisResults {
         critical_issues: 1,
         doc_health_score: 1.0,
         doc_issue_count: 0,
+        complexity: Default::default(),
+        timed_out: false,
     };
 
     let mut code_dictionary = CodeDictionary::default();
@@ -181,11 +184,22 @@ fn analysis_results_fixture(project_root: &Path) -> AnalysisResults {
             doc_health_score: 100.0,
         }),
         code_dictionary,
+        rule_findings: Vec::new(),
+        changed_files_only: false,
+        dependency_report: Vec::new(),
+        interface_report: None,
+        project_health: None,
+        context_statistics: Default::default(),
         documentation: None,
         directory_health: HashMap::new(),
         file_health: HashMap::new(),
         entity_health: HashMap::new(),
         directory_health_tree: None,
+        skipped_files: Vec::new(),
+        generated_code: Default::default(),
+        notebook_metadata: Vec::new(),
+        duplicates: Default::default(),
+        file_complexity: Default::default(),
     }
 }
 
@@ -446,6 +460,8 @@ fn test_condense_analysis_results() {
             critical_issues: 1,
             doc_health_score: 1.0,
             doc_issue_count: 0,
+            complexity: Default::default(),
+            timed_out: false,
         },
         normalized: None,
         passes: StageResultsBundle::disabled(),
@@ -468,11 +484,22 @@ fn test_condense_analysis_results() {
         warnings: vec![],
         health_metrics: None,
         code_dictionary: CodeDictionary::default(),
+        rule_findings: Vec::new(),
+        changed_files_only: false,
+        dependency_report: Vec::new(),
+        interface_report: None,
+        project_health: None,
+        context_statistics: Default::default(),
         documentation: None,
         directory_health: HashMap::new(),
         file_health: HashMap::new(),
         entity_health: HashMap::new(),
         directory_health_tree: None,
+        skipped_files: Vec::new(),
+        generated_code: Default::default(),
+        notebook_metadata: Vec::new(),
+        duplicates: Default::default(),
+        file_complexity: Default::default(),
     };
 
     let condensed = oracle.condense_analysis_results(&results);
@@ -233,3 +233,45 @@ async fn full_pipeline_smoke_test_covers_key_modules() -> Result<()> {
 
     Ok(())
 }
+
+/// Zero out fields that measure the run rather than the code.
+fn strip_timings(results: &mut valknut_rs::api::results::AnalysisResults) {
+    results.statistics.total_duration = Duration::ZERO;
+    results.statistics.avg_file_processing_time = Duration::ZERO;
+    results.statistics.avg_entity_processing_time = Duration::ZERO;
+    results.statistics.memory_stats.peak_memory_bytes = 0;
+    results.statistics.memory_stats.final_memory_bytes = 0;
+    results.statistics.memory_stats.efficiency_score = 0.0;
+    if let Some(clones) = results.clone_analysis.as_mut() {
+        clones.performance_metrics = None;
+    }
+}
+
+#[tokio::test]
+async fn repeated_analysis_serializes_identically() -> Result<()> {
+    let project = create_sample_project()?;
+
+    let mut outputs = Vec::new();
+    for _ in 0..2 {
+        let config = AnalysisConfig::new().languages(|mut languages| {
+            languages.enabled = vec![
+                "python".to_string(),
+                "rust".to_string(),
+                "typescript".to_string(),
+            ];
+            languages
+        });
+        let mut engine = ValknutEngine::new(config).await?;
+        let mut results = engine.analyze_directory(project.path()).await?;
+        strip_timings(&mut results);
+        outputs.push(serde_json::to_string_pretty(&results)?);
+    }
+
+    assert!(outputs[0].contains("refactoring_candidates"));
+    assert_eq!(
+        outputs[0], outputs[1],
+        "analysis output should be byte-identical"
+    );
+
+    Ok(())
+}
@@ -15,6 +15,7 @@ fn candidate(path: &str, severity: f64, priority: Priority) -> RefactoringCandid
         file_path: path.to_string(),
         line_range: Some((10, 40)),
         coverage_percentage: None,
+        context: Default::default(),
         priority,
         score: severity * 20.0,
         confidence: 0.8,